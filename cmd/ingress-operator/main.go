@@ -6,10 +6,12 @@ import (
 	"github.com/spf13/cobra"
 
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	echotestserver "github.com/openshift/cluster-ingress-operator/test/echo"
 	grpctestserver "github.com/openshift/cluster-ingress-operator/test/grpc"
 	h2specclient "github.com/openshift/cluster-ingress-operator/test/h2spec"
 	httphealthcheck "github.com/openshift/cluster-ingress-operator/test/http"
 	http2testserver "github.com/openshift/cluster-ingress-operator/test/http2"
+	websockettestserver "github.com/openshift/cluster-ingress-operator/test/websocket"
 )
 
 var log = logf.Logger.WithName("main")
@@ -19,6 +21,7 @@ func main() {
 	rootCmd.AddCommand(NewStartCommand())
 	rootCmd.AddCommand(NewRenderCommand())
 	rootCmd.AddCommand(httphealthcheck.NewServeHealthCheckCommand())
+	rootCmd.AddCommand(httphealthcheck.NewServeCanaryEdgeProbeCommand())
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "serve-grpc-test-server",
 		Short: "serve gRPC interoperability test server",
@@ -35,6 +38,22 @@ func main() {
 			http2testserver.Serve()
 		},
 	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "serve-echo-test-server",
+		Short: "serve HTTP echo test server",
+		Long:  "serve-echo-test-server runs an HTTP echo test server that describes each request that it receives.",
+		Run: func(cmd *cobra.Command, args []string) {
+			echotestserver.Serve()
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "serve-websocket-test-server",
+		Short: "serve WebSocket echo test server",
+		Long:  "serve-websocket-test-server runs a WebSocket echo test server.",
+		Run: func(cmd *cobra.Command, args []string) {
+			websockettestserver.Serve()
+		},
+	})
 	rootCmd.AddCommand(h2specclient.NewClientCommand())
 	rootCmd.AddCommand(httphealthcheck.NewServeDelayConnectCommand())
 
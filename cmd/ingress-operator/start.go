@@ -5,7 +5,9 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/fsnotify.v1"
@@ -15,9 +17,13 @@ import (
 	operatorconfig "github.com/openshift/cluster-ingress-operator/pkg/operator/config"
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	canarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/canary"
+	dnscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/dns"
+	externalresolutionprobecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/external-resolution-probe"
 	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+	orphancleanupcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/orphan-cleanup"
 	routemetricscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/route-metrics"
 	statuscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/status"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -51,6 +57,24 @@ type StartOptions struct {
 	CanaryImage string
 	// ReleaseVersion is the cluster version which the operator will converge to.
 	ReleaseVersion string
+	// DNSCleanupMaxAttempts is the number of failed attempts to delete a
+	// DNS record from the DNS provider after which the operator may give
+	// up and report the record as orphaned.
+	DNSCleanupMaxAttempts int
+	// DNSCleanupTimeout is how long the operator retries deleting a DNS
+	// record from the DNS provider before it may give up.
+	DNSCleanupTimeout time.Duration
+	// OrphanCleanupGracePeriod is how long an operand resource must remain
+	// orphaned before the operator deletes it.
+	OrphanCleanupGracePeriod time.Duration
+	// OrphanCleanupDryRun specifies that the operator only reports
+	// orphaned operand resources and does not delete them.  It is true by
+	// default.
+	OrphanCleanupDryRun bool
+	// DomainDelegationCheckResolvers are the addresses of the public
+	// resolvers with which the operator checks whether the public DNS zone
+	// is delegated to its name servers.
+	DomainDelegationCheckResolvers []string
 }
 
 func NewStartCommand() *cobra.Command {
@@ -74,6 +98,11 @@ func NewStartCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&options.ReleaseVersion, "release-version", "", statuscontroller.UnknownVersionValue, "the release version the operator should converge to (required)")
 	cmd.Flags().StringVarP(&options.MetricsListenAddr, "metrics-listen-addr", "", "127.0.0.1:60000", "metrics endpoint listen address (required)")
 	cmd.Flags().StringVarP(&options.ShutdownFile, "shutdown-file", "s", defaultTrustedCABundle, "if provided, shut down the operator when this file changes")
+	cmd.Flags().IntVarP(&options.DNSCleanupMaxAttempts, "dns-cleanup-max-attempts", "", 10, "number of failed attempts to delete a DNS record after which the operator gives up, if the dns-cleanup-timeout has also elapsed; 0 retries indefinitely")
+	cmd.Flags().DurationVarP(&options.DNSCleanupTimeout, "dns-cleanup-timeout", "", 1*time.Hour, "how long the operator retries deleting a DNS record before it gives up")
+	cmd.Flags().DurationVarP(&options.OrphanCleanupGracePeriod, "orphan-cleanup-grace-period", "", 1*time.Hour, "how long an operand resource labeled as owned by an ingresscontroller that does not exist must remain orphaned before the operator deletes it")
+	cmd.Flags().BoolVarP(&options.OrphanCleanupDryRun, "orphan-cleanup-dry-run", "", true, "report orphaned operand resources without deleting them; set to false to let the operator delete them")
+	cmd.Flags().StringSliceVarP(&options.DomainDelegationCheckResolvers, "domain-delegation-check-resolvers", "", []string{}, "IP addresses, optionally with ports, of the public resolvers with which the operator checks whether the public DNS zone is delegated to its name servers, for example 1.1.1.1,8.8.8.8; the check is disabled unless resolvers are specified")
 
 	if err := cmd.MarkFlagRequired("namespace"); err != nil {
 		panic(err)
@@ -98,6 +127,15 @@ func start(opts *StartOptions) error {
 		log.Info("Warning: no release version is specified", "release version", statuscontroller.UnknownVersionValue)
 	}
 
+	for _, resolver := range opts.DomainDelegationCheckResolvers {
+		if net.ParseIP(resolver) != nil {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(resolver); err != nil || net.ParseIP(host) == nil {
+			return fmt.Errorf("invalid domain delegation check resolver %q; must be an IP address, optionally with a port", resolver)
+		}
+	}
+
 	// verify that all idled services have the correct idle annotations
 	// mirrored over from the corresponding endpoints resources.
 	// This is to ensure that applications idled with an older version of oc
@@ -118,22 +156,43 @@ func start(opts *StartOptions) error {
 	defer cancel()
 
 	operatorConfig := operatorconfig.Config{
-		OperatorReleaseVersion: opts.ReleaseVersion,
-		Namespace:              opts.OperatorNamespace,
-		IngressControllerImage: opts.IngressControllerImage,
-		CanaryImage:            opts.CanaryImage,
+		OperatorReleaseVersion:         opts.ReleaseVersion,
+		Namespace:                      opts.OperatorNamespace,
+		IngressControllerImage:         opts.IngressControllerImage,
+		CanaryImage:                    opts.CanaryImage,
+		DNSCleanupMaxAttempts:          opts.DNSCleanupMaxAttempts,
+		DNSCleanupTimeout:              opts.DNSCleanupTimeout,
+		OrphanCleanupGracePeriod:       opts.OrphanCleanupGracePeriod,
+		OrphanCleanupDryRun:            opts.OrphanCleanupDryRun,
+		DomainDelegationCheckResolvers: opts.DomainDelegationCheckResolvers,
 	}
 
 	// Start operator metrics.
 	go operator.StartMetricsListener(opts.MetricsListenAddr, signal)
+	log.Info("registering Prometheus metrics for cache freshness")
+	if err := cachefreshness.RegisterMetrics(); err != nil {
+		log.Error(err, "unable to register metrics for cache freshness")
+	}
 	log.Info("registering Prometheus metrics for canary_controller")
 	if err := canarycontroller.RegisterMetrics(); err != nil {
 		log.Error(err, "unable to register metrics for canary_controller")
 	}
+	log.Info("registering Prometheus metrics for dns_controller")
+	if err := dnscontroller.RegisterMetrics(); err != nil {
+		log.Error(err, "unable to register metrics for dns_controller")
+	}
+	log.Info("registering Prometheus metrics for external_resolution_probe_controller")
+	if err := externalresolutionprobecontroller.RegisterMetrics(); err != nil {
+		log.Error(err, "unable to register metrics for external_resolution_probe_controller")
+	}
 	log.Info("registering Prometheus metrics for ingress_controller")
 	if err := ingresscontroller.RegisterMetrics(); err != nil {
 		log.Error(err, "unable to register metrics for ingress_controller")
 	}
+	log.Info("registering Prometheus metrics for orphan_cleanup_controller")
+	if err := orphancleanupcontroller.RegisterMetrics(); err != nil {
+		log.Error(err, "unable to register metrics for orphan_cleanup_controller")
+	}
 	log.Info("registering Prometheus metrics for route_metrics_controller")
 	if err := routemetricscontroller.RegisterMetrics(); err != nil {
 		log.Error(err, "unable to register metrics for route_metrics_controller")
@@ -11,6 +11,7 @@ import (
 	iov1 "github.com/openshift/api/operatoringress/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
@@ -55,15 +56,18 @@ const (
 )
 
 var (
-	_   dns.Provider = &Provider{}
-	log              = logf.Logger.WithName("dns")
+	_   dns.Provider               = &Provider{}
+	_   dns.ZoneDelegationDetector = &Provider{}
+	_   dns.ZoneNameServerLister   = &Provider{}
+	log                            = logf.Logger.WithName("dns")
 
 	hostedZoneIDRegex = regexp.MustCompile("^/?hostedzone/([^/]+)$")
 )
 
 // Provider is a dns.Provider for AWS Route53. It only supports DNSRecords of
 // type CNAME, and the CNAME records are implemented as A records using the
-// Route53 Alias feature.
+// Route53 Alias feature.  If a DNSRecord has the dnsrecord.DNSDualStackAnnotation
+// annotation, AAAA alias records are published alongside the A alias records.
 //
 // TODO: Records are considered owned by the manager if they exist in a managed
 // zone and if their names match expectations. This is relatively dangerous
@@ -506,10 +510,70 @@ func (m *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
 	return m.change(record, zone, upsertAction)
 }
 
-// change will perform an action on a record. The target must correspond to the
-// hostname of an ELB which will be automatically discovered.
+// DelegatedSubzone implements dns.ZoneDelegationDetector.  It looks up the
+// hosted zone's name and then looks for an NS record set at each name between
+// the zone's apex and the given DNS name.
+func (m *Provider) DelegatedSubzone(dnsName string, zone configv1.DNSZone) (string, error) {
+	zoneID, err := m.getZoneID(zone)
+	if err != nil {
+		return "", fmt.Errorf("failed to find hosted zone for zone %v: %w", zone, err)
+	}
+	output, err := m.route53.GetHostedZone(&route53.GetHostedZoneInput{Id: aws.String(zoneID)})
+	if err != nil {
+		return "", fmt.Errorf("failed to get hosted zone %s: %w", zoneID, err)
+	}
+	for _, name := range dns.DelegationCandidates(dnsName, aws.StringValue(output.HostedZone.Name)) {
+		input := route53.ListResourceRecordSetsInput{
+			HostedZoneId:    aws.String(zoneID),
+			StartRecordName: aws.String(name),
+			StartRecordType: aws.String(route53.RRTypeNs),
+			MaxItems:        aws.String("1"),
+		}
+		resp, err := m.route53.ListResourceRecordSets(&input)
+		if err != nil {
+			return "", fmt.Errorf("failed to list record sets for %s in hosted zone %s: %w", name, zoneID, err)
+		}
+		for _, recordSet := range resp.ResourceRecordSets {
+			if aws.StringValue(recordSet.Type) == route53.RRTypeNs && strings.EqualFold(aws.StringValue(recordSet.Name), name) {
+				return name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// ZoneNameServers implements dns.ZoneNameServerLister.  It returns the hosted
+// zone's name and the name servers of its delegation set.  Private hosted zones
+// have no delegation set.
+func (m *Provider) ZoneNameServers(zone configv1.DNSZone) (string, []string, error) {
+	zoneID, err := m.getZoneID(zone)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find hosted zone for zone %v: %w", zone, err)
+	}
+	output, err := m.route53.GetHostedZone(&route53.GetHostedZoneInput{Id: aws.String(zoneID)})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get hosted zone %s: %w", zoneID, err)
+	}
+	var nameServers []string
+	if output.DelegationSet != nil {
+		nameServers = aws.StringValueSlice(output.DelegationSet.NameServers)
+	}
+	return aws.StringValue(output.HostedZone.Name), nameServers, nil
+}
+
+// change will perform an action on a record. For a CNAME record, the target
+// must correspond to the hostname of an ELB which will be automatically
+// discovered.  An A record's targets must be IPv4 addresses, such as the
+// addresses of a private VIP.
 func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action action) error {
-	if record.Spec.RecordType != iov1.CNAMERecordType {
+	switch record.Spec.RecordType {
+	case iov1.CNAMERecordType:
+		if record.Annotations[dnsrecord.DNSZoneTargetRecordAnnotation] == "true" {
+			return m.changeRegularRecord(record, zone, action, route53.RRTypeCname)
+		}
+	case iov1.ARecordType:
+		return m.changeRegularRecord(record, zone, action, route53.RRTypeA)
+	default:
 		return fmt.Errorf("unsupported record type %s", record.Spec.RecordType)
 	}
 	// TODO: handle >0 targets
@@ -566,10 +630,13 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 	}
 
 	// Configure records.
-	err = m.updateRecord(domain, zoneID, target, targetHostedZoneID, string(action), record.Spec.RecordTTL)
+	err = m.updateRecord(domain, zoneID, target, targetHostedZoneID, string(action), route53.RRTypeA, record.Spec.RecordTTL)
 	if err != nil {
 		return fmt.Errorf("failed to update alias in zone %s: %v", zoneID, err)
 	}
+	if err := m.changeAAAAAlias(record, domain, zoneID, target, targetHostedZoneID, action); err != nil {
+		return fmt.Errorf("failed to update AAAA alias in zone %s: %v", zoneID, err)
+	}
 	switch action {
 	case upsertAction:
 		log.Info("upserted DNS record", "record", record.Spec, "zone", zone)
@@ -579,16 +646,100 @@ func (m *Provider) change(record *iov1.DNSRecord, zone configv1.DNSZone, action
 	return nil
 }
 
+// changeRegularRecord performs an action on a record of the given type whose
+// targets are IP addresses or, for a CNAME record, a hostname that need not be
+// an ELB.  Unlike the alias records for ELB targets, such a record is a regular
+// record with the record's TTL.
+func (m *Provider) changeRegularRecord(record *iov1.DNSRecord, zone configv1.DNSZone, action action, rrType string) error {
+	domain := record.Spec.DNSName
+	if len(domain) == 0 {
+		return fmt.Errorf("domain is required")
+	}
+	if len(record.Spec.Targets) == 0 {
+		return fmt.Errorf("target is required")
+	}
+	zoneID, err := m.getZoneID(zone)
+	if err != nil {
+		return fmt.Errorf("failed to find hosted zone for record: %v", err)
+	}
+	input := route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch:  newRecordChangeBatch(domain, rrType, record.Spec.Targets, string(action), record.Spec.RecordTTL),
+	}
+	resp, err := m.route53.ChangeResourceRecordSets(&input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && action == deleteAction && strings.Contains(aerr.Message(), "not found") {
+			log.Info("record not found", "zone id", zoneID, "domain", domain, "targets", record.Spec.Targets)
+			return nil
+		}
+		return fmt.Errorf("couldn't update DNS record in zone %s: %v", zoneID, err)
+	}
+	switch action {
+	case upsertAction:
+		log.Info("upserted DNS record", "record", record.Spec, "zone", zone, "response", resp)
+	case deleteAction:
+		log.Info("deleted DNS record", "record", record.Spec, "zone", zone, "response", resp)
+	}
+	return nil
+}
+
+// changeAAAAAlias publishes an AAAA alias record alongside the A alias record
+// for a DNSRecord that has the dnsrecord.DNSDualStackAnnotation annotation, and
+// deletes the AAAA alias record if the DNSRecord was previously published as
+// dual-stack, as indicated by the dnsrecord.DNSDualStackPublishedAnnotation
+// annotation, and no longer is or is being deleted.  In GovCloud, records are
+// CNAME records, which resolve to both IPv4 and IPv6 addresses, so no AAAA
+// record is needed.
+func (m *Provider) changeAAAAAlias(record *iov1.DNSRecord, domain, zoneID, target, targetHostedZoneID string, action action) error {
+	if clientEndpointIsGovCloud(&m.route53.Client.ClientInfo) {
+		return nil
+	}
+	dualStack := record.Annotations[dnsrecord.DNSDualStackAnnotation] == "true"
+	publishedDualStack := record.Annotations[dnsrecord.DNSDualStackPublishedAnnotation] == "true"
+	switch {
+	case action == upsertAction && dualStack:
+		return m.updateRecord(domain, zoneID, target, targetHostedZoneID, string(upsertAction), route53.RRTypeAaaa, record.Spec.RecordTTL)
+	case publishedDualStack, action == deleteAction && dualStack:
+		return m.updateRecord(domain, zoneID, target, targetHostedZoneID, string(deleteAction), route53.RRTypeAaaa, record.Spec.RecordTTL)
+	}
+	return nil
+}
+
 // updateRecord creates or updates a DNS record for domain in zoneID pointed at
-// target in targetHostedZoneID. An Alias record type is used for all regions
-// other than GovCloud (CNAME). See the following for additional details:
+// target in targetHostedZoneID. An Alias record of the given type (A or AAAA)
+// is used for all regions other than GovCloud (CNAME). See the following for
+// additional details:
 // https://docs.aws.amazon.com/govcloud-us/latest/UserGuide/govcloud-r53.html
 // Note that by API contract, TTL cannot be specified for an AliasTarget.
-func (m *Provider) updateRecord(domain, zoneID, target, targetHostedZoneID, action string, ttl int64) error {
-	input := route53.ChangeResourceRecordSetsInput{HostedZoneId: aws.String(zoneID)}
-	if clientEndpointIsGovCloud(&m.route53.Client.ClientInfo) {
+func (m *Provider) updateRecord(domain, zoneID, target, targetHostedZoneID, action, aliasType string, ttl int64) error {
+	input := route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch:  newChangeBatch(domain, target, targetHostedZoneID, action, aliasType, ttl, clientEndpointIsGovCloud(&m.route53.Client.ClientInfo)),
+	}
+	resp, err := m.route53.ChangeResourceRecordSets(&input)
+	if err != nil {
+		if action == string(deleteAction) {
+			if aerr, ok := err.(awserr.Error); ok {
+				if strings.Contains(aerr.Message(), "not found") {
+					log.Info("record not found", "zone id", zoneID, "domain", domain, "target", target)
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("couldn't update DNS record in zone %s: %v", zoneID, err)
+	}
+	log.Info("updated DNS record", "zone id", zoneID, "domain", domain, "target", target, "response", resp)
+	return nil
+}
+
+// newChangeBatch returns a change batch that performs the given action on a
+// DNS record for domain pointed at target in targetHostedZoneID.  In GovCloud,
+// the record is a CNAME record; otherwise, it is an alias record of the given
+// type.
+func newChangeBatch(domain, target, targetHostedZoneID, action, aliasType string, ttl int64, govCloud bool) *route53.ChangeBatch {
+	if govCloud {
 		record := route53.ResourceRecord{Value: aws.String(target)}
-		input.ChangeBatch = &route53.ChangeBatch{
+		return &route53.ChangeBatch{
 			Changes: []*route53.Change{
 				{
 					Action: aws.String(action),
@@ -601,38 +752,45 @@ func (m *Provider) updateRecord(domain, zoneID, target, targetHostedZoneID, acti
 				},
 			},
 		}
-	} else {
-		input.ChangeBatch = &route53.ChangeBatch{
-			Changes: []*route53.Change{
-				{
-					Action: aws.String(action),
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name: aws.String(domain),
-						Type: aws.String(route53.RRTypeA),
-						AliasTarget: &route53.AliasTarget{
-							HostedZoneId:         aws.String(targetHostedZoneID),
-							DNSName:              aws.String(target),
-							EvaluateTargetHealth: aws.Bool(false),
-						},
+	}
+	return &route53.ChangeBatch{
+		Changes: []*route53.Change{
+			{
+				Action: aws.String(action),
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name: aws.String(domain),
+					Type: aws.String(aliasType),
+					AliasTarget: &route53.AliasTarget{
+						HostedZoneId:         aws.String(targetHostedZoneID),
+						DNSName:              aws.String(target),
+						EvaluateTargetHealth: aws.Bool(false),
 					},
 				},
 			},
-		}
+		},
 	}
-	resp, err := m.route53.ChangeResourceRecordSets(&input)
-	if err != nil {
-		if action == string(deleteAction) {
-			if aerr, ok := err.(awserr.Error); ok {
-				if strings.Contains(aerr.Message(), "not found") {
-					log.Info("record not found", "zone id", zoneID, "domain", domain, "target", target)
-					return nil
-				}
-			}
-		}
-		return fmt.Errorf("couldn't update DNS record in zone %s: %v", zoneID, err)
+}
+
+// newRecordChangeBatch returns a change batch that performs the given action on
+// a regular record of the given type for domain with the given targets and TTL.
+func newRecordChangeBatch(domain, rrType string, targets []string, action string, ttl int64) *route53.ChangeBatch {
+	records := make([]*route53.ResourceRecord, len(targets))
+	for i := range targets {
+		records[i] = &route53.ResourceRecord{Value: aws.String(targets[i])}
+	}
+	return &route53.ChangeBatch{
+		Changes: []*route53.Change{
+			{
+				Action: aws.String(action),
+				ResourceRecordSet: &route53.ResourceRecordSet{
+					Name:            aws.String(domain),
+					Type:            aws.String(rrType),
+					TTL:             aws.Int64(ttl),
+					ResourceRecords: records,
+				},
+			},
+		},
 	}
-	log.Info("updated DNS record", "zone id", zoneID, "domain", domain, "target", target, "response", resp)
-	return nil
 }
 
 // clientEndpointIsGovCloud returns true if the provided client info
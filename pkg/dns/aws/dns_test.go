@@ -133,3 +133,98 @@ func Test_zoneIDFromResource(t *testing.T) {
 		})
 	}
 }
+
+// Test_newChangeBatch verifies that newChangeBatch uses a CNAME record in
+// GovCloud and an alias record of the requested type elsewhere.
+func Test_newChangeBatch(t *testing.T) {
+	testCases := []struct {
+		name        string
+		aliasType   string
+		govCloud    bool
+		expectType  string
+		expectAlias bool
+	}{
+		{
+			name:        "A alias",
+			aliasType:   route53.RRTypeA,
+			expectType:  route53.RRTypeA,
+			expectAlias: true,
+		},
+		{
+			name:        "AAAA alias",
+			aliasType:   route53.RRTypeAaaa,
+			expectType:  route53.RRTypeAaaa,
+			expectAlias: true,
+		},
+		{
+			name:        "GovCloud",
+			aliasType:   route53.RRTypeA,
+			govCloud:    true,
+			expectType:  route53.RRTypeCname,
+			expectAlias: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			batch := newChangeBatch("*.apps.example.com.", "lb.elb.amazonaws.com", "Z123", string(upsertAction), tc.aliasType, 30, tc.govCloud)
+			if !assert.Len(t, batch.Changes, 1) {
+				return
+			}
+			change := batch.Changes[0]
+			assert.Equal(t, string(upsertAction), aws.StringValue(change.Action))
+			assert.Equal(t, "*.apps.example.com.", aws.StringValue(change.ResourceRecordSet.Name))
+			assert.Equal(t, tc.expectType, aws.StringValue(change.ResourceRecordSet.Type))
+			if tc.expectAlias {
+				if assert.NotNil(t, change.ResourceRecordSet.AliasTarget) {
+					assert.Equal(t, "lb.elb.amazonaws.com", aws.StringValue(change.ResourceRecordSet.AliasTarget.DNSName))
+					assert.Equal(t, "Z123", aws.StringValue(change.ResourceRecordSet.AliasTarget.HostedZoneId))
+				}
+				assert.Nil(t, change.ResourceRecordSet.TTL)
+			} else {
+				assert.Nil(t, change.ResourceRecordSet.AliasTarget)
+				assert.Equal(t, int64(30), aws.Int64Value(change.ResourceRecordSet.TTL))
+			}
+		})
+	}
+}
+
+// Test_newRecordChangeBatch verifies that newRecordChangeBatch uses a regular
+// record of the given type with the record's TTL and a resource record for
+// each target.
+func Test_newRecordChangeBatch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rrType  string
+		targets []string
+	}{
+		{
+			name:    "A record for IP addresses",
+			rrType:  route53.RRTypeA,
+			targets: []string{"10.0.0.5", "10.0.0.6"},
+		},
+		{
+			name:    "CNAME record for a hostname that is not an ELB",
+			rrType:  route53.RRTypeCname,
+			targets: []string{"ingress.example.net"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			batch := newRecordChangeBatch("*.apps.example.com.", tc.rrType, tc.targets, string(deleteAction), 30)
+			if !assert.Len(t, batch.Changes, 1) {
+				return
+			}
+			change := batch.Changes[0]
+			assert.Equal(t, string(deleteAction), aws.StringValue(change.Action))
+			assert.Equal(t, "*.apps.example.com.", aws.StringValue(change.ResourceRecordSet.Name))
+			assert.Equal(t, tc.rrType, aws.StringValue(change.ResourceRecordSet.Type))
+			assert.Nil(t, change.ResourceRecordSet.AliasTarget)
+			assert.Equal(t, int64(30), aws.Int64Value(change.ResourceRecordSet.TTL))
+			var values []string
+			for _, record := range change.ResourceRecordSet.ResourceRecords {
+				values = append(values, aws.StringValue(record.Value))
+			}
+			assert.Equal(t, tc.targets, values)
+		})
+	}
+}
@@ -43,9 +43,14 @@ type ARecord struct {
 	// Name is the record name.
 	Name string
 
-	// Address is the IPv4 address of the A record.
+	// Address is the IPv4 address of the A record or, if IPv6 is true,
+	// the IPv6 address of the AAAA record.
 	Address string
 
+	// IPv6 indicates that the record is an AAAA record rather than an A
+	// record.
+	IPv6 bool
+
 	//TTL is the Time To Live property of the A record
 	TTL int64
 
@@ -113,29 +118,37 @@ func newRecordSetClient(config Config, userAgentExtension string) (*recordSetCli
 func (c *recordSetClient) Put(ctx context.Context, zone Zone, arec ARecord, metadata map[string]*string) error {
 	rs := dns.RecordSet{
 		RecordSetProperties: &dns.RecordSetProperties{
-			TTL: &arec.TTL,
-			ARecords: &[]dns.ARecord{
-				{Ipv4Address: &arec.Address},
-			},
+			TTL:      &arec.TTL,
 			Metadata: metadata,
 		},
 	}
-	_, err := c.client.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, rs, "", "")
+	recordType := dns.A
+	if arec.IPv6 {
+		recordType = dns.AAAA
+		rs.AaaaRecords = &[]dns.AaaaRecord{{Ipv6Address: &arec.Address}}
+	} else {
+		rs.ARecords = &[]dns.ARecord{{Ipv4Address: &arec.Address}}
+	}
+	_, err := c.client.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, arec.Name, recordType, rs, "", "")
 	if err != nil {
-		return errors.Wrapf(err, "failed to update dns a record: %s.%s", arec.Name, zone.Name)
+		return errors.Wrapf(err, "failed to update dns %s record: %s.%s", recordType, arec.Name, zone.Name)
 	}
 	return nil
 }
 
 func (c *recordSetClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
-	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
+	recordType := dns.A
+	if arec.IPv6 {
+		recordType = dns.AAAA
+	}
+	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, recordType)
 	if err != nil {
 		// TODO: How do we interpret this as a notfound error?
 		return nil
 	}
-	_, err = c.client.Delete(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, "")
+	_, err = c.client.Delete(ctx, zone.ResourceGroup, zone.Name, arec.Name, recordType, "")
 	if err != nil {
-		return errors.Wrapf(err, "failed to delete dns a record: %s.%s", arec.Name, zone.Name)
+		return errors.Wrapf(err, "failed to delete dns %s record: %s.%s", recordType, arec.Name, zone.Name)
 	}
 	return nil
 }
@@ -159,29 +172,37 @@ func newPrivateRecordSetClient(config Config, userAgentExtension string) (*priva
 func (c *privateRecordSetClient) Put(ctx context.Context, zone Zone, arec ARecord, metadata map[string]*string) error {
 	rs := privatedns.RecordSet{
 		RecordSetProperties: &privatedns.RecordSetProperties{
-			TTL: &arec.TTL,
-			ARecords: &[]privatedns.ARecord{
-				{Ipv4Address: &arec.Address},
-			},
+			TTL:      &arec.TTL,
 			Metadata: metadata,
 		},
 	}
-	_, err := c.client.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, privatedns.A, arec.Name, rs, "", "")
+	recordType := privatedns.A
+	if arec.IPv6 {
+		recordType = privatedns.AAAA
+		rs.AaaaRecords = &[]privatedns.AaaaRecord{{Ipv6Address: &arec.Address}}
+	} else {
+		rs.ARecords = &[]privatedns.ARecord{{Ipv4Address: &arec.Address}}
+	}
+	_, err := c.client.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, recordType, arec.Name, rs, "", "")
 	if err != nil {
-		return errors.Wrapf(err, "failed to update dns a record: %s.%s", arec.Name, zone.Name)
+		return errors.Wrapf(err, "failed to update dns %s record: %s.%s", recordType, arec.Name, zone.Name)
 	}
 	return nil
 }
 
 func (c *privateRecordSetClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
-	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, privatedns.A, arec.Name)
+	recordType := privatedns.A
+	if arec.IPv6 {
+		recordType = privatedns.AAAA
+	}
+	_, err := c.client.Get(ctx, zone.ResourceGroup, zone.Name, recordType, arec.Name)
 	if err != nil {
 		// TODO: How do we interpret this as a notfound error?
 		return nil
 	}
-	_, err = c.client.Delete(ctx, zone.ResourceGroup, zone.Name, privatedns.A, arec.Name, "")
+	_, err = c.client.Delete(ctx, zone.ResourceGroup, zone.Name, recordType, arec.Name, "")
 	if err != nil {
-		return errors.Wrapf(err, "failed to delete dns a record: %s.%s", arec.Name, zone.Name)
+		return errors.Wrapf(err, "failed to delete dns %s record: %s.%s", recordType, arec.Name, zone.Name)
 	}
 	return nil
 }
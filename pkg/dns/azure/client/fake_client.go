@@ -13,16 +13,30 @@ func NewFake(config Config) (*FakeDNSClient, error) {
 }
 
 func (c *FakeDNSClient) Put(ctx context.Context, zone Zone, arec ARecord, metadata map[string]*string) error {
-	c.fakeARM[zone.ResourceGroup+zone.Name+arec.Name] = "PUT"
+	c.fakeARM[fakeRecordKey(zone.ResourceGroup, zone.Name, arec.Name, arec.IPv6)] = "PUT"
 	return nil
 }
 
 func (c *FakeDNSClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
-	c.fakeARM[zone.ResourceGroup+zone.Name+arec.Name] = "DELETE"
+	c.fakeARM[fakeRecordKey(zone.ResourceGroup, zone.Name, arec.Name, arec.IPv6)] = "DELETE"
 	return nil
 }
 
 func (c *FakeDNSClient) RecordedCall(rg, zone, rel string) (string, bool) {
-	call, ok := c.fakeARM[rg+zone+rel]
+	call, ok := c.fakeARM[fakeRecordKey(rg, zone, rel, false)]
 	return call, ok
 }
+
+// RecordedAAAACall returns the last call for the AAAA record with the given
+// name, if any.
+func (c *FakeDNSClient) RecordedAAAACall(rg, zone, rel string) (string, bool) {
+	call, ok := c.fakeARM[fakeRecordKey(rg, zone, rel, true)]
+	return call, ok
+}
+
+func fakeRecordKey(rg, zone, rel string, ipv6 bool) string {
+	if ipv6 {
+		return rg + zone + rel + "/AAAA"
+	}
+	return rg + zone + rel
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns/azure/client"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 )
 
 const (
@@ -93,10 +94,19 @@ func userAgent(operatorReleaseVersion string) string {
 	return fmt.Sprintf("%s/%s", "openshift.io ingress-operator", operatorReleaseVersion)
 }
 
+// Ensure publishes an A record for the first IPv4 target of the given
+// DNSRecord and, if the DNSRecord has an IPv6 target, an AAAA record for the
+// first IPv6 target.  If the DNSRecord was previously published with an IPv6
+// target, as indicated by the dnsrecord.DNSDualStackPublishedAnnotation
+// annotation, and no longer has one, the AAAA record is deleted.
 func (m *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if record.Spec.RecordType != iov1.ARecordType {
 		return fmt.Errorf("only A record types are supported")
 	}
+	ipv4, ipv6 := dnsrecord.SplitTargetsByIPFamily(record.Spec.Targets)
+	if len(ipv4) == 0 && len(ipv6) == 0 {
+		return fmt.Errorf("target is required")
+	}
 
 	targetZone, err := client.ParseZone(zone.ID)
 	if err != nil {
@@ -108,25 +118,42 @@ func (m *provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	if err != nil {
 		return err
 	}
-	ARecord := client.ARecord{
-		Address: record.Spec.Targets[0],
-		Name:    ARecordName,
-		TTL:     record.Spec.RecordTTL,
-	}
-	if metadataLabel != "" {
-		ARecord.Label = fmt.Sprintf("kubernetes.io_cluster.%s", metadataLabel)
+	newRecord := func(address string, ipv6 bool) client.ARecord {
+		ARecord := client.ARecord{
+			Address: address,
+			Name:    ARecordName,
+			TTL:     record.Spec.RecordTTL,
+			IPv6:    ipv6,
+		}
+		if metadataLabel != "" {
+			ARecord.Label = fmt.Sprintf("kubernetes.io_cluster.%s", metadataLabel)
+		}
+		return ARecord
 	}
 
 	// TODO: handle >0 targets
-	err = m.client.Put(context.TODO(), *targetZone, ARecord, m.config.Tags)
-
-	if err == nil {
-		log.Info("upserted DNS record", "record", record.Spec, "zone", zone)
+	if len(ipv4) != 0 {
+		if err := m.client.Put(context.TODO(), *targetZone, newRecord(ipv4[0], false), m.config.Tags); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(ipv6) != 0:
+		if err := m.client.Put(context.TODO(), *targetZone, newRecord(ipv6[0], true), m.config.Tags); err != nil {
+			return err
+		}
+	case record.Annotations[dnsrecord.DNSDualStackPublishedAnnotation] == "true":
+		if err := m.client.Delete(context.TODO(), *targetZone, client.ARecord{Name: ARecordName, IPv6: true}); err != nil {
+			return err
+		}
 	}
 
-	return err
+	log.Info("upserted DNS record", "record", record.Spec, "zone", zone)
+	return nil
 }
 
+// Delete deletes the A record and, if the DNSRecord has an IPv6 target or was
+// previously published with one, the AAAA record for the given DNSRecord.
 func (m *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	targetZone, err := client.ParseZone(zone.ID)
 	if err != nil {
@@ -138,21 +165,29 @@ func (m *provider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 		return err
 	}
 
-	// TODO: handle >0 targets
-	err = m.client.Delete(
-		context.TODO(),
-		*targetZone,
-		client.ARecord{
-			Address: record.Spec.Targets[0],
-			Name:    ARecordName,
-			TTL:     record.Spec.RecordTTL,
-		})
-
-	if err == nil {
-		log.Info("deleted DNS record", "record", record.Spec, "zone", zone)
+	ipv4, ipv6 := dnsrecord.SplitTargetsByIPFamily(record.Spec.Targets)
+	if len(ipv4) != 0 {
+		// TODO: handle >0 targets
+		err = m.client.Delete(
+			context.TODO(),
+			*targetZone,
+			client.ARecord{
+				Address: ipv4[0],
+				Name:    ARecordName,
+				TTL:     record.Spec.RecordTTL,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if len(ipv6) != 0 || record.Annotations[dnsrecord.DNSDualStackPublishedAnnotation] == "true" {
+		if err := m.client.Delete(context.TODO(), *targetZone, client.ARecord{Name: ARecordName, IPv6: true}); err != nil {
+			return err
+		}
 	}
 
-	return err
+	log.Info("deleted DNS record", "record", record.Spec, "zone", zone)
+	return nil
 }
 
 func (m *provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
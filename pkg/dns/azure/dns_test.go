@@ -13,6 +13,9 @@ import (
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns/azure"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns/azure/client"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func fakeManager(fc *client.FakeDNSClient) (dns.Provider, error) {
@@ -58,6 +61,40 @@ func Test_Ensure(t *testing.T) {
 	}
 }
 
+// Test_EnsurePunycode verifies that an internationalized domain name in
+// punycode form is published using the punycode record name.
+func Test_EnsurePunycode(t *testing.T) {
+	c := client.Config{}
+	fc, _ := client.NewFake(c)
+	mgr, err := fakeManager(fc)
+	if err != nil {
+		t.Error("failed to steup the manager under test")
+	}
+	rg := "test-rg"
+	zone := "dnszone.io"
+	ARecordName := "xn--bcher-kva"
+	record := iov1.DNSRecord{
+		Spec: iov1.DNSRecordSpec{
+			DNSName:    "xn--bcher-kva.dnszone.io.",
+			RecordType: iov1.ARecordType,
+			Targets:    []string{"55.11.22.33"},
+			RecordTTL:  120,
+		},
+	}
+	dnsZone := configv1.DNSZone{
+		ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+	}
+	if err := mgr.Ensure(&record, dnsZone); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+
+	recordedCall, _ := fc.RecordedCall(rg, zone, ARecordName)
+
+	if recordedCall != "PUT" {
+		t.Fatalf("expected the dns client 'Put' func to be called for record %q, but found %q instead", ARecordName, recordedCall)
+	}
+}
+
 func Test_Delete(t *testing.T) {
 	c := client.Config{}
 	fc, err := client.NewFake(c)
@@ -99,6 +136,69 @@ func Test_Delete(t *testing.T) {
 	}
 }
 
+// Test_EnsureDualStack verifies that Ensure publishes an AAAA record for an
+// IPv6 target alongside the A record for an IPv4 target, and deletes the AAAA
+// record once a record that was published as dual-stack no longer has an IPv6
+// target.
+func Test_EnsureDualStack(t *testing.T) {
+	rg := "test-rg"
+	zone := "dnszone.io"
+	ARecordName := "subdomain"
+	dnsZone := configv1.DNSZone{
+		ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+	}
+	testCases := []struct {
+		name        string
+		targets     []string
+		annotations map[string]string
+		expectA     string
+		expectAAAA  string
+	}{
+		{
+			name:    "IPv4 target",
+			targets: []string{"55.11.22.33"},
+			expectA: "PUT",
+		},
+		{
+			name:       "IPv4 and IPv6 targets",
+			targets:    []string{"55.11.22.33", "2001:db8::1"},
+			expectA:    "PUT",
+			expectAAAA: "PUT",
+		},
+		{
+			name:        "IPv4 target after publishing dual-stack",
+			targets:     []string{"55.11.22.33"},
+			annotations: map[string]string{dnsrecord.DNSDualStackPublishedAnnotation: "true"},
+			expectA:     "PUT",
+			expectAAAA:  "DELETE",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fc, _ := client.NewFake(client.Config{})
+			mgr, _ := fakeManager(fc)
+			record := iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:    "subdomain.dnszone.io.",
+					RecordType: iov1.ARecordType,
+					Targets:    tc.targets,
+					RecordTTL:  120,
+				},
+			}
+			if err := mgr.Ensure(&record, dnsZone); err != nil {
+				t.Fatalf("failed to ensure dns: %v", err)
+			}
+			if call, _ := fc.RecordedCall(rg, zone, ARecordName); call != tc.expectA {
+				t.Errorf("expected %q for the A record, got %q", tc.expectA, call)
+			}
+			if call, _ := fc.RecordedAAAACall(rg, zone, ARecordName); call != tc.expectAAAA {
+				t.Errorf("expected %q for the AAAA record, got %q", tc.expectAAAA, call)
+			}
+		})
+	}
+}
+
 func Test_GetTagList(t *testing.T) {
 	infra := configv1.Infrastructure{
 		Status: configv1.InfrastructureStatus{
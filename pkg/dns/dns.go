@@ -1,6 +1,8 @@
 package dns
 
 import (
+	"strings"
+
 	iov1 "github.com/openshift/api/operatoringress/v1"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -18,6 +20,54 @@ type Provider interface {
 	Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
 }
 
+// ZoneDelegationDetector is implemented by providers that can inspect a zone's
+// records to determine whether the zone has delegated part of its namespace to
+// other name servers.  A record that is published in a zone within a subzone
+// that the zone delegates does not resolve, because resolvers follow the
+// delegation and never consult the zone for the record.
+type ZoneDelegationDetector interface {
+	// DelegatedSubzone returns the name of the subzone of the given zone
+	// that the zone delegates to other name servers and that contains the
+	// given DNS name, or the empty string if the zone is authoritative for
+	// the DNS name.
+	DelegatedSubzone(dnsName string, zone configv1.DNSZone) (string, error)
+}
+
+// ZoneNameServerLister is implemented by providers that can look up the name
+// servers that are authoritative for a zone.  For the zone's records to
+// resolve publicly, the zone's parent domain must delegate the zone's apex to
+// these name servers.
+type ZoneNameServerLister interface {
+	// ZoneNameServers returns the apex domain of the given zone and the
+	// names of the zone's name servers.
+	ZoneNameServers(zone configv1.DNSZone) (string, []string, error)
+}
+
+// DelegationCandidates returns the names at which a zone with the given apex
+// domain could delegate a subzone that contains the given DNS name, in order
+// from the name closest to the apex to the DNS name itself.  Both names are
+// compared case-insensitively and with trailing dots.  Wildcard labels are
+// not candidates because a wildcard cannot own NS records.  If the DNS name
+// is not a subdomain of the apex, the result is empty.
+func DelegationCandidates(dnsName, apex string) []string {
+	dnsName = strings.ToLower(strings.TrimSuffix(dnsName, ".")) + "."
+	apex = strings.ToLower(strings.TrimSuffix(apex, ".")) + "."
+	if apex == "." || !strings.HasSuffix(dnsName, "."+apex) {
+		return nil
+	}
+	labels := strings.Split(strings.TrimSuffix(dnsName, "."+apex), ".")
+	var candidates []string
+	name := apex
+	for i := len(labels) - 1; i >= 0; i-- {
+		if labels[i] == "*" {
+			break
+		}
+		name = labels[i] + "." + name
+		candidates = append(candidates, name)
+	}
+	return candidates
+}
+
 var _ Provider = &FakeProvider{}
 
 type FakeProvider struct{}
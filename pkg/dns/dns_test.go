@@ -0,0 +1,50 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelegationCandidates(t *testing.T) {
+	testCases := []struct {
+		dnsName  string
+		apex     string
+		expected []string
+	}{
+		{
+			dnsName:  "*.apps.cluster.example.com.",
+			apex:     "example.com.",
+			expected: []string{"cluster.example.com.", "apps.cluster.example.com."},
+		},
+		{
+			dnsName:  "api.cluster.example.com",
+			apex:     "Example.COM",
+			expected: []string{"cluster.example.com.", "api.cluster.example.com."},
+		},
+		{
+			dnsName:  "*.apps.example.com.",
+			apex:     "apps.example.com.",
+			expected: nil,
+		},
+		{
+			dnsName:  "example.com.",
+			apex:     "example.com.",
+			expected: nil,
+		},
+		{
+			dnsName:  "*.apps.example.org.",
+			apex:     "example.com.",
+			expected: nil,
+		},
+		{
+			dnsName:  "*.apps.notexample.com.",
+			apex:     "example.com.",
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		if actual := DelegationCandidates(tc.dnsName, tc.apex); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("DelegationCandidates(%q, %q): expected %v, got %v", tc.dnsName, tc.apex, tc.expected, actual)
+		}
+	}
+}
@@ -19,8 +19,9 @@ import (
 )
 
 var (
-	_   dns.Provider = &Provider{}
-	log              = logf.Logger.WithName("dns")
+	_   dns.Provider             = &Provider{}
+	_   dns.ZoneNameServerLister = &Provider{}
+	log                          = logf.Logger.WithName("dns")
 )
 
 type Provider struct {
@@ -74,6 +75,20 @@ func (p *Provider) parseZone(zone configv1.DNSZone) (string, string, error) {
 	return project, zoneID, nil
 }
 
+// ZoneNameServers implements dns.ZoneNameServerLister.  It returns the managed
+// zone's DNS name and name servers.
+func (p *Provider) ZoneNameServers(zone configv1.DNSZone) (string, []string, error) {
+	project, zoneID, err := p.parseZone(zone)
+	if err != nil {
+		return "", nil, err
+	}
+	managedZone, err := p.dnsService.ManagedZones.Get(project, zoneID).Do()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get managed zone %s/%s: %w", project, zoneID, err)
+	}
+	return managedZone.DnsName, managedZone.NameServers, nil
+}
+
 func (p *Provider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
 	change := &gdnsv1.Change{Additions: []*gdnsv1.ResourceRecordSet{resourceRecordSet(record)}}
 
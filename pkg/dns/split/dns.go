@@ -1,6 +1,7 @@
 package split
 
 import (
+	"fmt"
 	"reflect"
 
 	iov1 "github.com/openshift/api/operatoringress/v1"
@@ -11,8 +12,10 @@ import (
 )
 
 var (
-	_   dns.Provider = &Provider{}
-	log              = logf.Logger.WithName("dns")
+	_   dns.Provider               = &Provider{}
+	_   dns.ZoneDelegationDetector = &Provider{}
+	_   dns.ZoneNameServerLister   = &Provider{}
+	log                            = logf.Logger.WithName("dns")
 )
 
 // Provider is a dns.Provider that wraps two other providers.  The first
@@ -56,3 +59,31 @@ func (p *Provider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error
 	}
 	return p.public.Replace(record, zone)
 }
+
+// DelegatedSubzone calls the DelegatedSubzone method of one of the wrapped DNS
+// providers if that provider implements dns.ZoneDelegationDetector, and
+// otherwise reports that the zone is authoritative.
+func (p *Provider) DelegatedSubzone(dnsName string, zone configv1.DNSZone) (string, error) {
+	provider := p.public
+	if reflect.DeepEqual(zone, *p.privateZone) {
+		provider = p.private
+	}
+	if detector, ok := provider.(dns.ZoneDelegationDetector); ok {
+		return detector.DelegatedSubzone(dnsName, zone)
+	}
+	return "", nil
+}
+
+// ZoneNameServers calls the ZoneNameServers method of one of the wrapped DNS
+// providers if that provider implements dns.ZoneNameServerLister, and
+// otherwise returns an error.
+func (p *Provider) ZoneNameServers(zone configv1.DNSZone) (string, []string, error) {
+	provider := p.public
+	if reflect.DeepEqual(zone, *p.privateZone) {
+		provider = p.private
+	}
+	if lister, ok := provider.(dns.ZoneNameServerLister); ok {
+		return lister.ZoneNameServers(zone)
+	}
+	return "", nil, fmt.Errorf("the DNS provider for zone %v cannot look up the zone's name servers", zone)
+}
@@ -0,0 +1,149 @@
+package ingressclient
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Clientset provides typed clients for the ingress operator's API types.
+type Clientset struct {
+	client client.WithWatch
+}
+
+// NewForConfig returns a clientset for the cluster with the given REST config.
+// The clientset's client only knows about the types in NewScheme.
+func NewForConfig(config *rest.Config) (*Clientset, error) {
+	c, err := client.NewWithWatch(config, client.Options{Scheme: NewScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return New(c), nil
+}
+
+// New returns a clientset that uses the given client.  The client's scheme must
+// include the types in NewScheme, for example by using AddToScheme.
+func New(c client.WithWatch) *Clientset {
+	return &Clientset{client: c}
+}
+
+// DNSRecords returns a client for the DNSRecords in the given namespace.
+func (c *Clientset) DNSRecords(namespace string) *DNSRecordClient {
+	return &DNSRecordClient{client: c.client, namespace: namespace}
+}
+
+// IngressControllers returns a client for the IngressControllers in the given
+// namespace.
+func (c *Clientset) IngressControllers(namespace string) *IngressControllerClient {
+	return &IngressControllerClient{client: c.client, namespace: namespace}
+}
+
+// DNSRecordClient is a typed client for the DNSRecords in a namespace.
+type DNSRecordClient struct {
+	client    client.WithWatch
+	namespace string
+}
+
+// Get returns the named DNSRecord.
+func (c *DNSRecordClient) Get(ctx context.Context, name string) (*iov1.DNSRecord, error) {
+	record := &iov1.DNSRecord{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: name}, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// List returns the DNSRecords that match the given options.
+func (c *DNSRecordClient) List(ctx context.Context, opts ...client.ListOption) (*iov1.DNSRecordList, error) {
+	records := &iov1.DNSRecordList{}
+	if err := c.client.List(ctx, records, append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Watch watches the DNSRecords that match the given options.  The watch's
+// events have *iov1.DNSRecord objects.
+func (c *DNSRecordClient) Watch(ctx context.Context, opts ...client.ListOption) (watch.Interface, error) {
+	return c.client.Watch(ctx, &iov1.DNSRecordList{}, append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...)
+}
+
+// ApplyStatus applies the given function to the status of the named DNSRecord
+// and patches the status with the result, retrying with the latest version of
+// the DNSRecord on conflict.  The updated DNSRecord is returned.
+func (c *DNSRecordClient) ApplyStatus(ctx context.Context, name string, apply func(*iov1.DNSRecordStatus)) (*iov1.DNSRecord, error) {
+	record := &iov1.DNSRecord{}
+	key := types.NamespacedName{Namespace: c.namespace, Name: name}
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := c.client.Get(ctx, key, record); err != nil {
+			return err
+		}
+		patch := client.MergeFrom(record.DeepCopy())
+		apply(&record.Status)
+		return c.client.Status().Patch(ctx, record, patch)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update the status of dnsrecord %s: %w", key, err)
+	}
+	return record, nil
+}
+
+// IngressControllerClient is a typed client for the IngressControllers in a
+// namespace.
+type IngressControllerClient struct {
+	client    client.WithWatch
+	namespace string
+}
+
+// Get returns the named IngressController.
+func (c *IngressControllerClient) Get(ctx context.Context, name string) (*operatorv1.IngressController, error) {
+	ic := &operatorv1.IngressController{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: name}, ic); err != nil {
+		return nil, err
+	}
+	return ic, nil
+}
+
+// List returns the IngressControllers that match the given options.
+func (c *IngressControllerClient) List(ctx context.Context, opts ...client.ListOption) (*operatorv1.IngressControllerList, error) {
+	ics := &operatorv1.IngressControllerList{}
+	if err := c.client.List(ctx, ics, append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...); err != nil {
+		return nil, err
+	}
+	return ics, nil
+}
+
+// Watch watches the IngressControllers that match the given options.  The
+// watch's events have *operatorv1.IngressController objects.
+func (c *IngressControllerClient) Watch(ctx context.Context, opts ...client.ListOption) (watch.Interface, error) {
+	return c.client.Watch(ctx, &operatorv1.IngressControllerList{}, append([]client.ListOption{client.InNamespace(c.namespace)}, opts...)...)
+}
+
+// ApplyStatus applies the given function to the status of the named
+// IngressController and patches the status with the result, retrying with the
+// latest version of the IngressController on conflict.  The updated
+// IngressController is returned.
+func (c *IngressControllerClient) ApplyStatus(ctx context.Context, name string, apply func(*operatorv1.IngressControllerStatus)) (*operatorv1.IngressController, error) {
+	ic := &operatorv1.IngressController{}
+	key := types.NamespacedName{Namespace: c.namespace, Name: name}
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := c.client.Get(ctx, key, ic); err != nil {
+			return err
+		}
+		patch := client.MergeFrom(ic.DeepCopy())
+		apply(&ic.Status)
+		return c.client.Status().Patch(ctx, ic, patch)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update the status of ingresscontroller %s: %w", key, err)
+	}
+	return ic, nil
+}
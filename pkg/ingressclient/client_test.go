@@ -0,0 +1,157 @@
+package ingressclient
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestDNSRecordClient verifies that the DNSRecord client gets, lists, watches,
+// and updates the status of DNSRecords in its namespace.
+func TestDNSRecordClient(t *testing.T) {
+	ctx := context.Background()
+	record := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "default-wildcard",
+			Labels:    map[string]string{"ingresscontroller": "default"},
+		},
+		Spec: iov1.DNSRecordSpec{DNSName: "*.apps.example.com."},
+	}
+	other := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "other",
+			Name:      "default-wildcard",
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(NewScheme()).
+		WithObjects(record, other).
+		WithStatusSubresource(record, other).
+		Build()
+	records := New(cl).DNSRecords("openshift-ingress-operator")
+
+	actual, err := records.Get(ctx, "default-wildcard")
+	if err != nil {
+		t.Fatalf("failed to get dnsrecord: %v", err)
+	}
+	if actual.Spec.DNSName != record.Spec.DNSName {
+		t.Errorf("expected dnsName %q, got %q", record.Spec.DNSName, actual.Spec.DNSName)
+	}
+
+	list, err := records.List(ctx, client.MatchingLabels{"ingresscontroller": "default"})
+	if err != nil {
+		t.Fatalf("failed to list dnsrecords: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Namespace != "openshift-ingress-operator" {
+		t.Errorf("expected only the dnsrecord in the client's namespace, got %+v", list.Items)
+	}
+
+	w, err := records.Watch(ctx)
+	if err != nil {
+		t.Fatalf("failed to watch dnsrecords: %v", err)
+	}
+	defer w.Stop()
+
+	updated, err := records.ApplyStatus(ctx, "default-wildcard", func(status *iov1.DNSRecordStatus) {
+		status.ObservedGeneration = 2
+	})
+	if err != nil {
+		t.Fatalf("failed to update dnsrecord status: %v", err)
+	}
+	if updated.Status.ObservedGeneration != 2 {
+		t.Errorf("expected observedGeneration 2, got %d", updated.Status.ObservedGeneration)
+	}
+
+	select {
+	case event := <-w.ResultChan():
+		record, ok := event.Object.(*iov1.DNSRecord)
+		if event.Type != watch.Modified || !ok || record.Status.ObservedGeneration != 2 {
+			t.Errorf("expected a modified event for the updated dnsrecord, got %s %+v", event.Type, event.Object)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("timed out waiting for a watch event")
+	}
+
+	if _, err := records.ApplyStatus(ctx, "missing", func(*iov1.DNSRecordStatus) {}); err == nil {
+		t.Error("expected an error for a missing dnsrecord, got nil")
+	}
+}
+
+// TestIngressControllerClient verifies that the IngressController client gets,
+// lists, and updates the status of IngressControllers in its namespace.
+func TestIngressControllerClient(t *testing.T) {
+	ctx := context.Background()
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "default",
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithScheme(NewScheme()).
+		WithObjects(ic).
+		WithStatusSubresource(ic).
+		Build()
+	ics := New(cl).IngressControllers("openshift-ingress-operator")
+
+	if _, err := ics.Get(ctx, "default"); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+	list, err := ics.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list ingresscontrollers: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Errorf("expected 1 ingresscontroller, got %d", len(list.Items))
+	}
+	updated, err := ics.ApplyStatus(ctx, "default", func(status *operatorv1.IngressControllerStatus) {
+		status.Domain = "apps.example.com"
+	})
+	if err != nil {
+		t.Fatalf("failed to update ingresscontroller status: %v", err)
+	}
+	if actual, err := ics.Get(ctx, "default"); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	} else if actual.Status.Domain != "apps.example.com" || updated.Status.Domain != actual.Status.Domain {
+		t.Errorf("expected status.domain %q, got %q", "apps.example.com", actual.Status.Domain)
+	}
+}
+
+// TestImports verifies that the package does not import other packages of the
+// ingress operator, which would make it heavy to import.
+func TestImports(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if strings.HasPrefix(path, "github.com/openshift/cluster-ingress-operator/") {
+				t.Errorf("%s imports %s", file, path)
+			}
+		}
+	}
+}
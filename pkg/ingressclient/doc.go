@@ -0,0 +1,33 @@
+// Package ingressclient is a lightweight client for the ingress operator's API
+// types: DNSRecords in the operator.openshift.io/v1 ingress group and
+// IngressControllers.  It provides the scheme registration for these types and
+// typed Get, List, Watch, and ApplyStatus helpers, so programs that consume
+// these types do not need to use unstructured objects or import the operator.
+//
+// The package only depends on the API types from github.com/openshift/api,
+// k8s.io/apimachinery, k8s.io/client-go, and controller-runtime's client
+// package.  It must not import any other package of the ingress operator; the
+// operator registers its types using this package, not the other way around.
+//
+// Compatibility: exported identifiers in this package are not removed or
+// changed incompatibly within a minor release of OpenShift.  The API types
+// themselves follow the API compatibility level that is documented on each
+// type in github.com/openshift/api.
+//
+// To watch DNSRecords:
+//
+//	clientset, err := ingressclient.NewForConfig(config)
+//	if err != nil {
+//		return err
+//	}
+//	w, err := clientset.DNSRecords("openshift-ingress-operator").Watch(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Stop()
+//	for event := range w.ResultChan() {
+//		if record, ok := event.Object.(*iov1.DNSRecord); ok {
+//			fmt.Println(event.Type, record.Name, record.Spec.DNSName)
+//		}
+//	}
+package ingressclient
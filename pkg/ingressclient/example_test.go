@@ -0,0 +1,44 @@
+package ingressclient_test
+
+import (
+	"context"
+	"fmt"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/ingressclient"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// ExampleDNSRecordClient_Watch watches DNSRecords.  Programs that run against
+// a cluster use ingressclient.NewForConfig instead of a fake client.
+func ExampleDNSRecordClient_Watch() {
+	ctx := context.Background()
+	cl := fake.NewClientBuilder().WithScheme(ingressclient.NewScheme()).Build()
+	clientset := ingressclient.New(cl)
+
+	w, err := clientset.DNSRecords("openshift-ingress-operator").Watch(ctx)
+	if err != nil {
+		panic(err)
+	}
+	defer w.Stop()
+
+	record := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "default-wildcard",
+		},
+		Spec: iov1.DNSRecordSpec{DNSName: "*.apps.example.com."},
+	}
+	if err := cl.Create(ctx, record); err != nil {
+		panic(err)
+	}
+
+	event := <-w.ResultChan()
+	if record, ok := event.Object.(*iov1.DNSRecord); ok {
+		fmt.Println(event.Type, record.Name, record.Spec.DNSName)
+	}
+	// Output: ADDED default-wildcard *.apps.example.com.
+}
@@ -0,0 +1,26 @@
+package ingressclient
+
+import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+var (
+	// schemeBuilder registers the API types that this package provides
+	// clients for.
+	schemeBuilder = runtime.NewSchemeBuilder(operatorv1.AddToScheme, iov1.AddToScheme)
+	// AddToScheme adds the API types that this package provides clients
+	// for to a scheme.
+	AddToScheme = schemeBuilder.AddToScheme
+)
+
+// NewScheme returns a new scheme with the API types that this package provides
+// clients for.
+func NewScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(AddToScheme(scheme))
+	return scheme
+}
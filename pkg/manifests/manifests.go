@@ -24,28 +24,36 @@ import (
 )
 
 const (
-	RouterNamespaceAsset          = "assets/router/namespace.yaml"
-	RouterServiceAccountAsset     = "assets/router/service-account.yaml"
-	RouterClusterRoleAsset        = "assets/router/cluster-role.yaml"
-	RouterClusterRoleBindingAsset = "assets/router/cluster-role-binding.yaml"
-	RouterDeploymentAsset         = "assets/router/deployment.yaml"
-	RouterServiceInternalAsset    = "assets/router/service-internal.yaml"
-	RouterServiceCloudAsset       = "assets/router/service-cloud.yaml"
+	RouterNamespaceAsset                = "assets/router/namespace.yaml"
+	RouterServiceAccountAsset           = "assets/router/service-account.yaml"
+	RouterEgressDSCPServiceAccountAsset = "assets/router/service-account-egress-dscp.yaml"
+	RouterClusterRoleAsset              = "assets/router/cluster-role.yaml"
+	RouterClusterRoleBindingAsset       = "assets/router/cluster-role-binding.yaml"
+	RouterDeploymentAsset               = "assets/router/deployment.yaml"
+	RouterServiceInternalAsset          = "assets/router/service-internal.yaml"
+	RouterServiceCloudAsset             = "assets/router/service-cloud.yaml"
 
 	MetricsClusterRoleAsset        = "assets/router/metrics/cluster-role.yaml"
 	MetricsClusterRoleBindingAsset = "assets/router/metrics/cluster-role-binding.yaml"
 	MetricsRoleAsset               = "assets/router/metrics/role.yaml"
 	MetricsRoleBindingAsset        = "assets/router/metrics/role-binding.yaml"
 
-	CanaryNamespaceAsset = "assets/canary/namespace.yaml"
-	CanaryDaemonSetAsset = "assets/canary/daemonset.yaml"
-	CanaryServiceAsset   = "assets/canary/service.yaml"
-	CanaryRouteAsset     = "assets/canary/route.yaml"
+	CanaryNamespaceAsset      = "assets/canary/namespace.yaml"
+	CanaryServiceAccountAsset = "assets/canary/service-account.yaml"
+	CanaryDaemonSetAsset      = "assets/canary/daemonset.yaml"
+	CanaryServiceAsset        = "assets/canary/service.yaml"
+	CanaryRouteAsset          = "assets/canary/route.yaml"
 
-	GatewayClassCRDAsset   = "assets/gateway-api/gateway.networking.k8s.io_gatewayclasses.yaml"
-	GatewayCRDAsset        = "assets/gateway-api/gateway.networking.k8s.io_gateways.yaml"
-	HTTPRouteCRDAsset      = "assets/gateway-api/gateway.networking.k8s.io_httproutes.yaml"
-	ReferenceGrantCRDAsset = "assets/gateway-api/gateway.networking.k8s.io_referencegrants.yaml"
+	CanaryEdgeProbeServiceAccountAsset = "assets/canary/edge-probe-service-account.yaml"
+	CanaryEdgeProbeRoleBindingAsset    = "assets/canary/edge-probe-role-binding.yaml"
+	CanaryEdgeProbeDaemonSetAsset      = "assets/canary/edge-probe-daemonset.yaml"
+
+	GatewayClassCRDAsset     = "assets/gateway-api/gateway.networking.k8s.io_gatewayclasses.yaml"
+	GatewayCRDAsset          = "assets/gateway-api/gateway.networking.k8s.io_gateways.yaml"
+	HTTPRouteCRDAsset        = "assets/gateway-api/gateway.networking.k8s.io_httproutes.yaml"
+	ReferenceGrantCRDAsset   = "assets/gateway-api/gateway.networking.k8s.io_referencegrants.yaml"
+	BackendTLSPolicyCRDAsset = "assets/gateway-api/gateway.networking.k8s.io_backendtlspolicies.yaml"
+	TLSRouteCRDAsset         = "assets/gateway-api/gateway.networking.k8s.io_tlsroutes.yaml"
 
 	// Annotation used to inform the certificate generation service to
 	// generate a cluster-signed certificate and populate the secret.
@@ -60,6 +68,12 @@ const (
 	// ingress operator's canary end-to-end check controller.
 	OwningIngressCanaryCheckLabel = "ingress.openshift.io/canary"
 
+	// IngressOperatorManagedLabel is applied to cluster-scoped objects that
+	// the operator creates and therefore may delete and recreate, to
+	// distinguish them from objects with the same name that the operator
+	// did not create.
+	IngressOperatorManagedLabel = "ingress.operator.openshift.io/managed"
+
 	// IngressControllerFinalizer is used to block deletion of ingresscontrollers
 	// until the operator has ensured it's safe for deletion to proceed.
 	IngressControllerFinalizer = "ingresscontroller.operator.openshift.io/finalizer-ingresscontroller"
@@ -134,6 +148,14 @@ func RouterServiceAccount() *corev1.ServiceAccount {
 	return sa
 }
 
+func RouterEgressDSCPServiceAccount() *corev1.ServiceAccount {
+	sa, err := NewServiceAccount(MustAssetReader(RouterEgressDSCPServiceAccountAsset))
+	if err != nil {
+		panic(err)
+	}
+	return sa
+}
+
 func RouterClusterRole() *rbacv1.ClusterRole {
 	cr, err := NewClusterRole(MustAssetReader(RouterClusterRoleAsset))
 	if err != nil {
@@ -231,6 +253,14 @@ func CanaryNamespace() *corev1.Namespace {
 	return ns
 }
 
+func CanaryServiceAccount() *corev1.ServiceAccount {
+	sa, err := NewServiceAccount(MustAssetReader(CanaryServiceAccountAsset))
+	if err != nil {
+		panic(err)
+	}
+	return sa
+}
+
 func CanaryDaemonSet() *appsv1.DaemonSet {
 	daemonset, err := NewDaemonSet(MustAssetReader(CanaryDaemonSetAsset))
 	if err != nil {
@@ -255,6 +285,30 @@ func CanaryRoute() *routev1.Route {
 	return route
 }
 
+func CanaryEdgeProbeServiceAccount() *corev1.ServiceAccount {
+	sa, err := NewServiceAccount(MustAssetReader(CanaryEdgeProbeServiceAccountAsset))
+	if err != nil {
+		panic(err)
+	}
+	return sa
+}
+
+func CanaryEdgeProbeRoleBinding() *rbacv1.RoleBinding {
+	rb, err := NewRoleBinding(MustAssetReader(CanaryEdgeProbeRoleBindingAsset))
+	if err != nil {
+		panic(err)
+	}
+	return rb
+}
+
+func CanaryEdgeProbeDaemonSet() *appsv1.DaemonSet {
+	daemonset, err := NewDaemonSet(MustAssetReader(CanaryEdgeProbeDaemonSetAsset))
+	if err != nil {
+		panic(err)
+	}
+	return daemonset
+}
+
 func GatewayClassCRD() *apiextensionsv1.CustomResourceDefinition {
 	crd, err := NewCustomResourceDefinition(MustAssetReader(GatewayClassCRDAsset))
 	if err != nil {
@@ -287,6 +341,22 @@ func ReferenceGrantCRD() *apiextensionsv1.CustomResourceDefinition {
 	return crd
 }
 
+func BackendTLSPolicyCRD() *apiextensionsv1.CustomResourceDefinition {
+	crd, err := NewCustomResourceDefinition(MustAssetReader(BackendTLSPolicyCRDAsset))
+	if err != nil {
+		panic(err)
+	}
+	return crd
+}
+
+func TLSRouteCRD() *apiextensionsv1.CustomResourceDefinition {
+	crd, err := NewCustomResourceDefinition(MustAssetReader(TLSRouteCRDAsset))
+	if err != nil {
+		panic(err)
+	}
+	return crd
+}
+
 func NewServiceAccount(manifest io.Reader) (*corev1.ServiceAccount, error) {
 	sa := corev1.ServiceAccount{}
 	if err := yaml.NewYAMLOrJSONDecoder(manifest, 100).Decode(&sa); err != nil {
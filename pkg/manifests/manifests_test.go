@@ -30,6 +30,7 @@ func TestManifests(t *testing.T) {
 	}
 
 	RouterServiceAccount()
+	RouterEgressDSCPServiceAccount()
 	RouterClusterRole()
 	RouterClusterRoleBinding()
 	RouterStatsSecret(ci)
@@ -44,10 +45,14 @@ func TestManifests(t *testing.T) {
 	InternalIngressControllerService()
 	LoadBalancerService()
 
+	CanaryServiceAccount()
+
 	GatewayClassCRD()
 	GatewayCRD()
 	HTTPRouteCRD()
 	ReferenceGrantCRD()
+	BackendTLSPolicyCRD()
+	TLSRouteCRD()
 
 	MustAsset(CustomResourceDefinitionManifest)
 	MustAsset(NamespaceManifest)
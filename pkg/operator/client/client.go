@@ -3,11 +3,10 @@ package client
 import (
 	"fmt"
 
-	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/ingressclient"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
-	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
 
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
@@ -38,15 +37,14 @@ var (
 
 func init() {
 	scheme = kscheme.Scheme
-	if err := operatorv1.AddToScheme(scheme); err != nil {
+	// Register the ingress operator's own API types using the same
+	// package as external consumers of these types.
+	if err := ingressclient.AddToScheme(scheme); err != nil {
 		panic(err)
 	}
 	if err := configv1.Install(scheme); err != nil {
 		panic(err)
 	}
-	if err := iov1.AddToScheme(scheme); err != nil {
-		panic(err)
-	}
 	if err := routev1.Install(scheme); err != nil {
 		panic(err)
 	}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config is configuration for the operator and should include things like
 // operated images, scheduling configuration, etc.
 type Config struct {
@@ -15,5 +17,28 @@ type Config struct {
 	// CanaryImage is the ingress operator image, which runs a canary command.
 	CanaryImage string
 
+	// DNSCleanupMaxAttempts is the number of failed attempts to delete a
+	// DNS record from the DNS provider after which the operator may give
+	// up.  If it is not positive, the operator retries indefinitely.
+	DNSCleanupMaxAttempts int
+
+	// DNSCleanupTimeout is how long the operator retries deleting a DNS
+	// record from the DNS provider before it may give up.
+	DNSCleanupTimeout time.Duration
+
+	// OrphanCleanupGracePeriod is how long an operand resource that is
+	// labeled as owned by an ingresscontroller that does not exist must
+	// remain orphaned before the operator deletes it.
+	OrphanCleanupGracePeriod time.Duration
+
+	// OrphanCleanupDryRun specifies that the operator only reports orphaned
+	// operand resources and does not delete them.  It is true by default.
+	OrphanCleanupDryRun bool
+
+	// DomainDelegationCheckResolvers are the addresses of the public
+	// resolvers with which the operator checks whether the public DNS zone
+	// is delegated to its name servers.  If empty, the check is disabled.
+	DomainDelegationCheckResolvers []string
+
 	Stop chan struct{}
 }
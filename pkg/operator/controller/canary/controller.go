@@ -2,6 +2,7 @@ package canary
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -42,8 +43,12 @@ const (
 	// canaryCheckFrequency is how long to wait in between canary checks.
 	canaryCheckFrequency = 1 * time.Minute
 	// canaryCheckCycleCount is how many successful canary checks should be observed
-	// before rotating the canary endpoint.
+	// before rotating the canary endpoint if the rotation interval is not
+	// specified.
 	canaryCheckCycleCount = 5
+	// defaultCanaryRouteRotationInterval is how long to wait in between canary
+	// route rotations if the rotation interval is not specified.
+	defaultCanaryRouteRotationInterval = canaryCheckCycleCount * canaryCheckFrequency
 	// canaryCheckFailureCount is how many successive failing canary checks should
 	// be observed before the default ingress controller goes degraded.
 	canaryCheckFailureCount = 5
@@ -59,9 +64,34 @@ const (
 	// a value of "true" (disabled otherwise).
 	CanaryRouteRotationAnnotation = "ingress.operator.openshift.io/rotate-canary-route"
 
+	// CanaryRouteRotationIntervalAnnotation is an annotation on the default
+	// ingress controller that specifies how often the canary check loop
+	// should rotate the endpoints of the canary route when canary route
+	// rotation is enabled.  The value is parsed as a duration (for example,
+	// "10m") and is rounded down to a multiple of the canary check
+	// frequency.  Values that cannot be parsed or that are less than the
+	// canary check frequency are ignored, and the default interval is used.
+	CanaryRouteRotationIntervalAnnotation = "ingress.operator.openshift.io/canary-route-rotation-interval"
+
+	// canaryRotationReloadGracePeriod is how long, in addition to the
+	// router's reload interval, the canary check loop allows the router to
+	// apply a rotation of the canary route before it counts checks that
+	// fail because the router is still using the previous target port.
+	canaryRotationReloadGracePeriod = 30 * time.Second
+
+	// canaryRouteRotationStuckReason is the reason for the canary status
+	// condition when canary checks fail after the canary route has been
+	// rotated even though the checks succeeded before the rotation.  This
+	// indicates that the router is not applying configuration changes.
+	canaryRouteRotationStuckReason = "CanaryRouteRotationStuck"
+
 	// CanaryHealthcheckCommand is a parameter to pass to the ingress-operator to call
 	// into the handler for the canary daemonset health check
 	CanaryHealthcheckCommand = "serve-healthcheck"
+	// CanaryEdgeProbeCommand is a parameter to pass to the ingress-operator
+	// to run the canary edge probe, which probes the canary route from the
+	// host network of the node on which it runs.
+	CanaryEdgeProbeCommand = "serve-canary-edge-probe"
 	// CanaryHealthcheckResponse is the message that signals a successful health check
 	CanaryHealthcheckResponse = "Healthcheck requested"
 )
@@ -78,9 +108,10 @@ var (
 func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	operatorCache := mgr.GetCache()
 	reconciler := &reconciler{
-		config:                    config,
-		client:                    mgr.GetClient(),
-		enableCanaryRouteRotation: false,
+		config:                      config,
+		client:                      mgr.GetClient(),
+		enableCanaryRouteRotation:   false,
+		canaryRouteRotationInterval: defaultCanaryRouteRotationInterval,
 	}
 	c, err := controller.New(canaryControllerName, mgr, controller.Options{Reconciler: reconciler})
 	if err != nil {
@@ -148,7 +179,9 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	}
 	canaryDaemonSetPredicate := predicate.NewPredicateFuncs(func(o client.Object) bool {
 		canaryDaemonSet := operatorcontroller.CanaryDaemonSetName()
-		return o.GetNamespace() == canaryDaemonSet.Namespace && o.GetName() == canaryDaemonSet.Name
+		edgeProbeDaemonSet := operatorcontroller.CanaryEdgeProbeDaemonSetName()
+		return (o.GetNamespace() == canaryDaemonSet.Namespace && o.GetName() == canaryDaemonSet.Name) ||
+			(o.GetNamespace() == edgeProbeDaemonSet.Namespace && o.GetName() == edgeProbeDaemonSet.Name)
 	})
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &appsv1.DaemonSet{}, enqueueRequestForDefaultIngressController(config.Namespace), canaryDaemonSetPredicate)); err != nil {
 		return nil, err
@@ -160,6 +193,13 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Service{}, enqueueRequestForDefaultIngressController(config.Namespace), canaryServicePredicate)); err != nil {
 		return nil, err
 	}
+	canaryServiceAccountPredicate := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		canaryServiceAccount := operatorcontroller.CanaryServiceAccountName()
+		return o.GetNamespace() == canaryServiceAccount.Namespace && o.GetName() == canaryServiceAccount.Name
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.ServiceAccount{}, enqueueRequestForDefaultIngressController(config.Namespace), canaryServiceAccountPredicate)); err != nil {
+		return nil, err
+	}
 
 	return c, nil
 }
@@ -189,6 +229,12 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return result, fmt.Errorf("failed to ensure canary namespace: %v", err)
 	}
 
+	if _, _, err := r.ensureCanaryServiceAccount(); err != nil {
+		// The daemonset's pods cannot be created without their service
+		// account, so there is no point in proceeding.
+		return result, fmt.Errorf("failed to ensure canary service account: %v", err)
+	}
+
 	haveDs, daemonset, err := r.ensureCanaryDaemonSet()
 	if err != nil {
 		return result, fmt.Errorf("failed to ensure canary daemonset: %v", err)
@@ -228,10 +274,41 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	val, ok := ic.Annotations[CanaryRouteRotationAnnotation]
 	v, _ := strconv.ParseBool(val)
+	interval := canaryRouteRotationInterval(ic)
 	r.mu.Lock()
 	r.enableCanaryRouteRotation = ok && v
+	r.canaryRouteRotationInterval = interval
+	r.routerReloadInterval = ingresscontroller.ReloadIntervalForIngressController(ic)
 	r.mu.Unlock()
 
+	// Get the optional user probe from the default ingress controller.  An
+	// invalid probe is reported by the canary check loop.
+	r.setUserProbe(r.userProbeForIngressController(ic))
+
+	// Get the optional canary response sizes from the default ingress
+	// controller.
+	r.setResponseSize(responseSizeForIngressController(ic))
+
+	// Run the optional edge probe, which verifies that the canary route is
+	// reachable from outside the cluster, on the nodes that the default
+	// ingress controller designates.  An invalid probe is reported by the
+	// canary check loop.
+	edgeProbe, err := edgeProbeForIngressController(ic)
+	r.setEdgeProbe(edgeProbe, err)
+	if err := r.ensureCanaryEdgeProbe(edgeProbe, operatorcontroller.CanaryRouteHost(ic.Status.Domain)); err != nil {
+		return result, fmt.Errorf("failed to ensure canary edge probe: %w", err)
+	}
+
+	// Determine whether to probe the canary route through an external
+	// endpoint.  If the default ingress controller is fronted by an
+	// external CDN, the canary route's host resolves to the CDN, so the
+	// route is probed through the load balancer.
+	probePath := canaryProbePathForIngressController(ic)
+	r.setProbePath(probePath)
+	if err := r.syncExternalEndpointStatusCondition(probePath); err != nil {
+		return result, fmt.Errorf("failed to update external endpoint status condition: %w", err)
+	}
+
 	// Start probing the canary route.
 	routeProbeRunner.Do(func() {
 		r.startCanaryRoutePolling(r.config.Stop)
@@ -254,10 +331,33 @@ type reconciler struct {
 
 	client client.Client
 
-	// Use a mutex so enableCanaryRotation is
-	// go-routine safe.
-	mu                        sync.Mutex
-	enableCanaryRouteRotation bool
+	// Use a mutex so enableCanaryRotation,
+	// canaryRouteRotationInterval, routerReloadInterval, userProbe,
+	// userProbeErr, edgeProbe, edgeProbeErr, probePath, and responseSize
+	// are go-routine safe.
+	mu                          sync.Mutex
+	enableCanaryRouteRotation   bool
+	canaryRouteRotationInterval time.Duration
+	// routerReloadInterval is the interval at which the default ingress
+	// controller's router coalesces configuration changes into a reload.
+	routerReloadInterval time.Duration
+	// userProbe is the canary user probe that the default ingress
+	// controller specifies, or nil if it specifies none.
+	userProbe *ingresscontroller.CanaryUserProbe
+	// userProbeErr is the error from determining the canary user probe,
+	// if the default ingress controller specifies an invalid one.
+	userProbeErr error
+	// edgeProbe is the canary edge probe that the default ingress
+	// controller specifies, or nil if it specifies none.
+	edgeProbe *ingresscontroller.CanaryEdgeProbe
+	// edgeProbeErr is the error from determining the canary edge probe,
+	// if the default ingress controller specifies an invalid one.
+	edgeProbeErr error
+	// probePath is the path through which to probe the canary route.
+	probePath canaryProbePath
+	// responseSize is the canary response sizes that the default ingress
+	// controller specifies, or nil if it specifies none.
+	responseSize *ingresscontroller.CanaryResponseSize
 }
 
 func (r *reconciler) isCanaryRouteRotationEnabled() bool {
@@ -266,6 +366,68 @@ func (r *reconciler) isCanaryRouteRotationEnabled() bool {
 	return r.enableCanaryRouteRotation
 }
 
+// canaryRouteRotationCheckCount returns how many successful canary checks
+// should be observed before rotating the canary route endpoint.
+func (r *reconciler) canaryRouteRotationCheckCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if count := int(r.canaryRouteRotationInterval / canaryCheckFrequency); count > 0 {
+		return count
+	}
+	return canaryCheckCycleCount
+}
+
+// currentRouterReloadInterval returns the default ingress controller's router
+// reload interval.
+func (r *reconciler) currentRouterReloadInterval() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.routerReloadInterval
+}
+
+// awaitingRotation returns a Boolean value indicating whether the given failed
+// samples can be explained by the router not having applied the most recent
+// rotation of the canary route yet.  This is the case if all of the samples
+// reached a canary pod on the previous target port and the rotation happened
+// less than the router's reload interval plus canaryRotationReloadGracePeriod
+// ago, as the router may coalesce the rotation with other configuration
+// changes for up to its reload interval.
+func awaitingRotation(state *canaryCheckState, samples []canarySample, reloadInterval time.Duration, now time.Time) bool {
+	if !state.rotationPending || state.lastRotation == nil || state.lastRotation.err != nil {
+		return false
+	}
+	if now.Sub(state.lastRotation.timestamp) >= reloadInterval+canaryRotationReloadGracePeriod {
+		return false
+	}
+	for _, sample := range samples {
+		var mismatch *canaryPortMismatchError
+		if !errors.As(sample.err, &mismatch) {
+			return false
+		}
+	}
+	return len(samples) != 0
+}
+
+// canaryRouteRotationInterval returns the canary route rotation interval
+// specified by the given ingresscontroller's canary route rotation interval
+// annotation, or the default interval if the annotation is absent or invalid.
+func canaryRouteRotationInterval(ic *operatorv1.IngressController) time.Duration {
+	val, ok := ic.Annotations[CanaryRouteRotationIntervalAnnotation]
+	if !ok {
+		return defaultCanaryRouteRotationInterval
+	}
+	interval, err := time.ParseDuration(val)
+	if err != nil {
+		log.Error(err, "invalid canary route rotation interval; using the default", "annotation", CanaryRouteRotationIntervalAnnotation, "value", val, "default", defaultCanaryRouteRotationInterval)
+		return defaultCanaryRouteRotationInterval
+	}
+	if interval < canaryCheckFrequency {
+		log.Info("canary route rotation interval is less than the canary check frequency; using the default", "annotation", CanaryRouteRotationIntervalAnnotation, "value", val, "default", defaultCanaryRouteRotationInterval)
+		return defaultCanaryRouteRotationInterval
+	}
+	return interval
+}
+
 type timestampedError struct {
 	timestamp time.Time
 	err       error
@@ -275,91 +437,162 @@ func (err timestampedError) Error() string {
 	return err.err.Error()
 }
 
-func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
-	// Keep track of how many canary checks have passed
-	// so the route endpoint can be periodically cycled
-	// (when canary route rotation is enabled).
-	checkCount := 0
+// canaryRouteRotation records the outcome of a canary route rotation.
+type canaryRouteRotation struct {
+	timestamp time.Time
+	err       error
+}
 
-	// Keep track of successive canary check failures
-	// for status reporting.
-	successiveFail := 0
+// canaryCheckState is the state that the canary check loop keeps in between
+// canary checks.
+type canaryCheckState struct {
+	// checkCount is how many canary checks have passed since the last
+	// rotation so the route endpoint can be periodically cycled (when
+	// canary route rotation is enabled).
+	checkCount int
+	// successiveFail is how many successive canary checks have failed, for
+	// status reporting.
+	successiveFail int
+	// errors are the errors from the successive failing canary checks.
+	errors []timestampedError
+	// rotationPending is true if the canary route has been rotated and no
+	// canary check has succeeded since the rotation.
+	rotationPending bool
+	// lastRotation is the outcome of the most recent canary route rotation,
+	// or nil if the canary route has not been rotated.
+	lastRotation *canaryRouteRotation
+	// endpointFailures is how many successive canary checks have failed
+	// for each canary pod, keyed by pod name.
+	endpointFailures map[string]int
+}
 
-	errors := []timestampedError{}
+func (r *reconciler) startCanaryRoutePolling(stop <-chan struct{}) error {
+	state := &canaryCheckState{}
+	userState := &userProbeState{}
 
 	// using wait.NonSlidingUntil so that the canary runs every canaryCheckFrequency, regardless of how long the function takes
 	go wait.NonSlidingUntil(func() {
-		// Get the current canary route every iteration in case it has been modified
-		haveRoute, route, err := r.currentCanaryRoute()
-		if err != nil {
-			log.Error(err, "failed to get current canary route for canary check")
-			return
-		} else if !haveRoute {
-			log.Info("canary check route does not exist")
-			if err := r.setCanaryDoesNotExistStatusCondition(); err != nil {
-				log.Error(err, "error updating canary status condition")
+		r.checkCanaryRoute(state, func(route *routev1.Route, address string, size int) (string, error) {
+			rootCAs, err := r.canaryRootCAs()
+			if err != nil {
+				return "", err
 			}
-			return
-		}
+			return probeRouteEndpoint(route, rootCAs, address, size)
+		})
+		r.checkUserProbe(userState, probeUserEndpoint)
+		r.checkEdgeProbe(r.currentEdgeProbeResults)
+	}, canaryCheckFrequency, stop)
 
-		// Don't attempt to probe if route is not actually admitted.
-		if !checkRouteAdmitted(route) {
-			if err := r.setCanaryNotAdmittedStatusCondition(); err != nil {
-				log.Error(err, "error updating canary status condition")
-			}
-			return
-		}
+	return nil
+}
 
-		err = probeRouteEndpoint(route)
-		if err != nil {
-			log.Error(err, "error performing canary route check")
-			SetCanaryRouteReachableMetric(getRouteHost(route), false)
-			successiveFail += 1
-			errors = append(errors, timestampedError{err: err, timestamp: time.Now()})
-			// Mark the default ingress controller degraded after 5 successive canary check failures
-			if successiveFail >= canaryCheckFailureCount {
-				if err := r.setCanaryFailingStatusCondition(errors); err != nil {
-					log.Error(err, "error updating canary status condition")
-				}
-			}
-			return
+// checkCanaryRoute performs a single canary check using the given probe
+// function, updates the canary status conditions, and rotates the canary route
+// endpoint if canary route rotation is enabled and enough checks have passed
+// since the last rotation.  The probe function is given the address of the
+// external endpoint through which to probe the route, or the empty string to
+// probe the route through the in-cluster path, and the response size to ask
+// for, and returns the name of the canary pod that served the request, if
+// known.  The check sends multiple requests so that it can attribute failures
+// to individual canary pods, and it succeeds if any request succeeds, unless
+// the check alternates between small and large responses and every request
+// for a large response fails.
+func (r *reconciler) checkCanaryRoute(state *canaryCheckState, probe func(*routev1.Route, string, int) (string, error)) {
+	// Get the current canary route every iteration in case it has been modified
+	haveRoute, route, err := r.currentCanaryRoute()
+	if err != nil {
+		log.Error(err, "failed to get current canary route for canary check")
+		return
+	} else if !haveRoute {
+		log.Info("canary check route does not exist")
+		if err := r.setCanaryDoesNotExistStatusCondition(); err != nil {
+			log.Error(err, "error updating canary status condition")
 		}
+		return
+	}
 
-		SetCanaryRouteReachableMetric(getRouteHost(route), true)
-		if err := r.setCanaryPassingStatusCondition(); err != nil {
+	// Don't attempt to probe if route is not actually admitted.
+	if !checkRouteAdmitted(route) {
+		if err := r.setCanaryNotAdmittedStatusCondition(); err != nil {
 			log.Error(err, "error updating canary status condition")
 		}
-		successiveFail = 0
-		errors = []timestampedError{}
-
-		// Check if canary route rotations are enabled every iteration.
-		rotationEnabled := r.isCanaryRouteRotationEnabled()
-		// Increment checkCount and periodically rotate the canary route endpoint if canary route rotation is enabled.
-		if rotationEnabled {
-			checkCount++
-			if checkCount >= canaryCheckCycleCount {
-				haveService, service, err := r.currentCanaryService()
-				if err != nil {
-					log.Error(err, "failed to get canary service")
-					return
-				} else if !haveService {
-					log.Info("canary check service does not exist")
-					return
-				}
-				route, err = r.rotateRouteEndpoint(service, route)
-				if err != nil {
-					log.Error(err, "failed to rotate canary route endpoint")
-					return
-				}
-				checkCount = 0
+		return
+	}
+
+	endpoints, err := r.currentCanaryEndpoints()
+	if err != nil {
+		log.Error(err, "failed to get canary endpoints for canary check")
+	}
+	path := r.currentProbePath()
+	samples := sampleCanaryRoute(route, path.externalAddress, canaryCheckSampleCount(len(endpoints)), r.currentResponseSize(), probe)
+	err = canarySamplesError(samples)
+	if err == nil {
+		err = largeResponseSamplesError(samples)
+	}
+	if err != nil && awaitingRotation(state, samples, r.currentRouterReloadInterval(), time.Now()) {
+		// The router may not have reloaded since the rotation, so do
+		// not count the check as a failure yet.
+		log.Info("canary route check failed because the router has not applied the rotated canary route yet", "error", err.Error(), "rotated", state.lastRotation.timestamp, "reloadInterval", r.currentRouterReloadInterval())
+		return
+	}
+	r.checkCanaryEndpoints(state, endpoints, samples)
+	if err != nil {
+		log.Error(err, "error performing canary route check")
+		SetCanaryRouteReachableMetric(getRouteHost(route), false)
+		state.successiveFail += 1
+		state.errors = append(state.errors, timestampedError{err: err, timestamp: time.Now()})
+		// Mark the default ingress controller degraded after 5 successive canary check failures
+		if state.successiveFail >= canaryCheckFailureCount {
+			if err := r.setCanaryFailingStatusCondition(state.errors, state.rotationPending, path); err != nil {
+				log.Error(err, "error updating canary status condition")
 			}
 		}
-	}, canaryCheckFrequency, stop)
+		return
+	}
 
-	return nil
+	SetCanaryRouteReachableMetric(getRouteHost(route), true)
+	if err := r.setCanaryPassingStatusCondition(state.lastRotation, path); err != nil {
+		log.Error(err, "error updating canary status condition")
+	}
+	state.successiveFail = 0
+	state.errors = []timestampedError{}
+	state.rotationPending = false
+
+	// Check if canary route rotations are enabled every iteration.
+	rotationEnabled := r.isCanaryRouteRotationEnabled()
+	// Increment checkCount and periodically rotate the canary route endpoint if canary route rotation is enabled.
+	if rotationEnabled {
+		state.checkCount++
+		if state.checkCount >= r.canaryRouteRotationCheckCount() {
+			haveService, service, err := r.currentCanaryService()
+			if err != nil {
+				log.Error(err, "failed to get canary service")
+				return
+			} else if !haveService {
+				log.Info("canary check service does not exist")
+				return
+			}
+			_, err = r.rotateRouteEndpoint(service, route)
+			state.lastRotation = &canaryRouteRotation{timestamp: time.Now(), err: err}
+			SetCanaryRouteRotationMetrics(state.lastRotation.timestamp, err == nil)
+			if err != nil {
+				log.Error(err, "failed to rotate canary route endpoint")
+				return
+			}
+			state.checkCount = 0
+			state.rotationPending = true
+		}
+	}
 }
 
-func (r *reconciler) setCanaryFailingStatusCondition(errors []timestampedError) error {
+// setCanaryFailingStatusCondition sets the canary status condition to
+// indicate that canary checks through the given path are failing.  If
+// rotationPending is true, then the checks started failing after the canary
+// route was rotated, and the condition indicates that the router is not
+// applying the rotated route.  If the most recent check failed because only
+// large responses failed, the condition indicates a path MTU or fragmentation
+// problem instead.
+func (r *reconciler) setCanaryFailingStatusCondition(errors []timestampedError, rotationPending bool, path canaryProbePath) error {
 	errorStrings := deduplicateErrorStrings(errors, time.Now())
 	if len(errorStrings) > canaryFailingNumErrors {
 		errorStrings = errorStrings[len(errorStrings)-canaryFailingNumErrors:]
@@ -370,8 +603,23 @@ func (r *reconciler) setCanaryFailingStatusCondition(errors []timestampedError)
 		Reason:  "CanaryChecksRepetitiveFailures",
 		Message: fmt.Sprintf("Canary route checks for the default ingress controller are failing. Last %d error messages:\n%s", len(errorStrings), strings.Join(errorStrings, "\n")),
 	}
+	if rotationPending {
+		cond.Reason = canaryRouteRotationStuckReason
+		cond.Message = fmt.Sprintf("Canary route checks for the default ingress controller are failing since the canary route was rotated, which indicates that the router is not applying configuration changes. Last %d error messages:\n%s", len(errorStrings), strings.Join(errorStrings, "\n"))
+	}
+	if largeResponseFailure(errors) {
+		cond.Reason = canaryLargeResponseFailureReason
+		cond.Message = fmt.Sprintf("Canary route checks for the default ingress controller are failing for large responses only, which indicates a path MTU or fragmentation problem between clients and the router. Last %d error messages:\n%s", len(errorStrings), strings.Join(errorStrings, "\n"))
+	}
+	if path.external() {
+		cond.Message = fmt.Sprintf("%s\nThe checks were performed through %s.", cond.Message, path)
+	}
+	conditions := []operatorv1.OperatorCondition{cond}
+	if externalCond, ok := externalEndpointCondition(path, errorStrings); ok {
+		conditions = append(conditions, externalCond)
+	}
 
-	return r.setCanaryStatusCondition(cond)
+	return r.setCanaryStatusCondition(conditions...)
 }
 
 type dedupCounter struct {
@@ -418,15 +666,33 @@ func deduplicateErrorStrings(errors []timestampedError, now time.Time) []string
 	return ret
 }
 
-func (r *reconciler) setCanaryPassingStatusCondition() error {
+// setCanaryPassingStatusCondition sets the canary status condition to
+// indicate that canary checks through the given path are passing.  If
+// lastRotation is non-nil, the condition message includes the time and result
+// of the last canary route rotation.
+func (r *reconciler) setCanaryPassingStatusCondition(lastRotation *canaryRouteRotation, path canaryProbePath) error {
 	cond := operatorv1.OperatorCondition{
 		Type:    ingresscontroller.IngressControllerCanaryCheckSuccessConditionType,
 		Status:  operatorv1.ConditionTrue,
 		Reason:  "CanaryChecksSucceeding",
 		Message: "Canary route checks for the default ingress controller are successful",
 	}
+	if path.external() {
+		cond.Message = fmt.Sprintf("%s through %s", cond.Message, path)
+	}
+	switch {
+	case lastRotation == nil:
+	case lastRotation.err != nil:
+		cond.Message = fmt.Sprintf("%s; the last canary route rotation failed at %s: %v", cond.Message, lastRotation.timestamp.UTC().Format(time.RFC3339), lastRotation.err)
+	default:
+		cond.Message = fmt.Sprintf("%s; the last canary route rotation succeeded at %s", cond.Message, lastRotation.timestamp.UTC().Format(time.RFC3339))
+	}
+	conditions := []operatorv1.OperatorCondition{cond}
+	if externalCond, ok := externalEndpointCondition(path, nil); ok {
+		conditions = append(conditions, externalCond)
+	}
 
-	return r.setCanaryStatusCondition(cond)
+	return r.setCanaryStatusCondition(conditions...)
 }
 
 func (r *reconciler) setCanaryNotAdmittedStatusCondition() error {
@@ -451,10 +717,10 @@ func (r *reconciler) setCanaryDoesNotExistStatusCondition() error {
 	return r.setCanaryStatusCondition(cond)
 }
 
-// setCanaryStatusCondition applies the given condition to the default ingress controller.
-// The assumption here is that cond is a condition that does not overlap with any of the status
+// setCanaryStatusCondition applies the given conditions to the default ingress controller.
+// The assumption here is that conds are conditions that do not overlap with any of the status
 // conditions set by the ingress controller in pkg/operator/controller/ingress/status.go.
-func (r *reconciler) setCanaryStatusCondition(cond operatorv1.OperatorCondition) error {
+func (r *reconciler) setCanaryStatusCondition(conds ...operatorv1.OperatorCondition) error {
 	ic := &operatorv1.IngressController{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      manifests.DefaultIngressControllerName,
@@ -466,7 +732,7 @@ func (r *reconciler) setCanaryStatusCondition(cond operatorv1.OperatorCondition)
 	}
 
 	updated := ic.DeepCopy()
-	updated.Status.Conditions = ingresscontroller.MergeConditions(updated.Status.Conditions, cond)
+	updated.Status.Conditions = ingresscontroller.MergeConditions(updated.Status.Conditions, conds...)
 
 	if !ingresscontroller.IngressStatusesEqual(updated.Status, ic.Status) {
 		if err := r.client.Status().Update(context.TODO(), updated); err != nil {
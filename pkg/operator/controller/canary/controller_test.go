@@ -1,6 +1,7 @@
 package canary
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -8,10 +9,20 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_cycleServicePort(t *testing.T) {
@@ -236,3 +247,286 @@ func Test_deduplicateErrorStrings(t *testing.T) {
 		})
 	}
 }
+
+// Test_checkCanaryRoute verifies that checkCanaryRoute sets the expected
+// canary status condition after a series of canary checks, including when the
+// router does not apply a rotated canary route.
+func Test_checkCanaryRoute(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	port1 := intstr.FromInt32(8080)
+	port2 := intstr.FromInt32(8888)
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorNamespace,
+			Name:      manifests.DefaultIngressControllerName,
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: controller.CanaryServiceName().Namespace,
+			Name:      controller.CanaryServiceName().Name,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "8080-tcp", Port: 8080, TargetPort: port1},
+				{Name: "8888-tcp", Port: 8888, TargetPort: port2},
+			},
+		},
+	}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: controller.CanaryRouteName().Namespace,
+			Name:      controller.CanaryRouteName().Name,
+		},
+		Spec: routev1.RouteSpec{
+			Port: &routev1.RoutePort{TargetPort: port1},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{{
+				Host:       "canary-openshift-ingress-canary.apps.example.com",
+				RouterName: manifests.DefaultIngressControllerName,
+				Conditions: []routev1.RouteIngressCondition{{
+					Type:   routev1.RouteAdmitted,
+					Status: corev1.ConditionTrue,
+				}},
+			}},
+		},
+	}
+	// alwaysPass simulates a router that applies every route update.
+	alwaysPass := func(*routev1.Route, string, int) (string, error) { return "", nil }
+	// alwaysFail simulates a router that is not serving the canary route.
+	alwaysFail := func(*routev1.Route, string, int) (string, error) {
+		return "", fmt.Errorf("status code 503: Canary route not available via router")
+	}
+	// ignoreRotation simulates a router that does not apply changes to the
+	// canary route and thus keeps sending requests to the original port.
+	ignoreRotation := func(r *routev1.Route, _ string, _ int) (string, error) {
+		if r.Spec.Port.TargetPort != port1 {
+			return "", fmt.Errorf("canary request received on port %s, but route specifies %s", port1.String(), r.Spec.Port.TargetPort.String())
+		}
+		return "", nil
+	}
+	// delayRotation simulates a router that has not reloaded since the
+	// canary route was rotated and thus still sends requests to the
+	// original port, which the canary pod reports.
+	delayRotation := func(r *routev1.Route, _ string, _ int) (string, error) {
+		if r.Spec.Port.TargetPort != port1 {
+			return "", &canaryPortMismatchError{received: port1.String(), expected: r.Spec.Port.TargetPort.String()}
+		}
+		return "", nil
+	}
+	testCases := []struct {
+		name             string
+		rotationEnabled  bool
+		probe            func(*routev1.Route, string, int) (string, error)
+		checks           int
+		expectRotated    bool
+		expectStatus     operatorv1.ConditionStatus
+		expectReason     string
+		expectMessageHas string
+	}{
+		{
+			name:         "checks pass, rotation disabled",
+			probe:        alwaysPass,
+			checks:       canaryCheckFailureCount + 1,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "CanaryChecksSucceeding",
+		},
+		{
+			name:             "checks pass, rotation enabled",
+			rotationEnabled:  true,
+			probe:            alwaysPass,
+			checks:           3,
+			expectRotated:    true,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "CanaryChecksSucceeding",
+			expectMessageHas: "the last canary route rotation succeeded at",
+		},
+		{
+			name:            "checks fail before rotation",
+			rotationEnabled: true,
+			probe:           alwaysFail,
+			checks:          canaryCheckFailureCount,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "CanaryChecksRepetitiveFailures",
+		},
+		{
+			name:            "router ignores rotated route",
+			rotationEnabled: true,
+			probe:           ignoreRotation,
+			checks:          1 + canaryCheckFailureCount,
+			expectRotated:   true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    canaryRouteRotationStuckReason,
+		},
+		{
+			name:            "router has not reloaded since rotation",
+			rotationEnabled: true,
+			probe:           delayRotation,
+			checks:          1 + canaryCheckFailureCount,
+			expectRotated:   true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "CanaryChecksSucceeding",
+		},
+		{
+			name:            "router ignores rotated route, not enough failures",
+			rotationEnabled: true,
+			probe:           ignoreRotation,
+			checks:          canaryCheckFailureCount,
+			expectRotated:   true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "CanaryChecksSucceeding",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	routev1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic.DeepCopy(), service.DeepCopy(), route.DeepCopy()).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config:                      Config{Namespace: operatorNamespace},
+				client:                      client,
+				enableCanaryRouteRotation:   tc.rotationEnabled,
+				canaryRouteRotationInterval: canaryCheckFrequency,
+			}
+			state := &canaryCheckState{}
+			for i := 0; i < tc.checks; i++ {
+				r.checkCanaryRoute(state, tc.probe)
+			}
+
+			currentRoute := &routev1.Route{}
+			if err := client.Get(context.Background(), controller.CanaryRouteName(), currentRoute); err != nil {
+				t.Fatalf("failed to get canary route: %v", err)
+			}
+			if rotated := currentRoute.Spec.Port.TargetPort != port1; rotated != tc.expectRotated {
+				t.Errorf("expected rotated to be %t, got %t (port %s)", tc.expectRotated, rotated, currentRoute.Spec.Port.TargetPort.String())
+			}
+
+			currentIC := &operatorv1.IngressController{}
+			if err := client.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, currentIC); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			var cond *operatorv1.OperatorCondition
+			for i := range currentIC.Status.Conditions {
+				if currentIC.Status.Conditions[i].Type == ingresscontroller.IngressControllerCanaryCheckSuccessConditionType {
+					cond = &currentIC.Status.Conditions[i]
+				}
+			}
+			if cond == nil {
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			}
+			if cond.Status != tc.expectStatus || cond.Reason != tc.expectReason {
+				t.Errorf("expected condition with status %s and reason %s, got %+v", tc.expectStatus, tc.expectReason, *cond)
+			}
+			if !strings.Contains(cond.Message, tc.expectMessageHas) {
+				t.Errorf("expected condition message to contain %q, got %q", tc.expectMessageHas, cond.Message)
+			}
+		})
+	}
+}
+
+func Test_canaryRouteRotationInterval(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expect      time.Duration
+	}{
+		{
+			name:   "no annotation",
+			expect: defaultCanaryRouteRotationInterval,
+		},
+		{
+			name:        "valid interval",
+			annotations: map[string]string{CanaryRouteRotationIntervalAnnotation: "10m"},
+			expect:      10 * time.Minute,
+		},
+		{
+			name:        "invalid interval",
+			annotations: map[string]string{CanaryRouteRotationIntervalAnnotation: "often"},
+			expect:      defaultCanaryRouteRotationInterval,
+		},
+		{
+			name:        "interval less than check frequency",
+			annotations: map[string]string{CanaryRouteRotationIntervalAnnotation: "10s"},
+			expect:      defaultCanaryRouteRotationInterval,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+			}
+			if actual := canaryRouteRotationInterval(ic); actual != tc.expect {
+				t.Errorf("expected %v, got %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_awaitingRotation verifies that awaitingRotation only tolerates failed
+// canary checks that report the previous target port within the router's
+// reload interval plus canaryRotationReloadGracePeriod after a rotation.
+func Test_awaitingRotation(t *testing.T) {
+	rotated := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mismatch := canarySample{endpoint: "ingress-canary-a", err: &canaryPortMismatchError{received: "8080", expected: "8888"}}
+	unavailable := canarySample{err: fmt.Errorf("status code 503: Canary route not available via router")}
+	testCases := []struct {
+		name           string
+		state          canaryCheckState
+		samples        []canarySample
+		reloadInterval time.Duration
+		sinceRotation  time.Duration
+		expect         bool
+	}{
+		{
+			name:           "no rotation pending",
+			state:          canaryCheckState{lastRotation: &canaryRouteRotation{timestamp: rotated}},
+			samples:        []canarySample{mismatch},
+			reloadInterval: 5 * time.Second,
+			sinceRotation:  time.Second,
+		},
+		{
+			name:           "default reload interval, next check",
+			state:          canaryCheckState{rotationPending: true, lastRotation: &canaryRouteRotation{timestamp: rotated}},
+			samples:        []canarySample{mismatch},
+			reloadInterval: 5 * time.Second,
+			sinceRotation:  canaryCheckFrequency,
+		},
+		{
+			name:           "maximum reload interval, next check",
+			state:          canaryCheckState{rotationPending: true, lastRotation: &canaryRouteRotation{timestamp: rotated}},
+			samples:        []canarySample{mismatch, mismatch},
+			reloadInterval: 120 * time.Second,
+			sinceRotation:  2 * canaryCheckFrequency,
+			expect:         true,
+		},
+		{
+			name:           "maximum reload interval, grace period elapsed",
+			state:          canaryCheckState{rotationPending: true, lastRotation: &canaryRouteRotation{timestamp: rotated}},
+			samples:        []canarySample{mismatch},
+			reloadInterval: 120 * time.Second,
+			sinceRotation:  3 * canaryCheckFrequency,
+		},
+		{
+			name:           "other failure",
+			state:          canaryCheckState{rotationPending: true, lastRotation: &canaryRouteRotation{timestamp: rotated}},
+			samples:        []canarySample{mismatch, unavailable},
+			reloadInterval: 120 * time.Second,
+			sinceRotation:  canaryCheckFrequency,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := awaitingRotation(&tc.state, tc.samples, tc.reloadInterval, rotated.Add(tc.sinceRotation)); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
@@ -101,7 +101,7 @@ func desiredCanaryDaemonSet(canaryImage string) *appsv1.DaemonSet {
 }
 
 // canaryDaemonSetChanged returns true if current and expected differ by the pod template's
-// node selector, tolerations, or container image reference.
+// node selector, tolerations, service account, or container image reference.
 func canaryDaemonSetChanged(current, expected *appsv1.DaemonSet) (bool, *appsv1.DaemonSet) {
 	changed := false
 	updated := current.DeepCopy()
@@ -153,6 +153,11 @@ func canaryDaemonSetChanged(current, expected *appsv1.DaemonSet) (bool, *appsv1.
 		changed = true
 	}
 
+	if current.Spec.Template.Spec.ServiceAccountName != expected.Spec.Template.Spec.ServiceAccountName {
+		updated.Spec.Template.Spec.ServiceAccountName = expected.Spec.Template.Spec.ServiceAccountName
+		changed = true
+	}
+
 	if current.Spec.Template.Spec.PriorityClassName != expected.Spec.Template.Spec.PriorityClassName {
 		updated.Spec.Template.Spec.PriorityClassName = expected.Spec.Template.Spec.PriorityClassName
 		changed = true
@@ -73,6 +73,12 @@ func Test_desiredCanaryDaemonSet(t *testing.T) {
 		t.Errorf("expected daemonset priority class to be %q, but got %q", expectedPriorityClass, priorityClass)
 	}
 
+	serviceAccountName := daemonset.Spec.Template.Spec.ServiceAccountName
+	expectedServiceAccountName := "ingress-canary"
+	if serviceAccountName != expectedServiceAccountName {
+		t.Errorf("expected daemonset service account to be %q, but got %q", expectedServiceAccountName, serviceAccountName)
+	}
+
 	tolerations := daemonset.Spec.Template.Spec.Tolerations
 	expectedTolerations := []corev1.Toleration{
 		{
@@ -162,6 +168,13 @@ func Test_canaryDaemonsetChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if canary daemonset service account changed",
+			mutate: func(ds *appsv1.DaemonSet) {
+				ds.Spec.Template.Spec.ServiceAccountName = "default"
+			},
+			expect: true,
+		},
 		{
 			description: "if canary daemonset pod security context changed",
 			mutate: func(ds *appsv1.DaemonSet) {
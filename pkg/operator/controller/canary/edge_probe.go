@@ -0,0 +1,377 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// canaryEdgeProbeName is the value of the canary daemonset label on the canary
+// edge probe pods, which distinguishes them from the canary pods.
+const canaryEdgeProbeName = "canary_edge_probe"
+
+// edgeProbeForIngressController returns the canary edge probe that the given
+// ingresscontroller specifies, or nil if it specifies none.  An error is
+// returned if the probe is invalid.
+func edgeProbeForIngressController(ic *operatorv1.IngressController) (*ingresscontroller.CanaryEdgeProbe, error) {
+	probe, err := ingresscontroller.CanaryEdgeProbeForIngressController(ic)
+	if err != nil || probe == nil {
+		return nil, err
+	}
+	if err := ingresscontroller.ValidateCanaryEdgeProbe(probe); err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// setEdgeProbe records the canary edge probe whose results the canary check
+// loop should report, or the error from determining it.
+func (r *reconciler) setEdgeProbe(probe *ingresscontroller.CanaryEdgeProbe, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.edgeProbe = probe
+	r.edgeProbeErr = err
+}
+
+// currentEdgeProbe returns the canary edge probe whose results the canary
+// check loop should report, or the error from determining it.
+func (r *reconciler) currentEdgeProbe() (*ingresscontroller.CanaryEdgeProbe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.edgeProbe, r.edgeProbeErr
+}
+
+// ensureCanaryEdgeProbe ensures that the canary edge probe daemonset, which
+// probes the given host, and the service account and role binding that its
+// pods need exist if the given probe is not nil, and that the daemonset does
+// not exist otherwise.  The service account and role binding are left in place
+// when the probe is disabled because they grant nothing without the daemonset.
+func (r *reconciler) ensureCanaryEdgeProbe(probe *ingresscontroller.CanaryEdgeProbe, host string) error {
+	if probe == nil {
+		return r.deleteCanaryEdgeProbeDaemonSet()
+	}
+	if err := r.ensureCanaryEdgeProbeServiceAccount(); err != nil {
+		return err
+	}
+	if err := r.ensureCanaryEdgeProbeRoleBinding(); err != nil {
+		return err
+	}
+
+	desired := desiredCanaryEdgeProbeDaemonSet(r.config.CanaryImage, probe, host)
+	current := &appsv1.DaemonSet{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get canary edge probe daemonset %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create canary edge probe daemonset %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		log.Info("created canary edge probe daemonset", "namespace", desired.Namespace, "name", desired.Name)
+		return nil
+	}
+	changed, updated := canaryEdgeProbeDaemonSetChanged(current, desired)
+	if !changed {
+		return nil
+	}
+	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update canary edge probe daemonset %s/%s: %w", updated.Namespace, updated.Name, err)
+	}
+	log.Info("updated canary edge probe daemonset", "namespace", updated.Namespace, "name", updated.Name, "diff", diff)
+	return nil
+}
+
+// deleteCanaryEdgeProbeDaemonSet deletes the canary edge probe daemonset if it
+// exists.
+func (r *reconciler) deleteCanaryEdgeProbeDaemonSet() error {
+	name := controller.CanaryEdgeProbeDaemonSetName()
+	daemonset := &appsv1.DaemonSet{}
+	if err := r.client.Get(context.TODO(), name, daemonset); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get canary edge probe daemonset %s: %w", name, err)
+	}
+	if err := r.client.Delete(context.TODO(), daemonset); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete canary edge probe daemonset %s: %w", name, err)
+	}
+	log.Info("deleted canary edge probe daemonset", "namespace", name.Namespace, "name", name.Name)
+	return nil
+}
+
+// ensureCanaryEdgeProbeServiceAccount ensures that the service account that
+// the canary edge probe pods use exists.
+func (r *reconciler) ensureCanaryEdgeProbeServiceAccount() error {
+	desired := manifests.CanaryEdgeProbeServiceAccount()
+	name := controller.CanaryEdgeProbeServiceAccountName()
+	desired.Name = name.Name
+	desired.Namespace = name.Namespace
+	if err := r.client.Get(context.TODO(), name, &corev1.ServiceAccount{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get canary edge probe service account %s: %w", name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create canary edge probe service account %s: %w", name, err)
+		}
+		log.Info("created canary edge probe service account", "namespace", name.Namespace, "name", name.Name)
+	}
+	return nil
+}
+
+// ensureCanaryEdgeProbeRoleBinding ensures that the role binding that grants
+// the canary edge probe service account the use of the hostnetwork SCC exists
+// and has the expected subjects.
+func (r *reconciler) ensureCanaryEdgeProbeRoleBinding() error {
+	desired := manifests.CanaryEdgeProbeRoleBinding()
+	name := controller.CanaryEdgeProbeServiceAccountName()
+	desired.Name = name.Name
+	desired.Namespace = name.Namespace
+	current := &rbacv1.RoleBinding{}
+	if err := r.client.Get(context.TODO(), name, current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get canary edge probe role binding %s: %w", name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create canary edge probe role binding %s: %w", name, err)
+		}
+		log.Info("created canary edge probe role binding", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	// The role reference of a binding is immutable, and the operator only
+	// ever sets one role reference, so only the subjects are updated.
+	if cmp.Equal(current.Subjects, desired.Subjects, cmpopts.EquateEmpty()) {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Subjects = desired.Subjects
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update canary edge probe role binding %s: %w", name, err)
+	}
+	log.Info("updated canary edge probe role binding", "namespace", name.Namespace, "name", name.Name, "diff", cmp.Diff(current.Subjects, updated.Subjects))
+	return nil
+}
+
+// desiredCanaryEdgeProbeDaemonSet returns the desired canary edge probe
+// daemonset, which runs the given probe against the given host using the given
+// image.  Each pod probes the host once per interval and reports the outcome
+// of its most recent probe through its readiness.
+func desiredCanaryEdgeProbeDaemonSet(canaryImage string, probe *ingresscontroller.CanaryEdgeProbe, host string) *appsv1.DaemonSet {
+	daemonset := manifests.CanaryEdgeProbeDaemonSet()
+	name := controller.CanaryEdgeProbeDaemonSetName()
+	daemonset.Name = name.Name
+	daemonset.Namespace = name.Namespace
+
+	daemonset.Labels = map[string]string{
+		// associate the daemonset with the ingress canary controller
+		manifests.OwningIngressCanaryCheckLabel: canaryControllerName,
+	}
+
+	daemonset.Spec.Selector = controller.CanaryDaemonSetPodSelector(canaryEdgeProbeName)
+	daemonset.Spec.Template.Labels = controller.CanaryDaemonSetPodSelector(canaryEdgeProbeName).MatchLabels
+
+	podSpec := &daemonset.Spec.Template.Spec
+	podSpec.NodeSelector = probe.NodeSelector
+	podSpec.Tolerations = probe.Tolerations
+
+	container := &podSpec.Containers[0]
+	container.Image = canaryImage
+	container.Command = []string{"ingress-operator", CanaryEdgeProbeCommand}
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "CANARY_HOST", Value: host},
+		corev1.EnvVar{Name: "PROBE_INTERVAL", Value: probe.Interval},
+		corev1.EnvVar{Name: "PROBE_TIMEOUT", Value: probe.Timeout},
+	)
+	container.ReadinessProbe.Exec.Command = []string{"ingress-operator", CanaryEdgeProbeCommand, "--check"}
+	container.ReadinessProbe.PeriodSeconds = int32(probe.IntervalDuration() / time.Second)
+
+	return daemonset
+}
+
+// canaryEdgeProbeDaemonSetChanged returns true if current and expected differ
+// in any of the fields that canaryDaemonSetChanged compares or in the pod's use
+// of the host network, DNS policy, or the container's readiness probe.
+func canaryEdgeProbeDaemonSetChanged(current, expected *appsv1.DaemonSet) (bool, *appsv1.DaemonSet) {
+	changed, updated := canaryDaemonSetChanged(current, expected)
+	if !changed {
+		updated = current.DeepCopy()
+	}
+
+	podSpec, expectedPodSpec := &updated.Spec.Template.Spec, &expected.Spec.Template.Spec
+	if podSpec.HostNetwork != expectedPodSpec.HostNetwork {
+		podSpec.HostNetwork = expectedPodSpec.HostNetwork
+		changed = true
+	}
+	if podSpec.DNSPolicy != expectedPodSpec.DNSPolicy {
+		podSpec.DNSPolicy = expectedPodSpec.DNSPolicy
+		changed = true
+	}
+	if len(podSpec.Containers) > 0 && len(expectedPodSpec.Containers) > 0 {
+		if !equality.Semantic.DeepEqual(podSpec.Containers[0].ReadinessProbe, expectedPodSpec.Containers[0].ReadinessProbe) {
+			podSpec.Containers[0].ReadinessProbe = expectedPodSpec.Containers[0].ReadinessProbe
+			changed = true
+		}
+	}
+
+	if !changed {
+		return false, nil
+	}
+	return true, updated
+}
+
+// edgeProbeResult is the outcome of the most recent probe of a canary edge
+// probe pod.
+type edgeProbeResult struct {
+	// node is the name of the node on which the pod runs.
+	node string
+	// reported is true if the pod has reported the outcome of a probe.
+	reported bool
+	// reachable is true if the pod's most recent probe succeeded.
+	reachable bool
+}
+
+// currentEdgeProbeResults returns the outcomes of the most recent probes of
+// the canary edge probe pods, which report them through their readiness.
+func (r *reconciler) currentEdgeProbeResults(probe *ingresscontroller.CanaryEdgeProbe) ([]edgeProbeResult, error) {
+	pods := &corev1.PodList{}
+	name := controller.CanaryEdgeProbeDaemonSetName()
+	if err := r.client.List(context.TODO(), pods, client.InNamespace(name.Namespace), client.MatchingLabels(controller.CanaryDaemonSetPodSelector(canaryEdgeProbeName).MatchLabels)); err != nil {
+		return nil, fmt.Errorf("failed to list canary edge probe pods: %w", err)
+	}
+	return edgeProbeResultsForPods(pods.Items, 2*probe.IntervalDuration(), time.Now()), nil
+}
+
+// edgeProbeResultsForPods returns the outcomes of the most recent probes of the
+// given canary edge probe pods.  A pod whose container has been running for
+// less than the given grace period and is not ready may not have completed a
+// probe yet, so its outcome is not reported.
+func edgeProbeResultsForPods(pods []corev1.Pod, grace time.Duration, now time.Time) []edgeProbeResult {
+	var results []edgeProbeResult
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		result := edgeProbeResult{node: pod.Spec.NodeName}
+		if pod.Status.Phase == corev1.PodRunning && len(pod.Status.ContainerStatuses) != 0 {
+			status := pod.Status.ContainerStatuses[0]
+			switch {
+			case status.Ready:
+				result.reported = true
+				result.reachable = true
+			case status.State.Running != nil && now.Sub(status.State.Running.StartedAt.Time) >= grace:
+				result.reported = true
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkEdgeProbe updates the ExternalReachability status condition using the
+// outcomes of the canary edge probes that the given function returns, if a
+// canary edge probe is configured.  The condition is removed if no canary edge
+// probe is configured.
+func (r *reconciler) checkEdgeProbe(resultsFn func(*ingresscontroller.CanaryEdgeProbe) ([]edgeProbeResult, error)) {
+	probe, err := r.currentEdgeProbe()
+	switch {
+	case err != nil:
+		if err := r.setExternalReachabilityStatusCondition(operatorv1.ConditionFalse, "InvalidEdgeProbe", fmt.Sprintf("The canary edge probe is invalid and is not performed: %v", err)); err != nil {
+			log.Error(err, "error updating external reachability status condition")
+		}
+		return
+	case probe == nil:
+		if err := r.removeCanaryStatusCondition(ingresscontroller.IngressControllerExternalReachabilityConditionType); err != nil {
+			log.Error(err, "error removing external reachability status condition")
+		}
+		return
+	}
+
+	results, err := resultsFn(probe)
+	if err != nil {
+		log.Error(err, "failed to get canary edge probe results")
+		return
+	}
+	cond := computeExternalReachabilityCondition(probe, results)
+	if err := r.setCanaryStatusCondition(cond); err != nil {
+		log.Error(err, "error updating external reachability status condition")
+	}
+}
+
+// computeExternalReachabilityCondition computes the ExternalReachability
+// status condition from the outcomes of the canary edge probes.  The condition
+// is true if every probe that has reported an outcome reached the canary
+// route, false if any probe failed to reach it, and unknown if no node runs the
+// probe or no probe has reported an outcome yet.
+func computeExternalReachabilityCondition(probe *ingresscontroller.CanaryEdgeProbe, results []edgeProbeResult) operatorv1.OperatorCondition {
+	cond := operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerExternalReachabilityConditionType,
+	}
+	if len(results) == 0 {
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "NoProbeNodes"
+		cond.Message = fmt.Sprintf("No nodes run the canary edge probe.  Label nodes to match the node selector %q.", labels.SelectorFromSet(probe.NodeSelector).String())
+		return cond
+	}
+	var reachable, unreachable []string
+	for _, result := range results {
+		switch {
+		case !result.reported:
+		case result.reachable:
+			reachable = append(reachable, result.node)
+		default:
+			unreachable = append(unreachable, result.node)
+		}
+	}
+	sort.Strings(reachable)
+	sort.Strings(unreachable)
+	switch {
+	case len(unreachable) != 0 && len(reachable) == 0:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "Unreachable"
+		cond.Message = fmt.Sprintf("The canary route is unreachable from all edge probe nodes: %s.  Check the load balancer, security groups, and firewalls between the nodes and the load balancer.", strings.Join(unreachable, ", "))
+	case len(unreachable) != 0:
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "PartiallyUnreachable"
+		cond.Message = fmt.Sprintf("The canary route is unreachable from %d of %d edge probe nodes: %s.", len(unreachable), len(reachable)+len(unreachable), strings.Join(unreachable, ", "))
+	case len(reachable) != 0:
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "Reachable"
+		cond.Message = fmt.Sprintf("The canary route is reachable from all %d edge probe nodes.", len(reachable))
+	default:
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "ProbesPending"
+		cond.Message = "The canary edge probes have not reported any results yet."
+	}
+	return cond
+}
+
+// setExternalReachabilityStatusCondition sets the ExternalReachability status
+// condition on the default ingress controller.
+func (r *reconciler) setExternalReachabilityStatusCondition(status operatorv1.ConditionStatus, reason, message string) error {
+	return r.setCanaryStatusCondition(operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerExternalReachabilityConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
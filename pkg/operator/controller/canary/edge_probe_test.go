@@ -0,0 +1,298 @@
+package canary
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_checkEdgeProbe verifies that checkEdgeProbe sets the
+// ExternalReachability condition according to the results of the canary edge
+// probes, and removes the condition when no edge probe is configured.
+func Test_checkEdgeProbe(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	probe := &ingresscontroller.CanaryEdgeProbe{
+		NodeSelector: map[string]string{ingresscontroller.CanaryEdgeProbeNodeLabel: ""},
+		Interval:     "1m",
+		Timeout:      "10s",
+	}
+	reachable := func(node string) edgeProbeResult {
+		return edgeProbeResult{node: node, reported: true, reachable: true}
+	}
+	unreachable := func(node string) edgeProbeResult {
+		return edgeProbeResult{node: node, reported: true}
+	}
+	pending := func(node string) edgeProbeResult {
+		return edgeProbeResult{node: node}
+	}
+	testCases := []struct {
+		name            string
+		probe           *ingresscontroller.CanaryEdgeProbe
+		probeErr        error
+		results         []edgeProbeResult
+		resultsErr      error
+		expectCondition bool
+		expectStatus    operatorv1.ConditionStatus
+		expectReason    string
+	}{
+		{
+			name:            "reachable",
+			probe:           probe,
+			results:         []edgeProbeResult{reachable("edge-a"), reachable("edge-b"), pending("edge-c")},
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "Reachable",
+		},
+		{
+			name:            "unreachable",
+			probe:           probe,
+			results:         []edgeProbeResult{unreachable("edge-a"), unreachable("edge-b")},
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "Unreachable",
+		},
+		{
+			name:            "unreachable from some nodes",
+			probe:           probe,
+			results:         []edgeProbeResult{reachable("edge-a"), unreachable("edge-b")},
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "PartiallyUnreachable",
+		},
+		{
+			name:            "no probe nodes",
+			probe:           probe,
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionUnknown,
+			expectReason:    "NoProbeNodes",
+		},
+		{
+			name:            "probes pending",
+			probe:           probe,
+			results:         []edgeProbeResult{pending("edge-a")},
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionUnknown,
+			expectReason:    "ProbesPending",
+		},
+		{
+			name:            "invalid probe",
+			probeErr:        errors.New("timeout must be shorter than the interval"),
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "InvalidEdgeProbe",
+		},
+		{
+			name:            "results unavailable",
+			probe:           probe,
+			resultsErr:      errors.New("connection refused"),
+			expectCondition: false,
+		},
+		{
+			name:            "no probe",
+			expectCondition: false,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      manifests.DefaultIngressControllerName,
+				},
+				Status: operatorv1.IngressControllerStatus{
+					Conditions: []operatorv1.OperatorCondition{{
+						Type:   ingresscontroller.IngressControllerCanaryCheckSuccessConditionType,
+						Status: operatorv1.ConditionTrue,
+					}},
+				},
+			}
+			if tc.probe == nil && tc.probeErr == nil {
+				// Verify that a stale condition is removed.
+				ic.Status.Conditions = append(ic.Status.Conditions, operatorv1.OperatorCondition{
+					Type:   ingresscontroller.IngressControllerExternalReachabilityConditionType,
+					Status: operatorv1.ConditionTrue,
+				})
+			}
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config: Config{Namespace: operatorNamespace},
+				client: client,
+			}
+			r.setEdgeProbe(tc.probe, tc.probeErr)
+
+			// Feed the results through a channel as the probe pods
+			// would report them.
+			results := make(chan []edgeProbeResult, 1)
+			results <- tc.results
+			r.checkEdgeProbe(func(*ingresscontroller.CanaryEdgeProbe) ([]edgeProbeResult, error) {
+				if tc.resultsErr != nil {
+					return nil, tc.resultsErr
+				}
+				return <-results, nil
+			})
+
+			currentIC := &operatorv1.IngressController{}
+			if err := client.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, currentIC); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			var cond *operatorv1.OperatorCondition
+			for i := range currentIC.Status.Conditions {
+				if currentIC.Status.Conditions[i].Type == ingresscontroller.IngressControllerExternalReachabilityConditionType {
+					cond = &currentIC.Status.Conditions[i]
+				}
+			}
+			switch {
+			case cond == nil && tc.expectCondition:
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerExternalReachabilityConditionType)
+			case cond != nil && !tc.expectCondition:
+				t.Fatalf("expected no %s condition, got %+v", ingresscontroller.IngressControllerExternalReachabilityConditionType, *cond)
+			case cond != nil && (cond.Status != tc.expectStatus || cond.Reason != tc.expectReason):
+				t.Errorf("expected condition with status %s and reason %s, got %+v", tc.expectStatus, tc.expectReason, *cond)
+			}
+			if len(currentIC.Status.Conditions) == 0 || currentIC.Status.Conditions[0].Type != ingresscontroller.IngressControllerCanaryCheckSuccessConditionType {
+				t.Errorf("expected the canary check condition to be preserved, got %+v", currentIC.Status.Conditions)
+			}
+		})
+	}
+}
+
+// Test_edgeProbeResultsForPods verifies that the readiness of the canary edge
+// probe pods determines the reported results and that pods that have not had
+// time to complete a probe are not reported.
+func Test_edgeProbeResultsForPods(t *testing.T) {
+	now := time.Now()
+	pod := func(node string, phase corev1.PodPhase, ready bool, started time.Time) corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{
+				Phase: phase,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Ready: ready,
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(started)},
+					},
+				}},
+			},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("ready", corev1.PodRunning, true, now.Add(-time.Hour)),
+		pod("not-ready", corev1.PodRunning, false, now.Add(-time.Hour)),
+		pod("starting", corev1.PodRunning, false, now.Add(-time.Second)),
+		pod("pending", corev1.PodPending, false, now),
+		pod("", corev1.PodPending, false, now),
+	}
+	expected := []edgeProbeResult{
+		{node: "ready", reported: true, reachable: true},
+		{node: "not-ready", reported: true},
+		{node: "starting"},
+		{node: "pending"},
+	}
+	if actual := edgeProbeResultsForPods(pods, 2*time.Minute, now); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+// Test_ensureCanaryEdgeProbe verifies that ensureCanaryEdgeProbe creates the
+// canary edge probe daemonset with the configured placement and interval and
+// the resources that its pods need, updates the daemonset when the
+// configuration changes, and deletes it when the edge probe is disabled.
+func Test_ensureCanaryEdgeProbe(t *testing.T) {
+	const host = "canary-openshift-ingress-canary.apps.example.com"
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	rbacv1.AddToScheme(scheme)
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &reconciler{
+		config: Config{CanaryImage: "openshift/origin-cluster-ingress-operator:latest"},
+		client: client,
+	}
+
+	probe := &ingresscontroller.CanaryEdgeProbe{
+		NodeSelector: map[string]string{"node-role.kubernetes.io/edge": ""},
+		Tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/edge", Operator: corev1.TolerationOpExists}},
+		Interval:     "30s",
+		Timeout:      "5s",
+	}
+	if err := r.ensureCanaryEdgeProbe(probe, host); err != nil {
+		t.Fatalf("failed to ensure canary edge probe: %v", err)
+	}
+	daemonset := &appsv1.DaemonSet{}
+	if err := client.Get(context.Background(), controller.CanaryEdgeProbeDaemonSetName(), daemonset); err != nil {
+		t.Fatalf("failed to get canary edge probe daemonset: %v", err)
+	}
+	podSpec := daemonset.Spec.Template.Spec
+	if !podSpec.HostNetwork {
+		t.Error("expected the canary edge probe pods to use the host network")
+	}
+	if !reflect.DeepEqual(podSpec.NodeSelector, probe.NodeSelector) {
+		t.Errorf("expected node selector %v, got %v", probe.NodeSelector, podSpec.NodeSelector)
+	}
+	if !reflect.DeepEqual(podSpec.Tolerations, probe.Tolerations) {
+		t.Errorf("expected tolerations %v, got %v", probe.Tolerations, podSpec.Tolerations)
+	}
+	if podSpec.ServiceAccountName != controller.CanaryEdgeProbeServiceAccountName().Name {
+		t.Errorf("expected service account %s, got %s", controller.CanaryEdgeProbeServiceAccountName().Name, podSpec.ServiceAccountName)
+	}
+	env := map[string]string{}
+	for _, v := range podSpec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	if env["CANARY_HOST"] != host || env["PROBE_INTERVAL"] != "30s" || env["PROBE_TIMEOUT"] != "5s" {
+		t.Errorf("unexpected environment: %v", env)
+	}
+	if period := podSpec.Containers[0].ReadinessProbe.PeriodSeconds; period != 30 {
+		t.Errorf("expected readiness probe period of 30 seconds, got %d", period)
+	}
+	if err := client.Get(context.Background(), controller.CanaryEdgeProbeServiceAccountName(), &corev1.ServiceAccount{}); err != nil {
+		t.Errorf("failed to get canary edge probe service account: %v", err)
+	}
+	if err := client.Get(context.Background(), controller.CanaryEdgeProbeServiceAccountName(), &rbacv1.RoleBinding{}); err != nil {
+		t.Errorf("failed to get canary edge probe role binding: %v", err)
+	}
+
+	updatedProbe := *probe
+	updatedProbe.Interval = "2m"
+	if err := r.ensureCanaryEdgeProbe(&updatedProbe, host); err != nil {
+		t.Fatalf("failed to update canary edge probe: %v", err)
+	}
+	if err := client.Get(context.Background(), controller.CanaryEdgeProbeDaemonSetName(), daemonset); err != nil {
+		t.Fatalf("failed to get canary edge probe daemonset: %v", err)
+	}
+	if period := daemonset.Spec.Template.Spec.Containers[0].ReadinessProbe.PeriodSeconds; period != 120 {
+		t.Errorf("expected readiness probe period of 120 seconds, got %d", period)
+	}
+
+	if err := r.ensureCanaryEdgeProbe(nil, host); err != nil {
+		t.Fatalf("failed to disable canary edge probe: %v", err)
+	}
+	if err := client.Get(context.Background(), controller.CanaryEdgeProbeDaemonSetName(), daemonset); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the canary edge probe daemonset to be deleted, got %v", err)
+	}
+}
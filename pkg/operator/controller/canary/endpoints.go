@@ -0,0 +1,278 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// canaryCheckMinSamples is the minimum number of requests that a
+	// canary check sends to the canary route.
+	canaryCheckMinSamples = 3
+	// canaryCheckMaxSamples is the maximum number of requests that a
+	// canary check sends to the canary route.
+	canaryCheckMaxSamples = 20
+	// canaryCheckSamplingTimeout is how long a canary check keeps sending
+	// requests to the canary route.  Once the timeout has elapsed, the
+	// check evaluates the samples that it has so that slow requests do not
+	// delay the next check.
+	canaryCheckSamplingTimeout = canaryCheckFrequency / 2
+	// canaryPartialFailureCount is how many successive canary checks an
+	// endpoint must fail, while other endpoints succeed, before the
+	// CanaryPartialFailure status condition reports it.
+	canaryPartialFailureCount = 5
+)
+
+// canaryEndpoint is a canary pod that backs the canary route.
+type canaryEndpoint struct {
+	// pod is the name of the canary pod.
+	pod string
+	// node is the name of the node on which the canary pod runs.
+	node string
+}
+
+// canarySample is the outcome of a single request to the canary route.
+type canarySample struct {
+	// endpoint is the name of the canary pod that served the request, or
+	// empty if the response did not identify it, for example because the
+	// request failed before it reached a canary pod.
+	endpoint string
+	// err is the error from the request, or nil if it succeeded.
+	err error
+	// size is the response size, in bytes, that the request asked for,
+	// or 0 if it asked for the default response.
+	size int
+	// large is true if the request asked for a large response in a check
+	// that alternates between small and large responses.
+	large bool
+}
+
+// canaryEndpointResult counts the samples that a canary check attributed to an
+// endpoint.
+type canaryEndpointResult struct {
+	successes int
+	failures  int
+}
+
+// successRatio returns the ratio of the samples that succeeded.
+func (r canaryEndpointResult) successRatio() float64 {
+	if r.successes+r.failures == 0 {
+		return 0
+	}
+	return float64(r.successes) / float64(r.successes+r.failures)
+}
+
+// canaryCheckSampleCount returns how many requests a canary check should send
+// to the canary route given the number of canary endpoints.  The check sends
+// two requests per endpoint so that, with round-robin load balancing, each
+// endpoint is likely to serve a request in every check.
+func canaryCheckSampleCount(endpoints int) int {
+	count := 2 * endpoints
+	if count < canaryCheckMinSamples {
+		return canaryCheckMinSamples
+	}
+	if count > canaryCheckMaxSamples {
+		return canaryCheckMaxSamples
+	}
+	return count
+}
+
+// sampleCanaryRoute sends the given number of requests to the given route
+// through the given external address, if any, using the given probe function
+// and returns the samples.  Each request asks for the response size that the
+// given response sizes specify for it, which may be nil to ask for the default
+// response.  Sampling stops early once canaryCheckSamplingTimeout has elapsed.
+func sampleCanaryRoute(route *routev1.Route, address string, count int, responseSize *ingresscontroller.CanaryResponseSize, probe func(*routev1.Route, string, int) (string, error)) []canarySample {
+	deadline := time.Now().Add(canaryCheckSamplingTimeout)
+	samples := make([]canarySample, 0, count)
+	for i := 0; i < count; i++ {
+		if i != 0 && time.Now().After(deadline) {
+			log.Info("canary check sampling timed out", "samples", len(samples), "requested", count)
+			break
+		}
+		size, large := responseSize.SizeForSample(i)
+		endpoint, err := probe(route, address, size)
+		samples = append(samples, canarySample{endpoint: endpoint, err: err, size: size, large: large})
+	}
+	return samples
+}
+
+// canarySamplesError returns nil if any of the given samples succeeded, or else
+// the error from the last sample.  A canary check thus succeeds if the canary
+// route is reachable through any endpoint, as it did when the check sent a
+// single request.
+func canarySamplesError(samples []canarySample) error {
+	var err error
+	for _, sample := range samples {
+		if sample.err == nil {
+			return nil
+		}
+		err = sample.err
+	}
+	return err
+}
+
+// summarizeCanarySamples returns the results of the given samples for each of
+// the given endpoints and for any other endpoint to which a sample was
+// attributed, and the number of failed samples that could not be attributed to
+// an endpoint.
+func summarizeCanarySamples(samples []canarySample, endpoints []canaryEndpoint) (map[string]canaryEndpointResult, int) {
+	results := map[string]canaryEndpointResult{}
+	for _, endpoint := range endpoints {
+		results[endpoint.pod] = canaryEndpointResult{}
+	}
+	unattributed := 0
+	for _, sample := range samples {
+		if len(sample.endpoint) == 0 {
+			if sample.err != nil {
+				unattributed++
+			}
+			continue
+		}
+		result := results[sample.endpoint]
+		if sample.err == nil {
+			result.successes++
+		} else {
+			result.failures++
+		}
+		results[sample.endpoint] = result
+	}
+	return results, unattributed
+}
+
+// endpointFailed returns a Boolean value indicating whether a canary check
+// failed for an endpoint with the given result, given the number of failed
+// samples that could not be attributed to an endpoint.  The check failed for
+// the endpoint if no request that it served succeeded and either a request that
+// it served failed or it served no request while some requests failed without
+// reaching any endpoint, which is what happens if the router cannot connect to
+// the endpoint.
+func endpointFailed(result canaryEndpointResult, unattributed int) bool {
+	return result.successes == 0 && (result.failures != 0 || unattributed != 0)
+}
+
+// updateEndpointFailures updates the given canary check state's count of
+// successive failed checks for each endpoint with the given results and
+// returns the names of the endpoints that have failed at least
+// canaryPartialFailureCount successive checks while other endpoints succeeded,
+// in sorted order.  Endpoints that are not in the results are forgotten.
+func updateEndpointFailures(state *canaryCheckState, results map[string]canaryEndpointResult, unattributed int) []string {
+	failures := map[string]int{}
+	anySucceeded := false
+	for pod, result := range results {
+		if result.successes != 0 {
+			anySucceeded = true
+		}
+		if endpointFailed(result, unattributed) {
+			failures[pod] = state.endpointFailures[pod] + 1
+		}
+	}
+	state.endpointFailures = failures
+	if !anySucceeded {
+		// All endpoints are failing, which the CanaryChecksSucceeding
+		// status condition reports.
+		return nil
+	}
+	var failing []string
+	for pod, count := range failures {
+		if count >= canaryPartialFailureCount {
+			failing = append(failing, pod)
+		}
+	}
+	sort.Strings(failing)
+	return failing
+}
+
+// currentCanaryEndpoints returns the canary pods that are ready to serve the
+// canary route.
+func (r *reconciler) currentCanaryEndpoints() ([]canaryEndpoint, error) {
+	pods := &corev1.PodList{}
+	selector := operatorcontroller.CanaryDaemonSetPodSelector(canaryControllerName).MatchLabels
+	if err := r.client.List(context.TODO(), pods, client.InNamespace(operatorcontroller.DefaultCanaryNamespace), client.MatchingLabels(selector)); err != nil {
+		return nil, fmt.Errorf("failed to list canary pods: %w", err)
+	}
+	var endpoints []canaryEndpoint
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || !podIsReady(&pod) {
+			continue
+		}
+		endpoints = append(endpoints, canaryEndpoint{pod: pod.Name, node: pod.Spec.NodeName})
+	}
+	return endpoints, nil
+}
+
+// podIsReady returns a Boolean value indicating whether the given pod has the
+// Ready status condition.
+func podIsReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkCanaryEndpoints records the per-endpoint results of the given samples
+// in the canary check state and in metrics and updates the
+// CanaryPartialFailure status condition.
+func (r *reconciler) checkCanaryEndpoints(state *canaryCheckState, endpoints []canaryEndpoint, samples []canarySample) {
+	results, unattributed := summarizeCanarySamples(samples, endpoints)
+	SetCanaryEndpointSuccessRatioMetrics(results, unattributed)
+	failing := updateEndpointFailures(state, results, unattributed)
+	if err := r.setCanaryStatusCondition(canaryPartialFailureCondition(failing, endpoints, results)); err != nil {
+		log.Error(err, "error updating canary partial failure status condition")
+	}
+}
+
+// canaryPartialFailureCondition returns the CanaryPartialFailure status
+// condition for the given consistently failing endpoints.  The condition is a
+// warning that does not affect the ingress controller's availability; the
+// CanaryChecksSucceeding status condition reports whether the canary route is
+// reachable at all.
+func canaryPartialFailureCondition(failing []string, endpoints []canaryEndpoint, results map[string]canaryEndpointResult) operatorv1.OperatorCondition {
+	if len(failing) == 0 {
+		return operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerCanaryPartialFailureConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "NoCanaryEndpointsFailing",
+			Message: "No canary endpoint is consistently failing canary route checks while others succeed",
+		}
+	}
+	nodes := map[string]string{}
+	for _, endpoint := range endpoints {
+		nodes[endpoint.pod] = endpoint.node
+	}
+	descriptions := make([]string, 0, len(failing))
+	for _, pod := range failing {
+		description := pod
+		if node := nodes[pod]; len(node) != 0 {
+			description = fmt.Sprintf("%s on node %s", pod, node)
+		}
+		result := results[pod]
+		if result.successes+result.failures == 0 {
+			description += " (unreachable)"
+		} else {
+			description += fmt.Sprintf(" (%d of %d requests succeeded)", result.successes, result.successes+result.failures)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerCanaryPartialFailureConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "CanaryEndpointsFailing",
+		Message: fmt.Sprintf("The following canary endpoints have failed the last %d canary route checks while other endpoints succeeded, which may indicate a node-local networking problem: %s", canaryPartialFailureCount, strings.Join(descriptions, ", ")),
+	}
+}
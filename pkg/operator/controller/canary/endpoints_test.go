@@ -0,0 +1,205 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_canaryCheckSampleCount verifies that canaryCheckSampleCount scales the
+// number of samples with the number of endpoints within bounds.
+func Test_canaryCheckSampleCount(t *testing.T) {
+	for endpoints, expect := range map[int]int{0: canaryCheckMinSamples, 1: canaryCheckMinSamples, 3: 6, 50: canaryCheckMaxSamples} {
+		if actual := canaryCheckSampleCount(endpoints); actual != expect {
+			t.Errorf("expected %d samples for %d endpoints, got %d", expect, endpoints, actual)
+		}
+	}
+}
+
+// Test_checkCanaryRoute_partialFailure verifies that checkCanaryRoute keeps the
+// CanaryChecksSucceeding status condition true when one canary endpoint fails
+// while the others succeed and reports the failing endpoint in the
+// CanaryPartialFailure status condition once it has failed
+// canaryPartialFailureCount successive checks.
+func Test_checkCanaryRoute_partialFailure(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorNamespace,
+			Name:      manifests.DefaultIngressControllerName,
+		},
+	}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: controller.CanaryRouteName().Namespace,
+			Name:      controller.CanaryRouteName().Name,
+		},
+		Spec: routev1.RouteSpec{
+			Port: &routev1.RoutePort{TargetPort: intstr.FromInt32(8080)},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{{
+				Host:       "canary-openshift-ingress-canary.apps.example.com",
+				RouterName: manifests.DefaultIngressControllerName,
+				Conditions: []routev1.RouteIngressCondition{{
+					Type:   routev1.RouteAdmitted,
+					Status: corev1.ConditionTrue,
+				}},
+			}},
+		},
+	}
+	pod := func(name, node string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: controller.DefaultCanaryNamespace,
+				Name:      name,
+				Labels:    controller.CanaryDaemonSetPodSelector(canaryControllerName).MatchLabels,
+			},
+			Spec: corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	endpoints := []string{"ingress-canary-a", "ingress-canary-b", "ingress-canary-c"}
+	// roundRobin returns a probe function that simulates a router that
+	// balances requests across the canary endpoints in turn and that
+	// fails requests to ingress-canary-b.  If attributed is true, the
+	// endpoint serves the failing requests and identifies itself, as when
+	// the endpoint responds incorrectly; otherwise, the requests fail
+	// without reaching the endpoint, as when the router cannot connect to
+	// it.
+	roundRobin := func(attributed bool) func(*routev1.Route, string, int) (string, error) {
+		next := 0
+		return func(*routev1.Route, string, int) (string, error) {
+			endpoint := endpoints[next%len(endpoints)]
+			next++
+			if endpoint != "ingress-canary-b" {
+				return endpoint, nil
+			}
+			if attributed {
+				return endpoint, fmt.Errorf("canary request received on port 8888, but route specifies 8080")
+			}
+			return "", fmt.Errorf("error sending canary HTTP request: Timeout")
+		}
+	}
+	testCases := []struct {
+		name                string
+		probe               func(*routev1.Route, string, int) (string, error)
+		checks              int
+		expectPartialStatus operatorv1.ConditionStatus
+		expectMessageHas    string
+	}{
+		{
+			name:                "endpoint responds with failures, not enough checks",
+			probe:               roundRobin(true),
+			checks:              canaryPartialFailureCount - 1,
+			expectPartialStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:                "endpoint responds with failures",
+			probe:               roundRobin(true),
+			checks:              canaryPartialFailureCount,
+			expectPartialStatus: operatorv1.ConditionTrue,
+			expectMessageHas:    "ingress-canary-b on node worker-b (0 of 2 requests succeeded)",
+		},
+		{
+			name:                "endpoint is unreachable",
+			probe:               roundRobin(false),
+			checks:              canaryPartialFailureCount,
+			expectPartialStatus: operatorv1.ConditionTrue,
+			expectMessageHas:    "ingress-canary-b on node worker-b (unreachable)",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	routev1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic.DeepCopy(), route.DeepCopy(), pod("ingress-canary-a", "worker-a"), pod("ingress-canary-b", "worker-b"), pod("ingress-canary-c", "worker-c")).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config: Config{Namespace: operatorNamespace},
+				client: client,
+			}
+			state := &canaryCheckState{}
+			for i := 0; i < tc.checks; i++ {
+				r.checkCanaryRoute(state, tc.probe)
+			}
+
+			current := &operatorv1.IngressController{}
+			if err := client.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, current); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			canary := findCondition(current, ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			if canary == nil || canary.Status != operatorv1.ConditionTrue {
+				t.Errorf("expected %s=True, got %+v", ingresscontroller.IngressControllerCanaryCheckSuccessConditionType, canary)
+			}
+			partial := findCondition(current, ingresscontroller.IngressControllerCanaryPartialFailureConditionType)
+			if partial == nil {
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerCanaryPartialFailureConditionType)
+			}
+			if partial.Status != tc.expectPartialStatus {
+				t.Errorf("expected %s=%s, got %+v", ingresscontroller.IngressControllerCanaryPartialFailureConditionType, tc.expectPartialStatus, *partial)
+			}
+			if !strings.Contains(partial.Message, tc.expectMessageHas) {
+				t.Errorf("expected condition message to contain %q, got %q", tc.expectMessageHas, partial.Message)
+			}
+			for _, other := range []string{"ingress-canary-a", "ingress-canary-c"} {
+				if strings.Contains(partial.Message, other) {
+					t.Errorf("expected condition message not to mention %s, got %q", other, partial.Message)
+				}
+			}
+
+			if ratio := testutil.ToFloat64(CanaryEndpointSuccessRatio.WithLabelValues("ingress-canary-a")); ratio != 1 {
+				t.Errorf("expected success ratio 1 for ingress-canary-a, got %v", ratio)
+			}
+			if ratio := testutil.ToFloat64(CanaryEndpointSuccessRatio.WithLabelValues("ingress-canary-b")); ratio != 0 {
+				t.Errorf("expected success ratio 0 for ingress-canary-b, got %v", ratio)
+			}
+		})
+	}
+}
+
+// Test_updateEndpointFailures verifies that updateEndpointFailures does not
+// report a partial failure when all endpoints fail and forgets the failures of
+// an endpoint once it succeeds.
+func Test_updateEndpointFailures(t *testing.T) {
+	state := &canaryCheckState{}
+	allFailing := map[string]canaryEndpointResult{"a": {failures: 2}, "b": {failures: 2}}
+	for i := 0; i < canaryPartialFailureCount; i++ {
+		if failing := updateEndpointFailures(state, allFailing, 0); len(failing) != 0 {
+			t.Fatalf("expected no partial failure when all endpoints fail, got %v", failing)
+		}
+	}
+	recovered := map[string]canaryEndpointResult{"a": {successes: 2}, "b": {failures: 2}}
+	if failing := updateEndpointFailures(state, recovered, 0); len(failing) != 1 || failing[0] != "b" {
+		t.Errorf("expected b to be failing, got %v", failing)
+	}
+	if _, ok := state.endpointFailures["a"]; ok {
+		t.Errorf("expected the failures of a to be forgotten, got %v", state.endpointFailures)
+	}
+}
@@ -0,0 +1,174 @@
+package canary
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+)
+
+// canaryProbePath describes the network path through which the canary check
+// loop probes the canary route.
+type canaryProbePath struct {
+	// nodePort is true if the default ingresscontroller uses the
+	// NodePortService endpoint publishing strategy, in which case the
+	// operator has no load balancer status to report availability and
+	// instead reports whether the external endpoint is reachable.
+	nodePort bool
+	// externalAddress is the address of the external endpoint through
+	// which to probe the canary route, or empty to probe the canary route
+	// through the in-cluster path, resolving the route host using the
+	// cluster's DNS.
+	externalAddress string
+	// cdnOrigin is true if the default ingresscontroller is fronted by an
+	// external CDN, in which case the canary route's host resolves to the
+	// CDN and the canary check loop instead probes the canary route
+	// through the load balancer.
+	cdnOrigin bool
+	// err is the error from determining the external endpoint, if the
+	// default ingresscontroller specifies an invalid one.
+	err error
+}
+
+// external returns a Boolean value indicating whether the path is meant to
+// verify an external endpoint, for which the canary controller reports the
+// ExternalEndpointReachable status condition.
+func (p canaryProbePath) external() bool {
+	return p.nodePort || p.cdnOrigin
+}
+
+// canaryProbePathForIngressController returns the path through which the
+// canary check loop should probe the canary route for the given default
+// ingresscontroller.  An external endpoint is used with the NodePortService
+// endpoint publishing strategy if the ingresscontroller specifies one, and with
+// the LoadBalancerService endpoint publishing strategy if the ingresscontroller
+// is fronted by an external CDN, in which case the external endpoint is the
+// first address of the load balancer that the ingresscontroller's status
+// reports.
+func canaryProbePathForIngressController(ic *operatorv1.IngressController) canaryProbePath {
+	if ingresscontroller.CDNOriginEnabled(ic) {
+		path := canaryProbePath{cdnOrigin: true}
+		for _, ingress := range ingresscontroller.LoadBalancerIngressForIngressController(ic) {
+			host := ingress.IP
+			if len(host) == 0 {
+				host = ingress.Hostname
+			}
+			if len(host) != 0 {
+				path.externalAddress = net.JoinHostPort(host, "443")
+				break
+			}
+		}
+		return path
+	}
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.NodePortServiceStrategyType {
+		return canaryProbePath{}
+	}
+	path := canaryProbePath{nodePort: true}
+	endpoint, err := ingresscontroller.NodePortExternalEndpointForIngressController(ic)
+	switch {
+	case err != nil:
+		path.err = err
+	case endpoint == nil:
+	default:
+		if err := ingresscontroller.ValidateNodePortExternalEndpoint(endpoint); err != nil {
+			path.err = err
+		} else {
+			path.externalAddress = endpoint.DialAddress()
+		}
+	}
+	return path
+}
+
+// String returns a description of the path for status condition messages.
+func (p canaryProbePath) String() string {
+	switch {
+	case p.cdnOrigin && len(p.externalAddress) != 0:
+		return fmt.Sprintf("the load balancer %s", p.externalAddress)
+	case len(p.externalAddress) != 0:
+		return fmt.Sprintf("the external endpoint %s", p.externalAddress)
+	}
+	return "the in-cluster path"
+}
+
+// setProbePath records the path through which the canary check loop should
+// probe the canary route.
+func (r *reconciler) setProbePath(path canaryProbePath) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probePath = path
+}
+
+// currentProbePath returns the path through which the canary check loop should
+// probe the canary route.
+func (r *reconciler) currentProbePath() canaryProbePath {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.probePath
+}
+
+// syncExternalEndpointStatusCondition updates the ExternalEndpointReachable
+// status condition on the default ingress controller for the given path when
+// the condition does not depend on the outcome of canary checks.  The
+// condition is removed if the default ingress controller neither uses the
+// NodePortService endpoint publishing strategy nor is fronted by an external
+// CDN, and it is Unknown if no external endpoint is configured or the load
+// balancer has no address yet, in which case canary checks only verify the
+// in-cluster path.
+func (r *reconciler) syncExternalEndpointStatusCondition(path canaryProbePath) error {
+	switch {
+	case !path.external():
+		return r.removeCanaryStatusCondition(ingresscontroller.IngressControllerExternalEndpointReachableConditionType)
+	case path.cdnOrigin && len(path.externalAddress) == 0:
+		return r.setCanaryStatusCondition(operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "LoadBalancerPending",
+			Message: "The default ingress controller is fronted by an external CDN, but its load balancer has no address yet, so canary route checks only verify the in-cluster path.",
+		})
+	case path.err != nil:
+		return r.setCanaryStatusCondition(operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "InvalidExternalEndpoint",
+			Message: fmt.Sprintf("The external endpoint is invalid, so canary route checks only verify the in-cluster path: %v", path.err),
+		})
+	case len(path.externalAddress) == 0:
+		return r.setCanaryStatusCondition(operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "ExternalEndpointNotConfigured",
+			Message: "The default ingress controller uses the NodePortService endpoint publishing strategy without an external endpoint, so canary route checks only verify the in-cluster path.  Set spec.unsupportedConfigOverrides.nodePortExternalEndpoint.address to the VIP or hostname of the external load balancer to verify the external path.",
+		})
+	}
+	// The canary check loop reports the condition for a configured
+	// external endpoint.
+	return nil
+}
+
+// externalEndpointCondition returns the ExternalEndpointReachable status
+// condition for the outcome of canary checks through the given path, and a
+// Boolean value indicating whether the condition applies.  The condition only
+// applies if the path uses an external endpoint.
+func externalEndpointCondition(path canaryProbePath, errorStrings []string) (operatorv1.OperatorCondition, bool) {
+	if !path.external() || len(path.externalAddress) == 0 {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if len(errorStrings) != 0 {
+		return operatorv1.OperatorCondition{
+			Type:    ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "ExternalEndpointUnreachable",
+			Message: fmt.Sprintf("Canary route checks through %s are failing. Last %d error messages:\n%s", path, len(errorStrings), strings.Join(errorStrings, "\n")),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "ExternalEndpointReachable",
+		Message: fmt.Sprintf("Canary route checks through %s are successful", path),
+	}, true
+}
@@ -0,0 +1,360 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_canaryProbePathForIngressController verifies that
+// canaryProbePathForIngressController uses an external endpoint with the
+// NodePortService endpoint publishing strategy only if the endpoint is valid,
+// and uses the load balancer's address with the LoadBalancerService endpoint
+// publishing strategy only if the ingresscontroller is fronted by an external
+// CDN.
+func Test_canaryProbePathForIngressController(t *testing.T) {
+	testCases := []struct {
+		name            string
+		strategy        operatorv1.EndpointPublishingStrategyType
+		overrides       string
+		lbIngress       []corev1.LoadBalancerIngress
+		expectNodePort  bool
+		expectCDNOrigin bool
+		expectAddress   string
+		expectError     bool
+		expectPathMatch string
+	}{
+		{
+			name:            "load balancer",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			overrides:       `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			expectPathMatch: "in-cluster",
+		},
+		{
+			name:            "load balancer fronted by a CDN",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			overrides:       `{"cdnOrigin":{}}`,
+			lbIngress:       []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}, {IP: "192.0.2.20"}},
+			expectCDNOrigin: true,
+			expectAddress:   "lb.example.com:443",
+			expectPathMatch: "load balancer lb.example.com:443",
+		},
+		{
+			name:            "load balancer with IP address fronted by a CDN",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			overrides:       `{"cdnOrigin":{}}`,
+			lbIngress:       []corev1.LoadBalancerIngress{{IP: "192.0.2.20", Hostname: "lb.example.com"}},
+			expectCDNOrigin: true,
+			expectAddress:   "192.0.2.20:443",
+			expectPathMatch: "load balancer 192.0.2.20:443",
+		},
+		{
+			name:            "pending load balancer fronted by a CDN",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			overrides:       `{"cdnOrigin":{}}`,
+			expectCDNOrigin: true,
+			expectPathMatch: "in-cluster",
+		},
+		{
+			name:            "node port with CDN",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			overrides:       `{"cdnOrigin":{}}`,
+			lbIngress:       []corev1.LoadBalancerIngress{{IP: "192.0.2.20"}},
+			expectNodePort:  true,
+			expectPathMatch: "in-cluster",
+		},
+		{
+			name:            "node port without external endpoint",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			expectNodePort:  true,
+			expectPathMatch: "in-cluster",
+		},
+		{
+			name:            "node port with VIP",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			overrides:       `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			expectNodePort:  true,
+			expectAddress:   "192.0.2.10:443",
+			expectPathMatch: "external endpoint 192.0.2.10:443",
+		},
+		{
+			name:            "node port with IPv6 VIP",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			overrides:       `{"nodePortExternalEndpoint":{"address":"2001:db8::10"}}`,
+			expectNodePort:  true,
+			expectAddress:   "[2001:db8::10]:443",
+			expectPathMatch: "external endpoint [2001:db8::10]:443",
+		},
+		{
+			name:            "node port with hostname and port",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			overrides:       `{"nodePortExternalEndpoint":{"address":"lb.example.com:8443"}}`,
+			expectNodePort:  true,
+			expectAddress:   "lb.example.com:8443",
+			expectPathMatch: "external endpoint lb.example.com:8443",
+		},
+		{
+			name:            "node port with invalid address",
+			strategy:        operatorv1.NodePortServiceStrategyType,
+			overrides:       `{"nodePortExternalEndpoint":{"address":"lb_example.com:99999"}}`,
+			expectNodePort:  true,
+			expectError:     true,
+			expectPathMatch: "in-cluster",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: tc.strategy},
+				},
+			}
+			if tc.strategy == operatorv1.LoadBalancerServiceStrategyType {
+				ic.Status.Conditions = append(ic.Status.Conditions, ingresscontroller.LoadBalancerAddressCondition(tc.lbIngress))
+			}
+			path := canaryProbePathForIngressController(ic)
+			if path.nodePort != tc.expectNodePort {
+				t.Errorf("expected nodePort %t, got %t", tc.expectNodePort, path.nodePort)
+			}
+			if path.cdnOrigin != tc.expectCDNOrigin {
+				t.Errorf("expected cdnOrigin %t, got %t", tc.expectCDNOrigin, path.cdnOrigin)
+			}
+			if path.externalAddress != tc.expectAddress {
+				t.Errorf("expected address %q, got %q", tc.expectAddress, path.externalAddress)
+			}
+			if (path.err != nil) != tc.expectError {
+				t.Errorf("expected error %t, got %v", tc.expectError, path.err)
+			}
+			if !strings.Contains(path.String(), tc.expectPathMatch) {
+				t.Errorf("expected path description to contain %q, got %q", tc.expectPathMatch, path.String())
+			}
+		})
+	}
+}
+
+// Test_externalEndpointStatusConditions verifies that the canary controller
+// reports the ExternalEndpointReachable status condition and states which path
+// was verified for the NodePortService endpoint publishing strategy, both with
+// and without a configured external endpoint, and for a load balancer that is
+// fronted by an external CDN.
+func Test_externalEndpointStatusConditions(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: controller.CanaryRouteName().Namespace,
+			Name:      controller.CanaryRouteName().Name,
+		},
+		Spec: routev1.RouteSpec{
+			Port: &routev1.RoutePort{TargetPort: intstr.FromInt32(8080)},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{{
+				Host:       "canary-openshift-ingress-canary.apps.example.com",
+				RouterName: manifests.DefaultIngressControllerName,
+				Conditions: []routev1.RouteIngressCondition{{
+					Type:   routev1.RouteAdmitted,
+					Status: corev1.ConditionTrue,
+				}},
+			}},
+		},
+	}
+	// passThrough simulates an external load balancer that only forwards
+	// traffic sent to the configured VIP.
+	passThrough := func(_ *routev1.Route, address string, _ int) (string, error) {
+		if address != "192.0.2.10:443" {
+			return "", fmt.Errorf("error sending canary HTTP request: Timeout")
+		}
+		return "", nil
+	}
+	alwaysFail := func(*routev1.Route, string, int) (string, error) {
+		return "", fmt.Errorf("error sending canary HTTP request: Timeout")
+	}
+	testCases := []struct {
+		name                 string
+		strategy             operatorv1.EndpointPublishingStrategyType
+		overrides            string
+		lbIngress            []corev1.LoadBalancerIngress
+		probe                func(*routev1.Route, string, int) (string, error)
+		checks               int
+		expectExternalStatus operatorv1.ConditionStatus
+		expectExternalReason string
+		expectCanaryStatus   operatorv1.ConditionStatus
+		expectCanaryMessage  string
+	}{
+		{
+			name:                 "load balancer",
+			strategy:             operatorv1.LoadBalancerServiceStrategyType,
+			probe:                passThrough,
+			checks:               canaryCheckFailureCount,
+			expectCanaryStatus:   operatorv1.ConditionFalse,
+			expectExternalStatus: "",
+		},
+		{
+			name:                 "node port without external endpoint",
+			strategy:             operatorv1.NodePortServiceStrategyType,
+			probe:                func(*routev1.Route, string, int) (string, error) { return "", nil },
+			checks:               1,
+			expectExternalStatus: operatorv1.ConditionUnknown,
+			expectExternalReason: "ExternalEndpointNotConfigured",
+			expectCanaryStatus:   operatorv1.ConditionTrue,
+			expectCanaryMessage:  "through the in-cluster path",
+		},
+		{
+			name:                 "node port with reachable external endpoint",
+			strategy:             operatorv1.NodePortServiceStrategyType,
+			overrides:            `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			probe:                passThrough,
+			checks:               1,
+			expectExternalStatus: operatorv1.ConditionTrue,
+			expectExternalReason: "ExternalEndpointReachable",
+			expectCanaryStatus:   operatorv1.ConditionTrue,
+			expectCanaryMessage:  "through the external endpoint 192.0.2.10:443",
+		},
+		{
+			name:                 "node port with unreachable external endpoint",
+			strategy:             operatorv1.NodePortServiceStrategyType,
+			overrides:            `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			probe:                alwaysFail,
+			checks:               canaryCheckFailureCount,
+			expectExternalStatus: operatorv1.ConditionFalse,
+			expectExternalReason: "ExternalEndpointUnreachable",
+			expectCanaryStatus:   operatorv1.ConditionFalse,
+			expectCanaryMessage:  "The checks were performed through the external endpoint 192.0.2.10:443.",
+		},
+		{
+			name:                 "load balancer fronted by a CDN",
+			strategy:             operatorv1.LoadBalancerServiceStrategyType,
+			overrides:            `{"cdnOrigin":{}}`,
+			lbIngress:            []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}},
+			probe:                passThrough,
+			checks:               1,
+			expectExternalStatus: operatorv1.ConditionTrue,
+			expectExternalReason: "ExternalEndpointReachable",
+			expectCanaryStatus:   operatorv1.ConditionTrue,
+			expectCanaryMessage:  "through the load balancer 192.0.2.10:443",
+		},
+		{
+			name:                 "pending load balancer fronted by a CDN",
+			strategy:             operatorv1.LoadBalancerServiceStrategyType,
+			overrides:            `{"cdnOrigin":{}}`,
+			probe:                func(*routev1.Route, string, int) (string, error) { return "", nil },
+			checks:               1,
+			expectExternalStatus: operatorv1.ConditionUnknown,
+			expectExternalReason: "LoadBalancerPending",
+			expectCanaryStatus:   operatorv1.ConditionTrue,
+			expectCanaryMessage:  "through the in-cluster path",
+		},
+		{
+			name:                 "node port with invalid external endpoint",
+			strategy:             operatorv1.NodePortServiceStrategyType,
+			overrides:            `{"nodePortExternalEndpoint":{"address":"lb_example.com"}}`,
+			probe:                passThrough,
+			checks:               canaryCheckFailureCount,
+			expectExternalStatus: operatorv1.ConditionUnknown,
+			expectExternalReason: "InvalidExternalEndpoint",
+			expectCanaryStatus:   operatorv1.ConditionFalse,
+			expectCanaryMessage:  "The checks were performed through the in-cluster path.",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	routev1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      manifests.DefaultIngressControllerName,
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: tc.strategy},
+					Conditions: []operatorv1.OperatorCondition{{
+						Type:   ingresscontroller.IngressControllerExternalEndpointReachableConditionType,
+						Status: operatorv1.ConditionTrue,
+					}},
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic, route.DeepCopy()).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config: Config{Namespace: operatorNamespace},
+				client: cl,
+			}
+			if tc.strategy == operatorv1.LoadBalancerServiceStrategyType {
+				ic.Status.Conditions = append(ic.Status.Conditions, ingresscontroller.LoadBalancerAddressCondition(tc.lbIngress))
+			}
+			path := canaryProbePathForIngressController(ic)
+			r.setProbePath(path)
+			if err := r.syncExternalEndpointStatusCondition(path); err != nil {
+				t.Fatalf("failed to sync external endpoint status condition: %v", err)
+			}
+			state := &canaryCheckState{}
+			for i := 0; i < tc.checks; i++ {
+				r.checkCanaryRoute(state, tc.probe)
+			}
+
+			current := &operatorv1.IngressController{}
+			if err := cl.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, current); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			external := findCondition(current, ingresscontroller.IngressControllerExternalEndpointReachableConditionType)
+			switch {
+			case len(tc.expectExternalStatus) == 0 && external != nil:
+				t.Errorf("expected no %s condition, got %+v", ingresscontroller.IngressControllerExternalEndpointReachableConditionType, *external)
+			case len(tc.expectExternalStatus) != 0 && external == nil:
+				t.Errorf("expected %s condition, got none", ingresscontroller.IngressControllerExternalEndpointReachableConditionType)
+			case external != nil && (external.Status != tc.expectExternalStatus || external.Reason != tc.expectExternalReason):
+				t.Errorf("expected condition with status %s and reason %s, got %+v", tc.expectExternalStatus, tc.expectExternalReason, *external)
+			}
+			canary := findCondition(current, ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			if canary == nil {
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			}
+			if canary.Status != tc.expectCanaryStatus {
+				t.Errorf("expected %s condition with status %s, got %+v", canary.Type, tc.expectCanaryStatus, *canary)
+			}
+			if !strings.Contains(canary.Message, tc.expectCanaryMessage) {
+				t.Errorf("expected condition message to contain %q, got %q", tc.expectCanaryMessage, canary.Message)
+			}
+		})
+	}
+}
+
+// findCondition returns the status condition of the given type on the given
+// ingresscontroller, or nil if it has none.
+func findCondition(ic *operatorv1.IngressController, conditionType string) *operatorv1.OperatorCondition {
+	for i := range ic.Status.Conditions {
+		if ic.Status.Conditions[i].Type == conditionType {
+			return &ic.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package canary
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -13,19 +15,40 @@ import (
 
 	routev1 "github.com/openshift/api/route/v1"
 
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
 	"github.com/tcnksm/go-httpstat"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	echoServerPortAckHeader = "x-request-port"
+	// CanaryEndpointHeader is the header in which the canary server
+	// identifies the canary pod that served the request.
+	CanaryEndpointHeader = "x-canary-pod"
+	// CanaryResponseSizeQueryParameter is the query parameter with which a
+	// canary request asks the canary server for a response of the given
+	// size, in bytes.
+	CanaryResponseSizeQueryParameter = "size"
 )
 
-// probeRouteEndpoint probes the given route's host
-// and returns an error when applicable.
-func probeRouteEndpoint(route *routev1.Route) error {
+// canaryRequestTimeout is how long a canary request waits for a response.
+var canaryRequestTimeout = 10 * time.Second
+
+// probeRouteEndpoint probes the given route's host, verifying the canary's
+// serving certificate using the given root CAs, and returns the name of the
+// canary pod that served the request, if the response identifies it, and an
+// error when applicable.  If address is nonempty, the request is sent to that
+// address, bypassing DNS resolution of the route's host and the cluster-wide
+// proxy, so that the check verifies the path through an external endpoint.  If
+// size is positive, the request asks for a response of that many bytes, and
+// the probe fails if the response body is shorter.
+func probeRouteEndpoint(route *routev1.Route, rootCAs *x509.CertPool, address string, size int) (string, error) {
 	routeHost := getRouteHost(route)
 	if len(routeHost) == 0 {
-		return fmt.Errorf("route host is empty, cannot test route")
+		return "", fmt.Errorf("route host is empty, cannot test route")
 	}
 
 	// Create HTTP request
@@ -34,9 +57,13 @@ func probeRouteEndpoint(route *routev1.Route) error {
 	// via an external load balancer drop all traffic on port 80,
 	// in which case redirecting insecure traffic is not possible.
 	// See https://bugzilla.redhat.com/show_bug.cgi?id=1934773.
-	request, err := http.NewRequest("GET", "https://"+routeHost, nil)
+	url := "https://" + routeHost
+	if size > 0 {
+		url = fmt.Sprintf("%s/?%s=%d", url, CanaryResponseSizeQueryParameter, size)
+	}
+	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("error creating canary HTTP request %v: %v", request, err)
+		return "", fmt.Errorf("error creating canary HTTP request %v: %v", request, err)
 	}
 
 	// Create HTTP result
@@ -48,22 +75,28 @@ func probeRouteEndpoint(route *routev1.Route) error {
 	request = request.WithContext(ctx)
 
 	// Send the HTTP request
-	timeout, _ := time.ParseDuration("10s")
+	timeout := canaryRequestTimeout
+	transport := &http.Transport{
+		// Use the cluster-wide proxy if it is available in the
+		// pod's environment.
+		Proxy:             http.ProxyFromEnvironment,
+		TLSClientConfig:   &tls.Config{RootCAs: rootCAs},
+		DisableKeepAlives: true, // BZ#2037447
+	}
+	if len(address) != 0 {
+		dialer := &net.Dialer{Timeout: timeout}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		}
+	}
 	client := &http.Client{
 		Timeout: timeout,
-		// The canary route uses edge termination and the
-		// default router certificate may be self signed, so
-		// skip certificate verification here. See
-		// https://bugzilla.redhat.com/show_bug.cgi?id=1932401.
-		// TODO: Add the router's certificate to the HTTP client
-		// so we can enable TLS verification.
-		Transport: &http.Transport{
-			// Use the cluster-wide proxy if it is available in the
-			// pod's environment.
-			Proxy:             http.ProxyFromEnvironment,
-			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
-			DisableKeepAlives: true, // BZ#2037447
-		},
+		// The canary route uses passthrough termination, and the
+		// canary serves a certificate that the operator's CA signs,
+		// so verify the certificate using that CA, independently of
+		// the router's default certificate.
+		Transport: transport,
 	}
 	response, err := client.Do(request)
 
@@ -73,23 +106,29 @@ func probeRouteEndpoint(route *routev1.Route) error {
 		if errors.As(err, &dnsErr) {
 			// Handle DNS error
 			CanaryRouteDNSError.WithLabelValues(routeHost, dnsErr.Server).Inc()
-			return fmt.Errorf("error sending canary HTTP request: DNS error: %v", err)
+			return "", fmt.Errorf("error sending canary HTTP request: DNS error: %v", err)
 		}
 		// Check if err is a timeout error
 		if os.IsTimeout(err) {
 			// Handle timeout error
-			return fmt.Errorf("error sending canary HTTP Request: Timeout: %v", err)
+			return "", fmt.Errorf("error sending canary HTTP Request: Timeout: %v", err)
 		}
-		return fmt.Errorf("error sending canary HTTP request to %q: %v", routeHost, err)
+		if len(address) != 0 {
+			return "", fmt.Errorf("error sending canary HTTP request to %q through %q: %v", routeHost, address, err)
+		}
+		return "", fmt.Errorf("error sending canary HTTP request to %q: %v", routeHost, err)
 	}
 
 	// Close response body even if read fails
 	defer response.Body.Close()
 
+	// Attribute the response to the canary pod that served it, if any.
+	endpoint := response.Header.Get(CanaryEndpointHeader)
+
 	// Read response body
 	bodyBytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return fmt.Errorf("error reading canary response body: %v", err)
+		return endpoint, fmt.Errorf("error reading canary response body: %v", err)
 	}
 	body := string(bodyBytes)
 	t := time.Now()
@@ -99,23 +138,27 @@ func probeRouteEndpoint(route *routev1.Route) error {
 
 	// Verify body contents
 	if len(body) == 0 {
-		return fmt.Errorf("expected canary response body to not be empty")
+		return endpoint, fmt.Errorf("expected canary response body to not be empty")
 	}
 
 	if !strings.Contains(body, CanaryHealthcheckResponse) {
-		return fmt.Errorf("expected canary request body to contain %q", CanaryHealthcheckResponse)
+		return endpoint, fmt.Errorf("expected canary request body to contain %q", CanaryHealthcheckResponse)
+	}
+
+	if len(body) < size {
+		return endpoint, fmt.Errorf("expected canary response body to have %d bytes, got %d", size, len(body))
 	}
 
 	// Verify that the request was received on the correct port
 	recPort := response.Header.Get(echoServerPortAckHeader)
 	if len(recPort) == 0 {
-		return fmt.Errorf("expected %q header in canary response to have a nonempty value", echoServerPortAckHeader)
+		return endpoint, fmt.Errorf("expected %q header in canary response to have a nonempty value", echoServerPortAckHeader)
 	}
 	routePortStr := route.Spec.Port.TargetPort.String()
 	if routePortStr != recPort {
 		// router wedged, register in metrics counter
 		CanaryEndpointWrongPortEcho.Inc()
-		return fmt.Errorf("canary request received on port %s, but route specifies %v", recPort, routePortStr)
+		return endpoint, &canaryPortMismatchError{received: recPort, expected: routePortStr}
 	}
 
 	// Check status code
@@ -124,18 +167,86 @@ func probeRouteEndpoint(route *routev1.Route) error {
 		// Register total time in metrics (use milliseconds)
 		CanaryRequestTime.WithLabelValues(routeHost).Observe(float64(totalTime.Milliseconds()))
 	case http.StatusRequestTimeout:
-		return fmt.Errorf("status code %d: request timed out", status)
+		return endpoint, fmt.Errorf("status code %d: request timed out", status)
 	case http.StatusServiceUnavailable:
-		return fmt.Errorf("status code %d: Canary route not available via router", status)
+		return endpoint, fmt.Errorf("status code %d: Canary route not available via router", status)
 	case http.StatusBadGateway:
-		return fmt.Errorf("status code %d: bad gateway", status)
+		return endpoint, fmt.Errorf("status code %d: bad gateway", status)
 	case http.StatusInternalServerError:
-		return fmt.Errorf("status code %d: server error", status)
+		return endpoint, fmt.Errorf("status code %d: server error", status)
 	case http.StatusTooManyRequests:
-		return fmt.Errorf("status code %d: too many requests", status)
+		return endpoint, fmt.Errorf("status code %d: too many requests", status)
 	default:
-		return fmt.Errorf("unexpected status code: %d", status)
+		return endpoint, fmt.Errorf("unexpected status code: %d", status)
 	}
 
+	return endpoint, nil
+}
+
+// canaryRootCAs returns a certificate pool with the operator's CA, which signs
+// the canary serving certificate.  The CA is read for every canary check so
+// that the check always uses the current CA.
+func (r *reconciler) canaryRootCAs() (*x509.CertPool, error) {
+	name := operatorcontroller.RouterCASecretName(r.config.Namespace)
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		return nil, fmt.Errorf("failed to get CA secret %s: %v", name, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data["tls.crt"]) {
+		return nil, fmt.Errorf("failed to parse CA certificate from secret %s", name)
+	}
+	return pool, nil
+}
+
+// probeUserEndpoint probes the application that the given canary user probe
+// describes and returns an error if the response does not have the expected
+// status code and body.
+func probeUserEndpoint(probe *ingresscontroller.CanaryUserProbe) error {
+	url := "https://" + probe.Hostname + probe.Path
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating user probe HTTP request for %q: %v", url, err)
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// Use the cluster-wide proxy if it is available in the
+			// pod's environment.
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: probe.TLSVerification == ingresscontroller.CanaryUserProbeTLSSkip},
+			DisableKeepAlives: true,
+		},
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending user probe HTTP request to %q: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != probe.ExpectedStatus {
+		return fmt.Errorf("user probe request to %q returned status code %d, expected %d", url, response.StatusCode, probe.ExpectedStatus)
+	}
+	if len(probe.ExpectedBodySubstring) != 0 {
+		bodyBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("error reading user probe response body from %q: %v", url, err)
+		}
+		if !strings.Contains(string(bodyBytes), probe.ExpectedBodySubstring) {
+			return fmt.Errorf("expected user probe response body from %q to contain %q", url, probe.ExpectedBodySubstring)
+		}
+	}
 	return nil
 }
+
+// canaryPortMismatchError is the error for a canary request that the canary
+// pod received on a port other than the canary route's target port, which
+// means that the router has not applied the canary route's current target port.
+type canaryPortMismatchError struct {
+	received string
+	expected string
+}
+
+func (e *canaryPortMismatchError) Error() string {
+	return fmt.Sprintf("canary request received on port %s, but route specifies %v", e.received, e.expected)
+}
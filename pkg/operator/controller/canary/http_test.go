@@ -0,0 +1,104 @@
+package canary
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// newTestCA returns a new CA and a certificate pool with the CA's certificate.
+func newTestCA(t *testing.T, name string) (*crypto.CA, *x509.CertPool) {
+	t.Helper()
+	config, err := crypto.MakeSelfSignedCAConfigForDuration(name, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range config.Certs {
+		pool.AddCert(cert)
+	}
+	return &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}, pool
+}
+
+// Test_probeRouteEndpoint verifies that probeRouteEndpoint verifies the
+// canary's serving certificate using the given root CAs.
+func Test_probeRouteEndpoint(t *testing.T) {
+	ca, trusted := newTestCA(t, "ingress-operator")
+	_, untrusted := newTestCA(t, "untrusted")
+
+	serverCert, err := ca.MakeServerCertForDuration(sets.New("127.0.0.1", "canary.apps.example.com"), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make server certificate: %v", err)
+	}
+	certBytes, keyBytes, err := serverCert.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode server certificate: %v", err)
+	}
+	keyPair, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		t.Fatalf("failed to load server certificate: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, port, _ := net.SplitHostPort(r.Context().Value(http.LocalAddrContextKey).(net.Addr).String())
+		w.Header().Set(echoServerPortAckHeader, port)
+		w.Header().Set(CanaryEndpointHeader, "ingress-canary-abcde")
+		fmt.Fprintln(w, CanaryHealthcheckResponse)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{keyPair}}
+	server.StartTLS()
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	_, port, _ := net.SplitHostPort(host)
+	portNum, _ := strconv.Atoi(port)
+	route := &routev1.Route{
+		Spec: routev1.RouteSpec{
+			Port: &routev1.RoutePort{TargetPort: intstr.FromInt(portNum)},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{{
+				Host:       host,
+				RouterName: manifests.DefaultIngressControllerName,
+			}},
+		},
+	}
+
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+
+	if endpoint, err := probeRouteEndpoint(route, trusted, "", 0); err != nil {
+		t.Errorf("expected the probe to succeed with the trusted CA, got: %v", err)
+	} else if endpoint != "ingress-canary-abcde" {
+		t.Errorf("expected the probe to attribute the response to ingress-canary-abcde, got %q", endpoint)
+	}
+	if _, err := probeRouteEndpoint(route, untrusted, "", 0); err == nil {
+		t.Errorf("expected the probe to fail with an untrusted CA")
+	}
+
+	// Probe a route host that does not resolve through the server's
+	// address, as though the server were an external endpoint.
+	externalRoute := route.DeepCopy()
+	externalRoute.Status.Ingress[0].Host = "canary.apps.example.com"
+	if _, err := probeRouteEndpoint(externalRoute, trusted, host, 0); err != nil {
+		t.Errorf("expected the probe through the external endpoint to succeed, got: %v", err)
+	}
+	if _, err := probeRouteEndpoint(externalRoute, trusted, "127.0.0.1:1", 0); err == nil {
+		t.Errorf("expected the probe through an unreachable external endpoint to fail")
+	}
+}
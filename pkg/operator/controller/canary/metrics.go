@@ -1,6 +1,8 @@
 package canary
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -30,6 +32,34 @@ var (
 			Help: "A counter tracking canary route DNS lookup errors",
 		}, []string{"host", "dnsServer"})
 
+	CanaryRouteRotations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingress_canary_route_rotations_total",
+			Help: "A counter tracking canary route rotations by result",
+		}, []string{"result"})
+
+	CanaryRouteLastRotationTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ingress_canary_route_last_rotation_timestamp_seconds",
+			Help: "The Unix time of the last canary route rotation by result",
+		}, []string{"result"})
+
+	// CanaryUserProbeSucceeding reports whether the most recent canary
+	// user probe succeeded.
+	CanaryUserProbeSucceeding = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ingress_canary_user_probe_succeeding",
+			Help: "A gauge set to 0 or 1 to signify whether or not the most recent probe of the user-designated application succeeded",
+		}, []string{"host", "path"})
+
+	// CanaryEndpointSuccessRatio reports the ratio of the requests of the
+	// most recent canary check that each canary pod served successfully.
+	CanaryEndpointSuccessRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ingress_canary_endpoint_success_ratio",
+			Help: "The ratio of successful canary requests served by each canary pod in the most recent canary check",
+		}, []string{"pod"})
+
 	// Populate prometheus collector.
 	// Individual metrics are stored as public variables
 	// so that metrics can be globally controlled.
@@ -38,6 +68,10 @@ var (
 		CanaryEndpointWrongPortEcho,
 		CanaryRouteReachable,
 		CanaryRouteDNSError,
+		CanaryRouteRotations,
+		CanaryRouteLastRotationTimestamp,
+		CanaryUserProbeSucceeding,
+		CanaryEndpointSuccessRatio,
 	}
 )
 
@@ -51,6 +85,45 @@ func SetCanaryRouteReachableMetric(host string, status bool) {
 	}
 }
 
+// SetCanaryRouteRotationMetrics is a wrapper function to
+// record the time and result of a canary route rotation.
+func SetCanaryRouteRotationMetrics(timestamp time.Time, success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	CanaryRouteRotations.WithLabelValues(result).Inc()
+	CanaryRouteLastRotationTimestamp.WithLabelValues(result).Set(float64(timestamp.Unix()))
+}
+
+// SetCanaryUserProbeSucceedingMetric is a wrapper function to record the
+// result of a canary user probe.  Only the most recently probed host and path
+// are reported.
+func SetCanaryUserProbeSucceedingMetric(host, path string, success bool) {
+	CanaryUserProbeSucceeding.Reset()
+	if success {
+		CanaryUserProbeSucceeding.WithLabelValues(host, path).Set(1)
+	} else {
+		CanaryUserProbeSucceeding.WithLabelValues(host, path).Set(0)
+	}
+}
+
+// SetCanaryEndpointSuccessRatioMetrics is a wrapper function to record the
+// per-endpoint results of a canary check, given the number of failed requests
+// that could not be attributed to an endpoint.  Only the endpoints of the most
+// recent check are reported.  An endpoint that served no request is reported
+// as failing if any request failed without reaching an endpoint, and is
+// otherwise not reported.
+func SetCanaryEndpointSuccessRatioMetrics(results map[string]canaryEndpointResult, unattributed int) {
+	CanaryEndpointSuccessRatio.Reset()
+	for pod, result := range results {
+		if result.successes+result.failures == 0 && unattributed == 0 {
+			continue
+		}
+		CanaryEndpointSuccessRatio.WithLabelValues(pod).Set(result.successRatio())
+	}
+}
+
 // RegisterMetrics calls prometheus.Register on each metric in metricsList, and
 // returns on errors.
 func RegisterMetrics() error {
@@ -0,0 +1,96 @@
+package canary
+
+import (
+	"errors"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+)
+
+// canaryLargeResponseFailureReason is the reason for the canary status
+// condition when canary checks fail because requests for large responses fail
+// while requests for small responses succeed.  This is a strong indication of
+// a path MTU or fragmentation problem between clients and the router.
+const canaryLargeResponseFailureReason = "LargeResponseFailure"
+
+// responseSizeForIngressController returns the canary response sizes that the
+// given ingresscontroller specifies, or nil if it specifies none or specifies
+// invalid sizes, in which case the canary check requests the default response.
+func responseSizeForIngressController(ic *operatorv1.IngressController) *ingresscontroller.CanaryResponseSize {
+	size, err := ingresscontroller.CanaryResponseSizeForIngressController(ic)
+	if err == nil && size != nil {
+		err = ingresscontroller.ValidateCanaryResponseSize(size)
+	}
+	if err != nil {
+		log.Error(err, "invalid canary response size; using the default response")
+		return nil
+	}
+	return size
+}
+
+// setResponseSize records the response sizes that the canary check loop
+// should request.
+func (r *reconciler) setResponseSize(size *ingresscontroller.CanaryResponseSize) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responseSize = size
+}
+
+// currentResponseSize returns the response sizes that the canary check loop
+// should request.
+func (r *reconciler) currentResponseSize() *ingresscontroller.CanaryResponseSize {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.responseSize
+}
+
+// canaryLargeResponseError is the error for a canary check in which every
+// request for a large response failed while a request for a small response
+// succeeded.
+type canaryLargeResponseError struct {
+	// size is the size, in bytes, of the large responses.
+	size int
+	// err is the error from the last request for a large response.
+	err error
+}
+
+func (e *canaryLargeResponseError) Error() string {
+	return fmt.Sprintf("canary requests for %d-byte responses failed while smaller responses succeeded, which indicates a path MTU or fragmentation problem: %v", e.size, e.err)
+}
+
+func (e *canaryLargeResponseError) Unwrap() error {
+	return e.err
+}
+
+// largeResponseSamplesError returns a *canaryLargeResponseError if the given
+// samples include requests for large responses and all of them failed, or else
+// nil.  The caller must already have determined that some sample succeeded.
+func largeResponseSamplesError(samples []canarySample) error {
+	var err *canaryLargeResponseError
+	for _, sample := range samples {
+		if !sample.large {
+			continue
+		}
+		if sample.err == nil {
+			return nil
+		}
+		err = &canaryLargeResponseError{size: sample.size, err: sample.err}
+	}
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+// largeResponseFailure returns a Boolean value indicating whether the most
+// recent of the given errors from successive failing canary checks is a
+// failure of only the large responses.
+func largeResponseFailure(errs []timestampedError) bool {
+	if len(errs) == 0 {
+		return false
+	}
+	var largeErr *canaryLargeResponseError
+	return errors.As(errs[len(errs)-1].err, &largeErr)
+}
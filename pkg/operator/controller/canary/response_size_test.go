@@ -0,0 +1,187 @@
+package canary
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_checkCanaryRoute_responseSize verifies that checkCanaryRoute requests
+// the configured response sizes from a stub canary server that hangs on large
+// responses, as a path with an MTU blackhole would, and that it reports the
+// LargeResponseFailure reason only if it alternates between small and large
+// responses and only the large responses fail.
+func Test_checkCanaryRoute_responseSize(t *testing.T) {
+	ca, rootCAs := newTestCA(t, "ingress-operator")
+	serverCert, err := ca.MakeServerCertForDuration(sets.New("127.0.0.1"), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make server certificate: %v", err)
+	}
+	certBytes, keyBytes, err := serverCert.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode server certificate: %v", err)
+	}
+	keyPair, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		t.Fatalf("failed to load server certificate: %v", err)
+	}
+
+	defer func(timeout time.Duration) { canaryRequestTimeout = timeout }(canaryRequestTimeout)
+	canaryRequestTimeout = 100 * time.Millisecond
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+
+	const operatorNamespace = "openshift-ingress-operator"
+	testCases := []struct {
+		name         string
+		responseSize *ingresscontroller.CanaryResponseSize
+		// maxSize is the largest response that the stub server
+		// serves; it hangs on requests for larger responses.  If
+		// negative, the server hangs on every request.
+		maxSize      int
+		expectStatus operatorv1.ConditionStatus
+		expectReason string
+	}{
+		{
+			name:         "default response sizes",
+			maxSize:      1400,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "CanaryChecksSucceeding",
+		},
+		{
+			name:         "large responses succeed",
+			responseSize: &ingresscontroller.CanaryResponseSize{Large: 1024, Alternate: true},
+			maxSize:      1400,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "CanaryChecksSucceeding",
+		},
+		{
+			name:         "large responses hang",
+			responseSize: &ingresscontroller.CanaryResponseSize{Large: ingresscontroller.CanaryResponseSizeDefaultLarge, Alternate: true},
+			maxSize:      1400,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: canaryLargeResponseFailureReason,
+		},
+		{
+			name:         "large responses hang without alternating",
+			responseSize: &ingresscontroller.CanaryResponseSize{Small: 2000},
+			maxSize:      1400,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "CanaryChecksRepetitiveFailures",
+		},
+		{
+			name:         "all responses hang",
+			responseSize: &ingresscontroller.CanaryResponseSize{Large: ingresscontroller.CanaryResponseSizeDefaultLarge, Alternate: true},
+			maxSize:      -1,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "CanaryChecksRepetitiveFailures",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	routev1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				size, _ := strconv.Atoi(r.URL.Query().Get(CanaryResponseSizeQueryParameter))
+				if tc.maxSize < 0 || size > tc.maxSize {
+					<-r.Context().Done()
+					return
+				}
+				_, port, _ := net.SplitHostPort(r.Context().Value(http.LocalAddrContextKey).(net.Addr).String())
+				w.Header().Set(echoServerPortAckHeader, port)
+				body := CanaryHealthcheckResponse + "\n"
+				if size > len(body) {
+					body += strings.Repeat(".", size-len(body))
+				}
+				fmt.Fprint(w, body)
+			}))
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{keyPair}}
+			server.StartTLS()
+			defer server.Close()
+
+			host := server.Listener.Addr().String()
+			_, port, _ := net.SplitHostPort(host)
+			portNum, _ := strconv.Atoi(port)
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: controller.CanaryRouteName().Namespace,
+					Name:      controller.CanaryRouteName().Name,
+				},
+				Spec: routev1.RouteSpec{
+					Port: &routev1.RoutePort{TargetPort: intstr.FromInt(portNum)},
+				},
+				Status: routev1.RouteStatus{
+					Ingress: []routev1.RouteIngress{{
+						Host:       host,
+						RouterName: manifests.DefaultIngressControllerName,
+						Conditions: []routev1.RouteIngressCondition{{
+							Type:   routev1.RouteAdmitted,
+							Status: corev1.ConditionTrue,
+						}},
+					}},
+				},
+			}
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      manifests.DefaultIngressControllerName,
+				},
+			}
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic, route).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config: Config{Namespace: operatorNamespace},
+				client: client,
+			}
+			r.setResponseSize(tc.responseSize)
+			probe := func(route *routev1.Route, address string, size int) (string, error) {
+				return probeRouteEndpoint(route, rootCAs, address, size)
+			}
+			state := &canaryCheckState{}
+			for i := 0; i < canaryCheckFailureCount; i++ {
+				r.checkCanaryRoute(state, probe)
+			}
+
+			current := &operatorv1.IngressController{}
+			if err := client.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, current); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			cond := findCondition(current, ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			if cond == nil {
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerCanaryCheckSuccessConditionType)
+			}
+			if cond.Status != tc.expectStatus || cond.Reason != tc.expectReason {
+				t.Errorf("expected %s=%s with reason %s, got %+v", ingresscontroller.IngressControllerCanaryCheckSuccessConditionType, tc.expectStatus, tc.expectReason, *cond)
+			}
+		})
+	}
+}
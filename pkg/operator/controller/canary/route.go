@@ -158,7 +158,7 @@ func desiredCanaryRoute(service *corev1.Service) (*routev1.Route, error) {
 
 	route.Namespace = name.Namespace
 	route.Name = name.Name
-	route.Spec.Subdomain = fmt.Sprintf("%s-%s", route.Name, route.Namespace)
+	route.Spec.Subdomain = controller.CanaryRouteSubdomain()
 
 	if service == nil {
 		return route, fmt.Errorf("expected non-nil canary service for canary route %s/%s", route.Namespace, route.Name)
@@ -0,0 +1,48 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ensureCanaryServiceAccount ensures that the service account that the canary
+// daemonset's pods use exists, recreating it if it has been deleted.
+func (r *reconciler) ensureCanaryServiceAccount() (bool, *corev1.ServiceAccount, error) {
+	desired := manifests.CanaryServiceAccount()
+	name := controller.CanaryServiceAccountName()
+	desired.Name = name.Name
+	desired.Namespace = name.Namespace
+
+	haveServiceAccount, current, err := r.currentCanaryServiceAccount(desired)
+	if err != nil {
+		return false, nil, err
+	}
+	if haveServiceAccount {
+		return true, current, nil
+	}
+	if err := r.client.Create(context.TODO(), desired); err != nil {
+		return false, nil, fmt.Errorf("failed to create canary service account %s/%s: %v", desired.Namespace, desired.Name, err)
+	}
+	log.Info("created canary service account", "namespace", desired.Namespace, "name", desired.Name)
+	return r.currentCanaryServiceAccount(desired)
+}
+
+// currentCanaryServiceAccount gets the current canary service account
+// resource.
+func (r *reconciler) currentCanaryServiceAccount(desired *corev1.ServiceAccount) (bool, *corev1.ServiceAccount, error) {
+	sa := &corev1.ServiceAccount{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, sa); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, sa, nil
+}
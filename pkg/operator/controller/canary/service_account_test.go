@@ -0,0 +1,42 @@
+package canary
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_ensureCanaryServiceAccount verifies that ensureCanaryServiceAccount
+// creates the canary service account if it is missing and leaves an existing
+// one alone.
+func Test_ensureCanaryServiceAccount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	client := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &reconciler{client: client}
+
+	have, sa, err := r.ensureCanaryServiceAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !have {
+		t.Fatal("expected canary service account to be created")
+	}
+	if expected := controller.CanaryServiceAccountName(); sa.Namespace != expected.Namespace || sa.Name != expected.Name {
+		t.Errorf("expected service account %s, got %s/%s", expected, sa.Namespace, sa.Name)
+	}
+
+	// A second call should find the existing service account.
+	have, current, err := r.ensureCanaryServiceAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !have || current.UID != sa.UID || current.ResourceVersion != sa.ResourceVersion {
+		t.Errorf("expected existing service account to be unchanged, got %+v", current)
+	}
+}
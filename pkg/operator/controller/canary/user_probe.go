@@ -0,0 +1,160 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// userProbeForIngressController returns the canary user probe that the given
+// ingresscontroller specifies, or nil if it specifies none.  An error is
+// returned if the probe is invalid, in particular if its hostname is not a
+// subdomain of the domain of any ingresscontroller.
+func (r *reconciler) userProbeForIngressController(ic *operatorv1.IngressController) (*ingresscontroller.CanaryUserProbe, error) {
+	probe, err := ingresscontroller.CanaryUserProbeForIngressController(ic)
+	if err != nil || probe == nil {
+		return nil, err
+	}
+	ingresses := &operatorv1.IngressControllerList{}
+	if err := r.client.List(context.TODO(), ingresses, client.InNamespace(r.config.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list ingresscontrollers: %w", err)
+	}
+	if err := ingresscontroller.ValidateCanaryUserProbe(probe, ingresscontroller.IngressControllerDomains(ingresses.Items)); err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// setUserProbe records the canary user probe that the canary check loop should
+// perform, or the error from determining it.
+func (r *reconciler) setUserProbe(probe *ingresscontroller.CanaryUserProbe, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.userProbe = probe
+	r.userProbeErr = err
+}
+
+// currentUserProbe returns the canary user probe that the canary check loop
+// should perform, or the error from determining it.
+func (r *reconciler) currentUserProbe() (*ingresscontroller.CanaryUserProbe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.userProbe, r.userProbeErr
+}
+
+// userProbeState is the state that the canary check loop keeps in between
+// canary user probes.
+type userProbeState struct {
+	// probe is the most recently performed probe, or nil if no probe has
+	// been performed.
+	probe *ingresscontroller.CanaryUserProbe
+	// successiveFail is how many successive probes have failed.
+	successiveFail int
+	// errors are the errors from the successive failing probes.
+	errors []timestampedError
+}
+
+// checkUserProbe performs a single canary user probe using the given probe
+// function, if a user probe is configured, and updates the UserProbeSucceeding
+// status condition.  The condition is removed if no user probe is configured.
+func (r *reconciler) checkUserProbe(state *userProbeState, probeFn func(*ingresscontroller.CanaryUserProbe) error) {
+	probe, err := r.currentUserProbe()
+	switch {
+	case err != nil:
+		if err := r.setUserProbeStatusCondition(operatorv1.ConditionFalse, "InvalidUserProbe", fmt.Sprintf("The canary user probe is invalid and is not performed: %v", err)); err != nil {
+			log.Error(err, "error updating user probe status condition")
+		}
+		return
+	case probe == nil:
+		if state.probe != nil {
+			CanaryUserProbeSucceeding.Reset()
+			*state = userProbeState{}
+		}
+		if err := r.removeCanaryStatusCondition(ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType); err != nil {
+			log.Error(err, "error removing user probe status condition")
+		}
+		return
+	}
+
+	// Start counting failures again if the probe has changed.
+	if !reflect.DeepEqual(state.probe, probe) {
+		*state = userProbeState{probe: probe}
+	}
+
+	if err := probeFn(probe); err != nil {
+		log.Error(err, "error performing canary user probe")
+		SetCanaryUserProbeSucceedingMetric(probe.Hostname, probe.Path, false)
+		state.successiveFail++
+		state.errors = append(state.errors, timestampedError{err: err, timestamp: time.Now()})
+		if state.successiveFail >= canaryCheckFailureCount {
+			errorStrings := deduplicateErrorStrings(state.errors, time.Now())
+			if len(errorStrings) > canaryFailingNumErrors {
+				errorStrings = errorStrings[len(errorStrings)-canaryFailingNumErrors:]
+			}
+			message := fmt.Sprintf("Canary user probe checks of %s%s are failing. Last %d error messages:\n%s", probe.Hostname, probe.Path, len(errorStrings), strings.Join(errorStrings, "\n"))
+			if err := r.setUserProbeStatusCondition(operatorv1.ConditionFalse, "UserProbeRepetitiveFailures", message); err != nil {
+				log.Error(err, "error updating user probe status condition")
+			}
+		}
+		return
+	}
+
+	SetCanaryUserProbeSucceedingMetric(probe.Hostname, probe.Path, true)
+	state.successiveFail = 0
+	state.errors = nil
+	if err := r.setUserProbeStatusCondition(operatorv1.ConditionTrue, "UserProbeSucceeding", fmt.Sprintf("Canary user probe checks of %s%s are successful", probe.Hostname, probe.Path)); err != nil {
+		log.Error(err, "error updating user probe status condition")
+	}
+}
+
+// setUserProbeStatusCondition sets the UserProbeSucceeding status condition on
+// the default ingress controller.
+func (r *reconciler) setUserProbeStatusCondition(status operatorv1.ConditionStatus, reason, message string) error {
+	return r.setCanaryStatusCondition(operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// removeCanaryStatusCondition removes the status condition with the given type
+// from the default ingress controller, if the condition is present.
+func (r *reconciler) removeCanaryStatusCondition(conditionType string) error {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      manifests.DefaultIngressControllerName,
+			Namespace: r.config.Namespace,
+		},
+	}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}, ic); err != nil {
+		return fmt.Errorf("failed to get ingress controller %s: %v", ic.Name, err)
+	}
+
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = nil
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type != conditionType {
+			updated.Status.Conditions = append(updated.Status.Conditions, cond)
+		}
+	}
+	if len(updated.Status.Conditions) == len(ic.Status.Conditions) {
+		return nil
+	}
+	if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %v", ic.Name, err)
+	}
+	return nil
+}
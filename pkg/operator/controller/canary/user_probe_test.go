@@ -0,0 +1,209 @@
+package canary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_probeUserEndpoint verifies that probeUserEndpoint checks the status
+// code, body, and certificate of the response from a stub application.
+func Test_probeUserEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "status: ok")
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	testCases := []struct {
+		name             string
+		probe            ingresscontroller.CanaryUserProbe
+		expectErrorMatch string
+	}{
+		{
+			name:  "expected status and body",
+			probe: ingresscontroller.CanaryUserProbe{Path: "/healthz", ExpectedStatus: http.StatusOK, ExpectedBodySubstring: "ok", TLSVerification: ingresscontroller.CanaryUserProbeTLSSkip},
+		},
+		{
+			name:             "unexpected body",
+			probe:            ingresscontroller.CanaryUserProbe{Path: "/healthz", ExpectedStatus: http.StatusOK, ExpectedBodySubstring: "healthy", TLSVerification: ingresscontroller.CanaryUserProbeTLSSkip},
+			expectErrorMatch: `to contain "healthy"`,
+		},
+		{
+			name:             "unexpected status",
+			probe:            ingresscontroller.CanaryUserProbe{Path: "/unavailable", ExpectedStatus: http.StatusOK, TLSVerification: ingresscontroller.CanaryUserProbeTLSSkip},
+			expectErrorMatch: "returned status code 503, expected 200",
+		},
+		{
+			name:  "expected non-200 status",
+			probe: ingresscontroller.CanaryUserProbe{Path: "/unavailable", ExpectedStatus: http.StatusServiceUnavailable, TLSVerification: ingresscontroller.CanaryUserProbeTLSSkip},
+		},
+		{
+			name:             "untrusted certificate",
+			probe:            ingresscontroller.CanaryUserProbe{Path: "/healthz", ExpectedStatus: http.StatusOK, TLSVerification: ingresscontroller.CanaryUserProbeTLSVerify},
+			expectErrorMatch: "certificate",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.probe.Hostname = host
+			err := probeUserEndpoint(&tc.probe)
+			switch {
+			case err == nil && len(tc.expectErrorMatch) != 0:
+				t.Errorf("expected error matching %q, got nil", tc.expectErrorMatch)
+			case err != nil && len(tc.expectErrorMatch) == 0:
+				t.Errorf("unexpected error: %v", err)
+			case err != nil && !strings.Contains(err.Error(), tc.expectErrorMatch):
+				t.Errorf("expected error matching %q, got %v", tc.expectErrorMatch, err)
+			}
+		})
+	}
+}
+
+// Test_checkUserProbe verifies that checkUserProbe sets the UserProbeSucceeding
+// condition according to the results of the probes, and removes the condition
+// when no user probe is configured.
+func Test_checkUserProbe(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	probe := &ingresscontroller.CanaryUserProbe{
+		Hostname:        "app.apps.example.com",
+		Path:            "/",
+		ExpectedStatus:  http.StatusOK,
+		TLSVerification: ingresscontroller.CanaryUserProbeTLSVerify,
+	}
+	alwaysPass := func(*ingresscontroller.CanaryUserProbe) error { return nil }
+	alwaysFail := func(*ingresscontroller.CanaryUserProbe) error { return errors.New("connection refused") }
+	testCases := []struct {
+		name            string
+		probe           *ingresscontroller.CanaryUserProbe
+		probeErr        error
+		probeFn         func(*ingresscontroller.CanaryUserProbe) error
+		checks          int
+		expectCondition bool
+		expectStatus    operatorv1.ConditionStatus
+		expectReason    string
+	}{
+		{
+			name:            "probe passes",
+			probe:           probe,
+			probeFn:         alwaysPass,
+			checks:          1,
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "UserProbeSucceeding",
+		},
+		{
+			name:            "probe fails, not enough failures",
+			probe:           probe,
+			probeFn:         alwaysFail,
+			checks:          canaryCheckFailureCount - 1,
+			expectCondition: false,
+		},
+		{
+			name:            "probe fails",
+			probe:           probe,
+			probeFn:         alwaysFail,
+			checks:          canaryCheckFailureCount,
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "UserProbeRepetitiveFailures",
+		},
+		{
+			name:            "invalid probe",
+			probeErr:        errors.New("hostname is not a subdomain of the domain of any ingresscontroller"),
+			probeFn:         alwaysPass,
+			checks:          1,
+			expectCondition: true,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "InvalidUserProbe",
+		},
+		{
+			name:            "no probe",
+			probeFn:         alwaysPass,
+			checks:          1,
+			expectCondition: false,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      manifests.DefaultIngressControllerName,
+				},
+				Status: operatorv1.IngressControllerStatus{
+					Conditions: []operatorv1.OperatorCondition{{
+						Type:   ingresscontroller.IngressControllerCanaryCheckSuccessConditionType,
+						Status: operatorv1.ConditionTrue,
+					}},
+				},
+			}
+			if tc.probe == nil && tc.probeErr == nil {
+				// Verify that a stale condition is removed.
+				ic.Status.Conditions = append(ic.Status.Conditions, operatorv1.OperatorCondition{
+					Type:   ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType,
+					Status: operatorv1.ConditionTrue,
+				})
+			}
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(ic).
+				WithStatusSubresource(&operatorv1.IngressController{}).
+				Build()
+			r := &reconciler{
+				config: Config{Namespace: operatorNamespace},
+				client: client,
+			}
+			r.setUserProbe(tc.probe, tc.probeErr)
+			state := &userProbeState{}
+			for i := 0; i < tc.checks; i++ {
+				r.checkUserProbe(state, tc.probeFn)
+			}
+
+			currentIC := &operatorv1.IngressController{}
+			if err := client.Get(context.Background(), types.NamespacedName{Namespace: operatorNamespace, Name: manifests.DefaultIngressControllerName}, currentIC); err != nil {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			var cond *operatorv1.OperatorCondition
+			for i := range currentIC.Status.Conditions {
+				if currentIC.Status.Conditions[i].Type == ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType {
+					cond = &currentIC.Status.Conditions[i]
+				}
+			}
+			switch {
+			case cond == nil && tc.expectCondition:
+				t.Fatalf("expected %s condition, got none", ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType)
+			case cond != nil && !tc.expectCondition:
+				t.Fatalf("expected no %s condition, got %+v", ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType, *cond)
+			case cond != nil && (cond.Status != tc.expectStatus || cond.Reason != tc.expectReason):
+				t.Errorf("expected condition with status %s and reason %s, got %+v", tc.expectStatus, tc.expectReason, *cond)
+			}
+			if len(currentIC.Status.Conditions) == 0 || currentIC.Status.Conditions[0].Type != ingresscontroller.IngressControllerCanaryCheckSuccessConditionType {
+				t.Errorf("expected the canary check condition to be preserved, got %+v", currentIC.Status.Conditions)
+			}
+		})
+	}
+}
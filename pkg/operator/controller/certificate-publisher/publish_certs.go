@@ -106,7 +106,9 @@ func desiredRouterCertsGlobalSecret(secrets []corev1.Secret, ingresses []operato
 // secret to use for the given ingresscontroller out of the provided list of
 // secrets.  If the ingresscontroller does not specify a secret or specifies a
 // secret that doesn't exist, the operator-generated default certificate is
-// returned.
+// returned.  A secret that the ingresscontroller specifies using the
+// "defaultCertificateSource" unsupported config override is specified by way
+// of the operator-managed copy in the operand namespace.
 //
 // Note that if ingress.Spec.DefaultCertificate is updated to point to a
 // non-existent secret, the certificate controller does not delete the
@@ -118,11 +120,11 @@ func desiredRouterCertsGlobalSecret(secrets []corev1.Secret, ingresses []operato
 func getDefaultCertificateSecretForIngressController(ic *operatorv1.IngressController, secrets []corev1.Secret, operandNamespace string) *corev1.Secret {
 	var (
 		defaultCertName         = controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, operandNamespace)
-		customCertName          = ic.Spec.DefaultCertificate
+		customCertName          = controller.RouterEffectiveDefaultCertificateSecretName(ic, operandNamespace)
 		defaultCert, customCert *corev1.Secret
 	)
 	for i := range secrets {
-		if customCertName != nil && customCertName.Name == secrets[i].Name {
+		if customCertName.Name == secrets[i].Name {
 			customCert = &secrets[i]
 		}
 		if defaultCertName.Name == secrets[i].Name {
@@ -0,0 +1,160 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// canaryServingCertLifetimeInDays is the validity period of the
+	// canary serving certificate.
+	canaryServingCertLifetimeInDays = 365
+	// canaryServingCertRefreshPeriod is how long before the canary serving
+	// certificate expires that the operator replaces it.  The canary
+	// reloads its certificate when the secret changes, and the new
+	// certificate is signed by the same CA, so the replacement does not
+	// disrupt canary checks.
+	canaryServingCertRefreshPeriod = 90 * 24 * time.Hour
+)
+
+// ensureCanaryServingCertificate ensures that the canary serving certificate
+// secret exists, is signed by the given CA, covers the canary route's host for
+// the given (default) ingresscontroller, and is not close to expiring.
+// Returns the time at which the certificate will need to be replaced.
+func (r *reconciler) ensureCanaryServingCertificate(caSecret *corev1.Secret, ci *operatorv1.IngressController) (time.Time, error) {
+	ca, err := crypto.GetCAFromBytes(caSecret.Data["tls.crt"], caSecret.Data["tls.key"])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get CA from secret %s/%s: %v", caSecret.Namespace, caSecret.Name, err)
+	}
+	hostnames := canaryServingCertHostnames(ci.Status.Domain)
+
+	haveSecret, current, err := r.currentCanaryServingCertificate()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if haveSecret {
+		if notAfter, ok := canaryServingCertificateValid(current, ca, hostnames, time.Now()); ok {
+			return notAfter.Add(-canaryServingCertRefreshPeriod), nil
+		}
+	}
+
+	desired, notAfter, err := desiredCanaryServingCertificateSecret(ca, hostnames)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !haveSecret {
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return time.Time{}, fmt.Errorf("failed to create canary serving certificate secret %s/%s: %v", desired.Namespace, desired.Name, err)
+		}
+		r.recorder.Eventf(ci, "Normal", "CreatedCanaryServingCertificate", "Created canary serving certificate %q", desired.Name)
+		return notAfter.Add(-canaryServingCertRefreshPeriod), nil
+	}
+	updated := current.DeepCopy()
+	updated.Type = desired.Type
+	updated.Data = desired.Data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update canary serving certificate secret %s/%s: %v", updated.Namespace, updated.Name, err)
+	}
+	r.recorder.Eventf(ci, "Normal", "UpdatedCanaryServingCertificate", "Replaced canary serving certificate %q", updated.Name)
+	return notAfter.Add(-canaryServingCertRefreshPeriod), nil
+}
+
+// canaryServingCertHostnames returns the hostnames that the canary serving
+// certificate must cover: the canary route's host for the given ingress domain
+// and the canary service's DNS names.
+func canaryServingCertHostnames(domain string) []string {
+	service := controller.CanaryServiceName()
+	return []string{
+		controller.CanaryRouteHost(domain),
+		fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service.Name, service.Namespace),
+	}
+}
+
+// desiredCanaryServingCertificateSecret returns the desired canary serving
+// certificate secret, with a new certificate signed by the given CA, and the
+// certificate's expiration time.
+func desiredCanaryServingCertificateSecret(ca *crypto.CA, hostnames []string) (*corev1.Secret, time.Time, error) {
+	cert, err := ca.MakeServerCert(sets.New(hostnames...), canaryServingCertLifetimeInDays)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to make canary serving certificate: %v", err)
+	}
+	certBytes, keyBytes, err := cert.GetPEMBytes()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to encode canary serving certificate: %v", err)
+	}
+
+	name := controller.CanaryServingCertSecretName()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": certBytes,
+			"tls.key": keyBytes,
+		},
+	}
+	return secret, cert.Certs[0].NotAfter, nil
+}
+
+// canaryServingCertificateValid returns the expiration time of the certificate
+// in the given secret and a Boolean value indicating whether the certificate
+// has a key, is signed by the given CA, covers all the given hostnames, and
+// remains valid for at least canaryServingCertRefreshPeriod after now.
+func canaryServingCertificateValid(secret *corev1.Secret, ca *crypto.CA, hostnames []string, now time.Time) (time.Time, bool) {
+	if len(secret.Data["tls.key"]) == 0 {
+		return time.Time{}, false
+	}
+	block, _ := pem.Decode(secret.Data["tls.crt"])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	roots := x509.NewCertPool()
+	for _, caCert := range ca.Config.Certs {
+		roots.AddCert(caCert)
+	}
+	for _, hostname := range hostnames {
+		opts := x509.VerifyOptions{
+			DNSName:     hostname,
+			Roots:       roots,
+			CurrentTime: now,
+		}
+		if _, err := cert.Verify(opts); err != nil {
+			return cert.NotAfter, false
+		}
+	}
+	return cert.NotAfter, now.Add(canaryServingCertRefreshPeriod).Before(cert.NotAfter)
+}
+
+// currentCanaryServingCertificate returns the current canary serving
+// certificate secret.
+func (r *reconciler) currentCanaryServingCertificate() (bool, *corev1.Secret, error) {
+	name := controller.CanaryServingCertSecretName()
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, secret, nil
+}
@@ -0,0 +1,166 @@
+package certificate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestCA returns a new CA and a secret with the CA's certificate and key.
+func newTestCA(t *testing.T, name string) (*crypto.CA, *corev1.Secret) {
+	t.Helper()
+	config, err := crypto.MakeSelfSignedCAConfigForDuration(name, 2*365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make CA: %v", err)
+	}
+	certBytes, keyBytes, err := config.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode CA: %v", err)
+	}
+	ca := &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "router-ca"},
+		Data: map[string][]byte{
+			"tls.crt": certBytes,
+			"tls.key": keyBytes,
+		},
+	}
+	return ca, secret
+}
+
+// Test_canaryServingCertificateValid verifies that
+// canaryServingCertificateValid accepts a certificate only if it is signed by
+// the expected CA, covers the canary hostnames, and is not about to expire.
+func Test_canaryServingCertificateValid(t *testing.T) {
+	ca, _ := newTestCA(t, "ingress-operator")
+	otherCA, _ := newTestCA(t, "other")
+	hostnames := canaryServingCertHostnames("apps.example.com")
+
+	secretFor := func(ca *crypto.CA, hostnames []string, lifetime time.Duration) *corev1.Secret {
+		cert, err := ca.MakeServerCertForDuration(sets.New(hostnames...), lifetime)
+		if err != nil {
+			t.Fatalf("failed to make certificate: %v", err)
+		}
+		certBytes, keyBytes, err := cert.GetPEMBytes()
+		if err != nil {
+			t.Fatalf("failed to encode certificate: %v", err)
+		}
+		return &corev1.Secret{Data: map[string][]byte{"tls.crt": certBytes, "tls.key": keyBytes}}
+	}
+	noKey := secretFor(ca, hostnames, 365*24*time.Hour)
+	delete(noKey.Data, "tls.key")
+
+	testCases := []struct {
+		name   string
+		secret *corev1.Secret
+		expect bool
+	}{
+		{
+			name:   "valid",
+			secret: secretFor(ca, hostnames, 365*24*time.Hour),
+			expect: true,
+		},
+		{
+			name:   "signed by a different CA",
+			secret: secretFor(otherCA, hostnames, 365*24*time.Hour),
+			expect: false,
+		},
+		{
+			name:   "different ingress domain",
+			secret: secretFor(ca, canaryServingCertHostnames("apps.example.org"), 365*24*time.Hour),
+			expect: false,
+		},
+		{
+			name:   "expiring within the refresh period",
+			secret: secretFor(ca, hostnames, 30*24*time.Hour),
+			expect: false,
+		},
+		{
+			name:   "missing key",
+			secret: noKey,
+			expect: false,
+		},
+		{
+			name:   "garbage",
+			secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": []byte("garbage"), "tls.key": []byte("garbage")}},
+			expect: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, actual := canaryServingCertificateValid(tc.secret, ca, hostnames, time.Now()); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_ensureCanaryServingCertificate verifies that
+// ensureCanaryServingCertificate creates the canary serving certificate,
+// leaves a valid certificate alone, and replaces the certificate when the
+// ingress domain changes.
+func Test_ensureCanaryServingCertificate(t *testing.T) {
+	ca, caSecret := newTestCA(t, "ingress-operator")
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &reconciler{
+		client:            cl,
+		recorder:          record.NewFakeRecorder(10),
+		operatorNamespace: "openshift-ingress-operator",
+	}
+
+	getSecret := func() *corev1.Secret {
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.Background(), controller.CanaryServingCertSecretName(), secret); err != nil {
+			t.Fatalf("failed to get canary serving certificate secret: %v", err)
+		}
+		return secret
+	}
+
+	refreshAt, err := r.ensureCanaryServingCertificate(caSecret, ic)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshAt.Before(time.Now().Add(200 * 24 * time.Hour)) {
+		t.Errorf("expected the refresh time to be far in the future, got %v", refreshAt)
+	}
+	created := getSecret()
+	if _, ok := canaryServingCertificateValid(created, ca, canaryServingCertHostnames(ic.Status.Domain), time.Now()); !ok {
+		t.Fatalf("expected a valid canary serving certificate")
+	}
+
+	if _, err := r.ensureCanaryServingCertificate(caSecret, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unchanged := getSecret(); string(unchanged.Data["tls.crt"]) != string(created.Data["tls.crt"]) {
+		t.Errorf("expected a valid canary serving certificate to be left alone")
+	}
+
+	ic.Status.Domain = "apps.example.org"
+	if _, err := r.ensureCanaryServingCertificate(caSecret, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := canaryServingCertificateValid(getSecret(), ca, canaryServingCertHostnames(ic.Status.Domain), time.Now()); !ok {
+		t.Errorf("expected the canary serving certificate to be replaced for the new domain")
+	}
+}
@@ -1,7 +1,13 @@
 // The certificate controller is responsible for the following:
 //
 //  1. Managing a CA for minting self-signed certs.
-//  2. Managing self-signed certificates for any ingresscontrollers which require them.
+//  2. Managing self-signed certificates for any ingresscontrollers which require
+//     them, signed by the CA or by an intermediate CA that the ingresscontroller
+//     specifies, and replacing them before they expire.
+//  3. Managing the serving certificate for the canary route, which the canary
+//     controller verifies using the CA.
+//  4. Publishing the certificates of the CA and of the intermediate CAs in the
+//     ingress CA bundle configmap.
 package certificate
 
 import (
@@ -10,16 +16,19 @@ import (
 	"time"
 
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"k8s.io/client-go/tools/record"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
@@ -27,6 +36,7 @@ import (
 	runtimecontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -51,6 +61,53 @@ func New(mgr manager.Manager, operatorNamespace string) (runtimecontroller.Contr
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &operatorv1.IngressController{}, &handler.EnqueueRequestForObject{})); err != nil {
 		return nil, err
 	}
+	// Watch the canary serving certificate secret so that it is
+	// recreated or repaired if it is deleted or modified.
+	isCanaryServingCertSecret := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		name := controller.CanaryServingCertSecretName()
+		return o.GetNamespace() == name.Namespace && o.GetName() == name.Name
+	})
+	toDefaultIngressController := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{
+				Namespace: operatorNamespace,
+				Name:      manifests.DefaultIngressControllerName,
+			},
+		}}
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, toDefaultIngressController, isCanaryServingCertSecret)); err != nil {
+		return nil, err
+	}
+	// Watch secrets in the operator namespace so that the default
+	// certificates of ingresscontrollers are reissued if the intermediate
+	// CAs that they specify for signing them change.
+	isOperatorNamespaceSecret := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == operatorNamespace
+	})
+	toSignerUsers := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+		ingresses := &operatorv1.IngressControllerList{}
+		if err := reconciler.client.List(ctx, ingresses, client.InNamespace(operatorNamespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers for secret", "namespace", o.GetNamespace(), "name", o.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range ingresses.Items {
+			secretName, err := ingresscontroller.DefaultCertificateSignerForIngressController(&ingresses.Items[i])
+			if err != nil || secretName != o.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: ingresses.Items[i].Namespace,
+					Name:      ingresses.Items[i].Name,
+				},
+			})
+		}
+		return requests
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, toSignerUsers, isOperatorNamespaceSecret)); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -70,6 +127,9 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	result := reconcile.Result{}
 	errs := []error{}
+	if err := r.ensureIngressCABundle(ca); err != nil {
+		errs = append(errs, fmt.Errorf("failed to ensure ingress CA bundle: %v", err))
+	}
 	ingress := &operatorv1.IngressController{}
 	if err := r.client.Get(ctx, request.NamespacedName, ingress); err != nil {
 		if errors.IsNotFound(err) {
@@ -102,8 +162,19 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 				UID:        deployment.UID,
 				Controller: &trueVar,
 			}
-			if _, err := r.ensureDefaultCertificateForIngress(ca, deployment.Namespace, deploymentRef, ingress); err != nil {
+			if haveCert, refreshAt, err := r.ensureDefaultCertificateForIngress(ca, deployment.Namespace, deploymentRef, ingress); err != nil {
 				errs = append(errs, fmt.Errorf("failed to ensure default cert for %s: %v", ingress.Name, err))
+			} else if refreshAfter := time.Until(refreshAt); haveCert && (result.RequeueAfter == 0 || refreshAfter < result.RequeueAfter) {
+				result.RequeueAfter = refreshAfter
+			}
+		}
+		// The canary checks the default ingresscontroller, so the
+		// canary serving certificate covers its domain.
+		if ingress.Name == manifests.DefaultIngressControllerName {
+			if refreshAt, err := r.ensureCanaryServingCertificate(ca, ingress); err != nil {
+				errs = append(errs, fmt.Errorf("failed to ensure canary serving cert: %v", err))
+			} else if refreshAfter := time.Until(refreshAt); result.RequeueAfter == 0 || refreshAfter < result.RequeueAfter {
+				result.RequeueAfter = refreshAfter
 			}
 		}
 	}
@@ -2,12 +2,16 @@ package certificate
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"time"
 
 	"github.com/openshift/library-go/pkg/crypto"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -15,17 +19,32 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-// ensureDefaultCertificateForIngress creates or deletes an operator-generated
-// default certificate for a given IngressController as appropriate.  Returns true
-// if it the secret exists, or false if it does not, as well as any errors.
-func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret, namespace string, deploymentRef metav1.OwnerReference, ci *operatorv1.IngressController) (bool, error) {
-	ca, err := crypto.GetCAFromBytes(caSecret.Data["tls.crt"], caSecret.Data["tls.key"])
+// defaultCertificateRefreshPeriod is how long before an operator-generated
+// default certificate expires that the operator replaces it.  The router
+// reloads its certificates when the secret changes, so the replacement does
+// not disrupt connections.
+const defaultCertificateRefreshPeriod = 90 * 24 * time.Hour
+
+// ensureDefaultCertificateForIngress creates, updates, or deletes an
+// operator-generated default certificate for a given IngressController as
+// appropriate.  The certificate is signed by the intermediate CA that the
+// ingresscontroller specifies, if any, or else by the router CA, and it is
+// replaced if it no longer covers the ingresscontroller's domain, is not
+// signed by the current signer, or is close to expiring.  Returns true if the
+// secret exists, or false if it does not, the time at which the certificate
+// will need to be replaced, if it exists, as well as any errors.
+func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret, namespace string, deploymentRef metav1.OwnerReference, ci *operatorv1.IngressController) (bool, time.Time, error) {
+	signerSecret, err := r.defaultCertificateSignerSecret(caSecret, ci)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	ca, err := signerFromSecret(signerSecret)
 	if err != nil {
-		return false, fmt.Errorf("failed to get CA from secret %s/%s: %v", caSecret.Namespace, caSecret.Name, err)
+		return false, time.Time{}, err
 	}
 	wantCert, desired, err := desiredRouterDefaultCertificateSecret(ca, namespace, deploymentRef, ci)
 	if err != nil {
-		return false, err
+		return false, time.Time{}, err
 	}
 	if !wantCert {
 		// If the operator generated certificate is not being used, ensure that the ingress controller's
@@ -33,36 +52,52 @@ func (r *reconciler) ensureDefaultCertificateForIngress(caSecret *corev1.Secret,
 		// See https://bugzilla.redhat.com/show_bug.cgi?id=1887441
 		err := r.lookupUserSpecifiedRouterDefaultCertificate(ci, namespace)
 		if err != nil {
-			return false, fmt.Errorf("failed to lookup user specified default certificate: %v", err)
+			return false, time.Time{}, fmt.Errorf("failed to lookup user specified default certificate: %v", err)
 		}
 	}
 
 	haveCert, current, err := r.currentRouterDefaultCertificate(ci, namespace)
 	if err != nil {
-		return false, err
+		return false, time.Time{}, err
 	}
 	switch {
 	case !wantCert && !haveCert:
 		// Nothing to do.
 	case !wantCert && haveCert:
 		if deleted, err := r.deleteRouterDefaultCertificate(current); err != nil {
-			return true, fmt.Errorf("failed to delete default certificate: %v", err)
+			return true, time.Time{}, fmt.Errorf("failed to delete default certificate: %v", err)
 		} else if deleted {
 			r.recorder.Eventf(ci, "Normal", "DeletedDefaultCertificate", "Deleted default wildcard certificate %q", current.Name)
-			return false, nil
+			return false, time.Time{}, nil
 		}
 	case wantCert && !haveCert:
 		if created, err := r.createRouterDefaultCertificate(desired); err != nil {
-			return false, fmt.Errorf("failed to create default certificate: %v", err)
+			return false, time.Time{}, fmt.Errorf("failed to create default certificate: %v", err)
 		} else if created {
 			r.recorder.Eventf(ci, "Normal", "CreatedDefaultCertificate", "Created default wildcard certificate %q", desired.Name)
-			return true, nil
+			return true, certificateNotAfter(desired).Add(-defaultCertificateRefreshPeriod), nil
 		}
 	case wantCert && haveCert:
-		// TODO Update if CA certificate changed.
-		return true, nil
+		hostnames := defaultCertificateHostnames(ci)
+		notAfter, valid := defaultCertificateValid(current, ca, hostnames, time.Now())
+		if valid {
+			return true, notAfter.Add(-defaultCertificateRefreshPeriod), nil
+		}
+		updated := current.DeepCopy()
+		updated.Data = desired.Data
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return true, time.Time{}, fmt.Errorf("failed to update default certificate: %w", err)
+		}
+		if certificateHostnames(current).Equal(hostnames) {
+			r.recorder.Eventf(ci, "Normal", "RotatedDefaultCertificate", "Replaced default wildcard certificate %q signed by %s/%s", updated.Name, signerSecret.Namespace, signerSecret.Name)
+		} else {
+			// The ingresscontroller's domain has changed, or it
+			// has started or finished migrating to a new domain.
+			r.recorder.Eventf(ci, "Normal", "UpdatedDefaultCertificate", "Updated default wildcard certificate %q for hostnames %v", updated.Name, sets.List(hostnames))
+		}
+		return true, certificateNotAfter(desired).Add(-defaultCertificateRefreshPeriod), nil
 	}
-	return false, nil
+	return false, time.Time{}, nil
 }
 
 // desiredRouterDefaultCertificateSecret returns the desired default certificate
@@ -82,8 +117,7 @@ func desiredRouterDefaultCertificateSecret(ca *crypto.CA, namespace string, depl
 		return false, nil, nil
 	}
 
-	hostnames := sets.New(fmt.Sprintf("*.%s", ci.Status.Domain))
-	cert, err := ca.MakeServerCert(hostnames, 0)
+	cert, err := ca.MakeServerCert(defaultCertificateHostnames(ci), 0)
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to make certificate: %v", err)
 	}
@@ -108,6 +142,102 @@ func desiredRouterDefaultCertificateSecret(ca *crypto.CA, namespace string, depl
 	return true, secret, nil
 }
 
+// defaultCertificateHostnames returns the hostnames that the operator-generated
+// default certificate for the given ingresscontroller covers: the wildcard for
+// the ingresscontroller's domain and, while the ingresscontroller migrates to a
+// new domain, the wildcard for its previous domain.
+func defaultCertificateHostnames(ci *operatorv1.IngressController) sets.Set[string] {
+	hostnames := sets.New(fmt.Sprintf("*.%s", ci.Status.Domain))
+	if previousDomain := ingresscontroller.PreviousDomain(ci); len(previousDomain) != 0 {
+		hostnames.Insert(fmt.Sprintf("*.%s", previousDomain))
+	}
+	return hostnames
+}
+
+// certificateHostnames returns the DNS names of the certificate in the given
+// secret, or an empty set if the secret does not have a valid certificate.
+func certificateHostnames(secret *corev1.Secret) sets.Set[string] {
+	block, _ := pem.Decode(secret.Data["tls.crt"])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return sets.New[string]()
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return sets.New[string]()
+	}
+	return sets.New(cert.DNSNames...)
+}
+
+// defaultCertificateValid returns the expiration time of the certificate in the
+// given secret and a Boolean value indicating whether the certificate has a
+// key, covers exactly the given hostnames, is signed by the given CA, and remains
+// valid for at least defaultCertificateRefreshPeriod after now.
+func defaultCertificateValid(secret *corev1.Secret, ca *crypto.CA, hostnames sets.Set[string], now time.Time) (time.Time, bool) {
+	if len(secret.Data["tls.key"]) == 0 {
+		return time.Time{}, false
+	}
+	certs := parseCertificates(secret.Data["tls.crt"])
+	if len(certs) == 0 {
+		return time.Time{}, false
+	}
+	cert := certs[0]
+	if !sets.New(cert.DNSNames...).Equal(hostnames) {
+		return cert.NotAfter, false
+	}
+	// Trust only the signer's own certificate, not its issuers, so
+	// that a certificate that the issuer of an intermediate CA signed
+	// directly is replaced with one that the intermediate CA signs.
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Config.Certs[0])
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range certs[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return cert.NotAfter, false
+	}
+	return cert.NotAfter, now.Add(defaultCertificateRefreshPeriod).Before(cert.NotAfter)
+}
+
+// certificateNotAfter returns the expiration time of the (leaf) certificate in
+// the given secret, or the zero time if the secret does not have a valid
+// certificate.
+func certificateNotAfter(secret *corev1.Secret) time.Time {
+	certs := parseCertificates(secret.Data["tls.crt"])
+	if len(certs) == 0 {
+		return time.Time{}
+	}
+	return certs[0].NotAfter
+}
+
+// parseCertificates returns the certificates in the given PEM data, stopping at
+// the first block that is not a valid certificate.
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return certs
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certs
+		}
+		certs = append(certs, cert)
+		data = rest
+	}
+}
+
+// encodeCertificate returns the PEM encoding of the given certificate.
+func encodeCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
 // currentRouterDefaultCertificate returns the current router default
 // certificate secret.
 func (r *reconciler) currentRouterDefaultCertificate(ci *operatorv1.IngressController, namespace string) (bool, *corev1.Secret, error) {
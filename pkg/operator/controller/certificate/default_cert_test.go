@@ -1,14 +1,17 @@
 package certificate
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/openshift/library-go/pkg/crypto"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -59,9 +62,10 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 	}
 
 	testCases := []struct {
-		description string
-		ic          *operatorv1.IngressController
-		wantCert    bool
+		description     string
+		ic              *operatorv1.IngressController
+		wantCert        bool
+		expectHostnames []string
 	}{
 		{
 			description: "want operator generated default certificate",
@@ -73,7 +77,24 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 					Domain: "test.com",
 				},
 			},
-			wantCert: true,
+			wantCert:        true,
+			expectHostnames: []string{"*.test.com"},
+		},
+		{
+			description: "ingresscontroller migrating to a new domain",
+			ic: &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "default",
+					Annotations: map[string]string{
+						ingresscontroller.PreviousDomainAnnotation: "old.test.com",
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					Domain: "test.com",
+				},
+			},
+			wantCert:        true,
+			expectHostnames: []string{"*.old.test.com", "*.test.com"},
 		},
 		{
 			description: "domain not set",
@@ -122,7 +143,7 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			wantCert, _, err := desiredRouterDefaultCertificateSecret(ca, "test-namespace", metav1.OwnerReference{Name: "test-ref"}, tc.ic)
+			wantCert, secret, err := desiredRouterDefaultCertificateSecret(ca, "test-namespace", metav1.OwnerReference{Name: "test-ref"}, tc.ic)
 			switch {
 			case err != nil:
 				t.Fatalf("unexpected error: %v", err)
@@ -131,6 +152,11 @@ func Test_desiredRouterDefaultCertificateSecret(t *testing.T) {
 			case !tc.wantCert && wantCert:
 				t.Fatal("expected no default certificate")
 			}
+			if len(tc.expectHostnames) != 0 {
+				if actual := sets.List(certificateHostnames(secret)); !reflect.DeepEqual(actual, tc.expectHostnames) {
+					t.Errorf("expected hostnames %v, got %v", tc.expectHostnames, actual)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,144 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultCertificateSignerSecret returns the secret with the CA that signs the
+// operator-generated default certificate for the given ingresscontroller:
+// the intermediate CA secret that the ingresscontroller specifies, if any, or
+// else the given router CA secret.
+func (r *reconciler) defaultCertificateSignerSecret(caSecret *corev1.Secret, ci *operatorv1.IngressController) (*corev1.Secret, error) {
+	secretName, err := ingresscontroller.DefaultCertificateSignerForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if len(secretName) == 0 {
+		return caSecret, nil
+	}
+	secret := &corev1.Secret{}
+	name := types.NamespacedName{Namespace: r.operatorNamespace, Name: secretName}
+	if err := r.client.Get(context.TODO(), name, secret); err != nil {
+		return nil, fmt.Errorf("failed to get default certificate signer secret %s: %w", name, err)
+	}
+	return secret, nil
+}
+
+// signerFromSecret returns the CA in the given secret.  An error is returned
+// if the secret does not have a CA certificate and its key.
+func signerFromSecret(secret *corev1.Secret) (*crypto.CA, error) {
+	ca, err := crypto.GetCAFromBytes(secret.Data["tls.crt"], secret.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CA from secret %s/%s: %v", secret.Namespace, secret.Name, err)
+	}
+	if len(ca.Config.Certs) == 0 || !ca.Config.Certs[0].IsCA {
+		return nil, fmt.Errorf("secret %s/%s does not have a CA certificate", secret.Namespace, secret.Name)
+	}
+	return ca, nil
+}
+
+// ensureIngressCABundle publishes the certificates of the router CA and of the
+// intermediate CAs that ingresscontrollers specify for signing their generated
+// default certificates in the ingress CA bundle configmap.  Signers of
+// ingresscontrollers that use a user-specified default certificate are
+// omitted.
+func (r *reconciler) ensureIngressCABundle(caSecret *corev1.Secret) error {
+	ingresses := &operatorv1.IngressControllerList{}
+	if err := r.client.List(context.TODO(), ingresses, client.InNamespace(r.operatorNamespace)); err != nil {
+		return fmt.Errorf("failed to list ingresscontrollers: %w", err)
+	}
+	signers := map[string]*corev1.Secret{caSecret.Name: caSecret}
+	for i := range ingresses.Items {
+		ci := &ingresses.Items[i]
+		if ci.Spec.DefaultCertificate != nil && ci.Spec.DefaultCertificate.Name != controller.RouterOperatorGeneratedDefaultCertificateSecretName(ci, "").Name {
+			continue
+		}
+		secretName, err := ingresscontroller.DefaultCertificateSignerForIngressController(ci)
+		if err != nil || len(secretName) == 0 {
+			continue
+		}
+		if _, ok := signers[secretName]; ok {
+			continue
+		}
+		signer, err := r.defaultCertificateSignerSecret(caSecret, ci)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		signers[secretName] = signer
+	}
+	desired := desiredIngressCABundleConfigMap(signers)
+
+	current := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), controller.IngressCABundleConfigMapName(), current); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get ingress CA bundle configmap: %w", err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create ingress CA bundle configmap: %w", err)
+		}
+		log.Info("created ingress CA bundle configmap", "configmap", controller.IngressCABundleConfigMapName())
+		return nil
+	}
+	if current.Data["ca-bundle.crt"] == desired.Data["ca-bundle.crt"] {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = desired.Data
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update ingress CA bundle configmap: %w", err)
+	}
+	log.Info("updated ingress CA bundle configmap", "configmap", controller.IngressCABundleConfigMapName())
+	return nil
+}
+
+// desiredIngressCABundleConfigMap returns the desired ingress CA bundle
+// configmap with the certificates of the given signer secrets, ordered by
+// secret name and without duplicates.
+func desiredIngressCABundleConfigMap(signers map[string]*corev1.Secret) *corev1.ConfigMap {
+	names := make([]string, 0, len(signers))
+	for name := range signers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var bundle bytes.Buffer
+	seen := sets.New[string]()
+	for _, name := range names {
+		for _, cert := range parseCertificates(signers[name].Data["tls.crt"]) {
+			if seen.Has(string(cert.Raw)) {
+				continue
+			}
+			seen.Insert(string(cert.Raw))
+			bundle.Write(encodeCertificate(cert))
+		}
+	}
+	name := controller.IngressCABundleConfigMapName()
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Data: map[string]string{
+			"ca-bundle.crt": bundle.String(),
+		},
+	}
+}
@@ -0,0 +1,280 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestIntermediateCA returns a new intermediate CA signed by the given CA
+// and a secret with the intermediate CA's certificate chain and key.
+func newTestIntermediateCA(t *testing.T, issuer *crypto.CA, name string) (*crypto.CA, *corev1.Secret) {
+	t.Helper()
+	config, err := crypto.MakeCAConfigForDuration(name, 365*24*time.Hour, issuer)
+	if err != nil {
+		t.Fatalf("failed to make intermediate CA: %v", err)
+	}
+	certBytes, keyBytes, err := config.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode intermediate CA: %v", err)
+	}
+	ca := &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: name},
+		Data: map[string][]byte{
+			"tls.crt": certBytes,
+			"tls.key": keyBytes,
+		},
+	}
+	return ca, secret
+}
+
+// newTestShard returns an ingresscontroller with the given name and domain
+// and, if signer is not empty, the given default certificate signer.
+func newTestShard(name, domain, signer string) *operatorv1.IngressController {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: name},
+		Status:     operatorv1.IngressControllerStatus{Domain: domain},
+	}
+	if len(signer) != 0 {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+			Raw: []byte(`{"defaultCertificateSigner":{"secretName":"` + signer + `"}}`),
+		}
+	}
+	return ic
+}
+
+// newTestReconciler returns a reconciler with a fake client that has the given
+// objects.
+func newTestReconciler(objs ...client.Object) *reconciler {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	operatorv1.Install(scheme)
+	return &reconciler{
+		client:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		recorder:          record.NewFakeRecorder(10),
+		operatorNamespace: "openshift-ingress-operator",
+	}
+}
+
+// Test_ensureDefaultCertificateForIngress_shards verifies that
+// ensureDefaultCertificateForIngress generates a wildcard certificate for each
+// shard's own domain, signed by the router CA or by the intermediate CA that
+// the shard specifies, such that clients that trust only the router CA can
+// verify either certificate.
+func Test_ensureDefaultCertificateForIngress_shards(t *testing.T) {
+	ca, caSecret := newTestCA(t, "ingress-operator")
+	_, intermediateSecret := newTestIntermediateCA(t, ca, "shard-signer")
+	defaultShard := newTestShard("default", "apps.example.com", "")
+	internalShard := newTestShard("internal", "internal.apps.example.com", intermediateSecret.Name)
+	r := newTestReconciler(caSecret, intermediateSecret, defaultShard, internalShard)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Config.Certs[0])
+	for _, ic := range []*operatorv1.IngressController{defaultShard, internalShard} {
+		haveCert, refreshAt, err := r.ensureDefaultCertificateForIngress(caSecret, "openshift-ingress", metav1.OwnerReference{}, ic)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", ic.Name, err)
+		}
+		if !haveCert || refreshAt.Before(time.Now().Add(200*24*time.Hour)) {
+			t.Errorf("%s: expected a certificate with a refresh time far in the future, got %t, %v", ic.Name, haveCert, refreshAt)
+		}
+		secret := &corev1.Secret{}
+		if err := r.client.Get(context.Background(), controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, "openshift-ingress"), secret); err != nil {
+			t.Fatalf("%s: failed to get default certificate: %v", ic.Name, err)
+		}
+		certs := parseCertificates(secret.Data["tls.crt"])
+		if len(certs) == 0 {
+			t.Fatalf("%s: expected a certificate, got none", ic.Name)
+		}
+		expectHostnames := sets.New("*." + ic.Status.Domain)
+		if actual := sets.New(certs[0].DNSNames...); !actual.Equal(expectHostnames) {
+			t.Errorf("%s: expected hostnames %v, got %v", ic.Name, sets.List(expectHostnames), sets.List(actual))
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		opts := x509.VerifyOptions{
+			DNSName:       "foo." + ic.Status.Domain,
+			Roots:         roots,
+			Intermediates: intermediates,
+		}
+		chains, err := certs[0].Verify(opts)
+		if err != nil {
+			t.Fatalf("%s: failed to verify certificate: %v", ic.Name, err)
+		}
+		expectIssuer := "ingress-operator"
+		if ic == internalShard {
+			expectIssuer = "shard-signer"
+		}
+		if actual := chains[0][1].Subject.CommonName; actual != expectIssuer {
+			t.Errorf("%s: expected the certificate to be issued by %q, got %q", ic.Name, expectIssuer, actual)
+		}
+	}
+}
+
+// Test_ensureDefaultCertificateForIngress_rotation verifies that
+// ensureDefaultCertificateForIngress leaves a valid certificate alone and
+// replaces a certificate that is about to expire or that is not signed by the
+// ingresscontroller's current signer.
+func Test_ensureDefaultCertificateForIngress_rotation(t *testing.T) {
+	ca, caSecret := newTestCA(t, "ingress-operator")
+	intermediate, intermediateSecret := newTestIntermediateCA(t, ca, "shard-signer")
+	ic := newTestShard("internal", "internal.apps.example.com", "")
+	name := controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, "openshift-ingress")
+
+	secretFor := func(ca *crypto.CA, lifetime time.Duration) *corev1.Secret {
+		cert, err := ca.MakeServerCertForDuration(defaultCertificateHostnames(ic), lifetime)
+		if err != nil {
+			t.Fatalf("failed to make certificate: %v", err)
+		}
+		certBytes, keyBytes, err := cert.GetPEMBytes()
+		if err != nil {
+			t.Fatalf("failed to encode certificate: %v", err)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+			Type:       corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				"tls.crt": certBytes,
+				"tls.key": keyBytes,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		signer       string
+		current      *corev1.Secret
+		expectUpdate bool
+	}{
+		{
+			name:    "valid certificate",
+			current: secretFor(ca, 365*24*time.Hour),
+		},
+		{
+			name:         "certificate about to expire",
+			current:      secretFor(ca, 30*24*time.Hour),
+			expectUpdate: true,
+		},
+		{
+			name:         "certificate not signed by the intermediate CA",
+			signer:       intermediateSecret.Name,
+			current:      secretFor(ca, 365*24*time.Hour),
+			expectUpdate: true,
+		},
+		{
+			name:    "certificate signed by the intermediate CA",
+			signer:  intermediateSecret.Name,
+			current: secretFor(intermediate, 365*24*time.Hour),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := newTestShard(ic.Name, ic.Status.Domain, tc.signer)
+			r := newTestReconciler(caSecret, intermediateSecret, ic, tc.current.DeepCopy())
+			if _, _, err := r.ensureDefaultCertificateForIngress(caSecret, "openshift-ingress", metav1.OwnerReference{}, ic); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			secret := &corev1.Secret{}
+			if err := r.client.Get(context.Background(), name, secret); err != nil {
+				t.Fatalf("failed to get default certificate: %v", err)
+			}
+			updated := string(secret.Data["tls.crt"]) != string(tc.current.Data["tls.crt"])
+			if updated != tc.expectUpdate {
+				t.Fatalf("expected update to be %t, got %t", tc.expectUpdate, updated)
+			}
+			signer := ca
+			if len(tc.signer) != 0 {
+				signer = intermediate
+			}
+			if _, ok := defaultCertificateValid(secret, signer, defaultCertificateHostnames(ic), time.Now()); !ok {
+				t.Errorf("expected a valid certificate signed by %q", signer.Config.Certs[0].Subject.CommonName)
+			}
+		})
+	}
+}
+
+// Test_ensureDefaultCertificateForIngress_invalidSigner verifies that
+// ensureDefaultCertificateForIngress reports an error if the specified signer
+// secret does not exist or does not have a CA certificate.
+func Test_ensureDefaultCertificateForIngress_invalidSigner(t *testing.T) {
+	ca, caSecret := newTestCA(t, "ingress-operator")
+	leaf, err := ca.MakeServerCert(sets.New("*.apps.example.com"), 0)
+	if err != nil {
+		t.Fatalf("failed to make certificate: %v", err)
+	}
+	certBytes, keyBytes, err := leaf.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode certificate: %v", err)
+	}
+	leafSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "not-a-ca"},
+		Data: map[string][]byte{
+			"tls.crt": certBytes,
+			"tls.key": keyBytes,
+		},
+	}
+	for _, signer := range []string{"missing", leafSecret.Name} {
+		ic := newTestShard("internal", "internal.apps.example.com", signer)
+		r := newTestReconciler(caSecret, leafSecret, ic)
+		if _, _, err := r.ensureDefaultCertificateForIngress(caSecret, "openshift-ingress", metav1.OwnerReference{}, ic); err == nil {
+			t.Errorf("%s: expected an error, got nil", signer)
+		}
+	}
+}
+
+// Test_ensureIngressCABundle verifies that ensureIngressCABundle publishes the
+// router CA and the intermediate CAs that ingresscontrollers use to sign their
+// generated default certificates, without duplicates, and omits the signers
+// of ingresscontrollers that specify their own default certificates.
+func Test_ensureIngressCABundle(t *testing.T) {
+	ca, caSecret := newTestCA(t, "ingress-operator")
+	intermediate, intermediateSecret := newTestIntermediateCA(t, ca, "shard-signer")
+	unused, unusedSecret := newTestIntermediateCA(t, ca, "unused-signer")
+	custom := newTestShard("custom", "custom.example.com", unusedSecret.Name)
+	custom.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: "custom-cert"}
+	r := newTestReconciler(
+		caSecret, intermediateSecret, unusedSecret,
+		newTestShard("default", "apps.example.com", ""),
+		newTestShard("internal", "internal.apps.example.com", intermediateSecret.Name),
+		newTestShard("internal2", "internal2.apps.example.com", intermediateSecret.Name),
+		custom,
+	)
+
+	if err := r.ensureIngressCABundle(caSecret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(context.Background(), controller.IngressCABundleConfigMapName(), cm); err != nil {
+		t.Fatalf("failed to get ingress CA bundle configmap: %v", err)
+	}
+	actual := sets.New[string]()
+	certs := parseCertificates([]byte(cm.Data["ca-bundle.crt"]))
+	for _, cert := range certs {
+		actual.Insert(cert.Subject.CommonName)
+	}
+	expect := sets.New(ca.Config.Certs[0].Subject.CommonName, intermediate.Config.Certs[0].Subject.CommonName)
+	if len(certs) != expect.Len() || !actual.Equal(expect) {
+		t.Errorf("expected CA bundle with %v, got %d certificates for %v", sets.List(expect), len(certs), sets.List(actual))
+	}
+	if actual.Has(unused.Config.Certs[0].Subject.CommonName) {
+		t.Errorf("expected CA bundle to omit the signer of an ingresscontroller with a custom default certificate")
+	}
+}
@@ -0,0 +1,388 @@
+package defaultcertdependents
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/client-go/tools/record"
+
+	utilclock "k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	runtimecontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "default_certificate_dependents_controller"
+
+	// propagationGracePeriod is how long the dependents may take to serve
+	// a new default certificate before the propagation is reported as
+	// stalled.
+	propagationGracePeriod = 10 * time.Minute
+
+	// pendingResyncInterval is how often the controller checks the
+	// dependents while they do not serve the current default certificate.
+	// The dependents' copies of the certificate are in namespaces that the
+	// operator does not watch, so the controller polls them.
+	pendingResyncInterval = 30 * time.Second
+	// resyncInterval is how often the controller checks the dependents
+	// while they serve the current default certificate.
+	resyncInterval = 10 * time.Minute
+)
+
+var (
+	log = logf.Logger.WithName(controllerName)
+
+	// clock is used to determine how long ago the default certificate
+	// changed.
+	clock utilclock.Clock = utilclock.RealClock{}
+)
+
+// New creates and returns a controller that reports in the default
+// ingresscontroller's DefaultCertificatePropagated status condition whether
+// the platform components that depend on the default certificate, namely the
+// console and the OAuth server, have picked up the current default
+// certificate, and that emits events when the default certificate changes and
+// when the change has propagated so that the rollout can be correlated with
+// disruption to those components.
+func New(mgr manager.Manager, config Config) (runtimecontroller.Controller, error) {
+	operatorCache := mgr.GetCache()
+	reconciler := &reconciler{
+		config:   config,
+		client:   mgr.GetClient(),
+		cache:    operatorCache,
+		recorder: mgr.GetEventRecorderFor(controllerName),
+		probe:    probeServedCertificate,
+	}
+	c, err := runtimecontroller.New(controllerName, mgr, runtimecontroller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	isDefault := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == config.OperatorNamespace && o.GetName() == manifests.DefaultIngressControllerName
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &operatorv1.IngressController{}, &handler.EnqueueRequestForObject{}, isDefault)); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(reconciler.secretToDefaultIngressController))); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config holds all the configuration that must be provided when creating the
+// controller.
+type Config struct {
+	// OperatorNamespace is the namespace of the ingresscontrollers.
+	OperatorNamespace string
+	// OperandNamespace is the namespace of the default certificate
+	// secrets.
+	OperandNamespace string
+}
+
+// propagationState tracks the propagation of a default certificate to the
+// dependents.
+type propagationState struct {
+	// fingerprint is the fingerprint of the default certificate.
+	fingerprint string
+	// since is when the controller first observed the default
+	// certificate.
+	since time.Time
+	// changed indicates whether the default certificate replaced a
+	// previously observed certificate, as opposed to being observed when
+	// the operator started.
+	changed bool
+	// propagated indicates whether the dependents have been observed to
+	// serve the default certificate.
+	propagated bool
+	// stalledReported indicates whether a stalled propagation has been
+	// reported in an event.
+	stalledReported bool
+	// verified has the names of the dependents whose hosts the post-change
+	// verification probe has observed to serve the default certificate.
+	verified map[string]bool
+	// verificationFailedReported indicates whether the post-change
+	// verification probe's timing out has been reported in an event.
+	verificationFailedReported bool
+}
+
+// reconciler handles the actual default certificate dependents logic.
+type reconciler struct {
+	config Config
+
+	client   client.Client
+	cache    client.Reader
+	recorder record.EventRecorder
+	// probe returns the fingerprint of the certificate that a host
+	// serves.
+	probe probeFunc
+
+	// state is the propagation state of the default ingresscontroller's
+	// current default certificate, or nil if no certificate has been
+	// observed.  It is only accessed from Reconcile, which the controller
+	// never runs concurrently.
+	state *propagationState
+}
+
+// secretToDefaultIngressController maps a secret to a request for the default
+// ingresscontroller if the secret is the default ingresscontroller's effective
+// default certificate.
+func (r *reconciler) secretToDefaultIngressController(ctx context.Context, o client.Object) []reconcile.Request {
+	name := types.NamespacedName{Namespace: r.config.OperatorNamespace, Name: manifests.DefaultIngressControllerName}
+	ic := &operatorv1.IngressController{}
+	if err := r.cache.Get(ctx, name, ic); err != nil {
+		if !kerrors.IsNotFound(err) {
+			log.Error(err, "failed to get ingresscontroller", "name", name)
+		}
+		return nil
+	}
+	if controller.RouterEffectiveDefaultCertificateSecretName(ic, r.config.OperandNamespace) != (types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: name}}
+}
+
+// dependentStatus is the status of a dependent with respect to the current
+// default certificate.
+type dependentStatus struct {
+	name string
+	// serving indicates whether the dependent serves the current default
+	// certificate.
+	serving bool
+	// detail describes why the dependent does not serve the current
+	// default certificate.
+	detail string
+}
+
+// Reconcile expects request to refer to the default ingresscontroller.  It
+// determines the fingerprint of the default certificate, emits an event if
+// the certificate has changed, checks whether each dependent has picked up the
+// certificate and, if the ingresscontroller enables the post-change
+// verification probe, whether each dependent's host serves it, and updates the
+// DefaultCertificatePropagated condition.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	ic := &operatorv1.IngressController{}
+	if err := r.client.Get(ctx, request.NamespacedName, ic); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.state = nil
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get ingresscontroller %q: %w", request.NamespacedName, err)
+	}
+	if ic.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	secretName := controller.RouterEffectiveDefaultCertificateSecretName(ic, r.config.OperandNamespace)
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, secretName, secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			// The secret watch requeues the ingresscontroller once
+			// the secret is created.
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get secret %q: %w", secretName, err)
+	}
+	current, err := leafFingerprint(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		log.Error(err, "failed to parse default certificate", "secret", secretName)
+		return reconcile.Result{}, nil
+	}
+
+	r.observeCertificate(ic, current)
+
+	probe, err := ingresscontroller.DefaultCertificateVerificationProbeForIngressController(ic)
+	if err == nil && probe != nil {
+		err = ingresscontroller.ValidateDefaultCertificateVerificationProbe(probe)
+	}
+	if err != nil {
+		// The ingress controller reports the invalid probe.
+		probe = nil
+	}
+
+	var statuses []dependentStatus
+	for _, d := range dependents {
+		status, present, err := r.checkDependent(ctx, ic, d, probe)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if present {
+			statuses = append(statuses, status)
+		}
+	}
+
+	cond := r.computeCondition(ic, statuses)
+	if err := r.setStatusCondition(ctx, ic, cond); err != nil {
+		return reconcile.Result{}, err
+	}
+	if cond.Status == operatorv1.ConditionTrue {
+		return reconcile.Result{RequeueAfter: resyncInterval}, nil
+	}
+	return reconcile.Result{RequeueAfter: pendingResyncInterval}, nil
+}
+
+// observeCertificate records the default certificate with the given
+// fingerprint and emits an event if it replaced a previously observed
+// certificate.
+func (r *reconciler) observeCertificate(ic *operatorv1.IngressController, current string) {
+	if r.state != nil && r.state.fingerprint == current {
+		return
+	}
+	state := &propagationState{
+		fingerprint: current,
+		since:       clock.Now(),
+		verified:    map[string]bool{},
+	}
+	if r.state != nil {
+		state.changed = true
+		r.recorder.Eventf(ic, corev1.EventTypeNormal, "DefaultCertificateChanged", "The default certificate changed from SHA-256 fingerprint %s to %s; the console and OAuth server may be briefly unavailable until they serve the new certificate", r.state.fingerprint, current)
+	}
+	r.state = state
+}
+
+// checkDependent determines whether the given dependent serves the current
+// default certificate.  If the given post-change verification probe is not nil
+// and has not timed out, the dependent's host is probed until it has been
+// observed to serve the certificate.  Returns the dependent's status, a Boolean
+// value indicating whether the dependent is installed, and an error value.
+func (r *reconciler) checkDependent(ctx context.Context, ic *operatorv1.IngressController, d dependent, probe *ingresscontroller.DefaultCertificateVerificationProbe) (dependentStatus, bool, error) {
+	status := dependentStatus{name: d.name}
+	data, err := d.copiedCertificates(ctx, r.client)
+	if err != nil {
+		return status, false, err
+	}
+	if data == nil {
+		return status, false, nil
+	}
+	if !containsCertificate(data, r.state.fingerprint) {
+		status.detail = "has not picked up the current default certificate"
+		return status, true, nil
+	}
+
+	if probe == nil || !r.state.changed || r.state.verified[d.name] {
+		status.serving = true
+		return status, true, nil
+	}
+	if clock.Since(r.state.since) > probe.TimeoutDuration() {
+		// Fall back to the dependent's copy of the certificate rather
+		// than reporting the dependent as lagging indefinitely, for
+		// example if the operator cannot reach the dependent's host.
+		if !r.state.verificationFailedReported {
+			r.state.verificationFailedReported = true
+			r.recorder.Eventf(ic, corev1.EventTypeWarning, "DefaultCertificateVerificationFailed", "Failed to verify within %s that the hosts of the console and OAuth server serve the default certificate with SHA-256 fingerprint %s", probe.Timeout, r.state.fingerprint)
+		}
+		status.serving = true
+		return status, true, nil
+	}
+	route := &routev1.Route{}
+	if err := r.client.Get(ctx, d.route, route); err != nil {
+		if kerrors.IsNotFound(err) {
+			status.serving = true
+			return status, true, nil
+		}
+		return status, true, fmt.Errorf("failed to get route %s: %w", d.route, err)
+	}
+	served, err := r.probe(ctx, route.Spec.Host)
+	switch {
+	case err != nil:
+		status.detail = fmt.Sprintf("could not be probed at %s: %v", route.Spec.Host, err)
+	case served != r.state.fingerprint:
+		status.detail = fmt.Sprintf("serves the certificate with SHA-256 fingerprint %s at %s", served, route.Spec.Host)
+	default:
+		r.state.verified[d.name] = true
+		status.serving = true
+	}
+	return status, true, nil
+}
+
+// computeCondition returns the DefaultCertificatePropagated condition for the
+// given statuses of the installed dependents, and emits events when the
+// current default certificate has propagated or its propagation has stalled.
+func (r *reconciler) computeCondition(ic *operatorv1.IngressController, statuses []dependentStatus) operatorv1.OperatorCondition {
+	cond := operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerDefaultCertificatePropagatedConditionType,
+	}
+	if len(statuses) == 0 {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "NoDependents"
+		cond.Message = "No platform component that depends on the default certificate is installed."
+		return cond
+	}
+
+	var serving, lagging []string
+	for _, status := range statuses {
+		if status.serving {
+			serving = append(serving, status.name)
+		} else {
+			lagging = append(lagging, fmt.Sprintf("%s %s", status.name, status.detail))
+		}
+	}
+	sort.Strings(serving)
+	sort.Strings(lagging)
+	elapsed := clock.Since(r.state.since).Round(time.Second)
+
+	if len(lagging) == 0 {
+		if r.state.changed && !r.state.propagated {
+			r.recorder.Eventf(ic, corev1.EventTypeNormal, "DefaultCertificatePropagated", "The default certificate with SHA-256 fingerprint %s propagated to %s in %s", r.state.fingerprint, strings.Join(serving, ", "), elapsed)
+		}
+		r.state.propagated = true
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "DependentsServingCurrentCertificate"
+		cond.Message = fmt.Sprintf("The dependent platform components (%s) serve the current default certificate with SHA-256 fingerprint %s.", strings.Join(serving, ", "), r.state.fingerprint)
+		return cond
+	}
+
+	cond.Status = operatorv1.ConditionFalse
+	message := fmt.Sprintf("The default certificate with SHA-256 fingerprint %s was observed %s ago, but not every dependent platform component serves it:\n%s", r.state.fingerprint, elapsed, strings.Join(lagging, "\n"))
+	if elapsed < propagationGracePeriod {
+		cond.Reason = "PropagationInProgress"
+		cond.Message = message
+		return cond
+	}
+	if !r.state.stalledReported {
+		r.state.stalledReported = true
+		r.recorder.Eventf(ic, corev1.EventTypeWarning, "DefaultCertificatePropagationStalled", "The default certificate with SHA-256 fingerprint %s has not propagated after %s: %s", r.state.fingerprint, elapsed, strings.Join(lagging, "; "))
+	}
+	cond.Reason = "PropagationStalled"
+	cond.Message = message
+	return cond
+}
+
+// setStatusCondition applies the given condition to the given
+// ingresscontroller.  The condition does not overlap with any of the status
+// conditions that the ingress controller sets in
+// pkg/operator/controller/ingress/status.go.
+func (r *reconciler) setStatusCondition(ctx context.Context, ic *operatorv1.IngressController, cond operatorv1.OperatorCondition) error {
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = ingresscontroller.MergeConditions(updated.Status.Conditions, cond)
+	if ingresscontroller.IngressStatusesEqual(updated.Status, ic.Status) {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %w", ic.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package defaultcertdependents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/client-go/tools/record"
+
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newTestCertificate returns a new PEM-encoded serving certificate for the
+// given host and the certificate's fingerprint.
+func newTestCertificate(t *testing.T, ca *crypto.CA, host string) ([]byte, string) {
+	t.Helper()
+	cert, err := ca.MakeServerCertForDuration(sets.New(host), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make server certificate: %v", err)
+	}
+	certBytes, _, err := cert.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode server certificate: %v", err)
+	}
+	return certBytes, fingerprint(cert.Certs[0].Raw)
+}
+
+// Test_Reconcile verifies that the DefaultCertificatePropagated condition and
+// the controller's events follow a timeline of default certificate changes and
+// the dependents' picking up the changes.
+func Test_Reconcile(t *testing.T) {
+	fakeClock := utilclocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	clock = fakeClock
+	defer func() {
+		clock = utilclock.RealClock{}
+	}()
+
+	config, err := crypto.MakeSelfSignedCAConfigForDuration("ingress-operator", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make CA: %v", err)
+	}
+	ca := &crypto.CA{Config: config, SerialGenerator: &crypto.RandomSerialGenerator{}}
+	oldCert, oldFingerprint := newTestCertificate(t, ca, "*.apps.example.com")
+	newCert, newFingerprint := newTestCertificate(t, ca, "*.apps.example.com")
+
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default"}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+	}
+	defaultCert := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "router-certs-default",
+		},
+		Data: map[string][]byte{corev1.TLSCertKey: oldCert},
+	}
+	consoleCopy := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-console",
+			Name:      "default-ingress-cert",
+		},
+		Data: map[string]string{"ca-bundle.crt": string(oldCert)},
+	}
+	oauthCopy := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-authentication",
+			Name:      "v4-0-config-system-router-certs",
+		},
+		Data: map[string][]byte{"apps.example.com": oldCert},
+	}
+	consoleRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-console",
+			Name:      "console",
+		},
+		Spec: routev1.RouteSpec{Host: "console-openshift-console.apps.example.com"},
+	}
+	oauthRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-authentication",
+			Name:      "oauth-openshift",
+		},
+		Spec: routev1.RouteSpec{Host: "oauth-openshift.apps.example.com"},
+	}
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	routev1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ic, defaultCert, consoleCopy, oauthCopy, consoleRoute, oauthRoute).WithStatusSubresource(ic).Build()
+	recorder := record.NewFakeRecorder(10)
+	// served maps each host to the fingerprint of the certificate that
+	// the stubbed probe reports the host as serving.
+	served := map[string]string{}
+	r := &reconciler{
+		config: Config{
+			OperatorNamespace: "openshift-ingress-operator",
+			OperandNamespace:  "openshift-ingress",
+		},
+		client:   cl,
+		cache:    cl,
+		recorder: recorder,
+		probe: func(_ context.Context, host string) (string, error) {
+			if fp, ok := served[host]; ok {
+				return fp, nil
+			}
+			return "", fmt.Errorf("dial tcp %s:443: connection refused", host)
+		},
+	}
+
+	update := func(o client.Object, mutate func()) {
+		t.Helper()
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(o), o); err != nil {
+			t.Fatalf("failed to get %s: %v", o.GetName(), err)
+		}
+		mutate()
+		if err := cl.Update(context.Background(), o); err != nil {
+			t.Fatalf("failed to update %s: %v", o.GetName(), err)
+		}
+	}
+	setProbe := func(raw string) {
+		t.Helper()
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), name, current); err != nil {
+			t.Fatalf("failed to get ingresscontroller: %v", err)
+		}
+		current.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(raw)}
+		if err := cl.Update(context.Background(), current); err != nil {
+			t.Fatalf("failed to update ingresscontroller: %v", err)
+		}
+	}
+	expect := func(description string, expectedStatus operatorv1.ConditionStatus, expectedReason string, expectedRequeue time.Duration, expectedEvents ...string) {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: name})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if result.RequeueAfter != expectedRequeue {
+			t.Errorf("%s: expected requeue after %v, got %v", description, expectedRequeue, result.RequeueAfter)
+		}
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), name, current); err != nil {
+			t.Fatalf("%s: failed to get ingresscontroller: %v", description, err)
+		}
+		var cond *operatorv1.OperatorCondition
+		for i := range current.Status.Conditions {
+			if current.Status.Conditions[i].Type == ingresscontroller.IngressControllerDefaultCertificatePropagatedConditionType {
+				cond = &current.Status.Conditions[i]
+			}
+		}
+		switch {
+		case cond == nil:
+			t.Errorf("%s: expected condition with status %s and reason %s, got none", description, expectedStatus, expectedReason)
+		case cond.Status != expectedStatus || cond.Reason != expectedReason:
+			t.Errorf("%s: expected condition with status %s and reason %s, got status %s and reason %s: %s", description, expectedStatus, expectedReason, cond.Status, cond.Reason, cond.Message)
+		}
+		var events []string
+		for len(recorder.Events) != 0 {
+			events = append(events, <-recorder.Events)
+		}
+		if len(events) != len(expectedEvents) {
+			t.Errorf("%s: expected events %v, got %v", description, expectedEvents, events)
+			return
+		}
+		for i := range events {
+			if !strings.HasPrefix(events[i], expectedEvents[i]) {
+				t.Errorf("%s: expected event %d to start with %q, got %q", description, i, expectedEvents[i], events[i])
+			}
+		}
+	}
+
+	// The operator starts with both dependents serving the default
+	// certificate; no change is reported.
+	expect("initial sync", operatorv1.ConditionTrue, "DependentsServingCurrentCertificate", resyncInterval)
+
+	// The default certificate is rotated.  Neither dependent has picked it
+	// up yet.
+	fakeClock.Step(time.Hour)
+	update(defaultCert, func() { defaultCert.Data[corev1.TLSCertKey] = newCert })
+	expect("certificate changed", operatorv1.ConditionFalse, "PropagationInProgress", pendingResyncInterval, "Normal DefaultCertificateChanged")
+
+	// The console picks up the certificate within the grace period.
+	fakeClock.Step(2 * time.Minute)
+	update(consoleCopy, func() { consoleCopy.Data["ca-bundle.crt"] = string(newCert) })
+	expect("console propagated", operatorv1.ConditionFalse, "PropagationInProgress", pendingResyncInterval)
+
+	// The OAuth server lags past the grace period.  The stall is reported
+	// only once.
+	fakeClock.Step(propagationGracePeriod)
+	expect("oauth stalled", operatorv1.ConditionFalse, "PropagationStalled", pendingResyncInterval, "Warning DefaultCertificatePropagationStalled")
+	fakeClock.Step(time.Minute)
+	expect("oauth still stalled", operatorv1.ConditionFalse, "PropagationStalled", pendingResyncInterval)
+
+	// The OAuth server catches up.
+	update(oauthCopy, func() { oauthCopy.Data["apps.example.com"] = newCert })
+	expect("oauth propagated", operatorv1.ConditionTrue, "DependentsServingCurrentCertificate", resyncInterval, "Normal DefaultCertificatePropagated")
+	expect("steady state", operatorv1.ConditionTrue, "DependentsServingCurrentCertificate", resyncInterval)
+
+	// With the post-change verification probe enabled, the dependents'
+	// copies are not sufficient; their hosts must serve the certificate.
+	setProbe(`{"defaultCertificateVerificationProbe":{"timeout":"5m"}}`)
+	fakeClock.Step(time.Hour)
+	update(defaultCert, func() { defaultCert.Data[corev1.TLSCertKey] = oldCert })
+	update(consoleCopy, func() { consoleCopy.Data["ca-bundle.crt"] = string(oldCert) })
+	update(oauthCopy, func() { oauthCopy.Data["apps.example.com"] = oldCert })
+	served[consoleRoute.Spec.Host] = newFingerprint
+	served[oauthRoute.Spec.Host] = oldFingerprint
+	expect("probe sees stale console", operatorv1.ConditionFalse, "PropagationInProgress", pendingResyncInterval, "Normal DefaultCertificateChanged")
+	served[consoleRoute.Spec.Host] = oldFingerprint
+	expect("probe verified", operatorv1.ConditionTrue, "DependentsServingCurrentCertificate", resyncInterval, "Normal DefaultCertificatePropagated")
+
+	// If the probe cannot reach the hosts, the controller falls back to
+	// the dependents' copies once the probe times out.
+	fakeClock.Step(time.Hour)
+	update(defaultCert, func() { defaultCert.Data[corev1.TLSCertKey] = newCert })
+	update(consoleCopy, func() { consoleCopy.Data["ca-bundle.crt"] = string(newCert) })
+	update(oauthCopy, func() { oauthCopy.Data["apps.example.com"] = newCert })
+	delete(served, consoleRoute.Spec.Host)
+	delete(served, oauthRoute.Spec.Host)
+	expect("probe unreachable", operatorv1.ConditionFalse, "PropagationInProgress", pendingResyncInterval, "Normal DefaultCertificateChanged")
+	fakeClock.Step(6 * time.Minute)
+	expect("probe timed out", operatorv1.ConditionTrue, "DependentsServingCurrentCertificate", resyncInterval, "Warning DefaultCertificateVerificationFailed", "Normal DefaultCertificatePropagated")
+
+	// Without any dependents installed, the condition is true.
+	for _, o := range []client.Object{consoleCopy, oauthCopy} {
+		if err := cl.Delete(context.Background(), o); err != nil {
+			t.Fatalf("failed to delete %s: %v", o.GetName(), err)
+		}
+	}
+	expect("no dependents", operatorv1.ConditionTrue, "NoDependents", resyncInterval)
+}
@@ -0,0 +1,163 @@
+package defaultcertdependents
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dependent is a platform component whose route serves the default
+// ingresscontroller's default certificate, either through the router or, for a
+// passthrough route, from a copy of the certificate that the component's
+// operator makes.
+type dependent struct {
+	// name identifies the dependent in conditions and events.
+	name string
+	// route is the dependent's route, whose host the post-change
+	// verification probe checks.
+	route types.NamespacedName
+	// copiedCertificates returns the PEM-encoded data in which the
+	// dependent's operator has copied the default certificate, or nil if
+	// the dependent is not installed.
+	copiedCertificates func(ctx context.Context, cl client.Client) ([][]byte, error)
+}
+
+// dependents are the platform components that depend on the default
+// certificate.  The console operator copies the "default-ingress-cert"
+// configmap, which the certificate-publisher controller publishes, into the
+// console's namespace, and the authentication operator copies the
+// "router-certs" secret into the OAuth server's namespace, so each copy shows
+// which certificate the dependent has picked up.
+var dependents = []dependent{{
+	name:               "console",
+	route:              types.NamespacedName{Namespace: "openshift-console", Name: "console"},
+	copiedCertificates: configMapData(types.NamespacedName{Namespace: "openshift-console", Name: "default-ingress-cert"}),
+}, {
+	name:               "oauth",
+	route:              types.NamespacedName{Namespace: "openshift-authentication", Name: "oauth-openshift"},
+	copiedCertificates: secretData(types.NamespacedName{Namespace: "openshift-authentication", Name: "v4-0-config-system-router-certs"}),
+}}
+
+// configMapData returns a function that returns the values of the configmap
+// with the given name, or nil if the configmap does not exist.
+func configMapData(name types.NamespacedName) func(context.Context, client.Client) ([][]byte, error) {
+	return func(ctx context.Context, cl client.Client) ([][]byte, error) {
+		cm := &corev1.ConfigMap{}
+		if err := cl.Get(ctx, name, cm); err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		data := [][]byte{}
+		for _, v := range cm.Data {
+			data = append(data, []byte(v))
+		}
+		return data, nil
+	}
+}
+
+// secretData returns a function that returns the values of the secret with the
+// given name, or nil if the secret does not exist.  Only the certificates in
+// the values are used; private keys are ignored.
+func secretData(name types.NamespacedName) func(context.Context, client.Client) ([][]byte, error) {
+	return func(ctx context.Context, cl client.Client) ([][]byte, error) {
+		secret := &corev1.Secret{}
+		if err := cl.Get(ctx, name, secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		data := [][]byte{}
+		for _, v := range secret.Data {
+			data = append(data, v)
+		}
+		return data, nil
+	}
+}
+
+// fingerprint returns the hex-encoded SHA-256 fingerprint of the given
+// DER-encoded certificate.
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// leafFingerprint returns the fingerprint of the first certificate in the given
+// PEM-encoded data.
+func leafFingerprint(data []byte) (string, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return "", fmt.Errorf("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return fingerprint(block.Bytes), nil
+		}
+	}
+}
+
+// containsCertificate returns a Boolean value indicating whether any of the
+// given PEM-encoded data has a certificate with the given fingerprint.
+func containsCertificate(data [][]byte, want string) bool {
+	for _, rest := range data {
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type == "CERTIFICATE" && fingerprint(block.Bytes) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probeTimeout is how long the post-change verification probe waits to
+// establish a TLS connection with a host.
+const probeTimeout = 10 * time.Second
+
+// probeFunc returns the fingerprint of the certificate that the given host
+// serves.
+type probeFunc func(ctx context.Context, host string) (string, error)
+
+// probeServedCertificate returns the fingerprint of the certificate that the
+// given host serves on port 443 when the client specifies the host using SNI.
+// The certificate is not verified because the probe only compares it with the
+// default certificate.
+func probeServedCertificate(ctx context.Context, host string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "443"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("%s served no certificate", host)
+	}
+	return fingerprint(certs[0].Raw), nil
+}
@@ -0,0 +1,261 @@
+package defaultcertsource
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+	utilingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "default_certificate_source_controller"
+
+	// finalizer is the finalizer with which the controller ensures that it
+	// gets a chance to delete the copy of the default certificate secret
+	// when an ingresscontroller is deleted.
+	finalizer = "ingresscontroller.operator.openshift.io/finalizer-default-certificate-source"
+
+	// resyncInterval is how often the controller copies the source secret
+	// of an ingresscontroller's default certificate.  The source secrets
+	// are in namespaces that the operator does not watch, so the
+	// controller polls them to pick up rotated certificates.
+	resyncInterval = 1 * time.Minute
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// New creates a new controller that copies the secrets that ingresscontrollers
+// reference in other namespaces using the "defaultCertificateSource"
+// unsupported config override into the operand namespace, where the routers
+// use the copies as their default certificates.  The controller reports the
+// outcome of each copy in the ingresscontroller's
+// "DefaultCertificateSourceSynced" status condition.  This controller also adds
+// a finalizer to the ingresscontroller so that the controller can delete the
+// copy when the ingresscontroller is marked for deletion.
+func New(mgr manager.Manager, config Config) (controller.Controller, error) {
+	operatorCache := mgr.GetCache()
+	reconciler := &reconciler{
+		cache:    operatorCache,
+		client:   mgr.GetClient(),
+		config:   config,
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{
+		Reconciler: reconciler,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hasSource := func(o client.Object) bool {
+		source, err := utilingresscontroller.DefaultCertificateSource(o.(*operatorv1.IngressController))
+		return err == nil && source != nil
+	}
+	sourceOf := func(o client.Object) *types.NamespacedName {
+		source, _ := utilingresscontroller.DefaultCertificateSource(o.(*operatorv1.IngressController))
+		return source
+	}
+
+	// If the ingresscontroller's source secret reference changes,
+	// reconcile the ingresscontroller.
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &operatorv1.IngressController{}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasSource(e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return hasSource(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldIC := e.ObjectOld.(*operatorv1.IngressController)
+			newIC := e.ObjectNew.(*operatorv1.IngressController)
+			return !reflect.DeepEqual(sourceOf(oldIC), sourceOf(newIC)) ||
+				!reflect.DeepEqual(oldIC.Spec.DefaultCertificate, newIC.Spec.DefaultCertificate) ||
+				oldIC.DeletionTimestamp != newIC.DeletionTimestamp
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return hasSource(e.Object)
+		},
+	})); err != nil {
+		return nil, err
+	}
+
+	// If a copy is changed or deleted, reconcile its ingresscontroller so
+	// that the copy is restored.
+	isInNS := func(namespace string) func(o client.Object) bool {
+		return func(o client.Object) bool {
+			return o.GetNamespace() == namespace
+		}
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(reconciler.secretToIngressController), predicate.NewPredicateFuncs(isInNS(config.OperandNamespace)))); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Config holds all the things necessary for the controller to run.
+type Config struct {
+	// OperatorNamespace is the namespace of the ingresscontrollers.
+	OperatorNamespace string
+	// OperandNamespace is the namespace of the routers and of the copies
+	// of the source secrets.
+	OperandNamespace string
+}
+
+type reconciler struct {
+	cache    cache.Cache
+	client   client.Client
+	config   Config
+	recorder record.EventRecorder
+}
+
+// secretToIngressController maps a secret to a request for the
+// ingresscontroller whose copy of its default certificate source secret the
+// secret is.
+func (r *reconciler) secretToIngressController(ctx context.Context, o client.Object) []reconcile.Request {
+	controllers := &operatorv1.IngressControllerList{}
+	if err := r.cache.List(ctx, controllers, client.InNamespace(r.config.OperatorNamespace)); err != nil {
+		log.Error(err, "failed to list ingresscontrollers for secret", "namespace", o.GetNamespace(), "name", o.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range controllers.Items {
+		ic := &controllers.Items[i]
+		if operatorcontroller.RouterSyncedDefaultCertificateSecretName(ic, r.config.OperandNamespace).Name != o.GetName() {
+			continue
+		}
+		log.Info("queueing ingresscontroller", "name", ic.Name)
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: ic.Namespace,
+				Name:      ic.Name,
+			},
+		})
+	}
+	return requests
+}
+
+// Reconcile reconciles an ingresscontroller and the copy of its default
+// certificate source secret, if it specifies one.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	ic := &operatorv1.IngressController{}
+	if err := r.client.Get(ctx, request.NamespacedName, ic); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("ingresscontroller not found; reconciliation will be skipped", "request", request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get ingresscontroller %q: %w", request.NamespacedName, err)
+	}
+
+	source, err := utilingresscontroller.DefaultCertificateSource(ic)
+	if err != nil && ic.DeletionTimestamp == nil {
+		// The ingress controller reports the invalid overrides.  Keep
+		// any copy so that the router can continue to use it.
+		log.Error(err, "failed to get default certificate source", "request", request)
+		return reconcile.Result{}, nil
+	}
+	wantCopy := source != nil && ic.Spec.DefaultCertificate == nil && ic.DeletionTimestamp == nil
+
+	if wantCopy && !slice.ContainsString(ic.Finalizers, finalizer) {
+		// Ensure the ingresscontroller has a finalizer so we get a
+		// chance to delete the copy when the ingresscontroller is
+		// deleted.  As with the client CA configmap, the copy must
+		// exist before the router deployment, so it cannot be owned
+		// by the deployment.
+		ic.Finalizers = append(ic.Finalizers, finalizer)
+		if err := r.client.Update(ctx, ic); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to add default-certificate-source finalizer: %w", err)
+		}
+		log.Info("added default-certificate-source finalizer", "request", request)
+		if err := r.client.Get(ctx, request.NamespacedName, ic); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to get updated ingresscontroller: %w", err)
+		}
+	}
+
+	if !wantCopy {
+		if err := r.deleteSyncedSecret(ctx, ic); err != nil {
+			return reconcile.Result{}, err
+		}
+		if ic.DeletionTimestamp != nil {
+			if slice.ContainsString(ic.Finalizers, finalizer) {
+				ic.Finalizers = slice.RemoveString(ic.Finalizers, finalizer)
+				if err := r.client.Update(ctx, ic); err != nil {
+					return reconcile.Result{}, fmt.Errorf("failed to remove default-certificate-source finalizer: %w", err)
+				}
+				log.Info("removed default-certificate-source finalizer", "request", request)
+			}
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, r.removeStatusCondition(ctx, ic)
+	}
+
+	cond, err := r.ensureSyncedSecret(ctx, ic, *source)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to sync default certificate for ingresscontroller %q: %w", ic.Name, err)
+	}
+	if err := r.setStatusCondition(ctx, ic, cond); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// setStatusCondition applies the given condition to the given
+// ingresscontroller.  The condition does not overlap with any of the status
+// conditions that the ingress controller sets in
+// pkg/operator/controller/ingress/status.go.
+func (r *reconciler) setStatusCondition(ctx context.Context, ic *operatorv1.IngressController, cond operatorv1.OperatorCondition) error {
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = ingresscontroller.MergeConditions(updated.Status.Conditions, cond)
+	if ingresscontroller.IngressStatusesEqual(updated.Status, ic.Status) {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %w", ic.Name, err)
+	}
+	return nil
+}
+
+// removeStatusCondition removes the "DefaultCertificateSourceSynced" status
+// condition from the given ingresscontroller, if it has it.
+func (r *reconciler) removeStatusCondition(ctx context.Context, ic *operatorv1.IngressController) error {
+	var conditions []operatorv1.OperatorCondition
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type != ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType {
+			conditions = append(conditions, cond)
+		}
+	}
+	if len(conditions) == len(ic.Status.Conditions) {
+		return nil
+	}
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = conditions
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %w", ic.Name, err)
+	}
+	return nil
+}
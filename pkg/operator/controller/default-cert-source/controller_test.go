@@ -0,0 +1,205 @@
+package defaultcertsource
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Test_Reconcile verifies that the controller copies the source secret into
+// the operand namespace, propagates rotations of the source secret to the copy,
+// keeps the last good copy and reports the "DefaultCertificateSourceSynced"
+// condition as false when the router's access to the source secret is revoked
+// or the source secret is deleted, and cleans up the copy, the condition, and
+// the finalizer when the override is removed or the ingresscontroller is
+// deleted.
+func Test_Reconcile(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default"}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+		Spec: operatorv1.IngressControllerSpec{
+			UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"defaultCertificateSource":{"namespace":"team-certs","name":"wildcard"}}`)},
+		},
+	}
+	sourceName := types.NamespacedName{Namespace: "team-certs", Name: "wildcard"}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: sourceName.Namespace,
+			Name:      sourceName.Name,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("old-cert"),
+			corev1.TLSPrivateKeyKey: []byte("old-key"),
+		},
+	}
+	copyName := types.NamespacedName{Namespace: "openshift-ingress", Name: "router-certs-default-synced"}
+
+	// allowed is the result of the access reviews.
+	allowed := true
+	var reviews []authorizationv1.SubjectAccessReview
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	authorizationv1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ic, source).WithStatusSubresource(ic).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if sar, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+				reviews = append(reviews, *sar)
+				sar.Status.Allowed = allowed
+				if !allowed {
+					sar.Status.Reason = "no RBAC policy matched"
+				}
+				return nil
+			}
+			return cl.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		client:   cl,
+		config:   Config{OperatorNamespace: name.Namespace, OperandNamespace: "openshift-ingress"},
+		recorder: recorder,
+	}
+
+	reconcileAndGet := func(t *testing.T) (*operatorv1.IngressController, *corev1.Secret) {
+		t.Helper()
+		if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: name}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), name, current); err != nil {
+			if !errors.IsNotFound(err) {
+				t.Fatalf("failed to get ingresscontroller: %v", err)
+			}
+			current = nil
+		}
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.Background(), copyName, secret); err != nil {
+			if !errors.IsNotFound(err) {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			secret = nil
+		}
+		return current, secret
+	}
+	expectCondition := func(t *testing.T, ic *operatorv1.IngressController, status operatorv1.ConditionStatus, reason string) {
+		t.Helper()
+		for _, cond := range ic.Status.Conditions {
+			if cond.Type != ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType {
+				continue
+			}
+			if cond.Status != status || cond.Reason != reason {
+				t.Errorf("expected condition with status %q and reason %q, got %+v", status, reason, cond)
+			}
+			return
+		}
+		if len(status) != 0 {
+			t.Errorf("expected condition with status %q and reason %q, got none", status, reason)
+		}
+	}
+	expectCopy := func(t *testing.T, secret *corev1.Secret, cert string) {
+		t.Helper()
+		if secret == nil {
+			t.Fatal("expected the copy to exist")
+		}
+		if string(secret.Data[corev1.TLSCertKey]) != cert {
+			t.Errorf("expected the copy to have certificate %q, got %q", cert, string(secret.Data[corev1.TLSCertKey]))
+		}
+		if secret.Annotations[SourceAnnotation] != sourceName.String() {
+			t.Errorf("expected annotation %s=%s, got %q", SourceAnnotation, sourceName, secret.Annotations[SourceAnnotation])
+		}
+		if hash := certificateHash(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); secret.Annotations[SourceHashAnnotation] != hash {
+			t.Errorf("expected annotation %s=%s, got %q", SourceHashAnnotation, hash, secret.Annotations[SourceHashAnnotation])
+		}
+	}
+
+	// The controller copies the source secret.
+	current, secret := reconcileAndGet(t)
+	expectCopy(t, secret, "old-cert")
+	expectCondition(t, current, operatorv1.ConditionTrue, "Synced")
+	if len(current.Finalizers) != 1 || current.Finalizers[0] != finalizer {
+		t.Errorf("expected finalizer %q, got %v", finalizer, current.Finalizers)
+	}
+	if len(reviews) != 1 {
+		t.Fatalf("expected 1 access review, got %d", len(reviews))
+	}
+	if review := reviews[0].Spec; review.User != "system:serviceaccount:openshift-ingress:router" || review.ResourceAttributes == nil || *review.ResourceAttributes != (authorizationv1.ResourceAttributes{Namespace: "team-certs", Verb: "get", Resource: "secrets", Name: "wildcard"}) {
+		t.Errorf("unexpected access review: %+v", review)
+	}
+
+	// A rotated source secret propagates to the copy.
+	source.Data[corev1.TLSCertKey] = []byte("new-cert")
+	source.Data[corev1.TLSPrivateKeyKey] = []byte("new-key")
+	if err := cl.Update(context.Background(), source); err != nil {
+		t.Fatalf("failed to update source secret: %v", err)
+	}
+	current, secret = reconcileAndGet(t)
+	expectCopy(t, secret, "new-cert")
+	expectCondition(t, current, operatorv1.ConditionTrue, "Synced")
+	goodHash := secret.Annotations[SourceHashAnnotation]
+
+	// Revoked access and further rotations leave the last good copy in
+	// place.
+	allowed = false
+	source.Data[corev1.TLSCertKey] = []byte("unauthorized-cert")
+	if err := cl.Update(context.Background(), source); err != nil {
+		t.Fatalf("failed to update source secret: %v", err)
+	}
+	current, secret = reconcileAndGet(t)
+	expectCopy(t, secret, "new-cert")
+	expectCondition(t, current, operatorv1.ConditionFalse, "AccessDenied")
+	for _, cond := range current.Status.Conditions {
+		if cond.Type == ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType && !strings.Contains(cond.Message, goodHash) {
+			t.Errorf("expected condition message to mention the last good hash %s, got %q", goodHash, cond.Message)
+		}
+	}
+
+	// A deleted source secret leaves the last good copy in place.
+	allowed = true
+	if err := cl.Delete(context.Background(), source); err != nil {
+		t.Fatalf("failed to delete source secret: %v", err)
+	}
+	current, secret = reconcileAndGet(t)
+	expectCopy(t, secret, "new-cert")
+	expectCondition(t, current, operatorv1.ConditionFalse, "SourceNotFound")
+
+	// Removing the override deletes the copy and the condition.
+	current.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+	if err := cl.Update(context.Background(), current); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	current, secret = reconcileAndGet(t)
+	if secret != nil {
+		t.Error("expected the copy to be deleted")
+	}
+	expectCondition(t, current, "", "")
+
+	// Deleting the ingresscontroller removes the finalizer.
+	if err := cl.Delete(context.Background(), current); err != nil {
+		t.Fatalf("failed to delete ingresscontroller: %v", err)
+	}
+	if current, _ = reconcileAndGet(t); current != nil {
+		t.Errorf("expected the ingresscontroller to be deleted, got finalizers %v", current.Finalizers)
+	}
+}
@@ -0,0 +1,248 @@
+package defaultcertsource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// routerServiceAccountName is the name of the routers' service
+	// account in the operand namespace.
+	routerServiceAccountName = "router"
+
+	// SourceAnnotation is the annotation with which the operator records
+	// the namespace and name of the secret from which it copied a default
+	// certificate secret, in "namespace/name" format.
+	SourceAnnotation = "ingress.operator.openshift.io/default-certificate-source"
+	// SourceHashAnnotation is the annotation with which the operator
+	// records the SHA-256 hash of the certificate and key that it copied
+	// from the source secret.
+	SourceHashAnnotation = "ingress.operator.openshift.io/default-certificate-source-hash"
+)
+
+// ensureSyncedSecret copies the given source secret into the operand namespace
+// as the given ingresscontroller's default certificate, provided that the
+// router's service account may read the source secret, and returns the
+// ingresscontroller's "DefaultCertificateSourceSynced" status condition.
+//
+// The operator itself may read secrets in every namespace, so before copying a
+// secret, it checks that the secret's owner has granted the router access to
+// it.  Otherwise, anyone who can edit an ingresscontroller could use the
+// operator to expose any secret in the cluster.
+//
+// If the router may not read the source secret, or the source secret does not
+// exist or is not a valid TLS secret, the current copy, if any, is left as it
+// is so that the router continues to serve the last good certificate.
+func (r *reconciler) ensureSyncedSecret(ctx context.Context, ic *operatorv1.IngressController, source types.NamespacedName) (operatorv1.OperatorCondition, error) {
+	destName := operatorcontroller.RouterSyncedDefaultCertificateSecretName(ic, r.config.OperandNamespace)
+	have, current, err := r.currentSecret(ctx, destName)
+	if err != nil {
+		return operatorv1.OperatorCondition{}, err
+	}
+
+	if len(source.Namespace) == 0 || len(source.Name) == 0 {
+		// The ingress controller reports the invalid reference.
+		return notSyncedCondition("InvalidSource", "The default certificate source must specify both a namespace and a name.", current), nil
+	}
+
+	allowed, reason, err := r.routerCanReadSecret(ctx, source)
+	if err != nil {
+		return operatorv1.OperatorCondition{}, err
+	}
+	if !allowed {
+		message := fmt.Sprintf("The router service account %s/%s is not permitted to get secret %s.", r.config.OperandNamespace, routerServiceAccountName, source)
+		if len(reason) != 0 {
+			message = fmt.Sprintf("The router service account %s/%s is not permitted to get secret %s: %s.", r.config.OperandNamespace, routerServiceAccountName, source, reason)
+		}
+		return notSyncedCondition("AccessDenied", message, current), nil
+	}
+
+	haveSource, sourceSecret, err := r.currentSecret(ctx, source)
+	if err != nil {
+		return operatorv1.OperatorCondition{}, err
+	}
+	if !haveSource {
+		return notSyncedCondition("SourceNotFound", fmt.Sprintf("Secret %s does not exist.", source), current), nil
+	}
+
+	desired, err := desiredSyncedSecret(source, sourceSecret, destName)
+	if err != nil {
+		return notSyncedCondition("InvalidSource", fmt.Sprintf("Secret %s is not a valid TLS secret: %v.", source, err), current), nil
+	}
+
+	switch {
+	case !have:
+		if err := r.client.Create(ctx, desired); err != nil {
+			return operatorv1.OperatorCondition{}, fmt.Errorf("failed to create secret %s: %w", destName, err)
+		}
+		log.Info("created secret", "namespace", desired.Namespace, "name", desired.Name, "source", source)
+		r.recorder.Eventf(ic, corev1.EventTypeNormal, "DefaultCertificateSourceSynced", "Copied the default certificate from secret %s with hash %s", source, desired.Annotations[SourceHashAnnotation])
+	case !syncedSecretsEqual(current, desired):
+		updated := current.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		for k, v := range desired.Annotations {
+			updated.Annotations[k] = v
+		}
+		updated.Type = desired.Type
+		updated.Data = desired.Data
+		if err := r.client.Update(ctx, updated); err != nil {
+			return operatorv1.OperatorCondition{}, fmt.Errorf("failed to update secret %s: %w", destName, err)
+		}
+		log.Info("updated secret", "namespace", updated.Namespace, "name", updated.Name, "source", source)
+		if current.Annotations[SourceHashAnnotation] != desired.Annotations[SourceHashAnnotation] {
+			r.recorder.Eventf(ic, corev1.EventTypeNormal, "DefaultCertificateSourceSynced", "Copied the default certificate from secret %s with hash %s, replacing the certificate with hash %s", source, desired.Annotations[SourceHashAnnotation], current.Annotations[SourceHashAnnotation])
+		}
+	}
+
+	return operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "Synced",
+		Message: fmt.Sprintf("The default certificate with hash %s is copied from secret %s to secret %s.", desired.Annotations[SourceHashAnnotation], source, destName),
+	}, nil
+}
+
+// notSyncedCondition returns a "DefaultCertificateSourceSynced" status
+// condition with status "False" and the given reason and message, which the
+// condition's message extends with a description of the given current copy.
+func notSyncedCondition(reason, message string, current *corev1.Secret) operatorv1.OperatorCondition {
+	if current != nil {
+		message = fmt.Sprintf("%s  The router continues to use the last copied default certificate, which has hash %s.", message, current.Annotations[SourceHashAnnotation])
+	} else {
+		message = fmt.Sprintf("%s  The default certificate has not been copied, so the router cannot start.", message)
+	}
+	return operatorv1.OperatorCondition{
+		Type:    ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// routerCanReadSecret uses a SubjectAccessReview to determine whether the
+// router's service account may get the given secret.  Returns a Boolean value
+// indicating whether it may, the authorizer's reason, if any, and an error
+// value.
+func (r *reconciler) routerCanReadSecret(ctx context.Context, name types.NamespacedName) (bool, string, error) {
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User: fmt.Sprintf("system:serviceaccount:%s:%s", r.config.OperandNamespace, routerServiceAccountName),
+			Groups: []string{
+				"system:serviceaccounts",
+				"system:serviceaccounts:" + r.config.OperandNamespace,
+				"system:authenticated",
+			},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: name.Namespace,
+				Verb:      "get",
+				Resource:  "secrets",
+				Name:      name.Name,
+			},
+		},
+	}
+	if err := r.client.Create(ctx, sar); err != nil {
+		return false, "", fmt.Errorf("failed to create subjectaccessreview for secret %s: %w", name, err)
+	}
+	return sar.Status.Allowed, sar.Status.Reason, nil
+}
+
+// desiredSyncedSecret returns the desired copy of the given source secret.  An
+// error is returned if the source secret does not have a certificate and key.
+func desiredSyncedSecret(sourceName types.NamespacedName, source *corev1.Secret, name types.NamespacedName) (*corev1.Secret, error) {
+	crt, key := source.Data[corev1.TLSCertKey], source.Data[corev1.TLSPrivateKeyKey]
+	if len(crt) == 0 {
+		return nil, fmt.Errorf("missing %s", corev1.TLSCertKey)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("missing %s", corev1.TLSPrivateKeyKey)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Annotations: map[string]string{
+				SourceAnnotation:     sourceName.String(),
+				SourceHashAnnotation: certificateHash(crt, key),
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       crt,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}, nil
+}
+
+// certificateHash returns the hex-encoded SHA-256 hash of the given
+// certificate and key.
+func certificateHash(crt, key []byte) string {
+	h := sha256.New()
+	h.Write(crt)
+	h.Write([]byte{0})
+	h.Write(key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// syncedSecretsEqual compares two copies of a source secret.  Returns true if
+// the copies should be considered equal for the purpose of determining whether
+// an update is necessary, false otherwise.
+func syncedSecretsEqual(a, b *corev1.Secret) bool {
+	if a.Type != b.Type || !reflect.DeepEqual(a.Data, b.Data) {
+		return false
+	}
+	for k, v := range b.Annotations {
+		if a.Annotations[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteSyncedSecret deletes the given ingresscontroller's copy of its default
+// certificate source secret, if it exists.
+func (r *reconciler) deleteSyncedSecret(ctx context.Context, ic *operatorv1.IngressController) error {
+	name := operatorcontroller.RouterSyncedDefaultCertificateSecretName(ic, r.config.OperandNamespace)
+	have, current, err := r.currentSecret(ctx, name)
+	if err != nil || !have {
+		return err
+	}
+	if err := r.client.Delete(ctx, current); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete secret %s: %w", name, err)
+	}
+	log.Info("deleted secret", "namespace", current.Namespace, "name", current.Name)
+	return nil
+}
+
+// currentSecret returns the current secret with the given name.  Returns a
+// Boolean indicating whether the secret existed, the secret if it did exist,
+// and an error value.
+func (r *reconciler) currentSecret(ctx context.Context, name types.NamespacedName) (bool, *corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, name, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	return true, secret, nil
+}
@@ -0,0 +1,122 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// dnsCleanupAttemptsAnnotation is the annotation that the controller
+	// sets on a DNSRecord that is being deleted to record the number of
+	// failed attempts to delete the record from the DNS provider.
+	dnsCleanupAttemptsAnnotation = "ingress.operator.openshift.io/dns-cleanup-attempts"
+
+	// DNSRecordCleanupAbandonedConditionType is the type of the zone
+	// condition that the controller sets on a DNSRecord when it gives up
+	// deleting the record from the zone.
+	DNSRecordCleanupAbandonedConditionType = "CleanupAbandoned"
+)
+
+// dnsCleanupAttempts returns the number of failed attempts to delete the given
+// DNSRecord from the DNS provider.
+func dnsCleanupAttempts(record *iov1.DNSRecord) int {
+	attempts, err := strconv.Atoi(record.Annotations[dnsCleanupAttemptsAnnotation])
+	if err != nil || attempts < 0 {
+		return 0
+	}
+	return attempts
+}
+
+// dnsCleanupExhausted returns a Boolean value indicating whether the controller
+// should give up deleting the given DNSRecord from the DNS provider after the
+// current failed attempt.  Cleanup is abandoned once it has failed at least
+// DNSCleanupMaxAttempts times and the record has been pending deletion for at
+// least DNSCleanupTimeout.  If DNSCleanupMaxAttempts is not positive, cleanup is
+// retried indefinitely.
+func (r *reconciler) dnsCleanupExhausted(record *iov1.DNSRecord) bool {
+	if r.config.DNSCleanupMaxAttempts <= 0 || record.DeletionTimestamp == nil {
+		return false
+	}
+	if dnsCleanupAttempts(record)+1 < r.config.DNSCleanupMaxAttempts {
+		return false
+	}
+	return clock.Since(record.DeletionTimestamp.Time) >= r.config.DNSCleanupTimeout
+}
+
+// recordDNSCleanupAttempt increments the number of failed cleanup attempts on
+// the given DNSRecord.
+func (r *reconciler) recordDNSCleanupAttempt(record *iov1.DNSRecord) {
+	if r.config.DNSCleanupMaxAttempts <= 0 {
+		return
+	}
+	updated := record.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[dnsCleanupAttemptsAnnotation] = strconv.Itoa(dnsCleanupAttempts(record) + 1)
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		// The count is only used to bound retries, so log the error
+		// and continue.  At worst, cleanup is retried a little longer.
+		log.Error(err, "failed to record dns cleanup attempt", "dnsrecord", record.Name)
+		return
+	}
+	record.Annotations = updated.Annotations
+	record.ResourceVersion = updated.ResourceVersion
+}
+
+// abandonDNSCleanup reports that the controller has given up deleting the given
+// DNSRecord from the given zones.  It sets the "CleanupAbandoned" condition for
+// each zone, emits a warning event, and sets the orphaned record metric so that
+// an administrator can remove the record manually.
+func (r *reconciler) abandonDNSCleanup(record *iov1.DNSRecord, failedZones []configv1.DNSZone, cause error) {
+	zoneNames := make([]string, 0, len(failedZones))
+	var statuses []iov1.DNSZoneStatus
+	for _, zone := range failedZones {
+		zoneName := dnsZoneName(zone)
+		zoneNames = append(zoneNames, zoneName)
+		statuses = append(statuses, iov1.DNSZoneStatus{
+			DNSZone: zone,
+			Conditions: []iov1.DNSZoneCondition{{
+				Type:    DNSRecordCleanupAbandonedConditionType,
+				Status:  string(operatorv1.ConditionTrue),
+				Reason:  "ProviderError",
+				Message: fmt.Sprintf("DNS cleanup abandoned: %v; record %s in zone %s may need manual removal", cause, record.Spec.DNSName, zoneName),
+			}},
+		})
+		SetOrphanedDNSRecordMetric(record, zoneName)
+	}
+	message := fmt.Sprintf("DNS cleanup abandoned after %d attempts: %v; record %s in zone(s) %s may need manual removal", dnsCleanupAttempts(record)+1, cause, record.Spec.DNSName, strings.Join(zoneNames, ", "))
+	log.Info("abandoning dnsrecord cleanup", "dnsrecord", record.Name, "dnsName", record.Spec.DNSName, "zones", zoneNames, "error", cause.Error())
+	r.recorder.Event(record, "Warning", "DNSCleanupAbandoned", message)
+
+	updated := record.DeepCopy()
+	updated.Status.Zones = mergeStatuses(failedZones, updated.Status.Zones, statuses)
+	if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+		// The event and metric already report the orphaned record, so
+		// log the error and continue removing the finalizer.
+		log.Error(err, "failed to update dnsrecord status", "dnsrecord", record.Name)
+		return
+	}
+	record.ResourceVersion = updated.ResourceVersion
+}
+
+// dnsZoneName returns a human-readable name for the given zone.
+func dnsZoneName(zone configv1.DNSZone) string {
+	if len(zone.ID) != 0 {
+		return zone.ID
+	}
+	tags := make([]string, 0, len(zone.Tags))
+	for k, v := range zone.Tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return "tags{" + strings.Join(tags, ",") + "}"
+}
@@ -0,0 +1,198 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// failingProvider is a DNS provider that always fails.
+type failingProvider struct{}
+
+var _ dns.Provider = &failingProvider{}
+
+func (_ *failingProvider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return errors.New("access denied")
+}
+func (_ *failingProvider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return errors.New("access denied")
+}
+func (_ *failingProvider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return errors.New("access denied")
+}
+
+// Test_delete verifies that delete removes the finalizer after deleting the
+// record from the DNS provider, retries while cleanup keeps failing, and
+// abandons cleanup with a warning once the retries are exhausted.
+func Test_delete(t *testing.T) {
+	zone := configv1.DNSZone{ID: "example-zone"}
+	testCases := []struct {
+		name             string
+		provider         dns.Provider
+		maxAttempts      int
+		attempts         string
+		deletedAgo       time.Duration
+		expectError      bool
+		expectFinalized  bool
+		expectAttempts   string
+		expectEvent      bool
+		expectOrphanedIn string
+	}{
+		{
+			name:            "provider succeeds",
+			provider:        &dns.FakeProvider{},
+			maxAttempts:     3,
+			deletedAgo:      time.Minute,
+			expectFinalized: true,
+		},
+		{
+			name:           "provider fails, first attempt",
+			provider:       &failingProvider{},
+			maxAttempts:    3,
+			deletedAgo:     2 * time.Hour,
+			expectError:    true,
+			expectAttempts: "1",
+		},
+		{
+			name:           "provider fails, attempts exhausted within timeout",
+			provider:       &failingProvider{},
+			maxAttempts:    3,
+			attempts:       "2",
+			deletedAgo:     time.Minute,
+			expectError:    true,
+			expectAttempts: "3",
+		},
+		{
+			name:           "provider fails, retry indefinitely",
+			provider:       &failingProvider{},
+			maxAttempts:    0,
+			attempts:       "100",
+			deletedAgo:     24 * time.Hour,
+			expectError:    true,
+			expectAttempts: "100",
+		},
+		{
+			name:             "provider fails, attempts and timeout exhausted",
+			provider:         &failingProvider{},
+			maxAttempts:      3,
+			attempts:         "2",
+			deletedAgo:       2 * time.Hour,
+			expectFinalized:  true,
+			expectEvent:      true,
+			expectOrphanedIn: zone.ID,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orphanedDNSRecords.Reset()
+			name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default-wildcard"}
+			dnsRecord := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         name.Namespace,
+					Name:              name.Name,
+					Finalizers:        []string{manifests.DNSRecordFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-tc.deletedAgo)},
+				},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             "*.apps.example.com.",
+					RecordType:          iov1.CNAMERecordType,
+					Targets:             []string{"lb.example.com"},
+					RecordTTL:           30,
+					DNSManagementPolicy: iov1.ManagedDNS,
+				},
+				Status: iov1.DNSRecordStatus{
+					Zones: []iov1.DNSZoneStatus{{
+						DNSZone: zone,
+						Conditions: []iov1.DNSZoneCondition{{
+							Type:   iov1.DNSRecordPublishedConditionType,
+							Status: string(operatorv1.ConditionTrue),
+						}},
+					}},
+				},
+			}
+			if len(tc.attempts) != 0 {
+				dnsRecord.Annotations = map[string]string{dnsCleanupAttemptsAnnotation: tc.attempts}
+			}
+			scheme := runtime.NewScheme()
+			iov1.Install(scheme)
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithStatusSubresource(dnsRecord).
+				WithObjects(dnsRecord).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &reconciler{
+				config: Config{
+					DNSCleanupMaxAttempts: tc.maxAttempts,
+					DNSCleanupTimeout:     time.Hour,
+				},
+				client:      cl,
+				dnsProvider: tc.provider,
+				recorder:    recorder,
+			}
+			current := &iov1.DNSRecord{}
+			if err := cl.Get(context.Background(), name, current); err != nil {
+				t.Fatal(err)
+			}
+
+			switch err := r.delete(current, nil); {
+			case err == nil && tc.expectError:
+				t.Fatalf("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err := cl.Get(context.Background(), name, current)
+			switch {
+			case tc.expectFinalized && !kerrors.IsNotFound(err):
+				t.Errorf("expected dnsrecord to be finalized, got %v", err)
+			case !tc.expectFinalized && err != nil:
+				t.Errorf("expected dnsrecord to exist, got %v", err)
+			case !tc.expectFinalized && current.Annotations[dnsCleanupAttemptsAnnotation] != tc.expectAttempts:
+				t.Errorf("expected %q cleanup attempts, got %q", tc.expectAttempts, current.Annotations[dnsCleanupAttemptsAnnotation])
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !tc.expectEvent {
+					t.Errorf("unexpected event: %s", event)
+				} else if !strings.Contains(event, "DNSCleanupAbandoned") || !strings.Contains(event, "access denied") || !strings.Contains(event, "record *.apps.example.com. in zone(s) example-zone may need manual removal") {
+					t.Errorf("unexpected event message: %s", event)
+				}
+			default:
+				if tc.expectEvent {
+					t.Errorf("expected an event")
+				}
+			}
+
+			expectMetrics := 0
+			if len(tc.expectOrphanedIn) != 0 {
+				expectMetrics = 1
+				if v := testutil.ToFloat64(orphanedDNSRecords.WithLabelValues(name.Namespace, name.Name, "*.apps.example.com.", tc.expectOrphanedIn)); v != 1 {
+					t.Errorf("expected orphaned record metric to be 1, got %v", v)
+				}
+			}
+			if n := testutil.CollectAndCount(orphanedDNSRecords); n != expectMetrics {
+				t.Errorf("expected %d orphaned record metrics, got %d", expectMetrics, n)
+			}
+		})
+	}
+}
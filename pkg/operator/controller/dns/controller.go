@@ -26,8 +26,10 @@ import (
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 	oputil "github.com/openshift/cluster-ingress-operator/pkg/util"
 	awsutil "github.com/openshift/cluster-ingress-operator/pkg/util/aws"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
 	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
 
 	corev1 "k8s.io/api/core/v1"
@@ -76,11 +78,27 @@ func New(mgr manager.Manager, config Config) (runtimecontroller.Controller, erro
 		cache:    operatorCache,
 		recorder: mgr.GetEventRecorderFor(controllerName),
 	}
+	if len(config.DomainDelegationCheckResolvers) != 0 {
+		reconciler.delegationLookups = newDomainDelegationLookups(config.DomainDelegationCheckResolvers, lookupNS)
+	}
 	c, err := runtimecontroller.New(controllerName, mgr, runtimecontroller.Options{Reconciler: reconciler})
 	if err != nil {
 		return nil, err
 	}
-	if err := c.Watch(source.Kind[client.Object](operatorCache, &iov1.DNSRecord{}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})); err != nil {
+	// Changing whether a record is dual-stack, changing its per-zone
+	// targets, skipping the zone delegation check, or requesting another
+	// domain delegation check does not change the record's generation, so
+	// watch for changes to the annotations too.
+	publishAnnotationsChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAnnotations, newAnnotations := e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()
+			return oldAnnotations[dnsrecord.DNSDualStackAnnotation] != newAnnotations[dnsrecord.DNSDualStackAnnotation] ||
+				oldAnnotations[dnsrecord.DNSZoneTargetsAnnotation] != newAnnotations[dnsrecord.DNSZoneTargetsAnnotation] ||
+				oldAnnotations[dnsrecord.DNSSkipZoneDelegationCheckAnnotation] != newAnnotations[dnsrecord.DNSSkipZoneDelegationCheckAnnotation] ||
+				oldAnnotations[dnsrecord.DNSRecheckDomainDelegationAnnotation] != newAnnotations[dnsrecord.DNSRecheckDomainDelegationAnnotation]
+		},
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &iov1.DNSRecord{}, &handler.EnqueueRequestForObject{}, predicate.Or(predicate.GenerationChangedPredicate{}, publishAnnotationsChanged))); err != nil {
 		return nil, err
 	}
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &configv1.DNS{}, handler.EnqueueRequestsFromMapFunc(reconciler.ToDNSRecords))); err != nil {
@@ -116,6 +134,22 @@ type Config struct {
 	// PrivateHostedZoneAWSEnabled indicates whether the "SharedVPC" feature gate is
 	// enabled.
 	PrivateHostedZoneAWSEnabled bool
+	// DNSCleanupMaxAttempts is the number of failed attempts to delete a
+	// DNSRecord from the DNS provider after which the controller may
+	// abandon the cleanup.  If it is not positive, cleanup is retried
+	// indefinitely.
+	DNSCleanupMaxAttempts int
+	// DNSCleanupTimeout is how long a DNSRecord must be pending deletion
+	// before the controller may abandon the cleanup.
+	DNSCleanupTimeout time.Duration
+	// CacheFreshness tracks whether the cache is fresh enough for the
+	// controller to delete records from the DNS provider.
+	CacheFreshness *cachefreshness.Tracker
+	// DomainDelegationCheckResolvers are the addresses of the public
+	// resolvers with which the controller checks whether the public zone
+	// is delegated to its name servers, each an IP address, optionally
+	// with a port.  If empty, the check is disabled.
+	DomainDelegationCheckResolvers []string
 }
 
 type reconciler struct {
@@ -127,6 +161,10 @@ type reconciler struct {
 	infraConfig      *configv1.Infrastructure
 	cloudCredentials *corev1.Secret
 	recorder         record.EventRecorder
+	// delegationLookups looks up the delegations of public zones' apexes
+	// for the domain delegation check in the background and caches the
+	// results.  It is nil if the check is disabled.
+	delegationLookups *domainDelegationLookups
 }
 
 func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
@@ -160,7 +198,10 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	// If the DNS record was deleted, clean up and return.
 	if record.DeletionTimestamp != nil {
-		if err := r.delete(record); err != nil {
+		if err := r.delete(record, dnsConfig.Spec.PrivateZone); err != nil {
+			if staleErr, ok := cachefreshness.IsStaleCache(err); ok {
+				return reconcile.Result{RequeueAfter: staleErr.RetryAfter}, nil
+			}
 			log.Error(err, "failed to delete dnsrecord; will retry", "dnsrecord", record)
 			return reconcile.Result{RequeueAfter: 15 * time.Second}, nil
 		}
@@ -182,12 +223,34 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	if dnsConfig.Spec.PublicZone != nil {
 		zones = append(zones, *dnsConfig.Spec.PublicZone)
 	}
-	requeue, statuses := r.publishRecordToZones(zones, record)
+	requeue, statuses := r.publishRecordToZones(zones, dnsConfig.Spec.PrivateZone, record)
+
+	// Check whether the public zone is delegated to its name servers.  The
+	// outcome is only reported and never prevents publishing the record.
+	// The lookup happens in the background, so requeue until its result is
+	// available.  If the check is disabled, clear any outcome that was
+	// reported while it was enabled.
+	checkedDelegation, delegationPending := false, false
+	if r.needsDomainDelegationCheck(record, dnsConfig.Spec.PublicZone, statuses) {
+		cond, pending := r.checkDomainDelegation(record, *dnsConfig.Spec.PublicZone)
+		checkedDelegation, delegationPending = !pending, pending
+		if cond != nil {
+			statuses = mergeStatuses(zones, statuses, []iov1.DNSZoneStatus{{
+				DNSZone:    *dnsConfig.Spec.PublicZone,
+				Conditions: []iov1.DNSZoneCondition{*cond},
+			}})
+		}
+	} else if r.delegationLookups == nil {
+		statuses = removeDomainDelegationSuspectConditions(statuses)
+	}
 
-	// Requeue if publishing records failed.
+	// Requeue if publishing records failed or the domain delegation check
+	// is pending.
 	result := reconcile.Result{}
 	if requeue {
 		result.RequeueAfter = 30 * time.Second
+	} else if delegationPending {
+		result.RequeueAfter = domainDelegationLookupPollInterval
 	}
 
 	if !dnsZoneStatusSlicesEqual(statuses, record.Status.Zones) {
@@ -207,9 +270,55 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		}
 	}
 
+	if _, ok := record.Annotations[dnsrecord.DNSRecheckDomainDelegationAnnotation]; ok && checkedDelegation {
+		if err := r.removeRecheckDomainDelegationAnnotation(ctx, request.NamespacedName); err != nil {
+			log.Error(err, "failed to update dnsrecord; will retry", "dnsrecord", request.NamespacedName)
+			return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+	}
+
+	if !requeue && record.Spec.DNSManagementPolicy != iov1.UnmanagedDNS && dnsrecord.PublishPending(record) {
+		if err := r.syncPublishedAnnotations(ctx, request.NamespacedName, record); err != nil {
+			log.Error(err, "failed to update dnsrecord; will retry", "dnsrecord", request.NamespacedName)
+			return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+	}
+
 	return result, nil
 }
 
+// syncPublishedAnnotations updates the named DNSRecord's
+// dnsrecord.DNSDualStackPublishedAnnotation and
+// dnsrecord.DNSZoneTargetsPublishedAnnotation annotations to describe the given
+// record, which is the record that was just published.  If the record has
+// changed since, it will be published again, after which the annotations are
+// updated again.
+func (r *reconciler) syncPublishedAnnotations(ctx context.Context, name types.NamespacedName, published *iov1.DNSRecord) error {
+	var current iov1.DNSRecord
+	if err := r.client.Get(ctx, name, &current); err != nil {
+		return err
+	}
+	updated := current.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	if published.Annotations[dnsrecord.DNSDualStackAnnotation] == "true" {
+		updated.Annotations[dnsrecord.DNSDualStackPublishedAnnotation] = "true"
+	} else {
+		delete(updated.Annotations, dnsrecord.DNSDualStackPublishedAnnotation)
+	}
+	if zoneTargets := published.Annotations[dnsrecord.DNSZoneTargetsAnnotation]; len(zoneTargets) != 0 {
+		updated.Annotations[dnsrecord.DNSZoneTargetsPublishedAnnotation] = zoneTargets
+	} else {
+		delete(updated.Annotations, dnsrecord.DNSZoneTargetsPublishedAnnotation)
+	}
+	if err := r.client.Update(ctx, updated); err != nil {
+		return err
+	}
+	log.Info("updated dnsrecord published annotations", "dnsrecord", name, "dualStack", updated.Annotations[dnsrecord.DNSDualStackPublishedAnnotation], "zoneTargets", updated.Annotations[dnsrecord.DNSZoneTargetsPublishedAnnotation])
+	return nil
+}
+
 // createDNSProviderIfNeeded creates a new DNS provider if none has yet been
 // created or if the infrastructure platform status or cloud credentials have
 // changed since the current provider was created.  After creating a new
@@ -270,15 +379,28 @@ func (r *reconciler) createDNSProviderIfNeeded(dnsConfig *configv1.DNS, record *
 
 // replacePublishedRecord replaces a previously published record with the given record,
 // and the result is returned as a condition. Upon errors during publishing,
-// an error object is returned.
-func (r *reconciler) replacePublishedRecord(zone configv1.DNSZone, record *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
+// an error object is returned.  If the given previously published record is a
+// different kind of record than the given record, the previously published
+// record is deleted first, as DNS providers replace records of the same kind
+// only.  See dnsrecord.RecordKindChanged.
+func (r *reconciler) replacePublishedRecord(zone configv1.DNSZone, record, published *iov1.DNSRecord) (iov1.DNSZoneCondition, error) {
 	condition := iov1.DNSZoneCondition{
 		Status:             string(operatorv1.ConditionUnknown),
 		Type:               iov1.DNSRecordPublishedConditionType,
 		LastTransitionTime: metav1.Now(),
 	}
 
-	err := r.dnsProvider.Replace(record, zone)
+	var err error
+	if dnsrecord.RecordKindChanged(published, record) {
+		if err = r.dnsProvider.Delete(published, zone); err != nil {
+			log.Error(err, "failed to delete DNS record of previous kind from zone", "record", published.Spec, "dnszone", zone)
+		} else {
+			log.Info("deleted DNS record of previous kind from zone", "record", published.Spec, "dnszone", zone)
+		}
+	}
+	if err == nil {
+		err = r.dnsProvider.Replace(record, zone)
+	}
 	if err != nil {
 		log.Error(err, "failed to replace DNS record in zone", "record", record.Spec, "dnszone", zone)
 		condition.Status = string(operatorv1.ConditionFalse)
@@ -321,8 +443,11 @@ func (r *reconciler) publishRecord(zone configv1.DNSZone, record *iov1.DNSRecord
 }
 
 // publishRecordToZones attempts to publish records and returns a bool
-// indicating if we need to requeue due to errors and list of latest DNS Zone status.
-func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1.DNSRecord) (bool, []iov1.DNSZoneStatus) {
+// indicating if we need to requeue due to errors and list of latest DNS Zone
+// status.  The record is published to privateZone, if it is among the given
+// zones, with the record's private zone targets, and to the other zones with
+// the record's public zone targets.
+func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, privateZone *configv1.DNSZone, record *iov1.DNSRecord) (bool, []iov1.DNSZoneStatus) {
 	var statuses []iov1.DNSZoneStatus
 	var requeue bool
 	dnsPolicy := record.Spec.DNSManagementPolicy
@@ -330,16 +455,33 @@ func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1
 		isRecordPublished := recordIsAlreadyPublishedToZone(record, &zones[i])
 
 		// Only publish the record if the DNSRecord has been modified
-		// (which would mean the target could have changed) or its
-		// status does not indicate that it has already been published.
-		if record.Generation == record.Status.ObservedGeneration && isRecordPublished {
+		// (which would mean the target could have changed), whether
+		// the record is dual-stack or its per-zone targets have
+		// changed, or its status does not indicate that it has
+		// already been published.
+		if record.Generation == record.Status.ObservedGeneration && isRecordPublished && !dnsrecord.PublishPending(record) {
 			log.Info("skipping zone to which the DNS record is already published", "record", record.Spec, "dnszone", zones[i])
 			continue
 		}
 
-		var err error
+		private := isPrivateZone(zones[i], privateZone)
+		zoneRecord, err := dnsrecord.RecordForZone(record, private, false)
+		var publishedRecord *iov1.DNSRecord
+		if err == nil {
+			publishedRecord, err = dnsrecord.RecordForZone(record, private, true)
+		}
+
 		var condition iov1.DNSZoneCondition
-		if dnsPolicy == iov1.UnmanagedDNS {
+		if err != nil {
+			log.Error(err, "failed to determine DNS record targets for zone", "record", record.Spec, "dnszone", zones[i])
+			condition = iov1.DNSZoneCondition{
+				Message:            fmt.Sprintf("The DNS record has invalid per-zone targets: %v", err),
+				Reason:             "InvalidZoneTargets",
+				Status:             string(operatorv1.ConditionFalse),
+				Type:               iov1.DNSRecordPublishedConditionType,
+				LastTransitionTime: metav1.Now(),
+			}
+		} else if dnsPolicy == iov1.UnmanagedDNS {
 			log.Info("DNS record not published", "record", record.Spec)
 			condition = iov1.DNSZoneCondition{
 				Message:            "DNS record is currently not being managed by the operator",
@@ -348,10 +490,15 @@ func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1
 				Type:               iov1.DNSRecordPublishedConditionType,
 				LastTransitionTime: metav1.Now(),
 			}
+		} else if subzone := r.delegatedSubzone(record, zones[i]); len(subzone) != 0 {
+			// Requeue so that the record is published once the
+			// delegation is removed.
+			condition = zoneNotAuthoritativeCondition(subzone)
+			requeue = true
 		} else if isRecordPublished {
-			condition, err = r.replacePublishedRecord(zones[i], record)
+			condition, err = r.replacePublishedRecord(zones[i], zoneRecord, publishedRecord)
 		} else {
-			condition, err = r.publishRecord(zones[i], record)
+			condition, err = r.publishRecord(zones[i], zoneRecord)
 		}
 
 		// Check if replacing or publishing record resulted in an error.
@@ -369,6 +516,12 @@ func (r *reconciler) publishRecordToZones(zones []configv1.DNSZone, record *iov1
 	return requeue, mergeStatuses(zones, record.Status.DeepCopy().Zones, statuses)
 }
 
+// isPrivateZone returns a Boolean value indicating whether the given zone is the
+// given private zone.
+func isPrivateZone(zone configv1.DNSZone, privateZone *configv1.DNSZone) bool {
+	return privateZone != nil && reflect.DeepEqual(zone, *privateZone)
+}
+
 // recordIsAlreadyPublishedToZone returns a Boolean value indicating whether the
 // given DNSRecord is already published to the given zone, as determined from
 // the DNSRecord's status conditions.
@@ -388,8 +541,28 @@ func recordIsAlreadyPublishedToZone(record *iov1.DNSRecord, zoneToPublish *confi
 	return false
 }
 
-func (r *reconciler) delete(record *iov1.DNSRecord) error {
+// delete deletes the given DNSRecord from the DNS provider in each zone where it
+// is published and then removes the record's finalizer.  The record is deleted
+// from privateZone with the private zone targets with which it was last
+// published, and from other zones with the public zone targets.  If deleting the
+// record from the DNS provider keeps failing, delete eventually abandons the
+// cleanup, reports the orphaned record, and removes the finalizer anyway so that
+// the record's deletion, and the deletion of its owner, is not blocked forever.
+// While the cache might be stale, delete does not delete anything from the DNS
+// provider and returns a *cachefreshness.StaleCacheError, which does not count
+// as a failed attempt.
+func (r *reconciler) delete(record *iov1.DNSRecord, privateZone *configv1.DNSZone) error {
+	for i := range record.Status.Zones {
+		if !recordIsAlreadyPublishedToZone(record, &record.Status.Zones[i].DNSZone) {
+			continue
+		}
+		if err := r.config.CacheFreshness.AllowDestructiveAction(context.TODO(), r.cache, controllerName, "DeleteProviderRecord", &iov1.DNSRecord{}); err != nil {
+			return err
+		}
+		break
+	}
 	var errs []error
+	var failedZones []configv1.DNSZone
 	for i := range record.Status.Zones {
 		zone := record.Status.Zones[i].DNSZone
 		// If the record is currently not published in a zone,
@@ -397,23 +570,32 @@ func (r *reconciler) delete(record *iov1.DNSRecord) error {
 		if !recordIsAlreadyPublishedToZone(record, &zone) {
 			continue
 		}
-		err := r.dnsProvider.Delete(record, zone)
+		zoneRecord, err := dnsrecord.RecordForZone(record, isPrivateZone(zone, privateZone), true)
+		if err == nil {
+			err = r.dnsProvider.Delete(zoneRecord, zone)
+		}
 		if err != nil {
 			errs = append(errs, err)
+			failedZones = append(failedZones, zone)
 		} else {
 			log.Info("deleted dnsrecord from DNS provider", "record", record.Spec, "zone", zone)
 		}
 	}
-	if len(errs) == 0 {
-		updated := record.DeepCopy()
-		if slice.ContainsString(updated.Finalizers, manifests.DNSRecordFinalizer) {
-			updated.Finalizers = slice.RemoveString(updated.Finalizers, manifests.DNSRecordFinalizer)
-			if err := r.client.Update(context.TODO(), updated); err != nil {
-				errs = append(errs, fmt.Errorf("failed to remove finalizer from dnsrecord %s: %v", record.Name, err))
-			}
+	if len(errs) != 0 {
+		if !r.dnsCleanupExhausted(record) {
+			r.recordDNSCleanupAttempt(record)
+			return utilerrors.NewAggregate(errs)
 		}
+		r.abandonDNSCleanup(record, failedZones, utilerrors.NewAggregate(errs))
 	}
-	return utilerrors.NewAggregate(errs)
+	updated := record.DeepCopy()
+	if slice.ContainsString(updated.Finalizers, manifests.DNSRecordFinalizer) {
+		updated.Finalizers = slice.RemoveString(updated.Finalizers, manifests.DNSRecordFinalizer)
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return fmt.Errorf("failed to remove finalizer from dnsrecord %s: %v", record.Name, err)
+		}
+	}
+	return nil
 }
 
 // mergeStatuses updates or extends the provided slice of statuses with the
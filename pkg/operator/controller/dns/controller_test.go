@@ -9,6 +9,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	iov1 "github.com/openshift/api/operatoringress/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -119,7 +120,7 @@ func Test_publishRecordToZones(t *testing.T) {
 				dnsProvider: &dns.FakeProvider{},
 			}
 
-			_, actual := r.publishRecordToZones(test.zones, record)
+			_, actual := r.publishRecordToZones(test.zones, nil, record)
 			opts := cmpopts.IgnoreFields(iov1.DNSZoneCondition{}, "Reason", "Message", "LastTransitionTime")
 			if !cmp.Equal(actual, test.expect, opts) {
 				t.Fatalf("found diff between actual and expected:\n%s", cmp.Diff(actual, test.expect, opts))
@@ -218,7 +219,7 @@ func TestPublishRecordToZonesMergesStatus(t *testing.T) {
 			r := &reconciler{dnsProvider: &dns.FakeProvider{}}
 			zone := []configv1.DNSZone{{ID: "zone2"}}
 			oldStatuses := record.Status.DeepCopy().Zones
-			_, newStatuses := r.publishRecordToZones(zone, record)
+			_, newStatuses := r.publishRecordToZones(zone, nil, record)
 			if !dnsZoneStatusSlicesEqual(oldStatuses, tc.oldZoneStatuses) {
 				t.Fatalf("publishRecordToZones mutated the record's status conditions\nold: %#v\nnew: %#v", oldStatuses, tc.oldZoneStatuses)
 			}
@@ -229,6 +230,89 @@ func TestPublishRecordToZonesMergesStatus(t *testing.T) {
 	}
 }
 
+// replaceCountingProvider is a DNS provider that counts calls to Replace.
+type replaceCountingProvider struct {
+	dns.FakeProvider
+	replaced int
+}
+
+func (p *replaceCountingProvider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	p.replaced++
+	return nil
+}
+
+// Test_publishRecordToZonesDualStack verifies that publishRecordToZones
+// republishes an already published record if whether the record is dual-stack
+// has changed since it was last published, even if the record's generation has
+// not changed.
+func Test_publishRecordToZonesDualStack(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		expectReplace bool
+	}{
+		{
+			name:          "not dual-stack",
+			expectReplace: false,
+		},
+		{
+			name: "became dual-stack",
+			annotations: map[string]string{
+				dnsrecord.DNSDualStackAnnotation: "true",
+			},
+			expectReplace: true,
+		},
+		{
+			name: "already published as dual-stack",
+			annotations: map[string]string{
+				dnsrecord.DNSDualStackAnnotation:          "true",
+				dnsrecord.DNSDualStackPublishedAnnotation: "true",
+			},
+			expectReplace: false,
+		},
+		{
+			name: "no longer dual-stack",
+			annotations: map[string]string{
+				dnsrecord.DNSDualStackPublishedAnnotation: "true",
+			},
+			expectReplace: true,
+		},
+	}
+	zone := configv1.DNSZone{ID: "zone1"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation:  1,
+					Annotations: tc.annotations,
+				},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             "*.apps.dnszone.io.",
+					RecordType:          iov1.CNAMERecordType,
+					DNSManagementPolicy: iov1.ManagedDNS,
+					Targets:             []string{"lb.example.com"},
+				},
+				Status: iov1.DNSRecordStatus{
+					ObservedGeneration: 1,
+					Zones: []iov1.DNSZoneStatus{{
+						DNSZone: zone,
+						Conditions: []iov1.DNSZoneCondition{{
+							Type:   iov1.DNSRecordPublishedConditionType,
+							Status: string(operatorv1.ConditionTrue),
+						}},
+					}},
+				},
+			}
+			provider := &replaceCountingProvider{}
+			r := &reconciler{dnsProvider: provider}
+			r.publishRecordToZones([]configv1.DNSZone{zone}, nil, record)
+			if replaced := provider.replaced != 0; replaced != tc.expectReplace {
+				t.Errorf("expected replace %t, got %d calls to Replace", tc.expectReplace, provider.replaced)
+			}
+		})
+	}
+}
+
 func Test_migrateRecordStatusConditions(t *testing.T) {
 	tests := []struct {
 		name       string
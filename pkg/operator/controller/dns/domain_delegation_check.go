@@ -0,0 +1,304 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// nsLookupTimeout is how long to wait for a resolver to answer a query for NS
+// records.
+var nsLookupTimeout = 5 * time.Second
+
+const (
+	// domainDelegationLookupMaxAge is how long the outcome of looking up
+	// the delegation of a public zone's apex is reused for the domain
+	// delegation checks of other DNSRecords.
+	domainDelegationLookupMaxAge = 1 * time.Hour
+	// domainDelegationRecheckMaxAge is how old the outcome of a lookup
+	// may be to be reused when a recheck of the domain delegation is
+	// requested.
+	domainDelegationRecheckMaxAge = 1 * time.Minute
+	// domainDelegationLookupPollInterval is how long the DNS controller
+	// waits before it reconciles a DNSRecord again when the lookup for the
+	// record's domain delegation check is still in progress.
+	domainDelegationLookupPollInterval = 5 * time.Second
+)
+
+// nsLookupFunc looks up the names of the name servers to which the given DNS
+// name is delegated using the resolver with the given address.
+type nsLookupFunc func(ctx context.Context, address, name string) ([]string, error)
+
+// lookupNS looks up the NS records of the given DNS name using only the
+// resolver with the given address.  The name is resolved as a fully qualified
+// name so that the search domains of the operator's pod are not queried.
+func lookupNS(ctx context.Context, address, name string) ([]string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, nsLookupTimeout)
+	defer cancel()
+	records, err := resolver.LookupNS(ctx, strings.TrimSuffix(name, ".")+".")
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(records))
+	for _, record := range records {
+		hosts = append(hosts, record.Host)
+	}
+	return hosts, nil
+}
+
+// domainDelegationLookup is the outcome of looking up the name servers to
+// which a public zone's apex is delegated.
+type domainDelegationLookup struct {
+	// resolver is the address of the resolver that answered, if any.
+	resolver string
+	// nameServers are the normalized names of the name servers to which
+	// the resolver reported the apex to be delegated.
+	nameServers []string
+	// notDelegated indicates that the resolver reported that the apex
+	// does not exist.
+	notDelegated bool
+	// failures describes the errors from the resolvers that could not be
+	// queried.  It only matters if no resolver answered.
+	failures []string
+	// completed is when the lookup completed.
+	completed time.Time
+}
+
+// domainDelegationLookups looks up the delegations of public zones' apexes
+// in the background and caches the outcomes, so that the DNS controller never
+// waits on the resolvers, which might not be reachable at all, and so that
+// the DNSRecords for the same zone share one lookup.
+type domainDelegationLookups struct {
+	resolvers []string
+	lookupNS  nsLookupFunc
+
+	lock    sync.Mutex
+	results map[string]*domainDelegationLookup
+	pending map[string]struct{}
+}
+
+// newDomainDelegationLookups returns a domainDelegationLookups that queries
+// the given resolvers, in order, using the given lookup function.
+func newDomainDelegationLookups(resolvers []string, lookup nsLookupFunc) *domainDelegationLookups {
+	return &domainDelegationLookups{
+		resolvers: resolvers,
+		lookupNS:  lookup,
+		results:   map[string]*domainDelegationLookup{},
+		pending:   map[string]struct{}{},
+	}
+}
+
+// get returns the outcome of the latest lookup of the given apex if it is no
+// older than maxAge.  Otherwise, get starts a lookup in the background, unless
+// one is already in progress, and returns nil.
+func (l *domainDelegationLookups) get(apex string, maxAge time.Duration) *domainDelegationLookup {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if result, ok := l.results[apex]; ok && clock.Since(result.completed) <= maxAge {
+		return result
+	}
+	if _, ok := l.pending[apex]; ok {
+		return nil
+	}
+	l.pending[apex] = struct{}{}
+	go func() {
+		result := l.lookup(context.Background(), apex)
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.results[apex] = result
+		delete(l.pending, apex)
+	}()
+	return nil
+}
+
+// lookup queries the resolvers in order for the NS records of the given apex
+// until one of them answers.
+func (l *domainDelegationLookups) lookup(ctx context.Context, apex string) *domainDelegationLookup {
+	result := &domainDelegationLookup{}
+	for _, resolver := range l.resolvers {
+		address := resolver
+		if ip := net.ParseIP(resolver); ip != nil {
+			address = net.JoinHostPort(ip.String(), "53")
+		}
+		observed, err := l.lookupNS(ctx, address, apex)
+		var dnsErr *net.DNSError
+		switch {
+		case err == nil:
+			result.resolver, result.nameServers = address, normalizeNameServers(observed)
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+			result.resolver, result.notDelegated = address, true
+		default:
+			log.Info("failed to look up NS records for domain delegation check", "resolver", address, "name", apex, "error", err)
+			result.failures = append(result.failures, fmt.Sprintf("%s: %v", address, err))
+			continue
+		}
+		break
+	}
+	result.completed = clock.Now()
+	return result
+}
+
+// needsDomainDelegationCheck returns a Boolean value indicating whether the
+// DNS controller should check whether the given public zone is delegated to
+// its name servers for the given DNSRecord, which has the given zone statuses.
+// The check is performed once, after the record is first published to the
+// zone, and again whenever the record has the
+// dnsrecord.DNSRecheckDomainDelegationAnnotation annotation.  The check is
+// disabled if no resolvers are configured.
+func (r *reconciler) needsDomainDelegationCheck(record *iov1.DNSRecord, publicZone *configv1.DNSZone, statuses []iov1.DNSZoneStatus) bool {
+	if publicZone == nil || r.delegationLookups == nil || record.Spec.DNSManagementPolicy == iov1.UnmanagedDNS {
+		return false
+	}
+	if _, ok := r.dnsProvider.(dns.ZoneNameServerLister); !ok {
+		return false
+	}
+	for _, status := range statuses {
+		if !cmp.Equal(status.DNSZone, *publicZone) {
+			continue
+		}
+		published, checked := false, false
+		for _, cond := range status.Conditions {
+			switch cond.Type {
+			case iov1.DNSRecordPublishedConditionType:
+				published = cond.Status == string(operatorv1.ConditionTrue)
+			case dnsrecord.DNSDomainDelegationSuspectConditionType:
+				checked = true
+			}
+		}
+		if !published {
+			return false
+		}
+		_, recheck := record.Annotations[dnsrecord.DNSRecheckDomainDelegationAnnotation]
+		return !checked || recheck
+	}
+	return false
+}
+
+// checkDomainDelegation compares the name servers to which the public DNS
+// delegates the apex of the given public zone with the zone's name servers
+// and returns the "DomainDelegationSuspect" condition for the zone, or nil if
+// the zone's name servers cannot be determined.  If the zone is not delegated
+// to its name servers, records that are published to the zone appear healthy
+// but do not resolve publicly.
+//
+// The configured resolvers are queried in order until one of them answers.
+// The resolvers are expected to be public recursive resolvers, which follow
+// the delegations from the root zone.  If none of them can be reached, as in a
+// disconnected cluster, the condition's status is "Unknown".
+//
+// The resolvers are queried in the background.  If the outcome of the lookup
+// is not yet available, checkDomainDelegation returns a nil condition and a
+// true value to indicate that the check is pending.
+func (r *reconciler) checkDomainDelegation(record *iov1.DNSRecord, zone configv1.DNSZone) (*iov1.DNSZoneCondition, bool) {
+	lister, ok := r.dnsProvider.(dns.ZoneNameServerLister)
+	if !ok || r.delegationLookups == nil {
+		return nil, false
+	}
+	apex, expected, err := lister.ZoneNameServers(zone)
+	if err != nil {
+		log.Error(err, "failed to look up the zone's name servers; skipping domain delegation check", "record", record.Spec, "dnszone", zone)
+		return nil, false
+	}
+	if len(apex) == 0 || len(expected) == 0 {
+		log.Info("zone has no name servers; skipping domain delegation check", "record", record.Spec, "dnszone", zone)
+		return nil, false
+	}
+	apex = strings.ToLower(strings.TrimSuffix(apex, "."))
+	expected = normalizeNameServers(expected)
+
+	maxAge := domainDelegationLookupMaxAge
+	if _, ok := record.Annotations[dnsrecord.DNSRecheckDomainDelegationAnnotation]; ok {
+		maxAge = domainDelegationRecheckMaxAge
+	}
+	result := r.delegationLookups.get(apex, maxAge)
+	switch {
+	case result == nil:
+		return nil, true
+	case result.notDelegated:
+		return domainDelegationSuspectCondition(operatorv1.ConditionTrue, "NotDelegated", fmt.Sprintf("Resolver %s reports that the public zone's apex %s has no NS records, so %s does not resolve publicly.  Add NS records for %s to its parent domain with the zone's name servers: %s.", result.resolver, apex, record.Spec.DNSName, apex, strings.Join(expected, ", "))), false
+	case len(result.resolver) == 0:
+		return domainDelegationSuspectCondition(operatorv1.ConditionUnknown, "LookupFailed", fmt.Sprintf("None of the resolvers could look up the NS records for the public zone's apex %s, which is expected if the cluster cannot reach them, as in a disconnected environment:\n%s", apex, strings.Join(result.failures, "\n"))), false
+	case strings.Join(result.nameServers, ",") == strings.Join(expected, ","):
+		return domainDelegationSuspectCondition(operatorv1.ConditionFalse, "DelegationMatches", fmt.Sprintf("The public zone's apex %s is delegated to the zone's name servers: %s.", apex, strings.Join(expected, ", "))), false
+	default:
+		return domainDelegationSuspectCondition(operatorv1.ConditionTrue, "NameServerMismatch", fmt.Sprintf("Resolver %s reports that the public zone's apex %s is delegated to name servers %s, but the zone's name servers are %s, so %s might not resolve publicly.  Update the NS records for %s in its parent domain to match the zone's name servers.", result.resolver, apex, strings.Join(result.nameServers, ", "), strings.Join(expected, ", "), record.Spec.DNSName, apex)), false
+	}
+}
+
+// removeDomainDelegationSuspectConditions removes the
+// "DomainDelegationSuspect" condition from the given zone statuses and
+// returns the updated statuses.
+func removeDomainDelegationSuspectConditions(statuses []iov1.DNSZoneStatus) []iov1.DNSZoneStatus {
+	for i := range statuses {
+		conditions := statuses[i].Conditions[:0]
+		for _, cond := range statuses[i].Conditions {
+			if cond.Type != dnsrecord.DNSDomainDelegationSuspectConditionType {
+				conditions = append(conditions, cond)
+			}
+		}
+		statuses[i].Conditions = conditions
+	}
+	return statuses
+}
+
+// normalizeNameServers returns the given name server names in lower case,
+// without trailing dots, and sorted.
+func normalizeNameServers(nameServers []string) []string {
+	normalized := make([]string, 0, len(nameServers))
+	for _, ns := range nameServers {
+		normalized = append(normalized, strings.ToLower(strings.TrimSuffix(ns, ".")))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// domainDelegationSuspectCondition returns a "DomainDelegationSuspect"
+// condition with the given status, reason, and message.
+func domainDelegationSuspectCondition(status operatorv1.ConditionStatus, reason, message string) *iov1.DNSZoneCondition {
+	return &iov1.DNSZoneCondition{
+		Type:               dnsrecord.DNSDomainDelegationSuspectConditionType,
+		Status:             string(status),
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// removeRecheckDomainDelegationAnnotation removes the
+// dnsrecord.DNSRecheckDomainDelegationAnnotation annotation from the named
+// DNSRecord, if it has the annotation.
+func (r *reconciler) removeRecheckDomainDelegationAnnotation(ctx context.Context, name types.NamespacedName) error {
+	var current iov1.DNSRecord
+	if err := r.client.Get(ctx, name, &current); err != nil {
+		return err
+	}
+	if _, ok := current.Annotations[dnsrecord.DNSRecheckDomainDelegationAnnotation]; !ok {
+		return nil
+	}
+	updated := current.DeepCopy()
+	delete(updated.Annotations, dnsrecord.DNSRecheckDomainDelegationAnnotation)
+	return r.client.Update(ctx, updated)
+}
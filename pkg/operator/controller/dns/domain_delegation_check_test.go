@@ -0,0 +1,395 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilclock "k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// nameServerListingProvider is a DNS provider that records calls like
+// zoneRecordingProvider and lists fake name servers for zones.
+type nameServerListingProvider struct {
+	zoneRecordingProvider
+	apex        string
+	nameServers []string
+	err         error
+}
+
+func (p *nameServerListingProvider) ZoneNameServers(zone configv1.DNSZone) (string, []string, error) {
+	return p.apex, p.nameServers, p.err
+}
+
+// stubResolver answers NS queries from fake data, keyed by the resolver's
+// address, and records the addresses that it is asked to query.
+type stubResolver struct {
+	answers map[string][]string
+	errs    map[string]error
+
+	lock    sync.Mutex
+	queried []string
+}
+
+func (s *stubResolver) lookupNS(_ context.Context, address, name string) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.queried = append(s.queried, address)
+	if err, ok := s.errs[address]; ok {
+		return nil, err
+	}
+	if answer, ok := s.answers[address]; ok {
+		return answer, nil
+	}
+	return nil, fmt.Errorf("dial udp %s: i/o timeout", address)
+}
+
+func (s *stubResolver) queries() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.queried...)
+}
+
+// checkDomainDelegationAndWait calls checkDomainDelegation until the lookup
+// that it starts in the background completes.
+func checkDomainDelegationAndWait(t *testing.T, r *reconciler, record *iov1.DNSRecord, zone configv1.DNSZone) *iov1.DNSZoneCondition {
+	t.Helper()
+	var cond *iov1.DNSZoneCondition
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+		var pending bool
+		cond, pending = r.checkDomainDelegation(record, zone)
+		return !pending, nil
+	})
+	if err != nil {
+		t.Fatalf("domain delegation check did not complete: %v", err)
+	}
+	return cond
+}
+
+// Test_checkDomainDelegation verifies that checkDomainDelegation reports
+// whether the public zone's apex is delegated to the zone's name servers
+// according to the first resolver that answers.
+func Test_checkDomainDelegation(t *testing.T) {
+	zoneNameServers := []string{"ns-1.awsdns-01.org.", "NS-2.awsdns-02.com"}
+	nxdomain := &net.DNSError{Err: "no such host", Name: "example.com.", IsNotFound: true}
+	testCases := []struct {
+		name          string
+		resolvers     []string
+		answers       map[string][]string
+		errs          map[string]error
+		listerErr     error
+		nameServers   []string
+		expectNil     bool
+		expectStatus  operatorv1.ConditionStatus
+		expectReason  string
+		expectQueried []string
+		expectMessage string
+	}{
+		{
+			name:          "delegation matches",
+			resolvers:     []string{"1.1.1.1"},
+			answers:       map[string][]string{"1.1.1.1:53": {"ns-2.awsdns-02.com.", "ns-1.awsdns-01.org."}},
+			nameServers:   zoneNameServers,
+			expectStatus:  operatorv1.ConditionFalse,
+			expectReason:  "DelegationMatches",
+			expectQueried: []string{"1.1.1.1:53"},
+		},
+		{
+			name:          "delegation mismatches",
+			resolvers:     []string{"1.1.1.1"},
+			answers:       map[string][]string{"1.1.1.1:53": {"ns-3.awsdns-03.net.", "ns-1.awsdns-01.org."}},
+			nameServers:   zoneNameServers,
+			expectStatus:  operatorv1.ConditionTrue,
+			expectReason:  "NameServerMismatch",
+			expectQueried: []string{"1.1.1.1:53"},
+			expectMessage: "name servers ns-1.awsdns-01.org, ns-3.awsdns-03.net, but the zone's name servers are ns-1.awsdns-01.org, ns-2.awsdns-02.com",
+		},
+		{
+			name:          "apex does not exist",
+			resolvers:     []string{"1.1.1.1"},
+			errs:          map[string]error{"1.1.1.1:53": nxdomain},
+			nameServers:   zoneNameServers,
+			expectStatus:  operatorv1.ConditionTrue,
+			expectReason:  "NotDelegated",
+			expectQueried: []string{"1.1.1.1:53"},
+		},
+		{
+			name:          "first resolver unreachable",
+			resolvers:     []string{"1.1.1.1", "[2001:4860:4860::8888]:5353"},
+			answers:       map[string][]string{"[2001:4860:4860::8888]:5353": {"ns-1.awsdns-01.org", "ns-2.awsdns-02.com"}},
+			nameServers:   zoneNameServers,
+			expectStatus:  operatorv1.ConditionFalse,
+			expectReason:  "DelegationMatches",
+			expectQueried: []string{"1.1.1.1:53", "[2001:4860:4860::8888]:5353"},
+		},
+		{
+			name:          "all resolvers unreachable",
+			resolvers:     []string{"1.1.1.1", "8.8.8.8"},
+			nameServers:   zoneNameServers,
+			expectStatus:  operatorv1.ConditionUnknown,
+			expectReason:  "LookupFailed",
+			expectQueried: []string{"1.1.1.1:53", "8.8.8.8:53"},
+		},
+		{
+			name:        "zone's name servers cannot be listed",
+			resolvers:   []string{"1.1.1.1"},
+			listerErr:   fmt.Errorf("AccessDenied"),
+			nameServers: zoneNameServers,
+			expectNil:   true,
+		},
+		{
+			name:      "zone has no name servers",
+			resolvers: []string{"1.1.1.1"},
+			expectNil: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &nameServerListingProvider{apex: "example.com.", nameServers: tc.nameServers, err: tc.listerErr}
+			resolver := &stubResolver{answers: tc.answers, errs: tc.errs}
+			r := &reconciler{
+				config:            Config{DomainDelegationCheckResolvers: tc.resolvers},
+				dnsProvider:       provider,
+				delegationLookups: newDomainDelegationLookups(tc.resolvers, resolver.lookupNS),
+			}
+			record := &iov1.DNSRecord{Spec: iov1.DNSRecordSpec{DNSName: "*.apps.cluster.example.com."}}
+			cond := checkDomainDelegationAndWait(t, r, record, configv1.DNSZone{ID: "public"})
+			if tc.expectNil {
+				if cond != nil {
+					t.Errorf("expected no condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatal("expected a condition, got nil")
+			}
+			if cond.Type != dnsrecord.DNSDomainDelegationSuspectConditionType || cond.Status != string(tc.expectStatus) || cond.Reason != tc.expectReason {
+				t.Errorf("expected condition with status %q and reason %q, got %+v", tc.expectStatus, tc.expectReason, cond)
+			}
+			if !strings.Contains(cond.Message, tc.expectMessage) {
+				t.Errorf("expected message to contain %q, got %q", tc.expectMessage, cond.Message)
+			}
+			if actual := resolver.queries(); !reflect.DeepEqual(actual, tc.expectQueried) {
+				t.Errorf("expected resolvers %v to be queried, got %v", tc.expectQueried, actual)
+			}
+		})
+	}
+}
+
+// Test_checkDomainDelegationCachesLookups verifies that checkDomainDelegation
+// does not wait for the resolvers, that DNSRecords for the same zone share the
+// outcome of a lookup, and that a requested recheck only reuses a recent
+// outcome.
+func Test_checkDomainDelegationCachesLookups(t *testing.T) {
+	defer func(c utilclock.Clock) { clock = c }(clock)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	clock = fakeClock
+
+	blocked := make(chan struct{})
+	resolver := &stubResolver{answers: map[string][]string{"1.1.1.1:53": {"ns-1.example.org"}}}
+	lookup := func(ctx context.Context, address, name string) ([]string, error) {
+		<-blocked
+		return resolver.lookupNS(ctx, address, name)
+	}
+	r := &reconciler{
+		dnsProvider:       &nameServerListingProvider{apex: "example.com.", nameServers: []string{"ns-1.example.org"}},
+		delegationLookups: newDomainDelegationLookups([]string{"1.1.1.1"}, lookup),
+	}
+	zone := configv1.DNSZone{ID: "public"}
+	wildcard := &iov1.DNSRecord{Spec: iov1.DNSRecordSpec{DNSName: "*.apps.cluster.example.com."}}
+	other := &iov1.DNSRecord{Spec: iov1.DNSRecordSpec{DNSName: "*.other.cluster.example.com."}}
+
+	if cond, pending := r.checkDomainDelegation(wildcard, zone); cond != nil || !pending {
+		t.Fatalf("expected the check to be pending while the resolver does not answer, got condition %+v and pending %t", cond, pending)
+	}
+	if cond, pending := r.checkDomainDelegation(other, zone); cond != nil || !pending {
+		t.Fatalf("expected the check to be pending while the resolver does not answer, got condition %+v and pending %t", cond, pending)
+	}
+	close(blocked)
+	if cond := checkDomainDelegationAndWait(t, r, wildcard, zone); cond == nil || cond.Reason != "DelegationMatches" {
+		t.Fatalf("expected a DelegationMatches condition, got %+v", cond)
+	}
+	if cond, pending := r.checkDomainDelegation(other, zone); pending || cond == nil || cond.Reason != "DelegationMatches" {
+		t.Fatalf("expected the cached outcome to be reused, got condition %+v and pending %t", cond, pending)
+	}
+	if actual := resolver.queries(); len(actual) != 1 {
+		t.Fatalf("expected the resolver to be queried once, got %v", actual)
+	}
+
+	fakeClock.Step(2 * domainDelegationRecheckMaxAge)
+	if _, pending := r.checkDomainDelegation(other, zone); pending {
+		t.Fatal("expected the cached outcome to be reused without a recheck")
+	}
+	recheck := other.DeepCopy()
+	recheck.Annotations = map[string]string{dnsrecord.DNSRecheckDomainDelegationAnnotation: ""}
+	if _, pending := r.checkDomainDelegation(recheck, zone); !pending {
+		t.Fatal("expected a recheck to look up the delegation again")
+	}
+	checkDomainDelegationAndWait(t, r, recheck, zone)
+	if actual := resolver.queries(); len(actual) != 2 {
+		t.Fatalf("expected the resolver to be queried twice, got %v", actual)
+	}
+}
+
+// Test_removeDomainDelegationSuspectConditions verifies that the outcome of a
+// domain delegation check is removed from the zone statuses while the other
+// conditions are kept.
+func Test_removeDomainDelegationSuspectConditions(t *testing.T) {
+	published := iov1.DNSZoneCondition{Type: iov1.DNSRecordPublishedConditionType, Status: string(operatorv1.ConditionTrue)}
+	suspect := iov1.DNSZoneCondition{Type: dnsrecord.DNSDomainDelegationSuspectConditionType, Status: string(operatorv1.ConditionUnknown), Reason: "LookupFailed"}
+	statuses := []iov1.DNSZoneStatus{
+		{DNSZone: configv1.DNSZone{ID: "private"}, Conditions: []iov1.DNSZoneCondition{published}},
+		{DNSZone: configv1.DNSZone{ID: "public"}, Conditions: []iov1.DNSZoneCondition{published, suspect}},
+	}
+	expect := []iov1.DNSZoneStatus{
+		{DNSZone: configv1.DNSZone{ID: "private"}, Conditions: []iov1.DNSZoneCondition{published}},
+		{DNSZone: configv1.DNSZone{ID: "public"}, Conditions: []iov1.DNSZoneCondition{published}},
+	}
+	if actual := removeDomainDelegationSuspectConditions(statuses); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected %+v, got %+v", expect, actual)
+	}
+}
+
+// Test_needsDomainDelegationCheck verifies that the domain delegation check is
+// only performed once the record is published to the public zone and only
+// performed again when the recheck annotation is added.
+func Test_needsDomainDelegationCheck(t *testing.T) {
+	publicZone := configv1.DNSZone{ID: "public"}
+	published := iov1.DNSZoneCondition{Type: iov1.DNSRecordPublishedConditionType, Status: string(operatorv1.ConditionTrue)}
+	notPublished := iov1.DNSZoneCondition{Type: iov1.DNSRecordPublishedConditionType, Status: string(operatorv1.ConditionFalse)}
+	checked := iov1.DNSZoneCondition{Type: dnsrecord.DNSDomainDelegationSuspectConditionType, Status: string(operatorv1.ConditionFalse)}
+	recheck := map[string]string{dnsrecord.DNSRecheckDomainDelegationAnnotation: ""}
+	testCases := []struct {
+		name        string
+		resolvers   []string
+		notLister   bool
+		policy      iov1.DNSManagementPolicy
+		annotations map[string]string
+		publicZone  *configv1.DNSZone
+		conditions  []iov1.DNSZoneCondition
+		expect      bool
+	}{
+		{
+			name:       "published and not yet checked",
+			conditions: []iov1.DNSZoneCondition{published},
+			expect:     true,
+		},
+		{
+			name:       "published and already checked",
+			conditions: []iov1.DNSZoneCondition{published, checked},
+		},
+		{
+			name:        "published, already checked, and recheck requested",
+			annotations: recheck,
+			conditions:  []iov1.DNSZoneCondition{published, checked},
+			expect:      true,
+		},
+		{
+			name:        "not published and recheck requested",
+			annotations: recheck,
+			conditions:  []iov1.DNSZoneCondition{notPublished},
+		},
+		{
+			name:       "check disabled",
+			resolvers:  []string{},
+			conditions: []iov1.DNSZoneCondition{published},
+		},
+		{
+			name:       "provider cannot list name servers",
+			notLister:  true,
+			conditions: []iov1.DNSZoneCondition{published},
+		},
+		{
+			name:       "unmanaged",
+			policy:     iov1.UnmanagedDNS,
+			conditions: []iov1.DNSZoneCondition{published},
+		},
+		{
+			name:       "no public zone",
+			publicZone: &configv1.DNSZone{},
+			conditions: []iov1.DNSZoneCondition{published},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolvers := tc.resolvers
+			if resolvers == nil {
+				resolvers = []string{"1.1.1.1"}
+			}
+			r := &reconciler{
+				config:      Config{DomainDelegationCheckResolvers: resolvers},
+				dnsProvider: &nameServerListingProvider{},
+			}
+			if len(resolvers) != 0 {
+				r.delegationLookups = newDomainDelegationLookups(resolvers, (&stubResolver{}).lookupNS)
+			}
+			if tc.notLister {
+				r.dnsProvider = &zoneRecordingProvider{}
+			}
+			policy := tc.policy
+			if len(policy) == 0 {
+				policy = iov1.ManagedDNS
+			}
+			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec:       iov1.DNSRecordSpec{DNSManagementPolicy: policy},
+			}
+			zone := &publicZone
+			if tc.publicZone != nil {
+				zone = tc.publicZone
+			}
+			statuses := []iov1.DNSZoneStatus{{DNSZone: publicZone, Conditions: tc.conditions}}
+			if actual := r.needsDomainDelegationCheck(record, zone, statuses); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_removeRecheckDomainDelegationAnnotation verifies that
+// removeRecheckDomainDelegationAnnotation removes only the recheck annotation.
+func Test_removeRecheckDomainDelegationAnnotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	iov1.Install(scheme)
+	record := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "default-wildcard",
+			Annotations: map[string]string{
+				dnsrecord.DNSRecheckDomainDelegationAnnotation: "",
+				dnsrecord.DNSDualStackAnnotation:               "true",
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(record).Build()
+	r := &reconciler{client: cl}
+	name := types.NamespacedName{Namespace: record.Namespace, Name: record.Name}
+	if err := r.removeRecheckDomainDelegationAnnotation(context.Background(), name); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actual iov1.DNSRecord
+	if err := cl.Get(context.Background(), name, &actual); err != nil {
+		t.Fatalf("failed to get dnsrecord: %v", err)
+	}
+	expect := map[string]string{dnsrecord.DNSDualStackAnnotation: "true"}
+	if !reflect.DeepEqual(actual.Annotations, expect) {
+		t.Errorf("expected annotations %v, got %v", expect, actual.Annotations)
+	}
+}
@@ -0,0 +1,39 @@
+package dns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+)
+
+var (
+	// orphanedDNSRecords reports DNS records that the controller gave up
+	// deleting from the DNS provider and that may need to be removed
+	// manually.
+	orphanedDNSRecords = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_dns_record_orphaned",
+		Help: "Report DNS records that could not be deleted from the DNS provider and may need manual removal. The value is always 1.",
+	}, []string{"namespace", "name", "dns_name", "zone"})
+
+	// metricsList is a list of metrics for this package.
+	metricsList = []prometheus.Collector{
+		orphanedDNSRecords,
+	}
+)
+
+// SetOrphanedDNSRecordMetric reports that the given DNSRecord may remain
+// published in the given zone using the ingress_dns_record_orphaned metric.
+func SetOrphanedDNSRecordMetric(record *iov1.DNSRecord, zone string) {
+	orphanedDNSRecords.WithLabelValues(record.Namespace, record.Name, record.Spec.DNSName, zone).Set(1)
+}
+
+// RegisterMetrics calls prometheus.Register on each metric in metricsList, and
+// returns on errors.
+func RegisterMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
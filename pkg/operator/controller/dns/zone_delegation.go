@@ -0,0 +1,63 @@
+package dns
+
+import (
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// zoneNotAuthoritativeReason is the reason of the DNSRecord's "Published"
+// condition for a zone that delegates the subzone containing the record's name
+// to other name servers.
+const zoneNotAuthoritativeReason = "ZoneNotAuthoritative"
+
+// delegatedSubzone returns the name of the subzone of the given zone that the
+// zone delegates to other name servers and that contains the given record's
+// DNS name, or the empty string if the zone is authoritative for the name.
+// Publishing the record to the zone would succeed, but the record would not
+// resolve.
+//
+// The check is skipped, and the empty string returned, if the DNS provider
+// cannot inspect the zone's records or if the record has the
+// dnsrecord.DNSSkipZoneDelegationCheckAnnotation annotation.  If the check
+// fails, for example because the credentials do not allow reading the zone's
+// records, the error is logged and the empty string returned so that the
+// record is published as it was before the check was added.
+func (r *reconciler) delegatedSubzone(record *iov1.DNSRecord, zone configv1.DNSZone) string {
+	detector, ok := r.dnsProvider.(dns.ZoneDelegationDetector)
+	if !ok {
+		return ""
+	}
+	if record.Annotations[dnsrecord.DNSSkipZoneDelegationCheckAnnotation] == "true" {
+		log.Info("skipping zone delegation check", "record", record.Spec, "dnszone", zone)
+		return ""
+	}
+	subzone, err := detector.DelegatedSubzone(record.Spec.DNSName, zone)
+	if err != nil {
+		log.Error(err, "failed to check whether the zone delegates the DNS record's name; publishing the record anyway", "record", record.Spec, "dnszone", zone)
+		return ""
+	}
+	if len(subzone) != 0 {
+		log.Info("not publishing DNS record to zone that delegates the record's name", "record", record.Spec, "dnszone", zone, "subzone", subzone)
+	}
+	return subzone
+}
+
+// zoneNotAuthoritativeCondition returns the "Published" condition for a zone
+// that delegates the given subzone, which contains the record's name, to other
+// name servers.
+func zoneNotAuthoritativeCondition(subzone string) iov1.DNSZoneCondition {
+	return iov1.DNSZoneCondition{
+		Message:            fmt.Sprintf("The DNS record was not published because the zone delegates the subzone %q to other name servers, so the record would not resolve.  Publish the record in the zone for %q, or set the %q annotation to %q on the DNSRecord to publish it anyway.", subzone, subzone, dnsrecord.DNSSkipZoneDelegationCheckAnnotation, "true"),
+		Reason:             zoneNotAuthoritativeReason,
+		Status:             string(operatorv1.ConditionFalse),
+		Type:               iov1.DNSRecordPublishedConditionType,
+		LastTransitionTime: metav1.Now(),
+	}
+}
@@ -0,0 +1,182 @@
+package dns
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeZone is the zone data that delegatingProvider uses to detect
+// delegations.
+type fakeZone struct {
+	// apex is the zone's domain.
+	apex string
+	// nsRecords are the names in the zone that have NS records, other than
+	// the apex.
+	nsRecords []string
+}
+
+// delegatingProvider is a DNS provider that records calls like
+// zoneRecordingProvider and detects delegations from fake zone data.
+type delegatingProvider struct {
+	zoneRecordingProvider
+	zones map[string]fakeZone
+	err   error
+}
+
+func (p *delegatingProvider) DelegatedSubzone(dnsName string, zone configv1.DNSZone) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	z, ok := p.zones[zone.ID]
+	if !ok {
+		return "", fmt.Errorf("zone %s not found", zone.ID)
+	}
+	for _, name := range dns.DelegationCandidates(dnsName, z.apex) {
+		for _, ns := range z.nsRecords {
+			if strings.EqualFold(name, ns) {
+				return name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Test_publishRecordToZonesZoneDelegation verifies that a DNSRecord is not
+// published to a zone that delegates the subzone containing the record's name,
+// that the record's status reports the delegation, and that the record is
+// published anyway if it has the skip-zone-delegation-check annotation or if
+// the delegation cannot be determined.
+func Test_publishRecordToZonesZoneDelegation(t *testing.T) {
+	privateZone := configv1.DNSZone{ID: "private"}
+	publicZone := configv1.DNSZone{ID: "public"}
+	zones := []configv1.DNSZone{privateZone, publicZone}
+	testCases := []struct {
+		name        string
+		dnsName     string
+		annotations map[string]string
+		zones       map[string]fakeZone
+		err         error
+		// expectCalls is the zones to which the record is expected to
+		// be published.
+		expectCalls []string
+		// expectNotAuthoritative is the zones for which the record's
+		// status is expected to report ZoneNotAuthoritative.
+		expectNotAuthoritative []string
+		expectSubzone          string
+	}{
+		{
+			name:    "no delegation",
+			dnsName: "*.apps.cluster.example.com.",
+			zones: map[string]fakeZone{
+				"private": {apex: "cluster.example.com."},
+				"public":  {apex: "example.com.", nsRecords: []string{"other.example.com."}},
+			},
+			expectCalls: []string{"private", "public"},
+		},
+		{
+			name:    "public zone delegates the apps subzone",
+			dnsName: "*.apps.cluster.example.com.",
+			zones: map[string]fakeZone{
+				"private": {apex: "cluster.example.com."},
+				"public":  {apex: "example.com.", nsRecords: []string{"apps.cluster.example.com."}},
+			},
+			expectCalls:            []string{"private"},
+			expectNotAuthoritative: []string{"public"},
+			expectSubzone:          "apps.cluster.example.com.",
+		},
+		{
+			name:    "public zone delegates the cluster subzone",
+			dnsName: "*.apps.cluster.example.com.",
+			zones: map[string]fakeZone{
+				"private": {apex: "cluster.example.com."},
+				"public":  {apex: "example.com.", nsRecords: []string{"cluster.example.com.", "apps.cluster.example.com."}},
+			},
+			expectCalls:            []string{"private"},
+			expectNotAuthoritative: []string{"public"},
+			expectSubzone:          "cluster.example.com.",
+		},
+		{
+			name:    "delegation of a sibling subzone",
+			dnsName: "*.apps.cluster.example.com.",
+			zones: map[string]fakeZone{
+				"private": {apex: "cluster.example.com."},
+				"public":  {apex: "example.com.", nsRecords: []string{"apps.other.example.com."}},
+			},
+			expectCalls: []string{"private", "public"},
+		},
+		{
+			name:        "delegated, but the check is skipped",
+			dnsName:     "*.apps.cluster.example.com.",
+			annotations: map[string]string{dnsrecord.DNSSkipZoneDelegationCheckAnnotation: "true"},
+			zones: map[string]fakeZone{
+				"private": {apex: "cluster.example.com."},
+				"public":  {apex: "example.com.", nsRecords: []string{"apps.cluster.example.com."}},
+			},
+			expectCalls: []string{"private", "public"},
+		},
+		{
+			name:        "the check fails",
+			dnsName:     "*.apps.cluster.example.com.",
+			err:         fmt.Errorf("AccessDenied"),
+			expectCalls: []string{"private", "public"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation:  1,
+					Annotations: tc.annotations,
+				},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             tc.dnsName,
+					RecordType:          iov1.CNAMERecordType,
+					Targets:             []string{"lb.example.com"},
+					RecordTTL:           30,
+					DNSManagementPolicy: iov1.ManagedDNS,
+				},
+			}
+			provider := &delegatingProvider{zones: tc.zones, err: tc.err}
+			r := &reconciler{dnsProvider: provider}
+			requeue, statuses := r.publishRecordToZones(zones, &privateZone, record)
+			if expectRequeue := len(tc.expectNotAuthoritative) != 0; requeue != expectRequeue {
+				t.Errorf("expected requeue to be %t, got %t", expectRequeue, requeue)
+			}
+			var calls []string
+			for _, call := range provider.calls {
+				calls = append(calls, call.zone)
+			}
+			if !reflect.DeepEqual(calls, tc.expectCalls) {
+				t.Errorf("expected the record to be published to zones %v, got %v", tc.expectCalls, calls)
+			}
+			var notAuthoritative []string
+			for _, status := range statuses {
+				for _, condition := range status.Conditions {
+					if condition.Reason != zoneNotAuthoritativeReason {
+						continue
+					}
+					notAuthoritative = append(notAuthoritative, status.DNSZone.ID)
+					if condition.Status != string(operatorv1.ConditionFalse) {
+						t.Errorf("expected zone %s to have status %s, got %s", status.DNSZone.ID, operatorv1.ConditionFalse, condition.Status)
+					}
+					if !strings.Contains(condition.Message, tc.expectSubzone) {
+						t.Errorf("expected the message for zone %s to name subzone %q, got %q", status.DNSZone.ID, tc.expectSubzone, condition.Message)
+					}
+				}
+			}
+			if !reflect.DeepEqual(notAuthoritative, tc.expectNotAuthoritative) {
+				t.Errorf("expected zones %v to report %s, got %v", tc.expectNotAuthoritative, zoneNotAuthoritativeReason, notAuthoritative)
+			}
+		})
+	}
+}
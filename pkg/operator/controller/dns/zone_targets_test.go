@@ -0,0 +1,222 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// zoneRecordCall is a call to a DNS provider that zoneRecordingProvider
+// records.
+type zoneRecordCall struct {
+	action     string
+	zone       string
+	recordType iov1.DNSRecordType
+	targets    []string
+}
+
+// zoneRecordingProvider is a DNS provider that records the record type and
+// targets with which each zone's record is ensured, replaced, or deleted.
+type zoneRecordingProvider struct {
+	calls []zoneRecordCall
+}
+
+func (p *zoneRecordingProvider) record(action string, record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	p.calls = append(p.calls, zoneRecordCall{action, zone.ID, record.Spec.RecordType, record.Spec.Targets})
+	return nil
+}
+
+func (p *zoneRecordingProvider) Ensure(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.record("ensure", record, zone)
+}
+
+func (p *zoneRecordingProvider) Delete(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.record("delete", record, zone)
+}
+
+func (p *zoneRecordingProvider) Replace(record *iov1.DNSRecord, zone configv1.DNSZone) error {
+	return p.record("replace", record, zone)
+}
+
+// Test_publishRecordToZonesZoneTargets verifies that a DNSRecord with different
+// public and private zone targets is published to each zone with the zone's
+// targets and is deleted from both zones with the targets with which it was
+// published.
+func Test_publishRecordToZonesZoneTargets(t *testing.T) {
+	privateZone := configv1.DNSZone{ID: "private"}
+	publicZone := configv1.DNSZone{ID: "public"}
+	zones := []configv1.DNSZone{privateZone, publicZone}
+	zoneTargets := `{"public":{"recordType":"CNAME","targets":["public-lb.example.com"]},"private":{"recordType":"A","targets":["10.0.0.5","10.0.0.6"]}}`
+	dnsRecord := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "openshift-ingress-operator",
+			Name:       "default-wildcard",
+			Generation: 1,
+			Finalizers: []string{manifests.DNSRecordFinalizer},
+			Annotations: map[string]string{
+				dnsrecord.DNSZoneTargetsAnnotation: zoneTargets,
+			},
+		},
+		Spec: iov1.DNSRecordSpec{
+			DNSName:             "*.apps.example.com.",
+			RecordType:          iov1.CNAMERecordType,
+			Targets:             []string{"lb.example.com"},
+			RecordTTL:           30,
+			DNSManagementPolicy: iov1.ManagedDNS,
+		},
+	}
+
+	provider := &zoneRecordingProvider{}
+	r := &reconciler{dnsProvider: provider}
+	requeue, statuses := r.publishRecordToZones(zones, &privateZone, dnsRecord)
+	if requeue {
+		t.Fatalf("expected no requeue, got statuses %+v", statuses)
+	}
+	expected := []zoneRecordCall{
+		{"ensure", "private", iov1.ARecordType, []string{"10.0.0.5", "10.0.0.6"}},
+		{"ensure", "public", iov1.CNAMERecordType, []string{"public-lb.example.com"}},
+	}
+	if !reflect.DeepEqual(provider.calls, expected) {
+		t.Fatalf("expected provider calls %+v, got %+v", expected, provider.calls)
+	}
+	if !reflect.DeepEqual(dnsRecord.Spec.Targets, []string{"lb.example.com"}) {
+		t.Errorf("expected the record's spec not to be modified, got targets %v", dnsRecord.Spec.Targets)
+	}
+
+	// Publishing the record again without changes is a no-op.
+	dnsRecord.Status = iov1.DNSRecordStatus{ObservedGeneration: 1, Zones: statuses}
+	dnsRecord.Annotations[dnsrecord.DNSZoneTargetsPublishedAnnotation] = zoneTargets
+	provider.calls = nil
+	r.publishRecordToZones(zones, &privateZone, dnsRecord)
+	if len(provider.calls) != 0 {
+		t.Errorf("expected no provider calls, got %+v", provider.calls)
+	}
+
+	// Deleting the record deletes it from both zones.
+	dnsRecord.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	scheme := runtime.NewScheme()
+	iov1.Install(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(dnsRecord).
+		WithObjects(dnsRecord).
+		Build()
+	r = &reconciler{
+		client:      cl,
+		dnsProvider: provider,
+		recorder:    record.NewFakeRecorder(10),
+	}
+	current := &iov1.DNSRecord{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(dnsRecord), current); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.delete(current, &privateZone); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []zoneRecordCall{
+		{"delete", "private", iov1.ARecordType, []string{"10.0.0.5", "10.0.0.6"}},
+		{"delete", "public", iov1.CNAMERecordType, []string{"public-lb.example.com"}},
+	}
+	if !reflect.DeepEqual(provider.calls, expected) {
+		t.Errorf("expected provider calls %+v, got %+v", expected, provider.calls)
+	}
+}
+
+// Test_publishRecordToZonesZoneTargetsTypeChange verifies that changing a
+// zone's targets from IP addresses to a hostname deletes the A record that was
+// published before publishing the CNAME record, and that removing the per-zone
+// targets republishes the record in each zone with the targets in its spec.
+func Test_publishRecordToZonesZoneTargetsTypeChange(t *testing.T) {
+	privateZone := configv1.DNSZone{ID: "private"}
+	publicZone := configv1.DNSZone{ID: "public"}
+	published := []iov1.DNSZoneCondition{{
+		Type:   iov1.DNSRecordPublishedConditionType,
+		Status: string(operatorv1.ConditionTrue),
+	}}
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    []zoneRecordCall
+	}{
+		{
+			name: "private zone changes from A to CNAME",
+			annotations: map[string]string{
+				dnsrecord.DNSZoneTargetsAnnotation:          `{"private":{"recordType":"CNAME","targets":["private-lb.example.com"]}}`,
+				dnsrecord.DNSZoneTargetsPublishedAnnotation: `{"private":{"recordType":"A","targets":["10.0.0.5"]}}`,
+			},
+			expected: []zoneRecordCall{
+				{"delete", "private", iov1.ARecordType, []string{"10.0.0.5"}},
+				{"replace", "private", iov1.CNAMERecordType, []string{"private-lb.example.com"}},
+				{"replace", "public", iov1.CNAMERecordType, []string{"lb.example.com"}},
+			},
+		},
+		{
+			name: "per-zone targets removed",
+			annotations: map[string]string{
+				dnsrecord.DNSZoneTargetsPublishedAnnotation: `{"private":{"recordType":"A","targets":["10.0.0.5"]}}`,
+			},
+			expected: []zoneRecordCall{
+				{"delete", "private", iov1.ARecordType, []string{"10.0.0.5"}},
+				{"replace", "private", iov1.CNAMERecordType, []string{"lb.example.com"}},
+				{"replace", "public", iov1.CNAMERecordType, []string{"lb.example.com"}},
+			},
+		},
+		{
+			name: "private zone targets changed",
+			annotations: map[string]string{
+				dnsrecord.DNSZoneTargetsAnnotation:          `{"private":{"recordType":"A","targets":["10.0.0.6"]}}`,
+				dnsrecord.DNSZoneTargetsPublishedAnnotation: `{"private":{"recordType":"A","targets":["10.0.0.5"]}}`,
+			},
+			expected: []zoneRecordCall{
+				{"replace", "private", iov1.ARecordType, []string{"10.0.0.6"}},
+				{"replace", "public", iov1.CNAMERecordType, []string{"lb.example.com"}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dnsRecord := &iov1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation:  1,
+					Annotations: tc.annotations,
+				},
+				Spec: iov1.DNSRecordSpec{
+					DNSName:             "*.apps.example.com.",
+					RecordType:          iov1.CNAMERecordType,
+					Targets:             []string{"lb.example.com"},
+					RecordTTL:           30,
+					DNSManagementPolicy: iov1.ManagedDNS,
+				},
+				Status: iov1.DNSRecordStatus{
+					ObservedGeneration: 1,
+					Zones: []iov1.DNSZoneStatus{
+						{DNSZone: privateZone, Conditions: published},
+						{DNSZone: publicZone, Conditions: published},
+					},
+				},
+			}
+			provider := &zoneRecordingProvider{}
+			r := &reconciler{dnsProvider: provider}
+			if requeue, statuses := r.publishRecordToZones([]configv1.DNSZone{privateZone, publicZone}, &privateZone, dnsRecord); requeue {
+				t.Fatalf("expected no requeue, got statuses %+v", statuses)
+			}
+			if !reflect.DeepEqual(provider.calls, tc.expected) {
+				t.Errorf("expected provider calls %+v, got %+v", tc.expected, provider.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package externalresolutionprobe
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	utilclock "k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "external_resolution_probe_controller"
+
+	// probeHostnameLabel is the label that is prepended to an
+	// ingresscontroller's domain to form the name that the probe resolves.
+	// No route uses this name, so only the wildcard record matches it.
+	probeHostnameLabel = "external-resolution-probe"
+)
+
+var (
+	log = logf.Logger.WithName(controllerName)
+
+	// clock is used to determine when to probe and how long all resolvers
+	// have been failing.
+	clock utilclock.Clock = utilclock.RealClock{}
+)
+
+// New creates and returns a controller that, for each ingresscontroller that
+// enables the external resolution probe, periodically resolves a name under
+// the ingresscontroller's wildcard DNS record using the resolvers that the
+// ingresscontroller specifies, and reports the results in metrics and in the
+// ExternalResolutionSucceeding status condition.
+func New(mgr manager.Manager) (controller.Controller, error) {
+	reconciler := &reconciler{
+		client: mgr.GetClient(),
+		lookup: lookupHost,
+		states: map[string]*probeState{},
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &operatorv1.IngressController{}, &handler.EnqueueRequestForObject{})); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// probeState is the state that the controller keeps for an ingresscontroller
+// in between probes.
+type probeState struct {
+	// probe is the most recently performed probe.
+	probe *ingresscontroller.ExternalResolutionProbe
+	// lastProbe is when the probe was last performed.
+	lastProbe time.Time
+	// allFailingSince is when all resolvers started failing, or the zero
+	// time if at least one resolver succeeded in the most recent probe.
+	allFailingSince time.Time
+}
+
+// reconciler handles the actual external resolution probe logic.
+type reconciler struct {
+	client client.Client
+	// lookup resolves a hostname using the resolver with the given
+	// address.
+	lookup lookupFunc
+
+	// states has the probe state for each ingresscontroller, by name.  It
+	// is only accessed from Reconcile, which the controller never runs
+	// concurrently.
+	states map[string]*probeState
+}
+
+// Reconcile expects request to refer to an ingresscontroller.  If the
+// ingresscontroller enables the external resolution probe and the probe's
+// interval has elapsed since the last probe, Reconcile queries each resolver,
+// updates the metrics, and updates the ExternalResolutionSucceeding condition.
+// Reconcile requeues the request so that the probe is repeated at the
+// interval; events for the ingresscontroller do not cause additional queries.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	ic := &operatorv1.IngressController{}
+	if err := r.client.Get(ctx, request.NamespacedName, ic); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.forget(request.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get ingresscontroller %q: %w", request.NamespacedName, err)
+	}
+
+	probe, err := ingresscontroller.ExternalResolutionProbeForIngressController(ic)
+	if err == nil && probe != nil {
+		err = ingresscontroller.ValidateExternalResolutionProbe(probe)
+	}
+	if err != nil || probe == nil || ic.DeletionTimestamp != nil {
+		// The probe is disabled, or it is invalid, which the ingress
+		// controller reports, so stop probing.
+		r.forget(ic.Name)
+		if err := r.removeStatusCondition(ctx, ic); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	if len(ic.Status.Domain) == 0 {
+		// The ingresscontroller will be reconciled again once its
+		// domain is set.
+		return reconcile.Result{}, nil
+	}
+
+	state, ok := r.states[ic.Name]
+	if !ok || !reflect.DeepEqual(state.probe, probe) {
+		// Start over if the probe has changed.
+		deleteMetrics(ic.Name)
+		state = &probeState{probe: probe}
+		r.states[ic.Name] = state
+	}
+
+	interval := probe.IntervalDuration()
+	if !state.lastProbe.IsZero() {
+		if remaining := state.lastProbe.Add(interval).Sub(clock.Now()); remaining > 0 {
+			return reconcile.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	hostname := probeHostnameLabel + "." + strings.TrimSuffix(ic.Status.Domain, ".")
+	results := r.probe(ctx, ic.Name, hostname, probe.ResolverAddresses())
+	state.lastProbe = clock.Now()
+
+	if cond := state.update(hostname, results, probe.FailureThresholdDuration(), clock.Now()); cond != nil {
+		if err := r.setStatusCondition(ctx, ic, *cond); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: interval}, nil
+}
+
+// probe resolves the given hostname using each of the resolvers with the
+// given addresses in turn, records the result for each resolver in the
+// metrics, and returns the results.
+func (r *reconciler) probe(ctx context.Context, name, hostname string, addresses []string) []resolverResult {
+	results := make([]resolverResult, 0, len(addresses))
+	for _, address := range addresses {
+		start := clock.Now()
+		err := r.lookup(ctx, address, hostname)
+		result := resolverResult{
+			resolver: address,
+			outcome:  outcomeForError(err),
+			err:      err,
+		}
+		observeResult(name, result, clock.Since(start))
+		if err != nil {
+			log.Info("external resolution probe failed", "ingresscontroller", name, "resolver", address, "hostname", hostname, "outcome", result.outcome, "error", err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// update updates the state with the results of a probe of the given hostname
+// and returns the ExternalResolutionSucceeding condition that the results
+// indicate, or nil if the condition should not change.  The condition is false
+// only once all resolvers have failed for at least the given threshold, and it
+// is unknown if no resolver can be reached at all, which is expected in a
+// disconnected environment and does not indicate a problem with the record.
+func (s *probeState) update(hostname string, results []resolverResult, threshold time.Duration, now time.Time) *operatorv1.OperatorCondition {
+	var succeeded int
+	var failures []string
+	allUnreachable := true
+	for _, result := range results {
+		if result.err == nil {
+			succeeded++
+			allUnreachable = false
+			continue
+		}
+		if result.outcome != outcomeUnreachable {
+			allUnreachable = false
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s: %v", result.resolver, result.outcome, result.err))
+	}
+
+	cond := &operatorv1.OperatorCondition{
+		Type: ingresscontroller.IngressControllerExternalResolutionSucceedingConditionType,
+	}
+	switch {
+	case succeeded != 0:
+		s.allFailingSince = time.Time{}
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = "ResolutionSucceeding"
+		cond.Message = fmt.Sprintf("%s resolves using %d of %d external resolvers.", hostname, succeeded, len(results))
+		if len(failures) != 0 {
+			cond.Message += fmt.Sprintf(" Failing resolvers:\n%s", strings.Join(failures, "\n"))
+		}
+	case allUnreachable:
+		s.allFailingSince = time.Time{}
+		cond.Status = operatorv1.ConditionUnknown
+		cond.Reason = "ResolversUnreachable"
+		cond.Message = fmt.Sprintf("None of the external resolvers could be reached to resolve %s, which is expected if the cluster cannot reach them, as in a disconnected environment:\n%s", hostname, strings.Join(failures, "\n"))
+	default:
+		if s.allFailingSince.IsZero() {
+			s.allFailingSince = now
+		}
+		failingFor := now.Sub(s.allFailingSince)
+		if failingFor < threshold {
+			return nil
+		}
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = "ResolutionFailing"
+		cond.Message = fmt.Sprintf("%s has failed to resolve using every external resolver for %v:\n%s", hostname, failingFor.Round(time.Second), strings.Join(failures, "\n"))
+	}
+	return cond
+}
+
+// forget discards the probe state and metrics for the ingresscontroller with
+// the given name.
+func (r *reconciler) forget(name string) {
+	if _, ok := r.states[name]; ok {
+		delete(r.states, name)
+		deleteMetrics(name)
+	}
+}
+
+// setStatusCondition applies the given condition to the given
+// ingresscontroller.  The condition does not overlap with any of the status
+// conditions that the ingress controller sets in
+// pkg/operator/controller/ingress/status.go.
+func (r *reconciler) setStatusCondition(ctx context.Context, ic *operatorv1.IngressController, cond operatorv1.OperatorCondition) error {
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = ingresscontroller.MergeConditions(updated.Status.Conditions, cond)
+	if ingresscontroller.IngressStatusesEqual(updated.Status, ic.Status) {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %w", ic.Name, err)
+	}
+	return nil
+}
+
+// removeStatusCondition removes the ExternalResolutionSucceeding condition
+// from the given ingresscontroller, if the condition is present.
+func (r *reconciler) removeStatusCondition(ctx context.Context, ic *operatorv1.IngressController) error {
+	updated := ic.DeepCopy()
+	updated.Status.Conditions = nil
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type != ingresscontroller.IngressControllerExternalResolutionSucceedingConditionType {
+			updated.Status.Conditions = append(updated.Status.Conditions, cond)
+		}
+	}
+	if len(updated.Status.Conditions) == len(ic.Status.Conditions) {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update ingresscontroller %s status: %w", ic.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package externalresolutionprobe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// rcodeNoError, rcodeServFail, and rcodeNXDomain are the DNS response
+	// codes that the stub DNS server uses.
+	rcodeNoError  = 0
+	rcodeServFail = 2
+	rcodeNXDomain = 3
+
+	// stubNoResponse makes the stub DNS server drop every query.
+	stubNoResponse = -1
+)
+
+// startStubDNSServer starts a DNS server on the loopback interface that
+// answers every query with the given response code, and returns its address.
+// A successful response to a query for an A record has a single address.  If
+// rcode is stubNoResponse, the server never responds.
+func startStubDNSServer(t *testing.T, rcode int) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if rcode == stubNoResponse {
+				continue
+			}
+			if response := stubDNSResponse(buf[:n], byte(rcode)); response != nil {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// stubDNSResponse returns a response with the given response code to the given
+// query, or nil if the query cannot be parsed.
+func stubDNSResponse(query []byte, rcode byte) []byte {
+	const headerLen = 12
+	if len(query) < headerLen || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		return nil
+	}
+	// Find the end of the question's name, which is followed by the
+	// question's type and class.
+	end := headerLen
+	for end < len(query) && query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	if end > len(query) {
+		return nil
+	}
+	question := query[headerLen:end]
+	qtype := binary.BigEndian.Uint16(question[len(question)-4:])
+
+	var answers uint16
+	if rcode == rcodeNoError && qtype == 1 {
+		answers = 1
+	}
+	response := make([]byte, headerLen, headerLen+len(question)+16)
+	copy(response[0:2], query[0:2])
+	// Set QR and copy RD from the query, and set RA and the response code.
+	response[2] = 0x80 | query[2]&0x01
+	response[3] = 0x80 | rcode
+	binary.BigEndian.PutUint16(response[4:6], 1)
+	binary.BigEndian.PutUint16(response[6:8], answers)
+	response = append(response, question...)
+	if answers != 0 {
+		response = append(response,
+			0xc0, headerLen, // A pointer to the question's name.
+			0x00, 0x01, // Type A.
+			0x00, 0x01, // Class IN.
+			0x00, 0x00, 0x00, 0x3c, // A TTL of 60 seconds.
+			0x00, 0x04, // The length of the address.
+			192, 0, 2, 1,
+		)
+	}
+	return response
+}
+
+// Test_lookupHost verifies that lookupHost queries only the given resolver and
+// that outcomeForError classifies the stub DNS server's responses correctly.
+func Test_lookupHost(t *testing.T) {
+	defer func(timeout time.Duration) { queryTimeout = timeout }(queryTimeout)
+	queryTimeout = 500 * time.Millisecond
+
+	testCases := []struct {
+		name            string
+		rcode           int
+		expectedOutcome string
+	}{
+		{"success", rcodeNoError, outcomeSuccess},
+		{"NXDOMAIN", rcodeNXDomain, outcomeNotFound},
+		{"SERVFAIL", rcodeServFail, outcomeServerFailure},
+		{"no response", stubNoResponse, outcomeUnreachable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address := startStubDNSServer(t, tc.rcode)
+			err := lookupHost(context.Background(), address, "external-resolution-probe.apps.example.com")
+			if outcome := outcomeForError(err); outcome != tc.expectedOutcome {
+				t.Errorf("expected outcome %q, got %q (error: %v)", tc.expectedOutcome, outcome, err)
+			}
+		})
+	}
+}
+
+// Test_Reconcile verifies that the controller probes at most once per interval,
+// sets the ExternalResolutionSucceeding condition to false only after all
+// resolvers have failed for the failure threshold, reports unreachable
+// resolvers as unknown, and removes the condition when the probe is disabled.
+func Test_Reconcile(t *testing.T) {
+	defer func(timeout time.Duration) { queryTimeout = timeout }(queryTimeout)
+	queryTimeout = 500 * time.Millisecond
+
+	fakeClock := utilclocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	clock = fakeClock
+	defer func() {
+		clock = utilclock.RealClock{}
+	}()
+
+	success := startStubDNSServer(t, rcodeNoError)
+	nxdomain := startStubDNSServer(t, rcodeNXDomain)
+	servfail := startStubDNSServer(t, rcodeServFail)
+	unresponsive := startStubDNSServer(t, stubNoResponse)
+
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default"}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.example.com",
+		},
+	}
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ic).WithStatusSubresource(ic).Build()
+	r := &reconciler{
+		client: cl,
+		lookup: lookupHost,
+		states: map[string]*probeState{},
+	}
+
+	setResolvers := func(resolvers ...string) {
+		t.Helper()
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), name, current); err != nil {
+			t.Fatalf("failed to get ingresscontroller: %v", err)
+		}
+		current.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+		if len(resolvers) != 0 {
+			quoted := fmt.Sprintf("%q", resolvers[0])
+			for _, resolver := range resolvers[1:] {
+				quoted += fmt.Sprintf(",%q", resolver)
+			}
+			current.Spec.UnsupportedConfigOverrides.Raw = []byte(fmt.Sprintf(`{"externalResolutionProbe":{"resolvers":[%s],"interval":"1m","failureThreshold":"5m"}}`, quoted))
+		}
+		if err := cl.Update(context.Background(), current); err != nil {
+			t.Fatalf("failed to update ingresscontroller: %v", err)
+		}
+	}
+	reconcileOnce := func() reconcile.Result {
+		t.Helper()
+		result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: name})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+	condition := func() *operatorv1.OperatorCondition {
+		t.Helper()
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), name, current); err != nil {
+			t.Fatalf("failed to get ingresscontroller: %v", err)
+		}
+		for i := range current.Status.Conditions {
+			if current.Status.Conditions[i].Type == ingresscontroller.IngressControllerExternalResolutionSucceedingConditionType {
+				return &current.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+	expectCondition := func(status operatorv1.ConditionStatus, reason string) {
+		t.Helper()
+		cond := condition()
+		switch {
+		case cond == nil:
+			t.Fatalf("expected condition with status %q and reason %q, found no condition", status, reason)
+		case cond.Status != status || cond.Reason != reason:
+			t.Fatalf("expected condition with status %q and reason %q, got %q and %q: %s", status, reason, cond.Status, cond.Reason, cond.Message)
+		}
+	}
+	queries := func(resolver, outcome string) float64 {
+		return testutil.ToFloat64(externalResolutionProbeResults.WithLabelValues(name.Name, resolver, outcome))
+	}
+
+	// The probe is off by default.
+	if result := reconcileOnce(); result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue with the probe disabled, got %v", result.RequeueAfter)
+	}
+	if cond := condition(); cond != nil {
+		t.Fatalf("expected no condition with the probe disabled, got %+v", cond)
+	}
+
+	// Failures within the threshold do not set the condition.
+	setResolvers(nxdomain, servfail)
+	if result := reconcileOnce(); result.RequeueAfter != time.Minute {
+		t.Fatalf("expected requeue after 1m, got %v", result.RequeueAfter)
+	}
+	if cond := condition(); cond != nil {
+		t.Fatalf("expected no condition before the failure threshold, got %+v", cond)
+	}
+	if v := queries(nxdomain, outcomeNotFound); v != 1 {
+		t.Fatalf("expected 1 NotFound result for %s, got %v", nxdomain, v)
+	}
+	if v := queries(servfail, outcomeServerFailure); v != 1 {
+		t.Fatalf("expected 1 ServerFailure result for %s, got %v", servfail, v)
+	}
+	if v := testutil.ToFloat64(externalResolutionProbeSucceeding.WithLabelValues(name.Name, nxdomain)); v != 0 {
+		t.Fatalf("expected the succeeding metric for %s to be 0, got %v", nxdomain, v)
+	}
+
+	// Reconciling again before the interval elapses does not query the
+	// resolvers again.
+	fakeClock.Step(20 * time.Second)
+	if result := reconcileOnce(); result.RequeueAfter != 40*time.Second {
+		t.Fatalf("expected requeue after 40s, got %v", result.RequeueAfter)
+	}
+	if v := queries(nxdomain, outcomeNotFound); v != 1 {
+		t.Fatalf("expected the resolvers not to be queried before the interval elapsed, got %v NotFound results", v)
+	}
+
+	// Failures for the threshold set the condition to false.
+	fakeClock.Step(5 * time.Minute)
+	reconcileOnce()
+	expectCondition(operatorv1.ConditionFalse, "ResolutionFailing")
+
+	// A single succeeding resolver sets the condition to true.
+	setResolvers(nxdomain, success)
+	fakeClock.Step(time.Minute)
+	reconcileOnce()
+	expectCondition(operatorv1.ConditionTrue, "ResolutionSucceeding")
+	if v := testutil.ToFloat64(externalResolutionProbeSucceeding.WithLabelValues(name.Name, success)); v != 1 {
+		t.Fatalf("expected the succeeding metric for %s to be 1, got %v", success, v)
+	}
+
+	// Unreachable resolvers, as in a disconnected environment, set the
+	// condition to unknown.
+	setResolvers(unresponsive)
+	fakeClock.Step(time.Minute)
+	reconcileOnce()
+	expectCondition(operatorv1.ConditionUnknown, "ResolversUnreachable")
+
+	// Disabling the probe removes the condition and the metrics.
+	setResolvers()
+	reconcileOnce()
+	if cond := condition(); cond != nil {
+		t.Fatalf("expected the condition to be removed, got %+v", cond)
+	}
+	if n := testutil.CollectAndCount(externalResolutionProbeResults); n != 0 {
+		t.Fatalf("expected the metrics to be deleted, found %d", n)
+	}
+}
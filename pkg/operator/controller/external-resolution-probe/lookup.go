@@ -0,0 +1,81 @@
+package externalresolutionprobe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	// outcomeSuccess means that the resolver returned at least one address.
+	outcomeSuccess = "Success"
+	// outcomeNotFound means that the resolver reported that the name does
+	// not exist (NXDOMAIN) or has no addresses.
+	outcomeNotFound = "NotFound"
+	// outcomeServerFailure means that the resolver failed to resolve the
+	// name, as with a SERVFAIL or REFUSED response.
+	outcomeServerFailure = "ServerFailure"
+	// outcomeUnreachable means that the resolver did not respond, as when
+	// the network does not allow traffic to the resolver.
+	outcomeUnreachable = "Unreachable"
+)
+
+// queryTimeout is how long to wait for a resolver to answer.
+var queryTimeout = 5 * time.Second
+
+// lookupFunc resolves the given hostname using the resolver with the given
+// address and returns an error if the hostname does not resolve to at least
+// one address.
+type lookupFunc func(ctx context.Context, address, hostname string) error
+
+// resolverResult is the result of resolving the probe's hostname using one
+// resolver.
+type resolverResult struct {
+	// resolver is the address of the resolver.
+	resolver string
+	// outcome is one of outcomeSuccess, outcomeNotFound,
+	// outcomeServerFailure, or outcomeUnreachable.
+	outcome string
+	// err is the error from the lookup, or nil if it succeeded.
+	err error
+}
+
+// lookupHost resolves the given hostname using only the resolver with the
+// given address.  The hostname is resolved as a fully qualified name so that
+// the search domains of the operator's pod are not queried.
+func lookupHost(ctx context.Context, address, hostname string) error {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	_, err := resolver.LookupHost(ctx, hostname+".")
+	return err
+}
+
+// outcomeForError returns the outcome that the given lookup error indicates.
+func outcomeForError(err error) string {
+	if err == nil {
+		return outcomeSuccess
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return outcomeUnreachable
+	}
+	switch {
+	case dnsErr.IsNotFound:
+		return outcomeNotFound
+	case dnsErr.IsTimeout:
+		return outcomeUnreachable
+	case dnsErr.Err == "server misbehaving":
+		return outcomeServerFailure
+	}
+	// Other errors, such as a refused connection, come from the network
+	// rather than from the resolver.
+	return outcomeUnreachable
+}
@@ -0,0 +1,69 @@
+package externalresolutionprobe
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// externalResolutionProbeSucceeding reports whether the most recent
+	// query of each resolver succeeded.
+	externalResolutionProbeSucceeding = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_external_resolution_probe_succeeding",
+		Help: "A gauge set to 0 or 1 to signify whether or not the IngressController's wildcard DNS record resolved using the external resolver in the most recent probe",
+	}, []string{"name", "resolver"})
+
+	// externalResolutionProbeDuration reports how long the most recent
+	// query of each resolver took.
+	externalResolutionProbeDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_external_resolution_probe_duration_seconds",
+		Help: "How long the most recent query of the external resolver for the IngressController's wildcard DNS record took, in seconds",
+	}, []string{"name", "resolver"})
+
+	// externalResolutionProbeResults counts the queries of each resolver by
+	// outcome.
+	externalResolutionProbeResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ingress_external_resolution_probe_results_total",
+		Help: "Counts queries of external resolvers for the IngressController's wildcard DNS record by outcome: Success, NotFound, ServerFailure, or Unreachable",
+	}, []string{"name", "resolver", "outcome"})
+
+	// metricsList is a list of metrics for this package.
+	metricsList = []prometheus.Collector{
+		externalResolutionProbeSucceeding,
+		externalResolutionProbeDuration,
+		externalResolutionProbeResults,
+	}
+)
+
+// observeResult records the given result of a query that took the given
+// duration for the ingresscontroller with the given name.
+func observeResult(name string, result resolverResult, duration time.Duration) {
+	succeeding := 0.0
+	if result.err == nil {
+		succeeding = 1
+	}
+	externalResolutionProbeSucceeding.WithLabelValues(name, result.resolver).Set(succeeding)
+	externalResolutionProbeDuration.WithLabelValues(name, result.resolver).Set(duration.Seconds())
+	externalResolutionProbeResults.WithLabelValues(name, result.resolver, result.outcome).Inc()
+}
+
+// deleteMetrics deletes the metrics for the ingresscontroller with the given
+// name.
+func deleteMetrics(name string) {
+	labels := prometheus.Labels{"name": name}
+	externalResolutionProbeSucceeding.DeletePartialMatch(labels)
+	externalResolutionProbeDuration.DeletePartialMatch(labels)
+	externalResolutionProbeResults.DeletePartialMatch(labels)
+}
+
+// RegisterMetrics calls prometheus.Register on each metric in metricsList, and
+// returns on errors.
+func RegisterMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
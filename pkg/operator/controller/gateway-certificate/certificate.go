@@ -0,0 +1,274 @@
+package gatewaycertificate
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// gatewayCertificateLifetimeInDays is the validity period of gateway
+	// certificates.  Zero selects library-go's default lifetime, which is
+	// the lifetime of the default certificates that the operator generates
+	// for ingresscontrollers.
+	gatewayCertificateLifetimeInDays = 0
+	// gatewayCertificateRefreshPeriod is how long before a gateway
+	// certificate expires that the operator replaces it.
+	gatewayCertificateRefreshPeriod = 90 * 24 * time.Hour
+)
+
+// managedListenerHostnames returns the hostnames of the given gateway's
+// listeners whose certificate the operator manages.  The hostnames have no
+// trailing dot.
+func managedListenerHostnames(gateway *gatewayapiv1beta1.Gateway, baseDomain, secretName string) sets.Set[string] {
+	hostnames := sets.New[string]()
+	for i := range gateway.Spec.Listeners {
+		if listenerUsesDefaultCertificate(&gateway.Spec.Listeners[i], gateway.Namespace, baseDomain, secretName) {
+			hostnames.Insert(listenerHostname(&gateway.Spec.Listeners[i]))
+		}
+	}
+	return hostnames
+}
+
+// listenerHostname returns the given listener's hostname without a trailing
+// dot.
+func listenerHostname(listener *gatewayapiv1beta1.Listener) string {
+	if listener.Hostname == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(string(*listener.Hostname), "."))
+}
+
+// listenerUsesDefaultCertificate returns a Boolean value indicating whether the
+// operator manages the certificate of the given listener: the listener must be
+// an HTTPS listener that terminates TLS, its hostname must be under the given
+// base domain, and it must either specify no certificate or specify only the
+// secret with the given name, which the operator previously set.  A listener
+// without a hostname matches any hostname, so no certificate can cover it.
+func listenerUsesDefaultCertificate(listener *gatewayapiv1beta1.Listener, namespace, baseDomain, secretName string) bool {
+	if listener.Protocol != gatewayapiv1beta1.HTTPSProtocolType || len(baseDomain) == 0 {
+		return false
+	}
+	hostname := listenerHostname(listener)
+	if !strings.HasSuffix(hostname, "."+strings.ToLower(strings.TrimSuffix(baseDomain, "."))) {
+		return false
+	}
+	if listener.TLS == nil {
+		return true
+	}
+	if listener.TLS.Mode != nil && *listener.TLS.Mode != gatewayapiv1beta1.TLSModeTerminate {
+		return false
+	}
+	switch len(listener.TLS.CertificateRefs) {
+	case 0:
+		return true
+	case 1:
+		return isDefaultCertificateRef(listener.TLS.CertificateRefs[0], namespace, secretName)
+	}
+	return false
+}
+
+// isDefaultCertificateRef returns a Boolean value indicating whether the given
+// certificate reference refers to the secret with the given name in the given
+// namespace.
+func isDefaultCertificateRef(ref gatewayapiv1beta1.SecretObjectReference, namespace, secretName string) bool {
+	if ref.Group != nil && *ref.Group != "" {
+		return false
+	}
+	if ref.Kind != nil && *ref.Kind != "Secret" {
+		return false
+	}
+	if ref.Namespace != nil && string(*ref.Namespace) != namespace {
+		return false
+	}
+	return string(ref.Name) == secretName
+}
+
+// ensureGatewayCertificate ensures that the named secret exists, is owned by
+// the given gateway, and has a certificate that is signed by the given CA,
+// covers exactly the given hostnames, and is not close to expiring.  Returns
+// the time at which the certificate will need to be replaced.
+func (r *reconciler) ensureGatewayCertificate(ctx context.Context, ca *crypto.CA, gateway *gatewayapiv1beta1.Gateway, name types.NamespacedName, hostnames sets.Set[string]) (time.Time, error) {
+	current := &corev1.Secret{}
+	haveSecret := true
+	if err := r.client.Get(ctx, name, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return time.Time{}, fmt.Errorf("failed to get gateway certificate secret %s: %w", name, err)
+		}
+		haveSecret = false
+	}
+	if haveSecret && !metav1.IsControlledBy(current, gateway) {
+		return time.Time{}, fmt.Errorf("secret %s exists and is not owned by gateway %s/%s", name, gateway.Namespace, gateway.Name)
+	}
+	if haveSecret {
+		if notAfter, ok := gatewayCertificateValid(current, ca, hostnames, time.Now()); ok {
+			return notAfter.Add(-gatewayCertificateRefreshPeriod), nil
+		}
+	}
+
+	desired, notAfter, err := desiredGatewayCertificateSecret(ca, gateway, name, hostnames)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !haveSecret {
+		if err := r.client.Create(ctx, desired); err != nil {
+			return time.Time{}, fmt.Errorf("failed to create gateway certificate secret %s: %w", name, err)
+		}
+		r.recorder.Eventf(gateway, "Normal", "CreatedGatewayCertificate", "Created certificate %q for hostnames %v", name.Name, sets.List(hostnames))
+		return notAfter.Add(-gatewayCertificateRefreshPeriod), nil
+	}
+	updated := current.DeepCopy()
+	updated.Type = desired.Type
+	updated.Data = desired.Data
+	if err := r.client.Update(ctx, updated); err != nil {
+		return time.Time{}, fmt.Errorf("failed to update gateway certificate secret %s: %w", name, err)
+	}
+	r.recorder.Eventf(gateway, "Normal", "UpdatedGatewayCertificate", "Replaced certificate %q for hostnames %v", name.Name, sets.List(hostnames))
+	return notAfter.Add(-gatewayCertificateRefreshPeriod), nil
+}
+
+// desiredGatewayCertificateSecret returns the desired certificate secret for
+// the given gateway, with a new certificate for the given hostnames signed by
+// the given CA, and the certificate's expiration time.
+func desiredGatewayCertificateSecret(ca *crypto.CA, gateway *gatewayapiv1beta1.Gateway, name types.NamespacedName, hostnames sets.Set[string]) (*corev1.Secret, time.Time, error) {
+	cert, err := ca.MakeServerCert(hostnames, gatewayCertificateLifetimeInDays)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to make gateway certificate: %w", err)
+	}
+	certBytes, keyBytes, err := cert.GetPEMBytes()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to encode gateway certificate: %w", err)
+	}
+	trueVar := true
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: gatewayapiv1beta1.GroupVersion.String(),
+				Kind:       "Gateway",
+				Name:       gateway.Name,
+				UID:        gateway.UID,
+				Controller: &trueVar,
+			}},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": certBytes,
+			"tls.key": keyBytes,
+		},
+	}
+	return secret, cert.Certs[0].NotAfter, nil
+}
+
+// gatewayCertificateValid returns the expiration time of the certificate in
+// the given secret and a Boolean value indicating whether the certificate has a
+// key, is signed by the given CA, covers exactly the given hostnames, and
+// remains valid for at least gatewayCertificateRefreshPeriod after now.  The
+// hostnames may be wildcards, which x509 verification does not accept as
+// names to verify, so the certificate's DNS names are compared instead.
+func gatewayCertificateValid(secret *corev1.Secret, ca *crypto.CA, hostnames sets.Set[string], now time.Time) (time.Time, bool) {
+	if len(secret.Data["tls.key"]) == 0 {
+		return time.Time{}, false
+	}
+	block, _ := pem.Decode(secret.Data["tls.crt"])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	roots := x509.NewCertPool()
+	for _, caCert := range ca.Config.Certs {
+		roots.AddCert(caCert)
+	}
+	opts := x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return cert.NotAfter, false
+	}
+	if !sets.New(cert.DNSNames...).Equal(hostnames) {
+		return cert.NotAfter, false
+	}
+	return cert.NotAfter, now.Add(gatewayCertificateRefreshPeriod).Before(cert.NotAfter)
+}
+
+// defaultListenerCertificates sets the named secret as the certificate of each
+// of the given gateway's listeners whose certificate the operator manages and
+// that does not specify a certificate.
+func (r *reconciler) defaultListenerCertificates(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, baseDomain, secretName string) error {
+	updated := gateway.DeepCopy()
+	var defaulted []string
+	for i := range updated.Spec.Listeners {
+		listener := &updated.Spec.Listeners[i]
+		if !listenerUsesDefaultCertificate(listener, gateway.Namespace, baseDomain, secretName) {
+			continue
+		}
+		if listener.TLS != nil && len(listener.TLS.CertificateRefs) != 0 {
+			continue
+		}
+		if listener.TLS == nil {
+			listener.TLS = &gatewayapiv1beta1.GatewayTLSConfig{}
+		}
+		if listener.TLS.Mode == nil {
+			mode := gatewayapiv1beta1.TLSModeTerminate
+			listener.TLS.Mode = &mode
+		}
+		group := gatewayapiv1beta1.Group("")
+		kind := gatewayapiv1beta1.Kind("Secret")
+		listener.TLS.CertificateRefs = []gatewayapiv1beta1.SecretObjectReference{{
+			Group: &group,
+			Kind:  &kind,
+			Name:  gatewayapiv1beta1.ObjectName(secretName),
+		}}
+		defaulted = append(defaulted, string(listener.Name))
+	}
+	if len(defaulted) == 0 {
+		return nil
+	}
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to set the default certificate on listeners of gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	r.recorder.Eventf(gateway, "Normal", "DefaultedListenerCertificate", "Set certificate %q on listeners %v", secretName, defaulted)
+	log.Info("set default certificate on gateway listeners", "namespace", gateway.Namespace, "name", gateway.Name, "secret", secretName, "listeners", defaulted)
+	return nil
+}
+
+// deleteGatewayCertificate deletes the named certificate secret if it exists
+// and is owned by the given gateway, which happens once none of the gateway's
+// listeners use it.
+func (r *reconciler) deleteGatewayCertificate(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, name types.NamespacedName) error {
+	current := &corev1.Secret{}
+	if err := r.client.Get(ctx, name, current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get gateway certificate secret %s: %w", name, err)
+	}
+	if !metav1.IsControlledBy(current, gateway) {
+		return nil
+	}
+	if err := r.client.Delete(ctx, current); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete gateway certificate secret %s: %w", name, err)
+	}
+	r.recorder.Eventf(gateway, "Normal", "DeletedGatewayCertificate", "Deleted certificate %q, which no listener uses", name.Name)
+	return nil
+}
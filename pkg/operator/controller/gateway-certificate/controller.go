@@ -0,0 +1,176 @@
+package gatewaycertificate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "gateway_certificate_controller"
+
+	// caNotFoundRetryPeriod is how long the controller waits before
+	// retrying if the router CA, which the certificate controller creates,
+	// does not exist yet.
+	caNotFoundRetryPeriod = 10 * time.Second
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// NewUnmanaged creates and returns a controller that provisions certificates,
+// signed by the router CA, for the HTTPS listeners of gateways that use the
+// default gatewayclass and that do not specify a certificate.  This is an
+// unmanaged controller, which means that the manager does not start it.
+func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, error) {
+	operatorCache := mgr.GetCache()
+	reconciler := &reconciler{
+		config:   config,
+		client:   mgr.GetClient(),
+		cache:    operatorCache,
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+	c, err := controller.NewUnmanaged(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	scheme := mgr.GetClient().Scheme()
+	mapper := mgr.GetClient().RESTMapper()
+	isInOperandNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == config.OperandNamespace
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &gatewayapiv1beta1.Gateway{}, &handler.EnqueueRequestForObject{}, isInOperandNamespace, predicate.GenerationChangedPredicate{})); err != nil {
+		return nil, err
+	}
+	// Watch the certificate secrets so that they are recreated or repaired
+	// if they are deleted or modified.
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, handler.EnqueueRequestForOwner(scheme, mapper, &gatewayapiv1beta1.Gateway{}, handler.OnlyControllerOwner()), isInOperandNamespace)); err != nil {
+		return nil, err
+	}
+	// Watch the router CA so that the certificates are replaced if the CA
+	// is replaced.
+	isRouterCA := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		name := operatorcontroller.RouterCASecretName(config.OperatorNamespace)
+		return o.GetNamespace() == name.Namespace && o.GetName() == name.Name
+	})
+	toAllGateways := func(ctx context.Context, o client.Object) []reconcile.Request {
+		var gateways gatewayapiv1beta1.GatewayList
+		if err := reconciler.cache.List(ctx, &gateways, client.InNamespace(config.OperandNamespace)); err != nil {
+			log.Error(err, "failed to list gateways for router CA", "namespace", config.OperandNamespace)
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range gateways.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: gateways.Items[i].Namespace,
+				Name:      gateways.Items[i].Name,
+			}})
+		}
+		return requests
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(toAllGateways), isRouterCA)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config holds all the configuration that must be provided when creating the
+// controller.
+type Config struct {
+	// OperatorNamespace is the namespace of the router CA.
+	OperatorNamespace string
+	// OperandNamespace is the namespace in which to watch for gateways and
+	// in which to create certificate secrets.
+	OperandNamespace string
+}
+
+// reconciler handles the actual gateway certificate reconciliation logic.
+type reconciler struct {
+	config Config
+
+	client   client.Client
+	cache    cache.Cache
+	recorder record.EventRecorder
+}
+
+// Reconcile expects request to refer to a gateway.  If the gateway uses the
+// default gatewayclass and has HTTPS listeners with hostnames under the
+// cluster's base domain that do not specify a certificate, Reconcile ensures
+// that a certificate for those hostnames exists and sets it as the listeners'
+// certificate.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	var gateway gatewayapiv1beta1.Gateway
+	if err := r.cache.Get(ctx, request.NamespacedName, &gateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The certificate secret is owned by the gateway, so
+			// the garbage collector deletes it.
+			log.Info("gateway not found; reconciliation will be skipped", "request", request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if gateway.Spec.GatewayClassName != gatewayclass.OpenShiftDefaultGatewayClassName || gateway.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	dnsConfig := &configv1.DNS{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: "cluster"}, dnsConfig); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get dns 'cluster': %w", err)
+	}
+
+	secretName := operatorcontroller.GatewayCertificateSecretName(&gateway)
+	hostnames := managedListenerHostnames(&gateway, dnsConfig.Spec.BaseDomain, secretName.Name)
+	if hostnames.Len() == 0 {
+		return reconcile.Result{}, r.deleteGatewayCertificate(ctx, &gateway, secretName)
+	}
+
+	caSecret := &corev1.Secret{}
+	caSecretName := operatorcontroller.RouterCASecretName(r.config.OperatorNamespace)
+	if err := r.client.Get(ctx, caSecretName, caSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("router CA not found; will retry", "secret", caSecretName)
+			return reconcile.Result{RequeueAfter: caNotFoundRetryPeriod}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get router CA secret %s: %w", caSecretName, err)
+	}
+	ca, err := crypto.GetCAFromBytes(caSecret.Data["tls.crt"], caSecret.Data["tls.key"])
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get CA from secret %s: %w", caSecretName, err)
+	}
+
+	refreshAt, err := r.ensureGatewayCertificate(ctx, ca, &gateway, secretName, hostnames)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.defaultListenerCertificates(ctx, &gateway, dnsConfig.Spec.BaseDomain, secretName.Name); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: time.Until(refreshAt)}, nil
+}
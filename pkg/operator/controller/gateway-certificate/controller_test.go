@@ -0,0 +1,220 @@
+package gatewaycertificate
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Test_listenerUsesDefaultCertificate verifies that the operator manages the
+// certificate only of HTTPS listeners that terminate TLS, have a hostname
+// under the base domain, and specify no certificate or the operator's.
+func Test_listenerUsesDefaultCertificate(t *testing.T) {
+	listener := func(protocol gatewayapiv1beta1.ProtocolType, hostname string, tls *gatewayapiv1beta1.GatewayTLSConfig) *gatewayapiv1beta1.Listener {
+		l := &gatewayapiv1beta1.Listener{Name: "test", Protocol: protocol, Port: 443, TLS: tls}
+		if len(hostname) != 0 {
+			h := gatewayapiv1beta1.Hostname(hostname)
+			l.Hostname = &h
+		}
+		return l
+	}
+	tlsConfig := func(mode gatewayapiv1beta1.TLSModeType, names ...string) *gatewayapiv1beta1.GatewayTLSConfig {
+		config := &gatewayapiv1beta1.GatewayTLSConfig{Mode: &mode}
+		for _, name := range names {
+			config.CertificateRefs = append(config.CertificateRefs, gatewayapiv1beta1.SecretObjectReference{Name: gatewayapiv1beta1.ObjectName(name)})
+		}
+		return config
+	}
+	otherNamespace := gatewayapiv1beta1.Namespace("other")
+	testCases := []struct {
+		name     string
+		listener *gatewayapiv1beta1.Listener
+		expect   bool
+	}{
+		{"HTTPS without TLS configuration", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", nil), true},
+		{"HTTPS with trailing dot", listener(gatewayapiv1beta1.HTTPSProtocolType, "app.gws.example.com.", nil), true},
+		{"HTTPS without certificate", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModeTerminate)), true},
+		{"HTTPS with the operator's certificate", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModeTerminate, "gw-default-certificate")), true},
+		{"HTTPS with a user certificate", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModeTerminate, "user-certificate")), false},
+		{"HTTPS with additional certificates", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModeTerminate, "gw-default-certificate", "user-certificate")), false},
+		{"HTTPS with passthrough", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModePassthrough)), false},
+		{"HTTPS outside the base domain", listener(gatewayapiv1beta1.HTTPSProtocolType, "*.example.org", nil), false},
+		{"HTTPS without hostname", listener(gatewayapiv1beta1.HTTPSProtocolType, "", nil), false},
+		{"HTTP", listener(gatewayapiv1beta1.HTTPProtocolType, "*.gws.example.com", nil), false},
+		{"TLS", listener(gatewayapiv1beta1.TLSProtocolType, "*.gws.example.com", tlsConfig(gatewayapiv1beta1.TLSModeTerminate)), false},
+		{
+			name: "HTTPS with a same-named secret in another namespace",
+			listener: listener(gatewayapiv1beta1.HTTPSProtocolType, "*.gws.example.com", &gatewayapiv1beta1.GatewayTLSConfig{
+				CertificateRefs: []gatewayapiv1beta1.SecretObjectReference{{Name: "gw-default-certificate", Namespace: &otherNamespace}},
+			}),
+			expect: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := listenerUsesDefaultCertificate(tc.listener, "openshift-ingress", "example.com", "gw-default-certificate"); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_Reconcile verifies that the controller creates a certificate for the
+// HTTPS listeners of a gateway that do not specify a certificate, sets it as
+// those listeners' certificate, replaces it when the listeners' hostnames
+// change, and deletes it when no listener uses it.
+func Test_Reconcile(t *testing.T) {
+	caConfig, err := crypto.MakeSelfSignedCAConfigForDuration("ingress-operator", 2*365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to make CA: %v", err)
+	}
+	caCert, caKey, err := caConfig.GetPEMBytes()
+	if err != nil {
+		t.Fatalf("failed to encode CA: %v", err)
+	}
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "router-ca"},
+		Data:       map[string][]byte{"tls.crt": caCert, "tls.key": caKey},
+	}
+	hostname := func(h string) *gatewayapiv1beta1.Hostname {
+		hostname := gatewayapiv1beta1.Hostname(h)
+		return &hostname
+	}
+	userCertMode := gatewayapiv1beta1.TLSModeTerminate
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "gw", UID: "1"},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			GatewayClassName: "openshift-default",
+			Listeners: []gatewayapiv1beta1.Listener{
+				{Name: "http", Protocol: gatewayapiv1beta1.HTTPProtocolType, Port: 80, Hostname: hostname("*.gws.example.com")},
+				{Name: "https", Protocol: gatewayapiv1beta1.HTTPSProtocolType, Port: 443, Hostname: hostname("*.gws.example.com")},
+				{
+					Name:     "https-user",
+					Protocol: gatewayapiv1beta1.HTTPSProtocolType,
+					Port:     8443,
+					Hostname: hostname("secure.gws.example.com"),
+					TLS: &gatewayapiv1beta1.GatewayTLSConfig{
+						Mode:            &userCertMode,
+						CertificateRefs: []gatewayapiv1beta1.SecretObjectReference{{Name: "user-certificate"}},
+					},
+				},
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	configv1.Install(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(
+		&configv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.DNSSpec{BaseDomain: "example.com"}},
+		caSecret,
+		gateway,
+	).Build()
+	informers := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config:   Config{OperatorNamespace: "openshift-ingress-operator", OperandNamespace: "openshift-ingress"},
+		client:   cl,
+		cache:    fakeCache{Informers: &informers, Reader: cl},
+		recorder: record.NewFakeRecorder(10),
+	}
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	secretName := types.NamespacedName{Namespace: gateway.Namespace, Name: "gw-default-certificate"}
+
+	reconcileGateway := func(description string) {
+		t.Helper()
+		result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: gatewayName})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if result.RequeueAfter <= 0 {
+			t.Errorf("%s: expected the request to be requeued for the certificate's refresh, got %+v", description, result)
+		}
+	}
+	certificateFor := func(description string, expectHostnames ...string) *corev1.Secret {
+		t.Helper()
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.Background(), secretName, secret); err != nil {
+			t.Fatalf("%s: failed to get secret: %v", description, err)
+		}
+		block, _ := pem.Decode(secret.Data["tls.crt"])
+		if block == nil {
+			t.Fatalf("%s: secret has no certificate", description)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("%s: failed to parse certificate: %v", description, err)
+		}
+		if !sets.New(cert.DNSNames...).Equal(sets.New(expectHostnames...)) {
+			t.Errorf("%s: expected certificate for %v, got %v", description, expectHostnames, cert.DNSNames)
+		}
+		if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].UID != gateway.UID {
+			t.Errorf("%s: expected the secret to be owned by the gateway, got %+v", description, secret.OwnerReferences)
+		}
+		return secret
+	}
+
+	reconcileGateway("created")
+	created := certificateFor("created", "*.gws.example.com")
+	if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+		t.Fatalf("failed to get gateway: %v", err)
+	}
+	if tls := gateway.Spec.Listeners[1].TLS; tls == nil || len(tls.CertificateRefs) != 1 || tls.CertificateRefs[0].Name != "gw-default-certificate" {
+		t.Errorf("expected the https listener to use the default certificate, got %+v", tls)
+	}
+	if gateway.Spec.Listeners[0].TLS != nil {
+		t.Errorf("expected the http listener to be unchanged, got %+v", gateway.Spec.Listeners[0].TLS)
+	}
+	if refs := gateway.Spec.Listeners[2].TLS.CertificateRefs; len(refs) != 1 || refs[0].Name != "user-certificate" {
+		t.Errorf("expected the https-user listener to keep its certificate, got %+v", refs)
+	}
+
+	reconcileGateway("unchanged")
+	if unchanged := certificateFor("unchanged", "*.gws.example.com"); unchanged.ResourceVersion != created.ResourceVersion {
+		t.Errorf("expected the certificate not to be replaced")
+	}
+
+	gateway.Spec.Listeners[1].Hostname = hostname("*.other.example.com")
+	if err := cl.Update(context.Background(), gateway); err != nil {
+		t.Fatalf("failed to update gateway: %v", err)
+	}
+	reconcileGateway("hostname changed")
+	certificateFor("hostname changed", "*.other.example.com")
+
+	gateway.Spec.Listeners = gateway.Spec.Listeners[:1]
+	if err := cl.Update(context.Background(), gateway); err != nil {
+		t.Fatalf("failed to update gateway: %v", err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: gatewayName}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cl.Get(context.Background(), secretName, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the certificate to be deleted, got %v", err)
+	}
+}
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
@@ -0,0 +1,97 @@
+package gatewaymonitoring
+
+import (
+	"context"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "gateway_monitoring_controller"
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// NewUnmanaged creates and returns a controller that configures Prometheus to
+// scrape the Envoy metrics of the pods of gateways that use the default
+// gatewayclass.  This is an unmanaged controller, which means that the manager
+// does not start it.
+func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, error) {
+	reconciler := &reconciler{
+		config: config,
+		client: mgr.GetClient(),
+		cache:  mgr.GetCache(),
+	}
+	c, err := controller.NewUnmanaged(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	isInOperandNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == config.OperandNamespace
+	})
+	// The gateway's annotations determine whether the podmonitor keeps
+	// backend labels, and annotations do not change the generation.
+	specOrAnnotationsChanged := predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+	if err := c.Watch(source.Kind[client.Object](reconciler.cache, &gatewayapiv1beta1.Gateway{}, &handler.EnqueueRequestForObject{}, isInOperandNamespace, specOrAnnotationsChanged)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config holds all the configuration that must be provided when creating the
+// controller.
+type Config struct {
+	// OperandNamespace is the namespace in which to watch for gateways and
+	// in which to create podmonitors.
+	OperandNamespace string
+}
+
+// reconciler handles the actual gateway monitoring reconciliation logic.
+type reconciler struct {
+	config Config
+
+	client client.Client
+	cache  cache.Cache
+}
+
+// Reconcile expects request to refer to a gateway.  If the gateway uses the
+// default gatewayclass, Reconcile ensures that a podmonitor exists for the
+// gateway's pods.  Otherwise, Reconcile deletes the podmonitor if it exists.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	var gateway gatewayapiv1beta1.Gateway
+	if err := r.cache.Get(ctx, request.NamespacedName, &gateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The podmonitor is owned by the gateway, so the
+			// garbage collector deletes it.
+			log.Info("gateway not found; reconciliation will be skipped", "request", request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if gateway.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	if gateway.Spec.GatewayClassName != gatewayclass.OpenShiftDefaultGatewayClassName {
+		return reconcile.Result{}, r.deletePodMonitor(ctx, &gateway)
+	}
+	if _, _, err := r.ensurePodMonitor(ctx, &gateway); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,132 @@
+package gatewaymonitoring
+
+import (
+	"context"
+	"testing"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Test_Reconcile verifies that the controller creates a podmonitor that
+// selects the pods of a gateway that uses the default gatewayclass and labels
+// their metrics with the gateway's name and namespace, that the podmonitor
+// keeps only the Istio request metrics unless the gateway opts in to all Envoy
+// metrics, and that the controller deletes the podmonitor when the gateway no
+// longer uses the default gatewayclass.
+func Test_Reconcile(t *testing.T) {
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "gw", UID: "1"},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			GatewayClassName: "openshift-default",
+			Listeners: []gatewayapiv1beta1.Listener{
+				{Name: "http", Protocol: gatewayapiv1beta1.HTTPProtocolType, Port: 80},
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gateway).Build()
+	informers := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config: Config{OperandNamespace: "openshift-ingress"},
+		client: cl,
+		cache:  fakeCache{Informers: &informers, Reader: cl},
+	}
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	podMonitorName := types.NamespacedName{Namespace: gateway.Namespace, Name: "gw-metrics"}
+
+	reconcileGateway := func(t *testing.T) *unstructured.Unstructured {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: gatewayName}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pm := &unstructured.Unstructured{}
+		pm.SetGroupVersionKind(podMonitorGVK)
+		if err := cl.Get(context.Background(), podMonitorName, pm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			t.Fatalf("failed to get podmonitor: %v", err)
+		}
+		return pm
+	}
+	updateGateway := func(t *testing.T, mutate func(*gatewayapiv1beta1.Gateway)) {
+		t.Helper()
+		if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+			t.Fatalf("failed to get gateway: %v", err)
+		}
+		mutate(gateway)
+		if err := cl.Update(context.Background(), gateway); err != nil {
+			t.Fatalf("failed to update gateway: %v", err)
+		}
+	}
+	endpointOf := func(t *testing.T, pm *unstructured.Unstructured) map[string]interface{} {
+		t.Helper()
+		endpoints, _, err := unstructured.NestedSlice(pm.Object, "spec", "podMetricsEndpoints")
+		if err != nil || len(endpoints) != 1 {
+			t.Fatalf("expected one endpoint, got %v (%v)", endpoints, err)
+		}
+		return endpoints[0].(map[string]interface{})
+	}
+
+	pm := reconcileGateway(t)
+	if pm == nil {
+		t.Fatal("expected the podmonitor to be created")
+	}
+	if !metav1.IsControlledBy(pm, gateway) {
+		t.Errorf("expected the podmonitor to be owned by the gateway, got %+v", pm.GetOwnerReferences())
+	}
+	if selector, _, _ := unstructured.NestedStringMap(pm.Object, "spec", "selector", "matchLabels"); len(selector) != 1 || selector["istio.io/gateway-name"] != "gw" {
+		t.Errorf("expected the podmonitor to select the gateway's pods, got %v", selector)
+	}
+	endpoint := endpointOf(t, pm)
+	if endpoint["port"] != "http-envoy-prom" || endpoint["path"] != "/stats/prometheus" || endpoint["scheme"] != "http" {
+		t.Errorf("expected the podmonitor to scrape Envoy's metrics endpoint, got %v", endpoint)
+	}
+	targetLabels := map[string]bool{}
+	for _, relabeling := range endpoint["relabelings"].([]interface{}) {
+		targetLabels[relabeling.(map[string]interface{})["targetLabel"].(string)] = true
+	}
+	if !targetLabels["gateway_name"] || !targetLabels["gateway_namespace"] {
+		t.Errorf("expected the podmonitor to add the gateway_name and gateway_namespace labels, got %v", endpoint["relabelings"])
+	}
+	if metricRelabelings, ok := endpoint["metricRelabelings"].([]interface{}); !ok || len(metricRelabelings) != 1 || metricRelabelings[0].(map[string]interface{})["regex"] != istioRequestMetricsRegex {
+		t.Errorf("expected the podmonitor to keep only the Istio request metrics, got %v", endpoint["metricRelabelings"])
+	}
+
+	// Opting in to Envoy metrics removes the metric relabeling.
+	updateGateway(t, func(gw *gatewayapiv1beta1.Gateway) {
+		gw.Annotations = map[string]string{EnvoyMetricsAnnotation: "true"}
+	})
+	if pm = reconcileGateway(t); pm == nil {
+		t.Fatal("expected the podmonitor to exist")
+	}
+	if endpoint := endpointOf(t, pm); endpoint["metricRelabelings"] != nil {
+		t.Errorf("expected the podmonitor to keep all metrics, got %v", endpoint["metricRelabelings"])
+	}
+
+	// A gateway that uses another gatewayclass has no podmonitor.
+	updateGateway(t, func(gw *gatewayapiv1beta1.Gateway) {
+		gw.Spec.GatewayClassName = "other"
+	})
+	if pm = reconcileGateway(t); pm != nil {
+		t.Errorf("expected the podmonitor to be deleted")
+	}
+}
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
@@ -0,0 +1,224 @@
+package gatewaymonitoring
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// EnvoyMetricsAnnotation is the annotation with which a gateway opts
+	// in to the collection of all of its Envoy metrics.  By default, the
+	// podmonitor keeps only the standard Istio request metrics, which
+	// have a bounded number of series per gateway.  Envoy's own metrics
+	// include series for each upstream cluster, which is to say for each
+	// backend of each route, so they are collected only if this
+	// annotation is set to "true".
+	EnvoyMetricsAnnotation = "ingress.operator.openshift.io/gateway-envoy-metrics"
+
+	// gatewayNameLabelKey is the key of a label that Istio adds to the
+	// pods of a gateway.  The label's value is the gateway's name.
+	gatewayNameLabelKey = "istio.io/gateway-name"
+
+	// envoyMetricsPortName is the name of the port on which the Envoy
+	// proxy in a gateway pod serves metrics.
+	envoyMetricsPortName = "http-envoy-prom"
+	// envoyMetricsPath is the path at which the Envoy proxy in a gateway
+	// pod serves metrics in the Prometheus format.
+	envoyMetricsPath = "/stats/prometheus"
+
+	// istioRequestMetricsRegex matches the names of the standard Istio
+	// request metrics: request counts, which have the response code as a
+	// label, and request durations.
+	istioRequestMetricsRegex = "istio_requests_total|istio_request_duration_milliseconds_(bucket|count|sum)"
+)
+
+// podMonitorGVK is the group, version, and kind of a podmonitor.
+var podMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Kind:    "PodMonitor",
+	Version: "v1",
+}
+
+// ensurePodMonitor ensures the podmonitor exists for the given gateway.
+// Returns a Boolean indicating whether the podmonitor exists, the podmonitor
+// if it does exist, and an error value.
+func (r *reconciler) ensurePodMonitor(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) (bool, *unstructured.Unstructured, error) {
+	desired := desiredPodMonitor(gateway)
+
+	havePM, current, err := r.currentPodMonitor(ctx, gateway)
+	if err != nil {
+		return false, nil, err
+	}
+
+	switch {
+	case !havePM:
+		if err := r.client.Create(ctx, desired); err != nil {
+			return false, nil, fmt.Errorf("failed to create podmonitor %s/%s: %w", desired.GetNamespace(), desired.GetName(), err)
+		}
+		log.Info("created podmonitor", "namespace", desired.GetNamespace(), "name", desired.GetName())
+		return r.currentPodMonitor(ctx, gateway)
+	case havePM:
+		if updated, err := r.updatePodMonitor(ctx, current, desired); err != nil {
+			return true, current, fmt.Errorf("failed to update podmonitor %s/%s: %w", desired.GetNamespace(), desired.GetName(), err)
+		} else if updated {
+			return r.currentPodMonitor(ctx, gateway)
+		}
+	}
+
+	return true, current, nil
+}
+
+// desiredPodMonitor returns the desired podmonitor for the given gateway.
+//
+// The podmonitor selects the gateway's pods and scrapes the Envoy proxy's
+// metrics endpoint.  It adds "gateway_name" and "gateway_namespace" labels to
+// every series so that the metrics of different gateways can be told apart.
+//
+// The podmonitor scrapes the endpoint using plain HTTP: gateway pods have no
+// sidecar, and Istio excludes the metrics port from mesh traffic capture, so
+// the endpoint is not subject to mesh mTLS, and Envoy does not serve it over
+// TLS.
+func desiredPodMonitor(gateway *gatewayapiv1beta1.Gateway) *unstructured.Unstructured {
+	name := operatorcontroller.GatewayPodMonitorName(gateway)
+	// It is important to use the type []interface{} for list fields;
+	// see desiredServiceMonitor in the ingress controller.
+	var metricRelabelings []interface{}
+	if gateway.Annotations[EnvoyMetricsAnnotation] != "true" {
+		metricRelabelings = append(metricRelabelings, map[string]interface{}{
+			"action":       "keep",
+			"sourceLabels": []interface{}{"__name__"},
+			"regex":        istioRequestMetricsRegex,
+		})
+	}
+	endpoint := map[string]interface{}{
+		"port":     envoyMetricsPortName,
+		"path":     envoyMetricsPath,
+		"scheme":   "http",
+		"interval": "30s",
+		"relabelings": []interface{}{
+			map[string]interface{}{
+				"action":       "replace",
+				"sourceLabels": []interface{}{"__meta_kubernetes_pod_label_istio_io_gateway_name"},
+				"targetLabel":  "gateway_name",
+			},
+			map[string]interface{}{
+				"action":       "replace",
+				"sourceLabels": []interface{}{"__meta_kubernetes_namespace"},
+				"targetLabel":  "gateway_namespace",
+			},
+		},
+	}
+	if len(metricRelabelings) != 0 {
+		endpoint["metricRelabelings"] = metricRelabelings
+	}
+	trueVar := true
+	pm := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"namespace": name.Namespace,
+				"name":      name.Name,
+			},
+			"spec": map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{
+					"matchNames": []interface{}{
+						gateway.Namespace,
+					},
+				},
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						gatewayNameLabelKey: gateway.Name,
+					},
+				},
+				"podMetricsEndpoints": []interface{}{endpoint},
+			},
+		},
+	}
+	pm.SetGroupVersionKind(podMonitorGVK)
+	// The gateway owns the podmonitor so that the garbage collector
+	// deletes the podmonitor when the gateway is deleted.
+	pm.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: gatewayapiv1beta1.GroupVersion.String(),
+		Kind:       "Gateway",
+		Name:       gateway.Name,
+		UID:        gateway.UID,
+		Controller: &trueVar,
+	}})
+	return pm
+}
+
+// currentPodMonitor returns the current podmonitor for the given gateway.
+// Returns a Boolean indicating whether the podmonitor existed, the podmonitor
+// if it did exist, and an error value.
+func (r *reconciler) currentPodMonitor(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) (bool, *unstructured.Unstructured, error) {
+	pm := &unstructured.Unstructured{}
+	pm.SetGroupVersionKind(podMonitorGVK)
+	if err := r.client.Get(ctx, operatorcontroller.GatewayPodMonitorName(gateway), pm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, pm, nil
+}
+
+// updatePodMonitor updates a podmonitor.  Returns a Boolean indicating whether
+// the podmonitor was updated, and an error value.
+func (r *reconciler) updatePodMonitor(ctx context.Context, current, desired *unstructured.Unstructured) (bool, error) {
+	changed, updated := podMonitorChanged(current, desired)
+	if !changed {
+		return false, nil
+	}
+
+	// Diff before updating because the client may mutate the object.
+	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
+	if err := r.client.Update(ctx, updated); err != nil {
+		return false, err
+	}
+	log.Info("updated podmonitor", "namespace", updated.GetNamespace(), "name", updated.GetName(), "diff", diff)
+	return true, nil
+}
+
+// podMonitorChanged checks if the current podmonitor spec matches the expected
+// spec and if not returns an updated one.
+func podMonitorChanged(current, expected *unstructured.Unstructured) (bool, *unstructured.Unstructured) {
+	if reflect.DeepEqual(current.Object["spec"], expected.Object["spec"]) {
+		return false, nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Object["spec"] = expected.Object["spec"]
+	return true, updated
+}
+
+// deletePodMonitor deletes the podmonitor for the given gateway, if it exists
+// and the gateway owns it.
+func (r *reconciler) deletePodMonitor(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	havePM, current, err := r.currentPodMonitor(ctx, gateway)
+	if err != nil || !havePM {
+		return err
+	}
+	if !metav1.IsControlledBy(current, gateway) {
+		return nil
+	}
+	if err := r.client.Delete(ctx, current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete podmonitor %s/%s: %w", current.GetNamespace(), current.GetName(), err)
+	}
+	log.Info("deleted podmonitor", "namespace", current.GetNamespace(), "name", current.GetName())
+	return nil
+}
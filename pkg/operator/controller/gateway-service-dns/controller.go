@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
 
 	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
@@ -24,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilclock "k8s.io/utils/clock"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -61,6 +64,7 @@ func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, er
 		client:   mgr.GetClient(),
 		cache:    operatorCache,
 		recorder: mgr.GetEventRecorderFor(controllerName),
+		clock:    utilclock.RealClock{},
 	}
 	c, err := controller.NewUnmanaged(controllerName, mgr, controller.Options{Reconciler: reconciler})
 	if err != nil {
@@ -72,42 +76,30 @@ func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, er
 		_, ok := o.(*corev1.Service).Labels[managedByIstioLabelKey]
 		return ok
 	})
-	gatewayListenersChanged := predicate.Funcs{
+	gatewayListenersChangedOrDeleting := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
+			// A gateway that is marked for deletion needs its
+			// DNSRecord CRs to be deleted.
+			if e.ObjectNew.GetDeletionTimestamp() != nil {
+				return true
+			}
 			old := e.ObjectOld.(*gatewayapiv1beta1.Gateway).Spec.Listeners
 			new := e.ObjectNew.(*gatewayapiv1beta1.Gateway).Spec.Listeners
 			// A DNSRecord CR needs to be updated if, and only if,
 			// the hostname has changed (a listener's port and
-			// protocol have no bearing on the DNS record).
-			return gatewayListenersHostnamesChanged(old, new)
+			// protocol have no bearing on the DNS record) or the
+			// gateway's publishing or drain annotations have
+			// changed.
+			return gatewayListenersHostnamesChanged(old, new) || gatewayAnnotationsChanged(e.ObjectOld, e.ObjectNew)
 		},
 	}
 	isInOperandNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
 		return o.GetNamespace() == config.OperandNamespace
 	})
 	gatewayToService := func(ctx context.Context, o client.Object) []reconcile.Request {
-		var services corev1.ServiceList
-		listOpts := []client.ListOption{
-			client.MatchingLabels{gatewayNameLabelKey: o.GetName()},
-			client.InNamespace(config.OperandNamespace),
-		}
-		requests := []reconcile.Request{}
-		if err := reconciler.cache.List(ctx, &services, listOpts...); err != nil {
-			log.Error(err, "failed to list services for gateway", "gateway", o.GetName())
-			return requests
-		}
-		for i := range services.Items {
-			request := reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Namespace: services.Items[i].Namespace,
-					Name:      services.Items[i].Name,
-				},
-			}
-			requests = append(requests, request)
-		}
-		return requests
+		return reconciler.servicesForGateway(ctx, o.GetNamespace(), o.GetName())
 	}
-	if err := c.Watch(source.Kind[client.Object](operatorCache, &gatewayapiv1beta1.Gateway{}, handler.EnqueueRequestsFromMapFunc(gatewayToService), isInOperandNamespace, gatewayListenersChanged)); err != nil {
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &gatewayapiv1beta1.Gateway{}, handler.EnqueueRequestsFromMapFunc(gatewayToService), isInOperandNamespace, gatewayListenersChangedOrDeleting)); err != nil {
 		return nil, err
 	}
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Service{}, &handler.EnqueueRequestForObject{}, isServiceNeedingDNS, isInOperandNamespace)); err != nil {
@@ -116,9 +108,72 @@ func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, er
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &iov1.DNSRecord{}, handler.EnqueueRequestForOwner(scheme, mapper, &corev1.Service{}), isInOperandNamespace)); err != nil {
 		return nil, err
 	}
+	// Watch gateway pods so that the dnsrecords of a gateway that uses a
+	// NodePort service and the "NodeAddresses" DNS policy follow the
+	// nodes that run the gateway's ready pods.
+	isGatewayPod := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := o.GetLabels()[gatewayNameLabelKey]
+		return ok
+	})
+	podToService := func(ctx context.Context, o client.Object) []reconcile.Request {
+		return reconciler.servicesForGateway(ctx, o.GetNamespace(), o.GetLabels()[gatewayNameLabelKey])
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(podToService), isInOperandNamespace, isGatewayPod)); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
+// servicesForGateway returns reconcile requests for the services of the named
+// gateway.  If the gateway has no service, a request using the gateway's name
+// is returned instead.
+func (r *reconciler) servicesForGateway(ctx context.Context, namespace, name string) []reconcile.Request {
+	var services corev1.ServiceList
+	listOpts := []client.ListOption{
+		client.MatchingLabels{gatewayNameLabelKey: name},
+		client.InNamespace(r.config.OperandNamespace),
+	}
+	requests := []reconcile.Request{}
+	if err := r.cache.List(ctx, &services, listOpts...); err != nil {
+		log.Error(err, "failed to list services for gateway", "gateway", name)
+		return requests
+	}
+	for i := range services.Items {
+		request := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: services.Items[i].Namespace,
+				Name:      services.Items[i].Name,
+			},
+		}
+		requests = append(requests, request)
+	}
+	// A gateway that has no service is handled by
+	// reconcileGatewaysWithoutService, which Reconcile calls when the
+	// requested service does not exist, so enqueue a request using the
+	// gateway's name, which Istio does not use for gateway services.
+	if len(requests) == 0 {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: namespace,
+				Name:      name,
+			},
+		})
+	}
+	return requests
+}
+
+// gatewayAnnotationsChanged returns a Boolean indicating whether the
+// annotations that specify how a gateway is published or drained differ
+// between the given objects.
+func gatewayAnnotationsChanged(old, new client.Object) bool {
+	for _, key := range []string{GatewayEndpointPublishingStrategyAnnotation, GatewayNodePortDNSPolicyAnnotation, istioServiceTypeAnnotation, GatewayDrainPeriodAnnotation, istioProxyConfigAnnotation} {
+		if old.GetAnnotations()[key] != new.GetAnnotations()[key] {
+			return true
+		}
+	}
+	return false
+}
+
 // gatewayListenersHostnamesChanged returns a Boolean indicating whether any
 // hostnames changed in the given gateway listeners.
 func gatewayListenersHostnamesChanged(xs, ys []gatewayapiv1beta1.Listener) bool {
@@ -143,6 +198,10 @@ type Config struct {
 	// OperandNamespace is the namespace in which to watch for services and
 	// dnsrecords and in which to create dnsrecords.
 	OperandNamespace string
+	// CacheFreshness tracks whether the cache is fresh enough for the
+	// controller to delete dnsrecords based on the gateways and services
+	// that the cache contains.
+	CacheFreshness *cachefreshness.Tracker
 }
 
 // reconciler handles the actual service reconciliation logic.
@@ -152,6 +211,7 @@ type reconciler struct {
 	client   client.Client
 	cache    cache.Cache
 	recorder record.EventRecorder
+	clock    utilclock.PassiveClock
 }
 
 // Reconcile expects request to refer to a service and creates or reconciles a
@@ -162,8 +222,8 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	var service corev1.Service
 	if err := r.cache.Get(ctx, request.NamespacedName, &service); err != nil {
 		if apierrors.IsNotFound(err) {
-			log.Info("service not found; reconciliation will be skipped", "request", request)
-			return reconcile.Result{}, nil
+			log.Info("service not found; checking for gateways without a service", "request", request)
+			return reconcileResult(r.reconcileGatewaysWithoutService(ctx))
 		}
 		return reconcile.Result{}, err
 	}
@@ -180,12 +240,21 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	}
 	if err := r.cache.Get(ctx, gatewayName, &gateway); err != nil {
 		if apierrors.IsNotFound(err) {
-			log.Info("gateway not found; reconciliation will be skipped", "request", request)
-			return reconcile.Result{}, nil
+			// The gateway may have been deleted before it had
+			// the finalizer, so delete any dnsrecords that it
+			// left behind.
+			log.Info("gateway not found; deleting its dnsrecords", "request", request)
+			gateway.ObjectMeta = metav1.ObjectMeta{Namespace: gatewayName.Namespace, Name: gatewayName.Name}
+			return reconcileResult(r.deleteStaleDNSRecordsForGateway(ctx, &gateway, &service, sets.NewString())...)
 		}
 		return reconcile.Result{}, err
 	}
 
+	if gateway.DeletionTimestamp != nil {
+		log.Info("gateway is marked for deletion; deleting its dnsrecords", "request", request)
+		return reconcileResult(r.finalizeGateway(ctx, &gateway))
+	}
+
 	dnsConfig := &configv1.DNS{}
 	if err := r.client.Get(ctx, types.NamespacedName{Name: "cluster"}, dnsConfig); err != nil {
 		return reconcile.Result{}, fmt.Errorf("failed to get dns 'cluster': %v", err)
@@ -200,29 +269,116 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return reconcile.Result{}, nil
 	}
 
-	domains := getGatewayHostnames(&gateway)
+	publishing, err := publishingForGateway(&gateway)
+	if err != nil {
+		// Leave the service and the dnsrecords alone until the
+		// annotations are fixed, which triggers reconciliation.
+		log.Error(err, "gateway has invalid publishing annotations; reconciliation will be skipped", "request", request)
+		r.recorder.Event(&gateway, corev1.EventTypeWarning, "InvalidEndpointPublishingStrategy", err.Error())
+		return reconcile.Result{}, nil
+	}
+	if err := r.ensureGatewayAnnotations(ctx, &gateway, publishing); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Publish DNS records only for valid hostnames.  Any dnsrecords for
+	// hostnames that are invalid are deleted as stale.  While there is no
+	// target for the records to point to, such as the service's load
+	// balancer address, the gateway's existing dnsrecords are left alone
+	// so that a load balancer that briefly loses its address does not take
+	// the gateway's names out of DNS; they are updated once the target
+	// reappears, and they are deleted if the gateway's hostnames are
+	// removed, if the gateway's service is deleted, or if the gateway's
+	// publishing configuration no longer publishes DNS records.
+	hostnames := getGatewayHostnames(&gateway)
+	domains := hostnames.valid
+	targetService, targetCondition, err := r.dnsTargetService(ctx, &gateway, &service, publishing)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	r.recordDNSTargetTransition(&gateway, targetCondition)
 	var errs []error
-	errs = append(errs, r.ensureDNSRecordsForGateway(ctx, &gateway, &service, domains.List(), infraConfig, dnsConfig)...)
-	errs = append(errs, r.deleteStaleDNSRecordsForGateway(ctx, &gateway, &service, domains)...)
-	return reconcile.Result{}, utilerrors.NewAggregate(errs)
+	if targetService != nil {
+		// Add the finalizer before publishing any records so that
+		// the records are deleted with the gateway.
+		if domains.Len() != 0 {
+			if err := r.ensureGatewayDNSFinalizer(ctx, &gateway); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		errs = append(errs, r.ensureDNSRecordsForGateway(ctx, &gateway, targetService, domains.List(), infraConfig, dnsConfig)...)
+	} else {
+		log.Info("gateway has no DNS target; dnsrecords will be published once it has one", "request", request, "reason", targetCondition.Reason)
+		if !publishing.publishesDNSRecords() {
+			domains = sets.NewString()
+		}
+	}
+	staleErrs := r.deleteStaleDNSRecordsForGateway(ctx, &gateway, &service, domains)
+	errs = append(errs, staleErrs...)
+	if utilerrors.NewAggregate(staleErrs) == nil {
+		errs = append(errs, r.releaseGatewayDNSFinalizer(ctx, &gateway))
+	}
+	errs = append(errs, r.updateGatewayDNSConditions(ctx, &gateway, hostnames, targetCondition))
+	return reconcileResult(errs...)
 }
 
-// getGatewayHostnames returns a sets.String with the hostnames from the given
-// gateway's listeners.  Adds a trailing dot if it's missing from the hostname.
-func getGatewayHostnames(gateway *gatewayapiv1beta1.Gateway) sets.String {
-	domains := sets.NewString()
+// reconcileResult returns the result and error for a reconciliation that
+// encountered the given errors.  If the controller deferred deleting
+// dnsrecords because the cache might be stale, or is keeping a deleted gateway
+// while it drains, the request is requeued for when the cache may be fresh or
+// the drain period ends instead of being retried with the controller's
+// backoff, and the deferral is not reported as an error.
+func reconcileResult(errs ...error) (reconcile.Result, error) {
+	agg := utilerrors.Flatten(utilerrors.NewAggregate(errs))
+	if agg == nil {
+		return reconcile.Result{}, nil
+	}
+	var requeueAfter time.Duration
+	var remaining []error
+	for _, err := range agg.Errors() {
+		if staleErr, ok := cachefreshness.IsStaleCache(err); ok {
+			if requeueAfter == 0 || staleErr.RetryAfter < requeueAfter {
+				requeueAfter = staleErr.RetryAfter
+			}
+			continue
+		}
+		if drainErr, ok := isDrainPending(err); ok {
+			if requeueAfter == 0 || drainErr.RetryAfter < requeueAfter {
+				requeueAfter = drainErr.RetryAfter
+			}
+			continue
+		}
+		remaining = append(remaining, err)
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, utilerrors.NewAggregate(remaining)
+}
+
+// getGatewayHostnames returns the hostnames from the given gateway's
+// listeners, separated into those that can be published in DNS and those that
+// cannot.  Listeners of every protocol, including UDP, are considered because
+// the gateway's service exposes all of them on the same address.  Adds a
+// trailing dot if it's missing from the hostname.
+func getGatewayHostnames(gateway *gatewayapiv1beta1.Gateway) gatewayHostnames {
+	hostnames := gatewayHostnames{
+		valid:   sets.NewString(),
+		invalid: map[gatewayapiv1beta1.SectionName]error{},
+	}
 	for _, listener := range gateway.Spec.Listeners {
 		if listener.Hostname == nil || len(*listener.Hostname) == 0 {
 			continue
 		}
 		domain := string(*listener.Hostname)
+		if err := validateGatewayHostname(domain); err != nil {
+			hostnames.invalid[listener.Name] = err
+			continue
+		}
 		// If domain doesn't have a trailing dot, add it.
 		if !strings.HasSuffix(domain, ".") {
 			domain = domain + "."
 		}
-		domains.Insert(domain)
+		hostnames.valid.Insert(domain)
 	}
-	return domains
+	return hostnames
 }
 
 // ensureDNSRecordsForGateway ensures that a DNSRecord CR exists, associated
@@ -248,7 +404,7 @@ func (r *reconciler) ensureDNSRecordsForGateway(ctx context.Context, gateway *ga
 		if dnsrecord.ManageDNSForDomain(domain, infraConfig.Status.PlatformStatus, dnsConfig) {
 			dnsPolicy = iov1.ManagedDNS
 		}
-		_, _, err := dnsrecord.EnsureDNSRecord(r.client, name, labels, ownerRef, domain, dnsPolicy, service)
+		_, _, err := dnsrecord.EnsureDNSRecord(r.client, name, labels, ownerRef, domain, dnsPolicy, service, dnsrecord.TargetPreferenceForAnnotations(gateway.Annotations))
 		errs = append(errs, err)
 	}
 	return errs
@@ -257,8 +413,11 @@ func (r *reconciler) ensureDNSRecordsForGateway(ctx context.Context, gateway *ga
 // deleteStaleDNSRecordsForGateway deletes any DNSRecord CRs that are associated
 // with the given gateway but specify a DNS name that is not in the given set of
 // domains.  Such DNSRecord CRs may exist if a hostname was modified or deleted
-// on the gateway.  deleteStaleDNSRecordsForGateway returns a list of any errors
-// that result from deleting those DNSRecord CRs.
+// on the gateway.  Because the caller determines the gateway's domains from the
+// cache, deleteStaleDNSRecordsForGateway does not delete anything unless the
+// cache is fresh.  deleteStaleDNSRecordsForGateway returns a list of any errors
+// that result from deleting those DNSRecord CRs, or a
+// *cachefreshness.StaleCacheError if it deferred deleting them.
 func (r *reconciler) deleteStaleDNSRecordsForGateway(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, service *corev1.Service, domains sets.String) []error {
 	listOpts := []client.ListOption{
 		client.MatchingLabels{gatewayNameLabelKey: gateway.Name},
@@ -270,15 +429,24 @@ func (r *reconciler) deleteStaleDNSRecordsForGateway(ctx context.Context, gatewa
 	if err := r.client.List(ctx, &dnsrecords, listOpts...); err != nil {
 		return []error{err}
 	}
-	var errs []error
+	var stale []types.NamespacedName
 	for i := range dnsrecords.Items {
 		if domains.Has(dnsrecords.Items[i].Spec.DNSName) {
 			continue
 		}
-		name := types.NamespacedName{
+		stale = append(stale, types.NamespacedName{
 			Namespace: dnsrecords.Items[i].Namespace,
 			Name:      dnsrecords.Items[i].Name,
-		}
+		})
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if err := r.config.CacheFreshness.AllowDestructiveAction(ctx, r.cache, controllerName, "DeleteDNSRecord", &gatewayapiv1beta1.Gateway{}, &corev1.Service{}); err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, name := range stale {
 		errs = append(errs, dnsrecord.DeleteDNSRecord(r.client, name))
 	}
 	return errs
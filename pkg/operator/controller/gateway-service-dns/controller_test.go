@@ -2,7 +2,10 @@ package gateway_service_dns
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -12,12 +15,19 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	utilclock "k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
@@ -44,8 +54,9 @@ func Test_Reconcile(t *testing.T) {
 	gw := func(name string, listeners ...gatewayapiv1beta1.Listener) *gatewayapiv1beta1.Gateway {
 		return &gatewayapiv1beta1.Gateway{
 			ObjectMeta: metav1.ObjectMeta{
-				Namespace: "openshift-ingress",
-				Name:      name,
+				Namespace:  "openshift-ingress",
+				Name:       name,
+				Finalizers: []string{gatewayDNSFinalizer},
 			},
 			Spec: gatewayapiv1beta1.GatewaySpec{
 				Listeners: listeners,
@@ -94,6 +105,9 @@ func Test_Reconcile(t *testing.T) {
 				Labels:    labels,
 				Namespace: "openshift-ingress",
 				Name:      name,
+				Annotations: map[string]string{
+					dnsrecord.DNSTargetSelectionAnnotation: fmt.Sprintf("Selected hostname %s from 1 hostnames and 0 IP addresses with Hostname preference.", targets[0]),
+				},
 			},
 			Spec: iov1.DNSRecordSpec{
 				DNSName:             dnsName,
@@ -120,6 +134,9 @@ func Test_Reconcile(t *testing.T) {
 		expectUpdate     []client.Object
 		expectDelete     []client.Object
 		expectError      string
+		// expectHostnamesValid, if not empty, is the expected status of
+		// the gateway's DNSHostnamesValid condition.
+		expectHostnamesValid metav1.ConditionStatus
 	}{
 		{
 			name: "missing dns config",
@@ -148,6 +165,7 @@ func Test_Reconcile(t *testing.T) {
 			expectError:      `infrastructures.config.openshift.io "cluster" not found`,
 		},
 		{
+			// The gateway has no records, so the finalizer is removed.
 			name: "gateway with no listeners",
 			existingObjects: []runtime.Object{
 				dnsConfig, infraConfig,
@@ -156,7 +174,7 @@ func Test_Reconcile(t *testing.T) {
 			},
 			reconcileRequest: req("openshift-ingress", "example-gateway"),
 			expectCreate:     []client.Object{},
-			expectUpdate:     []client.Object{},
+			expectUpdate:     []client.Object{gw("example-gateway")},
 			expectDelete:     []client.Object{},
 		},
 		{
@@ -218,6 +236,70 @@ func Test_Reconcile(t *testing.T) {
 				dnsrecord("example-gateway-64754456b8-wildcard", "*.old.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
 			},
 		},
+		{
+			name: "gateway with listeners on different domains and a dnsrecord for a removed listener",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw(
+					"example-gateway",
+					l("apps", "*.apps.example.com", 443),
+					l("internal", "*.internal.example.com.", 443),
+				),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel, ingHost("lb.example.com")),
+				dnsrecord("example-gateway-6bfddf9b44-wildcard", "*.apps.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+				dnsrecord("example-gateway-68f5567889-wildcard", "*.old.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate: []client.Object{
+				dnsrecord("example-gateway-95dc656c-wildcard", "*.internal.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			expectUpdate: []client.Object{},
+			expectDelete: []client.Object{
+				dnsrecord("example-gateway-68f5567889-wildcard", "*.old.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+		},
+		{
+			// The gateway has no records, so the finalizer is removed.
+			name: "gateway with a pending load balancer",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw("example-gateway", l("stage-http", "*.stage.example.com", 80)),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate:     []client.Object{},
+			expectUpdate:     []client.Object{gw("example-gateway", l("stage-http", "*.stage.example.com", 80))},
+			expectDelete:     []client.Object{},
+		},
+		{
+			name: "gateway with a load balancer that lost its address and a dnsrecord",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw("example-gateway", l("stage-http", "*.stage.example.com", 80)),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel, corev1.LoadBalancerIngress{}),
+				dnsrecord("example-gateway-64754456b8-wildcard", "*.stage.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate:     []client.Object{},
+			expectUpdate:     []client.Object{},
+			expectDelete:     []client.Object{},
+		},
+		{
+			name: "gateway with a load balancer that lost its address and a dnsrecord for a removed listener",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw("example-gateway", l("stage-http", "*.stage.example.com", 80)),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel, corev1.LoadBalancerIngress{}),
+				dnsrecord("example-gateway-64754456b8-wildcard", "*.stage.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+				dnsrecord("example-gateway-68f5567889-wildcard", "*.old.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate:     []client.Object{},
+			expectUpdate:     []client.Object{},
+			expectDelete: []client.Object{
+				dnsrecord("example-gateway-68f5567889-wildcard", "*.old.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+		},
 		{
 			name: "gateway with two listeners and one host name, no dnsrecords, name ends up with trailing dot",
 			existingObjects: []runtime.Object{
@@ -246,6 +328,48 @@ func Test_Reconcile(t *testing.T) {
 			expectUpdate: []client.Object{},
 			expectDelete: []client.Object{},
 		},
+		{
+			name: "gateway with a punycode host name and a host name with a maximum-length label",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw(
+					"example-gateway",
+					l("idn", "xn--bcher-kva.example.com", 443),
+					l("long", "a123456789b123456789c123456789d123456789e123456789f123456789xyz.example.com", 443),
+				),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel, ingHost("lb.example.com")),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate: []client.Object{
+				dnsrecord("example-gateway-5478cf77c6-wildcard", "a123456789b123456789c123456789d123456789e123456789f123456789xyz.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+				dnsrecord("example-gateway-69d9fc445f-wildcard", "xn--bcher-kva.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			expectUpdate:         []client.Object{},
+			expectDelete:         []client.Object{},
+			expectHostnamesValid: metav1.ConditionTrue,
+		},
+		{
+			name: "gateway with a host name with an overlong label and a stale dnsrecord for it",
+			existingObjects: []runtime.Object{
+				dnsConfig, infraConfig,
+				gw(
+					"example-gateway",
+					l("long", "a123456789b123456789c123456789d123456789e123456789f123456789wxyz.example.com", 443),
+					l("http", "*.example.com", 80),
+				),
+				svc("example-gateway", gatewayManagedLabel, exampleGatewayLabel, ingHost("lb.example.com")),
+				dnsrecord("example-gateway-5fbfff44d9-wildcard", "a123456789b123456789c123456789d123456789e123456789f123456789wxyz.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			reconcileRequest: req("openshift-ingress", "example-gateway"),
+			expectCreate: []client.Object{
+				dnsrecord("example-gateway-7bdcfc8f68-wildcard", "*.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			expectUpdate: []client.Object{},
+			expectDelete: []client.Object{
+				dnsrecord("example-gateway-5fbfff44d9-wildcard", "a123456789b123456789c123456789d123456789e123456789f123456789wxyz.example.com.", iov1.ManagedDNS, exampleGatewayLabel, "lb.example.com"),
+			},
+			expectHostnamesValid: metav1.ConditionFalse,
+		},
 	}
 
 	scheme := runtime.NewScheme()
@@ -258,6 +382,7 @@ func Test_Reconcile(t *testing.T) {
 			fakeClient := fake.NewClientBuilder().
 				WithScheme(scheme).
 				WithRuntimeObjects(tc.existingObjects...).
+				WithStatusSubresource(&gatewayapiv1beta1.Gateway{}).
 				Build()
 			cl := &fakeClientRecorder{fakeClient, t, []client.Object{}, []client.Object{}, []client.Object{}}
 			informer := informertest.FakeInformers{Scheme: scheme}
@@ -266,8 +391,10 @@ func Test_Reconcile(t *testing.T) {
 				config: Config{
 					OperandNamespace: "openshift-ingress",
 				},
-				cache:  cache,
-				client: cl,
+				cache:    cache,
+				client:   cl,
+				recorder: record.NewFakeRecorder(10),
+				clock:    utilclock.RealClock{},
 			}
 			res, err := reconciler.Reconcile(context.Background(), tc.reconcileRequest)
 			if tc.expectError == "" {
@@ -289,15 +416,509 @@ func Test_Reconcile(t *testing.T) {
 			if diff := cmp.Diff(tc.expectUpdate, cl.updated, cmpOpts...); diff != "" {
 				t.Fatalf("found diff between expected and actual updates: %s", diff)
 			}
-			// A deleted object has zero spec.
-			delCmpOpts := append(cmpOpts, cmpopts.IgnoreTypes(iov1.DNSRecordSpec{}))
+			// A deleted object has zero spec and no annotations.
+			delCmpOpts := append(cmpOpts, cmpopts.IgnoreTypes(iov1.DNSRecordSpec{}), cmpopts.IgnoreFields(metav1.ObjectMeta{}, "Annotations"))
 			if diff := cmp.Diff(tc.expectDelete, cl.deleted, delCmpOpts...); diff != "" {
 				t.Fatalf("found diff between expected and actual deletes: %s", diff)
 			}
+			if len(tc.expectHostnamesValid) != 0 {
+				var gateway gatewayapiv1beta1.Gateway
+				if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "openshift-ingress", Name: "example-gateway"}, &gateway); err != nil {
+					t.Fatalf("failed to get gateway: %v", err)
+				}
+				cond := meta.FindStatusCondition(gateway.Status.Conditions, GatewayDNSHostnamesValidConditionType)
+				if cond == nil {
+					t.Fatalf("expected gateway to have a %s condition", GatewayDNSHostnamesValidConditionType)
+				}
+				assert.Equal(t, tc.expectHostnamesValid, cond.Status)
+			}
 		})
 	}
 }
 
+// Test_Reconcile_loadBalancerTransitions verifies that the controller defers
+// publishing a gateway's dnsrecord until the gateway's service has a load
+// balancer address, reports the waiting state on the gateway, publishes the
+// record once the address appears, keeps the record while the load balancer
+// briefly loses its address, and deletes the record and reports the waiting
+// state again when the service is deleted.
+func Test_Reconcile_loadBalancerTransitions(t *testing.T) {
+	hostname := gatewayapiv1beta1.Hostname("*.stage.example.com")
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway",
+		},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			Listeners: []gatewayapiv1beta1.Listener{{Name: "http", Hostname: &hostname, Port: 80}},
+		},
+	}
+	newService := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      "example-gateway",
+				Labels: map[string]string{
+					"gateway.istio.io/managed": "example-gateway",
+					"istio.io/gateway-name":    "example-gateway",
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"istio.io/gateway-name": "example-gateway"},
+			},
+		}
+	}
+	scheme := runtime.NewScheme()
+	iov1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(
+			&configv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.DNSSpec{BaseDomain: "example.com"}},
+			&configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType}}},
+			gateway,
+		).
+		WithStatusSubresource(&gatewayapiv1beta1.Gateway{}, &corev1.Service{}).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	informer := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config:   Config{OperandNamespace: "openshift-ingress"},
+		cache:    fakeCache{Informers: &informer, Reader: cl},
+		client:   cl,
+		recorder: recorder,
+		clock:    utilclock.RealClock{},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "openshift-ingress", Name: "example-gateway"}}
+
+	expect := func(description string, expectTargets []string, expectStatus metav1.ConditionStatus, expectReason, expectEvent string) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		var records iov1.DNSRecordList
+		if err := cl.List(context.Background(), &records, client.InNamespace("openshift-ingress")); err != nil {
+			t.Fatalf("%s: failed to list dnsrecords: %v", description, err)
+		}
+		var targets []string
+		for i := range records.Items {
+			// Let the deletion of a record complete as the DNS
+			// controller would.
+			if records.Items[i].DeletionTimestamp != nil {
+				records.Items[i].Finalizers = nil
+				if err := cl.Update(context.Background(), &records.Items[i]); err != nil {
+					t.Fatalf("%s: failed to remove finalizers from dnsrecord: %v", description, err)
+				}
+				continue
+			}
+			if len(records.Items[i].Spec.Targets) == 0 {
+				t.Errorf("%s: dnsrecord %s has no targets", description, records.Items[i].Name)
+			}
+			targets = append(targets, records.Items[i].Spec.Targets...)
+		}
+		assert.Equal(t, expectTargets, targets, description)
+
+		var current gatewayapiv1beta1.Gateway
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "openshift-ingress", Name: "example-gateway"}, &current); err != nil {
+			t.Fatalf("%s: failed to get gateway: %v", description, err)
+		}
+		cond := meta.FindStatusCondition(current.Status.Conditions, GatewayDNSTargetAvailableConditionType)
+		if cond == nil {
+			t.Fatalf("%s: expected gateway to have a %s condition", description, GatewayDNSTargetAvailableConditionType)
+		}
+		assert.Equal(t, expectStatus, cond.Status, description)
+		assert.Equal(t, expectReason, cond.Reason, description)
+
+		select {
+		case event := <-recorder.Events:
+			if len(expectEvent) == 0 {
+				t.Errorf("%s: unexpected event: %s", description, event)
+			} else if !strings.HasPrefix(event, "Normal "+expectEvent) {
+				t.Errorf("%s: expected a %s event, got %s", description, expectEvent, event)
+			}
+		default:
+			if len(expectEvent) != 0 {
+				t.Errorf("%s: expected a %s event, got none", description, expectEvent)
+			}
+		}
+	}
+	setAddress := func(service *corev1.Service, hostname string) {
+		t.Helper()
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: hostname}}
+		if err := cl.Status().Update(context.Background(), service); err != nil {
+			t.Fatalf("failed to update service status: %v", err)
+		}
+	}
+
+	service := newService()
+	if err := cl.Create(context.Background(), service); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	expect("pending", nil, metav1.ConditionFalse, "WaitingForLoadBalancer", "WaitingForLoadBalancer")
+	expect("still pending", nil, metav1.ConditionFalse, "WaitingForLoadBalancer", "")
+
+	setAddress(service, "lb-1.example.com")
+	expect("provisioned", []string{"lb-1.example.com"}, metav1.ConditionTrue, "LoadBalancerProvisioned", "LoadBalancerProvisioned")
+
+	service.Status.LoadBalancer.Ingress = nil
+	if err := cl.Status().Update(context.Background(), service); err != nil {
+		t.Fatalf("failed to update service status: %v", err)
+	}
+	expect("address lost", []string{"lb-1.example.com"}, metav1.ConditionFalse, "WaitingForLoadBalancer", "WaitingForLoadBalancer")
+	setAddress(service, "lb-1.example.com")
+	expect("address restored", []string{"lb-1.example.com"}, metav1.ConditionTrue, "LoadBalancerProvisioned", "LoadBalancerProvisioned")
+
+	if err := cl.Delete(context.Background(), service); err != nil {
+		t.Fatalf("failed to delete service: %v", err)
+	}
+	expect("deleted", nil, metav1.ConditionFalse, "ServiceNotFound", "ServiceNotFound")
+
+	service = newService()
+	if err := cl.Create(context.Background(), service); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	expect("recreated", nil, metav1.ConditionFalse, "WaitingForLoadBalancer", "WaitingForLoadBalancer")
+	setAddress(service, "lb-2.example.com")
+	expect("reprovisioned", []string{"lb-2.example.com"}, metav1.ConditionTrue, "LoadBalancerProvisioned", "LoadBalancerProvisioned")
+}
+
+// Test_Reconcile_gatewayDeletion verifies that the controller adds its
+// finalizer to a gateway for which it publishes DNS records, deletes the
+// gateway's dnsrecords when the gateway is marked for deletion, and removes the
+// finalizer only after the dnsrecords are gone and the drain period has ended,
+// both for a gateway that has a service and for one whose service is already
+// gone.
+func Test_Reconcile_gatewayDeletion(t *testing.T) {
+	hostname := gatewayapiv1beta1.Hostname("*.stage.example.com")
+	newGateway := func(name string) *gatewayapiv1beta1.Gateway {
+		return &gatewayapiv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      name,
+			},
+			Spec: gatewayapiv1beta1.GatewaySpec{
+				Listeners: []gatewayapiv1beta1.Listener{{Name: "http", Hostname: &hostname, Port: 80}},
+			},
+		}
+	}
+	newService := func(gatewayName string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      gatewayName + "-openshift-default",
+				Labels: map[string]string{
+					"gateway.istio.io/managed": gatewayName,
+					"istio.io/gateway-name":    gatewayName,
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"istio.io/gateway-name": gatewayName},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+				},
+			},
+		}
+	}
+	scheme := runtime.NewScheme()
+	iov1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+
+	testCases := []struct {
+		name          string
+		deleteService bool
+	}{
+		{name: "gateway with a service"},
+		{name: "gateway without a service", deleteService: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := newGateway("example-gateway")
+			service := newService(gateway.Name)
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(
+					&configv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.DNSSpec{BaseDomain: "example.com"}},
+					&configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType}}},
+					gateway, service,
+				).
+				WithStatusSubresource(&gatewayapiv1beta1.Gateway{}, &corev1.Service{}).
+				Build()
+			informer := informertest.FakeInformers{Scheme: scheme}
+			clock := clocktesting.NewFakePassiveClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			reconciler := &reconciler{
+				config:   Config{OperandNamespace: "openshift-ingress"},
+				cache:    fakeCache{Informers: &informer, Reader: cl},
+				client:   cl,
+				recorder: record.NewFakeRecorder(10),
+				clock:    clock,
+			}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: service.Namespace, Name: service.Name}}
+			gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+			reconcileAndListRecords := func(description string) []iov1.DNSRecord {
+				t.Helper()
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("%s: unexpected error: %v", description, err)
+				}
+				var records iov1.DNSRecordList
+				if err := cl.List(context.Background(), &records, client.InNamespace("openshift-ingress")); err != nil {
+					t.Fatalf("%s: failed to list dnsrecords: %v", description, err)
+				}
+				return records.Items
+			}
+
+			if records := reconcileAndListRecords("created"); len(records) != 1 {
+				t.Fatalf("expected 1 dnsrecord, got %d", len(records))
+			}
+			if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+				t.Fatalf("failed to get gateway: %v", err)
+			}
+			if !slice.ContainsString(gateway.Finalizers, gatewayDNSFinalizer) {
+				t.Fatalf("expected gateway to have finalizer %s, got %v", gatewayDNSFinalizer, gateway.Finalizers)
+			}
+
+			if tc.deleteService {
+				if err := cl.Delete(context.Background(), service); err != nil {
+					t.Fatalf("failed to delete service: %v", err)
+				}
+			}
+			if err := cl.Delete(context.Background(), gateway); err != nil {
+				t.Fatalf("failed to delete gateway: %v", err)
+			}
+			records := reconcileAndListRecords("gateway deleted")
+			if len(records) != 1 || records[0].DeletionTimestamp == nil {
+				t.Fatalf("expected the dnsrecord to be marked for deletion, got %+v", records)
+			}
+			if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+				t.Fatalf("expected the gateway to be kept until its dnsrecord is gone: %v", err)
+			}
+
+			// Let the deletion of the record complete as the DNS
+			// controller would.
+			records[0].Finalizers = nil
+			if err := cl.Update(context.Background(), &records[0]); err != nil {
+				t.Fatalf("failed to remove finalizers from dnsrecord: %v", err)
+			}
+			if records := reconcileAndListRecords("dnsrecord deleted"); len(records) != 0 {
+				t.Fatalf("expected no dnsrecords, got %d", len(records))
+			}
+			if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+				t.Fatalf("expected the gateway to be kept while it drains: %v", err)
+			}
+			if _, ok := gateway.Annotations[gatewayDNSRemovedAtAnnotation]; !ok {
+				t.Fatalf("expected the gateway to have annotation %s", gatewayDNSRemovedAtAnnotation)
+			}
+
+			clock.SetTime(clock.Now().Add(defaultGatewayDrainPeriod - time.Second))
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if err != nil {
+				t.Fatalf("draining: unexpected error: %v", err)
+			}
+			assert.Equal(t, time.Second, result.RequeueAfter)
+			if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+				t.Fatalf("expected the gateway to be kept while it drains: %v", err)
+			}
+
+			clock.SetTime(clock.Now().Add(time.Second))
+			reconcileAndListRecords("drained")
+			if err := cl.Get(context.Background(), gatewayName, gateway); !apierrors.IsNotFound(err) {
+				t.Fatalf("expected the gateway to be deleted, got %v", err)
+			}
+		})
+	}
+}
+
+// Test_Reconcile_gatewayFinalizer verifies that the controller adds its
+// finalizer to a gateway only once it publishes DNS records for the gateway and
+// removes the finalizer once the gateway has no dnsrecords, so that a gateway
+// for which the controller publishes nothing can be deleted without the
+// controller.
+func Test_Reconcile_gatewayFinalizer(t *testing.T) {
+	hostname := gatewayapiv1beta1.Hostname("*.stage.example.com")
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway",
+		},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			Listeners: []gatewayapiv1beta1.Listener{{Name: "http", Hostname: &hostname, Port: 80}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway-openshift-default",
+			Labels: map[string]string{
+				"gateway.istio.io/managed": "example-gateway",
+				"istio.io/gateway-name":    "example-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"istio.io/gateway-name": "example-gateway"},
+		},
+	}
+	scheme := runtime.NewScheme()
+	iov1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(
+			&configv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.DNSSpec{BaseDomain: "example.com"}},
+			&configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType}}},
+			gateway, service,
+		).
+		WithStatusSubresource(&gatewayapiv1beta1.Gateway{}, &corev1.Service{}).
+		Build()
+	informer := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config:   Config{OperandNamespace: "openshift-ingress"},
+		cache:    fakeCache{Informers: &informer, Reader: cl},
+		client:   cl,
+		recorder: record.NewFakeRecorder(10),
+		clock:    utilclock.RealClock{},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: service.Namespace, Name: service.Name}}
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	reconcileAndCheck := func(description string, expectRecords int, expectFinalizer bool) []iov1.DNSRecord {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		var records iov1.DNSRecordList
+		if err := cl.List(context.Background(), &records, client.InNamespace("openshift-ingress")); err != nil {
+			t.Fatalf("%s: failed to list dnsrecords: %v", description, err)
+		}
+		if len(records.Items) != expectRecords {
+			t.Fatalf("%s: expected %d dnsrecords, got %d", description, expectRecords, len(records.Items))
+		}
+		if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+			t.Fatalf("%s: failed to get gateway: %v", description, err)
+		}
+		if hasFinalizer := slice.ContainsString(gateway.Finalizers, gatewayDNSFinalizer); hasFinalizer != expectFinalizer {
+			t.Fatalf("%s: expected gateway to have finalizer %s: %t, got finalizers %v", description, gatewayDNSFinalizer, expectFinalizer, gateway.Finalizers)
+		}
+		return records.Items
+	}
+
+	reconcileAndCheck("pending load balancer", 0, false)
+
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	if err := cl.Status().Update(context.Background(), service); err != nil {
+		t.Fatalf("failed to update service status: %v", err)
+	}
+	reconcileAndCheck("provisioned", 1, true)
+
+	gateway.Spec.Listeners = nil
+	if err := cl.Update(context.Background(), gateway); err != nil {
+		t.Fatalf("failed to update gateway: %v", err)
+	}
+	records := reconcileAndCheck("hostnames removed", 1, true)
+	if records[0].DeletionTimestamp == nil {
+		t.Fatalf("expected the dnsrecord to be marked for deletion")
+	}
+
+	// Let the deletion of the record complete as the DNS controller
+	// would.
+	records[0].Finalizers = nil
+	if err := cl.Update(context.Background(), &records[0]); err != nil {
+		t.Fatalf("failed to remove finalizers from dnsrecord: %v", err)
+	}
+	reconcileAndCheck("dnsrecord deleted", 0, false)
+
+	if err := cl.Delete(context.Background(), gateway); err != nil {
+		t.Fatalf("failed to delete gateway: %v", err)
+	}
+	if err := cl.Get(context.Background(), gatewayName, gateway); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the gateway to be deleted without the controller, got %v", err)
+	}
+}
+
+// Test_Reconcile_staleCache verifies that the controller does not delete a
+// gateway's dnsrecords because the gateway is missing from the cache while the
+// cache might be stale, and that it deletes them once the cache is fresh.
+func Test_Reconcile_staleCache(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway-openshift-default",
+			Labels: map[string]string{
+				"gateway.istio.io/managed": "example-gateway",
+				"istio.io/gateway-name":    "example-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"istio.io/gateway-name": "example-gateway"},
+		},
+	}
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "example-gateway"},
+	}
+	dnsRecord := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway-7bdcfc8f5-wildcard",
+			Labels:    map[string]string{"istio.io/gateway-name": "example-gateway"},
+		},
+		Spec: iov1.DNSRecordSpec{DNSName: "*.stage.example.com."},
+	}
+	scheme := runtime.NewScheme()
+	iov1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(service, gateway, dnsRecord).Build()
+	// The cache has the service but has not received the gateway yet, as
+	// happens when the operator acts on a partially synced cache.
+	cachedObjects := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(service).Build()
+	informers := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config: Config{
+			OperandNamespace: "openshift-ingress",
+			CacheFreshness:   cachefreshness.NewTracker(time.Hour),
+		},
+		cache:    fakeCache{Informers: &informers, Reader: cachedObjects},
+		client:   cl,
+		recorder: record.NewFakeRecorder(10),
+		clock:    utilclock.RealClock{},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: service.Namespace, Name: service.Name}}
+	recordName := types.NamespacedName{Namespace: dnsRecord.Namespace, Name: dnsRecord.Name}
+	reconcileAndCheck := func(description string, expectDeleted bool) {
+		t.Helper()
+		result, err := reconciler.Reconcile(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		err = cl.Get(context.Background(), recordName, &iov1.DNSRecord{})
+		switch {
+		case expectDeleted && !apierrors.IsNotFound(err):
+			t.Fatalf("%s: expected the dnsrecord to be deleted, got %v", description, err)
+		case !expectDeleted && err != nil:
+			t.Fatalf("%s: expected the dnsrecord to be kept, got %v", description, err)
+		case !expectDeleted && result.RequeueAfter == 0:
+			t.Fatalf("%s: expected the request to be requeued, got %+v", description, result)
+		}
+	}
+
+	reconcileAndCheck("informers not synced", false)
+
+	for _, obj := range []client.Object{&gatewayapiv1beta1.Gateway{}, &corev1.Service{}} {
+		informer, err := informers.FakeInformerFor(context.Background(), obj)
+		if err != nil {
+			t.Fatalf("failed to get informer: %v", err)
+		}
+		informer.Synced = true
+	}
+	reconciler.config.CacheFreshness.WatchErrorHandler(nil, fmt.Errorf("connection refused"))
+	reconcileAndCheck("watch recently failed", false)
+
+	reconciler.config.CacheFreshness = cachefreshness.NewTracker(time.Hour)
+	reconcileAndCheck("cache fresh", true)
+}
+
 type fakeCache struct {
 	cache.Informers
 	client.Reader
@@ -0,0 +1,137 @@
+package gateway_service_dns
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// GatewayDrainPeriodAnnotation is an annotation that can be set on a
+	// gateway to specify, as a duration such as "45s", how long the
+	// gateway keeps serving after its DNS records have been removed when
+	// the gateway is deleted, so that clients whose resolvers cached the
+	// records can still connect until the records expire.  If the
+	// annotation is set, the operator also renders the value into the
+	// gateway's Envoy configuration as the drain duration that Envoy
+	// observes when a gateway pod terminates.  Drain durations longer than
+	// the pod's termination grace period are cut short.  If the
+	// annotation is absent, the drain period is defaultGatewayDrainPeriod
+	// and the Envoy configuration is left alone.
+	GatewayDrainPeriodAnnotation = "ingress.operator.openshift.io/drain-period"
+
+	// gatewayDNSRemovedAtAnnotation is an annotation that the controller
+	// sets on a gateway that is marked for deletion to record when the
+	// gateway's dnsrecords were all gone, which starts the drain period.
+	gatewayDNSRemovedAtAnnotation = "ingress.operator.openshift.io/dns-records-removed-at"
+
+	// istioProxyConfigAnnotation is the annotation with which Istio
+	// configures the Envoy proxies of a gateway.  Istio copies the
+	// gateway's annotations to the gateway's pods.
+	istioProxyConfigAnnotation = "proxy.istio.io/config"
+	// istioTerminationDrainDurationKey is the key in Istio's proxy
+	// configuration that specifies how long Envoy drains connections when
+	// the pod terminates.
+	istioTerminationDrainDurationKey = "terminationDrainDuration"
+
+	// defaultGatewayDrainPeriod is the default drain period, which is the
+	// TTL of the DNS records that the operator publishes.
+	defaultGatewayDrainPeriod = 30 * time.Second
+	// maxGatewayDrainPeriod is the maximum drain period.
+	maxGatewayDrainPeriod = 10 * time.Minute
+)
+
+// drainPendingError is returned when the controller keeps a deleted gateway
+// until its drain period ends.
+type drainPendingError struct {
+	// RetryAfter is the time remaining in the drain period.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *drainPendingError) Error() string {
+	return fmt.Sprintf("gateway is draining for %s", e.RetryAfter)
+}
+
+// isDrainPending returns a Boolean value indicating whether the given error is
+// or wraps a *drainPendingError, along with the error.
+func isDrainPending(err error) (*drainPendingError, bool) {
+	var drainErr *drainPendingError
+	if errors.As(err, &drainErr) {
+		return drainErr, true
+	}
+	return nil, false
+}
+
+// drainPeriodForGateway returns the drain period that the given gateway
+// specifies, a Boolean value indicating whether the gateway specifies it, and
+// an error if the annotation has an invalid value, in which case the default
+// drain period is returned.
+func drainPeriodForGateway(gateway *gatewayapiv1beta1.Gateway) (time.Duration, bool, error) {
+	value, ok := gateway.Annotations[GatewayDrainPeriodAnnotation]
+	if !ok {
+		return defaultGatewayDrainPeriod, false, nil
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil || period < 0 || period > maxGatewayDrainPeriod {
+		return defaultGatewayDrainPeriod, false, fmt.Errorf("annotation %s has invalid value %q; must be a duration between 0s and %s", GatewayDrainPeriodAnnotation, value, maxGatewayDrainPeriod)
+	}
+	return period, true, nil
+}
+
+// desiredProxyConfigAnnotations returns the given annotations with the drain
+// duration in Istio's proxy configuration set to the given period, and a
+// Boolean value indicating whether they differ from the given annotations.
+// Other keys in the proxy configuration are preserved.  An error is returned if
+// the current proxy configuration cannot be parsed.
+func desiredProxyConfigAnnotations(annotations map[string]string, period time.Duration) (map[string]string, bool, error) {
+	proxyConfig := map[string]interface{}{}
+	if value, ok := annotations[istioProxyConfigAnnotation]; ok && len(value) != 0 {
+		if err := yaml.Unmarshal([]byte(value), &proxyConfig); err != nil {
+			return annotations, false, fmt.Errorf("annotation %s has invalid value: %w", istioProxyConfigAnnotation, err)
+		}
+	}
+	if current, ok := proxyConfig[istioTerminationDrainDurationKey].(string); ok {
+		if d, err := time.ParseDuration(current); err == nil && d == period {
+			return annotations, false, nil
+		}
+	}
+	desired := make(map[string]interface{}, len(proxyConfig)+1)
+	for k, v := range proxyConfig {
+		desired[k] = v
+	}
+	desired[istioTerminationDrainDurationKey] = period.String()
+	value, err := yaml.Marshal(desired)
+	if err != nil {
+		return annotations, false, fmt.Errorf("failed to encode annotation %s: %w", istioProxyConfigAnnotation, err)
+	}
+	updated := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		updated[k] = v
+	}
+	updated[istioProxyConfigAnnotation] = string(value)
+	return updated, true, nil
+}
+
+// remainingDrainPeriod returns how much of the given gateway's drain period
+// remains at the given time, given the time at which the gateway's dnsrecords
+// were all gone, which the gateway records in an annotation.  The Boolean
+// value is false if the gateway does not record that time yet.
+func remainingDrainPeriod(gateway *gatewayapiv1beta1.Gateway, period time.Duration, now time.Time) (time.Duration, bool) {
+	value, ok := gateway.Annotations[gatewayDNSRemovedAtAnnotation]
+	if !ok {
+		return period, false
+	}
+	removedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return period, false
+	}
+	if remaining := removedAt.Add(period).Sub(now); remaining > 0 {
+		return remaining, true
+	}
+	return 0, true
+}
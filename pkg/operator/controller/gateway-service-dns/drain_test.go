@@ -0,0 +1,186 @@
+package gateway_service_dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/yaml"
+)
+
+func Test_drainPeriodForGateway(t *testing.T) {
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectPeriod    time.Duration
+		expectSpecified bool
+		expectError     bool
+	}{
+		{
+			name:         "no annotation",
+			expectPeriod: defaultGatewayDrainPeriod,
+		},
+		{
+			name:            "valid period",
+			annotations:     map[string]string{GatewayDrainPeriodAnnotation: "45s"},
+			expectPeriod:    45 * time.Second,
+			expectSpecified: true,
+		},
+		{
+			name:            "zero disables draining",
+			annotations:     map[string]string{GatewayDrainPeriodAnnotation: "0s"},
+			expectPeriod:    0,
+			expectSpecified: true,
+		},
+		{
+			name:         "not a duration",
+			annotations:  map[string]string{GatewayDrainPeriodAnnotation: "soon"},
+			expectPeriod: defaultGatewayDrainPeriod,
+			expectError:  true,
+		},
+		{
+			name:         "negative",
+			annotations:  map[string]string{GatewayDrainPeriodAnnotation: "-1s"},
+			expectPeriod: defaultGatewayDrainPeriod,
+			expectError:  true,
+		},
+		{
+			name:         "too long",
+			annotations:  map[string]string{GatewayDrainPeriodAnnotation: "1h"},
+			expectPeriod: defaultGatewayDrainPeriod,
+			expectError:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &gatewayapiv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			period, specified, err := drainPeriodForGateway(gateway)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectPeriod, period)
+			assert.Equal(t, tc.expectSpecified, specified)
+		})
+	}
+}
+
+func Test_desiredProxyConfigAnnotations(t *testing.T) {
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		period            time.Duration
+		expectChanged     bool
+		expectProxyConfig map[string]interface{}
+		expectError       bool
+	}{
+		{
+			name:              "no proxy config",
+			annotations:       map[string]string{"foo": "bar"},
+			period:            45 * time.Second,
+			expectChanged:     true,
+			expectProxyConfig: map[string]interface{}{"terminationDrainDuration": "45s"},
+		},
+		{
+			name:              "other keys are preserved",
+			annotations:       map[string]string{istioProxyConfigAnnotation: "concurrency: 2\n"},
+			period:            45 * time.Second,
+			expectChanged:     true,
+			expectProxyConfig: map[string]interface{}{"concurrency": float64(2), "terminationDrainDuration": "45s"},
+		},
+		{
+			name:              "drain duration is updated",
+			annotations:       map[string]string{istioProxyConfigAnnotation: "terminationDrainDuration: 5s\n"},
+			period:            time.Minute,
+			expectChanged:     true,
+			expectProxyConfig: map[string]interface{}{"terminationDrainDuration": "1m0s"},
+		},
+		{
+			name:              "equivalent drain duration is left alone",
+			annotations:       map[string]string{istioProxyConfigAnnotation: "terminationDrainDuration: 60s\n"},
+			period:            time.Minute,
+			expectProxyConfig: map[string]interface{}{"terminationDrainDuration": "60s"},
+		},
+		{
+			name:        "invalid proxy config",
+			annotations: map[string]string{istioProxyConfigAnnotation: "[unterminated"},
+			period:      time.Minute,
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := make(map[string]string, len(tc.annotations))
+			for k, v := range tc.annotations {
+				original[k] = v
+			}
+			actual, changed, err := desiredProxyConfigAnnotations(tc.annotations, tc.period)
+			assert.Equal(t, original, tc.annotations, "the annotations must not be mutated")
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.False(t, changed)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expectChanged, changed)
+			for k, v := range tc.annotations {
+				if k != istioProxyConfigAnnotation {
+					assert.Equal(t, v, actual[k])
+				}
+			}
+			proxyConfig := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(actual[istioProxyConfigAnnotation]), &proxyConfig); err != nil {
+				t.Fatalf("failed to parse proxy config %q: %v", actual[istioProxyConfigAnnotation], err)
+			}
+			assert.Equal(t, tc.expectProxyConfig, proxyConfig)
+		})
+	}
+}
+
+func Test_remainingDrainPeriod(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectRemaining time.Duration
+		expectRecorded  bool
+	}{
+		{
+			name:            "not recorded",
+			expectRemaining: 30 * time.Second,
+		},
+		{
+			name:            "unparseable",
+			annotations:     map[string]string{gatewayDNSRemovedAtAnnotation: "yesterday"},
+			expectRemaining: 30 * time.Second,
+		},
+		{
+			name:            "draining",
+			annotations:     map[string]string{gatewayDNSRemovedAtAnnotation: "2024-01-01T00:00:50Z"},
+			expectRemaining: 20 * time.Second,
+			expectRecorded:  true,
+		},
+		{
+			name:            "drained",
+			annotations:     map[string]string{gatewayDNSRemovedAtAnnotation: "2024-01-01T00:00:00Z"},
+			expectRemaining: 0,
+			expectRecorded:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &gatewayapiv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			remaining, recorded := remainingDrainPeriod(gateway, 30*time.Second, now)
+			assert.Equal(t, tc.expectRemaining, remaining)
+			assert.Equal(t, tc.expectRecorded, recorded)
+		})
+	}
+}
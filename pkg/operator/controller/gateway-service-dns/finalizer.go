@@ -0,0 +1,155 @@
+package gateway_service_dns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// gatewayDNSFinalizer is the finalizer that the controller adds to
+	// gateways for which it publishes DNS records so that it can delete
+	// their dnsrecords, and thereby the records in the cloud provider's DNS
+	// zones, before the gateways are deleted.  The controller removes the
+	// finalizer as soon as a gateway has no dnsrecords so that it never
+	// holds up the deletion of a gateway for which it publishes nothing.
+	gatewayDNSFinalizer = "ingress.operator.openshift.io/gateway-dns"
+)
+
+// ensureGatewayDNSFinalizer adds the DNS finalizer to the given gateway if it
+// does not already have it.  The given gateway is updated in place.
+func (r *reconciler) ensureGatewayDNSFinalizer(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	if slice.ContainsString(gateway.Finalizers, gatewayDNSFinalizer) {
+		return nil
+	}
+	gateway.Finalizers = append(gateway.Finalizers, gatewayDNSFinalizer)
+	if err := r.client.Update(ctx, gateway); err != nil {
+		return fmt.Errorf("failed to add finalizer to gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	log.Info("added finalizer to gateway", "namespace", gateway.Namespace, "name", gateway.Name, "finalizer", gatewayDNSFinalizer)
+	return nil
+}
+
+// releaseGatewayDNSFinalizer removes the DNS finalizer from the given gateway,
+// which is not marked for deletion, if the gateway has no dnsrecords.  A
+// gateway for which the controller does not publish DNS records, for example
+// because it has no valid hostnames or no DNS target, thus does not keep the
+// finalizer, and its deletion does not depend on the controller.  The given
+// gateway is updated in place.
+func (r *reconciler) releaseGatewayDNSFinalizer(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	if !slice.ContainsString(gateway.Finalizers, gatewayDNSFinalizer) {
+		return nil
+	}
+	dnsrecords, err := r.listDNSRecordsForGateway(ctx, gateway)
+	if err != nil {
+		return err
+	}
+	if len(dnsrecords) != 0 {
+		return nil
+	}
+	return r.removeGatewayDNSFinalizer(ctx, gateway)
+}
+
+// removeGatewayDNSFinalizer removes the DNS finalizer from the given gateway.
+// The given gateway is updated in place.
+func (r *reconciler) removeGatewayDNSFinalizer(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	gateway.Finalizers = slice.RemoveString(gateway.Finalizers, gatewayDNSFinalizer)
+	if err := r.client.Update(ctx, gateway); err != nil {
+		return fmt.Errorf("failed to remove finalizer from gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	log.Info("removed finalizer from gateway", "namespace", gateway.Namespace, "name", gateway.Name, "finalizer", gatewayDNSFinalizer)
+	return nil
+}
+
+// listDNSRecordsForGateway returns the given gateway's dnsrecords, including
+// any that are marked for deletion.  The dnsrecords are read from the API
+// rather than from the cache so that a dnsrecord that was just created or
+// deleted is accounted for.
+func (r *reconciler) listDNSRecordsForGateway(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) ([]iov1.DNSRecord, error) {
+	var dnsrecords iov1.DNSRecordList
+	listOpts := []client.ListOption{
+		client.MatchingLabels{gatewayNameLabelKey: gateway.Name},
+		client.InNamespace(r.config.OperandNamespace),
+	}
+	if err := r.client.List(ctx, &dnsrecords, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list dnsrecords for gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	return dnsrecords.Items, nil
+}
+
+// finalizeGateway handles a gateway that is marked for deletion.  It deletes
+// the gateway's dnsrecords and removes the DNS finalizer from the gateway once
+// the dnsrecords are gone and the gateway's drain period has ended.  A
+// dnsrecord is gone only after the DNS controller has removed the record from
+// the cloud provider's DNS zones and then removed the dnsrecord's own
+// finalizer.  The deletion of a dnsrecord triggers reconciliation of the
+// service that owns it, so finalizeGateway does not need to poll while it
+// waits for the dnsrecords; it returns a *drainPendingError while the gateway
+// is draining.  Because the garbage collector deletes the gateway's deployment
+// only once the gateway is gone, clients see the gateway's name stop resolving
+// before the gateway stops accepting connections.
+func (r *reconciler) finalizeGateway(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	if !slice.ContainsString(gateway.Finalizers, gatewayDNSFinalizer) {
+		return nil
+	}
+	if err := utilerrors.NewAggregate(r.deleteStaleDNSRecordsForGateway(ctx, gateway, nil, sets.NewString())); err != nil {
+		return err
+	}
+	dnsrecords, err := r.listDNSRecordsForGateway(ctx, gateway)
+	if err != nil {
+		return err
+	}
+	if len(dnsrecords) != 0 {
+		log.Info("waiting for dnsrecords to be deleted before removing finalizer from gateway", "namespace", gateway.Namespace, "name", gateway.Name, "dnsrecords", len(dnsrecords))
+		return nil
+	}
+	if err := r.drainGateway(ctx, gateway); err != nil {
+		return err
+	}
+	return r.removeGatewayDNSFinalizer(ctx, gateway.DeepCopy())
+}
+
+// drainGateway starts the drain period of the given gateway, which is marked
+// for deletion and has no dnsrecords, by recording the current time on the
+// gateway, and returns a *drainPendingError until the drain period has ended.
+func (r *reconciler) drainGateway(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) error {
+	period, _, err := drainPeriodForGateway(gateway)
+	if err != nil {
+		log.Error(err, "using the default drain period", "namespace", gateway.Namespace, "name", gateway.Name, "period", period)
+	}
+	if period == 0 {
+		return nil
+	}
+	now := r.clock.Now()
+	remaining, started := remainingDrainPeriod(gateway, period, now)
+	if !started {
+		updated := gateway.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[gatewayDNSRemovedAtAnnotation] = now.UTC().Format(time.RFC3339)
+		if err := r.client.Update(ctx, updated); err != nil {
+			return fmt.Errorf("failed to record the start of the drain period on gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+		}
+		r.recorder.Eventf(gateway, corev1.EventTypeNormal, "Draining", "DNS records were removed; the gateway will keep serving for %s before it is deleted", period)
+		log.Info("gateway dnsrecords are gone; draining before removing finalizer", "namespace", gateway.Namespace, "name", gateway.Name, "period", period)
+		return &drainPendingError{RetryAfter: period}
+	}
+	if remaining > 0 {
+		return &drainPendingError{RetryAfter: remaining}
+	}
+	return nil
+}
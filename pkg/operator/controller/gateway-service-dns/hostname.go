@@ -0,0 +1,177 @@
+package gateway_service_dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"golang.org/x/net/idna"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// GatewayDNSHostnamesValidConditionType is the type of a condition
+	// that the operator sets on a gateway to indicate whether the
+	// hostnames of all of the gateway's listeners can be published in
+	// DNS.
+	GatewayDNSHostnamesValidConditionType = "ingress.operator.openshift.io/DNSHostnamesValid"
+	// ListenerDNSHostnameValidConditionType is the type of a condition
+	// that the operator sets on a gateway listener's status to indicate
+	// whether the listener's hostname can be published in DNS.
+	ListenerDNSHostnameValidConditionType = "ingress.operator.openshift.io/DNSHostnameValid"
+
+	// maxDNSNameLength is the maximum length of a DNS name, not including
+	// the trailing dot, per RFC 1035.
+	maxDNSNameLength = 253
+	// maxDNSLabelLength is the maximum length of a DNS label, per RFC
+	// 1035.
+	maxDNSLabelLength = 63
+	// punycodePrefix is the ACE prefix of an internationalized domain name
+	// label that is encoded using punycode, per RFC 5890.
+	punycodePrefix = "xn--"
+)
+
+// validateGatewayHostname returns an error describing why the given listener
+// hostname cannot be published in DNS, or nil if it can.  A hostname may have
+// a trailing dot and may start with a wildcard label.  Internationalized
+// domain names must use the punycode form.
+func validateGatewayHostname(hostname string) error {
+	name := strings.TrimSuffix(hostname, ".")
+	if len(name) == 0 {
+		return fmt.Errorf("hostname is empty")
+	}
+	if !utf8.ValidString(name) || strings.IndexFunc(name, func(r rune) bool { return r > 127 }) != -1 {
+		return fmt.Errorf("hostname %q contains non-ASCII characters; internationalized domain names must use the punycode (%s) form", hostname, punycodePrefix)
+	}
+	if len(name) > maxDNSNameLength {
+		return fmt.Errorf("hostname %q is %d characters long, which exceeds the maximum of %d", hostname, len(name), maxDNSNameLength)
+	}
+	for i, label := range strings.Split(name, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		if err := validateDNSLabel(label); err != nil {
+			return fmt.Errorf("hostname %q is invalid: %w", hostname, err)
+		}
+	}
+	return nil
+}
+
+// validateDNSLabel returns an error describing why the given label is not a
+// valid DNS label, or nil if it is valid.
+func validateDNSLabel(label string) error {
+	if len(label) == 0 {
+		return fmt.Errorf("hostname has an empty label")
+	}
+	if len(label) > maxDNSLabelLength {
+		return fmt.Errorf("label %q is %d characters long, which exceeds the maximum of %d", label, len(label), maxDNSLabelLength)
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return fmt.Errorf("label %q contains the character %q; labels may contain only lower-case letters, digits, and hyphens", label, c)
+		}
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("label %q must start and end with a letter or digit", label)
+	}
+	if strings.HasPrefix(label, punycodePrefix) {
+		if _, err := idna.Lookup.ToUnicode(label); err != nil {
+			return fmt.Errorf("label %q is not a valid punycode label: %w", label, err)
+		}
+	}
+	return nil
+}
+
+// gatewayHostnames holds the hostnames of a gateway's listeners, normalized
+// with a trailing dot, separated into those that can be published in DNS and
+// those that cannot.
+type gatewayHostnames struct {
+	// valid is the set of hostnames that can be published in DNS.
+	valid sets.String
+	// invalid maps the name of each listener with a hostname that cannot
+	// be published in DNS to the reason why.
+	invalid map[gatewayapiv1beta1.SectionName]error
+}
+
+// computeGatewayDNSHostnamesValidCondition computes the condition that
+// indicates whether all of a gateway's listener hostnames can be published in
+// DNS.
+func computeGatewayDNSHostnamesValidCondition(gateway *gatewayapiv1beta1.Gateway, hostnames gatewayHostnames) metav1.Condition {
+	if len(hostnames.invalid) == 0 {
+		return metav1.Condition{
+			Type:               GatewayDNSHostnamesValidConditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Valid",
+			Message:            "All listener hostnames can be published in DNS.",
+			ObservedGeneration: gateway.Generation,
+		}
+	}
+	var messages []string
+	for _, listener := range gateway.Spec.Listeners {
+		if err, ok := hostnames.invalid[listener.Name]; ok {
+			messages = append(messages, fmt.Sprintf("listener %q: %v", listener.Name, err))
+		}
+	}
+	return metav1.Condition{
+		Type:               GatewayDNSHostnamesValidConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             "InvalidHostname",
+		Message:            fmt.Sprintf("DNS records are not published for listeners with invalid hostnames: %s.", strings.Join(messages, "; ")),
+		ObservedGeneration: gateway.Generation,
+	}
+}
+
+// computeListenerDNSHostnameValidCondition computes the condition that
+// indicates whether the named listener's hostname can be published in DNS.
+func computeListenerDNSHostnameValidCondition(gateway *gatewayapiv1beta1.Gateway, listener gatewayapiv1beta1.SectionName, hostnames gatewayHostnames) metav1.Condition {
+	if err, ok := hostnames.invalid[listener]; ok {
+		return metav1.Condition{
+			Type:               ListenerDNSHostnameValidConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "InvalidHostname",
+			Message:            fmt.Sprintf("The DNS record is not published: %v.", err),
+			ObservedGeneration: gateway.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               ListenerDNSHostnameValidConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "The listener hostname can be published in DNS.",
+		ObservedGeneration: gateway.Generation,
+	}
+}
+
+// updateGatewayDNSConditions sets the conditions that indicate whether the
+// given gateway's listener hostnames can be published in DNS on the gateway and
+// on the statuses of its listeners, sets the given DNSTargetAvailable condition
+// on the gateway, and updates the gateway's status if the conditions changed.
+func (r *reconciler) updateGatewayDNSConditions(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, hostnames gatewayHostnames, targetCondition metav1.Condition) error {
+	updated := gateway.DeepCopy()
+	changed := meta.SetStatusCondition(&updated.Status.Conditions, computeGatewayDNSHostnamesValidCondition(gateway, hostnames))
+	if meta.SetStatusCondition(&updated.Status.Conditions, targetCondition) {
+		changed = true
+	}
+	// The gateway controller adds listener statuses, so only set conditions
+	// on the ones that already exist.
+	for i := range updated.Status.Listeners {
+		listener := &updated.Status.Listeners[i]
+		if meta.SetStatusCondition(&listener.Conditions, computeListenerDNSHostnameValidCondition(gateway, listener.Name, hostnames)) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update status of gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	log.Info("updated gateway dns conditions", "namespace", gateway.Namespace, "name", gateway.Name)
+	return nil
+}
@@ -0,0 +1,168 @@
+package gateway_service_dns
+
+import (
+	"strings"
+	"testing"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_validateGatewayHostname(t *testing.T) {
+	maxLabel := strings.Repeat("a", 63)
+	tests := []struct {
+		name        string
+		hostname    string
+		expectError string
+	}{
+		{
+			name:     "simple hostname",
+			hostname: "www.example.com",
+		},
+		{
+			name:     "hostname with a trailing dot",
+			hostname: "www.example.com.",
+		},
+		{
+			name:     "wildcard hostname",
+			hostname: "*.apps.example.com",
+		},
+		{
+			name:     "punycode hostname",
+			hostname: "xn--bcher-kva.example.com",
+		},
+		{
+			name:     "wildcard punycode hostname",
+			hostname: "*.xn--mnchen-3ya.example.com",
+		},
+		{
+			name:     "maximum-length label",
+			hostname: maxLabel + ".example.com",
+		},
+		{
+			name:     "maximum-length hostname",
+			hostname: strings.Join([]string{maxLabel, maxLabel, maxLabel, strings.Repeat("b", 61)}, "."),
+		},
+		{
+			name:        "overlong label",
+			hostname:    maxLabel + "a.example.com",
+			expectError: "is 64 characters long, which exceeds the maximum of 63",
+		},
+		{
+			name:        "overlong hostname",
+			hostname:    strings.Join([]string{maxLabel, maxLabel, maxLabel, strings.Repeat("b", 62)}, "."),
+			expectError: "is 254 characters long, which exceeds the maximum of 253",
+		},
+		{
+			name:        "non-ASCII hostname",
+			hostname:    "bücher.example.com",
+			expectError: "must use the punycode (xn--) form",
+		},
+		{
+			name:        "invalid punycode label",
+			hostname:    "xn--a.example.com",
+			expectError: "is not a valid punycode label",
+		},
+		{
+			name:        "upper-case hostname",
+			hostname:    "WWW.example.com",
+			expectError: "may contain only lower-case letters, digits, and hyphens",
+		},
+		{
+			name:        "label with a leading hyphen",
+			hostname:    "-www.example.com",
+			expectError: "must start and end with a letter or digit",
+		},
+		{
+			name:        "empty label",
+			hostname:    "www..example.com",
+			expectError: "empty label",
+		},
+		{
+			name:        "wildcard that is not the first label",
+			hostname:    "www.*.example.com",
+			expectError: `contains the character '*'`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateGatewayHostname(tc.hostname)
+			if tc.expectError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expectError)
+			}
+		})
+	}
+}
+
+func Test_getGatewayHostnames(t *testing.T) {
+	l := func(name, hostname string) gatewayapiv1beta1.Listener {
+		h := gatewayapiv1beta1.Hostname(hostname)
+		return gatewayapiv1beta1.Listener{
+			Name:     gatewayapiv1beta1.SectionName(name),
+			Hostname: &h,
+		}
+	}
+	gateway := &gatewayapiv1beta1.Gateway{
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			Listeners: []gatewayapiv1beta1.Listener{
+				l("http", "*.example.com"),
+				l("https", "*.example.com."),
+				l("idn", "xn--bcher-kva.example.com"),
+				l("long", strings.Repeat("a", 64)+".example.com"),
+			},
+		},
+		Status: gatewayapiv1beta1.GatewayStatus{
+			Listeners: []gatewayapiv1beta1.ListenerStatus{
+				{Name: "http"},
+				{Name: "long"},
+			},
+		},
+	}
+	hostnames := getGatewayHostnames(gateway)
+	assert.Equal(t, []string{"*.example.com.", "xn--bcher-kva.example.com."}, hostnames.valid.List())
+	assert.Len(t, hostnames.invalid, 1)
+	assert.Contains(t, hostnames.invalid, gatewayapiv1beta1.SectionName("long"))
+
+	cond := computeGatewayDNSHostnamesValidCondition(gateway, hostnames)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, `listener "long"`)
+
+	assert.Equal(t, metav1.ConditionTrue, computeListenerDNSHostnameValidCondition(gateway, "http", hostnames).Status)
+	assert.Equal(t, metav1.ConditionFalse, computeListenerDNSHostnameValidCondition(gateway, "long", hostnames).Status)
+}
+
+// Test_getGatewayHostnames_protocols verifies that getGatewayHostnames returns
+// the hostnames of listeners of any protocol so that the controller publishes
+// DNS records for TLS and UDP listeners as well as for HTTP listeners.
+func Test_getGatewayHostnames_protocols(t *testing.T) {
+	l := func(name, hostname string, protocol gatewayapiv1beta1.ProtocolType, port gatewayapiv1beta1.PortNumber) gatewayapiv1beta1.Listener {
+		h := gatewayapiv1beta1.Hostname(hostname)
+		return gatewayapiv1beta1.Listener{
+			Name:     gatewayapiv1beta1.SectionName(name),
+			Hostname: &h,
+			Protocol: protocol,
+			Port:     port,
+		}
+	}
+	passthroughMode := gatewayapiv1beta1.TLSModePassthrough
+	passthrough := l("passthrough", "secure.example.com", gatewayapiv1beta1.TLSProtocolType, 443)
+	passthrough.TLS = &gatewayapiv1beta1.GatewayTLSConfig{Mode: &passthroughMode}
+	gateway := &gatewayapiv1beta1.Gateway{
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			Listeners: []gatewayapiv1beta1.Listener{
+				l("http", "www.example.com", gatewayapiv1beta1.HTTPProtocolType, 80),
+				passthrough,
+				l("dns", "dns.example.com", gatewayapiv1beta1.UDPProtocolType, 53),
+				l("syslog", "syslog.example.com", gatewayapiv1beta1.UDPProtocolType, 514),
+			},
+		},
+	}
+	hostnames := getGatewayHostnames(gateway)
+	assert.Equal(t, []string{"dns.example.com.", "secure.example.com.", "syslog.example.com.", "www.example.com."}, hostnames.valid.List())
+	assert.Empty(t, hostnames.invalid)
+}
@@ -0,0 +1,256 @@
+package gateway_service_dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GatewayEndpointPublishingStrategyAnnotation is an annotation that
+	// can be set on a gateway to specify how the gateway's service is
+	// published.  The value must be "LoadBalancerService" (the default) or
+	// "NodePortService", which have the same meaning as the corresponding
+	// endpoint publishing strategies of an ingresscontroller.  The
+	// operator renders the value into the annotation with which Istio
+	// selects the type of the service that it creates for the gateway.
+	// If the annotation is absent, the operator leaves Istio's annotation
+	// alone.
+	GatewayEndpointPublishingStrategyAnnotation = "ingress.operator.openshift.io/endpoint-publishing-strategy"
+
+	// GatewayNodePortDNSPolicyAnnotation is an annotation that can be set
+	// on a gateway that uses the "NodePortService" endpoint publishing
+	// strategy to specify whether the operator publishes DNS records for
+	// the gateway.  The value must be "None" (the default), in which case
+	// the operator publishes no DNS records, or "NodeAddresses", in which
+	// case the operator publishes DNS records that point to the host
+	// addresses of the nodes that run ready gateway pods.  Clients must
+	// then connect to the service's node ports.
+	GatewayNodePortDNSPolicyAnnotation = "ingress.operator.openshift.io/nodeport-dns-policy"
+
+	// NodePortDNSPolicyNone specifies that the operator publishes no DNS
+	// records for a gateway that uses a NodePort service.
+	NodePortDNSPolicyNone = "None"
+	// NodePortDNSPolicyNodeAddresses specifies that the operator publishes
+	// DNS records that point to the host addresses of the gateway's pods
+	// for a gateway that uses a NodePort service.
+	NodePortDNSPolicyNodeAddresses = "NodeAddresses"
+
+	// istioServiceTypeAnnotation is the annotation on a gateway with which
+	// Istio selects the type of the service that it creates for the
+	// gateway.
+	istioServiceTypeAnnotation = "networking.istio.io/service-type"
+)
+
+// gatewayPublishing describes how a gateway's service is published and whether
+// DNS records are published for it.
+type gatewayPublishing struct {
+	// ServiceType is the desired type of the gateway's service, or empty
+	// if the gateway does not specify the endpoint publishing strategy.
+	ServiceType corev1.ServiceType
+	// NodePortDNSPolicy is the DNS policy for a gateway whose service type
+	// is NodePort.
+	NodePortDNSPolicy string
+}
+
+// publishingForGateway returns the publishing configuration that the given
+// gateway's annotations specify.  An error is returned if an annotation has an
+// invalid value.
+func publishingForGateway(gateway *gatewayapiv1beta1.Gateway) (gatewayPublishing, error) {
+	publishing := gatewayPublishing{NodePortDNSPolicy: NodePortDNSPolicyNone}
+	strategy, ok := gateway.Annotations[GatewayEndpointPublishingStrategyAnnotation]
+	switch {
+	case !ok:
+	case strategy == string(operatorv1.LoadBalancerServiceStrategyType):
+		publishing.ServiceType = corev1.ServiceTypeLoadBalancer
+	case strategy == string(operatorv1.NodePortServiceStrategyType):
+		publishing.ServiceType = corev1.ServiceTypeNodePort
+	default:
+		return publishing, fmt.Errorf("annotation %s has invalid value %q; must be %q or %q", GatewayEndpointPublishingStrategyAnnotation, strategy, operatorv1.LoadBalancerServiceStrategyType, operatorv1.NodePortServiceStrategyType)
+	}
+	switch policy, ok := gateway.Annotations[GatewayNodePortDNSPolicyAnnotation]; {
+	case !ok:
+	case policy == NodePortDNSPolicyNone, policy == NodePortDNSPolicyNodeAddresses:
+		publishing.NodePortDNSPolicy = policy
+	default:
+		return publishing, fmt.Errorf("annotation %s has invalid value %q; must be %q or %q", GatewayNodePortDNSPolicyAnnotation, policy, NodePortDNSPolicyNone, NodePortDNSPolicyNodeAddresses)
+	}
+	return publishing, nil
+}
+
+// usesNodePortService returns a Boolean value indicating whether the given
+// publishing configuration publishes the gateway using a NodePort service.
+func (p gatewayPublishing) usesNodePortService() bool {
+	return p.ServiceType == corev1.ServiceTypeNodePort
+}
+
+// publishesDNSRecords returns a Boolean value indicating whether the given
+// publishing configuration has the operator publish DNS records for the
+// gateway.  It is false only for a gateway that uses a NodePort service without
+// the "NodeAddresses" DNS policy.
+func (p gatewayPublishing) publishesDNSRecords() bool {
+	return !p.usesNodePortService() || p.NodePortDNSPolicy == NodePortDNSPolicyNodeAddresses
+}
+
+// desiredGatewayAnnotations returns the annotations that the given gateway
+// should have for Istio to create a service of the type that the given
+// publishing configuration specifies, and a Boolean value indicating whether
+// they differ from the gateway's current annotations.
+func desiredGatewayAnnotations(gateway *gatewayapiv1beta1.Gateway, publishing gatewayPublishing) (map[string]string, bool) {
+	if len(publishing.ServiceType) == 0 || gateway.Annotations[istioServiceTypeAnnotation] == string(publishing.ServiceType) {
+		return gateway.Annotations, false
+	}
+	annotations := make(map[string]string, len(gateway.Annotations)+1)
+	for k, v := range gateway.Annotations {
+		annotations[k] = v
+	}
+	annotations[istioServiceTypeAnnotation] = string(publishing.ServiceType)
+	return annotations, true
+}
+
+// ensureGatewayAnnotations ensures that the given gateway has the annotation
+// with which Istio selects the service type that the given publishing
+// configuration specifies and, if the gateway specifies a drain period, the
+// annotation with which Istio configures Envoy's drain duration.  Istio then
+// updates the gateway's service and deployment.
+func (r *reconciler) ensureGatewayAnnotations(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, publishing gatewayPublishing) error {
+	annotations, serviceTypeChanged := desiredGatewayAnnotations(gateway, publishing)
+	drainChanged := false
+	period, specified, err := drainPeriodForGateway(gateway)
+	if err != nil {
+		r.recorder.Event(gateway, corev1.EventTypeWarning, "InvalidDrainPeriod", err.Error())
+	}
+	if specified {
+		annotations, drainChanged, err = desiredProxyConfigAnnotations(annotations, period)
+		if err != nil {
+			r.recorder.Event(gateway, corev1.EventTypeWarning, "InvalidProxyConfig", err.Error())
+		}
+	}
+	if !serviceTypeChanged && !drainChanged {
+		return nil
+	}
+	updated := gateway.DeepCopy()
+	updated.Annotations = annotations
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update annotations of gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	if serviceTypeChanged {
+		r.recorder.Eventf(gateway, corev1.EventTypeNormal, "UpdatedServiceType", "Set the service type to %s", publishing.ServiceType)
+		log.Info("set gateway service type", "namespace", gateway.Namespace, "name", gateway.Name, "type", publishing.ServiceType)
+	}
+	if drainChanged {
+		r.recorder.Eventf(gateway, corev1.EventTypeNormal, "UpdatedDrainDuration", "Set the Envoy drain duration to %s", period)
+		log.Info("set gateway drain duration", "namespace", gateway.Namespace, "name", gateway.Name, "period", period)
+	}
+	// Keep the in-memory gateway current so that a subsequent status
+	// update does not conflict.
+	gateway.ObjectMeta = updated.ObjectMeta
+	return nil
+}
+
+// readyPodHostAddresses returns the sorted, unique host IP addresses of the
+// ready pods among the given pods.
+func readyPodHostAddresses(pods []corev1.Pod) []string {
+	addresses := sets.New[string]()
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || len(pod.Status.HostIP) == 0 {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				addresses.Insert(pod.Status.HostIP)
+				break
+			}
+		}
+	}
+	return sets.List(addresses)
+}
+
+// dnsTargetService returns the service whose load-balancer ingress entries are
+// the targets of the given gateway's DNS records, and the gateway's
+// DNSTargetAvailable condition.  For a gateway that uses a load balancer
+// service, this is the gateway's service.  For a gateway that uses a NodePort
+// service and the "NodeAddresses" DNS policy, it is a copy of the gateway's
+// service with the host addresses of the gateway's ready pods as its ingress
+// entries.  The returned service is nil if the condition is false.
+func (r *reconciler) dnsTargetService(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, service *corev1.Service, publishing gatewayPublishing) (*corev1.Service, metav1.Condition, error) {
+	if !publishing.usesNodePortService() {
+		condition := computeGatewayDNSTargetAvailableCondition(gateway, service)
+		if condition.Status != metav1.ConditionTrue {
+			return nil, condition, nil
+		}
+		return service, condition, nil
+	}
+	var addresses []string
+	if publishing.NodePortDNSPolicy == NodePortDNSPolicyNodeAddresses && service.Spec.Type == corev1.ServiceTypeNodePort {
+		var pods corev1.PodList
+		listOpts := []client.ListOption{
+			client.MatchingLabels{gatewayNameLabelKey: gateway.Name},
+			client.InNamespace(r.config.OperandNamespace),
+		}
+		if err := r.cache.List(ctx, &pods, listOpts...); err != nil {
+			return nil, metav1.Condition{}, fmt.Errorf("failed to list pods for gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+		}
+		addresses = readyPodHostAddresses(pods.Items)
+	}
+	condition := computeNodePortDNSTargetAvailableCondition(gateway, service, publishing, addresses)
+	if condition.Status != metav1.ConditionTrue {
+		return nil, condition, nil
+	}
+	target := service.DeepCopy()
+	target.Status.LoadBalancer.Ingress = nil
+	for _, address := range addresses {
+		target.Status.LoadBalancer.Ingress = append(target.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: address})
+	}
+	return target, condition, nil
+}
+
+// computeNodePortDNSTargetAvailableCondition computes the DNSTargetAvailable
+// condition for a gateway that uses a NodePort service, given the host
+// addresses of the gateway's ready pods.
+func computeNodePortDNSTargetAvailableCondition(gateway *gatewayapiv1beta1.Gateway, service *corev1.Service, publishing gatewayPublishing, addresses []string) metav1.Condition {
+	switch {
+	case publishing.NodePortDNSPolicy != NodePortDNSPolicyNodeAddresses:
+		return metav1.Condition{
+			Type:               GatewayDNSTargetAvailableConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NodePortDNSDisabled",
+			Message:            fmt.Sprintf("The gateway uses a NodePort service and its %s annotation is not %q, so no DNS records are published.", GatewayNodePortDNSPolicyAnnotation, NodePortDNSPolicyNodeAddresses),
+			ObservedGeneration: gateway.Generation,
+		}
+	case service.Spec.Type != corev1.ServiceTypeNodePort:
+		return metav1.Condition{
+			Type:               GatewayDNSTargetAvailableConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "WaitingForNodePortService",
+			Message:            fmt.Sprintf("Service %s/%s has type %s.  DNS records will be published once it has type NodePort.", service.Namespace, service.Name, service.Spec.Type),
+			ObservedGeneration: gateway.Generation,
+		}
+	case len(addresses) == 0:
+		return metav1.Condition{
+			Type:               GatewayDNSTargetAvailableConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "WaitingForServingNodes",
+			Message:            "The gateway has no ready pods.  DNS records will be published once a pod is ready.",
+			ObservedGeneration: gateway.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               GatewayDNSTargetAvailableConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "NodeAddressesAvailable",
+		Message:            fmt.Sprintf("DNS records point to the addresses of the nodes that run the gateway's pods: %s.", strings.Join(addresses, ", ")),
+		ObservedGeneration: gateway.Generation,
+	}
+}
@@ -0,0 +1,267 @@
+package gateway_service_dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	configv1 "github.com/openshift/api/config/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	utilclock "k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func Test_publishingForGateway(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expect      gatewayPublishing
+		expectError bool
+	}{
+		{
+			name:   "no annotations",
+			expect: gatewayPublishing{NodePortDNSPolicy: NodePortDNSPolicyNone},
+		},
+		{
+			name:        "load balancer",
+			annotations: map[string]string{GatewayEndpointPublishingStrategyAnnotation: "LoadBalancerService"},
+			expect:      gatewayPublishing{ServiceType: corev1.ServiceTypeLoadBalancer, NodePortDNSPolicy: NodePortDNSPolicyNone},
+		},
+		{
+			name:        "node port",
+			annotations: map[string]string{GatewayEndpointPublishingStrategyAnnotation: "NodePortService"},
+			expect:      gatewayPublishing{ServiceType: corev1.ServiceTypeNodePort, NodePortDNSPolicy: NodePortDNSPolicyNone},
+		},
+		{
+			name: "node port with node addresses",
+			annotations: map[string]string{
+				GatewayEndpointPublishingStrategyAnnotation: "NodePortService",
+				GatewayNodePortDNSPolicyAnnotation:          "NodeAddresses",
+			},
+			expect: gatewayPublishing{ServiceType: corev1.ServiceTypeNodePort, NodePortDNSPolicy: NodePortDNSPolicyNodeAddresses},
+		},
+		{
+			name:        "invalid strategy",
+			annotations: map[string]string{GatewayEndpointPublishingStrategyAnnotation: "HostNetwork"},
+			expectError: true,
+		},
+		{
+			name: "invalid DNS policy",
+			annotations: map[string]string{
+				GatewayEndpointPublishingStrategyAnnotation: "NodePortService",
+				GatewayNodePortDNSPolicyAnnotation:          "Nodes",
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &gatewayapiv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			actual, err := publishingForGateway(gateway)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case err == nil:
+				assert.Equal(t, tc.expect, actual)
+			}
+		})
+	}
+}
+
+func Test_desiredGatewayAnnotations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		publishing    gatewayPublishing
+		expect        map[string]string
+		expectChanged bool
+	}{
+		{
+			name:          "unspecified strategy leaves the service type alone",
+			annotations:   map[string]string{istioServiceTypeAnnotation: "ClusterIP"},
+			publishing:    gatewayPublishing{},
+			expect:        map[string]string{istioServiceTypeAnnotation: "ClusterIP"},
+			expectChanged: false,
+		},
+		{
+			name:          "node port is set",
+			annotations:   map[string]string{"foo": "bar"},
+			publishing:    gatewayPublishing{ServiceType: corev1.ServiceTypeNodePort},
+			expect:        map[string]string{"foo": "bar", istioServiceTypeAnnotation: "NodePort"},
+			expectChanged: true,
+		},
+		{
+			name:          "node port is already set",
+			annotations:   map[string]string{istioServiceTypeAnnotation: "NodePort"},
+			publishing:    gatewayPublishing{ServiceType: corev1.ServiceTypeNodePort},
+			expect:        map[string]string{istioServiceTypeAnnotation: "NodePort"},
+			expectChanged: false,
+		},
+		{
+			name:          "load balancer replaces node port",
+			annotations:   map[string]string{istioServiceTypeAnnotation: "NodePort"},
+			publishing:    gatewayPublishing{ServiceType: corev1.ServiceTypeLoadBalancer},
+			expect:        map[string]string{istioServiceTypeAnnotation: "LoadBalancer"},
+			expectChanged: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &gatewayapiv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			original := gateway.DeepCopy()
+			actual, changed := desiredGatewayAnnotations(gateway, tc.publishing)
+			assert.Equal(t, tc.expectChanged, changed)
+			assert.Equal(t, tc.expect, actual)
+			assert.Equal(t, original, gateway, "the gateway must not be mutated")
+		})
+	}
+}
+
+// Test_Reconcile_nodePortPublishing verifies that the controller sets the
+// service type of a gateway that uses the NodePortService endpoint publishing
+// strategy, publishes DNS records that point to the nodes that run the
+// gateway's ready pods when the DNS policy is NodeAddresses, and deletes them
+// when the DNS policy is None.
+func Test_Reconcile_nodePortPublishing(t *testing.T) {
+	hostname := gatewayapiv1beta1.Hostname("*.stage.example.com")
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway",
+			Annotations: map[string]string{
+				GatewayEndpointPublishingStrategyAnnotation: "NodePortService",
+				GatewayNodePortDNSPolicyAnnotation:          "NodeAddresses",
+			},
+		},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			Listeners: []gatewayapiv1beta1.Listener{{Name: "http", Hostname: &hostname, Port: 80}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "example-gateway",
+			Labels: map[string]string{
+				"gateway.istio.io/managed": "example-gateway",
+				"istio.io/gateway-name":    "example-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"istio.io/gateway-name": "example-gateway"},
+		},
+	}
+	pod := func(name, hostIP string, ready bool) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      name,
+				Labels:    map[string]string{"istio.io/gateway-name": "example-gateway"},
+			},
+			Status: corev1.PodStatus{
+				HostIP:     hostIP,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+	scheme := runtime.NewScheme()
+	iov1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(
+			&configv1.DNS{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.DNSSpec{BaseDomain: "example.com"}},
+			&configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Status: configv1.InfrastructureStatus{PlatformStatus: &configv1.PlatformStatus{Type: configv1.NonePlatformType}}},
+			gateway,
+			service,
+			pod("gateway-1", "10.0.0.2", true),
+			pod("gateway-2", "10.0.0.1", true),
+			pod("gateway-3", "10.0.0.3", false),
+		).
+		WithStatusSubresource(&gatewayapiv1beta1.Gateway{}, &corev1.Service{}).
+		Build()
+	informer := informertest.FakeInformers{Scheme: scheme}
+	reconciler := &reconciler{
+		config:   Config{OperandNamespace: "openshift-ingress"},
+		cache:    fakeCache{Informers: &informer, Reader: cl},
+		client:   cl,
+		recorder: record.NewFakeRecorder(10),
+		clock:    utilclock.RealClock{},
+	}
+	gatewayName := types.NamespacedName{Namespace: "openshift-ingress", Name: "example-gateway"}
+	request := reconcile.Request{NamespacedName: gatewayName}
+
+	expect := func(description string, expectTargets []string, expectReason string) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		var records iov1.DNSRecordList
+		if err := cl.List(context.Background(), &records, client.InNamespace("openshift-ingress")); err != nil {
+			t.Fatalf("%s: failed to list dnsrecords: %v", description, err)
+		}
+		var targets []string
+		for i := range records.Items {
+			if records.Items[i].DeletionTimestamp != nil {
+				records.Items[i].Finalizers = nil
+				if err := cl.Update(context.Background(), &records.Items[i]); err != nil {
+					t.Fatalf("%s: failed to remove finalizers from dnsrecord: %v", description, err)
+				}
+				continue
+			}
+			assert.Equal(t, iov1.ARecordType, records.Items[i].Spec.RecordType, description)
+			targets = append(targets, records.Items[i].Spec.Targets...)
+		}
+		assert.Equal(t, expectTargets, targets, description)
+
+		var current gatewayapiv1beta1.Gateway
+		if err := cl.Get(context.Background(), gatewayName, &current); err != nil {
+			t.Fatalf("%s: failed to get gateway: %v", description, err)
+		}
+		assert.Equal(t, "NodePort", current.Annotations[istioServiceTypeAnnotation], description)
+		cond := meta.FindStatusCondition(current.Status.Conditions, GatewayDNSTargetAvailableConditionType)
+		if cond == nil {
+			t.Fatalf("%s: expected gateway to have a %s condition", description, GatewayDNSTargetAvailableConditionType)
+		}
+		assert.Equal(t, expectReason, cond.Reason, description)
+	}
+
+	// Istio has not yet changed the service's type.
+	expect("load balancer service", nil, "WaitingForNodePortService")
+
+	service.Spec.Type = corev1.ServiceTypeNodePort
+	if err := cl.Update(context.Background(), service); err != nil {
+		t.Fatalf("failed to update service: %v", err)
+	}
+	expect("node port service", []string{"10.0.0.1", "10.0.0.2"}, "NodeAddressesAvailable")
+
+	if err := cl.Get(context.Background(), gatewayName, gateway); err != nil {
+		t.Fatalf("failed to get gateway: %v", err)
+	}
+	gateway.Annotations[GatewayNodePortDNSPolicyAnnotation] = NodePortDNSPolicyNone
+	if err := cl.Update(context.Background(), gateway); err != nil {
+		t.Fatalf("failed to update gateway: %v", err)
+	}
+	expect("DNS disabled", nil, "NodePortDNSDisabled")
+}
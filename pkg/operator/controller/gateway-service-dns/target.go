@@ -0,0 +1,139 @@
+package gateway_service_dns
+
+import (
+	"context"
+	"fmt"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GatewayDNSTargetAvailableConditionType is the type of a condition
+	// that the operator sets on a gateway to indicate whether the
+	// gateway's service has a load balancer address to which the
+	// gateway's DNS records can point.  The operator does not publish or
+	// update DNS records for the gateway while the condition is false, but
+	// it keeps the records that it already published.
+	GatewayDNSTargetAvailableConditionType = "ingress.operator.openshift.io/DNSTargetAvailable"
+)
+
+// serviceHasLoadBalancerAddress returns a Boolean value indicating whether the
+// given service has at least one load balancer ingress with an IP address or a
+// hostname.
+func serviceHasLoadBalancerAddress(service *corev1.Service) bool {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) != 0 || len(ingress.Hostname) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// computeGatewayDNSTargetAvailableCondition computes the condition that
+// indicates whether the given gateway's service has a load balancer address.
+// The service is nil if the gateway has no service.
+func computeGatewayDNSTargetAvailableCondition(gateway *gatewayapiv1beta1.Gateway, service *corev1.Service) metav1.Condition {
+	switch {
+	case service == nil:
+		return metav1.Condition{
+			Type:               GatewayDNSTargetAvailableConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ServiceNotFound",
+			Message:            "The gateway has no service.  DNS records will be published once the service is created and has a load balancer address.",
+			ObservedGeneration: gateway.Generation,
+		}
+	case !serviceHasLoadBalancerAddress(service):
+		return metav1.Condition{
+			Type:               GatewayDNSTargetAvailableConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "WaitingForLoadBalancer",
+			Message:            fmt.Sprintf("Service %s/%s has no load balancer address.  DNS records will be published once the load balancer is provisioned.", service.Namespace, service.Name),
+			ObservedGeneration: gateway.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               GatewayDNSTargetAvailableConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "LoadBalancerProvisioned",
+		Message:            fmt.Sprintf("Service %s/%s has a load balancer address.", service.Namespace, service.Name),
+		ObservedGeneration: gateway.Generation,
+	}
+}
+
+// recordDNSTargetTransition emits an event on the given gateway if the given
+// condition changes the status of the gateway's current DNSTargetAvailable
+// condition.
+func (r *reconciler) recordDNSTargetTransition(gateway *gatewayapiv1beta1.Gateway, condition metav1.Condition) {
+	current := meta.FindStatusCondition(gateway.Status.Conditions, GatewayDNSTargetAvailableConditionType)
+	if current != nil && current.Status == condition.Status && current.Reason == condition.Reason {
+		return
+	}
+	if condition.Status == metav1.ConditionTrue && current == nil {
+		// Publishing the records is the expected outcome, so only
+		// report it when the gateway was waiting.
+		return
+	}
+	r.recorder.Event(gateway, corev1.EventTypeNormal, condition.Reason, condition.Message)
+}
+
+// reconcileGatewaysWithoutService sets the DNSTargetAvailable condition to false
+// and deletes the dnsrecords of any gateway in the operand namespace that has
+// the condition and no longer has a service.  It also finalizes any gateway
+// that is marked for deletion.  reconcileGatewaysWithoutService is called when
+// a service is deleted, which may leave the gateway that the service belonged
+// to without a service, or when a gateway without a service changes.
+func (r *reconciler) reconcileGatewaysWithoutService(ctx context.Context) error {
+	var gateways gatewayapiv1beta1.GatewayList
+	if err := r.cache.List(ctx, &gateways, client.InNamespace(r.config.OperandNamespace)); err != nil {
+		return fmt.Errorf("failed to list gateways: %w", err)
+	}
+	var errs []error
+	for i := range gateways.Items {
+		gateway := &gateways.Items[i]
+		if gateway.DeletionTimestamp != nil {
+			errs = append(errs, r.finalizeGateway(ctx, gateway))
+			continue
+		}
+		// Only gateways for which the controller has published DNS
+		// records have the condition.
+		if meta.FindStatusCondition(gateway.Status.Conditions, GatewayDNSTargetAvailableConditionType) == nil {
+			continue
+		}
+		var services corev1.ServiceList
+		listOpts := []client.ListOption{
+			client.MatchingLabels{gatewayNameLabelKey: gateway.Name},
+			client.InNamespace(r.config.OperandNamespace),
+		}
+		if err := r.cache.List(ctx, &services, listOpts...); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list services for gateway %s/%s: %w", gateway.Namespace, gateway.Name, err))
+			continue
+		}
+		if len(services.Items) != 0 {
+			continue
+		}
+		log.Info("gateway has no service; deleting its dnsrecords", "namespace", gateway.Namespace, "name", gateway.Name)
+		staleErrs := r.deleteStaleDNSRecordsForGateway(ctx, gateway, nil, sets.NewString())
+		errs = append(errs, staleErrs...)
+		if utilerrors.NewAggregate(staleErrs) == nil {
+			errs = append(errs, r.releaseGatewayDNSFinalizer(ctx, gateway))
+		}
+		condition := computeGatewayDNSTargetAvailableCondition(gateway, nil)
+		r.recordDNSTargetTransition(gateway, condition)
+		updated := gateway.DeepCopy()
+		if meta.SetStatusCondition(&updated.Status.Conditions, condition) {
+			if err := r.client.Status().Update(ctx, updated); err != nil {
+				errs = append(errs, fmt.Errorf("failed to update status of gateway %s/%s: %w", gateway.Namespace, gateway.Name, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -59,11 +59,79 @@ func Test_Reconcile(t *testing.T) {
 				crd("gateways.gateway.networking.k8s.io"),
 				crd("httproutes.gateway.networking.k8s.io"),
 				crd("referencegrants.gateway.networking.k8s.io"),
+				crd("backendtlspolicies.gateway.networking.k8s.io"),
+				crd("tlsroutes.gateway.networking.k8s.io"),
 			},
 			expectUpdate:    []client.Object{},
 			expectDelete:    []client.Object{},
 			expectStartCtrl: true,
 		},
+		{
+			name:              "gateway API enabled, backendtlspolicies CRD from a newer release",
+			gatewayAPIEnabled: true,
+			existingObjects: []runtime.Object{
+				&apiextensionsv1.CustomResourceDefinition{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "backendtlspolicies.gateway.networking.k8s.io",
+						Annotations: map[string]string{
+							"gateway.networking.k8s.io/bundle-version": "v1.1.0",
+							"gateway.networking.k8s.io/channel":        "experimental",
+						},
+					},
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Group: "gateway.networking.k8s.io",
+						Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+							{Name: "v1alpha3", Served: true, Storage: true},
+						},
+					},
+					Status: apiextensionsv1.CustomResourceDefinitionStatus{
+						StoredVersions: []string{"v1alpha3"},
+					},
+				},
+			},
+			expectCreate: []client.Object{
+				crd("gatewayclasses.gateway.networking.k8s.io"),
+				crd("gateways.gateway.networking.k8s.io"),
+				crd("httproutes.gateway.networking.k8s.io"),
+				crd("referencegrants.gateway.networking.k8s.io"),
+				crd("tlsroutes.gateway.networking.k8s.io"),
+			},
+			expectUpdate:    []client.Object{},
+			expectDelete:    []client.Object{},
+			expectStartCtrl: true,
+		},
+		{
+			name:              "gateway API enabled, outdated backendtlspolicies CRD",
+			gatewayAPIEnabled: true,
+			existingObjects: []runtime.Object{
+				&apiextensionsv1.CustomResourceDefinition{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "backendtlspolicies.gateway.networking.k8s.io",
+					},
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Group: "gateway.networking.k8s.io",
+						Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+							{Name: "v1alpha2", Served: true, Storage: true},
+						},
+					},
+					Status: apiextensionsv1.CustomResourceDefinitionStatus{
+						StoredVersions: []string{"v1alpha2"},
+					},
+				},
+			},
+			expectCreate: []client.Object{
+				crd("gatewayclasses.gateway.networking.k8s.io"),
+				crd("gateways.gateway.networking.k8s.io"),
+				crd("httproutes.gateway.networking.k8s.io"),
+				crd("referencegrants.gateway.networking.k8s.io"),
+				crd("tlsroutes.gateway.networking.k8s.io"),
+			},
+			expectUpdate: []client.Object{
+				crd("backendtlspolicies.gateway.networking.k8s.io"),
+			},
+			expectDelete:    []client.Object{},
+			expectStartCtrl: true,
+		},
 	}
 
 	scheme := runtime.NewScheme()
@@ -104,9 +172,9 @@ func Test_Reconcile(t *testing.T) {
 			assert.Equal(t, ctrl.started, tc.expectStartCtrl, "fake controller should have been started")
 			cmpOpts := []cmp.Option{
 				cmpopts.EquateEmpty(),
-				cmpopts.IgnoreFields(metav1.ObjectMeta{}, "Annotations", "ResourceVersion"),
+				cmpopts.IgnoreFields(metav1.ObjectMeta{}, "Annotations", "Labels", "ResourceVersion"),
 				cmpopts.IgnoreFields(metav1.TypeMeta{}, "Kind", "APIVersion"),
-				cmpopts.IgnoreFields(apiextensionsv1.CustomResourceDefinition{}, "Spec"),
+				cmpopts.IgnoreFields(apiextensionsv1.CustomResourceDefinition{}, "Spec", "Status"),
 			}
 			if diff := cmp.Diff(tc.expectCreate, cl.added, cmpOpts...); diff != "" {
 				t.Fatalf("found diff between expected and actual creates: %s", diff)
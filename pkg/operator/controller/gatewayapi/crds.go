@@ -16,12 +16,32 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
+const (
+	// bundleVersionAnnotation is the annotation on Gateway API CRDs that
+	// specifies the Gateway API release of the CRD.
+	bundleVersionAnnotation = "gateway.networking.k8s.io/bundle-version"
+	// channelAnnotation is the annotation on Gateway API CRDs that
+	// specifies the release channel ("standard" or "experimental") of the
+	// CRD.
+	channelAnnotation = "gateway.networking.k8s.io/channel"
+)
+
 // managedCRDs is a list of CRDs that this controller manages.
+//
+// The BackendTLSPolicy and TLSRoute CRDs are only available in the
+// experimental channel of Gateway API, whereas the other CRDs are from the
+// standard channel of the v0.6.2 release.  The CRDs' bundle-version and channel
+// annotations record where each one comes from.
+//
+// TODO: Replace the TLSRoute CRD, which is from a v0.6.0 development build, with
+// the experimental-channel CRD from the v0.6.2 release.
 var managedCRDs = []*apiextensionsv1.CustomResourceDefinition{
 	manifests.GatewayClassCRD(),
 	manifests.GatewayCRD(),
 	manifests.HTTPRouteCRD(),
 	manifests.ReferenceGrantCRD(),
+	manifests.BackendTLSPolicyCRD(),
+	manifests.TLSRouteCRD(),
 }
 
 // ensureCRD attempts to ensure that the specified CRD exists and returns a
@@ -55,7 +75,7 @@ func (r *reconciler) ensureCRD(ctx context.Context, desired *apiextensionsv1.Cus
 
 // ensureGatewayAPICRDs ensures the managed Gateway API CRDs are created and
 // returns an error value.  For now, the managed CRDs are the GatewayClass,
-// Gateway, HTTPRoute, and ReferenceGrant CRDs.
+// Gateway, HTTPRoute, ReferenceGrant, BackendTLSPolicy, and TLSRoute CRDs.
 func (r *reconciler) ensureGatewayAPICRDs(ctx context.Context) error {
 	var errs []error
 	for i := range managedCRDs {
@@ -96,6 +116,16 @@ func (r *reconciler) createCRD(ctx context.Context, desired *apiextensionsv1.Cus
 // updateCRD updates an CRD.  Returns a Boolean indicating
 // whether the CRD was updated, and an error value.
 func (r *reconciler) updateCRD(ctx context.Context, current, desired *apiextensionsv1.CustomResourceDefinition) (bool, error) {
+	// If the CRD was installed by someone else from a different release
+	// or channel, objects may be stored in versions that the desired CRD
+	// does not have.  The API rejects removing such versions, and
+	// replacing the CRD would break whatever installed it anyway, so leave
+	// the CRD alone.
+	if versions := droppedStoredVersions(current, desired); len(versions) != 0 {
+		log.Info("not updating CRD because it has stored versions that the desired CRD does not have", "name", current.Name, "versions", versions, "bundleVersion", current.Annotations[bundleVersionAnnotation], "channel", current.Annotations[channelAnnotation])
+		return false, nil
+	}
+
 	changed, updated := crdChanged(current, desired)
 	if !changed {
 		return false, nil
@@ -110,6 +140,25 @@ func (r *reconciler) updateCRD(ctx context.Context, current, desired *apiextensi
 	return true, nil
 }
 
+// droppedStoredVersions returns the versions in the current CRD's
+// status.storedVersions that the desired CRD does not have.
+func droppedStoredVersions(current, desired *apiextensionsv1.CustomResourceDefinition) []string {
+	var dropped []string
+	for _, stored := range current.Status.StoredVersions {
+		found := false
+		for _, version := range desired.Spec.Versions {
+			if version.Name == stored {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dropped = append(dropped, stored)
+		}
+	}
+	return dropped
+}
+
 // crdChanged checks if the current CRD spec matches
 // the expected spec and if not returns an updated one.
 func crdChanged(current, expected *apiextensionsv1.CustomResourceDefinition) (bool, *apiextensionsv1.CustomResourceDefinition) {
@@ -0,0 +1,225 @@
+package gatewayclass
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// accessLogEncodingKey is the key in the gatewayclass's parameters
+	// configmap that enables access logging for gateways and specifies
+	// the encoding of access log entries, which must be "Text" or "JSON".
+	// If the key is absent, gateways log to stdout using Envoy's default
+	// format, as they always have.
+	accessLogEncodingKey = "accessLogEncoding"
+	// accessLogTargetKey is the key in the gatewayclass's parameters
+	// configmap that specifies where access log entries are sent, which
+	// must be "Stdout" (the default), "OTLP", or "None" to disable access
+	// logging.
+	accessLogTargetKey = "accessLogTarget"
+	// accessLogOTLPEndpointKey is the key in the gatewayclass's parameters
+	// configmap that specifies the "host:port" address of the
+	// OpenTelemetry collector to which access log entries are sent when
+	// the target is "OTLP".
+	accessLogOTLPEndpointKey = "accessLogOTLPEndpoint"
+
+	accessLogEncodingText = "TEXT"
+	accessLogEncodingJSON = "JSON"
+
+	accessLogTargetStdout = "stdout"
+	accessLogTargetOTLP   = "otlp"
+	accessLogTargetNone   = "none"
+
+	// accessLogOTLPProviderName is the name of the extension provider that
+	// the operator configures in the servicemeshcontrolplane for sending
+	// access log entries to an OpenTelemetry collector.
+	accessLogOTLPProviderName = "openshift-gateway-access-log"
+)
+
+// accessLoggingConfig describes the access logging configuration for gateway
+// workloads.
+type accessLoggingConfig struct {
+	// Encoding is the encoding of access log entries, either "TEXT" or
+	// "JSON".
+	Encoding string
+	// Target is where access log entries are sent, either "stdout" or
+	// "otlp", or "none" if access logging is disabled.
+	Target string
+	// OTLPService and OTLPPort specify the OpenTelemetry collector when
+	// Target is "otlp".
+	OTLPService string
+	OTLPPort    int64
+}
+
+// isConfigMapReference returns a Boolean value indicating whether the given
+// parameters reference refers to a configmap.
+func isConfigMapReference(ref *gatewayapiv1beta1.ParametersReference) bool {
+	return ref != nil && len(ref.Group) == 0 && ref.Kind == "ConfigMap"
+}
+
+// currentAccessLoggingConfig returns the access logging configuration that the
+// given gatewayclass's parameters specify, or nil if the gatewayclass does not
+// configure access logging.  The parameters must be a configmap in the operator
+// namespace.
+func (r *reconciler) currentAccessLoggingConfig(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (*accessLoggingConfig, error) {
+	cm, err := r.parametersConfigMap(ctx, gatewayclass)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+	config, err := accessLoggingConfigForConfigMap(cm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters configmap %s/%s for gatewayclass %s: %w", cm.Namespace, cm.Name, gatewayclass.Name, err)
+	}
+	return config, nil
+}
+
+// parametersConfigMap returns the configmap that the given gatewayclass's
+// parameters reference, or nil if the gatewayclass has no parameters.  The
+// parameters must be a configmap in the operator namespace.
+func (r *reconciler) parametersConfigMap(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (*corev1.ConfigMap, error) {
+	ref := gatewayclass.Spec.ParametersRef
+	if ref == nil {
+		return nil, nil
+	}
+	if !isConfigMapReference(ref) {
+		return nil, fmt.Errorf("gatewayclass %s has unsupported parametersRef with group %q and kind %q; only configmaps are supported", gatewayclass.Name, ref.Group, ref.Kind)
+	}
+	if ref.Namespace == nil || string(*ref.Namespace) != r.config.OperatorNamespace {
+		return nil, fmt.Errorf("gatewayclass %s has parametersRef to configmap %s outside of namespace %s", gatewayclass.Name, ref.Name, r.config.OperatorNamespace)
+	}
+	name := types.NamespacedName{Namespace: r.config.OperatorNamespace, Name: ref.Name}
+	var cm corev1.ConfigMap
+	if err := r.cache.Get(ctx, name, &cm); err != nil {
+		return nil, fmt.Errorf("failed to get parameters configmap %s for gatewayclass %s: %w", name, gatewayclass.Name, err)
+	}
+	return &cm, nil
+}
+
+// accessLoggingConfigForConfigMap parses and validates the access logging
+// configuration in the given configmap.  It returns nil if the configmap does
+// not configure access logging.
+func accessLoggingConfigForConfigMap(cm *corev1.ConfigMap) (*accessLoggingConfig, error) {
+	if strings.ToLower(cm.Data[accessLogTargetKey]) == accessLogTargetNone {
+		return &accessLoggingConfig{Target: accessLogTargetNone}, nil
+	}
+	encoding, ok := cm.Data[accessLogEncodingKey]
+	if !ok {
+		return nil, nil
+	}
+	config := &accessLoggingConfig{
+		Encoding: strings.ToUpper(encoding),
+		Target:   accessLogTargetStdout,
+	}
+	switch config.Encoding {
+	case accessLogEncodingText, accessLogEncodingJSON:
+	default:
+		return nil, fmt.Errorf("invalid %s value %q: must be %q or %q", accessLogEncodingKey, encoding, "Text", "JSON")
+	}
+
+	if target, ok := cm.Data[accessLogTargetKey]; ok {
+		config.Target = strings.ToLower(target)
+	}
+	switch config.Target {
+	case accessLogTargetStdout:
+	case accessLogTargetOTLP:
+		endpoint := cm.Data[accessLogOTLPEndpointKey]
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil || len(host) == 0 {
+			return nil, fmt.Errorf("invalid %s value %q: must be of the form host:port", accessLogOTLPEndpointKey, endpoint)
+		}
+		portNumber, err := strconv.ParseInt(port, 10, 32)
+		if err != nil || portNumber < 1 || portNumber > 65535 {
+			return nil, fmt.Errorf("invalid %s value %q: invalid port %q", accessLogOTLPEndpointKey, endpoint, port)
+		}
+		config.OTLPService = host
+		config.OTLPPort = portNumber
+	default:
+		return nil, fmt.Errorf("invalid %s value %q: must be %q, %q, or %q", accessLogTargetKey, cm.Data[accessLogTargetKey], "Stdout", "OTLP", "None")
+	}
+	return config, nil
+}
+
+// setAccessLoggingConfig renders the given access logging configuration into
+// the given servicemeshcontrolplane spec.  If config is nil, gateways log to
+// stdout using Envoy's default format, which is what the operator has always
+// configured.  If the target is "none", the spec is left without any access
+// logging configuration.
+func setAccessLoggingConfig(spec *maistrav2.ControlPlaneSpec, techPreview map[string]interface{}, config *accessLoggingConfig) {
+	if config == nil {
+		config = &accessLoggingConfig{Target: accessLogTargetStdout}
+	}
+	switch config.Target {
+	case accessLogTargetStdout:
+		t := true
+		spec.Proxy = &maistrav2.ProxyConfig{
+			AccessLogging: &maistrav2.ProxyAccessLoggingConfig{
+				EnvoyService: &maistrav2.ProxyEnvoyServiceConfig{
+					Enablement: maistrav2.Enablement{
+						Enabled: &t,
+					},
+				},
+				File: &maistrav2.ProxyFileAccessLogConfig{
+					Name:     "/dev/stdout",
+					Encoding: config.Encoding,
+				},
+			},
+		}
+	case accessLogTargetOTLP:
+		spec.MeshConfig = &maistrav2.MeshConfig{
+			ExtensionProviders: []*maistrav2.ExtensionProviderConfig{{
+				Name: accessLogOTLPProviderName,
+				EnvoyOtelAls: &maistrav2.ExtensionProviderEnvoyOtelLogConfig{
+					Service: config.OTLPService,
+					Port:    config.OTLPPort,
+				},
+			}},
+		}
+		// The SMCP API has no field for the mesh-wide default
+		// providers, so enable the provider through the tech preview
+		// values, which are passed through to the mesh config.
+		techPreview["meshConfig"] = map[string]interface{}{
+			"defaultProviders": map[string]interface{}{
+				"accessLogging": []interface{}{accessLogOTLPProviderName},
+			},
+		}
+	}
+}
+
+// configMapToGatewayClasses maps a configmap to the gatewayclasses that refer
+// to it as their parameters.
+func (r *reconciler) configMapToGatewayClasses(ctx context.Context, o client.Object) []reconcile.Request {
+	var classes gatewayapiv1beta1.GatewayClassList
+	if err := r.cache.List(ctx, &classes); err != nil {
+		log.Error(err, "failed to list gatewayclasses for configmap", "namespace", o.GetNamespace(), "name", o.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if class.Spec.ControllerName != OpenShiftGatewayClassControllerName {
+			continue
+		}
+		ref := class.Spec.ParametersRef
+		if !isConfigMapReference(ref) || ref.Name != o.GetName() {
+			continue
+		}
+		if ref.Namespace == nil || string(*ref.Namespace) != o.GetNamespace() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: class.Name}})
+	}
+	return requests
+}
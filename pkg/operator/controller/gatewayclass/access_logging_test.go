@@ -0,0 +1,205 @@
+package gatewayclass
+
+import (
+	"reflect"
+	"testing"
+
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test_accessLoggingConfigForConfigMap verifies that
+// accessLoggingConfigForConfigMap parses valid access logging parameters and
+// rejects invalid ones.
+func Test_accessLoggingConfigForConfigMap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        map[string]string
+		expect      *accessLoggingConfig
+		expectError bool
+	}{
+		{
+			name:   "no parameters",
+			data:   nil,
+			expect: nil,
+		},
+		{
+			name: "JSON to stdout by default",
+			data: map[string]string{"accessLogEncoding": "JSON"},
+			expect: &accessLoggingConfig{
+				Encoding: "JSON",
+				Target:   "stdout",
+			},
+		},
+		{
+			name: "text to stdout",
+			data: map[string]string{"accessLogEncoding": "Text", "accessLogTarget": "Stdout"},
+			expect: &accessLoggingConfig{
+				Encoding: "TEXT",
+				Target:   "stdout",
+			},
+		},
+		{
+			name: "OTLP with endpoint",
+			data: map[string]string{"accessLogEncoding": "JSON", "accessLogTarget": "OTLP", "accessLogOTLPEndpoint": "otel-collector.observability.svc:4317"},
+			expect: &accessLoggingConfig{
+				Encoding:    "JSON",
+				Target:      "otlp",
+				OTLPService: "otel-collector.observability.svc",
+				OTLPPort:    4317,
+			},
+		},
+		{
+			name:   "disabled",
+			data:   map[string]string{"accessLogTarget": "None"},
+			expect: &accessLoggingConfig{Target: "none"},
+		},
+		{
+			name:        "invalid encoding",
+			data:        map[string]string{"accessLogEncoding": "yaml"},
+			expectError: true,
+		},
+		{
+			name:        "invalid target",
+			data:        map[string]string{"accessLogEncoding": "JSON", "accessLogTarget": "syslog"},
+			expectError: true,
+		},
+		{
+			name:        "OTLP without endpoint",
+			data:        map[string]string{"accessLogEncoding": "JSON", "accessLogTarget": "OTLP"},
+			expectError: true,
+		},
+		{
+			name:        "OTLP with invalid port",
+			data:        map[string]string{"accessLogEncoding": "JSON", "accessLogTarget": "OTLP", "accessLogOTLPEndpoint": "collector:http"},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: tc.data}
+			switch actual, err := accessLoggingConfigForConfigMap(cm); {
+			case err == nil && tc.expectError:
+				t.Fatalf("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case !reflect.DeepEqual(actual, tc.expect):
+				t.Errorf("expected %+v, got %+v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_desiredServiceMeshControlPlane_accessLogging verifies that
+// desiredServiceMeshControlPlane renders the access logging configuration,
+// that gateways log to stdout when no access logging parameters are set, and
+// that disabling access logging removes the configuration.
+func Test_desiredServiceMeshControlPlane_accessLogging(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress", Name: "openshift-gateway"}
+	ownerRef := metav1.OwnerReference{Name: "openshift-default"}
+	stdout := &accessLoggingConfig{Encoding: "JSON", Target: "stdout"}
+	otlp := &accessLoggingConfig{Encoding: "TEXT", Target: "otlp", OTLPService: "collector.example.svc", OTLPPort: 4317}
+	none := &accessLoggingConfig{Target: "none"}
+
+	// Without parameters, gateways must keep logging to stdout as they
+	// did before access logging became configurable.
+	t.Run("default", func(t *testing.T) {
+		smcp, err := desiredServiceMeshControlPlane(name, ownerRef, defaultControlPlaneVersion, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enabled := true
+		expected := &maistrav2.ProxyConfig{
+			AccessLogging: &maistrav2.ProxyAccessLoggingConfig{
+				EnvoyService: &maistrav2.ProxyEnvoyServiceConfig{
+					Enablement: maistrav2.Enablement{Enabled: &enabled},
+				},
+				File: &maistrav2.ProxyFileAccessLogConfig{Name: "/dev/stdout"},
+			},
+		}
+		if !reflect.DeepEqual(smcp.Spec.Proxy, expected) {
+			t.Errorf("expected proxy %+v, got %+v", expected, smcp.Spec.Proxy)
+		}
+		if smcp.Spec.MeshConfig != nil {
+			t.Errorf("expected no meshConfig, got %+v", smcp.Spec.MeshConfig)
+		}
+	})
+
+	disabled, err := desiredServiceMeshControlPlane(name, ownerRef, defaultControlPlaneVersion, none)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.Spec.Proxy != nil || disabled.Spec.MeshConfig != nil {
+		t.Errorf("expected no access logging configuration, got proxy %+v and meshConfig %+v", disabled.Spec.Proxy, disabled.Spec.MeshConfig)
+	}
+	if _, found, _ := disabled.Spec.TechPreview.GetFieldNoCopy("meshConfig"); found {
+		t.Errorf("expected no techPreview meshConfig")
+	}
+
+	enabled, err := desiredServiceMeshControlPlane(name, ownerRef, defaultControlPlaneVersion, stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFile := &maistrav2.ProxyFileAccessLogConfig{Name: "/dev/stdout", Encoding: "JSON"}
+	if enabled.Spec.Proxy == nil || enabled.Spec.Proxy.AccessLogging == nil || !reflect.DeepEqual(enabled.Spec.Proxy.AccessLogging.File, expectedFile) {
+		t.Errorf("expected access log file %+v, got proxy %+v", expectedFile, enabled.Spec.Proxy)
+	}
+
+	toOTLP, err := desiredServiceMeshControlPlane(name, ownerRef, defaultControlPlaneVersion, otlp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toOTLP.Spec.Proxy != nil {
+		t.Errorf("expected no proxy access log file for OTLP target, got %+v", toOTLP.Spec.Proxy)
+	}
+	if toOTLP.Spec.MeshConfig == nil || len(toOTLP.Spec.MeshConfig.ExtensionProviders) != 1 {
+		t.Fatalf("expected one extension provider, got meshConfig %+v", toOTLP.Spec.MeshConfig)
+	}
+	provider := toOTLP.Spec.MeshConfig.ExtensionProviders[0]
+	if provider.EnvoyOtelAls == nil || provider.EnvoyOtelAls.Service != "collector.example.svc" || provider.EnvoyOtelAls.Port != 4317 {
+		t.Errorf("unexpected extension provider %+v", provider)
+	}
+	providers, found, err := toOTLP.Spec.TechPreview.GetStringSlice("meshConfig.defaultProviders.accessLogging")
+	if err != nil || !found || !reflect.DeepEqual(providers, []string{accessLogOTLPProviderName}) {
+		t.Errorf("expected default access logging providers %v, got %v (found: %t, err: %v)", []string{accessLogOTLPProviderName}, providers, found, err)
+	}
+
+	// Disabling access logging must remove the configuration from the
+	// current servicemeshcontrolplane.
+	for _, current := range []*maistrav2.ServiceMeshControlPlane{enabled, toOTLP} {
+		changed, updated := serviceMeshControlPlaneChanged(current, disabled)
+		if !changed {
+			t.Fatalf("expected disabling access logging to change the servicemeshcontrolplane")
+		}
+		if updated.Spec.Proxy != nil && updated.Spec.Proxy.AccessLogging != nil {
+			t.Errorf("expected proxy access logging to be removed, got %+v", updated.Spec.Proxy.AccessLogging)
+		}
+		if updated.Spec.MeshConfig != nil && len(updated.Spec.MeshConfig.ExtensionProviders) != 0 {
+			t.Errorf("expected extension providers to be removed, got %+v", updated.Spec.MeshConfig.ExtensionProviders)
+		}
+		if _, found, _ := updated.Spec.TechPreview.GetFieldNoCopy("meshConfig"); found {
+			t.Errorf("expected techPreview meshConfig to be removed")
+		}
+	}
+
+	// Changing access logging must preserve proxy and mesh settings that
+	// the operator does not manage.
+	current := enabled.DeepCopy()
+	concurrency := int32(2)
+	current.Spec.Proxy.Concurrency = &concurrency
+	custom := &maistrav2.ExtensionProviderConfig{Name: "custom-authz"}
+	current.Spec.MeshConfig = &maistrav2.MeshConfig{ExtensionProviders: []*maistrav2.ExtensionProviderConfig{custom}}
+	changed, updated := serviceMeshControlPlaneChanged(current, toOTLP)
+	if !changed {
+		t.Fatalf("expected switching to OTLP to change the servicemeshcontrolplane")
+	}
+	if updated.Spec.Proxy == nil || !reflect.DeepEqual(updated.Spec.Proxy.Concurrency, &concurrency) || updated.Spec.Proxy.AccessLogging != nil {
+		t.Errorf("expected proxy concurrency to be preserved and access logging to be removed, got %+v", updated.Spec.Proxy)
+	}
+	if updated.Spec.MeshConfig == nil || len(updated.Spec.MeshConfig.ExtensionProviders) != 2 || !reflect.DeepEqual(updated.Spec.MeshConfig.ExtensionProviders[0], custom) || updated.Spec.MeshConfig.ExtensionProviders[1].Name != accessLogOTLPProviderName {
+		t.Errorf("expected the custom extension provider to be preserved alongside the access log provider, got %+v", updated.Spec.MeshConfig)
+	}
+}
@@ -3,17 +3,24 @@ package gatewayclass
 import (
 	"context"
 
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
 
 	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -61,6 +68,40 @@ func NewUnmanaged(mgr manager.Manager, config Config) (controller.Controller, er
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &gatewayapiv1beta1.GatewayClass{}, &handler.EnqueueRequestForObject{}, isOurGatewayClass, predicate.Not(isIstioGatewayClass))); err != nil {
 		return nil, err
 	}
+	// Watch configmaps in the operator namespace so that changes to a
+	// gatewayclass's parameters are reconciled.
+	inOperatorNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == config.OperatorNamespace
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(reconciler.configMapToGatewayClasses), inOperatorNamespace)); err != nil {
+		return nil, err
+	}
+	// Watch gateways so that deletion of a gatewayclass can proceed once
+	// its last gateway is deleted and so that new gateways get the status
+	// condition that reports on their dependencies.
+	gatewayClassNameChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			old := e.ObjectOld.(*gatewayapiv1beta1.Gateway).Spec.GatewayClassName
+			new := e.ObjectNew.(*gatewayapiv1beta1.Gateway).Spec.GatewayClassName
+			return old != new
+		},
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &gatewayapiv1beta1.Gateway{}, handler.EnqueueRequestsFromMapFunc(gatewayToGatewayClass), gatewayClassNameChanged)); err != nil {
+		return nil, err
+	}
+	// Watch the servicemeshcontrolplanes so that they are recreated if they
+	// are deleted out-of-band, so that out-of-band changes to the fields
+	// that the operator manages are reverted, so that gateways' status
+	// reflects their readiness, and so that a control plane migration
+	// proceeds once the new revision is ready.
+	scheme := mgr.GetClient().Scheme()
+	mapper := mgr.GetClient().RESTMapper()
+	isOurServiceMeshControlPlane := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return isServiceMeshControlPlaneRevisionName(config.OperandNamespace, types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()})
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &maistrav2.ServiceMeshControlPlane{}, handler.EnqueueRequestForOwner(scheme, mapper, &gatewayapiv1beta1.GatewayClass{}), isOurServiceMeshControlPlane)); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -84,21 +125,112 @@ type reconciler struct {
 }
 
 // Reconcile expects request to refer to a GatewayClass and creates or
-// reconciles an Istio deployment.
+// reconciles an Istio deployment.  It also protects the gatewayclass from
+// deletion while gateways reference it, recreates the servicemeshcontrolplane
+// if it is deleted out-of-band, and migrates gateways to a new Istio revision
+// when the control plane version changes.
 func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	log.Info("reconciling", "request", request)
 
 	var gatewayclass gatewayapiv1beta1.GatewayClass
 	if err := r.cache.Get(ctx, request.NamespacedName, &gatewayclass); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("gatewayclass not found; reconciliation will be skipped", "request", request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	// Gateways can reference any gatewayclass, so requests from the
+	// gateway watch may refer to gatewayclasses that are not ours.
+	if gatewayclass.Spec.ControllerName != OpenShiftGatewayClassControllerName {
+		return reconcile.Result{}, nil
+	}
+
+	gateways, err := r.gatewaysForGatewayClass(ctx, gatewayclass.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if gatewayclass.DeletionTimestamp != nil {
+		return r.reconcileGatewayClassDeletion(ctx, &gatewayclass, gateways)
+	}
+
+	// If the gatewayclass already has the finalizer, the operator has
+	// previously created the servicemeshcontrolplane, so a missing
+	// servicemeshcontrolplane means that it was deleted out-of-band.
+	reconciledBefore := slice.ContainsString(gatewayclass.Finalizers, gatewayClassProtectionFinalizer)
+	if err := r.ensureGatewayClassFinalizer(ctx, &gatewayclass); err != nil {
 		return reconcile.Result{}, err
 	}
 
 	var errs []error
-	if _, _, err := r.ensureServiceMeshOperatorSubscription(ctx); err != nil {
+	// Leave the subscription as it is if the gatewayclasses' subscription
+	// parameters are invalid or conflict or if the catalog source does not
+	// exist, and report why on the gatewayclass.
+	subscriptionConfig, subscriptionErr := r.currentSubscriptionConfig(ctx, &gatewayclass)
+	if subscriptionErr == nil {
+		_, _, subscriptionErr = r.ensureServiceMeshOperatorSubscription(ctx, subscriptionConfig)
+	}
+	if subscriptionErr != nil {
+		r.recorder.Eventf(&gatewayclass, corev1.EventTypeWarning, "SubscriptionUnavailable", "%v", subscriptionErr)
+		errs = append(errs, subscriptionErr)
+	}
+	if err := r.updateGatewayClassCondition(ctx, gatewayclass.Name, computeGatewayClassSubscriptionAvailableCondition(subscriptionConfig, subscriptionErr)); err != nil {
+		errs = append(errs, err)
+	}
+	smcps, err := r.currentServiceMeshControlPlanes(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// Leave the servicemeshcontrolplanes and the gateways' revisions as
+	// they are if the control plane parameters are invalid or conflict.
+	controlPlane, err := r.currentControlPlaneConfig(ctx, &gatewayclass)
+	if err != nil {
+		r.recorder.Eventf(&gatewayclass, corev1.EventTypeWarning, "InvalidParameters", "%v", err)
+		errs = append(errs, err)
+		controlPlane = frozenControlPlaneConfig(smcps)
+	}
+	revisions := selectControlPlaneRevisions(r.config.OperandNamespace, controlPlane, smcps)
+	_, smcp, err := r.currentServiceMeshControlPlane(ctx, revisions.target)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// Leave the servicemeshcontrolplane as it is if the gatewayclass's
+	// parameters are invalid rather than dropping configuration that the
+	// parameters previously enabled.
+	if accessLogging, err := r.currentAccessLoggingConfig(ctx, &gatewayclass); err != nil {
+		r.recorder.Eventf(&gatewayclass, corev1.EventTypeWarning, "InvalidParameters", "%v", err)
+		errs = append(errs, err)
+	} else if _, current, err := r.ensureServiceMeshControlPlane(ctx, &gatewayclass, revisions.target, revisions.targetVersion, accessLogging); err != nil {
+		errs = append(errs, err)
+	} else {
+		smcp = current
+	}
+	// A servicemeshcontrolplane for a new control plane version is created
+	// by a migration, so only the absence of any servicemeshcontrolplane
+	// means that it was deleted out-of-band.
+	recreated := reconciledBefore && len(smcps) == 0 && smcp != nil
+	if recreated {
+		r.recorder.Eventf(&gatewayclass, corev1.EventTypeWarning, "ServiceMeshControlPlaneRecreated", "ServiceMeshControlPlane %s was deleted and has been recreated; gateways that use this gatewayclass may be disrupted until it is ready", revisions.target)
+	}
+
+	// Gateways keep using a previous revision until the target revision
+	// is ready, so report on the previous revision's readiness until
+	// then.
+	dependency := smcp
+	if len(revisions.previous) != 0 && !serviceMeshControlPlaneReady(smcp) {
+		dependency = &revisions.previous[0]
+	}
+	condition := computeGatewayDependenciesAvailableCondition(&gatewayclass, dependency, recreated)
+	if err := r.updateGatewayConditions(ctx, gateways, condition); err != nil {
 		errs = append(errs, err)
 	}
-	if _, _, err := r.ensureServiceMeshControlPlane(ctx, &gatewayclass); err != nil {
+
+	// List the gateways again because updating their status changed them.
+	var result reconcile.Result
+	if managed, err := r.managedGateways(ctx); err != nil {
+		errs = append(errs, err)
+	} else if result, err = r.reconcileControlPlaneMigration(ctx, &gatewayclass, controlPlane, revisions, smcp, managed); err != nil {
 		errs = append(errs, err)
 	}
-	return reconcile.Result{}, utilerrors.NewAggregate(errs)
+	return result, utilerrors.NewAggregate(errs)
 }
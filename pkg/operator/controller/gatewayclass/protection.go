@@ -0,0 +1,191 @@
+package gatewayclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// gatewayClassProtectionFinalizer is the finalizer that the operator
+	// adds to its gatewayclasses so that it can block their deletion while
+	// gateways still reference them.
+	gatewayClassProtectionFinalizer = "ingress.operator.openshift.io/gatewayclass-protection"
+
+	// ForceDeleteAnnotation is the annotation that a cluster admin can set
+	// to "true" on a gatewayclass to allow its deletion to proceed even
+	// though gateways still reference it.
+	ForceDeleteAnnotation = "ingress.operator.openshift.io/force-delete"
+
+	// GatewayDependenciesAvailableConditionType is the type of the
+	// condition that the operator sets on gateways that reference its
+	// gatewayclasses to report whether the gatewayclass and the
+	// servicemeshcontrolplane on which the gateway depends are available.
+	GatewayDependenciesAvailableConditionType = "ingress.operator.openshift.io/DependenciesAvailable"
+
+	// gatewayClassDeletionRetryPeriod is how often the operator checks
+	// whether a gatewayclass whose deletion is blocked is still referenced
+	// by any gateways.
+	gatewayClassDeletionRetryPeriod = 30 * time.Second
+)
+
+// gatewaysForGatewayClass returns the gateways in all namespaces that
+// reference the given gatewayclass.  The operator's cache only covers a few
+// namespaces, so this uses the client.
+func (r *reconciler) gatewaysForGatewayClass(ctx context.Context, name string) ([]gatewayapiv1beta1.Gateway, error) {
+	var gateways gatewayapiv1beta1.GatewayList
+	if err := r.client.List(ctx, &gateways); err != nil {
+		return nil, fmt.Errorf("failed to list gateways: %w", err)
+	}
+	var dependents []gatewayapiv1beta1.Gateway
+	for i := range gateways.Items {
+		if string(gateways.Items[i].Spec.GatewayClassName) == name {
+			dependents = append(dependents, gateways.Items[i])
+		}
+	}
+	return dependents, nil
+}
+
+// ensureGatewayClassFinalizer adds the protection finalizer to the given
+// gatewayclass if it does not already have it.
+func (r *reconciler) ensureGatewayClassFinalizer(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) error {
+	if slice.ContainsString(gatewayclass.Finalizers, gatewayClassProtectionFinalizer) {
+		return nil
+	}
+	updated := gatewayclass.DeepCopy()
+	updated.Finalizers = append(updated.Finalizers, gatewayClassProtectionFinalizer)
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to add finalizer to gatewayclass %s: %w", gatewayclass.Name, err)
+	}
+	log.Info("added finalizer to gatewayclass", "name", gatewayclass.Name, "finalizer", gatewayClassProtectionFinalizer)
+	return nil
+}
+
+// reconcileGatewayClassDeletion handles a gatewayclass that is marked for
+// deletion.  The protection finalizer is removed only if no gateways reference
+// the gatewayclass or if the gatewayclass has the force-delete annotation;
+// otherwise the deletion is blocked, and an event and the status of the
+// dependent gateways explain why.
+func (r *reconciler) reconcileGatewayClassDeletion(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass, gateways []gatewayapiv1beta1.Gateway) (reconcile.Result, error) {
+	if !slice.ContainsString(gatewayclass.Finalizers, gatewayClassProtectionFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	if len(gateways) != 0 {
+		if gatewayclass.Annotations[ForceDeleteAnnotation] != "true" {
+			r.recorder.Eventf(gatewayclass, corev1.EventTypeWarning, "DeletionBlocked", "GatewayClass deletion is blocked while gateways reference it: %s; delete the gateways or set the %s=true annotation on the gatewayclass to force its deletion", gatewayNames(gateways), ForceDeleteAnnotation)
+			condition := computeGatewayDependenciesAvailableCondition(gatewayclass, nil, false)
+			if err := r.updateGatewayConditions(ctx, gateways, condition); err != nil {
+				return reconcile.Result{}, err
+			}
+			// Gateways in namespaces outside of the operator's
+			// cache do not trigger reconciliation, so poll for
+			// their removal.
+			return reconcile.Result{RequeueAfter: gatewayClassDeletionRetryPeriod}, nil
+		}
+		r.recorder.Eventf(gatewayclass, corev1.EventTypeWarning, "ForcedDeletion", "GatewayClass is being deleted even though gateways reference it: %s", gatewayNames(gateways))
+	}
+
+	updated := gatewayclass.DeepCopy()
+	updated.Finalizers = slice.RemoveString(updated.Finalizers, gatewayClassProtectionFinalizer)
+	if err := r.client.Update(ctx, updated); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to remove finalizer from gatewayclass %s: %w", gatewayclass.Name, err)
+	}
+	log.Info("removed finalizer from gatewayclass", "name", gatewayclass.Name, "finalizer", gatewayClassProtectionFinalizer)
+	return reconcile.Result{}, nil
+}
+
+// gatewayNames returns a sorted, comma-separated list of the namespaced names
+// of the given gateways.
+func gatewayNames(gateways []gatewayapiv1beta1.Gateway) string {
+	names := make([]string, 0, len(gateways))
+	for i := range gateways {
+		names = append(names, types.NamespacedName{Namespace: gateways[i].Namespace, Name: gateways[i].Name}.String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// computeGatewayDependenciesAvailableCondition computes the condition that
+// reports whether the dependencies of the gateways that reference the given
+// gatewayclass are available.  The smcp argument is the current
+// servicemeshcontrolplane, or nil if there is none, and recreated indicates
+// whether the operator just recreated the servicemeshcontrolplane after it was
+// deleted.
+func computeGatewayDependenciesAvailableCondition(gatewayclass *gatewayapiv1beta1.GatewayClass, smcp *maistrav2.ServiceMeshControlPlane, recreated bool) metav1.Condition {
+	condition := metav1.Condition{Type: GatewayDependenciesAvailableConditionType}
+	switch {
+	case gatewayclass.DeletionTimestamp != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "GatewayClassDeleting"
+		condition.Message = fmt.Sprintf("GatewayClass %q is being deleted.  Its deletion is blocked while this gateway references it unless the gatewayclass has the %s=true annotation.", gatewayclass.Name, ForceDeleteAnnotation)
+	case smcp == nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ServiceMeshControlPlaneMissing"
+		condition.Message = "The ServiceMeshControlPlane does not exist."
+	case recreated:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ServiceMeshControlPlaneRecreated"
+		condition.Message = fmt.Sprintf("ServiceMeshControlPlane %s/%s was deleted and has been recreated.  The gateway may not be programmed until the control plane is ready.", smcp.Namespace, smcp.Name)
+	default:
+		ready := smcp.Status.GetCondition(maistrastatus.ConditionTypeReady)
+		if ready.Status != maistrastatus.ConditionStatusTrue {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "ServiceMeshControlPlaneNotReady"
+			condition.Message = fmt.Sprintf("ServiceMeshControlPlane %s/%s is not ready: %s", smcp.Namespace, smcp.Name, ready.Message)
+		} else {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "DependenciesAvailable"
+			condition.Message = fmt.Sprintf("GatewayClass %q and ServiceMeshControlPlane %s/%s are available.", gatewayclass.Name, smcp.Namespace, smcp.Name)
+		}
+	}
+	return condition
+}
+
+// updateGatewayConditions sets the given condition on each of the given
+// gateways and updates the status of any gateway whose condition changed.
+func (r *reconciler) updateGatewayConditions(ctx context.Context, gateways []gatewayapiv1beta1.Gateway, condition metav1.Condition) error {
+	for i := range gateways {
+		updated := gateways[i].DeepCopy()
+		condition.ObservedGeneration = updated.Generation
+		if !meta.SetStatusCondition(&updated.Status.Conditions, condition) {
+			continue
+		}
+		if err := r.client.Status().Update(ctx, updated); err != nil {
+			// The gateway may have been deleted in the meantime.
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to update status of gateway %s/%s: %w", updated.Namespace, updated.Name, err)
+		}
+		log.Info("updated gateway status", "namespace", updated.Namespace, "name", updated.Name, "condition", condition.Type, "status", condition.Status, "reason", condition.Reason)
+	}
+	return nil
+}
+
+// gatewayToGatewayClass maps a gateway to the gatewayclass that it references.
+func gatewayToGatewayClass(ctx context.Context, o client.Object) []reconcile.Request {
+	gateway, ok := o.(*gatewayapiv1beta1.Gateway)
+	if !ok || len(gateway.Spec.GatewayClassName) == 0 {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}}}
+}
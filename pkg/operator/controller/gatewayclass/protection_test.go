@@ -0,0 +1,211 @@
+package gatewayclass
+
+import (
+	"context"
+	"testing"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_reconcileGatewayClassDeletion verifies that reconcileGatewayClassDeletion
+// blocks deletion of a gatewayclass while gateways reference it unless the
+// gatewayclass has the force-delete annotation, and that it reports the
+// blocked deletion on the dependent gateways.
+func Test_reconcileGatewayClassDeletion(t *testing.T) {
+	now := metav1.Now()
+	gatewayclass := func(annotations map[string]string) *gatewayapiv1beta1.GatewayClass {
+		return &gatewayapiv1beta1.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              OpenShiftDefaultGatewayClassName,
+				Annotations:       annotations,
+				Finalizers:        []string{gatewayClassProtectionFinalizer},
+				DeletionTimestamp: &now,
+			},
+			Spec: gatewayapiv1beta1.GatewayClassSpec{
+				ControllerName: OpenShiftGatewayClassControllerName,
+			},
+		}
+	}
+	gateway := &gatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "prod-gateway",
+		},
+		Spec: gatewayapiv1beta1.GatewaySpec{
+			GatewayClassName: OpenShiftDefaultGatewayClassName,
+		},
+	}
+	testCases := []struct {
+		name              string
+		gatewayclass      *gatewayapiv1beta1.GatewayClass
+		gateways          []gatewayapiv1beta1.Gateway
+		expectBlocked     bool
+		expectGatewayCond bool
+	}{
+		{
+			name:          "no gateways",
+			gatewayclass:  gatewayclass(nil),
+			expectBlocked: false,
+		},
+		{
+			name:              "gateway references the gatewayclass",
+			gatewayclass:      gatewayclass(nil),
+			gateways:          []gatewayapiv1beta1.Gateway{*gateway},
+			expectBlocked:     true,
+			expectGatewayCond: true,
+		},
+		{
+			name:          "gateway references the gatewayclass, forced",
+			gatewayclass:  gatewayclass(map[string]string{ForceDeleteAnnotation: "true"}),
+			gateways:      []gatewayapiv1beta1.Gateway{*gateway},
+			expectBlocked: false,
+		},
+		{
+			name:              "gateway references the gatewayclass, annotation not true",
+			gatewayclass:      gatewayclass(map[string]string{ForceDeleteAnnotation: "yes"}),
+			gateways:          []gatewayapiv1beta1.Gateway{*gateway},
+			expectBlocked:     true,
+			expectGatewayCond: true,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	gatewayapiv1beta1.AddToScheme(scheme)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects := []client.Object{tc.gatewayclass}
+			for i := range tc.gateways {
+				objects = append(objects, &tc.gateways[i])
+			}
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				WithStatusSubresource(&gatewayapiv1beta1.Gateway{}).
+				Build()
+			r := &reconciler{
+				client:   cl,
+				recorder: record.NewFakeRecorder(10),
+			}
+			result, err := r.reconcileGatewayClassDeletion(context.Background(), tc.gatewayclass, tc.gateways)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var current gatewayapiv1beta1.GatewayClass
+			err = cl.Get(context.Background(), types.NamespacedName{Name: tc.gatewayclass.Name}, &current)
+			switch {
+			case tc.expectBlocked && err != nil:
+				t.Fatalf("expected the gatewayclass to remain, got error: %v", err)
+			case tc.expectBlocked && result.RequeueAfter == 0:
+				t.Errorf("expected a requeue while the deletion is blocked")
+			case !tc.expectBlocked && !errors.IsNotFound(err):
+				t.Errorf("expected the gatewayclass to be deleted, got error: %v", err)
+			}
+
+			for i := range tc.gateways {
+				var gw gatewayapiv1beta1.Gateway
+				name := types.NamespacedName{Namespace: tc.gateways[i].Namespace, Name: tc.gateways[i].Name}
+				if err := cl.Get(context.Background(), name, &gw); err != nil {
+					t.Fatalf("failed to get gateway %s: %v", name, err)
+				}
+				cond := meta.FindStatusCondition(gw.Status.Conditions, GatewayDependenciesAvailableConditionType)
+				switch {
+				case tc.expectGatewayCond && cond == nil:
+					t.Errorf("expected gateway %s to have condition %s", name, GatewayDependenciesAvailableConditionType)
+				case tc.expectGatewayCond && (cond.Status != metav1.ConditionFalse || cond.Reason != "GatewayClassDeleting"):
+					t.Errorf("expected gateway %s to have condition %s=False with reason GatewayClassDeleting, got %+v", name, GatewayDependenciesAvailableConditionType, *cond)
+				case !tc.expectGatewayCond && cond != nil:
+					t.Errorf("expected gateway %s not to have condition %s, got %+v", name, GatewayDependenciesAvailableConditionType, *cond)
+				}
+			}
+		})
+	}
+}
+
+// Test_computeGatewayDependenciesAvailableCondition verifies that
+// computeGatewayDependenciesAvailableCondition reports the state of the
+// gatewayclass and the servicemeshcontrolplane.
+func Test_computeGatewayDependenciesAvailableCondition(t *testing.T) {
+	now := metav1.Now()
+	smcp := func(ready maistrastatus.ConditionStatus) *maistrav2.ServiceMeshControlPlane {
+		smcp := &maistrav2.ServiceMeshControlPlane{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      "openshift-gateway",
+			},
+		}
+		smcp.Status.SetCondition(maistrastatus.Condition{
+			Type:   maistrastatus.ConditionTypeReady,
+			Status: ready,
+		})
+		return smcp
+	}
+	testCases := []struct {
+		name         string
+		deleting     bool
+		smcp         *maistrav2.ServiceMeshControlPlane
+		recreated    bool
+		expectStatus metav1.ConditionStatus
+		expectReason string
+	}{
+		{
+			name:         "gatewayclass deleting",
+			deleting:     true,
+			smcp:         smcp(maistrastatus.ConditionStatusTrue),
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "GatewayClassDeleting",
+		},
+		{
+			name:         "smcp missing",
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "ServiceMeshControlPlaneMissing",
+		},
+		{
+			name:         "smcp recreated",
+			smcp:         smcp(maistrastatus.ConditionStatusUnknown),
+			recreated:    true,
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "ServiceMeshControlPlaneRecreated",
+		},
+		{
+			name:         "smcp not ready",
+			smcp:         smcp(maistrastatus.ConditionStatusFalse),
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "ServiceMeshControlPlaneNotReady",
+		},
+		{
+			name:         "smcp ready",
+			smcp:         smcp(maistrastatus.ConditionStatusTrue),
+			expectStatus: metav1.ConditionTrue,
+			expectReason: "DependenciesAvailable",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gatewayclass := &gatewayapiv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: OpenShiftDefaultGatewayClassName},
+			}
+			if tc.deleting {
+				gatewayclass.DeletionTimestamp = &now
+			}
+			actual := computeGatewayDependenciesAvailableCondition(gatewayclass, tc.smcp, tc.recreated)
+			if actual.Type != GatewayDependenciesAvailableConditionType || actual.Status != tc.expectStatus || actual.Reason != tc.expectReason {
+				t.Errorf("expected %s=%s with reason %s, got %+v", GatewayDependenciesAvailableConditionType, tc.expectStatus, tc.expectReason, actual)
+			}
+		})
+	}
+}
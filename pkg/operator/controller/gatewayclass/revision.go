@@ -0,0 +1,650 @@
+package gatewayclass
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ossmControlPlaneVersionKey is the key in the gatewayclass's
+	// parameters configmap that specifies the version of the Istio control
+	// plane, for example "v2.6".  The OpenShift Service Mesh operator that
+	// the subscription's channel installs must support the version.
+	ossmControlPlaneVersionKey = "ossmControlPlaneVersion"
+	// ossmControlPlaneMigrationKey is the key in the gatewayclass's
+	// parameters configmap that pauses or aborts the migration of gateways
+	// to a new control plane version.  The value must be "Pause" or
+	// "Abort".
+	ossmControlPlaneMigrationKey = "ossmControlPlaneMigration"
+
+	defaultControlPlaneVersion = "v2.5"
+
+	// controlPlaneMigrationPause holds the migration of gateways to a new
+	// control plane revision at its current step.
+	controlPlaneMigrationPause = "Pause"
+	// controlPlaneMigrationAbort migrates any gateways that were already
+	// migrated back to the previous control plane revision and then
+	// removes the new revision.
+	controlPlaneMigrationAbort = "Abort"
+
+	// istioRevisionLabelKey is the key of the label that selects the Istio
+	// revision, and thus the istiod instance, that manages a gateway.
+	// OpenShift Service Mesh uses the name of the servicemeshcontrolplane
+	// as the revision.  Istio also sets this label on the pod template of
+	// a gateway's deployment.
+	istioRevisionLabelKey = "istio.io/rev"
+	// gatewayNameLabelKey is the key of a label that Istio adds to
+	// deployments that it creates for gateways that it manages.
+	gatewayNameLabelKey = "istio.io/gateway-name"
+
+	// GatewayClassControlPlaneMigratingConditionType is the type of the
+	// condition that the operator sets on its gatewayclasses to report
+	// the progress of migrating gateways to a new control plane revision.
+	GatewayClassControlPlaneMigratingConditionType = "ingress.operator.openshift.io/ServiceMeshControlPlaneMigrating"
+	// GatewayControlPlaneRevisionConditionType is the type of the
+	// condition that the operator sets on gateways that reference its
+	// gatewayclasses to report whether the gateway uses the current control
+	// plane revision.
+	GatewayControlPlaneRevisionConditionType = "ingress.operator.openshift.io/ControlPlaneRevision"
+
+	// controlPlaneMigrationPollPeriod is how often the operator checks the
+	// progress of a control plane migration.  The operator does not watch
+	// gateway deployments because they may be in any namespace.
+	controlPlaneMigrationPollPeriod = 10 * time.Second
+)
+
+// controlPlaneVersionRegexp matches valid control plane versions.
+var controlPlaneVersionRegexp = regexp.MustCompile(`^v[0-9]+\.[0-9]+$`)
+
+// controlPlaneConfig describes the Istio control plane version and the state
+// of the migration of gateways to it.
+type controlPlaneConfig struct {
+	Version   string
+	Migration string
+}
+
+// defaultControlPlaneConfig returns the control plane configuration that the
+// operator uses unless a gatewayclass's parameters override it.
+func defaultControlPlaneConfig() controlPlaneConfig {
+	return controlPlaneConfig{Version: defaultControlPlaneVersion}
+}
+
+// controlPlaneConfigForConfigMap parses and validates the control plane
+// configuration in the given configmap.  It returns nil if the configmap does
+// not override any of the control plane's settings.
+func controlPlaneConfigForConfigMap(cm *corev1.ConfigMap) (*controlPlaneConfig, error) {
+	version, hasVersion := cm.Data[ossmControlPlaneVersionKey]
+	migration, hasMigration := cm.Data[ossmControlPlaneMigrationKey]
+	if !hasVersion && !hasMigration {
+		return nil, nil
+	}
+	config := defaultControlPlaneConfig()
+	if hasVersion {
+		if !controlPlaneVersionRegexp.MatchString(version) {
+			return nil, fmt.Errorf("invalid %s value %q: must have the form vMAJOR.MINOR, for example %q", ossmControlPlaneVersionKey, version, defaultControlPlaneVersion)
+		}
+		config.Version = version
+	}
+	if hasMigration {
+		switch migration {
+		case controlPlaneMigrationPause, controlPlaneMigrationAbort:
+		default:
+			return nil, fmt.Errorf("invalid %s value %q: must be %q or %q", ossmControlPlaneMigrationKey, migration, controlPlaneMigrationPause, controlPlaneMigrationAbort)
+		}
+		config.Migration = migration
+	}
+	return &config, nil
+}
+
+// currentControlPlaneConfig returns the control plane configuration that the
+// parameters of the operator's gatewayclasses specify.  Like the subscription,
+// the control plane is shared by all gatewayclasses, so the gatewayclasses that
+// override the configuration must agree.  An error is returned if the given
+// gatewayclass's parameters are invalid or if the gatewayclasses specify
+// conflicting configurations.
+func (r *reconciler) currentControlPlaneConfig(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (controlPlaneConfig, error) {
+	var classes gatewayapiv1beta1.GatewayClassList
+	if err := r.cache.List(ctx, &classes); err != nil {
+		return controlPlaneConfig{}, fmt.Errorf("failed to list gatewayclasses: %w", err)
+	}
+	sort.Slice(classes.Items, func(i, j int) bool {
+		return classes.Items[i].Name < classes.Items[j].Name
+	})
+	var (
+		config     *controlPlaneConfig
+		configFrom string
+	)
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if class.Spec.ControllerName != OpenShiftGatewayClassControllerName || class.DeletionTimestamp != nil {
+			continue
+		}
+		classConfig, err := r.controlPlaneConfigForGatewayClass(ctx, class)
+		if err != nil {
+			if class.Name == gatewayclass.Name {
+				return controlPlaneConfig{}, err
+			}
+			continue
+		}
+		switch {
+		case classConfig == nil:
+		case config == nil:
+			config, configFrom = classConfig, class.Name
+		case *config != *classConfig:
+			return controlPlaneConfig{}, fmt.Errorf("gatewayclasses %s and %s specify conflicting control plane parameters; the control plane is shared by all gatewayclasses, so their %s and %s parameters must agree", configFrom, class.Name, ossmControlPlaneVersionKey, ossmControlPlaneMigrationKey)
+		}
+	}
+	if config == nil {
+		return defaultControlPlaneConfig(), nil
+	}
+	return *config, nil
+}
+
+// controlPlaneConfigForGatewayClass returns the control plane configuration
+// that the given gatewayclass's parameters specify, or nil if they do not
+// override it.
+func (r *reconciler) controlPlaneConfigForGatewayClass(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (*controlPlaneConfig, error) {
+	cm, err := r.parametersConfigMap(ctx, gatewayclass)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+	config, err := controlPlaneConfigForConfigMap(cm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters configmap %s/%s for gatewayclass %s: %w", cm.Namespace, cm.Name, gatewayclass.Name, err)
+	}
+	return config, nil
+}
+
+// frozenControlPlaneConfig returns a control plane configuration that leaves
+// the given servicemeshcontrolplanes and the gateways as they are.  The
+// operator uses it when the gatewayclass's parameters are invalid.
+func frozenControlPlaneConfig(smcps []maistrav2.ServiceMeshControlPlane) controlPlaneConfig {
+	config := controlPlaneConfig{Version: defaultControlPlaneVersion, Migration: controlPlaneMigrationPause}
+	if len(smcps) != 0 && len(smcps[0].Spec.Version) != 0 {
+		config.Version = smcps[0].Spec.Version
+	}
+	return config
+}
+
+// serviceMeshControlPlaneNameForVersion returns the name of the
+// servicemeshcontrolplane for the given control plane version.  OpenShift
+// Service Mesh uses the name of a servicemeshcontrolplane as its Istio
+// revision, so each version has its own servicemeshcontrolplane, and gateways
+// can be migrated from one revision to the next without restarting all of
+// them at once.  The default version uses the name that the operator has
+// always used so that existing installations keep their revision.
+func serviceMeshControlPlaneNameForVersion(operandNamespace, version string) types.NamespacedName {
+	name := operatorcontroller.ServiceMeshControlPlaneName(operandNamespace)
+	if version != defaultControlPlaneVersion {
+		name.Name += "-" + strings.ReplaceAll(version, ".", "-")
+	}
+	return name
+}
+
+// isServiceMeshControlPlaneRevisionName returns a Boolean value indicating
+// whether the given name is the name of a servicemeshcontrolplane for some
+// control plane version in the given operand namespace.
+func isServiceMeshControlPlaneRevisionName(operandNamespace string, name types.NamespacedName) bool {
+	base := operatorcontroller.ServiceMeshControlPlaneName(operandNamespace)
+	return name.Namespace == base.Namespace && (name.Name == base.Name || strings.HasPrefix(name.Name, base.Name+"-"))
+}
+
+// currentServiceMeshControlPlanes returns the servicemeshcontrolplanes that the
+// operator created for its gatewayclasses, oldest first.
+func (r *reconciler) currentServiceMeshControlPlanes(ctx context.Context) ([]maistrav2.ServiceMeshControlPlane, error) {
+	var list maistrav2.ServiceMeshControlPlaneList
+	if err := r.cache.List(ctx, &list, client.InNamespace(r.config.OperandNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list ServiceMeshControlPlanes in namespace %s: %w", r.config.OperandNamespace, err)
+	}
+	var smcps []maistrav2.ServiceMeshControlPlane
+	for i := range list.Items {
+		smcp := &list.Items[i]
+		name := types.NamespacedName{Namespace: smcp.Namespace, Name: smcp.Name}
+		if !isServiceMeshControlPlaneRevisionName(r.config.OperandNamespace, name) || !ownedByGatewayClass(smcp.OwnerReferences) {
+			continue
+		}
+		smcps = append(smcps, *smcp)
+	}
+	sortServiceMeshControlPlanes(smcps)
+	return smcps, nil
+}
+
+// ownedByGatewayClass returns a Boolean value indicating whether the given
+// owner references include a gatewayclass.
+func ownedByGatewayClass(refs []metav1.OwnerReference) bool {
+	for _, ref := range refs {
+		if ref.APIVersion == gatewayapiv1beta1.SchemeGroupVersion.String() && ref.Kind == "GatewayClass" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortServiceMeshControlPlanes sorts the given servicemeshcontrolplanes by
+// creation time and then by name.
+func sortServiceMeshControlPlanes(smcps []maistrav2.ServiceMeshControlPlane) {
+	sort.SliceStable(smcps, func(i, j int) bool {
+		ti, tj := smcps[i].CreationTimestamp, smcps[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return smcps[i].Name < smcps[j].Name
+	})
+}
+
+// controlPlaneRevisions describes the servicemeshcontrolplanes between which
+// the operator migrates gateways.
+type controlPlaneRevisions struct {
+	// target is the name of the servicemeshcontrolplane that gateways
+	// should use.
+	target types.NamespacedName
+	// targetVersion is the control plane version that target should have.
+	targetVersion string
+	// previous are the servicemeshcontrolplanes from which gateways are
+	// migrated to target and which are removed once no gateway uses them,
+	// oldest first.
+	previous []maistrav2.ServiceMeshControlPlane
+}
+
+// selectControlPlaneRevisions determines the servicemeshcontrolplane that
+// gateways should use and the ones from which they should be migrated, given
+// the control plane configuration and the current servicemeshcontrolplanes,
+// oldest first.
+//
+// Normally, gateways use the servicemeshcontrolplane for the configured
+// version, and any other servicemeshcontrolplanes are previous revisions.  If
+// the migration is aborted, gateways instead use the oldest other
+// servicemeshcontrolplane, and the one for the configured version becomes a
+// previous revision.  If the migration is paused before the
+// servicemeshcontrolplane for the configured version has been created, the
+// oldest servicemeshcontrolplane is kept so that the migration does not start.
+func selectControlPlaneRevisions(operandNamespace string, config controlPlaneConfig, smcps []maistrav2.ServiceMeshControlPlane) controlPlaneRevisions {
+	desired := serviceMeshControlPlaneNameForVersion(operandNamespace, config.Version)
+	var (
+		others     []maistrav2.ServiceMeshControlPlane
+		hasDesired *maistrav2.ServiceMeshControlPlane
+	)
+	for i := range smcps {
+		if smcps[i].Name == desired.Name {
+			hasDesired = &smcps[i]
+			continue
+		}
+		others = append(others, smcps[i])
+	}
+	if len(others) == 0 {
+		return controlPlaneRevisions{target: desired, targetVersion: config.Version}
+	}
+	keepOldest := config.Migration == controlPlaneMigrationAbort || (config.Migration == controlPlaneMigrationPause && hasDesired == nil)
+	if !keepOldest {
+		return controlPlaneRevisions{target: desired, targetVersion: config.Version, previous: others}
+	}
+	oldest := others[0]
+	revisions := controlPlaneRevisions{
+		target:        types.NamespacedName{Namespace: oldest.Namespace, Name: oldest.Name},
+		targetVersion: oldest.Spec.Version,
+		previous:      others[1:],
+	}
+	if len(revisions.targetVersion) == 0 {
+		revisions.targetVersion = defaultControlPlaneVersion
+	}
+	if hasDesired != nil {
+		revisions.previous = append(revisions.previous, *hasDesired)
+		sortServiceMeshControlPlanes(revisions.previous)
+	}
+	return revisions
+}
+
+// serviceMeshControlPlaneReady returns a Boolean value indicating whether the
+// given servicemeshcontrolplane exists and reports that it is ready.
+func serviceMeshControlPlaneReady(smcp *maistrav2.ServiceMeshControlPlane) bool {
+	return smcp != nil && smcp.Status.GetCondition(maistrastatus.ConditionTypeReady).Status == maistrastatus.ConditionStatusTrue
+}
+
+// managedGateways returns the gateways in all namespaces that reference any of
+// the operator's gatewayclasses, sorted by namespace and name.  The control
+// plane is shared by all of the operator's gatewayclasses, so its revisions are
+// migrated for all of their gateways.
+func (r *reconciler) managedGateways(ctx context.Context) ([]gatewayapiv1beta1.Gateway, error) {
+	var classes gatewayapiv1beta1.GatewayClassList
+	if err := r.cache.List(ctx, &classes); err != nil {
+		return nil, fmt.Errorf("failed to list gatewayclasses: %w", err)
+	}
+	ours := map[string]struct{}{}
+	for i := range classes.Items {
+		if classes.Items[i].Spec.ControllerName == OpenShiftGatewayClassControllerName {
+			ours[classes.Items[i].Name] = struct{}{}
+		}
+	}
+	var gateways gatewayapiv1beta1.GatewayList
+	if err := r.client.List(ctx, &gateways); err != nil {
+		return nil, fmt.Errorf("failed to list gateways: %w", err)
+	}
+	var managed []gatewayapiv1beta1.Gateway
+	for i := range gateways.Items {
+		if _, ok := ours[string(gateways.Items[i].Spec.GatewayClassName)]; ok {
+			managed = append(managed, gateways.Items[i])
+		}
+	}
+	sort.Slice(managed, func(i, j int) bool {
+		if managed[i].Namespace != managed[j].Namespace {
+			return managed[i].Namespace < managed[j].Namespace
+		}
+		return managed[i].Name < managed[j].Name
+	})
+	return managed, nil
+}
+
+// gatewayRevision returns the Istio revision that manages the given gateway.
+// A gateway without the revision label is managed by the servicemeshcontrolplane
+// with the original name.
+func gatewayRevision(gateway *gatewayapiv1beta1.Gateway, operandNamespace string) string {
+	if revision, ok := gateway.Labels[istioRevisionLabelKey]; ok && len(revision) != 0 {
+		return revision
+	}
+	return operatorcontroller.ServiceMeshControlPlaneName(operandNamespace).Name
+}
+
+// gatewayMigrationState is the state of a gateway's migration to the target
+// control plane revision.
+type gatewayMigrationState string
+
+const (
+	// gatewayMigrationPending means that the gateway still uses a previous
+	// revision.
+	gatewayMigrationPending gatewayMigrationState = "PendingMigration"
+	// gatewayMigrationInProgress means that the gateway has been assigned
+	// the target revision, but its deployment has not finished rolling out
+	// proxies that the target revision manages.
+	gatewayMigrationInProgress gatewayMigrationState = "Migrating"
+	// gatewayMigrationDone means that the gateway uses the target
+	// revision.
+	gatewayMigrationDone gatewayMigrationState = "Migrated"
+)
+
+// gatewayMigrationStatus returns the state of the given gateway's migration to
+// the given revision and a message describing it.  The deployments are the
+// ones that Istio created for the gateway.  Istio replaces a gateway's
+// proxies with a rolling update when the gateway's revision changes, so the
+// gateway keeps serving throughout the migration.
+func gatewayMigrationStatus(gateway *gatewayapiv1beta1.Gateway, deployments []appsv1.Deployment, operandNamespace, revision string) (gatewayMigrationState, string) {
+	if current := gatewayRevision(gateway, operandNamespace); current != revision {
+		return gatewayMigrationPending, fmt.Sprintf("The gateway uses control plane revision %s and is waiting to be migrated to revision %s.", current, revision)
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		if podRevision := d.Spec.Template.Labels[istioRevisionLabelKey]; podRevision != revision {
+			return gatewayMigrationInProgress, fmt.Sprintf("The gateway is being migrated to control plane revision %s; deployment %s/%s has not been updated yet.", revision, d.Namespace, d.Name)
+		}
+		if !deploymentRolledOut(d) {
+			return gatewayMigrationInProgress, fmt.Sprintf("The gateway is being migrated to control plane revision %s; deployment %s/%s has %d updated and %d available of %d desired replicas.", revision, d.Namespace, d.Name, d.Status.UpdatedReplicas, d.Status.AvailableReplicas, deploymentReplicas(d))
+		}
+	}
+	return gatewayMigrationDone, fmt.Sprintf("The gateway uses control plane revision %s.", revision)
+}
+
+// deploymentReplicas returns the desired number of replicas of the given
+// deployment.
+func deploymentReplicas(d *appsv1.Deployment) int32 {
+	if d.Spec.Replicas == nil {
+		return 1
+	}
+	return *d.Spec.Replicas
+}
+
+// deploymentRolledOut returns a Boolean value indicating whether the given
+// deployment has finished rolling out its current pod template, with all of
+// its replicas updated and available and no old replicas remaining.
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	replicas := deploymentReplicas(d)
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.AvailableReplicas == replicas &&
+		d.Status.Replicas == replicas
+}
+
+// gatewayDeployments returns the deployments that Istio created for the given
+// gateway.  Gateways may be in any namespace, so this uses the client.
+func (r *reconciler) gatewayDeployments(ctx context.Context, gateway *gatewayapiv1beta1.Gateway) ([]appsv1.Deployment, error) {
+	var deployments appsv1.DeploymentList
+	if err := r.client.List(ctx, &deployments, client.InNamespace(gateway.Namespace), client.MatchingLabels{gatewayNameLabelKey: gateway.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list deployments for gateway %s/%s: %w", gateway.Namespace, gateway.Name, err)
+	}
+	return deployments.Items, nil
+}
+
+// setGatewayRevision sets the revision label on the given gateway so that the
+// istiod instance of the given revision manages it.
+func (r *reconciler) setGatewayRevision(ctx context.Context, gateway *gatewayapiv1beta1.Gateway, revision string) error {
+	updated := gateway.DeepCopy()
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	updated.Labels[istioRevisionLabelKey] = revision
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to set revision %s on gateway %s/%s: %w", revision, gateway.Namespace, gateway.Name, err)
+	}
+	log.Info("set gateway control plane revision", "namespace", gateway.Namespace, "name", gateway.Name, "revision", revision)
+	*gateway = *updated
+	return nil
+}
+
+// reconcileControlPlaneMigration migrates the given gateways from the previous
+// control plane revisions to the target revision, one gateway at a time, and
+// removes the previous revisions once no gateway uses them.  The target
+// servicemeshcontrolplane must be ready before any gateway is migrated, and a
+// gateway's deployment must have finished rolling out before the next gateway
+// is migrated.  The migration is held while it is paused.  The progress is
+// reported on the given gatewayclass and on each gateway.
+func (r *reconciler) reconcileControlPlaneMigration(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass, config controlPlaneConfig, revisions controlPlaneRevisions, target *maistrav2.ServiceMeshControlPlane, gateways []gatewayapiv1beta1.Gateway) (reconcile.Result, error) {
+	revision := revisions.target.Name
+	migrating := len(revisions.previous) != 0
+	paused := config.Migration == controlPlaneMigrationPause
+	aborting := config.Migration == controlPlaneMigrationAbort && revisions.targetVersion != config.Version
+	canMigrate := !migrating || (!paused && serviceMeshControlPlaneReady(target))
+
+	var errs []error
+	states := make([]gatewayMigrationState, len(gateways))
+	messages := make([]string, len(gateways))
+	busy := ""
+	for i := range gateways {
+		gateway := &gateways[i]
+		deployments, err := r.gatewayDeployments(ctx, gateway)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		states[i], messages[i] = gatewayMigrationStatus(gateway, deployments, r.config.OperandNamespace, revision)
+		if states[i] == gatewayMigrationInProgress && len(busy) == 0 {
+			busy = types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}.String()
+		}
+	}
+
+	// Without a previous revision, there is nothing to migrate, but a
+	// gateway that refers to a revision that no longer exists is assigned
+	// the target revision right away.  During a migration, the next
+	// pending gateway is migrated once no other gateway is in progress.
+	for i := range gateways {
+		if states[i] != gatewayMigrationPending || !canMigrate || (migrating && len(busy) != 0) {
+			continue
+		}
+		gateway := &gateways[i]
+		if err := r.setGatewayRevision(ctx, gateway, revision); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.recorder.Eventf(gatewayclass, corev1.EventTypeNormal, "MigratingGateway", "Migrating gateway %s/%s to control plane revision %s", gateway.Namespace, gateway.Name, revision)
+		states[i] = gatewayMigrationInProgress
+		messages[i] = fmt.Sprintf("The gateway is being migrated to control plane revision %s.", revision)
+		if migrating {
+			busy = types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}.String()
+		}
+	}
+
+	migrated := 0
+	for i := range gateways {
+		if states[i] == gatewayMigrationDone {
+			migrated++
+		}
+	}
+
+	// Once every gateway uses the target revision, the previous
+	// revisions are no longer needed.
+	removed := false
+	if migrating && canMigrate && migrated == len(gateways) {
+		for i := range revisions.previous {
+			previous := &revisions.previous[i]
+			if err := r.client.Delete(ctx, previous); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete ServiceMeshControlPlane %s/%s: %w", previous.Namespace, previous.Name, err))
+				continue
+			}
+			log.Info("deleted ServiceMeshControlPlane", "namespace", previous.Namespace, "name", previous.Name)
+			r.recorder.Eventf(gatewayclass, corev1.EventTypeNormal, "RemovedControlPlaneRevision", "Removed control plane revision %s because all gateways use revision %s", previous.Name, revision)
+		}
+		removed = len(errs) == 0
+	}
+
+	for i := range gateways {
+		condition := computeGatewayControlPlaneRevisionCondition(states[i], messages[i], paused && migrating)
+		if err := r.updateGatewayConditions(ctx, gateways[i:i+1], condition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var previousNames []string
+	for i := range revisions.previous {
+		previousNames = append(previousNames, revisions.previous[i].Name)
+	}
+	progress := controlPlaneMigrationProgress{
+		config:        config,
+		revision:      revision,
+		version:       revisions.targetVersion,
+		previous:      previousNames,
+		targetReady:   serviceMeshControlPlaneReady(target),
+		gateways:      len(gateways),
+		migrated:      migrated,
+		busy:          busy,
+		aborting:      aborting,
+		removed:       removed,
+		pausedToStart: paused && !migrating && revisions.targetVersion != config.Version,
+	}
+	if err := r.updateGatewayClassCondition(ctx, gatewayclass.Name, computeGatewayClassControlPlaneMigratingCondition(progress)); err != nil {
+		errs = append(errs, err)
+	}
+
+	var result reconcile.Result
+	if migrating && !paused && !removed {
+		result.RequeueAfter = controlPlaneMigrationPollPeriod
+	}
+	if len(errs) != 0 {
+		return result, fmt.Errorf("failed to migrate gateways to control plane revision %s: %v", revision, errs)
+	}
+	return result, nil
+}
+
+// computeGatewayControlPlaneRevisionCondition computes the condition that
+// reports the given gateway migration state.
+func computeGatewayControlPlaneRevisionCondition(state gatewayMigrationState, message string, paused bool) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    GatewayControlPlaneRevisionConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  string(state),
+		Message: message,
+	}
+	switch {
+	case state == gatewayMigrationDone:
+		condition.Status = metav1.ConditionTrue
+	case paused:
+		condition.Reason = "MigrationPaused"
+		condition.Message = fmt.Sprintf("%s  The migration is paused.", message)
+	}
+	return condition
+}
+
+// controlPlaneMigrationProgress summarizes the progress of a control plane
+// migration for reporting.
+type controlPlaneMigrationProgress struct {
+	// config is the control plane configuration.
+	config controlPlaneConfig
+	// revision and version are the target revision and its version.
+	revision string
+	version  string
+	// previous are the names of the previous revisions.
+	previous    []string
+	targetReady bool
+	// gateways is the number of gateways, of which migrated use the
+	// target revision, and busy is the gateway that is being migrated,
+	// if any.
+	gateways int
+	migrated int
+	busy     string
+	// aborting indicates that the migration to the configured version was
+	// aborted, and removed indicates that the previous revisions were just
+	// removed.
+	aborting bool
+	removed  bool
+	// pausedToStart indicates that a migration to the configured version
+	// was paused before it started.
+	pausedToStart bool
+}
+
+// computeGatewayClassControlPlaneMigratingCondition computes the condition that
+// narrates the progress of migrating gateways between control plane
+// revisions.
+func computeGatewayClassControlPlaneMigratingCondition(progress controlPlaneMigrationProgress) metav1.Condition {
+	condition := metav1.Condition{
+		Type:   GatewayClassControlPlaneMigratingConditionType,
+		Status: metav1.ConditionTrue,
+	}
+	previous := strings.Join(progress.previous, ", ")
+	direction := "Migrating"
+	if progress.aborting {
+		direction = "Aborting the migration to control plane version " + progress.config.Version + " by migrating"
+	}
+	switch {
+	case progress.pausedToStart:
+		condition.Reason = "MigrationPaused"
+		condition.Message = fmt.Sprintf("The migration to control plane version %s is paused and has not started.  Gateways use control plane revision %s (version %s).  Remove %s from the parameters to start it.", progress.config.Version, progress.revision, progress.version, ossmControlPlaneMigrationKey)
+	case len(progress.previous) == 0 || progress.removed:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "AsExpected"
+		condition.Message = fmt.Sprintf("Gateways use control plane revision %s (version %s).", progress.revision, progress.version)
+		if progress.aborting {
+			condition.Reason = "MigrationAborted"
+			condition.Message = fmt.Sprintf("The migration to control plane version %s was aborted.  Gateways use control plane revision %s (version %s).  Set %s to %s or remove %s from the parameters to retry the migration.", progress.config.Version, progress.revision, progress.version, ossmControlPlaneVersionKey, progress.version, ossmControlPlaneMigrationKey)
+		}
+	case progress.config.Migration == controlPlaneMigrationPause:
+		condition.Reason = "MigrationPaused"
+		condition.Message = fmt.Sprintf("The migration from control plane revision %s to revision %s is paused with %d of %d gateways migrated.  Remove %s from the parameters to resume it.", previous, progress.revision, progress.migrated, progress.gateways, ossmControlPlaneMigrationKey)
+	case !progress.targetReady:
+		condition.Reason = "ProvisioningRevision"
+		condition.Message = fmt.Sprintf("%s gateways from control plane revision %s to revision %s (version %s): waiting for ServiceMeshControlPlane %s to be ready.", direction, previous, progress.revision, progress.version, progress.revision)
+	case progress.migrated < progress.gateways:
+		condition.Reason = "MigratingGateways"
+		condition.Message = fmt.Sprintf("%s gateways from control plane revision %s to revision %s (version %s): %d of %d gateways migrated.", direction, previous, progress.revision, progress.version, progress.migrated, progress.gateways)
+		if len(progress.busy) != 0 {
+			condition.Message = fmt.Sprintf("%s  Migrating gateway %s.", condition.Message, progress.busy)
+		}
+	default:
+		condition.Reason = "RemovingPreviousRevisions"
+		condition.Message = fmt.Sprintf("All gateways use control plane revision %s (version %s); removing control plane revision %s.", progress.revision, progress.version, previous)
+	}
+	return condition
+}
@@ -0,0 +1,345 @@
+package gatewayclass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_controlPlaneConfigForConfigMap verifies that
+// controlPlaneConfigForConfigMap parses the control plane version and the
+// migration knob and rejects invalid values.
+func Test_controlPlaneConfigForConfigMap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        map[string]string
+		expect      *controlPlaneConfig
+		expectError bool
+	}{
+		{name: "no overrides", data: map[string]string{"accessLogEncoding": "JSON"}},
+		{name: "version", data: map[string]string{"ossmControlPlaneVersion": "v2.6"}, expect: &controlPlaneConfig{Version: "v2.6"}},
+		{name: "paused", data: map[string]string{"ossmControlPlaneVersion": "v2.6", "ossmControlPlaneMigration": "Pause"}, expect: &controlPlaneConfig{Version: "v2.6", Migration: "Pause"}},
+		{name: "aborted with the default version", data: map[string]string{"ossmControlPlaneMigration": "Abort"}, expect: &controlPlaneConfig{Version: "v2.5", Migration: "Abort"}},
+		{name: "invalid version", data: map[string]string{"ossmControlPlaneVersion": "2.6.1"}, expectError: true},
+		{name: "invalid migration", data: map[string]string{"ossmControlPlaneMigration": "Stop"}, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := controlPlaneConfigForConfigMap(&corev1.ConfigMap{Data: tc.data})
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected an error, got nil")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expect == nil && actual != nil:
+				t.Errorf("expected nil, got %+v", *actual)
+			case tc.expect != nil && (actual == nil || *actual != *tc.expect):
+				t.Errorf("expected %+v, got %+v", *tc.expect, actual)
+			}
+		})
+	}
+}
+
+// testServiceMeshControlPlane returns a servicemeshcontrolplane for tests with
+// the given name, version, age, and readiness.
+func testServiceMeshControlPlane(name, version string, age time.Duration, ready bool) maistrav2.ServiceMeshControlPlane {
+	smcp := maistrav2.ServiceMeshControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "openshift-ingress",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: gatewayapiv1beta1.SchemeGroupVersion.String(),
+				Kind:       "GatewayClass",
+				Name:       OpenShiftDefaultGatewayClassName,
+			}},
+		},
+		Spec: maistrav2.ControlPlaneSpec{Version: version},
+	}
+	status := maistrastatus.ConditionStatusFalse
+	if ready {
+		status = maistrastatus.ConditionStatusTrue
+	}
+	smcp.Status.SetCondition(maistrastatus.Condition{Type: maistrastatus.ConditionTypeReady, Status: status})
+	return smcp
+}
+
+// Test_selectControlPlaneRevisions verifies that selectControlPlaneRevisions
+// selects the revision that gateways should use and the revisions from which
+// they are migrated.
+func Test_selectControlPlaneRevisions(t *testing.T) {
+	base := testServiceMeshControlPlane("openshift-gateway", "v2.5", time.Hour, true)
+	next := testServiceMeshControlPlane("openshift-gateway-v2-6", "v2.6", time.Minute, true)
+	testCases := []struct {
+		name           string
+		config         controlPlaneConfig
+		smcps          []maistrav2.ServiceMeshControlPlane
+		expectTarget   string
+		expectVersion  string
+		expectPrevious []string
+	}{
+		{
+			name:          "new installation",
+			config:        controlPlaneConfig{Version: "v2.5"},
+			expectTarget:  "openshift-gateway",
+			expectVersion: "v2.5",
+		},
+		{
+			name:          "new installation with a newer version",
+			config:        controlPlaneConfig{Version: "v2.6"},
+			expectTarget:  "openshift-gateway-v2-6",
+			expectVersion: "v2.6",
+		},
+		{
+			name:          "up to date",
+			config:        controlPlaneConfig{Version: "v2.5"},
+			smcps:         []maistrav2.ServiceMeshControlPlane{base},
+			expectTarget:  "openshift-gateway",
+			expectVersion: "v2.5",
+		},
+		{
+			name:           "migration starts",
+			config:         controlPlaneConfig{Version: "v2.6"},
+			smcps:          []maistrav2.ServiceMeshControlPlane{base},
+			expectTarget:   "openshift-gateway-v2-6",
+			expectVersion:  "v2.6",
+			expectPrevious: []string{"openshift-gateway"},
+		},
+		{
+			name:           "migration in progress",
+			config:         controlPlaneConfig{Version: "v2.6"},
+			smcps:          []maistrav2.ServiceMeshControlPlane{base, next},
+			expectTarget:   "openshift-gateway-v2-6",
+			expectVersion:  "v2.6",
+			expectPrevious: []string{"openshift-gateway"},
+		},
+		{
+			name:           "migration paused",
+			config:         controlPlaneConfig{Version: "v2.6", Migration: "Pause"},
+			smcps:          []maistrav2.ServiceMeshControlPlane{base, next},
+			expectTarget:   "openshift-gateway-v2-6",
+			expectVersion:  "v2.6",
+			expectPrevious: []string{"openshift-gateway"},
+		},
+		{
+			name:          "migration paused before it starts",
+			config:        controlPlaneConfig{Version: "v2.6", Migration: "Pause"},
+			smcps:         []maistrav2.ServiceMeshControlPlane{base},
+			expectTarget:  "openshift-gateway",
+			expectVersion: "v2.5",
+		},
+		{
+			name:           "migration aborted",
+			config:         controlPlaneConfig{Version: "v2.6", Migration: "Abort"},
+			smcps:          []maistrav2.ServiceMeshControlPlane{base, next},
+			expectTarget:   "openshift-gateway",
+			expectVersion:  "v2.5",
+			expectPrevious: []string{"openshift-gateway-v2-6"},
+		},
+		{
+			name:          "migration aborted and cleaned up",
+			config:        controlPlaneConfig{Version: "v2.6", Migration: "Abort"},
+			smcps:         []maistrav2.ServiceMeshControlPlane{base},
+			expectTarget:  "openshift-gateway",
+			expectVersion: "v2.5",
+		},
+		{
+			name:           "migration back to the default version",
+			config:         controlPlaneConfig{Version: "v2.5"},
+			smcps:          []maistrav2.ServiceMeshControlPlane{next},
+			expectTarget:   "openshift-gateway",
+			expectVersion:  "v2.5",
+			expectPrevious: []string{"openshift-gateway-v2-6"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := selectControlPlaneRevisions("openshift-ingress", tc.config, tc.smcps)
+			if actual.target.Name != tc.expectTarget || actual.targetVersion != tc.expectVersion {
+				t.Errorf("expected target %s with version %s, got %s with version %s", tc.expectTarget, tc.expectVersion, actual.target.Name, actual.targetVersion)
+			}
+			var previous []string
+			for _, smcp := range actual.previous {
+				previous = append(previous, smcp.Name)
+			}
+			if len(previous) != len(tc.expectPrevious) || (len(previous) != 0 && previous[0] != tc.expectPrevious[0]) {
+				t.Errorf("expected previous revisions %v, got %v", tc.expectPrevious, previous)
+			}
+		})
+	}
+}
+
+// testGatewayDeployment returns a deployment for the given gateway whose
+// proxies the given revision manages.  If rolledOut is false, the deployment
+// is still rolling out.
+func testGatewayDeployment(gateway *gatewayapiv1beta1.Gateway, revision string, rolledOut bool) *appsv1.Deployment {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: gateway.Namespace,
+			Name:      gateway.Name + "-" + OpenShiftDefaultGatewayClassName,
+			Labels:    map[string]string{gatewayNameLabelKey: gateway.Name},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{istioRevisionLabelKey: revision}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	if !rolledOut {
+		d.Status.Replicas = 3
+		d.Status.UpdatedReplicas = 1
+	}
+	return d
+}
+
+// Test_reconcileControlPlaneMigration verifies that
+// reconcileControlPlaneMigration migrates gateways to the target revision one
+// at a time, waits for each gateway's deployment to roll out, removes the
+// previous revision once every gateway is migrated, and holds the migration
+// while it is paused.
+func Test_reconcileControlPlaneMigration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	gatewayapiv1beta1.AddToScheme(scheme)
+	maistrav2.SchemeBuilder.AddToScheme(scheme)
+	appsv1.AddToScheme(scheme)
+
+	gatewayclass := &gatewayapiv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: OpenShiftDefaultGatewayClassName},
+		Spec:       gatewayapiv1beta1.GatewayClassSpec{ControllerName: OpenShiftGatewayClassControllerName},
+	}
+	gateway := func(namespace, name string) *gatewayapiv1beta1.Gateway {
+		return &gatewayapiv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       gatewayapiv1beta1.GatewaySpec{GatewayClassName: OpenShiftDefaultGatewayClassName},
+		}
+	}
+	first, second := gateway("app-a", "gateway"), gateway("app-b", "gateway")
+	base := testServiceMeshControlPlane("openshift-gateway", "v2.5", time.Hour, true)
+	next := testServiceMeshControlPlane("openshift-gateway-v2-6", "v2.6", time.Minute, true)
+	config := controlPlaneConfig{Version: "v2.6"}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(gatewayclass, first, second, &base, &next,
+			testGatewayDeployment(first, "openshift-gateway", true),
+			testGatewayDeployment(second, "openshift-gateway", true)).
+		WithStatusSubresource(&gatewayapiv1beta1.Gateway{}, &gatewayapiv1beta1.GatewayClass{}).
+		Build()
+	r := &reconciler{
+		config:   Config{OperandNamespace: "openshift-ingress"},
+		client:   cl,
+		recorder: record.NewFakeRecorder(10),
+	}
+	reconcile := func(config controlPlaneConfig) {
+		t.Helper()
+		var smcps maistrav2.ServiceMeshControlPlaneList
+		if err := cl.List(context.Background(), &smcps); err != nil {
+			t.Fatal(err)
+		}
+		sortServiceMeshControlPlanes(smcps.Items)
+		revisions := selectControlPlaneRevisions("openshift-ingress", config, smcps.Items)
+		var target *maistrav2.ServiceMeshControlPlane
+		for i := range smcps.Items {
+			if smcps.Items[i].Name == revisions.target.Name {
+				target = &smcps.Items[i]
+			}
+		}
+		var gateways gatewayapiv1beta1.GatewayList
+		if err := cl.List(context.Background(), &gateways); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.reconcileControlPlaneMigration(context.Background(), gatewayclass, config, revisions, target, gateways.Items); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRevisions := func(expectFirst, expectSecond string) {
+		t.Helper()
+		for gw, expect := range map[*gatewayapiv1beta1.Gateway]string{first: expectFirst, second: expectSecond} {
+			var current gatewayapiv1beta1.Gateway
+			if err := cl.Get(context.Background(), types.NamespacedName{Namespace: gw.Namespace, Name: gw.Name}, &current); err != nil {
+				t.Fatal(err)
+			}
+			if actual := current.Labels[istioRevisionLabelKey]; actual != expect {
+				t.Errorf("expected gateway %s/%s to have revision %q, got %q", gw.Namespace, gw.Name, expect, actual)
+			}
+		}
+	}
+	expectGatewayClassReason := func(expect string) {
+		t.Helper()
+		var current gatewayapiv1beta1.GatewayClass
+		if err := cl.Get(context.Background(), types.NamespacedName{Name: gatewayclass.Name}, &current); err != nil {
+			t.Fatal(err)
+		}
+		cond := meta.FindStatusCondition(current.Status.Conditions, GatewayClassControlPlaneMigratingConditionType)
+		if cond == nil || cond.Reason != expect {
+			t.Errorf("expected condition %s with reason %s, got %+v", GatewayClassControlPlaneMigratingConditionType, expect, cond)
+		}
+	}
+	rollOut := func(gw *gatewayapiv1beta1.Gateway, revision string, rolledOut bool) {
+		t.Helper()
+		// Deployments have a status subresource, so update the spec
+		// and the status separately.
+		deployment := testGatewayDeployment(gw, revision, rolledOut)
+		status := deployment.Status
+		if err := cl.Update(context.Background(), deployment); err != nil {
+			t.Fatal(err)
+		}
+		deployment.Status = status
+		if err := cl.Status().Update(context.Background(), deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A paused migration does not migrate any gateway.
+	reconcile(controlPlaneConfig{Version: "v2.6", Migration: "Pause"})
+	expectRevisions("", "")
+	expectGatewayClassReason("MigrationPaused")
+
+	// Only the first gateway is migrated until its deployment has rolled
+	// out with proxies that the new revision manages.
+	reconcile(config)
+	expectRevisions("openshift-gateway-v2-6", "")
+	expectGatewayClassReason("MigratingGateways")
+	rollOut(first, "openshift-gateway-v2-6", false)
+	reconcile(config)
+	expectRevisions("openshift-gateway-v2-6", "")
+
+	rollOut(first, "openshift-gateway-v2-6", true)
+	reconcile(config)
+	expectRevisions("openshift-gateway-v2-6", "openshift-gateway-v2-6")
+
+	// The previous revision is removed once every gateway is migrated.
+	rollOut(second, "openshift-gateway-v2-6", true)
+	reconcile(config)
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(&base), &maistrav2.ServiceMeshControlPlane{}); !errors.IsNotFound(err) {
+		t.Errorf("expected ServiceMeshControlPlane %s to be deleted, got error: %v", base.Name, err)
+	}
+	expectGatewayClassReason("AsExpected")
+	var current gatewayapiv1beta1.Gateway
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(first), &current); err != nil {
+		t.Fatal(err)
+	}
+	if cond := meta.FindStatusCondition(current.Status.Conditions, GatewayControlPlaneRevisionConditionType); cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected gateway %s/%s to have condition %s=True, got %+v", first.Namespace, first.Name, GatewayControlPlaneRevisionConditionType, cond)
+	}
+}
@@ -11,18 +11,16 @@ import (
 	maistrav1 "github.com/maistra/istio-operator/pkg/apis/maistra/v1"
 	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
 
-	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
-
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
 // ensureServiceMeshControlPlane attempts to ensure that a
-// servicemeshcontrolplane is present and returns a Boolean indicating whether
-// it exists, the servicemeshcontrolplane if it exists, and an error value.
-func (r *reconciler) ensureServiceMeshControlPlane(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (bool, *maistrav2.ServiceMeshControlPlane, error) {
-	name := controller.ServiceMeshControlPlaneName(r.config.OperandNamespace)
+// servicemeshcontrolplane with the given name and control plane version is
+// present and returns a Boolean indicating whether it exists, the
+// servicemeshcontrolplane if it exists, and an error value.
+func (r *reconciler) ensureServiceMeshControlPlane(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass, name types.NamespacedName, version string, accessLogging *accessLoggingConfig) (bool, *maistrav2.ServiceMeshControlPlane, error) {
 	have, current, err := r.currentServiceMeshControlPlane(ctx, name)
 	if err != nil {
 		return false, nil, err
@@ -36,7 +34,7 @@ func (r *reconciler) ensureServiceMeshControlPlane(ctx context.Context, gatewayc
 		Name:       gatewayclass.Name,
 		UID:        gatewayclass.UID,
 	}
-	desired, err := desiredServiceMeshControlPlane(name, ownerRef)
+	desired, err := desiredServiceMeshControlPlane(name, ownerRef, version, accessLogging)
 	if err != nil {
 		return have, current, err
 	}
@@ -57,14 +55,25 @@ func (r *reconciler) ensureServiceMeshControlPlane(ctx context.Context, gatewayc
 	return true, current, nil
 }
 
-// desiredServiceMeshControlPlane returns the desired servicemeshcontrolplane.
-func desiredServiceMeshControlPlane(name types.NamespacedName, ownerRef metav1.OwnerReference) (*maistrav2.ServiceMeshControlPlane, error) {
+// desiredServiceMeshControlPlane returns the desired servicemeshcontrolplane
+// with the given control plane version.  Access logging for gateway workloads
+// is configured using accessLogging, or sent to stdout if accessLogging is nil.
+func desiredServiceMeshControlPlane(name types.NamespacedName, ownerRef metav1.OwnerReference, version string, accessLogging *accessLoggingConfig) (*maistrav2.ServiceMeshControlPlane, error) {
 	pilotContainerEnv := map[string]string{
 		"PILOT_ENABLE_GATEWAY_CONTROLLER_MODE":   "true",
 		"PILOT_GATEWAY_API_CONTROLLER_NAME":      OpenShiftGatewayClassControllerName,
 		"PILOT_GATEWAY_API_DEFAULT_GATEWAYCLASS": OpenShiftDefaultGatewayClassName,
 		// OSSM will only reconcile the default gateway class if this is true.
 		"PILOT_ENABLE_GATEWAY_API_GATEWAYCLASS_CONTROLLER": "true",
+		// Istio only watches experimental-channel Gateway API
+		// resources, such as BackendTLSPolicy and TLSRoute, if this is
+		// true.  BackendTLSPolicy configures istiod to re-encrypt
+		// traffic to backends using the referenced CA certificate and
+		// to verify the backend's certificate against the specified
+		// hostname.  TLSRoute configures istiod to forward TLS
+		// connections on listeners with mode Passthrough to backends
+		// based on SNI, without terminating TLS.
+		"PILOT_ENABLE_ALPHA_GATEWAY_API": "true",
 	}
 	f := false
 	t := true
@@ -119,18 +128,6 @@ func desiredServiceMeshControlPlane(name types.NamespacedName, ownerRef metav1.O
 				Type: maistrav2.PolicyTypeIstiod,
 			},
 			Profiles: []string{"default"},
-			Proxy: &maistrav2.ProxyConfig{
-				AccessLogging: &maistrav2.ProxyAccessLoggingConfig{
-					EnvoyService: &maistrav2.ProxyEnvoyServiceConfig{
-						Enablement: maistrav2.Enablement{
-							Enabled: &t,
-						},
-					},
-					File: &maistrav2.ProxyFileAccessLogConfig{
-						Name: "/dev/stdout",
-					},
-				},
-			},
 			Runtime: &maistrav2.ControlPlaneRuntimeConfig{
 				Components: map[maistrav2.ControlPlaneComponentName]*maistrav2.ComponentRuntimeConfig{
 					maistrav2.ControlPlaneComponentNamePilot: {
@@ -146,14 +143,16 @@ func desiredServiceMeshControlPlane(name types.NamespacedName, ownerRef metav1.O
 			Tracing: &maistrav2.TracingConfig{
 				Type: maistrav2.TracerTypeNone,
 			},
-			Version: "v2.5",
-			TechPreview: maistrav1.NewHelmValues(map[string]interface{}{
-				"gatewayAPI": map[string]interface{}{
-					"enabled": &t,
-				},
-			}),
+			Version: version,
+		},
+	}
+	techPreview := map[string]interface{}{
+		"gatewayAPI": map[string]interface{}{
+			"enabled": &t,
 		},
 	}
+	setAccessLoggingConfig(&smcp.Spec, techPreview, accessLogging)
+	smcp.Spec.TechPreview = maistrav1.NewHelmValues(techPreview)
 	return &smcp, nil
 }
 
@@ -206,13 +205,107 @@ func (r *reconciler) updateServiceMeshControlPlane(ctx context.Context, current,
 // serviceMeshControlPlaneChanged returns a Boolean indicating whether the
 // current ServiceMeshControlPlane matches the expected servicemeshcontrolplane
 // and the updated servicemeshcontrolplane if they do not match.
+//
+// Only the fields that the operator manages are compared and updated so that
+// the operator reverts out-of-band changes to those fields while preserving
+// any other configuration, such as spec.cluster, spec.general,
+// spec.telemetry, additional gateways, security settings other than
+// spec.security.manageNetworkPolicy, runtime settings other than the pilot
+// container's environment, proxy settings other than access logging, and
+// mesh configuration other than the access logging extension provider.
 func serviceMeshControlPlaneChanged(current, expected *maistrav2.ServiceMeshControlPlane) (bool, *maistrav2.ServiceMeshControlPlane) {
-	if cmp.Equal(current.Spec, expected.Spec, smcpCmpOpts...) {
+	updated := current.DeepCopy()
+	setManagedServiceMeshControlPlaneFields(&updated.Spec, expected.Spec.DeepCopy())
+
+	if cmp.Equal(current.Spec, updated.Spec, smcpCmpOpts...) {
 		return false, nil
 	}
 
-	updated := current.DeepCopy()
-	updated.Spec = expected.Spec
-
 	return true, updated
 }
+
+// setManagedServiceMeshControlPlaneFields copies the fields that the operator
+// manages from the expected spec to the given spec.
+func setManagedServiceMeshControlPlaneFields(spec, expected *maistrav2.ControlPlaneSpec) {
+	spec.Addons = expected.Addons
+	spec.Mode = expected.Mode
+	spec.Policy = expected.Policy
+	spec.Profiles = expected.Profiles
+	spec.TechPreview = expected.TechPreview
+	spec.Tracing = expected.Tracing
+	spec.Version = expected.Version
+	// The access logging configuration is rendered into
+	// spec.proxy.accessLogging and an extension provider in
+	// spec.meshConfig.extensionProviders, which must be removed when access
+	// logging is disabled.  Any other proxy or mesh configuration is left
+	// alone.
+	var accessLogging *maistrav2.ProxyAccessLoggingConfig
+	if expected.Proxy != nil {
+		accessLogging = expected.Proxy.AccessLogging
+	}
+	if accessLogging != nil || spec.Proxy != nil {
+		if spec.Proxy == nil {
+			spec.Proxy = &maistrav2.ProxyConfig{}
+		}
+		spec.Proxy.AccessLogging = accessLogging
+	}
+	var providers []*maistrav2.ExtensionProviderConfig
+	if spec.MeshConfig != nil {
+		for _, provider := range spec.MeshConfig.ExtensionProviders {
+			if provider == nil || provider.Name != accessLogOTLPProviderName {
+				providers = append(providers, provider)
+			}
+		}
+	}
+	if expected.MeshConfig != nil {
+		for _, provider := range expected.MeshConfig.ExtensionProviders {
+			if provider != nil && provider.Name == accessLogOTLPProviderName {
+				providers = append(providers, provider)
+			}
+		}
+	}
+	if len(providers) != 0 || spec.MeshConfig != nil {
+		if spec.MeshConfig == nil {
+			spec.MeshConfig = &maistrav2.MeshConfig{}
+		}
+		spec.MeshConfig.ExtensionProviders = providers
+	}
+
+	if expected.Gateways != nil {
+		if spec.Gateways == nil {
+			spec.Gateways = &maistrav2.GatewaysConfig{}
+		}
+		spec.Gateways.ClusterIngress = expected.Gateways.ClusterIngress
+		spec.Gateways.ClusterEgress = expected.Gateways.ClusterEgress
+	}
+
+	if expected.Security != nil {
+		if spec.Security == nil {
+			spec.Security = &maistrav2.SecurityConfig{}
+		}
+		spec.Security.ManageNetworkPolicy = expected.Security.ManageNetworkPolicy
+	}
+
+	if expected.Runtime != nil {
+		if spec.Runtime == nil {
+			spec.Runtime = &maistrav2.ControlPlaneRuntimeConfig{}
+		}
+		for name, expectedComponent := range expected.Runtime.Components {
+			if expectedComponent == nil || expectedComponent.Container == nil {
+				continue
+			}
+			if spec.Runtime.Components == nil {
+				spec.Runtime.Components = map[maistrav2.ControlPlaneComponentName]*maistrav2.ComponentRuntimeConfig{}
+			}
+			component := spec.Runtime.Components[name]
+			if component == nil {
+				component = &maistrav2.ComponentRuntimeConfig{}
+				spec.Runtime.Components[name] = component
+			}
+			if component.Container == nil {
+				component.Container = &maistrav2.ContainerConfig{}
+			}
+			component.Container.Env = expectedComponent.Container.Env
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gatewayclass
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test_serviceMeshControlPlaneChanged verifies that
+// serviceMeshControlPlaneChanged detects and reverts changes to the fields
+// that the operator manages and preserves changes to other fields.
+func Test_serviceMeshControlPlaneChanged(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress", Name: "openshift-gateway"}
+	ownerRef := metav1.OwnerReference{Name: "openshift-default"}
+	expected, err := desiredServiceMeshControlPlane(name, ownerRef, defaultControlPlaneVersion, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pilot := maistrav2.ControlPlaneComponentNamePilot
+	setUnmanagedFields := func(smcp *maistrav2.ServiceMeshControlPlane) {
+		validationMessages := true
+		smcp.Spec.General = &maistrav2.GeneralConfig{ValidationMessages: &validationMessages}
+		smcp.Spec.Gateways.IngressGateways = map[string]*maistrav2.IngressGatewayConfig{
+			"extra": {},
+		}
+		smcp.Spec.Security.DataPlane = &maistrav2.DataPlaneSecurityConfig{}
+		smcp.Spec.Runtime.Components[pilot].Container.Resources = &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+		}
+		concurrency := int32(2)
+		smcp.Spec.Proxy.Concurrency = &concurrency
+		smcp.Spec.MeshConfig = &maistrav2.MeshConfig{
+			ExtensionProviders: []*maistrav2.ExtensionProviderConfig{{Name: "custom-authz"}},
+		}
+	}
+	testCases := []struct {
+		description string
+		mutate      func(*maistrav2.ServiceMeshControlPlane)
+		expect      bool
+	}{
+		{
+			description: "if nothing changes",
+			mutate:      func(_ *maistrav2.ServiceMeshControlPlane) {},
+			expect:      false,
+		},
+		{
+			description: "if unmanaged fields change",
+			mutate:      setUnmanagedFields,
+			expect:      false,
+		},
+		{
+			description: "if the pilot environment changes",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				delete(smcp.Spec.Runtime.Components[pilot].Container.Env, "PILOT_ENABLE_GATEWAY_CONTROLLER_MODE")
+			},
+			expect: true,
+		},
+		{
+			description: "if the pilot environment is removed",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				smcp.Spec.Runtime = nil
+			},
+			expect: true,
+		},
+		{
+			description: "if the ingress gateway is enabled",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				enabled := true
+				smcp.Spec.Gateways.ClusterIngress.Enabled = &enabled
+			},
+			expect: true,
+		},
+		{
+			description: "if network policy management is enabled",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				enabled := true
+				smcp.Spec.Security.ManageNetworkPolicy = &enabled
+			},
+			expect: true,
+		},
+		{
+			description: "if access logging is removed",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				smcp.Spec.Proxy.AccessLogging = nil
+			},
+			expect: true,
+		},
+		{
+			description: "if the version changes",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				smcp.Spec.Version = "v2.4"
+			},
+			expect: true,
+		},
+		{
+			description: "if managed and unmanaged fields change",
+			mutate: func(smcp *maistrav2.ServiceMeshControlPlane) {
+				setUnmanagedFields(smcp)
+				smcp.Spec.Profiles = []string{"small"}
+			},
+			expect: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			original := expected.DeepCopy()
+			setUnmanagedFields(original)
+			current := expected.DeepCopy()
+			tc.mutate(current)
+			changed, updated := serviceMeshControlPlaneChanged(current, expected)
+			if changed != tc.expect {
+				t.Fatalf("expected changed to be %t, got %t", tc.expect, changed)
+			}
+			if !changed {
+				return
+			}
+			if changedAgain, _ := serviceMeshControlPlaneChanged(updated, expected); changedAgain {
+				t.Error("serviceMeshControlPlaneChanged does not behave as a fixed point function")
+			}
+			// Managed fields must be restored, and any unmanaged
+			// fields that were set must be preserved.
+			want := expected.DeepCopy()
+			if current.Spec.General != nil {
+				want = original
+			}
+			if diff := cmp.Diff(want.Spec, updated.Spec, smcpCmpOpts...); len(diff) != 0 {
+				t.Errorf("unexpected updated spec (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
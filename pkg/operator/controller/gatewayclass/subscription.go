@@ -3,6 +3,8 @@ package gatewayclass
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -11,22 +13,189 @@ import (
 
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+const (
+	// ossmCatalogSourceKey is the key in the gatewayclass's parameters
+	// configmap that specifies the name of the catalog source from which
+	// the operator installs the OpenShift Service Mesh operator.  This
+	// allows disconnected clusters to install it from a mirrored catalog.
+	ossmCatalogSourceKey = "ossmCatalogSource"
+	// ossmCatalogSourceNamespaceKey is the key in the gatewayclass's
+	// parameters configmap that specifies the namespace of the catalog
+	// source.
+	ossmCatalogSourceNamespaceKey = "ossmCatalogSourceNamespace"
+	// ossmChannelKey is the key in the gatewayclass's parameters configmap
+	// that specifies the subscription channel for the OpenShift Service
+	// Mesh operator.
+	ossmChannelKey = "ossmChannel"
+
+	defaultOSSMCatalogSource          = "redhat-operators"
+	defaultOSSMCatalogSourceNamespace = "openshift-marketplace"
+	defaultOSSMChannel                = "stable"
+
+	// GatewayClassSubscriptionAvailableConditionType is the type of the
+	// condition that the operator sets on its gatewayclasses to report
+	// whether the subscription for the OpenShift Service Mesh operator can
+	// be reconciled with the catalog source and channel that the
+	// gatewayclasses' parameters specify.
+	GatewayClassSubscriptionAvailableConditionType = "ingress.operator.openshift.io/ServiceMeshSubscriptionAvailable"
+)
+
+// subscriptionConfig describes the catalog source and channel of the
+// subscription for the OpenShift Service Mesh operator.
+type subscriptionConfig struct {
+	CatalogSource          string
+	CatalogSourceNamespace string
+	Channel                string
+}
+
+// defaultSubscriptionConfig returns the subscription configuration that the
+// operator uses unless a gatewayclass's parameters override it.
+func defaultSubscriptionConfig() subscriptionConfig {
+	return subscriptionConfig{
+		CatalogSource:          defaultOSSMCatalogSource,
+		CatalogSourceNamespace: defaultOSSMCatalogSourceNamespace,
+		Channel:                defaultOSSMChannel,
+	}
+}
+
+// subscriptionConfigForConfigMap parses and validates the subscription
+// configuration in the given configmap.  It returns nil if the configmap does
+// not override any of the subscription's settings.  Settings that the
+// configmap does not override have their default values.
+func subscriptionConfigForConfigMap(cm *corev1.ConfigMap) (*subscriptionConfig, error) {
+	catalogSource, hasCatalogSource := cm.Data[ossmCatalogSourceKey]
+	catalogSourceNamespace, hasCatalogSourceNamespace := cm.Data[ossmCatalogSourceNamespaceKey]
+	channel, hasChannel := cm.Data[ossmChannelKey]
+	if !hasCatalogSource && !hasCatalogSourceNamespace && !hasChannel {
+		return nil, nil
+	}
+	config := defaultSubscriptionConfig()
+	if hasCatalogSource {
+		if errs := validation.IsDNS1123Subdomain(catalogSource); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid %s value %q: %s", ossmCatalogSourceKey, catalogSource, strings.Join(errs, ", "))
+		}
+		config.CatalogSource = catalogSource
+	}
+	if hasCatalogSourceNamespace {
+		if errs := validation.IsDNS1123Label(catalogSourceNamespace); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid %s value %q: %s", ossmCatalogSourceNamespaceKey, catalogSourceNamespace, strings.Join(errs, ", "))
+		}
+		config.CatalogSourceNamespace = catalogSourceNamespace
+	}
+	if hasChannel {
+		if len(channel) == 0 || strings.ContainsAny(channel, " \t\n") {
+			return nil, fmt.Errorf("invalid %s value %q: must be non-empty and must not contain whitespace", ossmChannelKey, channel)
+		}
+		config.Channel = channel
+	}
+	return &config, nil
+}
+
+// currentSubscriptionConfig returns the subscription configuration that the
+// parameters of the operator's gatewayclasses specify.  The subscription is
+// shared by all gatewayclasses, so the gatewayclasses that override the
+// configuration must agree; gatewayclasses that do not override it and
+// gatewayclasses that are being deleted are ignored.  An error is returned if
+// the given gatewayclass's parameters are invalid or if the gatewayclasses
+// specify conflicting configurations.
+func (r *reconciler) currentSubscriptionConfig(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (subscriptionConfig, error) {
+	var classes gatewayapiv1beta1.GatewayClassList
+	if err := r.cache.List(ctx, &classes); err != nil {
+		return subscriptionConfig{}, fmt.Errorf("failed to list gatewayclasses: %w", err)
+	}
+	sort.Slice(classes.Items, func(i, j int) bool {
+		return classes.Items[i].Name < classes.Items[j].Name
+	})
+	var (
+		config     *subscriptionConfig
+		configFrom string
+	)
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if class.Spec.ControllerName != OpenShiftGatewayClassControllerName || class.DeletionTimestamp != nil {
+			continue
+		}
+		classConfig, err := r.subscriptionConfigForGatewayClass(ctx, class)
+		if err != nil {
+			// Other gatewayclasses' invalid parameters are
+			// reported when those gatewayclasses are reconciled.
+			if class.Name == gatewayclass.Name {
+				return subscriptionConfig{}, err
+			}
+			continue
+		}
+		switch {
+		case classConfig == nil:
+		case config == nil:
+			config, configFrom = classConfig, class.Name
+		case *config != *classConfig:
+			return subscriptionConfig{}, fmt.Errorf("gatewayclasses %s and %s specify conflicting subscription parameters; the subscription is shared by all gatewayclasses, so their %s, %s, and %s parameters must agree", configFrom, class.Name, ossmCatalogSourceKey, ossmCatalogSourceNamespaceKey, ossmChannelKey)
+		}
+	}
+	if config == nil {
+		return defaultSubscriptionConfig(), nil
+	}
+	return *config, nil
+}
+
+// subscriptionConfigForGatewayClass returns the subscription configuration
+// that the given gatewayclass's parameters specify, or nil if they do not
+// override it.
+func (r *reconciler) subscriptionConfigForGatewayClass(ctx context.Context, gatewayclass *gatewayapiv1beta1.GatewayClass) (*subscriptionConfig, error) {
+	cm, err := r.parametersConfigMap(ctx, gatewayclass)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+	config, err := subscriptionConfigForConfigMap(cm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters configmap %s/%s for gatewayclass %s: %w", cm.Namespace, cm.Name, gatewayclass.Name, err)
+	}
+	return config, nil
+}
+
+// catalogSourceNotFoundError is the error that
+// ensureServiceMeshOperatorSubscription returns if the catalog source that the
+// subscription should use does not exist.
+type catalogSourceNotFoundError struct {
+	name types.NamespacedName
+}
+
+func (e *catalogSourceNotFoundError) Error() string {
+	return fmt.Sprintf("catalog source %s does not exist", e.name)
+}
+
 // ensureServiceMeshOperatorSubscription attempts to ensure that a subscription
-// for servicemeshoperator is present and returns a Boolean indicating whether
-// it exists, the subscription if it exists, and an error value.
-func (r *reconciler) ensureServiceMeshOperatorSubscription(ctx context.Context) (bool, *operatorsv1alpha1.Subscription, error) {
+// for servicemeshoperator is present with the given configuration and returns
+// a Boolean indicating whether it exists, the subscription if it exists, and
+// an error value.  If the configuration's catalog source does not exist, the
+// subscription is left as it is, and a *catalogSourceNotFoundError is
+// returned.
+func (r *reconciler) ensureServiceMeshOperatorSubscription(ctx context.Context, config subscriptionConfig) (bool, *operatorsv1alpha1.Subscription, error) {
 	name := operatorcontroller.ServiceMeshSubscriptionName()
 	have, current, err := r.currentSubscription(ctx, name)
 	if err != nil {
 		return false, nil, err
 	}
 
-	desired, err := desiredSubscription(name)
+	catalogSourceName := types.NamespacedName{Namespace: config.CatalogSourceNamespace, Name: config.CatalogSource}
+	if err := r.client.Get(ctx, catalogSourceName, &operatorsv1alpha1.CatalogSource{}); err != nil {
+		if errors.IsNotFound(err) {
+			return have, current, &catalogSourceNotFoundError{name: catalogSourceName}
+		}
+		return have, current, fmt.Errorf("failed to get catalog source %s: %w", catalogSourceName, err)
+	}
+
+	desired, err := desiredSubscription(name, config)
 	if err != nil {
 		return have, current, err
 	}
@@ -48,23 +217,67 @@ func (r *reconciler) ensureServiceMeshOperatorSubscription(ctx context.Context)
 }
 
 // desiredSubscription returns the desired subscription.
-func desiredSubscription(name types.NamespacedName) (*operatorsv1alpha1.Subscription, error) {
+func desiredSubscription(name types.NamespacedName, config subscriptionConfig) (*operatorsv1alpha1.Subscription, error) {
 	subscription := operatorsv1alpha1.Subscription{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: name.Namespace,
 			Name:      name.Name,
 		},
 		Spec: &operatorsv1alpha1.SubscriptionSpec{
-			Channel:                "stable",
+			Channel:                config.Channel,
 			InstallPlanApproval:    operatorsv1alpha1.ApprovalAutomatic,
 			Package:                "servicemeshoperator",
-			CatalogSource:          "redhat-operators",
-			CatalogSourceNamespace: "openshift-marketplace",
+			CatalogSource:          config.CatalogSource,
+			CatalogSourceNamespace: config.CatalogSourceNamespace,
 		},
 	}
 	return &subscription, nil
 }
 
+// computeGatewayClassSubscriptionAvailableCondition computes the condition that
+// reports whether the subscription for the OpenShift Service Mesh operator
+// could be reconciled with the given configuration.  The err argument is the
+// error from determining the configuration or from ensuring the subscription.
+func computeGatewayClassSubscriptionAvailableCondition(config subscriptionConfig, err error) metav1.Condition {
+	condition := metav1.Condition{Type: GatewayClassSubscriptionAvailableConditionType}
+	_, notFound := err.(*catalogSourceNotFoundError)
+	switch {
+	case err == nil:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "SubscriptionAvailable"
+		condition.Message = fmt.Sprintf("The OpenShift Service Mesh operator subscription uses channel %q of catalog source %s/%s.", config.Channel, config.CatalogSourceNamespace, config.CatalogSource)
+	case notFound:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "CatalogSourceNotFound"
+		condition.Message = fmt.Sprintf("The OpenShift Service Mesh operator subscription cannot be reconciled because %v.", err)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SubscriptionUnavailable"
+		condition.Message = fmt.Sprintf("The OpenShift Service Mesh operator subscription cannot be reconciled: %v", err)
+	}
+	return condition
+}
+
+// updateGatewayClassCondition sets the given condition on the named
+// gatewayclass and updates its status if the condition changed.  The
+// gatewayclass is read using the client because the reconciler may have just
+// updated it, for example to add the protection finalizer.
+func (r *reconciler) updateGatewayClassCondition(ctx context.Context, name string, condition metav1.Condition) error {
+	var updated gatewayapiv1beta1.GatewayClass
+	if err := r.client.Get(ctx, types.NamespacedName{Name: name}, &updated); err != nil {
+		return fmt.Errorf("failed to get gatewayclass %s: %w", name, err)
+	}
+	condition.ObservedGeneration = updated.Generation
+	if !meta.SetStatusCondition(&updated.Status.Conditions, condition) {
+		return nil
+	}
+	if err := r.client.Status().Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update status of gatewayclass %s: %w", updated.Name, err)
+	}
+	log.Info("updated gatewayclass status", "name", updated.Name, "condition", condition.Type, "status", condition.Status, "reason", condition.Reason)
+	return nil
+}
+
 // currentSubscription returns the current subscription.
 func (r *reconciler) currentSubscription(ctx context.Context, name types.NamespacedName) (bool, *operatorsv1alpha1.Subscription, error) {
 	var subscription operatorsv1alpha1.Subscription
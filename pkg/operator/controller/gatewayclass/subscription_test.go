@@ -0,0 +1,240 @@
+package gatewayclass
+
+import (
+	"context"
+	"testing"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
+
+// Test_subscriptionConfigForConfigMap verifies that
+// subscriptionConfigForConfigMap parses valid subscription parameters, fills
+// in defaults for the parameters that are not specified, and rejects invalid
+// ones.
+func Test_subscriptionConfigForConfigMap(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        map[string]string
+		expect      *subscriptionConfig
+		expectError bool
+	}{
+		{
+			name:   "no parameters",
+			data:   map[string]string{"accessLogEncoding": "JSON"},
+			expect: nil,
+		},
+		{
+			name: "catalog source only",
+			data: map[string]string{"ossmCatalogSource": "mirrored-operators"},
+			expect: &subscriptionConfig{
+				CatalogSource:          "mirrored-operators",
+				CatalogSourceNamespace: "openshift-marketplace",
+				Channel:                "stable",
+			},
+		},
+		{
+			name: "all parameters",
+			data: map[string]string{"ossmCatalogSource": "mirrored-operators", "ossmCatalogSourceNamespace": "mirror", "ossmChannel": "stable-2.5"},
+			expect: &subscriptionConfig{
+				CatalogSource:          "mirrored-operators",
+				CatalogSourceNamespace: "mirror",
+				Channel:                "stable-2.5",
+			},
+		},
+		{
+			name:        "invalid catalog source",
+			data:        map[string]string{"ossmCatalogSource": "Mirrored_Operators"},
+			expectError: true,
+		},
+		{
+			name:        "invalid catalog source namespace",
+			data:        map[string]string{"ossmCatalogSourceNamespace": "mirror.example"},
+			expectError: true,
+		},
+		{
+			name:        "empty channel",
+			data:        map[string]string{"ossmChannel": ""},
+			expectError: true,
+		},
+		{
+			name:        "channel with whitespace",
+			data:        map[string]string{"ossmChannel": "stable 2.5"},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: tc.data}
+			switch actual, err := subscriptionConfigForConfigMap(cm); {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expect == nil && actual != nil:
+				t.Errorf("expected nil, got %+v", *actual)
+			case tc.expect != nil && (actual == nil || *actual != *tc.expect):
+				t.Errorf("expected %+v, got %+v", *tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_ensureServiceMeshOperatorSubscription verifies that the subscription
+// parameters of the operator's gatewayclasses determine the subscription's
+// catalog source and channel, that conflicting parameters are rejected, and
+// that the subscription is left as it is if the catalog source does not exist.
+func Test_ensureServiceMeshOperatorSubscription(t *testing.T) {
+	const operatorNamespace = "openshift-ingress-operator"
+	configMap := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: name},
+			Data:       data,
+		}
+	}
+	gatewayClass := func(name, parameters string) *gatewayapiv1beta1.GatewayClass {
+		gc := &gatewayapiv1beta1.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: gatewayapiv1beta1.GatewayClassSpec{
+				ControllerName: OpenShiftGatewayClassControllerName,
+			},
+		}
+		if len(parameters) != 0 {
+			ns := gatewayapiv1beta1.Namespace(operatorNamespace)
+			gc.Spec.ParametersRef = &gatewayapiv1beta1.ParametersReference{Kind: "ConfigMap", Name: parameters, Namespace: &ns}
+		}
+		return gc
+	}
+	catalogSource := func(namespace, name string) *operatorsv1alpha1.CatalogSource {
+		return &operatorsv1alpha1.CatalogSource{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	mirror := map[string]string{"ossmCatalogSource": "mirrored-operators", "ossmCatalogSourceNamespace": "mirror"}
+	testCases := []struct {
+		name    string
+		objects []client.Object
+		// expectCatalogSource is the expected catalog source of the
+		// subscription, or empty if no subscription is expected.
+		expectCatalogSource string
+		expectReason        string
+	}{
+		{
+			name: "default parameters",
+			objects: []client.Object{
+				gatewayClass("openshift-default", ""),
+				catalogSource("openshift-marketplace", "redhat-operators"),
+			},
+			expectCatalogSource: "openshift-marketplace/redhat-operators",
+			expectReason:        "SubscriptionAvailable",
+		},
+		{
+			name: "mirrored catalog source",
+			objects: []client.Object{
+				gatewayClass("openshift-default", "params"),
+				configMap("params", mirror),
+				catalogSource("mirror", "mirrored-operators"),
+			},
+			expectCatalogSource: "mirror/mirrored-operators",
+			expectReason:        "SubscriptionAvailable",
+		},
+		{
+			name: "mirrored catalog source set by another gatewayclass",
+			objects: []client.Object{
+				gatewayClass("openshift-default", ""),
+				gatewayClass("other", "params"),
+				configMap("params", mirror),
+				catalogSource("mirror", "mirrored-operators"),
+			},
+			expectCatalogSource: "mirror/mirrored-operators",
+			expectReason:        "SubscriptionAvailable",
+		},
+		{
+			name: "missing catalog source",
+			objects: []client.Object{
+				gatewayClass("openshift-default", "params"),
+				configMap("params", mirror),
+				catalogSource("openshift-marketplace", "redhat-operators"),
+			},
+			expectReason: "CatalogSourceNotFound",
+		},
+		{
+			name: "conflicting parameters",
+			objects: []client.Object{
+				gatewayClass("openshift-default", "params"),
+				gatewayClass("other", "other-params"),
+				configMap("params", mirror),
+				configMap("other-params", map[string]string{"ossmChannel": "candidate"}),
+				catalogSource("mirror", "mirrored-operators"),
+				catalogSource("openshift-marketplace", "redhat-operators"),
+			},
+			expectReason: "SubscriptionUnavailable",
+		},
+		{
+			name: "invalid parameters",
+			objects: []client.Object{
+				gatewayClass("openshift-default", "params"),
+				configMap("params", map[string]string{"ossmChannel": ""}),
+				catalogSource("openshift-marketplace", "redhat-operators"),
+			},
+			expectReason: "SubscriptionUnavailable",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	gatewayapiv1beta1.AddToScheme(scheme)
+	operatorsv1alpha1.AddToScheme(scheme)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.objects...).
+				WithStatusSubresource(&gatewayapiv1beta1.GatewayClass{}).
+				Build()
+			informer := informertest.FakeInformers{Scheme: scheme}
+			r := &reconciler{
+				config: Config{OperatorNamespace: operatorNamespace},
+				client: cl,
+				cache:  fakeCache{Informers: &informer, Reader: cl},
+			}
+			gc := tc.objects[0].(*gatewayapiv1beta1.GatewayClass)
+			config, err := r.currentSubscriptionConfig(context.Background(), gc)
+			if err == nil {
+				_, _, err = r.ensureServiceMeshOperatorSubscription(context.Background(), config)
+			}
+			condition := computeGatewayClassSubscriptionAvailableCondition(config, err)
+			if condition.Reason != tc.expectReason {
+				t.Errorf("expected reason %q, got %q (error: %v)", tc.expectReason, condition.Reason, err)
+			}
+
+			var subscription operatorsv1alpha1.Subscription
+			err = cl.Get(context.Background(), types.NamespacedName{Namespace: "openshift-operators", Name: "servicemeshoperator"}, &subscription)
+			switch {
+			case len(tc.expectCatalogSource) == 0 && err == nil:
+				t.Errorf("expected no subscription, got one with catalog source %s/%s", subscription.Spec.CatalogSourceNamespace, subscription.Spec.CatalogSource)
+			case len(tc.expectCatalogSource) != 0 && err != nil:
+				t.Fatalf("failed to get subscription: %v", err)
+			case len(tc.expectCatalogSource) != 0:
+				if actual := subscription.Spec.CatalogSourceNamespace + "/" + subscription.Spec.CatalogSource; actual != tc.expectCatalogSource {
+					t.Errorf("expected catalog source %s, got %s", tc.expectCatalogSource, actual)
+				}
+			}
+		})
+	}
+}
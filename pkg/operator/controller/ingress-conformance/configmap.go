@@ -0,0 +1,101 @@
+package ingressconformance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// routerVersionKey is the key in the feature support configmap that specifies
+// the version of the router that the feature support matrix describes.
+const routerVersionKey = "routerVersion"
+
+// ensureFeatureSupportConfigMap ensures that the configmap that publishes the
+// Ingress feature support matrix exists and is up to date.  Returns a Boolean
+// indicating whether the configmap exists, the configmap if it does exist, and
+// an error value.
+func (r *reconciler) ensureFeatureSupportConfigMap(ctx context.Context) (bool, *corev1.ConfigMap, error) {
+	desired := desiredFeatureSupportConfigMap(r.config.OperatorReleaseVersion)
+
+	have, current, err := r.currentFeatureSupportConfigMap(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if !have {
+		if err := r.client.Create(ctx, desired); err != nil {
+			return false, nil, fmt.Errorf("failed to create configmap: %w", err)
+		}
+		log.Info("created configmap", "namespace", desired.Namespace, "name", desired.Name)
+		return r.currentFeatureSupportConfigMap(ctx)
+	}
+	if updated, err := r.updateFeatureSupportConfigMap(ctx, current, desired); err != nil {
+		return true, current, fmt.Errorf("failed to update configmap: %w", err)
+	} else if updated {
+		return r.currentFeatureSupportConfigMap(ctx)
+	}
+
+	return true, current, nil
+}
+
+// desiredFeatureSupportConfigMap returns the desired configmap that publishes
+// the Ingress feature support matrix of the router with the given version.
+// Each key is the name of a feature, and each value is the level of support
+// followed by details.
+func desiredFeatureSupportConfigMap(routerVersion string) *corev1.ConfigMap {
+	name := operatorcontroller.IngressFeatureSupportConfigMapName()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+		Data: map[string]string{
+			routerVersionKey: routerVersion,
+		},
+	}
+	for _, feature := range ingressFeatures {
+		cm.Data[feature.name] = fmt.Sprintf("%s: %s", feature.support, feature.details)
+	}
+	return cm
+}
+
+// currentFeatureSupportConfigMap returns a Boolean indicating whether the
+// feature support configmap exists, as well as the configmap if it does exist
+// and an error value.
+func (r *reconciler) currentFeatureSupportConfigMap(ctx context.Context) (bool, *corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, operatorcontroller.IngressFeatureSupportConfigMapName(), cm); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, cm, nil
+}
+
+// updateFeatureSupportConfigMap updates the feature support configmap if its
+// data differs from the desired data.  Returns a Boolean indicating whether the
+// configmap was updated, and an error value.
+func (r *reconciler) updateFeatureSupportConfigMap(ctx context.Context, current, desired *corev1.ConfigMap) (bool, error) {
+	if cmp.Equal(current.Data, desired.Data, cmpopts.EquateEmpty()) {
+		return false, nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = desired.Data
+	// Diff before updating because the client may mutate the object.
+	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
+	if err := r.client.Update(ctx, updated); err != nil {
+		return false, err
+	}
+	log.Info("updated configmap", "namespace", updated.Namespace, "name", updated.Name, "diff", diff)
+	return true, nil
+}
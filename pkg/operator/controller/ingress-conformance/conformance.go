@@ -0,0 +1,112 @@
+package ingressconformance
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// featureSupport describes how the router handles a feature of Ingress
+// resources.
+type featureSupport string
+
+const (
+	// featureSupported means that the router implements the feature as
+	// the Ingress API specifies.
+	featureSupported featureSupport = "Supported"
+	// featurePartiallySupported means that the router implements the
+	// feature with different semantics than the Ingress API specifies.
+	featurePartiallySupported featureSupport = "PartiallySupported"
+	// featureUnsupported means that the router ignores the feature.
+	featureUnsupported featureSupport = "Unsupported"
+)
+
+// ingressFeature describes a feature of Ingress resources and how the router
+// handles it.
+type ingressFeature struct {
+	// name is the name of the feature, which is used as the key in the
+	// feature support configmap.
+	name string
+	// support is how the router handles the feature.
+	support featureSupport
+	// details describes how the router handles the feature.
+	details string
+}
+
+// ingressFeatures is the matrix of Ingress features and how the router that
+// the operator manages supports them.  Ingresses are converted into routes,
+// so the router's support is limited to what routes can express.
+var ingressFeatures = []ingressFeature{{
+	name:    "rules.host",
+	support: featureSupported,
+	details: "Each rule is converted into routes for the rule's host.",
+}, {
+	name:    "rules.http.paths.pathType.Prefix",
+	support: featureSupported,
+	details: "The path is matched as a prefix.",
+}, {
+	name:    "rules.http.paths.pathType.Exact",
+	support: featurePartiallySupported,
+	details: "The path is matched as a prefix, not exactly.",
+}, {
+	name:    "rules.http.paths.pathType.ImplementationSpecific",
+	support: featurePartiallySupported,
+	details: "The path is matched as a literal prefix; regular expressions and wildcards are not interpreted.",
+}, {
+	name:    "rules.http.paths.backend.service",
+	support: featureSupported,
+	details: "The service port may be specified by name or number.",
+}, {
+	name:    "rules.http.paths.backend.resource",
+	support: featureUnsupported,
+	details: "Paths with resource backends are ignored.",
+}, {
+	name:    "defaultBackend",
+	support: featureUnsupported,
+	details: "The default backend is ignored; requests that match no rule are not sent to it.",
+}, {
+	name:    "tls",
+	support: featureSupported,
+	details: "Routes are created with edge TLS termination using the certificate from the referenced secret, or the ingresscontroller's default certificate if no secret is specified.",
+}}
+
+// regexpMetacharacters are characters that commonly indicate that a path with
+// pathType ImplementationSpecific was written for an ingress controller that
+// interprets such paths as regular expressions or wildcards.
+const regexpMetacharacters = "*^$()[]{}|+?\\"
+
+// ingressWarnings returns messages describing the constructs in the given
+// Ingress that the router ignores or implements with semantics that differ
+// from what the Ingress API specifies.
+func ingressWarnings(ingress *networkingv1.Ingress) []string {
+	var warnings []string
+	if ingress.Spec.DefaultBackend != nil {
+		warnings = append(warnings, "spec.defaultBackend is ignored; requests that match no rule are not sent to the default backend")
+	}
+	for i, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for j, path := range rule.HTTP.Paths {
+			field := fmt.Sprintf("spec.rules[%d].http.paths[%d]", i, j)
+			if path.Backend.Resource != nil {
+				warnings = append(warnings, fmt.Sprintf("%s has a resource backend, which is not supported; the path is ignored", field))
+				continue
+			}
+			pathType := networkingv1.PathTypeImplementationSpecific
+			if path.PathType != nil {
+				pathType = *path.PathType
+			}
+			switch pathType {
+			case networkingv1.PathTypeExact:
+				warnings = append(warnings, fmt.Sprintf("%s has pathType Exact; the path %q is matched as a prefix", field, path.Path))
+			case networkingv1.PathTypeImplementationSpecific:
+				if strings.ContainsAny(path.Path, regexpMetacharacters) {
+					warnings = append(warnings, fmt.Sprintf("%s has pathType ImplementationSpecific; the path %q is matched as a literal prefix, not as a regular expression or wildcard", field, path.Path))
+				}
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,334 @@
+package ingressconformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// pathTypePtr returns a pointer to the given path type.
+func pathTypePtr(pathType networkingv1.PathType) *networkingv1.PathType {
+	return &pathType
+}
+
+// serviceBackend returns an Ingress backend for the service with the given
+// name.
+func serviceBackend(name string) networkingv1.IngressBackend {
+	return networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: name,
+			Port: networkingv1.ServiceBackendPort{Number: 8080},
+		},
+	}
+}
+
+// ingressWithPaths returns an Ingress with a single rule with the given paths.
+func ingressWithPaths(paths ...networkingv1.HTTPIngressPath) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: "www.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+				},
+			}},
+		},
+	}
+}
+
+// Test_ingressWarnings verifies that ingressWarnings reports the constructs
+// that the router ignores or implements differently than the Ingress API
+// specifies, and only those.
+func Test_ingressWarnings(t *testing.T) {
+	resourceBackend := networkingv1.IngressBackend{
+		Resource: &corev1.TypedLocalObjectReference{Kind: "StorageBucket", Name: "static-assets"},
+	}
+	testCases := []struct {
+		name          string
+		ingress       *networkingv1.Ingress
+		expectHas     []string
+		expectNoWarns bool
+	}{
+		{
+			name: "prefix path",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:     "/api",
+				PathType: pathTypePtr(networkingv1.PathTypePrefix),
+				Backend:  serviceBackend("api"),
+			}),
+			expectNoWarns: true,
+		},
+		{
+			name: "implementation-specific literal path",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:     "/static",
+				PathType: pathTypePtr(networkingv1.PathTypeImplementationSpecific),
+				Backend:  serviceBackend("static"),
+			}),
+			expectNoWarns: true,
+		},
+		{
+			name: "host without paths",
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "www.example.com"}},
+				},
+			},
+			expectNoWarns: true,
+		},
+		{
+			name:      "default backend",
+			ingress:   &networkingv1.Ingress{Spec: networkingv1.IngressSpec{DefaultBackend: &networkingv1.IngressBackend{Service: serviceBackend("fallback").Service}}},
+			expectHas: []string{"spec.defaultBackend is ignored"},
+		},
+		{
+			name: "exact path",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:     "/login",
+				PathType: pathTypePtr(networkingv1.PathTypeExact),
+				Backend:  serviceBackend("auth"),
+			}),
+			expectHas: []string{`spec.rules[0].http.paths[0] has pathType Exact; the path "/login" is matched as a prefix`},
+		},
+		{
+			name: "implementation-specific regular expression",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:     "/api/v[0-9]+",
+				PathType: pathTypePtr(networkingv1.PathTypeImplementationSpecific),
+				Backend:  serviceBackend("api"),
+			}),
+			expectHas: []string{`spec.rules[0].http.paths[0] has pathType ImplementationSpecific; the path "/api/v[0-9]+" is matched as a literal prefix`},
+		},
+		{
+			name: "wildcard path without path type",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:    "/images/*",
+				Backend: serviceBackend("images"),
+			}),
+			expectHas: []string{"spec.rules[0].http.paths[0] has pathType ImplementationSpecific"},
+		},
+		{
+			name: "resource backend",
+			ingress: ingressWithPaths(networkingv1.HTTPIngressPath{
+				Path:     "/assets",
+				PathType: pathTypePtr(networkingv1.PathTypeExact),
+				Backend:  resourceBackend,
+			}),
+			expectHas: []string{"spec.rules[0].http.paths[0] has a resource backend, which is not supported"},
+		},
+		{
+			name: "several constructs",
+			ingress: func() *networkingv1.Ingress {
+				ingress := ingressWithPaths(
+					networkingv1.HTTPIngressPath{Path: "/", PathType: pathTypePtr(networkingv1.PathTypePrefix), Backend: serviceBackend("web")},
+					networkingv1.HTTPIngressPath{Path: "/healthz", PathType: pathTypePtr(networkingv1.PathTypeExact), Backend: serviceBackend("web")},
+					networkingv1.HTTPIngressPath{Path: "/assets", PathType: pathTypePtr(networkingv1.PathTypePrefix), Backend: resourceBackend},
+				)
+				ingress.Spec.DefaultBackend = &resourceBackend
+				return ingress
+			}(),
+			expectHas: []string{
+				"spec.defaultBackend is ignored",
+				"spec.rules[0].http.paths[1] has pathType Exact",
+				"spec.rules[0].http.paths[2] has a resource backend",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := ingressWarnings(tc.ingress)
+			if tc.expectNoWarns {
+				if len(warnings) != 0 {
+					t.Errorf("expected no warnings, got %q", warnings)
+				}
+				return
+			}
+			if len(warnings) != len(tc.expectHas) {
+				t.Errorf("expected %d warnings, got %q", len(tc.expectHas), warnings)
+			}
+			for _, expect := range tc.expectHas {
+				found := false
+				for _, warning := range warnings {
+					if strings.Contains(warning, expect) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("expected a warning containing %q, got %q", expect, warnings)
+				}
+			}
+		})
+	}
+}
+
+// Test_Reconcile verifies that Reconcile emits events only for Ingresses that
+// an ingresscontroller handles and only once for each generation of an
+// Ingress.
+func Test_Reconcile(t *testing.T) {
+	scope := networkingv1.IngressClassParametersReferenceScopeCluster
+	openshiftDefault := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "openshift-default"},
+		Spec: networkingv1.IngressClassSpec{
+			Controller: routev1.IngressToRouteIngressClassControllerName,
+			Parameters: &networkingv1.IngressClassParametersReference{
+				APIGroup: &operatorv1.GroupName,
+				Kind:     "IngressController",
+				Name:     "default",
+				Scope:    &scope,
+			},
+		},
+	}
+	other := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec:       networkingv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+	}
+	exact := func(name string, className *string) *networkingv1.Ingress {
+		ingress := ingressWithPaths(networkingv1.HTTPIngressPath{
+			Path:     "/login",
+			PathType: pathTypePtr(networkingv1.PathTypeExact),
+			Backend:  serviceBackend("auth"),
+		})
+		ingress.ObjectMeta = metav1.ObjectMeta{Namespace: "app", Name: name, Generation: 1}
+		ingress.Spec.IngressClassName = className
+		ingress.Spec.DefaultBackend = &networkingv1.IngressBackend{Service: serviceBackend("fallback").Service}
+		return ingress
+	}
+	legacy := exact("legacy", nil)
+	legacy.Annotations = map[string]string{legacyIngressClassAnnotation: "openshift-default"}
+
+	testCases := []struct {
+		name         string
+		ingress      *networkingv1.Ingress
+		classes      []*networkingv1.IngressClass
+		expectEvents int
+	}{
+		{
+			name:         "ingresscontroller class",
+			ingress:      exact("openshift", &openshiftDefault.Name),
+			classes:      []*networkingv1.IngressClass{openshiftDefault, other},
+			expectEvents: 2,
+		},
+		{
+			name:         "legacy annotation",
+			ingress:      legacy,
+			classes:      []*networkingv1.IngressClass{openshiftDefault, other},
+			expectEvents: 2,
+		},
+		{
+			name:         "no class",
+			ingress:      exact("unclassed", nil),
+			classes:      []*networkingv1.IngressClass{openshiftDefault, other},
+			expectEvents: 2,
+		},
+		{
+			name:    "no class, other class is default",
+			ingress: exact("unclassed", nil),
+			classes: func() []*networkingv1.IngressClass {
+				defaultOther := other.DeepCopy()
+				defaultOther.Annotations = map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}
+				return []*networkingv1.IngressClass{openshiftDefault, defaultOther}
+			}(),
+		},
+		{
+			name:    "other class",
+			ingress: exact("nginx", &other.Name),
+			classes: []*networkingv1.IngressClass{openshiftDefault, other},
+		},
+		{
+			name:    "nonexistent class",
+			ingress: exact("missing", func() *string { s := "missing"; return &s }()),
+			classes: []*networkingv1.IngressClass{openshiftDefault, other},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	networkingv1.AddToScheme(scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.ingress)
+			for _, class := range tc.classes {
+				builder = builder.WithObjects(class)
+			}
+			cl := builder.Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &reconciler{
+				client:   cl,
+				cache:    cl,
+				recorder: recorder,
+				warned:   map[types.NamespacedName]warnedIngress{},
+			}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: tc.ingress.Namespace, Name: tc.ingress.Name}}
+			for i := 0; i < 2; i++ {
+				if _, err := r.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if len(recorder.Events) != tc.expectEvents {
+				t.Fatalf("expected %d events, got %d", tc.expectEvents, len(recorder.Events))
+			}
+			for i := 0; i < tc.expectEvents; i++ {
+				if event := <-recorder.Events; !strings.HasPrefix(event, "Warning UnsupportedIngressConstruct") {
+					t.Errorf("expected a Warning UnsupportedIngressConstruct event, got %q", event)
+				}
+			}
+		})
+	}
+}
+
+// Test_ensureFeatureSupportConfigMap verifies that
+// ensureFeatureSupportConfigMap publishes the feature support matrix and
+// restores it if it is modified.
+func Test_ensureFeatureSupportConfigMap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	name := operatorcontroller.IngressFeatureSupportConfigMapName()
+	modified := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+		Data:       map[string]string{"defaultBackend": "Supported"},
+	}
+	for _, existing := range []*corev1.ConfigMap{nil, modified} {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		if existing != nil {
+			builder = builder.WithObjects(existing.DeepCopy())
+		}
+		r := &reconciler{
+			config: Config{OperatorReleaseVersion: "4.18.0"},
+			client: builder.Build(),
+		}
+		have, cm, err := r.ensureFeatureSupportConfigMap(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !have {
+			t.Fatal("expected the configmap to exist")
+		}
+		if cm.Data[routerVersionKey] != "4.18.0" {
+			t.Errorf("expected %s=4.18.0, got %q", routerVersionKey, cm.Data[routerVersionKey])
+		}
+		if len(cm.Data) != len(ingressFeatures)+1 {
+			t.Errorf("expected %d keys, got %v", len(ingressFeatures)+1, cm.Data)
+		}
+		if !strings.HasPrefix(cm.Data["defaultBackend"], string(featureUnsupported)) {
+			t.Errorf("expected defaultBackend to be %s, got %q", featureUnsupported, cm.Data["defaultBackend"])
+		}
+	}
+}
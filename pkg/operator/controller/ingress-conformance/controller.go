@@ -0,0 +1,262 @@
+package ingressconformance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ingress_conformance_controller"
+
+	// legacyIngressClassAnnotation is the deprecated annotation that
+	// specifies the class of an Ingress.
+	legacyIngressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+var (
+	log = logf.Logger.WithName(controllerName)
+)
+
+// New creates and returns a controller that publishes which features of
+// Ingress resources the router supports and that emits events for Ingresses
+// that use features that the router ignores or implements differently than
+// the Ingress API specifies.
+func New(mgr manager.Manager, config Config) (controller.Controller, error) {
+	// Create a new cache to watch Ingresses in every namespace.
+	ingressCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Add the cache to the manager so that the cache is started along with
+	// the other runnables.
+	if err := mgr.Add(ingressCache); err != nil {
+		return nil, err
+	}
+	operatorCache := mgr.GetCache()
+	reconciler := &reconciler{
+		config:   config,
+		client:   mgr.GetClient(),
+		cache:    ingressCache,
+		recorder: mgr.GetEventRecorderFor(controllerName),
+		warned:   map[types.NamespacedName]warnedIngress{},
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind[client.Object](ingressCache, &networkingv1.Ingress{}, &handler.EnqueueRequestForObject{})); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &networkingv1.IngressClass{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressClassToIngresses))); err != nil {
+		return nil, err
+	}
+	// Publish the feature support configmap when the operator starts and
+	// restore it if it is modified or deleted.
+	toConfigMap := func(ctx context.Context, o client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: operatorcontroller.IngressFeatureSupportConfigMapName()}}
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &operatorv1.IngressController{}, handler.EnqueueRequestsFromMapFunc(toConfigMap))); err != nil {
+		return nil, err
+	}
+	isFeatureSupportConfigMap := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		name := operatorcontroller.IngressFeatureSupportConfigMapName()
+		return o.GetNamespace() == name.Namespace && o.GetName() == name.Name
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}, isFeatureSupportConfigMap)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config holds all the configuration that must be provided when creating the
+// controller.
+type Config struct {
+	// OperatorReleaseVersion is the version of the operator and of the
+	// router that it manages.
+	OperatorReleaseVersion string
+}
+
+// warnedIngress identifies the version of an Ingress for which the controller
+// emitted events.
+type warnedIngress struct {
+	uid        types.UID
+	generation int64
+}
+
+// reconciler handles the actual Ingress conformance reporting logic.
+type reconciler struct {
+	config Config
+
+	client client.Client
+	// cache is used to read Ingresses in every namespace.
+	cache    client.Reader
+	recorder record.EventRecorder
+
+	// warned records the version of each Ingress for which the controller
+	// last emitted events so that it emits events only when an Ingress
+	// changes.
+	warned map[types.NamespacedName]warnedIngress
+	// warnedLock protects warned, which Reconcile accesses concurrently
+	// for different Ingresses.
+	warnedLock sync.Mutex
+}
+
+// ingressClassToIngresses takes an ingressclass and returns a slice of
+// reconcile.Request with a request for each Ingress that specifies the
+// ingressclass.
+func (r *reconciler) ingressClassToIngresses(ctx context.Context, o client.Object) []reconcile.Request {
+	ingresses := &networkingv1.IngressList{}
+	if err := r.cache.List(ctx, ingresses); err != nil {
+		log.Error(err, "failed to list ingresses", "ingressclass", o.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range ingresses.Items {
+		if className := ingressClassName(&ingresses.Items[i]); className == nil || *className == o.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: ingresses.Items[i].Namespace,
+				Name:      ingresses.Items[i].Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// Reconcile expects request to refer to either the feature support configmap,
+// which it publishes, or to an Ingress, for which it emits an event for each
+// construct that the router ignores or implements differently than the
+// Ingress API specifies if the Ingress is handled by an ingresscontroller.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	if request.NamespacedName == operatorcontroller.IngressFeatureSupportConfigMapName() {
+		if _, _, err := r.ensureFeatureSupportConfigMap(ctx); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to ensure ingress feature support configmap: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	ingress := &networkingv1.Ingress{}
+	if err := r.cache.Get(ctx, request.NamespacedName, ingress); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.setWarned(request.NamespacedName, nil)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get ingress %q: %w", request.NamespacedName, err)
+	}
+
+	handled, err := r.isHandledByIngressController(ctx, ingress)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !handled {
+		r.setWarned(request.NamespacedName, nil)
+		return reconcile.Result{}, nil
+	}
+	version := warnedIngress{uid: ingress.UID, generation: ingress.Generation}
+	if !r.setWarned(request.NamespacedName, &version) {
+		return reconcile.Result{}, nil
+	}
+	for _, warning := range ingressWarnings(ingress) {
+		r.recorder.Event(ingress, corev1.EventTypeWarning, "UnsupportedIngressConstruct", warning)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// setWarned records that the controller emitted events for the given version
+// of the Ingress with the given name, or forgets the Ingress if version is nil.
+// Returns a Boolean value indicating whether the version differs from the one
+// that was previously recorded.
+func (r *reconciler) setWarned(name types.NamespacedName, version *warnedIngress) bool {
+	r.warnedLock.Lock()
+	defer r.warnedLock.Unlock()
+	if version == nil {
+		delete(r.warned, name)
+		return false
+	}
+	if previous, ok := r.warned[name]; ok && previous == *version {
+		return false
+	}
+	r.warned[name] = *version
+	return true
+}
+
+// ingressClassName returns the name of the ingressclass that the given Ingress
+// specifies, using the deprecated annotation if the Ingress does not specify
+// spec.ingressClassName, or nil if the Ingress specifies neither.
+func ingressClassName(ingress *networkingv1.Ingress) *string {
+	if ingress.Spec.IngressClassName != nil {
+		return ingress.Spec.IngressClassName
+	}
+	if className, ok := ingress.Annotations[legacyIngressClassAnnotation]; ok {
+		return &className
+	}
+	return nil
+}
+
+// isHandledByIngressController returns a Boolean value indicating whether the
+// given Ingress is converted into routes for an ingresscontroller.  This is
+// the case if the Ingress specifies an ingressclass that has the ingress-to-route
+// controller and that references an ingresscontroller, or if the Ingress
+// specifies no ingressclass and no other ingressclass is the default.
+func (r *reconciler) isHandledByIngressController(ctx context.Context, ingress *networkingv1.Ingress) (bool, error) {
+	className := ingressClassName(ingress)
+	if className == nil {
+		classes := &networkingv1.IngressClassList{}
+		if err := r.client.List(ctx, classes); err != nil {
+			return false, fmt.Errorf("failed to list ingressclasses: %w", err)
+		}
+		for i := range classes.Items {
+			if classes.Items[i].Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true" {
+				return isIngressControllerClass(&classes.Items[i]), nil
+			}
+		}
+		return true, nil
+	}
+	class := &networkingv1.IngressClass{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: *className}, class); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get ingressclass %q: %w", *className, err)
+	}
+	return isIngressControllerClass(class), nil
+}
+
+// isIngressControllerClass returns a Boolean value indicating whether the given
+// ingressclass has the ingress-to-route controller and references an
+// ingresscontroller.
+func isIngressControllerClass(class *networkingv1.IngressClass) bool {
+	return class.Spec.Controller == routev1.IngressToRouteIngressClassControllerName &&
+		class.Spec.Parameters != nil &&
+		class.Spec.Parameters.APIGroup != nil &&
+		*class.Spec.Parameters.APIGroup == operatorv1.GroupName &&
+		class.Spec.Parameters.Kind == "IngressController"
+}
@@ -0,0 +1,121 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// RouterAccessLogStatusCodes is the router environment variable that,
+	// when set, tells the router to emit access logs only for responses
+	// with a status code in one of the given ranges.  The value is a
+	// comma-separated list of ranges of the form "from-to", which the
+	// router renders into HAProxy's conditional logging configuration
+	// ("http-response set-log-level silent unless { status from:to ... }").
+	RouterAccessLogStatusCodes = "ROUTER_ACCESS_LOG_STATUS_CODES"
+
+	// minLoggedStatusCode and maxLoggedStatusCode are the bounds for
+	// status code ranges in the access log filter.
+	minLoggedStatusCode = 100
+	maxLoggedStatusCode = 599
+)
+
+// errorStatusCodeRange is the range of status codes that the errorsOnly access
+// log filter selects.
+var errorStatusCodeRange = statusCodeRange{From: 400, To: maxLoggedStatusCode}
+
+// accessLogFilter describes which responses the router emits access logs for.
+// At most one of ErrorsOnly and StatusCodeRanges may be specified.
+type accessLogFilter struct {
+	// ErrorsOnly, if true, selects responses with status codes 400 and
+	// above.
+	ErrorsOnly bool `json:"errorsOnly"`
+	// StatusCodeRanges selects responses with status codes in any of the
+	// given ranges.  The ranges may not overlap.
+	StatusCodeRanges []statusCodeRange `json:"statusCodeRanges"`
+}
+
+// statusCodeRange is an inclusive range of HTTP status codes.
+type statusCodeRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// accessLogFilterForIngressController returns the access log filter that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, or nil
+// if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func accessLogFilterForIngressController(ic *operatorv1.IngressController) (*accessLogFilter, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.AccessLogFilter, nil
+}
+
+// validateAccessLogFilter validates the given ingresscontroller's access log
+// filter, if it specifies one.  ErrorsOnly and StatusCodeRanges are mutually
+// exclusive, and each range must be within the valid status codes, must not be
+// reversed, and must not overlap another range.
+func validateAccessLogFilter(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	filter := overrides.AccessLogFilter
+	if filter == nil {
+		return nil
+	}
+	if filter.ErrorsOnly && len(filter.StatusCodeRanges) != 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.accessLogFilter.errorsOnly may not be specified together with spec.unsupportedConfigOverrides.accessLogFilter.statusCodeRanges")
+	}
+	var errs []error
+	for i, r := range filter.StatusCodeRanges {
+		switch {
+		case r.From < minLoggedStatusCode || r.To > maxLoggedStatusCode:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.accessLogFilter.statusCodeRanges[%d] (%d-%d) must be within %d-%d", i, r.From, r.To, minLoggedStatusCode, maxLoggedStatusCode))
+		case r.From > r.To:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.accessLogFilter.statusCodeRanges[%d] (%d-%d) has from greater than to", i, r.From, r.To))
+		}
+	}
+	if len(errs) != 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	ranges := sortedStatusCodeRanges(filter.StatusCodeRanges)
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].From <= ranges[i-1].To {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.accessLogFilter.statusCodeRanges has overlapping ranges %d-%d and %d-%d", ranges[i-1].From, ranges[i-1].To, ranges[i].From, ranges[i].To))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// sortedStatusCodeRanges returns a copy of the given ranges sorted by their
+// lower bounds.
+func sortedStatusCodeRanges(ranges []statusCodeRange) []statusCodeRange {
+	sorted := make([]statusCodeRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].From < sorted[j].From
+	})
+	return sorted
+}
+
+// serializeAccessLogFilter returns the value of the
+// ROUTER_ACCESS_LOG_STATUS_CODES environment variable for the given access log
+// filter, or the empty string if the filter selects all responses.
+func serializeAccessLogFilter(filter *accessLogFilter) string {
+	if filter == nil {
+		return ""
+	}
+	ranges := filter.StatusCodeRanges
+	if filter.ErrorsOnly {
+		ranges = []statusCodeRange{errorStatusCodeRange}
+	}
+	values := make([]string, 0, len(ranges))
+	for _, r := range sortedStatusCodeRanges(ranges) {
+		values = append(values, fmt.Sprintf("%d-%d", r.From, r.To))
+	}
+	return strings.Join(values, ",")
+}
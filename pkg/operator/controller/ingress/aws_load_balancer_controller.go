@@ -0,0 +1,194 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// awsLoadBalancerProvisionerInTree is the AWS load balancer provisioner
+	// value that specifies that the in-tree cloud provider provisions the
+	// load balancer for an ingresscontroller.  This is the default.
+	awsLoadBalancerProvisionerInTree = "InTree"
+	// awsLoadBalancerProvisionerALBController is the AWS load balancer
+	// provisioner value that specifies that the AWS Load Balancer
+	// Controller provisions the load balancer for an ingresscontroller.
+	//
+	// https://kubernetes-sigs.github.io/aws-load-balancer-controller/
+	awsLoadBalancerProvisionerALBController = "ALBController"
+
+	// awsLoadBalancerControllerClass is the load balancer class that tells
+	// the AWS Load Balancer Controller to provision a load balancer for a
+	// service and tells the in-tree cloud provider to ignore the service.
+	//
+	// https://kubernetes-sigs.github.io/aws-load-balancer-controller/latest/guide/service/nlb/#configuration
+	awsLoadBalancerControllerClass = "service.k8s.aws/nlb"
+
+	// awsLBTypeExternal is the value for the AWSLBTypeAnnotation annotation
+	// that specifies that an external controller, namely the AWS Load
+	// Balancer Controller, manages the load balancer.
+	awsLBTypeExternal = "external"
+
+	// awsLBSchemeAnnotation is the service annotation that the AWS Load
+	// Balancer Controller uses to determine whether a load balancer is
+	// internal or internet-facing.
+	awsLBSchemeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-scheme"
+	// awsLBSchemeInternal is the awsLBSchemeAnnotation value for an internal
+	// load balancer.
+	awsLBSchemeInternal = "internal"
+	// awsLBSchemeInternetFacing is the awsLBSchemeAnnotation value for an
+	// internet-facing load balancer.
+	awsLBSchemeInternetFacing = "internet-facing"
+
+	// awsLBNLBTargetTypeAnnotation is the service annotation that the AWS
+	// Load Balancer Controller uses to determine whether to register nodes
+	// or pod IP addresses as load balancer targets.
+	awsLBNLBTargetTypeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-nlb-target-type"
+	// awsLBNLBTargetTypeInstance is the awsLBNLBTargetTypeAnnotation value
+	// that specifies that nodes are registered as targets.  Pod IP
+	// addresses are not routable from the VPC with OpenShift's cluster
+	// network, so nodes must be used.
+	awsLBNLBTargetTypeInstance = "instance"
+
+	// awsLoadBalancerControllerCRDName is the name of a CRD that the AWS
+	// Load Balancer Controller installs.  The operator uses the presence of
+	// this CRD to determine whether the controller is installed.
+	awsLoadBalancerControllerCRDName = "targetgroupbindings.elbv2.k8s.aws"
+)
+
+// awsLoadBalancerProvisioner returns the AWS load balancer provisioner that the
+// given ingresscontroller specifies using
+// spec.unsupportedConfigOverrides.awsLoadBalancerProvisioner, or the default
+// provisioner if the ingresscontroller specifies none.  An error is returned
+// if spec.unsupportedConfigOverrides cannot be decoded or if the provisioner is
+// not valid.
+func awsLoadBalancerProvisioner(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.awsLoadBalancerProvisioner(ic.Name)
+}
+
+// awsLoadBalancerProvisioner returns the AWS load balancer provisioner that the
+// overrides for the named ingresscontroller specify, or the default
+// provisioner if they specify none.  An error is returned if the provisioner
+// is not valid.
+func (o *unsupportedConfigOverrides) awsLoadBalancerProvisioner(name string) (string, error) {
+	switch v := o.AWSLoadBalancerProvisioner; v {
+	case "", awsLoadBalancerProvisionerInTree:
+		return awsLoadBalancerProvisionerInTree, nil
+	case awsLoadBalancerProvisionerALBController:
+		return v, nil
+	default:
+		return "", fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.awsLoadBalancerProvisioner: %q; valid values are %q and %q", name, v, awsLoadBalancerProvisionerInTree, awsLoadBalancerProvisionerALBController)
+	}
+}
+
+// validateAWSLoadBalancerProvisioner validates the given ingresscontroller's
+// AWS load balancer provisioner.  The AWS Load Balancer Controller only
+// provisions network load balancers for services, so the provisioner cannot be
+// used with an explicitly specified classic load balancer.
+func validateAWSLoadBalancerProvisioner(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	provisioner, err := overrides.awsLoadBalancerProvisioner(ic.Name)
+	if err != nil {
+		return err
+	}
+	if provisioner != awsLoadBalancerProvisionerALBController {
+		return nil
+	}
+	eps := ic.Spec.EndpointPublishingStrategy
+	if eps == nil || eps.LoadBalancer == nil || eps.LoadBalancer.ProviderParameters == nil {
+		return nil
+	}
+	if aws := eps.LoadBalancer.ProviderParameters.AWS; aws != nil && aws.Type == operatorv1.AWSClassicLoadBalancer {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsLoadBalancerProvisioner %q cannot be used with an AWS load balancer of type %q", awsLoadBalancerProvisionerALBController, operatorv1.AWSClassicLoadBalancer)
+	}
+	return nil
+}
+
+// setAWSLoadBalancerControllerServiceFields mutates the given service so that
+// the AWS Load Balancer Controller, rather than the in-tree cloud provider,
+// provisions a network load balancer for it.
+func setAWSLoadBalancerControllerServiceFields(service *corev1.Service, isInternal bool) {
+	class := awsLoadBalancerControllerClass
+	service.Spec.LoadBalancerClass = &class
+	service.Annotations[AWSLBTypeAnnotation] = awsLBTypeExternal
+	service.Annotations[awsLBNLBTargetTypeAnnotation] = awsLBNLBTargetTypeInstance
+	service.Annotations[awsLBHealthCheckIntervalAnnotation] = awsLBHealthCheckIntervalNLB
+	if isInternal {
+		service.Annotations[awsLBSchemeAnnotation] = awsLBSchemeInternal
+	} else {
+		service.Annotations[awsLBSchemeAnnotation] = awsLBSchemeInternetFacing
+	}
+	// The connection idle timeout only applies to classic load balancers.
+	delete(service.Annotations, awsELBConnectionIdleTimeoutAnnotation)
+}
+
+// usesAWSLoadBalancerController returns a Boolean value indicating whether the
+// given ingresscontroller's load balancer is provisioned by the AWS Load
+// Balancer Controller on the given platform.
+func usesAWSLoadBalancerController(ic *operatorv1.IngressController, platform *configv1.PlatformStatus) bool {
+	if platform == nil || platform.Type != configv1.AWSPlatformType {
+		return false
+	}
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return false
+	}
+	provisioner, err := awsLoadBalancerProvisioner(ic)
+	return err == nil && provisioner == awsLoadBalancerProvisionerALBController
+}
+
+// awsLoadBalancerControllerInstalled returns a Boolean value indicating whether
+// the AWS Load Balancer Controller is installed, and an error value.
+func awsLoadBalancerControllerInstalled(cl client.Reader) (bool, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	name := types.NamespacedName{Name: awsLoadBalancerControllerCRDName}
+	if err := cl.Get(context.TODO(), name, crd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get CRD %q: %w", awsLoadBalancerControllerCRDName, err)
+	}
+	return true, nil
+}
+
+// computeAWSLoadBalancerControllerAvailableCondition computes the
+// ingresscontroller's "AWSLoadBalancerControllerAvailable" status condition
+// using the given result of checking whether the AWS Load Balancer Controller
+// is installed.
+func computeAWSLoadBalancerControllerAvailableCondition(installed bool, err error) operatorv1.OperatorCondition {
+	switch {
+	case err != nil:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerAWSLoadBalancerControllerAvailableConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "CheckFailed",
+			Message: fmt.Sprintf("Failed to determine whether the AWS Load Balancer Controller is installed: %v", err),
+		}
+	case !installed:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerAWSLoadBalancerControllerAvailableConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "ControllerNotInstalled",
+			Message: fmt.Sprintf("The IngressController specifies the %q AWS load balancer provisioner, but the AWS Load Balancer Controller is not installed (CRD %q was not found).  Install the AWS Load Balancer Controller or remove spec.unsupportedConfigOverrides.awsLoadBalancerProvisioner.", awsLoadBalancerProvisionerALBController, awsLoadBalancerControllerCRDName),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerAWSLoadBalancerControllerAvailableConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "ControllerInstalled",
+		Message: "The AWS Load Balancer Controller is installed",
+	}
+}
@@ -0,0 +1,268 @@
+package ingress
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_desiredLoadBalancerServiceAWSLoadBalancerController verifies that
+// desiredLoadBalancerService sets the load balancer class and annotations for
+// the AWS Load Balancer Controller if and only if the ingresscontroller
+// specifies the "ALBController" provisioner.
+func Test_desiredLoadBalancerServiceAWSLoadBalancerController(t *testing.T) {
+	testCases := []struct {
+		name                string
+		overrides           string
+		scope               operatorv1.LoadBalancerScope
+		expectClass         bool
+		expectedAnnotations map[string]string
+		expectError         bool
+	}{
+		{
+			name:        "no overrides",
+			overrides:   "",
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectClass: false,
+			expectedAnnotations: map[string]string{
+				awsLBHealthCheckIntervalAnnotation: awsLBHealthCheckIntervalDefault,
+			},
+		},
+		{
+			name:        "in-tree provisioner",
+			overrides:   `{"awsLoadBalancerProvisioner":"InTree"}`,
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectClass: false,
+			expectedAnnotations: map[string]string{
+				awsLBHealthCheckIntervalAnnotation: awsLBHealthCheckIntervalDefault,
+			},
+		},
+		{
+			name:        "ALB controller provisioner, external",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectClass: true,
+			expectedAnnotations: map[string]string{
+				AWSLBTypeAnnotation:                awsLBTypeExternal,
+				awsLBNLBTargetTypeAnnotation:       awsLBNLBTargetTypeInstance,
+				awsLBSchemeAnnotation:              awsLBSchemeInternetFacing,
+				awsLBHealthCheckIntervalAnnotation: awsLBHealthCheckIntervalNLB,
+			},
+		},
+		{
+			name:        "ALB controller provisioner, internal",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			scope:       operatorv1.InternalLoadBalancer,
+			expectClass: true,
+			expectedAnnotations: map[string]string{
+				AWSLBTypeAnnotation:          awsLBTypeExternal,
+				awsLBNLBTargetTypeAnnotation: awsLBNLBTargetTypeInstance,
+				awsLBSchemeAnnotation:        awsLBSchemeInternal,
+				awsInternalLBAnnotation:      "true",
+			},
+		},
+		{
+			name:        "invalid provisioner",
+			overrides:   `{"awsLoadBalancerProvisioner":"Magic"}`,
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+						LoadBalancer: &operatorv1.LoadBalancerStrategy{
+							Scope: tc.scope,
+						},
+					},
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			switch {
+			case tc.expectClass && svc.Spec.LoadBalancerClass == nil:
+				t.Errorf("expected load balancer class %q, got nil", awsLoadBalancerControllerClass)
+			case tc.expectClass && *svc.Spec.LoadBalancerClass != awsLoadBalancerControllerClass:
+				t.Errorf("expected load balancer class %q, got %q", awsLoadBalancerControllerClass, *svc.Spec.LoadBalancerClass)
+			case !tc.expectClass && svc.Spec.LoadBalancerClass != nil:
+				t.Errorf("expected nil load balancer class, got %q", *svc.Spec.LoadBalancerClass)
+			}
+			for k, v := range tc.expectedAnnotations {
+				if actual, ok := svc.Annotations[k]; !ok {
+					t.Errorf("missing expected annotation %s=%s", k, v)
+				} else if actual != v {
+					t.Errorf("expected annotation %s=%s, found %s=%s", k, v, k, actual)
+				}
+			}
+			if !tc.expectClass {
+				for _, k := range []string{awsLBSchemeAnnotation, awsLBNLBTargetTypeAnnotation} {
+					if v, ok := svc.Annotations[k]; ok {
+						t.Errorf("unexpected annotation %s=%s", k, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Test_validateAWSLoadBalancerProvisioner verifies that
+// validateAWSLoadBalancerProvisioner rejects invalid provisioners and the
+// "ALBController" provisioner with a classic load balancer.
+func Test_validateAWSLoadBalancerProvisioner(t *testing.T) {
+	aws := func(lbType operatorv1.AWSLoadBalancerType) *operatorv1.EndpointPublishingStrategy {
+		return &operatorv1.EndpointPublishingStrategy{
+			Type: operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{
+				ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+					Type: operatorv1.AWSLoadBalancerProvider,
+					AWS:  &operatorv1.AWSLoadBalancerParameters{Type: lbType},
+				},
+			},
+		}
+	}
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expectError: false,
+		},
+		{
+			description: "invalid provisioner",
+			overrides:   `{"awsLoadBalancerProvisioner":"Magic"}`,
+			expectError: true,
+		},
+		{
+			description: "ALB controller with default load balancer type",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType},
+			expectError: false,
+		},
+		{
+			description: "ALB controller with NLB",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			eps:         aws(operatorv1.AWSNetworkLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "ALB controller with CLB",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			eps:         aws(operatorv1.AWSClassicLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "in-tree with CLB",
+			overrides:   `{"awsLoadBalancerProvisioner":"InTree"}`,
+			eps:         aws(operatorv1.AWSClassicLoadBalancer),
+			expectError: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateAWSLoadBalancerProvisioner(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_awsLoadBalancerControllerInstalled verifies that
+// awsLoadBalancerControllerInstalled detects whether the AWS Load Balancer
+// Controller is installed and that the resulting status condition is correct.
+func Test_awsLoadBalancerControllerInstalled(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: awsLoadBalancerControllerCRDName},
+	}
+	testCases := []struct {
+		name              string
+		existingObjects   []client.Object
+		expectInstalled   bool
+		expectedCondition operatorv1.ConditionStatus
+	}{
+		{
+			name:              "controller not installed",
+			existingObjects:   nil,
+			expectInstalled:   false,
+			expectedCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name:              "controller installed",
+			existingObjects:   []client.Object{crd},
+			expectInstalled:   true,
+			expectedCondition: operatorv1.ConditionTrue,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.existingObjects...).Build()
+			installed, err := awsLoadBalancerControllerInstalled(cl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if installed != tc.expectInstalled {
+				t.Errorf("expected installed=%t, got %t", tc.expectInstalled, installed)
+			}
+			condition := computeAWSLoadBalancerControllerAvailableCondition(installed, err)
+			if condition.Status != tc.expectedCondition {
+				t.Errorf("expected condition status %s, got %s: %+v", tc.expectedCondition, condition.Status, condition)
+			}
+		})
+	}
+
+	// A client that does not know about CRDs returns an error, which should
+	// result in an unknown condition status.
+	cl := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+	installed, err := awsLoadBalancerControllerInstalled(cl)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if condition := computeAWSLoadBalancerControllerAvailableCondition(installed, err); condition.Status != operatorv1.ConditionUnknown {
+		t.Errorf("expected condition status %s, got %s: %+v", operatorv1.ConditionUnknown, condition.Status, condition)
+	}
+}
@@ -0,0 +1,132 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// awsNLBIPAddressTypeIPv4 is the IP address type of an AWS network
+	// load balancer that only has IPv4 addresses.
+	awsNLBIPAddressTypeIPv4 = "IPv4"
+	// awsNLBIPAddressTypeDualstack is the IP address type of an AWS
+	// network load balancer that has both IPv4 and IPv6 addresses.
+	awsNLBIPAddressTypeDualstack = "Dualstack"
+)
+
+// awsNetworkLoadBalancerConfig describes the AWS network load balancer
+// settings that an ingresscontroller specifies using
+// spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.
+type awsNetworkLoadBalancerConfig struct {
+	// IPAddressType is the IP address type of the load balancer, either
+	// "IPv4" or "Dualstack".  Empty means the cloud provider's default.
+	IPAddressType string `json:"ipAddressType,omitempty"`
+	// SecurityGroups are the IDs or names of the security groups to
+	// attach to the load balancer.  Empty means the provisioner's
+	// default.
+	SecurityGroups []string `json:"securityGroups,omitempty"`
+	// ClientIPPreservation specifies whether the load balancer's target
+	// groups preserve the client IP address, either "Enabled" or
+	// "Disabled".  Empty means the provisioner's default.
+	ClientIPPreservation string `json:"clientIPPreservation,omitempty"`
+}
+
+// awsNetworkLoadBalancerConfigForIngressController returns the AWS network
+// load balancer settings that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func awsNetworkLoadBalancerConfigForIngressController(ic *operatorv1.IngressController) (*awsNetworkLoadBalancerConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.AWSNetworkLoadBalancer, nil
+}
+
+// awsNLBIPAddressTypeForIngressController returns the IP address type that the
+// given ingresscontroller specifies for its AWS network load balancer in
+// spec.unsupportedConfigOverrides, or the empty string if it specifies none.
+// An error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func awsNLBIPAddressTypeForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.awsNLBIPAddressType(), nil
+}
+
+// awsNLBIPAddressType returns the IP address type that the overrides specify
+// for the AWS network load balancer, or the empty string if they specify none.
+func (o *unsupportedConfigOverrides) awsNLBIPAddressType() string {
+	if o.AWSNetworkLoadBalancer == nil {
+		return ""
+	}
+	return o.AWSNetworkLoadBalancer.IPAddressType
+}
+
+// validateAWSNLBIPAddressType validates the given ingresscontroller's AWS
+// network load balancer IP address type, if it specifies one.  The IP address
+// type can only be specified if the ingresscontroller uses an AWS network load
+// balancer.  If spec.endpointPublishingStrategy does not specify the load
+// balancer type, the type is determined by the cluster ingress config, so the
+// deployment reconciliation reports the error if the type is not NLB.
+func validateAWSNLBIPAddressType(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	ipAddressType := overrides.awsNLBIPAddressType()
+	if len(ipAddressType) == 0 {
+		return nil
+	}
+	switch ipAddressType {
+	case awsNLBIPAddressTypeIPv4, awsNLBIPAddressTypeDualstack:
+	default:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType must be %q or %q, got %q", awsNLBIPAddressTypeIPv4, awsNLBIPAddressTypeDualstack, ipAddressType)
+	}
+	eps := ic.Spec.EndpointPublishingStrategy
+	if eps == nil {
+		return nil
+	}
+	if eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	if lb := eps.LoadBalancer; lb != nil && lb.ProviderParameters != nil {
+		params := lb.ProviderParameters
+		if params.Type != operatorv1.AWSLoadBalancerProvider || params.AWS == nil || params.AWS.Type != operatorv1.AWSNetworkLoadBalancer {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType can only be used with an AWS load balancer of type %q", operatorv1.AWSNetworkLoadBalancer)
+		}
+	}
+	return nil
+}
+
+// setAWSNLBIPAddressTypeAnnotation sets the IP address type annotation on the
+// given service for the IP address type that the given ingresscontroller
+// specifies, if any.  An error is returned if the ingresscontroller specifies
+// an IP address type but does not use an AWS network load balancer.
+//
+// AWS allows changing the IP address type of an existing network load
+// balancer, so the annotation is managed, and a change is applied to the
+// existing load balancer without recreating it.
+func setAWSNLBIPAddressTypeAnnotation(ic *operatorv1.IngressController, service *corev1.Service) error {
+	ipAddressType, err := awsNLBIPAddressTypeForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	if len(ipAddressType) == 0 {
+		return nil
+	}
+	if getAWSLoadBalancerTypeInStatus(ic) != operatorv1.AWSNetworkLoadBalancer {
+		return fmt.Errorf("ingresscontroller %q specifies spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType but does not use an AWS load balancer of type %q", ic.Name, operatorv1.AWSNetworkLoadBalancer)
+	}
+	switch ipAddressType {
+	case awsNLBIPAddressTypeIPv4:
+		service.Annotations[dnsrecord.AWSLBIPAddressTypeAnnotation] = dnsrecord.AWSLBIPAddressTypeIPv4
+	case awsNLBIPAddressTypeDualstack:
+		service.Annotations[dnsrecord.AWSLBIPAddressTypeAnnotation] = dnsrecord.AWSLBIPAddressTypeDualStack
+	default:
+		return fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType: %q", ic.Name, ipAddressType)
+	}
+	return nil
+}
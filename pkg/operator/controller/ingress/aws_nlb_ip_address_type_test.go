@@ -0,0 +1,212 @@
+package ingress
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// awsLoadBalancerStrategy returns a LoadBalancerService endpoint publishing
+// strategy for an AWS load balancer of the given type.
+func awsLoadBalancerStrategy(lbType operatorv1.AWSLoadBalancerType) *operatorv1.EndpointPublishingStrategy {
+	return &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			Scope: operatorv1.ExternalLoadBalancer,
+			ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS:  &operatorv1.AWSLoadBalancerParameters{Type: lbType},
+			},
+		},
+	}
+}
+
+// Test_desiredLoadBalancerServiceAWSNLBIPAddressType verifies that
+// desiredLoadBalancerService sets the IP address type annotation for an AWS
+// network load balancer and returns an error for a classic load balancer.
+func Test_desiredLoadBalancerServiceAWSNLBIPAddressType(t *testing.T) {
+	testCases := []struct {
+		name             string
+		overrides        string
+		lbType           operatorv1.AWSLoadBalancerType
+		expectAnnotation string
+		expectError      bool
+	}{
+		{
+			name:   "no overrides",
+			lbType: operatorv1.AWSNetworkLoadBalancer,
+		},
+		{
+			name:             "dualstack NLB",
+			overrides:        `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			lbType:           operatorv1.AWSNetworkLoadBalancer,
+			expectAnnotation: "dualstack",
+		},
+		{
+			name:             "IPv4 NLB",
+			overrides:        `{"awsNetworkLoadBalancer":{"ipAddressType":"IPv4"}}`,
+			lbType:           operatorv1.AWSNetworkLoadBalancer,
+			expectAnnotation: "ipv4",
+		},
+		{
+			name:        "dualstack CLB",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			lbType:      operatorv1.AWSClassicLoadBalancer,
+			expectError: true,
+		},
+		{
+			name:        "invalid IP address type",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"IPv6"}}`,
+			lbType:      operatorv1.AWSNetworkLoadBalancer,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: awsLoadBalancerStrategy(tc.lbType),
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			actual, ok := svc.Annotations[dnsrecord.AWSLBIPAddressTypeAnnotation]
+			switch {
+			case len(tc.expectAnnotation) == 0 && ok:
+				t.Errorf("unexpected annotation %s=%s", dnsrecord.AWSLBIPAddressTypeAnnotation, actual)
+			case len(tc.expectAnnotation) != 0 && actual != tc.expectAnnotation:
+				t.Errorf("expected annotation %s=%s, found %q", dnsrecord.AWSLBIPAddressTypeAnnotation, tc.expectAnnotation, actual)
+			}
+		})
+	}
+}
+
+// Test_validateAWSNLBIPAddressType verifies that validateAWSNLBIPAddressType
+// rejects unknown IP address types and load balancers other than AWS NLBs.
+func Test_validateAWSNLBIPAddressType(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expectError: false,
+		},
+		{
+			description: "dualstack with default strategy",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			expectError: false,
+		},
+		{
+			description: "dualstack NLB",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "IPv4 NLB",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"IPv4"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "dualstack CLB",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSClassicLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "dualstack with host network",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType},
+			expectError: true,
+		},
+		{
+			description: "unknown IP address type",
+			overrides:   `{"awsNetworkLoadBalancer":{"ipAddressType":"dualstack-without-public-ipv4"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			switch err := validateAWSNLBIPAddressType(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_loadBalancerServiceChangedAWSNLBIPAddressType verifies that changing the
+// IP address type of an AWS network load balancer updates the service in place
+// rather than recreating the load balancer.
+func Test_loadBalancerServiceChangedAWSNLBIPAddressType(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Status: operatorv1.IngressControllerStatus{
+			EndpointPublishingStrategy: awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+		},
+	}
+	platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	_, current, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`)}
+	_, desired, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recreate, reason := shouldRecreateLoadBalancer(current, desired, platform); recreate {
+		t.Errorf("expected the load balancer not to be recreated, got reason %q", reason)
+	}
+	changed, updated := loadBalancerServiceChanged(current, desired)
+	if !changed {
+		t.Fatal("expected the service to be changed")
+	}
+	if v := updated.Annotations[dnsrecord.AWSLBIPAddressTypeAnnotation]; v != "dualstack" {
+		t.Errorf("expected annotation %s=dualstack, found %q", dnsrecord.AWSLBIPAddressTypeAnnotation, v)
+	}
+
+	// Reverting the override removes the annotation.
+	changed, reverted := loadBalancerServiceChanged(updated, current)
+	if !changed {
+		t.Fatal("expected the service to be changed")
+	}
+	if v, ok := reverted.Annotations[dnsrecord.AWSLBIPAddressTypeAnnotation]; ok {
+		t.Errorf("expected annotation %s to be removed, found %q", dnsrecord.AWSLBIPAddressTypeAnnotation, v)
+	}
+}
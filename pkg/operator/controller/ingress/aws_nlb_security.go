@@ -0,0 +1,215 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// awsLBSecurityGroupsAnnotation is the service annotation that the AWS
+	// Load Balancer Controller uses to determine which security groups to
+	// attach to a network load balancer, as a comma-separated list of
+	// security group IDs or names.
+	//
+	// https://kubernetes-sigs.github.io/aws-load-balancer-controller/latest/guide/service/annotations/#security-groups
+	awsLBSecurityGroupsAnnotation = "service.beta.kubernetes.io/aws-load-balancer-security-groups"
+
+	// awsLBTargetGroupAttributesAnnotation is the service annotation that
+	// the AWS Load Balancer Controller uses to set attributes on the
+	// target groups of a network load balancer, as a comma-separated list
+	// of key=value pairs.
+	//
+	// https://kubernetes-sigs.github.io/aws-load-balancer-controller/latest/guide/service/annotations/#target-group-attributes
+	awsLBTargetGroupAttributesAnnotation = "service.beta.kubernetes.io/aws-load-balancer-target-group-attributes"
+	// awsLBPreserveClientIPAttribute is the target group attribute that
+	// specifies whether the load balancer preserves the client IP address
+	// when it forwards connections to targets.
+	awsLBPreserveClientIPAttribute = "preserve_client_ip.enabled"
+
+	// awsNLBClientIPPreservationEnabled and
+	// awsNLBClientIPPreservationDisabled are the values of the
+	// "awsNetworkLoadBalancer.clientIPPreservation" unsupported config
+	// override.
+	awsNLBClientIPPreservationEnabled  = "Enabled"
+	awsNLBClientIPPreservationDisabled = "Disabled"
+
+	// awsNLBMaxSecurityGroups is the maximum number of security groups
+	// that AWS allows attaching to a network load balancer.
+	awsNLBMaxSecurityGroups = 5
+)
+
+// validateAWSNLBSecurity validates the security groups and client IP
+// preservation setting that the given ingresscontroller specifies for its AWS
+// network load balancer, if it specifies either.  The AWS Load Balancer
+// Controller implements both settings, so they can only be used with the
+// "ALBController" load balancer provisioner.  As with the IP address type, if
+// spec.endpointPublishingStrategy does not specify the load balancer type, the
+// deployment reconciliation reports the error if the type is not NLB.
+func validateAWSNLBSecurity(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.AWSNetworkLoadBalancer
+	if config == nil || (len(config.SecurityGroups) == 0 && len(config.ClientIPPreservation) == 0) {
+		return nil
+	}
+	if len(config.SecurityGroups) > awsNLBMaxSecurityGroups {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups must have at most %d security groups, got %d", awsNLBMaxSecurityGroups, len(config.SecurityGroups))
+	}
+	seen := sets.NewString()
+	for i, sg := range config.SecurityGroups {
+		if len(sg) == 0 || strings.ContainsAny(sg, ", \t\n") {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups[%d] must be a security group ID or name without commas or whitespace, got %q", i, sg)
+		}
+		if seen.Has(sg) {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups[%d] duplicates security group %q", i, sg)
+		}
+		seen.Insert(sg)
+	}
+	switch config.ClientIPPreservation {
+	case "", awsNLBClientIPPreservationEnabled, awsNLBClientIPPreservationDisabled:
+	default:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.clientIPPreservation must be %q or %q, got %q", awsNLBClientIPPreservationEnabled, awsNLBClientIPPreservationDisabled, config.ClientIPPreservation)
+	}
+	if provisioner, err := awsLoadBalancerProvisioner(ic); err != nil {
+		// validateAWSLoadBalancerProvisioner reports the error.
+		return nil
+	} else if provisioner != awsLoadBalancerProvisionerALBController {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups and clientIPPreservation can only be used with spec.unsupportedConfigOverrides.awsLoadBalancerProvisioner %q", awsLoadBalancerProvisionerALBController)
+	}
+	eps := ic.Spec.EndpointPublishingStrategy
+	if eps == nil {
+		return nil
+	}
+	if eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups and clientIPPreservation can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	if lb := eps.LoadBalancer; lb != nil && lb.ProviderParameters != nil {
+		params := lb.ProviderParameters
+		if params.Type != operatorv1.AWSLoadBalancerProvider || params.AWS == nil || params.AWS.Type != operatorv1.AWSNetworkLoadBalancer {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups and clientIPPreservation can only be used with an AWS load balancer of type %q", operatorv1.AWSNetworkLoadBalancer)
+		}
+	}
+	return nil
+}
+
+// setAWSNLBSecurityAnnotations sets the security groups and target group
+// attributes annotations on the given service for the security groups and
+// client IP preservation setting that the given ingresscontroller specifies,
+// if any.  An error is returned if the ingresscontroller specifies either
+// setting but does not use an AWS network load balancer that the AWS Load
+// Balancer Controller provisions.
+func setAWSNLBSecurityAnnotations(ic *operatorv1.IngressController, service *corev1.Service) error {
+	config, err := awsNetworkLoadBalancerConfigForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	if config == nil || (len(config.SecurityGroups) == 0 && len(config.ClientIPPreservation) == 0) {
+		return nil
+	}
+	if getAWSLoadBalancerTypeInStatus(ic) != operatorv1.AWSNetworkLoadBalancer {
+		return fmt.Errorf("ingresscontroller %q specifies spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups or clientIPPreservation but does not use an AWS load balancer of type %q", ic.Name, operatorv1.AWSNetworkLoadBalancer)
+	}
+	if provisioner, err := awsLoadBalancerProvisioner(ic); err != nil {
+		return err
+	} else if provisioner != awsLoadBalancerProvisionerALBController {
+		return fmt.Errorf("ingresscontroller %q specifies spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups or clientIPPreservation but does not use the %q load balancer provisioner", ic.Name, awsLoadBalancerProvisionerALBController)
+	}
+	if len(config.SecurityGroups) != 0 {
+		service.Annotations[awsLBSecurityGroupsAnnotation] = strings.Join(config.SecurityGroups, ",")
+	}
+	switch config.ClientIPPreservation {
+	case awsNLBClientIPPreservationEnabled:
+		service.Annotations[awsLBTargetGroupAttributesAnnotation] = awsLBPreserveClientIPAttribute + "=true"
+	case awsNLBClientIPPreservationDisabled:
+		service.Annotations[awsLBTargetGroupAttributesAnnotation] = awsLBPreserveClientIPAttribute + "=false"
+	case "":
+	default:
+		return fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.clientIPPreservation: %q", ic.Name, config.ClientIPPreservation)
+	}
+	return nil
+}
+
+// awsLBSecurityGroupsFromAnnotation returns the sorted security groups in the
+// given service's security groups annotation.
+func awsLBSecurityGroupsFromAnnotation(service *corev1.Service) []string {
+	var securityGroups []string
+	for _, sg := range strings.Split(service.Annotations[awsLBSecurityGroupsAnnotation], ",") {
+		if sg = strings.TrimSpace(sg); len(sg) != 0 {
+			securityGroups = append(securityGroups, sg)
+		}
+	}
+	sort.Strings(securityGroups)
+	return securityGroups
+}
+
+// awsLBSecurityGroupsRequireRecreation returns true if changing the security
+// groups of a network load balancer from those of the current service to those
+// of the desired service requires recreating the load balancer and false
+// otherwise.  AWS can only attach security groups to a network load balancer
+// when it creates the load balancer and does not allow removing all security
+// groups from a network load balancer that has them, but it does allow
+// replacing one set of security groups with another.  Only load balancers that
+// the AWS Load Balancer Controller provisions are considered; the operator
+// does not manage the annotation for other load balancers.
+func awsLBSecurityGroupsRequireRecreation(current, desired *corev1.Service) bool {
+	if !usesAWSLoadBalancerControllerClass(current) || !usesAWSLoadBalancerControllerClass(desired) {
+		return false
+	}
+	return (len(awsLBSecurityGroupsFromAnnotation(current)) == 0) != (len(awsLBSecurityGroupsFromAnnotation(desired)) == 0)
+}
+
+// awsLBSecurityGroupsChanged returns a Boolean value indicating whether the
+// security groups annotation of the current service must be updated in place
+// to match the expected service, and the expected annotation value.  The
+// annotation is only updated if both services have security groups; changes
+// that add or remove all security groups require recreating the load
+// balancer, so they are not applied to the current service.
+func awsLBSecurityGroupsChanged(current, expected *corev1.Service) (bool, string) {
+	if !usesAWSLoadBalancerControllerClass(current) || !usesAWSLoadBalancerControllerClass(expected) {
+		return false, ""
+	}
+	currentSGs, expectedSGs := awsLBSecurityGroupsFromAnnotation(current), awsLBSecurityGroupsFromAnnotation(expected)
+	if len(currentSGs) == 0 || len(expectedSGs) == 0 {
+		return false, ""
+	}
+	if strings.Join(currentSGs, ",") == strings.Join(expectedSGs, ",") {
+		return false, ""
+	}
+	return true, expected.Annotations[awsLBSecurityGroupsAnnotation]
+}
+
+// awsNLBSecurityGroupsIsProgressing returns an error value indicating whether
+// the security groups of the given service differ from those that the given
+// ingresscontroller specifies in a way that requires recreating the load
+// balancer, in which case the change does not take effect until the service
+// is deleted and recreated.
+func awsNLBSecurityGroupsIsProgressing(ic *operatorv1.IngressController, service *corev1.Service, platform *configv1.PlatformStatus) error {
+	if !usesAWSLoadBalancerController(ic, platform) || getAWSLoadBalancerTypeInStatus(ic) != operatorv1.AWSNetworkLoadBalancer {
+		return nil
+	}
+	config, err := awsNetworkLoadBalancerConfigForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	desired := &corev1.Service{Spec: corev1.ServiceSpec{LoadBalancerClass: service.Spec.LoadBalancerClass}}
+	if config != nil && len(config.SecurityGroups) != 0 {
+		desired.Annotations = map[string]string{awsLBSecurityGroupsAnnotation: strings.Join(config.SecurityGroups, ",")}
+	}
+	if !awsLBSecurityGroupsRequireRecreation(service, desired) {
+		return nil
+	}
+	have, want := strings.Join(awsLBSecurityGroupsFromAnnotation(service), ","), strings.Join(awsLBSecurityGroupsFromAnnotation(desired), ",")
+	return fmt.Errorf("The IngressController security groups were changed from %q to %q; changes will not take effect until the service is recreated because AWS cannot add security groups to, or remove all security groups from, an existing network load balancer.  To effectuate this change, you must delete the service: `oc -n %s delete svc/%s`; the service load-balancer will then be deprovisioned and a new one created.  This will most likely cause the new load-balancer to have a different host name and IP address from the old one's.  Alternatively, you can revert spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.securityGroups on the IngressController.", have, want, service.Namespace, service.Name)
+}
+
+// usesAWSLoadBalancerControllerClass returns a Boolean value indicating whether
+// the given service has the load balancer class of the AWS Load Balancer
+// Controller.
+func usesAWSLoadBalancerControllerClass(service *corev1.Service) bool {
+	return service.Spec.LoadBalancerClass != nil && *service.Spec.LoadBalancerClass == awsLoadBalancerControllerClass
+}
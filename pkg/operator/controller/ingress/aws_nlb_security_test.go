@@ -0,0 +1,308 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_desiredLoadBalancerServiceAWSNLBSecurity verifies that
+// desiredLoadBalancerService sets the security groups and target group
+// attributes annotations for an AWS network load balancer that the AWS Load
+// Balancer Controller provisions and returns an error otherwise.
+func Test_desiredLoadBalancerServiceAWSNLBSecurity(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		overrides                string
+		lbType                   operatorv1.AWSLoadBalancerType
+		expectSecurityGroups     string
+		expectTargetGroupAttribs string
+		expectError              bool
+	}{
+		{
+			name:      "no overrides",
+			overrides: `{"awsLoadBalancerProvisioner":"ALBController"}`,
+			lbType:    operatorv1.AWSNetworkLoadBalancer,
+		},
+		{
+			name:                 "security groups",
+			overrides:            `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1","sg-2"]}}`,
+			lbType:               operatorv1.AWSNetworkLoadBalancer,
+			expectSecurityGroups: "sg-1,sg-2",
+		},
+		{
+			name:                     "client IP preservation enabled",
+			overrides:                `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"clientIPPreservation":"Enabled"}}`,
+			lbType:                   operatorv1.AWSNetworkLoadBalancer,
+			expectTargetGroupAttribs: "preserve_client_ip.enabled=true",
+		},
+		{
+			name:                     "security groups and client IP preservation disabled",
+			overrides:                `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1"],"clientIPPreservation":"Disabled"}}`,
+			lbType:                   operatorv1.AWSNetworkLoadBalancer,
+			expectSecurityGroups:     "sg-1",
+			expectTargetGroupAttribs: "preserve_client_ip.enabled=false",
+		},
+		{
+			name:        "security groups without the ALBController provisioner",
+			overrides:   `{"awsNetworkLoadBalancer":{"securityGroups":["sg-1"]}}`,
+			lbType:      operatorv1.AWSNetworkLoadBalancer,
+			expectError: true,
+		},
+		{
+			name:        "client IP preservation with a CLB",
+			overrides:   `{"awsNetworkLoadBalancer":{"clientIPPreservation":"Enabled"}}`,
+			lbType:      operatorv1.AWSClassicLoadBalancer,
+			expectError: true,
+		},
+		{
+			name:        "invalid client IP preservation",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"clientIPPreservation":"true"}}`,
+			lbType:      operatorv1.AWSNetworkLoadBalancer,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: awsLoadBalancerStrategy(tc.lbType),
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			for annotation, expected := range map[string]string{
+				awsLBSecurityGroupsAnnotation:        tc.expectSecurityGroups,
+				awsLBTargetGroupAttributesAnnotation: tc.expectTargetGroupAttribs,
+			} {
+				actual, ok := svc.Annotations[annotation]
+				switch {
+				case len(expected) == 0 && ok:
+					t.Errorf("unexpected annotation %s=%s", annotation, actual)
+				case len(expected) != 0 && actual != expected:
+					t.Errorf("expected annotation %s=%s, found %q", annotation, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+// Test_validateAWSNLBSecurity verifies that validateAWSNLBSecurity rejects
+// invalid security groups and client IP preservation settings and settings
+// that the load balancer cannot honor.
+func Test_validateAWSNLBSecurity(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expectError: false,
+		},
+		{
+			description: "security groups with default strategy",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1"]}}`,
+			expectError: false,
+		},
+		{
+			description: "security groups and client IP preservation on NLB",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1","sg-2"],"clientIPPreservation":"Disabled"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "security groups without the ALBController provisioner",
+			overrides:   `{"awsNetworkLoadBalancer":{"securityGroups":["sg-1"]}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "client IP preservation with host network",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"clientIPPreservation":"Enabled"}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType},
+			expectError: true,
+		},
+		{
+			description: "invalid client IP preservation",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"clientIPPreservation":"enabled"}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "empty security group",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":[""]}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "security group with a comma",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1,sg-2"]}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "duplicate security groups",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1","sg-1"]}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "too many security groups",
+			overrides:   `{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":{"securityGroups":["sg-1","sg-2","sg-3","sg-4","sg-5","sg-6"]}}`,
+			eps:         awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			switch err := validateAWSNLBSecurity(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_loadBalancerServiceChangedAWSNLBSecurity verifies which changes to the
+// security groups and client IP preservation setting of an AWS network load
+// balancer update the service in place and which require recreating the load
+// balancer.
+func Test_loadBalancerServiceChangedAWSNLBSecurity(t *testing.T) {
+	testCases := []struct {
+		name            string
+		current         string
+		desired         string
+		expectChanged   bool
+		expectRecreate  bool
+		expectProgress  bool
+		expectSGsInSync bool
+	}{
+		{
+			name:    "no change",
+			current: `{"securityGroups":["sg-1"]}`,
+			desired: `{"securityGroups":["sg-1"]}`,
+		},
+		{
+			name:    "reordered security groups",
+			current: `{"securityGroups":["sg-1","sg-2"]}`,
+			desired: `{"securityGroups":["sg-2","sg-1"]}`,
+		},
+		{
+			name:            "replaced security groups",
+			current:         `{"securityGroups":["sg-1"]}`,
+			desired:         `{"securityGroups":["sg-2","sg-3"]}`,
+			expectChanged:   true,
+			expectSGsInSync: true,
+		},
+		{
+			name:           "added security groups",
+			current:        `{}`,
+			desired:        `{"securityGroups":["sg-1"]}`,
+			expectRecreate: true,
+			expectProgress: true,
+		},
+		{
+			name:           "removed security groups",
+			current:        `{"securityGroups":["sg-1"]}`,
+			desired:        `{}`,
+			expectRecreate: true,
+			expectProgress: true,
+		},
+		{
+			name:          "enabled client IP preservation",
+			current:       `{}`,
+			desired:       `{"clientIPPreservation":"Enabled"}`,
+			expectChanged: true,
+		},
+		{
+			name:          "toggled client IP preservation",
+			current:       `{"clientIPPreservation":"Enabled"}`,
+			desired:       `{"clientIPPreservation":"Disabled"}`,
+			expectChanged: true,
+		},
+	}
+
+	platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	icWithConfig := func(config string) *operatorv1.IngressController {
+		return &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: operatorv1.IngressControllerSpec{
+				UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"awsLoadBalancerProvisioner":"ALBController","awsNetworkLoadBalancer":` + config + `}`)},
+			},
+			Status: operatorv1.IngressControllerStatus{
+				EndpointPublishingStrategy: awsLoadBalancerStrategy(operatorv1.AWSNetworkLoadBalancer),
+			},
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, current, err := desiredLoadBalancerService(icWithConfig(tc.current), metav1.OwnerReference{}, platform, true, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			desiredIC := icWithConfig(tc.desired)
+			_, desired, err := desiredLoadBalancerService(desiredIC, metav1.OwnerReference{}, platform, true, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if recreate, reason := shouldRecreateLoadBalancer(current, desired, platform); recreate != tc.expectRecreate {
+				t.Errorf("expected recreate to be %t, got %t with reason %q", tc.expectRecreate, recreate, reason)
+			}
+			changed, updated := loadBalancerServiceChanged(current, desired)
+			if changed != tc.expectChanged {
+				t.Fatalf("expected changed to be %t, got %t", tc.expectChanged, changed)
+			}
+			if changed {
+				annotations := []string{awsLBTargetGroupAttributesAnnotation}
+				if tc.expectSGsInSync {
+					annotations = append(annotations, awsLBSecurityGroupsAnnotation)
+				}
+				for _, annotation := range annotations {
+					if actual, expected := updated.Annotations[annotation], desired.Annotations[annotation]; actual != expected {
+						t.Errorf("expected annotation %s=%q, found %q", annotation, expected, actual)
+					}
+				}
+			}
+			err = awsNLBSecurityGroupsIsProgressing(desiredIC, current, platform)
+			switch {
+			case err == nil && tc.expectProgress:
+				t.Error("expected a progressing error, got nil")
+			case err != nil && !tc.expectProgress:
+				t.Errorf("unexpected progressing error: %v", err)
+			case err != nil && !strings.Contains(err.Error(), "delete svc/"):
+				t.Errorf("expected the progressing error to explain how to recreate the service, got %q", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package ingress
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// azurePIPPrefixIDAnnotation is the annotation used on a service to
+	// specify the ID of the Azure public IP prefix from which the cloud
+	// provider allocates the load balancer's frontend public IP address.
+	// Only external load balancers have a public frontend IP address.
+	//
+	// https://cloud-provider-azure.sigs.k8s.io/topics/loadbalancer/#loadbalancer-annotations
+	azurePIPPrefixIDAnnotation = "service.beta.kubernetes.io/azure-pip-prefix-id"
+
+	// azureLBTCPIdleTimeoutAnnotation is the annotation used on a service
+	// to specify the TCP idle timeout, in minutes, of the Azure load
+	// balancer rules for the service.
+	//
+	// https://cloud-provider-azure.sigs.k8s.io/topics/loadbalancer/#loadbalancer-annotations
+	azureLBTCPIdleTimeoutAnnotation = "service.beta.kubernetes.io/azure-load-balancer-tcp-idle-timeout"
+
+	// azureLBIdleTimeoutMinutesMin and azureLBIdleTimeoutMinutesMax are the
+	// bounds that the operator accepts for a load balancer rule's idle
+	// timeout.  Azure accepts up to 100 minutes for some SKUs and
+	// protocols, but only up to 30 minutes for all of them.
+	azureLBIdleTimeoutMinutesMin = 4
+	azureLBIdleTimeoutMinutesMax = 30
+)
+
+// azurePIPPrefixIDRegexp matches the resource ID of an Azure public IP prefix.
+// Azure resource IDs are case-insensitive.
+var azurePIPPrefixIDRegexp = regexp.MustCompile(`(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Network/publicIPPrefixes/[^/]+$`)
+
+// azureLoadBalancerConfig describes the Azure load balancer settings that an
+// ingresscontroller specifies using
+// spec.unsupportedConfigOverrides.azureLoadBalancer.
+type azureLoadBalancerConfig struct {
+	// PublicIPPrefixID is the resource ID of the public IP prefix from
+	// which to allocate the frontend IP address of an external load
+	// balancer.
+	PublicIPPrefixID string `json:"publicIPPrefixID,omitempty"`
+	// IdleTimeoutMinutes is the TCP idle timeout of the load balancer
+	// rules.  Zero means the cloud provider's default.
+	IdleTimeoutMinutes int32 `json:"idleTimeoutMinutes,omitempty"`
+}
+
+// azureLoadBalancerConfigForIngressController returns the Azure load balancer
+// settings that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func azureLoadBalancerConfigForIngressController(ic *operatorv1.IngressController) (*azureLoadBalancerConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.AzureLoadBalancer, nil
+}
+
+// validateAzureLoadBalancerConfig validates the given ingresscontroller's Azure
+// load balancer settings, if it specifies any.  A public IP prefix can only be
+// used with an external load balancer because an internal load balancer has no
+// public frontend IP address.
+func validateAzureLoadBalancerConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.AzureLoadBalancer
+	if config == nil {
+		return nil
+	}
+	if v := config.IdleTimeoutMinutes; v != 0 && (v < azureLBIdleTimeoutMinutesMin || v > azureLBIdleTimeoutMinutesMax) {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.azureLoadBalancer.idleTimeoutMinutes must be between %d and %d, got %d", azureLBIdleTimeoutMinutesMin, azureLBIdleTimeoutMinutesMax, v)
+	}
+	if len(config.PublicIPPrefixID) == 0 {
+		return nil
+	}
+	if !azurePIPPrefixIDRegexp.MatchString(config.PublicIPPrefixID) {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.azureLoadBalancer.publicIPPrefixID %q is not a valid public IP prefix resource ID; expected /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Network/publicIPPrefixes/<name>", config.PublicIPPrefixID)
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.LoadBalancer != nil && eps.LoadBalancer.Scope == operatorv1.InternalLoadBalancer {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.azureLoadBalancer.publicIPPrefixID cannot be used with a load balancer of scope %q", operatorv1.InternalLoadBalancer)
+	}
+	return nil
+}
+
+// setAzureLoadBalancerServiceAnnotations sets the annotations on the given
+// service for the given Azure load balancer settings.  The public IP prefix is
+// only set for an external load balancer.
+func setAzureLoadBalancerServiceAnnotations(service *corev1.Service, config *azureLoadBalancerConfig, isInternal bool) {
+	if config == nil {
+		return
+	}
+	if config.IdleTimeoutMinutes != 0 {
+		service.Annotations[azureLBTCPIdleTimeoutAnnotation] = strconv.Itoa(int(config.IdleTimeoutMinutes))
+	}
+	if len(config.PublicIPPrefixID) != 0 && !isInternal {
+		service.Annotations[azurePIPPrefixIDAnnotation] = config.PublicIPPrefixID
+	}
+}
+
+// azurePIPPrefixIDEqual returns true if the two given services request the
+// same public IP prefix and false otherwise.  The cloud provider does not
+// replace the frontend IP address of an existing load balancer when the public
+// IP prefix changes, so changing it requires recreating the load balancer.
+// The prefix is irrelevant for an internal load balancer, so it is ignored if
+// the desired service is internal.
+func azurePIPPrefixIDEqual(current, desired *corev1.Service) bool {
+	if IsServiceInternal(desired) {
+		return true
+	}
+	return current.Annotations[azurePIPPrefixIDAnnotation] == desired.Annotations[azurePIPPrefixIDAnnotation]
+}
+
+// azureLoadBalancerIsProgressing returns an error value indicating whether the
+// public IP prefix of the given service differs from the one that the given
+// ingresscontroller specifies, in which case the service must be deleted and
+// recreated for the change to take effect.
+func azureLoadBalancerIsProgressing(ic *operatorv1.IngressController, service *corev1.Service, platform *configv1.PlatformStatus) error {
+	if platform.Type != configv1.AzurePlatformType || IsServiceInternal(service) {
+		return nil
+	}
+	if lb := ic.Status.EndpointPublishingStrategy.LoadBalancer; lb != nil && lb.Scope == operatorv1.InternalLoadBalancer {
+		return nil
+	}
+	config, err := azureLoadBalancerConfigForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	want := ""
+	if config != nil {
+		want = config.PublicIPPrefixID
+	}
+	have := service.Annotations[azurePIPPrefixIDAnnotation]
+	if want == have {
+		return nil
+	}
+	return fmt.Errorf("The IngressController public IP prefix was changed from %q to %q.  To effectuate this change, you must delete the service: `oc -n %s delete svc/%s`; the service load-balancer will then be deprovisioned and a new one created.  This will cause the new load-balancer to have a different IP address from the old one's.  Alternatively, you can revert spec.unsupportedConfigOverrides.azureLoadBalancer.publicIPPrefixID on the IngressController to %q.", have, want, service.Namespace, service.Name, have)
+}
@@ -0,0 +1,387 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const testAzurePIPPrefixID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ci-rg/providers/Microsoft.Network/publicIPPrefixes/ingress-prefix"
+
+// Test_desiredLoadBalancerServiceAzure verifies that desiredLoadBalancerService
+// sets the Azure public IP prefix annotation only for external load balancers
+// and the idle timeout annotation for both scopes.
+func Test_desiredLoadBalancerServiceAzure(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		overrides             string
+		scope                 operatorv1.LoadBalancerScope
+		expectedAnnotations   map[string]string
+		unexpectedAnnotations []string
+		expectError           bool
+	}{
+		{
+			name:                  "no overrides, external",
+			scope:                 operatorv1.ExternalLoadBalancer,
+			unexpectedAnnotations: []string{azurePIPPrefixIDAnnotation, azureLBTCPIdleTimeoutAnnotation},
+		},
+		{
+			name:      "prefix and idle timeout, external",
+			overrides: `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `","idleTimeoutMinutes":30}}`,
+			scope:     operatorv1.ExternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				azurePIPPrefixIDAnnotation:      testAzurePIPPrefixID,
+				azureLBTCPIdleTimeoutAnnotation: "30",
+			},
+			unexpectedAnnotations: []string{azureInternalLBAnnotation},
+		},
+		{
+			name:      "idle timeout, internal",
+			overrides: `{"azureLoadBalancer":{"idleTimeoutMinutes":4}}`,
+			scope:     operatorv1.InternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				azureInternalLBAnnotation:       "true",
+				azureLBTCPIdleTimeoutAnnotation: "4",
+			},
+			unexpectedAnnotations: []string{azurePIPPrefixIDAnnotation},
+		},
+		{
+			name:      "prefix, internal",
+			overrides: `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			scope:     operatorv1.InternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				azureInternalLBAnnotation: "true",
+			},
+			unexpectedAnnotations: []string{azurePIPPrefixIDAnnotation, azureLBTCPIdleTimeoutAnnotation},
+		},
+		{
+			name:        "malformed overrides",
+			overrides:   `{"azureLoadBalancer":`,
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+						LoadBalancer: &operatorv1.LoadBalancerStrategy{
+							Scope: tc.scope,
+						},
+					},
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: configv1.AzurePlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			for k, v := range tc.expectedAnnotations {
+				if actual, ok := svc.Annotations[k]; !ok {
+					t.Errorf("missing expected annotation %s=%s", k, v)
+				} else if actual != v {
+					t.Errorf("expected annotation %s=%s, found %s=%s", k, v, k, actual)
+				}
+			}
+			for _, k := range tc.unexpectedAnnotations {
+				if v, ok := svc.Annotations[k]; ok {
+					t.Errorf("unexpected annotation %s=%s", k, v)
+				}
+			}
+		})
+	}
+}
+
+// Test_validateAzureLoadBalancerConfig verifies that
+// validateAzureLoadBalancerConfig rejects out-of-range idle timeouts, malformed
+// public IP prefix IDs, and public IP prefixes with internal load balancers.
+func Test_validateAzureLoadBalancerConfig(t *testing.T) {
+	scope := func(scope operatorv1.LoadBalancerScope) *operatorv1.EndpointPublishingStrategy {
+		return &operatorv1.EndpointPublishingStrategy{
+			Type:         operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{Scope: scope},
+		}
+	}
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expectError: false,
+		},
+		{
+			description: "minimum idle timeout",
+			overrides:   `{"azureLoadBalancer":{"idleTimeoutMinutes":4}}`,
+			expectError: false,
+		},
+		{
+			description: "maximum idle timeout",
+			overrides:   `{"azureLoadBalancer":{"idleTimeoutMinutes":30}}`,
+			expectError: false,
+		},
+		{
+			description: "idle timeout too short",
+			overrides:   `{"azureLoadBalancer":{"idleTimeoutMinutes":3}}`,
+			expectError: true,
+		},
+		{
+			description: "idle timeout too long",
+			overrides:   `{"azureLoadBalancer":{"idleTimeoutMinutes":31}}`,
+			expectError: true,
+		},
+		{
+			description: "prefix with default scope",
+			overrides:   `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			expectError: false,
+		},
+		{
+			description: "prefix with external scope",
+			overrides:   `{"azureLoadBalancer":{"publicIPPrefixID":"` + strings.ToLower(testAzurePIPPrefixID) + `"}}`,
+			eps:         scope(operatorv1.ExternalLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "prefix with internal scope",
+			overrides:   `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "idle timeout with internal scope",
+			overrides:   `{"azureLoadBalancer":{"idleTimeoutMinutes":15}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+			expectError: false,
+		},
+		{
+			description: "public IP address instead of prefix",
+			overrides:   `{"azureLoadBalancer":{"publicIPPrefixID":"/subscriptions/0/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/ip"}}`,
+			expectError: true,
+		},
+		{
+			description: "prefix name instead of ID",
+			overrides:   `{"azureLoadBalancer":{"publicIPPrefixID":"ingress-prefix"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateAzureLoadBalancerConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_shouldRecreateLoadBalancerAzure verifies that shouldRecreateLoadBalancer
+// requires recreating an Azure load balancer when its public IP prefix changes
+// but not when only its idle timeout or scope changes.
+func Test_shouldRecreateLoadBalancerAzure(t *testing.T) {
+	svc := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+	testCases := []struct {
+		description    string
+		current        *corev1.Service
+		desired        *corev1.Service
+		expectRecreate bool
+	}{
+		{
+			description:    "no changes",
+			current:        svc(map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID}),
+			desired:        svc(map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID}),
+			expectRecreate: false,
+		},
+		{
+			description:    "idle timeout changed",
+			current:        svc(map[string]string{azureLBTCPIdleTimeoutAnnotation: "4"}),
+			desired:        svc(map[string]string{azureLBTCPIdleTimeoutAnnotation: "30"}),
+			expectRecreate: false,
+		},
+		{
+			description:    "prefix added",
+			current:        svc(nil),
+			desired:        svc(map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID}),
+			expectRecreate: true,
+		},
+		{
+			description:    "prefix removed",
+			current:        svc(map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID}),
+			desired:        svc(nil),
+			expectRecreate: true,
+		},
+		{
+			description:    "scope changed to internal",
+			current:        svc(map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID}),
+			desired:        svc(map[string]string{azureInternalLBAnnotation: "true"}),
+			expectRecreate: false,
+		},
+	}
+
+	platform := &configv1.PlatformStatus{Type: configv1.AzurePlatformType}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if actual, reason := shouldRecreateLoadBalancer(tc.current, tc.desired, platform); actual != tc.expectRecreate {
+				t.Errorf("expected %t, got %t (reason: %q)", tc.expectRecreate, actual, reason)
+			}
+		})
+	}
+}
+
+// Test_loadBalancerServiceChangedAzureIdleTimeout verifies that
+// loadBalancerServiceChanged updates the Azure idle timeout annotation in place
+// and leaves the public IP prefix annotation alone.
+func Test_loadBalancerServiceChangedAzureIdleTimeout(t *testing.T) {
+	current := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				azurePIPPrefixIDAnnotation:      testAzurePIPPrefixID,
+				azureLBTCPIdleTimeoutAnnotation: "4",
+			},
+		},
+	}
+	expected := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				azureLBTCPIdleTimeoutAnnotation: "30",
+			},
+		},
+	}
+	changed, updated := loadBalancerServiceChanged(current, expected)
+	if !changed {
+		t.Fatal("expected changed to be true")
+	}
+	if v := updated.Annotations[azureLBTCPIdleTimeoutAnnotation]; v != "30" {
+		t.Errorf("expected %s=30, got %q", azureLBTCPIdleTimeoutAnnotation, v)
+	}
+	if v := updated.Annotations[azurePIPPrefixIDAnnotation]; v != testAzurePIPPrefixID {
+		t.Errorf("expected %s to be preserved, got %q", azurePIPPrefixIDAnnotation, v)
+	}
+}
+
+// Test_azureLoadBalancerIsProgressing verifies that
+// azureLoadBalancerIsProgressing reports an error if and only if the public IP
+// prefix of an external Azure load balancer differs from the one that the
+// ingresscontroller specifies.
+func Test_azureLoadBalancerIsProgressing(t *testing.T) {
+	testCases := []struct {
+		description    string
+		overrides      string
+		scope          operatorv1.LoadBalancerScope
+		platform       configv1.PlatformType
+		annotations    map[string]string
+		expectProgress bool
+	}{
+		{
+			description:    "no prefix",
+			scope:          operatorv1.ExternalLoadBalancer,
+			platform:       configv1.AzurePlatformType,
+			expectProgress: false,
+		},
+		{
+			description:    "prefix matches",
+			overrides:      `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			scope:          operatorv1.ExternalLoadBalancer,
+			platform:       configv1.AzurePlatformType,
+			annotations:    map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID},
+			expectProgress: false,
+		},
+		{
+			description:    "prefix added",
+			overrides:      `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			scope:          operatorv1.ExternalLoadBalancer,
+			platform:       configv1.AzurePlatformType,
+			expectProgress: true,
+		},
+		{
+			description:    "prefix removed",
+			scope:          operatorv1.ExternalLoadBalancer,
+			platform:       configv1.AzurePlatformType,
+			annotations:    map[string]string{azurePIPPrefixIDAnnotation: testAzurePIPPrefixID},
+			expectProgress: true,
+		},
+		{
+			description:    "internal load balancer with stale prefix",
+			scope:          operatorv1.InternalLoadBalancer,
+			platform:       configv1.AzurePlatformType,
+			annotations:    map[string]string{azureInternalLBAnnotation: "true", azurePIPPrefixIDAnnotation: testAzurePIPPrefixID},
+			expectProgress: false,
+		},
+		{
+			description:    "not Azure",
+			overrides:      `{"azureLoadBalancer":{"publicIPPrefixID":"` + testAzurePIPPrefixID + `"}}`,
+			scope:          operatorv1.ExternalLoadBalancer,
+			platform:       configv1.GCPPlatformType,
+			expectProgress: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+						LoadBalancer: &operatorv1.LoadBalancerStrategy{
+							Scope: tc.scope,
+						},
+					},
+				},
+			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "openshift-ingress",
+					Name:        "router-default",
+					Annotations: tc.annotations,
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: tc.platform}
+			switch err := azureLoadBalancerIsProgressing(ic, service, platform); {
+			case err == nil && tc.expectProgress:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectProgress:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
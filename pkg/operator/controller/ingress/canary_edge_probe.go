@@ -0,0 +1,138 @@
+package ingress
+
+import (
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CanaryEdgeProbeNodeLabel is the label that designates the nodes on
+	// which the canary edge probe runs if the ingresscontroller does not
+	// specify a node selector.
+	CanaryEdgeProbeNodeLabel = "ingress.operator.openshift.io/canary-edge-probe"
+	// CanaryEdgeProbeDefaultInterval is how often each canary edge probe
+	// pod probes the canary route if the ingresscontroller does not
+	// specify an interval.
+	CanaryEdgeProbeDefaultInterval = 1 * time.Minute
+	// CanaryEdgeProbeMinInterval is the shortest interval that an
+	// ingresscontroller may specify.
+	CanaryEdgeProbeMinInterval = 10 * time.Second
+	// CanaryEdgeProbeDefaultTimeout is how long each canary edge probe
+	// waits for a response if the ingresscontroller does not specify a
+	// timeout.
+	CanaryEdgeProbeDefaultTimeout = 10 * time.Second
+)
+
+// CanaryEdgeProbe describes where and how often the canary controller probes
+// the canary route from the perspective of a client outside the cluster.  The
+// probe runs in host-network pods on designated nodes, which resolve the
+// canary route's host using the nodes' resolvers and connect to it through
+// the same load balancer, security groups, and firewalls as external clients.
+// The default ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.canaryEdgeProbe.  The probe is disabled
+// unless it is specified.
+type CanaryEdgeProbe struct {
+	// NodeSelector selects the nodes on which to run the probe.  The
+	// default selects the nodes that have the
+	// "ingress.operator.openshift.io/canary-edge-probe" label.
+	NodeSelector map[string]string `json:"nodeSelector"`
+	// Tolerations are the tolerations of the probe pods, which allow the
+	// probe to run on tainted nodes.
+	Tolerations []corev1.Toleration `json:"tolerations"`
+	// Interval is how often each probe pod probes the canary route, in the
+	// format of time.ParseDuration.  The default is 1m, and the minimum is
+	// 10s.
+	Interval string `json:"interval"`
+	// Timeout is how long each probe waits for a response, in the format
+	// of time.ParseDuration.  It must be shorter than the interval.  The
+	// default is 10s.
+	Timeout string `json:"timeout"`
+}
+
+// CanaryEdgeProbeForIngressController returns the canary edge probe that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, with
+// defaults applied, or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func CanaryEdgeProbeForIngressController(ic *operatorv1.IngressController) (*CanaryEdgeProbe, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.canaryEdgeProbe(), nil
+}
+
+// canaryEdgeProbe returns the canary edge probe that the overrides specify,
+// with defaults applied, or nil if they specify none.
+func (o *unsupportedConfigOverrides) canaryEdgeProbe() *CanaryEdgeProbe {
+	if o.CanaryEdgeProbe == nil {
+		return nil
+	}
+	probe := *o.CanaryEdgeProbe
+	if len(probe.NodeSelector) == 0 {
+		probe.NodeSelector = map[string]string{CanaryEdgeProbeNodeLabel: ""}
+	}
+	if len(probe.Interval) == 0 {
+		probe.Interval = CanaryEdgeProbeDefaultInterval.String()
+	}
+	if len(probe.Timeout) == 0 {
+		probe.Timeout = CanaryEdgeProbeDefaultTimeout.String()
+	}
+	return &probe
+}
+
+// IntervalDuration returns the probe's interval.  The probe must be valid.
+func (p *CanaryEdgeProbe) IntervalDuration() time.Duration {
+	d, _ := time.ParseDuration(p.Interval)
+	return d
+}
+
+// TimeoutDuration returns the probe's timeout.  The probe must be valid.
+func (p *CanaryEdgeProbe) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(p.Timeout)
+	return d
+}
+
+// ValidateCanaryEdgeProbe validates the given canary edge probe.  The node
+// selector must consist of valid labels, and the timeout must be shorter than
+// the interval so that probes do not overlap.
+func ValidateCanaryEdgeProbe(probe *CanaryEdgeProbe) error {
+	var errs []error
+	for key, value := range probe.NodeSelector {
+		if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.nodeSelector has invalid key %q: %v", key, msgs))
+		}
+		if msgs := validation.IsValidLabelValue(value); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.nodeSelector has invalid value %q for key %q: %v", value, key, msgs))
+		}
+	}
+	interval, err := time.ParseDuration(probe.Interval)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.interval is invalid: %w", err))
+	} else if interval < CanaryEdgeProbeMinInterval {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.interval %q is shorter than the minimum of %v", probe.Interval, CanaryEdgeProbeMinInterval))
+	}
+	if timeout, err := time.ParseDuration(probe.Timeout); err != nil {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.timeout is invalid: %w", err))
+	} else if timeout <= 0 {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.timeout must be positive: %q", probe.Timeout))
+	} else if interval > 0 && timeout >= interval {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryEdgeProbe.timeout %q must be shorter than the interval %q", probe.Timeout, probe.Interval))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateCanaryEdgeProbe validates the canary edge probe that the given
+// ingresscontroller specifies, if any.
+func validateCanaryEdgeProbe(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	probe := overrides.canaryEdgeProbe()
+	if probe == nil {
+		return nil
+	}
+	return ValidateCanaryEdgeProbe(probe)
+}
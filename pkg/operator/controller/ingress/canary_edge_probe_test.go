@@ -0,0 +1,123 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestCanaryEdgeProbeForIngressController verifies that the canary edge probe
+// is disabled unless the ingresscontroller specifies it and that defaults are
+// applied to the parameters that it does not specify.
+func TestCanaryEdgeProbeForIngressController(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expect      *CanaryEdgeProbe
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "other overrides",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.apps.example.com"}}`,
+		},
+		{
+			description: "defaults",
+			overrides:   `{"canaryEdgeProbe":{}}`,
+			expect: &CanaryEdgeProbe{
+				NodeSelector: map[string]string{CanaryEdgeProbeNodeLabel: ""},
+				Interval:     "1m0s",
+				Timeout:      "10s",
+			},
+		},
+		{
+			description: "all parameters",
+			overrides:   `{"canaryEdgeProbe":{"nodeSelector":{"topology.kubernetes.io/zone":"us-east-1a"},"interval":"30s","timeout":"5s"}}`,
+			expect: &CanaryEdgeProbe{
+				NodeSelector: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"},
+				Interval:     "30s",
+				Timeout:      "5s",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			probe, err := CanaryEdgeProbeForIngressController(ic)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(probe, tc.expect) {
+				t.Errorf("expected %+v, got %+v", tc.expect, probe)
+			}
+		})
+	}
+}
+
+// Test_validateCanaryEdgeProbe verifies that validateCanaryEdgeProbe accepts a
+// probe that is disabled or valid, and rejects invalid node selectors,
+// intervals that are shorter than the minimum, and timeouts that are not
+// shorter than the interval.
+func Test_validateCanaryEdgeProbe(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "defaults",
+			overrides:   `{"canaryEdgeProbe":{}}`,
+		},
+		{
+			description: "custom placement",
+			overrides:   `{"canaryEdgeProbe":{"nodeSelector":{"node-role.kubernetes.io/edge":""},"tolerations":[{"key":"node-role.kubernetes.io/edge","operator":"Exists"}]}}`,
+		},
+		{
+			description: "invalid node selector",
+			overrides:   `{"canaryEdgeProbe":{"nodeSelector":{"not a label":"x"}}}`,
+			expectError: true,
+		},
+		{
+			description: "interval shorter than the minimum",
+			overrides:   `{"canaryEdgeProbe":{"interval":"1s","timeout":"500ms"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid timeout",
+			overrides:   `{"canaryEdgeProbe":{"timeout":"soon"}}`,
+			expectError: true,
+		},
+		{
+			description: "timeout not shorter than the interval",
+			overrides:   `{"canaryEdgeProbe":{"interval":"30s","timeout":"30s"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateCanaryEdgeProbe(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// CanaryResponseSizeDefaultLarge is the size, in bytes, of the large
+	// canary responses if the ingresscontroller enables alternating
+	// response sizes but does not specify a large size.  It is well above
+	// the typical path MTU so that the response spans multiple full-sized
+	// packets.
+	CanaryResponseSizeDefaultLarge = 8192
+	// CanaryResponseSizeMax is the largest canary response size, in bytes,
+	// that an ingresscontroller may specify and that the canary server
+	// serves.
+	CanaryResponseSizeMax = 1024 * 1024
+)
+
+// CanaryResponseSize describes the sizes of the responses that the canary check
+// requests from the canary application.  By default, the canary application
+// responds with a short message, which cannot detect path MTU or fragmentation
+// problems that only affect responses that span multiple full-sized packets.
+// The default ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.canaryResponseSize.
+type CanaryResponseSize struct {
+	// Small is the size, in bytes, of the responses that the canary check
+	// requests.  The default, 0, requests the canary application's default
+	// response.
+	Small int `json:"small"`
+	// Large is the size, in bytes, of the responses that the canary check
+	// requests in alternation with small responses if Alternate is true.
+	// The default is 8192.
+	Large int `json:"large"`
+	// Alternate specifies whether the canary check alternates between
+	// small and large responses.  If only the large responses fail, the
+	// canary check reports the LargeResponseFailure reason, which
+	// indicates a path MTU or fragmentation problem.  The default is
+	// false.
+	Alternate bool `json:"alternate"`
+}
+
+// CanaryResponseSizeForIngressController returns the canary response sizes that
+// the given ingresscontroller specifies in spec.unsupportedConfigOverrides,
+// with defaults applied, or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func CanaryResponseSizeForIngressController(ic *operatorv1.IngressController) (*CanaryResponseSize, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.canaryResponseSize(), nil
+}
+
+// canaryResponseSize returns the canary response sizes that the overrides
+// specify, with defaults applied, or nil if they specify none.
+func (o *unsupportedConfigOverrides) canaryResponseSize() *CanaryResponseSize {
+	if o.CanaryResponseSize == nil {
+		return nil
+	}
+	size := *o.CanaryResponseSize
+	if size.Alternate && size.Large == 0 {
+		size.Large = CanaryResponseSizeDefaultLarge
+	}
+	return &size
+}
+
+// SizeForSample returns the response size to request in the canary check's
+// sample with the given index, and a Boolean value indicating whether the
+// sample requests a large response.  When alternating, odd samples request
+// large responses.  A nil receiver requests the default response.
+func (s *CanaryResponseSize) SizeForSample(i int) (int, bool) {
+	switch {
+	case s == nil:
+		return 0, false
+	case s.Alternate && i%2 == 1:
+		return s.Large, true
+	default:
+		return s.Small, false
+	}
+}
+
+// ValidateCanaryResponseSize validates the given canary response sizes.  The
+// sizes must be non-negative and at most CanaryResponseSizeMax, and when
+// alternating, the large size must exceed the small size.
+func ValidateCanaryResponseSize(size *CanaryResponseSize) error {
+	var errs []error
+	if size.Small < 0 || size.Small > CanaryResponseSizeMax {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryResponseSize.small must be between 0 and %d, got %d", CanaryResponseSizeMax, size.Small))
+	}
+	if size.Large < 0 || size.Large > CanaryResponseSizeMax {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryResponseSize.large must be between 0 and %d, got %d", CanaryResponseSizeMax, size.Large))
+	}
+	if size.Alternate && size.Large <= size.Small {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryResponseSize.large (%d) must be greater than small (%d) when alternate is true", size.Large, size.Small))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateCanaryResponseSize validates the canary response sizes that the given
+// ingresscontroller specifies, if any.
+func validateCanaryResponseSize(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	size := overrides.canaryResponseSize()
+	if size == nil {
+		return nil
+	}
+	return ValidateCanaryResponseSize(size)
+}
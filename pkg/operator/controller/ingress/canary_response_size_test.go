@@ -0,0 +1,97 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_CanaryResponseSizeForIngressController verifies that
+// CanaryResponseSizeForIngressController decodes the canary response sizes and
+// applies the default large size only when alternating.
+func Test_CanaryResponseSizeForIngressController(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		expect      *CanaryResponseSize
+		expectError bool
+	}{
+		{name: "no overrides"},
+		{name: "other overrides", overrides: `{"contStats":"true"}`},
+		{name: "small size", overrides: `{"canaryResponseSize":{"small":2000}}`, expect: &CanaryResponseSize{Small: 2000}},
+		{name: "alternate with default large size", overrides: `{"canaryResponseSize":{"alternate":true}}`, expect: &CanaryResponseSize{Large: CanaryResponseSizeDefaultLarge, Alternate: true}},
+		{name: "alternate with large size", overrides: `{"canaryResponseSize":{"small":100,"large":4000,"alternate":true}}`, expect: &CanaryResponseSize{Small: 100, Large: 4000, Alternate: true}},
+		{name: "invalid overrides", overrides: `{"canaryResponseSize":{"large":"8k"}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			actual, err := CanaryResponseSizeForIngressController(ic)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expect) {
+				t.Errorf("expected %+v, got %+v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_CanaryResponseSize_SizeForSample verifies that SizeForSample alternates
+// between the small and large sizes only when alternating.
+func Test_CanaryResponseSize_SizeForSample(t *testing.T) {
+	var unset *CanaryResponseSize
+	if size, large := unset.SizeForSample(1); size != 0 || large {
+		t.Errorf("expected the default response for a nil receiver, got size %d, large %t", size, large)
+	}
+	fixed := &CanaryResponseSize{Small: 2000, Large: 8000}
+	if size, large := fixed.SizeForSample(1); size != 2000 || large {
+		t.Errorf("expected the small size when not alternating, got size %d, large %t", size, large)
+	}
+	alternating := &CanaryResponseSize{Small: 0, Large: 8000, Alternate: true}
+	for i, expect := range []int{0, 8000, 0, 8000} {
+		if size, large := alternating.SizeForSample(i); size != expect || large != (expect == 8000) {
+			t.Errorf("sample %d: expected size %d, got size %d, large %t", i, expect, size, large)
+		}
+	}
+}
+
+// Test_ValidateCanaryResponseSize verifies that ValidateCanaryResponseSize
+// rejects out-of-range sizes and a large size that does not exceed the small
+// size when alternating.
+func Test_ValidateCanaryResponseSize(t *testing.T) {
+	testCases := []struct {
+		name        string
+		size        CanaryResponseSize
+		expectError bool
+	}{
+		{name: "default", size: CanaryResponseSize{}},
+		{name: "small only", size: CanaryResponseSize{Small: 2000}},
+		{name: "alternating", size: CanaryResponseSize{Small: 100, Large: 8192, Alternate: true}},
+		{name: "negative small", size: CanaryResponseSize{Small: -1}, expectError: true},
+		{name: "large too big", size: CanaryResponseSize{Large: CanaryResponseSizeMax + 1, Alternate: true}, expectError: true},
+		{name: "large not larger than small", size: CanaryResponseSize{Small: 8192, Large: 8192, Alternate: true}, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			switch err := ValidateCanaryResponseSize(&tc.size); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
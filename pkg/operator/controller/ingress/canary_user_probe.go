@@ -0,0 +1,155 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CanaryUserProbeTLSVerify specifies that the canary user probe
+	// verifies the certificate of the probed application.  This is the
+	// default.
+	CanaryUserProbeTLSVerify = "Verify"
+	// CanaryUserProbeTLSSkip specifies that the canary user probe skips
+	// verification of the certificate of the probed application, as the
+	// built-in canary check does.
+	CanaryUserProbeTLSSkip = "Skip"
+)
+
+// CanaryUserProbe describes an application that the canary controller probes in
+// addition to the built-in canary application.  The default ingresscontroller
+// specifies it using spec.unsupportedConfigOverrides.canaryUserProbe.
+type CanaryUserProbe struct {
+	// Hostname is the host of the route for the application.  It must be
+	// a subdomain of the domain of an ingresscontroller.
+	Hostname string `json:"hostname"`
+	// Path is the path of the request.  The default is "/".
+	Path string `json:"path"`
+	// ExpectedStatus is the expected status code of the response.  The
+	// default is 200.
+	ExpectedStatus int `json:"expectedStatus"`
+	// ExpectedBodySubstring, if nonempty, is a string that the body of
+	// the response must contain.
+	ExpectedBodySubstring string `json:"expectedBodySubstring"`
+	// TLSVerification is either "Verify" (the default) or "Skip".
+	TLSVerification string `json:"tlsVerification"`
+	// AffectsAvailability specifies whether the ingresscontroller's
+	// Available condition, and thus the clusteroperator's Available
+	// condition, depends on the probe succeeding.  The default is false.
+	AffectsAvailability bool `json:"affectsAvailability"`
+}
+
+// CanaryUserProbeForIngressController returns the canary user probe that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, with
+// defaults applied, or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func CanaryUserProbeForIngressController(ic *operatorv1.IngressController) (*CanaryUserProbe, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.canaryUserProbe(), nil
+}
+
+// canaryUserProbe returns the canary user probe that the overrides specify,
+// with defaults applied, or nil if they specify none.
+func (o *unsupportedConfigOverrides) canaryUserProbe() *CanaryUserProbe {
+	if o.CanaryUserProbe == nil {
+		return nil
+	}
+	probe := *o.CanaryUserProbe
+	if len(probe.Path) == 0 {
+		probe.Path = "/"
+	}
+	if probe.ExpectedStatus == 0 {
+		probe.ExpectedStatus = http.StatusOK
+	}
+	if len(probe.TLSVerification) == 0 {
+		probe.TLSVerification = CanaryUserProbeTLSVerify
+	}
+	return &probe
+}
+
+// ValidateCanaryUserProbe validates the given canary user probe.  In
+// particular, the probe's hostname must be a subdomain of one of the given
+// domains so that the canary controller only probes applications that are
+// exposed by the cluster's ingresscontrollers.
+func ValidateCanaryUserProbe(probe *CanaryUserProbe, domains []string) error {
+	var errs []error
+	hostname := strings.ToLower(strings.TrimSuffix(probe.Hostname, "."))
+	switch {
+	case len(hostname) == 0:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.hostname must be specified"))
+	case net.ParseIP(hostname) != nil:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.hostname must be a hostname, not an IP address: %q", probe.Hostname))
+	case len(validation.IsDNS1123Subdomain(hostname)) != 0:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.hostname is not a valid hostname: %q", probe.Hostname))
+	case !isSubdomainOfAny(hostname, domains):
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.hostname %q is not a subdomain of the domain of any ingresscontroller", probe.Hostname))
+	}
+	if !strings.HasPrefix(probe.Path, "/") {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.path must begin with \"/\": %q", probe.Path))
+	}
+	if probe.ExpectedStatus < 100 || probe.ExpectedStatus > 599 {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.expectedStatus is not a valid status code: %d", probe.ExpectedStatus))
+	}
+	switch probe.TLSVerification {
+	case CanaryUserProbeTLSVerify, CanaryUserProbeTLSSkip:
+	default:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.canaryUserProbe.tlsVerification has invalid value %q; must be %q or %q", probe.TLSVerification, CanaryUserProbeTLSVerify, CanaryUserProbeTLSSkip))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// IngressControllerDomains returns the domains of the given ingresscontrollers.
+func IngressControllerDomains(ingresses []operatorv1.IngressController) []string {
+	var domains []string
+	for _, ic := range ingresses {
+		if len(ic.Status.Domain) != 0 {
+			domains = append(domains, ic.Status.Domain)
+		}
+	}
+	return domains
+}
+
+// isSubdomainOfAny returns a Boolean value indicating whether the given
+// hostname is a subdomain of any of the given domains.
+func isSubdomainOfAny(hostname string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if len(domain) != 0 && strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCanaryUserProbe validates the canary user probe that the given
+// ingresscontroller specifies, if any, against the domains of the given
+// ingresscontrollers.
+func validateCanaryUserProbe(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides, ingresses []operatorv1.IngressController) error {
+	probe := overrides.canaryUserProbe()
+	if probe == nil {
+		return nil
+	}
+	domains := IngressControllerDomains(ingresses)
+	if len(ic.Status.Domain) != 0 {
+		domains = append(domains, ic.Status.Domain)
+	}
+	return ValidateCanaryUserProbe(probe, domains)
+}
+
+// canaryUserProbeAffectsAvailability returns a Boolean value indicating whether
+// the given ingresscontroller's Available condition depends on the canary user
+// probe.
+func canaryUserProbeAffectsAvailability(ic *operatorv1.IngressController) bool {
+	probe, err := CanaryUserProbeForIngressController(ic)
+	return err == nil && probe != nil && probe.AffectsAvailability
+}
@@ -0,0 +1,359 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CDNOriginDescriptorKey is the key of the origin descriptor in the
+	// configmap that controller.CDNOriginConfigMapName names.
+	CDNOriginDescriptorKey = "origin.json"
+
+	// cdnOriginDefaultHealthProbePath is the path that the CDN should
+	// probe if the ingresscontroller does not specify one.
+	cdnOriginDefaultHealthProbePath = "/"
+)
+
+// CDNOrigin describes the external CDN, such as Azure Front Door or Amazon
+// CloudFront, that fronts an ingresscontroller.  An ingresscontroller specifies
+// it using spec.unsupportedConfigOverrides.cdnOrigin, in which case the
+// wildcard DNS record points at the CDN and is managed outside of the cluster,
+// so the operator leaves the record unmanaged, and instead publishes an origin
+// descriptor with which CDN automation can configure the load balancer as the
+// CDN's origin.  The ingresscontroller must use the LoadBalancerService
+// endpoint publishing strategy.
+type CDNOrigin struct {
+	// HealthProbe is the request with which the CDN should probe the
+	// origin.  For the default ingresscontroller, the default is the
+	// canary route.  For other ingresscontrollers, no default exists, and
+	// the descriptor omits the health probe unless one is specified.
+	HealthProbe *CDNOriginHealthProbe `json:"healthProbe,omitempty"`
+}
+
+// CDNOriginHealthProbe describes a request with which a CDN probes the origin.
+type CDNOriginHealthProbe struct {
+	// Host is the host of a route that the ingresscontroller exposes.
+	Host string `json:"host"`
+	// Path is the path of the request.  The default is "/".
+	Path string `json:"path,omitempty"`
+}
+
+// CDNOriginDescriptor describes how a CDN must be configured to use an
+// ingresscontroller's load balancer as its origin.  The operator publishes it
+// in JSON in the configmap that controller.CDNOriginConfigMapName names and
+// keeps it up to date as the load balancer changes.
+type CDNOriginDescriptor struct {
+	// IngressController is the name of the ingresscontroller.
+	IngressController string `json:"ingressController"`
+	// Domain is the ingresscontroller's domain.
+	Domain string `json:"domain"`
+	// Origins are the load balancer's addresses.  They are empty until
+	// the load balancer is provisioned.
+	Origins []CDNOriginAddress `json:"origins"`
+	// HTTPPort and HTTPSPort are the load balancer's ports.
+	HTTPPort  int32 `json:"httpPort"`
+	HTTPSPort int32 `json:"httpsPort"`
+	// HostHeader describes the Host header that the CDN must send to the
+	// origin.
+	HostHeader CDNOriginHostHeader `json:"hostHeader"`
+	// HealthProbe is the request with which the CDN should probe the
+	// origin, if any.
+	HealthProbe *CDNOriginDescriptorHealthProbe `json:"healthProbe,omitempty"`
+	// Certificate describes the certificate that the origin serves.
+	Certificate CDNOriginCertificate `json:"certificate"`
+}
+
+// CDNOriginAddress is an address of the load balancer.
+type CDNOriginAddress struct {
+	Hostname string `json:"hostname,omitempty"`
+	IP       string `json:"ip,omitempty"`
+}
+
+// CDNOriginHostHeader describes the Host header that the CDN must send to the
+// origin.
+type CDNOriginHostHeader struct {
+	// Policy is "Preserve", meaning that the CDN must forward the
+	// client's Host header, because the router selects the route using
+	// the Host header and SNI.
+	Policy string `json:"policy"`
+	// Hosts are the hosts that the origin serves.
+	Hosts []string `json:"hosts"`
+}
+
+// CDNOriginDescriptorHealthProbe describes the request with which the CDN
+// should probe the origin.
+type CDNOriginDescriptorHealthProbe struct {
+	Protocol       string `json:"protocol"`
+	Port           int32  `json:"port"`
+	Host           string `json:"host"`
+	Path           string `json:"path"`
+	ExpectedStatus int    `json:"expectedStatus"`
+}
+
+// CDNOriginCertificate describes the certificate that the origin serves when
+// the client does not request a route with a custom certificate.
+type CDNOriginCertificate struct {
+	// DNSNames are the names that the certificate must be valid for,
+	// which the CDN must use for SNI and verification.
+	DNSNames []string `json:"dnsNames"`
+	// Secret is the namespace and name of the secret with the
+	// certificate.
+	Secret string `json:"secret"`
+	// PublishedConfigMap is the namespace and name of the configmap in
+	// which the operator publishes the certificate for clients to trust,
+	// if any.  The operator only publishes the default ingresscontroller's
+	// certificate.
+	PublishedConfigMap string `json:"publishedConfigMap,omitempty"`
+}
+
+// CDNOriginForIngressController returns the CDN that the given
+// ingresscontroller specifies in spec.unsupportedConfigOverrides, or nil if it
+// specifies none.  An error is returned if spec.unsupportedConfigOverrides
+// cannot be decoded.
+func CDNOriginForIngressController(ic *operatorv1.IngressController) (*CDNOrigin, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.CDNOrigin, nil
+}
+
+// CDNOriginEnabled returns a Boolean value indicating whether the given
+// ingresscontroller is fronted by an external CDN.  The ingresscontroller's
+// effective endpoint publishing strategy must be LoadBalancerService, and its
+// CDN must be valid.
+func CDNOriginEnabled(ic *operatorv1.IngressController) bool {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return false
+	}
+	cdn, err := CDNOriginForIngressController(ic)
+	if err != nil || cdn == nil {
+		return false
+	}
+	return ValidateCDNOrigin(cdn) == nil
+}
+
+// ValidateCDNOrigin validates the given CDN.
+func ValidateCDNOrigin(cdn *CDNOrigin) error {
+	if cdn.HealthProbe == nil {
+		return nil
+	}
+	host := cdn.HealthProbe.Host
+	if len(host) == 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.cdnOrigin.healthProbe.host must be specified")
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) != 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.cdnOrigin.healthProbe.host is not a valid hostname: %q: %s", host, strings.Join(errs, ", "))
+	}
+	if path := cdn.HealthProbe.Path; len(path) != 0 && !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.cdnOrigin.healthProbe.path must start with a slash: %q", path)
+	}
+	return nil
+}
+
+// validateCDNOrigin validates the CDN that the given ingresscontroller
+// specifies, if any.  A CDN can only be specified with the LoadBalancerService
+// endpoint publishing strategy.
+func validateCDNOrigin(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	cdn := overrides.CDNOrigin
+	if cdn == nil {
+		return nil
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.cdnOrigin can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	return ValidateCDNOrigin(cdn)
+}
+
+// wildcardRecordPublishingStrategy returns the endpoint publishing strategy
+// with which to configure the given ingresscontroller's wildcard DNS record.
+// If the ingresscontroller is fronted by an external CDN, the record points at
+// the CDN, so the DNS management policy is Unmanaged regardless of the
+// ingresscontroller's effective policy.
+func wildcardRecordPublishingStrategy(ic *operatorv1.IngressController) *operatorv1.EndpointPublishingStrategy {
+	eps := ic.Status.EndpointPublishingStrategy
+	if !CDNOriginEnabled(ic) || eps.LoadBalancer == nil {
+		return eps
+	}
+	eps = eps.DeepCopy()
+	eps.LoadBalancer.DNSManagementPolicy = operatorv1.UnmanagedLoadBalancerDNS
+	return eps
+}
+
+// desiredCDNOriginDescriptor returns the origin descriptor for the given
+// ingresscontroller, which is fronted by the given CDN, and the given load
+// balancer service, which may be nil if the service does not exist yet.
+func desiredCDNOriginDescriptor(ic *operatorv1.IngressController, cdn *CDNOrigin, lbService *corev1.Service) *CDNOriginDescriptor {
+	descriptor := &CDNOriginDescriptor{
+		IngressController: ic.Name,
+		Domain:            ic.Status.Domain,
+		Origins:           []CDNOriginAddress{},
+		HTTPPort:          80,
+		HTTPSPort:         443,
+		HostHeader: CDNOriginHostHeader{
+			Policy: "Preserve",
+			Hosts:  []string{"*." + ic.Status.Domain},
+		},
+		Certificate: CDNOriginCertificate{
+			DNSNames: []string{"*." + ic.Status.Domain},
+			Secret:   controller.RouterEffectiveDefaultCertificateSecretName(ic, controller.DefaultOperandNamespace).String(),
+		},
+	}
+	if lbService != nil {
+		for _, ingress := range lbService.Status.LoadBalancer.Ingress {
+			descriptor.Origins = append(descriptor.Origins, CDNOriginAddress{Hostname: ingress.Hostname, IP: ingress.IP})
+		}
+	}
+	if ic.Name == manifests.DefaultIngressControllerName {
+		descriptor.Certificate.PublishedConfigMap = controller.DefaultIngressCertConfigMapName().String()
+	}
+
+	var probe *CDNOriginHealthProbe
+	switch {
+	case cdn.HealthProbe != nil:
+		probe = cdn.HealthProbe
+	case ic.Name == manifests.DefaultIngressControllerName:
+		probe = &CDNOriginHealthProbe{Host: controller.CanaryRouteHost(ic.Status.Domain)}
+	}
+	if probe != nil {
+		path := probe.Path
+		if len(path) == 0 {
+			path = cdnOriginDefaultHealthProbePath
+		}
+		descriptor.HealthProbe = &CDNOriginDescriptorHealthProbe{
+			Protocol:       "HTTPS",
+			Port:           descriptor.HTTPSPort,
+			Host:           probe.Host,
+			Path:           path,
+			ExpectedStatus: 200,
+		}
+	}
+	return descriptor
+}
+
+// desiredCDNOriginConfigMap returns the desired configmap with the origin
+// descriptor for the given ingresscontroller and load balancer service.
+// Returns a Boolean indicating whether a configmap is desired, as well as the
+// configmap if one is desired.
+func desiredCDNOriginConfigMap(ic *operatorv1.IngressController, lbService *corev1.Service, deploymentRef metav1.OwnerReference) (bool, *corev1.ConfigMap, error) {
+	if !CDNOriginEnabled(ic) {
+		return false, nil, nil
+	}
+	cdn, _ := CDNOriginForIngressController(ic)
+	data, err := json.MarshalIndent(desiredCDNOriginDescriptor(ic, cdn, lbService), "", "  ")
+	if err != nil {
+		return false, nil, err
+	}
+
+	name := controller.CDNOriginConfigMapName(ic)
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels: map[string]string{
+				manifests.OwningIngressControllerLabel: ic.Name,
+			},
+		},
+		Data: map[string]string{
+			CDNOriginDescriptorKey: string(data),
+		},
+	}
+	cm.SetOwnerReferences([]metav1.OwnerReference{deploymentRef})
+
+	return true, &cm, nil
+}
+
+// ensureCDNOriginConfigMap ensures the configmap with the origin descriptor
+// exists and is up to date for a given ingresscontroller if the
+// ingresscontroller is fronted by an external CDN, and that it does not exist
+// otherwise.  Returns a Boolean indicating whether the configmap exists, the
+// configmap if it does exist, and an error value.
+func (r *reconciler) ensureCDNOriginConfigMap(ic *operatorv1.IngressController, lbService *corev1.Service, deploymentRef metav1.OwnerReference) (bool, *corev1.ConfigMap, error) {
+	wantCM, desired, err := desiredCDNOriginConfigMap(ic, lbService, deploymentRef)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to build CDN origin configmap: %w", err)
+	}
+
+	haveCM, current, err := r.currentCDNOriginConfigMap(ic)
+	if err != nil {
+		return false, nil, err
+	}
+
+	switch {
+	case !wantCM && !haveCM:
+		return false, nil, nil
+	case !wantCM && haveCM:
+		if err := r.client.Delete(context.TODO(), current); err != nil {
+			if !errors.IsNotFound(err) {
+				return true, current, fmt.Errorf("failed to delete CDN origin configmap: %w", err)
+			}
+		} else {
+			log.Info("deleted configmap", "configmap", current)
+		}
+		return false, nil, nil
+	case wantCM && !haveCM:
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return false, nil, fmt.Errorf("failed to create CDN origin configmap: %w", err)
+		}
+		log.Info("created configmap", "configmap", desired)
+		return r.currentCDNOriginConfigMap(ic)
+	case wantCM && haveCM:
+		if updated, err := r.updateCDNOriginConfigMap(current, desired); err != nil {
+			return true, current, fmt.Errorf("failed to update CDN origin configmap: %w", err)
+		} else if updated {
+			return r.currentCDNOriginConfigMap(ic)
+		}
+	}
+
+	return true, current, nil
+}
+
+// currentCDNOriginConfigMap returns the current configmap with the origin
+// descriptor.  Returns a Boolean indicating whether the configmap existed, the
+// configmap if it did exist, and an error value.
+func (r *reconciler) currentCDNOriginConfigMap(ic *operatorv1.IngressController) (bool, *corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), controller.CDNOriginConfigMapName(ic), cm); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	return true, cm, nil
+}
+
+// updateCDNOriginConfigMap updates a configmap.  Returns a Boolean indicating
+// whether the configmap was updated, and an error value.
+func (r *reconciler) updateCDNOriginConfigMap(current, desired *corev1.ConfigMap) (bool, error) {
+	if reflect.DeepEqual(current.Data, desired.Data) && reflect.DeepEqual(current.Labels, desired.Labels) {
+		return false, nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = desired.Data
+	updated.Labels = desired.Labels
+	// Diff before updating because the client may mutate the object.
+	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return false, err
+	}
+	log.Info("updated configmap", "namespace", updated.Namespace, "name", updated.Name, "diff", diff)
+	return true, nil
+}
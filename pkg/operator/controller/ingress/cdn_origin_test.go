@@ -0,0 +1,207 @@
+package ingress
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_validateCDNOrigin verifies that validateCDNOrigin accepts a CDN with or
+// without a valid health probe and rejects invalid health probes and endpoint
+// publishing strategies other than LoadBalancerService.
+func Test_validateCDNOrigin(t *testing.T) {
+	testCases := []struct {
+		description string
+		strategy    operatorv1.EndpointPublishingStrategyType
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			strategy:    operatorv1.NodePortServiceStrategyType,
+		},
+		{
+			description: "defaults",
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			overrides:   `{"cdnOrigin":{}}`,
+		},
+		{
+			description: "health probe",
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			overrides:   `{"cdnOrigin":{"healthProbe":{"host":"health.apps.example.com","path":"/healthz"}}}`,
+		},
+		{
+			description: "node port",
+			strategy:    operatorv1.NodePortServiceStrategyType,
+			overrides:   `{"cdnOrigin":{}}`,
+			expectError: true,
+		},
+		{
+			description: "health probe without host",
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			overrides:   `{"cdnOrigin":{"healthProbe":{"path":"/healthz"}}}`,
+			expectError: true,
+		},
+		{
+			description: "health probe with invalid host",
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			overrides:   `{"cdnOrigin":{"healthProbe":{"host":"health_apps.example.com"}}}`,
+			expectError: true,
+		},
+		{
+			description: "health probe with relative path",
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			overrides:   `{"cdnOrigin":{"healthProbe":{"host":"health.apps.example.com","path":"healthz"}}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: tc.strategy},
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateCDNOrigin(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_wildcardRecordPublishingStrategy verifies that the wildcard DNS record
+// is unmanaged for an ingresscontroller that is fronted by an external CDN,
+// without changing the ingresscontroller's effective strategy.
+func Test_wildcardRecordPublishingStrategy(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		Status: operatorv1.IngressControllerStatus{
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+				LoadBalancer: &operatorv1.LoadBalancerStrategy{
+					DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+				},
+			},
+		},
+	}
+	if eps := wildcardRecordPublishingStrategy(ic); eps.LoadBalancer.DNSManagementPolicy != operatorv1.ManagedLoadBalancerDNS {
+		t.Errorf("expected %q without a CDN, got %q", operatorv1.ManagedLoadBalancerDNS, eps.LoadBalancer.DNSManagementPolicy)
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"cdnOrigin":{}}`)}
+	if eps := wildcardRecordPublishingStrategy(ic); eps.LoadBalancer.DNSManagementPolicy != operatorv1.UnmanagedLoadBalancerDNS {
+		t.Errorf("expected %q with a CDN, got %q", operatorv1.UnmanagedLoadBalancerDNS, eps.LoadBalancer.DNSManagementPolicy)
+	}
+	if ic.Status.EndpointPublishingStrategy.LoadBalancer.DNSManagementPolicy != operatorv1.ManagedLoadBalancerDNS {
+		t.Errorf("expected the ingresscontroller's effective strategy to be unchanged, got %q", ic.Status.EndpointPublishingStrategy.LoadBalancer.DNSManagementPolicy)
+	}
+}
+
+// Test_ensureCDNOriginConfigMap verifies that ensureCDNOriginConfigMap
+// publishes the origin descriptor when the ingresscontroller is fronted by an
+// external CDN, updates the descriptor as the load balancer's address changes,
+// and deletes the configmap when the CDN is removed.
+func Test_ensureCDNOriginConfigMap(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress-operator",
+			Name:      "default",
+		},
+		Spec: operatorv1.IngressControllerSpec{
+			UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"cdnOrigin":{}}`)},
+		},
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.example.com",
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type:         operatorv1.LoadBalancerServiceStrategyType,
+				LoadBalancer: &operatorv1.LoadBalancerStrategy{},
+			},
+		},
+	}
+	lbService := &corev1.Service{}
+	deploymentRef := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "router-default"}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &reconciler{client: cl}
+
+	ensure := func(description string, expectOrigins []CDNOriginAddress) *CDNOriginDescriptor {
+		t.Helper()
+		have, cm, err := r.ensureCDNOriginConfigMap(ic, lbService, deploymentRef)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if !have {
+			t.Fatalf("%s: expected configmap %s to exist", description, controller.CDNOriginConfigMapName(ic))
+		}
+		descriptor := &CDNOriginDescriptor{}
+		if err := json.Unmarshal([]byte(cm.Data[CDNOriginDescriptorKey]), descriptor); err != nil {
+			t.Fatalf("%s: failed to decode origin descriptor: %v", description, err)
+		}
+		if !reflect.DeepEqual(descriptor.Origins, expectOrigins) {
+			t.Errorf("%s: expected origins %+v, got %+v", description, expectOrigins, descriptor.Origins)
+		}
+		return descriptor
+	}
+
+	descriptor := ensure("pending load balancer", []CDNOriginAddress{})
+	expectHostHeader := CDNOriginHostHeader{Policy: "Preserve", Hosts: []string{"*.apps.example.com"}}
+	if !reflect.DeepEqual(descriptor.HostHeader, expectHostHeader) {
+		t.Errorf("expected host header %+v, got %+v", expectHostHeader, descriptor.HostHeader)
+	}
+	expectHealthProbe := &CDNOriginDescriptorHealthProbe{
+		Protocol:       "HTTPS",
+		Port:           443,
+		Host:           "canary-openshift-ingress-canary.apps.example.com",
+		Path:           "/",
+		ExpectedStatus: 200,
+	}
+	if !reflect.DeepEqual(descriptor.HealthProbe, expectHealthProbe) {
+		t.Errorf("expected health probe %+v, got %+v", expectHealthProbe, descriptor.HealthProbe)
+	}
+	expectCertificate := CDNOriginCertificate{
+		DNSNames:           []string{"*.apps.example.com"},
+		Secret:             "openshift-ingress/router-certs-default",
+		PublishedConfigMap: "openshift-config-managed/default-ingress-cert",
+	}
+	if !reflect.DeepEqual(descriptor.Certificate, expectCertificate) {
+		t.Errorf("expected certificate %+v, got %+v", expectCertificate, descriptor.Certificate)
+	}
+
+	lbService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb-1.example.com"}}
+	ensure("provisioned load balancer", []CDNOriginAddress{{Hostname: "lb-1.example.com"}})
+
+	lbService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}, {IP: "192.0.2.11"}}
+	ensure("changed load balancer", []CDNOriginAddress{{IP: "192.0.2.10"}, {IP: "192.0.2.11"}})
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"cdnOrigin":{"healthProbe":{"host":"health.apps.example.com","path":"/healthz"}}}`)}
+	descriptor = ensure("custom health probe", []CDNOriginAddress{{IP: "192.0.2.10"}, {IP: "192.0.2.11"}})
+	if descriptor.HealthProbe == nil || descriptor.HealthProbe.Host != "health.apps.example.com" || descriptor.HealthProbe.Path != "/healthz" {
+		t.Errorf("expected the custom health probe, got %+v", descriptor.HealthProbe)
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+	if have, _, err := r.ensureCDNOriginConfigMap(ic, lbService, deploymentRef); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if have {
+		t.Errorf("expected configmap %s to be deleted", controller.CDNOriginConfigMapName(ic))
+	}
+}
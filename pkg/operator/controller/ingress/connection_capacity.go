@@ -0,0 +1,224 @@
+package ingress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// defaultConnectionUtilizationThresholdPercent is the connection
+	// utilization, in percent of the estimated capacity, at or above which
+	// the "ConnectionCapacity" status condition is false unless the
+	// ingresscontroller specifies another threshold.
+	defaultConnectionUtilizationThresholdPercent = 80
+	// connectionSampleMaxAge is how long the operator keeps using the most
+	// recent sample of a router's connections while the router's metrics
+	// cannot be scraped.  After that, utilization is reported as unknown.
+	connectionSampleMaxAge = 10 * time.Minute
+
+	// haproxyFrontendCurrentSessionsMetric is the name of the router's
+	// gauge metric for the current number of sessions on each HAProxy
+	// frontend.
+	haproxyFrontendCurrentSessionsMetric = "haproxy_frontend_current_sessions"
+)
+
+// clientFacingFrontends are the HAProxy frontends on which the router accepts
+// client connections.  Connections to the other frontends are internal
+// connections for TLS termination that the router makes to itself for
+// connections that it has already accepted on one of these, so counting them
+// would count the same client connection twice.
+var clientFacingFrontends = sets.NewString("public", "public_ssl")
+
+// connectionCapacityConfig enables connection capacity estimation for an
+// ingresscontroller.
+type connectionCapacityConfig struct {
+	// ThresholdPercent, if non-zero, is the connection utilization in
+	// percent at or above which the "ConnectionCapacity" status condition
+	// is false.  The default is
+	// defaultConnectionUtilizationThresholdPercent.
+	ThresholdPercent int `json:"thresholdPercent"`
+}
+
+// connectionCapacityConfigForIngressController returns the connection capacity
+// estimation options that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none, in which case
+// estimation is disabled.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func connectionCapacityConfigForIngressController(ic *operatorv1.IngressController) (*connectionCapacityConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.ConnectionCapacity, nil
+}
+
+// validateConnectionCapacityConfig validates the given ingresscontroller's
+// connection capacity estimation options, if it specifies any.  The threshold
+// must be between 1 and 100 percent.
+func validateConnectionCapacityConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.ConnectionCapacity
+	if config == nil {
+		return nil
+	}
+	if v := config.ThresholdPercent; v < 0 || v > 100 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.connectionCapacity.thresholdPercent (%d) must be between 1 and 100", v)
+	}
+	return nil
+}
+
+// thresholdPercent returns the effective utilization threshold in percent.
+func (c *connectionCapacityConfig) thresholdPercent() int {
+	if c.ThresholdPercent == 0 {
+		return defaultConnectionUtilizationThresholdPercent
+	}
+	return c.ThresholdPercent
+}
+
+// maxConnectionsPerRouter returns the value of HAProxy's maxconn setting that
+// the given ingresscontroller's router pods use, and a Boolean value that is
+// false if HAProxy computes the value dynamically, in which case the operator
+// cannot know it.
+func maxConnectionsPerRouter(ic *operatorv1.IngressController) (int, bool) {
+	switch v := ic.Spec.TuningOptions.MaxConnections; {
+	case v == -1:
+		return 0, false
+	case v > 0:
+		return int(v), true
+	}
+	return routerDefaultMaxConnections, true
+}
+
+// parseRouterCurrentConnections returns the number of client connections that
+// the router currently has open according to the given metrics in the
+// Prometheus text format.
+func parseRouterCurrentConnections(in io.Reader) (float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(in)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse router metrics: %w", err)
+	}
+	family, ok := families[haproxyFrontendCurrentSessionsMetric]
+	if !ok {
+		return 0, fmt.Errorf("router metrics do not include %s", haproxyFrontendCurrentSessionsMetric)
+	}
+	var connections float64
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "frontend" && clientFacingFrontends.Has(label.GetValue()) {
+				connections += metric.GetGauge().GetValue()
+				break
+			}
+		}
+	}
+	return connections, nil
+}
+
+// routerConnectionsSample is a sample of the total number of client
+// connections of an ingresscontroller's router pods.
+type routerConnectionsSample struct {
+	connections float64
+	pods        int
+	timestamp   time.Time
+}
+
+// routerConnectionsTracker keeps the most recent sample of the connections of
+// each ingresscontroller's routers so that the operator can keep reporting
+// utilization, flagged as stale, when the routers' metrics cannot be scraped.
+type routerConnectionsTracker struct {
+	mu      sync.Mutex
+	samples map[types.NamespacedName]routerConnectionsSample
+}
+
+// newRouterConnectionsTracker returns a new routerConnectionsTracker.
+func newRouterConnectionsTracker() *routerConnectionsTracker {
+	return &routerConnectionsTracker{samples: map[types.NamespacedName]routerConnectionsSample{}}
+}
+
+// record records the given sample for the given ingresscontroller.
+func (t *routerConnectionsTracker) record(name types.NamespacedName, sample routerConnectionsSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples[name] = sample
+}
+
+// last returns the most recent sample for the given ingresscontroller and a
+// Boolean value indicating whether there is one.
+func (t *routerConnectionsTracker) last(name types.NamespacedName) (routerConnectionsSample, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sample, ok := t.samples[name]
+	return sample, ok
+}
+
+// forget discards the samples of the given ingresscontroller.
+func (t *routerConnectionsTracker) forget(name types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.samples, name)
+}
+
+// computeConnectionCapacityCondition computes the ingresscontroller's
+// "ConnectionCapacity" status condition from the given sample of the router's
+// connections and the given number of available router replicas, and returns
+// the condition and the estimated connection utilization as a ratio, which is
+// only meaningful if the condition's status is not Unknown.  The estimated
+// capacity is HAProxy's maxconn setting times the number of available
+// replicas.  If the sample is older than connectionSampleMaxAge, the
+// utilization is unknown.
+//
+// The condition is false if the utilization is at or above the configured
+// threshold.  Its message does not include the utilization, which is reported
+// in the ingress_controller_connection_utilization_ratio metric, so that the
+// condition only changes when the utilization crosses the threshold.  The
+// condition does not affect the ingresscontroller's Degraded or Available
+// status conditions.
+func computeConnectionCapacityCondition(ic *operatorv1.IngressController, config *connectionCapacityConfig, availableReplicas int32, sample routerConnectionsSample, haveSample bool, now time.Time) (operatorv1.OperatorCondition, float64) {
+	unknown := func(reason, message string) (operatorv1.OperatorCondition, float64) {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerConnectionCapacityConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  reason,
+			Message: message,
+		}, 0
+	}
+	maxConnections, ok := maxConnectionsPerRouter(ic)
+	if !ok {
+		return unknown("MaxConnectionsDynamic", "The connection capacity cannot be estimated because spec.tuningOptions.maxConnections is -1, which lets HAProxy compute its connection limit dynamically.")
+	}
+	if availableReplicas == 0 {
+		return unknown("NoAvailableReplicas", "The connection capacity cannot be estimated because the router deployment has no available replicas.")
+	}
+	if !haveSample {
+		return unknown("MetricsUnavailable", "The connection utilization is not known because the router metrics have not been scraped successfully yet.")
+	}
+	if now.Sub(sample.timestamp) > connectionSampleMaxAge {
+		return unknown("MetricsStale", fmt.Sprintf("The connection utilization is not known because the router metrics have not been scraped successfully for more than %s.", connectionSampleMaxAge))
+	}
+
+	capacity := float64(maxConnections) * float64(availableReplicas)
+	utilization := sample.connections / capacity
+	threshold := config.thresholdPercent()
+	if utilization*100 >= float64(threshold) {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerConnectionCapacityConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "UtilizationAboveThreshold",
+			Message: fmt.Sprintf("The router pods' open client connections are at or above %d%% of the estimated capacity, which is spec.tuningOptions.maxConnections times the number of available replicas.  Add replicas or move routes to another ingresscontroller.", threshold),
+		}, utilization
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerConnectionCapacityConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "UtilizationBelowThreshold",
+		Message: fmt.Sprintf("The router pods' open client connections are below %d%% of the estimated capacity, which is spec.tuningOptions.maxConnections times the number of available replicas.", threshold),
+	}, utilization
+}
@@ -0,0 +1,269 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test_validateConnectionCapacityConfig verifies that
+// validateConnectionCapacityConfig accepts only thresholds between 0 and 100.
+func Test_validateConnectionCapacityConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "default threshold",
+			overrides:   `{"connectionCapacity":{}}`,
+		},
+		{
+			description: "custom threshold",
+			overrides:   `{"connectionCapacity":{"thresholdPercent":90}}`,
+		},
+		{
+			description: "negative threshold",
+			overrides:   `{"connectionCapacity":{"thresholdPercent":-1}}`,
+			expectError: true,
+		},
+		{
+			description: "threshold above 100",
+			overrides:   `{"connectionCapacity":{"thresholdPercent":101}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateConnectionCapacityConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_parseRouterCurrentConnections verifies that
+// parseRouterCurrentConnections sums the current sessions of the client-facing
+// frontends and ignores the internal TLS termination frontends.
+func Test_parseRouterCurrentConnections(t *testing.T) {
+	const metrics = `# HELP haproxy_up Was the last scrape of HAProxy successful.
+# TYPE haproxy_up gauge
+haproxy_up 1
+# HELP haproxy_frontend_current_sessions Current number of active sessions.
+# TYPE haproxy_frontend_current_sessions gauge
+haproxy_frontend_current_sessions{frontend="fe_no_sni"} 7
+haproxy_frontend_current_sessions{frontend="fe_sni"} 30
+haproxy_frontend_current_sessions{frontend="public"} 12
+haproxy_frontend_current_sessions{frontend="public_ssl"} 30
+`
+	connections, err := parseRouterCurrentConnections(strings.NewReader(metrics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if connections != 42 {
+		t.Errorf("expected 42 connections, got %v", connections)
+	}
+
+	if _, err := parseRouterCurrentConnections(strings.NewReader("haproxy_up 1\n")); err == nil {
+		t.Error("expected an error for metrics without the current sessions gauge, got nil")
+	}
+	if _, err := parseRouterCurrentConnections(strings.NewReader("not metrics")); err == nil {
+		t.Error("expected an error for malformed metrics, got nil")
+	}
+}
+
+// Test_routerConnectionsTracker verifies that routerConnectionsTracker keeps
+// the most recent sample of each ingresscontroller.
+func Test_routerConnectionsTracker(t *testing.T) {
+	tracker := newRouterConnectionsTracker()
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default"}
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if _, ok := tracker.last(name); ok {
+		t.Fatal("expected no sample before recording one")
+	}
+	tracker.record(name, routerConnectionsSample{connections: 10, pods: 2, timestamp: now})
+	tracker.record(name, routerConnectionsSample{connections: 20, pods: 2, timestamp: now.Add(time.Minute)})
+	if sample, ok := tracker.last(name); !ok || sample.connections != 20 {
+		t.Fatalf("expected the most recent sample with 20 connections, got %+v (ok=%t)", sample, ok)
+	}
+	tracker.forget(name)
+	if _, ok := tracker.last(name); ok {
+		t.Fatal("expected no sample after forgetting the ingresscontroller")
+	}
+}
+
+// Test_computeConnectionCapacityCondition verifies that
+// computeConnectionCapacityCondition compares the sampled connections with the
+// estimated capacity, degrades gracefully when the router's metrics cannot be
+// scraped, and reports a message that does not change with the utilization.
+func Test_computeConnectionCapacityCondition(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name              string
+		maxConnections    int32
+		thresholdPercent  int
+		availableReplicas int32
+		sample            *routerConnectionsSample
+		expectStatus      operatorv1.ConditionStatus
+		expectReason      string
+		expectUtilization float64
+		expectMessageHas  []string
+	}{
+		{
+			name:              "under threshold",
+			maxConnections:    1000,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 500, pods: 2, timestamp: now},
+			expectStatus:      operatorv1.ConditionTrue,
+			expectReason:      "UtilizationBelowThreshold",
+			expectUtilization: 0.25,
+			expectMessageHas:  []string{"below 80% of the estimated capacity"},
+		},
+		{
+			name:              "over threshold",
+			maxConnections:    1000,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 1800, pods: 2, timestamp: now},
+			expectStatus:      operatorv1.ConditionFalse,
+			expectReason:      "UtilizationAboveThreshold",
+			expectUtilization: 0.9,
+			expectMessageHas:  []string{"at or above 80% of the estimated capacity", "Add replicas"},
+		},
+		{
+			name:              "custom threshold",
+			maxConnections:    1000,
+			thresholdPercent:  95,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 1800, pods: 2, timestamp: now},
+			expectStatus:      operatorv1.ConditionTrue,
+			expectReason:      "UtilizationBelowThreshold",
+			expectUtilization: 0.9,
+			expectMessageHas:  []string{"below 95%"},
+		},
+		{
+			name:              "default maxConnections",
+			availableReplicas: 1,
+			sample:            &routerConnectionsSample{connections: 45000, pods: 1, timestamp: now},
+			expectStatus:      operatorv1.ConditionFalse,
+			expectReason:      "UtilizationAboveThreshold",
+			expectUtilization: 0.9,
+			expectMessageHas:  []string{"at or above 80%"},
+		},
+		{
+			name:              "recent sample",
+			maxConnections:    1000,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 500, pods: 2, timestamp: now.Add(-time.Minute)},
+			expectStatus:      operatorv1.ConditionTrue,
+			expectReason:      "UtilizationBelowThreshold",
+			expectUtilization: 0.25,
+		},
+		{
+			name:              "old sample",
+			maxConnections:    1000,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 500, pods: 2, timestamp: now.Add(-time.Hour)},
+			expectStatus:      operatorv1.ConditionUnknown,
+			expectReason:      "MetricsStale",
+			expectMessageHas:  []string{"for more than 10m0s"},
+		},
+		{
+			name:              "no sample",
+			maxConnections:    1000,
+			availableReplicas: 2,
+			expectStatus:      operatorv1.ConditionUnknown,
+			expectReason:      "MetricsUnavailable",
+			expectMessageHas:  []string{"not been scraped successfully yet"},
+		},
+		{
+			name:              "dynamic maxConnections",
+			maxConnections:    -1,
+			availableReplicas: 2,
+			sample:            &routerConnectionsSample{connections: 500, pods: 2, timestamp: now},
+			expectStatus:      operatorv1.ConditionUnknown,
+			expectReason:      "MaxConnectionsDynamic",
+		},
+		{
+			name:             "no available replicas",
+			maxConnections:   1000,
+			sample:           &routerConnectionsSample{connections: 500, pods: 2, timestamp: now},
+			expectStatus:     operatorv1.ConditionUnknown,
+			expectReason:     "NoAvailableReplicas",
+			expectMessageHas: []string{"no available replicas"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					TuningOptions: operatorv1.IngressControllerTuningOptions{
+						MaxConnections: tc.maxConnections,
+					},
+				},
+			}
+			config := &connectionCapacityConfig{ThresholdPercent: tc.thresholdPercent}
+			var sample routerConnectionsSample
+			if tc.sample != nil {
+				sample = *tc.sample
+			}
+			condition, utilization := computeConnectionCapacityCondition(ic, config, tc.availableReplicas, sample, tc.sample != nil, now)
+			if condition.Type != IngressControllerConnectionCapacityConditionType {
+				t.Errorf("expected condition type %q, got %q", IngressControllerConnectionCapacityConditionType, condition.Type)
+			}
+			if condition.Status != tc.expectStatus {
+				t.Errorf("expected status %q, got %q", tc.expectStatus, condition.Status)
+			}
+			if condition.Reason != tc.expectReason {
+				t.Errorf("expected reason %q, got %q", tc.expectReason, condition.Reason)
+			}
+			if utilization != tc.expectUtilization {
+				t.Errorf("expected utilization %v, got %v", tc.expectUtilization, utilization)
+			}
+			for _, s := range tc.expectMessageHas {
+				if !strings.Contains(condition.Message, s) {
+					t.Errorf("expected message to contain %q, got %q", s, condition.Message)
+				}
+			}
+		})
+	}
+}
+
+// Test_computeConnectionCapacityConditionMessageIsStable verifies that the
+// "ConnectionCapacity" status condition does not change as long as the
+// utilization stays on the same side of the threshold, so that new samples do
+// not cause status updates.
+func Test_computeConnectionCapacityConditionMessageIsStable(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ic := &operatorv1.IngressController{
+		Spec: operatorv1.IngressControllerSpec{
+			TuningOptions: operatorv1.IngressControllerTuningOptions{MaxConnections: 1000},
+		},
+	}
+	config := &connectionCapacityConfig{}
+	first, _ := computeConnectionCapacityCondition(ic, config, 2, routerConnectionsSample{connections: 100, pods: 2, timestamp: now}, true, now)
+	second, _ := computeConnectionCapacityCondition(ic, config, 2, routerConnectionsSample{connections: 1500, pods: 2, timestamp: now.Add(time.Minute)}, true, now.Add(time.Minute))
+	if first != second {
+		t.Errorf("expected the condition not to change below the threshold, got %+v and then %+v", first, second)
+	}
+	third, _ := computeConnectionCapacityCondition(ic, config, 2, routerConnectionsSample{connections: 1700, pods: 2, timestamp: now.Add(2 * time.Minute)}, true, now.Add(2*time.Minute))
+	if third.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the condition to change once the utilization crosses the threshold, got %+v", third)
+	}
+}
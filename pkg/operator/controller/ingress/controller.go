@@ -23,6 +23,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 
 	"k8s.io/client-go/tools/record"
 
@@ -35,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -60,7 +62,33 @@ const (
 	IngressControllerDeploymentRollingOutConditionType           = "DeploymentRollingOut"
 	IngressControllerLoadBalancerProgressingConditionType        = "LoadBalancerProgressing"
 	IngressControllerCanaryCheckSuccessConditionType             = "CanaryChecksSucceeding"
+	IngressControllerCanaryUserProbeSuccessConditionType         = "UserProbeSucceeding"
+	IngressControllerCanaryPartialFailureConditionType           = "CanaryPartialFailure"
+	IngressControllerExternalEndpointReachableConditionType      = "ExternalEndpointReachable"
+	IngressControllerExternalResolutionSucceedingConditionType   = "ExternalResolutionSucceeding"
+	IngressControllerExternalReachabilityConditionType           = "ExternalReachability"
+	IngressControllerDefaultCertificatePropagatedConditionType   = "DefaultCertificatePropagated"
 	IngressControllerEvaluationConditionsDetectedConditionType   = "EvaluationConditionsDetected"
+	IngressControllerPodsAuthorizedConditionType                 = "PodsAuthorized"
+
+	IngressControllerAWSLoadBalancerControllerAvailableConditionType = "AWSLoadBalancerControllerAvailable"
+	IngressControllerServingNodesAvailableConditionType              = "ServingNodesAvailable"
+	IngressControllerReplicasMoreThanSchedulableNodesConditionType   = "ReplicasMoreThanSchedulableNodes"
+
+	IngressControllerEndpointPublishingStrategySupportedConditionType = "EndpointPublishingStrategySupported"
+	IngressControllerStreamingResponsesConditionType                  = "StreamingResponses"
+	IngressControllerSecurityProfilePresetConditionType               = "SecurityProfilePreset"
+	IngressControllerStrictSNIHealthChecksCompatibleConditionType     = "StrictSNIHealthChecksCompatible"
+	IngressControllerDrainSurgeConditionType                          = "DrainSurge"
+	IngressControllerGCPLoadBalancerAddressReadyConditionType         = "GCPLoadBalancerAddressReady"
+	IngressControllerReloadIntervalConditionType                      = "ReloadInterval"
+	IngressControllerConnectionCapacityConditionType                  = "ConnectionCapacity"
+	IngressControllerRouterImageOverriddenConditionType               = "RouterImageOverridden"
+	IngressControllerEgressDSCPSupportedConditionType                 = "EgressDSCPSupported"
+	IngressControllerHTTP3SupportedConditionType                      = "HTTP3Supported"
+	IngressControllerDefaultCertificateSourceSyncedConditionType      = "DefaultCertificateSourceSynced"
+	IngressControllerDrainPeriodConditionType                         = "DrainPeriod"
+	IngressControllerDomainDelegationSuspectConditionType             = "DomainDelegationSuspect"
 
 	routerDefaultHeaderBufferSize           = 32768
 	routerDefaultHeaderBufferMaxRewriteSize = 8192
@@ -95,6 +123,8 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 		client:   mgr.GetClient(),
 		cache:    operatorCache,
 		recorder: mgr.GetEventRecorderFor(controllerName),
+
+		routerMetrics: newRouterMetricsPoller(),
 	}
 	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
 	if err != nil {
@@ -111,11 +141,18 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Service{}, enqueueRequestForOwningIngressController(config.Namespace))); err != nil {
 		return nil, err
 	}
-	// Add watch for deleted pods specifically for ensuring ingress deletion.
+	// Add watch for deleted pods specifically for ensuring ingress deletion,
+	// for scheduled pods so that the operator can label router pods with
+	// their zones for zone-aware routing, and for pods whose egress DSCP
+	// init container completes or fails so that the operator can update
+	// the EgressDSCPSupported status condition.
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Pod{}, enqueueRequestForOwningIngressController(config.Namespace), predicate.Funcs{
-		CreateFunc:  func(e event.CreateEvent) bool { return false },
-		DeleteFunc:  func(e event.DeleteEvent) bool { return true },
-		UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+		CreateFunc: func(e event.CreateEvent) bool { return false },
+		DeleteFunc: func(e event.DeleteEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPod, newPod := e.ObjectOld.(*corev1.Pod), e.ObjectNew.(*corev1.Pod)
+			return (len(oldPod.Spec.NodeName) == 0 && len(newPod.Spec.NodeName) != 0) || egressDSCPInitContainerStatusChanged(oldPod, newPod)
+		},
 		GenericFunc: func(e event.GenericEvent) bool { return false },
 	})); err != nil {
 		return nil, err
@@ -134,6 +171,45 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(source.Kind[client.Object](operatorCache, &configv1.Proxy{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController))); err != nil {
 		return nil, err
 	}
+	// Watch for nodes being cordoned or uncordoned so that the operator
+	// can surge router replicas when nodes that host router pods are
+	// drained and scale back afterwards.
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.Node{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController), predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return false },
+		DeleteFunc: func(e event.DeleteEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.(*corev1.Node).Spec.Unschedulable != e.ObjectNew.(*corev1.Node).Spec.Unschedulable
+		},
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	})); err != nil {
+		return nil, err
+	}
+	// Watch the router service account and RBAC resources so that the
+	// operator can restore them if they are deleted or modified.
+	routerServiceAccounts := []*corev1.ServiceAccount{manifests.RouterServiceAccount(), manifests.RouterEgressDSCPServiceAccount()}
+	routerServiceAccountPredicate := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		for _, sa := range routerServiceAccounts {
+			if o.GetNamespace() == sa.Namespace && o.GetName() == sa.Name {
+				return true
+			}
+		}
+		return false
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController), routerServiceAccountPredicate)); err != nil {
+		return nil, err
+	}
+	routerClusterRoleBindingName := manifests.RouterClusterRoleBinding().Name
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &rbacv1.ClusterRoleBinding{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController), predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == routerClusterRoleBindingName
+	}))); err != nil {
+		return nil, err
+	}
+	routerClusterRoleName := manifests.RouterClusterRole().Name
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &rbacv1.ClusterRole{}, handler.EnqueueRequestsFromMapFunc(reconciler.ingressConfigToIngressController), predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == routerClusterRoleName
+	}))); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -203,6 +279,10 @@ type reconciler struct {
 	client   client.Client
 	cache    cache.Cache
 	recorder record.EventRecorder
+
+	// routerMetrics scrapes the metrics of the ingresscontrollers'
+	// routers in the background.
+	routerMetrics *routerMetricsPoller
 }
 
 // admissionRejection is an error type for ingresscontroller admission
@@ -324,6 +404,14 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		}
 	}
 
+	// Start or finish any domain migration that the ingresscontroller
+	// specifies before ensuring the resources that depend on its domain.
+	if updated, err := r.syncDomainMigration(ingress, time.Now()); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to sync domain migration: %w", err)
+	} else if updated {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
 	// The ingresscontroller is safe to process, so ensure it.
 	if err := r.ensureIngressController(ingress, dnsConfig, infraConfig, platformStatus, ingressConfig, apiConfig, networkConfig, clusterProxyConfig); err != nil {
 		switch e := err.(type) {
@@ -334,6 +422,22 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 			return reconcile.Result{}, err
 		}
 	}
+	// Keep the snapshot of the default ingresscontroller's spec current so
+	// that the ingresscontroller can be recreated with the same spec if it
+	// is deleted.
+	if err := SnapshotDefaultIngressControllerSpec(ctx, r.client, ingress); err != nil {
+		return reconcile.Result{}, err
+	}
+	// Requeue when the overlap period of any domain migration ends so that
+	// the previous domain is retired on time.
+	if end, migrating := domainMigrationOverlapEnd(ingress); migrating {
+		return reconcile.Result{RequeueAfter: time.Until(end)}, nil
+	}
+	// Requeue while the router's metrics are scraped so that the status
+	// conditions that are computed from them pick up new samples.
+	if routerMetricsApply(ingress) {
+		return reconcile.Result{RequeueAfter: routerMetricsScrapeInterval}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
@@ -356,7 +460,7 @@ func (r *reconciler) admit(current *operatorv1.IngressController, ingressConfig
 	// get the default from the APIServer config (which is assumed to be
 	// valid).
 
-	if err := r.validate(updated); err != nil {
+	if err := r.validate(updated, platformStatus); err != nil {
 		switch err := err.(type) {
 		case *admissionRejection:
 			updated.Status.Conditions = MergeConditions(updated.Status.Conditions, operatorv1.OperatorCondition{
@@ -790,7 +894,7 @@ func tlsProfileSpecForSecurityProfile(profile *configv1.TLSSecurityProfile) *con
 // returns an error value, which will have a non-nil value of type
 // admissionRejection if the ingresscontroller is invalid, or a non-nil value of
 // a different type if validation could not be completed.
-func (r *reconciler) validate(ic *operatorv1.IngressController) error {
+func (r *reconciler) validate(ic *operatorv1.IngressController, platformStatus *configv1.PlatformStatus) error {
 	var errors []error
 
 	ingresses := &operatorv1.IngressControllerList{}
@@ -813,6 +917,30 @@ func (r *reconciler) validate(ic *operatorv1.IngressController) error {
 	if err := validateClientTLS(ic); err != nil {
 		errors = append(errors, err)
 	}
+	if err := validateSyslogLogging(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if err := validateDefaultCertificateSource(ic); err != nil {
+		errors = append(errors, err)
+	}
+	if overrides, err := unsupportedConfigOverridesForIngressController(ic); err != nil {
+		errors = append(errors, err)
+	} else {
+		for _, validate := range unsupportedConfigOverridesValidators {
+			if err := validate(ic, overrides); err != nil {
+				errors = append(errors, err)
+			}
+		}
+		if err := validateCanaryUserProbe(ic, overrides, ingresses.Items); err != nil {
+			errors = append(errors, err)
+		}
+		if err := validateGCPLoadBalancerConfig(ic, overrides, platformStatus); err != nil {
+			errors = append(errors, err)
+		}
+		if err := validateLoadBalancerIPFamilies(ic, overrides, platformStatus); err != nil {
+			errors = append(errors, err)
+		}
+	}
 	if err := utilerrors.NewAggregate(errors); err != nil {
 		return &admissionRejection{err.Error()}
 	}
@@ -935,6 +1063,40 @@ func validateHTTPHeaderBufferValues(ic *operatorv1.IngressController) error {
 	return nil
 }
 
+const (
+	// syslogMinMaxLength and syslogMaxMaxLength bound the maximum length of
+	// access log messages that the router sends to a syslog endpoint.
+	syslogMinMaxLength = 480
+	syslogMaxMaxLength = 4096
+)
+
+// syslogFacilities are the syslog facilities that the router can use for
+// access log messages.
+var syslogFacilities = sets.New[string]("kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "auth2", "ftp", "ntp", "audit", "alert", "cron2", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7")
+
+// validateSyslogLogging validates the given ingresscontroller's syslog access
+// logging destination, if it specifies one.  The API validates the facility and
+// the maximum message length too, but the router would fail to start with an
+// invalid value, so the operator checks them again rather than roll out a
+// broken deployment.
+func validateSyslogLogging(ic *operatorv1.IngressController) error {
+	if ic.Spec.Logging == nil || ic.Spec.Logging.Access == nil {
+		return nil
+	}
+	destination := ic.Spec.Logging.Access.Destination
+	if destination.Type != operatorv1.SyslogLoggingDestinationType || destination.Syslog == nil {
+		return nil
+	}
+	var errs []error
+	if facility := destination.Syslog.Facility; len(facility) != 0 && !syslogFacilities.Has(facility) {
+		errs = append(errs, fmt.Errorf("spec.logging.access.destination.syslog.facility must be one of %s: %q", strings.Join(sets.List(syslogFacilities), ", "), facility))
+	}
+	if maxLength := destination.Syslog.MaxLength; maxLength != 0 && (maxLength < syslogMinMaxLength || maxLength > syslogMaxMaxLength) {
+		errs = append(errs, fmt.Errorf("spec.logging.access.destination.syslog.maxLength must be between %d and %d: %d", syslogMinMaxLength, syslogMaxMaxLength, maxLength))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 // validateClientTLS validates the given ingresscontroller's client TLS
 // configuration.
 func validateClientTLS(ic *operatorv1.IngressController) error {
@@ -947,18 +1109,61 @@ func validateClientTLS(ic *operatorv1.IngressController) error {
 	return utilerrors.NewAggregate(errs)
 }
 
+// validateDefaultCertificateSource validates the secret that the given
+// ingresscontroller references in another namespace using the
+// "defaultCertificateSource" unsupported config override, if it references one.
+// Whether the router may read the secret is checked when the secret is copied
+// into the operand namespace and is reported in the
+// "DefaultCertificateSourceSynced" status condition.
+func validateDefaultCertificateSource(ic *operatorv1.IngressController) error {
+	source, err := ingresscontroller.DefaultCertificateSource(ic)
+	if err != nil || source == nil {
+		// validate reports decoding errors.
+		return nil
+	}
+	var errs []error
+	if ic.Spec.DefaultCertificate != nil {
+		errs = append(errs, fmt.Errorf("spec.defaultCertificate and spec.unsupportedConfigOverrides.defaultCertificateSource are mutually exclusive"))
+	}
+	switch {
+	case len(source.Namespace) == 0:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateSource.namespace must be specified"))
+	case source.Namespace == operatorcontroller.DefaultOperandNamespace:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateSource.namespace must not be %s; use spec.defaultCertificate for secrets in that namespace", operatorcontroller.DefaultOperandNamespace))
+	default:
+		for _, msg := range validation.IsDNS1123Label(source.Namespace) {
+			errs = append(errs, fmt.Errorf("invalid spec.unsupportedConfigOverrides.defaultCertificateSource.namespace %q: %s", source.Namespace, msg))
+		}
+	}
+	if len(source.Name) == 0 {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateSource.name must be specified"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(source.Name) {
+			errs = append(errs, fmt.Errorf("invalid spec.unsupportedConfigOverrides.defaultCertificateSource.name %q: %s", source.Name, msg))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 // ensureIngressDeleted tries to delete ingress, and if successful, will remove
 // the finalizer.
 func (r *reconciler) ensureIngressDeleted(ingress *operatorv1.IngressController) error {
 	errs := []error{}
 
-	// Delete the wildcard DNS record, and block ingresscontroller finalization
-	// until the dnsrecord has been finalized.
+	// Delete the wildcard DNS records, and block ingresscontroller
+	// finalization until the dnsrecords have been finalized.
 	dnsRecordName := operatorcontroller.WildcardDNSRecordName(ingress)
 	if err := dnsrecord.DeleteDNSRecord(r.client, dnsRecordName); err != nil {
 		errs = append(errs, fmt.Errorf("failed to delete wildcard dnsrecord for ingress %s/%s: %v", ingress.Namespace, ingress.Name, err))
 	}
+	previousDomainDNSRecordName := operatorcontroller.PreviousDomainWildcardDNSRecordName(ingress)
+	if err := dnsrecord.DeleteDNSRecord(r.client, previousDomainDNSRecordName); err != nil {
+		errs = append(errs, fmt.Errorf("failed to delete previous domain wildcard dnsrecord for ingress %s/%s: %v", ingress.Namespace, ingress.Name, err))
+	}
 	haveRec, _, err := dnsrecord.CurrentDNSRecord(r.client, dnsRecordName)
+	if err == nil && !haveRec {
+		haveRec, _, err = dnsrecord.CurrentDNSRecord(r.client, previousDomainDNSRecordName)
+	}
 	switch {
 	case err != nil:
 		errs = append(errs, fmt.Errorf("failed to get current wildcard dnsrecord for ingress %s/%s: %v", ingress.Namespace, ingress.Name, err))
@@ -997,9 +1202,16 @@ func (r *reconciler) ensureIngressDeleted(ingress *operatorv1.IngressController)
 	// Delete the metrics related to the ingresscontroller
 	DeleteIngressControllerConditionsMetric(ingress)
 	DeleteActiveNLBMetrics(ingress)
+	DeleteLoadBalancerReadyReasonMetric(ingress)
+	DeleteServingNodeAddressesMetric(ingress)
+	DeleteRoutesPendingStatusUpdateMetric(ingress)
+	DeleteConnectionUtilizationMetrics(ingress)
+	r.routerMetrics.forget(ingress)
 
 	// Delete the RoutesPerShard metric label corresponding to the Ingress Controller.
 	routemetrics.DeleteRouteMetricsControllerRoutesPerShardMetric(ingress.Name)
+	routemetrics.DeleteRouteMetricsControllerRejectionMetrics(ingress.Name)
+	routemetrics.DeleteRouteMetricsControllerRouteScaleMetrics(ingress.Name)
 
 	if len(errs) == 0 {
 		// Remove the ingresscontroller finalizer.
@@ -1104,11 +1316,16 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 		dnsRecordLabels := map[string]string{
 			manifests.OwningIngressControllerLabel: ci.Name,
 		}
-		if _, record, err := dnsrecord.EnsureWildcardDNSRecord(r.client, dnsRecordName, dnsRecordLabels, icRef, ci.Status.Domain, ci.Status.EndpointPublishingStrategy, lbService, haveLB); err != nil {
+		if zoneTargets, err := dnsZoneTargetsForIngressController(ci); err != nil {
+			errs = append(errs, fmt.Errorf("failed to ensure wildcard dnsrecord for %s: %v", ci.Name, err))
+		} else if _, record, err := dnsrecord.EnsureWildcardDNSRecord(r.client, dnsRecordName, dnsRecordLabels, icRef, ci.Status.Domain, wildcardRecordPublishingStrategy(ci), lbService, haveLB, dnsrecord.TargetPreferenceForAnnotations(ci.Annotations), zoneTargets); err != nil {
 			errs = append(errs, fmt.Errorf("failed to ensure wildcard dnsrecord for %s: %v", ci.Name, err))
 		} else {
 			wildcardRecord = record
 		}
+		if err := r.ensurePreviousDomainWildcardDNSRecord(ci, dnsRecordLabels, icRef, lbService, haveLB); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if _, _, err := r.ensureNodePortService(ci, deploymentRef); err != nil {
@@ -1127,6 +1344,10 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 		errs = append(errs, err)
 	}
 
+	if _, _, err := r.ensureCDNOriginConfigMap(ci, lbService, deploymentRef); err != nil {
+		errs = append(errs, err)
+	}
+
 	if _, _, err := r.ensureRouterPodDisruptionBudget(ci, deploymentRef); err != nil {
 		errs = append(errs, err)
 	}
@@ -1141,7 +1362,13 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 		errs = append(errs, fmt.Errorf("failed to list pods in namespace %q: %v", operatorcontroller.DefaultOperatorNamespace, err))
 	}
 
-	syncStatusErr, updated := r.syncIngressControllerStatus(ci, deployment, deploymentRef, pods.Items, lbService, operandEvents.Items, wildcardRecord, dnsConfig, platformStatus)
+	if zoneAwareRoutingEnabled(ci) {
+		if err := r.ensureRouterPodZoneLabels(deployment, pods.Items); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	syncStatusErr, updated := r.syncIngressControllerStatus(ci, deployment, deploymentRef, pods.Items, lbService, operandEvents.Items, wildcardRecord, dnsConfig, platformStatus, networkConfig)
 	errs = append(errs, syncStatusErr)
 
 	// If syncIngressControllerStatus updated our ingress status, it's important we query for that new object.
@@ -2,6 +2,7 @@ package ingress
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // Test_setDefaultDomain verifies that setDefaultDomain behaves correctly.
@@ -1328,9 +1330,909 @@ func Test_validateClientTLS(t *testing.T) {
 	}
 }
 
+// Test_validateSyslogLogging verifies that validateSyslogLogging accepts only
+// known syslog facilities and maximum message lengths between 480 and 4096.
+func Test_validateSyslogLogging(t *testing.T) {
+	testCases := []struct {
+		description string
+		facility    string
+		maxLength   uint32
+		expectError bool
+	}{
+		{
+			description: "defaults",
+		},
+		{
+			description: "facility and maximum length",
+			facility:    "local3",
+			maxLength:   4096,
+		},
+		{
+			description: "minimum length",
+			maxLength:   480,
+		},
+		{
+			description: "unknown facility",
+			facility:    "local8",
+			expectError: true,
+		},
+		{
+			description: "length below the minimum",
+			maxLength:   479,
+			expectError: true,
+		},
+		{
+			description: "length above the maximum",
+			maxLength:   4097,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					Logging: &operatorv1.IngressControllerLogging{
+						Access: &operatorv1.AccessLogging{
+							Destination: operatorv1.LoggingDestination{
+								Type: operatorv1.SyslogLoggingDestinationType,
+								Syslog: &operatorv1.SyslogLoggingDestinationParameters{
+									Address:   "1.2.3.4",
+									Port:      uint32(514),
+									Facility:  tc.facility,
+									MaxLength: tc.maxLength,
+								},
+							},
+						},
+					},
+				},
+			}
+			switch err := validateSyslogLogging(ic); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_validateDefaultCertificateSource verifies that
+// validateDefaultCertificateSource accepts a secret reference in another
+// namespace and rejects incomplete or invalid references, references to the
+// operand namespace, and references that conflict with
+// spec.defaultCertificate.
+func Test_validateDefaultCertificateSource(t *testing.T) {
+	testCases := []struct {
+		description        string
+		overrides          string
+		defaultCertificate string
+		expectError        bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "secret in another namespace",
+			overrides:   `{"defaultCertificateSource":{"namespace":"team-certs","name":"wildcard"}}`,
+		},
+		{
+			description:        "spec.defaultCertificate also specified",
+			overrides:          `{"defaultCertificateSource":{"namespace":"team-certs","name":"wildcard"}}`,
+			defaultCertificate: "custom",
+			expectError:        true,
+		},
+		{
+			description: "missing namespace",
+			overrides:   `{"defaultCertificateSource":{"name":"wildcard"}}`,
+			expectError: true,
+		},
+		{
+			description: "missing name",
+			overrides:   `{"defaultCertificateSource":{"namespace":"team-certs"}}`,
+			expectError: true,
+		},
+		{
+			description: "operand namespace",
+			overrides:   `{"defaultCertificateSource":{"namespace":"openshift-ingress","name":"wildcard"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid namespace",
+			overrides:   `{"defaultCertificateSource":{"namespace":"Team_Certs","name":"wildcard"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid name",
+			overrides:   `{"defaultCertificateSource":{"namespace":"team-certs","name":"wild/card"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			if len(tc.defaultCertificate) != 0 {
+				ic.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: tc.defaultCertificate}
+			}
+			switch err := validateDefaultCertificateSource(ic); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateRouteDefaults(t *testing.T) {
+	testCases := []struct {
+		description     string
+		overrides       string
+		expectError     bool
+		expectInMessage string
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "overrides without route defaults",
+			overrides:   `{"loadBalancingAlgorithm":"leastconn"}`,
+			expectError: false,
+		},
+		{
+			description: "valid route defaults",
+			overrides:   `{"routeDefaults":{"haproxy.router.openshift.io/balance":"source","haproxy.router.openshift.io/timeout":"30s"}}`,
+			expectError: false,
+		},
+		{
+			description:     "HSTS header annotation",
+			overrides:       `{"routeDefaults":{"haproxy.router.openshift.io/hsts_header":"max-age=31536000"}}`,
+			expectError:     true,
+			expectInMessage: "requiredHSTSPolicies",
+		},
+		{
+			description: "unsupported annotation",
+			overrides:   `{"routeDefaults":{"haproxy.router.openshift.io/rate-limit-connections":"true"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid load-balancing algorithm",
+			overrides:   `{"routeDefaults":{"haproxy.router.openshift.io/balance":"first"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid timeout",
+			overrides:   `{"routeDefaults":{"haproxy.router.openshift.io/timeout":"forever"}}`,
+			expectError: true,
+		},
+		{
+			description: "empty timeout",
+			overrides:   `{"routeDefaults":{"haproxy.router.openshift.io/timeout":""}}`,
+			expectError: true,
+		},
+		{
+			description: "redirect insecure policy",
+			overrides:   `{"routeDefaults":{"insecurePolicy":"Redirect"}}`,
+			expectError: false,
+		},
+		{
+			description: "disable insecure policy",
+			overrides:   `{"routeDefaults":{"insecurePolicy":"Disable"}}`,
+			expectError: false,
+		},
+		{
+			description: "route API name for disabled insecure policy",
+			overrides:   `{"routeDefaults":{"insecurePolicy":"None"}}`,
+			expectError: true,
+		},
+		{
+			description: "source balancing for passthrough routes",
+			overrides:   `{"routeDefaults":{"passthroughBalance":"source"}}`,
+			expectError: false,
+		},
+		{
+			description: "leastconn balancing for passthrough routes",
+			overrides:   `{"routeDefaults":{"passthroughBalance":"leastconn"}}`,
+			expectError: false,
+		},
+		{
+			description: "random balancing for passthrough routes",
+			overrides:   `{"routeDefaults":{"passthroughBalance":"random"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateRouteDefaults(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			case err != nil && !strings.Contains(err.Error(), tc.expectInMessage):
+				t.Errorf("expected error to contain %q, got %v", tc.expectInMessage, err)
+			}
+		})
+	}
+}
+
 // Test_IsProxyProtocolNeeded verifies that IsProxyProtocolNeeded returns the
 // expected values for various platforms and endpoint publishing strategy
 // parameters.
+func Test_validateHTTPHeaderOverrides(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "suppressible headers",
+			overrides:   `{"suppressForwardedHeaders":["X-Forwarded-Port","x-forwarded-proto-version"]}`,
+			expectError: false,
+		},
+		{
+			description: "unsupported header",
+			overrides:   `{"suppressForwardedHeaders":["X-Forwarded-For"]}`,
+			expectError: true,
+		},
+		{
+			description: "lowercase header name case",
+			overrides:   `{"headerNameCase":"Lowercase"}`,
+			expectError: false,
+		},
+		{
+			description: "preserve header name case",
+			overrides:   `{"headerNameCase":"Preserve"}`,
+			expectError: false,
+		},
+		{
+			description: "invalid header name case",
+			overrides:   `{"headerNameCase":"Title"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateHTTPHeaderOverrides(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateRouterMetricsConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "off",
+			overrides:   `{"routerMetrics":{"granularity":"Off"}}`,
+			expectError: false,
+		},
+		{
+			description: "per-backend aggregated",
+			overrides:   `{"routerMetrics":{"granularity":"PerBackendAggregated"}}`,
+			expectError: false,
+		},
+		{
+			description: "per-route without a selector",
+			overrides:   `{"routerMetrics":{"granularity":"PerRoute"}}`,
+			expectError: false,
+		},
+		{
+			description: "per-route with a selector",
+			overrides:   `{"routerMetrics":{"granularity":"PerRoute","routeSelector":{"matchLabels":{"metrics":"true"}}}}`,
+			expectError: false,
+		},
+		{
+			description: "invalid granularity",
+			overrides:   `{"routerMetrics":{"granularity":"PerServer"}}`,
+			expectError: true,
+		},
+		{
+			description: "selector without per-route granularity",
+			overrides:   `{"routerMetrics":{"granularity":"PerBackendAggregated","routeSelector":{"matchLabels":{"metrics":"true"}}}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid selector",
+			overrides:   `{"routerMetrics":{"granularity":"PerRoute","routeSelector":{"matchExpressions":[{"key":"metrics","operator":"Bogus"}]}}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateRouterMetricsConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateStreamingResponsesConfig(t *testing.T) {
+	testCases := []struct {
+		description  string
+		overrides    string
+		headerBuffer int32
+		maxRewrite   int32
+		expectError  bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "stream",
+			overrides:   `{"streamingResponses":{"policy":"Stream"}}`,
+			expectError: false,
+		},
+		{
+			description: "buffer",
+			overrides:   `{"streamingResponses":{"policy":"Buffer"}}`,
+			expectError: false,
+		},
+		{
+			description: "invalid policy",
+			overrides:   `{"streamingResponses":{"policy":"Flush"}}`,
+			expectError: true,
+		},
+		{
+			description: "valid response buffer size",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":16384}}`,
+			expectError: false,
+		},
+		{
+			description: "response buffer size too small",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":1024}}`,
+			expectError: true,
+		},
+		{
+			description: "response buffer size too large",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":2097152}}`,
+			expectError: true,
+		},
+		{
+			description: "response buffer size not larger than default max rewrite size",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":8192}}`,
+			expectError: true,
+		},
+		{
+			description: "response buffer size larger than a small max rewrite size",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":8192}}`,
+			maxRewrite:  4096,
+			expectError: false,
+		},
+		{
+			description:  "response buffer size with header buffer size",
+			overrides:    `{"streamingResponses":{"policy":"Stream","responseBufferBytes":16384}}`,
+			headerBuffer: 16384,
+			expectError:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+					TuningOptions: operatorv1.IngressControllerTuningOptions{
+						HeaderBufferBytes:           tc.headerBuffer,
+						HeaderBufferMaxRewriteBytes: tc.maxRewrite,
+					},
+				},
+			}
+			switch err := validateStreamingResponsesConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validatePropagatedMetadata(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "valid labels and annotations",
+			overrides:   `{"propagatedMetadata":{"labels":{"cost-center":"1234","example.com/team":"net"},"annotations":{"example.com/owner":"Network Edge"}}}`,
+			expectError: false,
+		},
+		{
+			description: "invalid label key",
+			overrides:   `{"propagatedMetadata":{"labels":{"cost center":"1234"}}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid label value",
+			overrides:   `{"propagatedMetadata":{"labels":{"team":"network edge"}}}`,
+			expectError: true,
+		},
+		{
+			description: "label key owned by the operator",
+			overrides:   `{"propagatedMetadata":{"labels":{"ingresscontroller.operator.openshift.io/owning-ingresscontroller":"other"}}}`,
+			expectError: true,
+		},
+		{
+			description: "unprefixed label key owned by the operator",
+			overrides:   `{"propagatedMetadata":{"labels":{"app":"other"}}}`,
+			expectError: true,
+		},
+		{
+			description: "label key in a reserved domain",
+			overrides:   `{"propagatedMetadata":{"labels":{"app.kubernetes.io/name":"router"}}}`,
+			expectError: true,
+		},
+		{
+			description: "annotation key in a reserved domain",
+			overrides:   `{"propagatedMetadata":{"annotations":{"service.beta.kubernetes.io/aws-load-balancer-internal":"true"}}}`,
+			expectError: true,
+		},
+		{
+			description: "annotation key managed on services",
+			overrides:   `{"propagatedMetadata":{"annotations":{"networking.gke.io/internal-load-balancer-allow-global-access":"true"}}}`,
+			expectError: true,
+		},
+		{
+			description: "annotation key with a domain that merely contains a reserved domain",
+			overrides:   `{"propagatedMetadata":{"annotations":{"notopenshift.io/owner":"me"}}}`,
+			expectError: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validatePropagatedMetadata(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateSecurityProfilePreset(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "default",
+			overrides:   `{"securityProfilePreset":"Default"}`,
+			expectError: false,
+		},
+		{
+			description: "hardened",
+			overrides:   `{"securityProfilePreset":"Hardened"}`,
+			expectError: false,
+		},
+		{
+			description: "invalid preset",
+			overrides:   `{"securityProfilePreset":"Paranoid"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateSecurityProfilePreset(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateStrictSNIPolicy(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "enabled",
+			overrides:   `{"strictSNI":"Enabled"}`,
+			expectError: false,
+		},
+		{
+			description: "disabled",
+			overrides:   `{"strictSNI":"Disabled"}`,
+			expectError: false,
+		},
+		{
+			description: "invalid policy",
+			overrides:   `{"strictSNI":"true"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateStrictSNIPolicy(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_validateStrictHostValidationPolicy verifies that the strict host
+// validation policy must be "Enabled" or "Disabled" if specified.
+func Test_validateStrictHostValidationPolicy(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "tuning options without strictHostValidation",
+			overrides:   `{"tuningOptions":{}}`,
+			expectError: false,
+		},
+		{
+			description: "enabled",
+			overrides:   `{"tuningOptions":{"strictHostValidation":"Enabled"}}`,
+			expectError: false,
+		},
+		{
+			description: "disabled",
+			overrides:   `{"tuningOptions":{"strictHostValidation":"Disabled"}}`,
+			expectError: false,
+		},
+		{
+			description: "invalid policy",
+			overrides:   `{"tuningOptions":{"strictHostValidation":"true"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateStrictHostValidationPolicy(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateNodePortExternalEndpoint(t *testing.T) {
+	strategy := func(t operatorv1.EndpointPublishingStrategyType) *operatorv1.EndpointPublishingStrategy {
+		return &operatorv1.EndpointPublishingStrategy{Type: t}
+	}
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: false,
+		},
+		{
+			description: "IPv4 VIP",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: false,
+		},
+		{
+			description: "IPv6 VIP with port",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"[2001:db8::10]:8443"}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: false,
+		},
+		{
+			description: "hostname",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"lb.example.com"}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: false,
+		},
+		{
+			description: "empty address",
+			overrides:   `{"nodePortExternalEndpoint":{"address":""}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: true,
+		},
+		{
+			description: "invalid hostname",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"lb_example.com"}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: true,
+		},
+		{
+			description: "invalid port",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"lb.example.com:0"}}`,
+			eps:         strategy(operatorv1.NodePortServiceStrategyType),
+			expectError: true,
+		},
+		{
+			description: "load balancer strategy",
+			overrides:   `{"nodePortExternalEndpoint":{"address":"192.0.2.10"}}`,
+			eps:         strategy(operatorv1.LoadBalancerServiceStrategyType),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateNodePortExternalEndpoint(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateAccessLogFilter(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectError: false,
+		},
+		{
+			description: "errors only",
+			overrides:   `{"accessLogFilter":{"errorsOnly":true}}`,
+			expectError: false,
+		},
+		{
+			description: "disjoint ranges",
+			overrides:   `{"accessLogFilter":{"statusCodeRanges":[{"from":500,"to":599},{"from":404,"to":404},{"from":429,"to":429}]}}`,
+			expectError: false,
+		},
+		{
+			description: "errors only with ranges",
+			overrides:   `{"accessLogFilter":{"errorsOnly":true,"statusCodeRanges":[{"from":500,"to":599}]}}`,
+			expectError: true,
+		},
+		{
+			description: "overlapping ranges",
+			overrides:   `{"accessLogFilter":{"statusCodeRanges":[{"from":500,"to":599},{"from":400,"to":500}]}}`,
+			expectError: true,
+		},
+		{
+			description: "reversed range",
+			overrides:   `{"accessLogFilter":{"statusCodeRanges":[{"from":599,"to":500}]}}`,
+			expectError: true,
+		},
+		{
+			description: "out-of-bounds range",
+			overrides:   `{"accessLogFilter":{"statusCodeRanges":[{"from":500,"to":600}]}}`,
+			expectError: true,
+		},
+		{
+			description: "empty range",
+			overrides:   `{"accessLogFilter":{"statusCodeRanges":[{}]}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateAccessLogFilter(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_validateCanaryUserProbe(t *testing.T) {
+	ingresses := []operatorv1.IngressController{
+		{Status: operatorv1.IngressControllerStatus{Domain: "apps.example.com"}},
+		{Status: operatorv1.IngressControllerStatus{Domain: "shard.example.com"}},
+	}
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no probe",
+			overrides:   `{"routeDefaults":{}}`,
+			expectError: false,
+		},
+		{
+			description: "probe with defaults",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.apps.example.com"}}`,
+			expectError: false,
+		},
+		{
+			description: "probe under a sharded domain",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.shard.example.com.","path":"/healthz","expectedStatus":204,"expectedBodySubstring":"ok","tlsVerification":"Skip"}}`,
+			expectError: false,
+		},
+		{
+			description: "probe of a non-cluster host",
+			overrides:   `{"canaryUserProbe":{"hostname":"www.example.org"}}`,
+			expectError: true,
+		},
+		{
+			description: "probe of a domain without a subdomain",
+			overrides:   `{"canaryUserProbe":{"hostname":"apps.example.com"}}`,
+			expectError: true,
+		},
+		{
+			description: "probe of a host whose name only ends with the domain",
+			overrides:   `{"canaryUserProbe":{"hostname":"evilapps.example.com"}}`,
+			expectError: true,
+		},
+		{
+			description: "probe of an IP address",
+			overrides:   `{"canaryUserProbe":{"hostname":"10.0.0.1"}}`,
+			expectError: true,
+		},
+		{
+			description: "probe with a relative path",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.apps.example.com","path":"healthz"}}`,
+			expectError: true,
+		},
+		{
+			description: "probe with an invalid status",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.apps.example.com","expectedStatus":999}}`,
+			expectError: true,
+		},
+		{
+			description: "probe with an invalid TLS verification mode",
+			overrides:   `{"canaryUserProbe":{"hostname":"app.apps.example.com","tlsVerification":"Sometimes"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			switch err := validateCanaryUserProbe(ic, mustDecodeUnsupportedConfigOverrides(t, ic), ingresses); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func Test_IsProxyProtocolNeeded(t *testing.T) {
 	var (
 		awsPlatform = configv1.PlatformStatus{
@@ -0,0 +1,41 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultCertificateSignerForIngressController returns the name of the secret
+// in the operator's namespace that holds the intermediate CA with which the
+// given ingresscontroller specifies that the operator sign its generated
+// default certificate, or the empty string if it specifies none, in which case
+// the operator's own ingress CA signs the certificate.  The ingresscontroller
+// specifies the secret using
+// spec.unsupportedConfigOverrides.defaultCertificateSigner.secretName.  A
+// default certificate that spec.defaultCertificate specifies always takes
+// precedence over the generated one.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func DefaultCertificateSignerForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.DefaultCertificateSigner.SecretName, nil
+}
+
+// validateDefaultCertificateSigner validates the name of the secret that the
+// given ingresscontroller specifies for signing its generated default
+// certificate, if it specifies one.
+func validateDefaultCertificateSigner(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	secretName := overrides.DefaultCertificateSigner.SecretName
+	if len(secretName) == 0 {
+		return nil
+	}
+	if msgs := validation.IsDNS1123Subdomain(secretName); len(msgs) != 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateSigner.secretName %q is not a valid secret name: %v", secretName, msgs)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateDefaultCertificateSigner verifies that
+// validateDefaultCertificateSigner accepts a missing or valid secret name and
+// rejects an invalid one.
+func Test_validateDefaultCertificateSigner(t *testing.T) {
+	testCases := []struct {
+		name         string
+		overrides    string
+		expectSigner string
+		expectError  bool
+	}{
+		{name: "no overrides"},
+		{name: "other overrides", overrides: `{"contStats":"true"}`},
+		{name: "valid secret name", overrides: `{"defaultCertificateSigner":{"secretName":"shard-signer"}}`, expectSigner: "shard-signer"},
+		{name: "invalid secret name", overrides: `{"defaultCertificateSigner":{"secretName":"Shard_Signer"}}`, expectSigner: "Shard_Signer", expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "internal"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			if signer, err := DefaultCertificateSignerForIngressController(ic); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if signer != tc.expectSigner {
+				t.Errorf("expected signer %q, got %q", tc.expectSigner, signer)
+			}
+			switch err := validateDefaultCertificateSigner(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
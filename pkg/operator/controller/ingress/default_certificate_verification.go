@@ -0,0 +1,88 @@
+package ingress
+
+import (
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// DefaultCertificateVerificationDefaultTimeout is how long the
+	// post-change verification probe tries to observe the new default
+	// certificate on the dependent routes' hosts if the ingresscontroller
+	// does not specify a timeout.
+	DefaultCertificateVerificationDefaultTimeout = 15 * time.Minute
+	// DefaultCertificateVerificationMinTimeout is the shortest timeout
+	// that an ingresscontroller may specify.
+	DefaultCertificateVerificationMinTimeout = 1 * time.Minute
+)
+
+// DefaultCertificateVerificationProbe describes the probe that verifies, after
+// the default certificate changes, that the hosts of the platform routes that
+// depend on the default certificate serve the new certificate.  The default
+// ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.defaultCertificateVerificationProbe.  The
+// probe is disabled unless it is specified.
+type DefaultCertificateVerificationProbe struct {
+	// Timeout is how long the probe tries to observe the new certificate,
+	// in the format of time.ParseDuration.  The default is 15m, and the
+	// minimum is 1m.
+	Timeout string `json:"timeout"`
+}
+
+// DefaultCertificateVerificationProbeForIngressController returns the
+// post-change verification probe that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, with defaults applied, or nil if it
+// specifies none.  An error is returned if spec.unsupportedConfigOverrides
+// cannot be decoded.
+func DefaultCertificateVerificationProbeForIngressController(ic *operatorv1.IngressController) (*DefaultCertificateVerificationProbe, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.defaultCertificateVerificationProbe(), nil
+}
+
+// defaultCertificateVerificationProbe returns the post-change verification
+// probe that the overrides specify, with defaults applied, or nil if they
+// specify none.
+func (o *unsupportedConfigOverrides) defaultCertificateVerificationProbe() *DefaultCertificateVerificationProbe {
+	if o.DefaultCertificateVerificationProbe == nil {
+		return nil
+	}
+	probe := *o.DefaultCertificateVerificationProbe
+	if len(probe.Timeout) == 0 {
+		probe.Timeout = DefaultCertificateVerificationDefaultTimeout.String()
+	}
+	return &probe
+}
+
+// TimeoutDuration returns the probe's timeout.  The probe must be valid.
+func (p *DefaultCertificateVerificationProbe) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(p.Timeout)
+	return d
+}
+
+// ValidateDefaultCertificateVerificationProbe validates the given post-change
+// verification probe.
+func ValidateDefaultCertificateVerificationProbe(probe *DefaultCertificateVerificationProbe) error {
+	d, err := time.ParseDuration(probe.Timeout)
+	if err != nil {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateVerificationProbe.timeout is invalid: %w", err)
+	}
+	if d < DefaultCertificateVerificationMinTimeout {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.defaultCertificateVerificationProbe.timeout %q is shorter than the minimum of %v", probe.Timeout, DefaultCertificateVerificationMinTimeout)
+	}
+	return nil
+}
+
+// validateDefaultCertificateVerificationProbe validates the post-change
+// verification probe that the given ingresscontroller specifies, if any.
+func validateDefaultCertificateVerificationProbe(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	probe := overrides.defaultCertificateVerificationProbe()
+	if probe == nil {
+		return nil
+	}
+	return ValidateDefaultCertificateVerificationProbe(probe)
+}
@@ -0,0 +1,59 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateDefaultCertificateVerificationProbe verifies that
+// validateDefaultCertificateVerificationProbe accepts a probe that is disabled
+// or that specifies a valid timeout, and rejects invalid timeouts and timeouts
+// that are shorter than the minimum.
+func Test_validateDefaultCertificateVerificationProbe(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "defaults",
+			overrides:   `{"defaultCertificateVerificationProbe":{}}`,
+		},
+		{
+			description: "valid timeout",
+			overrides:   `{"defaultCertificateVerificationProbe":{"timeout":"5m"}}`,
+		},
+		{
+			description: "timeout shorter than the minimum",
+			overrides:   `{"defaultCertificateVerificationProbe":{"timeout":"30s"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid timeout",
+			overrides:   `{"defaultCertificateVerificationProbe":{"timeout":"soon"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateDefaultCertificateVerificationProbe(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultSpecSnapshotKey is the key in the default ingresscontroller's spec
+// snapshot configmap whose value is the JSON-encoded spec.
+const defaultSpecSnapshotKey = "spec"
+
+// preservesSpecOnRecreation returns a Boolean value indicating whether the
+// given ingresscontroller's spec should be restored if it is recreated.
+func preservesSpecOnRecreation(ic *operatorv1.IngressController) bool {
+	return ic.Annotations[ingresscontroller.PreserveSpecOnRecreationAnnotation] != "false"
+}
+
+// defaultSpecSnapshot returns the spec with which the given ingresscontroller
+// should be recreated.  This is the ingresscontroller's spec, except that if
+// the spec does not specify an endpoint publishing strategy, the snapshot
+// specifies the effective strategy from the ingresscontroller's status so that
+// the recreated ingresscontroller gets the same kind of load balancer even if
+// the cluster ingress config's defaults have changed in the meantime.
+func defaultSpecSnapshot(ic *operatorv1.IngressController) operatorv1.IngressControllerSpec {
+	spec := *ic.Spec.DeepCopy()
+	if spec.EndpointPublishingStrategy == nil && ic.Status.EndpointPublishingStrategy != nil {
+		spec.EndpointPublishingStrategy = ic.Status.EndpointPublishingStrategy.DeepCopy()
+	}
+	return spec
+}
+
+// SnapshotDefaultIngressControllerSpec records the spec of the given
+// ingresscontroller in a configmap in the ingresscontroller's namespace if it is
+// the default ingresscontroller, so that the operator can restore the spec if
+// it recreates the ingresscontroller after it has been deleted.  If the
+// ingresscontroller opts out using the "preserve-spec-on-recreation"
+// annotation, the configmap is deleted instead.  Other ingresscontrollers are
+// ignored.
+func SnapshotDefaultIngressControllerSpec(ctx context.Context, cl client.Client, ic *operatorv1.IngressController) error {
+	if ic.Name != manifests.DefaultIngressControllerName {
+		return nil
+	}
+	name := operatorcontroller.DefaultIngressControllerSpecSnapshotConfigMapName(ic.Namespace)
+	current := &corev1.ConfigMap{}
+	haveConfigMap := true
+	if err := cl.Get(ctx, name, current); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		haveConfigMap = false
+	}
+
+	if !preservesSpecOnRecreation(ic) {
+		if !haveConfigMap {
+			return nil
+		}
+		if err := cl.Delete(ctx, current); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete configmap %s: %w", name, err)
+		}
+		log.Info("deleted default ingresscontroller spec snapshot", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+
+	data, err := json.Marshal(defaultSpecSnapshot(ic))
+	if err != nil {
+		return fmt.Errorf("failed to encode spec of ingresscontroller %s/%s: %w", ic.Namespace, ic.Name, err)
+	}
+	if !haveConfigMap {
+		desired := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: name.Namespace,
+				Name:      name.Name,
+			},
+			Data: map[string]string{defaultSpecSnapshotKey: string(data)},
+		}
+		if err := cl.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+		log.Info("created default ingresscontroller spec snapshot", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	if current.Data[defaultSpecSnapshotKey] == string(data) {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = map[string]string{defaultSpecSnapshotKey: string(data)}
+	if err := cl.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update configmap %s: %w", name, err)
+	}
+	log.Info("updated default ingresscontroller spec snapshot", "namespace", name.Namespace, "name", name.Name)
+	return nil
+}
+
+// DefaultIngressControllerSpecFromSnapshot returns the spec of the default
+// ingresscontroller that SnapshotDefaultIngressControllerSpec recorded in the
+// given namespace, or nil if there is no snapshot.  An error is returned if the
+// snapshot cannot be read or decoded.
+func DefaultIngressControllerSpecFromSnapshot(ctx context.Context, cl client.Reader, namespace string) (*operatorv1.IngressControllerSpec, error) {
+	name := operatorcontroller.DefaultIngressControllerSpecSnapshotConfigMapName(namespace)
+	cm := &corev1.ConfigMap{}
+	if err := cl.Get(ctx, name, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+	}
+	data, ok := cm.Data[defaultSpecSnapshotKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s has no %q key", name, defaultSpecSnapshotKey)
+	}
+	spec := &operatorv1.IngressControllerSpec{}
+	if err := json.Unmarshal([]byte(data), spec); err != nil {
+		return nil, fmt.Errorf("failed to decode configmap %s: %w", name, err)
+	}
+	return spec, nil
+}
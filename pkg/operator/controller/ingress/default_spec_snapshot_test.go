@@ -0,0 +1,127 @@
+package ingress
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_SnapshotDefaultIngressControllerSpec verifies that
+// SnapshotDefaultIngressControllerSpec keeps the snapshot of the default
+// ingresscontroller's spec current, fills in the effective endpoint publishing
+// strategy, ignores other ingresscontrollers, and deletes the snapshot when the
+// default ingresscontroller opts out.
+func Test_SnapshotDefaultIngressControllerSpec(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ctx := context.Background()
+	name := operatorcontroller.DefaultIngressControllerSpecSnapshotConfigMapName(namespace)
+
+	two := int32(2)
+	other := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "sharded"},
+		Spec:       operatorv1.IngressControllerSpec{Replicas: &two},
+	}
+	if err := SnapshotDefaultIngressControllerSpec(ctx, cl, other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec, err := DefaultIngressControllerSpecFromSnapshot(ctx, cl, namespace); err != nil || spec != nil {
+		t.Fatalf("expected no snapshot for a non-default ingresscontroller, got %+v (err: %v)", spec, err)
+	}
+
+	internal := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			Scope:               operatorv1.InternalLoadBalancer,
+			DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+		},
+	}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "default"},
+		Spec: operatorv1.IngressControllerSpec{
+			Replicas: &two,
+			NodePlacement: &operatorv1.NodePlacement{
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/infra": ""}},
+			},
+			TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+		},
+		Status: operatorv1.IngressControllerStatus{EndpointPublishingStrategy: internal},
+	}
+	if err := SnapshotDefaultIngressControllerSpec(ctx, cl, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := *ic.Spec.DeepCopy()
+	expected.EndpointPublishingStrategy = internal
+	spec, err := DefaultIngressControllerSpecFromSnapshot(ctx, cl, namespace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec == nil || !reflect.DeepEqual(*spec, expected) {
+		t.Fatalf("expected snapshot %+v, got %+v", expected, spec)
+	}
+
+	three := int32(3)
+	ic.Spec.Replicas = &three
+	if err := SnapshotDefaultIngressControllerSpec(ctx, cl, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec, err := DefaultIngressControllerSpecFromSnapshot(ctx, cl, namespace); err != nil || spec == nil || *spec.Replicas != 3 {
+		t.Fatalf("expected the snapshot to be updated to 3 replicas, got %+v (err: %v)", spec, err)
+	}
+
+	ic.Annotations = map[string]string{ingresscontroller.PreserveSpecOnRecreationAnnotation: "false"}
+	if err := SnapshotDefaultIngressControllerSpec(ctx, cl, ic); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cl.Get(ctx, name, &corev1.ConfigMap{}); err == nil {
+		t.Fatal("expected the snapshot to be deleted after opting out")
+	}
+}
+
+// Test_DefaultIngressControllerSpecFromSnapshot verifies that
+// DefaultIngressControllerSpecFromSnapshot reports an error for a snapshot
+// that cannot be decoded.
+func Test_DefaultIngressControllerSpecFromSnapshot(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	name := operatorcontroller.DefaultIngressControllerSpecSnapshotConfigMapName(namespace)
+	testCases := []struct {
+		name string
+		data map[string]string
+	}{
+		{
+			name: "missing key",
+			data: map[string]string{"foo": "{}"},
+		},
+		{
+			name: "invalid JSON",
+			data: map[string]string{defaultSpecSnapshotKey: "{"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+				Data:       tc.data,
+			}
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cm).Build()
+			if _, err := DefaultIngressControllerSpecFromSnapshot(context.Background(), cl, namespace); err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+		})
+	}
+}
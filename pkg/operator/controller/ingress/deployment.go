@@ -2,7 +2,6 @@ package ingress
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"hash"
 	"hash/fnv"
@@ -23,6 +22,7 @@ import (
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/library-go/pkg/crypto"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
@@ -102,6 +102,9 @@ const (
 	RouterServiceHTTPSPort = "ROUTER_SERVICE_HTTPS_PORT"
 	StatsPort              = "STATS_PORT"
 
+	RouterMetricsTLSMinVersion   = "ROUTER_METRICS_TLS_MIN_VERSION"
+	RouterMetricsTLSCipherSuites = "ROUTER_METRICS_TLS_CIPHER_SUITES"
+
 	HTTPPortName  = "http"
 	HTTPSPortName = "https"
 	StatsPortName = "metrics"
@@ -124,6 +127,28 @@ func (r *reconciler) ensureRouterDeployment(ci *operatorv1.IngressController, in
 	if err != nil {
 		return haveDepl, current, fmt.Errorf("failed to build router deployment: %v", err)
 	}
+	if preferPodAntiAffinityOverride(ci) && hasRequiredPodAntiAffinityByHostname(desired) {
+		schedulableNodes, err := r.schedulableNodes(desired)
+		if err != nil {
+			return haveDepl, current, err
+		}
+		if int(deploymentDesiredReplicas(desired)) > schedulableNodes {
+			preferPodAntiAffinity(desired)
+		}
+	}
+	// Apply the surge after the pod anti-affinity check because the
+	// surge never exceeds the untouched nodes, and changing the pod
+	// anti-affinity would roll out the deployment during the drain.
+	if drainSurgeApplies(ci) {
+		surge, err := r.drainSurgeReplicas(desired, current)
+		if err != nil {
+			return haveDepl, current, err
+		}
+		if surge != drainSurgeReplicasOf(current) {
+			log.Info("changing router replica surge for node drain", "namespace", ci.Namespace, "name", ci.Name, "previous", drainSurgeReplicasOf(current), "surge", surge)
+		}
+		applyDrainSurge(desired, surge)
+	}
 
 	switch {
 	case !haveDepl:
@@ -132,7 +157,7 @@ func (r *reconciler) ensureRouterDeployment(ci *operatorv1.IngressController, in
 		}
 		return r.currentRouterDeployment(ci)
 	case haveDepl:
-		if updated, err := r.updateRouterDeployment(current, desired); err != nil {
+		if updated, err := r.updateRouterDeployment(ci, current, desired); err != nil {
 			return true, current, err
 		} else if updated {
 			return r.currentRouterDeployment(ci)
@@ -243,6 +268,24 @@ func headerValues(values []operatorv1.IngressControllerHTTPHeader) string {
 
 // desiredRouterDeployment returns the desired router deployment.
 func desiredRouterDeployment(ci *operatorv1.IngressController, ingressControllerImage string, ingressConfig *configv1.Ingress, infraConfig *configv1.Infrastructure, apiConfig *configv1.APIServer, networkConfig *configv1.Network, proxyNeeded bool, haveClientCAConfigmap bool, clientCAConfigmap *corev1.ConfigMap, clusterProxyConfig *configv1.Proxy, routeExternalCertificateEnabled bool) (*appsv1.Deployment, error) {
+	// Expand the security profile preset into the settings that the
+	// ingresscontroller does not specify explicitly.
+	ci, strictSNI, err := applySecurityProfilePreset(ci)
+	if err != nil {
+		return nil, err
+	}
+	// An explicit strict SNI policy takes precedence over the preset.
+	strictSNI, err = strictSNIIsEnabled(ci, strictSNI)
+	if err != nil {
+		return nil, err
+	}
+	// The ingresscontroller may pin its router to an image other than the
+	// one from the release payload.
+	ingressControllerImage, err = routerImageForIngressController(ci, ingressControllerImage)
+	if err != nil {
+		return nil, err
+	}
+
 	deployment := manifests.RouterDeployment()
 	name := controller.RouterDeploymentName(ci)
 	deployment.Name = name.Name
@@ -257,10 +300,31 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	deployment.Spec.Selector = controller.IngressControllerDeploymentPodSelector(ci)
 	deployment.Spec.Template.Labels = controller.IngressControllerDeploymentPodSelector(ci).MatchLabels
 
+	// Propagate any labels and annotations that the ingresscontroller
+	// specifies to the deployment and its pods.
+	propagated, err := propagatedMetadataForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	applyPropagatedMetadata(&deployment.ObjectMeta, propagated)
+	applyPropagatedMetadata(&deployment.Spec.Template.ObjectMeta, propagated)
+
 	// the router should have a very long grace period by default (1h)
-	gracePeriod := int64(60 * 60)
+	gracePeriod := int64(routerDefaultTerminationGracePeriod / time.Second)
 	deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &gracePeriod
 
+	// If the ingresscontroller specifies a drain period, the router keeps
+	// serving connections for that long after its pod starts terminating.
+	var platformType configv1.PlatformType
+	if infraConfig.Status.PlatformStatus != nil {
+		platformType = infraConfig.Status.PlatformStatus.Type
+	}
+	drainPeriod, err := effectiveDrainPeriod(ci, platformType)
+	if err != nil {
+		return nil, err
+	}
+	applyDrainPeriod(deployment, drainPeriod)
+
 	// Services behind load balancers should roll out new instances only after we are certain
 	// the new instance is part of rotation. This is set based on the highest value across all
 	// platforms, excluding custom load balancers like an F5, but our recommendation for these
@@ -285,13 +349,25 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		// for rolling updates would fail to create new replicas (in the
 		// absence of node auto-scaling).  Thus, when using HostNetwork,
 		// we set max unavailable to 25% and surge to 0.
+		//
+		// A single replica cannot be rolled at all: the new pod cannot
+		// bind the host ports while the old pod is running, so the
+		// rollout would wedge until someone deleted the old pod.  Use
+		// the Recreate strategy in that case, accepting a brief
+		// outage while the pod is replaced.
 		pointerTo := func(ios intstr.IntOrString) *intstr.IntOrString { return &ios }
-		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
-			Type: appsv1.RollingUpdateDeploymentStrategyType,
-			RollingUpdate: &appsv1.RollingUpdateDeployment{
-				MaxUnavailable: pointerTo(intstr.FromString("25%")),
-				MaxSurge:       pointerTo(intstr.FromInt(0)),
-			},
+		if desiredReplicas == 1 {
+			deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			}
+		} else {
+			deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxUnavailable: pointerTo(intstr.FromString("25%")),
+					MaxSurge:       pointerTo(intstr.FromInt(0)),
+				},
+			}
 		}
 
 		// Pod replicas for ingress controllers that use the host
@@ -434,6 +510,20 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		},
 	}}
 
+	// If the ingresscontroller opts in, replace the pod anti-affinity with
+	// topology spread constraints.  Ingress controllers that use the host
+	// network or run in single-replica clusters have no pod anti-affinity
+	// and keep the default zone constraint.
+	if configureAffinity {
+		constraints, err := routerTopologySpreadConstraints(ci)
+		if err != nil {
+			return nil, err
+		}
+		if len(constraints) != 0 {
+			applyTopologySpreadConstraints(deployment, constraints)
+		}
+	}
+
 	statsSecretName := fmt.Sprintf("router-stats-%s", ci.Name)
 	statsVolumeName := "stats-auth"
 	statsVolumeMountPath := "/var/lib/haproxy/conf/metrics-auth"
@@ -512,23 +602,33 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 				Value: "/var/lib/haproxy/conf/error_code_pages/error-page-404.http",
 			})
 		}
+		errorPagesByDomainEnv, err := httpErrorCodePagesByDomainEnv(ci)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, errorPagesByDomainEnv...)
 	}
 
 	env = append(env, corev1.EnvVar{Name: "ROUTER_METRICS_TYPE", Value: "haproxy"})
 	env = append(env, corev1.EnvVar{Name: "ROUTER_METRICS_TLS_CERT_FILE", Value: filepath.Join(certsVolumeMountPath, "tls.crt")})
 	env = append(env, corev1.EnvVar{Name: "ROUTER_METRICS_TLS_KEY_FILE", Value: filepath.Join(certsVolumeMountPath, "tls.key")})
 
-	var unsupportedConfigOverrides struct {
-		LoadBalancingAlgorithm string `json:"loadBalancingAlgorithm"`
-		DynamicConfigManager   string `json:"dynamicConfigManager"`
-		ContStats              string `json:"contStats"`
+	metricsConfig, err := routerMetricsConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
 	}
-	if len(ci.Spec.UnsupportedConfigOverrides.Raw) > 0 {
-		if err := json.Unmarshal(ci.Spec.UnsupportedConfigOverrides.Raw, &unsupportedConfigOverrides); err != nil {
-			return nil, fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides: %w", ci.Name, err)
+	if granularity, selector := routerMetricsEnvValues(metricsConfig); len(granularity) != 0 {
+		env = append(env, corev1.EnvVar{Name: RouterMetricsGranularity, Value: granularity})
+		if len(selector) != 0 {
+			env = append(env, corev1.EnvVar{Name: RouterMetricsRouteLabelSelector, Value: selector})
 		}
 	}
 
+	overrides, err := unsupportedConfigOverridesForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+
 	// For non-TLS, edge-terminated, and reencrypt routes, use the
 	// "random" balancing algorithm by default, but allow an unsupported
 	// config override to override it.  For passthrough routes, use the
@@ -540,17 +640,30 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	// servers lines in "random" backends to weight 1 to avoid incurring extraneous
 	// memory allocations.
 	// Reference: https://issues.redhat.com/browse/NE-709
+	//
+	// The default for non-passthrough routes may also be specified using
+	// the route defaults override, which is in turn overridden by the
+	// loadBalancingAlgorithm override.  The default for passthrough
+	// routes may be specified using the route defaults override's
+	// passthroughBalance key.
 	loadBalancingAlgorithm := "random"
-	switch unsupportedConfigOverrides.LoadBalancingAlgorithm {
+	if v, ok := overrides.RouteDefaults[routeBalanceAnnotation]; ok && validLoadBalancingAlgorithms.Has(v) {
+		loadBalancingAlgorithm = v
+	}
+	switch overrides.LoadBalancingAlgorithm {
 	case "leastconn":
 		loadBalancingAlgorithm = "leastconn"
 	}
+	tcpLoadBalancingAlgorithm := "source"
+	if v, ok := overrides.RouteDefaults[routeDefaultPassthroughBalanceKey]; ok && validPassthroughLoadBalancingAlgorithms.Has(v) {
+		tcpLoadBalancingAlgorithm = v
+	}
 	env = append(env, corev1.EnvVar{
 		Name:  RouterLoadBalancingAlgorithmEnvName,
 		Value: loadBalancingAlgorithm,
 	}, corev1.EnvVar{
 		Name:  RouterTCPLoadBalancingAlgorithmEnvName,
-		Value: "source",
+		Value: tcpLoadBalancingAlgorithm,
 	})
 
 	switch v := ci.Spec.TuningOptions.MaxConnections; {
@@ -566,14 +679,27 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		})
 	}
 
-	dynamicConfigOverride := unsupportedConfigOverrides.DynamicConfigManager
-	if v, err := strconv.ParseBool(dynamicConfigOverride); err == nil && v {
+	dynamicConfigManager, err := dynamicConfigManagerForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if dynamicConfigManager {
 		env = append(env, corev1.EnvVar{
 			Name:  RouterHAProxyConfigManager,
 			Value: "true",
 		})
 	}
-	contStats := unsupportedConfigOverrides.ContStats
+	routeScale, err := routeScaleConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, routeScaleEnv(routeScale)...)
+	maxConnectionsPerFrontend, err := maxConnectionsPerFrontendConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, maxConnectionsPerFrontendEnv(maxConnectionsPerFrontend)...)
+	contStats := overrides.ContStats
 	if v, err := strconv.ParseBool(contStats); err == nil && v {
 		env = append(env, corev1.EnvVar{
 			Name:  RouterHAProxyContstats,
@@ -592,6 +718,26 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	if proxyNeeded {
 		env = append(env, corev1.EnvVar{Name: "ROUTER_USE_PROXY_PROTOCOL", Value: "true"})
 	}
+	proxyProtocol, err := proxyProtocolConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, proxyProtocolEnv(ci, proxyNeeded, proxyProtocol)...)
+	http3, err := http3ConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, http3Env(http3, http3AdvertisedPort(ci))...)
+	zoneAwareRouting, err := zoneAwareRoutingConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if zoneAwareRouting != nil {
+		env = append(env, zoneAwareRoutingEnv(zoneAwareRouting)...)
+		topologyVolume, topologyVolumeMount := routerTopologyVolume()
+		volumes = append(volumes, topologyVolume)
+		routerVolumeMounts = append(routerVolumeMounts, topologyVolumeMount)
+	}
 
 	threads := RouterHAProxyThreadsDefaultValue
 	if ci.Spec.TuningOptions.ThreadCount > 0 {
@@ -631,7 +777,7 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	if ci.Spec.TuningOptions.HealthCheckInterval != nil && ci.Spec.TuningOptions.HealthCheckInterval.Duration >= 1*time.Second {
 		env = append(env, corev1.EnvVar{Name: RouterBackendCheckInterval, Value: durationToHAProxyTimespec(ci.Spec.TuningOptions.HealthCheckInterval.Duration)})
 	}
-	env = append(env, corev1.EnvVar{Name: RouterReloadIntervalEnvName, Value: durationToHAProxyTimespec(capReloadIntervalValue(ci.Spec.TuningOptions.ReloadInterval.Duration))})
+	env = append(env, corev1.EnvVar{Name: RouterReloadIntervalEnvName, Value: durationToHAProxyTimespec(ReloadIntervalForIngressController(ci))})
 
 	nodeSelector := map[string]string{
 		"kubernetes.io/os": "linux",
@@ -907,6 +1053,17 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		if accessLogging.LogEmptyRequests == operatorv1.LoggingPolicyIgnore {
 			env = append(env, corev1.EnvVar{Name: RouterDontLogNull, Value: "true"})
 		}
+
+		// The access log filter applies to whichever destination and
+		// format are configured, so it is ignored if access logging is
+		// not enabled.
+		filter, err := accessLogFilterForIngressController(ci)
+		if err != nil {
+			return nil, err
+		}
+		if val := serializeAccessLogFilter(filter); len(val) != 0 {
+			env = append(env, corev1.EnvVar{Name: RouterAccessLogStatusCodes, Value: val})
+		}
 	}
 
 	tlsProfileSpec := tlsProfileSpecForIngressController(ci, apiConfig)
@@ -945,6 +1102,7 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		minTLSVersion = "TLSv1.2"
 	}
 	env = append(env, corev1.EnvVar{Name: "SSL_MIN_VERSION", Value: minTLSVersion})
+	env = append(env, desiredMetricsTLSEnv(tlsProfileSpec)...)
 
 	usingIPv4 := false
 	usingIPv6 := false
@@ -1009,6 +1167,10 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	}
 	env = append(env, corev1.EnvVar{Name: RouterForwardedHeadersPolicy, Value: routerForwardedHeadersPolicyValue})
 
+	if v := suppressedForwardedHeadersValue(overrides.SuppressForwardedHeaders); len(v) != 0 {
+		env = append(env, corev1.EnvVar{Name: RouterSuppressForwardedHeaders, Value: v})
+	}
+
 	if ci.Spec.HTTPHeaders != nil && len(ci.Spec.HTTPHeaders.UniqueId.Name) > 0 {
 		headerName := ci.Spec.HTTPHeaders.UniqueId.Name
 		headerFormat := ci.Spec.HTTPHeaders.UniqueId.Format
@@ -1021,7 +1183,11 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		)
 	}
 
-	if ci.Spec.HTTPHeaders != nil && len(ci.Spec.HTTPHeaders.HeaderNameCaseAdjustments) > 0 {
+	// With the "Lowercase" header name case policy, omit the case
+	// adjustments so that the router does not adjust the case of any
+	// header names, including for routes that specify the
+	// "haproxy.router.openshift.io/h1-adjust-case" annotation.
+	if ci.Spec.HTTPHeaders != nil && len(ci.Spec.HTTPHeaders.HeaderNameCaseAdjustments) > 0 && overrides.HeaderNameCase != headerNameCaseLowercase {
 		var adjustments []string
 		for _, v := range ci.Spec.HTTPHeaders.HeaderNameCaseAdjustments {
 			adjustments = append(adjustments, string(v))
@@ -1040,15 +1206,45 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		env = append(env, corev1.EnvVar{Name: RouterDisableHTTP2EnvName, Value: "true"})
 	}
 
-	if enabled, value := HardStopAfterIsEnabled(ci, ingressConfig); enabled {
+	if strictSNI {
+		env = append(env, corev1.EnvVar{Name: RouterStrictSNI, Value: "true"})
+	}
+
+	strictHostValidation, err := strictHostValidationIsEnabled(ci)
+	if err != nil {
+		return nil, err
+	}
+	if strictHostValidation {
+		env = append(env, corev1.EnvVar{Name: RouterStrictHostValidation, Value: "true"})
+	}
+
+	// The hard-stop-after tuning option takes precedence over the
+	// annotations on the ingresscontroller and the ingress config.
+	hardStopAfter, err := hardStopAfterForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if d, err := time.ParseDuration(hardStopAfter); err == nil && d > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterHardStopAfterEnvName, Value: durationToHAProxyTimespec(d)})
+	} else if enabled, value := HardStopAfterIsEnabled(ci, ingressConfig); enabled {
 		env = append(env, corev1.EnvVar{Name: RouterHardStopAfterEnvName, Value: value})
 	}
 
+	streamingConfig, err := streamingResponsesConfigForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if streamingConfig != nil && streamingConfig.Policy == streamingResponsePolicyStream {
+		env = append(env, corev1.EnvVar{Name: RouterStreamingResponses, Value: "true"})
+	}
+
 	// Apply HTTP Header Buffer size values to env
-	// when they are specified.
-	if ci.Spec.TuningOptions.HeaderBufferBytes != 0 {
+	// when they are specified.  The response buffer size from the
+	// streaming responses override sets the same parameter, so it is only
+	// used when the header buffer size is not specified.
+	if ci.Spec.TuningOptions.HeaderBufferBytes != 0 || (streamingConfig != nil && streamingConfig.ResponseBufferBytes != 0) {
 		env = append(env, corev1.EnvVar{Name: RouterHeaderBufferSize, Value: strconv.Itoa(
-			int(ci.Spec.TuningOptions.HeaderBufferBytes))})
+			effectiveRouterBufferSize(ci, streamingConfig))})
 	}
 
 	if ci.Spec.TuningOptions.HeaderBufferMaxRewriteBytes != 0 {
@@ -1152,6 +1348,10 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 	// trusted CA bundle that cluster-network-operator generates. The process for adding that is described here:
 	// https://docs.openshift.com/container-platform/4.13/operators/admin/olm-configuring-proxy-support.html#olm-inject-custom-ca_olm-configuring-proxy-support
 
+	// Add the route annotation defaults last so that they do not override
+	// any values that API fields specify.
+	env = append(env, routeDefaultsEnv(overrides.RouteDefaults, env)...)
+
 	// Add the environment variables to the container
 	deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env, env...)
 
@@ -1160,18 +1360,38 @@ func desiredRouterDeployment(ci *operatorv1.IngressController, ingressController
 		deployment.Spec.Template.Spec.Containers[0].Ports,
 		httpPort, httpsPort, statsPort,
 	)
+	if http3 != nil {
+		deployment.Spec.Template.Spec.Containers[0].Ports = append(deployment.Spec.Template.Spec.Containers[0].Ports, http3ContainerPort(httpsPort))
+	}
+
+	// Mark the traffic that the router originates if the ingresscontroller
+	// specifies an egress DSCP value and the router has its own network
+	// namespace.  Otherwise, the EgressDSCPSupported status condition
+	// reports why the value is ignored.
+	egressDSCP, err := egressDSCPForIngressController(ci)
+	if err != nil {
+		return nil, err
+	}
+	if egressDSCP != nil {
+		if *egressDSCP < 0 || *egressDSCP > maxDSCP {
+			return nil, fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.egressDSCP: %d is not between 0 and %d", ci.Name, *egressDSCP, maxDSCP)
+		}
+		if egressDSCPSupported(ci, networkConfig) == nil {
+			deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, desiredEgressDSCPInitContainer(ingressControllerImage, *egressDSCP))
+			if deployment.Spec.Template.Annotations == nil {
+				deployment.Spec.Template.Annotations = map[string]string{}
+			}
+			deployment.Spec.Template.Annotations[requiredSCCAnnotation] = egressDSCPSecurityContextConstraints
+			// Only this service account may use the egress DSCP
+			// security context constraints.
+			deployment.Spec.Template.Spec.ServiceAccountName = manifests.RouterEgressDSCPServiceAccount().Name
+		}
+	}
 
 	// Compute the hash for topology spread constraints and possibly
 	// affinity policy now, after all the other fields have been computed,
 	// and inject it into the appropriate fields.
-	hash := deploymentTemplateHash(deployment)
-	deployment.Spec.Template.Labels[controller.ControllerDeploymentHashLabel] = hash
-	values := []string{hash}
-	deployment.Spec.Template.Spec.TopologySpreadConstraints[0].LabelSelector.MatchExpressions[0].Values = values
-	if configureAffinity {
-		deployment.Spec.Template.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.LabelSelector.MatchExpressions[1].Values = values
-		deployment.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchExpressions[1].Values = values
-	}
+	setDeploymentTemplateHash(deployment)
 
 	return deployment, nil
 }
@@ -1241,6 +1461,41 @@ func getHTTPHeadersListForDelete(deleteHeaders operatorv1.IngressControllerHTTPH
 	return fmt.Sprintf("%s:%s", url.QueryEscape(deleteHeaders.Name), url.QueryEscape(string(deleteHeaders.Action.Type)))
 }
 
+// desiredMetricsTLSEnv returns the environment variables that configure the
+// minimum TLS version and the TLS 1.2 cipher suites of the router's
+// metrics/stats listener to match the given TLS profile.  The metrics listener
+// is served by the router process rather than by HAProxy, so it does not use
+// the SSL_MIN_VERSION and ROUTER_CIPHERS settings; it uses Go's names for TLS
+// versions and IANA names for cipher suites instead.  As with the data plane,
+// TLS 1.0 is not supported and is converted to TLS 1.1.
+//
+// Prometheus scrapes the metrics listener using Go's TLS client, which
+// supports TLS 1.3 as well as the ECDHE cipher suites that every predefined
+// profile specifies, so the servicemonitor remains compatible with every
+// profile, including the "Modern" profile, without any changes.
+func desiredMetricsTLSEnv(tlsProfileSpec *configv1.TLSProfileSpec) []corev1.EnvVar {
+	var minTLSVersion configv1.TLSProtocolVersion
+	switch tlsProfileSpec.MinTLSVersion {
+	case configv1.VersionTLS10, configv1.VersionTLS11:
+		minTLSVersion = configv1.VersionTLS11
+	case configv1.VersionTLS13:
+		minTLSVersion = configv1.VersionTLS13
+	default:
+		minTLSVersion = configv1.VersionTLS12
+	}
+	env := []corev1.EnvVar{{Name: RouterMetricsTLSMinVersion, Value: string(minTLSVersion)}}
+
+	// Go does not allow configuring TLS 1.3 cipher suites, and cipher
+	// suites that Go does not recognize are left out.  If no cipher suites
+	// remain, the router uses Go's defaults.
+	if minTLSVersion != configv1.VersionTLS13 {
+		if cipherSuites := crypto.OpenSSLToIANACipherSuites(tlsProfileSpec.Ciphers); len(cipherSuites) != 0 {
+			env = append(env, corev1.EnvVar{Name: RouterMetricsTLSCipherSuites, Value: strings.Join(cipherSuites, ",")})
+		}
+	}
+	return env
+}
+
 // inferTLSProfileSpecFromDeployment examines the given deployment's pod
 // template spec and reconstructs a TLS profile spec based on that pod spec.
 func inferTLSProfileSpecFromDeployment(deployment *appsv1.Deployment) *configv1.TLSProfileSpec {
@@ -1360,6 +1615,15 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 					return cmpMatchExpressions(exprs[i], exprs[j])
 				})
 			}
+			preferredTerms := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+			for _, term := range preferredTerms {
+				labelSelector := term.PodAffinityTerm.LabelSelector
+				zeroOutDeploymentHash(labelSelector)
+				exprs := labelSelector.MatchExpressions
+				sort.Slice(exprs, func(i, j int) bool {
+					return cmpMatchExpressions(exprs[i], exprs[j])
+				})
+			}
 		}
 		if affinity.NodeAffinity != nil {
 			terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
@@ -1414,14 +1678,32 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 			StartupProbe:    hashableProbe(container.StartupProbe),
 			SecurityContext: container.SecurityContext,
 			Ports:           container.Ports,
+			Lifecycle:       container.Lifecycle,
 		}
 	}
 	sort.Slice(containers, func(i, j int) bool {
 		return containers[i].Name < containers[j].Name
 	})
 	hashableDeployment.Spec.Template.Spec.Containers = containers
+	initContainers := make([]corev1.Container, len(deployment.Spec.Template.Spec.InitContainers))
+	for i, container := range deployment.Spec.Template.Spec.InitContainers {
+		initContainers[i] = corev1.Container{
+			Command:         container.Command,
+			Image:           container.Image,
+			ImagePullPolicy: container.ImagePullPolicy,
+			Name:            container.Name,
+			SecurityContext: container.SecurityContext,
+		}
+	}
+	hashableDeployment.Spec.Template.Spec.InitContainers = initContainers
 	hashableDeployment.Spec.Template.Spec.DNSPolicy = deployment.Spec.Template.Spec.DNSPolicy
+	// Ignore the default termination grace period so that the template
+	// hash of a deployment without a drain period does not change.
+	if v := deployment.Spec.Template.Spec.TerminationGracePeriodSeconds; v != nil && *v != int64(routerDefaultTerminationGracePeriod/time.Second) {
+		hashableDeployment.Spec.Template.Spec.TerminationGracePeriodSeconds = v
+	}
 	hashableDeployment.Spec.Template.Spec.HostNetwork = deployment.Spec.Template.Spec.HostNetwork
+	hashableDeployment.Spec.Template.Spec.ServiceAccountName = deployment.Spec.Template.Spec.ServiceAccountName
 	volumes := make([]corev1.Volume, len(deployment.Spec.Template.Spec.Volumes))
 	for i, vol := range deployment.Spec.Template.Spec.Volumes {
 		volumes[i] = *vol.DeepCopy()
@@ -1439,12 +1721,19 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 	})
 	hashableDeployment.Spec.Template.Spec.Volumes = volumes
 	hashableDeployment.Spec.Template.Annotations = make(map[string]string)
-	annotations := []string{LivenessGracePeriodSecondsAnnotation, WorkloadPartitioningManagement}
+	annotations := []string{LivenessGracePeriodSecondsAnnotation, WorkloadPartitioningManagement, requiredSCCAnnotation}
 	for _, key := range annotations {
 		if val, ok := deployment.Spec.Template.Annotations[key]; ok && len(val) > 0 {
 			hashableDeployment.Spec.Template.Annotations[key] = val
 		}
 	}
+	// Changes to the labels and annotations that are propagated from the
+	// ingresscontroller to the pods must trigger a rolling update.
+	templateLabels, templateAnnotations := propagatedMetadataOf(&deployment.Spec.Template.ObjectMeta)
+	hashableDeployment.Spec.Template.Labels = templateLabels
+	for key, val := range templateAnnotations {
+		hashableDeployment.Spec.Template.Annotations[key] = val
+	}
 
 	if onlyTemplate {
 		return &hashableDeployment
@@ -1453,6 +1742,13 @@ func hashableDeployment(deployment *appsv1.Deployment, onlyTemplate bool) *appsv
 	// Copy metadata and spec fields to which any changes should trigger an
 	// update of the deployment but should not trigger a rolling update.
 	hashableDeployment.Labels = deployment.Labels
+	_, hashableDeployment.Annotations = propagatedMetadataOf(&deployment.ObjectMeta)
+	if surge, ok := deployment.Annotations[drainSurgeReplicasAnnotation]; ok {
+		if hashableDeployment.Annotations == nil {
+			hashableDeployment.Annotations = map[string]string{}
+		}
+		hashableDeployment.Annotations[drainSurgeReplicasAnnotation] = surge
+	}
 	hashableDeployment.Spec.MinReadySeconds = deployment.Spec.MinReadySeconds
 	hashableDeployment.Spec.Strategy = deployment.Spec.Strategy
 	var replicas *int32
@@ -1554,19 +1850,26 @@ func (r *reconciler) createRouterDeployment(deployment *appsv1.Deployment) error
 	return nil
 }
 
-// updateRouterDeployment updates a router deployment.
-func (r *reconciler) updateRouterDeployment(current, desired *appsv1.Deployment) (bool, error) {
+// updateRouterDeployment updates a router deployment.  If the update rolls out
+// new pods, the pod template is annotated with the changes that caused the
+// rollout, and an event that lists them is emitted on the ingresscontroller.
+// Another event is emitted if the update enables or disables the dynamic
+// configuration manager.
+func (r *reconciler) updateRouterDeployment(ci *operatorv1.IngressController, current, desired *appsv1.Deployment) (bool, error) {
 	changed, updated := deploymentConfigChanged(current, desired)
 	if !changed {
 		return false, nil
 	}
+	rolloutChanges := setRouterRolloutChanges(current, updated)
 
 	// Diff before updating because the client may mutate the object.
 	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
 	if err := r.client.Update(context.TODO(), updated); err != nil {
 		return false, fmt.Errorf("failed to update router deployment %s/%s: %v", updated.Namespace, updated.Name, err)
 	}
-	log.Info("updated router deployment", "namespace", updated.Namespace, "name", updated.Name, "diff", diff)
+	log.Info("updated router deployment", "namespace", updated.Namespace, "name", updated.Name, "diff", diff, "rolloutChanges", rolloutChanges)
+	r.recordRouterRollout(ci, updated, rolloutChanges)
+	r.recordDynamicConfigManagerToggle(ci, current, updated)
 	return true, nil
 }
 
@@ -1602,10 +1905,27 @@ func deploymentConfigChanged(current, expected *appsv1.Deployment) (bool, *appsv
 		containers[i+1] = *container.DeepCopy()
 	}
 	updated.Spec.Template.Spec.Containers = containers
+	// Copy any init containers from expected verbatim.
+	var initContainers []corev1.Container
+	for _, container := range expected.Spec.Template.Spec.InitContainers {
+		initContainers = append(initContainers, *container.DeepCopy())
+	}
+	updated.Spec.Template.Spec.InitContainers = initContainers
 	updated.Spec.Template.Spec.DNSPolicy = expected.Spec.Template.Spec.DNSPolicy
+	updated.Spec.Template.Spec.ServiceAccountName = expected.Spec.Template.Spec.ServiceAccountName
+	copyPropagatedMetadata(&updated.ObjectMeta, &current.ObjectMeta, &expected.ObjectMeta)
+	if surge, ok := expected.Annotations[drainSurgeReplicasAnnotation]; ok {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[drainSurgeReplicasAnnotation] = surge
+	} else {
+		delete(updated.Annotations, drainSurgeReplicasAnnotation)
+	}
+	copyPropagatedMetadata(&updated.Spec.Template.ObjectMeta, &current.Spec.Template.ObjectMeta, &expected.Spec.Template.ObjectMeta)
 	updated.Spec.Template.Labels = expected.Spec.Template.Labels
 
-	annotations := []string{LivenessGracePeriodSecondsAnnotation, WorkloadPartitioningManagement}
+	annotations := []string{LivenessGracePeriodSecondsAnnotation, WorkloadPartitioningManagement, requiredSCCAnnotation}
 	for _, key := range annotations {
 		currentVal, have := current.Spec.Template.Annotations[key]
 		expectedVal, want := expected.Spec.Template.Annotations[key]
@@ -1634,6 +1954,8 @@ func deploymentConfigChanged(current, expected *appsv1.Deployment) (bool, *appsv
 	copyProbe(expected.Spec.Template.Spec.Containers[0].StartupProbe, updated.Spec.Template.Spec.Containers[0].StartupProbe, true)
 	updated.Spec.Template.Spec.Containers[0].VolumeMounts = expected.Spec.Template.Spec.Containers[0].VolumeMounts
 	updated.Spec.Template.Spec.Containers[0].Ports = expected.Spec.Template.Spec.Containers[0].Ports
+	updated.Spec.Template.Spec.Containers[0].Lifecycle = expected.Spec.Template.Spec.Containers[0].Lifecycle
+	updated.Spec.Template.Spec.TerminationGracePeriodSeconds = expected.Spec.Template.Spec.TerminationGracePeriodSeconds
 	updated.Spec.Template.Spec.Tolerations = expected.Spec.Template.Spec.Tolerations
 	updated.Spec.Template.Spec.TopologySpreadConstraints = expected.Spec.Template.Spec.TopologySpreadConstraints
 	updated.Spec.Template.Spec.Affinity = expected.Spec.Template.Spec.Affinity
@@ -1793,19 +2115,13 @@ func GetMIMETypes(mimeTypes []operatorv1.CompressionMIMEType) []string {
 // caps the value of ReloadInterval between the bounds of 1s and 120s
 // returns the default of 5s if the user gives a 0 value
 func capReloadIntervalValue(interval time.Duration) time.Duration {
-	const (
-		maxInterval     = 120 * time.Second
-		minInterval     = 1 * time.Second
-		defaultInterval = 5 * time.Second
-	)
-
 	switch {
 	case interval == 0:
-		return defaultInterval
-	case interval > maxInterval:
-		return maxInterval
-	case interval < minInterval:
-		return minInterval
+		return defaultReloadInterval
+	case interval > maxReloadInterval:
+		return maxReloadInterval
+	case interval < minReloadInterval:
+		return minReloadInterval
 	default:
 		return interval
 	}
@@ -15,6 +15,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/library-go/pkg/crypto"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -198,6 +199,557 @@ func TestTuningOptions(t *testing.T) {
 	checkDeploymentHasEnvSorted(t, deployment)
 }
 
+// TestRouteDefaults verifies that desiredRouterDeployment sets the router
+// environment variables for the route annotation defaults that
+// spec.unsupportedConfigOverrides.routeDefaults specifies, and that tuning
+// options take precedence over them.
+func TestRouteDefaults(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"routeDefaults":{"haproxy.router.openshift.io/balance":"roundrobin","haproxy.router.openshift.io/timeout":"5s","haproxy.router.openshift.io/timeout-tunnel":"2h","router.openshift.io/haproxy.health.check.interval":"10s","haproxy.router.openshift.io/hsts_header":"max-age=31536000"}}`),
+	}
+	ic.Spec.TuningOptions.TunnelTimeout = &metav1.Duration{Duration: 30 * time.Minute}
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+
+	tests := []envData{
+		{RouterLoadBalancingAlgorithmEnvName, true, "roundrobin"},
+		{"ROUTER_DEFAULT_SERVER_TIMEOUT", true, "5s"},
+		{"ROUTER_DEFAULT_TUNNEL_TIMEOUT", true, "30m"},
+		{RouterBackendCheckInterval, true, "10s"},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+		t.Error(err)
+	}
+	checkDeploymentHasEnvSorted(t, deployment)
+
+	// The loadBalancingAlgorithm override takes precedence over the
+	// route defaults.
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"loadBalancingAlgorithm":"leastconn","routeDefaults":{"haproxy.router.openshift.io/balance":"roundrobin"}}`),
+	}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	tests = []envData{
+		{RouterLoadBalancingAlgorithmEnvName, true, "leastconn"},
+		{"ROUTER_DEFAULT_SERVER_TIMEOUT", false, ""},
+		{RouterDefaultInsecureEdgeTerminationPolicy, false, ""},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+		t.Error(err)
+	}
+
+	// The passthrough load-balancing algorithm is "source" unless the
+	// route defaults specify another one.
+	for overrides, expected := range map[string]string{
+		`{"routeDefaults":{"haproxy.router.openshift.io/balance":"roundrobin"}}`: "source",
+		`{"routeDefaults":{"passthroughBalance":"source"}}`:                      "source",
+		`{"routeDefaults":{"passthroughBalance":"roundrobin"}}`:                  "roundrobin",
+		`{"routeDefaults":{"passthroughBalance":"leastconn"}}`:                   "leastconn",
+		`{"routeDefaults":{"passthroughBalance":"random"}}`:                      "source",
+	} {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(overrides)}
+		deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		tests = []envData{
+			{RouterTCPLoadBalancingAlgorithmEnvName, true, expected},
+		}
+		if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+			t.Errorf("overrides %s: %v", overrides, err)
+		}
+		checkDeploymentHasEnvSorted(t, deployment)
+	}
+
+	// The default insecure policy uses the route API's name for each
+	// policy.
+	for policy, expected := range map[string]string{"Allow": "Allow", "Redirect": "Redirect", "Disable": "None"} {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+			Raw: []byte(fmt.Sprintf(`{"routeDefaults":{"insecurePolicy":%q}}`, policy)),
+		}
+		deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		tests = []envData{
+			{RouterDefaultInsecureEdgeTerminationPolicy, true, expected},
+		}
+		if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+			t.Errorf("insecure policy %s: %v", policy, err)
+		}
+	}
+}
+
+// TestHTTPHeaderOverrides verifies that desiredRouterDeployment renders the
+// suppressed forwarded headers and the header name case policy that are
+// specified using unsupported config overrides.
+func TestHTTPHeaderOverrides(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Spec.HTTPHeaders.HeaderNameCaseAdjustments = []operatorv1.IngressControllerHTTPHeaderNameCaseAdjustment{"Host"}
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	tests := []envData{
+		{RouterSuppressForwardedHeaders, false, ""},
+		{RouterHTTPHeaderNameCaseAdjustments, true, "Host"},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+		t.Error(err)
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"suppressForwardedHeaders":["x-forwarded-proto-version","X-Forwarded-Port","X-Forwarded-For"],"headerNameCase":"Lowercase"}`),
+	}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	tests = []envData{
+		{RouterSuppressForwardedHeaders, true, "X-Forwarded-Port,X-Forwarded-Proto-Version"},
+		{RouterHTTPHeaderNameCaseAdjustments, false, ""},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
+		t.Error(err)
+	}
+	checkDeploymentHasEnvSorted(t, deployment)
+}
+
+// TestRouterMetricsGranularity verifies that desiredRouterDeployment sets the
+// metrics granularity and route label selector environment variables as
+// specified by spec.unsupportedConfigOverrides.routerMetrics.
+func TestRouterMetricsGranularity(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectEnv   []envData
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectEnv: []envData{
+				{RouterMetricsGranularity, false, ""},
+				{RouterMetricsRouteLabelSelector, false, ""},
+			},
+		},
+		{
+			description: "per-backend aggregated",
+			overrides:   `{"routerMetrics":{"granularity":"PerBackendAggregated"}}`,
+			expectEnv: []envData{
+				{RouterMetricsGranularity, true, "PerBackendAggregated"},
+				{RouterMetricsRouteLabelSelector, false, ""},
+			},
+		},
+		{
+			description: "per-route with an allow-list",
+			overrides:   `{"routerMetrics":{"granularity":"PerRoute","routeSelector":{"matchLabels":{"metrics":"true"}}}}`,
+			expectEnv: []envData{
+				{RouterMetricsGranularity, true, "PerRoute"},
+				{RouterMetricsRouteLabelSelector, true, "metrics=true"},
+			},
+		},
+		{
+			description: "invalid granularity",
+			overrides:   `{"routerMetrics":{"granularity":"PerServer"}}`,
+			expectEnv: []envData{
+				{RouterMetricsGranularity, false, ""},
+				{RouterMetricsRouteLabelSelector, false, ""},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			checkDeploymentHasEnvSorted(t, deployment)
+		})
+	}
+}
+
+// TestStreamingResponses verifies that desiredRouterDeployment enables
+// response streaming and sets the buffer size as specified by
+// spec.unsupportedConfigOverrides.streamingResponses.
+func TestStreamingResponses(t *testing.T) {
+	testCases := []struct {
+		description  string
+		overrides    string
+		headerBuffer int32
+		expectEnv    []envData
+	}{
+		{
+			description: "no overrides",
+			overrides:   "",
+			expectEnv: []envData{
+				{RouterStreamingResponses, false, ""},
+				{RouterHeaderBufferSize, false, ""},
+			},
+		},
+		{
+			description: "buffer",
+			overrides:   `{"streamingResponses":{"policy":"Buffer"}}`,
+			expectEnv: []envData{
+				{RouterStreamingResponses, false, ""},
+				{RouterHeaderBufferSize, false, ""},
+			},
+		},
+		{
+			description: "stream",
+			overrides:   `{"streamingResponses":{"policy":"Stream"}}`,
+			expectEnv: []envData{
+				{RouterStreamingResponses, true, "true"},
+				{RouterHeaderBufferSize, false, ""},
+			},
+		},
+		{
+			description: "stream with a response buffer size",
+			overrides:   `{"streamingResponses":{"policy":"Stream","responseBufferBytes":16384}}`,
+			expectEnv: []envData{
+				{RouterStreamingResponses, true, "true"},
+				{RouterHeaderBufferSize, true, "16384"},
+			},
+		},
+		{
+			description:  "header buffer size takes precedence",
+			overrides:    `{"streamingResponses":{"policy":"Stream","responseBufferBytes":16384}}`,
+			headerBuffer: 65536,
+			expectEnv: []envData{
+				{RouterStreamingResponses, true, "true"},
+				{RouterHeaderBufferSize, true, "65536"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			ic.Spec.TuningOptions.HeaderBufferBytes = tc.headerBuffer
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			checkDeploymentHasEnvSorted(t, deployment)
+		})
+	}
+}
+
+// TestSecurityProfilePreset verifies that desiredRouterDeployment expands the
+// security profile preset into the router's settings, that settings that the
+// ingresscontroller specifies explicitly take precedence over the preset, and
+// that the "Default" preset produces the same deployment as specifying no
+// preset.
+func TestSecurityProfilePreset(t *testing.T) {
+	hardenedEnv := []envData{
+		{"SSL_MIN_VERSION", true, "TLSv1.3"},
+		{RouterDisableHTTP2EnvName, true, "false"},
+		{RouterStrictSNI, true, "true"},
+		{RouterForwardedHeadersPolicy, true, "replace"},
+		{"ROUTER_DEFAULT_CLIENT_TIMEOUT", true, "30s"},
+		{"ROUTER_CLIENT_FIN_TIMEOUT", true, "1s"},
+		{"ROUTER_DEFAULT_SERVER_TIMEOUT", true, "30s"},
+		{"ROUTER_DEFAULT_SERVER_FIN_TIMEOUT", true, "1s"},
+		{"ROUTER_DEFAULT_TUNNEL_TIMEOUT", true, "15m"},
+		{"ROUTER_DEFAULT_CONNECT_TIMEOUT", true, "5s"},
+		{"ROUTER_INSPECT_DELAY", true, "5s"},
+	}
+	testCases := []struct {
+		description string
+		overrides   string
+		mutate      func(*operatorv1.IngressController)
+		expectEnv   []envData
+	}{
+		{
+			description: "no preset",
+			overrides:   "",
+			expectEnv: []envData{
+				{"SSL_MIN_VERSION", true, "TLSv1.1"},
+				{RouterDisableHTTP2EnvName, true, "true"},
+				{RouterStrictSNI, false, ""},
+				{RouterForwardedHeadersPolicy, true, "append"},
+				{"ROUTER_DEFAULT_CLIENT_TIMEOUT", false, ""},
+				{"ROUTER_DEFAULT_TUNNEL_TIMEOUT", false, ""},
+			},
+		},
+		{
+			description: "hardened preset",
+			overrides:   `{"securityProfilePreset":"Hardened"}`,
+			expectEnv:   hardenedEnv,
+		},
+		{
+			description: "hardened preset with explicit settings",
+			overrides:   `{"securityProfilePreset":"Hardened"}`,
+			mutate: func(ic *operatorv1.IngressController) {
+				ic.Annotations = map[string]string{RouterDefaultEnableHTTP2Annotation: "false"}
+				ic.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{
+					Type:         configv1.TLSProfileIntermediateType,
+					Intermediate: &configv1.IntermediateTLSProfile{},
+				}
+				ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{
+					ForwardedHeaderPolicy: operatorv1.IfNoneHTTPHeaderPolicy,
+				}
+				ic.Spec.TuningOptions.TunnelTimeout = &metav1.Duration{Duration: 2 * time.Hour}
+			},
+			expectEnv: []envData{
+				{"SSL_MIN_VERSION", true, "TLSv1.2"},
+				{RouterDisableHTTP2EnvName, true, "true"},
+				{RouterStrictSNI, true, "true"},
+				{RouterForwardedHeadersPolicy, true, "if-none"},
+				{"ROUTER_DEFAULT_CLIENT_TIMEOUT", true, "30s"},
+				{"ROUTER_DEFAULT_TUNNEL_TIMEOUT", true, "2h"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			if tc.mutate != nil {
+				tc.mutate(ic)
+			}
+			original := ic.DeepCopy()
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if !reflect.DeepEqual(ic, original) {
+				t.Error("expected desiredRouterDeployment not to mutate the ingresscontroller")
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			checkDeploymentHasEnvSorted(t, deployment)
+		})
+	}
+
+	t.Run("default preset reproduces no preset", func(t *testing.T) {
+		ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+		expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"securityProfilePreset":"Default"}`)}
+		actual, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected the Default preset to produce the same deployment as no preset")
+		}
+	})
+}
+
+// TestStrictSNI verifies that desiredRouterDeployment sets ROUTER_STRICT_SNI
+// according to the ingresscontroller's strict SNI policy and that an explicit
+// policy takes precedence over the security profile preset.
+func TestStrictSNI(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectEnv   []envData
+	}{
+		{
+			description: "no policy",
+			overrides:   "",
+			expectEnv:   []envData{{RouterStrictSNI, false, ""}},
+		},
+		{
+			description: "enabled",
+			overrides:   `{"strictSNI":"Enabled"}`,
+			expectEnv:   []envData{{RouterStrictSNI, true, "true"}},
+		},
+		{
+			description: "disabled",
+			overrides:   `{"strictSNI":"Disabled"}`,
+			expectEnv:   []envData{{RouterStrictSNI, false, ""}},
+		},
+		{
+			description: "hardened preset",
+			overrides:   `{"securityProfilePreset":"Hardened"}`,
+			expectEnv:   []envData{{RouterStrictSNI, true, "true"}},
+		},
+		{
+			description: "hardened preset with strict SNI disabled",
+			overrides:   `{"securityProfilePreset":"Hardened","strictSNI":"Disabled"}`,
+			expectEnv: []envData{
+				{RouterStrictSNI, false, ""},
+				{RouterForwardedHeadersPolicy, true, "replace"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestAccessLogFilter verifies that desiredRouterDeployment configures the
+// router to log only the responses that
+// spec.unsupportedConfigOverrides.accessLogFilter selects, and only if access
+// logging is enabled.
+func TestAccessLogFilter(t *testing.T) {
+	testCases := []struct {
+		description   string
+		overrides     string
+		accessLogging bool
+		expectEnv     []envData
+	}{
+		{
+			description:   "no overrides",
+			overrides:     "",
+			accessLogging: true,
+			expectEnv:     []envData{{RouterAccessLogStatusCodes, false, ""}},
+		},
+		{
+			description:   "errors only",
+			overrides:     `{"accessLogFilter":{"errorsOnly":true}}`,
+			accessLogging: true,
+			expectEnv:     []envData{{RouterAccessLogStatusCodes, true, "400-599"}},
+		},
+		{
+			description:   "status code ranges are sorted",
+			overrides:     `{"accessLogFilter":{"statusCodeRanges":[{"from":500,"to":599},{"from":404,"to":404}]}}`,
+			accessLogging: true,
+			expectEnv:     []envData{{RouterAccessLogStatusCodes, true, "404-404,500-599"}},
+		},
+		{
+			description:   "errors only without access logging",
+			overrides:     `{"accessLogFilter":{"errorsOnly":true}}`,
+			accessLogging: false,
+			expectEnv:     []envData{{RouterAccessLogStatusCodes, false, ""}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			ic.Spec.Logging = nil
+			if tc.accessLogging {
+				ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+					Access: &operatorv1.AccessLogging{
+						Destination: operatorv1.LoggingDestination{
+							Type:      operatorv1.ContainerLoggingDestinationType,
+							Container: &operatorv1.ContainerLoggingDestinationParameters{},
+						},
+					},
+				}
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			checkDeploymentHasEnvSorted(t, deployment)
+		})
+	}
+}
+
+// TestSyslogLogging verifies that desiredRouterDeployment sets the syslog
+// facility and maximum message length environment variables for an
+// ingresscontroller that logs to a syslog endpoint only if the ingresscontroller
+// specifies them.
+func TestSyslogLogging(t *testing.T) {
+	testCases := []struct {
+		description string
+		facility    string
+		maxLength   uint32
+		expectEnv   []envData
+	}{
+		{
+			description: "defaults",
+			expectEnv: []envData{
+				{RouterSyslogFacilityEnvName, false, ""},
+				{RouterLogMaxLengthEnvName, false, ""},
+				{RouterSyslogAddressEnvName, true, "1.2.3.4:12345"},
+			},
+		},
+		{
+			description: "facility",
+			facility:    "local5",
+			expectEnv: []envData{
+				{RouterSyslogFacilityEnvName, true, "local5"},
+				{RouterLogMaxLengthEnvName, false, ""},
+			},
+		},
+		{
+			description: "minimum length",
+			maxLength:   480,
+			expectEnv: []envData{
+				{RouterSyslogFacilityEnvName, false, ""},
+				{RouterLogMaxLengthEnvName, true, "480"},
+			},
+		},
+		{
+			description: "facility and maximum length",
+			facility:    "auth",
+			maxLength:   4096,
+			expectEnv: []envData{
+				{RouterSyslogFacilityEnvName, true, "auth"},
+				{RouterLogMaxLengthEnvName, true, "4096"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+				Access: &operatorv1.AccessLogging{
+					Destination: operatorv1.LoggingDestination{
+						Type: operatorv1.SyslogLoggingDestinationType,
+						Syslog: &operatorv1.SyslogLoggingDestinationParameters{
+							Address:   "1.2.3.4",
+							Port:      uint32(12345),
+							Facility:  tc.facility,
+							MaxLength: tc.maxLength,
+						},
+					},
+				},
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			checkDeploymentHasEnvSorted(t, deployment)
+		})
+	}
+}
+
 // TestClusterProxy tests that the cluster-wide proxy settings from proxies.config.openshift.io/cluster are included in the desired router deployment.
 func TestClusterProxy(t *testing.T) {
 	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
@@ -464,16 +1016,114 @@ func Test_desiredRouterDeployment(t *testing.T) {
 		{"STATS_USERNAME_FILE", true, "/var/lib/haproxy/conf/metrics-auth/statsUsername"},
 		{"STATS_PASSWORD_FILE", true, "/var/lib/haproxy/conf/metrics-auth/statsPassword"},
 		{"SSL_MIN_VERSION", true, "TLSv1.1"},
+		{RouterMetricsTLSMinVersion, true, "VersionTLS11"},
+		{RouterMetricsTLSCipherSuites, false, ""},
 		{WildcardRouteAdmissionPolicy, true, "false"},
 		{"ROUTER_DOMAIN", false, ""},
 		{"ROUTER_HTTP_RESPONSE_HEADERS", true, "X-Frame-Options:DENY:Set,X-XSS-Protection:1%3Bmode%3Dblock:Set,x-forwarded-client-cert:%25%7B%2BQ%7D%5Bssl_c_der%2Cbase64%5D:Set,X-Frame-Options:Delete,X-XSS-Protection:Delete"},
 		{"ROUTER_HTTP_REQUEST_HEADERS", true, "Accept:text%2Fplain%2C+text%2Fhtml:Set,Accept-Encoding:Delete"},
+		{RouterHardStopAfterEnvName, false, ""},
+		{RouterStrictHostValidation, false, ""},
 	}
 	if err := checkDeploymentEnvironment(t, deployment, tests); err != nil {
 		t.Error(err)
 	}
 
 	checkDeploymentHasEnvSorted(t, deployment)
+
+	// Verify that the hard-stop-after tuning option sets the environment
+	// variable, takes precedence over the annotation, and that clearing it
+	// removes the variable from the deployment.
+	t.Run("hardStopAfter", func(t *testing.T) {
+		withHardStopAfter := ic.DeepCopy()
+		withHardStopAfter.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"tuningOptions":{"hardStopAfter":"30m"}}`)}
+		withHardStopAfter.Annotations = map[string]string{RouterHardStopAfterAnnotation: "1h"}
+		set, err := desiredRouterDeployment(withHardStopAfter, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		if err := checkDeploymentEnvironment(t, set, []envData{{RouterHardStopAfterEnvName, true, "30m"}}); err != nil {
+			t.Error(err)
+		}
+		checkDeploymentHasEnvSorted(t, set)
+
+		cleared, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		changed, updated := deploymentConfigChanged(set, cleared)
+		if !changed {
+			t.Fatal("expected clearing hardStopAfter to change the deployment")
+		}
+		if err := checkDeploymentEnvironment(t, updated, []envData{{RouterHardStopAfterEnvName, false, ""}}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("strictHostValidation", func(t *testing.T) {
+		for _, tc := range []struct {
+			policy    string
+			expectEnv envData
+		}{
+			{"Enabled", envData{RouterStrictHostValidation, true, "true"}},
+			{"Disabled", envData{RouterStrictHostValidation, false, ""}},
+		} {
+			withPolicy := ic.DeepCopy()
+			withPolicy.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"tuningOptions":{"strictHostValidation":%q}}`, tc.policy))}
+			deployment, err := desiredRouterDeployment(withPolicy, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("%s: invalid router Deployment: %v", tc.policy, err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, []envData{tc.expectEnv}); err != nil {
+				t.Errorf("%s: %v", tc.policy, err)
+			}
+		}
+	})
+
+	t.Run("drainPeriod", func(t *testing.T) {
+		withoutDrain, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		if lifecycle := withoutDrain.Spec.Template.Spec.Containers[0].Lifecycle; lifecycle != nil {
+			t.Errorf("expected no lifecycle without a drain period, got %+v", lifecycle)
+		}
+		if gracePeriod := withoutDrain.Spec.Template.Spec.TerminationGracePeriodSeconds; gracePeriod == nil || *gracePeriod != 3600 {
+			t.Errorf("expected termination grace period 3600, got %v", gracePeriod)
+		}
+
+		// A drain period that is shorter than the time that the AWS
+		// classic load balancer needs to take a router pod out of
+		// rotation is raised to that time, so it yields the same pod
+		// template as that time.
+		lb := ic.DeepCopy()
+		lb.Status.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType}
+		withDrain := func(drainPeriod string) *appsv1.Deployment {
+			t.Helper()
+			withDrain := lb.DeepCopy()
+			withDrain.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"tuningOptions":{"drainPeriod":%q}}`, drainPeriod))}
+			deployment, err := desiredRouterDeployment(withDrain, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("%s: invalid router Deployment: %v", drainPeriod, err)
+			}
+			return deployment
+		}
+		short, long := withDrain("5s"), withDrain("10s")
+		lifecycle := short.Spec.Template.Spec.Containers[0].Lifecycle
+		if lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil || !reflect.DeepEqual(lifecycle.PreStop.Exec.Command, []string{"sleep", "10"}) {
+			t.Errorf("expected a preStop hook that sleeps for 10s, got %+v", lifecycle)
+		}
+		if deploymentTemplateHash(short) != deploymentTemplateHash(long) {
+			t.Error("expected a 5s drain period to be raised to the load balancer's 10s")
+		}
+
+		// Removing the drain period removes the preStop hook.
+		if changed, updated := deploymentConfigChanged(short, withoutDrain); !changed {
+			t.Error("expected removing the drain period to change the deployment")
+		} else if lifecycle := updated.Spec.Template.Spec.Containers[0].Lifecycle; lifecycle != nil {
+			t.Errorf("expected no lifecycle after removing the drain period, got %+v", lifecycle)
+		}
+	})
 }
 
 // assertHasVolumes asserts that the given slice of volumes has all of the
@@ -958,6 +1608,8 @@ func TestDesiredRouterDeploymentVariety(t *testing.T) {
 		{"ROUTER_CIPHERSUITES", true, "TLS_AES_256_GCM_SHA384:TLS_CHACHA20_POLY1305_SHA256"},
 
 		{"SSL_MIN_VERSION", true, "TLSv1.3"},
+		{RouterMetricsTLSMinVersion, true, "VersionTLS13"},
+		{RouterMetricsTLSCipherSuites, false, ""},
 
 		{"ROUTER_IP_V4_V6_MODE", true, "v6"},
 		{RouterDisableHTTP2EnvName, true, "true"},
@@ -1050,6 +1702,33 @@ func TestDesiredRouterDeploymentSingleReplica(t *testing.T) {
 	}
 }
 
+// TestDesiredRouterDeploymentHostNetworkSingleReplica verifies that
+// desiredRouterDeployment uses the Recreate strategy for an ingresscontroller
+// with a single replica that uses the "HostNetwork" endpoint publishing
+// strategy, whose replica cannot run alongside its replacement, and a rolling
+// update without surge otherwise.
+func TestDesiredRouterDeploymentHostNetworkSingleReplica(t *testing.T) {
+	for _, replicas := range []int32{1, 2} {
+		t.Run(fmt.Sprintf("%d replicas", replicas), func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.Replicas = &replicas
+			ic.Status.EndpointPublishingStrategy.Type = operatorv1.HostNetworkStrategyType
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			checkDeploymentHash(t, deployment)
+			if replicas == 1 {
+				if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType || deployment.Spec.Strategy.RollingUpdate != nil {
+					t.Errorf("expected Recreate deployment strategy, got %+v", deployment.Spec.Strategy)
+				}
+				return
+			}
+			checkRollingUpdateParams(t, deployment, intstr.FromString("25%"), intstr.FromInt(0))
+		})
+	}
+}
+
 // TestDesiredRouterDeploymentClientTLS verifies that desiredRouterDeployment
 // returns the expected deployment when client TLS is enabled.
 func TestDesiredRouterDeploymentClientTLS(t *testing.T) {
@@ -1111,6 +1790,59 @@ func checkContainerPort(t *testing.T, d *appsv1.Deployment, portName string, por
 	t.Errorf("deployment %s container does not have port with name %s and number %d", d.Name, portName, port)
 }
 
+// Test_desiredMetricsTLSEnv verifies that desiredMetricsTLSEnv renders the
+// minimum TLS version and cipher suites of each predefined TLS profile for the
+// router's metrics listener.
+func Test_desiredMetricsTLSEnv(t *testing.T) {
+	testCases := []struct {
+		name   string
+		spec   *configv1.TLSProfileSpec
+		expect []corev1.EnvVar
+	}{
+		{
+			name: "old",
+			spec: configv1.TLSProfiles[configv1.TLSProfileOldType],
+			expect: []corev1.EnvVar{
+				{Name: RouterMetricsTLSMinVersion, Value: "VersionTLS11"},
+				{Name: RouterMetricsTLSCipherSuites, Value: strings.Join(crypto.OpenSSLToIANACipherSuites(configv1.TLSProfiles[configv1.TLSProfileOldType].Ciphers), ",")},
+			},
+		},
+		{
+			name: "intermediate",
+			spec: configv1.TLSProfiles[configv1.TLSProfileIntermediateType],
+			expect: []corev1.EnvVar{
+				{Name: RouterMetricsTLSMinVersion, Value: "VersionTLS12"},
+				{Name: RouterMetricsTLSCipherSuites, Value: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+			},
+		},
+		{
+			name: "modern",
+			spec: configv1.TLSProfiles[configv1.TLSProfileModernType],
+			expect: []corev1.EnvVar{
+				{Name: RouterMetricsTLSMinVersion, Value: "VersionTLS13"},
+			},
+		},
+		{
+			name: "custom with unrecognized ciphers",
+			spec: &configv1.TLSProfileSpec{
+				Ciphers:       []string{"DHE-RSA-AES256-GCM-SHA384"},
+				MinTLSVersion: configv1.VersionTLS12,
+			},
+			expect: []corev1.EnvVar{
+				{Name: RouterMetricsTLSMinVersion, Value: "VersionTLS12"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := desiredMetricsTLSEnv(tc.spec)
+			if !reflect.DeepEqual(actual, tc.expect) {
+				t.Errorf("expected %v, got %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
 func Test_inferTLSProfileSpecFromDeployment(t *testing.T) {
 	testCases := []struct {
 		description string
@@ -1261,6 +1993,13 @@ func TestDeploymentHash(t *testing.T) {
 			},
 			expectDeploymentHashChanged: true,
 		},
+		{
+			description: "if the drain surge annotation is added",
+			mutate: func(deployment *appsv1.Deployment) {
+				deployment.Annotations = map[string]string{drainSurgeReplicasAnnotation: "1"}
+			},
+			expectDeploymentHashChanged: true,
+		},
 		{
 			description: "if .spec.template.spec.tolerations change",
 			mutate: func(deployment *appsv1.Deployment) {
@@ -1279,6 +2018,34 @@ func TestDeploymentHash(t *testing.T) {
 			expectDeploymentHashChanged: true,
 			expectTemplateHashChanged:   true,
 		},
+		{
+			description: "if a preStop hook is added",
+			mutate: func(deployment *appsv1.Deployment) {
+				deployment.Spec.Template.Spec.Containers[0].Lifecycle = &corev1.Lifecycle{
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"sleep", "30"}},
+					},
+				}
+			},
+			expectDeploymentHashChanged: true,
+			expectTemplateHashChanged:   true,
+		},
+		{
+			description: "if the default termination grace period is set",
+			mutate: func(deployment *appsv1.Deployment) {
+				gracePeriod := int64(3600)
+				deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+			},
+		},
+		{
+			description: "if the termination grace period is extended",
+			mutate: func(deployment *appsv1.Deployment) {
+				gracePeriod := int64(3660)
+				deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+			},
+			expectDeploymentHashChanged: true,
+			expectTemplateHashChanged:   true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1769,6 +2536,15 @@ func Test_deploymentConfigChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if replicas are surged for a node drain",
+			mutate: func(deployment *appsv1.Deployment) {
+				replicas := int32(2)
+				deployment.Spec.Replicas = &replicas
+				deployment.Annotations = map[string]string{drainSurgeReplicasAnnotation: "1"}
+			},
+			expect: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2076,6 +2852,7 @@ func Test_capReloadIntervalValue(t *testing.T) {
 		{365 * time.Second, 120 * time.Second},
 
 		// Values in the allowed range returns itself (i.e. between 1s and 120s).
+		{120 * time.Second, 120 * time.Second},
 		{1 * time.Minute, 1 * time.Minute},
 		{2 * time.Minute, 2 * time.Minute},
 		{1 * time.Second, 1 * time.Second},
@@ -2092,6 +2869,36 @@ func Test_capReloadIntervalValue(t *testing.T) {
 	}
 }
 
+// Test_desiredRouterDeploymentReloadInterval verifies that
+// desiredRouterDeployment renders spec.tuningOptions.reloadInterval, capped to
+// the allowed bounds, in the RELOAD_INTERVAL environment variable.
+func Test_desiredRouterDeploymentReloadInterval(t *testing.T) {
+	testCases := []struct {
+		reloadInterval time.Duration
+		expectedEnv    string
+	}{
+		{0, "5s"},
+		{500 * time.Millisecond, "1s"},
+		{1 * time.Second, "1s"},
+		{90 * time.Second, "90s"},
+		{120 * time.Second, "2m"},
+		{10 * time.Minute, "2m"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.reloadInterval.String(), func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.TuningOptions.ReloadInterval = metav1.Duration{Duration: tc.reloadInterval}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, []envData{{RouterReloadIntervalEnvName, true, tc.expectedEnv}}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
 func Test_GetMIMETypes(t *testing.T) {
 	testCases := []struct {
 		mimeArrayInput []operatorv1.CompressionMIMEType
@@ -0,0 +1,91 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// dnsRecordTargetForIngressController returns the hostname that the given
+// ingresscontroller specifies in spec.unsupportedConfigOverrides.dnsRecordTarget
+// as the target of its wildcard DNS record in place of the load balancer's
+// hostname, or the empty string if it specifies none.  This allows an
+// administrator to front the load balancer with an externally managed
+// hostname, such as a vanity CNAME record for the load balancer, so that
+// replacing the load balancer only requires updating that one record.  An
+// error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func dnsRecordTargetForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.DNSRecordTarget, nil
+}
+
+// validateDNSRecordTargetHostname returns an error if the given DNS record
+// target is not a fully qualified domain name, that is, a valid hostname with
+// at least two labels, which may have a trailing dot.  An IP address is not a
+// valid target because the record that targets the hostname is a CNAME record.
+func validateDNSRecordTargetHostname(target string) error {
+	if net.ParseIP(target) != nil {
+		return fmt.Errorf("invalid spec.unsupportedConfigOverrides.dnsRecordTarget: %q is an IP address, not a hostname", target)
+	}
+	hostname := strings.TrimSuffix(target, ".")
+	if errs := validation.IsDNS1123Subdomain(hostname); len(errs) != 0 {
+		return fmt.Errorf("invalid spec.unsupportedConfigOverrides.dnsRecordTarget: %q is not a valid hostname: %s", target, strings.Join(errs, "; "))
+	}
+	if !strings.Contains(hostname, ".") {
+		return fmt.Errorf("invalid spec.unsupportedConfigOverrides.dnsRecordTarget: %q is not a fully qualified domain name", target)
+	}
+	return nil
+}
+
+// validateDNSRecordTarget validates the given ingresscontroller's DNS record
+// target, if it specifies one.  The target can only be specified if the
+// ingresscontroller uses the "LoadBalancerService" endpoint publishing
+// strategy, as DNS is only managed for load balancers.
+func validateDNSRecordTarget(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	target := overrides.DNSRecordTarget
+	if len(target) == 0 {
+		return nil
+	}
+	if err := validateDNSRecordTargetHostname(target); err != nil {
+		return err
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.dnsRecordTarget can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	return nil
+}
+
+// withDNSRecordTarget returns the given per-zone DNS targets with the given DNS
+// record target as the target for each zone for which the per-zone targets
+// specify no targets.  Per-zone targets thus take precedence over the DNS
+// record target, which in turn takes precedence over the load balancer's
+// targets.  The DNS controller publishes the DNS record target as a CNAME
+// record while the DNSRecord's spec keeps tracking the load balancer.  If the
+// DNS record target is empty, the per-zone targets are returned as is.
+func withDNSRecordTarget(zoneTargets *dnsrecord.ZoneTargets, target string) *dnsrecord.ZoneTargets {
+	if len(target) == 0 {
+		return zoneTargets
+	}
+	var updated dnsrecord.ZoneTargets
+	if zoneTargets != nil {
+		updated = *zoneTargets
+	}
+	recordTarget := &dnsrecord.ZoneTarget{RecordType: iov1.CNAMERecordType, Targets: []string{target}}
+	if updated.Public == nil {
+		updated.Public = recordTarget
+	}
+	if updated.Private == nil {
+		updated.Private = recordTarget
+	}
+	return &updated
+}
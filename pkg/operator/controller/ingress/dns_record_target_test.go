@@ -0,0 +1,118 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateDNSRecordTarget verifies that validateDNSRecordTarget only
+// allows a fully qualified hostname as the DNS record target and only with the
+// LoadBalancerService endpoint publishing strategy.
+func Test_validateDNSRecordTarget(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		eps         operatorv1.EndpointPublishingStrategyType
+		expectError bool
+	}{
+		{name: "no overrides"},
+		{name: "empty target", overrides: `{"dnsRecordTarget":""}`, eps: operatorv1.HostNetworkStrategyType},
+		{name: "vanity hostname", overrides: `{"dnsRecordTarget":"ingress.example.net"}`, eps: operatorv1.LoadBalancerServiceStrategyType},
+		{name: "trailing dot", overrides: `{"dnsRecordTarget":"ingress.example.net."}`, eps: operatorv1.LoadBalancerServiceStrategyType},
+		{name: "default strategy", overrides: `{"dnsRecordTarget":"ingress.example.net"}`},
+		{name: "single label", overrides: `{"dnsRecordTarget":"ingress"}`, eps: operatorv1.LoadBalancerServiceStrategyType, expectError: true},
+		{name: "IP address", overrides: `{"dnsRecordTarget":"10.0.0.5"}`, eps: operatorv1.LoadBalancerServiceStrategyType, expectError: true},
+		{name: "invalid hostname", overrides: `{"dnsRecordTarget":"ingress_1.example.net"}`, eps: operatorv1.LoadBalancerServiceStrategyType, expectError: true},
+		{name: "HostNetwork", overrides: `{"dnsRecordTarget":"ingress.example.net"}`, eps: operatorv1.HostNetworkStrategyType, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			if len(tc.eps) != 0 {
+				ic.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: tc.eps}
+			}
+			switch err := validateDNSRecordTarget(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_dnsZoneTargetsForIngressController_dnsRecordTarget verifies the
+// precedence of the targets for the wildcard DNS record: per-zone targets take
+// precedence over the DNS record target, which takes precedence over the load
+// balancer's targets, for which dnsZoneTargetsForIngressController returns no
+// zone target.
+func Test_dnsZoneTargetsForIngressController_dnsRecordTarget(t *testing.T) {
+	vanity := &dnsrecord.ZoneTarget{RecordType: iov1.CNAMERecordType, Targets: []string{"ingress.example.net"}}
+	testCases := []struct {
+		name        string
+		overrides   string
+		expect      *dnsrecord.ZoneTargets
+		expectError bool
+	}{
+		{
+			name:      "load balancer",
+			overrides: `{"dnsRecordTarget":""}`,
+			expect:    nil,
+		},
+		{
+			name:      "DNS record target",
+			overrides: `{"dnsRecordTarget":"ingress.example.net"}`,
+			expect:    &dnsrecord.ZoneTargets{Public: vanity, Private: vanity},
+		},
+		{
+			name:      "DNS record target and private zone targets",
+			overrides: `{"dnsRecordTarget":"ingress.example.net","dnsZoneTargets":{"private":["10.0.0.5"]}}`,
+			expect: &dnsrecord.ZoneTargets{
+				Public:  vanity,
+				Private: &dnsrecord.ZoneTarget{RecordType: iov1.ARecordType, Targets: []string{"10.0.0.5"}},
+			},
+		},
+		{
+			name:      "DNS record target and zone targets for both zones",
+			overrides: `{"dnsRecordTarget":"ingress.example.net","dnsZoneTargets":{"public":["public-lb.example.com"],"private":["10.0.0.5"]}}`,
+			expect: &dnsrecord.ZoneTargets{
+				Public:  &dnsrecord.ZoneTarget{RecordType: iov1.CNAMERecordType, Targets: []string{"public-lb.example.com"}},
+				Private: &dnsrecord.ZoneTarget{RecordType: iov1.ARecordType, Targets: []string{"10.0.0.5"}},
+			},
+		},
+		{
+			name:        "invalid DNS record target",
+			overrides:   `{"dnsRecordTarget":"10.0.0.5"}`,
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			zoneTargets, err := dnsZoneTargetsForIngressController(ic)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			if diff := cmp.Diff(tc.expect, zoneTargets); len(diff) != 0 {
+				t.Errorf("unexpected zone targets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+)
+
+// dnsZoneTargetsConfig describes the per-zone DNS targets that an
+// ingresscontroller specifies using spec.unsupportedConfigOverrides.dnsZoneTargets.
+type dnsZoneTargetsConfig struct {
+	// Public is the list of targets for the wildcard DNS record in the
+	// cluster's public zone.  Empty means the load balancer's targets.
+	Public []string `json:"public,omitempty"`
+	// Private is the list of targets for the wildcard DNS record in the
+	// cluster's private zone.  Empty means the load balancer's targets.
+	Private []string `json:"private,omitempty"`
+}
+
+// dnsZoneTargetsConfigForIngressController returns the per-zone DNS targets
+// configuration that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func dnsZoneTargetsConfigForIngressController(ic *operatorv1.IngressController) (*dnsZoneTargetsConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.dnsZoneTargets(), nil
+}
+
+// dnsZoneTargets returns the per-zone DNS targets configuration that the
+// overrides specify, or nil if they specify none.
+func (o *unsupportedConfigOverrides) dnsZoneTargets() *dnsZoneTargetsConfig {
+	config := o.DNSZoneTargets
+	if config == nil || (len(config.Public) == 0 && len(config.Private) == 0) {
+		return nil
+	}
+	return config
+}
+
+// zoneTargets returns the DNS record types and targets for the configured
+// per-zone targets.  An error is returned if the targets for a zone are
+// neither IPv4 addresses nor a single hostname.
+func (config *dnsZoneTargetsConfig) zoneTargets() (*dnsrecord.ZoneTargets, error) {
+	var zoneTargets dnsrecord.ZoneTargets
+	if len(config.Public) != 0 {
+		zoneTarget, err := dnsrecord.NewZoneTarget(config.Public)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.unsupportedConfigOverrides.dnsZoneTargets.public: %w", err)
+		}
+		zoneTargets.Public = zoneTarget
+	}
+	if len(config.Private) != 0 {
+		zoneTarget, err := dnsrecord.NewZoneTarget(config.Private)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.unsupportedConfigOverrides.dnsZoneTargets.private: %w", err)
+		}
+		zoneTargets.Private = zoneTarget
+	}
+	return &zoneTargets, nil
+}
+
+// dnsZoneTargetsForIngressController returns the per-zone DNS targets that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, taking
+// into account its DNS record target (see withDNSRecordTarget), or nil if it
+// specifies none.  An error is returned if spec.unsupportedConfigOverrides
+// cannot be decoded or if the targets are invalid.
+func dnsZoneTargetsForIngressController(ic *operatorv1.IngressController) (*dnsrecord.ZoneTargets, error) {
+	config, err := dnsZoneTargetsConfigForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	var zoneTargets *dnsrecord.ZoneTargets
+	if config != nil {
+		if zoneTargets, err = config.zoneTargets(); err != nil {
+			return nil, err
+		}
+	}
+	target, err := dnsRecordTargetForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	if len(target) != 0 {
+		if err := validateDNSRecordTargetHostname(target); err != nil {
+			return nil, err
+		}
+	}
+	return withDNSRecordTarget(zoneTargets, target), nil
+}
+
+// validateDNSZoneTargets validates the given ingresscontroller's per-zone DNS
+// targets, if it specifies any.  Per-zone targets can only be specified if the
+// ingresscontroller uses the "LoadBalancerService" endpoint publishing
+// strategy, as DNS is only managed for load balancers.
+func validateDNSZoneTargets(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.dnsZoneTargets()
+	if config == nil {
+		return nil
+	}
+	if _, err := config.zoneTargets(); err != nil {
+		return err
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.dnsZoneTargets can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	return nil
+}
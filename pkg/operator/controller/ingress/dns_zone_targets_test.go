@@ -0,0 +1,142 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_dnsZoneTargetsForIngressController verifies that
+// dnsZoneTargetsForIngressController derives the record type for each zone's
+// targets and rejects invalid targets.
+func Test_dnsZoneTargetsForIngressController(t *testing.T) {
+	testCases := []struct {
+		name          string
+		overrides     string
+		expectPublic  iov1.DNSRecordType
+		expectPrivate iov1.DNSRecordType
+		expectError   bool
+	}{
+		{
+			name: "no overrides",
+		},
+		{
+			name:      "no zone targets",
+			overrides: `{"dnsZoneTargets":{}}`,
+		},
+		{
+			name:          "private VIP",
+			overrides:     `{"dnsZoneTargets":{"private":["10.0.0.5"]}}`,
+			expectPrivate: iov1.ARecordType,
+		},
+		{
+			name:          "public and private load balancers",
+			overrides:     `{"dnsZoneTargets":{"public":["public-lb.example.com"],"private":["private-lb.example.com"]}}`,
+			expectPublic:  iov1.CNAMERecordType,
+			expectPrivate: iov1.CNAMERecordType,
+		},
+		{
+			name:        "multiple hostnames",
+			overrides:   `{"dnsZoneTargets":{"public":["lb1.example.com","lb2.example.com"]}}`,
+			expectError: true,
+		},
+		{
+			name:        "IPv6 address",
+			overrides:   `{"dnsZoneTargets":{"private":["fd00::5"]}}`,
+			expectError: true,
+		},
+		{
+			name:        "malformed overrides",
+			overrides:   `{"dnsZoneTargets":`,
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			zoneTargets, err := dnsZoneTargetsForIngressController(ic)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			var public, private iov1.DNSRecordType
+			if zoneTargets != nil && zoneTargets.Public != nil {
+				public = zoneTargets.Public.RecordType
+			}
+			if zoneTargets != nil && zoneTargets.Private != nil {
+				private = zoneTargets.Private.RecordType
+			}
+			if public != tc.expectPublic || private != tc.expectPrivate {
+				t.Errorf("expected public %q and private %q record types, got %q and %q", tc.expectPublic, tc.expectPrivate, public, private)
+			}
+		})
+	}
+}
+
+// Test_validateDNSZoneTargets verifies that validateDNSZoneTargets rejects
+// invalid targets and endpoint publishing strategies other than
+// LoadBalancerService.
+func Test_validateDNSZoneTargets(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			expectError: false,
+		},
+		{
+			description: "private VIP with default strategy",
+			overrides:   `{"dnsZoneTargets":{"private":["10.0.0.5"]}}`,
+			expectError: false,
+		},
+		{
+			description: "private VIP with load balancer",
+			overrides:   `{"dnsZoneTargets":{"private":["10.0.0.5"]}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType},
+			expectError: false,
+		},
+		{
+			description: "private VIP with host network",
+			overrides:   `{"dnsZoneTargets":{"private":["10.0.0.5"]}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType},
+			expectError: true,
+		},
+		{
+			description: "mixed IP address and hostname",
+			overrides:   `{"dnsZoneTargets":{"public":["10.0.0.5","lb.example.com"]}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+				},
+			}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			switch err := validateDNSZoneTargets(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// IngressControllerDomainMigrationProgressingConditionType is the type
+	// of the ingresscontroller status condition that reports the progress
+	// of a domain migration.
+	IngressControllerDomainMigrationProgressingConditionType = "DomainMigrationProgressing"
+
+	// DomainMigrationDefaultOverlapPeriod is how long an ingresscontroller
+	// continues to serve its previous domain after it migrates to a new
+	// domain if the migration does not specify an overlap period.
+	DomainMigrationDefaultOverlapPeriod = 24 * time.Hour
+)
+
+// DomainMigration describes a migration of an ingresscontroller to a new
+// domain.  An ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.domainMigration.
+//
+// When the migration starts, the operator records the ingresscontroller's
+// current domain as its previous domain and sets status.domain to the new
+// domain, so that the operator's own resources, such as the wildcard DNS
+// record, the operator-generated default certificate, and the canary route,
+// follow the new domain.  For the duration of the overlap period, the operator
+// also publishes a wildcard DNS record for the previous domain and includes the
+// previous domain in the operator-generated default certificate so that routes
+// with hosts in the previous domain continue to work.  When the overlap period
+// ends, the operator retires the previous domain.  Migrating routes' hosts to
+// the new domain is up to their owners.
+type DomainMigration struct {
+	// Domain is the new domain.
+	Domain string `json:"domain"`
+	// OverlapPeriod is how long the ingresscontroller continues to serve
+	// the previous domain, in the format of time.ParseDuration.  The
+	// default is 24h.  Removing the migration or specifying a shorter
+	// period ends the overlap early.
+	OverlapPeriod string `json:"overlapPeriod"`
+}
+
+// DomainMigrationForIngressController returns the domain migration that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, with
+// defaults applied, or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func DomainMigrationForIngressController(ic *operatorv1.IngressController) (*DomainMigration, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.domainMigration(), nil
+}
+
+// domainMigration returns the domain migration that the overrides specify,
+// with defaults applied, or nil if they specify none.
+func (o *unsupportedConfigOverrides) domainMigration() *DomainMigration {
+	if o.DomainMigration == nil {
+		return nil
+	}
+	migration := *o.DomainMigration
+	migration.Domain = strings.TrimSuffix(migration.Domain, ".")
+	if len(migration.OverlapPeriod) == 0 {
+		migration.OverlapPeriod = DomainMigrationDefaultOverlapPeriod.String()
+	}
+	return &migration
+}
+
+// OverlapPeriodDuration returns the migration's overlap period.  The migration
+// must be valid.
+func (m *DomainMigration) OverlapPeriodDuration() time.Duration {
+	d, _ := time.ParseDuration(m.OverlapPeriod)
+	return d
+}
+
+// ValidateDomainMigration validates the given domain migration.  The domain
+// must be a valid DNS subdomain, and the overlap period must not be negative.
+func ValidateDomainMigration(migration *DomainMigration) error {
+	if len(migration.Domain) == 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.domainMigration.domain is required")
+	}
+	if msgs := validation.IsDNS1123Subdomain(migration.Domain); len(msgs) != 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.domainMigration.domain %q is invalid: %s", migration.Domain, strings.Join(msgs, ", "))
+	}
+	overlap, err := time.ParseDuration(migration.OverlapPeriod)
+	if err != nil {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.domainMigration.overlapPeriod is invalid: %w", err)
+	}
+	if overlap < 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.domainMigration.overlapPeriod must not be negative: %q", migration.OverlapPeriod)
+	}
+	return nil
+}
+
+// validateDomainMigration validates the domain migration that the given
+// ingresscontroller specifies, if any.
+func validateDomainMigration(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	migration := overrides.domainMigration()
+	if migration == nil {
+		return nil
+	}
+	return ValidateDomainMigration(migration)
+}
+
+// domainMigrationOverlapEnd returns the time at which the given
+// ingresscontroller stops serving its previous domain and a Boolean value
+// indicating whether the ingresscontroller is migrating to a new domain.  If
+// the ingresscontroller no longer specifies a valid migration or the recorded
+// start time is invalid, the overlap period has already ended.
+func domainMigrationOverlapEnd(ic *operatorv1.IngressController) (time.Time, bool) {
+	if len(ingresscontroller.PreviousDomain(ic)) == 0 {
+		return time.Time{}, false
+	}
+	started, err := time.Parse(time.RFC3339, ic.Annotations[ingresscontroller.DomainMigrationStartedAnnotation])
+	if err != nil {
+		return time.Time{}, true
+	}
+	migration, err := DomainMigrationForIngressController(ic)
+	if err != nil || migration == nil || ValidateDomainMigration(migration) != nil {
+		return time.Time{}, true
+	}
+	return started.Add(migration.OverlapPeriodDuration()), true
+}
+
+// syncDomainMigration starts or finishes the domain migration that the given
+// ingresscontroller specifies, if any, and returns a Boolean value indicating
+// whether it updated the ingresscontroller.
+//
+// To start a migration, the ingresscontroller's current domain and the current
+// time are recorded in annotations, and then status.domain is set to the new
+// domain.  To finish a migration, the annotations are removed, and
+// ensureIngressController then retires the previous domain's resources.  If
+// the migration's domain is changed to the previous domain before the overlap
+// period ends, the migration is finished, and a new migration back to the
+// previous domain is started.  If the migration's domain is changed to another
+// domain, the ingresscontroller migrates to that domain immediately and stops
+// serving the domain that it was migrating to, but it continues to serve the
+// previous domain until the overlap period ends.
+func (r *reconciler) syncDomainMigration(ic *operatorv1.IngressController, now time.Time) (bool, error) {
+	migration, err := DomainMigrationForIngressController(ic)
+	if err != nil {
+		return false, nil
+	}
+	previousDomain := ingresscontroller.PreviousDomain(ic)
+	if end, migrating := domainMigrationOverlapEnd(ic); migrating {
+		if migration == nil || !now.Before(end) || migration.Domain == previousDomain {
+			updated := ic.DeepCopy()
+			delete(updated.Annotations, ingresscontroller.PreviousDomainAnnotation)
+			delete(updated.Annotations, ingresscontroller.DomainMigrationStartedAnnotation)
+			if err := r.client.Update(context.TODO(), updated); err != nil {
+				return false, fmt.Errorf("failed to update ingresscontroller %s/%s: %w", ic.Namespace, ic.Name, err)
+			}
+			r.recorder.Eventf(ic, "Normal", "DomainMigrationCompleted", "Stopped serving previous domain %q", previousDomain)
+			return true, nil
+		}
+	}
+	if migration == nil || ValidateDomainMigration(migration) != nil || migration.Domain == ic.Status.Domain {
+		return false, nil
+	}
+
+	ingresses := &operatorv1.IngressControllerList{}
+	if err := r.cache.List(context.TODO(), ingresses, client.InNamespace(r.config.Namespace)); err != nil {
+		return false, fmt.Errorf("failed to list ingresscontrollers: %w", err)
+	}
+	candidate := ic.DeepCopy()
+	candidate.Status.Domain = migration.Domain
+	if err := validateDomainUniqueness(candidate, ingresses.Items); err != nil {
+		r.recorder.Eventf(ic, "Warning", "DomainMigrationRejected", "Cannot migrate to domain %q: %v", migration.Domain, err)
+		return false, nil
+	}
+
+	updated := ic.DeepCopy()
+	if len(previousDomain) == 0 {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[ingresscontroller.PreviousDomainAnnotation] = ic.Status.Domain
+		updated.Annotations[ingresscontroller.DomainMigrationStartedAnnotation] = now.UTC().Format(time.RFC3339)
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return false, fmt.Errorf("failed to update ingresscontroller %s/%s: %w", ic.Namespace, ic.Name, err)
+		}
+		previousDomain = ic.Status.Domain
+	}
+	updated.Status.Domain = migration.Domain
+	if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+		return true, fmt.Errorf("failed to update ingresscontroller %s/%s status: %w", ic.Namespace, ic.Name, err)
+	}
+	end, _ := domainMigrationOverlapEnd(updated)
+	r.recorder.Eventf(ic, "Normal", "DomainMigrationStarted", "Migrated from domain %q to %q; serving both until %s", previousDomain, migration.Domain, end.UTC().Format(time.RFC3339))
+	return true, nil
+}
+
+// computeDomainMigrationProgressingCondition computes the ingresscontroller's
+// "DomainMigrationProgressing" status condition and returns a Boolean value
+// indicating whether the ingresscontroller should have the condition, which it
+// should if it specifies a domain migration.
+func computeDomainMigrationProgressingCondition(ic *operatorv1.IngressController) (operatorv1.OperatorCondition, bool) {
+	migration, err := DomainMigrationForIngressController(ic)
+	if err != nil || migration == nil {
+		return operatorv1.OperatorCondition{}, false
+	}
+	condition := operatorv1.OperatorCondition{
+		Type: IngressControllerDomainMigrationProgressingConditionType,
+	}
+	previousDomain := ingresscontroller.PreviousDomain(ic)
+	switch end, migrating := domainMigrationOverlapEnd(ic); {
+	case migrating:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "Overlapping"
+		condition.Message = fmt.Sprintf("The ingresscontroller serves domain %q and previous domain %q until %s.", ic.Status.Domain, previousDomain, end.UTC().Format(time.RFC3339))
+	case migration.Domain != ic.Status.Domain:
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "Pending"
+		condition.Message = fmt.Sprintf("The ingresscontroller has not migrated from domain %q to %q.  Check the ingresscontroller's events for details.", ic.Status.Domain, migration.Domain)
+	default:
+		condition.Status = operatorv1.ConditionFalse
+		condition.Reason = "Completed"
+		condition.Message = fmt.Sprintf("The ingresscontroller has migrated to domain %q.", ic.Status.Domain)
+	}
+	return condition, true
+}
+
+// ensurePreviousDomainWildcardDNSRecord ensures that the wildcard dnsrecord for
+// the domain that the given ingresscontroller continues to serve while it
+// migrates to a new domain exists if the ingresscontroller is migrating and is
+// deleted otherwise.  The record has the same targets as the wildcard
+// dnsrecord for the ingresscontroller's current domain.
+func (r *reconciler) ensurePreviousDomainWildcardDNSRecord(ic *operatorv1.IngressController, dnsRecordLabels map[string]string, icRef metav1.OwnerReference, lbService *corev1.Service, haveLB bool) error {
+	name := operatorcontroller.PreviousDomainWildcardDNSRecordName(ic)
+	previousDomain := ingresscontroller.PreviousDomain(ic)
+	if len(previousDomain) == 0 {
+		if haveRecord, _, err := dnsrecord.CurrentDNSRecord(r.client, name); err != nil {
+			return fmt.Errorf("failed to get previous domain wildcard dnsrecord for %s: %w", ic.Name, err)
+		} else if !haveRecord {
+			return nil
+		}
+		if err := dnsrecord.DeleteDNSRecord(r.client, name); err != nil {
+			return fmt.Errorf("failed to delete previous domain wildcard dnsrecord for %s: %w", ic.Name, err)
+		}
+		log.Info("deleted previous domain wildcard dnsrecord", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	zoneTargets, err := dnsZoneTargetsForIngressController(ic)
+	if err != nil {
+		return fmt.Errorf("failed to ensure previous domain wildcard dnsrecord for %s: %w", ic.Name, err)
+	}
+	if _, _, err := dnsrecord.EnsureWildcardDNSRecord(r.client, name, dnsRecordLabels, icRef, previousDomain, wildcardRecordPublishingStrategy(ic), lbService, haveLB, dnsrecord.TargetPreferenceForAnnotations(ic.Annotations), zoneTargets); err != nil {
+		return fmt.Errorf("failed to ensure previous domain wildcard dnsrecord for %s: %w", ic.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
+
+// Test_validateDomainMigration verifies that validateDomainMigration accepts a
+// valid migration and rejects invalid domains and overlap periods.
+func Test_validateDomainMigration(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "default overlap period",
+			overrides:   `{"domainMigration":{"domain":"apps.new.example.com"}}`,
+		},
+		{
+			description: "custom overlap period and absolute domain",
+			overrides:   `{"domainMigration":{"domain":"apps.new.example.com.","overlapPeriod":"72h"}}`,
+		},
+		{
+			description: "zero overlap period",
+			overrides:   `{"domainMigration":{"domain":"apps.new.example.com","overlapPeriod":"0s"}}`,
+		},
+		{
+			description: "missing domain",
+			overrides:   `{"domainMigration":{"overlapPeriod":"1h"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid domain",
+			overrides:   `{"domainMigration":{"domain":"Apps_New.example.com"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid overlap period",
+			overrides:   `{"domainMigration":{"domain":"apps.new.example.com","overlapPeriod":"a day"}}`,
+			expectError: true,
+		},
+		{
+			description: "negative overlap period",
+			overrides:   `{"domainMigration":{"domain":"apps.new.example.com","overlapPeriod":"-1h"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateDomainMigration(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_syncDomainMigration verifies that syncDomainMigration starts a domain
+// migration, keeps the previous domain for the overlap period, finishes the
+// migration when the overlap period ends, and refuses to migrate to a domain
+// that another ingresscontroller uses.  It also verifies the
+// "DomainMigrationProgressing" status condition at each step.
+func Test_syncDomainMigration(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	admitted := []operatorv1.OperatorCondition{{Type: "Admitted", Status: operatorv1.ConditionTrue}}
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "default", UID: "1"},
+		Spec: operatorv1.IngressControllerSpec{
+			UnsupportedConfigOverrides: runtime.RawExtension{
+				Raw: []byte(`{"domainMigration":{"domain":"apps.new.example.com","overlapPeriod":"1h"}}`),
+			},
+		},
+		Status: operatorv1.IngressControllerStatus{Domain: "apps.old.example.com", Conditions: admitted},
+	}
+	other := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "other", UID: "2"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.other.example.com", Conditions: admitted},
+	}
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(ic).WithObjects(ic, other).Build()
+	r := &reconciler{
+		config:   Config{Namespace: namespace},
+		client:   cl,
+		cache:    fakeCache{Reader: cl},
+		recorder: record.NewFakeRecorder(10),
+	}
+	start := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	sync := func(description string, now time.Time, expectUpdate bool) *operatorv1.IngressController {
+		t.Helper()
+		current := &operatorv1.IngressController{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "default"}, current); err != nil {
+			t.Fatalf("%s: failed to get ingresscontroller: %v", description, err)
+		}
+		updated, err := r.syncDomainMigration(current, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if updated != expectUpdate {
+			t.Fatalf("%s: expected update to be %t, got %t", description, expectUpdate, updated)
+		}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "default"}, current); err != nil {
+			t.Fatalf("%s: failed to get ingresscontroller: %v", description, err)
+		}
+		return current
+	}
+	expect := func(description string, current *operatorv1.IngressController, domain, previousDomain, reason string) {
+		t.Helper()
+		if current.Status.Domain != domain {
+			t.Errorf("%s: expected status.domain %q, got %q", description, domain, current.Status.Domain)
+		}
+		if actual := ingresscontroller.PreviousDomain(current); actual != previousDomain {
+			t.Errorf("%s: expected previous domain %q, got %q", description, previousDomain, actual)
+		}
+		condition, ok := computeDomainMigrationProgressingCondition(current)
+		if !ok || condition.Reason != reason {
+			t.Errorf("%s: expected condition with reason %q, got %+v (ok: %t)", description, reason, condition, ok)
+		}
+	}
+
+	current := sync("start", start, true)
+	expect("start", current, "apps.new.example.com", "apps.old.example.com", "Overlapping")
+	if end, migrating := domainMigrationOverlapEnd(current); !migrating || !end.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected overlap to end at %v, got %v (migrating: %t)", start.Add(time.Hour), end, migrating)
+	}
+
+	current = sync("during overlap", start.Add(30*time.Minute), false)
+	expect("during overlap", current, "apps.new.example.com", "apps.old.example.com", "Overlapping")
+
+	current = sync("end of overlap", start.Add(time.Hour), true)
+	expect("end of overlap", current, "apps.new.example.com", "", "Completed")
+
+	current = sync("after migration", start.Add(2*time.Hour), false)
+	expect("after migration", current, "apps.new.example.com", "", "Completed")
+
+	current.Spec.UnsupportedConfigOverrides.Raw = []byte(`{"domainMigration":{"domain":"apps.other.example.com"}}`)
+	if err := cl.Update(context.Background(), current); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	current = sync("conflicting domain", start.Add(3*time.Hour), false)
+	expect("conflicting domain", current, "apps.new.example.com", "", "Pending")
+
+	current.Spec.UnsupportedConfigOverrides.Raw = nil
+	if err := cl.Update(context.Background(), current); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	if _, ok := computeDomainMigrationProgressingCondition(current); ok {
+		t.Error("expected no condition without a domain migration")
+	}
+}
@@ -0,0 +1,200 @@
+package ingress
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// routerDefaultTerminationGracePeriod is the router pods' termination
+	// grace period unless the drain period requires a longer one.  This is
+	// the grace period that node drains, and therefore cluster upgrades,
+	// allow for router pods.
+	routerDefaultTerminationGracePeriod = 1 * time.Hour
+	// routerMinShutdownPeriod is the minimum time that the router has to
+	// shut down gracefully after the drain period ends and before the
+	// kubelet kills it.
+	routerMinShutdownPeriod = 1 * time.Minute
+
+	// minDrainPeriod and maxDrainPeriod are the bounds of the
+	// "tuningOptions.drainPeriod" unsupported config override.
+	minDrainPeriod = 1 * time.Second
+	maxDrainPeriod = 1 * time.Hour
+
+	// Azure load balancer health checks are not customizable and are set
+	// to 2 failures at a 5s interval.
+	azureLBHealthCheckInterval           = 5 * time.Second
+	azureLBHealthCheckUnhealthyThreshold = 2
+	// GCP load balancer health checks are not customizable and are set to
+	// 3 failures at an 8s interval.
+	gcpLBHealthCheckInterval           = 8 * time.Second
+	gcpLBHealthCheckUnhealthyThreshold = 3
+)
+
+// drainPeriodForIngressController returns how long a terminating router pod for
+// the given ingresscontroller continues to serve traffic before the router
+// begins to shut down, in the format of time.ParseDuration, as specified using
+// the "tuningOptions.drainPeriod" unsupported config override, or the empty
+// string if the ingresscontroller does not specify it.  An error is returned
+// if spec.unsupportedConfigOverrides cannot be decoded.
+func drainPeriodForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.TuningOptions.DrainPeriod, nil
+}
+
+// validateDrainPeriod validates the drain period that the given
+// ingresscontroller specifies, if it specifies one.  The duration must be
+// between 1s and 1h.
+func validateDrainPeriod(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	value := overrides.TuningOptions.DrainPeriod
+	if len(value) == 0 {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.tuningOptions.drainPeriod is invalid: %w", err)
+	}
+	if d < minDrainPeriod || d > maxDrainPeriod {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.tuningOptions.drainPeriod must be between %v and %v: %q", minDrainPeriod, maxDrainPeriod, value)
+	}
+	return nil
+}
+
+// loadBalancerHealthCheckDrainPeriod returns how long the cloud load balancer
+// for the given ingresscontroller may continue to send new connections to a
+// router pod after the pod starts terminating, which is the load balancer's
+// health check interval multiplied by the number of failed health checks after
+// which the load balancer takes a backend out of rotation.  These are the
+// values of the health check annotations that the operator sets on the load
+// balancer service for the given platform.  Zero is returned if the
+// ingresscontroller does not use a load balancer service or the operator does
+// not configure the health checks of the platform's load balancers.
+func loadBalancerHealthCheckDrainPeriod(ic *operatorv1.IngressController, platformType configv1.PlatformType) time.Duration {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return 0
+	}
+	switch platformType {
+	case configv1.AWSPlatformType:
+		interval := awsLBHealthCheckIntervalDefault
+		if lb := eps.LoadBalancer; lb != nil && lb.ProviderParameters != nil && lb.ProviderParameters.AWS != nil && lb.ProviderParameters.AWS.Type == operatorv1.AWSNetworkLoadBalancer {
+			interval = awsLBHealthCheckIntervalNLB
+		}
+		seconds, _ := strconv.Atoi(interval)
+		threshold, _ := strconv.Atoi(awsLBHealthCheckUnhealthyThresholdDefault)
+		return time.Duration(seconds*threshold) * time.Second
+	case configv1.AzurePlatformType:
+		return azureLBHealthCheckInterval * azureLBHealthCheckUnhealthyThreshold
+	case configv1.GCPPlatformType:
+		return gcpLBHealthCheckInterval * gcpLBHealthCheckUnhealthyThreshold
+	}
+	return 0
+}
+
+// effectiveDrainPeriod returns the drain period for the given ingresscontroller
+// on the given platform, or zero if the ingresscontroller does not specify
+// one.  If the specified drain period is shorter than the time that the load
+// balancer needs to take a terminating router pod out of rotation, the load
+// balancer's time is used instead.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func effectiveDrainPeriod(ic *operatorv1.IngressController, platformType configv1.PlatformType) (time.Duration, error) {
+	value, err := drainPeriodForIngressController(ic)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		// The ingress controller reports the invalid value.
+		return 0, nil
+	}
+	if lb := loadBalancerHealthCheckDrainPeriod(ic, platformType); d < lb {
+		d = lb
+	}
+	return d, nil
+}
+
+// terminationGracePeriodForDrainPeriod returns the router pods' termination
+// grace period for the given drain period.  The grace period includes the
+// drain period, so the grace period is extended beyond the default if the
+// router would otherwise have less than routerMinShutdownPeriod to shut down.
+func terminationGracePeriodForDrainPeriod(drainPeriod time.Duration) time.Duration {
+	if gracePeriod := drainPeriod + routerMinShutdownPeriod; gracePeriod > routerDefaultTerminationGracePeriod {
+		return gracePeriod
+	}
+	return routerDefaultTerminationGracePeriod
+}
+
+// applyDrainPeriod configures the given router deployment to drain
+// connections for the given period before the router begins to shut down.
+//
+// When a router pod starts terminating, its endpoint is marked as terminating,
+// so kube-proxy's health check node port stops reporting the node as healthy,
+// and the load balancer takes the node out of rotation after enough failed
+// health checks.  Until then, the load balancer continues to send new
+// connections to the node.  A preStop hook keeps the router running and
+// serving these connections for the drain period before the kubelet sends the
+// router the termination signal.
+func applyDrainPeriod(deployment *appsv1.Deployment, drainPeriod time.Duration) {
+	if drainPeriod <= 0 {
+		return
+	}
+	seconds := int64(drainPeriod.Round(time.Second) / time.Second)
+	deployment.Spec.Template.Spec.Containers[0].Lifecycle = &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"sleep", strconv.FormatInt(seconds, 10)},
+			},
+		},
+	}
+	gracePeriod := int64(terminationGracePeriodForDrainPeriod(drainPeriod) / time.Second)
+	deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+}
+
+// computeDrainPeriodCondition returns the ingresscontroller's "DrainPeriod"
+// status condition and a Boolean value indicating whether the condition
+// applies.  The condition only applies if the ingresscontroller specifies a
+// drain period.  The condition is false if the drain period requires a
+// termination grace period that is longer than the default, which lengthens
+// node drains and cluster upgrades.
+func computeDrainPeriodCondition(ic *operatorv1.IngressController, platformStatus *configv1.PlatformStatus) (operatorv1.OperatorCondition, bool) {
+	var platformType configv1.PlatformType
+	if platformStatus != nil {
+		platformType = platformStatus.Type
+	}
+	drainPeriod, err := effectiveDrainPeriod(ic, platformType)
+	if err != nil || drainPeriod == 0 {
+		return operatorv1.OperatorCondition{}, false
+	}
+	message := fmt.Sprintf("Router pods drain connections for %v before shutting down.", drainPeriod)
+	if lb := loadBalancerHealthCheckDrainPeriod(ic, platformType); lb != 0 && lb == drainPeriod {
+		message = fmt.Sprintf("Router pods drain connections for %v before shutting down, which is how long the load balancer takes to stop sending connections to a terminating router pod.", drainPeriod)
+	}
+	if gracePeriod := terminationGracePeriodForDrainPeriod(drainPeriod); gracePeriod > routerDefaultTerminationGracePeriod {
+		return operatorv1.OperatorCondition{
+			Type:   IngressControllerDrainPeriodConditionType,
+			Status: operatorv1.ConditionFalse,
+			Reason: "ExceedsGracePeriod",
+			Message: fmt.Sprintf("%s  The drain period and the minimum shutdown period of %v exceed the allowed termination grace period of %v, so router pods have a termination grace period of %v, which lengthens node drains and cluster upgrades.",
+				message, routerMinShutdownPeriod, routerDefaultTerminationGracePeriod, gracePeriod),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerDrainPeriodConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "WithinGracePeriod",
+		Message: message,
+	}, true
+}
@@ -0,0 +1,203 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateDrainPeriod verifies that validateDrainPeriod only allows drain
+// periods between 1s and 1h.
+func Test_validateDrainPeriod(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		expectError bool
+	}{
+		{name: "no overrides"},
+		{name: "tuning options without drainPeriod", overrides: `{"tuningOptions":{}}`},
+		{name: "minimum", overrides: `{"tuningOptions":{"drainPeriod":"1s"}}`},
+		{name: "maximum", overrides: `{"tuningOptions":{"drainPeriod":"1h"}}`},
+		{name: "zero", overrides: `{"tuningOptions":{"drainPeriod":"0s"}}`, expectError: true},
+		{name: "too long", overrides: `{"tuningOptions":{"drainPeriod":"2h"}}`, expectError: true},
+		{name: "invalid duration", overrides: `{"tuningOptions":{"drainPeriod":"30"}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			switch err := validateDrainPeriod(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// drainPeriodTestIngressController returns an ingresscontroller with the given
+// unsupported config overrides and endpoint publishing strategy.
+func drainPeriodTestIngressController(overrides string, eps *operatorv1.EndpointPublishingStrategy) *operatorv1.IngressController {
+	return &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: operatorv1.IngressControllerSpec{
+			UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(overrides)},
+		},
+		Status: operatorv1.IngressControllerStatus{
+			EndpointPublishingStrategy: eps,
+		},
+	}
+}
+
+// Test_effectiveDrainPeriod verifies that effectiveDrainPeriod returns the
+// configured drain period, raised to the time that the platform's load balancer
+// needs to take a terminating router pod out of rotation.
+func Test_effectiveDrainPeriod(t *testing.T) {
+	lb := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+	}
+	nlb := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS: &operatorv1.AWSLoadBalancerParameters{
+					Type: operatorv1.AWSNetworkLoadBalancer,
+				},
+			},
+		},
+	}
+	hostNetwork := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.HostNetworkStrategyType,
+	}
+	testCases := []struct {
+		name      string
+		overrides string
+		eps       *operatorv1.EndpointPublishingStrategy
+		platform  configv1.PlatformType
+		expect    time.Duration
+	}{
+		{name: "unset", eps: lb, platform: configv1.AWSPlatformType},
+		{name: "unset with other tuning options", overrides: `{"tuningOptions":{}}`, eps: lb, platform: configv1.AWSPlatformType},
+		{name: "invalid duration", overrides: `{"tuningOptions":{"drainPeriod":"30"}}`, eps: lb, platform: configv1.AWSPlatformType},
+		{name: "AWS CLB raises short period", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: lb, platform: configv1.AWSPlatformType, expect: 10 * time.Second},
+		{name: "AWS NLB raises short period", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: nlb, platform: configv1.AWSPlatformType, expect: 20 * time.Second},
+		{name: "Azure raises short period", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: lb, platform: configv1.AzurePlatformType, expect: 10 * time.Second},
+		{name: "GCP raises short period", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: lb, platform: configv1.GCPPlatformType, expect: 24 * time.Second},
+		{name: "AWS CLB keeps long period", overrides: `{"tuningOptions":{"drainPeriod":"45s"}}`, eps: lb, platform: configv1.AWSPlatformType, expect: 45 * time.Second},
+		{name: "other platform", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: lb, platform: configv1.OpenStackPlatformType, expect: 5 * time.Second},
+		{name: "HostNetwork", overrides: `{"tuningOptions":{"drainPeriod":"5s"}}`, eps: hostNetwork, platform: configv1.AWSPlatformType, expect: 5 * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := drainPeriodTestIngressController(tc.overrides, tc.eps)
+			actual, err := effectiveDrainPeriod(ic, tc.platform)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expect {
+				t.Errorf("expected %v, got %v", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_applyDrainPeriod verifies that applyDrainPeriod adds a preStop hook
+// that sleeps for the drain period and only extends the termination grace
+// period when the drain period leaves the router too little time to shut down.
+func Test_applyDrainPeriod(t *testing.T) {
+	testCases := []struct {
+		name                string
+		drainPeriod         time.Duration
+		expectCommand       []string
+		expectGracePeriod   int64
+		expectNoPreStopHook bool
+	}{
+		{name: "unset", expectGracePeriod: 3600, expectNoPreStopHook: true},
+		{name: "30s", drainPeriod: 30 * time.Second, expectCommand: []string{"sleep", "30"}, expectGracePeriod: 3600},
+		{name: "59m", drainPeriod: 59 * time.Minute, expectCommand: []string{"sleep", "3540"}, expectGracePeriod: 3600},
+		{name: "1h", drainPeriod: time.Hour, expectCommand: []string{"sleep", "3600"}, expectGracePeriod: 3660},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gracePeriod := int64(3600)
+			deployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers:                    []corev1.Container{{Name: "router"}},
+							TerminationGracePeriodSeconds: &gracePeriod,
+						},
+					},
+				},
+			}
+			applyDrainPeriod(deployment, tc.drainPeriod)
+			lifecycle := deployment.Spec.Template.Spec.Containers[0].Lifecycle
+			switch {
+			case tc.expectNoPreStopHook && lifecycle != nil:
+				t.Errorf("expected no lifecycle, got %+v", lifecycle)
+			case !tc.expectNoPreStopHook && (lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil):
+				t.Errorf("expected a preStop exec hook, got %+v", lifecycle)
+			case !tc.expectNoPreStopHook && !reflect.DeepEqual(lifecycle.PreStop.Exec.Command, tc.expectCommand):
+				t.Errorf("expected preStop command %v, got %v", tc.expectCommand, lifecycle.PreStop.Exec.Command)
+			}
+			if actual := *deployment.Spec.Template.Spec.TerminationGracePeriodSeconds; actual != tc.expectGracePeriod {
+				t.Errorf("expected termination grace period %d, got %d", tc.expectGracePeriod, actual)
+			}
+		})
+	}
+}
+
+// Test_computeDrainPeriodCondition verifies that computeDrainPeriodCondition
+// only reports the "DrainPeriod" status condition when a drain period is
+// configured and reports it as false when the drain period requires a longer
+// termination grace period than the default.
+func Test_computeDrainPeriodCondition(t *testing.T) {
+	lb := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+	}
+	aws := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	testCases := []struct {
+		name           string
+		overrides      string
+		platformStatus *configv1.PlatformStatus
+		expectApplies  bool
+		expectStatus   operatorv1.ConditionStatus
+		expectReason   string
+	}{
+		{name: "unset", platformStatus: aws},
+		{name: "invalid duration", overrides: `{"tuningOptions":{"drainPeriod":"30"}}`, platformStatus: aws},
+		{name: "within grace period", overrides: `{"tuningOptions":{"drainPeriod":"30s"}}`, platformStatus: aws, expectApplies: true, expectStatus: operatorv1.ConditionTrue, expectReason: "WithinGracePeriod"},
+		{name: "raised to load balancer time", overrides: `{"tuningOptions":{"drainPeriod":"1s"}}`, platformStatus: aws, expectApplies: true, expectStatus: operatorv1.ConditionTrue, expectReason: "WithinGracePeriod"},
+		{name: "nil platform status", overrides: `{"tuningOptions":{"drainPeriod":"30s"}}`, expectApplies: true, expectStatus: operatorv1.ConditionTrue, expectReason: "WithinGracePeriod"},
+		{name: "exceeds grace period", overrides: `{"tuningOptions":{"drainPeriod":"59m30s"}}`, platformStatus: aws, expectApplies: true, expectStatus: operatorv1.ConditionFalse, expectReason: "ExceedsGracePeriod"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := drainPeriodTestIngressController(tc.overrides, lb)
+			condition, applies := computeDrainPeriodCondition(ic, tc.platformStatus)
+			if applies != tc.expectApplies {
+				t.Fatalf("expected applies=%t, got %t", tc.expectApplies, applies)
+			}
+			if !applies {
+				return
+			}
+			if condition.Type != IngressControllerDrainPeriodConditionType || condition.Status != tc.expectStatus || condition.Reason != tc.expectReason {
+				t.Errorf("expected condition with status %q and reason %q, got %+v", tc.expectStatus, tc.expectReason, condition)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// drainSurgeReplicasAnnotation is the annotation in which the operator
+	// records on the router deployment the number of replicas that it has
+	// added to the deployment's desired replicas because nodes that host
+	// router pods are cordoned.  The operator uses the annotation to scale
+	// the deployment back once the drain is complete.
+	drainSurgeReplicasAnnotation = "ingress.operator.openshift.io/drain-surge-replicas"
+)
+
+// surgeOnNodeDrainForIngressController returns a Boolean value indicating
+// whether the given ingresscontroller opts in to surging router replicas when
+// nodes that host router pods are cordoned, for example when the machine
+// config operator drains nodes during a cluster upgrade.  The opt-in is
+// specified using the "surgeOnNodeDrain" unsupported config override.  An
+// error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func surgeOnNodeDrainForIngressController(ic *operatorv1.IngressController) (bool, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return false, err
+	}
+	return overrides.SurgeOnNodeDrain, nil
+}
+
+// validateSurgeOnNodeDrain validates the given ingresscontroller's drain surge
+// opt-in.  Surging on node drain can only be enabled with the
+// LoadBalancerService endpoint publishing strategy because with the other
+// strategies, a surged replica either cannot serve traffic or competes for host
+// ports with the replicas that it is meant to protect.
+func validateSurgeOnNodeDrain(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	enabled := overrides.SurgeOnNodeDrain
+	if !enabled {
+		return nil
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.surgeOnNodeDrain can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	return nil
+}
+
+// drainSurgeApplies returns a Boolean value indicating whether the operator
+// should surge the given ingresscontroller's router replicas when nodes that
+// host router pods are cordoned.
+func drainSurgeApplies(ic *operatorv1.IngressController) bool {
+	if enabled, err := surgeOnNodeDrainForIngressController(ic); err != nil || !enabled {
+		return false
+	}
+	eps := ic.Status.EndpointPublishingStrategy
+	return eps != nil && eps.Type == operatorv1.LoadBalancerServiceStrategyType
+}
+
+// routerPodsByNode returns the number of the given pods that belong to the
+// given deployment and are not terminating, keyed by the name of the node on
+// which they are scheduled.
+func routerPodsByNode(deployment *appsv1.Deployment, pods []corev1.Pod) map[string]int {
+	podsByNode := map[string]int{}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return podsByNode
+	}
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pod.DeletionTimestamp != nil || len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName]++
+	}
+	return podsByNode
+}
+
+// cordonedRouterNodes returns the sorted names of the given nodes that are
+// cordoned and host pods of the given deployment.
+func cordonedRouterNodes(deployment *appsv1.Deployment, pods []corev1.Pod, nodes []corev1.Node) []string {
+	podsByNode := routerPodsByNode(deployment, pods)
+	var cordoned []string
+	for i := range nodes {
+		if nodes[i].Spec.Unschedulable && podsByNode[nodes[i].Name] != 0 {
+			cordoned = append(cordoned, nodes[i].Name)
+		}
+	}
+	sort.Strings(cordoned)
+	return cordoned
+}
+
+// untouchedNodes returns the number of the given nodes to which the given
+// deployment's pods can be scheduled and that do not already host a pod of the
+// deployment, which are the nodes on which surged replicas can be scheduled.
+func untouchedNodes(deployment *appsv1.Deployment, pods []corev1.Pod, nodes []corev1.Node) int {
+	podsByNode := routerPodsByNode(deployment, pods)
+	hosting := sets.New[string]()
+	for name := range podsByNode {
+		hosting.Insert(name)
+	}
+	var candidates []corev1.Node
+	for i := range nodes {
+		if !hosting.Has(nodes[i].Name) {
+			candidates = append(candidates, nodes[i])
+		}
+	}
+	return schedulableNodesForDeployment(deployment, candidates)
+}
+
+// drainSurgeReplicasOf returns the number of surged replicas that the given
+// deployment's drainSurgeReplicasAnnotation annotation records.
+func drainSurgeReplicasOf(deployment *appsv1.Deployment) int32 {
+	if deployment == nil {
+		return 0
+	}
+	surge, err := strconv.Atoi(deployment.Annotations[drainSurgeReplicasAnnotation])
+	if err != nil || surge < 0 {
+		return 0
+	}
+	return int32(surge)
+}
+
+// desiredDrainSurgeReplicas returns the number of replicas to add to the
+// desired router deployment, given the current deployment, the router pods, and
+// the cluster's nodes.  The operator surges one replica for each cordoned node
+// that hosts a router pod so that the replica is running on an untouched node
+// before the drain evicts the pod.  Because surged replicas must not be
+// colocated with other replicas, the surge is limited to the number of
+// untouched nodes plus the previous surge, whose replicas occupy nodes that
+// were untouched when the operator surged them.  Once the cordoned nodes no
+// longer host router pods, the operator keeps the surge until all of the
+// current deployment's replicas are available so that the evicted pods'
+// replacements are running before it scales back.
+func desiredDrainSurgeReplicas(desired, current *appsv1.Deployment, pods []corev1.Pod, nodes []corev1.Node) int32 {
+	previous := drainSurgeReplicasOf(current)
+	surge := int32(len(cordonedRouterNodes(desired, pods, nodes)))
+	if limit := int32(untouchedNodes(desired, pods, nodes)) + previous; surge > limit {
+		surge = limit
+	}
+	if surge < previous && current.Status.AvailableReplicas < deploymentDesiredReplicas(current) {
+		surge = previous
+	}
+	return surge
+}
+
+// drainSurgeReplicas lists the router pods and the cluster's nodes and returns
+// the number of replicas to add to the given desired router deployment because
+// nodes that host router pods are cordoned.  See desiredDrainSurgeReplicas.
+func (r *reconciler) drainSurgeReplicas(desired, current *appsv1.Deployment) (int32, error) {
+	var pods corev1.PodList
+	if err := r.cache.List(context.TODO(), &pods, client.InNamespace(desired.Namespace)); err != nil {
+		return 0, fmt.Errorf("failed to list pods in namespace %q: %w", desired.Namespace, err)
+	}
+	var nodes corev1.NodeList
+	if err := r.client.List(context.TODO(), &nodes); err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return desiredDrainSurgeReplicas(desired, current, pods.Items, nodes.Items), nil
+}
+
+// applyDrainSurge adds the given number of surged replicas to the given
+// deployment's desired replicas and records the surge in the deployment's
+// drainSurgeReplicasAnnotation annotation.
+func applyDrainSurge(deployment *appsv1.Deployment, surge int32) {
+	if surge == 0 {
+		return
+	}
+	replicas := deploymentDesiredReplicas(deployment) + surge
+	deployment.Spec.Replicas = &replicas
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[drainSurgeReplicasAnnotation] = strconv.Itoa(int(surge))
+}
+
+// computeDrainSurgeCondition computes the ingresscontroller's "DrainSurge"
+// status condition, which reports whether the operator has surged the router
+// deployment's replicas because the given cordoned nodes host router pods.
+func computeDrainSurgeCondition(deployment *appsv1.Deployment, cordoned []string) operatorv1.OperatorCondition {
+	surge := drainSurgeReplicasOf(deployment)
+	replicas := deploymentDesiredReplicas(deployment)
+	switch {
+	case surge != 0 && len(cordoned) != 0:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerDrainSurgeConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "SurgingForNodeDrain",
+			Message: fmt.Sprintf("Surged %d extra router replicas, for a total of %d, because the following nodes that host router pods are cordoned: %s.", surge, replicas, strings.Join(cordoned, ", ")),
+		}
+	case surge != 0:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerDrainSurgeConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "WaitingForReplicas",
+			Message: fmt.Sprintf("Keeping %d extra router replicas until all %d replicas are available after the node drain; %d are available.", surge, replicas, deployment.Status.AvailableReplicas),
+		}
+	case len(cordoned) != 0:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerDrainSurgeConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "NoNodesForSurge",
+			Message: fmt.Sprintf("The following nodes that host router pods are cordoned, but no other node can host an extra router replica: %s.", strings.Join(cordoned, ", ")),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerDrainSurgeConditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "NoNodeDrain",
+		Message: "No node that hosts router pods is cordoned.",
+	}
+}
@@ -0,0 +1,201 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+// Test_validateSurgeOnNodeDrain verifies that validateSurgeOnNodeDrain only
+// allows the drain surge opt-in with the LoadBalancerService endpoint
+// publishing strategy.
+func Test_validateSurgeOnNodeDrain(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		eps         operatorv1.EndpointPublishingStrategyType
+		expectError bool
+	}{
+		{name: "no overrides", eps: operatorv1.HostNetworkStrategyType},
+		{name: "disabled", overrides: `{"surgeOnNodeDrain":false}`, eps: operatorv1.HostNetworkStrategyType},
+		{name: "LoadBalancerService", overrides: `{"surgeOnNodeDrain":true}`, eps: operatorv1.LoadBalancerServiceStrategyType},
+		{name: "default strategy", overrides: `{"surgeOnNodeDrain":true}`},
+		{name: "HostNetwork", overrides: `{"surgeOnNodeDrain":true}`, eps: operatorv1.HostNetworkStrategyType, expectError: true},
+		{name: "NodePortService", overrides: `{"surgeOnNodeDrain":true}`, eps: operatorv1.NodePortServiceStrategyType, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			if len(tc.eps) != 0 {
+				ic.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: tc.eps}
+			}
+			switch err := validateSurgeOnNodeDrain(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_desiredDrainSurgeReplicas simulates a node drain and verifies that the
+// operator surges a router replica when a node that hosts a router pod is
+// cordoned, keeps the surge while the drain is in progress and until the
+// evicted pod's replacement is available, and then scales back.
+func Test_desiredDrainSurgeReplicas(t *testing.T) {
+	labels := map[string]string{controller.ControllerDeploymentLabel: "default"}
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(2),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{"node-role.kubernetes.io/worker": ""},
+				},
+			},
+		},
+	}
+	node := func(name string, cordoned bool) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+			},
+			Spec: corev1.NodeSpec{Unschedulable: cordoned},
+		}
+	}
+	pod := func(name, nodeName string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	terminating := func(p corev1.Pod) corev1.Pod {
+		p.DeletionTimestamp = &metav1.Time{}
+		return p
+	}
+	// The steps are applied in order, and each step's current deployment
+	// is the deployment that the previous step's desired surge produced,
+	// with the given number of available replicas.
+	steps := []struct {
+		name              string
+		nodes             []corev1.Node
+		pods              []corev1.Pod
+		availableReplicas int32
+		expectSurge       int32
+		expectReplicas    int32
+		expectReason      string
+	}{
+		{
+			name:              "no node is cordoned",
+			nodes:             []corev1.Node{node("a", false), node("b", false), node("c", false)},
+			pods:              []corev1.Pod{pod("router-1", "a"), pod("router-2", "b")},
+			availableReplicas: 2,
+			expectSurge:       0,
+			expectReplicas:    2,
+			expectReason:      "NoNodeDrain",
+		},
+		{
+			name:              "node a is cordoned",
+			nodes:             []corev1.Node{node("a", true), node("b", false), node("c", false)},
+			pods:              []corev1.Pod{pod("router-1", "a"), pod("router-2", "b")},
+			availableReplicas: 2,
+			expectSurge:       1,
+			expectReplicas:    3,
+			expectReason:      "SurgingForNodeDrain",
+		},
+		{
+			name:              "surged replica is running on node c",
+			nodes:             []corev1.Node{node("a", true), node("b", false), node("c", false)},
+			pods:              []corev1.Pod{pod("router-1", "a"), pod("router-2", "b"), pod("router-3", "c")},
+			availableReplicas: 3,
+			expectSurge:       1,
+			expectReplicas:    3,
+			expectReason:      "SurgingForNodeDrain",
+		},
+		{
+			name:              "node a is drained and the replacement pod is pending",
+			nodes:             []corev1.Node{node("a", true), node("b", false), node("c", false)},
+			pods:              []corev1.Pod{terminating(pod("router-1", "a")), pod("router-2", "b"), pod("router-3", "c"), pod("router-4", "")},
+			availableReplicas: 2,
+			expectSurge:       1,
+			expectReplicas:    3,
+			expectReason:      "WaitingForReplicas",
+		},
+		{
+			name:              "node a is uncordoned and the replacement pod is running on it",
+			nodes:             []corev1.Node{node("a", false), node("b", false), node("c", false)},
+			pods:              []corev1.Pod{pod("router-2", "b"), pod("router-3", "c"), pod("router-4", "a")},
+			availableReplicas: 3,
+			expectSurge:       0,
+			expectReplicas:    2,
+			expectReason:      "NoNodeDrain",
+		},
+		{
+			name:              "node b is cordoned",
+			nodes:             []corev1.Node{node("a", false), node("b", true), node("c", false)},
+			pods:              []corev1.Pod{pod("router-2", "b"), pod("router-3", "c")},
+			availableReplicas: 2,
+			expectSurge:       1,
+			expectReplicas:    3,
+			expectReason:      "SurgingForNodeDrain",
+		},
+		{
+			name:              "nodes b and c are cordoned back-to-back",
+			nodes:             []corev1.Node{node("a", false), node("b", true), node("c", true), node("d", false)},
+			pods:              []corev1.Pod{pod("router-2", "b"), pod("router-3", "c"), pod("router-5", "a")},
+			availableReplicas: 3,
+			expectSurge:       2,
+			expectReplicas:    4,
+			expectReason:      "SurgingForNodeDrain",
+		},
+	}
+	var current *appsv1.Deployment
+	for _, step := range steps {
+		if current != nil {
+			current.Status.AvailableReplicas = step.availableReplicas
+		}
+		surge := desiredDrainSurgeReplicas(desired, current, step.pods, step.nodes)
+		if surge != step.expectSurge {
+			t.Fatalf("%s: expected surge %d, got %d", step.name, step.expectSurge, surge)
+		}
+		updated := desired.DeepCopy()
+		applyDrainSurge(updated, surge)
+		if replicas := deploymentDesiredReplicas(updated); replicas != step.expectReplicas {
+			t.Fatalf("%s: expected %d replicas, got %d", step.name, step.expectReplicas, replicas)
+		}
+		if recorded := drainSurgeReplicasOf(updated); recorded != surge {
+			t.Fatalf("%s: expected the deployment to record surge %d, got %d", step.name, surge, recorded)
+		}
+		updated.Status.AvailableReplicas = step.availableReplicas
+		condition := computeDrainSurgeCondition(updated, cordonedRouterNodes(updated, step.pods, step.nodes))
+		if condition.Reason != step.expectReason {
+			t.Fatalf("%s: expected condition reason %q, got %q: %s", step.name, step.expectReason, condition.Reason, condition.Message)
+		}
+		current = updated
+	}
+}
+
+// Test_computeDrainSurgeCondition verifies that computeDrainSurgeCondition
+// reports a cordoned node for which no node is available for a surge.
+func Test_computeDrainSurgeCondition(t *testing.T) {
+	deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: pointer.Int32(2)}}
+	condition := computeDrainSurgeCondition(deployment, []string{"a"})
+	if condition.Status != operatorv1.ConditionFalse || condition.Reason != "NoNodesForSurge" {
+		t.Errorf("expected DrainSurge=False with reason NoNodesForSurge, got %s=%s with reason %s", condition.Type, condition.Status, condition.Reason)
+	}
+}
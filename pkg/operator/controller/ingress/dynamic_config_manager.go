@@ -0,0 +1,68 @@
+package ingress
+
+import (
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// dynamicConfigManagerForIngressController returns a Boolean value indicating
+// whether the given ingresscontroller enables the router's dynamic
+// configuration manager, which applies some route and endpoint changes
+// without reloading HAProxy by using pre-allocated blueprint backends and
+// server slots.  The manager is enabled using the "dynamicConfigManager"
+// unsupported config override; the route scale options configure the number
+// of pre-allocated backends and servers.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+//
+// TODO Read the first-class spec.tuningOptions field that the
+// dynamic configuration manager request calls for, guarded by its feature
+// gate, once github.com/openshift/api has the field and the gate and they
+// are vendored.  Until then the request is not delivered: the override below
+// is only a stopgap, and this function is the single place that must change.
+func dynamicConfigManagerForIngressController(ic *operatorv1.IngressController) (bool, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return false, err
+	}
+	enabled, err := strconv.ParseBool(overrides.DynamicConfigManager)
+	return err == nil && enabled, nil
+}
+
+// routerDeploymentHasDynamicConfigManager returns a Boolean value indicating
+// whether the given router deployment enables the dynamic configuration
+// manager.
+func routerDeploymentHasDynamicConfigManager(deployment *appsv1.Deployment) bool {
+	if deployment == nil {
+		return false
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "router" {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == RouterHAProxyConfigManager {
+				enabled, err := strconv.ParseBool(env.Value)
+				return err == nil && enabled
+			}
+		}
+	}
+	return false
+}
+
+// recordDynamicConfigManagerToggle emits an event on the given
+// ingresscontroller if updating its router deployment from current to updated
+// enables or disables the dynamic configuration manager.
+func (r *reconciler) recordDynamicConfigManagerToggle(ci *operatorv1.IngressController, current, updated *appsv1.Deployment) {
+	wasEnabled, enabled := routerDeploymentHasDynamicConfigManager(current), routerDeploymentHasDynamicConfigManager(updated)
+	if wasEnabled == enabled || r.recorder == nil {
+		return
+	}
+	if enabled {
+		r.recorder.Eventf(ci, "Normal", "DynamicConfigManagerEnabled", "Enabled the dynamic configuration manager for router deployment %s/%s", updated.Namespace, updated.Name)
+		return
+	}
+	r.recorder.Eventf(ci, "Normal", "DynamicConfigManagerDisabled", "Disabled the dynamic configuration manager for router deployment %s/%s", updated.Namespace, updated.Name)
+}
@@ -0,0 +1,116 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_dynamicConfigManagerForIngressController verifies that
+// dynamicConfigManagerForIngressController only enables the dynamic
+// configuration manager if the "dynamicConfigManager" unsupported config
+// override is a true Boolean value.
+func Test_dynamicConfigManagerForIngressController(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		expect      bool
+		expectError bool
+	}{
+		{name: "no overrides"},
+		{name: "other overrides", overrides: `{"contStats":"true"}`},
+		{name: "true", overrides: `{"dynamicConfigManager":"true"}`, expect: true},
+		{name: "TRUE", overrides: `{"dynamicConfigManager":"TRUE"}`, expect: true},
+		{name: "false", overrides: `{"dynamicConfigManager":"false"}`},
+		{name: "not a Boolean value", overrides: `{"dynamicConfigManager":"Enabled"}`},
+		{name: "invalid overrides", overrides: `{"dynamicConfigManager":true}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			actual, err := dynamicConfigManagerForIngressController(ic)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_updateRouterDeploymentDynamicConfigManagerEvents verifies that
+// updateRouterDeployment emits an event when an update enables or disables the
+// dynamic configuration manager and no such event for other updates.
+func Test_updateRouterDeploymentDynamicConfigManagerEvents(t *testing.T) {
+	routerDeployment := func(env ...corev1.EnvVar) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "router", Image: "router", Env: env}},
+					},
+				},
+			},
+		}
+	}
+	enabled := corev1.EnvVar{Name: RouterHAProxyConfigManager, Value: "true"}
+	other := corev1.EnvVar{Name: RouterHAProxyContstats, Value: "true"}
+	testCases := []struct {
+		name        string
+		current     *appsv1.Deployment
+		desired     *appsv1.Deployment
+		expectEvent string
+	}{
+		{name: "enabled", current: routerDeployment(), desired: routerDeployment(enabled), expectEvent: "DynamicConfigManagerEnabled"},
+		{name: "disabled", current: routerDeployment(enabled), desired: routerDeployment(), expectEvent: "DynamicConfigManagerDisabled"},
+		{name: "still enabled", current: routerDeployment(enabled), desired: routerDeployment(enabled, other)},
+		{name: "still disabled", current: routerDeployment(), desired: routerDeployment(other)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &reconciler{
+				client:   fake.NewClientBuilder().WithObjects(tc.current).Build(),
+				recorder: recorder,
+			}
+			ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"}}
+			if changed, err := r.updateRouterDeployment(ic, tc.current, tc.desired); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if !changed {
+				t.Fatal("expected the deployment to be updated")
+			}
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				if strings.Contains(event, "DynamicConfigManager") {
+					events = append(events, event)
+				}
+			}
+			switch {
+			case len(tc.expectEvent) == 0 && len(events) != 0:
+				t.Errorf("expected no dynamic configuration manager event, got %v", events)
+			case len(tc.expectEvent) != 0 && (len(events) != 1 || !strings.Contains(events[0], tc.expectEvent)):
+				t.Errorf("expected a %s event, got %v", tc.expectEvent, events)
+			}
+		})
+	}
+}
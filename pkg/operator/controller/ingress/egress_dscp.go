@@ -0,0 +1,240 @@
+package ingress
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// egressDSCPInitContainerName is the name of the init container that
+	// marks the router's egress traffic with the ingresscontroller's
+	// egress DSCP value.
+	egressDSCPInitContainerName = "egress-dscp"
+
+	// egressDSCPSecurityContextConstraints is the name of the security
+	// context constraints that allow the egress DSCP init container to
+	// add the NET_ADMIN capability.  Only the router-egress-dscp service
+	// account may use them.
+	egressDSCPSecurityContextConstraints = "ingress-router-egress-dscp"
+
+	// requiredSCCAnnotation is the annotation with which a pod specifies
+	// the security context constraints that admission must use for it.
+	requiredSCCAnnotation = "openshift.io/required-scc"
+
+	// maxDSCP is the largest DSCP value, which is a 6-bit field.
+	maxDSCP = 63
+)
+
+// egressDSCPNetworkTypes are the cluster network types with which the router
+// pods have their own network namespace in which the operator can mark egress
+// traffic without affecting other pods.
+var egressDSCPNetworkTypes = map[string]struct{}{
+	string(operatorv1.NetworkTypeOVNKubernetes): {},
+	string(operatorv1.NetworkTypeOpenShiftSDN):  {},
+}
+
+// egressDSCPForIngressController returns the DSCP value with which the given
+// ingresscontroller's router marks the traffic that it originates, such as
+// connections to backends, or nil if the router does not mark its traffic.
+// The value is specified using the "egressDSCP" unsupported config override.
+// An error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func egressDSCPForIngressController(ic *operatorv1.IngressController) (*int, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.EgressDSCP, nil
+}
+
+// validateEgressDSCP validates the given ingresscontroller's egress DSCP value.
+func validateEgressDSCP(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	dscp := overrides.EgressDSCP
+	if dscp == nil {
+		return nil
+	}
+	if *dscp < 0 || *dscp > maxDSCP {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.egressDSCP must be between 0 and %d: %d", maxDSCP, *dscp)
+	}
+	return nil
+}
+
+// egressDSCPSupported returns an error describing why the router of the given
+// ingresscontroller cannot mark its egress traffic in the given cluster
+// network, or nil if it can.  The router marks its traffic using a rule in
+// its pod's network namespace, so the router must not use the host network.
+func egressDSCPSupported(ic *operatorv1.IngressController, networkConfig *configv1.Network) error {
+	if eps := ic.Status.EndpointPublishingStrategy; eps != nil && eps.Type == operatorv1.HostNetworkStrategyType {
+		return fmt.Errorf("the %q endpoint publishing strategy is not supported because the router uses the node's network namespace", operatorv1.HostNetworkStrategyType)
+	}
+	if networkConfig == nil {
+		return fmt.Errorf("the cluster network type is unknown")
+	}
+	if _, ok := egressDSCPNetworkTypes[networkConfig.Status.NetworkType]; !ok {
+		return fmt.Errorf("the %q cluster network type is not supported", networkConfig.Status.NetworkType)
+	}
+	return nil
+}
+
+// desiredEgressDSCPInitContainer returns the init container that marks the
+// traffic that the router originates with the given DSCP value.  The init
+// container adds a rule to the mangle table of the router pod's network
+// namespace that marks packets in the original direction of connections that
+// the router initiates, which excludes the router's responses to clients.  The
+// init container fails if the image has neither iptables nor ip6tables so that
+// the router does not start without marking its traffic.
+func desiredEgressDSCPInitContainer(image string, dscp int) corev1.Container {
+	script := fmt.Sprintf(`set -e
+marked=false
+for cmd in iptables ip6tables; do
+  if command -v "$cmd" >/dev/null; then
+    "$cmd" -t mangle -A OUTPUT -m conntrack --ctdir ORIGINAL -j DSCP --set-dscp %[1]d
+    marked=true
+  fi
+done
+if [ "$marked" != true ]; then
+  echo "cannot set DSCP value %[1]d: neither iptables nor ip6tables is available" >&2
+  exit 1
+fi
+`, dscp)
+	runAsUser := int64(0)
+	allowPrivilegeEscalation := false
+	return corev1.Container{
+		Name:                     egressDSCPInitContainerName,
+		Image:                    image,
+		ImagePullPolicy:          corev1.PullIfNotPresent,
+		Command:                  []string{"/bin/bash", "-c", script},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser:                &runAsUser,
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities: &corev1.Capabilities{
+				Add:  []corev1.Capability{"NET_ADMIN"},
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+	}
+}
+
+// computeEgressDSCPSupportedCondition returns the ingresscontroller's
+// "EgressDSCPSupported" status condition, which reports whether the router
+// marks its egress traffic with the ingresscontroller's egress DSCP value, and
+// a Boolean value indicating whether the condition applies.  The condition
+// applies only if the ingresscontroller specifies an egress DSCP value.  The
+// condition is true only once the egress DSCP init container of a router pod
+// with the current DSCP value has completed successfully.
+func computeEgressDSCPSupportedCondition(ic *operatorv1.IngressController, networkConfig *configv1.Network, deployment *appsv1.Deployment, pods []corev1.Pod) (operatorv1.OperatorCondition, bool) {
+	dscp, err := egressDSCPForIngressController(ic)
+	if err != nil || dscp == nil {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if err := egressDSCPSupported(ic, networkConfig); err != nil {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerEgressDSCPSupportedConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "Unsupported",
+			Message: fmt.Sprintf("spec.unsupportedConfigOverrides.egressDSCP is ignored: %v.", err),
+		}, true
+	}
+	applied, failure := egressDSCPInitContainerResult(deployment, pods, *dscp)
+	switch {
+	case len(failure) != 0:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerEgressDSCPSupportedConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "MarkingFailed",
+			Message: fmt.Sprintf("The router failed to mark the connections that it initiates with DSCP value %d: %s", *dscp, failure),
+		}, true
+	case !applied:
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerEgressDSCPSupportedConditionType,
+			Status:  operatorv1.ConditionUnknown,
+			Reason:  "Pending",
+			Message: fmt.Sprintf("No router pod has marked the connections that it initiates with DSCP value %d yet.", *dscp),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerEgressDSCPSupportedConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "Supported",
+		Message: fmt.Sprintf("The router marks the connections that it initiates with DSCP value %d.", *dscp),
+	}, true
+}
+
+// egressDSCPInitContainerResult inspects the egress DSCP init containers of the
+// given deployment's pods that mark traffic with the given DSCP value.  It
+// returns a Boolean value indicating whether any of them completed
+// successfully, and the termination message of one that failed, if any did.
+// Pods from an earlier rollout with a different DSCP value are ignored.
+func egressDSCPInitContainerResult(deployment *appsv1.Deployment, pods []corev1.Pod, dscp int) (bool, string) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return false, ""
+	}
+	expected := desiredEgressDSCPInitContainer("", dscp).Command
+	applied := false
+	for i := range pods {
+		if !selector.Matches(labels.Set(pods[i].Labels)) {
+			continue
+		}
+		if !podHasInitContainerCommand(&pods[i], egressDSCPInitContainerName, expected) {
+			continue
+		}
+		for _, status := range pods[i].Status.InitContainerStatuses {
+			if status.Name != egressDSCPInitContainerName {
+				continue
+			}
+			for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+				switch {
+				case terminated == nil:
+				case terminated.ExitCode == 0:
+					applied = true
+				case len(terminated.Message) != 0:
+					return applied, strings.TrimSpace(terminated.Message)
+				default:
+					return applied, fmt.Sprintf("init container %s in pod %s exited with code %d", egressDSCPInitContainerName, pods[i].Name, terminated.ExitCode)
+				}
+			}
+		}
+	}
+	return applied, ""
+}
+
+// podHasInitContainerCommand returns a Boolean value indicating whether the
+// given pod has an init container with the given name and command.
+func podHasInitContainerCommand(pod *corev1.Pod, name string, command []string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == name {
+			return reflect.DeepEqual(container.Command, command)
+		}
+	}
+	return false
+}
+
+// egressDSCPInitContainerStatusChanged returns a Boolean value indicating
+// whether the status of the given pod's egress DSCP init container changed, in
+// which case the "EgressDSCPSupported" status condition must be recomputed.
+func egressDSCPInitContainerStatusChanged(oldPod, newPod *corev1.Pod) bool {
+	status := func(pod *corev1.Pod) *corev1.ContainerStatus {
+		for i := range pod.Status.InitContainerStatuses {
+			if pod.Status.InitContainerStatuses[i].Name == egressDSCPInitContainerName {
+				return &pod.Status.InitContainerStatuses[i]
+			}
+		}
+		return nil
+	}
+	oldStatus, newStatus := status(oldPod), status(newPod)
+	if newStatus == nil {
+		return false
+	}
+	return oldStatus == nil || !reflect.DeepEqual(oldStatus.State, newStatus.State) || !reflect.DeepEqual(oldStatus.LastTerminationState, newStatus.LastTerminationState)
+}
@@ -0,0 +1,326 @@
+package ingress
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateEgressDSCP verifies that validateEgressDSCP accepts only DSCP
+// values between 0 and 63.
+func Test_validateEgressDSCP(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "other overrides",
+			overrides:   `{"routerImage":""}`,
+		},
+		{
+			description: "zero",
+			overrides:   `{"egressDSCP":0}`,
+		},
+		{
+			description: "expedited forwarding",
+			overrides:   `{"egressDSCP":46}`,
+		},
+		{
+			description: "maximum",
+			overrides:   `{"egressDSCP":63}`,
+		},
+		{
+			description: "negative",
+			overrides:   `{"egressDSCP":-1}`,
+			expectError: true,
+		},
+		{
+			description: "too large",
+			overrides:   `{"egressDSCP":64}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateEgressDSCP(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentEgressDSCP verifies that desiredRouterDeployment
+// adds the egress DSCP init container with the expected command and security
+// context only if the ingresscontroller specifies an egress DSCP value and the
+// router does not use the host network in a supported cluster network.
+func TestDesiredRouterDeploymentEgressDSCP(t *testing.T) {
+	testCases := []struct {
+		description   string
+		overrides     string
+		strategy      operatorv1.EndpointPublishingStrategyType
+		networkType   string
+		expectInit    bool
+		expectFailure bool
+	}{
+		{
+			description: "no egress DSCP",
+			strategy:    operatorv1.PrivateStrategyType,
+			networkType: string(operatorv1.NetworkTypeOVNKubernetes),
+		},
+		{
+			description: "egress DSCP with OVN-Kubernetes",
+			overrides:   `{"egressDSCP":46}`,
+			strategy:    operatorv1.PrivateStrategyType,
+			networkType: string(operatorv1.NetworkTypeOVNKubernetes),
+			expectInit:  true,
+		},
+		{
+			description: "egress DSCP with OpenShift SDN and a load balancer",
+			overrides:   `{"egressDSCP":46}`,
+			strategy:    operatorv1.LoadBalancerServiceStrategyType,
+			networkType: string(operatorv1.NetworkTypeOpenShiftSDN),
+			expectInit:  true,
+		},
+		{
+			description: "egress DSCP with host network",
+			overrides:   `{"egressDSCP":46}`,
+			strategy:    operatorv1.HostNetworkStrategyType,
+			networkType: string(operatorv1.NetworkTypeOVNKubernetes),
+		},
+		{
+			description: "egress DSCP with an unsupported network type",
+			overrides:   `{"egressDSCP":46}`,
+			strategy:    operatorv1.PrivateStrategyType,
+			networkType: "Calico",
+		},
+		{
+			description:   "out-of-range egress DSCP",
+			overrides:     `{"egressDSCP":64}`,
+			strategy:      operatorv1.PrivateStrategyType,
+			networkType:   string(operatorv1.NetworkTypeOVNKubernetes),
+			expectFailure: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Status.EndpointPublishingStrategy.Type = tc.strategy
+			networkConfig.Status.NetworkType = tc.networkType
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if tc.expectFailure {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			scc, haveSCC := deployment.Spec.Template.Annotations[requiredSCCAnnotation]
+			if !tc.expectInit {
+				if len(initContainers) != 0 {
+					t.Errorf("expected no init containers, got %+v", initContainers)
+				}
+				if haveSCC {
+					t.Errorf("expected no %s annotation, got %q", requiredSCCAnnotation, scc)
+				}
+				if sa := deployment.Spec.Template.Spec.ServiceAccountName; sa != "router" {
+					t.Errorf("expected service account %q, got %q", "router", sa)
+				}
+				return
+			}
+			if len(initContainers) != 1 {
+				t.Fatalf("expected 1 init container, got %d", len(initContainers))
+			}
+			if scc != egressDSCPSecurityContextConstraints {
+				t.Errorf("expected %s annotation %q, got %q", requiredSCCAnnotation, egressDSCPSecurityContextConstraints, scc)
+			}
+			if sa := deployment.Spec.Template.Spec.ServiceAccountName; sa != "router-egress-dscp" {
+				t.Errorf("expected service account %q, got %q", "router-egress-dscp", sa)
+			}
+			container := initContainers[0]
+			if container.Name != egressDSCPInitContainerName {
+				t.Errorf("expected init container name %q, got %q", egressDSCPInitContainerName, container.Name)
+			}
+			if container.Image != ingressControllerImage {
+				t.Errorf("expected init container image %q, got %q", ingressControllerImage, container.Image)
+			}
+			if script := strings.Join(container.Command, " "); !strings.Contains(script, "-j DSCP --set-dscp 46") || !strings.Contains(script, "--ctdir ORIGINAL") {
+				t.Errorf("expected the init container to mark originated connections with DSCP 46, got %q", script)
+			}
+			sc := container.SecurityContext
+			if sc == nil || sc.RunAsUser == nil || *sc.RunAsUser != 0 {
+				t.Fatalf("expected the init container to run as root, got %+v", sc)
+			}
+			if sc.Capabilities == nil || !reflect.DeepEqual(sc.Capabilities.Add, []corev1.Capability{"NET_ADMIN"}) || !reflect.DeepEqual(sc.Capabilities.Drop, []corev1.Capability{"ALL"}) {
+				t.Errorf("expected the init container to add only NET_ADMIN, got %+v", sc.Capabilities)
+			}
+			if sc.Privileged != nil && *sc.Privileged {
+				t.Error("expected the init container not to be privileged")
+			}
+			if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+				t.Error("expected the init container to disallow privilege escalation")
+			}
+			if c := deployment.Spec.Template.Spec.Containers[0].SecurityContext; c != nil && c.Capabilities != nil && len(c.Capabilities.Add) != 0 {
+				t.Errorf("expected the router container not to add capabilities, got %+v", c.Capabilities)
+			}
+		})
+	}
+}
+
+// TestDeploymentConfigChangedEgressDSCP verifies that deploymentConfigChanged
+// detects adding, changing, and removing the egress DSCP init container.
+func TestDeploymentConfigChangedEgressDSCP(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	networkConfig.Status.NetworkType = string(operatorv1.NetworkTypeOVNKubernetes)
+	desired := func(overrides string) *corev1.PodTemplateSpec {
+		t.Helper()
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(overrides)}
+		deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		return &deployment.Spec.Template
+	}
+	current, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+
+	for _, overrides := range []string{`{"egressDSCP":46}`, `{"egressDSCP":10}`, `{}`} {
+		expected := current.DeepCopy()
+		expected.Spec.Template = *desired(overrides)
+		changed, updated := deploymentConfigChanged(current, expected)
+		if !changed {
+			t.Fatalf("%s: expected deploymentConfigChanged to detect the change", overrides)
+		}
+		if !reflect.DeepEqual(updated.Spec.Template.Spec.InitContainers, expected.Spec.Template.Spec.InitContainers) {
+			t.Errorf("%s: expected init containers %+v, got %+v", overrides, expected.Spec.Template.Spec.InitContainers, updated.Spec.Template.Spec.InitContainers)
+		}
+		if updated.Spec.Template.Annotations[requiredSCCAnnotation] != expected.Spec.Template.Annotations[requiredSCCAnnotation] {
+			t.Errorf("%s: expected %s annotation %q, got %q", overrides, requiredSCCAnnotation, expected.Spec.Template.Annotations[requiredSCCAnnotation], updated.Spec.Template.Annotations[requiredSCCAnnotation])
+		}
+		if changed, _ := deploymentConfigChanged(updated, expected); changed {
+			t.Errorf("%s: expected deploymentConfigChanged to report no change after the update", overrides)
+		}
+		current = updated
+	}
+}
+
+// Test_computeEgressDSCPSupportedCondition verifies that the
+// "EgressDSCPSupported" condition reports why the egress DSCP value is
+// ignored, that it is true only once a router pod has marked its traffic, and
+// that it does not apply without an egress DSCP value.
+func Test_computeEgressDSCPSupportedCondition(t *testing.T) {
+	ic, _, _, _, networkConfig, _, _ := getRouterDeploymentComponents(t)
+	networkConfig.Status.NetworkType = string(operatorv1.NetworkTypeOVNKubernetes)
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "router"}},
+		},
+	}
+	routerPod := func(dscp int, state corev1.ContainerState) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "router", Labels: map[string]string{"app": "router"}},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{desiredEgressDSCPInitContainer("router-image", dscp)},
+			},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: egressDSCPInitContainerName, State: state}},
+			},
+		}
+	}
+	completed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	failed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "neither iptables nor ip6tables is available"}}
+
+	if _, ok := computeEgressDSCPSupportedCondition(ic, networkConfig, deployment, nil); ok {
+		t.Error("expected the condition not to apply without an egress DSCP value")
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"egressDSCP":46}`)}
+	testCases := []struct {
+		name            string
+		pods            []corev1.Pod
+		expectStatus    operatorv1.ConditionStatus
+		expectReason    string
+		expectInMessage string
+	}{
+		{
+			name:         "no router pods",
+			expectStatus: operatorv1.ConditionUnknown,
+			expectReason: "Pending",
+		},
+		{
+			name:         "init container still running",
+			pods:         []corev1.Pod{routerPod(46, corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})},
+			expectStatus: operatorv1.ConditionUnknown,
+			expectReason: "Pending",
+		},
+		{
+			name:         "only a pod with a previous DSCP value completed",
+			pods:         []corev1.Pod{routerPod(10, completed)},
+			expectStatus: operatorv1.ConditionUnknown,
+			expectReason: "Pending",
+		},
+		{
+			name:            "init container failed",
+			pods:            []corev1.Pod{routerPod(46, failed)},
+			expectStatus:    operatorv1.ConditionFalse,
+			expectReason:    "MarkingFailed",
+			expectInMessage: "neither iptables nor ip6tables",
+		},
+		{
+			name:         "init container completed",
+			pods:         []corev1.Pod{routerPod(46, completed)},
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "Supported",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition, ok := computeEgressDSCPSupportedCondition(ic, networkConfig, deployment, tc.pods)
+			if !ok || condition.Status != tc.expectStatus || condition.Reason != tc.expectReason || !strings.Contains(condition.Message, tc.expectInMessage) {
+				t.Errorf("expected status %s with reason %s and message containing %q, got %+v", tc.expectStatus, tc.expectReason, tc.expectInMessage, condition)
+			}
+		})
+	}
+
+	ic.Status.EndpointPublishingStrategy.Type = operatorv1.HostNetworkStrategyType
+	condition, ok := computeEgressDSCPSupportedCondition(ic, networkConfig, deployment, nil)
+	if !ok || condition.Status != operatorv1.ConditionFalse || condition.Reason != "Unsupported" || !strings.Contains(condition.Message, "HostNetwork") {
+		t.Errorf("expected status False with reason Unsupported for host network, got %+v", condition)
+	}
+
+	ic.Status.EndpointPublishingStrategy.Type = operatorv1.PrivateStrategyType
+	networkConfig.Status.NetworkType = "Calico"
+	condition, ok = computeEgressDSCPSupportedCondition(ic, networkConfig, deployment, nil)
+	if !ok || condition.Status != operatorv1.ConditionFalse || !strings.Contains(condition.Message, "Calico") {
+		t.Errorf("expected status False with reason Unsupported for an unsupported network type, got %+v", condition)
+	}
+}
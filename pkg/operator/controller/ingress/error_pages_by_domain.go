@@ -0,0 +1,175 @@
+package ingress
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// RouterErrorFile503ByDomainEnvName is the router environment variable
+	// that specifies the path of a map file with one line for each domain
+	// that has its own error page.  Each line has a domain and the path of
+	// the domain's HTTP 503 error page, separated by a space.  For
+	// requests that it cannot route or whose route has no available
+	// endpoints, the router serves the page of the longest domain that
+	// matches the request's host, and otherwise the page that
+	// ROUTER_ERRORFILE_503 specifies.  The router watches the map file and
+	// the pages and reloads HAProxy when they change, so that changes to
+	// the pages take effect without restarting the router.
+	RouterErrorFile503ByDomainEnvName = "ROUTER_ERRORFILE_503_BY_DOMAIN"
+
+	// HTTPErrorCodePagesMountPath is the path at which the operator-managed
+	// error-page configmap is mounted in the router container.
+	HTTPErrorCodePagesMountPath = "/var/lib/haproxy/conf/error_code_pages"
+	// HTTPErrorCodePagesByDomainMapKey is the key of the map file for
+	// RouterErrorFile503ByDomainEnvName in the operator-managed error-page
+	// configmap.
+	HTTPErrorCodePagesByDomainMapKey = "error-pages-by-domain.map"
+
+	// MaxHTTPErrorCodePageBytes is the maximum size of a domain's error
+	// page.  HAProxy requires error pages to fit into a buffer, and the
+	// router reserves part of each buffer for rewriting headers.
+	MaxHTTPErrorCodePageBytes = 16384
+	// maxHTTPErrorCodePagesByDomain is the maximum number of domains that
+	// an ingresscontroller may specify error pages for.
+	maxHTTPErrorCodePagesByDomain = 32
+)
+
+// httpErrorCodePageContentTypes are the media types that a domain's error page
+// may specify in its Content-Type header.
+var httpErrorCodePageContentTypes = map[string]struct{}{
+	"text/html":  {},
+	"text/plain": {},
+}
+
+// HTTPErrorCodePagesByDomainForIngressController returns the keys of the
+// HTTP 503 error pages that the given ingresscontroller specifies for
+// individual domains using the "httpErrorCodePagesByDomain" unsupported config
+// override, indexed by domain, or nil if it specifies none.  The keys refer to
+// the configmap that spec.httpErrorCodePages specifies.  An empty key means
+// that the router serves the operator's default page for the domain instead of
+// the ingresscontroller's custom page.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func HTTPErrorCodePagesByDomainForIngressController(ic *operatorv1.IngressController) (map[string]string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.HTTPErrorCodePagesByDomain, nil
+}
+
+// validateHTTPErrorCodePagesByDomain validates the given ingresscontroller's
+// error pages for individual domains, if it specifies any.
+func validateHTTPErrorCodePagesByDomain(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	pages := overrides.HTTPErrorCodePagesByDomain
+	if len(pages) == 0 {
+		return nil
+	}
+	const field = "spec.unsupportedConfigOverrides.httpErrorCodePagesByDomain"
+	var errs []error
+	if len(ic.Spec.HttpErrorCodePages.Name) == 0 {
+		errs = append(errs, fmt.Errorf("%s requires spec.httpErrorCodePages.name to be set", field))
+	}
+	if len(pages) > maxHTTPErrorCodePagesByDomain {
+		errs = append(errs, fmt.Errorf("%s must not have more than %d domains: %d", field, maxHTTPErrorCodePagesByDomain, len(pages)))
+	}
+	for _, domain := range sortedHTTPErrorCodePageDomains(pages) {
+		for _, msg := range validation.IsDNS1123Subdomain(domain) {
+			errs = append(errs, fmt.Errorf("%s has invalid domain %q: %s", field, domain, msg))
+		}
+		if key := pages[domain]; len(key) != 0 {
+			for _, msg := range validation.IsConfigMapKey(key) {
+				errs = append(errs, fmt.Errorf("%s has invalid configmap key %q for domain %q: %s", field, key, domain, msg))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// ValidateHTTPErrorCodePage returns an error if the given error page is not a
+// complete HTTP 503 response of at most MaxHTTPErrorCodePageBytes with a
+// Content-Type header that specifies HTML or plain text.  HAProxy sends error
+// pages verbatim, so a page without a valid status line or headers breaks the
+// responses for its domain.
+func ValidateHTTPErrorCodePage(page string) error {
+	if len(page) > MaxHTTPErrorCodePageBytes {
+		return fmt.Errorf("error page has %d bytes, which exceeds the maximum of %d", len(page), MaxHTTPErrorCodePageBytes)
+	}
+	response, err := http.ReadResponse(bufio.NewReader(strings.NewReader(page)), nil)
+	if err != nil {
+		return fmt.Errorf("error page is not a valid HTTP response: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusServiceUnavailable {
+		return fmt.Errorf("error page has status code %d; must be %d", response.StatusCode, http.StatusServiceUnavailable)
+	}
+	contentType := response.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		return fmt.Errorf("error page must have a Content-Type header")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("error page has invalid Content-Type header %q: %w", contentType, err)
+	}
+	if _, ok := httpErrorCodePageContentTypes[mediaType]; !ok {
+		return fmt.Errorf("error page has unsupported content type %q; must be \"text/html\" or \"text/plain\"", mediaType)
+	}
+	return nil
+}
+
+// HTTPErrorCodePageKeyForDomain returns the key of the given domain's error
+// page in the operator-managed error-page configmap.
+func HTTPErrorCodePageKeyForDomain(domain string) string {
+	return "error-page-503." + domain + ".http"
+}
+
+// HTTPErrorCodePagesByDomainMap returns the contents of the map file for
+// RouterErrorFile503ByDomainEnvName for the given domains, which must have
+// pages in the operator-managed error-page configmap.
+func HTTPErrorCodePagesByDomainMap(domains []string) string {
+	sorted := append([]string(nil), domains...)
+	sort.Strings(sorted)
+	var b strings.Builder
+	for _, domain := range sorted {
+		fmt.Fprintf(&b, "%s %s\n", domain, path.Join(HTTPErrorCodePagesMountPath, HTTPErrorCodePageKeyForDomain(domain)))
+	}
+	return b.String()
+}
+
+// httpErrorCodePagesByDomainEnv returns the router environment variables for
+// the given ingresscontroller's error pages for individual domains.
+func httpErrorCodePagesByDomainEnv(ic *operatorv1.IngressController) ([]corev1.EnvVar, error) {
+	if len(ic.Spec.HttpErrorCodePages.Name) == 0 {
+		return nil, nil
+	}
+	pages, err := HTTPErrorCodePagesByDomainForIngressController(ic)
+	if err != nil || len(pages) == 0 {
+		return nil, err
+	}
+	return []corev1.EnvVar{{
+		Name:  RouterErrorFile503ByDomainEnvName,
+		Value: path.Join(HTTPErrorCodePagesMountPath, HTTPErrorCodePagesByDomainMapKey),
+	}}, nil
+}
+
+// sortedHTTPErrorCodePageDomains returns the domains of the given error pages
+// in lexicographic order.
+func sortedHTTPErrorCodePageDomains(pages map[string]string) []string {
+	domains := make([]string, 0, len(pages))
+	for domain := range pages {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
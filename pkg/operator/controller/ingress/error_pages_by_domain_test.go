@@ -0,0 +1,144 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateHTTPErrorCodePagesByDomain verifies that
+// validateHTTPErrorCodePagesByDomain rejects invalid domains and configmap
+// keys, too many domains, and error pages for domains without a custom
+// error-page configmap.
+func Test_validateHTTPErrorCodePagesByDomain(t *testing.T) {
+	tooMany := make([]string, 0, maxHTTPErrorCodePagesByDomain+1)
+	for i := 0; i <= maxHTTPErrorCodePagesByDomain; i++ {
+		tooMany = append(tooMany, `"d`+strings.Repeat("x", i)+`.example.com":""`)
+	}
+	testCases := []struct {
+		name        string
+		configMap   string
+		overrides   string
+		expectError bool
+	}{
+		{name: "no overrides", configMap: "pages"},
+		{name: "valid", configMap: "pages", overrides: `{"httpErrorCodePagesByDomain":{"a.example.com":"error-page-503-a.http","b.example.com":""}}`},
+		{name: "no configmap", overrides: `{"httpErrorCodePagesByDomain":{"a.example.com":"error-page-503-a.http"}}`, expectError: true},
+		{name: "invalid domain", configMap: "pages", overrides: `{"httpErrorCodePagesByDomain":{"A_B.example.com":"error-page-503-a.http"}}`, expectError: true},
+		{name: "wildcard domain", configMap: "pages", overrides: `{"httpErrorCodePagesByDomain":{"*.example.com":"error-page-503-a.http"}}`, expectError: true},
+		{name: "invalid key", configMap: "pages", overrides: `{"httpErrorCodePagesByDomain":{"a.example.com":"pages/a"}}`, expectError: true},
+		{name: "too many domains", configMap: "pages", overrides: `{"httpErrorCodePagesByDomain":{` + strings.Join(tooMany, ",") + `}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					HttpErrorCodePages:         configv1.ConfigMapNameReference{Name: tc.configMap},
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			switch err := validateHTTPErrorCodePagesByDomain(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_ValidateHTTPErrorCodePage verifies that ValidateHTTPErrorCodePage
+// accepts complete HTTP 503 responses with HTML or plain text and rejects
+// pages that are too large, are not HTTP responses, have another status code,
+// or have no or another content type.
+func Test_ValidateHTTPErrorCodePage(t *testing.T) {
+	page := func(statusLine, contentType, body string) string {
+		headers := statusLine + "\r\nConnection: close\r\n"
+		if len(contentType) != 0 {
+			headers += "Content-Type: " + contentType + "\r\n"
+		}
+		return headers + "\r\n" + body
+	}
+	testCases := []struct {
+		name        string
+		page        string
+		expectError bool
+	}{
+		{name: "HTML", page: page("HTTP/1.0 503 Service Unavailable", "text/html", "<html><body>Example</body></html>")},
+		{name: "plain text with charset", page: page("HTTP/1.1 503 Service Unavailable", "text/plain; charset=utf-8", "Example")},
+		{name: "LF line endings", page: "HTTP/1.0 503 Service Unavailable\nContent-Type: text/html\n\n<html></html>"},
+		{name: "too large", page: page("HTTP/1.0 503 Service Unavailable", "text/html", strings.Repeat("x", MaxHTTPErrorCodePageBytes)), expectError: true},
+		{name: "body only", page: "<html><body>Example</body></html>", expectError: true},
+		{name: "wrong status code", page: page("HTTP/1.0 404 Not Found", "text/html", "<html></html>"), expectError: true},
+		{name: "no content type", page: page("HTTP/1.0 503 Service Unavailable", "", "<html></html>"), expectError: true},
+		{name: "unsupported content type", page: page("HTTP/1.0 503 Service Unavailable", "application/javascript", "alert(1)"), expectError: true},
+		{name: "invalid content type", page: page("HTTP/1.0 503 Service Unavailable", "text/", "<html></html>"), expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			switch err := ValidateHTTPErrorCodePage(tc.page); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_HTTPErrorCodePagesByDomainMap verifies that
+// HTTPErrorCodePagesByDomainMap lists the domains in order with the paths of
+// their pages in the router container.
+func Test_HTTPErrorCodePagesByDomainMap(t *testing.T) {
+	expected := "a.example.com /var/lib/haproxy/conf/error_code_pages/error-page-503.a.example.com.http\n" +
+		"b.example.com /var/lib/haproxy/conf/error_code_pages/error-page-503.b.example.com.http\n"
+	if actual := HTTPErrorCodePagesByDomainMap([]string{"b.example.com", "a.example.com"}); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+// Test_httpErrorCodePagesByDomainEnv verifies that the router gets the map
+// file of the error pages for individual domains only if the ingresscontroller
+// specifies a custom error-page configmap and error pages for domains.
+func Test_httpErrorCodePagesByDomainEnv(t *testing.T) {
+	overrides := `{"httpErrorCodePagesByDomain":{"a.example.com":"error-page-503-a.http"}}`
+	testCases := []struct {
+		name      string
+		configMap string
+		overrides string
+		expect    string
+	}{
+		{name: "no overrides", configMap: "pages"},
+		{name: "no configmap", overrides: overrides},
+		{name: "configmap and overrides", configMap: "pages", overrides: overrides, expect: "/var/lib/haproxy/conf/error_code_pages/error-pages-by-domain.map"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					HttpErrorCodePages:         configv1.ConfigMapNameReference{Name: tc.configMap},
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			env, err := httpErrorCodePagesByDomainEnv(ic)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := ""
+			for _, v := range env {
+				if v.Name == RouterErrorFile503ByDomainEnvName {
+					actual = v.Value
+				}
+			}
+			if actual != tc.expect {
+				t.Errorf("expected %s to be %q, got %q", RouterErrorFile503ByDomainEnvName, tc.expect, actual)
+			}
+		})
+	}
+}
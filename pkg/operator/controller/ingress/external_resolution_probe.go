@@ -0,0 +1,147 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// ExternalResolutionProbeDefaultInterval is how often the external
+	// resolution probe queries each resolver if the ingresscontroller does
+	// not specify an interval.
+	ExternalResolutionProbeDefaultInterval = 5 * time.Minute
+	// ExternalResolutionProbeMinInterval is the shortest interval that an
+	// ingresscontroller may specify so that the probe does not flood the
+	// resolvers with queries.
+	ExternalResolutionProbeMinInterval = 1 * time.Minute
+	// ExternalResolutionProbeDefaultFailureThreshold is how long all
+	// resolvers must fail before the ExternalResolutionSucceeding condition
+	// is set to false if the ingresscontroller does not specify a
+	// threshold.
+	ExternalResolutionProbeDefaultFailureThreshold = 15 * time.Minute
+	// ExternalResolutionProbeMaxResolvers is the most resolvers that an
+	// ingresscontroller may specify.
+	ExternalResolutionProbeMaxResolvers = 5
+)
+
+// ExternalResolutionProbe describes the resolvers outside the cluster against
+// which the external resolution probe checks that the ingresscontroller's
+// wildcard DNS record resolves.  An ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.externalResolutionProbe.  The probe is
+// disabled unless at least one resolver is specified.
+type ExternalResolutionProbe struct {
+	// Resolvers are the IP addresses of the resolvers to query, each
+	// optionally with a port.  The default port is 53.
+	Resolvers []string `json:"resolvers"`
+	// Interval is how often each resolver is queried, in the format of
+	// time.ParseDuration.  The default is 5m, and the minimum is 1m.
+	Interval string `json:"interval"`
+	// FailureThreshold is how long all resolvers must fail before the
+	// ExternalResolutionSucceeding condition is set to false, in the format
+	// of time.ParseDuration.  The default is 15m.
+	FailureThreshold string `json:"failureThreshold"`
+}
+
+// ExternalResolutionProbeForIngressController returns the external resolution
+// probe that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, with defaults applied, or nil if it
+// specifies none or specifies no resolvers.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func ExternalResolutionProbeForIngressController(ic *operatorv1.IngressController) (*ExternalResolutionProbe, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.externalResolutionProbe(), nil
+}
+
+// externalResolutionProbe returns the external resolution probe that the
+// overrides specify, with defaults applied, or nil if they specify none or
+// specify no resolvers.
+func (o *unsupportedConfigOverrides) externalResolutionProbe() *ExternalResolutionProbe {
+	if o.ExternalResolutionProbe == nil || len(o.ExternalResolutionProbe.Resolvers) == 0 {
+		return nil
+	}
+	probe := *o.ExternalResolutionProbe
+	if len(probe.Interval) == 0 {
+		probe.Interval = ExternalResolutionProbeDefaultInterval.String()
+	}
+	if len(probe.FailureThreshold) == 0 {
+		probe.FailureThreshold = ExternalResolutionProbeDefaultFailureThreshold.String()
+	}
+	return &probe
+}
+
+// ResolverAddresses returns the address of each of the probe's resolvers,
+// with the default port if the resolver does not specify one.  The probe must
+// be valid.
+func (p *ExternalResolutionProbe) ResolverAddresses() []string {
+	addresses := make([]string, 0, len(p.Resolvers))
+	for _, resolver := range p.Resolvers {
+		if ip := net.ParseIP(resolver); ip != nil {
+			addresses = append(addresses, net.JoinHostPort(ip.String(), "53"))
+			continue
+		}
+		addresses = append(addresses, resolver)
+	}
+	return addresses
+}
+
+// IntervalDuration returns the probe's interval.  The probe must be valid.
+func (p *ExternalResolutionProbe) IntervalDuration() time.Duration {
+	d, _ := time.ParseDuration(p.Interval)
+	return d
+}
+
+// FailureThresholdDuration returns the probe's failure threshold.  The probe
+// must be valid.
+func (p *ExternalResolutionProbe) FailureThresholdDuration() time.Duration {
+	d, _ := time.ParseDuration(p.FailureThreshold)
+	return d
+}
+
+// ValidateExternalResolutionProbe validates the given external resolution
+// probe.  Each resolver must be an IP address, optionally with a port, so that
+// the probe never depends on the cluster's own resolver, and the interval may
+// not be so short that the probe floods the resolvers with queries.
+func ValidateExternalResolutionProbe(probe *ExternalResolutionProbe) error {
+	var errs []error
+	if len(probe.Resolvers) > ExternalResolutionProbeMaxResolvers {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.resolvers may specify at most %d resolvers", ExternalResolutionProbeMaxResolvers))
+	}
+	for _, resolver := range probe.Resolvers {
+		if net.ParseIP(resolver) != nil {
+			continue
+		}
+		host, _, err := net.SplitHostPort(resolver)
+		if err != nil || net.ParseIP(host) == nil {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.resolvers has invalid resolver %q; must be an IP address, optionally with a port", resolver))
+		}
+	}
+	if d, err := time.ParseDuration(probe.Interval); err != nil {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.interval is invalid: %w", err))
+	} else if d < ExternalResolutionProbeMinInterval {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.interval %q is shorter than the minimum of %v", probe.Interval, ExternalResolutionProbeMinInterval))
+	}
+	if d, err := time.ParseDuration(probe.FailureThreshold); err != nil {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.failureThreshold is invalid: %w", err))
+	} else if d <= 0 {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.externalResolutionProbe.failureThreshold must be positive: %q", probe.FailureThreshold))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateExternalResolutionProbe validates the external resolution probe that
+// the given ingresscontroller specifies, if any.
+func validateExternalResolutionProbe(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	probe := overrides.externalResolutionProbe()
+	if probe == nil {
+		return nil
+	}
+	return ValidateExternalResolutionProbe(probe)
+}
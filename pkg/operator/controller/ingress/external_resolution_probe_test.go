@@ -0,0 +1,86 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateExternalResolutionProbe verifies that
+// validateExternalResolutionProbe accepts a probe that is disabled or that
+// specifies IP addresses of resolvers, and rejects hostnames, too many
+// resolvers, and intervals that are shorter than the minimum.
+func Test_validateExternalResolutionProbe(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "no resolvers",
+			overrides:   `{"externalResolutionProbe":{"interval":"1s"}}`,
+		},
+		{
+			description: "defaults",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["192.0.2.53"]}}`,
+		},
+		{
+			description: "IPv6 address and address with port",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["2001:db8::53","[2001:db8::54]:5353","192.0.2.53:53"],"interval":"2m","failureThreshold":"10m"}}`,
+		},
+		{
+			description: "hostname",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["dns.example.com"]}}`,
+			expectError: true,
+		},
+		{
+			description: "too many resolvers",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["192.0.2.1","192.0.2.2","192.0.2.3","192.0.2.4","192.0.2.5","192.0.2.6"]}}`,
+			expectError: true,
+		},
+		{
+			description: "interval shorter than the minimum",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["192.0.2.53"],"interval":"10s"}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid failure threshold",
+			overrides:   `{"externalResolutionProbe":{"resolvers":["192.0.2.53"],"failureThreshold":"soon"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateExternalResolutionProbe(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestExternalResolutionProbeResolverAddresses verifies that the default port
+// is added to resolvers that do not specify a port.
+func TestExternalResolutionProbeResolverAddresses(t *testing.T) {
+	probe := &ExternalResolutionProbe{
+		Resolvers: []string{"192.0.2.53", "192.0.2.54:5353", "2001:db8::53", "[2001:db8::54]:5353"},
+	}
+	expected := []string{"192.0.2.53:53", "192.0.2.54:5353", "[2001:db8::53]:53", "[2001:db8::54]:5353"}
+	if actual := probe.ResolverAddresses(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,68 @@
+package ingress
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// RouterSuppressForwardedHeaders is the router environment variable
+	// that specifies a comma-separated list of router-generated forwarded
+	// headers that the router must not add to requests.
+	RouterSuppressForwardedHeaders = "ROUTER_SUPPRESS_FORWARDED_HEADERS"
+
+	// headerNameCasePreserve is the default header name case policy, with
+	// which the router adjusts the case of header names as specified by
+	// spec.httpHeaders.headerNameCaseAdjustments and the
+	// "haproxy.router.openshift.io/h1-adjust-case" route annotation.
+	headerNameCasePreserve = "Preserve"
+	// headerNameCaseLowercase is the header name case policy with which
+	// the router does not adjust the case of any header names, so that
+	// HAProxy sends all header names in lowercase.
+	headerNameCaseLowercase = "Lowercase"
+)
+
+// suppressibleForwardedHeaders is the set of router-generated forwarded headers
+// that an ingresscontroller may suppress using
+// spec.unsupportedConfigOverrides.suppressForwardedHeaders.  The headers that
+// spec.httpHeaders.forwardedHeaderPolicy governs, such as X-Forwarded-For, are
+// not in the set; use the "Never" policy to suppress those.
+var suppressibleForwardedHeaders = sets.New[string]("X-Forwarded-Port", "X-Forwarded-Proto-Version")
+
+// validateHTTPHeaderOverrides validates the given ingresscontroller's HTTP
+// header options, if it specifies any.  Each suppressed header must be in the
+// set of suppressible headers, and the header name case policy must be valid.
+func validateHTTPHeaderOverrides(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	var errs []error
+	for _, header := range overrides.SuppressForwardedHeaders {
+		if !suppressibleForwardedHeaders.Has(http.CanonicalHeaderKey(header)) {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.suppressForwardedHeaders has unsupported header %q; supported headers: %v", header, sets.List(suppressibleForwardedHeaders)))
+		}
+	}
+	switch overrides.HeaderNameCase {
+	case "", headerNameCasePreserve, headerNameCaseLowercase:
+	default:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.headerNameCase has invalid value %q; must be %q or %q", overrides.HeaderNameCase, headerNameCasePreserve, headerNameCaseLowercase))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// suppressedForwardedHeadersValue returns the value for the
+// ROUTER_SUPPRESS_FORWARDED_HEADERS environment variable for the given
+// headers, or the empty string if no valid headers are given.  Headers that are
+// not suppressible are ignored.
+func suppressedForwardedHeadersValue(headers []string) string {
+	suppressed := sets.New[string]()
+	for _, header := range headers {
+		if canonical := http.CanonicalHeaderKey(header); suppressibleForwardedHeaders.Has(canonical) {
+			suppressed.Insert(canonical)
+		}
+	}
+	return strings.Join(sets.List(suppressed), ",")
+}
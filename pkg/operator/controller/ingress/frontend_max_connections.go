@@ -0,0 +1,103 @@
+package ingress
+
+import (
+	"fmt"
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// RouterMaxConnectionsHTTPEnvName is the router environment variable
+	// that specifies the maxconn setting of the frontend that accepts
+	// plain-text HTTP connections.
+	RouterMaxConnectionsHTTPEnvName = "ROUTER_MAX_CONNECTIONS_HTTP"
+	// RouterMaxConnectionsHTTPSEnvName is the router environment variable
+	// that specifies the maxconn setting of the frontend that accepts TLS
+	// connections.
+	RouterMaxConnectionsHTTPSEnvName = "ROUTER_MAX_CONNECTIONS_HTTPS"
+	// RouterMaxConnectionsStatsEnvName is the router environment variable
+	// that specifies the maxconn setting of the stats and metrics
+	// frontend.
+	RouterMaxConnectionsStatsEnvName = "ROUTER_MAX_CONNECTIONS_STATS"
+)
+
+// maxConnectionsPerFrontendConfig describes the per-frontend connection limits
+// that an ingresscontroller specifies using the "maxConnectionsPerFrontend"
+// unsupported config override.  Each limit caps the connections of one HAProxy
+// frontend so that a busy frontend cannot use all of the connections that
+// spec.tuningOptions.maxConnections allows the process.
+type maxConnectionsPerFrontendConfig struct {
+	// HTTP sets ROUTER_MAX_CONNECTIONS_HTTP.
+	HTTP *int `json:"http,omitempty"`
+	// HTTPS sets ROUTER_MAX_CONNECTIONS_HTTPS.
+	HTTPS *int `json:"https,omitempty"`
+	// Stats sets ROUTER_MAX_CONNECTIONS_STATS.
+	Stats *int `json:"stats,omitempty"`
+}
+
+// maxConnectionsPerFrontendConfigForIngressController returns the per-frontend
+// connection limits that the given ingresscontroller specifies using the
+// "maxConnectionsPerFrontend" unsupported config override, or nil if it
+// specifies none.  An error is returned if spec.unsupportedConfigOverrides
+// cannot be decoded.
+func maxConnectionsPerFrontendConfigForIngressController(ic *operatorv1.IngressController) (*maxConnectionsPerFrontendConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.MaxConnectionsPerFrontend, nil
+}
+
+// validateMaxConnectionsPerFrontendConfig validates the given
+// ingresscontroller's per-frontend connection limits, if it specifies any.
+// Every specified limit must be positive and must not exceed the global limit
+// that spec.tuningOptions.maxConnections specifies.  If the global limit is -1,
+// HAProxy computes it dynamically, and only the first condition is checked.
+func validateMaxConnectionsPerFrontendConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.MaxConnectionsPerFrontend
+	if config == nil {
+		return nil
+	}
+	globalLimit, globalLimitKnown := maxConnectionsPerRouter(ic)
+	var errs []error
+	for _, v := range []struct {
+		field string
+		value *int
+	}{
+		{"http", config.HTTP},
+		{"https", config.HTTPS},
+		{"stats", config.Stats},
+	} {
+		switch {
+		case v.value == nil:
+		case *v.value <= 0:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.maxConnectionsPerFrontend.%s must be positive: %d", v.field, *v.value))
+		case globalLimitKnown && *v.value > globalLimit:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.maxConnectionsPerFrontend.%s (%d) must not exceed the global connection limit (%d)", v.field, *v.value, globalLimit))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// maxConnectionsPerFrontendEnv returns the router environment variables for the
+// given per-frontend connection limits.
+func maxConnectionsPerFrontendEnv(config *maxConnectionsPerFrontendConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if config == nil {
+		return env
+	}
+	if v := config.HTTP; v != nil && *v > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterMaxConnectionsHTTPEnvName, Value: strconv.Itoa(*v)})
+	}
+	if v := config.HTTPS; v != nil && *v > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterMaxConnectionsHTTPSEnvName, Value: strconv.Itoa(*v)})
+	}
+	if v := config.Stats; v != nil && *v > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterMaxConnectionsStatsEnvName, Value: strconv.Itoa(*v)})
+	}
+	return env
+}
@@ -0,0 +1,192 @@
+package ingress
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_validateMaxConnectionsPerFrontendConfig verifies that
+// validateMaxConnectionsPerFrontendConfig accepts only positive per-frontend
+// limits that do not exceed the global limit.
+func Test_validateMaxConnectionsPerFrontendConfig(t *testing.T) {
+	testCases := []struct {
+		description    string
+		maxConnections int32
+		overrides      string
+		expectError    bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "below the default global limit",
+			overrides:   `{"maxConnectionsPerFrontend":{"http":20000,"https":30000,"stats":100}}`,
+		},
+		{
+			description: "equal to the default global limit",
+			overrides:   `{"maxConnectionsPerFrontend":{"https":50000}}`,
+		},
+		{
+			description: "above the default global limit",
+			overrides:   `{"maxConnectionsPerFrontend":{"https":50001}}`,
+			expectError: true,
+		},
+		{
+			description:    "above an explicit global limit",
+			maxConnections: 10000,
+			overrides:      `{"maxConnectionsPerFrontend":{"http":20000}}`,
+			expectError:    true,
+		},
+		{
+			description:    "dynamic global limit",
+			maxConnections: -1,
+			overrides:      `{"maxConnectionsPerFrontend":{"http":100000}}`,
+		},
+		{
+			description: "zero",
+			overrides:   `{"maxConnectionsPerFrontend":{"stats":0}}`,
+			expectError: true,
+		},
+		{
+			description:    "negative with dynamic global limit",
+			maxConnections: -1,
+			overrides:      `{"maxConnectionsPerFrontend":{"http":-1}}`,
+			expectError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			ic.Spec.TuningOptions.MaxConnections = tc.maxConnections
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateMaxConnectionsPerFrontendConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentMaxConnectionsPerFrontend verifies that
+// desiredRouterDeployment sets the per-frontend maxconn environment variables
+// from the "maxConnectionsPerFrontend" unsupported config override alongside
+// the global limit.
+func TestDesiredRouterDeploymentMaxConnectionsPerFrontend(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	expectedEnv := []envData{
+		{RouterMaxConnectionsHTTPEnvName, false, ""},
+		{RouterMaxConnectionsHTTPSEnvName, false, ""},
+		{RouterMaxConnectionsStatsEnvName, false, ""},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, expectedEnv); err != nil {
+		t.Error(err)
+	}
+
+	ic.Spec.TuningOptions.MaxConnections = 40000
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"maxConnectionsPerFrontend":{"http":10000,"https":30000}}`)}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	expectedEnv = []envData{
+		{RouterMaxConnectionsEnvName, true, "40000"},
+		{RouterMaxConnectionsHTTPEnvName, true, "10000"},
+		{RouterMaxConnectionsHTTPSEnvName, true, "30000"},
+		{RouterMaxConnectionsStatsEnvName, false, ""},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, expectedEnv); err != nil {
+		t.Error(err)
+	}
+
+	ic.Spec.TuningOptions.MaxConnections = -1
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"maxConnectionsPerFrontend":{"stats":50}}`)}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	expectedEnv = []envData{
+		{RouterMaxConnectionsEnvName, true, "auto"},
+		{RouterMaxConnectionsHTTPEnvName, false, ""},
+		{RouterMaxConnectionsHTTPSEnvName, false, ""},
+		{RouterMaxConnectionsStatsEnvName, true, "50"},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, expectedEnv); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test_admitMaxConnectionsPerFrontend verifies that admission rejects an
+// ingresscontroller whose per-frontend limit exceeds its global limit and that
+// the rejection makes the ingresscontroller degraded.
+func Test_admitMaxConnectionsPerFrontend(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "default"},
+		Spec: operatorv1.IngressControllerSpec{
+			TuningOptions: operatorv1.IngressControllerTuningOptions{MaxConnections: 20000},
+			UnsupportedConfigOverrides: runtime.RawExtension{
+				Raw: []byte(`{"maxConnectionsPerFrontend":{"https":30000}}`),
+			},
+		},
+	}
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(ic).WithObjects(ic).Build()
+	r := &reconciler{
+		config:   Config{Namespace: namespace},
+		client:   cl,
+		cache:    fakeCache{Reader: cl},
+		recorder: record.NewFakeRecorder(10),
+	}
+	ingressConfig := &configv1.Ingress{Spec: configv1.IngressSpec{Domain: "apps.example.com"}}
+	platformStatus := &configv1.PlatformStatus{Type: configv1.NonePlatformType}
+	dnsConfig := &configv1.DNS{Spec: configv1.DNSSpec{BaseDomain: "example.com"}}
+
+	err := r.admit(ic, ingressConfig, platformStatus, dnsConfig, false)
+	if _, ok := err.(*admissionRejection); !ok {
+		t.Fatalf("expected an admission rejection, got %v", err)
+	}
+
+	current := &operatorv1.IngressController{}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "default"}, current); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+	var admitted *operatorv1.OperatorCondition
+	for i := range current.Status.Conditions {
+		if current.Status.Conditions[i].Type == IngressControllerAdmittedConditionType {
+			admitted = &current.Status.Conditions[i]
+		}
+	}
+	if admitted == nil || admitted.Status != operatorv1.ConditionFalse {
+		t.Fatalf("expected Admitted=False, got %+v", admitted)
+	}
+	if !strings.Contains(admitted.Message, "maxConnectionsPerFrontend.https (30000) must not exceed the global connection limit (20000)") {
+		t.Errorf("unexpected Admitted message: %q", admitted.Message)
+	}
+	degraded, _ := computeIngressDegradedCondition(current.Status.Conditions, current.Name)
+	if degraded.Status != operatorv1.ConditionTrue {
+		t.Errorf("expected Degraded=True, got %+v", degraded)
+	}
+}
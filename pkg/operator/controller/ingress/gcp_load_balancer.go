@@ -0,0 +1,288 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// gcpNetworkTierAnnotation is the annotation used on a service to
+	// specify the network tier of the GCP load balancer's forwarding rule.
+	// Only external load balancers have a network tier.
+	//
+	// https://cloud.google.com/kubernetes-engine/docs/how-to/service-parameters#network_tier
+	gcpNetworkTierAnnotation = "cloud.google.com/network-tier"
+
+	// gcpLoadBalancerIPAddressesAnnotation is the annotation used on a
+	// service to specify the name of a reserved regional static IP address
+	// for the GCP load balancer's forwarding rule.
+	//
+	// https://cloud.google.com/kubernetes-engine/docs/concepts/service-load-balancer-parameters#spd-static-ip
+	gcpLoadBalancerIPAddressesAnnotation = "networking.gke.io/load-balancer-ip-addresses"
+
+	// gcpLBBackendTimeoutAnnotation is the annotation used on a service to
+	// specify the timeout, in seconds, of the GCP load balancer's backend
+	// service, after which the load balancer closes idle connections.
+	gcpLBBackendTimeoutAnnotation = "cloud.google.com/backend-timeout-sec"
+
+	// gcpLBTimeoutSecMin and gcpLBTimeoutSecMax are the bounds that GCP
+	// accepts for a backend service's timeout.
+	gcpLBTimeoutSecMin = 1
+	gcpLBTimeoutSecMax = 86400
+
+	// gcpNetworkTierPremium and gcpNetworkTierStandard are the network
+	// tiers that GCP supports for an external load balancer.
+	gcpNetworkTierPremium  = "Premium"
+	gcpNetworkTierStandard = "Standard"
+)
+
+// gcpAddressNameRegexp matches the name of a GCP address resource.
+var gcpAddressNameRegexp = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// gcpLoadBalancerConfig describes the GCP load balancer settings that an
+// ingresscontroller specifies using
+// spec.unsupportedConfigOverrides.gcpLoadBalancer.
+type gcpLoadBalancerConfig struct {
+	// Address is either the literal IP address or the name of a reserved
+	// regional static IP address to use for the load balancer's forwarding
+	// rule.  Empty means an ephemeral address.
+	Address string `json:"address,omitempty"`
+	// NetworkTier is the network tier of an external load balancer, either
+	// "Premium" or "Standard".  Empty means the project's default tier.
+	NetworkTier string `json:"networkTier,omitempty"`
+	// TimeoutSec is the timeout of the load balancer's backend service in
+	// seconds.  Zero means the cloud provider's default.
+	TimeoutSec int32 `json:"timeoutSec,omitempty"`
+}
+
+// addressIsIP returns a Boolean value indicating whether the configured
+// address is a literal IP address rather than the name of an address resource.
+func (c *gcpLoadBalancerConfig) addressIsIP() bool {
+	return net.ParseIP(c.Address) != nil
+}
+
+// gcpLoadBalancerConfigForIngressController returns the GCP load balancer
+// settings that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func gcpLoadBalancerConfigForIngressController(ic *operatorv1.IngressController) (*gcpLoadBalancerConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.GCPLoadBalancer, nil
+}
+
+// validateGCPLoadBalancerConfig validates the given ingresscontroller's GCP
+// load balancer settings, if it specifies any, against the given platform and
+// the load balancer's scope.  An external load balancer needs a public
+// address, an internal load balancer needs a private address, and only an
+// external load balancer has a network tier.
+func validateGCPLoadBalancerConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides, platform *configv1.PlatformStatus) error {
+	config := overrides.GCPLoadBalancer
+	if config == nil {
+		return nil
+	}
+	if platform == nil || platform.Type != configv1.GCPPlatformType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer can only be used on the %q platform", configv1.GCPPlatformType)
+	}
+	eps := ic.Spec.EndpointPublishingStrategy
+	if eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	if v := config.TimeoutSec; v != 0 && (v < gcpLBTimeoutSecMin || v > gcpLBTimeoutSecMax) {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.timeoutSec must be between %d and %d, got %d", gcpLBTimeoutSecMin, gcpLBTimeoutSecMax, v)
+	}
+	isInternal := eps != nil && eps.LoadBalancer != nil && eps.LoadBalancer.Scope == operatorv1.InternalLoadBalancer
+	switch config.NetworkTier {
+	case "", gcpNetworkTierPremium:
+	case gcpNetworkTierStandard:
+		if isInternal {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.networkTier %q cannot be used with a load balancer of scope %q", config.NetworkTier, operatorv1.InternalLoadBalancer)
+		}
+	default:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.networkTier must be %q or %q, got %q", gcpNetworkTierPremium, gcpNetworkTierStandard, config.NetworkTier)
+	}
+	if len(config.Address) == 0 {
+		return nil
+	}
+	if ip := net.ParseIP(config.Address); ip != nil {
+		if ip.To4() == nil {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.address %q must be an IPv4 address", config.Address)
+		}
+		if isInternal && !ip.IsPrivate() {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.address %q must be a private address for a load balancer of scope %q", config.Address, operatorv1.InternalLoadBalancer)
+		}
+		if !isInternal && (ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified()) {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.address %q must be a public address for a load balancer of scope %q", config.Address, operatorv1.ExternalLoadBalancer)
+		}
+		return nil
+	}
+	if !gcpAddressNameRegexp.MatchString(config.Address) {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.gcpLoadBalancer.address %q is neither an IP address nor a valid address resource name", config.Address)
+	}
+	return nil
+}
+
+// setGCPLoadBalancerServiceFields sets the spec fields and annotations on the
+// given service for the given GCP load balancer settings.  A literal IP address
+// is set in spec.loadBalancerIP, and the name of an address resource is set in
+// an annotation.  The network tier is only set for an external load balancer.
+// The cloud provider updates the backend service's timeout in place, so
+// changing it does not require recreating the load balancer.
+func setGCPLoadBalancerServiceFields(service *corev1.Service, config *gcpLoadBalancerConfig, isInternal bool) {
+	if config == nil {
+		return
+	}
+	if config.TimeoutSec != 0 {
+		service.Annotations[gcpLBBackendTimeoutAnnotation] = strconv.Itoa(int(config.TimeoutSec))
+	}
+	switch {
+	case len(config.Address) == 0:
+	case config.addressIsIP():
+		service.Spec.LoadBalancerIP = config.Address
+	default:
+		service.Annotations[gcpLoadBalancerIPAddressesAnnotation] = config.Address
+	}
+	if len(config.NetworkTier) != 0 && !isInternal {
+		service.Annotations[gcpNetworkTierAnnotation] = config.NetworkTier
+	}
+}
+
+// gcpLoadBalancerAddressEqual returns true if the two given services request
+// the same static address and false otherwise.  The operator recreates the
+// load balancer when the address changes rather than updating the service so
+// that the cloud provider does not leave a forwarding rule on the old address.
+func gcpLoadBalancerAddressEqual(current, desired *corev1.Service) bool {
+	return current.Spec.LoadBalancerIP == desired.Spec.LoadBalancerIP &&
+		current.Annotations[gcpLoadBalancerIPAddressesAnnotation] == desired.Annotations[gcpLoadBalancerIPAddressesAnnotation]
+}
+
+// gcpNetworkTierEqual returns true if the two given services request the same
+// network tier and false otherwise.  An existing forwarding rule cannot be
+// moved to another tier, so changing the tier requires recreating the load
+// balancer.  The tier is irrelevant for an internal load balancer, so it is
+// ignored if the desired service is internal.
+func gcpNetworkTierEqual(current, desired *corev1.Service) bool {
+	if IsServiceInternal(desired) {
+		return true
+	}
+	return current.Annotations[gcpNetworkTierAnnotation] == desired.Annotations[gcpNetworkTierAnnotation]
+}
+
+// gcpLoadBalancerIsProgressing returns an error value indicating whether the
+// static address or network tier of the given service differs from the one
+// that the given ingresscontroller specifies, in which case the service must be
+// deleted and recreated for the change to take effect.
+func gcpLoadBalancerIsProgressing(ic *operatorv1.IngressController, service *corev1.Service, platform *configv1.PlatformStatus) error {
+	if platform.Type != configv1.GCPPlatformType {
+		return nil
+	}
+	config, err := gcpLoadBalancerConfigForIngressController(ic)
+	if err != nil {
+		return err
+	}
+	isInternal := false
+	if lb := ic.Status.EndpointPublishingStrategy.LoadBalancer; lb != nil && lb.Scope == operatorv1.InternalLoadBalancer {
+		isInternal = true
+	}
+	desired := &corev1.Service{}
+	desired.Annotations = map[string]string{}
+	if isInternal {
+		for name, value := range InternalLBAnnotations[configv1.GCPPlatformType] {
+			desired.Annotations[name] = value
+		}
+	}
+	setGCPLoadBalancerServiceFields(desired, config, isInternal)
+
+	var changes []string
+	if !gcpLoadBalancerAddressEqual(service, desired) {
+		changes = append(changes, fmt.Sprintf("static address was changed from %q to %q", gcpServiceAddress(service), gcpServiceAddress(desired)))
+	}
+	if !gcpNetworkTierEqual(service, desired) {
+		changes = append(changes, fmt.Sprintf("network tier was changed from %q to %q", service.Annotations[gcpNetworkTierAnnotation], desired.Annotations[gcpNetworkTierAnnotation]))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("The IngressController load balancer %s.  To effectuate this change, you must delete the service: `oc -n %s delete svc/%s`; the service load-balancer will then be deprovisioned and a new one created.  Alternatively, you can revert spec.unsupportedConfigOverrides.gcpLoadBalancer on the IngressController.", strings.Join(changes, " and "), service.Namespace, service.Name)
+}
+
+// gcpServiceAddress returns the static address that the given service requests,
+// either a literal IP address or the name of an address resource, or empty if
+// it requests an ephemeral address.
+func gcpServiceAddress(service *corev1.Service) string {
+	if len(service.Spec.LoadBalancerIP) != 0 {
+		return service.Spec.LoadBalancerIP
+	}
+	return service.Annotations[gcpLoadBalancerIPAddressesAnnotation]
+}
+
+// computeGCPLoadBalancerAddressCondition computes the ingresscontroller's
+// "GCPLoadBalancerAddressReady" status condition, which reports whether the
+// cloud provider has assigned the static address or network tier that the
+// ingresscontroller specifies to the given service.  The returned Boolean
+// value is false if the ingresscontroller does not specify GCP load balancer
+// settings, in which case the condition should be removed.
+func computeGCPLoadBalancerAddressCondition(ic *operatorv1.IngressController, service *corev1.Service, operandEvents []corev1.Event, platform *configv1.PlatformStatus) (operatorv1.OperatorCondition, bool) {
+	if platform.Type != configv1.GCPPlatformType {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if eps := ic.Status.EndpointPublishingStrategy; eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return operatorv1.OperatorCondition{}, false
+	}
+	config, err := gcpLoadBalancerConfigForIngressController(ic)
+	if err != nil || config == nil || (len(config.Address) == 0 && len(config.NetworkTier) == 0) {
+		return operatorv1.OperatorCondition{}, false
+	}
+	condition := operatorv1.OperatorCondition{Type: IngressControllerGCPLoadBalancerAddressReadyConditionType}
+	if service == nil {
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = "ServiceNotFound"
+		condition.Message = "The LoadBalancer service resource is missing"
+		return condition, true
+	}
+	if event := latestLoadBalancerSyncFailure(service, operandEvents); event != nil {
+		message := strings.ToLower(event.Message)
+		switch {
+		case strings.Contains(message, "network tier"):
+			condition.Status = operatorv1.ConditionFalse
+			condition.Reason = "NetworkTierMismatch"
+			condition.Message = fmt.Sprintf("The load balancer's network tier does not match the tier of its static address: %s", event.Message)
+			return condition, true
+		case len(config.Address) != 0 && (strings.Contains(message, strings.ToLower(config.Address)) || strings.Contains(message, "static ip") || strings.Contains(message, "requested ip")):
+			condition.Status = operatorv1.ConditionFalse
+			condition.Reason = "AddressUnavailable"
+			condition.Message = fmt.Sprintf("The static address %q is unavailable to the load balancer: %s", config.Address, event.Message)
+			return condition, true
+		}
+	}
+	if !isProvisioned(service) {
+		condition.Status = operatorv1.ConditionUnknown
+		condition.Reason = "LoadBalancerPending"
+		condition.Message = "The LoadBalancer service is pending"
+		return condition, true
+	}
+	if len(config.Address) != 0 && config.addressIsIP() {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.IP != config.Address {
+				condition.Status = operatorv1.ConditionFalse
+				condition.Reason = "AddressNotAssigned"
+				condition.Message = fmt.Sprintf("The load balancer has address %q rather than the static address %q", ingress.IP, config.Address)
+				return condition, true
+			}
+		}
+	}
+	condition.Status = operatorv1.ConditionTrue
+	condition.Reason = "AddressAssigned"
+	condition.Message = "The load balancer is using the static address and network tier that the IngressController specifies"
+	return condition, true
+}
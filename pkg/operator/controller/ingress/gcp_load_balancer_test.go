@@ -0,0 +1,648 @@
+package ingress
+
+import (
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test_desiredLoadBalancerServiceGCP verifies that desiredLoadBalancerService
+// renders the GCP static address as spec.loadBalancerIP or the address name
+// annotation and sets the network tier annotation only for external load
+// balancers on GCP.
+func Test_desiredLoadBalancerServiceGCP(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		platform              configv1.PlatformType
+		overrides             string
+		scope                 operatorv1.LoadBalancerScope
+		expectLoadBalancerIP  string
+		expectedAnnotations   map[string]string
+		unexpectedAnnotations []string
+		expectError           bool
+	}{
+		{
+			name:                  "no overrides, external",
+			platform:              configv1.GCPPlatformType,
+			scope:                 operatorv1.ExternalLoadBalancer,
+			unexpectedAnnotations: []string{gcpLoadBalancerIPAddressesAnnotation, gcpNetworkTierAnnotation, gcpLBBackendTimeoutAnnotation},
+		},
+		{
+			name:                  "literal IP, external",
+			platform:              configv1.GCPPlatformType,
+			overrides:             `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			scope:                 operatorv1.ExternalLoadBalancer,
+			expectLoadBalancerIP:  "203.0.113.10",
+			unexpectedAnnotations: []string{gcpLoadBalancerIPAddressesAnnotation, gcpNetworkTierAnnotation},
+		},
+		{
+			name:      "address name and standard tier, external",
+			platform:  configv1.GCPPlatformType,
+			overrides: `{"gcpLoadBalancer":{"address":"ingress-vip","networkTier":"Standard"}}`,
+			scope:     operatorv1.ExternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				gcpLoadBalancerIPAddressesAnnotation: "ingress-vip",
+				gcpNetworkTierAnnotation:             "Standard",
+			},
+		},
+		{
+			name:      "literal IP and premium tier, external",
+			platform:  configv1.GCPPlatformType,
+			overrides: `{"gcpLoadBalancer":{"address":"203.0.113.10","networkTier":"Premium"}}`,
+			scope:     operatorv1.ExternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				gcpNetworkTierAnnotation: "Premium",
+			},
+			expectLoadBalancerIP:  "203.0.113.10",
+			unexpectedAnnotations: []string{gcpLoadBalancerIPAddressesAnnotation},
+		},
+		{
+			name:                 "literal IP, internal",
+			platform:             configv1.GCPPlatformType,
+			overrides:            `{"gcpLoadBalancer":{"address":"10.0.0.10"}}`,
+			scope:                operatorv1.InternalLoadBalancer,
+			expectLoadBalancerIP: "10.0.0.10",
+			expectedAnnotations: map[string]string{
+				gcpLBTypeAnnotation: "Internal",
+			},
+			unexpectedAnnotations: []string{gcpLoadBalancerIPAddressesAnnotation, gcpNetworkTierAnnotation},
+		},
+		{
+			name:      "address name and tier, internal",
+			platform:  configv1.GCPPlatformType,
+			overrides: `{"gcpLoadBalancer":{"address":"ingress-ilb","networkTier":"Premium"}}`,
+			scope:     operatorv1.InternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				gcpLBTypeAnnotation:                  "Internal",
+				gcpLoadBalancerIPAddressesAnnotation: "ingress-ilb",
+			},
+			unexpectedAnnotations: []string{gcpNetworkTierAnnotation},
+		},
+		{
+			name:      "backend timeout, external",
+			platform:  configv1.GCPPlatformType,
+			overrides: `{"gcpLoadBalancer":{"timeoutSec":600}}`,
+			scope:     operatorv1.ExternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				gcpLBBackendTimeoutAnnotation: "600",
+			},
+		},
+		{
+			name:      "backend timeout, internal",
+			platform:  configv1.GCPPlatformType,
+			overrides: `{"gcpLoadBalancer":{"timeoutSec":3600}}`,
+			scope:     operatorv1.InternalLoadBalancer,
+			expectedAnnotations: map[string]string{
+				gcpLBTypeAnnotation:           "Internal",
+				gcpLBBackendTimeoutAnnotation: "3600",
+			},
+		},
+		{
+			name:                  "other platform",
+			platform:              configv1.AzurePlatformType,
+			overrides:             `{"gcpLoadBalancer":{"address":"203.0.113.10","networkTier":"Standard"}}`,
+			scope:                 operatorv1.ExternalLoadBalancer,
+			unexpectedAnnotations: []string{gcpLoadBalancerIPAddressesAnnotation, gcpNetworkTierAnnotation},
+		},
+		{
+			name:        "malformed overrides",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":`,
+			scope:       operatorv1.ExternalLoadBalancer,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+						LoadBalancer: &operatorv1.LoadBalancerStrategy{
+							Scope: tc.scope,
+						},
+					},
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: tc.platform}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.expectError:
+				return
+			}
+			if svc.Spec.LoadBalancerIP != tc.expectLoadBalancerIP {
+				t.Errorf("expected spec.loadBalancerIP %q, got %q", tc.expectLoadBalancerIP, svc.Spec.LoadBalancerIP)
+			}
+			for k, v := range tc.expectedAnnotations {
+				if actual, ok := svc.Annotations[k]; !ok {
+					t.Errorf("missing expected annotation %s=%s", k, v)
+				} else if actual != v {
+					t.Errorf("expected annotation %s=%s, found %s=%s", k, v, k, actual)
+				}
+			}
+			for _, k := range tc.unexpectedAnnotations {
+				if v, ok := svc.Annotations[k]; ok {
+					t.Errorf("unexpected annotation %s=%s", k, v)
+				}
+			}
+		})
+	}
+}
+
+// Test_validateGCPLoadBalancerConfig verifies that
+// validateGCPLoadBalancerConfig rejects GCP load balancer settings on other
+// platforms, with other endpoint publishing strategies, with unknown network
+// tiers, and with addresses that do not match the load balancer's scope.
+func Test_validateGCPLoadBalancerConfig(t *testing.T) {
+	scope := func(scope operatorv1.LoadBalancerScope) *operatorv1.EndpointPublishingStrategy {
+		return &operatorv1.EndpointPublishingStrategy{
+			Type:         operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{Scope: scope},
+		}
+	}
+	testCases := []struct {
+		description string
+		platform    configv1.PlatformType
+		overrides   string
+		eps         *operatorv1.EndpointPublishingStrategy
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+			platform:    configv1.AWSPlatformType,
+		},
+		{
+			description: "other platform",
+			platform:    configv1.AWSPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"Premium"}}`,
+			expectError: true,
+		},
+		{
+			description: "HostNetwork",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"Premium"}}`,
+			eps:         &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType},
+			expectError: true,
+		},
+		{
+			description: "minimum backend timeout",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"timeoutSec":1}}`,
+		},
+		{
+			description: "maximum backend timeout",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"timeoutSec":86400}}`,
+		},
+		{
+			description: "negative backend timeout",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"timeoutSec":-1}}`,
+			expectError: true,
+		},
+		{
+			description: "backend timeout too long",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"timeoutSec":86401}}`,
+			expectError: true,
+		},
+		{
+			description: "standard tier with default scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"Standard"}}`,
+		},
+		{
+			description: "standard tier with internal scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"Standard"}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "premium tier with internal scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"Premium"}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+		},
+		{
+			description: "unknown tier",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"networkTier":"standard"}}`,
+			expectError: true,
+		},
+		{
+			description: "public IP with external scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			eps:         scope(operatorv1.ExternalLoadBalancer),
+		},
+		{
+			description: "private IP with external scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"10.0.0.10"}}`,
+			eps:         scope(operatorv1.ExternalLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "private IP with internal scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"10.0.0.10"}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+		},
+		{
+			description: "public IP with internal scope",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			eps:         scope(operatorv1.InternalLoadBalancer),
+			expectError: true,
+		},
+		{
+			description: "IPv6 address",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"2001:db8::10"}}`,
+			expectError: true,
+		},
+		{
+			description: "address name",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"ingress-vip"}}`,
+		},
+		{
+			description: "invalid address name",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"Ingress_VIP"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: tc.eps,
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: tc.platform}
+			switch err := validateGCPLoadBalancerConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic), platform); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_shouldRecreateLoadBalancerGCP verifies that shouldRecreateLoadBalancer
+// recreates a GCP load balancer when its static address or its network tier
+// changes, ignoring the network tier of an internal load balancer.
+func Test_shouldRecreateLoadBalancerGCP(t *testing.T) {
+	service := func(ip string, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec:       corev1.ServiceSpec{LoadBalancerIP: ip},
+		}
+	}
+	testCases := []struct {
+		description    string
+		current        *corev1.Service
+		desired        *corev1.Service
+		expectRecreate bool
+		expectReason   string
+	}{
+		{
+			description: "no change",
+			current:     service("203.0.113.10", map[string]string{gcpNetworkTierAnnotation: "Standard"}),
+			desired:     service("203.0.113.10", map[string]string{gcpNetworkTierAnnotation: "Standard"}),
+		},
+		{
+			description:    "ephemeral to literal IP",
+			current:        service("", nil),
+			desired:        service("203.0.113.10", nil),
+			expectRecreate: true,
+			expectReason:   "its static address changed",
+		},
+		{
+			description:    "literal IP to address name",
+			current:        service("203.0.113.10", nil),
+			desired:        service("", map[string]string{gcpLoadBalancerIPAddressesAnnotation: "ingress-vip"}),
+			expectRecreate: true,
+			expectReason:   "its static address changed",
+		},
+		{
+			description:    "premium to standard tier",
+			current:        service("", map[string]string{gcpNetworkTierAnnotation: "Premium"}),
+			desired:        service("", map[string]string{gcpNetworkTierAnnotation: "Standard"}),
+			expectRecreate: true,
+			expectReason:   "its network tier changed",
+		},
+		{
+			description: "backend timeout changed",
+			current:     service("203.0.113.10", map[string]string{gcpLBBackendTimeoutAnnotation: "30"}),
+			desired:     service("203.0.113.10", map[string]string{gcpLBBackendTimeoutAnnotation: "600"}),
+		},
+		{
+			description: "tier on internal load balancer",
+			current:     service("", map[string]string{gcpLBTypeAnnotation: "Internal", gcpNetworkTierAnnotation: "Premium"}),
+			desired:     service("", map[string]string{gcpLBTypeAnnotation: "Internal"}),
+		},
+	}
+	platform := &configv1.PlatformStatus{Type: configv1.GCPPlatformType}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			recreate, reason := shouldRecreateLoadBalancer(tc.current, tc.desired, platform)
+			if recreate != tc.expectRecreate || reason != tc.expectReason {
+				t.Errorf("expected (%t, %q), got (%t, %q)", tc.expectRecreate, tc.expectReason, recreate, reason)
+			}
+		})
+	}
+}
+
+// Test_gcpGlobalAccessUpdate verifies that changing the GCP client access of
+// an internal load balancer, or switching an external load balancer with
+// global client access to internal scope, updates the service's annotations
+// in place rather than recreating the load balancer, and that the operator
+// sets the global access annotation only for internal load balancers with
+// GCP provider parameters.
+func Test_gcpGlobalAccessUpdate(t *testing.T) {
+	strategy := func(scope operatorv1.LoadBalancerScope, clientAccess operatorv1.GCPClientAccess) *operatorv1.EndpointPublishingStrategy {
+		eps := &operatorv1.EndpointPublishingStrategy{
+			Type: operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{
+				Scope: scope,
+			},
+		}
+		if len(clientAccess) != 0 {
+			eps.LoadBalancer.ProviderParameters = &operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.GCPLoadBalancerProvider,
+				GCP: &operatorv1.GCPLoadBalancerParameters{
+					ClientAccess: clientAccess,
+				},
+			}
+		}
+		return eps
+	}
+	platform := &configv1.PlatformStatus{Type: configv1.GCPPlatformType}
+	service := func(t *testing.T, eps *operatorv1.EndpointPublishingStrategy) *corev1.Service {
+		t.Helper()
+		ic := &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Status: operatorv1.IngressControllerStatus{
+				EndpointPublishingStrategy: eps,
+			},
+		}
+		_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return svc
+	}
+	testCases := []struct {
+		description          string
+		current              *operatorv1.EndpointPublishingStrategy
+		desired              *operatorv1.EndpointPublishingStrategy
+		expectChanged        bool
+		expectLBType         string
+		expectGlobalAccess   string
+		expectNoGlobalAccess bool
+	}{
+		{
+			description:          "default internal",
+			current:              strategy(operatorv1.InternalLoadBalancer, ""),
+			desired:              strategy(operatorv1.InternalLoadBalancer, ""),
+			expectLBType:         "Internal",
+			expectNoGlobalAccess: true,
+		},
+		{
+			description:        "local to global",
+			current:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPLocalAccess),
+			desired:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			expectChanged:      true,
+			expectLBType:       "Internal",
+			expectGlobalAccess: "true",
+		},
+		{
+			description:        "global to local",
+			current:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			desired:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPLocalAccess),
+			expectChanged:      true,
+			expectLBType:       "Internal",
+			expectGlobalAccess: "false",
+		},
+		{
+			description:        "default to global",
+			current:            strategy(operatorv1.InternalLoadBalancer, ""),
+			desired:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			expectChanged:      true,
+			expectLBType:       "Internal",
+			expectGlobalAccess: "true",
+		},
+		{
+			description:          "external with global",
+			current:              strategy(operatorv1.ExternalLoadBalancer, ""),
+			desired:              strategy(operatorv1.ExternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			expectNoGlobalAccess: true,
+		},
+		{
+			description:        "external to internal with global",
+			current:            strategy(operatorv1.ExternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			desired:            strategy(operatorv1.InternalLoadBalancer, operatorv1.GCPGlobalAccess),
+			expectChanged:      true,
+			expectLBType:       "Internal",
+			expectGlobalAccess: "true",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			current := service(t, tc.current)
+			desired := service(t, tc.desired)
+			if recreate, reason := shouldRecreateLoadBalancer(current, desired, platform); recreate {
+				t.Errorf("expected the load balancer not to be recreated, got recreation because %s", reason)
+			}
+			changed, updated := loadBalancerServiceChanged(current, desired)
+			if changed != tc.expectChanged {
+				t.Fatalf("expected loadBalancerServiceChanged to return %t, got %t", tc.expectChanged, changed)
+			}
+			if !changed {
+				updated = current
+			}
+			if actual := updated.Annotations[gcpLBTypeAnnotation]; actual != tc.expectLBType {
+				t.Errorf("expected annotation %s=%q, got %q", gcpLBTypeAnnotation, tc.expectLBType, actual)
+			}
+			actual, ok := updated.Annotations[GCPGlobalAccessAnnotation]
+			switch {
+			case tc.expectNoGlobalAccess && ok:
+				t.Errorf("unexpected annotation %s=%s", GCPGlobalAccessAnnotation, actual)
+			case !tc.expectNoGlobalAccess && actual != tc.expectGlobalAccess:
+				t.Errorf("expected annotation %s=%q, got %q", GCPGlobalAccessAnnotation, tc.expectGlobalAccess, actual)
+			}
+		})
+	}
+}
+
+// Test_computeGCPLoadBalancerAddressCondition verifies that
+// computeGCPLoadBalancerAddressCondition reports an unavailable static address
+// or a network tier mismatch from the cloud provider's events, and whether the
+// load balancer has the requested address.
+func Test_computeGCPLoadBalancerAddressCondition(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "router-default",
+			UID:       types.UID("1"),
+		},
+	}
+	provisioned := func(ip string) *corev1.Service {
+		svc := service.DeepCopy()
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ip}}
+		return svc
+	}
+	event := func(reason, message string, age time.Duration) corev1.Event {
+		return corev1.Event{
+			Reason:  reason,
+			Message: message,
+			Source:  corev1.EventSource{Component: "service-controller"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Service",
+				Namespace: service.Namespace,
+				Name:      service.Name,
+				UID:       service.UID,
+			},
+			LastTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		}
+	}
+	testCases := []struct {
+		description  string
+		platform     configv1.PlatformType
+		overrides    string
+		service      *corev1.Service
+		events       []corev1.Event
+		expectOK     bool
+		expectStatus operatorv1.ConditionStatus
+		expectReason string
+	}{
+		{
+			description: "no overrides",
+			platform:    configv1.GCPPlatformType,
+			service:     provisioned("203.0.113.10"),
+		},
+		{
+			description: "other platform",
+			platform:    configv1.AWSPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			service:     provisioned("203.0.113.10"),
+		},
+		{
+			description:  "address assigned",
+			platform:     configv1.GCPPlatformType,
+			overrides:    `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			service:      provisioned("203.0.113.10"),
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "AddressAssigned",
+		},
+		{
+			description:  "address not assigned yet",
+			platform:     configv1.GCPPlatformType,
+			overrides:    `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			service:      provisioned("198.51.100.20"),
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "AddressNotAssigned",
+		},
+		{
+			description:  "pending",
+			platform:     configv1.GCPPlatformType,
+			overrides:    `{"gcpLoadBalancer":{"address":"ingress-vip"}}`,
+			service:      service,
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionUnknown,
+			expectReason: "LoadBalancerPending",
+		},
+		{
+			description: "address unavailable",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			service:     service,
+			events: []corev1.Event{
+				event("SyncLoadBalancerFailed", `Error syncing load balancer: failed to ensure load balancer: requested ip "203.0.113.10" is neither static nor assigned to the LB`, time.Minute),
+			},
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "AddressUnavailable",
+		},
+		{
+			description: "network tier mismatch",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"ingress-vip","networkTier":"Standard"}}`,
+			service:     provisioned("203.0.113.10"),
+			events: []corev1.Event{
+				event("SyncLoadBalancerFailed", `Error syncing load balancer: failed to ensure load balancer: user specified IP "203.0.113.10" has network tier "PREMIUM", which does not match the desired network tier "STANDARD"`, time.Minute),
+			},
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "NetworkTierMismatch",
+		},
+		{
+			description: "failure resolved",
+			platform:    configv1.GCPPlatformType,
+			overrides:   `{"gcpLoadBalancer":{"address":"203.0.113.10"}}`,
+			service:     provisioned("203.0.113.10"),
+			events: []corev1.Event{
+				event("SyncLoadBalancerFailed", `requested ip "203.0.113.10" is neither static nor assigned to the LB`, 2*time.Minute),
+				event("EnsuredLoadBalancer", "Ensured load balancer", time.Minute),
+			},
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "AddressAssigned",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{
+						Raw: []byte(tc.overrides),
+					},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+					},
+				},
+			}
+			platform := &configv1.PlatformStatus{Type: tc.platform}
+			condition, ok := computeGCPLoadBalancerAddressCondition(ic, tc.service, tc.events, platform)
+			if ok != tc.expectOK {
+				t.Fatalf("expected ok=%t, got %t", tc.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if condition.Status != tc.expectStatus || condition.Reason != tc.expectReason {
+				t.Errorf("expected %s=%s with reason %s, got %s=%s with reason %s: %s", IngressControllerGCPLoadBalancerAddressReadyConditionType, tc.expectStatus, tc.expectReason, condition.Type, condition.Status, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package ingress
+
+import (
+	"fmt"
+	"reflect"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// RouterEnableHTTP3EnvName is the router environment variable that
+	// enables the router's HTTP/3 (QUIC) listener.
+	RouterEnableHTTP3EnvName = "ROUTER_ENABLE_HTTP3"
+	// RouterHTTP3AltSvcEnvName is the router environment variable that
+	// specifies the value of the Alt-Svc response header with which the
+	// router advertises HTTP/3 to clients that connect over TCP.
+	RouterHTTP3AltSvcEnvName = "ROUTER_HTTP3_ALT_SVC"
+
+	// HTTP3PortName is the name of the router container's UDP port for
+	// HTTP/3 and of the corresponding service ports.
+	HTTP3PortName = "http3"
+
+	// defaultHTTP3AltSvcMaxAgeSeconds is the default lifetime of the
+	// router's HTTP/3 advertisement, which is the default that RFC 7838
+	// specifies for Alt-Svc.
+	defaultHTTP3AltSvcMaxAgeSeconds = 86400
+)
+
+// http3Config describes the HTTP/3 options that an ingresscontroller specifies
+// using the "http3" unsupported config override.  Specifying the override at
+// all enables HTTP/3.
+//
+// HTTP/3 uses QUIC, which runs over UDP, so the router listens on the HTTPS
+// port for UDP as well as for TCP, and the operator adds the UDP port to the
+// ingresscontroller's NodePort or load balancer service.  Clients discover
+// HTTP/3 using the Alt-Svc header on responses that the router sends over TCP
+// and then use the same host name and port over UDP, so no DNS changes are
+// needed, but firewalls between clients and the router must allow UDP on the
+// HTTPS port.  Some load balancers cannot forward UDP; for those, the operator
+// leaves the UDP port off of the service, and the "HTTP3Supported" status
+// condition reports that clients cannot reach the router using HTTP/3.
+type http3Config struct {
+	// AltSvcMaxAgeSeconds is the number of seconds for which clients may
+	// cache the router's HTTP/3 advertisement.  The default is 86400.
+	AltSvcMaxAgeSeconds *int32 `json:"altSvcMaxAgeSeconds,omitempty"`
+}
+
+// http3ConfigForIngressController returns the HTTP/3 options that the given
+// ingresscontroller specifies using the "http3" unsupported config override,
+// or nil if HTTP/3 is not enabled.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func http3ConfigForIngressController(ic *operatorv1.IngressController) (*http3Config, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.HTTP3, nil
+}
+
+// validateHTTP3Config validates the given ingresscontroller's HTTP/3 options,
+// if it specifies any.
+func validateHTTP3Config(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.HTTP3
+	if config == nil {
+		return nil
+	}
+	if v := config.AltSvcMaxAgeSeconds; v != nil && *v <= 0 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.http3.altSvcMaxAgeSeconds must be positive: %d", *v)
+	}
+	return nil
+}
+
+// http3Enabled returns a Boolean value indicating whether the given
+// ingresscontroller enables HTTP/3.
+func http3Enabled(ic *operatorv1.IngressController) bool {
+	config, err := http3ConfigForIngressController(ic)
+	return err == nil && config != nil
+}
+
+// http3Env returns the router environment variables for the given HTTP/3
+// options.  The router advertises HTTP/3 on the given port; see
+// http3AdvertisedPort.
+func http3Env(config *http3Config, port int32) []corev1.EnvVar {
+	if config == nil {
+		return nil
+	}
+	maxAge := int32(defaultHTTP3AltSvcMaxAgeSeconds)
+	if config.AltSvcMaxAgeSeconds != nil && *config.AltSvcMaxAgeSeconds > 0 {
+		maxAge = *config.AltSvcMaxAgeSeconds
+	}
+	return []corev1.EnvVar{
+		{Name: RouterEnableHTTP3EnvName, Value: "true"},
+		{Name: RouterHTTP3AltSvcEnvName, Value: fmt.Sprintf(`h3=":%d"; ma=%d`, port, maxAge)},
+	}
+}
+
+// http3AdvertisedPort returns the port on which clients connect to the given
+// ingresscontroller's router over HTTPS, and therefore over HTTP/3.  This is
+// the HTTPS host port for the HostNetwork endpoint publishing strategy and 443
+// otherwise.
+func http3AdvertisedPort(ic *operatorv1.IngressController) int32 {
+	if eps := ic.Status.EndpointPublishingStrategy; eps != nil && eps.Type == operatorv1.HostNetworkStrategyType && eps.HostNetwork != nil && eps.HostNetwork.HTTPSPort != 0 {
+		return eps.HostNetwork.HTTPSPort
+	}
+	return int32(routerDefaultHostNetworkHTTPSPort)
+}
+
+// http3ContainerPort returns the router container's UDP port for HTTP/3, which
+// uses the same port number, and host port if any, as the given HTTPS port.
+func http3ContainerPort(httpsPort corev1.ContainerPort) corev1.ContainerPort {
+	return corev1.ContainerPort{
+		Name:          HTTP3PortName,
+		ContainerPort: httpsPort.ContainerPort,
+		HostPort:      httpsPort.HostPort,
+		Protocol:      corev1.ProtocolUDP,
+	}
+}
+
+// http3ServicePort returns the service port that forwards HTTP/3 traffic to
+// the router's UDP port.
+func http3ServicePort() corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       HTTP3PortName,
+		Protocol:   corev1.ProtocolUDP,
+		Port:       int32(443),
+		TargetPort: intstr.FromString(HTTP3PortName),
+	}
+}
+
+// http3LoadBalancerUnsupportedReason returns a description of why the given
+// ingresscontroller's load balancer cannot forward HTTP/3 traffic, or the
+// empty string if it can or if the ingresscontroller does not use a load
+// balancer.
+func http3LoadBalancerUnsupportedReason(ic *operatorv1.IngressController, platform *configv1.PlatformStatus) string {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType || platform == nil {
+		return ""
+	}
+	switch platform.Type {
+	case configv1.AWSPlatformType:
+		if getAWSLoadBalancerTypeInStatus(ic) != operatorv1.AWSNetworkLoadBalancer {
+			return "AWS Classic Load Balancers cannot forward UDP; use a Network Load Balancer for HTTP/3"
+		}
+	}
+	return ""
+}
+
+// computeHTTP3SupportedCondition computes the ingresscontroller's
+// "HTTP3Supported" status condition, which reports whether clients can reach
+// the router using HTTP/3, and a Boolean value indicating whether the
+// condition applies.  The condition applies only if the ingresscontroller
+// enables HTTP/3.
+func computeHTTP3SupportedCondition(ic *operatorv1.IngressController, platform *configv1.PlatformStatus) (operatorv1.OperatorCondition, bool) {
+	config, err := http3ConfigForIngressController(ic)
+	if err != nil || config == nil {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if reason := http3LoadBalancerUnsupportedReason(ic, platform); len(reason) != 0 {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerHTTP3SupportedConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "LoadBalancerDoesNotSupportUDP",
+			Message: fmt.Sprintf("The router listens for HTTP/3, but clients cannot reach it: %s.", reason),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerHTTP3SupportedConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "Supported",
+		Message: fmt.Sprintf("The router listens for HTTP/3 on UDP port %d and advertises it using the Alt-Svc header.  Firewalls between clients and the router must allow UDP traffic to that port.", http3AdvertisedPort(ic)),
+	}, true
+}
+
+// http3ServicePortChanged returns a Boolean value indicating whether the
+// current service's HTTP/3 port differs from the expected service's, and if
+// so, the current service's ports with the HTTP/3 port replaced by the
+// expected one, or removed if the expected service has none.  Other ports are
+// left as they are, and the current HTTP/3 port's node port is preserved.
+func http3ServicePortChanged(current, expected *corev1.Service) (bool, []corev1.ServicePort) {
+	var currentPort, expectedPort *corev1.ServicePort
+	var ports []corev1.ServicePort
+	for i := range current.Spec.Ports {
+		if current.Spec.Ports[i].Name == HTTP3PortName {
+			currentPort = &current.Spec.Ports[i]
+			continue
+		}
+		ports = append(ports, current.Spec.Ports[i])
+	}
+	for i := range expected.Spec.Ports {
+		if expected.Spec.Ports[i].Name == HTTP3PortName {
+			expectedPort = &expected.Spec.Ports[i]
+		}
+	}
+	switch {
+	case currentPort == nil && expectedPort == nil:
+		return false, nil
+	case expectedPort == nil:
+		return true, ports
+	}
+	port := *expectedPort
+	if currentPort != nil {
+		port.NodePort = currentPort.NodePort
+		if reflect.DeepEqual(*currentPort, port) {
+			return false, nil
+		}
+	}
+	return true, append(ports, port)
+}
@@ -0,0 +1,301 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Test_validateHTTP3Config verifies that validateHTTP3Config accepts only
+// positive Alt-Svc lifetimes.
+func Test_validateHTTP3Config(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "enabled with defaults",
+			overrides:   `{"http3":{}}`,
+		},
+		{
+			description: "positive lifetime",
+			overrides:   `{"http3":{"altSvcMaxAgeSeconds":3600}}`,
+		},
+		{
+			description: "zero lifetime",
+			overrides:   `{"http3":{"altSvcMaxAgeSeconds":0}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateHTTP3Config(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentHTTP3 verifies that desiredRouterDeployment adds
+// the HTTP/3 environment variables and a UDP container port on the HTTPS port
+// only if the ingresscontroller enables HTTP/3.
+func TestDesiredRouterDeploymentHTTP3(t *testing.T) {
+	testCases := []struct {
+		name       string
+		strategy   *operatorv1.EndpointPublishingStrategy
+		overrides  string
+		expectEnv  []envData
+		expectPort *corev1.ContainerPort
+	}{
+		{
+			name:     "disabled",
+			strategy: &operatorv1.EndpointPublishingStrategy{Type: operatorv1.PrivateStrategyType},
+			expectEnv: []envData{
+				{RouterEnableHTTP3EnvName, false, ""},
+				{RouterHTTP3AltSvcEnvName, false, ""},
+			},
+		},
+		{
+			name:      "Private",
+			strategy:  &operatorv1.EndpointPublishingStrategy{Type: operatorv1.PrivateStrategyType},
+			overrides: `{"http3":{}}`,
+			expectEnv: []envData{
+				{RouterEnableHTTP3EnvName, true, "true"},
+				{RouterHTTP3AltSvcEnvName, true, `h3=":443"; ma=86400`},
+			},
+			expectPort: &corev1.ContainerPort{Name: "http3", ContainerPort: 443, Protocol: corev1.ProtocolUDP},
+		},
+		{
+			name: "HostNetwork with custom ports",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:        operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{HTTPPort: 8080, HTTPSPort: 8443, StatsPort: 8936},
+			},
+			overrides: `{"http3":{"altSvcMaxAgeSeconds":3600}}`,
+			expectEnv: []envData{
+				{RouterEnableHTTP3EnvName, true, "true"},
+				{RouterHTTP3AltSvcEnvName, true, `h3=":8443"; ma=3600`},
+			},
+			expectPort: &corev1.ContainerPort{Name: "http3", ContainerPort: 8443, HostPort: 8443, Protocol: corev1.ProtocolUDP},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Status.EndpointPublishingStrategy = tc.strategy
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			var actualPort *corev1.ContainerPort
+			for i, port := range deployment.Spec.Template.Spec.Containers[0].Ports {
+				if port.Name == HTTP3PortName {
+					actualPort = &deployment.Spec.Template.Spec.Containers[0].Ports[i]
+				}
+			}
+			if !reflect.DeepEqual(actualPort, tc.expectPort) {
+				t.Errorf("expected HTTP/3 container port %+v, got %+v", tc.expectPort, actualPort)
+			}
+		})
+	}
+}
+
+// Test_desiredServicesHTTP3 verifies that desiredLoadBalancerService and
+// desiredNodePortService add a UDP port for HTTP/3 if the ingresscontroller
+// enables HTTP/3, except to a load balancer service for a load balancer that
+// cannot forward UDP, and that computeHTTP3SupportedCondition reports whether
+// clients can reach the router using HTTP/3.
+func Test_desiredServicesHTTP3(t *testing.T) {
+	aws := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+	gcp := &configv1.PlatformStatus{Type: configv1.GCPPlatformType}
+	lbStrategy := func(provider *operatorv1.ProviderLoadBalancerParameters) *operatorv1.EndpointPublishingStrategy {
+		return &operatorv1.EndpointPublishingStrategy{
+			Type: operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{
+				Scope:              operatorv1.ExternalLoadBalancer,
+				ProviderParameters: provider,
+			},
+		}
+	}
+	testCases := []struct {
+		name            string
+		strategy        *operatorv1.EndpointPublishingStrategy
+		platform        *configv1.PlatformStatus
+		enabled         bool
+		expectPort      bool
+		expectCondition operatorv1.ConditionStatus
+	}{
+		{
+			name:     "disabled",
+			strategy: lbStrategy(nil),
+			platform: gcp,
+		},
+		{
+			name:            "GCP load balancer",
+			strategy:        lbStrategy(nil),
+			platform:        gcp,
+			enabled:         true,
+			expectPort:      true,
+			expectCondition: operatorv1.ConditionTrue,
+		},
+		{
+			name: "AWS Classic Load Balancer",
+			strategy: lbStrategy(&operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSClassicLoadBalancer},
+			}),
+			platform:        aws,
+			enabled:         true,
+			expectCondition: operatorv1.ConditionFalse,
+		},
+		{
+			name: "AWS Network Load Balancer",
+			strategy: lbStrategy(&operatorv1.ProviderLoadBalancerParameters{
+				Type: operatorv1.AWSLoadBalancerProvider,
+				AWS:  &operatorv1.AWSLoadBalancerParameters{Type: operatorv1.AWSNetworkLoadBalancer},
+			}),
+			platform:        aws,
+			enabled:         true,
+			expectPort:      true,
+			expectCondition: operatorv1.ConditionTrue,
+		},
+		{
+			name:            "NodePort service on AWS",
+			strategy:        &operatorv1.EndpointPublishingStrategy{Type: operatorv1.NodePortServiceStrategyType},
+			platform:        aws,
+			enabled:         true,
+			expectPort:      true,
+			expectCondition: operatorv1.ConditionTrue,
+		},
+	}
+	expectedPort := corev1.ServicePort{Name: "http3", Protocol: corev1.ProtocolUDP, Port: 443, TargetPort: intstr.FromString("http3")}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Status:     operatorv1.IngressControllerStatus{EndpointPublishingStrategy: tc.strategy},
+			}
+			if tc.enabled {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"http3":{}}`)}
+			}
+			deploymentRef := metav1.OwnerReference{Name: "router-default"}
+			var (
+				svc *corev1.Service
+				err error
+			)
+			if tc.strategy.Type == operatorv1.NodePortServiceStrategyType {
+				_, svc, err = desiredNodePortService(ic, deploymentRef, false)
+			} else {
+				_, svc, err = desiredLoadBalancerService(ic, deploymentRef, tc.platform, false, false)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			havePort := false
+			for _, port := range svc.Spec.Ports {
+				if port.Name == HTTP3PortName {
+					havePort = true
+					if !reflect.DeepEqual(port, expectedPort) {
+						t.Errorf("expected HTTP/3 service port %+v, got %+v", expectedPort, port)
+					}
+				}
+			}
+			if havePort != tc.expectPort {
+				t.Errorf("expected HTTP/3 service port: %t, got %t", tc.expectPort, havePort)
+			}
+			condition, ok := computeHTTP3SupportedCondition(ic, tc.platform)
+			switch {
+			case !ok && len(tc.expectCondition) != 0:
+				t.Errorf("expected %s condition with status %s, got none", IngressControllerHTTP3SupportedConditionType, tc.expectCondition)
+			case ok && condition.Status != tc.expectCondition:
+				t.Errorf("expected %s condition with status %q, got %+v", IngressControllerHTTP3SupportedConditionType, tc.expectCondition, condition)
+			}
+		})
+	}
+}
+
+// Test_loadBalancerServiceChangedHTTP3 verifies that loadBalancerServiceChanged
+// adds and removes the HTTP/3 port, preserves the port's node port, and leaves
+// the other ports alone.
+func Test_loadBalancerServiceChangedHTTP3(t *testing.T) {
+	tcpPorts := []corev1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("http"), NodePort: 30080},
+		{Name: "https", Protocol: corev1.ProtocolTCP, Port: 443, TargetPort: intstr.FromString("https"), NodePort: 30443},
+	}
+	withHTTP3 := func(ports []corev1.ServicePort, nodePort int32) []corev1.ServicePort {
+		port := http3ServicePort()
+		port.NodePort = nodePort
+		return append(append([]corev1.ServicePort{}, ports...), port)
+	}
+	testCases := []struct {
+		name          string
+		current       []corev1.ServicePort
+		expected      []corev1.ServicePort
+		expectChanged bool
+		expectPorts   []corev1.ServicePort
+	}{
+		{
+			name:     "no HTTP/3 port",
+			current:  tcpPorts,
+			expected: tcpPorts,
+		},
+		{
+			name:          "add HTTP/3 port",
+			current:       tcpPorts,
+			expected:      withHTTP3(nil, 0),
+			expectChanged: true,
+			expectPorts:   withHTTP3(tcpPorts, 0),
+		},
+		{
+			name:     "HTTP/3 port with allocated node port",
+			current:  withHTTP3(tcpPorts, 31443),
+			expected: withHTTP3(tcpPorts, 0),
+		},
+		{
+			name:          "remove HTTP/3 port",
+			current:       withHTTP3(tcpPorts, 31443),
+			expected:      tcpPorts,
+			expectChanged: true,
+			expectPorts:   tcpPorts,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := &corev1.Service{Spec: corev1.ServiceSpec{Ports: tc.current}}
+			expected := &corev1.Service{Spec: corev1.ServiceSpec{Ports: tc.expected}}
+			changed, updated := loadBalancerServiceChanged(current, expected)
+			if changed != tc.expectChanged {
+				t.Fatalf("expected changed to be %t, got %t", tc.expectChanged, changed)
+			}
+			if changed && !reflect.DeepEqual(updated.Spec.Ports, tc.expectPorts) {
+				t.Errorf("expected ports %+v, got %+v", tc.expectPorts, updated.Spec.Ports)
+			}
+		})
+	}
+}
@@ -80,6 +80,11 @@ func desiredInternalIngressControllerService(ic *operatorv1.IngressController, d
 		ServingCertSecretAnnotation: fmt.Sprintf("router-metrics-certs-%s", ic.Name),
 	}
 
+	// The deployment reconciliation reports any error decoding the
+	// overrides, so it can be ignored here.
+	propagated, _ := propagatedMetadataForIngressController(ic)
+	applyPropagatedMetadata(&s.ObjectMeta, propagated)
+
 	s.Spec.Selector = controller.IngressControllerDeploymentPodSelector(ic).MatchLabels
 
 	s.SetOwnerReferences([]metav1.OwnerReference{deploymentRef})
@@ -144,12 +149,17 @@ func internalServiceChanged(current, expected *corev1.Service) (bool, *corev1.Se
 		changed = true
 	}
 
+	if propagatedMetadataChanged(&current.ObjectMeta, &expected.ObjectMeta) {
+		changed = true
+	}
+
 	if !changed {
 		return false, nil
 	}
 
 	updated := current.DeepCopy()
 	updated.Spec = expected.Spec
+	copyPropagatedMetadata(&updated.ObjectMeta, &current.ObjectMeta, &expected.ObjectMeta)
 
 	if updated.Annotations == nil {
 		updated.Annotations = map[string]string{}
@@ -0,0 +1,104 @@
+package ingress
+
+import (
+	"net"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// IngressControllerLoadBalancerAddressConditionType is the type of the
+	// ingresscontroller status condition that reports the addresses that
+	// the cloud provider assigned to the ingresscontroller's load balancer,
+	// which the ingresscontroller only has with the LoadBalancerService
+	// endpoint publishing strategy.  The status is true if the load
+	// balancer has at least one address, in which case the message lists
+	// the addresses.  Use LoadBalancerIngressForIngressController to read
+	// them.
+	//
+	// TODO: Replace the condition with a status field in the
+	// LoadBalancerStrategy type in openshift/api.
+	IngressControllerLoadBalancerAddressConditionType = "LoadBalancerAddress"
+
+	// loadBalancerAddressMessagePrefix is the prefix of the message of the
+	// LoadBalancerAddress status condition, which is followed by a
+	// comma-separated list of the load balancer's addresses.
+	loadBalancerAddressMessagePrefix = "The load balancer has the following addresses: "
+)
+
+// LoadBalancerAddressCondition returns the LoadBalancerAddress status
+// condition for a load balancer with the given ingress points.  An ingress
+// point is reported by its IP address if it has one and by its hostname
+// otherwise.
+func LoadBalancerAddressCondition(ingresses []corev1.LoadBalancerIngress) operatorv1.OperatorCondition {
+	var addresses []string
+	for _, ingress := range ingresses {
+		address := ingress.IP
+		if len(address) == 0 {
+			address = ingress.Hostname
+		}
+		if len(address) != 0 {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerLoadBalancerAddressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "AddressPending",
+			Message: "The load balancer has not been assigned an address.",
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerLoadBalancerAddressConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "AddressAssigned",
+		Message: loadBalancerAddressMessagePrefix + strings.Join(addresses, ", "),
+	}
+}
+
+// computeLoadBalancerAddressCondition returns the LoadBalancerAddress status
+// condition for the given ingresscontroller and its load balancer service,
+// which may be nil if the service does not exist.  The returned Boolean value
+// is false if the ingresscontroller does not use the LoadBalancerService
+// endpoint publishing strategy, in which case the condition must be removed.
+func computeLoadBalancerAddressCondition(ic *operatorv1.IngressController, service *corev1.Service) (operatorv1.OperatorCondition, bool) {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if service == nil {
+		return LoadBalancerAddressCondition(nil), true
+	}
+	return LoadBalancerAddressCondition(service.Status.LoadBalancer.Ingress), true
+}
+
+// LoadBalancerIngressForIngressController returns the ingress points of the
+// given ingresscontroller's load balancer as reported by its
+// LoadBalancerAddress status condition, or nil if the ingresscontroller has no
+// load balancer or the load balancer has not been assigned an address.
+func LoadBalancerIngressForIngressController(ic *operatorv1.IngressController) []corev1.LoadBalancerIngress {
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type != IngressControllerLoadBalancerAddressConditionType || cond.Status != operatorv1.ConditionTrue {
+			continue
+		}
+		if !strings.HasPrefix(cond.Message, loadBalancerAddressMessagePrefix) {
+			return nil
+		}
+		var ingresses []corev1.LoadBalancerIngress
+		for _, address := range strings.Split(strings.TrimPrefix(cond.Message, loadBalancerAddressMessagePrefix), ", ") {
+			switch {
+			case len(address) == 0:
+			case net.ParseIP(address) != nil:
+				ingresses = append(ingresses, corev1.LoadBalancerIngress{IP: address})
+			default:
+				ingresses = append(ingresses, corev1.LoadBalancerIngress{Hostname: address})
+			}
+		}
+		return ingresses
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Test_computeLoadBalancerAddressCondition verifies that
+// computeLoadBalancerAddressCondition reports the addresses of the load
+// balancer service only with the LoadBalancerService endpoint publishing
+// strategy, and that LoadBalancerIngressForIngressController reads the
+// addresses back from the condition.
+func Test_computeLoadBalancerAddressCondition(t *testing.T) {
+	service := func(ingresses ...corev1.LoadBalancerIngress) *corev1.Service {
+		return &corev1.Service{
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingresses},
+			},
+		}
+	}
+	testCases := []struct {
+		name            string
+		strategy        operatorv1.EndpointPublishingStrategyType
+		service         *corev1.Service
+		expectOK        bool
+		expectStatus    operatorv1.ConditionStatus
+		expectReason    string
+		expectIngresses []corev1.LoadBalancerIngress
+	}{
+		{
+			name:     "node port",
+			strategy: operatorv1.NodePortServiceStrategyType,
+			service:  service(corev1.LoadBalancerIngress{IP: "203.0.113.10"}),
+		},
+		{
+			name:     "host network",
+			strategy: operatorv1.HostNetworkStrategyType,
+		},
+		{
+			name:         "service not found",
+			strategy:     operatorv1.LoadBalancerServiceStrategyType,
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "AddressPending",
+		},
+		{
+			name:         "pending",
+			strategy:     operatorv1.LoadBalancerServiceStrategyType,
+			service:      service(),
+			expectOK:     true,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "AddressPending",
+		},
+		{
+			name:            "IP address",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			service:         service(corev1.LoadBalancerIngress{IP: "203.0.113.10"}),
+			expectOK:        true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "AddressAssigned",
+			expectIngresses: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}},
+		},
+		{
+			name:            "hostname",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			service:         service(corev1.LoadBalancerIngress{Hostname: "lb.example.com"}),
+			expectOK:        true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "AddressAssigned",
+			expectIngresses: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+		},
+		{
+			name:     "dual-stack",
+			strategy: operatorv1.LoadBalancerServiceStrategyType,
+			service: service(
+				corev1.LoadBalancerIngress{IP: "203.0.113.10"},
+				corev1.LoadBalancerIngress{IP: "2001:db8::10"},
+			),
+			expectOK:        true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "AddressAssigned",
+			expectIngresses: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}, {IP: "2001:db8::10"}},
+		},
+		{
+			name:            "IP address and hostname",
+			strategy:        operatorv1.LoadBalancerServiceStrategyType,
+			service:         service(corev1.LoadBalancerIngress{IP: "203.0.113.10", Hostname: "lb.example.com"}),
+			expectOK:        true,
+			expectStatus:    operatorv1.ConditionTrue,
+			expectReason:    "AddressAssigned",
+			expectIngresses: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: tc.strategy},
+				},
+			}
+			condition, ok := computeLoadBalancerAddressCondition(ic, tc.service)
+			if ok != tc.expectOK {
+				t.Fatalf("expected %t, got %t", tc.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if condition.Type != IngressControllerLoadBalancerAddressConditionType || condition.Status != tc.expectStatus || condition.Reason != tc.expectReason {
+				t.Errorf("expected condition %s=%s with reason %q, got %s=%s with reason %q", IngressControllerLoadBalancerAddressConditionType, tc.expectStatus, tc.expectReason, condition.Type, condition.Status, condition.Reason)
+			}
+			ic.Status.Conditions = []operatorv1.OperatorCondition{condition}
+			if actual := LoadBalancerIngressForIngressController(ic); !reflect.DeepEqual(actual, tc.expectIngresses) {
+				t.Errorf("expected ingress points %v, got %v", tc.expectIngresses, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package ingress
+
+import (
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// platformsWithDualStackLoadBalancers is the set of platforms on which the
+// load balancer provider can provision a load balancer with both IPv4 and IPv6
+// addresses for a service that requests both IP families.  On bare metal and
+// on platforms without a cloud provider, this is typically MetalLB.  On AWS,
+// dual-stack network load balancers are requested using
+// spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType
+// instead.
+var platformsWithDualStackLoadBalancers = map[configv1.PlatformType]struct{}{
+	configv1.AzurePlatformType:     {},
+	configv1.BareMetalPlatformType: {},
+	configv1.ExternalPlatformType:  {},
+	configv1.NonePlatformType:      {},
+	configv1.OpenStackPlatformType: {},
+}
+
+// loadBalancerIPFamiliesConfig describes the IP families that an
+// ingresscontroller specifies for its load balancer service using
+// spec.unsupportedConfigOverrides.loadBalancerIPFamilies.
+type loadBalancerIPFamiliesConfig struct {
+	// IPFamilyPolicy is the service's IP family policy, either
+	// "SingleStack", "PreferDualStack", or "RequireDualStack".  Empty means
+	// "SingleStack".
+	IPFamilyPolicy corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+	// IPFamilies are the service's IP families, "IPv4" or "IPv6", in order
+	// of preference.  The first family is the service's primary family.
+	// Empty means the cluster's default families.
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+}
+
+// dualStack returns a Boolean value indicating whether the configuration
+// requests a dual-stack service.
+func (c *loadBalancerIPFamiliesConfig) dualStack() bool {
+	return c.IPFamilyPolicy == corev1.IPFamilyPolicyPreferDualStack || c.IPFamilyPolicy == corev1.IPFamilyPolicyRequireDualStack
+}
+
+// loadBalancerIPFamiliesConfigForIngressController returns the IP families
+// that the given ingresscontroller specifies for its load balancer service in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func loadBalancerIPFamiliesConfigForIngressController(ic *operatorv1.IngressController) (*loadBalancerIPFamiliesConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.LoadBalancerIPFamilies, nil
+}
+
+// validateLoadBalancerIPFamilies validates the IP families that the given
+// ingresscontroller specifies for its load balancer service, if it specifies
+// any.  A dual-stack policy can only be used on platforms whose load balancers
+// support dual-stack services, and two families require a dual-stack policy.
+func validateLoadBalancerIPFamilies(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides, platform *configv1.PlatformStatus) error {
+	config := overrides.LoadBalancerIPFamilies
+	if config == nil {
+		return nil
+	}
+	switch config.IPFamilyPolicy {
+	case "", corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack:
+	default:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilyPolicy must be %q, %q, or %q, got %q", corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack, config.IPFamilyPolicy)
+	}
+	if len(config.IPFamilies) > 2 {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilies may have at most 2 families, got %d", len(config.IPFamilies))
+	}
+	for i, family := range config.IPFamilies {
+		if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilies[%d] must be %q or %q, got %q", i, corev1.IPv4Protocol, corev1.IPv6Protocol, family)
+		}
+		if i > 0 && family == config.IPFamilies[0] {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilies has duplicate family %q", family)
+		}
+	}
+	if len(config.IPFamilies) == 2 && !config.dualStack() {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilies can only have 2 families with the %q or %q IP family policy", corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack)
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies can only be used with the %q endpoint publishing strategy", operatorv1.LoadBalancerServiceStrategyType)
+	}
+	if !config.dualStack() {
+		return nil
+	}
+	if _, ok := platformsWithDualStackLoadBalancers[platform.Type]; !ok {
+		if platform.Type == configv1.AWSPlatformType {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilyPolicy %q is not supported on platform %q; use spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType %q with a network load balancer instead", config.IPFamilyPolicy, platform.Type, awsNLBIPAddressTypeDualstack)
+		}
+		return fmt.Errorf("spec.unsupportedConfigOverrides.loadBalancerIPFamilies.ipFamilyPolicy %q is not supported on platform %q because its load balancers do not support dual-stack services", config.IPFamilyPolicy, platform.Type)
+	}
+	return nil
+}
+
+// setLoadBalancerIPFamilies sets the IP family policy and IP families on the
+// given service for the IP families that the given ingresscontroller
+// specifies, if any.
+func setLoadBalancerIPFamilies(ic *operatorv1.IngressController, service *corev1.Service) error {
+	config, err := loadBalancerIPFamiliesConfigForIngressController(ic)
+	if err != nil || config == nil {
+		return err
+	}
+	if len(config.IPFamilyPolicy) != 0 {
+		policy := config.IPFamilyPolicy
+		service.Spec.IPFamilyPolicy = &policy
+	}
+	if len(config.IPFamilies) != 0 {
+		service.Spec.IPFamilies = append([]corev1.IPFamily(nil), config.IPFamilies...)
+	}
+	return nil
+}
+
+// serviceIPFamilyPolicy returns the IP family policy of the given service.  The
+// API server defaults the policy to "SingleStack", so an unset policy is
+// "SingleStack".
+func serviceIPFamilyPolicy(service *corev1.Service) corev1.IPFamilyPolicy {
+	if service.Spec.IPFamilyPolicy == nil {
+		return corev1.IPFamilyPolicySingleStack
+	}
+	return *service.Spec.IPFamilyPolicy
+}
+
+// loadBalancerIPFamiliesEqual returns true if the current service has the IP
+// family policy and the primary IP family that the desired service requests
+// and false otherwise.  If the desired service does not request specific
+// families, the families that the API server assigned to the current service
+// are accepted.  The secondary family is not compared because the API server
+// omits it for a "PreferDualStack" service on a single-stack cluster.  The
+// cloud provider does not reconfigure the addresses of an existing load
+// balancer, so changing its families requires recreating the load balancer.
+func loadBalancerIPFamiliesEqual(current, desired *corev1.Service) bool {
+	if serviceIPFamilyPolicy(current) != serviceIPFamilyPolicy(desired) {
+		return false
+	}
+	if len(desired.Spec.IPFamilies) == 0 || len(current.Spec.IPFamilies) == 0 {
+		return true
+	}
+	return current.Spec.IPFamilies[0] == desired.Spec.IPFamilies[0]
+}
+
+// loadBalancerIPFamiliesIsProgressing returns an error value indicating whether
+// the IP families of the given service differ from the ones that the given
+// ingresscontroller specifies, in which case the service must be deleted and
+// recreated for the change to take effect.
+func loadBalancerIPFamiliesIsProgressing(ic *operatorv1.IngressController, service *corev1.Service) error {
+	desired := &corev1.Service{}
+	if err := setLoadBalancerIPFamilies(ic, desired); err != nil {
+		return err
+	}
+	if loadBalancerIPFamiliesEqual(service, desired) {
+		return nil
+	}
+	have := fmt.Sprintf("%s %v", serviceIPFamilyPolicy(service), service.Spec.IPFamilies)
+	want := fmt.Sprintf("%s %v", serviceIPFamilyPolicy(desired), desired.Spec.IPFamilies)
+	return fmt.Errorf("The IngressController load balancer IP families were changed from %q to %q.  To effectuate this change, you must delete the service: `oc -n %s delete svc/%s`; the service load-balancer will then be deprovisioned and a new one created.  This will most likely cause the new load-balancer to have a different host name and IP address from the old one's.  Alternatively, you can revert spec.unsupportedConfigOverrides.loadBalancerIPFamilies on the IngressController.", have, want, service.Namespace, service.Name)
+}
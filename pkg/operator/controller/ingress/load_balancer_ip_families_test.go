@@ -0,0 +1,205 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// newLoadBalancerIPFamiliesIngressController returns an ingresscontroller that
+// uses the "LoadBalancerService" endpoint publishing strategy with the given
+// unsupported config overrides.
+func newLoadBalancerIPFamiliesIngressController(overrides string) *operatorv1.IngressController {
+	eps := &operatorv1.EndpointPublishingStrategy{
+		Type: operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{
+			Scope: operatorv1.ExternalLoadBalancer,
+		},
+	}
+	return &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: operatorv1.IngressControllerSpec{
+			EndpointPublishingStrategy: eps,
+			UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(overrides)},
+		},
+		Status: operatorv1.IngressControllerStatus{
+			EndpointPublishingStrategy: eps.DeepCopy(),
+		},
+	}
+}
+
+// Test_validateLoadBalancerIPFamilies verifies that
+// validateLoadBalancerIPFamilies rejects invalid IP family policies and
+// families and rejects dual-stack policies on platforms whose load balancers
+// do not support dual-stack services.
+func Test_validateLoadBalancerIPFamilies(t *testing.T) {
+	testCases := []struct {
+		name        string
+		platform    configv1.PlatformType
+		overrides   string
+		expectError bool
+	}{
+		{name: "no overrides", platform: configv1.AWSPlatformType},
+		{name: "PreferDualStack on bare metal", platform: configv1.BareMetalPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack","ipFamilies":["IPv4","IPv6"]}}`},
+		{name: "RequireDualStack on Azure", platform: configv1.AzurePlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"RequireDualStack","ipFamilies":["IPv6","IPv4"]}}`},
+		{name: "SingleStack IPv6 on GCP", platform: configv1.GCPPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"SingleStack","ipFamilies":["IPv6"]}}`},
+		{name: "PreferDualStack on AWS", platform: configv1.AWSPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack"}}`, expectError: true},
+		{name: "PreferDualStack on GCP", platform: configv1.GCPPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack"}}`, expectError: true},
+		{name: "invalid policy", platform: configv1.BareMetalPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"DualStack"}}`, expectError: true},
+		{name: "invalid family", platform: configv1.BareMetalPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack","ipFamilies":["IPv5"]}}`, expectError: true},
+		{name: "duplicate family", platform: configv1.BareMetalPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack","ipFamilies":["IPv4","IPv4"]}}`, expectError: true},
+		{name: "two families without dual-stack policy", platform: configv1.BareMetalPlatformType, overrides: `{"loadBalancerIPFamilies":{"ipFamilies":["IPv4","IPv6"]}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := newLoadBalancerIPFamiliesIngressController(tc.overrides)
+			platform := &configv1.PlatformStatus{Type: tc.platform}
+			switch err := validateLoadBalancerIPFamilies(ic, mustDecodeUnsupportedConfigOverrides(t, ic), platform); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	ic := newLoadBalancerIPFamiliesIngressController(`{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack"}}`)
+	ic.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType}
+	if err := validateLoadBalancerIPFamilies(ic, mustDecodeUnsupportedConfigOverrides(t, ic), &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType}); err == nil {
+		t.Error("expected an error for the HostNetwork endpoint publishing strategy, got nil")
+	}
+}
+
+// Test_desiredLoadBalancerServiceIPFamilies verifies that
+// desiredLoadBalancerService sets the IP family policy and families that the
+// ingresscontroller specifies and leaves them for the API server to default
+// otherwise.
+func Test_desiredLoadBalancerServiceIPFamilies(t *testing.T) {
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+	testCases := []struct {
+		name           string
+		overrides      string
+		expectPolicy   *corev1.IPFamilyPolicy
+		expectFamilies []corev1.IPFamily
+	}{
+		{name: "no overrides"},
+		{
+			name:         "PreferDualStack",
+			overrides:    `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack"}}`,
+			expectPolicy: &preferDualStack,
+		},
+		{
+			name:           "PreferDualStack with IPv6 primary",
+			overrides:      `{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack","ipFamilies":["IPv6","IPv4"]}}`,
+			expectPolicy:   &preferDualStack,
+			expectFamilies: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := newLoadBalancerIPFamiliesIngressController(tc.overrides)
+			platform := &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, metav1.OwnerReference{}, platform, true, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(svc.Spec.IPFamilyPolicy, tc.expectPolicy) {
+				t.Errorf("expected IP family policy %v, got %v", tc.expectPolicy, svc.Spec.IPFamilyPolicy)
+			}
+			if !reflect.DeepEqual(svc.Spec.IPFamilies, tc.expectFamilies) {
+				t.Errorf("expected IP families %v, got %v", tc.expectFamilies, svc.Spec.IPFamilies)
+			}
+		})
+	}
+}
+
+// Test_shouldRecreateLoadBalancer_ipFamilies verifies that changing the IP
+// family policy or the primary IP family of the load balancer service requires
+// recreating the load balancer, and that the families that the API server
+// assigns to a service that does not request specific families do not.
+func Test_shouldRecreateLoadBalancer_ipFamilies(t *testing.T) {
+	singleStack := corev1.IPFamilyPolicySingleStack
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+	service := func(policy *corev1.IPFamilyPolicy, families ...corev1.IPFamily) *corev1.Service {
+		return &corev1.Service{Spec: corev1.ServiceSpec{IPFamilyPolicy: policy, IPFamilies: families}}
+	}
+	testCases := []struct {
+		name           string
+		current        *corev1.Service
+		desired        *corev1.Service
+		expectRecreate bool
+	}{
+		{
+			name:    "defaulted single-stack service",
+			current: service(&singleStack, corev1.IPv4Protocol),
+			desired: service(nil),
+		},
+		{
+			name:    "defaulted dual-stack families",
+			current: service(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol),
+			desired: service(&preferDualStack),
+		},
+		{
+			name:    "PreferDualStack on a single-stack cluster",
+			current: service(&preferDualStack, corev1.IPv4Protocol),
+			desired: service(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol),
+		},
+		{
+			name:           "single-stack to dual-stack",
+			current:        service(&singleStack, corev1.IPv4Protocol),
+			desired:        service(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol),
+			expectRecreate: true,
+		},
+		{
+			name:           "dual-stack to default",
+			current:        service(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol),
+			desired:        service(nil),
+			expectRecreate: true,
+		},
+		{
+			name:           "primary family changed",
+			current:        service(&preferDualStack, corev1.IPv4Protocol, corev1.IPv6Protocol),
+			desired:        service(&preferDualStack, corev1.IPv6Protocol, corev1.IPv4Protocol),
+			expectRecreate: true,
+		},
+	}
+	platform := &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if recreate, reason := shouldRecreateLoadBalancer(tc.current, tc.desired, platform); recreate != tc.expectRecreate {
+				t.Errorf("expected recreate to be %t, got %t (%s)", tc.expectRecreate, recreate, reason)
+			}
+		})
+	}
+}
+
+// Test_loadBalancerIPFamiliesIsProgressing verifies that
+// loadBalancerIPFamiliesIsProgressing reports an error while the load balancer
+// service's IP families differ from the ones that the ingresscontroller
+// specifies.
+func Test_loadBalancerIPFamiliesIsProgressing(t *testing.T) {
+	singleStack := corev1.IPFamilyPolicySingleStack
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+	ic := newLoadBalancerIPFamiliesIngressController(`{"loadBalancerIPFamilies":{"ipFamilyPolicy":"PreferDualStack"}}`)
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default"},
+		Spec: corev1.ServiceSpec{
+			IPFamilyPolicy: &singleStack,
+			IPFamilies:     []corev1.IPFamily{corev1.IPv4Protocol},
+		},
+	}
+	if err := loadBalancerIPFamiliesIsProgressing(ic, service); err == nil {
+		t.Error("expected an error for a single-stack service, got nil")
+	}
+	service.Spec.IPFamilyPolicy = &preferDualStack
+	service.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	if err := loadBalancerIPFamiliesIsProgressing(ic, service); err != nil {
+		t.Errorf("unexpected error for a dual-stack service: %v", err)
+	}
+}
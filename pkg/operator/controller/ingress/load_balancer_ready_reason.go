@@ -0,0 +1,128 @@
+package ingress
+
+import (
+	"strings"
+)
+
+// The following are the reasons that the operator sets on the
+// LoadBalancerReady condition when the service controller cannot provision or
+// update an ingresscontroller's load balancer or when provisioning has not
+// finished.  The reasons for failures are derived from the messages of the
+// SyncLoadBalancerFailed events on the load balancer service so that
+// automation can react to common problems without parsing the condition
+// message, which preserves the original event message.
+const (
+	// LoadBalancerSyncFailedReason indicates that the cloud provider
+	// rejected the load balancer for a reason that does not have a more
+	// specific reason.
+	LoadBalancerSyncFailedReason = "SyncLoadBalancerFailed"
+	// LoadBalancerQuotaExceededReason indicates that provisioning the load
+	// balancer would exceed a cloud account quota or limit, such as the
+	// maximum number of load balancers, public IP addresses, security
+	// groups, or security group rules.
+	LoadBalancerQuotaExceededReason = "QuotaExceeded"
+	// LoadBalancerSubnetDiscoveryFailedReason indicates that the cloud
+	// provider could not find or resolve the subnets for the load balancer.
+	LoadBalancerSubnetDiscoveryFailedReason = "SubnetDiscoveryFailed"
+	// LoadBalancerPendingProvisioningReason indicates that the load
+	// balancer has not been provisioned yet and that no failure has been
+	// reported.
+	LoadBalancerPendingProvisioningReason = "PendingProvisioning"
+	// LoadBalancerUnsupportedPlatformReason indicates that the cloud
+	// provider does not support the requested load balancer
+	// configuration.
+	LoadBalancerUnsupportedPlatformReason = "UnsupportedPlatform"
+	// LoadBalancerUnknownFailureReason indicates that the service
+	// controller reported a failure with a message that the operator does
+	// not recognize.
+	LoadBalancerUnknownFailureReason = "Unknown"
+)
+
+// loadBalancerFailurePatterns maps load balancer failure reasons to substrings
+// of the lowercased event messages that indicate them, in the order in which
+// they are checked.  Quota errors are checked first because cloud providers
+// report exhausted subnet and security group capacity as limit errors.  The
+// substrings are short fragments of the cloud providers' error codes and
+// messages so that changes to the surrounding text do not prevent a match.
+var loadBalancerFailurePatterns = []struct {
+	reason   string
+	patterns []string
+}{{
+	reason: LoadBalancerQuotaExceededReason,
+	patterns: []string{
+		// AWS: "TooManyLoadBalancers: Exceeded quota of account",
+		// "SecurityGroupLimitExceeded", and
+		// "RulesPerSecurityGroupLimitExceeded".
+		"toomanyloadbalancers",
+		"limitexceeded",
+		// Azure: "PublicIPCountLimitReached" and "QuotaExceeded".
+		"limitreached",
+		"quotaexceeded",
+		// GCP: "Quota 'FORWARDING_RULES' exceeded" and
+		// "QUOTA_EXCEEDED".
+		"quota_exceeded",
+		"exceeded quota",
+		"quota '",
+		"limit exceeded",
+	},
+}, {
+	reason: LoadBalancerSubnetDiscoveryFailedReason,
+	patterns: []string{
+		// AWS: "could not find any suitable subnets for creating
+		// the ELB" and "InvalidSubnetID.NotFound".
+		"suitable subnets",
+		"invalidsubnetid",
+		// AWS: "failed to resolve subnet" and "unable to resolve at
+		// least one subnet".
+		"resolve subnet",
+		"resolve at least one subnet",
+		// Azure: "failed to get subnet" and "subnet ... not found".
+		"get subnet",
+		"subnet not found",
+		"subnetnotfound",
+	},
+}, {
+	reason: LoadBalancerUnsupportedPlatformReason,
+	patterns: []string{
+		// AWS: "Only TCP LoadBalancer is supported for AWS ELB".
+		"is supported for",
+		// Kubernetes cloud providers: "mixed protocol is not
+		// supported for LoadBalancer".
+		"not supported",
+		"unsupported",
+		"does not support",
+	},
+}}
+
+// loadBalancerSyncFailedPrefixes are substrings of the lowercased messages
+// that the service controller uses for SyncLoadBalancerFailed events.  A
+// message with one of these substrings that does not indicate a more specific
+// reason is reported with the generic SyncLoadBalancerFailed reason.
+var loadBalancerSyncFailedPrefixes = []string{
+	"error syncing load balancer",
+	"failed to ensure load balancer",
+	"failed to update load balancer",
+	"failed to delete load balancer",
+}
+
+// loadBalancerFailureReason returns the LoadBalancerReady condition reason for
+// the given message of a SyncLoadBalancerFailed event.  The reason is
+// "Unknown" if the message is not recognizable as a load balancer sync
+// failure, for example because the service controller changed its message
+// format.
+func loadBalancerFailureReason(message string) string {
+	message = strings.ToLower(message)
+	for _, category := range loadBalancerFailurePatterns {
+		for _, pattern := range category.patterns {
+			if strings.Contains(message, pattern) {
+				return category.reason
+			}
+		}
+	}
+	for _, prefix := range loadBalancerSyncFailedPrefixes {
+		if strings.Contains(message, prefix) {
+			return LoadBalancerSyncFailedReason
+		}
+	}
+	return LoadBalancerUnknownFailureReason
+}
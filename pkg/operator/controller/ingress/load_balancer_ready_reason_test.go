@@ -0,0 +1,146 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test_loadBalancerFailureReason verifies that loadBalancerFailureReason maps
+// messages of SyncLoadBalancerFailed events that cloud providers report to the
+// expected LoadBalancerReady condition reasons.
+func Test_loadBalancerFailureReason(t *testing.T) {
+	testCases := []struct {
+		name    string
+		message string
+		expect  string
+	}{
+		{
+			name:    "AWS load balancer quota",
+			message: "Error syncing load balancer: failed to ensure load balancer: TooManyLoadBalancers: Exceeded quota of account 123456789012\n\tstatus code: 400, request id: 1b7f1e33-5c4d-4f1c-9a43-6a52b3f1f1b2",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "AWS security group limit",
+			message: "Error syncing load balancer: failed to ensure load balancer: SecurityGroupLimitExceeded: The maximum number of security groups for 'vpc-0a1b2c3d4e5f67890' has been reached.\n\tstatus code: 400, request id: 2c8e2f44-6d5e-4a2d-8b54-7b63c4a2a2c3",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "AWS security group rules limit",
+			message: "Error syncing load balancer: failed to ensure load balancer: error authorizing security group ingress: \"RulesPerSecurityGroupLimitExceeded: The maximum number of rules per security group has been reached.\\n\\tstatus code: 400, request id: 3d9f3a55-7e6f-4b3e-9c65-8c74d5b3b3d4\"",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "Azure public IP limit",
+			message: "Error syncing load balancer: failed to ensure load balancer: Retriable: false, RetryAfter: 0s, HTTPStatusCode: 400, RawError: {\n  \"error\": {\n    \"code\": \"PublicIPCountLimitReached\",\n    \"message\": \"Cannot create more than 10 public IP addresses for this subscription in this region.\",\n    \"details\": []\n  }\n}",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "Azure public IP quota",
+			message: "Error syncing load balancer: failed to ensure load balancer: Retriable: false, RetryAfter: 0s, HTTPStatusCode: 400, RawError: Code=\"QuotaExceeded\" Message=\"Operation could not be completed as it results in exceeding approved standardPublicIPAddresses quota.\"",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "GCP forwarding rules quota",
+			message: "Error syncing load balancer: failed to ensure load balancer: googleapi: Error 403: Quota 'FORWARDING_RULES' exceeded. Limit: 15.0 globally., quotaExceeded",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "GCP in-use addresses quota",
+			message: "Error syncing load balancer: failed to ensure load balancer: googleapi: Error 403: QUOTA_EXCEEDED - Quota 'IN_USE_ADDRESSES' exceeded.  Limit: 8.0 in region us-east1.",
+			expect:  "QuotaExceeded",
+		},
+		{
+			name:    "AWS no suitable subnets",
+			message: "Error syncing load balancer: failed to ensure load balancer: could not find any suitable subnets for creating the ELB",
+			expect:  "SubnetDiscoveryFailed",
+		},
+		{
+			name:    "AWS subnet not found",
+			message: "Error syncing load balancer: failed to ensure load balancer: error creating load balancer: \"InvalidSubnetID.NotFound: The subnet ID 'subnet-0123456789abcdef0' does not exist\\n\\tstatus code: 400, request id: 4e0a4b66-8f7a-4c4f-8d76-9d85e6c4c4e5\"",
+			expect:  "SubnetDiscoveryFailed",
+		},
+		{
+			name:    "AWS subnet name not resolved",
+			message: "Error syncing load balancer: failed to ensure load balancer: failed to resolve subnet names: unable to resolve at least one subnet (2 provided, 1 resolved)",
+			expect:  "SubnetDiscoveryFailed",
+		},
+		{
+			name:    "Azure subnet not found",
+			message: "Error syncing load balancer: failed to ensure load balancer: ensure(openshift-ingress/router-internal): lb(ci-ln-abc123-internal) - failed to get subnet: ci-ln-abc123-vnet/ci-ln-abc123-worker-subnet",
+			expect:  "SubnetDiscoveryFailed",
+		},
+		{
+			name:    "AWS protocol not supported",
+			message: "Error syncing load balancer: failed to ensure load balancer: Only TCP LoadBalancer is supported for AWS ELB",
+			expect:  "UnsupportedPlatform",
+		},
+		{
+			name:    "mixed protocols not supported",
+			message: "Error syncing load balancer: failed to ensure load balancer: mixed protocol is not supported for LoadBalancer",
+			expect:  "UnsupportedPlatform",
+		},
+		{
+			name:    "AWS access denied",
+			message: "Error syncing load balancer: failed to ensure load balancer: AccessDenied: User: arn:aws:sts::123456789012:assumed-role/ci-ln-abc123-openshift-ingress/1234 is not authorized to perform: elasticloadbalancing:CreateLoadBalancer\n\tstatus code: 403, request id: 5f1b5c77-9a8b-4d5a-8e87-ae96f7d5d5f6",
+			expect:  "SyncLoadBalancerFailed",
+		},
+		{
+			name:    "GCP static IP address not reserved",
+			message: "Error syncing load balancer: failed to ensure load balancer: requested ip \"203.0.113.10\" is neither static nor assigned to the LB",
+			expect:  "SyncLoadBalancerFailed",
+		},
+		{
+			name:    "unrecognized message format",
+			message: "load balancer reconciliation did not complete",
+			expect:  "Unknown",
+		},
+		{
+			name:   "empty message",
+			expect: "Unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := loadBalancerFailureReason(tc.message); actual != tc.expect {
+				t.Errorf("expected reason %q, got %q", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_SetLoadBalancerReadyReasonMetric verifies that
+// SetLoadBalancerReadyReasonMetric reports only the current reason of the
+// LoadBalancerReady condition and that DeleteLoadBalancerReadyReasonMetric
+// deletes the metric.
+func Test_SetLoadBalancerReadyReasonMetric(t *testing.T) {
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	setReason := func(reason string) {
+		ic.Status.Conditions = []operatorv1.OperatorCondition{{
+			Type:   operatorv1.LoadBalancerReadyIngressConditionType,
+			Status: operatorv1.ConditionFalse,
+			Reason: reason,
+		}}
+		SetLoadBalancerReadyReasonMetric(ic)
+	}
+
+	setReason("PendingProvisioning")
+	setReason("QuotaExceeded")
+	expected := `
+	# HELP ingress_controller_load_balancer_ready_reason Report the reason of the LoadBalancerReady condition for ingress controllers with a managed load balancer. The value is 1 for the current reason.
+	# TYPE ingress_controller_load_balancer_ready_reason gauge
+	ingress_controller_load_balancer_ready_reason{name="default",reason="QuotaExceeded"} 1
+	`
+	if err := testutil.CollectAndCompare(loadBalancerReadyReasonMetric, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	DeleteLoadBalancerReadyReasonMetric(ic)
+	if n := testutil.CollectAndCount(loadBalancerReadyReasonMetric); n != 0 {
+		t.Errorf("expected no metrics after deletion, got %d", n)
+	}
+}
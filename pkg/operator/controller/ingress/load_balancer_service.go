@@ -15,6 +15,7 @@ import (
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 	corev1 "k8s.io/api/core/v1"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -244,6 +245,11 @@ var (
 			localWithFallbackAnnotation,
 			// AWS load balancer type annotation to set either CLB/ELB or NLB
 			AWSLBTypeAnnotation,
+			// AWS Load Balancer Controller scheme and target type
+			// annotations, which the operator only sets when the
+			// controller provisions the load balancer.
+			awsLBSchemeAnnotation,
+			awsLBNLBTargetTypeAnnotation,
 			// awsLBProxyProtocolAnnotation is used to enable the PROXY protocol on any
 			// AWS load balancer services created.
 			//
@@ -254,6 +260,25 @@ var (
 			//
 			// https://cloud.ibm.com/docs/containers?topic=containers-vpc-lbaas
 			iksLBEnableFeaturesAnnotation,
+			// Azure load balancer rule idle timeout annotation, which
+			// the cloud provider updates in place.  The public IP
+			// prefix annotation is deliberately omitted because
+			// changing it requires recreating the load balancer.
+			azureLBTCPIdleTimeoutAnnotation,
+			// GCP backend service timeout annotation, which the
+			// cloud provider also updates in place.
+			gcpLBBackendTimeoutAnnotation,
+			// AWS network load balancer IP address type annotation,
+			// which AWS allows changing on an existing load balancer.
+			dnsrecord.AWSLBIPAddressTypeAnnotation,
+			// AWS Load Balancer Controller target group attributes
+			// annotation, which the operator only sets for client IP
+			// preservation.  The security groups annotation is
+			// deliberately omitted because AWS cannot add security
+			// groups to, or remove all security groups from, an
+			// existing network load balancer (see
+			// awsLBSecurityGroupsChanged).
+			awsLBTargetGroupAttributesAnnotation,
 		)
 
 		// Azure and GCP support switching between internal and external
@@ -325,9 +350,13 @@ func (r *reconciler) ensureLoadBalancerService(ci *operatorv1.IngressController,
 		if _, ok := ci.Annotations[autoDeleteLoadBalancerAnnotation]; ok {
 			autoDeleteLB = true
 		}
+		annotationConflicts := loadBalancerSourceRangesAnnotationConflicts(ci, currentLBService)
 		if updated, err := r.updateLoadBalancerService(currentLBService, desiredLBService, platformStatus, autoDeleteLB); err != nil {
 			return true, currentLBService, fmt.Errorf("failed to update load balancer service: %v", err)
 		} else if updated {
+			if annotationConflicts {
+				r.recorder.Eventf(ci, "Warning", "SourceRangesAnnotationRemoved", "Removed the %s annotation with value %q from service %s/%s because it conflicts with spec.endpointPublishingStrategy.loadBalancer.allowedSourceRanges", corev1.AnnotationLoadBalancerSourceRangesKey, currentLBService.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey], currentLBService.Namespace, currentLBService.Name)
+			}
 			return r.currentLoadBalancerService(ci)
 		}
 	}
@@ -445,6 +474,19 @@ func desiredLoadBalancerService(ci *operatorv1.IngressController, deploymentRef
 				}
 			}
 
+			if err := setAWSNLBIPAddressTypeAnnotation(ci, service); err != nil {
+				return true, service, err
+			}
+			if err := setAWSNLBSecurityAnnotations(ci, service); err != nil {
+				return true, service, err
+			}
+
+			if provisioner, err := awsLoadBalancerProvisioner(ci); err != nil {
+				return true, service, err
+			} else if provisioner == awsLoadBalancerProvisionerALBController {
+				setAWSLoadBalancerControllerServiceFields(service, isInternal)
+			}
+
 			if platform.AWS != nil && len(platform.AWS.ResourceTags) > 0 {
 				var additionalTags []string
 				for _, userTag := range platform.AWS.ResourceTags {
@@ -475,8 +517,20 @@ func desiredLoadBalancerService(ci *operatorv1.IngressController, deploymentRef
 			if !isInternal {
 				service.Annotations[alibabaCloudLBAddressTypeAnnotation] = alibabaCloudLBAddressTypeInternet
 			}
+		case configv1.AzurePlatformType:
+			config, err := azureLoadBalancerConfigForIngressController(ci)
+			if err != nil {
+				return true, service, err
+			}
+			setAzureLoadBalancerServiceAnnotations(service, config, isInternal)
+		case configv1.GCPPlatformType:
+			config, err := gcpLoadBalancerConfigForIngressController(ci)
+			if err != nil {
+				return true, service, err
+			}
+			setGCPLoadBalancerServiceFields(service, config, isInternal)
 		}
-		// Azure load balancers are not customizable and are set to (2 fail @ 5s interval, 2 healthy)
+		// Azure load balancer health checks are not customizable and are set to (2 fail @ 5s interval, 2 healthy)
 		// GCP load balancers are not customizable and are set to (3 fail @ 8s interval, 1 healthy)
 
 		if v, err := shouldUseLocalWithFallback(ci, service); err != nil {
@@ -486,6 +540,14 @@ func desiredLoadBalancerService(ci *operatorv1.IngressController, deploymentRef
 		}
 	}
 
+	if err := setLoadBalancerIPFamilies(ci, service); err != nil {
+		return true, service, err
+	}
+
+	if http3Enabled(ci) && len(http3LoadBalancerUnsupportedReason(ci, platform)) == 0 {
+		service.Spec.Ports = append(service.Spec.Ports, http3ServicePort())
+	}
+
 	if ci.Spec.EndpointPublishingStrategy != nil {
 		lb := ci.Spec.EndpointPublishingStrategy.LoadBalancer
 		if lb != nil && len(lb.AllowedSourceRanges) > 0 {
@@ -497,6 +559,12 @@ func desiredLoadBalancerService(ci *operatorv1.IngressController, deploymentRef
 		}
 	}
 
+	propagated, err := propagatedMetadataForIngressController(ci)
+	if err != nil {
+		return true, service, err
+	}
+	applyPropagatedMetadata(&service.ObjectMeta, propagated)
+
 	service.SetOwnerReferences([]metav1.OwnerReference{deploymentRef})
 	return true, service, nil
 }
@@ -513,20 +581,15 @@ func shouldUseLocalWithFallback(ic *operatorv1.IngressController, service *corev
 	}
 
 	// Allow the user to override local-with-fallback.
-	if len(ic.Spec.UnsupportedConfigOverrides.Raw) > 0 {
-		var unsupportedConfigOverrides struct {
-			LocalWithFallback string `json:"localWithFallback"`
-		}
-		if err := json.Unmarshal(ic.Spec.UnsupportedConfigOverrides.Raw, &unsupportedConfigOverrides); err != nil {
-			return false, fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides: %w", ic.Name, err)
-		}
-		override := unsupportedConfigOverrides.LocalWithFallback
-		if len(override) != 0 {
-			if val, err := strconv.ParseBool(override); err != nil {
-				return false, fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.localWithFallback: %w", ic.Name, err)
-			} else {
-				return val, nil
-			}
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return false, err
+	}
+	if override := overrides.LocalWithFallback; len(override) != 0 {
+		if val, err := strconv.ParseBool(override); err != nil {
+			return false, fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.localWithFallback: %w", ic.Name, err)
+		} else {
+			return val, nil
 		}
 	}
 
@@ -661,9 +724,40 @@ func shouldRecreateLoadBalancer(current, desired *corev1.Service, platform *conf
 	if platform.Type == configv1.AWSPlatformType && !serviceEIPAllocationsEqual(current, desired) {
 		return true, "its eipAllocations changed"
 	}
+	if platform.Type == configv1.AWSPlatformType && awsLBSecurityGroupsRequireRecreation(current, desired) {
+		return true, "its security groups were added or removed"
+	}
+	if platform.Type == configv1.AzurePlatformType && !azurePIPPrefixIDEqual(current, desired) {
+		return true, "its public IP prefix changed"
+	}
+	if platform.Type == configv1.GCPPlatformType && !gcpLoadBalancerAddressEqual(current, desired) {
+		return true, "its static address changed"
+	}
+	if platform.Type == configv1.GCPPlatformType && !gcpNetworkTierEqual(current, desired) {
+		return true, "its network tier changed"
+	}
+	if !loadBalancerClassEqual(current, desired) {
+		return true, "its load balancer class changed"
+	}
+	if !loadBalancerIPFamiliesEqual(current, desired) {
+		return true, "its IP families changed"
+	}
 	return false, ""
 }
 
+// loadBalancerClassEqual returns true if the load balancer class is the same
+// between the two given services and false if it is different.
+func loadBalancerClassEqual(a, b *corev1.Service) bool {
+	aClass, bClass := "", ""
+	if a.Spec.LoadBalancerClass != nil {
+		aClass = *a.Spec.LoadBalancerClass
+	}
+	if b.Spec.LoadBalancerClass != nil {
+		bClass = *b.Spec.LoadBalancerClass
+	}
+	return aClass == bClass
+}
+
 // loadBalancerServiceChanged checks if the current load balancer service
 // matches the expected and if not returns an updated one.
 func loadBalancerServiceChanged(current, expected *corev1.Service) (bool, *corev1.Service) {
@@ -698,6 +792,30 @@ func loadBalancerServiceChanged(current, expected *corev1.Service) (bool, *corev
 		}
 	}
 
+	if portChanged, ports := http3ServicePortChanged(current, expected); portChanged {
+		if !changed {
+			changed = true
+			updated = current.DeepCopy()
+		}
+		updated.Spec.Ports = ports
+	}
+
+	if securityGroupsChanged, securityGroups := awsLBSecurityGroupsChanged(current, expected); securityGroupsChanged {
+		if !changed {
+			changed = true
+			updated = current.DeepCopy()
+		}
+		updated.Annotations[awsLBSecurityGroupsAnnotation] = securityGroups
+	}
+
+	if propagatedMetadataChanged(&current.ObjectMeta, &expected.ObjectMeta) {
+		if !changed {
+			changed = true
+			updated = current.DeepCopy()
+		}
+		copyPropagatedMetadata(&updated.ObjectMeta, &current.ObjectMeta, &expected.ObjectMeta)
+	}
+
 	return changed, updated
 }
 
@@ -858,7 +976,26 @@ func loadBalancerServiceIsProgressing(ic *operatorv1.IngressController, service
 		}
 	}
 
-	errs = append(errs, loadBalancerSourceRangesAnnotationSet(service))
+	if platform.Type == configv1.AWSPlatformType {
+		wantClass := ""
+		if usesAWSLoadBalancerController(ic, platform) {
+			wantClass = awsLoadBalancerControllerClass
+		}
+		haveClass := ""
+		if service.Spec.LoadBalancerClass != nil {
+			haveClass = *service.Spec.LoadBalancerClass
+		}
+		if wantClass != haveClass {
+			err := fmt.Errorf("The IngressController load balancer class was changed from %q to %q.  To effectuate this change, you must delete the service: `oc -n %s delete svc/%s`; the service load-balancer will then be deprovisioned and a new one created.  This will most likely cause the new load-balancer to have a different host name and IP address from the old one's.", haveClass, wantClass, service.Namespace, service.Name)
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, azureLoadBalancerIsProgressing(ic, service, platform))
+	errs = append(errs, gcpLoadBalancerIsProgressing(ic, service, platform))
+	errs = append(errs, awsNLBSecurityGroupsIsProgressing(ic, service, platform))
+	errs = append(errs, loadBalancerIPFamiliesIsProgressing(ic, service))
+	errs = append(errs, loadBalancerSourceRangesAnnotationSet(ic, service))
 	errs = append(errs, loadBalancerSourceRangesMatch(ic, service))
 
 	return kerrors.NewAggregate(errs)
@@ -919,7 +1056,7 @@ func convertAWSSubnetListToPatchJson(subnets *operatorv1.AWSSubnets, emptySubnet
 // load balancer service is in EvaluationConditionsDetected status.
 func loadBalancerServiceEvaluationConditionsDetected(ic *operatorv1.IngressController, service *corev1.Service) error {
 	var errs []error
-	errs = append(errs, loadBalancerSourceRangesAnnotationSet(service))
+	errs = append(errs, loadBalancerSourceRangesAnnotationSet(ic, service))
 	errs = append(errs, loadBalancerSourceRangesMatch(ic, service))
 
 	return kerrors.NewAggregate(errs)
@@ -934,15 +1071,38 @@ func loadBalancerServiceEvaluationConditionsDetected(ic *operatorv1.IngressContr
 // Otherwise, the return value is a non-nil error indicating that the annotation
 // must be unset. The intention is to guide the cluster
 // admin towards using the IngressController API and deprecate use of the service
-// annotation for ingress.
-func loadBalancerSourceRangesAnnotationSet(current *corev1.Service) error {
+// annotation for ingress.  If the ingresscontroller specifies
+// AllowedSourceRanges, the operator removes the annotation itself, and the
+// error says so.
+func loadBalancerSourceRangesAnnotationSet(ic *operatorv1.IngressController, current *corev1.Service) error {
 	if a, ok := current.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey]; !ok || (ok && len(a) == 0) {
 		return nil
 	}
 
+	if loadBalancerSourceRangesAnnotationConflicts(ic, current) {
+		return fmt.Errorf("The %v annotation on service %q conflicts with the AllowedSourceRanges API field on the ingresscontroller, which takes precedence. The operator is removing the annotation and migrating the service to the allowed source ranges %v.", corev1.AnnotationLoadBalancerSourceRangesKey, current.Name, ic.Spec.EndpointPublishingStrategy.LoadBalancer.AllowedSourceRanges)
+	}
+
 	return fmt.Errorf("You have manually edited an operator-managed object. You must revert your modifications by removing the %v annotation on service %q. You can use the new AllowedSourceRanges API field on the ingresscontroller object to configure this setting instead.", corev1.AnnotationLoadBalancerSourceRangesKey, current.Name)
 }
 
+// loadBalancerSourceRangesAnnotationConflicts returns a Boolean value
+// indicating whether the given service has the
+// "service.beta.kubernetes.io/load-balancer-source-ranges" annotation although
+// the given ingresscontroller specifies AllowedSourceRanges.  In this case, the
+// AllowedSourceRanges field takes precedence, and the operator removes the
+// annotation when it updates the service.
+func loadBalancerSourceRangesAnnotationConflicts(ic *operatorv1.IngressController, current *corev1.Service) bool {
+	if current == nil {
+		return false
+	}
+	if _, ok := current.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey]; !ok {
+		return false
+	}
+	eps := ic.Spec.EndpointPublishingStrategy
+	return eps != nil && eps.LoadBalancer != nil && len(eps.LoadBalancer.AllowedSourceRanges) > 0
+}
+
 // loadBalancerSourceRangesMatch returns an error value indicating if the
 // ingresscontroller associated with the load balancer service should report the Progressing
 // and EvaluationConditionsDetected status conditions with status True.  This function
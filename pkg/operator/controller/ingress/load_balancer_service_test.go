@@ -2,6 +2,7 @@ package ingress
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -1150,6 +1151,13 @@ func Test_loadBalancerServiceChanged(t *testing.T) {
 			},
 			expect: true,
 		},
+		{
+			description: "if the cloud.google.com/backend-timeout-sec annotation is added",
+			mutate: func(svc *corev1.Service) {
+				svc.Annotations["cloud.google.com/backend-timeout-sec"] = "600"
+			},
+			expect: true,
+		},
 		{
 			description: "if the service.kubernetes.io/ibm-load-balancer-cloud-provider-enable-features annotation is added",
 			mutate: func(svc *corev1.Service) {
@@ -1560,6 +1568,75 @@ func TestUpdateLoadBalancerServiceSourceRanges(t *testing.T) {
 	}
 }
 
+// Test_loadBalancerSourceRangesAnnotationSet verifies that
+// loadBalancerSourceRangesAnnotationSet reports the
+// service.beta.kubernetes.io/load-balancer-source-ranges annotation and
+// reports that the operator removes it if it conflicts with the
+// ingresscontroller's allowedSourceRanges.
+func Test_loadBalancerSourceRangesAnnotationSet(t *testing.T) {
+	testCases := []struct {
+		name                string
+		allowedSourceRanges []operatorv1.CIDR
+		annotations         map[string]string
+		expectError         bool
+		expectConflict      bool
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "empty annotation",
+			annotations: map[string]string{corev1.AnnotationLoadBalancerSourceRangesKey: ""},
+		},
+		{
+			name:        "annotation without allowedSourceRanges",
+			annotations: map[string]string{corev1.AnnotationLoadBalancerSourceRangesKey: "10.0.0.0/8"},
+			expectError: true,
+		},
+		{
+			name:                "annotation with allowedSourceRanges",
+			allowedSourceRanges: []operatorv1.CIDR{"192.168.0.0/16"},
+			annotations:         map[string]string{corev1.AnnotationLoadBalancerSourceRangesKey: "10.0.0.0/8"},
+			expectError:         true,
+			expectConflict:      true,
+		},
+		{
+			name:                "allowedSourceRanges without annotation",
+			allowedSourceRanges: []operatorv1.CIDR{"192.168.0.0/16"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+						LoadBalancer: &operatorv1.LoadBalancerStrategy{
+							AllowedSourceRanges: tc.allowedSourceRanges,
+						},
+					},
+				},
+			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "router-default", Annotations: tc.annotations},
+			}
+			err := loadBalancerSourceRangesAnnotationSet(ic, service)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected an error, got nil")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if conflicts := loadBalancerSourceRangesAnnotationConflicts(ic, service); conflicts != tc.expectConflict {
+				t.Errorf("expected conflict to be %t, got %t", tc.expectConflict, conflicts)
+			}
+			if err != nil && tc.expectConflict != strings.Contains(err.Error(), "takes precedence") {
+				t.Errorf("unexpected error message for conflict=%t: %v", tc.expectConflict, err)
+			}
+		})
+	}
+}
+
 // TestLoadBalancerServiceChangedEmptyAnnotations verifies that a service with null
 // .metadata.annotations and a service with empty .metadata.annotations are
 // considered equal.
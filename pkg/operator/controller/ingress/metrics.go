@@ -31,10 +31,65 @@ var (
 		Help: "Report the number of active NLBs on AWS clusters.",
 	}, []string{"name"})
 
+	// servingNodeAddressesMetric reports the addresses of the nodes that are
+	// serving each IngressController that uses the "HostNetwork" or
+	// "NodePortService" endpoint publishing strategy.
+	servingNodeAddressesMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_serving_node_address",
+		Help: "Report the addresses of the nodes that are serving ingress controllers that use node endpoints. The value is always 1.",
+	}, []string{"name", "address"})
+
+	// routesPendingStatusUpdate reports the number of routes whose status
+	// the operator still needs to clear for each IngressController after
+	// the IngressController's selectors change or the IngressController is
+	// deleted.
+	routesPendingStatusUpdate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_routes_pending_status_update",
+		Help: "Report the number of routes whose status the operator still needs to clear for an ingress controller.",
+	}, []string{"name"})
+
+	// connectionUtilizationMetric reports the estimated connection
+	// utilization of each IngressController that enables connection
+	// capacity estimation.
+	connectionUtilizationMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_connection_utilization_ratio",
+		Help: "Report the open client connections of an ingress controller's router pods as a ratio of maxConnections times the available replicas.",
+	}, []string{"name"})
+
+	// connectionUtilizationStaleMetric reports whether the reported
+	// connection utilization of each IngressController is from an earlier
+	// sample because the router metrics could not be scraped.
+	connectionUtilizationStaleMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_connection_utilization_stale",
+		Help: "Report whether the connection utilization of an ingress controller is from an earlier sample because the router metrics could not be scraped. 0 is fresh and 1 is stale.",
+	}, []string{"name"})
+
+	// routerReloadRateMetric reports the observed reload rate of the
+	// router pods of each IngressController that specifies
+	// spec.tuningOptions.reloadInterval.
+	routerReloadRateMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_router_reload_rate",
+		Help: "Report the average number of reloads per minute per router pod of an ingress controller that specifies a reload interval.",
+	}, []string{"name"})
+
+	// loadBalancerReadyReasonMetric reports the reason of the
+	// LoadBalancerReady status condition of each IngressController that
+	// has a managed load balancer.
+	loadBalancerReadyReasonMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingress_controller_load_balancer_ready_reason",
+		Help: "Report the reason of the LoadBalancerReady condition for ingress controllers with a managed load balancer. The value is 1 for the current reason.",
+	}, []string{"name", "reason"})
+
 	// metricsList is a list of metrics for this package.
 	metricsList = []prometheus.Collector{
 		ingressControllerConditions,
 		activeNLBs,
+		servingNodeAddressesMetric,
+		routesPendingStatusUpdate,
+		connectionUtilizationMetric,
+		connectionUtilizationStaleMetric,
+		routerReloadRateMetric,
+		loadBalancerReadyReasonMetric,
 	}
 )
 
@@ -74,10 +129,93 @@ func DeleteIngressControllerConditionsMetric(ic *operatorv1.IngressController) {
 	}
 }
 
+// SetLoadBalancerReadyReasonMetric updates the
+// ingress_controller_load_balancer_ready_reason metric for the given
+// IngressController to report the reason of its LoadBalancerReady condition.
+// The metric is deleted if the IngressController has no such condition.
+func SetLoadBalancerReadyReasonMetric(ic *operatorv1.IngressController) {
+	DeleteLoadBalancerReadyReasonMetric(ic)
+	for _, c := range ic.Status.Conditions {
+		if c.Type == operatorv1.LoadBalancerReadyIngressConditionType && len(c.Reason) != 0 {
+			loadBalancerReadyReasonMetric.WithLabelValues(ic.Name, c.Reason).Set(1)
+		}
+	}
+}
+
+// DeleteLoadBalancerReadyReasonMetric deletes the
+// ingress_controller_load_balancer_ready_reason metric that belongs to the
+// given IngressController.
+func DeleteLoadBalancerReadyReasonMetric(ic *operatorv1.IngressController) {
+	loadBalancerReadyReasonMetric.DeletePartialMatch(prometheus.Labels{"name": ic.Name})
+}
+
 func DeleteActiveNLBMetrics(ic *operatorv1.IngressController) {
 	activeNLBs.DeleteLabelValues(ic.Name)
 }
 
+// SetServingNodeAddressesMetric updates the
+// ingress_controller_serving_node_address metric values for the given
+// IngressController to report the given node addresses.
+func SetServingNodeAddressesMetric(ic *operatorv1.IngressController, addresses []string) {
+	DeleteServingNodeAddressesMetric(ic)
+	for _, address := range addresses {
+		servingNodeAddressesMetric.WithLabelValues(ic.Name, address).Set(1)
+	}
+}
+
+// DeleteServingNodeAddressesMetric deletes the
+// ingress_controller_serving_node_address metrics that belong to the given
+// IngressController.
+func DeleteServingNodeAddressesMetric(ic *operatorv1.IngressController) {
+	servingNodeAddressesMetric.DeletePartialMatch(prometheus.Labels{"name": ic.Name})
+}
+
+// DeleteRoutesPendingStatusUpdateMetric deletes the
+// ingress_controller_routes_pending_status_update metric that belongs to the
+// given IngressController.
+func DeleteRoutesPendingStatusUpdateMetric(ic *operatorv1.IngressController) {
+	routesPendingStatusUpdate.DeleteLabelValues(ic.Name)
+}
+
+// SetConnectionUtilizationMetrics updates the
+// ingress_controller_connection_utilization_ratio and
+// ingress_controller_connection_utilization_stale metrics for the given
+// IngressController.  If known is false, the metrics are deleted.
+func SetConnectionUtilizationMetrics(ic *operatorv1.IngressController, utilization float64, known, stale bool) {
+	if !known {
+		DeleteConnectionUtilizationMetrics(ic)
+		return
+	}
+	connectionUtilizationMetric.WithLabelValues(ic.Name).Set(utilization)
+	var v float64
+	if stale {
+		v = 1
+	}
+	connectionUtilizationStaleMetric.WithLabelValues(ic.Name).Set(v)
+}
+
+// DeleteConnectionUtilizationMetrics deletes the
+// ingress_controller_connection_utilization_ratio and
+// ingress_controller_connection_utilization_stale metrics that belong to the
+// given IngressController.
+func DeleteConnectionUtilizationMetrics(ic *operatorv1.IngressController) {
+	connectionUtilizationMetric.DeleteLabelValues(ic.Name)
+	connectionUtilizationStaleMetric.DeleteLabelValues(ic.Name)
+}
+
+// SetRouterReloadRateMetric updates the ingress_controller_router_reload_rate
+// metric for the given IngressController.
+func SetRouterReloadRateMetric(ic *operatorv1.IngressController, perMinute float64) {
+	routerReloadRateMetric.WithLabelValues(ic.Name).Set(perMinute)
+}
+
+// DeleteRouterReloadRateMetric deletes the
+// ingress_controller_router_reload_rate metric that belongs to the given
+// IngressController.
+func DeleteRouterReloadRateMetric(ic *operatorv1.IngressController) {
+	routerReloadRateMetric.DeleteLabelValues(ic.Name)
+}
+
 func SetIngressControllerNLBMetric(ci *operatorv1.IngressController) {
 	labelVal := 0
 	if ci.Status.EndpointPublishingStrategy != nil &&
@@ -8,7 +8,9 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -127,31 +129,85 @@ func routerNamespaceChanged(current, expected *corev1.Namespace) (bool, *corev1.
 	return true, updated
 }
 
+// ensureRouterServiceAccount ensures that the router service accounts exist:
+// the service account that router pods use by default and the one that router
+// pods use if they mark their egress traffic, which may additionally use the
+// egress DSCP security context constraints.
 func (r *reconciler) ensureRouterServiceAccount() error {
-	sa := manifests.RouterServiceAccount()
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: sa.Namespace, Name: sa.Name}, sa); err != nil {
-		if !errors.IsNotFound(err) {
-			return fmt.Errorf("failed to get router service account %s/%s: %v", sa.Namespace, sa.Name, err)
-		}
-		if err := r.client.Create(context.TODO(), sa); err != nil {
-			return fmt.Errorf("failed to create router service account %s/%s: %v", sa.Namespace, sa.Name, err)
+	for _, sa := range []*corev1.ServiceAccount{manifests.RouterServiceAccount(), manifests.RouterEgressDSCPServiceAccount()} {
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: sa.Namespace, Name: sa.Name}, sa); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get router service account %s/%s: %v", sa.Namespace, sa.Name, err)
+			}
+			if err := r.client.Create(context.TODO(), sa); err != nil {
+				return fmt.Errorf("failed to create router service account %s/%s: %v", sa.Namespace, sa.Name, err)
+			}
+			log.Info("created router service account", "namespace", sa.Namespace, "name", sa.Name)
 		}
-		log.Info("created router service account", "namespace", sa.Namespace, "name", sa.Name)
 	}
 
 	return nil
 }
 
+// ensureRouterClusterRoleBinding ensures that the cluster role binding that
+// grants the router service account its cluster role (and by extension, the
+// use of the required security context constraints) exists and has the
+// expected subjects and, if the operator manages the binding, the expected role
+// reference.
 func (r *reconciler) ensureRouterClusterRoleBinding() error {
-	crb := manifests.RouterClusterRoleBinding()
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: crb.Name}, crb); err != nil {
+	desired := manifests.RouterClusterRoleBinding()
+	current := &rbacv1.ClusterRoleBinding{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: desired.Name}, current); err != nil {
 		if !errors.IsNotFound(err) {
-			return fmt.Errorf("failed to get router cluster role binding %s: %v", crb.Name, err)
+			return fmt.Errorf("failed to get router cluster role binding %s: %v", desired.Name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to create router cluster role binding %s: %v", desired.Name, err)
 		}
-		if err := r.client.Create(context.TODO(), crb); err != nil {
-			return fmt.Errorf("failed to create router cluster role binding %s: %v", crb.Name, err)
+		log.Info("created router cluster role binding", "name", desired.Name)
+		return nil
+	}
+
+	changed, updated := routerClusterRoleBindingChanged(current, desired)
+	if !changed {
+		return nil
+	}
+	// The role reference of a binding is immutable, so a binding that
+	// references the wrong role must be deleted and recreated.  Only do so
+	// for the binding that the operator created; a binding with the same
+	// name that someone else created is left alone.
+	if !equality.Semantic.DeepEqual(current.RoleRef, desired.RoleRef) {
+		if current.Labels[manifests.IngressOperatorManagedLabel] != "true" {
+			log.Info("router cluster role binding references an unexpected role but is not managed by the operator; leaving it alone", "name", current.Name, "roleRef", current.RoleRef)
+			return nil
+		}
+		if err := r.client.Delete(context.TODO(), current); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete router cluster role binding %s: %v", current.Name, err)
+		}
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return fmt.Errorf("failed to recreate router cluster role binding %s: %v", desired.Name, err)
 		}
-		log.Info("created router cluster role binding", "name", crb.Name)
+		log.Info("recreated router cluster role binding", "name", desired.Name, "diff", cmp.Diff(current.RoleRef, desired.RoleRef))
+		return nil
 	}
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to update router cluster role binding %s: %v", updated.Name, err)
+	}
+	log.Info("updated router cluster role binding", "name", updated.Name, "diff", cmp.Diff(current.Subjects, updated.Subjects))
 	return nil
 }
+
+// routerClusterRoleBindingChanged returns a Boolean indicating whether the
+// current cluster role binding differs from the expected one in its subjects
+// or role reference, and if so, an updated binding.
+func routerClusterRoleBindingChanged(current, expected *rbacv1.ClusterRoleBinding) (bool, *rbacv1.ClusterRoleBinding) {
+	if cmp.Equal(current.Subjects, expected.Subjects, cmpopts.EquateEmpty()) && equality.Semantic.DeepEqual(current.RoleRef, expected.RoleRef) {
+		return false, nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Subjects = expected.Subjects
+	updated.RoleRef = expected.RoleRef
+
+	return true, updated
+}
@@ -1,11 +1,19 @@
 package ingress
 
 import (
+	"context"
 	"testing"
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_routerNamespaceChanged(t *testing.T) {
@@ -68,3 +76,114 @@ func Test_routerNamespaceChanged(t *testing.T) {
 		})
 	}
 }
+
+// Test_ensureRouterClusterRoleBinding verifies that
+// ensureRouterClusterRoleBinding creates the router cluster role binding if it
+// is missing, restores its subjects if they have been modified, and restores
+// its role reference only if the operator manages the binding.
+func Test_ensureRouterClusterRoleBinding(t *testing.T) {
+	testCases := []struct {
+		description string
+		existing    func() []client.Object
+		// expectRoleRefName, if not empty, is the name of the role that
+		// the binding is expected to reference instead of the desired
+		// one.
+		expectRoleRefName string
+	}{
+		{
+			description: "binding is missing",
+			existing:    func() []client.Object { return nil },
+		},
+		{
+			description: "binding is unchanged",
+			existing: func() []client.Object {
+				return []client.Object{manifests.RouterClusterRoleBinding()}
+			},
+		},
+		{
+			description: "binding subjects have been removed",
+			existing: func() []client.Object {
+				crb := manifests.RouterClusterRoleBinding()
+				crb.Subjects = nil
+				return []client.Object{crb}
+			},
+		},
+		{
+			description: "binding subject has been replaced",
+			existing: func() []client.Object {
+				crb := manifests.RouterClusterRoleBinding()
+				crb.Subjects = []rbacv1.Subject{{
+					Kind:      "ServiceAccount",
+					Name:      "default",
+					Namespace: "openshift-ingress",
+				}}
+				return []client.Object{crb}
+			},
+		},
+		{
+			description: "binding references another role",
+			existing: func() []client.Object {
+				crb := manifests.RouterClusterRoleBinding()
+				crb.RoleRef.Name = "view"
+				return []client.Object{crb}
+			},
+		},
+		{
+			description: "unmanaged binding references another role",
+			existing: func() []client.Object {
+				crb := manifests.RouterClusterRoleBinding()
+				crb.Labels = nil
+				crb.RoleRef.Name = "view"
+				return []client.Object{crb}
+			},
+			expectRoleRefName: "view",
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.existing()...).Build()
+			r := &reconciler{client: cl}
+			if err := r.ensureRouterClusterRoleBinding(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			desired := manifests.RouterClusterRoleBinding()
+			actual := &rbacv1.ClusterRoleBinding{}
+			if err := cl.Get(context.Background(), types.NamespacedName{Name: desired.Name}, actual); err != nil {
+				t.Fatalf("failed to get cluster role binding: %v", err)
+			}
+			if len(tc.expectRoleRefName) != 0 {
+				if actual.RoleRef.Name != tc.expectRoleRefName {
+					t.Errorf("expected cluster role binding to be left alone, got role reference %v", actual.RoleRef)
+				}
+				return
+			}
+			if changed, _ := routerClusterRoleBindingChanged(actual, desired); changed {
+				t.Errorf("expected cluster role binding to be restored, got subjects %v and role reference %v", actual.Subjects, actual.RoleRef)
+			}
+		})
+	}
+}
+
+// Test_ensureRouterServiceAccount verifies that ensureRouterServiceAccount
+// recreates the router service accounts if they have been deleted.
+func Test_ensureRouterServiceAccount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &reconciler{client: cl}
+	if err := r.ensureRouterServiceAccount(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, sa := range []*corev1.ServiceAccount{manifests.RouterServiceAccount(), manifests.RouterEgressDSCPServiceAccount()} {
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: sa.Namespace, Name: sa.Name}, &corev1.ServiceAccount{}); err != nil {
+			t.Errorf("expected service account %s/%s to be created: %v", sa.Namespace, sa.Name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NodePortExternalEndpoint describes the external load balancer in front of an
+// ingresscontroller that uses the NodePortService endpoint publishing strategy.
+// The default ingresscontroller specifies it using
+// spec.unsupportedConfigOverrides.nodePortExternalEndpoint so that the canary
+// controller can verify the external path to the router.
+type NodePortExternalEndpoint struct {
+	// Address is the VIP or hostname of the external load balancer,
+	// optionally followed by a colon and a port.  The default port is 443.
+	Address string `json:"address"`
+}
+
+// NodePortExternalEndpointForIngressController returns the external endpoint
+// that the given ingresscontroller specifies in spec.unsupportedConfigOverrides,
+// or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func NodePortExternalEndpointForIngressController(ic *operatorv1.IngressController) (*NodePortExternalEndpoint, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.NodePortExternalEndpoint, nil
+}
+
+// DialAddress returns the address, with the default port if the endpoint
+// specifies none, that a client dials to reach the external endpoint.
+func (e *NodePortExternalEndpoint) DialAddress() string {
+	if _, _, err := net.SplitHostPort(e.Address); err == nil {
+		return e.Address
+	}
+	return net.JoinHostPort(strings.Trim(e.Address, "[]"), "443")
+}
+
+// ValidateNodePortExternalEndpoint validates the given external endpoint.  The
+// address must be an IP address or a hostname, optionally followed by a valid
+// port.
+func ValidateNodePortExternalEndpoint(endpoint *NodePortExternalEndpoint) error {
+	host := endpoint.Address
+	if h, port, err := net.SplitHostPort(endpoint.Address); err == nil {
+		host = h
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.nodePortExternalEndpoint.address has an invalid port: %q", endpoint.Address)
+		}
+	}
+	host = strings.Trim(host, "[]")
+	switch {
+	case len(host) == 0:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.nodePortExternalEndpoint.address must be specified")
+	case net.ParseIP(host) != nil:
+		return nil
+	case len(validation.IsDNS1123Subdomain(strings.ToLower(strings.TrimSuffix(host, ".")))) != 0:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.nodePortExternalEndpoint.address is not a valid IP address or hostname: %q", endpoint.Address)
+	}
+	return nil
+}
+
+// validateNodePortExternalEndpoint validates the given ingresscontroller's
+// external endpoint, if it specifies one.  An external endpoint can only be
+// specified with the NodePortService endpoint publishing strategy.
+func validateNodePortExternalEndpoint(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	endpoint := overrides.NodePortExternalEndpoint
+	if endpoint == nil {
+		return nil
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type != operatorv1.NodePortServiceStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.nodePortExternalEndpoint can only be used with the %q endpoint publishing strategy", operatorv1.NodePortServiceStrategyType)
+	}
+	return ValidateNodePortExternalEndpoint(endpoint)
+}
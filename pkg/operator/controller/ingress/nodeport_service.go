@@ -142,6 +142,9 @@ func desiredNodePortService(ic *operatorv1.IngressController, deploymentRef meta
 	if !wantMetricsPort {
 		service.Spec.Ports = service.Spec.Ports[0:2]
 	}
+	if http3Enabled(ic) {
+		service.Spec.Ports = append(service.Spec.Ports, http3ServicePort())
+	}
 
 	if v, err := shouldUseLocalWithFallback(ic, service); err != nil {
 		return true, service, err
@@ -149,6 +152,12 @@ func desiredNodePortService(ic *operatorv1.IngressController, deploymentRef meta
 		service.Annotations[localWithFallbackAnnotation] = ""
 	}
 
+	propagated, err := propagatedMetadataForIngressController(ic)
+	if err != nil {
+		return true, service, err
+	}
+	applyPropagatedMetadata(&service.ObjectMeta, propagated)
+
 	return true, service, nil
 }
 
@@ -222,12 +231,17 @@ func nodePortServiceChanged(current, expected *corev1.Service) (bool, *corev1.Se
 		changed = true
 	}
 
+	if propagatedMetadataChanged(&current.ObjectMeta, &expected.ObjectMeta) {
+		changed = true
+	}
+
 	if !changed {
 		return false, nil
 	}
 
 	updated := current.DeepCopy()
 	updated.Spec = expected.Spec
+	copyPropagatedMetadata(&updated.ObjectMeta, &current.ObjectMeta, &expected.ObjectMeta)
 
 	if updated.Annotations == nil {
 		updated.Annotations = map[string]string{}
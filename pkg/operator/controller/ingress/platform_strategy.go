@@ -0,0 +1,147 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// metalLBCRDName is the name of the CRD that the MetalLB operator
+	// installs.  The presence of this CRD indicates that MetalLB can
+	// provision load balancers on platforms that lack a cloud load
+	// balancer.
+	metalLBCRDName = "metallbs.metallb.io"
+
+	// strategyUnsupportedOnPlatformReason is the reason for the
+	// "EndpointPublishingStrategySupported" status condition when the
+	// platform cannot satisfy the ingresscontroller's endpoint publishing
+	// strategy.
+	strategyUnsupportedOnPlatformReason = "StrategyUnsupportedOnPlatform"
+)
+
+// platformsWithoutLoadBalancers is the set of platforms that do not provide a
+// load balancer implementation for services of type LoadBalancer.  On these
+// platforms, the "LoadBalancerService" endpoint publishing strategy requires
+// MetalLB or some other add-on load balancer implementation.  Platforms that
+// are not in this set are assumed to have a load balancer implementation,
+// either natively or (for the "External" platform) by way of the platform's
+// cloud controller manager.
+var platformsWithoutLoadBalancers = map[configv1.PlatformType]struct{}{
+	configv1.BareMetalPlatformType: {},
+	configv1.LibvirtPlatformType:   {},
+	configv1.NonePlatformType:      {},
+	configv1.NutanixPlatformType:   {},
+	configv1.OvirtPlatformType:     {},
+	configv1.VSpherePlatformType:   {},
+}
+
+// loadBalancerImplementationForIngressController returns the load balancer
+// implementation that the given ingresscontroller specifies using
+// spec.unsupportedConfigOverrides.loadBalancerImplementation, or the empty
+// string if it specifies none or spec.unsupportedConfigOverrides cannot be
+// decoded.  This is a hint to the operator that some load balancer
+// implementation is present even though the operator cannot detect it.
+func loadBalancerImplementationForIngressController(ic *operatorv1.IngressController) string {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return ""
+	}
+	return overrides.LoadBalancerImplementation
+}
+
+// metalLBInstalled returns a Boolean value indicating whether MetalLB is
+// installed, and an error value.
+func metalLBInstalled(cl client.Reader) (bool, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	name := types.NamespacedName{Name: metalLBCRDName}
+	if err := cl.Get(context.TODO(), name, crd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get CRD %q: %w", metalLBCRDName, err)
+	}
+	return true, nil
+}
+
+// needsLoadBalancerImplementationCheck returns a Boolean value indicating
+// whether computeEndpointPublishingStrategySupportedCondition needs to know
+// whether an add-on load balancer implementation is installed in order to
+// compute the condition for the given ingresscontroller.
+func needsLoadBalancerImplementationCheck(ic *operatorv1.IngressController, platform *configv1.PlatformStatus, service *corev1.Service) bool {
+	if ic.Status.EndpointPublishingStrategy == nil || ic.Status.EndpointPublishingStrategy.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return false
+	}
+	if _, ok := platformsWithoutLoadBalancers[platform.Type]; !ok {
+		return false
+	}
+	if service != nil && service.Spec.LoadBalancerClass != nil {
+		return false
+	}
+	return len(loadBalancerImplementationForIngressController(ic)) == 0
+}
+
+// computeEndpointPublishingStrategySupportedCondition computes the
+// ingresscontroller's "EndpointPublishingStrategySupported" status condition,
+// which indicates whether the platform can satisfy the ingresscontroller's
+// endpoint publishing strategy.  Only the "LoadBalancerService" strategy has
+// platform requirements: the platform must provide a load balancer
+// implementation, the service must specify a load balancer class, the
+// ingresscontroller must specify the loadBalancerImplementation unsupported
+// config override, or MetalLB must be installed.  The installed argument is the
+// result of checking whether MetalLB is installed, which is only consulted if
+// the other requirements are not met.
+func computeEndpointPublishingStrategySupportedCondition(ic *operatorv1.IngressController, platform *configv1.PlatformStatus, service *corev1.Service, installed bool) operatorv1.OperatorCondition {
+	supported := func(reason, message string) operatorv1.OperatorCondition {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerEndpointPublishingStrategySupportedConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		}
+	}
+
+	if ic.Status.EndpointPublishingStrategy == nil || ic.Status.EndpointPublishingStrategy.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return supported("StrategySupported", "The endpoint publishing strategy has no platform requirements")
+	}
+	if _, ok := platformsWithoutLoadBalancers[platform.Type]; !ok {
+		return supported("StrategySupported", fmt.Sprintf("The %s platform provides a load balancer implementation", platform.Type))
+	}
+	if service != nil && service.Spec.LoadBalancerClass != nil {
+		return supported("LoadBalancerClassSpecified", fmt.Sprintf("The load balancer service specifies the %q load balancer class", *service.Spec.LoadBalancerClass))
+	}
+	if impl := loadBalancerImplementationForIngressController(ic); len(impl) != 0 {
+		return supported("LoadBalancerImplementationSpecified", fmt.Sprintf("The ingresscontroller specifies the %q load balancer implementation", impl))
+	}
+	if installed {
+		return supported("MetalLBInstalled", "MetalLB is installed and can provision the load balancer")
+	}
+
+	var unsupported []string
+	for platformType := range platformsWithoutLoadBalancers {
+		unsupported = append(unsupported, string(platformType))
+	}
+	sort.Strings(unsupported)
+	return operatorv1.OperatorCondition{
+		Type:   IngressControllerEndpointPublishingStrategySupportedConditionType,
+		Status: operatorv1.ConditionFalse,
+		Reason: strategyUnsupportedOnPlatformReason,
+		Message: fmt.Sprintf("The %s endpoint publishing strategy requires a load balancer implementation, which the %s platform does not provide.  "+
+			"The following platforms require MetalLB or another load balancer implementation for this strategy: %s.  "+
+			"Install MetalLB, specify spec.unsupportedConfigOverrides.loadBalancerImplementation if another implementation is installed, "+
+			"or use the %s or %s endpoint publishing strategy.",
+			operatorv1.LoadBalancerServiceStrategyType, platform.Type, strings.Join(unsupported, ", "),
+			operatorv1.HostNetworkStrategyType, operatorv1.NodePortServiceStrategyType),
+	}
+}
@@ -0,0 +1,177 @@
+package ingress
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_computeEndpointPublishingStrategySupportedCondition verifies that
+// computeEndpointPublishingStrategySupportedCondition reports the
+// "LoadBalancerService" strategy as unsupported on exactly those platforms that
+// lack a load balancer implementation, and that other strategies are supported
+// on every platform.
+func Test_computeEndpointPublishingStrategySupportedCondition(t *testing.T) {
+	platforms := []configv1.PlatformType{
+		configv1.AWSPlatformType,
+		configv1.AzurePlatformType,
+		configv1.BareMetalPlatformType,
+		configv1.GCPPlatformType,
+		configv1.LibvirtPlatformType,
+		configv1.OpenStackPlatformType,
+		configv1.NonePlatformType,
+		configv1.VSpherePlatformType,
+		configv1.OvirtPlatformType,
+		configv1.IBMCloudPlatformType,
+		configv1.KubevirtPlatformType,
+		configv1.EquinixMetalPlatformType,
+		configv1.PowerVSPlatformType,
+		configv1.AlibabaCloudPlatformType,
+		configv1.NutanixPlatformType,
+		configv1.ExternalPlatformType,
+	}
+	unsupported := map[configv1.PlatformType]bool{
+		configv1.BareMetalPlatformType: true,
+		configv1.LibvirtPlatformType:   true,
+		configv1.NonePlatformType:      true,
+		configv1.NutanixPlatformType:   true,
+		configv1.OvirtPlatformType:     true,
+		configv1.VSpherePlatformType:   true,
+	}
+	strategies := []operatorv1.EndpointPublishingStrategyType{
+		operatorv1.LoadBalancerServiceStrategyType,
+		operatorv1.HostNetworkStrategyType,
+		operatorv1.NodePortServiceStrategyType,
+		operatorv1.PrivateStrategyType,
+	}
+
+	for _, platform := range platforms {
+		for _, strategy := range strategies {
+			t.Run(string(platform)+"/"+string(strategy), func(t *testing.T) {
+				ic := &operatorv1.IngressController{
+					Status: operatorv1.IngressControllerStatus{
+						EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{Type: strategy},
+					},
+				}
+				platformStatus := &configv1.PlatformStatus{Type: platform}
+				expectSupported := strategy != operatorv1.LoadBalancerServiceStrategyType || !unsupported[platform]
+				if needsCheck := needsLoadBalancerImplementationCheck(ic, platformStatus, nil); needsCheck == expectSupported {
+					t.Errorf("expected needsLoadBalancerImplementationCheck to return %t, got %t", !expectSupported, needsCheck)
+				}
+				condition := computeEndpointPublishingStrategySupportedCondition(ic, platformStatus, nil, false)
+				switch {
+				case expectSupported && condition.Status != operatorv1.ConditionTrue:
+					t.Errorf("expected the strategy to be supported, got %+v", condition)
+				case !expectSupported && (condition.Status != operatorv1.ConditionFalse || condition.Reason != strategyUnsupportedOnPlatformReason):
+					t.Errorf("expected the strategy to be unsupported with reason %s, got %+v", strategyUnsupportedOnPlatformReason, condition)
+				}
+			})
+		}
+	}
+}
+
+// Test_computeEndpointPublishingStrategySupportedConditionWithImplementation
+// verifies that the "LoadBalancerService" strategy is supported on a platform
+// without a native load balancer implementation if the service specifies a
+// load balancer class, the ingresscontroller specifies a load balancer
+// implementation, or MetalLB is installed.
+func Test_computeEndpointPublishingStrategySupportedConditionWithImplementation(t *testing.T) {
+	class := "example.com/lb"
+	testCases := []struct {
+		name         string
+		overrides    string
+		service      *corev1.Service
+		installed    bool
+		expectStatus operatorv1.ConditionStatus
+		expectReason string
+	}{
+		{
+			name:         "no implementation",
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: strategyUnsupportedOnPlatformReason,
+		},
+		{
+			name:         "service specifies a load balancer class",
+			service:      &corev1.Service{Spec: corev1.ServiceSpec{LoadBalancerClass: &class}},
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "LoadBalancerClassSpecified",
+		},
+		{
+			name:         "ingresscontroller specifies an implementation",
+			overrides:    `{"loadBalancerImplementation":"kube-vip"}`,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "LoadBalancerImplementationSpecified",
+		},
+		{
+			name:         "invalid overrides",
+			overrides:    `{"loadBalancerImplementation":`,
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: strategyUnsupportedOnPlatformReason,
+		},
+		{
+			name:         "metallb installed",
+			installed:    true,
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "MetalLBInstalled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+						Type: operatorv1.LoadBalancerServiceStrategyType,
+					},
+				},
+			}
+			platformStatus := &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType}
+			condition := computeEndpointPublishingStrategySupportedCondition(ic, platformStatus, tc.service, tc.installed)
+			if condition.Status != tc.expectStatus || condition.Reason != tc.expectReason {
+				t.Errorf("expected status %s and reason %s, got %+v", tc.expectStatus, tc.expectReason, condition)
+			}
+		})
+	}
+}
+
+// Test_metalLBInstalled verifies that metalLBInstalled detects the MetalLB CRD.
+func Test_metalLBInstalled(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: metalLBCRDName},
+	}
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name            string
+		existingObjects []client.Object
+		expectInstalled bool
+	}{
+		{name: "metallb not installed", expectInstalled: false},
+		{name: "metallb installed", existingObjects: []client.Object{crd}, expectInstalled: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.existingObjects...).Build()
+			installed, err := metalLBInstalled(cl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if installed != tc.expectInstalled {
+				t.Errorf("expected installed=%t, got %t", tc.expectInstalled, installed)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// propagatedLabelsAnnotation is the annotation in which the operator
+	// records the keys of the labels that it has propagated from the
+	// ingresscontroller to an object so that it can remove them when they
+	// are dropped from the ingresscontroller.
+	propagatedLabelsAnnotation = "ingress.operator.openshift.io/propagated-labels"
+	// propagatedAnnotationsAnnotation is the annotation in which the
+	// operator records the keys of the annotations that it has propagated
+	// from the ingresscontroller to an object.
+	propagatedAnnotationsAnnotation = "ingress.operator.openshift.io/propagated-annotations"
+)
+
+// reservedMetadataDomains are the domains of label and annotation keys that
+// are reserved for the operator, the platform, and Kubernetes and therefore
+// cannot be propagated from an ingresscontroller.  Subdomains are reserved as
+// well.
+var reservedMetadataDomains = []string{
+	"openshift.io",
+	"kubernetes.io",
+	"k8s.io",
+}
+
+// reservedUnprefixedMetadataKeys are label and annotation keys without a
+// domain prefix that the operator sets on the objects that it manages.
+var reservedUnprefixedMetadataKeys = sets.New[string]("app", "router")
+
+// propagatedMetadata describes labels and annotations that the operator adds
+// to the router deployment, its pod template, and the router's services.
+type propagatedMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// propagatedMetadataForIngressController returns the labels and annotations
+// that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func propagatedMetadataForIngressController(ic *operatorv1.IngressController) (*propagatedMetadata, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.PropagatedMetadata, nil
+}
+
+// validatePropagatedMetadata validates the labels and annotations that the
+// given ingresscontroller specifies for propagation, if it specifies any.  The
+// keys and label values must be syntactically valid, and the keys must not
+// collide with keys that are reserved for the operator.
+func validatePropagatedMetadata(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	metadata := overrides.PropagatedMetadata
+	if metadata == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range sets.List(sets.KeySet(metadata.Labels)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("invalid propagated label key %q: %s", key, strings.Join(msgs, "; ")))
+		} else if isReservedMetadataKey(key) {
+			errs = append(errs, fmt.Errorf("propagated label key %q is reserved", key))
+		}
+		if msgs := validation.IsValidLabelValue(metadata.Labels[key]); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("invalid value %q for propagated label %q: %s", metadata.Labels[key], key, strings.Join(msgs, "; ")))
+		}
+	}
+	for _, key := range sets.List(sets.KeySet(metadata.Annotations)) {
+		if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+			errs = append(errs, fmt.Errorf("invalid propagated annotation key %q: %s", key, strings.Join(msgs, "; ")))
+		} else if isReservedMetadataKey(key) || isManagedServiceAnnotation(key) {
+			errs = append(errs, fmt.Errorf("propagated annotation key %q is reserved", key))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// isReservedMetadataKey returns a Boolean value indicating whether the given
+// label or annotation key is reserved for the operator, the platform, or
+// Kubernetes.
+func isReservedMetadataKey(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return reservedUnprefixedMetadataKeys.Has(key)
+	}
+	for _, domain := range reservedMetadataDomains {
+		if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedServiceAnnotation returns a Boolean value indicating whether the
+// given annotation key is one that the operator manages on the router's
+// services.
+func isManagedServiceAnnotation(key string) bool {
+	return managedLoadBalancerServiceAnnotations.Has(key) ||
+		managedInternalServiceAnnotations.Has(key) ||
+		managedNodePortServiceAnnotations.Has(key)
+}
+
+// applyPropagatedMetadata adds the given labels and annotations to the given
+// object metadata, along with annotations that record their keys.  Keys that
+// the object metadata already has are not overwritten.
+func applyPropagatedMetadata(meta *metav1.ObjectMeta, metadata *propagatedMetadata) {
+	if metadata == nil {
+		return
+	}
+	var labelKeys, annotationKeys []string
+	for key, value := range metadata.Labels {
+		if _, ok := meta.Labels[key]; ok {
+			continue
+		}
+		if meta.Labels == nil {
+			meta.Labels = map[string]string{}
+		}
+		meta.Labels[key] = value
+		labelKeys = append(labelKeys, key)
+	}
+	for key, value := range metadata.Annotations {
+		if _, ok := meta.Annotations[key]; ok {
+			continue
+		}
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		meta.Annotations[key] = value
+		annotationKeys = append(annotationKeys, key)
+	}
+	if meta.Annotations == nil && (len(labelKeys) != 0 || len(annotationKeys) != 0) {
+		meta.Annotations = map[string]string{}
+	}
+	if len(labelKeys) != 0 {
+		sort.Strings(labelKeys)
+		meta.Annotations[propagatedLabelsAnnotation] = strings.Join(labelKeys, ",")
+	}
+	if len(annotationKeys) != 0 {
+		sort.Strings(annotationKeys)
+		meta.Annotations[propagatedAnnotationsAnnotation] = strings.Join(annotationKeys, ",")
+	}
+}
+
+// propagatedKeys returns the keys that the given annotation on the given
+// object metadata records.
+func propagatedKeys(meta *metav1.ObjectMeta, annotation string) []string {
+	value, ok := meta.Annotations[annotation]
+	if !ok || len(value) == 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// propagatedMetadataOf returns the labels and annotations that the operator
+// has propagated to the given object metadata, including the annotations that
+// record their keys, or nil maps if there are none.
+func propagatedMetadataOf(meta *metav1.ObjectMeta) (map[string]string, map[string]string) {
+	var labels, annotations map[string]string
+	for _, key := range propagatedKeys(meta, propagatedLabelsAnnotation) {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[key] = meta.Labels[key]
+	}
+	for _, key := range propagatedKeys(meta, propagatedAnnotationsAnnotation) {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = meta.Annotations[key]
+	}
+	for _, key := range []string{propagatedLabelsAnnotation, propagatedAnnotationsAnnotation} {
+		if value, ok := meta.Annotations[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = value
+		}
+	}
+	return labels, annotations
+}
+
+// propagatedMetadataChanged returns a Boolean value indicating whether the
+// labels and annotations that the operator has propagated to the current
+// object metadata differ from the ones in the expected object metadata.
+func propagatedMetadataChanged(current, expected *metav1.ObjectMeta) bool {
+	currentLabels, currentAnnotations := propagatedMetadataOf(current)
+	expectedLabels, expectedAnnotations := propagatedMetadataOf(expected)
+	return !equalStringMaps(currentLabels, expectedLabels) || !equalStringMaps(currentAnnotations, expectedAnnotations)
+}
+
+// copyPropagatedMetadata removes from the updated object metadata the labels
+// and annotations that the operator propagated to the current object metadata
+// and then copies the propagated labels and annotations from the expected
+// object metadata.  Other labels and annotations are left as they are.
+func copyPropagatedMetadata(updated, current, expected *metav1.ObjectMeta) {
+	currentLabels, currentAnnotations := propagatedMetadataOf(current)
+	for key := range currentLabels {
+		delete(updated.Labels, key)
+	}
+	for key := range currentAnnotations {
+		delete(updated.Annotations, key)
+	}
+	expectedLabels, expectedAnnotations := propagatedMetadataOf(expected)
+	for key, value := range expectedLabels {
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+		updated.Labels[key] = value
+	}
+	for key, value := range expectedAnnotations {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[key] = value
+	}
+}
+
+// equalStringMaps returns a Boolean value indicating whether the given maps
+// have the same entries.  A nil map is equal to an empty map.
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
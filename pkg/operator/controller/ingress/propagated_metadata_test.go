@@ -0,0 +1,279 @@
+package ingress
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_desiredRouterDeploymentPropagatedMetadata verifies that
+// desiredRouterDeployment merges the labels and annotations from the
+// ingresscontroller onto the deployment and its pod template without
+// overwriting the labels that the operator sets.
+func Test_desiredRouterDeploymentPropagatedMetadata(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"propagatedMetadata":{"labels":{"cost-center":"1234","team":"net"},"annotations":{"example.com/owner":"Network Edge"}}}`)}
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+
+	for _, meta := range []*metav1.ObjectMeta{&deployment.ObjectMeta, &deployment.Spec.Template.ObjectMeta} {
+		for key, value := range map[string]string{"cost-center": "1234", "team": "net"} {
+			if meta.Labels[key] != value {
+				t.Errorf("expected label %s=%s, got %q", key, value, meta.Labels[key])
+			}
+		}
+		if meta.Annotations["example.com/owner"] != "Network Edge" {
+			t.Errorf("expected annotation example.com/owner, got %q", meta.Annotations["example.com/owner"])
+		}
+		if v := meta.Annotations[propagatedLabelsAnnotation]; v != "cost-center,team" {
+			t.Errorf("expected annotation %s=cost-center,team, got %q", propagatedLabelsAnnotation, v)
+		}
+		if v := meta.Annotations[propagatedAnnotationsAnnotation]; v != "example.com/owner" {
+			t.Errorf("expected annotation %s=example.com/owner, got %q", propagatedAnnotationsAnnotation, v)
+		}
+	}
+	selector := controller.IngressControllerDeploymentPodSelector(ic).MatchLabels
+	for key, value := range selector {
+		if deployment.Spec.Template.Labels[key] != value {
+			t.Errorf("expected pod selector label %s=%s, got %q", key, value, deployment.Spec.Template.Labels[key])
+		}
+	}
+	if len(deployment.Spec.Selector.MatchLabels) != len(selector) {
+		t.Errorf("expected the pod selector not to include propagated labels, got %v", deployment.Spec.Selector.MatchLabels)
+	}
+}
+
+// Test_deploymentConfigChangedPropagatedMetadata verifies that
+// deploymentConfigChanged adds and removes propagated labels and annotations,
+// preserves labels and annotations that it did not propagate, and only
+// changes the pod template when the propagated metadata changes.
+func Test_deploymentConfigChangedPropagatedMetadata(t *testing.T) {
+	testCases := []struct {
+		description   string
+		currentMeta   string
+		expectedMeta  string
+		mutate        func(*metav1.ObjectMeta)
+		expectChanged bool
+		expectLabels  map[string]string
+		expectAbsent  []string
+		expectRollout bool
+	}{
+		{
+			description:   "no change",
+			currentMeta:   `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectedMeta:  `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectChanged: false,
+		},
+		{
+			description:   "label added",
+			currentMeta:   "",
+			expectedMeta:  `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectChanged: true,
+			expectLabels:  map[string]string{"team": "net"},
+			expectRollout: true,
+		},
+		{
+			description:   "label value changed",
+			currentMeta:   `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectedMeta:  `{"propagatedMetadata":{"labels":{"team":"edge"}}}`,
+			expectChanged: true,
+			expectLabels:  map[string]string{"team": "edge"},
+			expectRollout: true,
+		},
+		{
+			description:   "label removed",
+			currentMeta:   `{"propagatedMetadata":{"labels":{"team":"net","cost-center":"1234"}}}`,
+			expectedMeta:  `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectChanged: true,
+			expectLabels:  map[string]string{"team": "net"},
+			expectAbsent:  []string{"cost-center"},
+			expectRollout: true,
+		},
+		{
+			description:   "all propagated metadata removed",
+			currentMeta:   `{"propagatedMetadata":{"labels":{"team":"net"},"annotations":{"example.com/owner":"me"}}}`,
+			expectedMeta:  "",
+			expectChanged: true,
+			expectAbsent:  []string{"team", "example.com/owner", propagatedLabelsAnnotation, propagatedAnnotationsAnnotation},
+			expectRollout: true,
+		},
+		{
+			description:  "unrelated annotation added by another actor",
+			currentMeta:  `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectedMeta: `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			mutate: func(meta *metav1.ObjectMeta) {
+				meta.Annotations["example.com/other"] = "value"
+			},
+			expectChanged: false,
+		},
+		{
+			description:  "propagated label removed from the deployment only",
+			currentMeta:  `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			expectedMeta: `{"propagatedMetadata":{"labels":{"team":"net"}}}`,
+			mutate: func(meta *metav1.ObjectMeta) {
+				delete(meta.Labels, "team")
+			},
+			expectChanged: true,
+			expectLabels:  map[string]string{"team": "net"},
+			expectRollout: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.currentMeta)}
+			current, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if current.Annotations == nil {
+				current.Annotations = map[string]string{}
+			}
+			current.Annotations["deployment.kubernetes.io/revision"] = "1"
+			if tc.mutate != nil {
+				tc.mutate(&current.ObjectMeta)
+			}
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.expectedMeta)}
+			expected, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+
+			changed, updated := deploymentConfigChanged(current, expected)
+			if changed != tc.expectChanged {
+				t.Fatalf("expected deploymentConfigChanged to return %t, got %t", tc.expectChanged, changed)
+			}
+			if !changed {
+				return
+			}
+			if changedAgain, _ := deploymentConfigChanged(updated, expected); changedAgain {
+				t.Error("expected deploymentConfigChanged to return false for the updated deployment")
+			}
+			if updated.Annotations["deployment.kubernetes.io/revision"] != "1" {
+				t.Error("expected the updated deployment to preserve annotations that the operator did not propagate")
+			}
+			for _, meta := range []*metav1.ObjectMeta{&updated.ObjectMeta, &updated.Spec.Template.ObjectMeta} {
+				for key, value := range tc.expectLabels {
+					if meta.Labels[key] != value {
+						t.Errorf("expected label %s=%s, got %q", key, value, meta.Labels[key])
+					}
+				}
+				for _, key := range tc.expectAbsent {
+					if _, ok := meta.Labels[key]; ok {
+						t.Errorf("expected label %s to be removed", key)
+					}
+					if _, ok := meta.Annotations[key]; ok {
+						t.Errorf("expected annotation %s to be removed", key)
+					}
+				}
+			}
+			currentHash := current.Spec.Template.Labels[controller.ControllerDeploymentHashLabel]
+			updatedHash := updated.Spec.Template.Labels[controller.ControllerDeploymentHashLabel]
+			if rollout := currentHash != updatedHash; rollout != tc.expectRollout {
+				t.Errorf("expected rollout to be %t, got %t", tc.expectRollout, rollout)
+			}
+		})
+	}
+}
+
+// Test_servicePropagatedMetadata verifies that the operator merges the
+// propagated labels and annotations onto the router's services and removes
+// them when they are dropped from the ingresscontroller.
+func Test_servicePropagatedMetadata(t *testing.T) {
+	deploymentRef := metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "router-default",
+		UID:        "1",
+		Controller: &[]bool{true}[0],
+	}
+	desiredServices := map[string]func(*operatorv1.IngressController) *corev1.Service{
+		"internal": func(ic *operatorv1.IngressController) *corev1.Service {
+			return desiredInternalIngressControllerService(ic, deploymentRef)
+		},
+		"nodeport": func(ic *operatorv1.IngressController) *corev1.Service {
+			ic.Status.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.NodePortServiceStrategyType}
+			_, svc, err := desiredNodePortService(ic, deploymentRef, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return svc
+		},
+		"loadbalancer": func(ic *operatorv1.IngressController) *corev1.Service {
+			ic.Status.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{
+				Type:         operatorv1.LoadBalancerServiceStrategyType,
+				LoadBalancer: &operatorv1.LoadBalancerStrategy{Scope: operatorv1.ExternalLoadBalancer},
+			}
+			platform := &configv1.PlatformStatus{Type: configv1.AWSPlatformType}
+			_, svc, err := desiredLoadBalancerService(ic, deploymentRef, platform, false, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return svc
+		},
+	}
+	servicesChanged := map[string]func(current, expected *corev1.Service) (bool, *corev1.Service){
+		"internal":     internalServiceChanged,
+		"nodeport":     nodePortServiceChanged,
+		"loadbalancer": loadBalancerServiceChanged,
+	}
+	newIngressController := func(overrides string) *operatorv1.IngressController {
+		return &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: operatorv1.IngressControllerSpec{
+				UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(overrides)},
+			},
+		}
+	}
+
+	for name, desired := range desiredServices {
+		t.Run(name, func(t *testing.T) {
+			current := desired(newIngressController(`{"propagatedMetadata":{"labels":{"team":"net","cost-center":"1234"},"annotations":{"example.com/owner":"me"}}}`))
+			if current.Labels["team"] != "net" || current.Labels["cost-center"] != "1234" {
+				t.Errorf("expected propagated labels, got %v", current.Labels)
+			}
+			if current.Annotations["example.com/owner"] != "me" {
+				t.Errorf("expected propagated annotation, got %v", current.Annotations)
+			}
+			if current.Labels[manifests.OwningIngressControllerLabel] != "default" {
+				t.Errorf("expected operator-owned label to be preserved, got %v", current.Labels)
+			}
+			current.Labels["example.com/other"] = "value"
+
+			expected := desired(newIngressController(`{"propagatedMetadata":{"labels":{"team":"net"}}}`))
+			changed, updated := servicesChanged[name](current, expected)
+			if !changed {
+				t.Fatal("expected the service to be changed")
+			}
+			if updated.Labels["team"] != "net" {
+				t.Errorf("expected label team=net, got %v", updated.Labels)
+			}
+			if _, ok := updated.Labels["cost-center"]; ok {
+				t.Errorf("expected label cost-center to be removed, got %v", updated.Labels)
+			}
+			if _, ok := updated.Annotations["example.com/owner"]; ok {
+				t.Errorf("expected annotation example.com/owner to be removed, got %v", updated.Annotations)
+			}
+			if _, ok := updated.Annotations[propagatedAnnotationsAnnotation]; ok {
+				t.Errorf("expected annotation %s to be removed, got %v", propagatedAnnotationsAnnotation, updated.Annotations)
+			}
+			if updated.Labels["example.com/other"] != "value" {
+				t.Errorf("expected label that the operator did not propagate to be preserved, got %v", updated.Labels)
+			}
+			if changedAgain, _ := servicesChanged[name](updated, expected); changedAgain {
+				t.Error("expected the updated service not to be changed")
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ingress
+
+import (
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// RouterProxyProtocolVersionEnvName is the router environment variable
+	// that specifies the version of the PROXY protocol that the
+	// ingresscontroller's external load balancer sends.
+	RouterProxyProtocolVersionEnvName = "ROUTER_PROXY_PROTOCOL_VERSION"
+	// RouterProxyProtocolTLVHeadersEnvName is the router environment
+	// variable that specifies which PROXY protocol version 2 TLVs the
+	// router forwards to backends, and in which request headers, as a
+	// comma-separated list of type:header pairs, such as
+	// "0xe0:X-LB-Id,0xe1:X-Tenant".
+	RouterProxyProtocolTLVHeadersEnvName = "ROUTER_PROXY_PROTOCOL_TLV_HEADERS"
+
+	// proxyProtocolV1 and proxyProtocolV2 are the values of the
+	// "proxyProtocol.version" unsupported config override.
+	proxyProtocolV1 = "v1"
+	proxyProtocolV2 = "v2"
+
+	// minCustomProxyProtocolTLVType and maxCustomProxyProtocolTLVType
+	// bound the range of TLV types that the PROXY protocol specification
+	// reserves for custom, application-specific use.
+	minCustomProxyProtocolTLVType = 0xe0
+	maxCustomProxyProtocolTLVType = 0xef
+)
+
+// proxyProtocolTLVHeaderRegexp matches a valid HTTP header field name.
+var proxyProtocolTLVHeaderRegexp = regexp.MustCompile("^[-!#$%&'*+.^_`|~0-9A-Za-z]+$")
+
+// reservedProxyProtocolTLVHeaders are header names, in canonical form, that
+// the router sets itself and that TLVs therefore may not be forwarded in.
+var reservedProxyProtocolTLVHeaders = sets.NewString("Host", "Forwarded", "X-Forwarded-For", "X-Forwarded-Host", "X-Forwarded-Port", "X-Forwarded-Proto", "X-Forwarded-Proto-Version")
+
+// proxyProtocolConfig describes the PROXY protocol options that an
+// ingresscontroller specifies using the "proxyProtocol" unsupported config
+// override.  The options apply only if the ingresscontroller uses the
+// HostNetwork or NodePortService endpoint publishing strategy with the PROXY
+// protocol enabled.
+//
+// HAProxy accepts both versions of the PROXY protocol on a listener that
+// accepts the PROXY protocol at all, so the version does not change which
+// connections the router accepts.  This means that, when the version changes,
+// router pods with the old configuration and pods with the new configuration
+// both accept the connections and health checks that the load balancer sends
+// during the rolling update.  Only version 2 carries TLVs, so TLVs can only be
+// forwarded if the version is "v2".
+type proxyProtocolConfig struct {
+	// Version is the version of the PROXY protocol that the external load
+	// balancer sends: "v1" (the default) or "v2".
+	Version string `json:"version,omitempty"`
+	// TLVHeaders specifies custom TLVs that the router forwards to
+	// backends in request headers.
+	TLVHeaders []proxyProtocolTLVHeader `json:"tlvHeaders,omitempty"`
+}
+
+// proxyProtocolTLVHeader specifies a PROXY protocol version 2 TLV that the
+// router forwards in a request header.
+type proxyProtocolTLVHeader struct {
+	// Type is the TLV type, such as "0xE0".  It must be in the range that
+	// the specification reserves for custom use, 0xE0 through 0xEF.
+	Type string `json:"type"`
+	// Header is the name of the request header in which the router
+	// forwards the TLV's value.
+	Header string `json:"header"`
+}
+
+// proxyProtocolConfigForIngressController returns the PROXY protocol options
+// that the given ingresscontroller specifies using the "proxyProtocol"
+// unsupported config override, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func proxyProtocolConfigForIngressController(ic *operatorv1.IngressController) (*proxyProtocolConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.ProxyProtocol, nil
+}
+
+// parseProxyProtocolTLVType parses the given TLV type, which may be in
+// hexadecimal with a "0x" prefix or in decimal, and returns an error if it is
+// not in the range reserved for custom use.
+func parseProxyProtocolTLVType(value string) (uint8, error) {
+	v, err := strconv.ParseUint(value, 0, 8)
+	if err != nil || v < minCustomProxyProtocolTLVType || v > maxCustomProxyProtocolTLVType {
+		return 0, fmt.Errorf("must be a TLV type between %#x and %#x: %q", minCustomProxyProtocolTLVType, maxCustomProxyProtocolTLVType, value)
+	}
+	return uint8(v), nil
+}
+
+// validateProxyProtocolConfig validates the given ingresscontroller's PROXY
+// protocol options, if it specifies any.
+func validateProxyProtocolConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.ProxyProtocol
+	if config == nil {
+		return nil
+	}
+	var errs []error
+	switch config.Version {
+	case "", proxyProtocolV1, proxyProtocolV2:
+	default:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.proxyProtocol.version must be %q or %q: %q", proxyProtocolV1, proxyProtocolV2, config.Version))
+	}
+	if len(config.TLVHeaders) != 0 && config.Version != proxyProtocolV2 {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.proxyProtocol.tlvHeaders requires version %q", proxyProtocolV2))
+	}
+	types := sets.NewInt()
+	headers := sets.NewString()
+	for i, tlv := range config.TLVHeaders {
+		field := fmt.Sprintf("spec.unsupportedConfigOverrides.proxyProtocol.tlvHeaders[%d]", i)
+		if t, err := parseProxyProtocolTLVType(tlv.Type); err != nil {
+			errs = append(errs, fmt.Errorf("%s.type %w", field, err))
+		} else if types.Has(int(t)) {
+			errs = append(errs, fmt.Errorf("%s.type is a duplicate: %q", field, tlv.Type))
+		} else {
+			types.Insert(int(t))
+		}
+		canonical := strings.ToLower(tlv.Header)
+		switch {
+		case !proxyProtocolTLVHeaderRegexp.MatchString(tlv.Header):
+			errs = append(errs, fmt.Errorf("%s.header must be a valid header name: %q", field, tlv.Header))
+		case reservedProxyProtocolTLVHeaders.Has(textproto.CanonicalMIMEHeaderKey(tlv.Header)):
+			errs = append(errs, fmt.Errorf("%s.header is reserved: %q", field, tlv.Header))
+		case headers.Has(canonical):
+			errs = append(errs, fmt.Errorf("%s.header is a duplicate: %q", field, tlv.Header))
+		default:
+			headers.Insert(canonical)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// proxyProtocolEnv returns the router environment variables for the given
+// PROXY protocol options.  The options apply only to ingresscontrollers that
+// use the HostNetwork or NodePortService endpoint publishing strategy and
+// enable the PROXY protocol, so proxyNeeded must be true and the strategy must
+// be one of those for any variables to be returned.
+func proxyProtocolEnv(ic *operatorv1.IngressController, proxyNeeded bool, config *proxyProtocolConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if !proxyNeeded || config == nil || config.Version != proxyProtocolV2 {
+		return env
+	}
+	if eps := ic.Status.EndpointPublishingStrategy; eps == nil || (eps.Type != operatorv1.HostNetworkStrategyType && eps.Type != operatorv1.NodePortServiceStrategyType) {
+		return env
+	}
+	env = append(env, corev1.EnvVar{Name: RouterProxyProtocolVersionEnvName, Value: proxyProtocolV2})
+	var pairs []string
+	for _, tlv := range config.TLVHeaders {
+		t, err := parseProxyProtocolTLVType(tlv.Type)
+		if err != nil || !proxyProtocolTLVHeaderRegexp.MatchString(tlv.Header) {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%#x:%s", t, tlv.Header))
+	}
+	if len(pairs) != 0 {
+		// Sort the pairs so that reordering the TLVs in the override
+		// does not cause a rollout.
+		sort.Strings(pairs)
+		env = append(env, corev1.EnvVar{Name: RouterProxyProtocolTLVHeadersEnvName, Value: strings.Join(pairs, ",")})
+	}
+	return env
+}
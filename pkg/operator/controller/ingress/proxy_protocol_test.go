@@ -0,0 +1,197 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateProxyProtocolConfig verifies that validateProxyProtocolConfig
+// accepts only known versions and custom TLVs with valid, unique header names,
+// and only with version 2.
+func Test_validateProxyProtocolConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "version 1",
+			overrides:   `{"proxyProtocol":{"version":"v1"}}`,
+		},
+		{
+			description: "version 2 with TLVs",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"X-LB-Id"},{"type":"239","header":"X-Tenant"}]}}`,
+		},
+		{
+			description: "unknown version",
+			overrides:   `{"proxyProtocol":{"version":"v3"}}`,
+			expectError: true,
+		},
+		{
+			description: "TLVs with version 1",
+			overrides:   `{"proxyProtocol":{"tlvHeaders":[{"type":"0xE0","header":"X-LB-Id"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "TLV type outside the custom range",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0x05","header":"X-Unique-Id"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "TLV type that is not a number",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"custom","header":"X-LB-Id"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "duplicate TLV type",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"X-A"},{"type":"224","header":"X-B"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "invalid header name",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"X LB Id"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "reserved header name",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"x-forwarded-for"}]}}`,
+			expectError: true,
+		},
+		{
+			description: "duplicate header name",
+			overrides:   `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"X-LB-Id"},{"type":"0xE1","header":"x-lb-id"}]}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateProxyProtocolConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentProxyProtocolV2 verifies that
+// desiredRouterDeployment sets the PROXY protocol version and TLV environment
+// variables from the "proxyProtocol" unsupported config override only for
+// ingresscontrollers that use the HostNetwork or NodePortService endpoint
+// publishing strategy with the PROXY protocol, and that such an
+// ingresscontroller with multiple replicas is rolled out gradually.
+func TestDesiredRouterDeploymentProxyProtocolV2(t *testing.T) {
+	const overrides = `{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE1","header":"X-Tenant"},{"type":"0xE0","header":"X-LB-Id"}]}}`
+	testCases := []struct {
+		name      string
+		strategy  *operatorv1.EndpointPublishingStrategy
+		overrides string
+		expectEnv []envData
+	}{
+		{
+			name: "HostNetwork with PROXY and version 2",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:        operatorv1.HostNetworkStrategyType,
+				HostNetwork: &operatorv1.HostNetworkStrategy{Protocol: operatorv1.ProxyProtocol, HTTPPort: 80, HTTPSPort: 443, StatsPort: 1936},
+			},
+			overrides: overrides,
+			expectEnv: []envData{
+				{"ROUTER_USE_PROXY_PROTOCOL", true, "true"},
+				{RouterProxyProtocolVersionEnvName, true, "v2"},
+				{RouterProxyProtocolTLVHeadersEnvName, true, "0xe0:X-LB-Id,0xe1:X-Tenant"},
+			},
+		},
+		{
+			name: "NodePortService with PROXY and version 2 without TLVs",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:     operatorv1.NodePortServiceStrategyType,
+				NodePort: &operatorv1.NodePortStrategy{Protocol: operatorv1.ProxyProtocol},
+			},
+			overrides: `{"proxyProtocol":{"version":"v2"}}`,
+			expectEnv: []envData{
+				{"ROUTER_USE_PROXY_PROTOCOL", true, "true"},
+				{RouterProxyProtocolVersionEnvName, true, "v2"},
+				{RouterProxyProtocolTLVHeadersEnvName, false, ""},
+			},
+		},
+		{
+			name: "NodePortService with PROXY and version 1",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:     operatorv1.NodePortServiceStrategyType,
+				NodePort: &operatorv1.NodePortStrategy{Protocol: operatorv1.ProxyProtocol},
+			},
+			overrides: `{"proxyProtocol":{"version":"v1"}}`,
+			expectEnv: []envData{
+				{"ROUTER_USE_PROXY_PROTOCOL", true, "true"},
+				{RouterProxyProtocolVersionEnvName, false, ""},
+				{RouterProxyProtocolTLVHeadersEnvName, false, ""},
+			},
+		},
+		{
+			name: "NodePortService without PROXY",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:     operatorv1.NodePortServiceStrategyType,
+				NodePort: &operatorv1.NodePortStrategy{Protocol: operatorv1.TCPProtocol},
+			},
+			overrides: overrides,
+			expectEnv: []envData{
+				{"ROUTER_USE_PROXY_PROTOCOL", false, ""},
+				{RouterProxyProtocolVersionEnvName, false, ""},
+				{RouterProxyProtocolTLVHeadersEnvName, false, ""},
+			},
+		},
+		{
+			name: "Private with PROXY",
+			strategy: &operatorv1.EndpointPublishingStrategy{
+				Type:    operatorv1.PrivateStrategyType,
+				Private: &operatorv1.PrivateStrategy{Protocol: operatorv1.ProxyProtocol},
+			},
+			overrides: overrides,
+			expectEnv: []envData{
+				{"ROUTER_USE_PROXY_PROTOCOL", true, "true"},
+				{RouterProxyProtocolVersionEnvName, false, ""},
+				{RouterProxyProtocolTLVHeadersEnvName, false, ""},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			three := int32(3)
+			ic.Spec.Replicas = &three
+			ic.Status.EndpointPublishingStrategy = tc.strategy
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			proxyNeeded, err := IsProxyProtocolNeeded(ic, infraConfig.Status.PlatformStatus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, proxyNeeded, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			// HAProxy accepts both PROXY protocol versions, so
+			// old and new pods can serve side by side while the
+			// deployment rolls out.
+			if deployment.Spec.Strategy.Type != appsv1.RollingUpdateDeploymentStrategyType {
+				t.Errorf("expected a rolling update strategy, got %q", deployment.Spec.Strategy.Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package ingress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// minReloadInterval and maxReloadInterval are the bounds to which
+	// spec.tuningOptions.reloadInterval is capped.
+	minReloadInterval = 1 * time.Second
+	maxReloadInterval = 120 * time.Second
+	// defaultReloadInterval is the reload interval that the router uses
+	// if spec.tuningOptions.reloadInterval is zero.
+	defaultReloadInterval = 5 * time.Second
+
+	// minHardStopAfter and maxHardStopAfter are the bounds of the
+	// "tuningOptions.hardStopAfter" unsupported config override.
+	minHardStopAfter = 1 * time.Second
+	maxHardStopAfter = 1 * time.Hour
+
+	// defaultHealthCheckInterval is the interval between backend health
+	// checks that the router uses if spec.tuningOptions.healthCheckInterval
+	// is not set.
+	defaultHealthCheckInterval = 5 * time.Second
+	// haproxyHealthCheckFall is how many successive backend health checks
+	// must fail before HAProxy marks a server as down.  The router uses
+	// HAProxy's default.
+	haproxyHealthCheckFall = 3
+
+	// routerReloadSecondsMetric is the name of the router's summary metric
+	// for the time spent reloading HAProxy.  The summary's sample count is
+	// the number of reloads that the router has performed.
+	routerReloadSecondsMetric = "template_router_reload_seconds"
+	// routerReloadRateWindow is the minimum time between two samples of
+	// the router's reload count from which the operator computes the
+	// observed reload rate.
+	routerReloadRateWindow = 1 * time.Minute
+)
+
+// ReloadIntervalForIngressController returns the interval at which the router
+// for the given ingresscontroller coalesces configuration changes into a
+// single reload, which is spec.tuningOptions.reloadInterval capped to the
+// allowed bounds, or the default if it is zero.
+func ReloadIntervalForIngressController(ic *operatorv1.IngressController) time.Duration {
+	return capReloadIntervalValue(ic.Spec.TuningOptions.ReloadInterval.Duration)
+}
+
+// hardStopAfterForIngressController returns how long after a reload the router
+// for the given ingresscontroller kills the old HAProxy processes that are
+// still draining connections, in the format of time.ParseDuration, as specified
+// using the "tuningOptions.hardStopAfter" unsupported config override, or the
+// empty string if the ingresscontroller does not specify it.  Without a bound,
+// frequent reloads with long-lived connections can accumulate old processes.
+// An error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func hardStopAfterForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.TuningOptions.HardStopAfter, nil
+}
+
+// validateHardStopAfter validates the hard-stop-after duration that the given
+// ingresscontroller specifies, if it specifies one.  The duration must be
+// between 1s and 1h.
+func validateHardStopAfter(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	value := overrides.TuningOptions.HardStopAfter
+	if len(value) == 0 {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.tuningOptions.hardStopAfter is invalid: %w", err)
+	}
+	if d < minHardStopAfter || d > maxHardStopAfter {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.tuningOptions.hardStopAfter must be between %v and %v: %q", minHardStopAfter, maxHardStopAfter, value)
+	}
+	return nil
+}
+
+// healthCheckIntervalForIngressController returns the interval between backend
+// health checks that the router for the given ingresscontroller uses.
+func healthCheckIntervalForIngressController(ic *operatorv1.IngressController) time.Duration {
+	if v := ic.Spec.TuningOptions.HealthCheckInterval; v != nil && v.Duration >= 1*time.Second {
+		return v.Duration
+	}
+	return defaultHealthCheckInterval
+}
+
+// routerReloadSample is a sample of a router pod's reload count.
+type routerReloadSample struct {
+	count     float64
+	timestamp time.Time
+}
+
+// routerReloadRate is the observed reload rate of an ingresscontroller's
+// router pods.
+type routerReloadRate struct {
+	// baselines are the samples of each router pod's reload count from
+	// which the next rate is computed, keyed by pod name.
+	baselines map[string]routerReloadSample
+	// perMinute is the most recently computed average number of reloads
+	// per minute per router pod.
+	perMinute float64
+	// known is true if perMinute has been computed.
+	known bool
+}
+
+// routerReloadRateTracker tracks the observed reload rates of the
+// ingresscontrollers' routers in between reconciliations.
+type routerReloadRateTracker struct {
+	mu    sync.Mutex
+	rates map[types.NamespacedName]*routerReloadRate
+}
+
+// newRouterReloadRateTracker returns a new routerReloadRateTracker.
+func newRouterReloadRateTracker() *routerReloadRateTracker {
+	return &routerReloadRateTracker{rates: map[types.NamespacedName]*routerReloadRate{}}
+}
+
+// observe records the given samples of the reload counts of the given
+// ingresscontroller's router pods, keyed by pod name, and returns the average
+// number of reloads per minute per router pod and a Boolean value indicating
+// whether the rate is known.  The rate is computed from the change in each
+// pod's reload count since its baseline sample once at least
+// routerReloadRateWindow has elapsed; until then, the previously computed rate
+// is returned.  A pod whose count decreased has restarted, so its sample
+// becomes its new baseline.
+func (t *routerReloadRateTracker) observe(name types.NamespacedName, samples map[string]routerReloadSample) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rate, ok := t.rates[name]
+	if !ok {
+		rate = &routerReloadRate{baselines: map[string]routerReloadSample{}}
+		t.rates[name] = rate
+	}
+	var reloads, minutes float64
+	baselines := map[string]routerReloadSample{}
+	for pod, sample := range samples {
+		baseline, ok := rate.baselines[pod]
+		switch {
+		case !ok, sample.count < baseline.count:
+			baselines[pod] = sample
+		case sample.timestamp.Sub(baseline.timestamp) < routerReloadRateWindow:
+			baselines[pod] = baseline
+		default:
+			reloads += sample.count - baseline.count
+			minutes += sample.timestamp.Sub(baseline.timestamp).Minutes()
+			baselines[pod] = sample
+		}
+	}
+	rate.baselines = baselines
+	if minutes != 0 {
+		rate.perMinute = reloads / minutes
+		rate.known = true
+	}
+	return rate.perMinute, rate.known
+}
+
+// forget discards the observed reload rate of the given ingresscontroller.
+func (t *routerReloadRateTracker) forget(name types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.rates, name)
+}
+
+// parseRouterReloadCount returns the number of reloads that the router has
+// performed according to the given metrics in the Prometheus text format.
+func parseRouterReloadCount(in io.Reader) (float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(in)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse router metrics: %w", err)
+	}
+	family, ok := families[routerReloadSecondsMetric]
+	if !ok {
+		return 0, fmt.Errorf("router metrics do not include %s", routerReloadSecondsMetric)
+	}
+	var count float64
+	for _, metric := range family.GetMetric() {
+		count += float64(metric.GetSummary().GetSampleCount())
+	}
+	return count, nil
+}
+
+// computeReloadIntervalCondition computes the ingresscontroller's
+// "ReloadInterval" status condition and returns a Boolean value indicating
+// whether the condition applies, which is the case if the ingresscontroller
+// specifies spec.tuningOptions.reloadInterval.  The condition reports the
+// reload interval that the router uses.  The router's observed reload rate is
+// reported in the ingress_controller_router_reload_rate metric rather than in
+// the condition so that the condition only changes when the configuration
+// does.
+//
+// The router only applies endpoint changes when it reloads, so in between
+// reloads, HAProxy relies on backend health checks to stop sending traffic to
+// servers that have gone away.  The condition is false if the health checks
+// cannot detect such a server before the next reload, in which case raising the
+// reload interval directly prolongs the time for which HAProxy keeps sending
+// traffic to it.
+func computeReloadIntervalCondition(ic *operatorv1.IngressController) (operatorv1.OperatorCondition, bool) {
+	requested := ic.Spec.TuningOptions.ReloadInterval.Duration
+	if requested == 0 {
+		return operatorv1.OperatorCondition{}, false
+	}
+	interval := ReloadIntervalForIngressController(ic)
+	message := fmt.Sprintf("The router coalesces configuration changes for %s before reloading.", interval)
+	if interval != requested {
+		message += fmt.Sprintf("  The requested reload interval of %s is outside the allowed range of %s to %s and has been adjusted.", requested, minReloadInterval, maxReloadInterval)
+	}
+	healthCheckInterval := healthCheckIntervalForIngressController(ic)
+	if detection := haproxyHealthCheckFall * healthCheckInterval; interval > defaultReloadInterval && detection >= interval {
+		return operatorv1.OperatorCondition{
+			Type:   IngressControllerReloadIntervalConditionType,
+			Status: operatorv1.ConditionFalse,
+			Reason: "HealthChecksSlowerThanReloads",
+			Message: fmt.Sprintf("%s  In between reloads, HAProxy relies on backend health checks to stop sending traffic to endpoints that have gone away, but with a health check interval of %s, health checks take %s to detect such an endpoint, which is not shorter than the reload interval.  "+
+				"Decrease spec.tuningOptions.healthCheckInterval or spec.tuningOptions.reloadInterval.", message, healthCheckInterval, detection),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerReloadIntervalConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "ReloadIntervalApplied",
+		Message: message,
+	}, true
+}
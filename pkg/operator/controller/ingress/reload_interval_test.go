@@ -0,0 +1,248 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test_parseRouterReloadCount verifies that parseRouterReloadCount reads the
+// reload count from the router's reload duration summary.
+func Test_parseRouterReloadCount(t *testing.T) {
+	const metrics = `# HELP haproxy_up Was the last scrape of HAProxy successful.
+# TYPE haproxy_up gauge
+haproxy_up 1
+# HELP template_router_reload_seconds Measures the time spent reloading the router in seconds.
+# TYPE template_router_reload_seconds summary
+template_router_reload_seconds{quantile="0.5"} 0.1
+template_router_reload_seconds{quantile="0.9"} 0.2
+template_router_reload_seconds_sum 4.2
+template_router_reload_seconds_count 42
+`
+	count, err := parseRouterReloadCount(strings.NewReader(metrics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("expected 42 reloads, got %v", count)
+	}
+
+	if _, err := parseRouterReloadCount(strings.NewReader("haproxy_up 1\n")); err == nil {
+		t.Error("expected an error for metrics without the reload summary, got nil")
+	}
+}
+
+// Test_routerReloadRateTracker verifies that routerReloadRateTracker computes
+// the average reload rate per router pod once routerReloadRateWindow has
+// elapsed and rebases the samples of restarted pods.
+func Test_routerReloadRateTracker(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default"}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(count float64, after time.Duration) routerReloadSample {
+		return routerReloadSample{count: count, timestamp: start.Add(after)}
+	}
+	steps := []struct {
+		name        string
+		samples     map[string]routerReloadSample
+		expectRate  float64
+		expectKnown bool
+	}{
+		{
+			name:    "first samples",
+			samples: map[string]routerReloadSample{"router-a": sample(100, 0), "router-b": sample(200, 0)},
+		},
+		{
+			name:    "window not elapsed",
+			samples: map[string]routerReloadSample{"router-a": sample(110, 30*time.Second), "router-b": sample(210, 30*time.Second)},
+		},
+		{
+			name:        "window elapsed",
+			samples:     map[string]routerReloadSample{"router-a": sample(112, 2*time.Minute), "router-b": sample(204, 2*time.Minute)},
+			expectRate:  4,
+			expectKnown: true,
+		},
+		{
+			name:        "router-b restarted",
+			samples:     map[string]routerReloadSample{"router-a": sample(115, 3*time.Minute), "router-b": sample(1, 3*time.Minute)},
+			expectRate:  3,
+			expectKnown: true,
+		},
+		{
+			name:        "window not elapsed, previous rate",
+			samples:     map[string]routerReloadSample{"router-a": sample(116, 3*time.Minute+10*time.Second), "router-b": sample(2, 3*time.Minute+10*time.Second)},
+			expectRate:  3,
+			expectKnown: true,
+		},
+	}
+	tracker := newRouterReloadRateTracker()
+	for _, step := range steps {
+		rate, known := tracker.observe(name, step.samples)
+		if rate != step.expectRate || known != step.expectKnown {
+			t.Fatalf("%s: expected (%v, %t), got (%v, %t)", step.name, step.expectRate, step.expectKnown, rate, known)
+		}
+	}
+	tracker.forget(name)
+	if _, known := tracker.observe(name, map[string]routerReloadSample{"router-a": sample(120, 10*time.Minute)}); known {
+		t.Error("expected the rate to be unknown after forgetting it")
+	}
+}
+
+// Test_computeReloadIntervalCondition verifies that
+// computeReloadIntervalCondition reports the effective reload interval and that
+// it reports backend health checks that cannot detect a removed endpoint before
+// the next reload.
+func Test_computeReloadIntervalCondition(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+	testCases := []struct {
+		name                string
+		reloadInterval      time.Duration
+		healthCheckInterval *metav1.Duration
+		expectOK            bool
+		expectStatus        operatorv1.ConditionStatus
+		expectReason        string
+		expectMessageHas    []string
+	}{
+		{
+			name: "reload interval not set",
+		},
+		{
+			name:             "reload interval set",
+			reloadInterval:   30 * time.Second,
+			expectOK:         true,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "ReloadIntervalApplied",
+			expectMessageHas: []string{"for 30s before reloading"},
+		},
+		{
+			name:             "capped",
+			reloadInterval:   5 * time.Minute,
+			expectOK:         true,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "ReloadIntervalApplied",
+			expectMessageHas: []string{"for 2m0s before reloading", "5m0s is outside the allowed range"},
+		},
+		{
+			name:                "short reload interval with slow health checks",
+			reloadInterval:      5 * time.Second,
+			healthCheckInterval: duration(30 * time.Second),
+			expectOK:            true,
+			expectStatus:        operatorv1.ConditionTrue,
+			expectReason:        "ReloadIntervalApplied",
+		},
+		{
+			name:                "long reload interval with fast health checks",
+			reloadInterval:      120 * time.Second,
+			healthCheckInterval: duration(10 * time.Second),
+			expectOK:            true,
+			expectStatus:        operatorv1.ConditionTrue,
+			expectReason:        "ReloadIntervalApplied",
+		},
+		{
+			name:                "long reload interval with slow health checks",
+			reloadInterval:      60 * time.Second,
+			healthCheckInterval: duration(20 * time.Second),
+			expectOK:            true,
+			expectStatus:        operatorv1.ConditionFalse,
+			expectReason:        "HealthChecksSlowerThanReloads",
+			expectMessageHas:    []string{"health checks take 1m0s"},
+		},
+		{
+			name:             "long reload interval with default health checks",
+			reloadInterval:   10 * time.Second,
+			expectOK:         true,
+			expectStatus:     operatorv1.ConditionFalse,
+			expectReason:     "HealthChecksSlowerThanReloads",
+			expectMessageHas: []string{"health check interval of 5s"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					TuningOptions: operatorv1.IngressControllerTuningOptions{
+						ReloadInterval:      metav1.Duration{Duration: tc.reloadInterval},
+						HealthCheckInterval: tc.healthCheckInterval,
+					},
+				},
+			}
+			condition, ok := computeReloadIntervalCondition(ic)
+			if ok != tc.expectOK {
+				t.Fatalf("expected ok=%t, got %t", tc.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if condition.Status != tc.expectStatus || condition.Reason != tc.expectReason {
+				t.Errorf("expected %s=%s with reason %s, got %s=%s with reason %s: %s", IngressControllerReloadIntervalConditionType, tc.expectStatus, tc.expectReason, condition.Type, condition.Status, condition.Reason, condition.Message)
+			}
+			for _, s := range tc.expectMessageHas {
+				if !strings.Contains(condition.Message, s) {
+					t.Errorf("expected condition message to contain %q, got %q", s, condition.Message)
+				}
+			}
+		})
+	}
+}
+
+// Test_validateHardStopAfter verifies that validateHardStopAfter accepts
+// hard-stop-after durations between 1s and 1h and rejects other durations and
+// values that are not durations.
+func Test_validateHardStopAfter(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "tuning options without hardStopAfter",
+			overrides:   `{"tuningOptions":{}}`,
+		},
+		{
+			description: "minimum",
+			overrides:   `{"tuningOptions":{"hardStopAfter":"1s"}}`,
+		},
+		{
+			description: "maximum",
+			overrides:   `{"tuningOptions":{"hardStopAfter":"1h"}}`,
+		},
+		{
+			description: "below the minimum",
+			overrides:   `{"tuningOptions":{"hardStopAfter":"999ms"}}`,
+			expectError: true,
+		},
+		{
+			description: "above the maximum",
+			overrides:   `{"tuningOptions":{"hardStopAfter":"61m"}}`,
+			expectError: true,
+		},
+		{
+			description: "not a duration",
+			overrides:   `{"tuningOptions":{"hardStopAfter":"30"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			switch err := validateHardStopAfter(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package ingress
+
+import (
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+const (
+	// RouterRolloutChangesAnnotation is the annotation that the operator
+	// sets on the router deployment's pod template, and thus on the
+	// ReplicaSet and pods of the rollout, when it updates the deployment
+	// in a way that rolls out new pods.  The value is a comma-separated
+	// list of the changes that caused the rollout, relative to the
+	// previous pod template.  Each change is one of the following:
+	//
+	//   - "image/<container>" if a container's image changed;
+	//   - "env/<container>/<name>" if an environment variable of a
+	//     container was added, removed, or changed;
+	//   - "volume/<name>" if a volume was added, removed, or changed;
+	//   - "container/<name>" or "initContainer/<name>" if a container or
+	//     init container was added or removed;
+	//   - "other" if the pod template changed in any other way.
+	//
+	// Only names are listed, never values, so that the annotation does not
+	// reveal the contents of environment variables or volumes.
+	RouterRolloutChangesAnnotation = "ingress.operator.openshift.io/rollout-changes"
+
+	// otherRolloutChange is the change that
+	// routerDeploymentRolloutChanges reports for pod template changes
+	// that it does not otherwise describe.
+	otherRolloutChange = "other"
+)
+
+// routerDeploymentRolloutChanges returns the sorted list of changes, in the
+// format of RouterRolloutChangesAnnotation, from the current router
+// deployment's pod template to the updated one.  The result is empty if the
+// update does not roll out new pods.
+func routerDeploymentRolloutChanges(current, updated *appsv1.Deployment) []string {
+	if deploymentTemplateHash(current) == deploymentTemplateHash(updated) {
+		return nil
+	}
+	currentSpec, updatedSpec := &current.Spec.Template.Spec, &updated.Spec.Template.Spec
+	changes := map[string]struct{}{}
+	containerChanges(changes, "container", currentSpec.Containers, updatedSpec.Containers)
+	containerChanges(changes, "initContainer", currentSpec.InitContainers, updatedSpec.InitContainers)
+	for name := range changedNames(volumesByName(currentSpec.Volumes), volumesByName(updatedSpec.Volumes)) {
+		changes["volume/"+name] = struct{}{}
+	}
+	if len(changes) == 0 {
+		changes[otherRolloutChange] = struct{}{}
+	}
+	result := make([]string, 0, len(changes))
+	for change := range changes {
+		result = append(result, change)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// containerChanges adds to changes the added and removed containers, using the
+// given kind, and the image and environment variable changes of the containers
+// that are in both of the given lists.
+func containerChanges(changes map[string]struct{}, kind string, current, updated []corev1.Container) {
+	currentByName := map[string]*corev1.Container{}
+	for i := range current {
+		currentByName[current[i].Name] = &current[i]
+	}
+	updatedByName := map[string]*corev1.Container{}
+	for i := range updated {
+		updatedByName[updated[i].Name] = &updated[i]
+	}
+	for name := range currentByName {
+		if _, ok := updatedByName[name]; !ok {
+			changes[kind+"/"+name] = struct{}{}
+		}
+	}
+	for name, u := range updatedByName {
+		c, ok := currentByName[name]
+		if !ok {
+			changes[kind+"/"+name] = struct{}{}
+			continue
+		}
+		if c.Image != u.Image {
+			changes["image/"+name] = struct{}{}
+		}
+		for env := range changedNames(envByName(c.Env), envByName(u.Env)) {
+			changes["env/"+name+"/"+env] = struct{}{}
+		}
+	}
+}
+
+// envByName returns the given environment variables indexed by name.
+func envByName(env []corev1.EnvVar) map[string]interface{} {
+	m := make(map[string]interface{}, len(env))
+	for i := range env {
+		m[env[i].Name] = env[i]
+	}
+	return m
+}
+
+// volumesByName returns the given volumes indexed by name.
+func volumesByName(volumes []corev1.Volume) map[string]interface{} {
+	m := make(map[string]interface{}, len(volumes))
+	for i := range volumes {
+		m[volumes[i].Name] = volumes[i]
+	}
+	return m
+}
+
+// changedNames returns the names that are in only one of the given maps or
+// whose values differ between them.
+func changedNames(current, updated map[string]interface{}) map[string]struct{} {
+	names := map[string]struct{}{}
+	for name, c := range current {
+		if u, ok := updated[name]; !ok || !equality.Semantic.DeepEqual(c, u) {
+			names[name] = struct{}{}
+		}
+	}
+	for name := range updated {
+		if _, ok := current[name]; !ok {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// setRouterRolloutChanges sets RouterRolloutChangesAnnotation on the updated
+// deployment's pod template to describe the changes from the current
+// deployment and returns the changes.  If the update does not roll out new
+// pods, the annotation is left as is because it still describes the rollout
+// that created the current pods.
+func setRouterRolloutChanges(current, updated *appsv1.Deployment) []string {
+	changes := routerDeploymentRolloutChanges(current, updated)
+	if len(changes) == 0 {
+		return nil
+	}
+	if updated.Spec.Template.Annotations == nil {
+		updated.Spec.Template.Annotations = map[string]string{}
+	}
+	updated.Spec.Template.Annotations[RouterRolloutChangesAnnotation] = strings.Join(changes, ",")
+	return changes
+}
+
+// recordRouterRollout emits an event on the given ingresscontroller that lists
+// the given changes, which caused a rollout of its router deployment.
+func (r *reconciler) recordRouterRollout(ci *operatorv1.IngressController, deployment *appsv1.Deployment, changes []string) {
+	if len(changes) == 0 || r.recorder == nil {
+		return
+	}
+	r.recorder.Eventf(ci, "Normal", "RouterRollout", "Rolling out router deployment %s/%s because of changes to: %s", deployment.Namespace, deployment.Name, strings.Join(changes, ", "))
+}
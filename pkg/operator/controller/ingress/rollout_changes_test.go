@@ -0,0 +1,124 @@
+package ingress
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Test_setRouterRolloutChanges verifies that updating a router deployment
+// annotates the pod template with the environment variables, images, volumes,
+// and containers that changed, without their values, and that updates that do
+// not roll out new pods leave the annotation alone.
+func Test_setRouterRolloutChanges(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	original, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	original.Spec.Template.Annotations[RouterRolloutChangesAnnotation] = "image/router"
+
+	testCases := []struct {
+		description string
+		mutate      func(*appsv1.Deployment)
+		// expectChanges is the expected annotation value, or nil if
+		// the update is not expected to roll out new pods.
+		expectChanges []string
+	}{
+		{
+			description:   "no change",
+			mutate:        func(*appsv1.Deployment) {},
+			expectChanges: nil,
+		},
+		{
+			description: "replicas changed",
+			mutate: func(d *appsv1.Deployment) {
+				replicas := *d.Spec.Replicas + 1
+				d.Spec.Replicas = &replicas
+			},
+			expectChanges: nil,
+		},
+		{
+			description: "image changed",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Image = "quay.io/openshift/router@sha256:0123"
+			},
+			expectChanges: []string{"image/router"},
+		},
+		{
+			description: "env var added, changed, and removed",
+			mutate: func(d *appsv1.Deployment) {
+				env := d.Spec.Template.Spec.Containers[0].Env
+				var updated []corev1.EnvVar
+				for _, v := range env {
+					switch v.Name {
+					case "ROUTER_SERVICE_NAME":
+						continue
+					case "ROUTER_CIPHERS":
+						v.Value = "secret-value"
+					}
+					updated = append(updated, v)
+				}
+				d.Spec.Template.Spec.Containers[0].Env = append(updated, corev1.EnvVar{Name: "ROUTER_NEW", Value: "secret"})
+			},
+			expectChanges: []string{"env/router/ROUTER_CIPHERS", "env/router/ROUTER_NEW", "env/router/ROUTER_SERVICE_NAME"},
+		},
+		{
+			description: "volume changed",
+			mutate: func(d *appsv1.Deployment) {
+				for i := range d.Spec.Template.Spec.Volumes {
+					if d.Spec.Template.Spec.Volumes[i].Secret != nil {
+						d.Spec.Template.Spec.Volumes[i].Secret.SecretName = "other-secret"
+						return
+					}
+				}
+				t.Fatal("expected the deployment to have a secret volume")
+			},
+			expectChanges: []string{"volume/default-certificate"},
+		},
+		{
+			description: "init container added",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "init-image"}}
+			},
+			expectChanges: []string{"initContainer/init"},
+		},
+		{
+			description: "probe changed",
+			mutate: func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].LivenessProbe.ProbeHandler.HTTPGet.Port = intstr.FromInt(1937)
+				d.Spec.Template.Spec.Containers[0].LivenessProbe.PeriodSeconds = 42
+			},
+			expectChanges: []string{"other"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			current := original.DeepCopy()
+			expected := original.DeepCopy()
+			tc.mutate(expected)
+			changed, updated := deploymentConfigChanged(current, expected)
+			if !changed {
+				updated = current.DeepCopy()
+			}
+			changes := setRouterRolloutChanges(current, updated)
+			if !reflect.DeepEqual(changes, tc.expectChanges) {
+				t.Errorf("expected changes %v, got %v", tc.expectChanges, changes)
+			}
+			expectedAnnotation := "image/router"
+			if tc.expectChanges != nil {
+				expectedAnnotation = strings.Join(tc.expectChanges, ",")
+			}
+			if actual := updated.Spec.Template.Annotations[RouterRolloutChangesAnnotation]; actual != expectedAnnotation {
+				t.Errorf("expected annotation %q, got %q", expectedAnnotation, actual)
+			}
+			if changed && deploymentTemplateHash(updated) != deploymentTemplateHash(expected) {
+				t.Error("expected the annotation not to affect the pod template hash")
+			}
+		})
+	}
+}
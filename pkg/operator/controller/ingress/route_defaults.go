@@ -0,0 +1,194 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// routeBalanceAnnotation is the route annotation that specifies the
+// load-balancing algorithm for the route.
+const routeBalanceAnnotation = "haproxy.router.openshift.io/balance"
+
+const (
+	// routeDefaultInsecurePolicyKey is the key in
+	// spec.unsupportedConfigOverrides.routeDefaults that specifies the
+	// default insecure edge termination policy for routes that do not
+	// specify spec.tls.insecureEdgeTerminationPolicy.  Unlike the other
+	// keys, it corresponds to a route field rather than an annotation.
+	routeDefaultInsecurePolicyKey = "insecurePolicy"
+
+	// routeDefaultPassthroughBalanceKey is the key in
+	// spec.unsupportedConfigOverrides.routeDefaults that specifies the
+	// default load-balancing algorithm for passthrough routes, which the
+	// router balances at the TCP level and which thus cannot use
+	// cookie-based session affinity.  The default is "source", which
+	// hashes the client's address so that a client's connections reach
+	// the same endpoint from any router replica.  With the PROXY protocol
+	// enabled, the router hashes the client address that the PROXY
+	// protocol header specifies; otherwise, it hashes the address of the
+	// load balancer if the load balancer does not preserve the client's
+	// address.  The balance annotation on a passthrough route takes
+	// precedence, as it does for other routes.
+	routeDefaultPassthroughBalanceKey = "passthroughBalance"
+
+	// RouterDefaultInsecureEdgeTerminationPolicy is the router environment
+	// variable that specifies the insecure edge termination policy for
+	// edge-terminated and reencrypt routes that do not specify one.  Its
+	// value is "Allow", "Redirect", or "None".  Passthrough routes do not
+	// use the default: a passthrough route that does not specify a policy
+	// continues to reject insecure traffic, as if its policy were "None".
+	// The router never applies the default to a route that specifies a
+	// policy, including "None".
+	RouterDefaultInsecureEdgeTerminationPolicy = "ROUTER_DEFAULT_INSECURE_EDGE_TERMINATION_POLICY"
+)
+
+// routeDefaultInsecurePolicies maps the values that an ingresscontroller may
+// specify for the default insecure policy to the values of the router
+// environment variable.  "Disable" is the ingresscontroller's name for the
+// route API's "None" policy.
+var routeDefaultInsecurePolicies = map[string]string{
+	"Allow":    string(routev1.InsecureEdgeTerminationPolicyAllow),
+	"Redirect": string(routev1.InsecureEdgeTerminationPolicyRedirect),
+	"Disable":  string(routev1.InsecureEdgeTerminationPolicyNone),
+}
+
+// validLoadBalancingAlgorithms is the set of load-balancing algorithms that
+// the router supports for routes.
+var validLoadBalancingAlgorithms = sets.New[string]("leastconn", "random", "roundrobin", "source")
+
+// validPassthroughLoadBalancingAlgorithms is the set of load-balancing
+// algorithms that an ingresscontroller may specify as the default for
+// passthrough routes.
+var validPassthroughLoadBalancingAlgorithms = sets.New[string]("leastconn", "roundrobin", "source")
+
+// routeDefault describes a route annotation or field for which an
+// ingresscontroller may specify a shard-wide default value.
+type routeDefault struct {
+	// envName is the name of the router environment variable that
+	// specifies the default value for routes that do not specify the
+	// annotation or field.
+	envName string
+	// value validates the given default value and returns the value to
+	// use for the router environment variable.
+	value func(string) (string, error)
+}
+
+// routeDefaultAnnotations is the allow-list of route annotations for which an
+// ingresscontroller may specify default values using
+// spec.unsupportedConfigOverrides.routeDefaults.  Only annotations for which
+// the router has a corresponding environment variable are allowed: the router
+// uses the environment variable's value as the default value for routes that
+// do not specify the annotation, and the annotation on the route always takes
+// precedence.  Notably, the HSTS header annotation has no such environment
+// variable, so it cannot be defaulted; see unsupportedRouteDefaultAnnotations.
+// In addition to annotations, the allow-list has the "insecurePolicy" key for
+// the default insecure edge termination policy, for which the route's
+// spec.tls.insecureEdgeTerminationPolicy field likewise takes precedence, and
+// the "passthroughBalance" key for the default load-balancing algorithm for
+// passthrough routes, for which the balance annotation takes precedence.
+var routeDefaultAnnotations = map[string]routeDefault{
+	routeBalanceAnnotation: {
+		envName: RouterLoadBalancingAlgorithmEnvName,
+		value: func(val string) (string, error) {
+			if !validLoadBalancingAlgorithms.Has(val) {
+				return "", fmt.Errorf("unsupported load-balancing algorithm: %q", val)
+			}
+			return val, nil
+		},
+	},
+	"haproxy.router.openshift.io/timeout": {
+		envName: "ROUTER_DEFAULT_SERVER_TIMEOUT",
+		value:   clipHAProxyTimeoutValue,
+	},
+	"haproxy.router.openshift.io/timeout-tunnel": {
+		envName: "ROUTER_DEFAULT_TUNNEL_TIMEOUT",
+		value:   clipHAProxyTimeoutValue,
+	},
+	"router.openshift.io/haproxy.health.check.interval": {
+		envName: RouterBackendCheckInterval,
+		value:   clipHAProxyTimeoutValue,
+	},
+	routeDefaultInsecurePolicyKey: {
+		envName: RouterDefaultInsecureEdgeTerminationPolicy,
+		value: func(val string) (string, error) {
+			policy, ok := routeDefaultInsecurePolicies[val]
+			if !ok {
+				return "", fmt.Errorf("unsupported insecure policy %q; supported policies: %v", val, sets.List(sets.KeySet(routeDefaultInsecurePolicies)))
+			}
+			return policy, nil
+		},
+	},
+	routeDefaultPassthroughBalanceKey: {
+		envName: RouterTCPLoadBalancingAlgorithmEnvName,
+		value: func(val string) (string, error) {
+			if !validPassthroughLoadBalancingAlgorithms.Has(val) {
+				return "", fmt.Errorf("unsupported load-balancing algorithm for passthrough routes %q; supported algorithms: %v", val, sets.List(validPassthroughLoadBalancingAlgorithms))
+			}
+			return val, nil
+		},
+	},
+}
+
+// unsupportedRouteDefaultAnnotations maps route annotations that an
+// ingresscontroller might be expected to default but that the router cannot
+// default to the reason why, so that validation can explain the rejection.
+var unsupportedRouteDefaultAnnotations = map[string]string{
+	"haproxy.router.openshift.io/hsts_header": "the router has no environment variable for a default HSTS header; use spec.requiredHSTSPolicies on the cluster ingress config to require the annotation on routes instead",
+}
+
+// validateRouteDefaults validates the given ingresscontroller's route
+// annotation defaults, if it specifies any.  Each annotation must be in the
+// allow-list and must have a valid value.
+func validateRouteDefaults(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	routeDefaults := overrides.RouteDefaults
+	var errs []error
+	for _, annotation := range sets.List(sets.KeySet(routeDefaults)) {
+		def, ok := routeDefaultAnnotations[annotation]
+		if reason, unsupported := unsupportedRouteDefaultAnnotations[annotation]; !ok && unsupported {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routeDefaults has unsupported key %q: %s", annotation, reason))
+			continue
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routeDefaults has unsupported key %q; supported keys: %v", annotation, sets.List(sets.KeySet(routeDefaultAnnotations))))
+			continue
+		}
+		if len(routeDefaults[annotation]) == 0 {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routeDefaults has empty value for key %q", annotation))
+			continue
+		}
+		if _, err := def.value(routeDefaults[annotation]); err != nil {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routeDefaults has invalid value for key %q: %w", annotation, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// routeDefaultsEnv returns the router environment variables for the given
+// route annotation defaults, omitting any that would override a value that
+// the given environment variables already specify.  Annotations that are not
+// in the allow-list or that have invalid values are ignored.
+func routeDefaultsEnv(routeDefaults map[string]string, env []corev1.EnvVar) []corev1.EnvVar {
+	alreadySet := sets.New[string]()
+	for _, v := range env {
+		alreadySet.Insert(v.Name)
+	}
+	var result []corev1.EnvVar
+	for _, annotation := range sets.List(sets.KeySet(routeDefaults)) {
+		def, ok := routeDefaultAnnotations[annotation]
+		if !ok || alreadySet.Has(def.envName) || len(routeDefaults[annotation]) == 0 {
+			continue
+		}
+		value, err := def.value(routeDefaults[annotation])
+		if err != nil {
+			continue
+		}
+		result = append(result, corev1.EnvVar{Name: def.envName, Value: value})
+	}
+	return result
+}
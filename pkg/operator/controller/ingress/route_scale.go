@@ -0,0 +1,91 @@
+package ingress
+
+import (
+	"fmt"
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// RouterBlueprintRoutePoolSize is the router environment variable that
+	// specifies how many blueprint backends the dynamic configuration
+	// manager pre-allocates for each route type so that new routes can be
+	// added without a reload.
+	RouterBlueprintRoutePoolSize = "ROUTER_BLUEPRINT_ROUTE_POOL_SIZE"
+	// RouterMaxDynamicServers is the router environment variable that
+	// specifies how many dynamic server slots the dynamic configuration
+	// manager pre-allocates in each backend.
+	RouterMaxDynamicServers = "ROUTER_MAX_DYNAMIC_SERVERS"
+)
+
+// routeScaleConfig describes the route scale options that an
+// ingresscontroller specifies using spec.unsupportedConfigOverrides.  The
+// soft limits are used by the route metrics controller.
+type routeScaleConfig struct {
+	// RouteSoftLimit raises the soft limit on the number of admitted
+	// routes for the ingresscontroller.
+	RouteSoftLimit *int `json:"routeSoftLimit,omitempty"`
+	// CertificateSoftLimit raises the soft limit on the number of admitted
+	// routes with their own certificates for the ingresscontroller.
+	CertificateSoftLimit *int `json:"certificateSoftLimit,omitempty"`
+	// BlueprintRoutePoolSize sets ROUTER_BLUEPRINT_ROUTE_POOL_SIZE.
+	BlueprintRoutePoolSize *int `json:"blueprintRoutePoolSize,omitempty"`
+	// MaxDynamicServers sets ROUTER_MAX_DYNAMIC_SERVERS.
+	MaxDynamicServers *int `json:"maxDynamicServers,omitempty"`
+}
+
+// routeScaleConfigForIngressController returns the route scale options that the
+// given ingresscontroller specifies using the "routeScale" unsupported config
+// override, or nil if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func routeScaleConfigForIngressController(ic *operatorv1.IngressController) (*routeScaleConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.RouteScale, nil
+}
+
+// validateRouteScaleConfig validates the given ingresscontroller's route scale
+// options, if it specifies any.  Every specified value must be positive.
+func validateRouteScaleConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.RouteScale
+	if config == nil {
+		return nil
+	}
+	var errs []error
+	for _, v := range []struct {
+		field string
+		value *int
+	}{
+		{"routeSoftLimit", config.RouteSoftLimit},
+		{"certificateSoftLimit", config.CertificateSoftLimit},
+		{"blueprintRoutePoolSize", config.BlueprintRoutePoolSize},
+		{"maxDynamicServers", config.MaxDynamicServers},
+	} {
+		if v.value != nil && *v.value <= 0 {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routeScale.%s must be positive: %d", v.field, *v.value))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// routeScaleEnv returns the router environment variables for the given route
+// scale options.
+func routeScaleEnv(config *routeScaleConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if config == nil {
+		return env
+	}
+	if v := config.BlueprintRoutePoolSize; v != nil && *v > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterBlueprintRoutePoolSize, Value: strconv.Itoa(*v)})
+	}
+	if v := config.MaxDynamicServers; v != nil && *v > 0 {
+		env = append(env, corev1.EnvVar{Name: RouterMaxDynamicServers, Value: strconv.Itoa(*v)})
+	}
+	return env
+}
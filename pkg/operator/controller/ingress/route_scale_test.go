@@ -0,0 +1,101 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateRouteScaleConfig verifies that validateRouteScaleConfig accepts
+// only positive route scale options.
+func Test_validateRouteScaleConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "soft limits",
+			overrides:   `{"routeScale":{"routeSoftLimit":60000,"certificateSoftLimit":40000}}`,
+		},
+		{
+			description: "router tuning",
+			overrides:   `{"routeScale":{"blueprintRoutePoolSize":20,"maxDynamicServers":10}}`,
+		},
+		{
+			description: "zero soft limit",
+			overrides:   `{"routeScale":{"routeSoftLimit":0}}`,
+			expectError: true,
+		},
+		{
+			description: "negative pool size",
+			overrides:   `{"routeScale":{"blueprintRoutePoolSize":-1}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateRouteScaleConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentRouteScale verifies that desiredRouterDeployment
+// sets the router's dynamic configuration manager sizing environment variables
+// from the "routeScale" unsupported config override, and that the soft limits
+// do not affect the deployment.
+func TestDesiredRouterDeploymentRouteScale(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	expectedEnv := []envData{
+		{RouterBlueprintRoutePoolSize, false, ""},
+		{RouterMaxDynamicServers, false, ""},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, expectedEnv); err != nil {
+		t.Error(err)
+	}
+	defaultHash := deploymentTemplateHash(deployment)
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"routeScale":{"routeSoftLimit":60000}}`)}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	if hash := deploymentTemplateHash(deployment); hash != defaultHash {
+		t.Error("expected the soft limits not to change the pod template")
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"dynamicConfigManager":"true","routeScale":{"blueprintRoutePoolSize":50,"maxDynamicServers":20}}`)}
+	deployment, err = desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	expectedEnv = []envData{
+		{RouterHAProxyConfigManager, true, "true"},
+		{RouterBlueprintRoutePoolSize, true, "50"},
+		{RouterMaxDynamicServers, true, "20"},
+	}
+	if err := checkDeploymentEnvironment(t, deployment, expectedEnv); err != nil {
+		t.Error(err)
+	}
+}
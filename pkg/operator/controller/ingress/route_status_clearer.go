@@ -0,0 +1,165 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// routeStatusClearConcurrency is the maximum number of route status
+	// updates that the operator has in flight at once.
+	routeStatusClearConcurrency = 10
+	// routeStatusClearQPS is the maximum sustained rate of API requests,
+	// including retries, that the operator makes to clear route status.
+	routeStatusClearQPS = 50
+	// routeStatusClearBurst is the maximum burst of API requests that the
+	// operator makes to clear route status.
+	routeStatusClearBurst = 100
+)
+
+// routeStatusClearBackoff is the backoff for retrying a route status update
+// that fails because of a conflict.  The jitter spreads out the retries of
+// concurrent workers that conflict with the same router.
+var routeStatusClearBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 50 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+}
+
+// routeStatusClearer clears the status that an ingress controller has set on
+// routes.  The API has no bulk status update, so when a selector change or the
+// deletion of an ingress controller requires clearing the status of thousands
+// of routes, routeStatusClearer updates them in parallel using a bounded number
+// of workers that share a rate limiter, which keeps the load on the API server
+// bounded.
+type routeStatusClearer struct {
+	client      client.Client
+	concurrency int
+	limiter     flowcontrol.RateLimiter
+	backoff     wait.Backoff
+}
+
+// newRouteStatusClearer returns a routeStatusClearer with the default
+// concurrency, rate limit, and backoff.
+func newRouteStatusClearer(cl client.Client) *routeStatusClearer {
+	return &routeStatusClearer{
+		client:      cl,
+		concurrency: routeStatusClearConcurrency,
+		limiter:     flowcontrol.NewTokenBucketRateLimiter(routeStatusClearQPS, routeStatusClearBurst),
+		backoff:     routeStatusClearBackoff,
+	}
+}
+
+// clearRoutesStatus clears the status that the named ingress controller has
+// set on the given routes and returns the number of routes that it cleared
+// along with any errors.  The routes_pending_status_update metric reports the
+// number of routes that still need to be cleared.
+func (c *routeStatusClearer) clearRoutesStatus(icName string, routes []*routev1.Route) (int, []error) {
+	pending := routesPendingStatusUpdate.WithLabelValues(icName)
+	pending.Set(float64(len(routes)))
+	if len(routes) == 0 {
+		return 0, nil
+	}
+
+	workers := c.concurrency
+	if workers > len(routes) {
+		workers = len(routes)
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		cleared int
+		errs    []error
+	)
+	queue := make(chan *routev1.Route)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for route := range queue {
+				ok, err := c.clearRouteStatus(context.TODO(), route, icName)
+				mu.Lock()
+				switch {
+				case err != nil:
+					errs = append(errs, err)
+				case ok:
+					cleared++
+					pending.Dec()
+				default:
+					pending.Dec()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, route := range routes {
+		queue <- route
+	}
+	close(queue)
+	wg.Wait()
+
+	return cleared, errs
+}
+
+// clearRouteStatus clears the status that the named ingress controller has set
+// on the given route and returns a Boolean value indicating whether it cleared
+// any status.  If the update fails because of a conflict, clearRouteStatus gets
+// the current route and retries using the backoff.
+func (c *routeStatusClearer) clearRouteStatus(ctx context.Context, route *routev1.Route, icName string) (bool, error) {
+	name := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+	current := route
+	cleared := false
+	err := retry.OnError(c.backoff, kerrors.IsConflict, func() error {
+		if current == nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return err
+			}
+			current = &routev1.Route{}
+			if err := c.client.Get(ctx, name, current); err != nil {
+				if kerrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+		}
+		i := admittedIngressIndex(current, icName)
+		if i == -1 {
+			return nil
+		}
+		updated := current.DeepCopy()
+		updated.Status.Ingress = append(updated.Status.Ingress[:i], updated.Status.Ingress[i+1:]...)
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := c.client.Status().Update(ctx, updated); err != nil {
+			// Get the current route before retrying.
+			current = nil
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		cleared = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to clear route status of %s for routerName %s: %w", name, icName, err)
+	}
+	if cleared {
+		log.V(4).Info("cleared admitted status for route", "Route", name.String(), "Ingress Controller", icName)
+	}
+	return cleared, nil
+}
@@ -0,0 +1,242 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// routeStatusUpdateRecorder simulates API server latency for route status
+// updates and records the requests that a routeStatusClearer makes.
+type routeStatusUpdateRecorder struct {
+	latency time.Duration
+	// conflictEvery makes the first update of every nth route fail with a
+	// conflict, or no route if it is 0.
+	conflictEvery int
+
+	requests    atomic.Int64
+	conflicts   atomic.Int64
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+
+	mu         sync.Mutex
+	conflicted map[string]bool
+}
+
+func (r *routeStatusUpdateRecorder) interceptorFuncs() interceptor.Funcs {
+	return interceptor.Funcs{
+		Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			r.requests.Add(1)
+			return cl.Get(ctx, key, obj, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, cl client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			r.requests.Add(1)
+			n := r.inFlight.Add(1)
+			defer r.inFlight.Add(-1)
+			for {
+				max := r.maxInFlight.Load()
+				if n <= max || r.maxInFlight.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(r.latency)
+			if r.shouldConflict(obj) {
+				r.conflicts.Add(1)
+				return kerrors.NewConflict(schema.GroupResource{Group: "route.openshift.io", Resource: "routes"}, obj.GetName(), fmt.Errorf("the object has been modified"))
+			}
+			return cl.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+	}
+}
+
+func (r *routeStatusUpdateRecorder) shouldConflict(obj client.Object) bool {
+	if r.conflictEvery == 0 {
+		return false
+	}
+	var i int
+	if _, err := fmt.Sscanf(obj.GetName(), "route-%d", &i); err != nil || i%r.conflictEvery != 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conflicted == nil {
+		r.conflicted = map[string]bool{}
+	}
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	if r.conflicted[key] {
+		return false
+	}
+	r.conflicted[key] = true
+	return true
+}
+
+// newRoutesForStatusClearing returns the given number of routes that both the
+// "sharded" and "default" ingress controllers have admitted.
+func newRoutesForStatusClearing(n int) []client.Object {
+	admitted := []routev1.RouteIngressCondition{{
+		Type:   routev1.RouteAdmitted,
+		Status: corev1.ConditionTrue,
+	}}
+	routes := make([]client.Object, n)
+	for i := range routes {
+		routes[i] = &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: fmt.Sprintf("ns-%d", i%10),
+				Name:      fmt.Sprintf("route-%d", i),
+			},
+			Status: routev1.RouteStatus{
+				Ingress: []routev1.RouteIngress{
+					{RouterName: "default", Conditions: admitted},
+					{RouterName: "sharded", Conditions: admitted},
+				},
+			},
+		}
+	}
+	return routes
+}
+
+// clearRouteStatusForTest lists the routes in the given client and clears the
+// "sharded" ingress controller's status from them using the given clearer.
+func clearRouteStatusForTest(t testing.TB, cl client.Client, clearer *routeStatusClearer) (int, []error) {
+	t.Helper()
+	routeList := &routev1.RouteList{}
+	if err := cl.List(context.Background(), routeList); err != nil {
+		t.Fatalf("failed to list routes: %v", err)
+	}
+	routes := make([]*routev1.Route, len(routeList.Items))
+	for i := range routeList.Items {
+		routes[i] = &routeList.Items[i]
+	}
+	return clearer.clearRoutesStatus("sharded", routes)
+}
+
+// Test_routeStatusClearer verifies that routeStatusClearer clears the status of
+// thousands of routes faster than serial updates would, without exceeding its
+// concurrency or rate limit, and that it retries updates that fail because of
+// conflicts.
+func Test_routeStatusClearer(t *testing.T) {
+	const (
+		numRoutes   = 3000
+		latency     = time.Millisecond
+		concurrency = 20
+		qps         = 5000
+		burst       = 50
+	)
+	scheme := runtime.NewScheme()
+	routev1.Install(scheme)
+	recorder := &routeStatusUpdateRecorder{latency: latency, conflictEvery: 10}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(newRoutesForStatusClearing(numRoutes)...).
+		WithStatusSubresource(&routev1.Route{}).
+		WithInterceptorFuncs(recorder.interceptorFuncs()).
+		Build()
+	clearer := &routeStatusClearer{
+		client:      cl,
+		concurrency: concurrency,
+		limiter:     flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		backoff:     wait.Backoff{Steps: 5, Duration: time.Millisecond, Factor: 2.0, Jitter: 0.5},
+	}
+
+	start := time.Now()
+	cleared, errs := clearRouteStatusForTest(t, cl, clearer)
+	elapsed := time.Since(start)
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %d, first: %v", len(errs), errs[0])
+	}
+	if cleared != numRoutes {
+		t.Errorf("expected %d routes to be cleared, got %d", numRoutes, cleared)
+	}
+	if pending := testutil.ToFloat64(routesPendingStatusUpdate.WithLabelValues("sharded")); pending != 0 {
+		t.Errorf("expected no routes pending status update, got %v", pending)
+	}
+
+	// Serial updates would take at least numRoutes*latency.
+	if serial := numRoutes * latency; elapsed >= serial {
+		t.Errorf("expected clearing to take less than %v, took %v", serial, elapsed)
+	}
+	if max := recorder.maxInFlight.Load(); max > concurrency {
+		t.Errorf("expected at most %d concurrent updates, observed %d", concurrency, max)
+	}
+	// The token bucket allows at most burst requests plus qps requests
+	// per second.
+	requests := recorder.requests.Load()
+	if limit := int64(burst + qps*elapsed.Seconds() + 1); requests > limit {
+		t.Errorf("expected at most %d requests in %v, observed %d", limit, elapsed, requests)
+	}
+	if conflicts := recorder.conflicts.Load(); conflicts != numRoutes/10 {
+		t.Errorf("expected %d conflicts, observed %d", numRoutes/10, conflicts)
+	}
+
+	routeList := &routev1.RouteList{}
+	if err := cl.List(context.Background(), routeList); err != nil {
+		t.Fatalf("failed to list routes: %v", err)
+	}
+	for _, route := range routeList.Items {
+		if len(route.Status.Ingress) != 1 || route.Status.Ingress[0].RouterName != "default" {
+			t.Fatalf("expected route %s/%s to have only the default ingress controller's status, got %+v", route.Namespace, route.Name, route.Status.Ingress)
+		}
+	}
+
+	// Clearing again is a no-op, which makes clearing resumable.
+	requestsBefore := recorder.requests.Load()
+	if cleared, errs := clearRouteStatusForTest(t, cl, clearer); cleared != 0 || len(errs) != 0 {
+		t.Errorf("expected no routes to be cleared again, got %d cleared and errors %v", cleared, errs)
+	}
+	if requests := recorder.requests.Load() - requestsBefore; requests != 0 {
+		t.Errorf("expected no requests to clear already cleared routes, observed %d", requests)
+	}
+}
+
+// BenchmarkRouteStatusClearer compares the throughput of clearing route status
+// serially to clearing route status using the default concurrency.
+func BenchmarkRouteStatusClearer(b *testing.B) {
+	const numRoutes = 2000
+	scheme := runtime.NewScheme()
+	routev1.Install(scheme)
+	for _, concurrency := range []int{1, routeStatusClearConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				recorder := &routeStatusUpdateRecorder{latency: time.Millisecond}
+				cl := fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(newRoutesForStatusClearing(numRoutes)...).
+					WithStatusSubresource(&routev1.Route{}).
+					WithInterceptorFuncs(recorder.interceptorFuncs()).
+					Build()
+				clearer := &routeStatusClearer{
+					client:      cl,
+					concurrency: concurrency,
+					limiter:     flowcontrol.NewFakeAlwaysRateLimiter(),
+					backoff:     routeStatusClearBackoff,
+				}
+				b.StartTimer()
+				start := time.Now()
+				if _, errs := clearRouteStatusForTest(b, cl, clearer); len(errs) != 0 {
+					b.Fatalf("expected no errors, got %v", errs)
+				}
+				b.ReportMetric(numRoutes/time.Since(start).Seconds(), "routes/s")
+			}
+		})
+	}
+}
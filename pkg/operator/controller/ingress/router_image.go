@@ -0,0 +1,100 @@
+package ingress
+
+import (
+	"fmt"
+	"regexp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// digestImageReferenceRegexp matches an image reference that specifies the
+// image by its sha256 digest, such as
+// "quay.io/openshift/origin-haproxy-router@sha256:<64 hex digits>".
+var digestImageReferenceRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9._:/-]*[a-z0-9])?@sha256:[a-f0-9]{64}$`)
+
+// routerImageOverrideForIngressController returns the router image that the
+// given ingresscontroller specifies using the "routerImage" unsupported config
+// override, or the empty string if it does not specify one.  The override
+// allows a cluster administrator to roll out a new router image on a single
+// shard before the image is applied to all shards in a cluster upgrade.  The
+// canary daemonset is shared by all ingresscontrollers, so its image cannot be
+// overridden per ingresscontroller.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func routerImageOverrideForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.RouterImage, nil
+}
+
+// validateRouterImageOverride validates the given ingresscontroller's router
+// image override.  The image must be specified by digest so that the router
+// that the ingresscontroller runs cannot change without an update to the
+// ingresscontroller.
+func validateRouterImageOverride(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	image := overrides.RouterImage
+	if len(image) == 0 {
+		return nil
+	}
+	if !digestImageReferenceRegexp.MatchString(image) {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.routerImage must be an image reference with a sha256 digest: %q", image)
+	}
+	return nil
+}
+
+// routerImageForIngressController returns the router image for the given
+// ingresscontroller, which is the image from the ingresscontroller's router
+// image override if it specifies one, or else the given image from the
+// operator's release payload.
+func routerImageForIngressController(ic *operatorv1.IngressController, payloadImage string) (string, error) {
+	image, err := routerImageOverrideForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	if len(image) == 0 {
+		return payloadImage, nil
+	}
+	if !digestImageReferenceRegexp.MatchString(image) {
+		return "", fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides.routerImage: %q is not an image reference with a sha256 digest", ic.Name, image)
+	}
+	return image, nil
+}
+
+// computeRouterImageOverriddenCondition returns the ingresscontroller's
+// "RouterImageOverridden" status condition, which reports that the
+// ingresscontroller's router image diverges from the image in the operator's
+// release payload, and a Boolean value indicating whether the condition
+// applies.  The condition applies only if the ingresscontroller overrides the
+// router image.
+func computeRouterImageOverriddenCondition(ic *operatorv1.IngressController, payloadImage string) (operatorv1.OperatorCondition, bool) {
+	image, err := routerImageOverrideForIngressController(ic)
+	if err != nil || len(image) == 0 {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if image == payloadImage {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerRouterImageOverriddenConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "MatchesPayloadImage",
+			Message: fmt.Sprintf("spec.unsupportedConfigOverrides.routerImage specifies the router image from the release payload: %s.", image),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerRouterImageOverriddenConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "RouterImageOverridden",
+		Message: fmt.Sprintf("spec.unsupportedConfigOverrides.routerImage specifies the router image %s instead of the image from the release payload, %s.  Remove the override to use the image from the release payload.", image, payloadImage),
+	}, true
+}
+
+// routerImageOverrideIsUpgradeable returns an error if the given
+// ingresscontroller overrides the router image.  A cluster upgrade would not
+// update the router image of such an ingresscontroller.
+func routerImageOverrideIsUpgradeable(ic *operatorv1.IngressController) error {
+	image, err := routerImageOverrideForIngressController(ic)
+	if err != nil || len(image) == 0 {
+		return nil
+	}
+	return fmt.Errorf("spec.unsupportedConfigOverrides.routerImage overrides the router image with %s", image)
+}
@@ -0,0 +1,154 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const testRouterImageOverride = "quay.io/example/haproxy-router@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// Test_validateRouterImageOverride verifies that validateRouterImageOverride
+// accepts only image references with a sha256 digest.
+func Test_validateRouterImageOverride(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "empty image",
+			overrides:   `{"routerImage":""}`,
+		},
+		{
+			description: "digest",
+			overrides:   `{"routerImage":"` + testRouterImageOverride + `"}`,
+		},
+		{
+			description: "registry with port",
+			overrides:   `{"routerImage":"registry.example.com:5000/router@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}`,
+		},
+		{
+			description: "tag",
+			overrides:   `{"routerImage":"quay.io/example/haproxy-router:latest"}`,
+			expectError: true,
+		},
+		{
+			description: "short digest",
+			overrides:   `{"routerImage":"quay.io/example/haproxy-router@sha256:0123"}`,
+			expectError: true,
+		},
+		{
+			description: "uppercase digest",
+			overrides:   `{"routerImage":"quay.io/example/haproxy-router@sha256:0123456789ABCDEF0123456789abcdef0123456789abcdef0123456789abcdef"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateRouterImageOverride(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentRouterImageOverride verifies that
+// desiredRouterDeployment uses the router image override for the router and
+// logging sidecar containers, and that removing the override reverts to the
+// image from the release payload.
+func TestDesiredRouterDeploymentRouterImageOverride(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+		Access: &operatorv1.AccessLogging{
+			Destination: operatorv1.LoggingDestination{
+				Type:      operatorv1.ContainerLoggingDestinationType,
+				Container: &operatorv1.ContainerLoggingDestinationParameters{},
+			},
+		},
+	}
+
+	expectImage := func(description, expected string) {
+		t.Helper()
+		deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("%s: invalid router Deployment: %v", description, err)
+		}
+		if len(deployment.Spec.Template.Spec.Containers) != 2 {
+			t.Fatalf("%s: expected 2 containers, got %d", description, len(deployment.Spec.Template.Spec.Containers))
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			if container.Image != expected {
+				t.Errorf("%s: expected container %s to have image %q, got %q", description, container.Name, expected, container.Image)
+			}
+		}
+	}
+
+	expectImage("payload image", ingressControllerImage)
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"routerImage":"` + testRouterImageOverride + `"}`)}
+	expectImage("overridden image", testRouterImageOverride)
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+	expectImage("reverted image", ingressControllerImage)
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"routerImage":"quay.io/example/haproxy-router:latest"}`)}
+	if _, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false); err == nil {
+		t.Error("expected an error for an image reference without a digest")
+	}
+}
+
+// Test_computeRouterImageOverriddenCondition verifies that the
+// "RouterImageOverridden" condition and the "Upgradeable" condition report a
+// router image override, and that neither does once the override is removed.
+func Test_computeRouterImageOverriddenCondition(t *testing.T) {
+	ic := &operatorv1.IngressController{}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"routerImage":"` + testRouterImageOverride + `"}`)}
+
+	condition, ok := computeRouterImageOverriddenCondition(ic, ingressControllerImage)
+	if !ok {
+		t.Fatal("expected the condition to apply with an override")
+	}
+	if condition.Status != operatorv1.ConditionTrue || condition.Reason != "RouterImageOverridden" {
+		t.Errorf("expected status True with reason RouterImageOverridden, got %s with reason %s", condition.Status, condition.Reason)
+	}
+	if !strings.Contains(condition.Message, testRouterImageOverride) || !strings.Contains(condition.Message, ingressControllerImage) {
+		t.Errorf("expected the message to mention both images, got %q", condition.Message)
+	}
+	upgradeable := computeIngressUpgradeableCondition(ic, metav1.OwnerReference{}, nil, nil, &corev1.Secret{}, false, false)
+	if upgradeable.Status != operatorv1.ConditionFalse || !strings.Contains(upgradeable.Message, "routerImage") {
+		t.Errorf("expected Upgradeable=False because of the router image override, got %s: %s", upgradeable.Status, upgradeable.Message)
+	}
+
+	condition, ok = computeRouterImageOverriddenCondition(ic, testRouterImageOverride)
+	if !ok || condition.Status != operatorv1.ConditionFalse || condition.Reason != "MatchesPayloadImage" {
+		t.Errorf("expected status False with reason MatchesPayloadImage when the override matches the payload image, got %+v", condition)
+	}
+
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+	if _, ok := computeRouterImageOverriddenCondition(ic, ingressControllerImage); ok {
+		t.Error("expected the condition not to apply without an override")
+	}
+	upgradeable = computeIngressUpgradeableCondition(ic, metav1.OwnerReference{}, nil, nil, &corev1.Secret{}, false, false)
+	if strings.Contains(upgradeable.Message, "routerImage") {
+		t.Errorf("expected Upgradeable not to mention the router image without an override, got %s: %s", upgradeable.Status, upgradeable.Message)
+	}
+}
@@ -0,0 +1,106 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// RouterMetricsGranularity is the router environment variable that
+	// specifies which HAProxy series the router's metrics exporter
+	// exports.
+	RouterMetricsGranularity = "ROUTER_METRICS_GRANULARITY"
+	// RouterMetricsRouteLabelSelector is the router environment variable
+	// that specifies a label selector for the routes for which the
+	// router's metrics exporter exports per-route series.  Series for
+	// other routes are aggregated by backend.
+	RouterMetricsRouteLabelSelector = "ROUTER_METRICS_ROUTE_LABEL_SELECTOR"
+
+	// metricsGranularityOff is the metrics granularity with which the
+	// router exports neither per-backend nor per-route series.
+	metricsGranularityOff = "Off"
+	// metricsGranularityPerBackendAggregated is the metrics granularity
+	// with which the router exports per-backend series but no per-route
+	// (per-server) series.
+	metricsGranularityPerBackendAggregated = "PerBackendAggregated"
+	// metricsGranularityPerRoute is the metrics granularity with which the
+	// router exports per-route series, optionally only for routes that
+	// match a label selector.
+	metricsGranularityPerRoute = "PerRoute"
+)
+
+// routerMetricsConfig describes the granularity of the router's metrics.
+type routerMetricsConfig struct {
+	// Granularity is one of "Off", "PerBackendAggregated", or "PerRoute".
+	// If empty, the router exports all series, which is the default.
+	Granularity string `json:"granularity"`
+	// RouteSelector, which is only valid with the "PerRoute" granularity,
+	// restricts per-route series to the routes that match the selector.
+	// If nil, every route gets per-route series.
+	RouteSelector *metav1.LabelSelector `json:"routeSelector,omitempty"`
+}
+
+// routerMetricsConfigForIngressController returns the metrics options that the
+// given ingresscontroller specifies in spec.unsupportedConfigOverrides, or nil
+// if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func routerMetricsConfigForIngressController(ic *operatorv1.IngressController) (*routerMetricsConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.RouterMetrics, nil
+}
+
+// validateRouterMetricsConfig validates the given ingresscontroller's metrics
+// options, if it specifies any.  The granularity must be valid, and a route
+// selector may only be specified, and must be valid, with the "PerRoute"
+// granularity.
+func validateRouterMetricsConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.RouterMetrics
+	if config == nil {
+		return nil
+	}
+	var errs []error
+	switch config.Granularity {
+	case "", metricsGranularityOff, metricsGranularityPerBackendAggregated, metricsGranularityPerRoute:
+	default:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routerMetrics.granularity has invalid value %q; must be %q, %q, or %q", config.Granularity, metricsGranularityOff, metricsGranularityPerBackendAggregated, metricsGranularityPerRoute))
+	}
+	if config.RouteSelector != nil {
+		if config.Granularity != metricsGranularityPerRoute {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routerMetrics.routeSelector may only be specified with granularity %q", metricsGranularityPerRoute))
+		} else if _, err := metav1.LabelSelectorAsSelector(config.RouteSelector); err != nil {
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.routerMetrics.routeSelector is invalid: %w", err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// routerMetricsEnvValues returns the values for the ROUTER_METRICS_GRANULARITY
+// and ROUTER_METRICS_ROUTE_LABEL_SELECTOR environment variables for the given
+// metrics options.  Empty values mean that the respective variable should not
+// be set.  An invalid route selector is ignored.
+func routerMetricsEnvValues(config *routerMetricsConfig) (string, string) {
+	if config == nil {
+		return "", ""
+	}
+	switch config.Granularity {
+	case metricsGranularityOff, metricsGranularityPerBackendAggregated:
+		return config.Granularity, ""
+	case metricsGranularityPerRoute:
+		if config.RouteSelector == nil {
+			return config.Granularity, ""
+		}
+		selector, err := metav1.LabelSelectorAsSelector(config.RouteSelector)
+		if err != nil || selector.Empty() {
+			return config.Granularity, ""
+		}
+		return config.Granularity, selector.String()
+	}
+	return "", ""
+}
@@ -0,0 +1,273 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// routerMetricsScrapeTimeout is the timeout for a request to a router
+	// pod's metrics endpoint.
+	routerMetricsScrapeTimeout = 5 * time.Second
+	// routerMetricsScrapeInterval is how often the operator scrapes the
+	// metrics of an ingresscontroller's router pods while a status
+	// condition or metric that the operator computes from them applies.
+	routerMetricsScrapeInterval = 1 * time.Minute
+	// routerMetricsMaxResponseBytes is the maximum size of a router pod's
+	// metrics that the operator reads.
+	routerMetricsMaxResponseBytes = 16 << 20
+)
+
+// routerMetricsScraper requests metrics from the metrics endpoints of an
+// ingresscontroller's router pods.
+type routerMetricsScraper struct {
+	client   *http.Client
+	username string
+	password string
+	// pods are the running router pods that serve metrics.
+	pods []*corev1.Pod
+}
+
+// routerMetricsApply returns a Boolean value indicating whether the operator
+// scrapes the metrics of the given ingresscontroller's router pods, which is
+// the case if the ingresscontroller specifies spec.tuningOptions.reloadInterval
+// or enables connection capacity estimation.
+func routerMetricsApply(ic *operatorv1.IngressController) bool {
+	if ic.Spec.TuningOptions.ReloadInterval.Duration != 0 {
+		return true
+	}
+	config, err := connectionCapacityConfigForIngressController(ic)
+	return err == nil && config != nil
+}
+
+// routerMetricsPort returns the port on which the given router pod serves
+// metrics, or zero if the pod does not specify one.
+func routerMetricsPort(pod *corev1.Pod) int32 {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == StatsPortName {
+				return port.ContainerPort
+			}
+		}
+	}
+	return 0
+}
+
+// newRouterMetricsScraper returns a routerMetricsScraper for those of the
+// given pods that are running router pods of the given ingresscontroller's
+// deployment.  The scraper authenticates with the router's stats credentials
+// and verifies the router's metrics certificate, which the service CA signs.
+func (r *reconciler) newRouterMetricsScraper(ic *operatorv1.IngressController, deployment *appsv1.Deployment, pods []corev1.Pod) (*routerMetricsScraper, error) {
+	statsSecret := manifests.RouterStatsSecret(ic)
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: statsSecret.Namespace, Name: statsSecret.Name}, statsSecret); err != nil {
+		return nil, fmt.Errorf("failed to get router stats secret %s/%s: %w", statsSecret.Namespace, statsSecret.Name, err)
+	}
+	caName := operatorcontroller.ServiceCAConfigMapName()
+	caConfigMap := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), caName, caConfigMap); err != nil {
+		return nil, fmt.Errorf("failed to get service CA configmap %s: %w", caName, err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM([]byte(caConfigMap.Data["service-ca.crt"])) {
+		return nil, fmt.Errorf("failed to parse service CA certificate from configmap %s", caName)
+	}
+	internalService := operatorcontroller.InternalIngressControllerServiceName(ic)
+	scraper := &routerMetricsScraper{
+		client: &http.Client{
+			Timeout: routerMetricsScrapeTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:    rootCAs,
+					ServerName: fmt.Sprintf("%s.%s.svc", internalService.Name, internalService.Namespace),
+				},
+			},
+		},
+		username: string(statsSecret.Data["statsUsername"]),
+		password: string(statsSecret.Data["statsPassword"]),
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return nil, fmt.Errorf("router deployment %s/%s has an invalid selector: %v", deployment.Namespace, deployment.Name, err)
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !selector.Matches(labels.Set(pod.Labels)) || pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || len(pod.Status.PodIP) == 0 {
+			continue
+		}
+		if routerMetricsPort(pod) == 0 {
+			continue
+		}
+		scraper.pods = append(scraper.pods, pod)
+	}
+	return scraper, nil
+}
+
+// sample scrapes the metrics of the scraper's router pods and returns a sample
+// of each pod's reload count, keyed by pod name, and a sample of the pods'
+// total client connections.  An error is returned if the metrics of any router
+// pod could not be scraped or if there are no running router pods to scrape,
+// because a partial sample would understate utilization.
+func (s *routerMetricsScraper) sample() (map[string]routerReloadSample, routerConnectionsSample, error) {
+	reloads := map[string]routerReloadSample{}
+	if len(s.pods) == 0 {
+		return nil, routerConnectionsSample{}, fmt.Errorf("router deployment has no running pods to scrape")
+	}
+	var connections float64
+	for _, pod := range s.pods {
+		url := fmt.Sprintf("https://%s/metrics", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(routerMetricsPort(pod)))))
+		metrics, err := scrapeRouterMetrics(s.client, url, s.username, s.password)
+		if err != nil {
+			return nil, routerConnectionsSample{}, fmt.Errorf("failed to scrape metrics of router pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		count, err := parseRouterReloadCount(bytes.NewReader(metrics))
+		if err != nil {
+			return nil, routerConnectionsSample{}, fmt.Errorf("failed to scrape metrics of router pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		current, err := parseRouterCurrentConnections(bytes.NewReader(metrics))
+		if err != nil {
+			return nil, routerConnectionsSample{}, fmt.Errorf("failed to scrape metrics of router pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		reloads[pod.Name] = routerReloadSample{count: count, timestamp: clock.Now()}
+		connections += current
+	}
+	return reloads, routerConnectionsSample{connections: connections, pods: len(s.pods), timestamp: clock.Now()}, nil
+}
+
+// scrapeRouterMetrics requests the given router metrics URL using the given
+// client and credentials and returns the response body.
+func scrapeRouterMetrics(client *http.Client, url, username, password string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(username, password)
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, routerMetricsMaxResponseBytes))
+}
+
+// routerMetricsPoller scrapes the metrics of the ingresscontrollers' router
+// pods in the background so that status syncs never wait on router pods.  An
+// ingresscontroller's router pods are scraped at most once per
+// routerMetricsScrapeInterval, and at most one scrape per ingresscontroller is
+// in progress at a time.  Each scrape feeds the reload rate and connection
+// trackers, from which status syncs read the most recent samples.
+type routerMetricsPoller struct {
+	mu sync.Mutex
+	// started is when the most recent scrape of each ingresscontroller's
+	// router pods started.
+	started map[types.NamespacedName]time.Time
+	// pending has an entry for each ingresscontroller whose router pods
+	// are being scraped.
+	pending map[types.NamespacedName]bool
+	// errs are the errors of the most recent completed scrape of each
+	// ingresscontroller's router pods.
+	errs map[types.NamespacedName]error
+
+	// reloadRates tracks the observed reload rates of the routers.
+	reloadRates *routerReloadRateTracker
+	// connectionSamples keeps the most recent samples of the routers'
+	// connections.
+	connectionSamples *routerConnectionsTracker
+}
+
+// newRouterMetricsPoller returns a new routerMetricsPoller.
+func newRouterMetricsPoller() *routerMetricsPoller {
+	return &routerMetricsPoller{
+		started:           map[types.NamespacedName]time.Time{},
+		pending:           map[types.NamespacedName]bool{},
+		errs:              map[types.NamespacedName]error{},
+		reloadRates:       newRouterReloadRateTracker(),
+		connectionSamples: newRouterConnectionsTracker(),
+	}
+}
+
+// poll starts a scrape of the given ingresscontroller's router pods in the
+// background, using the scraper that the given function returns for a copy of
+// the ingresscontroller, unless a
+// scrape is already in progress or the most recent one started less than
+// routerMetricsScrapeInterval ago.  poll returns the error of the most recent
+// completed scrape, if it failed.
+func (p *routerMetricsPoller) poll(ic *operatorv1.IngressController, newScraper func(*operatorv1.IngressController) (*routerMetricsScraper, error)) error {
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if started, ok := p.started[name]; !p.pending[name] && (!ok || clock.Since(started) >= routerMetricsScrapeInterval) {
+		p.pending[name] = true
+		p.started[name] = clock.Now()
+		go p.scrape(ic.DeepCopy(), newScraper)
+	}
+	return p.errs[name]
+}
+
+// scrape scrapes the given ingresscontroller's router pods using the scraper
+// that the given function returns and records the result.
+func (p *routerMetricsPoller) scrape(ic *operatorv1.IngressController, newScraper func(*operatorv1.IngressController) (*routerMetricsScraper, error)) {
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	var (
+		reloads     map[string]routerReloadSample
+		connections routerConnectionsSample
+	)
+	scraper, err := newScraper(ic)
+	if err == nil {
+		reloads, connections, err = scraper.sample()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.pending[name] {
+		// The ingresscontroller was forgotten during the scrape.
+		return
+	}
+	delete(p.pending, name)
+	p.errs[name] = err
+	if err != nil {
+		log.Error(err, "failed to scrape router metrics", "ingresscontroller", ic.Name)
+		return
+	}
+	if rate, known := p.reloadRates.observe(name, reloads); known {
+		SetRouterReloadRateMetric(ic, rate)
+	}
+	p.connectionSamples.record(name, connections)
+}
+
+// forget stops tracking the given ingresscontroller's router metrics and
+// discards its samples.
+func (p *routerMetricsPoller) forget(ic *operatorv1.IngressController) {
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.started, name)
+	delete(p.pending, name)
+	delete(p.errs, name)
+	p.reloadRates.forget(name)
+	p.connectionSamples.forget(name)
+	DeleteRouterReloadRateMetric(ic)
+}
@@ -0,0 +1,150 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+)
+
+// Test_routerMetricsPoller verifies that routerMetricsPoller scrapes the
+// router pods in the background, at most once per routerMetricsScrapeInterval
+// and never concurrently for the same ingresscontroller, and that it records
+// the samples for the status sync.
+func Test_routerMetricsPoller(t *testing.T) {
+	fakeClock := utilclocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	clock = fakeClock
+	t.Cleanup(func() { clock = utilclock.RealClock{} })
+
+	const metrics = `# TYPE template_router_reload_seconds summary
+template_router_reload_seconds_sum 1.5
+template_router_reload_seconds_count 3
+# TYPE haproxy_frontend_current_sessions gauge
+haproxy_frontend_current_sessions{frontend="public"} 5
+haproxy_frontend_current_sessions{frontend="public_ssl"} 7
+`
+	var (
+		mu      sync.Mutex
+		fail    bool
+		release = make(chan struct{})
+	)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, metrics)
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "router-default-1"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Ports: []corev1.ContainerPort{{Name: StatsPortName, ContainerPort: int32(portNumber)}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: host},
+	}
+
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress-operator", Name: "default"}}
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	var scrapes int
+	newScraper := func(*operatorv1.IngressController) (*routerMetricsScraper, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		scrapes++
+		return &routerMetricsScraper{client: server.Client(), pods: []*corev1.Pod{pod}}, nil
+	}
+	scrapeCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return scrapes
+	}
+	poller := newRouterMetricsPoller()
+	awaitScrape := func() {
+		t.Helper()
+		release <- struct{}{}
+		if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+			poller.mu.Lock()
+			defer poller.mu.Unlock()
+			return !poller.pending[name], nil
+		}); err != nil {
+			t.Fatalf("scrape did not complete: %v", err)
+		}
+	}
+
+	// The first poll starts a scrape and returns without waiting for it.
+	if err := poller.poll(ic, newScraper); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := poller.connectionSamples.last(name); ok {
+		t.Fatal("expected no sample before the scrape completes")
+	}
+	// Another poll while the scrape is in progress does not start another.
+	poller.poll(ic, newScraper)
+	awaitScrape()
+	if n := scrapeCount(); n != 1 {
+		t.Fatalf("expected 1 scrape, got %d", n)
+	}
+	sample, ok := poller.connectionSamples.last(name)
+	if !ok || sample.connections != 12 || sample.pods != 1 {
+		t.Fatalf("expected a sample of 12 connections on 1 pod, got %+v (ok=%t)", sample, ok)
+	}
+
+	// A poll within the scrape interval does not start another scrape.
+	poller.poll(ic, newScraper)
+	if n := scrapeCount(); n != 1 {
+		t.Fatalf("expected 1 scrape within the scrape interval, got %d", n)
+	}
+
+	// Once the interval has elapsed, the next poll scrapes again, and a
+	// failed scrape is reported by the following poll without discarding
+	// the previous sample.
+	fakeClock.Step(routerMetricsScrapeInterval)
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	poller.poll(ic, newScraper)
+	awaitScrape()
+	if n := scrapeCount(); n != 2 {
+		t.Fatalf("expected 2 scrapes after the scrape interval, got %d", n)
+	}
+	if err := poller.poll(ic, newScraper); err == nil {
+		t.Error("expected the failed scrape to be reported")
+	}
+	if _, ok := poller.connectionSamples.last(name); !ok {
+		t.Error("expected the previous sample to be kept after a failed scrape")
+	}
+
+	// Forgetting the ingresscontroller discards its samples.
+	poller.forget(ic)
+	if _, ok := poller.connectionSamples.last(name); ok {
+		t.Error("expected no sample after forgetting the ingresscontroller")
+	}
+}
@@ -32,6 +32,12 @@ import (
 //    - When the selectors (routeSelector and namespaceSelector) are updated, the operator simply clears the status of
 //      any route that it is no longer selecting using the updated selectors.
 //    - We determine what routes are admitted by the current state of the selectors (just like the openshift-router).
+//
+// In both scenarios, the operator may need to clear the status of many routes at once, so it updates route status
+// using a bounded number of concurrent workers and a rate limit (see routeStatusClearer). Clearing is resumable: the
+// operator only considers routes that still have status from the ingress controller, and it only syncs the selectors
+// to the ingress controller's status (or removes the finalizer) once it has cleared the status of every route. If the
+// operator restarts or an update fails, the next reconciliation picks up the routes that have not been cleared yet.
 
 // syncRouteStatus ensures that all routes status have been synced with the ingress controller's state.
 func (r *reconciler) syncRouteStatus(ic *operatorv1.IngressController) []error {
@@ -81,18 +87,17 @@ func (r *reconciler) clearAllRoutesStatusForIngressController(icName string) []e
 	errs := []error{}
 	start := time.Now()
 	routeList := &routev1.RouteList{}
-	routesCleared := 0
 	if err := r.client.List(context.TODO(), routeList); err != nil {
 		return append(errs, fmt.Errorf("failed to list all routes in order to clear route status for deployment %s: %w", icName, err))
 	}
 	// Clear status on the routes that belonged to icName.
+	routes := []*routev1.Route{}
 	for i := range routeList.Items {
-		if cleared, err := r.clearRouteStatus(&routeList.Items[i], icName); err != nil {
-			errs = append(errs, err)
-		} else if cleared {
-			routesCleared++
+		if routeAdmittedByIngressController(&routeList.Items[i], icName) {
+			routes = append(routes, &routeList.Items[i])
 		}
 	}
+	routesCleared, errs := newRouteStatusClearer(r.client).clearRoutesStatus(icName, routes)
 	elapsed := time.Since(start)
 	log.Info("cleared all route status for ingress", "Ingress Controller",
 		icName, "Routes Status Cleared", routesCleared, "Time Elapsed", elapsed)
@@ -100,28 +105,26 @@ func (r *reconciler) clearAllRoutesStatusForIngressController(icName string) []e
 	return errs
 }
 
-// clearRouteStatus clears a route's status that is admitted by a specific ingress controller.
-func (r *reconciler) clearRouteStatus(route *routev1.Route, icName string) (bool, error) {
-	// Go through each route and clear status if admitted by this ingress controller.
-	var updated routev1.Route
+// routeAdmittedByIngressController returns a Boolean value indicating whether
+// the given route has status indicating that the given ingress controller has
+// admitted it.
+func routeAdmittedByIngressController(route *routev1.Route, icName string) bool {
+	return admittedIngressIndex(route, icName) != -1
+}
+
+// admittedIngressIndex returns the index of the given ingress controller's
+// entry in the given route's status if the entry has an Admitted condition, or
+// -1 if it has none.
+func admittedIngressIndex(route *routev1.Route, icName string) int {
 	for i := range route.Status.Ingress {
+		if route.Status.Ingress[i].RouterName != icName {
+			continue
+		}
 		if condition := findCondition(&route.Status.Ingress[i], routev1.RouteAdmitted); condition != nil {
-			if route.Status.Ingress[i].RouterName == icName {
-				// Remove this status since it matches our routerName.
-				route.DeepCopyInto(&updated)
-				updated.Status.Ingress = append(route.Status.Ingress[:i], route.Status.Ingress[i+1:]...)
-				if err := r.client.Status().Update(context.TODO(), &updated); err != nil {
-					return false, fmt.Errorf("failed to clear route status of %s/%s for routerName %s: %w",
-						route.Namespace, route.Name, icName, err)
-				}
-				log.Info("cleared admitted status for route", "Route", route.Namespace+"/"+route.Name,
-					"Ingress Controller", icName)
-				return true, nil
-			}
+			return i
 		}
 	}
-
-	return false, nil
+	return -1
 }
 
 // routeSelectorsUpdated returns whether any of the route selectors have been updated by comparing
@@ -169,22 +172,18 @@ func (r *reconciler) clearRoutesNotAdmittedByIngress(ingress *operatorv1.Ingress
 	}
 
 	// Iterate over the entire route list and clear if not selected by route selector OR namespace selector.
-	routesCleared := 0
+	routes := []*routev1.Route{}
 	for i := range routeList.Items {
 		route := &routeList.Items[i]
 
 		routeInShard := routeSelector.Matches(labels.Set(route.Labels))
 		namespaceInShard := namespacesInShard.Has(route.Namespace)
 
-		if !routeInShard || !namespaceInShard {
-			if cleared, err := r.clearRouteStatus(route, ingress.ObjectMeta.Name); err != nil {
-				errs = append(errs, err)
-			} else if cleared {
-				routesCleared++
-			}
+		if (!routeInShard || !namespaceInShard) && routeAdmittedByIngressController(route, ingress.Name) {
+			routes = append(routes, route)
 		}
-
 	}
+	routesCleared, errs := newRouteStatusClearer(r.client).clearRoutesStatus(ingress.Name, routes)
 	elapsed := time.Since(start)
 	log.Info("cleared route status after selector update", "Ingress Controller", ingress.Name, "Routes Status Cleared", routesCleared, "Time Elapsed", elapsed)
 	return errs
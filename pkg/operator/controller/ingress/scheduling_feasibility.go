@@ -0,0 +1,215 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// hostnameTopologyKey is the topology key that the router deployment's
+	// pod anti-affinity uses to prevent colocating replicas.
+	hostnameTopologyKey = "kubernetes.io/hostname"
+)
+
+// preferPodAntiAffinityOverride returns a Boolean value indicating whether the
+// given ingresscontroller opts in to having its router deployment's required
+// pod anti-affinity downgraded to preferred pod anti-affinity when the desired
+// replicas exceed the number of schedulable nodes.  The opt-in is specified
+// using the "preferPodAntiAffinityForExcessReplicas" unsupported config
+// override.
+func preferPodAntiAffinityOverride(ic *operatorv1.IngressController) bool {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return false
+	}
+	return overrides.PreferPodAntiAffinityForExcessReplicas
+}
+
+// hasRequiredPodAntiAffinityByHostname returns a Boolean value indicating
+// whether the given deployment's pod template has required pod anti-affinity
+// that prevents colocating replicas on the same node.
+func hasRequiredPodAntiAffinityByHostname(deployment *appsv1.Deployment) bool {
+	affinity := deployment.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.PodAntiAffinity == nil {
+		return false
+	}
+	for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		if term.TopologyKey == hostnameTopologyKey {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPreferredPodAntiAffinityByHostname returns a Boolean value indicating
+// whether the given deployment's pod template has preferred pod anti-affinity
+// that discourages colocating replicas on the same node.
+func hasPreferredPodAntiAffinityByHostname(deployment *appsv1.Deployment) bool {
+	affinity := deployment.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.PodAntiAffinity == nil {
+		return false
+	}
+	for _, term := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if term.PodAffinityTerm.TopologyKey == hostnameTopologyKey {
+			return true
+		}
+	}
+	return false
+}
+
+// deploymentDesiredReplicas returns the given deployment's desired replicas.
+func deploymentDesiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// schedulableNodesForDeployment returns the number of the given nodes to which
+// the given deployment's pods can be scheduled, taking into account the pod
+// template's node selector and tolerations and whether the node is marked
+// unschedulable.
+func schedulableNodesForDeployment(deployment *appsv1.Deployment, nodes []corev1.Node) int {
+	selector := labels.SelectorFromSet(deployment.Spec.Template.Spec.NodeSelector)
+	tolerations := deployment.Spec.Template.Spec.Tolerations
+	count := 0
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable || !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if !toleratesNodeTaints(tolerations, node.Spec.Taints) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// toleratesNodeTaints returns a Boolean value indicating whether the given
+// tolerations tolerate all of the given taints that prevent scheduling.
+func toleratesNodeTaints(tolerations []corev1.Toleration, taints []corev1.Taint) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// preferPodAntiAffinity replaces the given deployment's required pod
+// anti-affinity terms with equivalent preferred terms so that replicas in
+// excess of the number of schedulable nodes can be colocated, and updates the
+// deployment's template hash accordingly.
+func preferPodAntiAffinity(deployment *appsv1.Deployment) {
+	antiAffinity := deployment.Spec.Template.Spec.Affinity.PodAntiAffinity
+	for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+			Weight:          int32(100),
+			PodAffinityTerm: term,
+		})
+	}
+	antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+	setDeploymentTemplateHash(deployment)
+}
+
+// setDeploymentTemplateHash recomputes the given deployment's template hash and
+// sets it in the pod template's labels and in every label selector in the pod
+// template that selects on the hash.
+func setDeploymentTemplateHash(deployment *appsv1.Deployment) {
+	hash := deploymentTemplateHash(deployment)
+	deployment.Spec.Template.Labels[controller.ControllerDeploymentHashLabel] = hash
+	setHash := func(labelSelector *metav1.LabelSelector) {
+		if labelSelector == nil {
+			return
+		}
+		for i := range labelSelector.MatchExpressions {
+			if labelSelector.MatchExpressions[i].Key == controller.ControllerDeploymentHashLabel {
+				labelSelector.MatchExpressions[i].Values = []string{hash}
+			}
+		}
+	}
+	podSpec := &deployment.Spec.Template.Spec
+	for i := range podSpec.TopologySpreadConstraints {
+		setHash(podSpec.TopologySpreadConstraints[i].LabelSelector)
+	}
+	if podSpec.Affinity == nil {
+		return
+	}
+	if podSpec.Affinity.PodAffinity != nil {
+		for i := range podSpec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			setHash(podSpec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i].PodAffinityTerm.LabelSelector)
+		}
+	}
+	if podSpec.Affinity.PodAntiAffinity != nil {
+		for i := range podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			setHash(podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[i].LabelSelector)
+		}
+		for i := range podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			setHash(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i].PodAffinityTerm.LabelSelector)
+		}
+	}
+}
+
+// schedulableNodes lists the cluster's nodes and returns the number of them to
+// which the given deployment's pods can be scheduled.
+func (r *reconciler) schedulableNodes(deployment *appsv1.Deployment) (int, error) {
+	var nodes corev1.NodeList
+	if err := r.client.List(context.TODO(), &nodes); err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return schedulableNodesForDeployment(deployment, nodes.Items), nil
+}
+
+// computeReplicasMoreThanSchedulableNodesCondition computes the
+// ingresscontroller's "ReplicasMoreThanSchedulableNodes" status condition,
+// which reports whether the deployment's desired replicas exceed the number of
+// nodes to which the deployment's pods can be scheduled given that replicas
+// must not be colocated.  The required argument indicates whether the
+// deployment's pod anti-affinity is required, in which case the excess
+// replicas cannot be scheduled, or preferred, in which case the excess
+// replicas are colocated with other replicas.
+func computeReplicasMoreThanSchedulableNodesCondition(deployment *appsv1.Deployment, schedulableNodes int, required bool) operatorv1.OperatorCondition {
+	replicas := deploymentDesiredReplicas(deployment)
+	if int(replicas) <= schedulableNodes {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerReplicasMoreThanSchedulableNodesConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "SufficientSchedulableNodes",
+			Message: fmt.Sprintf("%d desired replicas can be scheduled on %d nodes that match the node placement.", replicas, schedulableNodes),
+		}
+	}
+	if required {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerReplicasMoreThanSchedulableNodesConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "InsufficientSchedulableNodes",
+			Message: fmt.Sprintf("%d desired replicas exceed the %d nodes that match the node placement; %d replicas cannot be scheduled because replicas must not be colocated on the same node.  Reduce the replicas, add nodes that match the node placement, or set the \"preferPodAntiAffinityForExcessReplicas\" unsupported config override to allow colocating replicas.", replicas, schedulableNodes, int(replicas)-schedulableNodes),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerReplicasMoreThanSchedulableNodesConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "ReplicasColocated",
+		Message: fmt.Sprintf("%d desired replicas exceed the %d nodes that match the node placement; %d replicas will be colocated with other replicas.", replicas, schedulableNodes, int(replicas)-schedulableNodes),
+	}
+}
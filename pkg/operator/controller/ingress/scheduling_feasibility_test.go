@@ -0,0 +1,224 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+// Test_schedulableNodesForDeployment verifies that
+// schedulableNodesForDeployment counts the nodes that match the deployment's
+// node selector and tolerations and that are not marked unschedulable.
+func Test_schedulableNodesForDeployment(t *testing.T) {
+	node := func(name string, labels map[string]string, unschedulable bool, taints ...corev1.Taint) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec: corev1.NodeSpec{
+				Unschedulable: unschedulable,
+				Taints:        taints,
+			},
+		}
+	}
+	worker := map[string]string{"node-role.kubernetes.io/worker": ""}
+	infra := map[string]string{"node-role.kubernetes.io/infra": ""}
+	infraTaint := corev1.Taint{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}
+	preferNoScheduleTaint := corev1.Taint{Key: "example.com/busy", Effect: corev1.TaintEffectPreferNoSchedule}
+	nodes := []corev1.Node{
+		node("worker-1", worker, false),
+		node("worker-2", worker, false),
+		node("worker-3", worker, true),
+		node("worker-4", worker, false, preferNoScheduleTaint),
+		node("infra-1", infra, false, infraTaint),
+		node("infra-2", infra, false, infraTaint),
+	}
+	testCases := []struct {
+		name         string
+		nodeSelector map[string]string
+		tolerations  []corev1.Toleration
+		expect       int
+	}{
+		{
+			name:         "worker nodes",
+			nodeSelector: worker,
+			expect:       3,
+		},
+		{
+			name:         "infra nodes without tolerations",
+			nodeSelector: infra,
+			expect:       0,
+		},
+		{
+			name:         "infra nodes with tolerations",
+			nodeSelector: infra,
+			tolerations:  []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}},
+			expect:       2,
+		},
+		{
+			name:        "any node with tolerations",
+			tolerations: []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}},
+			expect:      5,
+		},
+		{
+			name:         "no matching nodes",
+			nodeSelector: map[string]string{"node-role.kubernetes.io/edge": ""},
+			expect:       0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.NodeSelector = tc.nodeSelector
+			deployment.Spec.Template.Spec.Tolerations = tc.tolerations
+			if actual := schedulableNodesForDeployment(deployment, nodes); actual != tc.expect {
+				t.Errorf("expected %d schedulable nodes, got %d", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_computeReplicasMoreThanSchedulableNodesCondition verifies that
+// computeReplicasMoreThanSchedulableNodesCondition reports whether the desired
+// replicas exceed the schedulable nodes, with the numbers in the message.
+func Test_computeReplicasMoreThanSchedulableNodesCondition(t *testing.T) {
+	testCases := []struct {
+		name             string
+		replicas         *int32
+		schedulableNodes int
+		required         bool
+		expectStatus     operatorv1.ConditionStatus
+		expectReason     string
+		expectInMessage  string
+	}{
+		{
+			name:             "default replicas, one node",
+			replicas:         nil,
+			schedulableNodes: 1,
+			required:         true,
+			expectStatus:     operatorv1.ConditionFalse,
+			expectReason:     "SufficientSchedulableNodes",
+			expectInMessage:  "1 desired replicas can be scheduled on 1 nodes",
+		},
+		{
+			name:             "3 replicas, 3 nodes",
+			replicas:         pointer.Int32(3),
+			schedulableNodes: 3,
+			required:         true,
+			expectStatus:     operatorv1.ConditionFalse,
+			expectReason:     "SufficientSchedulableNodes",
+			expectInMessage:  "3 desired replicas can be scheduled on 3 nodes",
+		},
+		{
+			name:             "2 replicas, 5 nodes",
+			replicas:         pointer.Int32(2),
+			schedulableNodes: 5,
+			required:         true,
+			expectStatus:     operatorv1.ConditionFalse,
+			expectReason:     "SufficientSchedulableNodes",
+			expectInMessage:  "2 desired replicas can be scheduled on 5 nodes",
+		},
+		{
+			name:             "5 replicas, 3 nodes, required anti-affinity",
+			replicas:         pointer.Int32(5),
+			schedulableNodes: 3,
+			required:         true,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "InsufficientSchedulableNodes",
+			expectInMessage:  "5 desired replicas exceed the 3 nodes that match the node placement; 2 replicas cannot be scheduled",
+		},
+		{
+			name:             "2 replicas, no nodes, required anti-affinity",
+			replicas:         pointer.Int32(2),
+			schedulableNodes: 0,
+			required:         true,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "InsufficientSchedulableNodes",
+			expectInMessage:  "2 replicas cannot be scheduled",
+		},
+		{
+			name:             "5 replicas, 3 nodes, preferred anti-affinity",
+			replicas:         pointer.Int32(5),
+			schedulableNodes: 3,
+			required:         false,
+			expectStatus:     operatorv1.ConditionTrue,
+			expectReason:     "ReplicasColocated",
+			expectInMessage:  "2 replicas will be colocated",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: tc.replicas}}
+			actual := computeReplicasMoreThanSchedulableNodesCondition(deployment, tc.schedulableNodes, tc.required)
+			if actual.Type != IngressControllerReplicasMoreThanSchedulableNodesConditionType {
+				t.Errorf("expected condition type %q, got %q", IngressControllerReplicasMoreThanSchedulableNodesConditionType, actual.Type)
+			}
+			if actual.Status != tc.expectStatus {
+				t.Errorf("expected status %q, got %q", tc.expectStatus, actual.Status)
+			}
+			if actual.Reason != tc.expectReason {
+				t.Errorf("expected reason %q, got %q", tc.expectReason, actual.Reason)
+			}
+			if !strings.Contains(actual.Message, tc.expectInMessage) {
+				t.Errorf("expected message to contain %q, got %q", tc.expectInMessage, actual.Message)
+			}
+		})
+	}
+}
+
+// Test_preferPodAntiAffinity verifies that preferPodAntiAffinity replaces the
+// router deployment's required pod anti-affinity with preferred pod
+// anti-affinity, updates the template hash, and that the override that enables
+// it is parsed.
+func Test_preferPodAntiAffinity(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.PrivateStrategyType}
+	ic.Status.EndpointPublishingStrategy = ic.Spec.EndpointPublishingStrategy
+	infraConfig.Status.InfrastructureTopology = "HighlyAvailable"
+	ingressConfig.Status.DefaultPlacement = "Workers"
+
+	if preferPodAntiAffinityOverride(ic) {
+		t.Errorf("expected override to be disabled by default")
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"preferPodAntiAffinityForExcessReplicas":true}`)}
+	if !preferPodAntiAffinityOverride(ic) {
+		t.Errorf("expected override to be enabled")
+	}
+
+	deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+	if err != nil {
+		t.Fatalf("invalid router Deployment: %v", err)
+	}
+	if !hasRequiredPodAntiAffinityByHostname(deployment) {
+		t.Fatalf("expected router deployment to have required pod anti-affinity")
+	}
+	oldHash := deployment.Spec.Template.Labels[controller.ControllerDeploymentHashLabel]
+
+	preferPodAntiAffinity(deployment)
+
+	if hasRequiredPodAntiAffinityByHostname(deployment) {
+		t.Errorf("expected required pod anti-affinity to be removed")
+	}
+	if !hasPreferredPodAntiAffinityByHostname(deployment) {
+		t.Fatalf("expected preferred pod anti-affinity")
+	}
+	newHash := deployment.Spec.Template.Labels[controller.ControllerDeploymentHashLabel]
+	if newHash == oldHash {
+		t.Errorf("expected template hash to change")
+	}
+	if newHash != deploymentTemplateHash(deployment) {
+		t.Errorf("expected template hash label %q to match computed hash %q", newHash, deploymentTemplateHash(deployment))
+	}
+	term := deployment.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0]
+	for _, expr := range term.PodAffinityTerm.LabelSelector.MatchExpressions {
+		if expr.Key == controller.ControllerDeploymentHashLabel && (len(expr.Values) != 1 || expr.Values[0] != newHash) {
+			t.Errorf("expected preferred pod anti-affinity to select hash %q, got %v", newHash, expr.Values)
+		}
+	}
+}
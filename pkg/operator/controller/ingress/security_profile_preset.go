@@ -0,0 +1,197 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RouterStrictSNI is the router environment variable that, when set to
+	// "true", tells the router to reject TLS connections that do not
+	// specify a server name that matches a certificate (using HAProxy's
+	// "strict-sni" option) instead of serving the default certificate.
+	RouterStrictSNI = "ROUTER_STRICT_SNI"
+
+	// securityProfilePresetDefault is the security profile preset that
+	// leaves every setting at the value that the ingresscontroller
+	// specifies or at the router's default.
+	securityProfilePresetDefault = "Default"
+	// securityProfilePresetHardened is the security profile preset that
+	// applies the recommended hardening settings to every setting that the
+	// ingresscontroller does not specify explicitly.
+	securityProfilePresetHardened = "Hardened"
+)
+
+// hardenedTuningOptions are the timeouts that the "Hardened" security profile
+// preset uses for any timeouts that the ingresscontroller does not specify.
+var hardenedTuningOptions = operatorv1.IngressControllerTuningOptions{
+	ClientTimeout:    &metav1.Duration{Duration: 30 * time.Second},
+	ClientFinTimeout: &metav1.Duration{Duration: 1 * time.Second},
+	ServerTimeout:    &metav1.Duration{Duration: 30 * time.Second},
+	ServerFinTimeout: &metav1.Duration{Duration: 1 * time.Second},
+	TunnelTimeout:    &metav1.Duration{Duration: 15 * time.Minute},
+	ConnectTimeout:   &metav1.Duration{Duration: 5 * time.Second},
+	TLSInspectDelay:  &metav1.Duration{Duration: 5 * time.Second},
+}
+
+// securityProfilePresetForIngressController returns the security profile
+// preset that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or "Default" if it specifies none.  An
+// error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func securityProfilePresetForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.securityProfilePreset(), nil
+}
+
+// securityProfilePreset returns the security profile preset that the
+// overrides specify, or "Default" if they specify none.
+func (o *unsupportedConfigOverrides) securityProfilePreset() string {
+	if len(o.SecurityProfilePreset) == 0 {
+		return securityProfilePresetDefault
+	}
+	return o.SecurityProfilePreset
+}
+
+// validateSecurityProfilePreset validates the given ingresscontroller's
+// security profile preset, if it specifies one.
+func validateSecurityProfilePreset(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	preset := overrides.securityProfilePreset()
+	switch preset {
+	case securityProfilePresetDefault, securityProfilePresetHardened:
+		return nil
+	}
+	return fmt.Errorf("spec.unsupportedConfigOverrides.securityProfilePreset has invalid value %q; must be %q or %q", preset, securityProfilePresetDefault, securityProfilePresetHardened)
+}
+
+// applySecurityProfilePreset returns the given ingresscontroller with the
+// settings of its security profile preset applied to every setting that the
+// ingresscontroller does not specify explicitly, and a Boolean value
+// indicating whether the preset enables strict SNI.  The given
+// ingresscontroller is not mutated; if the preset is "Default", it is returned
+// as is.  An error is returned if spec.unsupportedConfigOverrides cannot be
+// decoded.
+//
+// The "Hardened" preset uses the Modern TLS security profile, enables HTTP/2,
+// enables strict SNI, replaces the Forwarded and X-Forwarded-* headers, and
+// uses shorter timeouts.  The router has no setting that makes port 80
+// redirect-only for all routes, so the preset cannot enforce this; routes must
+// still set spec.tls.insecureEdgeTerminationPolicy to Redirect.
+func applySecurityProfilePreset(ic *operatorv1.IngressController) (*operatorv1.IngressController, bool, error) {
+	preset, err := securityProfilePresetForIngressController(ic)
+	if err != nil {
+		return nil, false, err
+	}
+	if preset != securityProfilePresetHardened {
+		return ic, false, nil
+	}
+
+	effective := ic.DeepCopy()
+	if !hasTLSSecurityProfile(effective) {
+		effective.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{
+			Type:   configv1.TLSProfileModernType,
+			Modern: &configv1.ModernTLSProfile{},
+		}
+	}
+	if _, ok := effective.Annotations[RouterDefaultEnableHTTP2Annotation]; !ok {
+		if effective.Annotations == nil {
+			effective.Annotations = map[string]string{}
+		}
+		effective.Annotations[RouterDefaultEnableHTTP2Annotation] = "true"
+	}
+	if effective.Spec.HTTPHeaders == nil {
+		effective.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{}
+	}
+	if len(effective.Spec.HTTPHeaders.ForwardedHeaderPolicy) == 0 {
+		effective.Spec.HTTPHeaders.ForwardedHeaderPolicy = operatorv1.ReplaceHTTPHeaderPolicy
+	}
+	tuning := &effective.Spec.TuningOptions
+	for _, timeout := range []struct {
+		current  **metav1.Duration
+		hardened *metav1.Duration
+	}{
+		{&tuning.ClientTimeout, hardenedTuningOptions.ClientTimeout},
+		{&tuning.ClientFinTimeout, hardenedTuningOptions.ClientFinTimeout},
+		{&tuning.ServerTimeout, hardenedTuningOptions.ServerTimeout},
+		{&tuning.ServerFinTimeout, hardenedTuningOptions.ServerFinTimeout},
+		{&tuning.TunnelTimeout, hardenedTuningOptions.TunnelTimeout},
+		{&tuning.ConnectTimeout, hardenedTuningOptions.ConnectTimeout},
+		{&tuning.TLSInspectDelay, hardenedTuningOptions.TLSInspectDelay},
+	} {
+		if *timeout.current == nil || (*timeout.current).Duration <= 0 {
+			*timeout.current = timeout.hardened.DeepCopy()
+		}
+	}
+	return effective, true, nil
+}
+
+// computeSecurityProfilePresetCondition returns the ingresscontroller's
+// "SecurityProfilePreset" status condition, which reports the effective values
+// of the settings that the security profile preset controls as the router
+// deployment configures them, and a Boolean value indicating whether the
+// condition applies.  The condition only applies if the ingresscontroller uses
+// the "Hardened" preset.
+func computeSecurityProfilePresetCondition(ic *operatorv1.IngressController, deployment *appsv1.Deployment) (operatorv1.OperatorCondition, bool) {
+	preset, err := securityProfilePresetForIngressController(ic)
+	if err != nil || preset != securityProfilePresetHardened || deployment == nil {
+		return operatorv1.OperatorCondition{}, false
+	}
+	env := map[string]string{}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "router" {
+			continue
+		}
+		for _, v := range container.Env {
+			env[v.Name] = v.Value
+		}
+	}
+	envValue := func(name, defaultValue string) string {
+		if v, ok := env[name]; ok {
+			return v
+		}
+		return defaultValue
+	}
+	minTLSVersion := "unknown"
+	if ic.Status.TLSProfile != nil {
+		minTLSVersion = string(ic.Status.TLSProfile.MinTLSVersion)
+	}
+	settings := []string{
+		fmt.Sprintf("minimum TLS version %s", minTLSVersion),
+		fmt.Sprintf("HTTP/2 %s", enabledOrDisabled(envValue(RouterDisableHTTP2EnvName, "true") != "true")),
+		fmt.Sprintf("strict SNI %s", enabledOrDisabled(envValue(RouterStrictSNI, "false") == "true")),
+		fmt.Sprintf("forwarded header policy %s", envValue(RouterForwardedHeadersPolicy, "append")),
+		fmt.Sprintf("client timeout %s", envValue("ROUTER_DEFAULT_CLIENT_TIMEOUT", "default")),
+		fmt.Sprintf("client FIN timeout %s", envValue("ROUTER_CLIENT_FIN_TIMEOUT", "default")),
+		fmt.Sprintf("server timeout %s", envValue("ROUTER_DEFAULT_SERVER_TIMEOUT", "default")),
+		fmt.Sprintf("server FIN timeout %s", envValue("ROUTER_DEFAULT_SERVER_FIN_TIMEOUT", "default")),
+		fmt.Sprintf("tunnel timeout %s", envValue("ROUTER_DEFAULT_TUNNEL_TIMEOUT", "default")),
+		fmt.Sprintf("connect timeout %s", envValue("ROUTER_DEFAULT_CONNECT_TIMEOUT", "default")),
+		fmt.Sprintf("TLS inspect delay %s", envValue("ROUTER_INSPECT_DELAY", "default")),
+	}
+	return operatorv1.OperatorCondition{
+		Type:   IngressControllerSecurityProfilePresetConditionType,
+		Status: operatorv1.ConditionTrue,
+		Reason: securityProfilePresetHardened,
+		Message: fmt.Sprintf("The %s security profile preset is in effect with %s.  "+
+			"Plain HTTP requests are redirected to HTTPS only for routes that set spec.tls.insecureEdgeTerminationPolicy to Redirect.",
+			securityProfilePresetHardened, strings.Join(settings, ", ")),
+	}, true
+}
+
+// enabledOrDisabled returns "enabled" if the given value is true and
+// "disabled" otherwise.
+func enabledOrDisabled(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
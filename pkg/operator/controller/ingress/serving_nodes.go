@@ -0,0 +1,84 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// usesNodeEndpoints returns a Boolean value indicating whether clients reach
+// the given ingresscontroller's router pods using the addresses of the nodes on
+// which the pods run, which is the case for the "HostNetwork" and
+// "NodePortService" endpoint publishing strategies.
+func usesNodeEndpoints(ic *operatorv1.IngressController) bool {
+	eps := ic.Status.EndpointPublishingStrategy
+	if eps == nil {
+		return false
+	}
+	switch eps.Type {
+	case operatorv1.HostNetworkStrategyType, operatorv1.NodePortServiceStrategyType:
+		return true
+	}
+	return false
+}
+
+// servingNodeAddresses returns the sorted, unique host IP addresses of the
+// ready pods among the given pods that belong to the given deployment.
+func servingNodeAddresses(deployment *appsv1.Deployment, pods []corev1.Pod) []string {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return nil
+	}
+	addresses := sets.New[string]()
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pod.DeletionTimestamp != nil || len(pod.Status.HostIP) == 0 {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				addresses.Insert(pod.Status.HostIP)
+				break
+			}
+		}
+	}
+	return sets.List(addresses)
+}
+
+// computeServingNodesAvailableCondition computes the ingresscontroller's
+// "ServingNodesAvailable" status condition, which reports the addresses of the
+// nodes that are serving the ingresscontroller and whether there are at least
+// as many such nodes as the deployment's desired replicas.
+func computeServingNodesAvailableCondition(deployment *appsv1.Deployment, addresses []string) operatorv1.OperatorCondition {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	addressList := "none"
+	if len(addresses) != 0 {
+		addressList = strings.Join(addresses, ", ")
+	}
+	if int32(len(addresses)) < replicas {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerServingNodesAvailableConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "InsufficientServingNodes",
+			Message: fmt.Sprintf("%d of %d desired nodes are serving the IngressController; serving node addresses: %s", len(addresses), replicas, addressList),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerServingNodesAvailableConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "SufficientServingNodes",
+		Message: fmt.Sprintf("%d nodes are serving the IngressController; serving node addresses: %s", len(addresses), addressList),
+	}
+}
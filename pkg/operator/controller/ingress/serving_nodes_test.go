@@ -0,0 +1,184 @@
+package ingress
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// Test_servingNodeAddresses verifies that servingNodeAddresses returns the
+// unique host IP addresses of the deployment's ready pods.
+func Test_servingNodeAddresses(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "router"},
+			},
+		},
+	}
+	pod := func(name, app, hostIP string, ready, deleting bool) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"app": app},
+			},
+			Status: corev1.PodStatus{HostIP: hostIP},
+		}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		if deleting {
+			now := metav1.Now()
+			p.DeletionTimestamp = &now
+		}
+		return p
+	}
+	testCases := []struct {
+		name     string
+		pods     []corev1.Pod
+		expected []string
+	}{
+		{
+			name:     "no pods",
+			pods:     nil,
+			expected: []string{},
+		},
+		{
+			name: "ready pods on distinct nodes",
+			pods: []corev1.Pod{
+				pod("a", "router", "10.0.0.2", true, false),
+				pod("b", "router", "10.0.0.1", true, false),
+			},
+			expected: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "ready pods on the same node",
+			pods: []corev1.Pod{
+				pod("a", "router", "10.0.0.1", true, false),
+				pod("b", "router", "10.0.0.1", true, false),
+			},
+			expected: []string{"10.0.0.1"},
+		},
+		{
+			name: "unready, deleting, unscheduled, and unrelated pods are ignored",
+			pods: []corev1.Pod{
+				pod("a", "router", "10.0.0.1", true, false),
+				pod("b", "router", "10.0.0.2", false, false),
+				pod("c", "router", "10.0.0.3", true, true),
+				pod("d", "router", "", true, false),
+				pod("e", "other", "10.0.0.4", true, false),
+			},
+			expected: []string{"10.0.0.1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := servingNodeAddresses(deployment, tc.pods)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// Test_computeServingNodesAvailableCondition verifies that
+// computeServingNodesAvailableCondition reports whether there are at least as
+// many serving nodes as desired replicas and lists the addresses.
+func Test_computeServingNodesAvailableCondition(t *testing.T) {
+	testCases := []struct {
+		name            string
+		replicas        *int32
+		addresses       []string
+		expectStatus    operatorv1.ConditionStatus
+		expectInMessage string
+	}{
+		{
+			name:            "default replicas, one node",
+			replicas:        nil,
+			addresses:       []string{"10.0.0.1"},
+			expectStatus:    operatorv1.ConditionTrue,
+			expectInMessage: "10.0.0.1",
+		},
+		{
+			name:            "two replicas, two nodes",
+			replicas:        pointer.Int32(2),
+			addresses:       []string{"10.0.0.1", "10.0.0.2"},
+			expectStatus:    operatorv1.ConditionTrue,
+			expectInMessage: "10.0.0.1, 10.0.0.2",
+		},
+		{
+			name:            "three replicas, two nodes",
+			replicas:        pointer.Int32(3),
+			addresses:       []string{"10.0.0.1", "10.0.0.2"},
+			expectStatus:    operatorv1.ConditionFalse,
+			expectInMessage: "2 of 3",
+		},
+		{
+			name:            "two replicas, no nodes",
+			replicas:        pointer.Int32(2),
+			addresses:       nil,
+			expectStatus:    operatorv1.ConditionFalse,
+			expectInMessage: "none",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: tc.replicas}}
+			actual := computeServingNodesAvailableCondition(deployment, tc.addresses)
+			if actual.Type != IngressControllerServingNodesAvailableConditionType {
+				t.Errorf("expected condition type %q, got %q", IngressControllerServingNodesAvailableConditionType, actual.Type)
+			}
+			if actual.Status != tc.expectStatus {
+				t.Errorf("expected status %q, got %q", tc.expectStatus, actual.Status)
+			}
+			if !strings.Contains(actual.Message, tc.expectInMessage) {
+				t.Errorf("expected message to contain %q, got %q", tc.expectInMessage, actual.Message)
+			}
+		})
+	}
+}
+
+// Test_SetServingNodeAddressesMetric verifies that
+// SetServingNodeAddressesMetric replaces the addresses that were previously
+// reported for the ingresscontroller and that
+// DeleteServingNodeAddressesMetric deletes them.
+func Test_SetServingNodeAddressesMetric(t *testing.T) {
+	servingNodeAddressesMetric.Reset()
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	other := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+	SetServingNodeAddressesMetric(other, []string{"10.0.0.9"})
+	SetServingNodeAddressesMetric(ic, []string{"10.0.0.1", "10.0.0.2"})
+	SetServingNodeAddressesMetric(ic, []string{"10.0.0.2"})
+
+	expected := `
+	# HELP ingress_controller_serving_node_address Report the addresses of the nodes that are serving ingress controllers that use node endpoints. The value is always 1.
+	# TYPE ingress_controller_serving_node_address gauge
+	ingress_controller_serving_node_address{address="10.0.0.2",name="test"} 1
+	ingress_controller_serving_node_address{address="10.0.0.9",name="other"} 1
+	`
+	if err := testutil.CollectAndCompare(servingNodeAddressesMetric, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	DeleteServingNodeAddressesMetric(ic)
+	expected = `
+	# HELP ingress_controller_serving_node_address Report the addresses of the nodes that are serving ingress controllers that use node endpoints. The value is always 1.
+	# TYPE ingress_controller_serving_node_address gauge
+	ingress_controller_serving_node_address{address="10.0.0.9",name="other"} 1
+	`
+	if err := testutil.CollectAndCompare(servingNodeAddressesMetric, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
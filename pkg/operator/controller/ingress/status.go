@@ -16,6 +16,7 @@ import (
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 	"github.com/openshift/cluster-ingress-operator/pkg/util/retryableerror"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -30,6 +31,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilclock "k8s.io/utils/clock"
@@ -38,6 +40,16 @@ import (
 // clock is to enable unit testing
 var clock utilclock.Clock = utilclock.RealClock{}
 
+// recreateRolloutGracePeriod is how long a router deployment that uses the
+// Recreate strategy may be unavailable during a rollout before the
+// ingresscontroller reports that it is degraded.
+const recreateRolloutGracePeriod = 5 * time.Minute
+
+// replicaSetFailedCreateReason is the reason of the "ReplicaFailure" condition
+// that the replicaset controller sets, and the deployment controller copies to
+// the deployment, when the replicaset controller fails to create pods.
+const replicaSetFailedCreateReason = "FailedCreate"
+
 // expectedCondition contains a condition that is expected to be checked when
 // determining Available or Degraded status of the ingress controller
 type expectedCondition struct {
@@ -51,7 +63,7 @@ type expectedCondition struct {
 
 // syncIngressControllerStatus computes the current status of ic and
 // updates status upon any changes since last sync.
-func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressController, deployment *appsv1.Deployment, deploymentRef metav1.OwnerReference, pods []corev1.Pod, service *corev1.Service, operandEvents []corev1.Event, wildcardRecord *iov1.DNSRecord, dnsConfig *configv1.DNS, platformStatus *configv1.PlatformStatus) (error, bool) {
+func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressController, deployment *appsv1.Deployment, deploymentRef metav1.OwnerReference, pods []corev1.Pod, service *corev1.Service, operandEvents []corev1.Event, wildcardRecord *iov1.DNSRecord, dnsConfig *configv1.DNS, platformStatus *configv1.PlatformStatus, networkConfig *configv1.Network) (error, bool) {
 	updatedIc := false
 	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
 	if err != nil {
@@ -85,10 +97,142 @@ func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressControlle
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDeploymentReplicasMinAvailableCondition(deployment, pods))
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDeploymentReplicasAllAvailableCondition(deployment))
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDeploymentRollingOutCondition(deployment))
+	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDeploymentPodsAuthorizedCondition(deployment))
+	var lbImplementationInstalled bool
+	var lbImplementationErr error
+	if needsLoadBalancerImplementationCheck(updated, platformStatus, service) {
+		lbImplementationInstalled, lbImplementationErr = metalLBInstalled(r.client)
+	}
+	if lbImplementationErr != nil {
+		// Keep the previous condition rather than flapping the
+		// ingresscontroller's status because of a transient error.
+		errs = append(errs, lbImplementationErr)
+	} else {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeEndpointPublishingStrategySupportedCondition(updated, platformStatus, service, lbImplementationInstalled))
+	}
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeLoadBalancerStatus(ic, service, operandEvents)...)
+	if condition, ok := computeLoadBalancerAddressCondition(updated, service); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerLoadBalancerAddressConditionType)
+	}
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeLoadBalancerProgressingStatus(updated, service, platformStatus, r.config.IngressControllerLBSubnetsAWSEnabled, r.config.IngressControllerEIPAllocationsAWSEnabled))
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDNSStatus(ic, wildcardRecord, platformStatus, dnsConfig)...)
-	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeIngressAvailableCondition(updated.Status.Conditions))
+	if usesNodeEndpoints(updated) {
+		addresses := servingNodeAddresses(deployment, pods)
+		servingNodesCondition := computeServingNodesAvailableCondition(deployment, addresses)
+		if servingNodesCondition.Status == operatorv1.ConditionFalse {
+			for _, cond := range ic.Status.Conditions {
+				if cond.Type == IngressControllerServingNodesAvailableConditionType && cond.Status == operatorv1.ConditionTrue {
+					r.recorder.Event(ic, "Warning", "ServingNodesLost", servingNodesCondition.Message)
+					break
+				}
+			}
+		}
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, servingNodesCondition)
+		SetServingNodeAddressesMetric(ic, addresses)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerServingNodesAvailableConditionType)
+		DeleteServingNodeAddressesMetric(ic)
+	}
+	if required := hasRequiredPodAntiAffinityByHostname(deployment); required || hasPreferredPodAntiAffinityByHostname(deployment) {
+		if schedulableNodes, err := r.schedulableNodes(deployment); err != nil {
+			errs = append(errs, err)
+		} else {
+			updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeReplicasMoreThanSchedulableNodesCondition(deployment, schedulableNodes, required))
+		}
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerReplicasMoreThanSchedulableNodesConditionType)
+	}
+	if condition, ok := computeDomainMigrationProgressingCondition(updated); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerDomainMigrationProgressingConditionType)
+	}
+	if condition, ok := computeStreamingResponsesCondition(updated); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerStreamingResponsesConditionType)
+	}
+	if condition, ok := computeSecurityProfilePresetCondition(updated, deployment); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerSecurityProfilePresetConditionType)
+	}
+	if condition, ok := computeRouterImageOverriddenCondition(updated, r.config.IngressControllerImage); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerRouterImageOverriddenConditionType)
+	}
+	if condition, ok := computeEgressDSCPSupportedCondition(updated, networkConfig, deployment, pods); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerEgressDSCPSupportedConditionType)
+	}
+	if condition, ok := computeHTTP3SupportedCondition(updated, platformStatus); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerHTTP3SupportedConditionType)
+	}
+	if condition, ok := computeStrictSNIHealthChecksCompatibleCondition(updated, deployment, service); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerStrictSNIHealthChecksCompatibleConditionType)
+	}
+	if condition, ok := computeDrainPeriodCondition(updated, platformStatus); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerDrainPeriodConditionType)
+	}
+	if condition, ok := computeDomainDelegationSuspectCondition(wildcardRecord, dnsConfig); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerDomainDelegationSuspectConditionType)
+	}
+	if drainSurgeApplies(updated) {
+		var nodes corev1.NodeList
+		if err := r.client.List(context.TODO(), &nodes); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list nodes: %w", err))
+		} else {
+			updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeDrainSurgeCondition(deployment, cordonedRouterNodes(deployment, pods, nodes.Items)))
+		}
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerDrainSurgeConditionType)
+	}
+	var routerMetricsErr error
+	if routerMetricsApply(updated) && deployment != nil {
+		routerMetricsErr = r.routerMetrics.poll(updated, func(ic *operatorv1.IngressController) (*routerMetricsScraper, error) {
+			return r.newRouterMetricsScraper(ic, deployment, pods)
+		})
+	} else {
+		r.routerMetrics.forget(updated)
+	}
+	if condition, ok := computeReloadIntervalCondition(updated); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerReloadIntervalConditionType)
+	}
+	if config, err := connectionCapacityConfigForIngressController(updated); err == nil && config != nil && deployment != nil {
+		sample, haveSample := r.routerMetrics.connectionSamples.last(types.NamespacedName{Namespace: updated.Namespace, Name: updated.Name})
+		condition, utilization := computeConnectionCapacityCondition(updated, config, deployment.Status.AvailableReplicas, sample, haveSample, clock.Now())
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+		SetConnectionUtilizationMetrics(updated, utilization, condition.Status != operatorv1.ConditionUnknown, routerMetricsErr != nil)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerConnectionCapacityConditionType)
+		DeleteConnectionUtilizationMetrics(updated)
+	}
+	if condition, ok := computeGCPLoadBalancerAddressCondition(updated, service, operandEvents, platformStatus); ok {
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, condition)
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerGCPLoadBalancerAddressReadyConditionType)
+	}
+	if usesAWSLoadBalancerController(updated, platformStatus) {
+		installed, err := awsLoadBalancerControllerInstalled(r.client)
+		updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeAWSLoadBalancerControllerAvailableCondition(installed, err))
+	} else {
+		updated.Status.Conditions = removeCondition(updated.Status.Conditions, IngressControllerAWSLoadBalancerControllerAvailableConditionType)
+	}
+	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeIngressAvailableCondition(updated.Status.Conditions, canaryUserProbeAffectsAvailability(updated)))
 	degradedCondition, err := computeIngressDegradedCondition(updated.Status.Conditions, updated.Name)
 	errs = append(errs, err)
 	updated.Status.Conditions = MergeConditions(updated.Status.Conditions, computeIngressProgressingCondition(updated.Status.Conditions))
@@ -104,6 +248,7 @@ func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressControlle
 		} else {
 			updatedIc = true
 			SetIngressControllerConditionsMetric(updated)
+			SetLoadBalancerReadyReasonMetric(updated)
 		}
 	}
 
@@ -154,6 +299,18 @@ func MergeConditions(conditions []operatorv1.OperatorCondition, updates ...opera
 	return conditions
 }
 
+// removeCondition returns the given conditions without any condition of the
+// given type.
+func removeCondition(conditions []operatorv1.OperatorCondition, conditionType string) []operatorv1.OperatorCondition {
+	var result []operatorv1.OperatorCondition
+	for _, condition := range conditions {
+		if condition.Type != conditionType {
+			result = append(result, condition)
+		}
+	}
+	return result
+}
+
 // PruneConditions removes any conditions that are not currently supported.
 // Returns the updated condition array.
 func PruneConditions(conditions []operatorv1.OperatorCondition) []operatorv1.OperatorCondition {
@@ -183,6 +340,8 @@ func computeIngressTLSProfile(oldProfile *configv1.TLSProfileSpec, deployment *a
 // computeAllowedSourceRanges computes the effective AllowedSourceRanges value
 // by looking at the LoadBalancerSourceRanges field and service.beta.kubernetes.io/load-balancer-source-ranges
 // annotation of the LoadBalancer-typed Service. The field takes precedence over the annotation.
+// Whitespace around the ranges in the annotation is ignored, as the cloud
+// providers do.
 func computeAllowedSourceRanges(service *corev1.Service) []operatorv1.CIDR {
 	if service == nil {
 		return nil
@@ -200,7 +359,9 @@ func computeAllowedSourceRanges(service *corev1.Service) []operatorv1.CIDR {
 		if len(a) > 0 {
 			sourceRanges := strings.Split(a, ",")
 			for _, r := range sourceRanges {
-				cidrs = append(cidrs, operatorv1.CIDR(r))
+				if r = strings.TrimSpace(r); len(r) != 0 {
+					cidrs = append(cidrs, operatorv1.CIDR(r))
+				}
 			}
 			return cidrs
 		}
@@ -269,13 +430,20 @@ func checkPodsScheduledForDeployment(deployment *appsv1.Deployment, pods []corev
 // 1) the Available condition of Deployment,
 // 2) the DNSReady condition of the IngressController, and
 // 3) the LoadBalancerReady condition of the IngressController.
-// The ingresscontroller is judged Available only if all 3 conditions are true
-func computeIngressAvailableCondition(conditions []operatorv1.OperatorCondition) operatorv1.OperatorCondition {
+// The ingresscontroller is judged Available only if all 3 conditions are true.
+// If userProbeAffectsAvailability is true, the UserProbeSucceeding condition of
+// the IngressController must be true as well.
+func computeIngressAvailableCondition(conditions []operatorv1.OperatorCondition, userProbeAffectsAvailability bool) operatorv1.OperatorCondition {
 	expected := []expectedCondition{
 		{
 			condition: IngressControllerDeploymentAvailableConditionType,
 			status:    operatorv1.ConditionTrue,
 		},
+		{
+			condition:        IngressControllerAWSLoadBalancerControllerAvailableConditionType,
+			status:           operatorv1.ConditionTrue,
+			ifConditionsTrue: []string{operatorv1.LoadBalancerManagedIngressConditionType},
+		},
 		{
 			condition: operatorv1.DNSReadyIngressConditionType,
 			status:    operatorv1.ConditionTrue,
@@ -291,6 +459,12 @@ func computeIngressAvailableCondition(conditions []operatorv1.OperatorCondition)
 			ifConditionsTrue: []string{operatorv1.LoadBalancerManagedIngressConditionType},
 		},
 	}
+	if userProbeAffectsAvailability {
+		expected = append(expected, expectedCondition{
+			condition: IngressControllerCanaryUserProbeSuccessConditionType,
+			status:    operatorv1.ConditionTrue,
+		})
+	}
 
 	// Cover the rare case of no conditions
 	if len(conditions) == 0 {
@@ -502,6 +676,21 @@ func computeDeploymentReplicasAllAvailableCondition(deployment *appsv1.Deploymen
 // of expected or available replicas.
 // See Reference: https://github.com/kubernetes/kubectl/blob/master/pkg/polymorphichelpers/rollout_status.go
 func computeDeploymentRollingOutCondition(deployment *appsv1.Deployment) operatorv1.OperatorCondition {
+	condition := computeDeploymentRollingOutConditionForReplicas(deployment)
+	// A deployment that uses the Recreate strategy stops its old replica
+	// before it starts the new one, so the router is expected to be
+	// unavailable for a moment during the rollout.
+	if condition.Status == operatorv1.ConditionTrue && deployment.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		condition.Reason = "DeploymentRecreating"
+		condition.Message = "The router deployment uses the Recreate strategy because its single replica uses the host network and cannot run alongside its replacement, so the router is briefly unavailable while the replica is replaced. " + condition.Message
+	}
+	return condition
+}
+
+// computeDeploymentRollingOutConditionForReplicas computes the
+// "DeploymentRollingOut" status condition from the deployment's replica counts.
+// See computeDeploymentRollingOutCondition.
+func computeDeploymentRollingOutConditionForReplicas(deployment *appsv1.Deployment) operatorv1.OperatorCondition {
 	// If have replicas is less than want replicas, then we are waiting for replicas to be updated.
 	if deployment.Spec.Replicas != nil && deployment.Status.UpdatedReplicas < *deployment.Spec.Replicas {
 		return operatorv1.OperatorCondition{
@@ -544,6 +733,37 @@ func computeDeploymentRollingOutCondition(deployment *appsv1.Deployment) operato
 	}
 }
 
+// computeDeploymentPodsAuthorizedCondition computes the ingresscontroller's
+// "PodsAuthorized" status condition by examining the deployment's
+// "ReplicaFailure" status condition, which the deployment controller copies
+// from the replicaset when the replicaset controller fails to create pods.  The
+// condition is false with reason "FailedCreate" if the replicaset controller
+// reports that reason, which it does when the API rejects the pods, for example
+// because the router service account is missing or is not allowed to use the
+// required security context constraint.  The message of the deployment's
+// condition, which has the details, is included in the condition's message.
+func computeDeploymentPodsAuthorizedCondition(deployment *appsv1.Deployment) operatorv1.OperatorCondition {
+	for _, cond := range deployment.Status.Conditions {
+		if cond.Type != appsv1.DeploymentReplicaFailure || cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		if cond.Reason == replicaSetFailedCreateReason {
+			return operatorv1.OperatorCondition{
+				Type:    IngressControllerPodsAuthorizedConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  replicaSetFailedCreateReason,
+				Message: fmt.Sprintf("Router pods cannot be created: %s", cond.Message),
+			}
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerPodsAuthorizedConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "PodsAuthorized",
+		Message: "No authorization failures are preventing the creation of router pods",
+	}
+}
+
 // computeIngressDegradedCondition computes the ingresscontroller's "Degraded"
 // status condition, which aggregates other status conditions that can indicate
 // a degraded state.  In addition, computeIngressDegradedCondition returns a
@@ -587,6 +807,14 @@ func computeIngressDegradedCondition(conditions []operatorv1.OperatorCondition,
 			},
 			gracePeriod: time.Second * 30,
 		},
+		{
+			condition: IngressControllerPodsAuthorizedConditionType,
+			status:    operatorv1.ConditionTrue,
+		},
+		{
+			condition: IngressControllerEndpointPublishingStrategySupportedConditionType,
+			status:    operatorv1.ConditionTrue,
+		},
 	}
 
 	// Only check the default ingress controller for the canary
@@ -610,6 +838,25 @@ func computeIngressDegradedCondition(conditions []operatorv1.OperatorCondition,
 	if len(conditions) == 0 {
 		return operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeDegraded, Status: operatorv1.ConditionFalse}, nil
 	}
+
+	// While a deployment that uses the Recreate strategy replaces its
+	// replica, the deployment is expected to be unavailable until the new
+	// replica is ready, so allow more time before reporting the
+	// deployment's unavailability as degradation.
+	for _, cond := range conditions {
+		if cond.Type == IngressControllerDeploymentRollingOutConditionType && cond.Status == operatorv1.ConditionTrue && cond.Reason == "DeploymentRecreating" {
+			for i := range expectedConditions {
+				switch expectedConditions[i].condition {
+				case IngressControllerDeploymentAvailableConditionType, IngressControllerDeploymentReplicasMinAvailableConditionType:
+					if expectedConditions[i].gracePeriod < recreateRolloutGracePeriod {
+						expectedConditions[i].gracePeriod = recreateRolloutGracePeriod
+					}
+				}
+			}
+			break
+		}
+	}
+
 	graceConditions, degradedConditions, requeueAfter := checkConditions(expectedConditions, conditions)
 	if len(degradedConditions) != 0 {
 		// Keep checking conditions every minute while degraded.
@@ -622,6 +869,15 @@ func computeIngressDegradedCondition(conditions []operatorv1.OperatorCondition,
 			Reason:  "DegradedConditions",
 			Message: "One or more other status conditions indicate a degraded state: " + degraded,
 		}
+		// An unsupported endpoint publishing strategy is the most
+		// actionable cause of degradation, so surface its reason
+		// directly.
+		for _, cond := range degradedConditions {
+			if cond.Type == IngressControllerEndpointPublishingStrategySupportedConditionType {
+				condition.Reason = cond.Reason
+				break
+			}
+		}
 
 		return condition, retryableerror.New(errors.New("IngressController is degraded: "+degraded), retryAfter)
 	}
@@ -647,6 +903,7 @@ func computeIngressUpgradeableCondition(ic *operatorv1.IngressController, deploy
 	var errs []error
 
 	errs = append(errs, checkDefaultCertificate(secret, "*."+ic.Status.Domain))
+	errs = append(errs, routerImageOverrideIsUpgradeable(ic))
 
 	if service != nil {
 		errs = append(errs, loadBalancerServiceIsUpgradeable(ic, deploymentRef, service, platform, subnetsAWSEnabled, eipAllocationsAWSEnabled))
@@ -871,14 +1128,27 @@ func computeLoadBalancerStatus(ic *operatorv1.IngressController, service *corev1
 			Message: "The LoadBalancer service resource is missing",
 		})
 	case isProvisioned(service):
+		reason := "LoadBalancerProvisioned"
+		message := "The LoadBalancer service is provisioned"
+
+		// The cloud provider may reject an update to a provisioned
+		// load balancer, for example if a public IP prefix or idle
+		// timeout is invalid.  The existing load balancer keeps
+		// serving, so report the rejection without marking the load
+		// balancer as not ready.
+		if event := latestLoadBalancerSyncFailure(service, operandEvents); event != nil {
+			reason = loadBalancerFailureReason(event.Message)
+			message = fmt.Sprintf("The LoadBalancer service is provisioned, but the %s component is reporting SyncLoadBalancerFailed events like: %s\n%s",
+				event.Source.Component, event.Message, "The cloud-controller-manager logs may contain more details.")
+		}
 		conditions = append(conditions, operatorv1.OperatorCondition{
 			Type:    operatorv1.LoadBalancerReadyIngressConditionType,
 			Status:  operatorv1.ConditionTrue,
-			Reason:  "LoadBalancerProvisioned",
-			Message: "The LoadBalancer service is provisioned",
+			Reason:  reason,
+			Message: message,
 		})
 	case isPending(service):
-		reason := "LoadBalancerPending"
+		reason := LoadBalancerPendingProvisioningReason
 		message := "The LoadBalancer service is pending"
 
 		// Try and find a more specific reason for for the pending status.
@@ -887,7 +1157,7 @@ func computeLoadBalancerStatus(ic *operatorv1.IngressController, service *corev1
 		for _, event := range failedLoadBalancerEvents {
 			involved := event.InvolvedObject
 			if involved.Kind == "Service" && involved.Namespace == service.Namespace && involved.Name == service.Name && involved.UID == service.UID {
-				reason = "SyncLoadBalancerFailed"
+				reason = loadBalancerFailureReason(event.Message)
 				message = fmt.Sprintf("The %s component is reporting SyncLoadBalancerFailed events like: %s\n%s",
 					event.Source.Component, event.Message, "The cloud-controller-manager logs may contain more details.")
 				break
@@ -988,6 +1258,34 @@ func isPending(service *corev1.Service) bool {
 	return !isProvisioned(service)
 }
 
+// latestLoadBalancerSyncFailure returns the most recent SyncLoadBalancerFailed
+// event for the given service if it is newer than the most recent
+// EnsuredLoadBalancer event for the service, or nil otherwise.
+func latestLoadBalancerSyncFailure(service *corev1.Service, events []corev1.Event) *corev1.Event {
+	var failed, ensured *corev1.Event
+	for i := range events {
+		event := &events[i]
+		involved := event.InvolvedObject
+		if event.Source.Component != "service-controller" || involved.Kind != "Service" || involved.Namespace != service.Namespace || involved.Name != service.Name || involved.UID != service.UID {
+			continue
+		}
+		switch event.Reason {
+		case "SyncLoadBalancerFailed":
+			if failed == nil || failed.LastTimestamp.Before(&event.LastTimestamp) {
+				failed = event
+			}
+		case "EnsuredLoadBalancer":
+			if ensured == nil || ensured.LastTimestamp.Before(&event.LastTimestamp) {
+				ensured = event
+			}
+		}
+	}
+	if failed == nil || (ensured != nil && !ensured.LastTimestamp.Before(&failed.LastTimestamp)) {
+		return nil
+	}
+	return failed
+}
+
 func getEventsByReason(events []corev1.Event, component, reason string) []corev1.Event {
 	var filtered []corev1.Event
 	for i := range events {
@@ -1022,14 +1320,22 @@ func computeDNSStatus(ic *operatorv1.IngressController, wildcardRecord *iov1.DNS
 		}
 	}
 	var conditions []operatorv1.OperatorCondition
-	if ic.Status.EndpointPublishingStrategy.LoadBalancer.DNSManagementPolicy == operatorv1.UnmanagedLoadBalancerDNS {
+	switch {
+	case CDNOriginEnabled(ic):
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSManagedIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "ExternalCDNOrigin",
+			Message: fmt.Sprintf("The ingress controller is fronted by an external CDN, so DNS is managed outside of the cluster. The origin descriptor is published in configmap %s.", controller.CDNOriginConfigMapName(ic)),
+		})
+	case ic.Status.EndpointPublishingStrategy.LoadBalancer.DNSManagementPolicy == operatorv1.UnmanagedLoadBalancerDNS:
 		conditions = append(conditions, operatorv1.OperatorCondition{
 			Type:    operatorv1.DNSManagedIngressConditionType,
 			Status:  operatorv1.ConditionFalse,
 			Reason:  "UnmanagedLoadBalancerDNS",
 			Message: "The DNS management policy is set to Unmanaged.",
 		})
-	} else {
+	default:
 		conditions = append(conditions, operatorv1.OperatorCondition{
 			Type:    operatorv1.DNSManagedIngressConditionType,
 			Status:  operatorv1.ConditionTrue,
@@ -1111,6 +1417,37 @@ func computeDNSStatus(ic *operatorv1.IngressController, wildcardRecord *iov1.DNS
 	return conditions
 }
 
+// computeDomainDelegationSuspectCondition returns the ingresscontroller's
+// "DomainDelegationSuspect" status condition and a Boolean value indicating
+// whether the condition applies.  The condition reflects the DNS controller's
+// check of whether the public zone to which the given wildcard DNS record is
+// published is delegated to the zone's name servers, and it only applies once
+// the DNS controller has performed the check.  The condition is only a
+// warning: a true status means that the wildcard record likely does not
+// resolve publicly even though it is published.
+func computeDomainDelegationSuspectCondition(wildcardRecord *iov1.DNSRecord, dnsConfig *configv1.DNS) (operatorv1.OperatorCondition, bool) {
+	if wildcardRecord == nil || dnsConfig.Spec.PublicZone == nil {
+		return operatorv1.OperatorCondition{}, false
+	}
+	for _, zone := range wildcardRecord.Status.Zones {
+		if !zonesMatch(&zone.DNSZone, dnsConfig.Spec.PublicZone) {
+			continue
+		}
+		for _, cond := range zone.Conditions {
+			if cond.Type != dnsrecord.DNSDomainDelegationSuspectConditionType {
+				continue
+			}
+			return operatorv1.OperatorCondition{
+				Type:    IngressControllerDomainDelegationSuspectConditionType,
+				Status:  operatorv1.ConditionStatus(cond.Status),
+				Reason:  cond.Reason,
+				Message: cond.Message,
+			}, true
+		}
+	}
+	return operatorv1.OperatorCondition{}, false
+}
+
 // checkZoneInConfig - private utility to check for a zone in the current config
 func checkZoneInConfig(dnsConfig *configv1.DNS, zone configv1.DNSZone) bool {
 	return zonesMatch(&zone, dnsConfig.Spec.PublicZone) || zonesMatch(&zone, dnsConfig.Spec.PrivateZone)
@@ -21,6 +21,7 @@ import (
 	retryable "github.com/openshift/cluster-ingress-operator/pkg/util/retryableerror"
 
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -30,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilclock "k8s.io/utils/clock"
@@ -101,6 +103,29 @@ func failedCreateLBEvent(service string, UID types.UID) corev1.Event {
 	}
 }
 
+func ensuredLBEvent(service string, UID types.UID) corev1.Event {
+	return corev1.Event{
+		Type:    "Normal",
+		Reason:  "EnsuredLoadBalancer",
+		Message: "Ensured load balancer",
+		Source: corev1.EventSource{
+			Component: "service-controller",
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Service",
+			Name: service,
+			UID:  UID,
+		},
+	}
+}
+
+// withLastTimestamp returns a copy of the given event with the given last
+// timestamp.
+func withLastTimestamp(event corev1.Event, t time.Time) corev1.Event {
+	event.LastTimestamp = metav1.NewTime(t)
+	return event
+}
+
 func schedulerEvent() corev1.Event {
 	return corev1.Event{
 		Type:   "Normal",
@@ -271,7 +296,10 @@ func Test_computeIngressDegradedCondition(t *testing.T) {
 		icName                      string
 		conditions                  []operatorv1.OperatorCondition
 		expectIngressDegradedStatus operatorv1.ConditionStatus
-		expectRequeue               bool
+		// expectReason, if non-empty, is the expected reason of the
+		// Degraded condition.
+		expectReason  string
+		expectRequeue bool
 		// A degraded condition will give a 1 minute retry duration
 		// unless there is a grace period expected
 		expectAfter time.Duration
@@ -311,6 +339,28 @@ func Test_computeIngressDegradedCondition(t *testing.T) {
 			// Exceeded grace period, just use the one minute for this degraded condition
 			expectAfter: time.Minute,
 		},
+		{
+			name: "deployment unavailable for >30s while recreating",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerDeploymentAvailableConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Second*-90)),
+				cond(IngressControllerDeploymentReplicasMinAvailableConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Second*-90)),
+				cond(IngressControllerDeploymentRollingOutConditionType, operatorv1.ConditionTrue, "DeploymentRecreating", clock.Now().Add(time.Second*-90)),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionFalse,
+			expectRequeue:               true,
+			// Grace period is 5 minutes while recreating, subtract the 90 second spoofed last transition time
+			expectAfter: time.Second * 210,
+		},
+		{
+			name: "deployment unavailable for >5m while recreating",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerDeploymentAvailableConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Minute*-6)),
+				cond(IngressControllerDeploymentRollingOutConditionType, operatorv1.ConditionTrue, "DeploymentRecreating", clock.Now().Add(time.Minute*-6)),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectRequeue:               true,
+			expectAfter:                 time.Minute,
+		},
 		{
 			name: "deployment minimum replicas unavailable for <60s",
 			conditions: []operatorv1.OperatorCondition{
@@ -466,6 +516,48 @@ func Test_computeIngressDegradedCondition(t *testing.T) {
 			expectRequeue:               false,
 			icName:                      "default",
 		},
+		{
+			name: "pods not authorized and deployment unavailable",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerDeploymentAvailableConditionType, operatorv1.ConditionFalse, "", clock.Now().Add(time.Second*-31)),
+				cond(IngressControllerPodsAuthorizedConditionType, operatorv1.ConditionFalse, "FailedCreate", clock.Now()),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectReason:                "DegradedConditions",
+			expectRequeue:               true,
+			expectAfter:                 time.Minute,
+		},
+		{
+			name: "pods not authorized",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerPodsAuthorizedConditionType, operatorv1.ConditionFalse, "FailedCreate", clock.Now()),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectReason:                "DegradedConditions",
+			expectRequeue:               true,
+			expectAfter:                 time.Minute,
+		},
+		{
+			name: "endpoint publishing strategy unsupported on platform",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerEndpointPublishingStrategySupportedConditionType, operatorv1.ConditionFalse, "StrategyUnsupportedOnPlatform", clock.Now()),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectReason:                "StrategyUnsupportedOnPlatform",
+			expectRequeue:               true,
+			expectAfter:                 time.Minute,
+		},
+		{
+			name: "degraded for a reason other than authorization",
+			conditions: []operatorv1.OperatorCondition{
+				cond(IngressControllerAdmittedConditionType, operatorv1.ConditionFalse, "", clock.Now()),
+				cond(IngressControllerPodsAuthorizedConditionType, operatorv1.ConditionTrue, "PodsAuthorized", clock.Now()),
+			},
+			expectIngressDegradedStatus: operatorv1.ConditionTrue,
+			expectReason:                "DegradedConditions",
+			expectRequeue:               true,
+			expectAfter:                 time.Minute,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -488,6 +580,9 @@ func Test_computeIngressDegradedCondition(t *testing.T) {
 			if actual.Status != test.expectIngressDegradedStatus {
 				t.Errorf("expected status to be %s, got %s", test.expectIngressDegradedStatus, actual.Status)
 			}
+			if len(test.expectReason) != 0 && actual.Reason != test.expectReason {
+				t.Errorf("expected reason to be %s, got %s", test.expectReason, actual.Reason)
+			}
 		})
 	}
 }
@@ -501,7 +596,9 @@ func Test_computeDeploymentRollingOutCondition(t *testing.T) {
 		replicasHave          *int32
 		replicasUpdated       *int32
 		replicasAvailable     *int32
+		recreate              bool
 		expectStatus          operatorv1.ConditionStatus
+		expectReason          string
 		expectMessageContains string
 	}{
 		{
@@ -567,6 +664,28 @@ func Test_computeDeploymentRollingOutCondition(t *testing.T) {
 			replicasAvailable:     pointer.Int32(1),
 			expectMessageContains: "Deployment is not actively rolling out",
 		},
+		{
+			name:                  "Router pod replica recreating",
+			recreate:              true,
+			expectStatus:          operatorv1.ConditionTrue,
+			expectReason:          "DeploymentRecreating",
+			replicasHave:          pointer.Int32(1),
+			replicasWanted:        pointer.Int32(1),
+			replicasUpdated:       pointer.Int32(1),
+			replicasAvailable:     pointer.Int32(0),
+			expectMessageContains: "the router is briefly unavailable while the replica is replaced",
+		},
+		{
+			name:                  "Router pod replica recreated",
+			recreate:              true,
+			expectStatus:          operatorv1.ConditionFalse,
+			expectReason:          "DeploymentNotRollingOut",
+			replicasHave:          pointer.Int32(1),
+			replicasWanted:        pointer.Int32(1),
+			replicasUpdated:       pointer.Int32(1),
+			replicasAvailable:     pointer.Int32(1),
+			expectMessageContains: "Deployment is not actively rolling out",
+		},
 		{
 			name:                  "Router pods replicas have < updated/available (not a possible scenario)",
 			expectStatus:          operatorv1.ConditionFalse,
@@ -589,10 +708,16 @@ func Test_computeDeploymentRollingOutCondition(t *testing.T) {
 					UpdatedReplicas:   *test.replicasUpdated,
 				},
 			}
+			if test.recreate {
+				routerDeploy.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+			}
 			actual := computeDeploymentRollingOutCondition(routerDeploy)
 			if actual.Status != test.expectStatus {
 				t.Errorf("expected status to be %s, got %s", test.expectStatus, actual.Status)
 			}
+			if len(test.expectReason) != 0 && actual.Reason != test.expectReason {
+				t.Errorf("expected reason to be %s, got %s", test.expectReason, actual.Reason)
+			}
 			if len(test.expectMessageContains) != 0 && !strings.Contains(actual.Message, test.expectMessageContains) {
 				t.Errorf("expected message to include %q, got %q", test.expectMessageContains, actual.Message)
 			}
@@ -1304,6 +1429,345 @@ func Test_computeDeploymentAvailableCondition(t *testing.T) {
 	}
 }
 
+// Test_computeStreamingResponsesCondition verifies that
+// computeStreamingResponsesCondition only reports the "StreamingResponses"
+// condition when response streaming is enabled and that its message reflects
+// the effective buffer size.
+func Test_computeStreamingResponsesCondition(t *testing.T) {
+	tests := []struct {
+		name           string
+		overrides      string
+		maxConnections int32
+		expectApplies  bool
+		expectMessage  string
+	}{
+		{
+			name:          "no overrides",
+			expectApplies: false,
+		},
+		{
+			name:          "buffer",
+			overrides:     `{"streamingResponses":{"policy":"Buffer"}}`,
+			expectApplies: false,
+		},
+		{
+			name:          "stream with defaults",
+			overrides:     `{"streamingResponses":{"policy":"Stream"}}`,
+			expectApplies: true,
+			expectMessage: "two 32768-byte buffers per connection, and each router pod accepts up to 50000 connections, for up to 3125 MiB",
+		},
+		{
+			name:           "stream with a response buffer size and max connections",
+			overrides:      `{"streamingResponses":{"policy":"Stream","responseBufferBytes":16384}}`,
+			maxConnections: 10000,
+			expectApplies:  true,
+			expectMessage:  "two 16384-byte buffers per connection, and each router pod accepts up to 10000 connections, for up to 312 MiB",
+		},
+		{
+			name:           "stream with dynamic max connections",
+			overrides:      `{"streamingResponses":{"policy":"Stream"}}`,
+			maxConnections: -1,
+			expectApplies:  true,
+			expectMessage:  "determines dynamically",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(test.overrides)},
+					TuningOptions: operatorv1.IngressControllerTuningOptions{
+						MaxConnections: test.maxConnections,
+					},
+				},
+			}
+			actual, applies := computeStreamingResponsesCondition(ic)
+			if applies != test.expectApplies {
+				t.Fatalf("expected applies=%t, got %t", test.expectApplies, applies)
+			}
+			if !applies {
+				return
+			}
+			if actual.Type != IngressControllerStreamingResponsesConditionType || actual.Status != operatorv1.ConditionTrue {
+				t.Errorf("unexpected condition: %+v", actual)
+			}
+			if !strings.Contains(actual.Message, test.expectMessage) {
+				t.Errorf("expected message to contain %q, got %q", test.expectMessage, actual.Message)
+			}
+		})
+	}
+}
+
+// Test_computeSecurityProfilePresetCondition verifies that
+// computeSecurityProfilePresetCondition reports the effective values of the
+// settings that the "Hardened" security profile preset controls.
+func Test_computeSecurityProfilePresetCondition(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "router",
+						Env: []corev1.EnvVar{
+							{Name: RouterDisableHTTP2EnvName, Value: "false"},
+							{Name: RouterStrictSNI, Value: "true"},
+							{Name: RouterForwardedHeadersPolicy, Value: "replace"},
+							{Name: "ROUTER_DEFAULT_TUNNEL_TIMEOUT", Value: "2h"},
+						},
+					}},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name          string
+		overrides     string
+		expectApplies bool
+		expectMessage []string
+	}{
+		{
+			name:          "no overrides",
+			expectApplies: false,
+		},
+		{
+			name:          "default preset",
+			overrides:     `{"securityProfilePreset":"Default"}`,
+			expectApplies: false,
+		},
+		{
+			name:          "hardened preset",
+			overrides:     `{"securityProfilePreset":"Hardened"}`,
+			expectApplies: true,
+			expectMessage: []string{
+				"minimum TLS version VersionTLS13",
+				"HTTP/2 enabled",
+				"strict SNI enabled",
+				"forwarded header policy replace",
+				"tunnel timeout 2h",
+				"connect timeout default",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(test.overrides)},
+				},
+				Status: operatorv1.IngressControllerStatus{
+					TLSProfile: &configv1.TLSProfileSpec{MinTLSVersion: configv1.VersionTLS13},
+				},
+			}
+			actual, applies := computeSecurityProfilePresetCondition(ic, deployment)
+			if applies != test.expectApplies {
+				t.Fatalf("expected applies=%t, got %t", test.expectApplies, applies)
+			}
+			if !applies {
+				return
+			}
+			if actual.Type != IngressControllerSecurityProfilePresetConditionType || actual.Status != operatorv1.ConditionTrue {
+				t.Errorf("unexpected condition: %+v", actual)
+			}
+			for _, expected := range test.expectMessage {
+				if !strings.Contains(actual.Message, expected) {
+					t.Errorf("expected message to contain %q, got %q", expected, actual.Message)
+				}
+			}
+		})
+	}
+}
+
+// Test_computeStrictSNIHealthChecksCompatibleCondition verifies that
+// computeStrictSNIHealthChecksCompatibleCondition reports a conflict if and only
+// if strict SNI is enabled and the load balancer service configures TLS health
+// checks.
+func Test_computeStrictSNIHealthChecksCompatibleCondition(t *testing.T) {
+	deploymentWithStrictSNI := func(enabled bool) *appsv1.Deployment {
+		container := corev1.Container{Name: "router"}
+		if enabled {
+			container.Env = []corev1.EnvVar{{Name: RouterStrictSNI, Value: "true"}}
+		}
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{container}},
+				},
+			},
+		}
+	}
+	serviceWithAnnotations := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "openshift-ingress",
+				Name:        "router-default",
+				Annotations: annotations,
+			},
+		}
+	}
+	tests := []struct {
+		name          string
+		strategy      operatorv1.EndpointPublishingStrategyType
+		deployment    *appsv1.Deployment
+		service       *corev1.Service
+		expectApplies bool
+		expectStatus  operatorv1.ConditionStatus
+	}{
+		{
+			name:          "strict SNI disabled",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(false),
+			service:       serviceWithAnnotations(map[string]string{awsLBHealthCheckProtocolAnnotation: "SSL"}),
+			expectApplies: false,
+		},
+		{
+			name:          "strict SNI enabled with host network",
+			strategy:      operatorv1.HostNetworkStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(nil),
+			expectApplies: false,
+		},
+		{
+			name:          "strict SNI enabled with default health checks",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(map[string]string{awsLBHealthCheckIntervalAnnotation: "5"}),
+			expectApplies: true,
+			expectStatus:  operatorv1.ConditionTrue,
+		},
+		{
+			name:          "strict SNI enabled with HTTP health checks",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(map[string]string{azureLBHealthProbeProtocolAnnotation: "Http"}),
+			expectApplies: true,
+			expectStatus:  operatorv1.ConditionTrue,
+		},
+		{
+			name:          "strict SNI enabled with AWS SSL health checks",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(map[string]string{awsLBHealthCheckProtocolAnnotation: "SSL"}),
+			expectApplies: true,
+			expectStatus:  operatorv1.ConditionFalse,
+		},
+		{
+			name:          "strict SNI enabled with Azure HTTPS health probes",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(map[string]string{azureLBHealthProbeProtocolAnnotation: "Https"}),
+			expectApplies: true,
+			expectStatus:  operatorv1.ConditionFalse,
+		},
+		{
+			name:          "strict SNI enabled with IBM Cloud HTTPS health checks",
+			strategy:      operatorv1.LoadBalancerServiceStrategyType,
+			deployment:    deploymentWithStrictSNI(true),
+			service:       serviceWithAnnotations(map[string]string{iksLBHealthCheckProtocolAnnotation: "https"}),
+			expectApplies: true,
+			expectStatus:  operatorv1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ic := ingressController("default", test.strategy)
+			actual, applies := computeStrictSNIHealthChecksCompatibleCondition(ic, test.deployment, test.service)
+			if applies != test.expectApplies {
+				t.Fatalf("expected applies=%t, got %t", test.expectApplies, applies)
+			}
+			if !applies {
+				return
+			}
+			if actual.Type != IngressControllerStrictSNIHealthChecksCompatibleConditionType || actual.Status != test.expectStatus {
+				t.Errorf("expected status %s, got %+v", test.expectStatus, actual)
+			}
+		})
+	}
+}
+
+// Test_computeDeploymentPodsAuthorizedCondition verifies that
+// computeDeploymentPodsAuthorizedCondition reports pod creation failures by the
+// reason of the deployment's ReplicaFailure condition.
+func Test_computeDeploymentPodsAuthorizedCondition(t *testing.T) {
+	tests := []struct {
+		name                 string
+		deploymentConditions []appsv1.DeploymentCondition
+		expectStatus         operatorv1.ConditionStatus
+		expectReason         string
+	}{
+		{
+			name:                 "no replica failure",
+			deploymentConditions: []appsv1.DeploymentCondition{},
+			expectStatus:         operatorv1.ConditionTrue,
+			expectReason:         "PodsAuthorized",
+		},
+		{
+			name: "replica failure for scc",
+			deploymentConditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentReplicaFailure,
+				Status:  corev1.ConditionTrue,
+				Reason:  "FailedCreate",
+				Message: `pods "router-default-5d8f7c9b6-" is forbidden: unable to validate against any security context constraint: [provider "restricted-v2": Forbidden: not usable by user or serviceaccount]`,
+			}},
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "FailedCreate",
+		},
+		{
+			name: "replica failure for missing service account",
+			deploymentConditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentReplicaFailure,
+				Status:  corev1.ConditionTrue,
+				Reason:  "FailedCreate",
+				Message: `pods "router-default-5d8f7c9b6-" is forbidden: error looking up service account openshift-ingress/router: serviceaccount "router" not found`,
+			}},
+			expectStatus: operatorv1.ConditionFalse,
+			expectReason: "FailedCreate",
+		},
+		{
+			name: "replica failure for another reason",
+			deploymentConditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentReplicaFailure,
+				Status:  corev1.ConditionTrue,
+				Reason:  "FailedDelete",
+				Message: `pods "router-default-5d8f7c9b6-abcde" is forbidden: unable to delete`,
+			}},
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "PodsAuthorized",
+		},
+		{
+			name: "resolved replica failure",
+			deploymentConditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentReplicaFailure,
+				Status:  corev1.ConditionFalse,
+				Reason:  "FailedCreate",
+				Message: `pods "router-default-5d8f7c9b6-" is forbidden: unable to validate against any security context constraint`,
+			}},
+			expectStatus: operatorv1.ConditionTrue,
+			expectReason: "PodsAuthorized",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deploy := &appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Conditions: test.deploymentConditions,
+				},
+			}
+
+			actual := computeDeploymentPodsAuthorizedCondition(deploy)
+			if actual.Status != test.expectStatus {
+				t.Errorf("expected status %v, got %v", test.expectStatus, actual.Status)
+			}
+			if actual.Reason != test.expectReason {
+				t.Errorf("expected reason %q, got %q", test.expectReason, actual.Reason)
+			}
+		})
+	}
+}
+
 func Test_computeDeploymentReplicasMinAvailableCondition(t *testing.T) {
 	pointerToInt32 := func(i int32) *int32 { return &i }
 	pointerToIntVal := func(val intstr.IntOrString) *intstr.IntOrString { return &val }
@@ -1596,6 +2060,32 @@ func Test_computeLoadBalancerStatus(t *testing.T) {
 				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionTrue, "LoadBalancerProvisioned", clock.Now()),
 			},
 		},
+		{
+			name:       "lb provisioned, update rejected after last sync",
+			controller: ingressController("default", operatorv1.LoadBalancerServiceStrategyType),
+			service:    provisionedLBservice("default"),
+			events: []corev1.Event{
+				withLastTimestamp(ensuredLBEvent("default", ""), clock.Now().Add(-time.Minute)),
+				withLastTimestamp(failedCreateLBEvent("default", ""), clock.Now()),
+			},
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.LoadBalancerManagedIngressConditionType, operatorv1.ConditionTrue, "WantedByEndpointPublishingStrategy", clock.Now()),
+				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionTrue, "QuotaExceeded", clock.Now()),
+			},
+		},
+		{
+			name:       "lb provisioned, update rejected before last sync",
+			controller: ingressController("default", operatorv1.LoadBalancerServiceStrategyType),
+			service:    provisionedLBservice("default"),
+			events: []corev1.Event{
+				withLastTimestamp(failedCreateLBEvent("default", ""), clock.Now().Add(-time.Minute)),
+				withLastTimestamp(ensuredLBEvent("default", ""), clock.Now()),
+			},
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.LoadBalancerManagedIngressConditionType, operatorv1.ConditionTrue, "WantedByEndpointPublishingStrategy", clock.Now()),
+				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionTrue, "LoadBalancerProvisioned", clock.Now()),
+			},
+		},
 		{
 			name:       "no events for current lb",
 			controller: ingressController("default", operatorv1.LoadBalancerServiceStrategyType),
@@ -1607,7 +2097,7 @@ func Test_computeLoadBalancerStatus(t *testing.T) {
 			},
 			expect: []operatorv1.OperatorCondition{
 				cond(operatorv1.LoadBalancerManagedIngressConditionType, operatorv1.ConditionTrue, "WantedByEndpointPublishingStrategy", clock.Now()),
-				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionFalse, "LoadBalancerPending", clock.Now()),
+				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionFalse, "PendingProvisioning", clock.Now()),
 			},
 		},
 		{
@@ -1621,7 +2111,7 @@ func Test_computeLoadBalancerStatus(t *testing.T) {
 			},
 			expect: []operatorv1.OperatorCondition{
 				cond(operatorv1.LoadBalancerManagedIngressConditionType, operatorv1.ConditionTrue, "WantedByEndpointPublishingStrategy", clock.Now()),
-				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionFalse, "SyncLoadBalancerFailed", clock.Now()),
+				cond(operatorv1.LoadBalancerReadyIngressConditionType, operatorv1.ConditionFalse, "QuotaExceeded", clock.Now()),
 			},
 		},
 		{
@@ -1785,9 +2275,10 @@ func Test_computeIngressProgressingCondition(t *testing.T) {
 
 func Test_computeIngressAvailableCondition(t *testing.T) {
 	testCases := []struct {
-		description string
-		conditions  []operatorv1.OperatorCondition
-		expect      operatorv1.OperatorCondition
+		description                  string
+		conditions                   []operatorv1.OperatorCondition
+		userProbeAffectsAvailability bool
+		expect                       operatorv1.OperatorCondition
 	}{
 		{
 			description: "deployment, dns, and lb available",
@@ -1849,11 +2340,49 @@ func Test_computeIngressAvailableCondition(t *testing.T) {
 			conditions:  []operatorv1.OperatorCondition{},
 			expect:      operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionFalse},
 		},
+		{
+			description: "user probe failing, but user probe does not affect availability",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: IngressControllerDeploymentAvailableConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: IngressControllerCanaryUserProbeSuccessConditionType, Status: operatorv1.ConditionFalse},
+			},
+			expect: operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionTrue},
+		},
+		{
+			description: "user probe failing and user probe affects availability",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: IngressControllerDeploymentAvailableConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: IngressControllerCanaryUserProbeSuccessConditionType, Status: operatorv1.ConditionFalse},
+			},
+			userProbeAffectsAvailability: true,
+			expect:                       operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionFalse},
+		},
+		{
+			description: "user probe succeeding and user probe affects availability",
+			conditions: []operatorv1.OperatorCondition{
+				{Type: IngressControllerDeploymentAvailableConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.DNSReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: operatorv1.LoadBalancerReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+				{Type: IngressControllerCanaryUserProbeSuccessConditionType, Status: operatorv1.ConditionTrue},
+			},
+			userProbeAffectsAvailability: true,
+			expect:                       operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionTrue},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			actual := computeIngressAvailableCondition(tc.conditions)
+			actual := computeIngressAvailableCondition(tc.conditions, tc.userProbeAffectsAvailability)
 			conditionsCmpOpts := []cmp.Option{
 				cmpopts.IgnoreFields(operatorv1.OperatorCondition{}, "LastTransitionTime", "Reason", "Message"),
 				cmpopts.EquateEmpty(),
@@ -3258,6 +3787,17 @@ func Test_computeAllowedSourceRanges(t *testing.T) {
 			},
 			expect: []operatorv1.CIDR{"10.0.0.0/8", "192.128.0.0/16"},
 		},
+		{
+			name: "service has service.beta.kubernetes.io/load-balancer-source-ranges with whitespace",
+			service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"service.beta.kubernetes.io/load-balancer-source-ranges": " 10.0.0.0/8, 192.128.0.0/16 ,",
+					},
+				},
+			},
+			expect: []operatorv1.CIDR{"10.0.0.0/8", "192.128.0.0/16"},
+		},
 		{
 			name: "service has service.beta.kubernetes.io/load-balancer-source-ranges, but it's empty",
 			service: &corev1.Service{
@@ -3293,3 +3833,80 @@ func Test_computeAllowedSourceRanges(t *testing.T) {
 		})
 	}
 }
+
+// Test_computeDomainDelegationSuspectCondition verifies that
+// computeDomainDelegationSuspectCondition reports the outcome of the DNS
+// controller's domain delegation check for the public zone and does not report
+// a condition before the check has been performed.
+func Test_computeDomainDelegationSuspectCondition(t *testing.T) {
+	publicZone := configv1.DNSZone{ID: "public"}
+	privateZone := configv1.DNSZone{ID: "private"}
+	dnsConfig := &configv1.DNS{
+		Spec: configv1.DNSSpec{
+			PublicZone:  &publicZone,
+			PrivateZone: &privateZone,
+		},
+	}
+	published := iov1.DNSZoneCondition{
+		Type:   iov1.DNSRecordPublishedConditionType,
+		Status: string(operatorv1.ConditionTrue),
+	}
+	suspect := iov1.DNSZoneCondition{
+		Type:    dnsrecord.DNSDomainDelegationSuspectConditionType,
+		Status:  string(operatorv1.ConditionTrue),
+		Reason:  "NameServerMismatch",
+		Message: "mismatch",
+	}
+	testCases := []struct {
+		name          string
+		dnsConfig     *configv1.DNS
+		zones         []iov1.DNSZoneStatus
+		expectApplies bool
+	}{
+		{
+			name:      "not yet checked",
+			dnsConfig: dnsConfig,
+			zones:     []iov1.DNSZoneStatus{{DNSZone: publicZone, Conditions: []iov1.DNSZoneCondition{published}}},
+		},
+		{
+			name:          "checked",
+			dnsConfig:     dnsConfig,
+			zones:         []iov1.DNSZoneStatus{{DNSZone: privateZone, Conditions: []iov1.DNSZoneCondition{published}}, {DNSZone: publicZone, Conditions: []iov1.DNSZoneCondition{published, suspect}}},
+			expectApplies: true,
+		},
+		{
+			name:      "condition on a zone other than the public zone",
+			dnsConfig: dnsConfig,
+			zones:     []iov1.DNSZoneStatus{{DNSZone: privateZone, Conditions: []iov1.DNSZoneCondition{published, suspect}}},
+		},
+		{
+			name:      "no public zone",
+			dnsConfig: &configv1.DNS{Spec: configv1.DNSSpec{PrivateZone: &privateZone}},
+			zones:     []iov1.DNSZoneStatus{{DNSZone: publicZone, Conditions: []iov1.DNSZoneCondition{published, suspect}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := &iov1.DNSRecord{Status: iov1.DNSRecordStatus{Zones: tc.zones}}
+			condition, applies := computeDomainDelegationSuspectCondition(record, tc.dnsConfig)
+			if applies != tc.expectApplies {
+				t.Fatalf("expected applies=%t, got %t", tc.expectApplies, applies)
+			}
+			if !applies {
+				return
+			}
+			expected := operatorv1.OperatorCondition{
+				Type:    IngressControllerDomainDelegationSuspectConditionType,
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "NameServerMismatch",
+				Message: "mismatch",
+			}
+			if !reflect.DeepEqual(condition, expected) {
+				t.Errorf("expected %+v, got %+v", expected, condition)
+			}
+		})
+	}
+	if _, applies := computeDomainDelegationSuspectCondition(nil, dnsConfig); applies {
+		t.Error("expected no condition for a nil wildcard record")
+	}
+}
@@ -0,0 +1,133 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// RouterStreamingResponses is the router environment variable that,
+	// when set to "true", tells the router to flush response data to
+	// clients as soon as it is received from the backend (using HAProxy's
+	// "option http-no-delay") rather than buffering it, so that streamed
+	// responses such as server-sent events are delivered promptly.
+	RouterStreamingResponses = "ROUTER_STREAMING_RESPONSES"
+
+	// streamingResponsePolicyBuffer is the streaming response policy with
+	// which the router buffers responses.  This is the default.
+	streamingResponsePolicyBuffer = "Buffer"
+	// streamingResponsePolicyStream is the streaming response policy with
+	// which the router flushes response data promptly.
+	streamingResponsePolicyStream = "Stream"
+
+	// minResponseBufferBytes and maxResponseBufferBytes are the bounds for
+	// the response buffer size.  The lower bound is HAProxy's minimum
+	// tune.bufsize; the upper bound limits the router's memory usage.
+	minResponseBufferBytes = 4096
+	maxResponseBufferBytes = 1048576
+
+	// routerDefaultMaxConnections is the router's default value for
+	// HAProxy's maxconn setting.
+	routerDefaultMaxConnections = 50000
+)
+
+// streamingResponsesConfig describes how the router handles response data.
+type streamingResponsesConfig struct {
+	// Policy is either "Buffer" (the default) or "Stream".
+	Policy string `json:"policy"`
+	// ResponseBufferBytes, if non-zero, is the size of the buffers that
+	// HAProxy uses for requests and responses (tune.bufsize).  A smaller
+	// buffer causes HAProxy to forward response data in smaller chunks.
+	ResponseBufferBytes int `json:"responseBufferBytes"`
+}
+
+// streamingResponsesConfigForIngressController returns the response streaming
+// options that the given ingresscontroller specifies in
+// spec.unsupportedConfigOverrides, or nil if it specifies none.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+func streamingResponsesConfigForIngressController(ic *operatorv1.IngressController) (*streamingResponsesConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.StreamingResponses, nil
+}
+
+// validateStreamingResponsesConfig validates the given ingresscontroller's
+// response streaming options, if it specifies any.  The policy must be valid,
+// and the response buffer size must be within bounds, must be larger than the
+// effective header buffer maximum rewrite size, and may not be specified
+// together with spec.tuningOptions.headerBufferBytes, which sets the same
+// HAProxy parameter.
+func validateStreamingResponsesConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.StreamingResponses
+	if config == nil {
+		return nil
+	}
+	var errs []error
+	switch config.Policy {
+	case "", streamingResponsePolicyBuffer, streamingResponsePolicyStream:
+	default:
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.streamingResponses.policy has invalid value %q; must be %q or %q", config.Policy, streamingResponsePolicyBuffer, streamingResponsePolicyStream))
+	}
+	if v := config.ResponseBufferBytes; v != 0 {
+		maxRewrite := int(ic.Spec.TuningOptions.HeaderBufferMaxRewriteBytes)
+		if maxRewrite == 0 {
+			maxRewrite = routerDefaultHeaderBufferMaxRewriteSize
+		}
+		switch {
+		case ic.Spec.TuningOptions.HeaderBufferBytes != 0:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.streamingResponses.responseBufferBytes may not be specified together with spec.tuningOptions.headerBufferBytes"))
+		case v < minResponseBufferBytes || v > maxResponseBufferBytes:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.streamingResponses.responseBufferBytes (%d) must be between %d and %d", v, minResponseBufferBytes, maxResponseBufferBytes))
+		case v <= maxRewrite:
+			errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.streamingResponses.responseBufferBytes (%d) must be larger than headerBufferMaxRewriteBytes (%d)", v, maxRewrite))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// effectiveRouterBufferSize returns the size of HAProxy's buffers for the given
+// ingresscontroller and response streaming options.
+func effectiveRouterBufferSize(ic *operatorv1.IngressController, config *streamingResponsesConfig) int {
+	switch {
+	case ic.Spec.TuningOptions.HeaderBufferBytes != 0:
+		return int(ic.Spec.TuningOptions.HeaderBufferBytes)
+	case config != nil && config.ResponseBufferBytes != 0:
+		return config.ResponseBufferBytes
+	}
+	return routerDefaultHeaderBufferSize
+}
+
+// computeStreamingResponsesCondition returns the ingresscontroller's
+// "StreamingResponses" status condition, which notes the memory implications
+// of the ingresscontroller's response streaming options, and a Boolean value
+// indicating whether the condition applies.  The condition only applies if the
+// ingresscontroller enables response streaming.
+func computeStreamingResponsesCondition(ic *operatorv1.IngressController) (operatorv1.OperatorCondition, bool) {
+	config, err := streamingResponsesConfigForIngressController(ic)
+	if err != nil || config == nil || config.Policy != streamingResponsePolicyStream {
+		return operatorv1.OperatorCondition{}, false
+	}
+	bufSize := effectiveRouterBufferSize(ic, config)
+	var connections string
+	switch v := ic.Spec.TuningOptions.MaxConnections; {
+	case v == -1:
+		connections = "a number of connections that the router determines dynamically"
+	case v > 0:
+		connections = fmt.Sprintf("%d connections, for up to %d MiB", v, int64(v)*2*int64(bufSize)/(1024*1024))
+	default:
+		connections = fmt.Sprintf("%d connections, for up to %d MiB", routerDefaultMaxConnections, int64(routerDefaultMaxConnections)*2*int64(bufSize)/(1024*1024))
+	}
+	return operatorv1.OperatorCondition{
+		Type:   IngressControllerStreamingResponsesConditionType,
+		Status: operatorv1.ConditionTrue,
+		Reason: "StreamingEnabled",
+		Message: fmt.Sprintf("The router flushes response data promptly.  "+
+			"Long-lived streamed responses hold their connections and buffers open: HAProxy allocates two %d-byte buffers per connection, "+
+			"and each router pod accepts up to %s of buffer memory.", bufSize, connections),
+	}, true
+}
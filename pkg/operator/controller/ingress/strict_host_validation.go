@@ -0,0 +1,64 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// RouterStrictHostValidation is the router environment variable that,
+	// when set to "true", tells the router to reject with status 400 any
+	// request that has more than one Host header, an HTTP/2 request whose
+	// :authority pseudo-header does not match its Host header, or a
+	// request with an absolute-form target whose host does not match its
+	// Host header.  Such requests could otherwise be routed by the router
+	// using one host and by the backend using another.  The router counts
+	// the rejected requests in its metrics.
+	RouterStrictHostValidation = "ROUTER_STRICT_HOST_VALIDATION"
+
+	// strictHostValidationEnabled is the strict host validation policy
+	// that tells the router to reject requests with ambiguous hosts.
+	strictHostValidationEnabled = "Enabled"
+	// strictHostValidationDisabled is the strict host validation policy
+	// that tells the router to forward requests with ambiguous hosts, as
+	// it always has.  This is the default.
+	strictHostValidationDisabled = "Disabled"
+)
+
+// strictHostValidationPolicyForIngressController returns the strict host
+// validation policy that the given ingresscontroller specifies using the
+// "tuningOptions.strictHostValidation" unsupported config override, or the
+// empty string if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func strictHostValidationPolicyForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.TuningOptions.StrictHostValidation, nil
+}
+
+// validateStrictHostValidationPolicy validates the given ingresscontroller's
+// strict host validation policy, if it specifies one.
+func validateStrictHostValidationPolicy(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	policy := overrides.TuningOptions.StrictHostValidation
+	switch policy {
+	case "", strictHostValidationEnabled, strictHostValidationDisabled:
+		return nil
+	}
+	return fmt.Errorf("spec.unsupportedConfigOverrides.tuningOptions.strictHostValidation has invalid value %q; must be %q or %q", policy, strictHostValidationEnabled, strictHostValidationDisabled)
+}
+
+// strictHostValidationIsEnabled returns a Boolean value indicating whether the
+// router should reject requests with ambiguous hosts for the given
+// ingresscontroller.  Strict host validation is disabled by default for
+// compatibility with clients that send such requests.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func strictHostValidationIsEnabled(ic *operatorv1.IngressController) (bool, error) {
+	policy, err := strictHostValidationPolicyForIngressController(ic)
+	if err != nil {
+		return false, err
+	}
+	return policy == strictHostValidationEnabled, nil
+}
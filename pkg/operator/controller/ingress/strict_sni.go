@@ -0,0 +1,164 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// strictSNIEnabled is the strict SNI policy that tells the router to
+	// reject TLS connections that do not specify a server name that
+	// matches a certificate.
+	strictSNIEnabled = "Enabled"
+	// strictSNIDisabled is the strict SNI policy that tells the router to
+	// serve the default certificate for TLS connections that do not
+	// specify a server name that matches a certificate.
+	strictSNIDisabled = "Disabled"
+
+	// awsLBHealthCheckProtocolAnnotation is the annotation used on a
+	// service to specify the protocol of the AWS load balancer's health
+	// checks.
+	awsLBHealthCheckProtocolAnnotation = "service.beta.kubernetes.io/aws-load-balancer-healthcheck-protocol"
+	// azureLBHealthProbeProtocolAnnotation is the annotation used on a
+	// service to specify the protocol of the Azure load balancer's health
+	// probes.
+	azureLBHealthProbeProtocolAnnotation = "service.beta.kubernetes.io/azure-load-balancer-health-probe-protocol"
+	// iksLBHealthCheckProtocolAnnotation is the annotation used on a
+	// service to specify the protocol of the IBM Cloud VPC load balancer's
+	// health checks.
+	iksLBHealthCheckProtocolAnnotation = "service.kubernetes.io/ibm-load-balancer-cloud-provider-vpc-health-check-protocol"
+)
+
+// tlsHealthCheckProtocols maps annotations that specify the protocol of a
+// cloud load balancer's health checks to the (lowercased) protocol values that
+// make the load balancer perform a TLS handshake.  Cloud load balancers do not
+// send SNI in their health checks.
+var tlsHealthCheckProtocols = map[string][]string{
+	awsLBHealthCheckProtocolAnnotation:   {"https", "ssl"},
+	azureLBHealthProbeProtocolAnnotation: {"https"},
+	iksLBHealthCheckProtocolAnnotation:   {"https"},
+}
+
+// strictSNIPolicyForIngressController returns the strict SNI policy that the
+// given ingresscontroller specifies using spec.unsupportedConfigOverrides, or
+// the empty string if it specifies none.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func strictSNIPolicyForIngressController(ic *operatorv1.IngressController) (string, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return "", err
+	}
+	return overrides.StrictSNI, nil
+}
+
+// validateStrictSNIPolicy validates the given ingresscontroller's strict SNI
+// policy, if it specifies one.
+func validateStrictSNIPolicy(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	policy := overrides.StrictSNI
+	switch policy {
+	case "", strictSNIEnabled, strictSNIDisabled:
+		return nil
+	}
+	return fmt.Errorf("spec.unsupportedConfigOverrides.strictSNI has invalid value %q; must be %q or %q", policy, strictSNIEnabled, strictSNIDisabled)
+}
+
+// strictSNIIsEnabled returns a Boolean value indicating whether the router
+// should use strict SNI for the given ingresscontroller.  An explicitly
+// specified strict SNI policy takes precedence over the given default, which
+// the security profile preset determines.  Strict SNI is disabled by default
+// for compatibility with clients that do not send SNI.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func strictSNIIsEnabled(ic *operatorv1.IngressController, presetDefault bool) (bool, error) {
+	policy, err := strictSNIPolicyForIngressController(ic)
+	if err != nil {
+		return false, err
+	}
+	switch policy {
+	case strictSNIEnabled:
+		return true, nil
+	case strictSNIDisabled:
+		return false, nil
+	}
+	return presetDefault, nil
+}
+
+// deploymentUsesStrictSNI returns a Boolean value indicating whether the router
+// container of the given deployment has strict SNI enabled.
+func deploymentUsesStrictSNI(deployment *appsv1.Deployment) bool {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "router" {
+			continue
+		}
+		for _, v := range container.Env {
+			if v.Name == RouterStrictSNI {
+				return v.Value == "true"
+			}
+		}
+	}
+	return false
+}
+
+// tlsHealthCheckAnnotations returns the sorted names of the annotations on the
+// given service that configure the cloud load balancer to perform TLS health
+// checks.
+func tlsHealthCheckAnnotations(service *corev1.Service) []string {
+	var names []string
+	for name, protocols := range tlsHealthCheckProtocols {
+		v, ok := service.Annotations[name]
+		if !ok {
+			continue
+		}
+		for _, protocol := range protocols {
+			if strings.EqualFold(v, protocol) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// computeStrictSNIHealthChecksCompatibleCondition returns the
+// ingresscontroller's "StrictSNIHealthChecksCompatible" status condition and a
+// Boolean value indicating whether the condition applies.  The condition only
+// applies if the router deployment has strict SNI enabled and the
+// ingresscontroller uses a load balancer service.
+//
+// The load balancer health checks that the operator configures use plain HTTP
+// against kube-proxy's health check node port or plain TCP, which are not
+// affected by strict SNI.  However, if the load balancer service has been
+// annotated to make the load balancer perform TLS health checks, these health
+// checks do not send SNI, so the router rejects them, and the load balancer
+// takes every router out of rotation.
+func computeStrictSNIHealthChecksCompatibleCondition(ic *operatorv1.IngressController, deployment *appsv1.Deployment, service *corev1.Service) (operatorv1.OperatorCondition, bool) {
+	if deployment == nil || service == nil || !deploymentUsesStrictSNI(deployment) {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if eps := ic.Status.EndpointPublishingStrategy; eps == nil || eps.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return operatorv1.OperatorCondition{}, false
+	}
+	if names := tlsHealthCheckAnnotations(service); len(names) != 0 {
+		return operatorv1.OperatorCondition{
+			Type:   IngressControllerStrictSNIHealthChecksCompatibleConditionType,
+			Status: operatorv1.ConditionFalse,
+			Reason: "TLSHealthChecksWithoutSNI",
+			Message: fmt.Sprintf("Strict SNI is enabled, but service %s/%s has annotations that configure TLS health checks: %s.  "+
+				"Load balancer health checks do not send SNI, so the router rejects them.  "+
+				"Remove the annotations or disable strict SNI by setting spec.unsupportedConfigOverrides.strictSNI to %q.",
+				service.Namespace, service.Name, strings.Join(names, ", "), strictSNIDisabled),
+		}, true
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerStrictSNIHealthChecksCompatibleConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "NoTLSHealthChecks",
+		Message: "Strict SNI is enabled, and the load balancer health checks do not use TLS.",
+	}, true
+}
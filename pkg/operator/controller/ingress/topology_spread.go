@@ -0,0 +1,170 @@
+package ingress
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// podAntiAffinitySpreadPolicy spreads router replicas using required
+	// pod anti-affinity by hostname, which prevents scheduling more
+	// replicas than there are nodes.  This is the default.
+	podAntiAffinitySpreadPolicy = "PodAntiAffinity"
+	// topologySpreadConstraintsSpreadPolicy spreads router replicas using
+	// topology spread constraints, which allow scheduling more replicas
+	// than there are nodes while keeping the replicas evenly spread.
+	topologySpreadConstraintsSpreadPolicy = "TopologySpreadConstraints"
+)
+
+// nodePlacementOverrides is the "nodePlacement" unsupported config override.
+type nodePlacementOverrides struct {
+	// PodSpreadPolicy is how router replicas are spread across nodes,
+	// either "PodAntiAffinity" or "TopologySpreadConstraints".
+	PodSpreadPolicy string `json:"podSpreadPolicy"`
+	// TopologySpreadConstraints replaces the default topology spread
+	// constraints when PodSpreadPolicy is "TopologySpreadConstraints".
+	// The operator sets the constraints' label selectors.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints"`
+}
+
+// nodePlacementForIngressController returns the "nodePlacement" unsupported
+// config override of the given ingresscontroller, or nil if the
+// ingresscontroller does not specify it.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func nodePlacementForIngressController(ic *operatorv1.IngressController) (*nodePlacementOverrides, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.NodePlacement, nil
+}
+
+// validateNodePlacement validates the "nodePlacement" unsupported config
+// override of the given ingresscontroller, if it specifies one.  Topology
+// spread constraints cannot be used with the HostNetwork endpoint publishing
+// strategy, which relies on host port conflicts to spread replicas.
+func validateNodePlacement(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	placement := overrides.NodePlacement
+	if placement == nil {
+		return nil
+	}
+	switch placement.PodSpreadPolicy {
+	case "", podAntiAffinitySpreadPolicy:
+		if len(placement.TopologySpreadConstraints) != 0 {
+			return fmt.Errorf("spec.unsupportedConfigOverrides.nodePlacement.topologySpreadConstraints can only be used with podSpreadPolicy %q", topologySpreadConstraintsSpreadPolicy)
+		}
+		return nil
+	case topologySpreadConstraintsSpreadPolicy:
+	default:
+		return fmt.Errorf("spec.unsupportedConfigOverrides.nodePlacement.podSpreadPolicy must be %q or %q: %q", podAntiAffinitySpreadPolicy, topologySpreadConstraintsSpreadPolicy, placement.PodSpreadPolicy)
+	}
+	if eps := ic.Spec.EndpointPublishingStrategy; eps != nil && eps.Type == operatorv1.HostNetworkStrategyType {
+		return fmt.Errorf("spec.unsupportedConfigOverrides.nodePlacement.podSpreadPolicy %q cannot be used with the %q endpoint publishing strategy", topologySpreadConstraintsSpreadPolicy, operatorv1.HostNetworkStrategyType)
+	}
+	type key struct {
+		topologyKey       string
+		whenUnsatisfiable corev1.UnsatisfiableConstraintAction
+	}
+	seen := map[key]struct{}{}
+	for i, constraint := range placement.TopologySpreadConstraints {
+		field := fmt.Sprintf("spec.unsupportedConfigOverrides.nodePlacement.topologySpreadConstraints[%d]", i)
+		if constraint.MaxSkew < 1 {
+			return fmt.Errorf("%s.maxSkew must be at least 1: %d", field, constraint.MaxSkew)
+		}
+		if len(constraint.TopologyKey) == 0 {
+			return fmt.Errorf("%s.topologyKey must be specified", field)
+		}
+		switch constraint.WhenUnsatisfiable {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			return fmt.Errorf("%s.whenUnsatisfiable must be %q or %q: %q", field, corev1.DoNotSchedule, corev1.ScheduleAnyway, constraint.WhenUnsatisfiable)
+		}
+		if constraint.LabelSelector != nil || len(constraint.MatchLabelKeys) != 0 {
+			return fmt.Errorf("%s must not specify labelSelector or matchLabelKeys, which the operator sets", field)
+		}
+		k := key{constraint.TopologyKey, constraint.WhenUnsatisfiable}
+		if _, ok := seen[k]; ok {
+			return fmt.Errorf("%s duplicates topologyKey %q and whenUnsatisfiable %q", field, constraint.TopologyKey, constraint.WhenUnsatisfiable)
+		}
+		seen[k] = struct{}{}
+	}
+	return nil
+}
+
+// defaultRouterTopologySpreadConstraints returns the topology spread
+// constraints that replace the router deployment's pod anti-affinity when the
+// "TopologySpreadConstraints" pod spread policy is used and the
+// ingresscontroller does not specify its own constraints.
+//
+// The first constraint keeps the number of replicas on any two nodes within
+// one of each other.  Unlike required pod anti-affinity, it allows scheduling
+// more replicas than there are nodes, for example 3 replicas and a surged
+// replica on a 3-node cluster, without colocating replicas while other nodes
+// have fewer.  Tainted nodes that the router does not tolerate are not counted
+// so that they do not hold the skew down.
+//
+// The second constraint is the constraint that spreads replicas across zones
+// with any pod spread policy.  It has no effect on clusters with a single
+// zone.
+func defaultRouterTopologySpreadConstraints() []corev1.TopologySpreadConstraint {
+	honor := corev1.NodeInclusionPolicyHonor
+	return []corev1.TopologySpreadConstraint{{
+		MaxSkew:           int32(1),
+		TopologyKey:       hostnameTopologyKey,
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		NodeTaintsPolicy:  &honor,
+	}, {
+		MaxSkew:           int32(1),
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+	}}
+}
+
+// applyTopologySpreadConstraints configures the given router deployment to
+// spread its replicas using the given topology spread constraints in place of
+// required pod anti-affinity by hostname.  The pod affinity that colocates
+// replicas of different generations during a rolling update is kept.  Like the
+// pod affinity, the constraints only select replicas of the same generation;
+// the hash values are set with the deployment's other selectors.
+func applyTopologySpreadConstraints(deployment *appsv1.Deployment, constraints []corev1.TopologySpreadConstraint) {
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.TopologySpreadConstraints = make([]corev1.TopologySpreadConstraint, len(constraints))
+	for i := range constraints {
+		constraint := *constraints[i].DeepCopy()
+		constraint.LabelSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      controller.ControllerDeploymentHashLabel,
+				Operator: metav1.LabelSelectorOpIn,
+			}},
+		}
+		podSpec.TopologySpreadConstraints[i] = constraint
+	}
+	if podSpec.Affinity != nil {
+		podSpec.Affinity.PodAntiAffinity = nil
+	}
+}
+
+// routerTopologySpreadConstraints returns the topology spread constraints that
+// replace the router deployment's pod anti-affinity for the given
+// ingresscontroller, or nil if the ingresscontroller uses the default
+// "PodAntiAffinity" pod spread policy.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func routerTopologySpreadConstraints(ic *operatorv1.IngressController) ([]corev1.TopologySpreadConstraint, error) {
+	placement, err := nodePlacementForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	if placement == nil || placement.PodSpreadPolicy != topologySpreadConstraintsSpreadPolicy {
+		return nil, nil
+	}
+	if len(placement.TopologySpreadConstraints) != 0 {
+		return placement.TopologySpreadConstraints, nil
+	}
+	return defaultRouterTopologySpreadConstraints(), nil
+}
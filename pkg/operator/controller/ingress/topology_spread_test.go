@@ -0,0 +1,255 @@
+package ingress
+
+import (
+	"fmt"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Test_validateNodePlacement verifies that validateNodePlacement accepts the
+// pod spread policies and well-formed topology spread constraints and rejects
+// topology spread constraints with the HostNetwork endpoint publishing
+// strategy.
+func Test_validateNodePlacement(t *testing.T) {
+	testCases := []struct {
+		name        string
+		overrides   string
+		eps         operatorv1.EndpointPublishingStrategyType
+		expectError bool
+	}{
+		{name: "no overrides"},
+		{name: "PodAntiAffinity", overrides: `{"nodePlacement":{"podSpreadPolicy":"PodAntiAffinity"}}`},
+		{name: "TopologySpreadConstraints", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints"}}`, eps: operatorv1.LoadBalancerServiceStrategyType},
+		{name: "custom constraints", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule"},{"maxSkew":2,"topologyKey":"topology.kubernetes.io/zone","whenUnsatisfiable":"DoNotSchedule"}]}}`},
+		{name: "unknown policy", overrides: `{"nodePlacement":{"podSpreadPolicy":"Spread"}}`, expectError: true},
+		{name: "constraints without policy", overrides: `{"nodePlacement":{"topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule"}]}}`, expectError: true},
+		{name: "HostNetwork", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints"}}`, eps: operatorv1.HostNetworkStrategyType, expectError: true},
+		{name: "zero maxSkew", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":0,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule"}]}}`, expectError: true},
+		{name: "missing topologyKey", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":1,"whenUnsatisfiable":"DoNotSchedule"}]}}`, expectError: true},
+		{name: "invalid whenUnsatisfiable", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"Never"}]}}`, expectError: true},
+		{name: "labelSelector", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule","labelSelector":{"matchLabels":{"app":"router"}}}]}}`, expectError: true},
+		{name: "duplicate constraints", overrides: `{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule"},{"maxSkew":2,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"DoNotSchedule"}]}}`, expectError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec: operatorv1.IngressControllerSpec{
+					UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.overrides)},
+				},
+			}
+			if len(tc.eps) != 0 {
+				ic.Spec.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: tc.eps}
+			}
+			switch err := validateNodePlacement(ic, mustDecodeUnsupportedConfigOverrides(t, ic)); {
+			case err == nil && tc.expectError:
+				t.Error("expected an error, got nil")
+			case err != nil && !tc.expectError:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// simulatedNode is a node for scheduleRouterPods.
+type simulatedNode struct {
+	name string
+	zone string
+}
+
+// topologyValue returns the node's value for the given topology key.
+func (n simulatedNode) topologyValue(key string) string {
+	switch key {
+	case hostnameTopologyKey:
+		return n.name
+	case corev1.LabelTopologyZone:
+		return n.zone
+	}
+	return ""
+}
+
+// scheduleRouterPods simulates scheduling the given deployment's replicas, all
+// of the same generation, one at a time on the given nodes, honoring the pod
+// template's required pod anti-affinity by hostname and its topology spread
+// constraints.  Each pod is scheduled to the first node that satisfies the
+// hard constraints with the least skew for the soft constraints.  Returns the
+// number of pods on each node and the number of pods that could not be
+// scheduled.
+func scheduleRouterPods(deployment *appsv1.Deployment, nodes []simulatedNode) (map[string]int, int) {
+	podSpec := deployment.Spec.Template.Spec
+	requireAntiAffinity := hasRequiredPodAntiAffinityByHostname(deployment)
+	pods := map[string]int{}
+	// skew returns the skew that scheduling another pod to the given node
+	// would cause for the given topology key.
+	skew := func(node simulatedNode, key string) int {
+		domains := map[string]int{}
+		for _, n := range nodes {
+			domains[n.topologyValue(key)] += pods[n.name]
+		}
+		min := -1
+		for _, count := range domains {
+			if min == -1 || count < min {
+				min = count
+			}
+		}
+		return domains[node.topologyValue(key)] + 1 - min
+	}
+	unschedulable := 0
+	for i := int32(0); i < *deployment.Spec.Replicas; i++ {
+		best, bestScore := "", 0
+		for _, node := range nodes {
+			if requireAntiAffinity && pods[node.name] != 0 {
+				continue
+			}
+			feasible, score := true, 0
+			for _, constraint := range podSpec.TopologySpreadConstraints {
+				s := skew(node, constraint.TopologyKey)
+				if constraint.WhenUnsatisfiable == corev1.DoNotSchedule && s > int(constraint.MaxSkew) {
+					feasible = false
+				}
+				if s > int(constraint.MaxSkew) {
+					score += s
+				}
+			}
+			if feasible && (len(best) == 0 || score < bestScore) {
+				best, bestScore = node.name, score
+			}
+		}
+		if len(best) == 0 {
+			unschedulable++
+			continue
+		}
+		pods[best]++
+	}
+	return pods, unschedulable
+}
+
+// Test_desiredRouterDeployment_topologySpread verifies that with the
+// "TopologySpreadConstraints" pod spread policy, the router deployment's
+// replicas are scheduled evenly across the nodes and zones of a 3-node
+// cluster, including when there are more replicas than nodes, whereas with the
+// default pod anti-affinity, excess replicas cannot be scheduled.
+func Test_desiredRouterDeployment_topologySpread(t *testing.T) {
+	ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+	ic.Status.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.LoadBalancerServiceStrategyType}
+
+	clusters := map[string][]simulatedNode{
+		"1 zone":  {{"node-a", "zone-a"}, {"node-b", "zone-a"}, {"node-c", "zone-a"}},
+		"3 zones": {{"node-a", "zone-a"}, {"node-b", "zone-b"}, {"node-c", "zone-c"}},
+	}
+	for _, policy := range []string{podAntiAffinitySpreadPolicy, topologySpreadConstraintsSpreadPolicy} {
+		for clusterName, nodes := range clusters {
+			for _, replicas := range []int32{1, 2, 3, 4} {
+				t.Run(fmt.Sprintf("%s/%s/%d replicas", policy, clusterName, replicas), func(t *testing.T) {
+					ic := ic.DeepCopy()
+					ic.Spec.Replicas = &replicas
+					ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"nodePlacement":{"podSpreadPolicy":%q}}`, policy))}
+					deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+					if err != nil {
+						t.Fatalf("invalid router Deployment: %v", err)
+					}
+					if actual := hasRequiredPodAntiAffinityByHostname(deployment); actual != (policy == podAntiAffinitySpreadPolicy) {
+						t.Errorf("expected required pod anti-affinity to be %t, got %t", policy == podAntiAffinitySpreadPolicy, actual)
+					}
+					hash := deployment.Spec.Template.Labels[controller.ControllerDeploymentHashLabel]
+					for _, constraint := range deployment.Spec.Template.Spec.TopologySpreadConstraints {
+						if exprs := constraint.LabelSelector.MatchExpressions; len(exprs) != 1 || len(exprs[0].Values) != 1 || exprs[0].Values[0] != hash {
+							t.Errorf("expected topology spread constraint for %s to select hash %q, got %+v", constraint.TopologyKey, hash, exprs)
+						}
+					}
+
+					pods, unschedulable := scheduleRouterPods(deployment, nodes)
+					expectUnschedulable := 0
+					if policy == podAntiAffinitySpreadPolicy && int(replicas) > len(nodes) {
+						expectUnschedulable = int(replicas) - len(nodes)
+					}
+					if unschedulable != expectUnschedulable {
+						t.Errorf("expected %d unschedulable pods, got %d", expectUnschedulable, unschedulable)
+					}
+					zones := map[string]int{}
+					minPerNode, maxPerNode := -1, 0
+					for _, node := range nodes {
+						zones[node.zone] += pods[node.name]
+						if minPerNode == -1 || pods[node.name] < minPerNode {
+							minPerNode = pods[node.name]
+						}
+						if pods[node.name] > maxPerNode {
+							maxPerNode = pods[node.name]
+						}
+					}
+					if maxPerNode-minPerNode > 1 {
+						t.Errorf("expected pods to be spread evenly across nodes, got %v", pods)
+					}
+					if len(zones) > 1 {
+						minPerZone, maxPerZone := -1, 0
+						for _, count := range zones {
+							if minPerZone == -1 || count < minPerZone {
+								minPerZone = count
+							}
+							if count > maxPerZone {
+								maxPerZone = count
+							}
+						}
+						if maxPerZone-minPerZone > 1 {
+							t.Errorf("expected pods to be spread evenly across zones, got %v", zones)
+						}
+					}
+				})
+			}
+		}
+	}
+
+	// Custom constraints replace the default constraints.
+	t.Run("custom constraints", func(t *testing.T) {
+		ic := ic.DeepCopy()
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints","topologySpreadConstraints":[{"maxSkew":2,"topologyKey":"topology.kubernetes.io/zone","whenUnsatisfiable":"DoNotSchedule"}]}}`)}
+		deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+		if err != nil {
+			t.Fatalf("invalid router Deployment: %v", err)
+		}
+		constraints := deployment.Spec.Template.Spec.TopologySpreadConstraints
+		if len(constraints) != 1 || constraints[0].TopologyKey != corev1.LabelTopologyZone || constraints[0].MaxSkew != 2 || constraints[0].WhenUnsatisfiable != corev1.DoNotSchedule {
+			t.Errorf("expected the custom topology spread constraint, got %+v", constraints)
+		}
+	})
+
+	// The pod spread policy has no effect on ingress controllers that use
+	// the host network or run in single-replica clusters.
+	t.Run("HostNetwork and single replica", func(t *testing.T) {
+		hostNetwork := ic.DeepCopy()
+		hostNetwork.Status.EndpointPublishingStrategy = &operatorv1.EndpointPublishingStrategy{Type: operatorv1.HostNetworkStrategyType}
+		singleReplicaInfraConfig := infraConfig.DeepCopy()
+		singleReplicaInfraConfig.Status.InfrastructureTopology = configv1.SingleReplicaTopologyMode
+		for _, tc := range []struct {
+			name        string
+			ic          *operatorv1.IngressController
+			infraConfig *configv1.Infrastructure
+		}{
+			{"HostNetwork", hostNetwork, infraConfig},
+			{"single replica", ic, singleReplicaInfraConfig},
+		} {
+			withoutPolicy := tc.ic.DeepCopy()
+			withPolicy := tc.ic.DeepCopy()
+			withPolicy.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"nodePlacement":{"podSpreadPolicy":"TopologySpreadConstraints"}}`)}
+			expected, err := desiredRouterDeployment(withoutPolicy, ingressControllerImage, ingressConfig, tc.infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("%s: invalid router Deployment: %v", tc.name, err)
+			}
+			actual, err := desiredRouterDeployment(withPolicy, ingressControllerImage, ingressConfig, tc.infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("%s: invalid router Deployment: %v", tc.name, err)
+			}
+			if deploymentTemplateHash(expected) != deploymentTemplateHash(actual) {
+				t.Errorf("%s: expected the pod spread policy not to change the pod template", tc.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package ingress
+
+import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+)
+
+// unsupportedConfigOverrides describes the unsupported config overrides that
+// the ingress controller implements.  Each override is documented with the
+// function that returns it, and each override is validated by a function in
+// unsupportedConfigOverridesValidators or in validate.
+type unsupportedConfigOverrides struct {
+	AccessLogFilter            *accessLogFilter              `json:"accessLogFilter"`
+	AWSLoadBalancerProvisioner string                        `json:"awsLoadBalancerProvisioner"`
+	AWSNetworkLoadBalancer     *awsNetworkLoadBalancerConfig `json:"awsNetworkLoadBalancer"`
+	AzureLoadBalancer          *azureLoadBalancerConfig      `json:"azureLoadBalancer"`
+	CanaryEdgeProbe            *CanaryEdgeProbe              `json:"canaryEdgeProbe"`
+	CanaryResponseSize         *CanaryResponseSize           `json:"canaryResponseSize"`
+	CanaryUserProbe            *CanaryUserProbe              `json:"canaryUserProbe"`
+	CDNOrigin                  *CDNOrigin                    `json:"cdnOrigin"`
+	ConnectionCapacity         *connectionCapacityConfig     `json:"connectionCapacity"`
+	ContStats                  string                        `json:"contStats"`
+	DefaultCertificateSigner   struct {
+		SecretName string `json:"secretName"`
+	} `json:"defaultCertificateSigner"`
+	DefaultCertificateVerificationProbe *DefaultCertificateVerificationProbe `json:"defaultCertificateVerificationProbe"`
+	DNSRecordTarget                     string                               `json:"dnsRecordTarget"`
+	DNSZoneTargets                      *dnsZoneTargetsConfig                `json:"dnsZoneTargets"`
+	DomainMigration                     *DomainMigration                     `json:"domainMigration"`
+	DynamicConfigManager                string                               `json:"dynamicConfigManager"`
+	EgressDSCP                          *int                                 `json:"egressDSCP"`
+	ExternalResolutionProbe             *ExternalResolutionProbe             `json:"externalResolutionProbe"`
+	GCPLoadBalancer                     *gcpLoadBalancerConfig               `json:"gcpLoadBalancer"`
+	// HeaderNameCase is the header name case policy, either "Preserve"
+	// (the default) or "Lowercase".
+	HeaderNameCase                         string                           `json:"headerNameCase"`
+	HTTP3                                  *http3Config                     `json:"http3"`
+	HTTPErrorCodePagesByDomain             map[string]string                `json:"httpErrorCodePagesByDomain"`
+	LoadBalancerImplementation             string                           `json:"loadBalancerImplementation"`
+	LoadBalancerIPFamilies                 *loadBalancerIPFamiliesConfig    `json:"loadBalancerIPFamilies"`
+	LoadBalancingAlgorithm                 string                           `json:"loadBalancingAlgorithm"`
+	LocalWithFallback                      string                           `json:"localWithFallback"`
+	MaxConnectionsPerFrontend              *maxConnectionsPerFrontendConfig `json:"maxConnectionsPerFrontend"`
+	NodePlacement                          *nodePlacementOverrides          `json:"nodePlacement"`
+	NodePortExternalEndpoint               *NodePortExternalEndpoint        `json:"nodePortExternalEndpoint"`
+	PreferPodAntiAffinityForExcessReplicas bool                             `json:"preferPodAntiAffinityForExcessReplicas"`
+	PropagatedMetadata                     *propagatedMetadata              `json:"propagatedMetadata"`
+	ProxyProtocol                          *proxyProtocolConfig             `json:"proxyProtocol"`
+	RouteDefaults                          map[string]string                `json:"routeDefaults"`
+	RouterImage                            string                           `json:"routerImage"`
+	RouterMetrics                          *routerMetricsConfig             `json:"routerMetrics"`
+	RouteScale                             *routeScaleConfig                `json:"routeScale"`
+	SecurityProfilePreset                  string                           `json:"securityProfilePreset"`
+	StreamingResponses                     *streamingResponsesConfig        `json:"streamingResponses"`
+	StrictSNI                              string                           `json:"strictSNI"`
+	// SuppressForwardedHeaders is the list of router-generated forwarded
+	// headers that the router must not add to requests.
+	SuppressForwardedHeaders []string                `json:"suppressForwardedHeaders"`
+	SurgeOnNodeDrain         bool                    `json:"surgeOnNodeDrain"`
+	TuningOptions            tuningOptionsOverrides  `json:"tuningOptions"`
+	ZoneAwareRouting         *zoneAwareRoutingConfig `json:"zoneAwareRouting"`
+}
+
+// tuningOptionsOverrides describes the unsupported config overrides that
+// extend spec.tuningOptions.
+type tuningOptionsOverrides struct {
+	DrainPeriod          string `json:"drainPeriod"`
+	HardStopAfter        string `json:"hardStopAfter"`
+	StrictHostValidation string `json:"strictHostValidation"`
+}
+
+// unsupportedConfigOverridesForIngressController decodes the given
+// ingresscontroller's spec.unsupportedConfigOverrides.  If the
+// ingresscontroller specifies no overrides, the returned overrides are empty.
+// An error is returned if spec.unsupportedConfigOverrides cannot be decoded.
+func unsupportedConfigOverridesForIngressController(ic *operatorv1.IngressController) (*unsupportedConfigOverrides, error) {
+	overrides := &unsupportedConfigOverrides{}
+	if err := ingresscontroller.DecodeUnsupportedConfigOverrides(ic, overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// unsupportedConfigOverridesValidator validates the given ingresscontroller's
+// decoded unsupported config overrides.
+type unsupportedConfigOverridesValidator func(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error
+
+// unsupportedConfigOverridesValidators are the validators for the unsupported
+// config overrides that need nothing but the ingresscontroller.  Validators
+// that need more context are called directly by validate.
+var unsupportedConfigOverridesValidators = []unsupportedConfigOverridesValidator{
+	validateRouteDefaults,
+	validateAWSLoadBalancerProvisioner,
+	validateHTTPHeaderOverrides,
+	validateExternalResolutionProbe,
+	validateCanaryEdgeProbe,
+	validateCanaryResponseSize,
+	validateDefaultCertificateSigner,
+	validateDomainMigration,
+	validateDefaultCertificateVerificationProbe,
+	validateRouterMetricsConfig,
+	validateStreamingResponsesConfig,
+	validateAccessLogFilter,
+	validatePropagatedMetadata,
+	validateSecurityProfilePreset,
+	validateAzureLoadBalancerConfig,
+	validateStrictSNIPolicy,
+	validateNodePortExternalEndpoint,
+	validateCDNOrigin,
+	validateAWSNLBIPAddressType,
+	validateAWSNLBSecurity,
+	validateDNSZoneTargets,
+	validateDNSRecordTarget,
+	validateSurgeOnNodeDrain,
+	validateRouterImageOverride,
+	validateEgressDSCP,
+	validateRouteScaleConfig,
+	validateConnectionCapacityConfig,
+	validateMaxConnectionsPerFrontendConfig,
+	validateProxyProtocolConfig,
+	validateZoneAwareRoutingConfig,
+	validateHTTP3Config,
+	validateHardStopAfter,
+	validateStrictHostValidationPolicy,
+	validateDrainPeriod,
+	validateNodePlacement,
+	validateHTTPErrorCodePagesByDomain,
+}
@@ -0,0 +1,89 @@
+package ingress
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// mustDecodeUnsupportedConfigOverrides decodes the given ingresscontroller's
+// unsupported config overrides for a validator test and fails the test if
+// they cannot be decoded.
+func mustDecodeUnsupportedConfigOverrides(t *testing.T, ic *operatorv1.IngressController) *unsupportedConfigOverrides {
+	t.Helper()
+
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		t.Fatalf("failed to decode unsupported config overrides: %v", err)
+	}
+	return overrides
+}
+
+// Test_unsupportedConfigOverridesForIngressController verifies that
+// unsupportedConfigOverridesForIngressController decodes the overrides once
+// into the typed struct and reports malformed overrides.
+func Test_unsupportedConfigOverridesForIngressController(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		expectError bool
+		check       func(*testing.T, *unsupportedConfigOverrides)
+	}{
+		{
+			name: "no overrides",
+			raw:  "",
+			check: func(t *testing.T, o *unsupportedConfigOverrides) {
+				if o.RouterImage != "" || o.TuningOptions.DrainPeriod != "" || o.CanaryEdgeProbe != nil {
+					t.Errorf("expected empty overrides, got %+v", o)
+				}
+			},
+		},
+		{
+			name: "several overrides",
+			raw:  `{"routerImage":"quay.io/example/router:latest","tuningOptions":{"drainPeriod":"30s","hardStopAfter":"1h"},"defaultCertificateSigner":{"secretName":"signer"},"surgeOnNodeDrain":true}`,
+			check: func(t *testing.T, o *unsupportedConfigOverrides) {
+				if o.RouterImage != "quay.io/example/router:latest" {
+					t.Errorf("unexpected routerImage %q", o.RouterImage)
+				}
+				if o.TuningOptions.DrainPeriod != "30s" || o.TuningOptions.HardStopAfter != "1h" {
+					t.Errorf("unexpected tuningOptions %+v", o.TuningOptions)
+				}
+				if o.DefaultCertificateSigner.SecretName != "signer" {
+					t.Errorf("unexpected defaultCertificateSigner %+v", o.DefaultCertificateSigner)
+				}
+				if !o.SurgeOnNodeDrain {
+					t.Error("expected surgeOnNodeDrain to be true")
+				}
+			},
+		},
+		{
+			name:        "malformed overrides",
+			raw:         `{"routerImage":`,
+			expectError: true,
+		},
+		{
+			name:        "override of the wrong type",
+			raw:         `{"surgeOnNodeDrain":"yes"}`,
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if tc.raw != "" {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.raw)}
+			}
+			overrides, err := unsupportedConfigOverridesForIngressController(ic)
+			switch {
+			case tc.expectError && err == nil:
+				t.Fatal("expected an error, got nil")
+			case !tc.expectError && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.check != nil:
+				tc.check(t, overrides)
+			}
+		})
+	}
+}
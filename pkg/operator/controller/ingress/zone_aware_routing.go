@@ -0,0 +1,213 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RouterZoneAwareRoutingEnvName is the router environment variable
+	// that enables preferring endpoints in the router pod's own zone.
+	RouterZoneAwareRoutingEnvName = "ROUTER_ZONE_AWARE_ROUTING"
+	// RouterCrossZoneWeightPercentEnvName is the router environment
+	// variable that specifies the weight of endpoints in other zones as a
+	// percentage of the weight of endpoints in the router pod's zone.
+	RouterCrossZoneWeightPercentEnvName = "ROUTER_CROSS_ZONE_WEIGHT_PERCENT"
+	// RouterZoneFileEnvName is the router environment variable that
+	// specifies the path of the file that contains the name of the router
+	// pod's zone.
+	RouterZoneFileEnvName = "ROUTER_ZONE_FILE"
+
+	// routerTopologyVolumeName is the name of the downward API volume that
+	// provides the router pod's zone label to the router container.
+	routerTopologyVolumeName = "router-topology"
+	// routerTopologyVolumeMountPath is the path at which the router
+	// topology volume is mounted in the router container.
+	routerTopologyVolumeMountPath = "/var/run/router-topology"
+	// routerZoneFileName is the name of the file in the router topology
+	// volume that contains the router pod's zone.
+	routerZoneFileName = "zone"
+)
+
+// zoneAwareRoutingConfig describes the zone-aware routing options that an
+// ingresscontroller specifies using the "zoneAwareRouting" unsupported config
+// override.  Specifying the override at all enables zone-aware routing.
+//
+// With zone-aware routing, the router gives the endpoints of a route's
+// backends that are in the router pod's zone precedence over endpoints in
+// other zones.  The router determines an endpoint's zone from the endpoint's
+// topology hints in its EndpointSlice, if the service has hints, and from the
+// endpoint's zone otherwise.  If the router pod's zone has no ready endpoints
+// for a backend, the router sends the backend's traffic to the endpoints in
+// the other zones as if zone-aware routing were disabled.
+//
+// Kubernetes does not provide a node's labels to the pods that run on the
+// node, so the operator labels each scheduled router pod with its node's zone
+// and provides the label to the router container through a downward API
+// volume, whose contents, unlike environment variables, are updated after the
+// container has started.  Until the label is set, the router does not know its
+// zone and treats all endpoints alike.
+type zoneAwareRoutingConfig struct {
+	// CrossZoneWeightPercent is the weight of endpoints in other zones as
+	// a percentage of the weight of endpoints in the router pod's zone.
+	// The default, 0, means that the router only uses endpoints in other
+	// zones when the router pod's zone has no ready endpoints.
+	CrossZoneWeightPercent *int32 `json:"crossZoneWeightPercent,omitempty"`
+}
+
+// zoneAwareRoutingConfigForIngressController returns the zone-aware routing
+// options that the given ingresscontroller specifies using the
+// "zoneAwareRouting" unsupported config override, or nil if zone-aware routing
+// is not enabled.  An error is returned if spec.unsupportedConfigOverrides
+// cannot be decoded.
+func zoneAwareRoutingConfigForIngressController(ic *operatorv1.IngressController) (*zoneAwareRoutingConfig, error) {
+	overrides, err := unsupportedConfigOverridesForIngressController(ic)
+	if err != nil {
+		return nil, err
+	}
+	return overrides.ZoneAwareRouting, nil
+}
+
+// validateZoneAwareRoutingConfig validates the given ingresscontroller's
+// zone-aware routing options, if it specifies any.
+func validateZoneAwareRoutingConfig(ic *operatorv1.IngressController, overrides *unsupportedConfigOverrides) error {
+	config := overrides.ZoneAwareRouting
+	if config == nil {
+		return nil
+	}
+	var errs []error
+	if v := config.CrossZoneWeightPercent; v != nil && (*v < 0 || *v > 100) {
+		errs = append(errs, fmt.Errorf("spec.unsupportedConfigOverrides.zoneAwareRouting.crossZoneWeightPercent must be between 0 and 100: %d", *v))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// zoneAwareRoutingEnabled returns a Boolean value indicating whether the given
+// ingresscontroller enables zone-aware routing.
+func zoneAwareRoutingEnabled(ic *operatorv1.IngressController) bool {
+	config, err := zoneAwareRoutingConfigForIngressController(ic)
+	return err == nil && config != nil
+}
+
+// zoneAwareRoutingEnv returns the router environment variables for the given
+// zone-aware routing options.
+func zoneAwareRoutingEnv(config *zoneAwareRoutingConfig) []corev1.EnvVar {
+	if config == nil {
+		return nil
+	}
+	crossZoneWeightPercent := int32(0)
+	if config.CrossZoneWeightPercent != nil {
+		crossZoneWeightPercent = *config.CrossZoneWeightPercent
+	}
+	return []corev1.EnvVar{
+		{Name: RouterZoneAwareRoutingEnvName, Value: "true"},
+		{Name: RouterCrossZoneWeightPercentEnvName, Value: strconv.Itoa(int(crossZoneWeightPercent))},
+		{Name: RouterZoneFileEnvName, Value: routerTopologyVolumeMountPath + "/" + routerZoneFileName},
+	}
+}
+
+// routerTopologyVolume returns the downward API volume that provides the
+// router pod's zone label to the router container, and the volume's mount.
+func routerTopologyVolume() (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: routerTopologyVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				// Specify the defaults explicitly so that the
+				// deployment does not appear to have changed when
+				// the API sets them.
+				DefaultMode: ptr.To[int32](0644),
+				Items: []corev1.DownwardAPIVolumeFile{{
+					Path: routerZoneFileName,
+					FieldRef: &corev1.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  fmt.Sprintf("metadata.labels['%s']", corev1.LabelTopologyZone),
+					},
+				}},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      routerTopologyVolumeName,
+		MountPath: routerTopologyVolumeMountPath,
+		ReadOnly:  true,
+	}
+	return volume, mount
+}
+
+// routerPodZoneLabels returns the zone labels that the operator must set on
+// the given pods that belong to the given deployment, keyed by pod name.  A
+// pod needs a label if it is scheduled to a node in the given map of node
+// names to zones and it does not already have the label with the node's zone.
+func routerPodZoneLabels(deployment *appsv1.Deployment, pods []corev1.Pod, nodeZones map[string]string) map[string]string {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return nil
+	}
+	zones := map[string]string{}
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.Labels)) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		zone, ok := nodeZones[pod.Spec.NodeName]
+		if !ok || len(zone) == 0 || pod.Labels[corev1.LabelTopologyZone] == zone {
+			continue
+		}
+		zones[pod.Name] = zone
+	}
+	return zones
+}
+
+// ensureRouterPodZoneLabels labels the given deployment's scheduled router
+// pods among the given pods with the zones of their nodes.
+func (r *reconciler) ensureRouterPodZoneLabels(deployment *appsv1.Deployment, pods []corev1.Pod) error {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("deployment %s/%s has an invalid selector: %w", deployment.Namespace, deployment.Name, err)
+	}
+	nodeZones := map[string]string{}
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if len(nodeName) == 0 || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if _, ok := nodeZones[nodeName]; ok {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get node %q: %w", nodeName, err)
+		}
+		nodeZones[nodeName] = node.Labels[corev1.LabelTopologyZone]
+	}
+	var errs []error
+	for name, zone := range routerPodZoneLabels(deployment, pods, nodeZones) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: deployment.Namespace, Name: name},
+		}
+		patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, corev1.LabelTopologyZone, zone)))
+		if err := r.client.Patch(context.TODO(), pod, patch); err != nil {
+			errs = append(errs, fmt.Errorf("failed to label pod %s/%s with zone %q: %w", deployment.Namespace, name, zone, err))
+			continue
+		}
+		log.Info("labeled router pod with zone", "namespace", deployment.Namespace, "name", name, "zone", zone)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,227 @@
+package ingress
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_validateZoneAwareRoutingConfig verifies that
+// validateZoneAwareRoutingConfig accepts only cross-zone weights between 0 and
+// 100 percent.
+func Test_validateZoneAwareRoutingConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		overrides   string
+		expectError bool
+	}{
+		{
+			description: "no overrides",
+		},
+		{
+			description: "enabled with defaults",
+			overrides:   `{"zoneAwareRouting":{}}`,
+		},
+		{
+			description: "cross-zone weight of 100 percent",
+			overrides:   `{"zoneAwareRouting":{"crossZoneWeightPercent":100}}`,
+		},
+		{
+			description: "negative cross-zone weight",
+			overrides:   `{"zoneAwareRouting":{"crossZoneWeightPercent":-1}}`,
+			expectError: true,
+		},
+		{
+			description: "cross-zone weight above 100 percent",
+			overrides:   `{"zoneAwareRouting":{"crossZoneWeightPercent":101}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ic := &operatorv1.IngressController{}
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			err := validateZoneAwareRoutingConfig(ic, mustDecodeUnsupportedConfigOverrides(t, ic))
+			switch {
+			case err == nil && tc.expectError:
+				t.Fatal("expected error, got nil")
+			case err != nil && !tc.expectError:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDesiredRouterDeploymentZoneAwareRouting verifies that
+// desiredRouterDeployment sets the zone-aware routing environment variables and
+// mounts the router topology volume only if the ingresscontroller enables
+// zone-aware routing.
+func TestDesiredRouterDeploymentZoneAwareRouting(t *testing.T) {
+	testCases := []struct {
+		name         string
+		overrides    string
+		expectEnv    []envData
+		expectVolume bool
+	}{
+		{
+			name: "disabled",
+			expectEnv: []envData{
+				{RouterZoneAwareRoutingEnvName, false, ""},
+				{RouterCrossZoneWeightPercentEnvName, false, ""},
+				{RouterZoneFileEnvName, false, ""},
+			},
+		},
+		{
+			name:      "enabled with defaults",
+			overrides: `{"zoneAwareRouting":{}}`,
+			expectEnv: []envData{
+				{RouterZoneAwareRoutingEnvName, true, "true"},
+				{RouterCrossZoneWeightPercentEnvName, true, "0"},
+				{RouterZoneFileEnvName, true, "/var/run/router-topology/zone"},
+			},
+			expectVolume: true,
+		},
+		{
+			name:      "enabled with a cross-zone weight",
+			overrides: `{"zoneAwareRouting":{"crossZoneWeightPercent":25}}`,
+			expectEnv: []envData{
+				{RouterZoneAwareRoutingEnvName, true, "true"},
+				{RouterCrossZoneWeightPercentEnvName, true, "25"},
+				{RouterZoneFileEnvName, true, "/var/run/router-topology/zone"},
+			},
+			expectVolume: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic, ingressConfig, infraConfig, apiConfig, networkConfig, _, clusterProxyConfig := getRouterDeploymentComponents(t)
+			if len(tc.overrides) != 0 {
+				ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(tc.overrides)}
+			}
+			deployment, err := desiredRouterDeployment(ic, ingressControllerImage, ingressConfig, infraConfig, apiConfig, networkConfig, false, false, nil, clusterProxyConfig, false)
+			if err != nil {
+				t.Fatalf("invalid router Deployment: %v", err)
+			}
+			if err := checkDeploymentEnvironment(t, deployment, tc.expectEnv); err != nil {
+				t.Error(err)
+			}
+			haveVolume := false
+			for _, volume := range deployment.Spec.Template.Spec.Volumes {
+				if volume.Name != routerTopologyVolumeName {
+					continue
+				}
+				haveVolume = true
+				if volume.DownwardAPI == nil || len(volume.DownwardAPI.Items) != 1 || volume.DownwardAPI.Items[0].FieldRef.FieldPath != "metadata.labels['topology.kubernetes.io/zone']" {
+					t.Errorf("unexpected router topology volume: %+v", volume)
+				}
+			}
+			haveMount := false
+			for _, mount := range deployment.Spec.Template.Spec.Containers[0].VolumeMounts {
+				if mount.Name == routerTopologyVolumeName {
+					haveMount = true
+				}
+			}
+			if haveVolume != tc.expectVolume || haveMount != tc.expectVolume {
+				t.Errorf("expected router topology volume and mount: %t, got volume: %t, mount: %t", tc.expectVolume, haveVolume, haveMount)
+			}
+		})
+	}
+}
+
+// Test_ensureRouterPodZoneLabels verifies that ensureRouterPodZoneLabels labels
+// the deployment's scheduled router pods with their nodes' zones and leaves
+// unscheduled pods, pods on nodes without a zone, and other deployments' pods
+// alone.
+func Test_ensureRouterPodZoneLabels(t *testing.T) {
+	const namespace = "openshift-ingress"
+	selector := map[string]string{"ingresscontroller.operator.openshift.io/deployment-ingresscontroller": "default"}
+	otherSelector := map[string]string{"ingresscontroller.operator.openshift.io/deployment-ingresscontroller": "other"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "router-default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+	node := func(name, zone string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if len(zone) != 0 {
+			n.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+		}
+		return n
+	}
+	pod := func(name, nodeName string, podLabels map[string]string) *corev1.Pod {
+		l := map[string]string{}
+		for k, v := range podLabels {
+			l[k] = v
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: l},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	labeled := pod("labeled", "node-b", selector)
+	labeled.Labels[corev1.LabelTopologyZone] = "zone-b"
+	stale := pod("stale", "node-a", selector)
+	stale.Labels[corev1.LabelTopologyZone] = "zone-b"
+	objects := []runtime.Object{
+		node("node-a", "zone-a"),
+		node("node-b", "zone-b"),
+		node("node-c", ""),
+		pod("scheduled", "node-a", selector),
+		labeled,
+		stale,
+		pod("unscheduled", "", selector),
+		pod("no-zone", "node-c", selector),
+		pod("other", "node-a", otherSelector),
+	}
+	expectZones := map[string]string{
+		"scheduled":   "zone-a",
+		"labeled":     "zone-b",
+		"stale":       "zone-a",
+		"unscheduled": "",
+		"no-zone":     "",
+		"other":       "",
+	}
+
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+	r := &reconciler{
+		config:   Config{Namespace: "openshift-ingress-operator"},
+		client:   cl,
+		cache:    fakeCache{Reader: cl},
+		recorder: record.NewFakeRecorder(10),
+	}
+	pods := &corev1.PodList{}
+	if err := cl.List(context.Background(), pods); err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if err := r.ensureRouterPodZoneLabels(deployment, pods.Items); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actualZones := map[string]string{}
+	for name := range expectZones {
+		p := &corev1.Pod{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, p); err != nil {
+			t.Fatalf("failed to get pod %q: %v", name, err)
+		}
+		actualZones[name] = p.Labels[corev1.LabelTopologyZone]
+	}
+	if !reflect.DeepEqual(actualZones, expectZones) {
+		t.Errorf("expected zone labels %v, got %v", expectZones, actualZones)
+	}
+}
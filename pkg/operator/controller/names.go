@@ -2,12 +2,14 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
 	gatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	util "github.com/openshift/cluster-ingress-operator/pkg/util"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -109,6 +111,27 @@ func DefaultIngressCertConfigMapName() types.NamespacedName {
 	}
 }
 
+// IngressCABundleConfigMapName returns the namespaced name for the configmap
+// in which the operator publishes the CA certificates that sign the
+// operator-generated default certificates of all ingresscontrollers, so that
+// internal clients can verify the certificates of any ingresscontroller shard.
+func IngressCABundleConfigMapName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: GlobalMachineSpecifiedConfigNamespace,
+		Name:      "ingress-ca-bundle",
+	}
+}
+
+// IngressFeatureSupportConfigMapName returns the namespaced name for the
+// configmap in which the operator publishes which features of Ingress
+// resources the router supports.
+func IngressFeatureSupportConfigMapName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: GlobalMachineSpecifiedConfigNamespace,
+		Name:      "ingress-feature-support",
+	}
+}
+
 // RouterCertsGlobalSecretName returns the namespaced name for the router certs
 // secret.  The operator uses this secret to publish the default certificates and
 // their keys, so that the authentication operator can configure the OAuth server
@@ -155,6 +178,15 @@ func RsyslogConfigMapName(ic *operatorv1.IngressController) types.NamespacedName
 	}
 }
 
+// CDNOriginConfigMapName returns the namespaced name for the configmap with the
+// origin descriptor of an ingresscontroller that is fronted by an external CDN.
+func CDNOriginConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultOperandNamespace,
+		Name:      "router-cdn-origin-" + ic.Name,
+	}
+}
+
 // HttpErrorCodePageConfigMapName returns the namespaced name for the errorpage configmap.
 func HttpErrorCodePageConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
 	return types.NamespacedName{
@@ -172,12 +204,26 @@ func RouterPodDisruptionBudgetName(ic *operatorv1.IngressController) types.Names
 	}
 }
 
+// RouterSyncedDefaultCertificateSecretName returns the namespaced name for the
+// operator-managed copy of the default certificate secret that an
+// ingresscontroller references in another namespace using the
+// "defaultCertificateSource" unsupported config override.
+func RouterSyncedDefaultCertificateSecretName(ci *operatorv1.IngressController, namespace string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: namespace,
+		Name:      fmt.Sprintf("router-certs-%s-synced", ci.Name),
+	}
+}
+
 // RouterEffectiveDefaultCertificateSecretName returns the namespaced name for
 // the in-use router default certificate secret.
 func RouterEffectiveDefaultCertificateSecretName(ci *operatorv1.IngressController, namespace string) types.NamespacedName {
 	if cert := ci.Spec.DefaultCertificate; cert != nil {
 		return types.NamespacedName{Namespace: namespace, Name: cert.Name}
 	}
+	if source, err := ingresscontroller.DefaultCertificateSource(ci); err == nil && source != nil {
+		return RouterSyncedDefaultCertificateSecretName(ci, namespace)
+	}
 	return RouterOperatorGeneratedDefaultCertificateSecretName(ci, namespace)
 }
 
@@ -229,6 +275,16 @@ func WildcardDNSRecordName(ic *operatorv1.IngressController) types.NamespacedNam
 	}
 }
 
+// PreviousDomainWildcardDNSRecordName returns the namespaced name for the
+// wildcard dnsrecord for the domain that the given ingresscontroller continues
+// to serve while it migrates to a new domain.
+func PreviousDomainWildcardDNSRecordName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: ic.Namespace,
+		Name:      fmt.Sprintf("%s-wildcard-previous", ic.Name),
+	}
+}
+
 func CanaryDaemonSetName() types.NamespacedName {
 	return types.NamespacedName{
 		Namespace: DefaultCanaryNamespace,
@@ -244,6 +300,32 @@ func CanaryDaemonSetPodSelector(canaryControllerName string) *metav1.LabelSelect
 	}
 }
 
+func CanaryServiceAccountName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultCanaryNamespace,
+		Name:      "ingress-canary",
+	}
+}
+
+// CanaryEdgeProbeDaemonSetName returns the namespaced name for the canary
+// edge probe daemonset.
+func CanaryEdgeProbeDaemonSetName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultCanaryNamespace,
+		Name:      "ingress-canary-edge-probe",
+	}
+}
+
+// CanaryEdgeProbeServiceAccountName returns the namespaced name for the
+// service account of the canary edge probe daemonset's pods and for the role
+// binding that grants the service account the use of the hostnetwork SCC.
+func CanaryEdgeProbeServiceAccountName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultCanaryNamespace,
+		Name:      "ingress-canary-edge-probe",
+	}
+}
+
 func CanaryServiceName() types.NamespacedName {
 	return types.NamespacedName{
 		Namespace: DefaultCanaryNamespace,
@@ -258,6 +340,28 @@ func CanaryRouteName() types.NamespacedName {
 	}
 }
 
+// CanaryRouteSubdomain returns the subdomain of the canary route.
+func CanaryRouteSubdomain() string {
+	name := CanaryRouteName()
+	return fmt.Sprintf("%s-%s", name.Name, name.Namespace)
+}
+
+// CanaryRouteHost returns the host of the canary route for the given ingress
+// domain.
+func CanaryRouteHost(domain string) string {
+	return CanaryRouteSubdomain() + "." + domain
+}
+
+// CanaryServingCertSecretName returns the namespaced name for the secret with
+// the operator-generated certificate that the canary serves for the canary
+// route.
+func CanaryServingCertSecretName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: DefaultCanaryNamespace,
+		Name:      "canary-route-serving-cert",
+	}
+}
+
 func IngressClassName(ingressControllerName string) types.NamespacedName {
 	return types.NamespacedName{Name: "openshift-" + ingressControllerName}
 }
@@ -273,6 +377,44 @@ func ServiceMeshControlPlaneName(operandNamespace string) types.NamespacedName {
 	}
 }
 
+// ShardChangeImpactConfigMapName returns the namespaced name for the configmap
+// in which the operator reports the routes that a proposed change to the given
+// ingresscontroller would affect.
+func ShardChangeImpactConfigMapName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: ic.Namespace,
+		Name:      ic.Name + "-shard-change-impact",
+	}
+}
+
+// DefaultIngressControllerSpecSnapshotConfigMapName returns the namespaced name
+// for the configmap in which the operator keeps a snapshot of the default
+// ingresscontroller's spec.  The configmap has no owner so that it outlives the
+// ingresscontroller.
+func DefaultIngressControllerSpecSnapshotConfigMapName(operatorNamespace string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: operatorNamespace,
+		Name:      "default-ingresscontroller-spec",
+	}
+}
+
+// IstiodDeploymentName returns the namespaced name for the istiod deployment
+// that OpenShift Service Mesh creates for the ServiceMeshControlPlane CR in the
+// given operand namespace.
+func IstiodDeploymentName(operandNamespace string) types.NamespacedName {
+	return IstiodDeploymentNameForServiceMeshControlPlane(ServiceMeshControlPlaneName(operandNamespace))
+}
+
+// IstiodDeploymentNameForServiceMeshControlPlane returns the namespaced name
+// for the istiod deployment that OpenShift Service Mesh creates for the
+// ServiceMeshControlPlane CR with the given name.
+func IstiodDeploymentNameForServiceMeshControlPlane(smcpName types.NamespacedName) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: smcpName.Namespace,
+		Name:      "istiod-" + smcpName.Name,
+	}
+}
+
 // ServiceMeshSubscriptionName returns the namespaced name for a Subscription CR
 // to install OpenShift Service Mesh.
 func ServiceMeshSubscriptionName() types.NamespacedName {
@@ -285,10 +427,36 @@ func ServiceMeshSubscriptionName() types.NamespacedName {
 // GatewayDNSRecordName returns the namespaced name for a DNSRecord CR
 // associated with a Gateway.  This CR is created in the Gateway's namespace and
 // is named using the Gateway's name, listener's hashed host name, and the
-// suffix "-wildcard".
+// suffix "-wildcard".  A Gateway has one such CR for each unique listener host
+// name.  The host name is hashed with a trailing dot, which is added if it is
+// missing, so that the name is the same whether or not the given host name
+// has a trailing dot.
 func GatewayDNSRecordName(gateway *gatewayapiv1beta1.Gateway, host string) types.NamespacedName {
+	if !strings.HasSuffix(host, ".") {
+		host = host + "."
+	}
 	return types.NamespacedName{
 		Namespace: gateway.Namespace,
 		Name:      fmt.Sprintf("%s-%s-wildcard", gateway.Name, util.Hash(host)),
 	}
 }
+
+// GatewayCertificateSecretName returns the namespaced name for the secret in
+// which the operator publishes a certificate, signed by the router CA, for the
+// listeners of the given gateway that do not specify a certificate.
+func GatewayCertificateSecretName(gateway *gatewayapiv1beta1.Gateway) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: gateway.Namespace,
+		Name:      gateway.Name + "-default-certificate",
+	}
+}
+
+// GatewayPodMonitorName returns the namespaced name for the podmonitor with
+// which the operator configures Prometheus to scrape the pods of the given
+// gateway.
+func GatewayPodMonitorName(gateway *gatewayapiv1beta1.Gateway) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: gateway.Namespace,
+		Name:      gateway.Name + "-metrics",
+	}
+}
@@ -0,0 +1,282 @@
+package orphancleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"k8s.io/client-go/tools/record"
+
+	utilclock "k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "orphan_cleanup_controller"
+
+	// orphanScanInterval is how often the controller scans the operand
+	// namespace for orphaned resources when no orphan is pending deletion.
+	orphanScanInterval = 10 * time.Minute
+)
+
+var (
+	log = logf.Logger.WithName(controllerName)
+
+	// clock is used to determine how long a resource has been orphaned.
+	clock utilclock.Clock = utilclock.RealClock{}
+)
+
+// New creates and returns a controller that periodically deletes operand
+// resources that the operator created for an IngressController that no longer
+// exists, or only reports them in dry-run mode, which is the default.
+func New(mgr manager.Manager, config Config) (controller.Controller, error) {
+	reconciler := &reconciler{
+		config:   config,
+		client:   mgr.GetClient(),
+		cache:    mgr.GetCache(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+		orphans:  map[orphanKey]*orphanState{},
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	// Every scan covers the whole operand namespace, so map all
+	// ingresscontroller events to a single request.  Deleting an
+	// ingresscontroller starts the grace period of its resources as soon
+	// as possible, and the initial list at start-up triggers the first
+	// scan.
+	toScan := func(ctx context.Context, o client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: config.OperandNamespace}}}
+	}
+	if err := c.Watch(source.Kind[client.Object](mgr.GetCache(), &operatorv1.IngressController{}, handler.EnqueueRequestsFromMapFunc(toScan))); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Config holds all the configuration that must be provided when creating the
+// controller.
+type Config struct {
+	// OperatorNamespace is the namespace of the ingresscontrollers.
+	OperatorNamespace string
+	// OperandNamespace is the namespace that is scanned for orphaned
+	// resources.
+	OperandNamespace string
+	// GracePeriod is how long a resource must remain orphaned before the
+	// controller deletes it.
+	GracePeriod time.Duration
+	// DryRun specifies that the controller only reports orphaned resources
+	// and does not delete them.
+	DryRun bool
+	// CacheFreshness tracks whether the cache is fresh enough for the
+	// controller to delete orphaned resources.
+	CacheFreshness *cachefreshness.Tracker
+}
+
+// orphanKey identifies an orphaned resource.  The UID distinguishes a
+// resource from a later one with the same name.
+type orphanKey struct {
+	kind string
+	name string
+	uid  types.UID
+}
+
+// orphanState tracks an orphaned resource across scans.
+type orphanState struct {
+	// since is when the resource was first found orphaned.
+	since time.Time
+	// reported indicates whether the resource has been reported in
+	// dry-run mode, so that it is reported only once.
+	reported bool
+}
+
+// reconciler handles the actual orphan cleanup logic.
+type reconciler struct {
+	config Config
+
+	client   client.Client
+	cache    cache.Cache
+	recorder record.EventRecorder
+
+	// orphans tracks the orphaned resources found by previous scans.  It
+	// is only accessed from Reconcile, which the controller never runs
+	// concurrently for the single request that it handles.
+	orphans map[orphanKey]*orphanState
+}
+
+// operandKinds lists the kinds of operand resources that the controller scans,
+// along with a function to create an empty list for each kind.
+var operandKinds = []struct {
+	kind    string
+	newList func() client.ObjectList
+}{
+	{"Deployment", func() client.ObjectList { return &appsv1.DeploymentList{} }},
+	{"Service", func() client.ObjectList { return &corev1.ServiceList{} }},
+	{"ConfigMap", func() client.ObjectList { return &corev1.ConfigMapList{} }},
+	{"Secret", func() client.ObjectList { return &corev1.SecretList{} }},
+}
+
+// Reconcile scans the operand namespace for resources that the operator created
+// for an ingresscontroller that does not exist anymore, and deletes those that
+// have been orphaned for at least the grace period.  Resources without the
+// owning-ingresscontroller label or without the operator's ownership are never
+// considered.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	now := clock.Now()
+	requeueAfter := orphanScanInterval
+	seen := map[orphanKey]struct{}{}
+	var errs []error
+	for _, k := range operandKinds {
+		list := k.newList()
+		if err := r.client.List(ctx, list, client.InNamespace(r.config.OperandNamespace), client.HasLabels{manifests.OwningIngressControllerLabel}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s resources in namespace %q: %w", k.kind, r.config.OperandNamespace, err))
+			continue
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to extract %s resources: %w", k.kind, err))
+			continue
+		}
+		for _, o := range objects {
+			obj := o.(client.Object)
+			orphaned, err := r.isOrphaned(ctx, obj)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !orphaned {
+				continue
+			}
+			key := orphanKey{kind: k.kind, name: obj.GetName(), uid: obj.GetUID()}
+			seen[key] = struct{}{}
+			state, ok := r.orphans[key]
+			if !ok {
+				state = &orphanState{since: now}
+				r.orphans[key] = state
+				log.Info("found orphaned operand resource", "kind", k.kind, "namespace", obj.GetNamespace(), "name", obj.GetName(), "ingresscontroller", obj.GetLabels()[manifests.OwningIngressControllerLabel])
+			}
+			if remaining := state.since.Add(r.config.GracePeriod).Sub(now); remaining > 0 {
+				if remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+				continue
+			}
+			if err := r.cleanUp(ctx, k.kind, obj, state); err != nil {
+				if staleErr, ok := cachefreshness.IsStaleCache(err); ok {
+					if staleErr.RetryAfter < requeueAfter {
+						requeueAfter = staleErr.RetryAfter
+					}
+					continue
+				}
+				errs = append(errs, err)
+			}
+		}
+	}
+	// Forget resources that are gone or that are no longer orphaned, for
+	// example because the ingresscontroller was recreated.
+	for key := range r.orphans {
+		if _, ok := seen[key]; !ok {
+			delete(r.orphans, key)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, utilerrors.NewAggregate(errs)
+}
+
+// isOrphaned returns a Boolean value indicating whether the given resource
+// names an ingresscontroller in its owning-ingresscontroller label that does
+// not exist, and the operator created the resource for that ingresscontroller.
+// Resources that are being deleted or that have an empty label value are not
+// considered orphaned.
+func (r *reconciler) isOrphaned(ctx context.Context, obj client.Object) (bool, error) {
+	if obj.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+	owner := obj.GetLabels()[manifests.OwningIngressControllerLabel]
+	if len(owner) == 0 || !createdByOperator(obj, owner) {
+		return false, nil
+	}
+	name := types.NamespacedName{Namespace: r.config.OperatorNamespace, Name: owner}
+	if err := r.client.Get(ctx, name, &operatorv1.IngressController{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get ingresscontroller %q: %w", name, err)
+	}
+	return false, nil
+}
+
+// createdByOperator returns a Boolean value indicating whether the operator
+// created the given resource for the named ingresscontroller.  Anyone can set
+// the owning-ingresscontroller label, so the label alone is not enough.  The
+// operator names the router deployment after the ingresscontroller and selects
+// the router pods using the deployment-ingresscontroller label, and it makes
+// the router deployment the controller of the other operand resources.
+func createdByOperator(obj client.Object, owner string) bool {
+	deploymentName := operatorcontroller.RouterDeploymentName(&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: owner}}).Name
+	if deployment, ok := obj.(*appsv1.Deployment); ok {
+		selector := deployment.Spec.Selector
+		return deployment.Name == deploymentName && selector != nil && selector.MatchLabels[operatorcontroller.ControllerDeploymentLabel] == owner
+	}
+	ref := metav1.GetControllerOf(obj)
+	return ref != nil && ref.APIVersion == "apps/v1" && ref.Kind == "Deployment" && ref.Name == deploymentName
+}
+
+// cleanUp deletes the given orphaned resource, or only reports it if the
+// controller is in dry-run mode, and records an event and a metric for it.  In
+// dry-run mode, each orphan is reported only once.  While the cache might be
+// stale, cleanUp does not delete the resource and returns a
+// *cachefreshness.StaleCacheError.
+func (r *reconciler) cleanUp(ctx context.Context, kind string, obj client.Object, state *orphanState) error {
+	owner := obj.GetLabels()[manifests.OwningIngressControllerLabel]
+	if r.config.DryRun {
+		if state.reported {
+			return nil
+		}
+		state.reported = true
+		r.recorder.Eventf(obj, corev1.EventTypeWarning, "OrphanedOperandFound", "%s %s/%s is labeled as owned by ingresscontroller %q, which does not exist; not deleting it because the orphan cleanup is in dry-run mode", kind, obj.GetNamespace(), obj.GetName(), owner)
+		orphanedOperandResources.WithLabelValues(kind, orphanActionReported).Inc()
+		log.Info("found orphaned operand resource in dry-run mode", "kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName(), "ingresscontroller", owner)
+		return nil
+	}
+	if err := r.config.CacheFreshness.AllowDestructiveAction(ctx, r.cache, controllerName, "Delete"+kind, &operatorv1.IngressController{}); err != nil {
+		return err
+	}
+	uid := obj.GetUID()
+	if err := r.client.Delete(ctx, obj, client.Preconditions{UID: &uid}); err != nil {
+		if kerrors.IsNotFound(err) || kerrors.IsConflict(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete orphaned %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+	}
+	r.recorder.Eventf(obj, corev1.EventTypeNormal, "OrphanedOperandDeleted", "Deleted %s %s/%s, which was labeled as owned by ingresscontroller %q, which does not exist", kind, obj.GetNamespace(), obj.GetName(), owner)
+	orphanedOperandResources.WithLabelValues(kind, orphanActionDeleted).Inc()
+	log.Info("deleted orphaned operand resource", "kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName(), "ingresscontroller", owner)
+	return nil
+}
@@ -0,0 +1,199 @@
+package orphancleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.io/client-go/tools/record"
+
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Test_Reconcile verifies that Reconcile deletes operand resources that are
+// labeled as owned by an ingresscontroller that does not exist once the grace
+// period has elapsed, that it never deletes resources of existing
+// ingresscontrollers, resources without the owning-ingresscontroller label, or
+// labeled resources that the operator did not create, and that it deletes
+// nothing in dry-run mode.
+func Test_Reconcile(t *testing.T) {
+	const (
+		operatorNamespace = "openshift-ingress-operator"
+		operandNamespace  = "openshift-ingress"
+		gracePeriod       = time.Hour
+	)
+	meta := func(namespace, name, owner string) metav1.ObjectMeta {
+		m := metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(namespace + "/" + name),
+		}
+		if len(owner) != 0 {
+			m.Labels = map[string]string{manifests.OwningIngressControllerLabel: owner}
+		}
+		return m
+	}
+	// owned returns the given metadata with a controller reference to the
+	// given deployment.
+	owned := func(m metav1.ObjectMeta, deployment string) metav1.ObjectMeta {
+		controller := true
+		m.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: deployment, UID: types.UID(operandNamespace + "/" + deployment), Controller: &controller}}
+		return m
+	}
+	// routerSelector returns the selector of the given ingresscontroller's
+	// router deployment.
+	routerSelector := func(owner string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{operatorcontroller.ControllerDeploymentLabel: owner}}
+	}
+	ic := &operatorv1.IngressController{ObjectMeta: meta(operatorNamespace, "default", "")}
+	orphans := []client.Object{
+		&appsv1.Deployment{ObjectMeta: meta(operandNamespace, "router-renamed", "renamed"), Spec: appsv1.DeploymentSpec{Selector: routerSelector("renamed")}},
+		&corev1.Service{ObjectMeta: owned(meta(operandNamespace, "router-internal-renamed", "renamed"), "router-renamed")},
+		&corev1.ConfigMap{ObjectMeta: owned(meta(operandNamespace, "service-ca-bundle-renamed", "renamed"), "router-renamed")},
+		&corev1.Secret{ObjectMeta: owned(meta(operandNamespace, "router-stats-deleted", "deleted"), "router-deleted")},
+	}
+	legitimate := []client.Object{
+		// Resources of an ingresscontroller that exists.
+		&appsv1.Deployment{ObjectMeta: meta(operandNamespace, "router-default", "default"), Spec: appsv1.DeploymentSpec{Selector: routerSelector("default")}},
+		&corev1.Service{ObjectMeta: owned(meta(operandNamespace, "router-internal-default", "default"), "router-default")},
+		// Resources without the label, including one with a name that
+		// looks like that of an operand.
+		&corev1.Secret{ObjectMeta: meta(operandNamespace, "router-stats-renamed", "")},
+		&corev1.ConfigMap{ObjectMeta: meta(operandNamespace, "user-config", "")},
+		&corev1.Service{ObjectMeta: meta(operandNamespace, "user-service", "")},
+		// A resource with an empty label value.
+		&corev1.ConfigMap{ObjectMeta: meta(operandNamespace, "empty-owner", "")},
+		// A labeled resource outside the operand namespace.
+		&appsv1.Deployment{ObjectMeta: meta("user-namespace", "router-renamed", "renamed"), Spec: appsv1.DeploymentSpec{Selector: routerSelector("renamed")}},
+		// Labeled resources that the operator did not create: a
+		// deployment that is not named or does not select pods like a
+		// router deployment, a resource without a controller, and a
+		// resource that another deployment controls.
+		&appsv1.Deployment{ObjectMeta: meta(operandNamespace, "user-router", "deleted"), Spec: appsv1.DeploymentSpec{Selector: routerSelector("deleted")}},
+		&appsv1.Deployment{ObjectMeta: meta(operandNamespace, "router-deleted", "deleted"), Spec: appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "user"}}}},
+		&corev1.ConfigMap{ObjectMeta: meta(operandNamespace, "user-labeled", "deleted")},
+		&corev1.Service{ObjectMeta: owned(meta(operandNamespace, "user-labeled", "deleted"), "user-router")},
+	}
+	legitimate[5].SetLabels(map[string]string{manifests.OwningIngressControllerLabel: ""})
+
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+
+	for _, dryRun := range []bool{false, true} {
+		name := "delete"
+		if dryRun {
+			name = "dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			fakeClock := utilclocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+			clock = fakeClock
+			defer func() {
+				clock = utilclock.RealClock{}
+			}()
+
+			objects := []client.Object{ic.DeepCopy()}
+			for _, o := range append(append([]client.Object{}, orphans...), legitimate...) {
+				objects = append(objects, o.DeepCopyObject().(client.Object))
+			}
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			recorder := record.NewFakeRecorder(20)
+			r := &reconciler{
+				config: Config{
+					OperatorNamespace: operatorNamespace,
+					OperandNamespace:  operandNamespace,
+					GracePeriod:       gracePeriod,
+					DryRun:            dryRun,
+				},
+				client:   cl,
+				recorder: recorder,
+				orphans:  map[orphanKey]*orphanState{},
+			}
+			action := orphanActionDeleted
+			if dryRun {
+				action = orphanActionReported
+			}
+			deployments := testutil.ToFloat64(orphanedOperandResources.WithLabelValues("Deployment", action))
+
+			exists := func(o client.Object) bool {
+				current := o.DeepCopyObject().(client.Object)
+				err := cl.Get(context.Background(), client.ObjectKeyFromObject(o), current)
+				if err != nil && !kerrors.IsNotFound(err) {
+					t.Fatalf("failed to get %s/%s: %v", o.GetNamespace(), o.GetName(), err)
+				}
+				return err == nil
+			}
+			reconcileOnce := func() reconcile.Result {
+				result, err := r.Reconcile(context.Background(), reconcile.Request{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return result
+			}
+
+			// Within the grace period, nothing is deleted, and the
+			// controller scans again when the grace period ends.
+			if result := reconcileOnce(); result.RequeueAfter > orphanScanInterval || result.RequeueAfter <= 0 {
+				t.Errorf("expected a requeue within %v, got %v", orphanScanInterval, result.RequeueAfter)
+			}
+			fakeClock.Step(gracePeriod - time.Minute)
+			if result := reconcileOnce(); result.RequeueAfter != time.Minute {
+				t.Errorf("expected a requeue after the remaining grace period of 1m, got %v", result.RequeueAfter)
+			}
+			for _, o := range orphans {
+				if !exists(o) {
+					t.Errorf("expected %s/%s not to be deleted within the grace period", o.GetNamespace(), o.GetName())
+				}
+			}
+			if len(recorder.Events) != 0 {
+				t.Errorf("expected no events within the grace period, got %d", len(recorder.Events))
+			}
+
+			// After the grace period, only the orphans are deleted,
+			// or reported once in dry-run mode.
+			fakeClock.Step(time.Minute)
+			reconcileOnce()
+			reconcileOnce()
+			for _, o := range orphans {
+				if exists(o) == !dryRun {
+					t.Errorf("expected %s/%s to exist=%t, got exist=%t", o.GetNamespace(), o.GetName(), dryRun, !dryRun)
+				}
+			}
+			for _, o := range legitimate {
+				if !exists(o) {
+					t.Errorf("expected %s/%s not to be deleted", o.GetNamespace(), o.GetName())
+				}
+			}
+			if !exists(ic) {
+				t.Error("expected the ingresscontroller not to be deleted")
+			}
+			if len(recorder.Events) != len(orphans) {
+				t.Errorf("expected %d events, got %d", len(orphans), len(recorder.Events))
+			}
+			if delta := testutil.ToFloat64(orphanedOperandResources.WithLabelValues("Deployment", action)) - deployments; delta != 1 {
+				t.Errorf("expected the %s metric for deployments to increase by 1, got %v", action, delta)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package orphancleanup
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// orphanActionDeleted is the value of the action label for orphaned
+	// resources that the controller deleted.
+	orphanActionDeleted = "deleted"
+	// orphanActionReported is the value of the action label for orphaned
+	// resources that the controller only reported because it is in dry-run
+	// mode.
+	orphanActionReported = "reported"
+)
+
+var (
+	// orphanedOperandResources counts the orphaned operand resources that
+	// the controller cleaned up or, in dry-run mode, reported.
+	orphanedOperandResources = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ingress_orphaned_operand_resources_total",
+		Help: "Counts operand resources labeled as owned by an IngressController that does not exist, by kind and by whether they were deleted or only reported in dry-run mode.",
+	}, []string{"kind", "action"})
+
+	// metricsList is a list of metrics for this package.
+	metricsList = []prometheus.Collector{
+		orphanedOperandResources,
+	}
+)
+
+// RegisterMetrics calls prometheus.Register on each metric in metricsList, and
+// returns on errors.
+func RegisterMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,7 +17,9 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+	utilclock "k8s.io/utils/clock"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,12 +36,17 @@ const (
 
 var (
 	log = logf.Logger.WithName(controllerName)
+
+	// clock is used to determine when the controller observes route
+	// rejections.
+	clock utilclock.Clock = utilclock.RealClock{}
 )
 
 // New creates the route metrics controller. This is the controller
 // that handles all the logic for gathering and exporting
-// metrics related to route resources.
-func New(mgr manager.Manager, namespace string) (controller.Controller, error) {
+// metrics related to route resources.  The operator release version
+// determines the soft limits on the number of routes that a router can serve.
+func New(mgr manager.Manager, namespace, operatorReleaseVersion string) (controller.Controller, error) {
 	// Create a new cache to watch on Route objects from every namespace.
 	newCache, err := cache.New(mgr.GetConfig(), cache.Options{
 		Scheme: mgr.GetScheme(),
@@ -52,8 +59,12 @@ func New(mgr manager.Manager, namespace string) (controller.Controller, error) {
 	operatorCache := mgr.GetCache()
 	reconciler := &reconciler{
 		cache:            newCache,
+		client:           mgr.GetClient(),
 		namespace:        namespace,
 		routeToIngresses: make(map[types.NamespacedName]sets.String),
+		recorder:         mgr.GetEventRecorderFor(controllerName),
+		rejections:       make(map[string]*shardRejections),
+		routeScaleLimits: routeScaleLimitsForVersion(operatorReleaseVersion),
 	}
 	c, err := controller.New(controllerName, mgr, controller.Options{
 		Reconciler: reconciler,
@@ -97,9 +108,10 @@ func (r *reconciler) routeToIngressController(context context.Context, obj clien
 
 	// Iterate through the related Route's Ingresses.
 	for _, ri := range route.Status.Ingress {
-		// Check if the Route was admitted by the RouteIngress.
+		// Check if the RouteIngress admitted or rejected the Route.
+		// Rejections are tracked as well as admissions.
 		for _, cond := range ri.Conditions {
-			if cond.Type == routev1.RouteAdmitted && cond.Status == corev1.ConditionTrue {
+			if cond.Type == routev1.RouteAdmitted && (cond.Status == corev1.ConditionTrue || cond.Status == corev1.ConditionFalse) {
 				log.Info("queueing ingresscontroller", "name", ri.RouterName)
 				// Create a reconcile.Request for the router named in the RouteIngress.
 				request := reconcile.Request{
@@ -146,9 +158,16 @@ func (r *reconciler) routeToIngressController(context context.Context, obj clien
 // reconciler handles the actual ingresscontroller reconciliation logic in response to events.
 type reconciler struct {
 	cache     cache.Cache
+	client    client.Client
 	namespace string
-	// routeToIngresses stores the Ingress Controllers that have admitted a given route.
+	// routeToIngresses stores the Ingress Controllers that have admitted or rejected a given route.
 	routeToIngresses map[types.NamespacedName]sets.String
+	recorder         record.EventRecorder
+	// rejections stores the rejection state of each Ingress Controller, by name.
+	rejections map[string]*shardRejections
+	// routeScaleLimits are the default soft limits on the number of routes
+	// and certificates for the router version.
+	routeScaleLimits routeScaleLimits
 }
 
 // Reconcile expects request to refer to an Ingress Controller resource, and will do all the work to gather metrics related to
@@ -162,6 +181,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		if kerrors.IsNotFound(err) {
 			// This means the Ingress Controller object was already deleted/finalized.
 			log.Info("Ingress Controller not found; reconciliation will be skipped", "request", request)
+			delete(r.rejections, request.Name)
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, fmt.Errorf("failed to get Ingress Controller %q: %w", request, err)
@@ -170,6 +190,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	// If the Ingress Controller is marked to be deleted, then return early. The corresponding RouteMetricsControllerRoutesPerShard metric label
 	// will be deleted in "ensureIngressDeleted" function of ingresscontroller.
 	if ingressController.DeletionTimestamp != nil {
+		delete(r.rejections, request.Name)
 		return reconcile.Result{}, nil
 	}
 
@@ -178,6 +199,10 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return reconcile.Result{}, nil
 	}
 
+	if err := r.syncShardChangeImpact(ctx, ingressController); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// NOTE: Even though the route admitted status should reflect validity of the namespace and route labelselectors, we still will validate
 	// the namespace and route labels as there are still edge scenarios where the route status may be inaccurate.
 
@@ -221,20 +246,39 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	// Variable to store the number of routes admitted by the Shard (Ingress Controller).
 	routesAdmitted := 0
+	// Variable to store the number of admitted routes with their own certificates.
+	certificatesAdmitted := 0
+	// Variable to store the routes that the Shard selects.
+	var selectedRoutes []*routev1.Route
 
 	// Iterate through the list Routes.
-	for _, route := range routeList.Items {
-		// Check if the Route's Namespace matches one of the Namespaces in the set namespacesSet and
-		// the Route is admitted by the Ingress Controller.
-		if namespacesSet.Has(route.Namespace) && routeStatusAdmitted(route, ingressController.Name) {
+	for i := range routeList.Items {
+		route := &routeList.Items[i]
+		if !namespacesSet.Has(route.Namespace) {
+			continue
+		}
+		selectedRoutes = append(selectedRoutes, route)
+		// Check if the Route is admitted by the Ingress Controller.
+		if routeStatusAdmitted(*route, ingressController.Name) {
 			// If the Route is admitted then, the routesAdmitted should be incremented by 1 for the Shard.
 			routesAdmitted++
+			if routeHasCertificate(route) {
+				certificatesAdmitted++
+			}
 		}
 	}
 
 	// Set the value of the metric to the number of routesAdmitted for the corresponding Shard (Ingress Controller).
 	SetRouteMetricsControllerRoutesPerShardMetric(request.Name, float64(routesAdmitted))
 
+	// Update the rejection metrics for the Shard.
+	r.syncRejections(ingressController, selectedRoutes)
+
+	// Update the route scale metrics and condition for the Shard.
+	if err := r.syncRouteScale(ctx, ingressController, routesAdmitted, certificatesAdmitted); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -298,12 +298,13 @@ func Test_Reconcile(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err, _, cache := newFakeClient(tc.initObjs...)
+			err, cl, cache := newFakeClient(tc.initObjs...)
 			if err != nil {
 				t.Fatalf("error creating fake client: %v", err)
 			}
 			r := reconciler{
 				cache:            cache,
+				client:           cl,
 				routeToIngresses: make(map[types.NamespacedName]sets.String),
 				namespace:        operatorcontroller.DefaultOperatorNamespace,
 			}
@@ -442,7 +443,7 @@ func newFakeClient(initObjs ...client.Object) (error, client.Client, cache.Cache
 	if err := operator.Install(s); err != nil {
 		return err, nil, nil
 	}
-	client := clientBuilder.WithScheme(s).WithObjects(initObjs...).Build()
+	client := clientBuilder.WithScheme(s).WithObjects(initObjs...).WithStatusSubresource(&v1.IngressController{}).Build()
 	informer := informertest.FakeInformers{
 		Scheme: client.Scheme(),
 	}
@@ -0,0 +1,322 @@
+package routemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// shardChangeImpactMaxNamespaces is the maximum number of namespaces
+	// that a shard change impact report lists, which bounds the size of
+	// the report's configmap.  The report's totals include all namespaces.
+	shardChangeImpactMaxNamespaces = 200
+
+	// shardChangeImpactPreviewKey is the key in the report's configmap
+	// that records the preview for which the report was computed.
+	shardChangeImpactPreviewKey = "preview"
+	// shardChangeImpactGenerationKey is the key in the report's configmap
+	// that records the generation of the ingresscontroller for which the
+	// report was computed.
+	shardChangeImpactGenerationKey = "observedGeneration"
+	// shardChangeImpactReportKey is the key in the report's configmap that
+	// holds the JSON-encoded report.
+	shardChangeImpactReportKey = "report"
+	// shardChangeImpactErrorKey is the key in the report's configmap that
+	// explains why the report could not be computed.
+	shardChangeImpactErrorKey = "error"
+)
+
+// ShardChangePreview is a proposed change to an ingresscontroller's shard, as
+// specified by the ingress.operator.openshift.io/shard-change-preview
+// annotation.  A field that is omitted keeps its current value.  An empty
+// selector selects everything.
+type ShardChangePreview struct {
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	RouteSelector     *metav1.LabelSelector `json:"routeSelector,omitempty"`
+	Domain            string                `json:"domain,omitempty"`
+}
+
+// shardChangeImpact counts the routes that a proposed shard change affects.
+// Newly admitted routes are selected by the proposed shard but not by the
+// current one, newly unadmitted routes are selected by the current shard but
+// not by the proposed one, and unchanged routes are selected by both.  Routes
+// with a changed host are routes that both shards select and that specify
+// spec.subdomain, whose host the router derives from the domain.
+type shardChangeImpact struct {
+	NewlyAdmitted   int `json:"newlyAdmitted"`
+	NewlyUnadmitted int `json:"newlyUnadmitted"`
+	Unchanged       int `json:"unchanged"`
+	HostChanged     int `json:"hostChanged"`
+}
+
+// add adds the counts in the given impact to this impact.
+func (i *shardChangeImpact) add(o shardChangeImpact) {
+	i.NewlyAdmitted += o.NewlyAdmitted
+	i.NewlyUnadmitted += o.NewlyUnadmitted
+	i.Unchanged += o.Unchanged
+	i.HostChanged += o.HostChanged
+}
+
+// changed returns the number of routes whose admission or host changes.
+func (i *shardChangeImpact) changed() int {
+	return i.NewlyAdmitted + i.NewlyUnadmitted + i.HostChanged
+}
+
+// namespaceShardChangeImpact is the impact of a proposed shard change on the
+// routes in one namespace.
+type namespaceShardChangeImpact struct {
+	Namespace         string `json:"namespace"`
+	shardChangeImpact `json:",inline"`
+}
+
+// shardChangeImpactReport is the report that the operator stores in the
+// configmap for a shard change preview.  Namespaces are ordered by the number
+// of routes whose admission or host changes, and namespaces beyond the limit
+// are only included in the totals.
+type shardChangeImpactReport struct {
+	Total             shardChangeImpact            `json:"total"`
+	Namespaces        []namespaceShardChangeImpact `json:"namespaces"`
+	OmittedNamespaces int                          `json:"omittedNamespaces,omitempty"`
+}
+
+// parseShardChangePreview parses and validates the given value of the shard
+// change preview annotation.
+func parseShardChangePreview(value string) (*ShardChangePreview, error) {
+	preview := &ShardChangePreview{}
+	if err := json.Unmarshal([]byte(value), preview); err != nil {
+		return nil, fmt.Errorf("annotation %s is invalid: %w", ingresscontroller.ShardChangePreviewAnnotation, err)
+	}
+	for name, selector := range map[string]*metav1.LabelSelector{"namespaceSelector": preview.NamespaceSelector, "routeSelector": preview.RouteSelector} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, fmt.Errorf("annotation %s has an invalid %s: %w", ingresscontroller.ShardChangePreviewAnnotation, name, err)
+		}
+	}
+	preview.Domain = strings.TrimSuffix(preview.Domain, ".")
+	if len(preview.Domain) != 0 {
+		if msgs := validation.IsDNS1123Subdomain(preview.Domain); len(msgs) != 0 {
+			return nil, fmt.Errorf("annotation %s has an invalid domain %q: %s", ingresscontroller.ShardChangePreviewAnnotation, preview.Domain, strings.Join(msgs, ", "))
+		}
+	}
+	return preview, nil
+}
+
+// selectorOrEverything converts the given label selector into a selector.  A
+// nil label selector selects everything, as it does in an ingresscontroller's
+// spec.
+func selectorOrEverything(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
+// shardSelection holds the selectors and domain of a shard.
+type shardSelection struct {
+	namespaces sets.String
+	routes     labels.Selector
+	domain     string
+}
+
+// selects returns a Boolean value indicating whether the shard selects the
+// given route.
+func (s *shardSelection) selects(route *routev1.Route) bool {
+	return s.namespaces.Has(route.Namespace) && s.routes.Matches(labels.Set(route.Labels))
+}
+
+// newShardSelection returns the selection of a shard with the given selectors
+// and domain from the given namespaces.
+func newShardSelection(namespaceSelector, routeSelector *metav1.LabelSelector, domain string, namespaces []corev1.Namespace) (*shardSelection, error) {
+	nsSelector, err := selectorOrEverything(namespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	rSelector, err := selectorOrEverything(routeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid route selector: %w", err)
+	}
+	selected := sets.NewString()
+	for i := range namespaces {
+		if nsSelector.Matches(labels.Set(namespaces[i].Labels)) {
+			selected.Insert(namespaces[i].Name)
+		}
+	}
+	return &shardSelection{namespaces: selected, routes: rSelector, domain: domain}, nil
+}
+
+// computeShardChangeImpact computes the impact of the given preview on the
+// given ingresscontroller's shard, using the given namespaces and routes.
+func computeShardChangeImpact(ic *operatorv1.IngressController, preview *ShardChangePreview, namespaces []corev1.Namespace, routes []routev1.Route) (*shardChangeImpactReport, error) {
+	current, err := newShardSelection(ic.Spec.NamespaceSelector, ic.Spec.RouteSelector, ic.Status.Domain, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	namespaceSelector, routeSelector, domain := ic.Spec.NamespaceSelector, ic.Spec.RouteSelector, ic.Status.Domain
+	if preview.NamespaceSelector != nil {
+		namespaceSelector = preview.NamespaceSelector
+	}
+	if preview.RouteSelector != nil {
+		routeSelector = preview.RouteSelector
+	}
+	if len(preview.Domain) != 0 {
+		domain = preview.Domain
+	}
+	proposed, err := newShardSelection(namespaceSelector, routeSelector, domain, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	byNamespace := map[string]*shardChangeImpact{}
+	for i := range routes {
+		route := &routes[i]
+		inCurrent, inProposed := current.selects(route), proposed.selects(route)
+		if !inCurrent && !inProposed {
+			continue
+		}
+		impact, ok := byNamespace[route.Namespace]
+		if !ok {
+			impact = &shardChangeImpact{}
+			byNamespace[route.Namespace] = impact
+		}
+		switch {
+		case inCurrent && inProposed:
+			impact.Unchanged++
+			if len(route.Spec.Subdomain) != 0 && current.domain != proposed.domain {
+				impact.HostChanged++
+			}
+		case inProposed:
+			impact.NewlyAdmitted++
+		default:
+			impact.NewlyUnadmitted++
+		}
+	}
+
+	report := &shardChangeImpactReport{Namespaces: []namespaceShardChangeImpact{}}
+	for namespace, impact := range byNamespace {
+		report.Total.add(*impact)
+		report.Namespaces = append(report.Namespaces, namespaceShardChangeImpact{Namespace: namespace, shardChangeImpact: *impact})
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		a, b := report.Namespaces[i], report.Namespaces[j]
+		if a.changed() != b.changed() {
+			return a.changed() > b.changed()
+		}
+		return a.Namespace < b.Namespace
+	})
+	if len(report.Namespaces) > shardChangeImpactMaxNamespaces {
+		report.OmittedNamespaces = len(report.Namespaces) - shardChangeImpactMaxNamespaces
+		report.Namespaces = report.Namespaces[:shardChangeImpactMaxNamespaces]
+	}
+	return report, nil
+}
+
+// syncShardChangeImpact reports the impact of the shard change that the given
+// ingresscontroller's shard change preview annotation proposes in a configmap
+// in the operator's namespace, and deletes the configmap if the
+// ingresscontroller has no such annotation.  The report is computed only when
+// the annotation or the ingresscontroller's generation changes, not whenever
+// routes change.  To refresh the report, change the annotation, or remove and
+// add it again.
+func (r *reconciler) syncShardChangeImpact(ctx context.Context, ic *operatorv1.IngressController) error {
+	name := operatorcontroller.ShardChangeImpactConfigMapName(ic)
+	current := &corev1.ConfigMap{}
+	haveConfigMap := true
+	if err := r.client.Get(ctx, name, current); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		haveConfigMap = false
+	}
+
+	value, wantReport := ic.Annotations[ingresscontroller.ShardChangePreviewAnnotation]
+	if !wantReport {
+		if !haveConfigMap {
+			return nil
+		}
+		if err := r.client.Delete(ctx, current); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete configmap %s: %w", name, err)
+		}
+		log.Info("deleted shard change impact report", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	generation := strconv.FormatInt(ic.Generation, 10)
+	if haveConfigMap && current.Data[shardChangeImpactPreviewKey] == value && current.Data[shardChangeImpactGenerationKey] == generation {
+		return nil
+	}
+
+	trueVar := true
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: operatorv1.GroupVersion.String(),
+				Kind:       "IngressController",
+				Name:       ic.Name,
+				UID:        ic.UID,
+				Controller: &trueVar,
+			}},
+		},
+		Data: map[string]string{
+			shardChangeImpactPreviewKey:    value,
+			shardChangeImpactGenerationKey: generation,
+		},
+	}
+	// An invalid preview or selector is reported in the configmap, but a
+	// failure to list namespaces or routes is retried.
+	var report *shardChangeImpactReport
+	preview, err := parseShardChangePreview(value)
+	if err == nil {
+		namespaces := corev1.NamespaceList{}
+		if err := r.cache.List(ctx, &namespaces); err != nil {
+			return fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		routes := routev1.RouteList{}
+		if err := r.cache.List(ctx, &routes); err != nil {
+			return fmt.Errorf("failed to list routes: %w", err)
+		}
+		report, err = computeShardChangeImpact(ic, preview, namespaces.Items, routes.Items)
+	}
+	if err != nil {
+		desired.Data[shardChangeImpactErrorKey] = err.Error()
+		r.recorder.Eventf(ic, corev1.EventTypeWarning, "ShardChangeImpactFailed", "Failed to compute the impact of the shard change preview: %v", err)
+	} else {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode shard change impact report: %w", err)
+		}
+		desired.Data[shardChangeImpactReportKey] = string(data)
+		r.recorder.Eventf(ic, corev1.EventTypeNormal, "ShardChangeImpactComputed", "The shard change preview would newly admit %d routes, stop admitting %d routes, and change the host of %d routes; see configmap %s", report.Total.NewlyAdmitted, report.Total.NewlyUnadmitted, report.Total.HostChanged, name)
+	}
+
+	if !haveConfigMap {
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+		log.Info("created shard change impact report", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.OwnerReferences = desired.OwnerReferences
+	updated.Data = desired.Data
+	if err := r.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update configmap %s: %w", name, err)
+	}
+	log.Info("updated shard change impact report", "namespace", name.Namespace, "name", name.Name)
+	return nil
+}
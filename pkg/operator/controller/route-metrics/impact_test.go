@@ -0,0 +1,238 @@
+package routemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+	"github.com/openshift/cluster-ingress-operator/test/unit"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+)
+
+// Test_computeShardChangeImpact verifies that computeShardChangeImpact counts
+// the routes that a proposed namespace selector, route selector, or domain
+// would newly admit, stop admitting, leave unchanged, or give a new host, per
+// namespace.
+func Test_computeShardChangeImpact(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		*unit.NewNamespaceBuilder().WithName("team-a").WithLabel("shard", "x").Build(),
+		*unit.NewNamespaceBuilder().WithName("team-b").WithLabel("shard", "x").Build(),
+		*unit.NewNamespaceBuilder().WithName("team-c").WithLabel("shard", "y").Build(),
+	}
+	subdomainRoute := unit.NewRouteBuilder().WithName("subdomain").WithNamespace("team-a").WithLabel("tier", "public").Build()
+	subdomainRoute.Spec.Subdomain = "app"
+	routes := []routev1.Route{
+		*subdomainRoute,
+		*unit.NewRouteBuilder().WithName("public").WithNamespace("team-a").WithLabel("tier", "public").Build(),
+		*unit.NewRouteBuilder().WithName("internal").WithNamespace("team-a").WithLabel("tier", "internal").Build(),
+		*unit.NewRouteBuilder().WithName("public").WithNamespace("team-b").WithLabel("tier", "public").Build(),
+		*unit.NewRouteBuilder().WithName("public").WithNamespace("team-c").WithLabel("tier", "public").Build(),
+		*unit.NewRouteBuilder().WithName("internal").WithNamespace("team-c").WithLabel("tier", "internal").Build(),
+	}
+	impact := func(namespace string, newlyAdmitted, newlyUnadmitted, unchanged, hostChanged int) namespaceShardChangeImpact {
+		return namespaceShardChangeImpact{Namespace: namespace, shardChangeImpact: shardChangeImpact{newlyAdmitted, newlyUnadmitted, unchanged, hostChanged}}
+	}
+	selector := func(key, value string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{key: value}}
+	}
+
+	testCases := []struct {
+		name              string
+		namespaceSelector *metav1.LabelSelector
+		routeSelector     *metav1.LabelSelector
+		preview           ShardChangePreview
+		expectTotal       shardChangeImpact
+		expectNamespaces  []namespaceShardChangeImpact
+	}{
+		{
+			name:              "no change",
+			namespaceSelector: selector("shard", "x"),
+			expectTotal:       shardChangeImpact{Unchanged: 4},
+			expectNamespaces:  []namespaceShardChangeImpact{impact("team-a", 0, 0, 3, 0), impact("team-b", 0, 0, 1, 0)},
+		},
+		{
+			name:              "namespace selector moves to another shard",
+			namespaceSelector: selector("shard", "x"),
+			preview:           ShardChangePreview{NamespaceSelector: selector("shard", "y")},
+			expectTotal:       shardChangeImpact{NewlyAdmitted: 2, NewlyUnadmitted: 4},
+			expectNamespaces:  []namespaceShardChangeImpact{impact("team-a", 0, 3, 0, 0), impact("team-c", 2, 0, 0, 0), impact("team-b", 0, 1, 0, 0)},
+		},
+		{
+			name:             "route selector narrows an unsharded ingresscontroller",
+			preview:          ShardChangePreview{RouteSelector: selector("tier", "public")},
+			expectTotal:      shardChangeImpact{NewlyUnadmitted: 2, Unchanged: 4},
+			expectNamespaces: []namespaceShardChangeImpact{impact("team-a", 0, 1, 2, 0), impact("team-c", 0, 1, 1, 0), impact("team-b", 0, 0, 1, 0)},
+		},
+		{
+			name:              "empty namespace selector selects everything",
+			namespaceSelector: selector("shard", "y"),
+			routeSelector:     selector("tier", "internal"),
+			preview:           ShardChangePreview{NamespaceSelector: &metav1.LabelSelector{}},
+			expectTotal:       shardChangeImpact{NewlyAdmitted: 1, Unchanged: 1},
+			expectNamespaces:  []namespaceShardChangeImpact{impact("team-a", 1, 0, 0, 0), impact("team-c", 0, 0, 1, 0)},
+		},
+		{
+			name:              "domain change",
+			namespaceSelector: selector("shard", "x"),
+			preview:           ShardChangePreview{Domain: "apps.new.example.com"},
+			expectTotal:       shardChangeImpact{Unchanged: 4, HostChanged: 1},
+			expectNamespaces:  []namespaceShardChangeImpact{impact("team-a", 0, 0, 3, 1), impact("team-b", 0, 0, 1, 0)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ic := unit.NewIngressControllerBuilder().WithName("shard").Build()
+			ic.Spec.NamespaceSelector = tc.namespaceSelector
+			ic.Spec.RouteSelector = tc.routeSelector
+			ic.Status.Domain = "apps.example.com"
+			report, err := computeShardChangeImpact(ic, &tc.preview, namespaces, routes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report.Total != tc.expectTotal {
+				t.Errorf("expected total %+v, got %+v", tc.expectTotal, report.Total)
+			}
+			if !reflect.DeepEqual(report.Namespaces, tc.expectNamespaces) {
+				t.Errorf("expected namespaces %+v, got %+v", tc.expectNamespaces, report.Namespaces)
+			}
+		})
+	}
+}
+
+// Test_computeShardChangeImpact_bounded verifies that the report lists at most
+// shardChangeImpactMaxNamespaces namespaces, preferring the most affected
+// ones, and that the totals include the omitted namespaces.
+func Test_computeShardChangeImpact_bounded(t *testing.T) {
+	var namespaces []corev1.Namespace
+	var routes []routev1.Route
+	for i := 0; i < shardChangeImpactMaxNamespaces+10; i++ {
+		name := "ns-" + strings.Repeat("x", i%3) + string(rune('a'+i%26)) + "-" + string(rune('a'+i/26))
+		namespaces = append(namespaces, *unit.NewNamespaceBuilder().WithName(name).Build())
+		routes = append(routes, *unit.NewRouteBuilder().WithName("route").WithNamespace(name).Build())
+	}
+	// One namespace loses its route, which must keep it in the report.
+	last := namespaces[len(namespaces)-1].Name
+	routes[len(routes)-1].Labels = map[string]string{"excluded": "true"}
+	ic := unit.NewIngressControllerBuilder().WithName("shard").Build()
+	ic.Spec.RouteSelector = nil
+	ic.Spec.NamespaceSelector = nil
+	preview := &ShardChangePreview{RouteSelector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "excluded", Operator: metav1.LabelSelectorOpDoesNotExist}},
+	}}
+	report, err := computeShardChangeImpact(ic, preview, namespaces, routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Namespaces) != shardChangeImpactMaxNamespaces || report.OmittedNamespaces != 10 {
+		t.Errorf("expected %d namespaces and 10 omitted, got %d and %d", shardChangeImpactMaxNamespaces, len(report.Namespaces), report.OmittedNamespaces)
+	}
+	if report.Namespaces[0].Namespace != last {
+		t.Errorf("expected the affected namespace %s to be listed first, got %s", last, report.Namespaces[0].Namespace)
+	}
+	expected := shardChangeImpact{NewlyUnadmitted: 1, Unchanged: shardChangeImpactMaxNamespaces + 9}
+	if report.Total != expected {
+		t.Errorf("expected total %+v, got %+v", expected, report.Total)
+	}
+}
+
+// Test_syncShardChangeImpact verifies that syncShardChangeImpact writes a
+// report when the preview annotation is added or changed, does not recompute
+// the report when only routes change, reports an invalid preview, and deletes
+// the report when the annotation is removed.
+func Test_syncShardChangeImpact(t *testing.T) {
+	ic := unit.NewIngressControllerBuilder().WithName("shard").WithNamespaceSelector("shard", "x").WithAdmitted(true).Build()
+	err, cl, cache := newFakeClient(
+		ic,
+		unit.NewNamespaceBuilder().WithName("team-a").WithLabel("shard", "x").Build(),
+		unit.NewNamespaceBuilder().WithName("team-b").WithLabel("shard", "y").Build(),
+		unit.NewRouteBuilder().WithName("a").WithNamespace("team-a").Build(),
+		unit.NewRouteBuilder().WithName("b").WithNamespace("team-b").Build(),
+	)
+	if err != nil {
+		t.Fatalf("error creating fake client: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		cache:            cache,
+		client:           cl,
+		namespace:        operatorcontroller.DefaultOperatorNamespace,
+		routeToIngresses: make(map[types.NamespacedName]sets.String),
+		recorder:         recorder,
+	}
+	name := operatorcontroller.ShardChangeImpactConfigMapName(ic)
+
+	sync := func(description, preview string) *corev1.ConfigMap {
+		t.Helper()
+		current := ic.DeepCopy()
+		if len(preview) == 0 {
+			delete(current.Annotations, ingresscontroller.ShardChangePreviewAnnotation)
+		} else {
+			current.Annotations = map[string]string{ingresscontroller.ShardChangePreviewAnnotation: preview}
+		}
+		if err := r.syncShardChangeImpact(context.Background(), current); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		cm := &corev1.ConfigMap{}
+		if err := cl.Get(context.Background(), name, cm); err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+			t.Fatalf("%s: failed to get configmap: %v", description, err)
+		}
+		return cm
+	}
+	expectReport := func(description string, cm *corev1.ConfigMap, expected shardChangeImpact) {
+		t.Helper()
+		if cm == nil {
+			t.Fatalf("%s: expected configmap %s", description, name)
+		}
+		var report shardChangeImpactReport
+		if err := json.Unmarshal([]byte(cm.Data[shardChangeImpactReportKey]), &report); err != nil {
+			t.Fatalf("%s: failed to decode report %q: %v", description, cm.Data[shardChangeImpactReportKey], err)
+		}
+		if report.Total != expected {
+			t.Errorf("%s: expected total %+v, got %+v", description, expected, report.Total)
+		}
+	}
+
+	if cm := sync("no preview", ""); cm != nil {
+		t.Fatalf("expected no configmap, got %+v", cm)
+	}
+
+	cm := sync("preview", `{"namespaceSelector":{"matchLabels":{"shard":"y"}}}`)
+	expectReport("preview", cm, shardChangeImpact{NewlyAdmitted: 1, NewlyUnadmitted: 1})
+	if event := <-recorder.Events; !strings.Contains(event, "ShardChangeImpactComputed") {
+		t.Errorf("expected a ShardChangeImpactComputed event, got %s", event)
+	}
+
+	// Route changes alone must not cause the report to be recomputed.
+	if err := cl.Create(context.Background(), unit.NewRouteBuilder().WithName("c").WithNamespace("team-b").Build()); err != nil {
+		t.Fatalf("failed to create route: %v", err)
+	}
+	cm = sync("unchanged preview", `{"namespaceSelector":{"matchLabels":{"shard":"y"}}}`)
+	expectReport("unchanged preview", cm, shardChangeImpact{NewlyAdmitted: 1, NewlyUnadmitted: 1})
+
+	cm = sync("changed preview", `{"namespaceSelector":{}}`)
+	expectReport("changed preview", cm, shardChangeImpact{NewlyAdmitted: 2, Unchanged: 1})
+
+	cm = sync("invalid preview", `{"routeSelector":{"matchExpressions":[{"key":"tier","operator":"Bogus"}]}}`)
+	if cm == nil || len(cm.Data[shardChangeImpactErrorKey]) == 0 || len(cm.Data[shardChangeImpactReportKey]) != 0 {
+		t.Errorf("expected the configmap to report an error, got %+v", cm)
+	}
+
+	if cm := sync("preview removed", ""); cm != nil {
+		t.Errorf("expected the configmap to be deleted, got %+v", cm)
+	}
+}
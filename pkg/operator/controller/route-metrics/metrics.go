@@ -12,9 +12,42 @@ var (
 		Help: "Report the number of routes for shards (ingress controllers).",
 	}, []string{"shard_name"})
 
+	// routeMetricsControllerRejectedRoutes reports the number of routes
+	// that each shard currently rejects, by rejection reason.
+	routeMetricsControllerRejectedRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_rejected_routes",
+		Help: "Report the number of routes that shards (ingress controllers) currently reject, by rejection reason.",
+	}, []string{"shard_name", "reason"})
+
+	// routeMetricsControllerRouteRejectionsTotal counts the route
+	// rejections that the operator has observed for each shard, by
+	// rejection reason.
+	routeMetricsControllerRouteRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "route_metrics_controller_route_rejections_total",
+		Help: "Counts the route rejections by shards (ingress controllers), by rejection reason.",
+	}, []string{"shard_name", "reason"})
+
+	// routeMetricsControllerCertificatesPerShard reports the number of
+	// admitted routes with their own certificates for each shard.
+	routeMetricsControllerCertificatesPerShard = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_certificates_per_shard",
+		Help: "Report the number of admitted routes with their own certificates for shards (ingress controllers).",
+	}, []string{"shard_name"})
+
+	// routeMetricsControllerRouteScaleSoftLimit reports the soft limits on
+	// the number of routes and certificates for each shard.
+	routeMetricsControllerRouteScaleSoftLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "route_metrics_controller_route_scale_soft_limit",
+		Help: "Report the soft limits on the number of admitted routes and route certificates for shards (ingress controllers), by resource.",
+	}, []string{"shard_name", "resource"})
+
 	// metricsList is a list of metrics for this package.
 	metricsList = []prometheus.Collector{
 		routeMetricsControllerRoutesPerShard,
+		routeMetricsControllerRejectedRoutes,
+		routeMetricsControllerRouteRejectionsTotal,
+		routeMetricsControllerCertificatesPerShard,
+		routeMetricsControllerRouteScaleSoftLimit,
 	}
 )
 
@@ -26,6 +59,26 @@ func DeleteRouteMetricsControllerRoutesPerShardMetric(shardName string) {
 	routeMetricsControllerRoutesPerShard.DeleteLabelValues(shardName)
 }
 
+// DeleteRouteMetricsControllerRejectionMetrics deletes the route rejection
+// metrics for the given shard.
+func DeleteRouteMetricsControllerRejectionMetrics(shardName string) {
+	routeMetricsControllerRejectedRoutes.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+	routeMetricsControllerRouteRejectionsTotal.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+}
+
+// SetRouteMetricsControllerCertificatesPerShardMetric sets the number of
+// admitted routes with their own certificates for the given shard.
+func SetRouteMetricsControllerCertificatesPerShardMetric(shardName string, value float64) {
+	routeMetricsControllerCertificatesPerShard.WithLabelValues(shardName).Set(value)
+}
+
+// DeleteRouteMetricsControllerRouteScaleMetrics deletes the certificate count
+// and soft limit metrics for the given shard.
+func DeleteRouteMetricsControllerRouteScaleMetrics(shardName string) {
+	routeMetricsControllerCertificatesPerShard.DeleteLabelValues(shardName)
+	routeMetricsControllerRouteScaleSoftLimit.DeletePartialMatch(prometheus.Labels{"shard_name": shardName})
+}
+
 // RegisterMetrics calls prometheus.Register on each metric in metricsList, and
 // returns on errors.
 func RegisterMetrics() error {
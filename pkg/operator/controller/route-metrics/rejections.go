@@ -0,0 +1,187 @@
+package routemetrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// otherRejectionReason is the reason label for rejections with a
+	// reason that is not in knownRejectionReasons.
+	otherRejectionReason = "Other"
+
+	// rejectionSpikeWindow is the window over which the controller
+	// counts new rejections for each shard to detect a spike.
+	rejectionSpikeWindow = 5 * time.Minute
+	// rejectionSpikeThreshold is the number of new rejections within
+	// rejectionSpikeWindow for which the controller emits an event on the
+	// ingresscontroller.
+	rejectionSpikeThreshold = 10
+)
+
+// knownRejectionReasons are the reasons with which the router rejects routes.
+// Rejections with any other reason are reported with the reason "Other" so
+// that the cardinality of the rejection metrics is bounded by the number of
+// shards.
+var knownRejectionReasons = []string{
+	"ExtendedValidationFailed",
+	"HostAlreadyClaimed",
+	"InvalidHost",
+	"NamespaceOwnershipCheckFailed",
+	"RouteNotAdmitted",
+	otherRejectionReason,
+}
+
+// rejectionReason returns the reason label for the given rejection reason.
+func rejectionReason(reason string) string {
+	for _, known := range knownRejectionReasons {
+		if reason == known {
+			return reason
+		}
+	}
+	return otherRejectionReason
+}
+
+// rejection identifies a shard's rejection of a route.  A route whose rejection
+// has the same reason and transition time as on the previous sync is the same
+// rejection and is not counted again.
+type rejection struct {
+	reason             string
+	lastTransitionTime time.Time
+}
+
+// recentRejection is a rejection that the controller observed at a given time.
+type recentRejection struct {
+	observed time.Time
+	reason   string
+}
+
+// shardRejections is the rejection state of a shard.
+type shardRejections struct {
+	// routes has the current rejections of the shard's routes.
+	routes map[types.NamespacedName]rejection
+	// recent has the new rejections that the controller observed within
+	// rejectionSpikeWindow.
+	recent []recentRejection
+	// spikeReported indicates whether the current spike of rejections has
+	// been reported in an event.
+	spikeReported bool
+}
+
+// routeRejection returns the rejection of the given route by the given
+// ingresscontroller, and a Boolean value indicating whether the
+// ingresscontroller rejects the route.
+func routeRejection(route *routev1.Route, ingressControllerName string) (rejection, bool) {
+	for _, ingress := range route.Status.Ingress {
+		if ingress.RouterName != ingressControllerName {
+			continue
+		}
+		for _, cond := range ingress.Conditions {
+			if cond.Type == routev1.RouteAdmitted && cond.Status == corev1.ConditionFalse {
+				r := rejection{reason: rejectionReason(cond.Reason)}
+				if cond.LastTransitionTime != nil {
+					r.lastTransitionTime = cond.LastTransitionTime.Time
+				}
+				return r, true
+			}
+		}
+		return rejection{}, false
+	}
+	return rejection{}, false
+}
+
+// syncRejections updates the rejection metrics for the given ingresscontroller
+// from the given routes, which are the routes that the ingresscontroller
+// selects, and emits an event on the ingresscontroller if the number of new
+// rejections within rejectionSpikeWindow reaches rejectionSpikeThreshold.  The
+// first sync of a shard records the shard's current rejections without counting
+// them, so that restarting the operator does not register a spike.
+func (r *reconciler) syncRejections(ic *operatorv1.IngressController, routes []*routev1.Route) {
+	now := clock.Now()
+	if r.rejections == nil {
+		r.rejections = make(map[string]*shardRejections)
+	}
+	state, ok := r.rejections[ic.Name]
+	if !ok {
+		state = &shardRejections{}
+		r.rejections[ic.Name] = state
+		// Initialize the counters so that rate queries see the
+		// first rejection of each reason.
+		for _, reason := range knownRejectionReasons {
+			routeMetricsControllerRouteRejectionsTotal.WithLabelValues(ic.Name, reason).Add(0)
+		}
+	}
+
+	current := map[types.NamespacedName]rejection{}
+	rejected := map[string]int{}
+	for _, route := range routes {
+		rej, ok := routeRejection(route, ic.Name)
+		if !ok {
+			continue
+		}
+		name := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		current[name] = rej
+		rejected[rej.reason]++
+		if state.routes == nil {
+			continue
+		}
+		if previous, ok := state.routes[name]; ok && previous == rej {
+			continue
+		}
+		routeMetricsControllerRouteRejectionsTotal.WithLabelValues(ic.Name, rej.reason).Inc()
+		state.recent = append(state.recent, recentRejection{observed: now, reason: rej.reason})
+	}
+	state.routes = current
+
+	for _, reason := range knownRejectionReasons {
+		routeMetricsControllerRejectedRoutes.WithLabelValues(ic.Name, reason).Set(float64(rejected[reason]))
+	}
+
+	// Prune rejections that are outside the window, and report a spike
+	// once until the number of recent rejections falls below the
+	// threshold again.
+	i := 0
+	for i < len(state.recent) && now.Sub(state.recent[i].observed) > rejectionSpikeWindow {
+		i++
+	}
+	state.recent = state.recent[i:]
+	switch {
+	case len(state.recent) < rejectionSpikeThreshold:
+		state.spikeReported = false
+	case !state.spikeReported:
+		state.spikeReported = true
+		r.recorder.Eventf(ic, corev1.EventTypeWarning, "RouteRejectionSpike", "%d routes were rejected in the last %s: %s", len(state.recent), rejectionSpikeWindow, summarizeRejections(state.recent))
+	}
+}
+
+// summarizeRejections returns a description of the number of the given
+// rejections by reason, in descending order of number.
+func summarizeRejections(recent []recentRejection) string {
+	counts := map[string]int{}
+	for _, rej := range recent {
+		counts[rej.reason]++
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	summary := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		summary = append(summary, fmt.Sprintf("%s (%d)", reason, counts[reason]))
+	}
+	return strings.Join(summary, ", ")
+}
@@ -0,0 +1,160 @@
+package routemetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/unit"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	utilclock "k8s.io/utils/clock"
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Test_rejectionReason verifies that rejectionReason bounds the reason label to
+// the known rejection reasons.
+func Test_rejectionReason(t *testing.T) {
+	testCases := map[string]string{
+		"HostAlreadyClaimed":       "HostAlreadyClaimed",
+		"ExtendedValidationFailed": "ExtendedValidationFailed",
+		"":                         "Other",
+		"SomethingUnexpected":      "Other",
+	}
+	for reason, expected := range testCases {
+		if actual := rejectionReason(reason); actual != expected {
+			t.Errorf("expected reason %q to be reported as %q, got %q", reason, expected, actual)
+		}
+	}
+}
+
+// Test_syncRejections drives a shard through a timeline of route status
+// transitions and verifies that the rejection counters count each rejection
+// once, regardless of how often the shard is synced, that the gauge reports the
+// currently rejected routes, and that a spike of rejections is reported in a
+// single event.
+func Test_syncRejections(t *testing.T) {
+	fakeClock := utilclocktesting.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	clock = fakeClock
+	defer func() {
+		clock = utilclock.RealClock{}
+	}()
+	routeMetricsControllerRejectedRoutes.Reset()
+	routeMetricsControllerRouteRejectionsTotal.Reset()
+
+	const shard = "foo-ic"
+	t0 := fakeClock.Now()
+	err, cl, cache := newFakeClient(
+		unit.NewIngressControllerBuilder().WithName(shard).WithAdmitted(true).Build(),
+		unit.NewNamespaceBuilder().WithName("foo-ns").Build(),
+		unit.NewRouteBuilder().WithName("claimed-1").WithNamespace("foo-ns").WithRejectedIC(shard, "HostAlreadyClaimed", t0).Build(),
+		unit.NewRouteBuilder().WithName("claimed-2").WithNamespace("foo-ns").WithRejectedIC(shard, "HostAlreadyClaimed", t0).Build(),
+		unit.NewRouteBuilder().WithName("other-shard").WithNamespace("foo-ns").WithRejectedIC("bar-ic", "HostAlreadyClaimed", t0).Build(),
+		unit.NewRouteBuilder().WithName("admitted").WithNamespace("foo-ns").WithAdmittedICs(shard).Build(),
+	)
+	if err != nil {
+		t.Fatalf("error creating fake client: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		cache:            cache,
+		client:           cl,
+		namespace:        operatorcontroller.DefaultOperatorNamespace,
+		routeToIngresses: make(map[types.NamespacedName]sets.String),
+		recorder:         recorder,
+	}
+
+	setRoute := func(route *routev1.Route) {
+		t.Helper()
+		current := &routev1.Route{}
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(route), current); err != nil {
+			if err := cl.Create(context.Background(), route); err != nil {
+				t.Fatalf("failed to create route %s: %v", route.Name, err)
+			}
+			return
+		}
+		current.Status = route.Status
+		if err := cl.Update(context.Background(), current); err != nil {
+			t.Fatalf("failed to update route %s: %v", route.Name, err)
+		}
+	}
+	expect := func(description string, expectedRejected, expectedTotal map[string]float64, expectEvent bool) {
+		t.Helper()
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: operatorcontroller.DefaultOperatorNamespace, Name: shard}}
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		for _, reason := range knownRejectionReasons {
+			if actual := testutil.ToFloat64(routeMetricsControllerRejectedRoutes.WithLabelValues(shard, reason)); actual != expectedRejected[reason] {
+				t.Errorf("%s: expected %v rejected routes with reason %s, got %v", description, expectedRejected[reason], reason, actual)
+			}
+			if actual := testutil.ToFloat64(routeMetricsControllerRouteRejectionsTotal.WithLabelValues(shard, reason)); actual != expectedTotal[reason] {
+				t.Errorf("%s: expected %v rejections with reason %s, got %v", description, expectedTotal[reason], reason, actual)
+			}
+		}
+		select {
+		case event := <-recorder.Events:
+			if !expectEvent {
+				t.Errorf("%s: unexpected event: %s", description, event)
+			} else if !strings.HasPrefix(event, "Warning RouteRejectionSpike") {
+				t.Errorf("%s: expected a RouteRejectionSpike event, got %s", description, event)
+			}
+		default:
+			if expectEvent {
+				t.Errorf("%s: expected a RouteRejectionSpike event, got none", description)
+			}
+		}
+	}
+
+	// The rejections that exist when the operator starts are reported as
+	// current but are not counted.
+	expect("initial sync", map[string]float64{"HostAlreadyClaimed": 2}, nil, false)
+	expect("repeated sync", map[string]float64{"HostAlreadyClaimed": 2}, nil, false)
+
+	// A new rejection with an unknown reason is counted once as "Other".
+	fakeClock.Step(time.Minute)
+	setRoute(unit.NewRouteBuilder().WithName("weird").WithNamespace("foo-ns").WithRejectedIC(shard, "SomethingUnexpected", fakeClock.Now()).Build())
+	expect("new rejection", map[string]float64{"HostAlreadyClaimed": 2, "Other": 1}, map[string]float64{"Other": 1}, false)
+	expect("new rejection repeated sync", map[string]float64{"HostAlreadyClaimed": 2, "Other": 1}, map[string]float64{"Other": 1}, false)
+
+	// A rejected route is admitted, which is not a rejection, and then
+	// rejected again, which is.
+	setRoute(unit.NewRouteBuilder().WithName("claimed-1").WithNamespace("foo-ns").WithAdmittedICs(shard).Build())
+	expect("route admitted", map[string]float64{"HostAlreadyClaimed": 1, "Other": 1}, map[string]float64{"Other": 1}, false)
+	fakeClock.Step(time.Minute)
+	setRoute(unit.NewRouteBuilder().WithName("claimed-1").WithNamespace("foo-ns").WithRejectedIC(shard, "HostAlreadyClaimed", fakeClock.Now()).Build())
+	expect("route rejected again", map[string]float64{"HostAlreadyClaimed": 2, "Other": 1}, map[string]float64{"HostAlreadyClaimed": 1, "Other": 1}, false)
+
+	// A push creates conflicting routes, bringing the number of new
+	// rejections within the window to the threshold.  The spike is
+	// reported once.
+	for i := 0; i < rejectionSpikeThreshold-2; i++ {
+		setRoute(unit.NewRouteBuilder().WithName(fmt.Sprintf("conflict-%d", i)).WithNamespace("foo-ns").WithRejectedIC(shard, "HostAlreadyClaimed", fakeClock.Now()).Build())
+	}
+	expect("spike", map[string]float64{"HostAlreadyClaimed": 10, "Other": 1}, map[string]float64{"HostAlreadyClaimed": 9, "Other": 1}, true)
+	expect("spike repeated sync", map[string]float64{"HostAlreadyClaimed": 10, "Other": 1}, map[string]float64{"HostAlreadyClaimed": 9, "Other": 1}, false)
+
+	// Once the window passes, a single rejection is not a spike.
+	fakeClock.Step(rejectionSpikeWindow + time.Second)
+	setRoute(unit.NewRouteBuilder().WithName("late").WithNamespace("foo-ns").WithRejectedIC(shard, "ExtendedValidationFailed", fakeClock.Now()).Build())
+	expect("after the window", map[string]float64{"HostAlreadyClaimed": 10, "Other": 1, "ExtendedValidationFailed": 1}, map[string]float64{"HostAlreadyClaimed": 9, "Other": 1, "ExtendedValidationFailed": 1}, false)
+
+	// Deleting the shard's metrics removes every series for the shard.
+	DeleteRouteMetricsControllerRejectionMetrics(shard)
+	if n := testutil.CollectAndCount(routeMetricsControllerRejectedRoutes) + testutil.CollectAndCount(routeMetricsControllerRouteRejectionsTotal); n != 0 {
+		t.Errorf("expected no rejection metrics after deletion, got %d series", n)
+	}
+}
@@ -0,0 +1,183 @@
+package routemetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// IngressControllerApproachingRouteScaleLimitConditionType is the type
+	// of the ingresscontroller status condition that reports whether the
+	// number of routes or certificates that the ingresscontroller's router
+	// serves is approaching the soft limit beyond which router reloads
+	// are known to fail.  The route metrics controller owns the condition.
+	IngressControllerApproachingRouteScaleLimitConditionType = "ApproachingRouteScaleLimit"
+
+	// routeScaleWarningPercent is the percentage of a soft limit at which
+	// the controller reports that a shard is approaching the limit.
+	routeScaleWarningPercent = 80
+)
+
+// routeScaleLimits are the soft limits on the number of admitted routes and the
+// number of certificates that a router can serve.
+type routeScaleLimits struct {
+	routes       int
+	certificates int
+}
+
+// routeScaleSoftLimits are the soft limits for each router version, in
+// descending order of version, which is given as the OpenShift minor version
+// with which the router is released.  The limits are the scale at which the
+// HAProxy version that the router uses has been observed to fail reloads
+// because of the size of its maps and certificate list.
+var routeScaleSoftLimits = []struct {
+	major, minor int
+	limits       routeScaleLimits
+}{
+	// HAProxy 2.6 and later.
+	{major: 4, minor: 14, limits: routeScaleLimits{routes: 40000, certificates: 30000}},
+	// HAProxy 2.2.
+	{major: 4, minor: 0, limits: routeScaleLimits{routes: 30000, certificates: 20000}},
+}
+
+// routeScaleLimitsForVersion returns the soft limits for the router that is
+// released with the given operator release version.  If the version cannot be
+// parsed or is a development version such as "0.0.1-snapshot", the limits for
+// the latest router version are returned.
+func routeScaleLimitsForVersion(version string) routeScaleLimits {
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil || major == 0 {
+		return routeScaleSoftLimits[0].limits
+	}
+	for _, l := range routeScaleSoftLimits {
+		if major > l.major || (major == l.major && minor >= l.minor) {
+			return l.limits
+		}
+	}
+	return routeScaleSoftLimits[len(routeScaleSoftLimits)-1].limits
+}
+
+// routeScaleLimitsForIngressController returns the soft limits for the given
+// ingresscontroller, which are the given default limits unless the
+// ingresscontroller raises them using the "routeScale" unsupported config
+// override.  A cluster administrator can raise the limits for a shard whose
+// routers have been tuned to serve more routes.  Invalid overrides are
+// ignored; the ingress controller reports them.
+func routeScaleLimitsForIngressController(ic *operatorv1.IngressController, defaults routeScaleLimits) routeScaleLimits {
+	limits := defaults
+	var unsupportedConfigOverrides struct {
+		RouteScale struct {
+			RouteSoftLimit       int `json:"routeSoftLimit"`
+			CertificateSoftLimit int `json:"certificateSoftLimit"`
+		} `json:"routeScale"`
+	}
+	if err := ingresscontroller.DecodeUnsupportedConfigOverrides(ic, &unsupportedConfigOverrides); err != nil {
+		return limits
+	}
+	if v := unsupportedConfigOverrides.RouteScale.RouteSoftLimit; v > 0 {
+		limits.routes = v
+	}
+	if v := unsupportedConfigOverrides.RouteScale.CertificateSoftLimit; v > 0 {
+		limits.certificates = v
+	}
+	return limits
+}
+
+// routeHasCertificate returns a Boolean value indicating whether the given
+// route specifies its own certificate, which the router adds to its
+// certificate list.
+func routeHasCertificate(route *routev1.Route) bool {
+	tls := route.Spec.TLS
+	if tls == nil {
+		return false
+	}
+	return len(tls.Certificate) != 0 || tls.ExternalCertificate != nil
+}
+
+// approachingLimit returns a Boolean value indicating whether the given count
+// is at least routeScaleWarningPercent of the given limit.  A limit that is not
+// positive is no limit.
+func approachingLimit(count, limit int) bool {
+	return limit > 0 && count*100 >= limit*routeScaleWarningPercent
+}
+
+// computeApproachingRouteScaleLimitCondition returns the
+// "ApproachingRouteScaleLimit" condition for a shard with the given numbers of
+// admitted routes and certificates and the given soft limits.  The message does
+// not include the numbers so that the condition changes only when the shard
+// crosses a threshold; the route_metrics_controller_routes_per_shard and
+// route_metrics_controller_certificates_per_shard metrics report the numbers.
+func computeApproachingRouteScaleLimitCondition(routes, certificates int, limits routeScaleLimits) operatorv1.OperatorCondition {
+	var approaching []string
+	if approachingLimit(routes, limits.routes) {
+		approaching = append(approaching, fmt.Sprintf("admitted routes (soft limit %d)", limits.routes))
+	}
+	if approachingLimit(certificates, limits.certificates) {
+		approaching = append(approaching, fmt.Sprintf("route certificates (soft limit %d)", limits.certificates))
+	}
+	if len(approaching) == 0 {
+		return operatorv1.OperatorCondition{
+			Type:    IngressControllerApproachingRouteScaleLimitConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "BelowSoftLimits",
+			Message: fmt.Sprintf("The router serves fewer than %d%% of the soft limits of %d admitted routes and %d route certificates.", routeScaleWarningPercent, limits.routes, limits.certificates),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    IngressControllerApproachingRouteScaleLimitConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "ApproachingSoftLimits",
+		Message: fmt.Sprintf("The number of %s is at least %d%% of the scale at which router reloads are known to fail.  Move routes to another ingresscontroller using spec.routeSelector or spec.namespaceSelector, or, if the router has been tuned to serve more routes, raise the soft limits using spec.unsupportedConfigOverrides.routeScale.", strings.Join(approaching, " and the number of "), routeScaleWarningPercent),
+	}
+}
+
+// syncRouteScale updates the route scale metrics and the
+// "ApproachingRouteScaleLimit" status condition of the given ingresscontroller
+// for the given numbers of admitted routes and certificates, and emits an event
+// when the ingresscontroller starts approaching its soft limits.
+func (r *reconciler) syncRouteScale(ctx context.Context, ic *operatorv1.IngressController, routes, certificates int) error {
+	limits := routeScaleLimitsForIngressController(ic, r.routeScaleLimits)
+	SetRouteMetricsControllerCertificatesPerShardMetric(ic.Name, float64(certificates))
+	routeMetricsControllerRouteScaleSoftLimit.WithLabelValues(ic.Name, "routes").Set(float64(limits.routes))
+	routeMetricsControllerRouteScaleSoftLimit.WithLabelValues(ic.Name, "certificates").Set(float64(limits.certificates))
+
+	desired := computeApproachingRouteScaleLimitCondition(routes, certificates, limits)
+	updated := ic.DeepCopy()
+	var current *operatorv1.OperatorCondition
+	wasApproaching := false
+	for i := range updated.Status.Conditions {
+		if updated.Status.Conditions[i].Type == desired.Type {
+			current = &updated.Status.Conditions[i]
+			wasApproaching = current.Status == operatorv1.ConditionTrue
+			break
+		}
+	}
+	switch {
+	case current == nil:
+		desired.LastTransitionTime = metav1.NewTime(clock.Now())
+		updated.Status.Conditions = append(updated.Status.Conditions, desired)
+	case current.Status == desired.Status && current.Reason == desired.Reason && current.Message == desired.Message:
+		return nil
+	default:
+		desired.LastTransitionTime = current.LastTransitionTime
+		if current.Status != desired.Status {
+			desired.LastTransitionTime = metav1.NewTime(clock.Now())
+		}
+		*current = desired
+	}
+	if desired.Status == operatorv1.ConditionTrue && !wasApproaching && r.recorder != nil {
+		r.recorder.Event(ic, corev1.EventTypeWarning, "ApproachingRouteScaleLimit", desired.Message)
+	}
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update status of ingresscontroller %s: %w", ic.Name, err)
+	}
+	return nil
+}
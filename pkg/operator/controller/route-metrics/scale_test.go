@@ -0,0 +1,212 @@
+package routemetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/unit"
+
+	v1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Test_routeScaleLimitsForVersion verifies that routeScaleLimitsForVersion
+// returns the soft limits for the router version that is released with the
+// given operator version.
+func Test_routeScaleLimitsForVersion(t *testing.T) {
+	latest := routeScaleSoftLimits[0].limits
+	previous := routeScaleSoftLimits[1].limits
+	testCases := map[string]routeScaleLimits{
+		"4.14.0":                         latest,
+		"4.17.0-0.nightly-2024-06-01":    latest,
+		"5.0.0":                          latest,
+		"4.13.30":                        previous,
+		"4.6.0":                          previous,
+		"3.11.0":                         previous,
+		"":                               latest,
+		"0.0.1-snapshot":                 latest,
+		"not-a-version":                  latest,
+		"4.16.0-0.okd-2024-06-01-000000": latest,
+	}
+	for version, expected := range testCases {
+		if actual := routeScaleLimitsForVersion(version); actual != expected {
+			t.Errorf("version %q: expected limits %+v, got %+v", version, expected, actual)
+		}
+	}
+}
+
+// Test_routeScaleLimitsForIngressController verifies that the "routeScale"
+// unsupported config override raises the soft limits and that invalid values
+// are ignored.
+func Test_routeScaleLimitsForIngressController(t *testing.T) {
+	defaults := routeScaleLimits{routes: 40000, certificates: 30000}
+	testCases := []struct {
+		overrides string
+		expected  routeScaleLimits
+	}{
+		{"", defaults},
+		{`{"routeScale":{"routeSoftLimit":60000}}`, routeScaleLimits{routes: 60000, certificates: 30000}},
+		{`{"routeScale":{"certificateSoftLimit":50000}}`, routeScaleLimits{routes: 40000, certificates: 50000}},
+		{`{"routeScale":{"routeSoftLimit":-1,"certificateSoftLimit":0}}`, defaults},
+		{`{"routeScale":"invalid"}`, defaults},
+	}
+	for _, tc := range testCases {
+		ic := unit.NewIngressControllerBuilder().WithUnsupportedConfigOverrides(tc.overrides).Build()
+		if actual := routeScaleLimitsForIngressController(ic, defaults); actual != tc.expected {
+			t.Errorf("overrides %q: expected limits %+v, got %+v", tc.overrides, tc.expected, actual)
+		}
+	}
+}
+
+// Test_computeApproachingRouteScaleLimitCondition verifies the condition for
+// synthetic route and certificate counts around the warning thresholds of the
+// default soft limits.
+func Test_computeApproachingRouteScaleLimitCondition(t *testing.T) {
+	limits := routeScaleLimits{routes: 40000, certificates: 30000}
+	testCases := []struct {
+		routes, certificates int
+		expectApproaching    bool
+		expectMessage        []string
+	}{
+		{routes: 0, certificates: 0},
+		{routes: 31999, certificates: 23999},
+		{routes: 32000, certificates: 0, expectApproaching: true, expectMessage: []string{"admitted routes (soft limit 40000)"}},
+		{routes: 41000, certificates: 100, expectApproaching: true, expectMessage: []string{"admitted routes"}},
+		{routes: 24000, certificates: 24000, expectApproaching: true, expectMessage: []string{"route certificates (soft limit 30000)"}},
+		{routes: 32000, certificates: 24000, expectApproaching: true, expectMessage: []string{"admitted routes", "route certificates"}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d routes, %d certificates", tc.routes, tc.certificates), func(t *testing.T) {
+			condition := computeApproachingRouteScaleLimitCondition(tc.routes, tc.certificates, limits)
+			if condition.Type != IngressControllerApproachingRouteScaleLimitConditionType {
+				t.Fatalf("unexpected condition type %q", condition.Type)
+			}
+			expectedStatus := v1.ConditionFalse
+			if tc.expectApproaching {
+				expectedStatus = v1.ConditionTrue
+			}
+			if condition.Status != expectedStatus {
+				t.Errorf("expected status %s, got %s: %s", expectedStatus, condition.Status, condition.Message)
+			}
+			for _, s := range tc.expectMessage {
+				if !strings.Contains(condition.Message, s) {
+					t.Errorf("expected message to contain %q, got %q", s, condition.Message)
+				}
+			}
+		})
+	}
+}
+
+// Test_syncRouteScale verifies that reconciling a shard whose admitted routes
+// grow past and then fall below the warning threshold of its soft limit sets
+// the "ApproachingRouteScaleLimit" condition accordingly, emits a single event
+// when the shard starts approaching the limit, and reports the certificate
+// count and soft limits in metrics.
+func Test_syncRouteScale(t *testing.T) {
+	routeMetricsControllerCertificatesPerShard.Reset()
+	routeMetricsControllerRouteScaleSoftLimit.Reset()
+
+	const shard = "foo-ic"
+	err, cl, cache := newFakeClient(
+		unit.NewIngressControllerBuilder().
+			WithName(shard).
+			WithAdmitted(true).
+			WithUnsupportedConfigOverrides(`{"routeScale":{"routeSoftLimit":10}}`).
+			Build(),
+		unit.NewNamespaceBuilder().WithName("foo-ns").Build(),
+	)
+	if err != nil {
+		t.Fatalf("error creating fake client: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &reconciler{
+		cache:            cache,
+		client:           cl,
+		namespace:        operatorcontroller.DefaultOperatorNamespace,
+		routeToIngresses: make(map[types.NamespacedName]sets.String),
+		recorder:         recorder,
+		routeScaleLimits: routeScaleLimits{routes: 40000, certificates: 30000},
+	}
+
+	routes := 0
+	setRoutes := func(n int) {
+		t.Helper()
+		for ; routes < n; routes++ {
+			builder := unit.NewRouteBuilder().WithName(fmt.Sprintf("route-%d", routes)).WithNamespace("foo-ns").WithAdmittedICs(shard)
+			if routes%2 == 0 {
+				builder = builder.WithCertificate("cert")
+			}
+			if err := cl.Create(context.Background(), builder.Build()); err != nil {
+				t.Fatalf("failed to create route: %v", err)
+			}
+		}
+		for ; routes > n; routes-- {
+			route := unit.NewRouteBuilder().WithName(fmt.Sprintf("route-%d", routes-1)).WithNamespace("foo-ns").Build()
+			if err := cl.Delete(context.Background(), route); err != nil {
+				t.Fatalf("failed to delete route: %v", err)
+			}
+		}
+	}
+	expect := func(description string, expectedStatus v1.ConditionStatus, expectEvent bool) {
+		t.Helper()
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: operatorcontroller.DefaultOperatorNamespace, Name: shard}}
+		if _, err := r.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		ic := &v1.IngressController{}
+		if err := cl.Get(context.Background(), request.NamespacedName, ic); err != nil {
+			t.Fatalf("%s: failed to get ingresscontroller: %v", description, err)
+		}
+		var condition *v1.OperatorCondition
+		for i := range ic.Status.Conditions {
+			if ic.Status.Conditions[i].Type == IngressControllerApproachingRouteScaleLimitConditionType {
+				condition = &ic.Status.Conditions[i]
+			}
+		}
+		if condition == nil {
+			t.Fatalf("%s: expected the %s condition", description, IngressControllerApproachingRouteScaleLimitConditionType)
+		}
+		if condition.Status != expectedStatus {
+			t.Errorf("%s: expected status %s, got %s: %s", description, expectedStatus, condition.Status, condition.Message)
+		}
+		if expected, actual := float64((routes+1)/2), testutil.ToFloat64(routeMetricsControllerCertificatesPerShard.WithLabelValues(shard)); actual != expected {
+			t.Errorf("%s: expected %v certificates, got %v", description, expected, actual)
+		}
+		if actual := testutil.ToFloat64(routeMetricsControllerRouteScaleSoftLimit.WithLabelValues(shard, "routes")); actual != 10 {
+			t.Errorf("%s: expected the route soft limit to be 10, got %v", description, actual)
+		}
+		select {
+		case event := <-recorder.Events:
+			if !expectEvent {
+				t.Errorf("%s: unexpected event: %s", description, event)
+			} else if !strings.Contains(event, "ApproachingRouteScaleLimit") {
+				t.Errorf("%s: unexpected event: %s", description, event)
+			}
+		default:
+			if expectEvent {
+				t.Errorf("%s: expected an event", description)
+			}
+		}
+	}
+
+	setRoutes(7)
+	expect("below the threshold", v1.ConditionFalse, false)
+	setRoutes(8)
+	expect("at the threshold", v1.ConditionTrue, true)
+	setRoutes(11)
+	expect("past the limit", v1.ConditionTrue, false)
+	setRoutes(5)
+	expect("back below the threshold", v1.ConditionFalse, false)
+	setRoutes(9)
+	expect("approaching again", v1.ConditionTrue, true)
+}
@@ -0,0 +1,199 @@
+package statussummary
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "status_summary_controller"
+
+	// summaryConfigMapName is the name of the configmap in the operator
+	// namespace that holds the status summary.
+	summaryConfigMapName = "ingress-operator-status-summary"
+
+	// debounceInterval is the interval over which changes to watched
+	// resources are coalesced into a single update of the summary.
+	debounceInterval = 5 * time.Second
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// Config holds all the things necessary for the controller to run.
+type Config struct {
+	// Namespace is the operator namespace, which has the ingresscontrollers
+	// and dnsrecords and where the summary configmap is published.
+	Namespace string
+}
+
+// New creates the status summary controller.  This is the controller that
+// maintains a configmap that summarizes the status of every ingresscontroller
+// so that monitoring tools can read the health of ingress in a single request
+// instead of polling every ingresscontroller and dnsrecord.
+//
+// The controller watches ingresscontrollers and dnsrecords in the operator
+// namespace, router deployments in the operand namespace, and the summary
+// configmap.  Every event enqueues the same request after a delay so that
+// bursts of changes result in a single update of the summary.
+func New(mgr manager.Manager, config Config) (controller.Controller, error) {
+	operatorCache := mgr.GetCache()
+	reconciler := &reconciler{
+		config: config,
+		client: mgr.GetClient(),
+		cache:  operatorCache,
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+
+	enqueueSummary := debouncedEnqueue(ConfigMapName(config.Namespace), debounceInterval)
+	inNamespace := func(namespace string) predicate.Predicate {
+		return predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetNamespace() == namespace
+		})
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &operatorv1.IngressController{}, enqueueSummary, inNamespace(config.Namespace))); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &iov1.DNSRecord{}, enqueueSummary, inNamespace(config.Namespace))); err != nil {
+		return nil, err
+	}
+	isRouterDeployment := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := o.GetLabels()[operatorcontroller.ControllerDeploymentLabel]
+		return ok && o.GetNamespace() == operatorcontroller.DefaultOperandNamespace
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &appsv1.Deployment{}, enqueueSummary, isRouterDeployment)); err != nil {
+		return nil, err
+	}
+	isSummaryConfigMap := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == config.Namespace && o.GetName() == summaryConfigMapName
+	})
+	if err := c.Watch(source.Kind[client.Object](operatorCache, &corev1.ConfigMap{}, enqueueSummary, isSummaryConfigMap)); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// debouncedEnqueue returns an event handler that enqueues a request for the
+// given name after the given delay for every event.  The workqueue coalesces a
+// request that is already waiting, so all events within the delay result in a
+// single reconciliation.
+func debouncedEnqueue(name types.NamespacedName, delay time.Duration) handler.EventHandler {
+	request := reconcile.Request{NamespacedName: name}
+	enqueue := func(q workqueue.RateLimitingInterface) {
+		q.AddAfter(request, delay)
+	}
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, _ event.CreateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q)
+		},
+		UpdateFunc: func(_ context.Context, _ event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q)
+		},
+		DeleteFunc: func(_ context.Context, _ event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q)
+		},
+		GenericFunc: func(_ context.Context, _ event.GenericEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q)
+		},
+	}
+}
+
+// ConfigMapName returns the namespaced name for the status summary configmap.
+func ConfigMapName(namespace string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: namespace,
+		Name:      summaryConfigMapName,
+	}
+}
+
+// reconciler handles the actual status summary reconciliation logic in
+// response to events.
+type reconciler struct {
+	config Config
+
+	client client.Client
+	cache  cache.Cache
+}
+
+// Reconcile computes the status summary of every ingresscontroller and
+// publishes it in the summary configmap.
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.V(1).Info("reconciling", "request", request)
+
+	ingressControllers := &operatorv1.IngressControllerList{}
+	if err := r.cache.List(ctx, ingressControllers, client.InNamespace(r.config.Namespace)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list ingresscontrollers in namespace %s: %w", r.config.Namespace, err)
+	}
+	dnsRecords := &iov1.DNSRecordList{}
+	if err := r.cache.List(ctx, dnsRecords, client.InNamespace(r.config.Namespace)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list dnsrecords in namespace %s: %w", r.config.Namespace, err)
+	}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.cache.List(ctx, deployments, client.InNamespace(operatorcontroller.DefaultOperandNamespace), client.HasLabels{operatorcontroller.ControllerDeploymentLabel}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list router deployments in namespace %s: %w", operatorcontroller.DefaultOperandNamespace, err)
+	}
+
+	data, err := desiredSummaryData(ingressControllers.Items, dnsRecords.Items, deployments.Items)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.ensureSummaryConfigMap(ctx, data); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to ensure status summary configmap: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// ensureSummaryConfigMap creates or updates the summary configmap so that it
+// has the given data.
+func (r *reconciler) ensureSummaryConfigMap(ctx context.Context, data map[string]string) error {
+	name := ConfigMapName(r.config.Namespace)
+	current := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, name, current); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		desired := desiredSummaryConfigMap(name, data)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return err
+		}
+		log.Info("created status summary configmap", "namespace", name.Namespace, "name", name.Name)
+		return nil
+	}
+	if !summaryChanged(current.Data, data) {
+		return nil
+	}
+	updated := current.DeepCopy()
+	updated.Data = data
+	if err := r.client.Update(ctx, updated); err != nil {
+		return err
+	}
+	log.V(1).Info("updated status summary configmap", "namespace", name.Namespace, "name", name.Name)
+	return nil
+}
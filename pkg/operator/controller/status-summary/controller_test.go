@@ -0,0 +1,232 @@
+package statussummary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testNamespace = "openshift-ingress-operator"
+
+type fakeCache struct {
+	cache.Informers
+	client.Reader
+}
+
+// newIngressController returns an ingresscontroller with the given name,
+// available replicas, and conditions.
+func newIngressController(name string, availableReplicas int32, conditions ...operatorv1.OperatorCondition) *operatorv1.IngressController {
+	return &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+		},
+		Status: operatorv1.IngressControllerStatus{
+			AvailableReplicas: availableReplicas,
+			Conditions:        conditions,
+		},
+	}
+}
+
+// newRouterDeployment returns a router deployment for the ingresscontroller
+// with the given name.
+func newRouterDeployment(icName string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorcontroller.DefaultOperandNamespace,
+			Name:      "router-" + icName,
+			Labels: map[string]string{
+				operatorcontroller.ControllerDeploymentLabel: icName,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+	}
+}
+
+// newWildcardDNSRecord returns the wildcard dnsrecord for the ingresscontroller
+// with the given name, with the Published condition set to the given status in
+// each of the given zones.
+func newWildcardDNSRecord(icName, published string, zones ...string) *iov1.DNSRecord {
+	record := &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      icName + "-wildcard",
+		},
+	}
+	for _, zone := range zones {
+		record.Status.Zones = append(record.Status.Zones, iov1.DNSZoneStatus{
+			DNSZone: configv1.DNSZone{ID: zone},
+			Conditions: []iov1.DNSZoneCondition{{
+				Type:   iov1.DNSRecordPublishedConditionType,
+				Status: published,
+			}},
+		})
+	}
+	return record
+}
+
+// Test_Reconcile verifies that Reconcile publishes a summary entry for each
+// ingresscontroller and that a change to one ingresscontroller changes only
+// that ingresscontroller's entry.
+func Test_Reconcile(t *testing.T) {
+	transitionTime := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheme := runtime.NewScheme()
+	operatorv1.Install(scheme)
+	iov1.AddToScheme(scheme)
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+
+	available := operatorv1.OperatorCondition{Type: "Available", Status: operatorv1.ConditionTrue, LastTransitionTime: transitionTime}
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&operatorv1.IngressController{}).
+		WithObjects(
+			newIngressController("default", 2, available),
+			newIngressController("sharded", 1, available),
+			newRouterDeployment("default", 2),
+			newRouterDeployment("sharded", 2),
+			newWildcardDNSRecord("default", "True", "private-zone", "public-zone"),
+			newWildcardDNSRecord("sharded", "True", "private-zone"),
+		).
+		Build()
+	informer := informertest.FakeInformers{Scheme: scheme}
+	r := &reconciler{
+		config: Config{Namespace: testNamespace},
+		client: cl,
+		cache:  fakeCache{Informers: &informer, Reader: cl},
+	}
+	request := reconcile.Request{NamespacedName: ConfigMapName(testNamespace)}
+
+	getSummary := func() *corev1.ConfigMap {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := cl.Get(context.Background(), ConfigMapName(testNamespace), cm); err != nil {
+			t.Fatalf("failed to get summary configmap: %v", err)
+		}
+		return cm
+	}
+
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	initial := getSummary()
+	expectedDefault := `{"available":{"status":"True","lastTransitionTime":"2024-01-01T00:00:00Z"},"desiredReplicas":2,"availableReplicas":2,"dnsZones":[{"zone":"private-zone","published":{"status":"True","lastTransitionTime":null}},{"zone":"public-zone","published":{"status":"True","lastTransitionTime":null}}]}`
+	if initial.Data["default"] != expectedDefault {
+		t.Errorf("unexpected summary for ingresscontroller default:\nexpected: %s\n     got: %s", expectedDefault, initial.Data["default"])
+	}
+	if len(initial.Data) != 2 {
+		t.Fatalf("expected 2 summary entries, got %d: %v", len(initial.Data), initial.Data)
+	}
+
+	// Reconciling without changes must not update the configmap.
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unchanged := getSummary(); unchanged.ResourceVersion != initial.ResourceVersion {
+		t.Errorf("expected configmap not to be updated, resource version changed from %s to %s", initial.ResourceVersion, unchanged.ResourceVersion)
+	}
+
+	// Mutate one ingresscontroller, and verify that only its entry
+	// changes.
+	ic := &operatorv1.IngressController{}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: "sharded"}, ic); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+	ic.Status.AvailableReplicas = 2
+	ic.Status.Conditions = append(ic.Status.Conditions, operatorv1.OperatorCondition{Type: "Degraded", Status: operatorv1.ConditionFalse, LastTransitionTime: transitionTime})
+	if err := cl.Status().Update(context.Background(), ic); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := getSummary()
+	for name := range initial.Data {
+		switch changed := initial.Data[name] != updated.Data[name]; {
+		case name == "sharded" && !changed:
+			t.Errorf("expected summary entry for %s to change", name)
+		case name != "sharded" && changed:
+			t.Errorf("unexpected change to summary entry for %s:\n%s", name, cmp.Diff(initial.Data[name], updated.Data[name]))
+		}
+	}
+	summary := ingressControllerSummary{}
+	if err := json.Unmarshal([]byte(updated.Data["sharded"]), &summary); err != nil {
+		t.Fatalf("failed to decode summary entry: %v", err)
+	}
+	if summary.AvailableReplicas != 2 || summary.Degraded == nil || summary.Degraded.Status != "False" {
+		t.Errorf("unexpected summary entry for sharded: %s", updated.Data["sharded"])
+	}
+}
+
+// Test_desiredSummaryData verifies that the summary entries stay small enough
+// for the configmap to hold the summaries of many ingresscontrollers.
+func Test_desiredSummaryData(t *testing.T) {
+	const (
+		numIngressControllers = 150
+		// maxConfigMapSize is the maximum size of a configmap's data.
+		maxConfigMapSize = 1024 * 1024
+	)
+	transitionTime := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var (
+		ingressControllers []operatorv1.IngressController
+		dnsRecords         []iov1.DNSRecord
+		deployments        []appsv1.Deployment
+	)
+	for i := 0; i < numIngressControllers; i++ {
+		name := fmt.Sprintf("ingresscontroller-with-a-long-name-%d", i)
+		var conditions []operatorv1.OperatorCondition
+		for _, conditionType := range []string{"Admitted", "Available", "Degraded", "LoadBalancerReady", "CanaryChecksSucceeding", "DNSReady", "Progressing"} {
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:               conditionType,
+				Status:             operatorv1.ConditionTrue,
+				LastTransitionTime: transitionTime,
+				Message:            "The messages of conditions are not included in the summary.",
+			})
+		}
+		ingressControllers = append(ingressControllers, *newIngressController(name, 3, conditions...))
+		dnsRecords = append(dnsRecords, *newWildcardDNSRecord(name, "True", "private-zone-id", "public-zone-id"))
+		deployments = append(deployments, *newRouterDeployment(name, 3))
+	}
+	data, err := desiredSummaryData(ingressControllers, dnsRecords, deployments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != numIngressControllers {
+		t.Fatalf("expected %d entries, got %d", numIngressControllers, len(data))
+	}
+	size := 0
+	for k, v := range data {
+		if len(v) > 1024 {
+			t.Errorf("summary entry for %s has %d bytes, expected at most 1024", k, len(v))
+		}
+		size += len(k) + len(v)
+	}
+	if size*(1000/numIngressControllers) > maxConfigMapSize {
+		t.Errorf("summary of %d ingresscontrollers has %d bytes, which would not scale to 1000 ingresscontrollers", numIngressControllers, size)
+	}
+}
@@ -0,0 +1,188 @@
+package statussummary
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ingressControllerSummary is the summary of an ingresscontroller's status.
+// It is published as JSON under the ingresscontroller's name in the summary
+// configmap.  The summary deliberately omits condition messages and other
+// free-form text so that each entry has a small, bounded size; an entry is well
+// under 1 KiB, so the configmap can hold the summaries of over a thousand
+// ingresscontrollers.
+type ingressControllerSummary struct {
+	// Admitted is the status of the ingresscontroller's Admitted condition.
+	Admitted *conditionSummary `json:"admitted,omitempty"`
+	// Available is the status of the ingresscontroller's Available
+	// condition.
+	Available *conditionSummary `json:"available,omitempty"`
+	// Degraded is the status of the ingresscontroller's Degraded
+	// condition.
+	Degraded *conditionSummary `json:"degraded,omitempty"`
+	// LoadBalancerReady is the status of the ingresscontroller's
+	// LoadBalancerReady condition.  It is omitted if the ingresscontroller
+	// does not use a load balancer.
+	LoadBalancerReady *conditionSummary `json:"loadBalancerReady,omitempty"`
+	// CanaryChecksSucceeding is the status of the ingresscontroller's
+	// CanaryChecksSucceeding condition.  It is omitted if canary checks are
+	// not performed for the ingresscontroller.
+	CanaryChecksSucceeding *conditionSummary `json:"canaryChecksSucceeding,omitempty"`
+	// DesiredReplicas is the number of replicas that the router
+	// deployment specifies.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// AvailableReplicas is the number of available router replicas.
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// DNSZones has the publishing status of the ingresscontroller's
+	// wildcard DNS record in each DNS zone, sorted by zone.
+	DNSZones []dnsZoneSummary `json:"dnsZones,omitempty"`
+}
+
+// conditionSummary is the status and last transition time of a condition.
+type conditionSummary struct {
+	Status             string      `json:"status"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+// dnsZoneSummary is the publishing status of a DNS record in a zone.
+type dnsZoneSummary struct {
+	// Zone is the zone's ID, or its tags if it has no ID.
+	Zone string `json:"zone"`
+	// Published is the status of the record's Published condition for
+	// the zone.
+	Published *conditionSummary `json:"published,omitempty"`
+}
+
+// desiredSummaryData returns the data for the summary configmap for the given
+// ingresscontrollers, dnsrecords, and router deployments.  The data has one
+// key for each ingresscontroller, with the JSON encoding of the
+// ingresscontroller's summary as the value.  The encoding of each summary
+// depends only on the ingresscontroller and its own dnsrecord and deployment,
+// so a change to one ingresscontroller changes only that ingresscontroller's
+// entry.
+func desiredSummaryData(ingressControllers []operatorv1.IngressController, dnsRecords []iov1.DNSRecord, deployments []appsv1.Deployment) (map[string]string, error) {
+	dnsRecordsByName := make(map[types.NamespacedName]*iov1.DNSRecord, len(dnsRecords))
+	for i := range dnsRecords {
+		dnsRecordsByName[types.NamespacedName{Namespace: dnsRecords[i].Namespace, Name: dnsRecords[i].Name}] = &dnsRecords[i]
+	}
+	deploymentsByName := make(map[types.NamespacedName]*appsv1.Deployment, len(deployments))
+	for i := range deployments {
+		deploymentsByName[types.NamespacedName{Namespace: deployments[i].Namespace, Name: deployments[i].Name}] = &deployments[i]
+	}
+
+	data := make(map[string]string, len(ingressControllers))
+	for i := range ingressControllers {
+		ic := &ingressControllers[i]
+		dnsRecord := dnsRecordsByName[operatorcontroller.WildcardDNSRecordName(ic)]
+		deployment := deploymentsByName[operatorcontroller.RouterDeploymentName(ic)]
+		summary := summarizeIngressController(ic, dnsRecord, deployment)
+		encoded, err := json.Marshal(summary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode status summary for ingresscontroller %s: %w", ic.Name, err)
+		}
+		data[ic.Name] = string(encoded)
+	}
+	return data, nil
+}
+
+// summarizeIngressController returns the summary of the given
+// ingresscontroller's status.  The dnsrecord and deployment may be nil.
+func summarizeIngressController(ic *operatorv1.IngressController, dnsRecord *iov1.DNSRecord, deployment *appsv1.Deployment) ingressControllerSummary {
+	summary := ingressControllerSummary{
+		Admitted:               summarizeCondition(ic, ingress.IngressControllerAdmittedConditionType),
+		Available:              summarizeCondition(ic, operatorv1.OperatorStatusTypeAvailable),
+		Degraded:               summarizeCondition(ic, operatorv1.OperatorStatusTypeDegraded),
+		LoadBalancerReady:      summarizeCondition(ic, operatorv1.LoadBalancerReadyIngressConditionType),
+		CanaryChecksSucceeding: summarizeCondition(ic, ingress.IngressControllerCanaryCheckSuccessConditionType),
+		AvailableReplicas:      ic.Status.AvailableReplicas,
+	}
+	switch {
+	case deployment != nil && deployment.Spec.Replicas != nil:
+		summary.DesiredReplicas = *deployment.Spec.Replicas
+	case ic.Spec.Replicas != nil:
+		summary.DesiredReplicas = *ic.Spec.Replicas
+	}
+	if dnsRecord != nil {
+		for _, zone := range dnsRecord.Status.Zones {
+			zoneSummary := dnsZoneSummary{Zone: zoneName(zone.DNSZone)}
+			for _, cond := range zone.Conditions {
+				if cond.Type == iov1.DNSRecordPublishedConditionType {
+					zoneSummary.Published = &conditionSummary{
+						Status:             cond.Status,
+						LastTransitionTime: cond.LastTransitionTime,
+					}
+				}
+			}
+			summary.DNSZones = append(summary.DNSZones, zoneSummary)
+		}
+		sort.Slice(summary.DNSZones, func(i, j int) bool {
+			return summary.DNSZones[i].Zone < summary.DNSZones[j].Zone
+		})
+	}
+	return summary
+}
+
+// summarizeCondition returns the summary of the ingresscontroller's condition
+// with the given type, or nil if the ingresscontroller has no such condition.
+func summarizeCondition(ic *operatorv1.IngressController, conditionType string) *conditionSummary {
+	for _, cond := range ic.Status.Conditions {
+		if cond.Type == conditionType {
+			return &conditionSummary{
+				Status:             string(cond.Status),
+				LastTransitionTime: cond.LastTransitionTime,
+			}
+		}
+	}
+	return nil
+}
+
+// zoneName returns a name for the given zone: its ID if it has one, and
+// otherwise its tags, sorted.
+func zoneName(zone configv1.DNSZone) string {
+	if len(zone.ID) != 0 {
+		return zone.ID
+	}
+	tags := make([]string, 0, len(zone.Tags))
+	for k, v := range zone.Tags {
+		tags = append(tags, k+"="+v)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// desiredSummaryConfigMap returns the summary configmap with the given name
+// and data.
+func desiredSummaryConfigMap(name types.NamespacedName, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+		Data: data,
+	}
+}
+
+// summaryChanged returns a Boolean value indicating whether the current
+// summary data differs from the desired data.
+func summaryChanged(current, desired map[string]string) bool {
+	if len(current) == 0 && len(desired) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(current, desired)
+}
@@ -101,6 +101,13 @@ type Config struct {
 	CanaryImage            string
 	OperatorReleaseVersion string
 	Namespace              string
+	// OperandNamespace is the namespace in which the operator installs the
+	// ServiceMeshControlPlane for Gateway API.
+	OperandNamespace string
+	// GatewayAPIEnabled indicates whether the GatewayAPI featuregate is
+	// enabled, in which case the controller reports failures of the
+	// Gateway API components in the GatewayAPIDegraded status condition.
+	GatewayAPIEnabled bool
 }
 
 // reconciler handles the actual status reconciliation logic in response to
@@ -174,6 +181,16 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	co.Status.Versions = r.computeOperatorStatusVersions(oldStatus.Versions, allIngressesAvailable)
 
+	result := reconcile.Result{}
+	degradedCondition := computeOperatorDegradedCondition(state.IngressControllers)
+	if gatewayAPIDegradedCondition, ok := computeGatewayAPIDegradedCondition(state.GatewayAPI, clock.Now()); ok {
+		co.Status.Conditions = mergeConditions(co.Status.Conditions, gatewayAPIDegradedCondition)
+		degradedCondition = rollUpGatewayAPIDegradedCondition(degradedCondition, gatewayAPIDegradedCondition)
+		result.RequeueAfter = gatewayAPIResyncPeriod
+	} else {
+		co.Status.Conditions = removeCondition(co.Status.Conditions, GatewayAPIDegradedConditionType)
+	}
+
 	co.Status.Conditions = mergeConditions(co.Status.Conditions,
 		computeOperatorAvailableCondition(state.IngressControllers),
 		computeOperatorProgressingCondition(
@@ -185,7 +202,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 			r.config.IngressControllerImage,
 			r.config.CanaryImage,
 		),
-		computeOperatorDegradedCondition(state.IngressControllers),
+		degradedCondition,
 		computeOperatorUpgradeableCondition(state.IngressControllers),
 		computeOperatorEvaluationConditionsDetectedCondition(state.IngressControllers),
 	)
@@ -196,7 +213,7 @@ func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		}
 	}
 
-	return reconcile.Result{}, nil
+	return result, nil
 }
 
 // Populate versions and conditions in cluster operator status as CVO expects these fields.
@@ -236,6 +253,8 @@ type operatorState struct {
 	CanaryNamespace    *corev1.Namespace
 	IngressControllers []operatorv1.IngressController
 	DNSRecords         []iov1.DNSRecord
+	// GatewayAPI is nil unless Gateway API is enabled and in use.
+	GatewayAPI *gatewayAPIState
 }
 
 // getOperatorState gets and returns the resources necessary to compute the
@@ -268,6 +287,14 @@ func (r *reconciler) getOperatorState(ingressNamespace, canaryNamespace string)
 		state.IngressControllers = ingressList.Items
 	}
 
+	if r.config.GatewayAPIEnabled {
+		gatewayAPI, err := r.getGatewayAPIState(context.TODO())
+		if err != nil {
+			return state, fmt.Errorf("failed to get gateway api state: %w", err)
+		}
+		state.GatewayAPI = gatewayAPI
+	}
+
 	return state, nil
 }
 
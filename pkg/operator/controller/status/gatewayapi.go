@@ -0,0 +1,315 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// GatewayAPIDegradedConditionType is the type of the clusteroperator
+	// status condition that reports whether the components that the
+	// operator installs to implement Gateway API, namely the OpenShift
+	// Service Mesh operator, istiod, and the ServiceMeshControlPlane, are
+	// failing.  The operator reports Degraded=True when this condition is
+	// true.
+	GatewayAPIDegradedConditionType = "GatewayAPIDegraded"
+
+	// gatewayAPIDegradedGracePeriod is how long istiod may have no
+	// available replicas, or the ServiceMeshControlPlane may report that it
+	// is not ready, before the operator reports that Gateway API is
+	// degraded.  The grace period avoids reporting degraded while these
+	// components are being installed or upgraded.
+	gatewayAPIDegradedGracePeriod = 5 * time.Minute
+
+	// gatewayAPIResyncPeriod is how often the status controller checks the
+	// Gateway API components while Gateway API is in use.  The controller
+	// does not watch these components because their CRDs may not exist and
+	// because the operator does not cache the namespace of the
+	// subscription.
+	gatewayAPIResyncPeriod = 1 * time.Minute
+)
+
+// gatewayAPIState holds the resources that the status controller uses to
+// compute the GatewayAPIDegraded status condition.  A nil field means that the
+// resource does not exist.
+type gatewayAPIState struct {
+	Subscription            *operatorsv1alpha1.Subscription
+	InstallPlan             *operatorsv1alpha1.InstallPlan
+	IstiodDeployment        *appsv1.Deployment
+	ServiceMeshControlPlane *maistrav2.ServiceMeshControlPlane
+}
+
+// getGatewayAPIState gets the resources necessary to compute the
+// GatewayAPIDegraded status condition.  It returns nil if the subscription for
+// the OpenShift Service Mesh operator does not exist, which means that Gateway
+// API is not in use.
+func (r *reconciler) getGatewayAPIState(ctx context.Context) (*gatewayAPIState, error) {
+	subscription := &operatorsv1alpha1.Subscription{}
+	if found, err := r.getOptionalObject(ctx, operatorcontroller.ServiceMeshSubscriptionName(), subscription); err != nil || !found {
+		return nil, err
+	}
+	state := &gatewayAPIState{Subscription: subscription}
+
+	if ref := subscription.Status.InstallPlanRef; ref != nil {
+		installPlan := &operatorsv1alpha1.InstallPlan{}
+		name := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if found, err := r.getOptionalObject(ctx, name, installPlan); err != nil {
+			return nil, err
+		} else if found {
+			state.InstallPlan = installPlan
+		}
+	}
+
+	smcpName := operatorcontroller.ServiceMeshControlPlaneName(r.config.OperandNamespace)
+	if smcp, err := r.newestServiceMeshControlPlane(ctx); err != nil {
+		return nil, err
+	} else if smcp != nil {
+		state.ServiceMeshControlPlane = smcp
+		smcpName = types.NamespacedName{Namespace: smcp.Namespace, Name: smcp.Name}
+	}
+
+	deployment := &appsv1.Deployment{}
+	if found, err := r.getOptionalObject(ctx, operatorcontroller.IstiodDeploymentNameForServiceMeshControlPlane(smcpName), deployment); err != nil {
+		return nil, err
+	} else if found {
+		state.IstiodDeployment = deployment
+	}
+
+	return state, nil
+}
+
+// newestServiceMeshControlPlane returns the most recently created
+// ServiceMeshControlPlane in the operand namespace that has the name that the
+// operator uses or a name for a control plane revision that the operator
+// created during a control plane migration.  During a migration, this is the
+// revision to which gateways are migrated.  It returns nil if there is no such
+// ServiceMeshControlPlane.
+func (r *reconciler) newestServiceMeshControlPlane(ctx context.Context) (*maistrav2.ServiceMeshControlPlane, error) {
+	var list maistrav2.ServiceMeshControlPlaneList
+	if err := r.client.List(ctx, &list, client.InNamespace(r.config.OperandNamespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list ServiceMeshControlPlanes in namespace %s: %w", r.config.OperandNamespace, err)
+	}
+	base := operatorcontroller.ServiceMeshControlPlaneName(r.config.OperandNamespace).Name
+	var newest *maistrav2.ServiceMeshControlPlane
+	for i := range list.Items {
+		smcp := &list.Items[i]
+		if smcp.Name != base && !strings.HasPrefix(smcp.Name, base+"-") {
+			continue
+		}
+		if newest == nil || newest.CreationTimestamp.Before(&smcp.CreationTimestamp) {
+			newest = smcp
+		}
+	}
+	return newest, nil
+}
+
+// getOptionalObject gets the object with the given name and returns a Boolean
+// value indicating whether the object exists.  The object does not exist if
+// its CRD does not exist.
+func (r *reconciler) getOptionalObject(ctx context.Context, name types.NamespacedName, obj client.Object) (bool, error) {
+	if err := r.client.Get(ctx, name, obj); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get %T %s: %w", obj, name, err)
+	}
+	return true, nil
+}
+
+// gatewayAPIFailure describes a failing Gateway API component.
+type gatewayAPIFailure struct {
+	reason  string
+	message string
+}
+
+// computeGatewayAPIDegradedCondition computes the operator's
+// GatewayAPIDegraded status condition and returns a Boolean value indicating
+// whether the operator should have the condition, which it should if Gateway
+// API is in use.
+//
+// Gateway API is degraded if the subscription for the OpenShift Service Mesh
+// operator has a failed install plan, if istiod has had no available replicas
+// for longer than the grace period, or if the ServiceMeshControlPlane has been
+// not ready for longer than the grace period.
+func computeGatewayAPIDegradedCondition(state *gatewayAPIState, now time.Time) (configv1.ClusterOperatorStatusCondition, bool) {
+	if state == nil || state.Subscription == nil {
+		return configv1.ClusterOperatorStatusCondition{}, false
+	}
+
+	var failures []gatewayAPIFailure
+	if failure, failed := installPlanFailure(state.Subscription, state.InstallPlan); failed {
+		failures = append(failures, failure)
+	}
+	if failure, failed := istiodFailure(state.IstiodDeployment, now); failed {
+		failures = append(failures, failure)
+	}
+	if failure, failed := serviceMeshControlPlaneFailure(state.ServiceMeshControlPlane, now); failed {
+		failures = append(failures, failure)
+	}
+
+	condition := configv1.ClusterOperatorStatusCondition{
+		Type: GatewayAPIDegradedConditionType,
+	}
+	switch len(failures) {
+	case 0:
+		condition.Status = configv1.ConditionFalse
+		condition.Reason = "AsExpected"
+		condition.Message = "The Gateway API components are healthy."
+	case 1:
+		condition.Status = configv1.ConditionTrue
+		condition.Reason = failures[0].reason
+		condition.Message = failures[0].message
+	default:
+		messages := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			messages = append(messages, failure.message)
+		}
+		condition.Status = configv1.ConditionTrue
+		condition.Reason = "MultipleComponentsDegraded"
+		condition.Message = strings.Join(messages, "  ")
+	}
+	return condition, true
+}
+
+// installPlanFailure returns a failure if the given subscription reports that
+// its install plan failed or the given install plan is in the failed phase.
+func installPlanFailure(subscription *operatorsv1alpha1.Subscription, installPlan *operatorsv1alpha1.InstallPlan) (gatewayAPIFailure, bool) {
+	name := fmt.Sprintf("%s/%s", subscription.Namespace, subscription.Name)
+	if installPlan != nil && installPlan.Status.Phase == operatorsv1alpha1.InstallPlanPhaseFailed {
+		message := fmt.Sprintf("InstallPlan %s/%s for subscription %s failed.", installPlan.Namespace, installPlan.Name, name)
+		for _, cond := range installPlan.Status.Conditions {
+			if cond.Type == operatorsv1alpha1.InstallPlanInstalled && len(cond.Message) != 0 {
+				message = fmt.Sprintf("InstallPlan %s/%s for subscription %s failed: %s", installPlan.Namespace, installPlan.Name, name, cond.Message)
+			}
+		}
+		return gatewayAPIFailure{reason: "InstallPlanFailed", message: message}, true
+	}
+	if cond := subscription.Status.GetCondition(operatorsv1alpha1.SubscriptionInstallPlanFailed); cond.Status == corev1.ConditionTrue {
+		return gatewayAPIFailure{
+			reason:  "InstallPlanFailed",
+			message: fmt.Sprintf("Subscription %s reports InstallPlanFailed: %s: %s", name, cond.Reason, cond.Message),
+		}, true
+	}
+	return gatewayAPIFailure{}, false
+}
+
+// istiodFailure returns a failure if the given istiod deployment has had no
+// available replicas for longer than the grace period.
+func istiodFailure(deployment *appsv1.Deployment, now time.Time) (gatewayAPIFailure, bool) {
+	if deployment == nil || deployment.Status.AvailableReplicas != 0 {
+		return gatewayAPIFailure{}, false
+	}
+	since := deployment.CreationTimestamp
+	message := ""
+	for _, cond := range deployment.Status.Conditions {
+		if cond.Type == appsv1.DeploymentAvailable && cond.Status != corev1.ConditionTrue {
+			since = cond.LastTransitionTime
+			message = cond.Message
+		}
+	}
+	if !gracePeriodExpired(since, now) {
+		return gatewayAPIFailure{}, false
+	}
+	name := fmt.Sprintf("%s/%s", deployment.Namespace, deployment.Name)
+	if len(message) == 0 {
+		message = fmt.Sprintf("Deployment %s has 0 available replicas.", name)
+	} else {
+		message = fmt.Sprintf("Deployment %s has 0 available replicas: %s", name, message)
+	}
+	return gatewayAPIFailure{reason: "IstiodUnavailable", message: message}, true
+}
+
+// serviceMeshControlPlaneFailure returns a failure if the given
+// ServiceMeshControlPlane has not been ready for longer than the grace period.
+// The failure names the components that the ServiceMeshControlPlane reports as
+// unready.
+func serviceMeshControlPlaneFailure(smcp *maistrav2.ServiceMeshControlPlane, now time.Time) (gatewayAPIFailure, bool) {
+	if smcp == nil {
+		return gatewayAPIFailure{}, false
+	}
+	cond := smcp.Status.GetCondition(maistrastatus.ConditionTypeReady)
+	if cond.Status == maistrastatus.ConditionStatusTrue {
+		return gatewayAPIFailure{}, false
+	}
+	since := cond.LastTransitionTime
+	if since.IsZero() {
+		since = smcp.CreationTimestamp
+	}
+	if !gracePeriodExpired(since, now) {
+		return gatewayAPIFailure{}, false
+	}
+	name := fmt.Sprintf("%s/%s", smcp.Namespace, smcp.Name)
+	message := fmt.Sprintf("ServiceMeshControlPlane %s is not ready", name)
+	if unready := append([]string{}, smcp.Status.Readiness.Components["unready"]...); len(unready) != 0 {
+		sort.Strings(unready)
+		message = fmt.Sprintf("%s; unready components: %s", message, strings.Join(unready, ", "))
+	}
+	if len(cond.Message) != 0 {
+		message = fmt.Sprintf("%s: %s: %s", message, cond.Reason, cond.Message)
+	} else {
+		message += "."
+	}
+	return gatewayAPIFailure{reason: "ServiceMeshControlPlaneNotReady", message: message}, true
+}
+
+// gracePeriodExpired returns a Boolean value indicating whether the Gateway API
+// grace period has elapsed since the given time.
+func gracePeriodExpired(since metav1.Time, now time.Time) bool {
+	return !now.Before(since.Add(gatewayAPIDegradedGracePeriod))
+}
+
+// rollUpGatewayAPIDegradedCondition returns the operator's Degraded status
+// condition with the given GatewayAPIDegraded status condition rolled into it.
+// The operator is degraded if either the default ingresscontroller or Gateway
+// API is degraded.
+func rollUpGatewayAPIDegradedCondition(degraded, gatewayAPIDegraded configv1.ClusterOperatorStatusCondition) configv1.ClusterOperatorStatusCondition {
+	if gatewayAPIDegraded.Status != configv1.ConditionTrue {
+		return degraded
+	}
+	message := fmt.Sprintf("Gateway API is degraded: %s: %s", gatewayAPIDegraded.Reason, gatewayAPIDegraded.Message)
+	if degraded.Status == configv1.ConditionTrue {
+		degraded.Reason = "IngressAndGatewayAPIDegraded"
+		degraded.Message = fmt.Sprintf("%s  %s", degraded.Message, message)
+		return degraded
+	}
+	degraded.Status = configv1.ConditionTrue
+	degraded.Reason = "GatewayAPIDegraded"
+	degraded.Message = message
+	return degraded
+}
+
+// removeCondition removes the condition with the given type, if any, from the
+// given conditions and returns the result.
+func removeCondition(conditions []configv1.ClusterOperatorStatusCondition, conditionType configv1.ClusterStatusConditionType) []configv1.ClusterOperatorStatusCondition {
+	result := conditions[:0]
+	for _, cond := range conditions {
+		if cond.Type != conditionType {
+			result = append(result, cond)
+		}
+	}
+	return result
+}
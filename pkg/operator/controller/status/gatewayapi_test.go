@@ -0,0 +1,248 @@
+package status
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test_computeGatewayAPIDegradedCondition verifies that
+// computeGatewayAPIDegradedCondition reports a failed install plan
+// immediately, reports istiod and ServiceMeshControlPlane failures only after
+// the grace period, and names the failing component in the message.
+func Test_computeGatewayAPIDegradedCondition(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	recently := metav1.NewTime(now.Add(-time.Minute))
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+
+	subscription := func(installPlanFailed bool) *operatorsv1alpha1.Subscription {
+		sub := &operatorsv1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-operators", Name: "servicemeshoperator"},
+		}
+		if installPlanFailed {
+			sub.Status.Conditions = []operatorsv1alpha1.SubscriptionCondition{{
+				Type:    operatorsv1alpha1.SubscriptionInstallPlanFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "InstallCheckFailed",
+				Message: "install timeout",
+			}}
+		}
+		return sub
+	}
+	failedInstallPlan := &operatorsv1alpha1.InstallPlan{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-operators", Name: "install-abcde"},
+		Status: operatorsv1alpha1.InstallPlanStatus{
+			Phase: operatorsv1alpha1.InstallPlanPhaseFailed,
+			Conditions: []operatorsv1alpha1.InstallPlanCondition{{
+				Type:    operatorsv1alpha1.InstallPlanInstalled,
+				Status:  corev1.ConditionFalse,
+				Message: "bundle unpacking failed",
+			}},
+		},
+	}
+	istiod := func(available int32, since metav1.Time) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "istiod-openshift-gateway", CreationTimestamp: longAgo},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+		}
+		if available == 0 {
+			deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+				Type:               appsv1.DeploymentAvailable,
+				Status:             corev1.ConditionFalse,
+				Reason:             "MinimumReplicasUnavailable",
+				Message:            "Deployment does not have minimum availability.",
+				LastTransitionTime: since,
+			}}
+		}
+		return deployment
+	}
+	smcp := func(ready bool, since metav1.Time, unready ...string) *maistrav2.ServiceMeshControlPlane {
+		status := maistrastatus.ConditionStatusTrue
+		if !ready {
+			status = maistrastatus.ConditionStatusFalse
+		}
+		cp := &maistrav2.ServiceMeshControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-ingress", Name: "openshift-gateway", CreationTimestamp: longAgo},
+		}
+		cp.Status.Conditions = []maistrastatus.Condition{{
+			Type:               maistrastatus.ConditionTypeReady,
+			Status:             status,
+			Reason:             "ComponentsNotReady",
+			Message:            "Some components are not fully available",
+			LastTransitionTime: since,
+		}}
+		cp.Status.Readiness.Components = maistrav2.ReadinessMap{"unready": unready}
+		return cp
+	}
+
+	testCases := []struct {
+		description     string
+		state           *gatewayAPIState
+		expectCondition bool
+		expectStatus    configv1.ConditionStatus
+		expectReason    string
+		expectMessage   []string
+	}{
+		{
+			description: "gateway api disabled",
+		},
+		{
+			description: "gateway api not in use",
+			state:       &gatewayAPIState{},
+		},
+		{
+			description:     "subscription only",
+			state:           &gatewayAPIState{Subscription: subscription(false)},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionFalse,
+			expectReason:    "AsExpected",
+		},
+		{
+			description: "healthy",
+			state: &gatewayAPIState{
+				Subscription:            subscription(false),
+				IstiodDeployment:        istiod(1, longAgo),
+				ServiceMeshControlPlane: smcp(true, longAgo),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionFalse,
+			expectReason:    "AsExpected",
+		},
+		{
+			description: "failed install plan",
+			state: &gatewayAPIState{
+				Subscription: subscription(false),
+				InstallPlan:  failedInstallPlan,
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionTrue,
+			expectReason:    "InstallPlanFailed",
+			expectMessage:   []string{"openshift-operators/install-abcde", "bundle unpacking failed"},
+		},
+		{
+			description:     "subscription reports failed install plan",
+			state:           &gatewayAPIState{Subscription: subscription(true)},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionTrue,
+			expectReason:    "InstallPlanFailed",
+			expectMessage:   []string{"openshift-operators/servicemeshoperator", "install timeout"},
+		},
+		{
+			description: "istiod recently unavailable",
+			state: &gatewayAPIState{
+				Subscription:     subscription(false),
+				IstiodDeployment: istiod(0, recently),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionFalse,
+			expectReason:    "AsExpected",
+		},
+		{
+			description: "istiod unavailable",
+			state: &gatewayAPIState{
+				Subscription:     subscription(false),
+				IstiodDeployment: istiod(0, longAgo),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionTrue,
+			expectReason:    "IstiodUnavailable",
+			expectMessage:   []string{"openshift-ingress/istiod-openshift-gateway", "minimum availability"},
+		},
+		{
+			description: "smcp recently not ready",
+			state: &gatewayAPIState{
+				Subscription:            subscription(false),
+				ServiceMeshControlPlane: smcp(false, recently, "istiod"),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionFalse,
+			expectReason:    "AsExpected",
+		},
+		{
+			description: "smcp not ready",
+			state: &gatewayAPIState{
+				Subscription:            subscription(false),
+				ServiceMeshControlPlane: smcp(false, longAgo, "istiod", "gateways"),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionTrue,
+			expectReason:    "ServiceMeshControlPlaneNotReady",
+			expectMessage:   []string{"openshift-ingress/openshift-gateway", "gateways, istiod", "Some components are not fully available"},
+		},
+		{
+			description: "multiple failures",
+			state: &gatewayAPIState{
+				Subscription:            subscription(false),
+				IstiodDeployment:        istiod(0, longAgo),
+				ServiceMeshControlPlane: smcp(false, longAgo, "istiod"),
+			},
+			expectCondition: true,
+			expectStatus:    configv1.ConditionTrue,
+			expectReason:    "MultipleComponentsDegraded",
+			expectMessage:   []string{"istiod-openshift-gateway", "ServiceMeshControlPlane openshift-ingress/openshift-gateway"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			actual, ok := computeGatewayAPIDegradedCondition(tc.state, now)
+			if ok != tc.expectCondition {
+				t.Fatalf("expected condition to be %t, got %t: %+v", tc.expectCondition, ok, actual)
+			}
+			if !ok {
+				return
+			}
+			if actual.Type != GatewayAPIDegradedConditionType || actual.Status != tc.expectStatus || actual.Reason != tc.expectReason {
+				t.Errorf("expected %s=%s with reason %s, got %+v", GatewayAPIDegradedConditionType, tc.expectStatus, tc.expectReason, actual)
+			}
+			for _, s := range tc.expectMessage {
+				if !strings.Contains(actual.Message, s) {
+					t.Errorf("expected message to contain %q, got %q", s, actual.Message)
+				}
+			}
+		})
+	}
+}
+
+// Test_rollUpGatewayAPIDegradedCondition verifies that the operator reports
+// Degraded=True if Gateway API is degraded.
+func Test_rollUpGatewayAPIDegradedCondition(t *testing.T) {
+	notDegraded := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse, Reason: "IngressNotDegraded"}
+	ingressDegraded := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "IngressDegraded"}
+	gatewayAPIHealthy := configv1.ClusterOperatorStatusCondition{Type: GatewayAPIDegradedConditionType, Status: configv1.ConditionFalse}
+	gatewayAPIDegraded := configv1.ClusterOperatorStatusCondition{Type: GatewayAPIDegradedConditionType, Status: configv1.ConditionTrue, Reason: "IstiodUnavailable", Message: "istiod is down"}
+
+	testCases := []struct {
+		description  string
+		degraded     configv1.ClusterOperatorStatusCondition
+		gatewayAPI   configv1.ClusterOperatorStatusCondition
+		expectStatus configv1.ConditionStatus
+		expectReason string
+	}{
+		{"neither degraded", notDegraded, gatewayAPIHealthy, configv1.ConditionFalse, "IngressNotDegraded"},
+		{"ingress degraded", ingressDegraded, gatewayAPIHealthy, configv1.ConditionTrue, "IngressDegraded"},
+		{"gateway api degraded", notDegraded, gatewayAPIDegraded, configv1.ConditionTrue, "GatewayAPIDegraded"},
+		{"both degraded", ingressDegraded, gatewayAPIDegraded, configv1.ConditionTrue, "IngressAndGatewayAPIDegraded"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := rollUpGatewayAPIDegradedCondition(tc.degraded, tc.gatewayAPI)
+			if actual.Type != configv1.OperatorDegraded || actual.Status != tc.expectStatus || actual.Reason != tc.expectReason {
+				t.Errorf("expected Degraded=%s with reason %s, got %+v", tc.expectStatus, tc.expectReason, actual)
+			}
+			if tc.gatewayAPI.Status == configv1.ConditionTrue && !strings.Contains(actual.Message, tc.gatewayAPI.Message) {
+				t.Errorf("expected message to contain %q, got %q", tc.gatewayAPI.Message, actual.Message)
+			}
+		})
+	}
+}
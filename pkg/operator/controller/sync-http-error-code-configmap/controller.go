@@ -3,12 +3,14 @@ package sync_http_error_code_configmap
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -188,13 +190,19 @@ func (r *reconciler) hasConfigMap(o client.Object) bool {
 }
 
 // configMapChanged returns true if the name of configmap that the given
-// ingresscontroller uses for custom error pages has changed, false otherwise.
+// ingresscontroller uses for custom error pages or the keys of its error pages
+// for individual domains have changed, false otherwise.
 func (r *reconciler) configMapChanged(old, new runtime.Object) bool {
 	oldController := old.(*operatorv1.IngressController)
 	newController := new.(*operatorv1.IngressController)
 	oldName := oldController.Spec.HttpErrorCodePages.Name
 	newName := newController.Spec.HttpErrorCodePages.Name
-	return oldName != newName
+	if oldName != newName {
+		return true
+	}
+	oldPages, _ := ingresscontroller.HTTPErrorCodePagesByDomainForIngressController(oldController)
+	newPages, _ := ingresscontroller.HTTPErrorCodePagesByDomainForIngressController(newController)
+	return !reflect.DeepEqual(oldPages, newPages)
 }
 
 // Reconcile reconciles an ingresscontroller and its associated error-page
@@ -139,7 +139,7 @@ func Test_desiredHttpErrorCodeConfigMap(t *testing.T) {
 		t.Run(tc.description, func(t *testing.T) {
 			expected := tc.output.configMap
 			name := types.NamespacedName{Name: tc.inputs.configmap.Name, Namespace: tc.inputs.configmap.Namespace}
-			_, actual, err := desiredHttpErrorCodeConfigMap(true, &tc.inputs.configmap, name, deploymentRef)
+			_, actual, err := desiredHttpErrorCodeConfigMap(true, &tc.inputs.configmap, name, deploymentRef, nil)
 			if err != nil {
 				t.Fatalf("failed to get error-page configmap: %v", err)
 			}
@@ -150,3 +150,50 @@ func Test_desiredHttpErrorCodeConfigMap(t *testing.T) {
 		})
 	}
 }
+
+// Test_httpErrorCodePagesByDomain verifies that httpErrorCodePagesByDomain
+// copies valid error pages for domains from the source configmap, uses the
+// default error page for domains with an empty key, and omits domains whose
+// pages are missing or invalid, and that desiredHttpErrorCodeConfigMap adds
+// the pages and the router's map file for them to the error-page configmap.
+func Test_httpErrorCodePagesByDomain(t *testing.T) {
+	const pageA = "HTTP/1.0 503 Service Unavailable\r\nConnection: close\r\nContent-Type: text/html\r\n\r\n<html><body>A</body></html>\r\n"
+	source := newConfigMap("my-custom-error-code-pages", "openshift-config", "configMapWithCustom503")
+	source.Data["error-page-503-a.http"] = pageA
+	source.Data["error-page-503-invalid.http"] = "<html><body>Invalid</body></html>\r\n"
+	keysByDomain := map[string]string{
+		"a.example.com":       "error-page-503-a.http",
+		"b.example.com":       "",
+		"invalid.example.com": "error-page-503-invalid.http",
+		"missing.example.com": "error-page-503-missing.http",
+	}
+
+	pagesByDomain, errs := httpErrorCodePagesByDomain(&source, keysByDomain)
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors for the invalid and missing pages, got %v", errs)
+	}
+	expectedPages := map[string]string{
+		"a.example.com": pageA,
+		"b.example.com": DEFAULT_503_ERROR_PAGE,
+	}
+	if !reflect.DeepEqual(expectedPages, pagesByDomain) {
+		t.Fatalf("expected pages for domains %v, got %v", expectedPages, pagesByDomain)
+	}
+
+	name := types.NamespacedName{Name: "my-custom-error-code-pages", Namespace: "openshift-ingress"}
+	_, actual, err := desiredHttpErrorCodeConfigMap(true, &source, name, metav1.OwnerReference{}, pagesByDomain)
+	if err != nil {
+		t.Fatalf("failed to get error-page configmap: %v", err)
+	}
+	expected := map[string]string{
+		"error-page-404.http":               DEFAULT_404_ERROR_PAGE,
+		"error-page-503.http":               errorpage503,
+		"error-page-503.a.example.com.http": pageA,
+		"error-page-503.b.example.com.http": DEFAULT_503_ERROR_PAGE,
+		"error-pages-by-domain.map": "a.example.com /var/lib/haproxy/conf/error_code_pages/error-page-503.a.example.com.http\n" +
+			"b.example.com /var/lib/haproxy/conf/error_code_pages/error-page-503.b.example.com.http\n",
+	}
+	if !reflect.DeepEqual(expected, actual.Data) {
+		t.Errorf("expected configmap data:\n%v\ngot:\n%v", expected, actual.Data)
+	}
+}
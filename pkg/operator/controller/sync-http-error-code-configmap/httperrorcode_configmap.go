@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
@@ -39,7 +41,20 @@ func (r *reconciler) ensureHttpErrorCodeConfigMap(ic *operatorv1.IngressControll
 	if err != nil {
 		return false, nil, err
 	}
-	want, desired, err := desiredHttpErrorCodeConfigMap(haveSource, source, name, deploymentRef)
+	keysByDomain, err := ingresscontroller.HTTPErrorCodePagesByDomainForIngressController(ic)
+	if err != nil {
+		return have, current, err
+	}
+	var pagesByDomain map[string]string
+	if haveSource {
+		var invalid []error
+		pagesByDomain, invalid = httpErrorCodePagesByDomain(source, keysByDomain)
+		for _, err := range invalid {
+			log.Info("ignoring error page", "ingresscontroller", ic.Name, "reason", err.Error())
+			r.recorder.Eventf(ic, "Warning", "InvalidErrorPage", "Ignoring the error page for a domain: %v", err)
+		}
+	}
+	want, desired, err := desiredHttpErrorCodeConfigMap(haveSource, source, name, deploymentRef, pagesByDomain)
 	if err != nil {
 		return have, current, err
 	}
@@ -75,7 +90,9 @@ func (r *reconciler) ensureHttpErrorCodeConfigMap(ic *operatorv1.IngressControll
 // desiredHttpErrorCodeConfigMap returns the desired error-page configmap.
 // Returns a Boolean indicating whether a configmap is desired, as well as the
 // configmap if one is desired.
-func desiredHttpErrorCodeConfigMap(haveSource bool, sourceConfigmap *corev1.ConfigMap, name types.NamespacedName, deploymentRef metav1.OwnerReference) (bool, *corev1.ConfigMap, error) {
+// The configmap also has the given error pages for individual domains and the
+// router's map file for them, if there are any.
+func desiredHttpErrorCodeConfigMap(haveSource bool, sourceConfigmap *corev1.ConfigMap, name types.NamespacedName, deploymentRef metav1.OwnerReference, pagesByDomain map[string]string) (bool, *corev1.ConfigMap, error) {
 	if !haveSource {
 		return false, nil, nil
 	}
@@ -96,10 +113,55 @@ func desiredHttpErrorCodeConfigMap(haveSource bool, sourceConfigmap *corev1.Conf
 	} else {
 		cm.Data["error-page-404.http"] = DEFAULT_404_ERROR_PAGE
 	}
+	if len(pagesByDomain) != 0 {
+		domains := make([]string, 0, len(pagesByDomain))
+		for domain, page := range pagesByDomain {
+			cm.Data[ingresscontroller.HTTPErrorCodePageKeyForDomain(domain)] = page
+			domains = append(domains, domain)
+		}
+		cm.Data[ingresscontroller.HTTPErrorCodePagesByDomainMapKey] = ingresscontroller.HTTPErrorCodePagesByDomainMap(domains)
+	}
 	cm.SetOwnerReferences([]metav1.OwnerReference{deploymentRef})
 	return true, &cm, nil
 }
 
+// httpErrorCodePagesByDomain returns the error pages for the domains in the
+// given map of domains to keys in the given source configmap, indexed by
+// domain.  A domain with an empty key gets the default error page.  Domains
+// whose pages are missing or invalid are omitted so that the router serves the
+// ingresscontroller's custom or default error page for them, and an error is
+// returned for each of them.
+func httpErrorCodePagesByDomain(source *corev1.ConfigMap, keysByDomain map[string]string) (map[string]string, []error) {
+	if len(keysByDomain) == 0 {
+		return nil, nil
+	}
+	domains := make([]string, 0, len(keysByDomain))
+	for domain := range keysByDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	pages := map[string]string{}
+	var errs []error
+	for _, domain := range domains {
+		key := keysByDomain[domain]
+		if len(key) == 0 {
+			pages[domain] = DEFAULT_503_ERROR_PAGE
+			continue
+		}
+		page, ok := source.Data[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("configmap %s/%s has no key %q for domain %q", source.Namespace, source.Name, key, domain))
+			continue
+		}
+		if err := ingresscontroller.ValidateHTTPErrorCodePage(page); err != nil {
+			errs = append(errs, fmt.Errorf("key %q of configmap %s/%s for domain %q: %w", key, source.Namespace, source.Name, domain, err))
+			continue
+		}
+		pages[domain] = page
+	}
+	return pages, errs
+}
+
 // currentHttpErrorCodeConfigMap returns the current configmap.  Returns a
 // Boolean indicating whether the configmap existed, the configmap if it did
 // exist, and an error value.
@@ -32,14 +32,24 @@ import (
 	clientcacontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/clientca-configmap"
 	configurableroutecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/configurable-route"
 	crlcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/crl"
+	defaultcertdependentscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/default-cert-dependents"
+	defaultcertsourcecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/default-cert-source"
 	dnscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/dns"
+	externalresolutionprobecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/external-resolution-probe"
+	gatewaycertificatecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-certificate"
+	gatewaymonitoringcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-monitoring"
 	gatewayservicednscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-service-dns"
 	gatewayapicontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayapi"
 	gatewayclasscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
 	ingress "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+	ingressconformancecontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress-conformance"
 	ingressclasscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingressclass"
+	orphancleanupcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/orphan-cleanup"
 	statuscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/status"
+	statussummarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/status-summary"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/cachefreshness"
+	ingresscontrollerutil "github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
 	"github.com/openshift/library-go/pkg/operator/events"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -136,10 +146,16 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 	ingressControllerLBSubnetsAWSEnabled := featureGates.Enabled(features.FeatureGateIngressControllerLBSubnetsAWS)
 	ingressControllerEIPAllocationsAWSEnabled := featureGates.Enabled(features.FeatureGateSetEIPForNLBIngressController)
 
+	// Track failed list and watch calls so that controllers can defer
+	// destructive actions while the cache might be stale, for example
+	// during a disruption of the API server.
+	cacheFreshness := cachefreshness.NewTracker(cachefreshness.DefaultWindow)
+
 	// Set up an operator manager for the operator namespace.
 	mgr, err := manager.New(kubeConfig, manager.Options{
 		Scheme: scheme,
 		Cache: cache.Options{
+			DefaultWatchErrorHandler: cacheFreshness.WatchErrorHandler,
 			DefaultNamespaces: map[string]cache.Config{
 				config.Namespace: {},
 				operatorcontroller.GlobalUserSpecifiedConfigNamespace:    {},
@@ -206,10 +222,19 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 		IngressControllerImage: config.IngressControllerImage,
 		CanaryImage:            config.CanaryImage,
 		OperatorReleaseVersion: config.OperatorReleaseVersion,
+		OperandNamespace:       operatorcontroller.DefaultOperandNamespace,
+		GatewayAPIEnabled:      gatewayAPIEnabled,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to create status controller: %v", err)
 	}
 
+	// Set up the status summary controller.
+	if _, err := statussummarycontroller.New(mgr, statussummarycontroller.Config{
+		Namespace: config.Namespace,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create status summary controller: %v", err)
+	}
+
 	// Set up the certificate controller
 	if _, err := certcontroller.New(mgr, config.Namespace); err != nil {
 		return nil, fmt.Errorf("failed to create cacert controller: %v", err)
@@ -249,9 +274,13 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 			config.Namespace,
 			operatorcontroller.DefaultOperandNamespace,
 		},
-		OperatorReleaseVersion:       config.OperatorReleaseVersion,
-		AzureWorkloadIdentityEnabled: azureWorkloadIdentityEnabled,
-		PrivateHostedZoneAWSEnabled:  sharedVPCEnabled,
+		OperatorReleaseVersion:         config.OperatorReleaseVersion,
+		AzureWorkloadIdentityEnabled:   azureWorkloadIdentityEnabled,
+		PrivateHostedZoneAWSEnabled:    sharedVPCEnabled,
+		DNSCleanupMaxAttempts:          config.DNSCleanupMaxAttempts,
+		DNSCleanupTimeout:              config.DNSCleanupTimeout,
+		CacheFreshness:                 cacheFreshness,
+		DomainDelegationCheckResolvers: config.DomainDelegationCheckResolvers,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to create dns controller: %v", err)
 	}
@@ -263,6 +292,13 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 		return nil, fmt.Errorf("failed to create ingressclass controller: %w", err)
 	}
 
+	// Set up the ingress conformance controller.
+	if _, err := ingressconformancecontroller.New(mgr, ingressconformancecontroller.Config{
+		OperatorReleaseVersion: config.OperatorReleaseVersion,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create ingress conformance controller: %w", err)
+	}
+
 	// Set up the canary controller when the config.CanaryImage is not empty
 	// Canary can be disabled when running the operator locally.
 	if len(config.CanaryImage) != 0 {
@@ -276,10 +312,42 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 	}
 
 	// Set up the route metrics controller.
-	if _, err := routemetricscontroller.New(mgr, config.Namespace); err != nil {
+	if _, err := routemetricscontroller.New(mgr, config.Namespace, config.OperatorReleaseVersion); err != nil {
 		return nil, fmt.Errorf("failed to create route metrics controller: %w", err)
 	}
 
+	// Set up the orphan cleanup controller.
+	if _, err := orphancleanupcontroller.New(mgr, orphancleanupcontroller.Config{
+		OperatorNamespace: config.Namespace,
+		OperandNamespace:  operatorcontroller.DefaultOperandNamespace,
+		GracePeriod:       config.OrphanCleanupGracePeriod,
+		DryRun:            config.OrphanCleanupDryRun,
+		CacheFreshness:    cacheFreshness,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create orphan cleanup controller: %w", err)
+	}
+
+	// Set up the external resolution probe controller.
+	if _, err := externalresolutionprobecontroller.New(mgr); err != nil {
+		return nil, fmt.Errorf("failed to create external resolution probe controller: %w", err)
+	}
+
+	// Set up the default certificate dependents controller.
+	if _, err := defaultcertdependentscontroller.New(mgr, defaultcertdependentscontroller.Config{
+		OperatorNamespace: config.Namespace,
+		OperandNamespace:  operatorcontroller.DefaultOperandNamespace,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create default certificate dependents controller: %w", err)
+	}
+
+	// Set up the default certificate source controller.
+	if _, err := defaultcertsourcecontroller.New(mgr, defaultcertsourcecontroller.Config{
+		OperatorNamespace: config.Namespace,
+		OperandNamespace:  operatorcontroller.DefaultOperandNamespace,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create default certificate source controller: %w", err)
+	}
+
 	// Set up the route monitoring dashboard controller.
 	if _, err := monitoringdashboard.New(mgr); err != nil {
 		return nil, fmt.Errorf("failed to create monitoring dashboard controller: %w", err)
@@ -301,17 +369,41 @@ func New(config operatorconfig.Config, kubeConfig *rest.Config) (*Operator, erro
 	// Gateway API CRDs.
 	gatewayServiceDNSController, err := gatewayservicednscontroller.NewUnmanaged(mgr, gatewayservicednscontroller.Config{
 		OperandNamespace: operatorcontroller.DefaultOperandNamespace,
+		CacheFreshness:   cacheFreshness,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gateway-service-dns controller: %v", err)
 	}
 
+	// Set up the gateway certificate controller.  This controller is
+	// unmanaged by the manager; the gatewayapi controller starts it after it
+	// creates the Gateway API CRDs.
+	gatewayCertificateController, err := gatewaycertificatecontroller.NewUnmanaged(mgr, gatewaycertificatecontroller.Config{
+		OperatorNamespace: config.Namespace,
+		OperandNamespace:  operatorcontroller.DefaultOperandNamespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gateway certificate controller: %w", err)
+	}
+
+	// Set up the gateway monitoring controller.  This controller is
+	// unmanaged by the manager; the gatewayapi controller starts it after it
+	// creates the Gateway API CRDs.
+	gatewayMonitoringController, err := gatewaymonitoringcontroller.NewUnmanaged(mgr, gatewaymonitoringcontroller.Config{
+		OperandNamespace: operatorcontroller.DefaultOperandNamespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gateway monitoring controller: %w", err)
+	}
+
 	// Set up the gatewayapi controller.
 	if _, err := gatewayapicontroller.New(mgr, gatewayapicontroller.Config{
 		GatewayAPIEnabled: gatewayAPIEnabled,
 		DependentControllers: []controller.Controller{
 			gatewayClassController,
 			gatewayServiceDNSController,
+			gatewayCertificateController,
+			gatewayMonitoringController,
 		},
 	}); err != nil {
 		return nil, fmt.Errorf("failed to create gatewayapi controller: %w", err)
@@ -340,22 +432,20 @@ func (o *Operator) Start(ctx context.Context) error {
 		log.Info("skipping default ingress controller creation")
 	} else {
 		// Periodicaly ensure the default controller exists.
-		go wait.Until(func() {
+		go untilWithBackoff(ctx, defaultIngressControllerSyncPeriod, apiErrorBackoff, func() error {
 			if !o.manager.GetCache().WaitForCacheSync(ctx) {
-				log.Error(nil, "failed to sync cache before ensuring default ingresscontroller")
-				return
+				return fmt.Errorf("failed to sync cache before ensuring default ingresscontroller")
 			}
 			ingressConfigName := operatorcontroller.IngressClusterConfigName()
 			ingressConfig := &configv1.Ingress{}
 			if err := o.client.Get(context.TODO(), ingressConfigName, ingressConfig); err != nil {
-				log.Error(err, "failed to fetch ingress config")
-				return
+				return fmt.Errorf("failed to fetch ingress config: %w", err)
 			}
-			err := o.ensureDefaultIngressController(infraConfig, ingressConfig)
-			if err != nil {
-				log.Error(err, "failed to ensure default ingresscontroller")
+			if err := o.ensureDefaultIngressController(infraConfig, ingressConfig); err != nil {
+				return fmt.Errorf("failed to ensure default ingresscontroller: %w", err)
 			}
-		}, 1*time.Minute, ctx.Done())
+			return nil
+		})
 
 	}
 
@@ -377,6 +467,50 @@ func (o *Operator) Start(ctx context.Context) error {
 	}
 }
 
+// defaultIngressControllerSyncPeriod is how often the operator ensures that
+// the default ingresscontroller exists.
+const defaultIngressControllerSyncPeriod = 1 * time.Minute
+
+// apiErrorBackoff is the backoff for retrying a periodic sync that failed,
+// typically because the API server is unavailable.  A failed sync is retried
+// no sooner than the sync period so that an unavailable API server gets fewer
+// requests from the operator, not more, and the delay grows while the API
+// server remains unavailable.  The jitter keeps the operator's retries from
+// arriving in lockstep with those of other clients once the API server
+// recovers.
+var apiErrorBackoff = wait.Backoff{
+	Duration: defaultIngressControllerSyncPeriod,
+	Factor:   2.0,
+	Jitter:   0.5,
+	Steps:    10,
+	Cap:      10 * time.Minute,
+}
+
+// untilWithBackoff calls f every period, with jitter, until the context is
+// done.  If f returns an error, untilWithBackoff logs the error and retries
+// using the given backoff, which is reset once f succeeds.  A retry is never
+// scheduled sooner than the period.
+func untilWithBackoff(ctx context.Context, period time.Duration, backoff wait.Backoff, f func() error) {
+	if backoff.Duration < period {
+		backoff.Duration = period
+	}
+	retry := backoff
+	for {
+		delay := wait.Jitter(period, 0.1)
+		if err := f(); err != nil {
+			delay = retry.Step()
+			log.Error(err, "periodic sync failed; will retry", "after", delay.Round(time.Millisecond))
+		} else {
+			retry = backoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
 // handleSingleNode4Dot11Upgrade sets the defaultPlacement status in the
 // ingress config CR of none-platform single node clusters to "ControlPlane" if
 // it's not already set. The situations in which this value is not set are in
@@ -448,7 +582,10 @@ func (o *Operator) handleSingleNode4Dot11Upgrade() error {
 }
 
 // ensureDefaultIngressController creates the default ingresscontroller if it
-// doesn't already exist.
+// doesn't already exist.  If the operator has a snapshot of the spec of a
+// previous default ingresscontroller, the new ingresscontroller gets that spec
+// and an annotation that records the restoration.  Otherwise, it gets the
+// default spec.
 func (o *Operator) ensureDefaultIngressController(infraConfig *configv1.Infrastructure, ingressConfig *configv1.Ingress) error {
 	name := types.NamespacedName{Namespace: o.namespace, Name: manifests.DefaultIngressControllerName}
 	ic := &operatorv1.IngressController{}
@@ -464,6 +601,33 @@ func (o *Operator) ensureDefaultIngressController(infraConfig *configv1.Infrastr
 	// https://github.com/kubernetes/kubernetes/pull/75210
 	replicas := ingress.DetermineReplicas(ingressConfig, infraConfig)
 
+	snapshot, err := ingress.DefaultIngressControllerSpecFromSnapshot(context.TODO(), o.client, o.namespace)
+	if err != nil {
+		// A corrupt snapshot must not keep the default
+		// ingresscontroller from being recreated.
+		log.Error(err, "failed to read snapshot of default ingresscontroller spec; using the default spec")
+	}
+	if snapshot != nil {
+		ic = &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.Name,
+				Namespace: name.Namespace,
+				Annotations: map[string]string{
+					ingresscontrollerutil.RestoredFromSnapshotAnnotation: time.Now().UTC().Format(time.RFC3339),
+				},
+			},
+			Spec: *snapshot,
+		}
+		if ic.Spec.Replicas == nil {
+			ic.Spec.Replicas = &replicas
+		}
+		if err := o.client.Create(context.TODO(), ic); err != nil {
+			return err
+		}
+		log.Info("recreated default ingresscontroller from snapshot", "namespace", ic.Namespace, "name", ic.Name)
+		return nil
+	}
+
 	ic = &operatorv1.IngressController{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name.Name,
@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	ingress "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+	ingresscontrollerutil "github.com/openshift/cluster-ingress-operator/pkg/util/ingresscontroller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Test_ensureDefaultIngressController verifies that
+// ensureDefaultIngressController recreates a deleted default ingresscontroller
+// with the spec from the snapshot of the previous default ingresscontroller,
+// and with the default spec if there is no snapshot.
+func Test_ensureDefaultIngressController(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	name := types.NamespacedName{Namespace: namespace, Name: "default"}
+	infraConfig := &configv1.Infrastructure{
+		Status: configv1.InfrastructureStatus{InfrastructureTopology: configv1.HighlyAvailableTopologyMode},
+	}
+	ingressConfig := &configv1.Ingress{}
+	two, five := int32(2), int32(5)
+	tunedSpec := operatorv1.IngressControllerSpec{
+		Replicas: &five,
+		NodePlacement: &operatorv1.NodePlacement{
+			NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/infra": ""}},
+		},
+		TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+		EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+			Type: operatorv1.LoadBalancerServiceStrategyType,
+			LoadBalancer: &operatorv1.LoadBalancerStrategy{
+				Scope:               operatorv1.InternalLoadBalancer,
+				DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		snapshot       bool
+		optOut         bool
+		expectSpec     operatorv1.IngressControllerSpec
+		expectRestored bool
+	}{
+		{
+			name:           "restored from snapshot",
+			snapshot:       true,
+			expectSpec:     tunedSpec,
+			expectRestored: true,
+		},
+		{
+			name:       "opted out",
+			snapshot:   true,
+			optOut:     true,
+			expectSpec: operatorv1.IngressControllerSpec{Replicas: &two},
+		},
+		{
+			name:       "no snapshot",
+			expectSpec: operatorv1.IngressControllerSpec{Replicas: &two},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			corev1.AddToScheme(scheme)
+			operatorv1.Install(scheme)
+			cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+			o := &Operator{client: cl, namespace: namespace}
+			ctx := context.Background()
+
+			if tc.snapshot {
+				// Reconcile a tuned default ingresscontroller
+				// and then delete it.
+				ic := &operatorv1.IngressController{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "default"},
+					Spec:       *tunedSpec.DeepCopy(),
+				}
+				if tc.optOut {
+					ic.Annotations = map[string]string{ingresscontrollerutil.PreserveSpecOnRecreationAnnotation: "false"}
+				}
+				if err := cl.Create(ctx, ic); err != nil {
+					t.Fatalf("failed to create ingresscontroller: %v", err)
+				}
+				if err := ingress.SnapshotDefaultIngressControllerSpec(ctx, cl, ic); err != nil {
+					t.Fatalf("failed to snapshot ingresscontroller spec: %v", err)
+				}
+				if err := cl.Delete(ctx, ic); err != nil {
+					t.Fatalf("failed to delete ingresscontroller: %v", err)
+				}
+			}
+
+			if err := o.ensureDefaultIngressController(infraConfig, ingressConfig); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			recreated := &operatorv1.IngressController{}
+			if err := cl.Get(ctx, name, recreated); err != nil {
+				t.Fatalf("failed to get recreated ingresscontroller: %v", err)
+			}
+			if !reflect.DeepEqual(recreated.Spec, tc.expectSpec) {
+				t.Errorf("expected spec %+v, got %+v", tc.expectSpec, recreated.Spec)
+			}
+			_, restored := recreated.Annotations[ingresscontrollerutil.RestoredFromSnapshotAnnotation]
+			if restored != tc.expectRestored {
+				t.Errorf("expected %s annotation to be present: %t, got annotations %v", ingresscontrollerutil.RestoredFromSnapshotAnnotation, tc.expectRestored, recreated.Annotations)
+			}
+		})
+	}
+}
+
+// Test_apiErrorBackoff verifies that a failed periodic sync is never retried
+// sooner than the sync period and that the retry delay grows up to the cap.
+func Test_apiErrorBackoff(t *testing.T) {
+	backoff := apiErrorBackoff
+	maxDelay := time.Duration(float64(backoff.Cap) * (1 + backoff.Jitter))
+	var last time.Duration
+	for i := 0; i < 20; i++ {
+		delay := backoff.Step()
+		if delay < defaultIngressControllerSyncPeriod {
+			t.Fatalf("retry %d: expected a delay of at least %v, got %v", i, defaultIngressControllerSyncPeriod, delay)
+		}
+		if delay > maxDelay {
+			t.Fatalf("retry %d: expected a delay of at most %v, got %v", i, maxDelay, delay)
+		}
+		last = delay
+	}
+	if last < backoff.Cap {
+		t.Errorf("expected the delay to reach the cap of %v, got %v", backoff.Cap, last)
+	}
+}
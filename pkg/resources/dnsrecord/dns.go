@@ -32,14 +32,76 @@ var log = logf.Logger.WithName("dnsrecord")
 // [1] https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/resource-record-sets-choosing-alias-non-alias.html
 const defaultRecordTTL int64 = 30
 
+const (
+	// DNSDualStackAnnotation is an annotation that the operator sets on a
+	// DNSRecord, with the value "true", if the record's target is a
+	// dual-stack load balancer.  DNS providers that publish alias records
+	// use it to publish AAAA records alongside A records, and DNS providers
+	// that publish A records for IP address targets use it to publish the
+	// IPv6 targets as AAAA records.
+	DNSDualStackAnnotation = "ingress.operator.openshift.io/dns-dualstack"
+
+	// DNSDualStackPublishedAnnotation is an annotation that the DNS
+	// controller sets on a DNSRecord, with the value "true", once it has
+	// published the record with the DNSDualStackAnnotation annotation, and
+	// removes once it has published the record without it.  DNS providers
+	// use it to delete AAAA records that are no longer needed.
+	DNSDualStackPublishedAnnotation = "ingress.operator.openshift.io/dns-dualstack-published"
+
+	// DNSSkipZoneDelegationCheckAnnotation is an annotation that a cluster
+	// administrator can set on a DNSRecord, with the value "true", to make
+	// the DNS controller publish the record to the configured zones even
+	// if a zone delegates the subzone that contains the record's name to
+	// other name servers.
+	DNSSkipZoneDelegationCheckAnnotation = "ingress.operator.openshift.io/skip-zone-delegation-check"
+
+	// DNSRecheckDomainDelegationAnnotation is an annotation that a cluster
+	// administrator can set on a DNSRecord, with any value, to make the
+	// DNS controller check again whether the public zone's parent domain
+	// delegates the zone to the zone's name servers.  The DNS controller
+	// removes the annotation once it has performed the check.
+	DNSRecheckDomainDelegationAnnotation = "ingress.operator.openshift.io/recheck-domain-delegation"
+
+	// DNSDomainDelegationSuspectConditionType is the type of the condition
+	// that the DNS controller sets in a DNSRecord's status for the public
+	// zone to report whether the zone appears not to be delegated to its
+	// name servers, in which case the record does not resolve publicly
+	// even though it is published.
+	DNSDomainDelegationSuspectConditionType = "DomainDelegationSuspect"
+
+	// AWSLBIPAddressTypeAnnotation is the annotation on a LoadBalancer-type
+	// service that specifies the IP address type of an AWS network load
+	// balancer.
+	AWSLBIPAddressTypeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-ip-address-type"
+	// AWSLBIPAddressTypeDualStack is the AWSLBIPAddressTypeAnnotation value
+	// for a load balancer that has both IPv4 and IPv6 addresses.
+	AWSLBIPAddressTypeDualStack = "dualstack"
+	// AWSLBIPAddressTypeIPv4 is the AWSLBIPAddressTypeAnnotation value for
+	// a load balancer that only has IPv4 addresses.
+	AWSLBIPAddressTypeIPv4 = "ipv4"
+)
+
+// managedDNSRecordAnnotations is the set of annotations on a DNSRecord that
+// the operator manages.  Other annotations, which DNS providers may use to
+// store provider-specific state, are preserved.
+var managedDNSRecordAnnotations = []string{
+	DNSTargetSelectionAnnotation,
+	DNSDualStackAnnotation,
+	DNSZoneTargetsAnnotation,
+}
+
 // EnsureWildcardDNSRecord will create wildcard DNS records for the given LB
-// service.  If service is nil (haveLBS is false), nothing is done.
-func EnsureWildcardDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, endpointPublishingStrategy *operatorv1.EndpointPublishingStrategy, service *corev1.Service, haveLBS bool) (bool, *iov1.DNSRecord, error) {
+// service.  If service is nil (haveLBS is false), nothing is done.  The
+// preference determines the record's target when the service has both
+// hostname and IP load-balancer ingress entries.  If zoneTargets is not nil,
+// it specifies targets for the public or private zone that override the
+// service's targets.
+func EnsureWildcardDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, endpointPublishingStrategy *operatorv1.EndpointPublishingStrategy, service *corev1.Service, haveLBS bool, preference TargetPreference, zoneTargets *ZoneTargets) (bool, *iov1.DNSRecord, error) {
 	if !haveLBS {
 		return false, nil, nil
 	}
 
-	wantWC, desired := desiredWildcardDNSRecord(name, dnsRecordLabels, ownerRef, domain, endpointPublishingStrategy, service)
+	wantWC, desired := desiredWildcardDNSRecord(name, dnsRecordLabels, ownerRef, domain, endpointPublishingStrategy, service, preference, zoneTargets)
 	haveWC, current, err := CurrentDNSRecord(client, name)
 	if err != nil {
 		return false, nil, err
@@ -64,9 +126,11 @@ func EnsureWildcardDNSRecord(client client.Client, name types.NamespacedName, dn
 }
 
 // EnsureDNSRecord will create DNS records for the given LB service.  If service
-// is nil (haveLBS is false), nothing is done.
-func EnsureDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, dnsPolicy iov1.DNSManagementPolicy, service *corev1.Service) (bool, *iov1.DNSRecord, error) {
-	wantWC, desired := desiredDNSRecord(name, dnsRecordLabels, ownerRef, domain, dnsPolicy, service)
+// is nil (haveLBS is false), nothing is done.  The preference determines the
+// record's target when the service has both hostname and IP load-balancer
+// ingress entries.
+func EnsureDNSRecord(client client.Client, name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, dnsPolicy iov1.DNSManagementPolicy, service *corev1.Service, preference TargetPreference) (bool, *iov1.DNSRecord, error) {
+	wantWC, desired := desiredDNSRecord(name, dnsRecordLabels, ownerRef, domain, dnsPolicy, service, preference)
 	haveWC, current, err := CurrentDNSRecord(client, name)
 	if err != nil {
 		return false, nil, err
@@ -91,8 +155,9 @@ func EnsureDNSRecord(client client.Client, name types.NamespacedName, dnsRecordL
 }
 
 // desiredWildcardDNSRecord will return any necessary wildcard DNS records for the
-// given service.
-func desiredWildcardDNSRecord(name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, dnsDomain string, endpointPublishingStrategy *operatorv1.EndpointPublishingStrategy, service *corev1.Service) (bool, *iov1.DNSRecord) {
+// given service.  If zoneTargets specifies targets for the public or private
+// zone, the record has the DNSZoneTargetsAnnotation annotation.
+func desiredWildcardDNSRecord(name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, dnsDomain string, endpointPublishingStrategy *operatorv1.EndpointPublishingStrategy, service *corev1.Service, preference TargetPreference, zoneTargets *ZoneTargets) (bool, *iov1.DNSRecord) {
 	// If the ingresscontroller has no ingress domain, we cannot configure any
 	// DNS records.
 	if len(dnsDomain) == 0 {
@@ -115,41 +180,37 @@ func desiredWildcardDNSRecord(name types.NamespacedName, dnsRecordLabels map[str
 		dnsPolicy = iov1.UnmanagedDNS
 	}
 
-	return desiredDNSRecord(name, dnsRecordLabels, ownerRef, domain, dnsPolicy, service)
+	want, desired := desiredDNSRecord(name, dnsRecordLabels, ownerRef, domain, dnsPolicy, service, preference)
+	if want {
+		if value := annotationForZoneTargets(zoneTargets); len(value) != 0 {
+			desired.Annotations[DNSZoneTargetsAnnotation] = value
+		}
+	}
+	return want, desired
 }
 
 // desiredDNSRecord will return any necessary DNS records for the given domain
-// and service.
-//
-// For now, if the service has more than one .status.loadbalancer.ingress, only
-// the first will be used.
+// and service.  The record's target is selected from the service's
+// .status.loadBalancer.ingress using selectDNSTargets with the given
+// preference, and the selection is recorded in the record's
+// DNSTargetSelectionAnnotation annotation.  If the service is a dual-stack load
+// balancer, the record has the DNSDualStackAnnotation annotation.
 //
 // TODO: If .status.loadbalancer.ingress is processed once as non-empty and then
 // later becomes empty, what should we do? Currently we'll treat it as an intent
 // to not have a desired record.
-func desiredDNSRecord(name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, dnsPolicy iov1.DNSManagementPolicy, service *corev1.Service) (bool, *iov1.DNSRecord) {
+func desiredDNSRecord(name types.NamespacedName, dnsRecordLabels map[string]string, ownerRef metav1.OwnerReference, domain string, dnsPolicy iov1.DNSManagementPolicy, service *corev1.Service, preference TargetPreference) (bool, *iov1.DNSRecord) {
+	recordType, targets, selection := selectDNSTargets(service.Status.LoadBalancer.Ingress, preference)
 	// No LB target exists for the domain record to point at.
-	if len(service.Status.LoadBalancer.Ingress) == 0 {
+	if len(targets) == 0 {
 		return false, nil
 	}
 
-	ingress := service.Status.LoadBalancer.Ingress[0]
-
-	// Quick sanity check since we don't know how to handle both being set (is
-	// that even a valid state?)
-	if len(ingress.Hostname) > 0 && len(ingress.IP) > 0 {
-		return false, nil
+	annotations := map[string]string{
+		DNSTargetSelectionAnnotation: selection,
 	}
-
-	var target string
-	var recordType iov1.DNSRecordType
-
-	if len(ingress.Hostname) > 0 {
-		recordType = iov1.CNAMERecordType
-		target = ingress.Hostname
-	} else {
-		recordType = iov1.ARecordType
-		target = ingress.IP
+	if serviceIsDualStack(service) {
+		annotations[DNSDualStackAnnotation] = "true"
 	}
 
 	return true, &iov1.DNSRecord{
@@ -157,19 +218,40 @@ func desiredDNSRecord(name types.NamespacedName, dnsRecordLabels map[string]stri
 			Namespace:       name.Namespace,
 			Name:            name.Name,
 			Labels:          dnsRecordLabels,
+			Annotations:     annotations,
 			OwnerReferences: []metav1.OwnerReference{ownerRef},
 			Finalizers:      []string{manifests.DNSRecordFinalizer},
 		},
 		Spec: iov1.DNSRecordSpec{
 			DNSName:             domain,
 			DNSManagementPolicy: dnsPolicy,
-			Targets:             []string{target},
+			Targets:             targets,
 			RecordType:          recordType,
 			RecordTTL:           defaultRecordTTL,
 		},
 	}
 }
 
+// DualStackPublishPending returns a Boolean value indicating whether the given
+// DNSRecord's DNSDualStackAnnotation annotation has changed since the DNS
+// controller last published the record.
+func DualStackPublishPending(record *iov1.DNSRecord) bool {
+	return record.Annotations[DNSDualStackAnnotation] != record.Annotations[DNSDualStackPublishedAnnotation]
+}
+
+// serviceIsDualStack returns a Boolean value indicating whether the given
+// service requests a dual-stack load balancer, either using the AWS IP address
+// type annotation or using a dual-stack IP family policy.
+func serviceIsDualStack(service *corev1.Service) bool {
+	if policy := service.Spec.IPFamilyPolicy; policy != nil {
+		switch *policy {
+		case corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack:
+			return true
+		}
+	}
+	return strings.EqualFold(service.Annotations[AWSLBIPAddressTypeAnnotation], AWSLBIPAddressTypeDualStack)
+}
+
 func CurrentDNSRecord(client client.Client, name types.NamespacedName) (bool, *iov1.DNSRecord, error) {
 	current := &iov1.DNSRecord{}
 	err := client.Get(context.TODO(), name, current)
@@ -212,15 +294,34 @@ func updateDNSRecord(client client.Client, current, desired *iov1.DNSRecord) (bo
 	return true, nil
 }
 
-// dnsRecordChanged checks if the current DNSRecord spec matches the expected spec and
-// if not returns an updated one.
+// dnsRecordChanged checks if the current DNSRecord spec and managed annotations
+// match the expected ones and if not returns an updated DNSRecord.  Other
+// annotations, which DNS providers may use to store provider-specific state,
+// are preserved.
 func dnsRecordChanged(current, expected *iov1.DNSRecord) (bool, *iov1.DNSRecord) {
-	if cmp.Equal(current.Spec, expected.Spec, cmpopts.EquateEmpty()) {
+	annotationsChanged := false
+	for _, key := range managedDNSRecordAnnotations {
+		if current.Annotations[key] != expected.Annotations[key] {
+			annotationsChanged = true
+			break
+		}
+	}
+	if cmp.Equal(current.Spec, expected.Spec, cmpopts.EquateEmpty()) && !annotationsChanged {
 		return false, nil
 	}
 
 	updated := current.DeepCopy()
 	updated.Spec = expected.Spec
+	for _, key := range managedDNSRecordAnnotations {
+		if v := expected.Annotations[key]; len(v) != 0 {
+			if updated.Annotations == nil {
+				updated.Annotations = map[string]string{}
+			}
+			updated.Annotations[key] = v
+		} else {
+			delete(updated.Annotations, key)
+		}
+	}
 	return true, updated
 }
 
@@ -242,6 +343,8 @@ func ManageDNSForDomain(domain string, status *configv1.PlatformStatus, dnsConfi
 	if strings.HasSuffix(domain, ".") {
 		domain = domain[:len(domain)-1]
 	}
+	// DNS names are case-insensitive.
+	mustContain, domain = strings.ToLower(mustContain), strings.ToLower(domain)
 
 	switch status.Type {
 	case configv1.AWSPlatformType, configv1.GCPPlatformType:
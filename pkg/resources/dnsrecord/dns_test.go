@@ -146,7 +146,7 @@ func Test_desiredWildcardDNSRecord(t *testing.T) {
 				service.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, ingress)
 			}
 
-			haveWC, actual := desiredWildcardDNSRecord(name, labels, icRef, test.domain, &test.publish, service)
+			haveWC, actual := desiredWildcardDNSRecord(name, labels, icRef, test.domain, &test.publish, service, PreferHostname, nil)
 			switch {
 			case test.expect != nil && haveWC:
 				if !cmp.Equal(actual.Spec, *test.expect) {
@@ -161,6 +161,192 @@ func Test_desiredWildcardDNSRecord(t *testing.T) {
 	}
 }
 
+// Test_selectDNSTargets verifies that selectDNSTargets selects the expected
+// record type and targets for various load-balancer ingress statuses and target
+// preferences.
+func Test_selectDNSTargets(t *testing.T) {
+	tests := []struct {
+		name         string
+		ingresses    []corev1.LoadBalancerIngress
+		preference   TargetPreference
+		expectType   iov1.DNSRecordType
+		expectTarget []string
+	}{
+		{
+			name:       "no ingresses",
+			preference: PreferHostname,
+		},
+		{
+			name:       "empty ingress",
+			ingresses:  []corev1.LoadBalancerIngress{{}},
+			preference: PreferHostname,
+		},
+		{
+			name:         "hostname only",
+			ingresses:    []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com"}},
+			preference:   PreferHostname,
+			expectType:   iov1.CNAMERecordType,
+			expectTarget: []string{"lb.cloud.example.com"},
+		},
+		{
+			name:         "hostname only, prefer IP",
+			ingresses:    []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com"}},
+			preference:   PreferIP,
+			expectType:   iov1.CNAMERecordType,
+			expectTarget: []string{"lb.cloud.example.com"},
+		},
+		{
+			name:         "IP only",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}},
+			preference:   PreferHostname,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"192.0.2.1"},
+		},
+		{
+			name:         "hostname and IP in the same entry",
+			ingresses:    []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com", IP: "192.0.2.1"}},
+			preference:   PreferHostname,
+			expectType:   iov1.CNAMERecordType,
+			expectTarget: []string{"lb.cloud.example.com"},
+		},
+		{
+			name:         "IP before hostname",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}, {Hostname: "lb.cloud.example.com"}},
+			preference:   PreferHostname,
+			expectType:   iov1.CNAMERecordType,
+			expectTarget: []string{"lb.cloud.example.com"},
+		},
+		{
+			name:         "hostname and IP, prefer IP",
+			ingresses:    []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com"}, {IP: "192.0.2.1"}},
+			preference:   PreferIP,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"192.0.2.1"},
+		},
+		{
+			name:         "multiple hostnames",
+			ingresses:    []corev1.LoadBalancerIngress{{Hostname: "b.cloud.example.com"}, {Hostname: "a.cloud.example.com"}},
+			preference:   PreferHostname,
+			expectType:   iov1.CNAMERecordType,
+			expectTarget: []string{"a.cloud.example.com"},
+		},
+		{
+			name:         "multiple IPs",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "192.0.2.3"}, {IP: "192.0.2.1"}, {IP: "192.0.2.2"}, {IP: "192.0.2.1"}},
+			preference:   PreferHostname,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"},
+		},
+		{
+			name:         "multiple IPs and hostname, prefer IP",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "192.0.2.2", Hostname: "lb.cloud.example.com"}, {IP: "192.0.2.1"}},
+			preference:   PreferIP,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:         "IPv4 and IPv6 addresses",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "2001:db8::2"}, {IP: "40.0.2.1"}, {IP: "2001:db8::1"}, {IP: "192.0.2.1"}},
+			preference:   PreferHostname,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"192.0.2.1", "40.0.2.1", "2001:db8::1", "2001:db8::2"},
+		},
+		{
+			name:         "IPv6 address only",
+			ingresses:    []corev1.LoadBalancerIngress{{IP: "2001:db8::1"}},
+			preference:   PreferHostname,
+			expectType:   iov1.ARecordType,
+			expectTarget: []string{"2001:db8::1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recordType, targets, selection := selectDNSTargets(tc.ingresses, tc.preference)
+			if recordType != tc.expectType {
+				t.Errorf("expected record type %q, got %q", tc.expectType, recordType)
+			}
+			if !cmp.Equal(targets, tc.expectTarget) {
+				t.Errorf("expected targets %v, got %v", tc.expectTarget, targets)
+			}
+			if len(targets) != 0 && len(selection) == 0 {
+				t.Errorf("expected a description of the selection")
+			}
+		})
+	}
+}
+
+// Test_TargetPreferenceForAnnotations verifies that
+// TargetPreferenceForAnnotations parses the target preference annotation and
+// defaults to preferring hostnames.
+func Test_TargetPreferenceForAnnotations(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expect      TargetPreference
+	}{
+		{nil, PreferHostname},
+		{map[string]string{DNSTargetPreferenceAnnotation: "Hostname"}, PreferHostname},
+		{map[string]string{DNSTargetPreferenceAnnotation: "IP"}, PreferIP},
+		{map[string]string{DNSTargetPreferenceAnnotation: "ip"}, PreferIP},
+		{map[string]string{DNSTargetPreferenceAnnotation: "bogus"}, PreferHostname},
+	}
+	for _, tc := range tests {
+		if actual := TargetPreferenceForAnnotations(tc.annotations); actual != tc.expect {
+			t.Errorf("expected %q for annotations %v, got %q", tc.expect, tc.annotations, actual)
+		}
+	}
+}
+
+// Test_dnsRecordChanged verifies that dnsRecordChanged detects changes to the
+// target selection and dual-stack annotations and preserves other annotations.
+func Test_dnsRecordChanged(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default-wildcard"}
+	service := &corev1.Service{}
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com"}, {IP: "192.0.2.1"}}
+	_, byHostname := desiredDNSRecord(name, nil, metav1.OwnerReference{}, "*.apps.example.com.", iov1.ManagedDNS, service, PreferHostname)
+	_, byIP := desiredDNSRecord(name, nil, metav1.OwnerReference{}, "*.apps.example.com.", iov1.ManagedDNS, service, PreferIP)
+
+	current := byHostname.DeepCopy()
+	current.Annotations["example.com/provider-state"] = "foo"
+	if changed, _ := dnsRecordChanged(current, byHostname); changed {
+		t.Errorf("expected no change")
+	}
+	changed, updated := dnsRecordChanged(current, byIP)
+	if !changed {
+		t.Fatalf("expected a change")
+	}
+	if !cmp.Equal(updated.Spec, byIP.Spec) {
+		t.Errorf("expected spec %v, got %v", byIP.Spec, updated.Spec)
+	}
+	if updated.Annotations[DNSTargetSelectionAnnotation] != byIP.Annotations[DNSTargetSelectionAnnotation] {
+		t.Errorf("expected selection annotation %q, got %q", byIP.Annotations[DNSTargetSelectionAnnotation], updated.Annotations[DNSTargetSelectionAnnotation])
+	}
+	if updated.Annotations["example.com/provider-state"] != "foo" {
+		t.Errorf("expected other annotations to be preserved, got %v", updated.Annotations)
+	}
+
+	dualStackService := service.DeepCopy()
+	dualStackService.Annotations = map[string]string{AWSLBIPAddressTypeAnnotation: AWSLBIPAddressTypeDualStack}
+	_, dualStack := desiredDNSRecord(name, nil, metav1.OwnerReference{}, "*.apps.example.com.", iov1.ManagedDNS, dualStackService, PreferHostname)
+	if dualStack.Annotations[DNSDualStackAnnotation] != "true" {
+		t.Fatalf("expected %s annotation on record for dual-stack service, got %v", DNSDualStackAnnotation, dualStack.Annotations)
+	}
+	changed, updated = dnsRecordChanged(current, dualStack)
+	if !changed {
+		t.Fatalf("expected a change when the service becomes dual-stack")
+	}
+	if updated.Annotations[DNSDualStackAnnotation] != "true" || updated.Annotations["example.com/provider-state"] != "foo" {
+		t.Errorf("expected dual-stack annotation to be added and other annotations to be preserved, got %v", updated.Annotations)
+	}
+	changed, updated = dnsRecordChanged(updated, byHostname)
+	if !changed {
+		t.Fatalf("expected a change when the service is no longer dual-stack")
+	}
+	if _, ok := updated.Annotations[DNSDualStackAnnotation]; ok {
+		t.Errorf("expected dual-stack annotation to be removed, got %v", updated.Annotations)
+	}
+}
+
 func Test_manageDNSForDomain(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -244,6 +430,27 @@ func Test_manageDNSForDomain(t *testing.T) {
 			platformType: configv1.AWSPlatformType,
 			expected:     false,
 		},
+		{
+			name:         "punycode domain matches the baseDomain on AWS",
+			domain:       "xn--bcher-kva.apps.openshift.example.com.",
+			baseDomain:   "openshift.example.com",
+			platformType: configv1.AWSPlatformType,
+			expected:     true,
+		},
+		{
+			name:         "domain matches a punycode baseDomain on AWS",
+			domain:       "apps.xn--bcher-kva.example.",
+			baseDomain:   "xn--bcher-kva.example",
+			platformType: configv1.AWSPlatformType,
+			expected:     true,
+		},
+		{
+			name:         "domain matches a baseDomain that differs in case on AWS",
+			domain:       "apps.openshift.example.com",
+			baseDomain:   "OpenShift.Example.com",
+			platformType: configv1.AWSPlatformType,
+			expected:     true,
+		},
 		{
 			name:         "domain matches the baseDomain on GCP",
 			domain:       "apps.openshift.example.com",
@@ -299,3 +506,222 @@ func Test_manageDNSForDomain(t *testing.T) {
 		})
 	}
 }
+
+// Test_NewZoneTarget verifies that NewZoneTarget derives the record type from
+// the targets and rejects targets that cannot be published as a single record.
+func Test_NewZoneTarget(t *testing.T) {
+	tests := []struct {
+		targets     []string
+		expectType  iov1.DNSRecordType
+		expectError bool
+	}{
+		{targets: nil, expectError: true},
+		{targets: []string{"10.0.0.5"}, expectType: iov1.ARecordType},
+		{targets: []string{"10.0.0.5", "10.0.0.6"}, expectType: iov1.ARecordType},
+		{targets: []string{"lb.example.com"}, expectType: iov1.CNAMERecordType},
+		{targets: []string{"lb.example.com."}, expectType: iov1.CNAMERecordType},
+		{targets: []string{"lb.example.com", "lb2.example.com"}, expectError: true},
+		{targets: []string{"10.0.0.5", "lb.example.com"}, expectError: true},
+		{targets: []string{"fd00::5"}, expectError: true},
+		{targets: []string{"not a hostname"}, expectError: true},
+	}
+	for _, tc := range tests {
+		actual, err := NewZoneTarget(tc.targets)
+		switch {
+		case err == nil && tc.expectError:
+			t.Errorf("expected error for targets %v, got %+v", tc.targets, actual)
+		case err != nil && !tc.expectError:
+			t.Errorf("unexpected error for targets %v: %v", tc.targets, err)
+		case err == nil && actual.RecordType != tc.expectType:
+			t.Errorf("expected record type %s for targets %v, got %s", tc.expectType, tc.targets, actual.RecordType)
+		}
+	}
+}
+
+// Test_RecordForZone verifies that desiredWildcardDNSRecord records per-zone
+// targets in the DNSZoneTargetsAnnotation annotation and that RecordForZone
+// returns the record with each zone's targets.
+func Test_RecordForZone(t *testing.T) {
+	name := types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default-wildcard"}
+	service := &corev1.Service{}
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.cloud.example.com"}}
+	eps := &operatorv1.EndpointPublishingStrategy{
+		Type:         operatorv1.LoadBalancerServiceStrategyType,
+		LoadBalancer: &operatorv1.LoadBalancerStrategy{DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS},
+	}
+	zoneTargets := &ZoneTargets{
+		Private: &ZoneTarget{RecordType: iov1.ARecordType, Targets: []string{"10.0.0.5"}},
+	}
+	_, record := desiredWildcardDNSRecord(name, nil, metav1.OwnerReference{}, "apps.example.com", eps, service, PreferHostname, zoneTargets)
+	if _, ok := record.Annotations[DNSZoneTargetsAnnotation]; !ok {
+		t.Fatalf("expected %s annotation, got %v", DNSZoneTargetsAnnotation, record.Annotations)
+	}
+	if !PublishPending(record) {
+		t.Errorf("expected publish to be pending for a record with unpublished zone targets")
+	}
+
+	private, err := RecordForZone(record, true, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if private.Spec.RecordType != iov1.ARecordType || !cmp.Equal(private.Spec.Targets, []string{"10.0.0.5"}) {
+		t.Errorf("expected private zone record A 10.0.0.5, got %s %v", private.Spec.RecordType, private.Spec.Targets)
+	}
+	if private.Annotations[DNSZoneTargetRecordAnnotation] != "true" {
+		t.Errorf("expected %s annotation on the private zone record, got %v", DNSZoneTargetRecordAnnotation, private.Annotations)
+	}
+	public, err := RecordForZone(record, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if public.Spec.RecordType != iov1.CNAMERecordType || !cmp.Equal(public.Spec.Targets, []string{"lb.cloud.example.com"}) {
+		t.Errorf("expected public zone record CNAME lb.cloud.example.com, got %s %v", public.Spec.RecordType, public.Spec.Targets)
+	}
+	if _, ok := public.Annotations[DNSZoneTargetRecordAnnotation]; ok {
+		t.Errorf("expected no %s annotation on the public zone record, got %v", DNSZoneTargetRecordAnnotation, public.Annotations)
+	}
+	if record.Spec.RecordType != iov1.CNAMERecordType {
+		t.Errorf("expected the record not to be modified, got record type %s", record.Spec.RecordType)
+	}
+
+	// Until the record is published, the published targets are the
+	// targets in the spec.
+	published, err := RecordForZone(record, true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if published != record {
+		t.Errorf("expected the record itself for the published private zone targets, got %s %v", published.Spec.RecordType, published.Spec.Targets)
+	}
+
+	record.Annotations[DNSZoneTargetsAnnotation] = "{"
+	if _, err := RecordForZone(record, true, false); err == nil {
+		t.Errorf("expected error for invalid %s annotation", DNSZoneTargetsAnnotation)
+	}
+
+	_, withoutZoneTargets := desiredWildcardDNSRecord(name, nil, metav1.OwnerReference{}, "apps.example.com", eps, service, PreferHostname, &ZoneTargets{})
+	if _, ok := withoutZoneTargets.Annotations[DNSZoneTargetsAnnotation]; ok {
+		t.Errorf("expected no %s annotation for empty zone targets, got %v", DNSZoneTargetsAnnotation, withoutZoneTargets.Annotations)
+	}
+}
+
+// Test_RecordKindChanged verifies that RecordKindChanged detects a change of
+// record type and a change between a CNAME record for the load balancer and a
+// CNAME record for per-zone targets, such as a vanity hostname.
+func Test_RecordKindChanged(t *testing.T) {
+	record := func(recordType iov1.DNSRecordType, target string, zoneTarget bool) *iov1.DNSRecord {
+		r := &iov1.DNSRecord{Spec: iov1.DNSRecordSpec{RecordType: recordType, Targets: []string{target}}}
+		if zoneTarget {
+			r.Annotations = map[string]string{DNSZoneTargetRecordAnnotation: "true"}
+		}
+		return r
+	}
+	testCases := []struct {
+		name      string
+		published *iov1.DNSRecord
+		record    *iov1.DNSRecord
+		expect    bool
+	}{
+		{
+			name:      "same load balancer CNAME",
+			published: record(iov1.CNAMERecordType, "lb1.cloud.example.com", false),
+			record:    record(iov1.CNAMERecordType, "lb2.cloud.example.com", false),
+			expect:    false,
+		},
+		{
+			name:      "same vanity CNAME",
+			published: record(iov1.CNAMERecordType, "ingress.example.net", true),
+			record:    record(iov1.CNAMERecordType, "ingress.example.org", true),
+			expect:    false,
+		},
+		{
+			name:      "load balancer CNAME to vanity CNAME",
+			published: record(iov1.CNAMERecordType, "lb.cloud.example.com", false),
+			record:    record(iov1.CNAMERecordType, "ingress.example.net", true),
+			expect:    true,
+		},
+		{
+			name:      "vanity CNAME to load balancer CNAME",
+			published: record(iov1.CNAMERecordType, "ingress.example.net", true),
+			record:    record(iov1.CNAMERecordType, "lb.cloud.example.com", false),
+			expect:    true,
+		},
+		{
+			name:      "A record to vanity CNAME",
+			published: record(iov1.ARecordType, "192.0.2.1", false),
+			record:    record(iov1.CNAMERecordType, "ingress.example.net", true),
+			expect:    true,
+		},
+		{
+			name:      "load balancer A record to zone target A record",
+			published: record(iov1.ARecordType, "192.0.2.1", false),
+			record:    record(iov1.ARecordType, "10.0.0.5", true),
+			expect:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := RecordKindChanged(tc.published, tc.record); actual != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, actual)
+			}
+		})
+	}
+}
+
+// Test_desiredDNSRecord_dualStack verifies that desiredDNSRecord annotates the
+// DNSRecord for a dual-stack service, whether the service requests dual-stack
+// using the AWS IP address type annotation or using its IP family policy, and
+// lists the IPv4 targets before the IPv6 targets.
+func Test_desiredDNSRecord_dualStack(t *testing.T) {
+	preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+	singleStack := corev1.IPFamilyPolicySingleStack
+	tests := []struct {
+		name            string
+		service         *corev1.Service
+		expectDualStack bool
+	}{
+		{
+			name:    "single-stack service",
+			service: &corev1.Service{Spec: corev1.ServiceSpec{IPFamilyPolicy: &singleStack}},
+		},
+		{
+			name:            "PreferDualStack service",
+			service:         &corev1.Service{Spec: corev1.ServiceSpec{IPFamilyPolicy: &preferDualStack}},
+			expectDualStack: true,
+		},
+		{
+			name: "AWS dual-stack annotation",
+			service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{AWSLBIPAddressTypeAnnotation: AWSLBIPAddressTypeDualStack},
+			}},
+			expectDualStack: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "2001:db8::1"}, {IP: "192.0.2.1"}}
+			want, record := desiredDNSRecord(types.NamespacedName{Namespace: "openshift-ingress-operator", Name: "default-wildcard"}, nil, metav1.OwnerReference{}, "*.apps.example.com.", iov1.ManagedDNS, tc.service, PreferHostname)
+			if !want {
+				t.Fatal("expected a DNSRecord")
+			}
+			if actual := record.Annotations[DNSDualStackAnnotation] == "true"; actual != tc.expectDualStack {
+				t.Errorf("expected dual-stack annotation to be %t, got %t", tc.expectDualStack, actual)
+			}
+			if expect := []string{"192.0.2.1", "2001:db8::1"}; !cmp.Equal(record.Spec.Targets, expect) {
+				t.Errorf("expected targets %v, got %v", expect, record.Spec.Targets)
+			}
+		})
+	}
+}
+
+// Test_SplitTargetsByIPFamily verifies that SplitTargetsByIPFamily splits
+// targets into IPv4 and IPv6 addresses and preserves their order.
+func Test_SplitTargetsByIPFamily(t *testing.T) {
+	ipv4, ipv6 := SplitTargetsByIPFamily([]string{"192.0.2.2", "2001:db8::2", "192.0.2.1", "::ffff:192.0.2.3", "2001:db8::1"})
+	if expect := []string{"192.0.2.2", "192.0.2.1", "::ffff:192.0.2.3"}; !cmp.Equal(ipv4, expect) {
+		t.Errorf("expected IPv4 targets %v, got %v", expect, ipv4)
+	}
+	if expect := []string{"2001:db8::2", "2001:db8::1"}; !cmp.Equal(ipv6, expect) {
+		t.Errorf("expected IPv6 targets %v, got %v", expect, ipv6)
+	}
+}
@@ -0,0 +1,110 @@
+package dnsrecord
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// DNSTargetPreferenceAnnotation is an annotation that can be set on an
+	// IngressController or a Gateway to specify which kind of
+	// load-balancer ingress entry the operator uses as the target of the
+	// associated DNS records when the load-balancer service reports both
+	// hostnames and IP addresses.  The value must be "Hostname" (the
+	// default) or "IP".
+	DNSTargetPreferenceAnnotation = "ingress.operator.openshift.io/dns-target-preference"
+
+	// DNSTargetSelectionAnnotation is an annotation that the operator sets
+	// on a DNSRecord to describe how the record's targets were selected
+	// from the load-balancer service's ingress entries.
+	DNSTargetSelectionAnnotation = "ingress.operator.openshift.io/dns-target-selection"
+)
+
+// TargetPreference specifies which kind of load-balancer ingress entry is used
+// as a DNS record's target when both kinds are present.
+type TargetPreference string
+
+const (
+	// PreferHostname selects a CNAME record to a load-balancer hostname
+	// when the load-balancer has a hostname.
+	PreferHostname TargetPreference = "Hostname"
+	// PreferIP selects an A record to the load-balancer's IP addresses
+	// when the load-balancer has IP addresses.
+	PreferIP TargetPreference = "IP"
+)
+
+// TargetPreferenceForAnnotations returns the target preference that the given
+// annotations specify using DNSTargetPreferenceAnnotation.  If the annotation
+// is absent or has an unrecognized value, PreferHostname is returned.
+func TargetPreferenceForAnnotations(annotations map[string]string) TargetPreference {
+	if strings.EqualFold(annotations[DNSTargetPreferenceAnnotation], string(PreferIP)) {
+		return PreferIP
+	}
+	return PreferHostname
+}
+
+// selectDNSTargets selects the record type and targets for a DNS record from
+// the given load-balancer ingress entries, and returns a description of the
+// selection.  Selection is deterministic and does not depend on the order of
+// the entries:
+//
+//   - If there are hostnames and either the preference is PreferHostname or
+//     there are no IP addresses, a CNAME record to the lexically first
+//     hostname is selected because a CNAME record can have only one target.
+//
+//   - Otherwise, an A record with all of the IP addresses is selected.  The
+//     IPv4 addresses, sorted, come before the IPv6 addresses, sorted, so
+//     that providers that only publish the first target publish an IPv4
+//     address.  Providers that support dual-stack records publish the IPv6
+//     addresses as AAAA records.
+//
+// If there are no hostnames or IP addresses, no targets are returned.
+//
+// Note that some DNS providers only publish the first target of a record.
+func selectDNSTargets(ingresses []corev1.LoadBalancerIngress, preference TargetPreference) (iov1.DNSRecordType, []string, string) {
+	hostnames, ips := sets.NewString(), sets.NewString()
+	for _, ingress := range ingresses {
+		if len(ingress.Hostname) != 0 {
+			hostnames.Insert(ingress.Hostname)
+		}
+		if len(ingress.IP) != 0 {
+			ips.Insert(ingress.IP)
+		}
+	}
+
+	switch {
+	case hostnames.Len() != 0 && (preference != PreferIP || ips.Len() == 0):
+		target := hostnames.List()[0]
+		selection := fmt.Sprintf("Selected hostname %s from %d hostnames and %d IP addresses with %s preference.", target, hostnames.Len(), ips.Len(), preference)
+		return iov1.CNAMERecordType, []string{target}, selection
+	case ips.Len() != 0:
+		ipv4, ipv6 := SplitTargetsByIPFamily(ips.List())
+		targets := append(ipv4, ipv6...)
+		selection := fmt.Sprintf("Selected %d IP addresses from %d hostnames and %d IP addresses with %s preference.", len(targets), hostnames.Len(), ips.Len(), preference)
+		return iov1.ARecordType, targets, selection
+	}
+	return "", nil, ""
+}
+
+// SplitTargetsByIPFamily splits the given A record targets into the IPv4
+// addresses and the IPv6 addresses, preserving their order.  Targets that are
+// not IP addresses are treated as IPv4 addresses so that the provider reports
+// them as invalid A record targets.
+func SplitTargetsByIPFamily(targets []string) ([]string, []string) {
+	var ipv4, ipv6 []string
+	for _, target := range targets {
+		if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+			ipv6 = append(ipv6, target)
+		} else {
+			ipv4 = append(ipv4, target)
+		}
+	}
+	return ipv4, ipv6
+}
@@ -0,0 +1,166 @@
+package dnsrecord
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DNSZoneTargetsAnnotation is an annotation that the operator sets on
+	// a DNSRecord to specify targets for the public zone or the private
+	// zone that differ from the targets in the record's spec, for example
+	// in split-horizon environments in which internal clients should reach
+	// the ingress controller through a private load balancer or VIP.  The
+	// value is a JSON-encoded ZoneTargets value.
+	DNSZoneTargetsAnnotation = "ingress.operator.openshift.io/dns-zone-targets"
+
+	// DNSZoneTargetsPublishedAnnotation is an annotation that the DNS
+	// controller sets on a DNSRecord to the value of the record's
+	// DNSZoneTargetsAnnotation annotation once it has published the
+	// record, and removes once it has published the record without it.
+	// The DNS controller uses it to delete the records that it actually
+	// published.
+	DNSZoneTargetsPublishedAnnotation = "ingress.operator.openshift.io/dns-zone-targets-published"
+
+	// DNSZoneTargetRecordAnnotation is an annotation that RecordForZone
+	// sets to "true" on the record that it returns if the record's targets
+	// are per-zone targets rather than the targets in the DNSRecord's
+	// spec.  Because per-zone targets need not be load balancers, DNS
+	// providers that publish alias records for load balancer hostnames
+	// publish a regular CNAME record for such a record.
+	DNSZoneTargetRecordAnnotation = "ingress.operator.openshift.io/dns-zone-target"
+)
+
+// ZoneTargets specifies the targets of a DNS record for the public zone and
+// the private zone.  If the value for a zone is nil, the record uses the
+// targets in its spec for that zone.
+type ZoneTargets struct {
+	Public  *ZoneTarget `json:"public,omitempty"`
+	Private *ZoneTarget `json:"private,omitempty"`
+}
+
+// ZoneTarget specifies the record type and targets of a DNS record for a zone.
+type ZoneTarget struct {
+	RecordType iov1.DNSRecordType `json:"recordType"`
+	Targets    []string           `json:"targets"`
+}
+
+// NewZoneTarget returns a ZoneTarget for the given targets, which must be
+// either one or more IPv4 addresses, in which case the record is an A record,
+// or a single hostname, in which case the record is a CNAME record.
+func NewZoneTarget(targets []string) (*ZoneTarget, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("at least one target must be specified")
+	}
+	ips := 0
+	for _, target := range targets {
+		if ip := net.ParseIP(target); ip != nil {
+			if ip.To4() == nil {
+				return nil, fmt.Errorf("target %q is not an IPv4 address", target)
+			}
+			ips++
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(target, ".")); len(errs) != 0 {
+			return nil, fmt.Errorf("target %q is not a valid IPv4 address or hostname: %s", target, strings.Join(errs, "; "))
+		}
+	}
+	switch {
+	case ips == len(targets):
+		return &ZoneTarget{RecordType: iov1.ARecordType, Targets: targets}, nil
+	case len(targets) == 1:
+		return &ZoneTarget{RecordType: iov1.CNAMERecordType, Targets: targets}, nil
+	}
+	return nil, fmt.Errorf("targets must be either IPv4 addresses or a single hostname, got %q", strings.Join(targets, ","))
+}
+
+// zoneTargetsForAnnotation decodes the given value of the
+// DNSZoneTargetsAnnotation annotation.  It returns nil if the value is empty.
+func zoneTargetsForAnnotation(value string) (*ZoneTargets, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var zoneTargets ZoneTargets
+	if err := json.Unmarshal([]byte(value), &zoneTargets); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", DNSZoneTargetsAnnotation, err)
+	}
+	return &zoneTargets, nil
+}
+
+// annotationForZoneTargets encodes the given zone targets as the value of the
+// DNSZoneTargetsAnnotation annotation.  It returns the empty string if the
+// zone targets specify no targets.
+func annotationForZoneTargets(zoneTargets *ZoneTargets) string {
+	if zoneTargets == nil || (zoneTargets.Public == nil && zoneTargets.Private == nil) {
+		return ""
+	}
+	value, err := json.Marshal(zoneTargets)
+	if err != nil {
+		// ZoneTargets has only string fields, so encoding cannot fail.
+		panic(err)
+	}
+	return string(value)
+}
+
+// RecordForZone returns the given DNSRecord with the record type and targets
+// that the record specifies for the public zone or the private zone, depending
+// on the value of private.  If published is true, the targets are determined
+// using the DNSZoneTargetsPublishedAnnotation annotation, which describes the
+// records that the DNS controller last published, rather than the
+// DNSZoneTargetsAnnotation annotation.  If the record specifies no targets for
+// the zone, the record itself is returned.
+func RecordForZone(record *iov1.DNSRecord, private, published bool) (*iov1.DNSRecord, error) {
+	key := DNSZoneTargetsAnnotation
+	if published {
+		key = DNSZoneTargetsPublishedAnnotation
+	}
+	zoneTargets, err := zoneTargetsForAnnotation(record.Annotations[key])
+	if err != nil || zoneTargets == nil {
+		return record, err
+	}
+	zoneTarget := zoneTargets.Public
+	if private {
+		zoneTarget = zoneTargets.Private
+	}
+	if zoneTarget == nil {
+		return record, nil
+	}
+	updated := record.DeepCopy()
+	updated.Spec.RecordType = zoneTarget.RecordType
+	updated.Spec.Targets = zoneTarget.Targets
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[DNSZoneTargetRecordAnnotation] = "true"
+	return updated, nil
+}
+
+// RecordKindChanged returns a Boolean value indicating whether the given
+// record, which RecordForZone returned for a zone, would be published as a
+// different kind of record than the given previously published record.  This
+// is the case if the records have different types or if one of them is a
+// CNAME record for per-zone targets and the other is a CNAME record for the
+// spec's targets, which some DNS providers publish as an alias record.  DNS
+// providers replace records of the same kind only, so the previously
+// published record must be deleted first.
+func RecordKindChanged(published, record *iov1.DNSRecord) bool {
+	if published.Spec.RecordType != record.Spec.RecordType {
+		return true
+	}
+	return record.Spec.RecordType == iov1.CNAMERecordType &&
+		published.Annotations[DNSZoneTargetRecordAnnotation] != record.Annotations[DNSZoneTargetRecordAnnotation]
+}
+
+// PublishPending returns a Boolean value indicating whether the given
+// DNSRecord's annotations that affect what the DNS controller publishes have
+// changed since the DNS controller last published the record.
+func PublishPending(record *iov1.DNSRecord) bool {
+	return DualStackPublishPending(record) ||
+		record.Annotations[DNSZoneTargetsAnnotation] != record.Annotations[DNSZoneTargetsPublishedAnnotation]
+}
@@ -0,0 +1,71 @@
+package ensure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var log = logf.Logger.WithName("ensure")
+
+// ChangedFunc compares the current and desired states of an object and returns
+// a Boolean value indicating whether the current object must be updated and, if
+// so, the updated object.  The updated object must be a copy of current with
+// the desired changes applied so that fields that the caller does not manage,
+// such as the resource version, are preserved.
+type ChangedFunc[T client.Object] func(current, desired T) (bool, T)
+
+// CreateOrUpdate creates the desired object.  If the object already exists,
+// CreateOrUpdate gets the existing object into current and, if changed is not
+// nil and reports that the existing object differs from the desired object,
+// updates the existing object to the object that changed returns.  If changed
+// is nil, an existing object is never updated, so CreateOrUpdate only creates
+// or gets the object.  CreateOrUpdate returns the resulting object, or an error
+// if creating, getting, or updating the object fails.
+//
+// Unlike controllerutil.CreateOrUpdate, CreateOrUpdate attempts the create
+// first, so it does not depend on a cache, and it leaves the decision whether
+// and how to update an existing object to changed.
+//
+// The current argument must be a non-nil, empty object of the same type as
+// desired; it is used to receive the existing object.
+func CreateOrUpdate[T client.Object](ctx context.Context, cl client.Client, desired, current T, changed ChangedFunc[T]) (T, error) {
+	var zero T
+	kind := fmt.Sprintf("%T", desired)
+	name := client.ObjectKeyFromObject(desired)
+
+	err := cl.Create(ctx, desired)
+	if err == nil {
+		log.Info("created object", "kind", kind, "name", name)
+		return desired, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return zero, fmt.Errorf("failed to create %s %s: %w", kind, name, err)
+	}
+
+	if err := cl.Get(ctx, name, current); err != nil {
+		return zero, fmt.Errorf("failed to get existing %s %s: %w", kind, name, err)
+	}
+	if changed == nil {
+		return current, nil
+	}
+	needsUpdate, updated := changed(current, desired)
+	if !needsUpdate {
+		return current, nil
+	}
+	// Diff before updating because the client may mutate the object.
+	diff := cmp.Diff(current, updated, cmpopts.EquateEmpty())
+	if err := cl.Update(ctx, updated); err != nil {
+		return zero, fmt.Errorf("failed to update existing %s %s: %w", kind, name, err)
+	}
+	log.Info("updated object", "kind", kind, "name", name, "diff", diff)
+	return updated, nil
+}
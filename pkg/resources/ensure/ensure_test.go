@@ -0,0 +1,120 @@
+package ensure
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// Test_CreateOrUpdate verifies that CreateOrUpdate creates an object that does
+// not exist, gets an object that already exists and updates it only if changed
+// reports a difference, and propagates errors.
+func Test_CreateOrUpdate(t *testing.T) {
+	configMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "openshift-ingress",
+				Name:      "test",
+			},
+			Data: data,
+		}
+	}
+	dataChanged := func(current, desired *corev1.ConfigMap) (bool, *corev1.ConfigMap) {
+		if reflect.DeepEqual(current.Data, desired.Data) {
+			return false, nil
+		}
+		updated := current.DeepCopy()
+		updated.Data = desired.Data
+		return true, updated
+	}
+	errGet := errors.New("get failed")
+	errCreate := errors.New("create failed")
+
+	testCases := []struct {
+		name         string
+		existing     []client.Object
+		interceptors interceptor.Funcs
+		changed      ChangedFunc[*corev1.ConfigMap]
+		expectError  error
+		expectData   map[string]string
+	}{
+		{
+			name:       "create succeeds",
+			expectData: map[string]string{"foo": "desired"},
+		},
+		{
+			name:       "exists and get succeeds",
+			existing:   []client.Object{configMap(map[string]string{"foo": "existing"})},
+			expectData: map[string]string{"foo": "existing"},
+		},
+		{
+			name:       "exists and is updated",
+			existing:   []client.Object{configMap(map[string]string{"foo": "existing"})},
+			changed:    dataChanged,
+			expectData: map[string]string{"foo": "desired"},
+		},
+		{
+			name:     "exists and get fails",
+			existing: []client.Object{configMap(map[string]string{"foo": "existing"})},
+			interceptors: interceptor.Funcs{
+				Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+					return errGet
+				},
+			},
+			expectError: errGet,
+		},
+		{
+			name: "create fails",
+			interceptors: interceptor.Funcs{
+				Create: func(context.Context, client.WithWatch, client.Object, ...client.CreateOption) error {
+					return errCreate
+				},
+			},
+			expectError: errCreate,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.existing...).WithInterceptorFuncs(tc.interceptors).Build()
+			desired := configMap(map[string]string{"foo": "desired"})
+			result, err := CreateOrUpdate(context.Background(), cl, desired, &corev1.ConfigMap{}, tc.changed)
+			if tc.expectError != nil {
+				if !errors.Is(err, tc.expectError) {
+					t.Fatalf("expected error %v, got %v", tc.expectError, err)
+				}
+				if result != nil {
+					t.Errorf("expected nil result on error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Data, tc.expectData) {
+				t.Errorf("expected returned data %v, got %v", tc.expectData, result.Data)
+			}
+			actual := &corev1.ConfigMap{}
+			if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "openshift-ingress", Name: "test"}, actual); err != nil {
+				t.Fatalf("failed to get configmap: %v", err)
+			}
+			if !reflect.DeepEqual(actual.Data, tc.expectData) {
+				t.Errorf("expected stored data %v, got %v", tc.expectData, actual.Data)
+			}
+		})
+	}
+}
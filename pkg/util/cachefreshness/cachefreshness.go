@@ -0,0 +1,195 @@
+package cachefreshness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	toolscache "k8s.io/client-go/tools/cache"
+
+	utilclock "k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultWindow is the default freshness window.  The cache is
+	// considered stale until no list or watch has failed for this long.
+	DefaultWindow = 2 * time.Minute
+
+	// unsyncedRetryPeriod is how long a controller should wait before
+	// retrying a destructive action that was suppressed because an
+	// informer has not synced yet.
+	unsyncedRetryPeriod = 10 * time.Second
+
+	// watchFailureLogInterval is the minimum interval between log messages
+	// for failed list and watch calls.  During a disruption of the API
+	// server, every informer fails repeatedly, and logging each failure
+	// floods the operator's logs.
+	watchFailureLogInterval = 30 * time.Second
+)
+
+var (
+	log = logf.Logger.WithName("cache_freshness")
+
+	// destructiveActionsSuppressed counts destructive actions that a
+	// controller did not perform because the cache on which the action was
+	// based might have been stale.
+	destructiveActionsSuppressed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ingress_operator_destructive_actions_suppressed_total",
+		Help: "Counts destructive actions, such as deleting DNS records or services, that a controller deferred because its cache might have been stale, by controller and action.",
+	}, []string{"controller", "action"})
+
+	// metricsList is a list of metrics for this package.
+	metricsList = []prometheus.Collector{
+		destructiveActionsSuppressed,
+	}
+)
+
+// Tracker tracks the health of the informers of a controller-runtime cache so
+// that controllers can avoid destructive actions that are based on what the
+// cache does not contain.  While an informer has not synced, or shortly after
+// its list or watch calls have failed, an object being absent from the cache
+// does not mean that the object is absent from the API, and a controller that
+// deletes something because an object appears to be gone may delete something
+// that is still in use.
+//
+// A nil Tracker considers the cache to be fresh.
+type Tracker struct {
+	window time.Duration
+	clock  utilclock.PassiveClock
+
+	mu sync.Mutex
+	// lastWatchFailure is when a list or watch call last failed.
+	lastWatchFailure time.Time
+	// lastLogged is when a failed list or watch call was last logged.
+	lastLogged time.Time
+	// suppressedLogs is the number of failed list or watch calls that
+	// have not been logged since lastLogged.
+	suppressedLogs int
+}
+
+// NewTracker returns a Tracker that considers the cache stale for the given
+// window after a failed list or watch call.
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{window: window, clock: utilclock.RealClock{}}
+}
+
+// WatchErrorHandler records failed list and watch calls.  It is meant to be
+// used as the cache's DefaultWatchErrorHandler.  Watches that expire or that
+// the API server closes normally are not failures.  Failures are logged at
+// most once per watchFailureLogInterval, along with the number of failures
+// that were not logged.
+func (t *Tracker) WatchErrorHandler(r *toolscache.Reflector, err error) {
+	if err == io.EOF || kerrors.IsResourceExpired(err) || kerrors.IsGone(err) {
+		toolscache.DefaultWatchErrorHandler(r, err)
+		return
+	}
+	t.mu.Lock()
+	now := t.clock.Now()
+	t.lastWatchFailure = now
+	if now.Sub(t.lastLogged) < watchFailureLogInterval {
+		t.suppressedLogs++
+		t.mu.Unlock()
+		return
+	}
+	suppressed := t.suppressedLogs
+	t.lastLogged, t.suppressedLogs = now, 0
+	t.mu.Unlock()
+	log.Error(err, "failed to list or watch resources; destructive actions are deferred until the cache is fresh", "unloggedFailures", suppressed, "window", t.window)
+}
+
+// StaleCacheError is the error that CheckFresh returns if the cache might be
+// stale.
+type StaleCacheError struct {
+	// Reason describes why the cache might be stale.
+	Reason string
+	// RetryAfter is how long to wait before checking again.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *StaleCacheError) Error() string {
+	return "cache might be stale: " + e.Reason
+}
+
+// IsStaleCache returns a Boolean value indicating whether the given error is
+// or wraps a *StaleCacheError, along with the error.
+func IsStaleCache(err error) (*StaleCacheError, bool) {
+	var staleErr *StaleCacheError
+	if errors.As(err, &staleErr) {
+		return staleErr, true
+	}
+	return nil, false
+}
+
+// CheckFresh returns nil if the informers for the given objects' kinds have
+// synced and no list or watch call has failed within the freshness window.
+// Otherwise, it returns a *StaleCacheError.
+func (t *Tracker) CheckFresh(ctx context.Context, informers cache.Informers, objs ...client.Object) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	sinceFailure := t.clock.Since(t.lastWatchFailure)
+	failed := !t.lastWatchFailure.IsZero()
+	t.mu.Unlock()
+	if failed && sinceFailure < t.window {
+		return &StaleCacheError{
+			Reason:     fmt.Sprintf("a list or watch call failed %s ago, which is within the freshness window of %s", sinceFailure.Round(time.Second), t.window),
+			RetryAfter: t.window - sinceFailure,
+		}
+	}
+	for _, obj := range objs {
+		informer, err := informers.GetInformer(ctx, obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			return &StaleCacheError{
+				Reason:     fmt.Sprintf("failed to get informer for %T: %v", obj, err),
+				RetryAfter: unsyncedRetryPeriod,
+			}
+		}
+		if !informer.HasSynced() {
+			return &StaleCacheError{
+				Reason:     fmt.Sprintf("the informer for %T has not synced", obj),
+				RetryAfter: unsyncedRetryPeriod,
+			}
+		}
+	}
+	return nil
+}
+
+// AllowDestructiveAction returns nil if the cache is fresh as determined by
+// CheckFresh.  Otherwise, it logs that the named controller deferred the named
+// action, increments the
+// ingress_operator_destructive_actions_suppressed_total metric, and returns the
+// *StaleCacheError.
+func (t *Tracker) AllowDestructiveAction(ctx context.Context, informers cache.Informers, controller, action string, objs ...client.Object) error {
+	err := t.CheckFresh(ctx, informers, objs...)
+	if err == nil {
+		return nil
+	}
+	destructiveActionsSuppressed.WithLabelValues(controller, action).Inc()
+	log.Info("deferring destructive action because the cache might be stale", "controller", controller, "action", action, "reason", err.Error())
+	return err
+}
+
+// RegisterMetrics calls prometheus.Register on each metric in metricsList, and
+// returns on errors.
+func RegisterMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
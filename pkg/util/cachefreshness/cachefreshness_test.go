@@ -0,0 +1,59 @@
+package cachefreshness
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	utilclocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+)
+
+// Test_CheckFresh verifies that CheckFresh reports the cache as stale while an
+// informer has not synced and for the freshness window after a failed list or
+// watch call, but not after a watch that closed normally.
+func Test_CheckFresh(t *testing.T) {
+	ctx := context.Background()
+	clock := utilclocktesting.NewFakeClock(time.Now())
+	tracker := &Tracker{window: time.Minute, clock: clock}
+	informers := &informertest.FakeInformers{}
+	informer, err := informers.FakeInformerFor(ctx, &corev1.Service{})
+	if err != nil {
+		t.Fatalf("failed to get informer: %v", err)
+	}
+
+	expectStale := func(description string, expect bool) {
+		t.Helper()
+		err := tracker.CheckFresh(ctx, informers, &corev1.Service{})
+		if _, stale := IsStaleCache(err); stale != expect {
+			t.Errorf("%s: expected stale to be %t, got error %v", description, expect, err)
+		}
+	}
+
+	expectStale("informer not synced", true)
+	informer.Synced = true
+	expectStale("informer synced", false)
+
+	tracker.WatchErrorHandler(nil, io.EOF)
+	expectStale("watch closed normally", false)
+
+	tracker.WatchErrorHandler(nil, errors.New("connection refused"))
+	expectStale("watch failed", true)
+	clock.Step(30 * time.Second)
+	err = tracker.CheckFresh(ctx, informers, &corev1.Service{})
+	if staleErr, ok := IsStaleCache(err); !ok || staleErr.RetryAfter != 30*time.Second {
+		t.Errorf("expected the cache to be stale for another 30s, got %v", err)
+	}
+	clock.Step(30 * time.Second)
+	expectStale("freshness window elapsed", false)
+
+	var nilTracker *Tracker
+	if err := nilTracker.CheckFresh(ctx, informers, &corev1.Service{}); err != nil {
+		t.Errorf("expected a nil tracker to consider the cache fresh, got %v", err)
+	}
+}
@@ -1,7 +1,12 @@
 package ingresscontroller
 
 import (
+	"encoding/json"
+	"fmt"
+
 	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
@@ -13,6 +18,32 @@ const (
 	// "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 	// package into a third package that the first two can import.
 	ingressControllerAdmittedConditionType = "Admitted"
+
+	// PreviousDomainAnnotation is the annotation with which the operator
+	// records the domain that an ingresscontroller continues to serve
+	// while it migrates to a new domain.
+	PreviousDomainAnnotation = "ingress.operator.openshift.io/previous-domain"
+	// DomainMigrationStartedAnnotation is the annotation with which the
+	// operator records when an ingresscontroller started to migrate to a
+	// new domain, in RFC 3339 format.
+	DomainMigrationStartedAnnotation = "ingress.operator.openshift.io/domain-migration-started"
+	// ShardChangePreviewAnnotation is the annotation with which an
+	// administrator asks the operator to report which routes a proposed
+	// change to an ingresscontroller's namespace selector, route selector,
+	// or domain would affect.  The value is a JSON object with optional
+	// "namespaceSelector", "routeSelector", and "domain" fields.
+	ShardChangePreviewAnnotation = "ingress.operator.openshift.io/shard-change-preview"
+	// PreserveSpecOnRecreationAnnotation is the annotation with which an
+	// administrator opts the default ingresscontroller out of having its
+	// spec restored when the operator recreates it after it has been
+	// deleted.  The only recognized value is "false".  Otherwise, the
+	// operator keeps a snapshot of the default ingresscontroller's spec and
+	// uses it to recreate the ingresscontroller.
+	PreserveSpecOnRecreationAnnotation = "ingress.operator.openshift.io/preserve-spec-on-recreation"
+	// RestoredFromSnapshotAnnotation is the annotation with which the
+	// operator records when it recreated the default ingresscontroller from
+	// the snapshot of its spec, in RFC 3339 format.
+	RestoredFromSnapshotAnnotation = "ingress.operator.openshift.io/restored-from-snapshot"
 )
 
 // IsAdmitted returns a Boolean value indicating whether the given
@@ -26,3 +57,54 @@ func IsAdmitted(ic *operatorv1.IngressController) bool {
 	}
 	return false
 }
+
+// PreviousDomain returns the domain that the given ingresscontroller continues
+// to serve while it migrates to a new domain, or the empty string if it is not
+// migrating.
+func PreviousDomain(ic *operatorv1.IngressController) string {
+	return ic.Annotations[PreviousDomainAnnotation]
+}
+
+// DecodeUnsupportedConfigOverrides decodes the given ingresscontroller's
+// spec.unsupportedConfigOverrides into the value that v points to.  Nothing is
+// decoded if the ingresscontroller specifies no overrides.  An error is
+// returned if spec.unsupportedConfigOverrides cannot be decoded.
+//
+// The ingress controller decodes every override that it implements into one
+// type; see unsupportedConfigOverrides in
+// "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress".
+// Packages that cannot import that package decode the overrides that they
+// need using this function.
+func DecodeUnsupportedConfigOverrides(ic *operatorv1.IngressController, v interface{}) error {
+	if len(ic.Spec.UnsupportedConfigOverrides.Raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(ic.Spec.UnsupportedConfigOverrides.Raw, v); err != nil {
+		return fmt.Errorf("ingresscontroller %q has invalid spec.unsupportedConfigOverrides: %w", ic.Name, err)
+	}
+	return nil
+}
+
+// DefaultCertificateSource returns the namespace and name of the secret in
+// another namespace that the given ingresscontroller uses as its default
+// certificate, as specified using the "defaultCertificateSource" unsupported
+// config override, or nil if the ingresscontroller does not specify one.  The
+// operator copies the secret into the operand namespace; see
+// pkg/operator/controller/default-cert-source.  An error is returned if
+// spec.unsupportedConfigOverrides cannot be decoded.
+func DefaultCertificateSource(ic *operatorv1.IngressController) (*types.NamespacedName, error) {
+	var overrides struct {
+		DefaultCertificateSource *struct {
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"defaultCertificateSource"`
+	}
+	if err := DecodeUnsupportedConfigOverrides(ic, &overrides); err != nil {
+		return nil, err
+	}
+	source := overrides.DefaultCertificateSource
+	if source == nil {
+		return nil, nil
+	}
+	return &types.NamespacedName{Namespace: source.Namespace, Name: source.Name}, nil
+}
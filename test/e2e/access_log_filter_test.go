@@ -0,0 +1,170 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// TestAccessLogFilter verifies that an ingresscontroller with container access
+// logging and an errorsOnly access log filter logs only error responses.  It
+// sends a request that gets a 200 response from the console route and a
+// request for an unknown host, which gets a 503 response from the router, and
+// verifies that only the 503 response appears in the logs sidecar.
+func TestAccessLogFilter(t *testing.T) {
+	t.Parallel()
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "accesslogfilter"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+		Access: &operatorv1.AccessLogging{
+			Destination: operatorv1.LoggingDestination{
+				Type:      operatorv1.ContainerLoggingDestinationType,
+				Container: &operatorv1.ContainerLoggingDestinationParameters{},
+			},
+			// Log the status code and request URI so that the test
+			// can tell the requests apart.
+			HttpLogFormat: "accesslogfilter %ST %HU",
+		},
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"accessLogFilter":{"errorsOnly":true}}`)}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("router deployment has invalid spec.selector: %v", err)
+	}
+	podList := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), podList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list pods for ingresscontroller: %v", err)
+	}
+	if len(podList.Items) != 1 {
+		t.Fatalf("expected ingress controller %s to have exactly 1 router pod, but it has %d", ic.Name, len(podList.Items))
+	}
+	routerPod := podList.Items[0]
+
+	routeName := types.NamespacedName{Namespace: "openshift-console", Name: "console"}
+	route := &routev1.Route{}
+	if err := kclient.Get(context.TODO(), routeName, route); err != nil {
+		t.Fatalf("failed to get the console route: %v", err)
+	}
+	unknownHost := "unknown." + domain
+	curl := func(host, path string) string {
+		return fmt.Sprintf("/bin/curl -k -o /dev/null -s --resolve %s:443:%s https://%s%s", host, routerPod.Status.PodIP, host, path)
+	}
+	clientPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "accesslogfiltertest",
+			Namespace: routerPod.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:    "curl",
+					Image:   routerPod.Spec.Containers[0].Image,
+					Command: []string{"/bin/sh", "-c"},
+					Args: []string{
+						curl(route.Spec.Host, "/accesslogfilter-ok") + "; " + curl(unknownHost, "/accesslogfilter-unavailable"),
+					},
+				},
+			},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), clientPod); err != nil && !apierrors.IsNotFound(err) {
+			t.Errorf("failed to delete pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+		}
+	}()
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to create kube client: %v", err)
+	}
+
+	// The client sends the request that gets a 200 response first, so once
+	// the 503 response is logged, the 200 response would have been logged
+	// too if the filter did not suppress it.
+	var lines []string
+	err = wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		readCloser, err := kubeClient.CoreV1().Pods(routerPod.Namespace).GetLogs(routerPod.Name, &corev1.PodLogOptions{
+			Container: "logs",
+		}).Stream(ctx)
+		if err != nil {
+			t.Logf("failed to read logs from pod %s: %v", routerPod.Name, err)
+			return false, nil
+		}
+		defer readCloser.Close()
+		data, err := io.ReadAll(readCloser)
+		if err != nil {
+			t.Logf("failed to read logs from pod %s: %v", routerPod.Name, err)
+			return false, nil
+		}
+		lines = nil
+		found := false
+		scanner := bufio.NewScanner(bytes.NewBuffer(data))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.Contains(line, "accesslogfilter ") {
+				continue
+			}
+			lines = append(lines, line)
+			if strings.Contains(line, "accesslogfilter 503 /accesslogfilter-unavailable") {
+				found = true
+			}
+		}
+		if !found {
+			t.Logf("no access log for the 503 response in pod %s yet, retrying...", routerPod.Name)
+		}
+		return found, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe the access log for the 503 response: %v", err)
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "/accesslogfilter-ok") {
+			t.Errorf("expected the 200 response not to be logged, found: %s", line)
+		}
+	}
+}
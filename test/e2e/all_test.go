@@ -21,20 +21,25 @@ func TestAll(t *testing.T) {
 	// invoke t.Parallel().
 	t.Run("parallel", func(t *testing.T) {
 		t.Run("TestAWSELBConnectionIdleTimeout", TestAWSELBConnectionIdleTimeout)
+		t.Run("TestAWSLoadBalancerControllerProvisioner", TestAWSLoadBalancerControllerProvisioner)
 		t.Run("TestClientTLS", TestClientTLS)
 		t.Run("TestMTLSWithCRLs", TestMTLSWithCRLs)
 		t.Run("TestCRLUpdate", TestCRLUpdate)
 		t.Run("TestContainerLogging", TestContainerLogging)
 		t.Run("TestContainerLoggingMaxLength", TestContainerLoggingMaxLength)
 		t.Run("TestContainerLoggingMinLength", TestContainerLoggingMinLength)
+		t.Run("TestAccessLogFilter", TestAccessLogFilter)
 		t.Run("TestCustomErrorpages", TestCustomErrorpages)
+		t.Run("TestCustomErrorpagesByDomain", TestCustomErrorpagesByDomain)
 		t.Run("TestCustomIngressClass", TestCustomIngressClass)
 		t.Run("TestDomainNotMatchingBase", TestDomainNotMatchingBase)
+		t.Run("TestEchoServerTLSAndHTTP2", TestEchoServerTLSAndHTTP2)
 		t.Run("TestUnsupportedConfigOverride", TestUnsupportedConfigOverride)
 		t.Run("TestForwardedHeaderPolicyAppend", TestForwardedHeaderPolicyAppend)
 		t.Run("TestForwardedHeaderPolicyIfNone", TestForwardedHeaderPolicyIfNone)
 		t.Run("TestForwardedHeaderPolicyNever", TestForwardedHeaderPolicyNever)
 		t.Run("TestForwardedHeaderPolicyReplace", TestForwardedHeaderPolicyReplace)
+		t.Run("TestForwardedHeaderSuppression", TestForwardedHeaderSuppression)
 		t.Run("TestHAProxyTimeouts", TestHAProxyTimeouts)
 		t.Run("TestHAProxyTimeoutsRejection", TestHAProxyTimeoutsRejection)
 		t.Run("TestCookieLen", TestCookieLen)
@@ -44,6 +49,7 @@ func TestAll(t *testing.T) {
 		t.Run("TestHeaderNameCaseAdjustment", TestHeaderNameCaseAdjustment)
 		t.Run("TestHealthCheckIntervalIngressController", TestHealthCheckIntervalIngressController)
 		t.Run("TestHostNetworkEndpointPublishingStrategy", TestHostNetworkEndpointPublishingStrategy)
+		t.Run("TestHostNetworkSingleReplicaRollout", TestHostNetworkSingleReplicaRollout)
 		t.Run("TestIngressControllerScale", TestIngressControllerScale)
 		t.Run("TestIngressControllerServiceNameCollision", TestIngressControllerServiceNameCollision)
 		t.Run("TestInternalLoadBalancer", TestInternalLoadBalancer)
@@ -53,17 +59,28 @@ func TestAll(t *testing.T) {
 		t.Run("TestNetworkLoadBalancer", TestNetworkLoadBalancer)
 		t.Run("TestNodePortServiceEndpointPublishingStrategy", TestNodePortServiceEndpointPublishingStrategy)
 		t.Run("TestProxyProtocolAPI", TestProxyProtocolAPI)
+		t.Run("TestProxyProtocolV2", TestProxyProtocolV2)
+		t.Run("TestZoneAwareRouting", TestZoneAwareRouting)
+		t.Run("TestHTTP3", TestHTTP3)
+		t.Run("TestDefaultCertificateSource", TestDefaultCertificateSource)
+		t.Run("TestStrictHostValidation", TestStrictHostValidation)
 		t.Run("TestRouteAdmissionPolicy", TestRouteAdmissionPolicy)
+		t.Run("TestRouteDefaults", TestRouteDefaults)
+		t.Run("TestRouteDefaultInsecurePolicy", TestRouteDefaultInsecurePolicy)
+		t.Run("TestRouteDefaultPassthroughBalance", TestRouteDefaultPassthroughBalance)
 		t.Run("TestRouterCompressionParsing", TestRouterCompressionParsing)
 		t.Run("TestScopeChange", TestScopeChange)
 		t.Run("TestSyslogLogging", TestSyslogLogging)
+		t.Run("TestSyslogLoggingFacilityAndMaxLength", TestSyslogLoggingFacilityAndMaxLength)
 		t.Run("TestTLSSecurityProfile", TestTLSSecurityProfile)
+		t.Run("TestMetricsTLSSecurityProfile", TestMetricsTLSSecurityProfile)
 		t.Run("TestTunableMaxConnectionsInvalidValues", TestTunableMaxConnectionsInvalidValues)
 		t.Run("TestTunableMaxConnectionsValidValues", TestTunableMaxConnectionsValidValues)
 		t.Run("TestTunableRouterKubeletProbesForCustomIngressController", TestTunableRouterKubeletProbesForCustomIngressController)
 		t.Run("TestUniqueDomainRejection", TestUniqueDomainRejection)
 		t.Run("TestUniqueIdHeader", TestUniqueIdHeader)
 		t.Run("TestUserDefinedIngressController", TestUserDefinedIngressController)
+		t.Run("TestWebSocketAndGRPCWithTuningOptions", TestWebSocketAndGRPCWithTuningOptions)
 		t.Run("TestIngressOperatorCacheIsNotGlobal", TestIngressOperatorCacheIsNotGlobal)
 		t.Run("TestDeleteIngressControllerShouldClearRouteStatus", TestDeleteIngressControllerShouldClearRouteStatus)
 		t.Run("TestIngressControllerRouteSelectorUpdateShouldClearRouteStatus", TestIngressControllerRouteSelectorUpdateShouldClearRouteStatus)
@@ -72,10 +89,16 @@ func TestAll(t *testing.T) {
 		t.Run("TestAWSLBTypeChange", TestAWSLBTypeChange)
 		t.Run("TestAllowedSourceRanges", TestAllowedSourceRanges)
 		t.Run("TestAllowedSourceRangesStatus", TestAllowedSourceRangesStatus)
+		t.Run("TestAllowedSourceRangesAnnotationOverridden", TestAllowedSourceRangesAnnotationOverridden)
 		t.Run("TestSourceRangesProgressingAndEvaluationConditionsDetectedStatuses", TestSourceRangesProgressingAndEvaluationConditionsDetectedStatuses)
 		t.Run("TestUnmanagedDNSToManagedDNSIngressController", TestUnmanagedDNSToManagedDNSIngressController)
 		t.Run("TestManagedDNSToUnmanagedDNSIngressController", TestManagedDNSToUnmanagedDNSIngressController)
 		t.Run("TestUnmanagedDNSToManagedDNSInternalIngressController", TestUnmanagedDNSToManagedDNSInternalIngressController)
+		t.Run("TestRouterMetricsRouteAllowList", TestRouterMetricsRouteAllowList)
+		t.Run("TestLoadBalancerServiceStrategyUnsupportedOnPlatform", TestLoadBalancerServiceStrategyUnsupportedOnPlatform)
+		t.Run("TestStreamingResponses", TestStreamingResponses)
+		t.Run("TestEgressDSCP", TestEgressDSCP)
+		t.Run("TestStrictSNI", TestStrictSNI)
 		t.Run("TestRouteMetricsControllerOnlyRouteSelector", TestRouteMetricsControllerOnlyRouteSelector)
 		t.Run("TestRouteMetricsControllerOnlyNamespaceSelector", TestRouteMetricsControllerOnlyNamespaceSelector)
 		t.Run("TestRouteMetricsControllerRouteAndNamespaceSelector", TestRouteMetricsControllerRouteAndNamespaceSelector)
@@ -88,6 +111,10 @@ func TestAll(t *testing.T) {
 		t.Run("TestUnmanagedAWSLBSubnets", TestUnmanagedAWSLBSubnets)
 		t.Run("TestAWSEIPAllocationsForNLB", TestAWSEIPAllocationsForNLB)
 		t.Run("TestUnmanagedAWSEIPAllocations", TestUnmanagedAWSEIPAllocations)
+		t.Run("TestAWSNLBDualstackIPAddressType", TestAWSNLBDualstackIPAddressType)
+		t.Run("TestDNSZoneTargets", TestDNSZoneTargets)
+		t.Run("TestDNSRecordTarget", TestDNSRecordTarget)
+		t.Run("TestIngressControllerDomainMigration", TestIngressControllerDomainMigration)
 	})
 
 	t.Run("serial", func(t *testing.T) {
@@ -113,15 +140,21 @@ func TestAll(t *testing.T) {
 		t.Run("TestRouteHardStopAfterTestOneDayDuration", TestRouteHardStopAfterTestOneDayDuration)
 		t.Run("TestRouteHardStopAfterTestZeroLengthDuration", TestRouteHardStopAfterTestZeroLengthDuration)
 		t.Run("TestRouteNbthreadIngressController", TestRouteNbthreadIngressController)
+		t.Run("TestDynamicConfigManagerOnDefaultIngressController", TestDynamicConfigManagerOnDefaultIngressController)
 		t.Run("TestRouterCompressionOperation", TestRouterCompressionOperation)
 		t.Run("TestUpdateDefaultIngressControllerSecret", TestUpdateDefaultIngressControllerSecret)
 		t.Run("TestCanaryRoute", TestCanaryRoute)
 		t.Run("TestCanaryWithMTLS", TestCanaryWithMTLS)
 		t.Run("TestCanaryRouteClearsSpecHost", TestCanaryRouteClearsSpecHost)
+		t.Run("TestCanaryUserProbe", TestCanaryUserProbe)
+		t.Run("TestRouterRBACSelfHealing", TestRouterRBACSelfHealing)
+		t.Run("TestCanaryServingCertificateTrust", TestCanaryServingCertificateTrust)
 		t.Run("TestRouteHTTP2EnableAndDisableIngressConfig", TestRouteHTTP2EnableAndDisableIngressConfig)
 		t.Run("TestRouteHardStopAfterEnableOnIngressConfig", TestRouteHardStopAfterEnableOnIngressConfig)
 		t.Run("TestRouteHardStopAfterEnableOnIngressControllerHasPriorityOverIngressConfig", TestRouteHardStopAfterEnableOnIngressControllerHasPriorityOverIngressConfig)
 		t.Run("TestHostNetworkPortBinding", TestHostNetworkPortBinding)
+		t.Run("TestServingNodeAddressesTrackNodeDeletion", TestServingNodeAddressesTrackNodeDeletion)
 		t.Run("TestDashboardCreation", TestDashboardCreation)
+		t.Run("TestIngressControllerLifecycleSoak", TestIngressControllerLifecycleSoak)
 	})
 }
@@ -397,3 +397,96 @@ func TestSourceRangesProgressingAndEvaluationConditionsDetectedStatuses(t *testi
 		t.Fatalf("expected ingresscontroller to have progressing=false and evaluationConditionsDetected=false: %v, lbService: %v", err, lbService)
 	}
 }
+
+// TestAllowedSourceRangesAnnotationOverridden creates an ingresscontroller
+// with the "LoadBalancerService" endpoint publishing strategy type and
+// AllowedSourceRanges set, manually adds a conflicting
+// service.beta.kubernetes.io/load-balancer-source-ranges annotation to the
+// service, and verifies that the operator removes the annotation, keeps the
+// LoadBalancerSourceRanges field of the service matching AllowedSourceRanges,
+// and reports the ingresscontroller's ranges in
+// status.endpointPublishingStrategy.loadBalancer.allowedSourceRanges.
+func TestAllowedSourceRangesAnnotationOverridden(t *testing.T) {
+	t.Parallel()
+
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	supportedPlatforms := map[configv1.PlatformType]struct{}{
+		configv1.AWSPlatformType:   {},
+		configv1.AzurePlatformType: {},
+		configv1.GCPPlatformType:   {},
+	}
+	if _, supported := supportedPlatforms[infraConfig.Status.PlatformStatus.Type]; !supported {
+		t.Skipf("test skipped on platform %q", infraConfig.Status.PlatformStatus.Type)
+	}
+
+	allowedCIDR := "10.0.0.0/8"
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "sourcerangeconflict"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(name, domain)
+	ic.Spec.EndpointPublishingStrategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+		Scope:               operatorv1.ExternalLoadBalancer,
+		AllowedSourceRanges: []operatorv1.CIDR{operatorv1.CIDR(allowedCIDR)},
+		DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	// Wait for the load balancer and DNS to be ready.
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, name, availableConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	// Set the annotation to a range that conflicts with AllowedSourceRanges.
+	lbService := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.LoadBalancerServiceName(ic), lbService); err != nil {
+		t.Fatalf("failed to get service: %v", err)
+	}
+	lbService.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey] = "127.0.0.0/8"
+	if err := kclient.Update(context.TODO(), lbService); err != nil {
+		t.Fatalf("failed to update service: %v", err)
+	}
+
+	// The operator must remove the annotation and keep the field.
+	err := wait.PollImmediate(5*time.Second, 2*time.Minute, func() (bool, error) {
+		if err := kclient.Get(context.TODO(), controller.LoadBalancerServiceName(ic), lbService); err != nil {
+			t.Logf("failed to get service: %v, retrying...", err)
+			return false, nil
+		}
+		if a, ok := lbService.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey]; ok {
+			t.Logf("service still has annotation %s=%q, retrying...", corev1.AnnotationLoadBalancerSourceRangesKey, a)
+			return false, nil
+		}
+		return reflect.DeepEqual(lbService.Spec.LoadBalancerSourceRanges, []string{allowedCIDR}), nil
+	})
+	if err != nil {
+		t.Fatalf("expected the operator to remove the conflicting annotation and keep LoadBalancerSourceRanges %v: %v, lbService: %v", []string{allowedCIDR}, err, lbService)
+	}
+
+	// The status must report the ingresscontroller's ranges, and the
+	// ingresscontroller must stop progressing.
+	err = wait.PollImmediate(5*time.Second, 2*time.Minute, func() (bool, error) {
+		if err := kclient.Get(context.TODO(), name, ic); err != nil {
+			t.Logf("failed to get ingresscontroller: %v, retrying...", err)
+			return false, nil
+		}
+		eps := ic.Status.EndpointPublishingStrategy
+		if eps == nil || eps.LoadBalancer == nil || !reflect.DeepEqual(eps.LoadBalancer.AllowedSourceRanges, []operatorv1.CIDR{operatorv1.CIDR(allowedCIDR)}) {
+			t.Logf("ingresscontroller status does not yet report allowed source ranges %v, retrying...", allowedCIDR)
+			return false, nil
+		}
+		for _, cond := range ic.Status.Conditions {
+			if cond.Type == operatorv1.OperatorStatusTypeProgressing && cond.Status != operatorv1.ConditionFalse {
+				t.Logf("ingresscontroller is still progressing: %s, retrying...", cond.Message)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("expected status.endpointPublishingStrategy.loadBalancer.allowedSourceRanges to be %v: %v", allowedCIDR, err)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// awsLoadBalancerControllerCapabilityEnvVar is the name of the environment
+// variable that CI sets to "true" when the AWS Load Balancer Controller is
+// installed on the test cluster.
+const awsLoadBalancerControllerCapabilityEnvVar = "E2E_AWS_LOAD_BALANCER_CONTROLLER_INSTALLED"
+
+// TestAWSLoadBalancerControllerProvisioner verifies that an ingresscontroller
+// that specifies the "ALBController" AWS load balancer provisioner gets a
+// load balancer service with the AWS Load Balancer Controller's load balancer
+// class, that the operator reports that the controller is available, and that
+// the operator manages DNS for the resulting load balancer.
+//
+// This test requires the AWS Load Balancer Controller, which is not installed
+// by default, so CI must opt in by setting
+// E2E_AWS_LOAD_BALANCER_CONTROLLER_INSTALLED=true.
+func TestAWSLoadBalancerControllerProvisioner(t *testing.T) {
+	t.Parallel()
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	if infraConfig.Status.PlatformStatus.Type != configv1.AWSPlatformType {
+		t.Skipf("test skipped on platform %q", infraConfig.Status.PlatformStatus.Type)
+	}
+	if os.Getenv(awsLoadBalancerControllerCapabilityEnvVar) != "true" {
+		t.Skipf("test skipped because %s is not set to \"true\"", awsLoadBalancerControllerCapabilityEnvVar)
+	}
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "aws-lb-controller"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"awsLoadBalancerProvisioner":"ALBController"}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	expected := operatorv1.OperatorCondition{
+		Type:   ingresscontroller.IngressControllerAWSLoadBalancerControllerAvailableConditionType,
+		Status: operatorv1.ConditionTrue,
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 1*time.Minute, icName, expected); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.LoadBalancerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get load balancer service: %v", err)
+	}
+	if service.Spec.LoadBalancerClass == nil || *service.Spec.LoadBalancerClass != "service.k8s.aws/nlb" {
+		t.Fatalf("expected load balancer class %q, got %v", "service.k8s.aws/nlb", service.Spec.LoadBalancerClass)
+	}
+	if len(service.Status.LoadBalancer.Ingress) == 0 || len(service.Status.LoadBalancer.Ingress[0].Hostname) == 0 {
+		t.Fatalf("expected load balancer service to have a hostname, got %+v", service.Status.LoadBalancer)
+	}
+	hostname := service.Status.LoadBalancer.Ingress[0].Hostname
+
+	wildcardRecordName := controller.WildcardDNSRecordName(ic)
+	wildcardRecord := &iov1.DNSRecord{}
+	if err := kclient.Get(context.TODO(), wildcardRecordName, wildcardRecord); err != nil {
+		t.Fatalf("failed to get wildcard dnsrecord %s: %v", wildcardRecordName, err)
+	}
+	if len(wildcardRecord.Spec.Targets) != 1 || wildcardRecord.Spec.Targets[0] != hostname {
+		t.Errorf("expected wildcard dnsrecord %s to target %q, got %v", wildcardRecordName, hostname, wildcardRecord.Spec.Targets)
+	}
+}
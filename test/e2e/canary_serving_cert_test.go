@@ -0,0 +1,133 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+)
+
+// TestCanaryServingCertificateTrust verifies that the canary serves a
+// certificate for the canary route's host that the operator's CA signs and
+// that canary checks, which verify that certificate, keep succeeding when the
+// default ingresscontroller's default certificate is replaced with one that
+// the operator does not trust.
+func TestCanaryServingCertificateTrust(t *testing.T) {
+	ic := &operatorv1.IngressController{}
+	if err := kclient.Get(context.TODO(), defaultName, ic); err != nil {
+		t.Fatalf("failed to get default ingresscontroller: %v", err)
+	}
+
+	caSecret := &corev1.Secret{}
+	if err := kclient.Get(context.TODO(), controller.RouterCASecretName(operatorNamespace), caSecret); err != nil {
+		t.Fatalf("failed to get CA secret: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caSecret.Data["tls.crt"]) {
+		t.Fatalf("failed to parse CA certificate")
+	}
+	host := controller.CanaryRouteHost(ic.Status.Domain)
+	servingCertSecret := &corev1.Secret{}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, controller.CanaryServingCertSecretName(), servingCertSecret); err != nil {
+			t.Logf("failed to get canary serving certificate secret: %v, retrying...", err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe canary serving certificate secret: %v", err)
+	}
+	block, _ := pem.Decode(servingCertSecret.Data["tls.crt"])
+	if block == nil {
+		t.Fatalf("failed to decode canary serving certificate")
+	}
+	servingCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse canary serving certificate: %v", err)
+	}
+	if _, err := servingCert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+		t.Fatalf("failed to verify canary serving certificate for %s using the operator's CA: %v", host, err)
+	}
+
+	// Replace the default certificate with one that is not signed by the
+	// operator's CA.
+	secretName := names.SimpleNameGenerator.GenerateName("untrusted-default-cert-")
+	secret, err := createDefaultCertTestSecret(kclient, secretName)
+	if err != nil {
+		t.Fatalf("failed to create secret %s: %v", secretName, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), secret); err != nil {
+			t.Errorf("failed to delete secret %s: %v", secretName, err)
+		}
+	})
+	originalSecret := ic.Spec.DefaultCertificate.DeepCopy()
+	if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+		ic.Spec.DefaultCertificate = &corev1.LocalObjectReference{Name: secret.Name}
+	}); err != nil {
+		t.Fatalf("failed to update default ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			ic.Spec.DefaultCertificate = originalSecret
+		}); err != nil {
+			t.Errorf("failed to reset default ingresscontroller: %v", err)
+		}
+	})
+
+	deployment := &appsv1.Deployment{}
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, controller.RouterDeploymentName(ic), deployment); err != nil {
+			t.Logf("failed to get deployment %s: %v, retrying...", controller.RouterDeploymentName(ic), err)
+			return false, nil
+		}
+		return deployment.Spec.Template.Spec.Volumes[0].Secret.SecretName == secret.Name, nil
+	}); err != nil {
+		t.Fatalf("failed to observe updated deployment: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 5*time.Minute); err != nil {
+		t.Fatalf("failed to observe router rollout: %v", err)
+	}
+
+	// Canary checks run every minute, and the canary status condition
+	// reports failure after 5 successive failures, so poll long enough to
+	// observe a failure if the checks do not verify the canary serving
+	// certificate independently of the default certificate.
+	if err := wait.PollUntilContextTimeout(context.Background(), 20*time.Second, 6*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, defaultName, ic); err != nil {
+			t.Logf("failed to get default ingresscontroller: %v, retrying...", err)
+			return false, nil
+		}
+		for _, condition := range ic.Status.Conditions {
+			if condition.Type != ingresscontroller.IngressControllerCanaryCheckSuccessConditionType {
+				continue
+			}
+			if condition.Status == operatorv1.ConditionFalse {
+				t.Fatalf("canary checks failed after the default certificate was replaced: %s: %s", condition.Reason, condition.Message)
+			}
+		}
+		return false, nil
+	}); err != nil && !wait.Interrupted(err) {
+		t.Fatalf("failed to observe canary status: %v", err)
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 1*time.Minute, defaultName, operatorv1.OperatorCondition{
+		Type:   ingresscontroller.IngressControllerCanaryCheckSuccessConditionType,
+		Status: operatorv1.ConditionTrue,
+	}); err != nil {
+		t.Fatalf("failed to observe successful canary checks: %v", err)
+	}
+}
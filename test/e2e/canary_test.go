@@ -6,6 +6,7 @@ package e2e
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -25,7 +26,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/pointer"
 )
@@ -367,3 +370,97 @@ func TestCanaryWithMTLS(t *testing.T) {
 		t.Fatalf("Expected poll to time out.")
 	}
 }
+
+// TestCanaryUserProbe verifies that the canary controller probes a
+// user-designated application that the default ingresscontroller specifies
+// using spec.unsupportedConfigOverrides.canaryUserProbe, reports the result
+// using the UserProbeSucceeding status condition, and does not mark the
+// ingresscontroller or the clusteroperator unavailable when the probe fails.
+func TestCanaryUserProbe(t *testing.T) {
+	t.Log("Waiting for the default IngressController to be available...")
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, defaultAvailableConditions...); err != nil {
+		t.Fatal(err)
+	}
+	ic := &operatorv1.IngressController{}
+	if err := kclient.Get(context.TODO(), defaultName, ic); err != nil {
+		t.Fatalf("failed to get ingresscontroller %s: %v", defaultName, err)
+	}
+	originalOverrides := ic.Spec.UnsupportedConfigOverrides.DeepCopy()
+
+	// Create a stub application behind an edge-terminated route under the
+	// default ingresscontroller's domain.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-user-probe-"))
+	echoPod := buildEchoPod("user-probe-echo", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	echoRoute := buildRoute(echoPod.Name, ns.Name, echoService.Name)
+	echoRoute.Spec.Host = "user-probe-" + ns.Name + "." + ic.Status.Domain
+	echoRoute.Spec.TLS = &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}
+	if err := kclient.Create(context.TODO(), echoRoute); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", echoRoute.Namespace, echoRoute.Name, err)
+	}
+
+	setProbe := func(expectedBodySubstring string) {
+		t.Helper()
+		probe := ingresscontroller.CanaryUserProbe{
+			Hostname:              echoRoute.Spec.Host,
+			Path:                  "/healthz",
+			ExpectedBodySubstring: expectedBodySubstring,
+			// The default certificate may be self-signed.
+			TLSVerification: ingresscontroller.CanaryUserProbeTLSSkip,
+		}
+		raw, err := json.Marshal(map[string]interface{}{"canaryUserProbe": probe})
+		if err != nil {
+			t.Fatalf("failed to encode canary user probe: %v", err)
+		}
+		if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: raw}
+		}); err != nil {
+			t.Fatalf("failed to update ingresscontroller %s: %v", defaultName, err)
+		}
+	}
+	t.Cleanup(func() {
+		if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			ic.Spec.UnsupportedConfigOverrides = *originalOverrides
+		}); err != nil {
+			t.Errorf("failed to restore ingresscontroller %s: %v", defaultName, err)
+		}
+	})
+
+	// The echo server echoes the request, so the response body includes
+	// the request path.
+	setProbe("/healthz")
+	t.Log("Waiting for the canary user probe to succeed...")
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, operatorv1.OperatorCondition{
+		Type:   ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType,
+		Status: operatorv1.ConditionTrue,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	setProbe("this string is not in the response")
+	t.Log("Waiting for the canary user probe to fail...")
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, defaultName, operatorv1.OperatorCondition{
+		Type:   ingresscontroller.IngressControllerCanaryUserProbeSuccessConditionType,
+		Status: operatorv1.ConditionFalse,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The probe does not affect availability unless the ingresscontroller
+	// opts in.
+	if err := waitForIngressControllerCondition(t, kclient, 1*time.Minute, defaultName, defaultAvailableConditions...); err != nil {
+		t.Errorf("expected the default ingresscontroller to remain available: %v", err)
+	}
+	expected := []configv1.ClusterOperatorStatusCondition{
+		{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+	}
+	if err := waitForClusterOperatorConditions(t, kclient, expected...); err != nil {
+		t.Errorf("expected the clusteroperator to remain available: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestDefaultCertificateSource creates an ingresscontroller that uses a secret
+// in another namespace as its default certificate by way of the
+// "defaultCertificateSource" unsupported config override.  The test verifies
+// that the operator does not copy the secret until the router's service
+// account is granted access to it, that the router serves the secret's
+// certificate once it is granted access, that a rotation of the secret
+// propagates to the router, and that revoking the access sets the
+// "DefaultCertificateSourceSynced" status condition to false while the router
+// continues to serve the last copied certificate.
+func TestDefaultCertificateSource(t *testing.T) {
+	t.Parallel()
+
+	ns := createNamespace(t, "default-cert-source-e2e")
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "default-cert-source"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns.Name,
+			Name:      "wildcard",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: defaultCertificateSourceData("default-cert-source-1"),
+	}
+	if err := kclient.Create(context.TODO(), source); err != nil {
+		t.Fatalf("failed to create secret %s/%s: %v", source.Namespace, source.Name, err)
+	}
+
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"defaultCertificateSource":{"namespace":%q,"name":%q}}`, source.Namespace, source.Name))}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+
+	// The router is not yet permitted to read the secret, so the operator
+	// must not copy it.
+	notSynced := operatorv1.OperatorCondition{Type: ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType, Status: operatorv1.ConditionFalse}
+	if err := waitForIngressControllerCondition(t, kclient, 2*time.Minute, icName, notSynced); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	copyName := controller.RouterSyncedDefaultCertificateSecretName(ic, controller.DefaultOperandNamespace)
+	if err := kclient.Get(context.TODO(), copyName, &corev1.Secret{}); err == nil {
+		t.Fatalf("expected secret %s not to exist before the router is granted access to the source secret", copyName)
+	}
+
+	// Grant the router access to the secret.
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "router-default-certificate"},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{source.Name},
+			Verbs:         []string{"get"},
+		}},
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "router-default-certificate"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: role.Name},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: controller.DefaultOperandNamespace,
+			Name:      "router",
+		}},
+	}
+	for _, obj := range []client.Object{role, roleBinding} {
+		if err := kclient.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("failed to create %T %s/%s: %v", obj, obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	synced := operatorv1.OperatorCondition{Type: ingresscontroller.IngressControllerDefaultCertificateSourceSyncedConditionType, Status: operatorv1.ConditionTrue}
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, append([]operatorv1.OperatorCondition{synced}, availableConditionsForPrivateIngressController...)...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("deployment has invalid selector: %v", err)
+	}
+	routerPods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), routerPods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list router pods: %v", err)
+	}
+	if len(routerPods.Items) == 0 {
+		t.Fatal("expected at least one router pod")
+	}
+	routerPodIP := routerPods.Items[0].Status.PodIP
+
+	clientPod := buildExecPod("default-cert-source-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	if err := waitForPodReady(t, kclient, clientPod, 5*time.Minute); err != nil {
+		t.Fatalf("failed to wait for pod %s/%s to become ready: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+
+	// waitForServedCertificate waits for the router to serve a certificate
+	// with the given common name.
+	host := "test." + domain
+	cmd := []string{"/bin/curl", "-k", "-v", "-s", "-o", "/dev/null", "--max-time", "10", "--resolve", fmt.Sprintf("%s:443:%s", host, routerPodIP), "https://" + host + "/"}
+	waitForServedCertificate := func(t *testing.T, commonName string) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+				t.Logf("failed to send request: %v: %s, retrying...", err, stderr.String())
+				return false, nil
+			}
+			for _, line := range strings.Split(stderr.String(), "\n") {
+				if strings.Contains(line, "subject:") && strings.Contains(line, "CN="+commonName) {
+					return true, nil
+				}
+			}
+			t.Logf("router does not serve the certificate with common name %q, retrying...", commonName)
+			return false, nil
+		}); err != nil {
+			t.Fatalf("failed to observe the router serve the certificate with common name %q: %v", commonName, err)
+		}
+	}
+	waitForServedCertificate(t, "default-cert-source-1")
+
+	// Rotate the certificate and verify that the router picks up the new
+	// one.
+	updateSource := func(t *testing.T, commonName string) {
+		t.Helper()
+		if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: source.Namespace, Name: source.Name}, source); err != nil {
+			t.Fatalf("failed to get secret %s/%s: %v", source.Namespace, source.Name, err)
+		}
+		source.Data = defaultCertificateSourceData(commonName)
+		if err := kclient.Update(context.TODO(), source); err != nil {
+			t.Fatalf("failed to update secret %s/%s: %v", source.Namespace, source.Name, err)
+		}
+	}
+	updateSource(t, "default-cert-source-2")
+	waitForServedCertificate(t, "default-cert-source-2")
+
+	// Revoke the router's access and rotate the certificate again.  The
+	// operator must report the revocation and keep the last copy, and the
+	// router must continue to serve it.
+	if err := kclient.Delete(context.TODO(), roleBinding); err != nil {
+		t.Fatalf("failed to delete rolebinding %s/%s: %v", roleBinding.Namespace, roleBinding.Name, err)
+	}
+	updateSource(t, "default-cert-source-3")
+	if err := waitForIngressControllerCondition(t, kclient, 3*time.Minute, icName, append([]operatorv1.OperatorCondition{notSynced}, availableConditionsForPrivateIngressController...)...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	copied := &corev1.Secret{}
+	if err := kclient.Get(context.TODO(), copyName, copied); err != nil {
+		t.Fatalf("expected secret %s to be kept after the router's access was revoked: %v", copyName, err)
+	}
+	if cn := certificateCommonName(t, copied.Data[corev1.TLSCertKey]); cn != "default-cert-source-2" {
+		t.Fatalf("expected secret %s to have the last copied certificate, got certificate with common name %q", copyName, cn)
+	}
+	waitForServedCertificate(t, "default-cert-source-2")
+}
+
+// defaultCertificateSourceData returns the data of a TLS secret with a new
+// self-signed certificate with the given common name.
+func defaultCertificateSourceData(commonName string) map[string][]byte {
+	keyCert := MustCreateTLSKeyCert(commonName, time.Now(), time.Now().Add(24*time.Hour), false, nil, nil)
+	key := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(keyCert.Key)})
+	return map[string][]byte{
+		corev1.TLSCertKey:       []byte(keyCert.CertPem),
+		corev1.TLSPrivateKeyKey: key,
+	}
+}
+
+// certificateCommonName returns the common name of the given PEM-encoded
+// certificate.
+func certificateCommonName(t *testing.T, data []byte) string {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("failed to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,126 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestDNSRecordTarget creates an IngressController that specifies a vanity
+// hostname as the target of its wildcard DNS record using
+// spec.unsupportedConfigOverrides.dnsRecordTarget.  The test verifies that the
+// DNS controller publishes the vanity hostname as the record's target in both
+// zones while the DNSRecord's spec keeps tracking the load balancer, and that
+// the DNS controller publishes the load balancer's hostname again once the
+// override is cleared.
+func TestDNSRecordTarget(t *testing.T) {
+	t.Parallel()
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	if infraConfig.Status.PlatformStatus.Type != configv1.AWSPlatformType {
+		t.Skipf("test skipped on platform %q", infraConfig.Status.PlatformStatus.Type)
+	}
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "dns-record-target"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(name, domain)
+	ic.Spec.EndpointPublishingStrategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+		Scope:               operatorv1.ExternalLoadBalancer,
+		DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+	}
+	// example.net is reserved for documentation, so the published record
+	// does not route anywhere, which is fine for verifying that it is
+	// published.
+	const vanity = "ingress.example.net"
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"dnsRecordTarget":"` + vanity + `"}`),
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableNotProgressingConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	lbService := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.LoadBalancerServiceName(ic), lbService); err != nil {
+		t.Fatalf("failed to get load balancer service: %v", err)
+	}
+	if len(lbService.Status.LoadBalancer.Ingress) == 0 || len(lbService.Status.LoadBalancer.Ingress[0].Hostname) == 0 {
+		t.Fatalf("load balancer service has no hostname: %+v", lbService.Status.LoadBalancer)
+	}
+	lbHostname := lbService.Status.LoadBalancer.Ingress[0].Hostname
+
+	wildcardRecordName := controller.WildcardDNSRecordName(ic)
+	if err := waitForPublishedDNSRecordTarget(t, wildcardRecordName, lbHostname, vanity); err != nil {
+		t.Fatalf("failed to observe wildcard dnsrecord %s published with target %s: %v", wildcardRecordName, vanity, err)
+	}
+
+	if err := updateIngressControllerWithRetryOnConflict(t, name, 1*time.Minute, func(ic *operatorv1.IngressController) {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+	}); err != nil {
+		t.Fatalf("failed to clear the DNS record target: %v", err)
+	}
+	if err := waitForPublishedDNSRecordTarget(t, wildcardRecordName, lbHostname, lbHostname); err != nil {
+		t.Fatalf("failed to observe wildcard dnsrecord %s published with target %s: %v", wildcardRecordName, lbHostname, err)
+	}
+}
+
+// waitForPublishedDNSRecordTarget waits for the given DNSRecord to track the
+// given load balancer hostname in its spec and to be published to all zones
+// with the given target.
+func waitForPublishedDNSRecordTarget(t *testing.T, name types.NamespacedName, lbHostname, target string) error {
+	t.Helper()
+	return wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(ctx, name, record); err != nil {
+			t.Logf("failed to get dnsrecord %s: %v", name, err)
+			return false, nil
+		}
+		if len(record.Spec.Targets) != 1 || record.Spec.Targets[0] != lbHostname {
+			t.Logf("dnsrecord %s does not track load balancer %s; targets: %v", name, lbHostname, record.Spec.Targets)
+			return false, nil
+		}
+		if record.Generation != record.Status.ObservedGeneration || dnsrecord.PublishPending(record) {
+			t.Logf("dnsrecord %s has not been published yet", name)
+			return false, nil
+		}
+		for _, private := range []bool{false, true} {
+			zoneRecord, err := dnsrecord.RecordForZone(record, private, true)
+			if err != nil {
+				t.Fatalf("dnsrecord %s has invalid published per-zone targets: %v", name, err)
+			}
+			if zoneRecord.Spec.RecordType != iov1.CNAMERecordType || len(zoneRecord.Spec.Targets) != 1 || zoneRecord.Spec.Targets[0] != target {
+				t.Logf("dnsrecord %s is published with %s %v (private zone: %t), expected CNAME %s", name, zoneRecord.Spec.RecordType, zoneRecord.Spec.Targets, private, target)
+				return false, nil
+			}
+		}
+		for _, zone := range record.Status.Zones {
+			for _, condition := range zone.Conditions {
+				if condition.Type == iov1.DNSRecordPublishedConditionType && condition.Status != string(operatorv1.ConditionTrue) {
+					t.Logf("dnsrecord %s is not published to zone %+v: %s", name, zone.DNSZone, condition.Message)
+					return false, nil
+				}
+			}
+		}
+		return len(record.Status.Zones) != 0, nil
+	})
+}
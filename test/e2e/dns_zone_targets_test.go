@@ -0,0 +1,103 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestDNSZoneTargets creates an IngressController that specifies a private VIP
+// as the target for the private zone using
+// spec.unsupportedConfigOverrides.dnsZoneTargets.  The test verifies that the
+// wildcard DNSRecord has the per-zone targets and that the DNS controller
+// publishes the record to both the public zone and the private zone.
+func TestDNSZoneTargets(t *testing.T) {
+	t.Parallel()
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	switch infraConfig.Status.PlatformStatus.Type {
+	case configv1.AWSPlatformType, configv1.AzurePlatformType, configv1.GCPPlatformType:
+	default:
+		t.Skipf("test skipped on platform %q", infraConfig.Status.PlatformStatus.Type)
+	}
+	if dnsConfig.Spec.PrivateZone == nil || dnsConfig.Spec.PublicZone == nil {
+		t.Skip("test skipped because the cluster does not have both a public zone and a private zone")
+	}
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "zone-targets"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(name, domain)
+	ic.Spec.EndpointPublishingStrategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+		Scope:               operatorv1.ExternalLoadBalancer,
+		DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+	}
+	// 192.0.2.0/24 is reserved for documentation, so the private zone's
+	// record does not route anywhere, which is fine for verifying that
+	// it is published.
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"dnsZoneTargets":{"private":["192.0.2.10"]}}`),
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableNotProgressingConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	wildcardRecordName := controller.WildcardDNSRecordName(ic)
+	err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(ctx, wildcardRecordName, record); err != nil {
+			t.Logf("failed to get wildcard dnsrecord %s: %v", wildcardRecordName, err)
+			return false, nil
+		}
+		zoneTargets := record.Annotations[dnsrecord.DNSZoneTargetsAnnotation]
+		if len(zoneTargets) == 0 {
+			t.Logf("wildcard dnsrecord %s has no per-zone targets yet; annotations: %v", wildcardRecordName, record.Annotations)
+			return false, nil
+		}
+		if published := record.Annotations[dnsrecord.DNSZoneTargetsPublishedAnnotation]; published != zoneTargets {
+			t.Logf("wildcard dnsrecord %s has not been published with per-zone targets %s yet; published: %q", wildcardRecordName, zoneTargets, published)
+			return false, nil
+		}
+		privateZone, err := dnsrecord.RecordForZone(record, true, false)
+		if err != nil {
+			t.Fatalf("wildcard dnsrecord %s has invalid per-zone targets: %v", wildcardRecordName, err)
+		}
+		if privateZone.Spec.RecordType != iov1.ARecordType || len(privateZone.Spec.Targets) != 1 || privateZone.Spec.Targets[0] != "192.0.2.10" {
+			t.Fatalf("expected the private zone record to be an A record for 192.0.2.10, got %s %v", privateZone.Spec.RecordType, privateZone.Spec.Targets)
+		}
+		published := 0
+		for _, zone := range record.Status.Zones {
+			for _, condition := range zone.Conditions {
+				if condition.Type == iov1.DNSRecordPublishedConditionType && condition.Status == string(operatorv1.ConditionTrue) {
+					published++
+				}
+			}
+		}
+		if published != 2 {
+			t.Logf("wildcard dnsrecord %s is not yet published to both zones; status: %+v", wildcardRecordName, record.Status.Zones)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe wildcard dnsrecord %s published with per-zone targets: %v", wildcardRecordName, err)
+	}
+}
@@ -0,0 +1,197 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestIngressControllerDomainMigration verifies that an ingresscontroller
+// migrates to the domain that spec.unsupportedConfigOverrides.domainMigration
+// specifies.  The test creates an ingresscontroller with a throwaway domain
+// and migrates it to another throwaway domain with a short overlap period.
+// During the overlap period, the test verifies that status.domain, the
+// wildcard DNS record, and the router deployment use the new domain, that a
+// wildcard DNS record for the previous domain is published, and that the
+// operator-generated default certificate covers both domains.  After the
+// overlap period, the test verifies that the previous domain's DNS record and
+// certificate SAN are retired.
+func TestIngressControllerDomainMigration(t *testing.T) {
+	t.Parallel()
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	platform := infraConfig.Status.PlatformStatus.Type
+	supportedPlatforms := map[configv1.PlatformType]struct{}{
+		configv1.AWSPlatformType:   {},
+		configv1.AzurePlatformType: {},
+		configv1.GCPPlatformType:   {},
+	}
+	if _, supported := supportedPlatforms[platform]; !supported {
+		t.Skipf("test skipped on platform %q", platform)
+	}
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "domain-migration"}
+	oldDomain := "domain-migration-old." + dnsConfig.Spec.BaseDomain
+	newDomain := "domain-migration-new." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(name, oldDomain)
+	ic.Spec.EndpointPublishingStrategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+		Scope:               operatorv1.ExternalLoadBalancer,
+		DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableNotProgressingConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	wildcardRecordName := controller.WildcardDNSRecordName(ic)
+	previousRecordName := controller.PreviousDomainWildcardDNSRecordName(ic)
+	if err := waitForPublishedWildcardDNSRecord(t, wildcardRecordName, oldDomain); err != nil {
+		t.Fatalf("failed to observe wildcard dnsrecord for domain %s: %v", oldDomain, err)
+	}
+
+	// Use a short overlap period so that the test observes the cutover.
+	const overlapPeriod = 3 * time.Minute
+	if err := updateIngressControllerWithRetryOnConflict(t, name, 1*time.Minute, func(ic *operatorv1.IngressController) {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+			Raw: []byte(fmt.Sprintf(`{"domainMigration":{"domain":%q,"overlapPeriod":%q}}`, newDomain, overlapPeriod)),
+		}
+	}); err != nil {
+		t.Fatalf("failed to specify domain migration: %v", err)
+	}
+
+	// Overlap: both domains are served.
+	overlapping := operatorv1.OperatorCondition{Type: ingresscontroller.IngressControllerDomainMigrationProgressingConditionType, Status: operatorv1.ConditionTrue}
+	if err := waitForIngressControllerCondition(t, kclient, 2*time.Minute, name, overlapping); err != nil {
+		t.Fatalf("failed to observe domain migration overlap: %v", err)
+	}
+	if err := kclient.Get(context.TODO(), name, ic); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+	if ic.Status.Domain != newDomain {
+		t.Fatalf("expected status.domain %q, got %q", newDomain, ic.Status.Domain)
+	}
+	if err := waitForPublishedWildcardDNSRecord(t, wildcardRecordName, newDomain); err != nil {
+		t.Errorf("failed to observe wildcard dnsrecord for domain %s: %v", newDomain, err)
+	}
+	if err := waitForPublishedWildcardDNSRecord(t, previousRecordName, oldDomain); err != nil {
+		t.Errorf("failed to observe wildcard dnsrecord for previous domain %s: %v", oldDomain, err)
+	}
+	if err := waitForDefaultCertificateHostnames(t, ic, "*."+newDomain, "*."+oldDomain); err != nil {
+		t.Errorf("failed to observe default certificate for both domains: %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_DOMAIN", newDomain); err != nil {
+		t.Errorf("failed to observe ROUTER_DOMAIN=%s: %v", newDomain, err)
+	}
+
+	// Cutover: the previous domain is retired.
+	completed := operatorv1.OperatorCondition{Type: ingresscontroller.IngressControllerDomainMigrationProgressingConditionType, Status: operatorv1.ConditionFalse}
+	if err := waitForIngressControllerCondition(t, kclient, overlapPeriod+3*time.Minute, name, completed); err != nil {
+		t.Fatalf("failed to observe domain migration completion: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, previousRecordName, &iov1.DNSRecord{}); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			t.Logf("failed to get dnsrecord %s: %v, retrying...", previousRecordName, err)
+		}
+		return false, nil
+	}); err != nil {
+		t.Errorf("failed to observe deletion of dnsrecord %s: %v", previousRecordName, err)
+	}
+	if err := waitForDefaultCertificateHostnames(t, ic, "*."+newDomain); err != nil {
+		t.Errorf("failed to observe default certificate for the new domain only: %v", err)
+	}
+}
+
+// waitForPublishedWildcardDNSRecord waits for the given DNSRecord to specify
+// the wildcard name for the given domain and to be published to at least one
+// zone.
+func waitForPublishedWildcardDNSRecord(t *testing.T, name types.NamespacedName, domain string) error {
+	t.Helper()
+	expected := "*." + domain + "."
+	return wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(ctx, name, record); err != nil {
+			t.Logf("failed to get dnsrecord %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		if record.Spec.DNSName != expected {
+			t.Logf("dnsrecord %s has name %q, expected %q, retrying...", name, record.Spec.DNSName, expected)
+			return false, nil
+		}
+		for _, zone := range record.Status.Zones {
+			for _, condition := range zone.Conditions {
+				if condition.Type == iov1.DNSRecordPublishedConditionType && condition.Status == string(operatorv1.ConditionTrue) {
+					return true, nil
+				}
+			}
+		}
+		t.Logf("dnsrecord %s is not published yet, retrying...", name)
+		return false, nil
+	})
+}
+
+// waitForDefaultCertificateHostnames waits for the operator-generated default
+// certificate for the given ingresscontroller to have exactly the given DNS
+// names.
+func waitForDefaultCertificateHostnames(t *testing.T, ic *operatorv1.IngressController, hostnames ...string) error {
+	t.Helper()
+	expected := append([]string{}, hostnames...)
+	sort.Strings(expected)
+	name := controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, controller.DefaultOperandNamespace)
+	return wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		secret := &corev1.Secret{}
+		if err := kclient.Get(ctx, name, secret); err != nil {
+			t.Logf("failed to get secret %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		block, _ := pem.Decode(secret.Data["tls.crt"])
+		if block == nil {
+			t.Logf("secret %s has no PEM-encoded certificate, retrying...", name)
+			return false, nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Logf("failed to parse certificate in secret %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		actual := append([]string{}, cert.DNSNames...)
+		sort.Strings(actual)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Logf("certificate in secret %s has DNS names %v, expected %v, retrying...", name, actual, expected)
+			return false, nil
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,93 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestDynamicConfigManagerOnDefaultIngressController enables the dynamic
+// configuration manager on the default ingresscontroller using the
+// "dynamicConfigManager" unsupported config override and verifies that the
+// router deployment is configured to use it and that the router still admits
+// new routes.  The original unsupported config overrides are restored
+// afterwards.
+//
+// Note: This test mutates the default ingresscontroller.
+func TestDynamicConfigManagerOnDefaultIngressController(t *testing.T) {
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, defaultAvailableConditions...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	ic, err := getIngressController(t, kclient, defaultName, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+	if len(ic.Spec.UnsupportedConfigOverrides.Raw) != 0 {
+		t.Skipf("default ingresscontroller already has unsupported config overrides: %s", string(ic.Spec.UnsupportedConfigOverrides.Raw))
+	}
+	deploymentName := controller.RouterDeploymentName(ic)
+	deployment, err := getDeployment(t, kclient, deploymentName, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get router deployment: %v", err)
+	}
+
+	if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"dynamicConfigManager":"true"}`)}
+	}); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	defer func() {
+		if err := updateIngressControllerWithRetryOnConflict(t, defaultName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{}
+		}); err != nil {
+			t.Fatalf("failed to restore ingresscontroller: %v", err)
+		}
+		if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterHAProxyConfigManager, ""); err != nil {
+			t.Errorf("expected router deployment not to set %s: %v", ingresscontroller.RouterHAProxyConfigManager, err)
+		}
+		if err := waitForDeploymentCompleteWithOldPodTermination(t, kclient, deploymentName, 5*time.Minute); err != nil {
+			t.Errorf("failed to observe the router deployment roll out: %v", err)
+		}
+	}()
+
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterHAProxyConfigManager, "true"); err != nil {
+		t.Fatalf("expected router deployment to set %s=true: %v", ingresscontroller.RouterHAProxyConfigManager, err)
+	}
+	if err := waitForDeploymentCompleteWithOldPodTermination(t, kclient, deploymentName, 5*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment roll out: %v", err)
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, defaultAvailableConditions...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	ns := createNamespace(t, "dynamic-config-manager-e2e")
+	echoPod := buildEchoPod("echo", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, name := range []string{"route-1", "route-2"} {
+		route := buildRoute(name, ns.Name, echoService.Name)
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+		}
+		routeName := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		if err := waitForRouteIngressConditions(t, kclient, routeName, ic.Name, admittedCondition); err != nil {
+			t.Errorf("failed to observe route %s admitted by the default ingresscontroller: %v", routeName, err)
+		}
+	}
+}
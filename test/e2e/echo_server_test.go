@@ -0,0 +1,195 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/echo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestEchoServerTLSAndHTTP2 verifies the TLS, HTTP/2, and multiple-port modes
+// of the echo test server that buildEchoPod returns with withEchoServerImage.
+// It creates an echo server that serves plaintext HTTP on two ports, with h2c
+// enabled, and HTTPS with h2 enabled using a serving certificate that the test
+// generates, and it verifies from a client pod that each port serves the
+// expected protocol and that the identifying response headers describe the
+// request.  It also verifies that the default ingresscontroller can use the
+// echo server as the backend of a reencrypt route that specifies the test's CA
+// certificate as the destination CA certificate.
+func TestEchoServerTLSAndHTTP2(t *testing.T) {
+	t.Parallel()
+
+	operatorImage, err := getIngressOperatorDeploymentImage(t, kclient, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingress operator image: %v", err)
+	}
+	ic, err := getIngressController(t, kclient, defaultName, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingresscontroller %s: %v", defaultName, err)
+	}
+
+	ns := createNamespace(t, "echo-server-e2e")
+	serviceHost := fmt.Sprintf("echo.%s.svc", ns.Name)
+	caCert, err := createEchoServingCertSecret(t, "echo-cert", ns.Name, serviceHost)
+	if err != nil {
+		t.Fatalf("failed to create serving certificate secret: %v", err)
+	}
+
+	echoOpts := []echoOption{
+		withEchoServerImage(operatorImage),
+		withEchoHTTPPorts(8080, 8081),
+		withEchoTLS("echo-cert", 8443),
+		withEchoHTTP2(),
+	}
+	echoPod := buildEchoPod("echo", ns.Name, echoOpts...)
+	clientPod := buildExecPod("echo-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels, echoOpts...)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	// Write the CA certificate into the client pod so that curl can
+	// verify the echo server's serving certificate.
+	var stdout, stderr bytes.Buffer
+	if err := podExec(t, *clientPod, &stdout, &stderr, []string{"/bin/sh", "-c", fmt.Sprintf("printf '%%s' '%s' > /tmp/ca.crt", caCert)}); err != nil {
+		t.Fatalf("failed to write CA certificate into pod %s/%s: %v: %s", clientPod.Namespace, clientPod.Name, err, stderr.String())
+	}
+
+	// Expose the HTTPS port through the default ingresscontroller using a
+	// reencrypt route, and reach it through the router's internal service
+	// so that the test does not depend on DNS for the route's host.
+	routeHost := fmt.Sprintf("echo-reencrypt-%s.%s", ns.Name, ic.Status.Domain)
+	route := buildRouteWithTermination("echo-reencrypt", ns.Name, echoService.Name, routeHost, "https", routev1.TLSTerminationReencrypt)
+	route.Spec.TLS.DestinationCACertificate = caCert
+	if err := kclient.Create(context.TODO(), route); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+	}
+	internalService := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), internalService); err != nil {
+		t.Fatalf("failed to get service %s: %v", controller.InternalIngressControllerServiceName(ic), err)
+	}
+
+	testCases := []struct {
+		name          string
+		curlArgs      []string
+		expectedHost  string
+		expectedProto string
+		expectedPort  string
+		expectedTLS   bool
+	}{{
+		name:          "HTTP/1.1 on the first plaintext port",
+		curlArgs:      []string{"http://" + serviceHost + "/"},
+		expectedHost:  serviceHost,
+		expectedProto: "HTTP/1.1",
+		expectedPort:  "8080",
+	}, {
+		name:          "h2c on the second plaintext port",
+		curlArgs:      []string{"--http2-prior-knowledge", "http://" + serviceHost + ":8081/"},
+		expectedHost:  serviceHost + ":8081",
+		expectedProto: "HTTP/2.0",
+		expectedPort:  "8081",
+	}, {
+		name:          "HTTP/1.1 over TLS",
+		curlArgs:      []string{"--http1.1", "--cacert", "/tmp/ca.crt", "https://" + serviceHost + ":8443/"},
+		expectedHost:  serviceHost + ":8443",
+		expectedProto: "HTTP/1.1",
+		expectedPort:  "8443",
+		expectedTLS:   true,
+	}, {
+		name:          "h2 over TLS",
+		curlArgs:      []string{"--http2", "--cacert", "/tmp/ca.crt", "https://" + serviceHost + ":8443/"},
+		expectedHost:  serviceHost + ":8443",
+		expectedProto: "HTTP/2.0",
+		expectedPort:  "8443",
+		expectedTLS:   true,
+	}, {
+		name:          "reencrypt route",
+		curlArgs:      []string{"-k", "--resolve", fmt.Sprintf("%s:443:%s", routeHost, internalService.Spec.ClusterIP), "https://" + routeHost + "/"},
+		expectedHost:  routeHost,
+		expectedProto: "HTTP/1.1",
+		expectedPort:  "8443",
+		expectedTLS:   true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var response *echo.Response
+			cmd := append([]string{"curl", "-s", "-i", "--max-time", "10"}, tc.curlArgs...)
+			// Retry until the route is admitted and the router
+			// has loaded it.
+			if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+					t.Logf("failed to run %v: %v: %s, retrying...", cmd, err, stderr.String())
+					return false, nil
+				}
+				resp, err := readCurlResponse(stdout.String())
+				if err != nil {
+					t.Logf("failed to read response from %v: %v, retrying...", cmd, err)
+					return false, nil
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Logf("got status %s from %v, retrying...", resp.Status, cmd)
+					return false, nil
+				}
+				if response, err = parseEchoResponse(resp); err != nil {
+					t.Logf("failed to parse response from %v: %v, retrying...", cmd, err)
+					return false, nil
+				}
+				return true, nil
+			}); err != nil {
+				t.Fatalf("failed to get a response from the echo server using %v: %v", cmd, err)
+			}
+			if response.PodName != echoPod.Name {
+				t.Errorf("expected the response to come from pod %q, got %q", echoPod.Name, response.PodName)
+			}
+			if response.Host != tc.expectedHost {
+				t.Errorf("expected the echo server to receive host %q, got %q", tc.expectedHost, response.Host)
+			}
+			if response.Proto != tc.expectedProto {
+				t.Errorf("expected the echo server to receive protocol %q, got %q", tc.expectedProto, response.Proto)
+			}
+			if response.Port != tc.expectedPort {
+				t.Errorf("expected the echo server to receive the request on port %q, got %q", tc.expectedPort, response.Port)
+			}
+			if response.TLS != tc.expectedTLS {
+				t.Errorf("expected TLS %t, got %t", tc.expectedTLS, response.TLS)
+			}
+		})
+	}
+}
+
+// readCurlResponse parses the given output of "curl -i" as an HTTP response.
+// curl reports HTTP/2 responses as "HTTP/2", which the response parser does
+// not accept, so the status line is rewritten as "HTTP/2.0".
+func readCurlResponse(output string) (*http.Response, error) {
+	if strings.HasPrefix(output, "HTTP/2 ") {
+		output = "HTTP/2.0 " + strings.TrimPrefix(output, "HTTP/2 ")
+	}
+	return http.ReadResponse(bufio.NewReader(strings.NewReader(output)), nil)
+}
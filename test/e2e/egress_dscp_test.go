@@ -0,0 +1,96 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestEgressDSCP verifies that an ingresscontroller that specifies an egress
+// DSCP value using spec.unsupportedConfigOverrides.egressDSCP gets router pods
+// with the init container that marks the router's egress traffic, and that the
+// pods are admitted with the expected security context constraints.
+func TestEgressDSCP(t *testing.T) {
+	t.Parallel()
+
+	networkConfig := &configv1.Network{}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, networkConfig); err != nil {
+		t.Fatalf("failed to get network config: %v", err)
+	}
+	switch networkConfig.Status.NetworkType {
+	case string(operatorv1.NetworkTypeOVNKubernetes), string(operatorv1.NetworkTypeOpenShiftSDN):
+	default:
+		t.Skipf("test skipped on network type %q", networkConfig.Status.NetworkType)
+	}
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "egress-dscp"}
+	ic := newPrivateController(name, name.Name+"."+dnsConfig.Spec.BaseDomain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"egressDSCP":46}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	supported := operatorv1.OperatorCondition{Type: ingresscontroller.IngressControllerEgressDSCPSupportedConditionType, Status: operatorv1.ConditionTrue}
+	if err := waitForIngressControllerCondition(t, kclient, 1*time.Minute, name, supported); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe expected conditions for deployment %s: %v", deployment.Name, err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(controller.IngressControllerDeploymentPodSelector(ic))
+	if err != nil {
+		t.Fatalf("failed to build pod selector: %v", err)
+	}
+	pods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), pods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list pods for ingresscontroller %s: %v", ic.Name, err)
+	}
+	if len(pods.Items) == 0 {
+		t.Fatalf("no router pods found for ingresscontroller %s", ic.Name)
+	}
+	for _, pod := range pods.Items {
+		if scc := pod.Annotations["openshift.io/scc"]; scc != "ingress-router-egress-dscp" {
+			t.Errorf("expected pod %s to be admitted with SCC %q, got %q", pod.Name, "ingress-router-egress-dscp", scc)
+		}
+		if pod.Spec.ServiceAccountName != "router-egress-dscp" {
+			t.Errorf("expected pod %s to use service account %q, got %q", pod.Name, "router-egress-dscp", pod.Spec.ServiceAccountName)
+		}
+		if len(pod.Spec.InitContainers) != 1 || pod.Spec.InitContainers[0].Name != "egress-dscp" {
+			t.Errorf("expected pod %s to have the egress-dscp init container, got %+v", pod.Name, pod.Spec.InitContainers)
+			continue
+		}
+		sc := pod.Spec.InitContainers[0].SecurityContext
+		if sc == nil || sc.Capabilities == nil || len(sc.Capabilities.Add) != 1 || sc.Capabilities.Add[0] != "NET_ADMIN" {
+			t.Errorf("expected the init container of pod %s to add NET_ADMIN, got %+v", pod.Name, sc)
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+				t.Errorf("expected init container %s of pod %s to have succeeded, got %+v", status.Name, pod.Name, status.State)
+			}
+		}
+	}
+}
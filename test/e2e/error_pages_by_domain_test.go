@@ -0,0 +1,156 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestCustomErrorpagesByDomain creates an ingresscontroller with error pages
+// for two domains by way of the "httpErrorCodePagesByDomain" unsupported
+// config override.  The test verifies that requests for unknown hosts in each
+// domain get the domain's error page and that requests for unknown hosts in
+// other domains get the ingresscontroller's custom error page, and that an
+// update to a domain's page takes effect without a rollout of the router
+// deployment.
+func TestCustomErrorpagesByDomain(t *testing.T) {
+	t.Parallel()
+
+	ns := createNamespace(t, "errorpage-by-domain-e2e")
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "errorpage-by-domain"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	domainA := "a." + domain
+	domainB := "b." + domain
+	errorPage := func(body string) string {
+		return "HTTP/1.0 503 Service Unavailable\r\nPragma: no-cache\r\nCache-Control: private, max-age=0, no-cache, no-store\r\nConnection: close\r\nContent-Type: text/html\r\n\r\n<html><body>" + body + "</body></html>\r\n"
+	}
+	errorPageConfigmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "custom-error-pages-by-domain",
+			Namespace: "openshift-config",
+		},
+		Data: map[string]string{
+			"error-page-503.http":   errorPage("Shard"),
+			"error-page-503-a.http": errorPage("Brand A"),
+			"error-page-503-b.http": errorPage("Brand B"),
+		},
+	}
+	if err := kclient.Create(context.TODO(), errorPageConfigmap); err != nil {
+		t.Fatalf("failed to create configmap %s/%s: %v", errorPageConfigmap.Namespace, errorPageConfigmap.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), errorPageConfigmap); err != nil {
+			t.Errorf("failed to delete configmap %s/%s: %v", errorPageConfigmap.Namespace, errorPageConfigmap.Name, err)
+		}
+	}()
+
+	ic := newPrivateController(icName, domain)
+	ic.Spec.HttpErrorCodePages.Name = errorPageConfigmap.Name
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"httpErrorCodePagesByDomain":{%q:"error-page-503-a.http",%q:"error-page-503-b.http"}}`, domainA, domainB))}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterErrorFile503ByDomainEnvName, "/var/lib/haproxy/conf/error_code_pages/error-pages-by-domain.map"); err != nil {
+		t.Fatalf("expected deployment %s to use the error pages for domains: %v", deployment.Name, err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}, deployment); err != nil {
+		t.Fatalf("failed to get deployment %s: %v", deployment.Name, err)
+	}
+	generation := deployment.Generation
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("deployment has invalid selector: %v", err)
+	}
+	routerPods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), routerPods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list router pods: %v", err)
+	}
+	if len(routerPods.Items) == 0 {
+		t.Fatal("expected at least one router pod")
+	}
+	routerPodIP := routerPods.Items[0].Status.PodIP
+
+	clientPod := buildExecPod("errorpage-by-domain-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	if err := waitForPodReady(t, kclient, clientPod, 5*time.Minute); err != nil {
+		t.Fatalf("failed to wait for pod %s/%s to become ready: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+
+	// waitForErrorPage waits for the router to respond to a request for the
+	// given host with an HTTP 503 response whose body has the given text.
+	waitForErrorPage := func(t *testing.T, host, expected string) {
+		t.Helper()
+		cmd := []string{"/bin/curl", "-s", "-w", "\n%{http_code}", "--max-time", "10", "--resolve", fmt.Sprintf("%s:80:%s", host, routerPodIP), "http://" + host + "/"}
+		if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+				t.Logf("failed to send request for host %s: %v: %s, retrying...", host, err, stderr.String())
+				return false, nil
+			}
+			output := stdout.String()
+			if !strings.HasSuffix(output, "\n503") || !strings.Contains(output, expected) {
+				t.Logf("expected an HTTP 503 response with %q for host %s, got %q, retrying...", expected, host, output)
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			t.Fatalf("failed to observe the router respond with %q for host %s: %v", expected, host, err)
+		}
+	}
+	waitForErrorPage(t, "unknown."+domainA, "Brand A")
+	waitForErrorPage(t, "unknown."+domainB, "Brand B")
+	waitForErrorPage(t, "unknown."+domain, "Shard")
+
+	// Update the first domain's page and verify that the router serves the
+	// new page without a rollout.
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: errorPageConfigmap.Namespace, Name: errorPageConfigmap.Name}, errorPageConfigmap); err != nil {
+		t.Fatalf("failed to get configmap %s/%s: %v", errorPageConfigmap.Namespace, errorPageConfigmap.Name, err)
+	}
+	errorPageConfigmap.Data["error-page-503-a.http"] = errorPage("Rebranded A")
+	if err := kclient.Update(context.TODO(), errorPageConfigmap); err != nil {
+		t.Fatalf("failed to update configmap %s/%s: %v", errorPageConfigmap.Namespace, errorPageConfigmap.Name, err)
+	}
+	waitForErrorPage(t, "unknown."+domainA, "Rebranded A")
+	waitForErrorPage(t, "unknown."+domainB, "Brand B")
+
+	current := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}, current); err != nil {
+		t.Fatalf("failed to get deployment %s: %v", deployment.Name, err)
+	}
+	if current.Generation != generation {
+		t.Errorf("expected the update to the error page not to roll out deployment %s, but its generation changed from %d to %d", deployment.Name, generation, current.Generation)
+	}
+}
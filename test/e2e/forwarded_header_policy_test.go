@@ -22,6 +22,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 
@@ -126,18 +127,8 @@ func TestForwardedHeaderPolicyAppend(t *testing.T) {
 	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "forwardedheader-append"}
 	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
 	ic := newPrivateController(icName, domain)
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -224,18 +215,8 @@ func TestForwardedHeaderPolicyReplace(t *testing.T) {
 	ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{
 		ForwardedHeaderPolicy: operatorv1.ReplaceHTTPHeaderPolicy,
 	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -294,18 +275,8 @@ func TestForwardedHeaderPolicyNever(t *testing.T) {
 	ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{
 		ForwardedHeaderPolicy: operatorv1.NeverHTTPHeaderPolicy,
 	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -365,18 +336,8 @@ func TestForwardedHeaderPolicyIfNone(t *testing.T) {
 	ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{
 		ForwardedHeaderPolicy: operatorv1.IfNoneHTTPHeaderPolicy,
 	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -423,3 +384,124 @@ func TestForwardedHeaderPolicyIfNone(t *testing.T) {
 	testRouteHeaders(t, clientPodImage, echoRoute, service.Spec.ClusterIP, []string{"x-forwarded-for:foo"}, "x-forwarded-for:", 1)
 	testRouteHeaders(t, clientPodImage, echoRoute, service.Spec.ClusterIP, []string{"x-forwarded-for:foo", "x-forwarded-for:bar"}, "x-forwarded-for:", 2)
 }
+
+// testRouteHeadersSuppressed connects to the specified route using the provided
+// address and verifies that the response includes the expected header and does
+// not include the suppressed header.  Case is ignored when comparing header
+// names.
+func testRouteHeadersSuppressed(t *testing.T, image string, route *routev1.Route, address string, expectedHeader, suppressedHeader string) {
+	t.Helper()
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to create kube client: %v", err)
+	}
+
+	extraCurlArgs := []string{"--resolve", route.Spec.Host + ":80:" + address}
+	testPodCount++
+	name := fmt.Sprintf("%s%d", route.Name, testPodCount)
+	clientPod := buildCurlPod(name, route.Namespace, image, route.Spec.Host, address, extraCurlArgs...)
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), clientPod); err != nil {
+			if !errors.IsNotFound(err) {
+				t.Fatalf("failed to delete pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+			}
+		}
+	}()
+	expectedHeader = strings.ToLower(expectedHeader) + ":"
+	suppressedHeader = strings.ToLower(suppressedHeader) + ":"
+	var logs []byte
+	err = wait.PollImmediate(1*time.Second, 4*time.Minute, func() (bool, error) {
+		logs, err = client.CoreV1().Pods(clientPod.Namespace).GetLogs(clientPod.Name, &corev1.PodLogOptions{
+			Container: "curl",
+			Follow:    false,
+		}).DoRaw(context.TODO())
+		if err != nil {
+			t.Logf("failed to read output from pod %s: %v", clientPod.Name, err)
+			return false, nil
+		}
+		// Wait until the echoed request includes the expected header
+		// so that the absence of the suppressed header is meaningful.
+		output := strings.ToLower(string(logs))
+		if !strings.Contains(output, expectedHeader) {
+			return false, nil
+		}
+		if strings.Contains(output, suppressedHeader) {
+			return false, fmt.Errorf("found suppressed header %q in response", suppressedHeader)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe the expected output: %v\nclient pod logs:\n%s", err, logs)
+	}
+}
+
+// TestForwardedHeaderSuppression verifies that the ingress controller does not
+// add a router-generated forwarded header that the ingresscontroller
+// suppresses using spec.unsupportedConfigOverrides.suppressForwardedHeaders,
+// and that the router still adds the other forwarded headers.
+func TestForwardedHeaderSuppression(t *testing.T) {
+	t.Parallel()
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "forwardedheader-suppress"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"suppressForwardedHeaders":["X-Forwarded-Port"]}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_SUPPRESS_FORWARDED_HEADERS", "X-Forwarded-Port"); err != nil {
+		t.Fatalf("failed to observe ROUTER_SUPPRESS_FORWARDED_HEADERS=X-Forwarded-Port: %v", err)
+	}
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+
+	echoPod := buildEchoPod("forwarded-header-suppress-echo", deployment.Namespace)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), echoPod); err != nil {
+			t.Fatalf("failed to delete pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+		}
+	}()
+
+	echoService := buildEchoService(echoPod.Name, echoPod.Namespace, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), echoService); err != nil {
+			t.Fatalf("failed to delete service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+		}
+	}()
+
+	echoRoute := buildRoute(echoPod.Name, echoPod.Namespace, echoService.Name)
+	if err := kclient.Create(context.TODO(), echoRoute); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", echoRoute.Namespace, echoRoute.Name, err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), echoRoute); err != nil {
+			t.Fatalf("failed to delete route %s/%s: %v", echoRoute.Namespace, echoRoute.Name, err)
+		}
+	}()
+
+	clientPodImage := deployment.Spec.Template.Spec.Containers[0].Image
+
+	testRouteHeadersSuppressed(t, clientPodImage, echoRoute, service.Spec.ClusterIP, "X-Forwarded-For", "X-Forwarded-Port")
+	testRouteHeaders(t, clientPodImage, echoRoute, service.Spec.ClusterIP, nil, "x-forwarded-proto:", 1)
+}
@@ -5,19 +5,31 @@ package e2e
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/api/features"
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+	statuscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/status"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/kubernetes"
 
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -28,8 +40,13 @@ const (
 	expectedCatalogSourceName = "redhat-operators"
 	// The expected catalog source namespace.
 	expectedCatalogSourceNamespace = "openshift-marketplace"
+	// The expected OSSM subscription channel.
+	expectedSubscriptionChannel = "stable"
 	// The test gateway name used in multiple places.
 	testGatewayName = "test-gateway"
+	// The environment variable that, if set to "true", makes TestGatewayAPI
+	// run without granting the ingress-operator the OSSM workaround role.
+	gatewayAPIWithoutOSSMWorkaroundEnvVar = "E2E_GATEWAY_API_WITHOUT_OSSM_WORKAROUND"
 )
 
 var crdNames = []string{
@@ -37,6 +54,8 @@ var crdNames = []string{
 	"gateways.gateway.networking.k8s.io",
 	"httproutes.gateway.networking.k8s.io",
 	"referencegrants.gateway.networking.k8s.io",
+	"backendtlspolicies.gateway.networking.k8s.io",
+	"tlsroutes.gateway.networking.k8s.io",
 }
 
 // Global variables for testing.
@@ -60,6 +79,14 @@ func TestGatewayAPI(t *testing.T) {
 		t.Skip("Gateway API not enabled, skipping TestGatewayAPI")
 	}
 
+	// Until OSSM-3508 is fixed, the operator needs more permissions than
+	// its own cluster role grants in order to install OSSM.
+	if os.Getenv(gatewayAPIWithoutOSSMWorkaroundEnvVar) != "true" {
+		if err := grantIngressOperatorOSSMWorkaroundRole(t); err != nil {
+			t.Fatalf("failed to grant the ingress-operator the OSSM workaround role: %v", err)
+		}
+	}
+
 	// Defer the cleanup of the test gateway.
 	t.Cleanup(func() {
 		testGateway := gwapi.Gateway{ObjectMeta: metav1.ObjectMeta{Name: testGatewayName, Namespace: operatorcontroller.DefaultOperandNamespace}}
@@ -75,6 +102,59 @@ func TestGatewayAPI(t *testing.T) {
 	t.Run("testGatewayAPIResources", testGatewayAPIResources)
 	t.Run("testGatewayAPIObjects", testGatewayAPIObjects)
 	t.Run("testGatewayAPIIstioInstallation", testGatewayAPIIstioInstallation)
+	t.Run("testGatewayAPIAccessLogging", testGatewayAPIAccessLogging)
+	t.Run("testGatewayAPIMetrics", testGatewayAPIMetrics)
+	t.Run("testGatewayAPIInvalidBackendRefs", testGatewayAPIInvalidBackendRefs)
+	t.Run("testGatewayAPIBackendTLSPolicy", testGatewayAPIBackendTLSPolicy)
+	t.Run("testGatewayAPIGatewayClassDeletionProtection", testGatewayAPIGatewayClassDeletionProtection)
+	t.Run("testGatewayAPIServiceMeshControlPlaneRecreation", testGatewayAPIServiceMeshControlPlaneRecreation)
+	t.Run("testGatewayAPIServiceMeshControlPlaneRepair", testGatewayAPIServiceMeshControlPlaneRepair)
+	t.Run("testGatewayAPIListenerHostnames", testGatewayAPIListenerHostnames)
+	t.Run("testGatewayAPIListenerDomains", testGatewayAPIListenerDomains)
+	t.Run("testGatewayAPIDNSRecordCleanup", testGatewayAPIDNSRecordCleanup)
+	t.Run("testGatewayAPITLSRoutePassthrough", testGatewayAPITLSRoutePassthrough)
+	t.Run("testGatewayAPIDefaultCertificate", testGatewayAPIDefaultCertificate)
+	t.Run("testGatewayAPINodePortPublishing", testGatewayAPINodePortPublishing)
+	t.Run("testGatewayAPIDeletionDrain", testGatewayAPIDeletionDrain)
+	t.Run("testGatewayAPISubscriptionParameters", testGatewayAPISubscriptionParameters)
+	t.Run("testGatewayAPIControlPlaneRevisionUpgrade", testGatewayAPIControlPlaneRevisionUpgrade)
+	t.Run("testGatewayAPIWithoutClusterAdmin", testGatewayAPIWithoutClusterAdmin)
+}
+
+// testGatewayAPIWithoutClusterAdmin verifies that the operator installs OSSM
+// and reconciles the gatewayclass using only its own permissions, which must
+// not include cluster-admin privileges.  The test is skipped unless
+// E2E_GATEWAY_API_WITHOUT_OSSM_WORKAROUND is set to "true", in which case
+// TestGatewayAPI does not grant the OSSM workaround role.  Set the variable
+// once https://issues.redhat.com/browse/OSSM-3508 is fixed.
+func testGatewayAPIWithoutClusterAdmin(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv(gatewayAPIWithoutOSSMWorkaroundEnvVar) != "true" {
+		t.Skipf("test skipped because %s is not set to \"true\"", gatewayAPIWithoutOSSMWorkaroundEnvVar)
+	}
+
+	if isAdmin, err := ingressOperatorIsClusterAdmin(context.TODO()); err != nil {
+		t.Fatalf("failed to review the ingress-operator's permissions: %v", err)
+	} else if isAdmin {
+		t.Fatalf("expected %s not to have cluster-admin privileges", ingressOperatorServiceAccountUser)
+	}
+	binding := &rbacv1.ClusterRoleBinding{}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Name: ossmWorkaroundRoleName}, binding); err == nil {
+		t.Fatalf("expected clusterrolebinding %s not to exist", ossmWorkaroundRoleName)
+	} else if !errors.IsNotFound(err) {
+		t.Fatalf("failed to get clusterrolebinding %s: %v", ossmWorkaroundRoleName, err)
+	}
+
+	if _, err := assertGatewayClassSuccessful(t, gatewayclass.OpenShiftDefaultGatewayClassName); err != nil {
+		t.Fatalf("failed to find successful gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	if err := assertSMCP(context.TODO(), t); err != nil {
+		t.Fatalf("failed to find expected ServiceMeshControlPlane: %v", err)
+	}
+	if err := assertIstiodControlPlane(context.TODO(), t); err != nil {
+		t.Fatalf("failed to find expected istiod control plane: %v", err)
+	}
 }
 
 // testGatewayAPIResources tests that Gateway API Custom Resource Definitions are available.
@@ -102,21 +182,17 @@ func testGatewayAPIResources(t *testing.T) {
 func testGatewayAPIIstioInstallation(t *testing.T) {
 	t.Helper()
 
-	if err := assertSubscription(t, openshiftOperatorsNamespace, expectedSubscriptionName); err != nil {
-		t.Fatalf("failed to find expected Subscription %s: %v", expectedSubscriptionName, err)
+	if err := assertOSSMInstallation(context.TODO(), t); err != nil {
+		t.Fatalf("failed to find expected OSSM installation: %v", err)
 	}
-	if err := assertCatalogSource(t, expectedCatalogSourceNamespace, expectedCatalogSourceName); err != nil {
-		t.Fatalf("failed to find expected CatalogSource %s: %v", expectedCatalogSourceName, err)
+	// The operator reports on the health of the components that it
+	// installed.
+	expected := configv1.ClusterOperatorStatusCondition{
+		Type:   statuscontroller.GatewayAPIDegradedConditionType,
+		Status: configv1.ConditionFalse,
 	}
-	if err := assertOSSMOperator(t); err != nil {
-		t.Fatalf("failed to find expected Istio operator: %v", err)
-	}
-	if err := assertIstiodControlPlane(t); err != nil {
-		t.Fatalf("failed to find expected Istiod control plane: %v", err)
-	}
-	// TODO - In OSSM 3.x the configuration object to check will be different.
-	if err := assertSMCP(t); err != nil {
-		t.Fatalf("failed to find expected SMCP: %v", err)
+	if err := waitForClusterOperatorConditions(t, kclient, expected); err != nil {
+		t.Errorf("failed to observe expected clusteroperator condition %s=%s: %v", expected.Type, expected.Status, err)
 	}
 }
 
@@ -141,6 +217,244 @@ func testGatewayAPIObjects(t *testing.T) {
 	}
 }
 
+// testGatewayAPIAccessLogging tests that access logging for gateways can be
+// enabled through the gatewayclass's parameters.  It configures JSON access
+// logging to stdout, sends a request through the test gateway, and verifies
+// that a parseable JSON access log entry for the request appears in the
+// gateway pod's logs.  It then verifies that removing the parameters removes
+// the access logging configuration from the servicemeshcontrolplane.
+//
+// This test depends on the gateway and http route that testGatewayAPIObjects
+// creates.
+func testGatewayAPIAccessLogging(t *testing.T) {
+	t.Helper()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorcontroller.DefaultOperatorNamespace,
+			Name:      "gateway-access-logging",
+		},
+		Data: map[string]string{
+			"accessLogEncoding": "JSON",
+			"accessLogTarget":   "Stdout",
+		},
+	}
+	if err := kclient.Create(context.TODO(), cm); err != nil {
+		t.Fatalf("failed to create configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), cm); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+	})
+
+	namespace := gwapi.Namespace(cm.Namespace)
+	if err := updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+		gc.Spec.ParametersRef = &gwapi.ParametersReference{
+			Kind:      "ConfigMap",
+			Name:      cm.Name,
+			Namespace: &namespace,
+		}
+	}); err != nil {
+		t.Fatalf("failed to set parameters on gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	removeParameters := func() error {
+		return updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+			gc.Spec.ParametersRef = nil
+		})
+	}
+	parametersRemoved := false
+	t.Cleanup(func() {
+		if parametersRemoved {
+			return
+		}
+		if err := removeParameters(); err != nil {
+			t.Errorf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+		}
+	})
+
+	smcpName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: openshiftSMCPName}
+	accessLogEncoding := func(smcp *maistrav2.ServiceMeshControlPlane) string {
+		if smcp.Spec.Proxy == nil || smcp.Spec.Proxy.AccessLogging == nil || smcp.Spec.Proxy.AccessLogging.File == nil {
+			return ""
+		}
+		return smcp.Spec.Proxy.AccessLogging.File.Encoding
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		smcp := &maistrav2.ServiceMeshControlPlane{}
+		if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		return accessLogEncoding(smcp) == "JSON", nil
+	}); err != nil {
+		t.Fatalf("failed to observe JSON access logging in ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to create kube client: %v", err)
+	}
+
+	// Istiod needs to push the new configuration to the gateway, so keep
+	// sending requests until the gateway logs one of them.
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	path := "/" + names.SimpleNameGenerator.GenerateName("access-log-")
+	gatewayPodSelector := "istio.io/gateway-name=" + testGatewayName
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if _, err := getHttpResponse(httpClient, defaultRoutename+path); err != nil {
+			t.Logf("%v, retrying...", err)
+			return false, nil
+		}
+		pods, err := kubeClient.CoreV1().Pods(operatorcontroller.DefaultOperandNamespace).List(ctx, metav1.ListOptions{LabelSelector: gatewayPodSelector})
+		if err != nil {
+			t.Logf("failed to list gateway pods: %v, retrying...", err)
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			logs, err := kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+			if err != nil {
+				t.Logf("failed to get logs for pod %s/%s: %v, retrying...", pod.Namespace, pod.Name, err)
+				continue
+			}
+			for _, line := range strings.Split(string(logs), "\n") {
+				if !strings.Contains(line, path) {
+					continue
+				}
+				entry := map[string]interface{}{}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Logf("found access log entry for %s in pod %s/%s that is not valid JSON: %v: %q", path, pod.Namespace, pod.Name, err, line)
+					continue
+				}
+				if entry["path"] != path {
+					continue
+				}
+				t.Logf("found JSON access log entry in pod %s/%s: %s", pod.Namespace, pod.Name, line)
+				return true, nil
+			}
+		}
+		t.Logf("found no JSON access log entry for %s in gateway pods, retrying...", path)
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to observe JSON access log entry for %s: %v", path, err)
+	}
+
+	// Removing the parameters must remove the access logging configuration.
+	if err := removeParameters(); err != nil {
+		t.Fatalf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	parametersRemoved = true
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		smcp := &maistrav2.ServiceMeshControlPlane{}
+		if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		return smcp.Spec.Proxy == nil, nil
+	}); err != nil {
+		t.Fatalf("failed to observe access logging removed from ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+}
+
+// testGatewayAPIInvalidBackendRefs tests that http routes with invalid backend
+// references fail fast.  For each of a reference to a nonexistent service, a
+// reference to a port that the service does not have, and a reference to an
+// unsupported kind, it creates an http route with one rule that has the invalid
+// reference and one rule that has a valid reference.  It verifies that the http
+// route reports ResolvedRefs=False with the reason that the Gateway API
+// specification requires, that requests for the invalid rule fail with a 500 or
+// 503 response, and that requests for the valid rule still succeed.
+//
+// This test depends on the gateway that testGatewayAPIObjects creates.
+func testGatewayAPIInvalidBackendRefs(t *testing.T) {
+	t.Helper()
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-invalid-"))
+
+	gateway, err := assertGatewaySuccessful(t, operatorcontroller.DefaultOperandNamespace, testGatewayName)
+	if err != nil {
+		t.Fatalf("failed to find gateway %s: %v", testGatewayName, err)
+	}
+
+	// Create the backend for the valid rules.  The pod and service are
+	// cleaned up when the namespace is deleted.
+	echoPod := buildEchoPod("valid-backend", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+
+	validPort := gwapi.PortNumber(defaultPortNumber)
+	invalidPort := gwapi.PortNumber(8081)
+	configMapKind := gwapi.Kind("ConfigMap")
+	coreGroup := gwapi.Group("")
+	testCases := []struct {
+		name           string
+		backendRef     gwapi.BackendObjectReference
+		expectedReason gwapi.RouteConditionReason
+	}{
+		{
+			name: "missing-service",
+			backendRef: gwapi.BackendObjectReference{
+				Name: "nonexistent-service",
+				Port: &validPort,
+			},
+			expectedReason: gwapi.RouteReasonBackendNotFound,
+		},
+		{
+			name: "port-mismatch",
+			backendRef: gwapi.BackendObjectReference{
+				Name: gwapi.ObjectName(echoService.Name),
+				Port: &invalidPort,
+			},
+			expectedReason: gwapi.RouteReasonBackendNotFound,
+		},
+		{
+			name: "unsupported-kind",
+			backendRef: gwapi.BackendObjectReference{
+				Group: &coreGroup,
+				Kind:  &configMapKind,
+				Name:  "nonexistent-configmap",
+			},
+			expectedReason: gwapi.RouteReasonInvalidKind,
+		},
+	}
+	validRef := gwapi.BackendObjectReference{
+		Name: gwapi.ObjectName(echoService.Name),
+		Port: &validPort,
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hostname := names.SimpleNameGenerator.GenerateName(tc.name+"-") + ".gws." + dnsConfig.Spec.BaseDomain
+			rules := []gwapi.HTTPRouteRule{
+				buildHTTPRouteRule("/valid", validRef),
+				buildHTTPRouteRule("/invalid", tc.backendRef),
+			}
+			httpRoute := buildHTTPRouteWithRules(tc.name, ns.Name, gateway.Name, gateway.Namespace, hostname, rules)
+			if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+				t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+			}
+
+			if err := assertHttpRouteResolvedRefsFalse(t, httpRoute.Namespace, httpRoute.Name, tc.expectedReason); err != nil {
+				t.Fatal(err)
+			}
+			if err := assertHttpRouteRuleResponse(t, hostname, "/valid", http.StatusOK); err != nil {
+				t.Error(err)
+			}
+			if err := assertHttpRouteRuleResponse(t, hostname, "/invalid", http.StatusInternalServerError, http.StatusServiceUnavailable); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
 // ensureCRDs tests that the Gateway API custom resource definitions exist.
 func ensureCRDs(t *testing.T) {
 	t.Helper()
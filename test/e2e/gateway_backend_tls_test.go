@@ -0,0 +1,238 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// backendTLSPolicyGVK is the group, version, and kind of the BackendTLSPolicy
+// API.  BackendTLSPolicy is only available in the experimental channel of
+// Gateway API, for which the operator does not vendor a typed client, so the
+// test uses unstructured objects.
+var backendTLSPolicyGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+	Kind:    "BackendTLSPolicy",
+}
+
+// testGatewayAPIBackendTLSPolicy tests that the gateway re-encrypts traffic to
+// backends that a BackendTLSPolicy targets.  It creates an echo server that
+// only serves HTTPS using a serving certificate from the service CA, and for
+// each of several policies, a service for the echo server, a BackendTLSPolicy
+// that targets the service, and an http route for the service.  It verifies
+// that requests succeed if the policy specifies the service CA and the
+// hostname in the serving certificate, and that requests fail, rather than
+// falling back to plaintext, if the policy specifies the wrong CA or the
+// wrong hostname.  It also verifies that a policy that references a
+// nonexistent CA certificate config map reports the failure in its status.
+//
+// This test depends on the gateway that testGatewayAPIObjects creates.
+func testGatewayAPIBackendTLSPolicy(t *testing.T) {
+	t.Helper()
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-backendtls-"))
+
+	gateway, err := assertGatewaySuccessful(t, operatorcontroller.DefaultOperandNamespace, testGatewayName)
+	if err != nil {
+		t.Fatalf("failed to find gateway %s: %v", testGatewayName, err)
+	}
+
+	// Create the HTTPS echo server.  The pod and services are cleaned up
+	// when the namespace is deleted.
+	echoTLS := withEchoTLS("https-echo-cert")
+	echoPod := buildEchoPod("https-echo", ns.Name, echoTLS)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	servingCertService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels, echoTLS)
+	servingCertService.Annotations = map[string]string{
+		"service.beta.openshift.io/serving-cert-secret-name": echoPod.Name + "-cert",
+	}
+	if err := kclient.Create(context.TODO(), servingCertService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", servingCertService.Namespace, servingCertService.Name, err)
+	}
+	if err := waitForPodReady(t, kclient, echoPod, 3*time.Minute); err != nil {
+		t.Fatalf("pod %s/%s is not ready: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	// The serving certificate is valid for the service's DNS name.
+	validHostname := fmt.Sprintf("%s.%s.svc", servingCertService.Name, servingCertService.Namespace)
+
+	// Copy the service CA certificate, which the service CA operator
+	// injects into every namespace, into a config map with the key that
+	// BackendTLSPolicy requires.  Create another config map with a CA
+	// that did not sign the serving certificate.
+	serviceCA := &corev1.ConfigMap{}
+	serviceCAName := types.NamespacedName{Namespace: ns.Name, Name: "openshift-service-ca.crt"}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, serviceCAName, serviceCA); err != nil {
+			t.Logf("failed to get config map %s: %v, retrying...", serviceCAName, err)
+			return false, nil
+		}
+		return len(serviceCA.Data["service-ca.crt"]) != 0, nil
+	}); err != nil {
+		t.Fatalf("failed to observe the service CA certificate in config map %s: %v", serviceCAName, err)
+	}
+	wrongCA := MustCreateTLSKeyCert("wrong-ca", time.Now(), time.Now().Add(24*time.Hour), true, nil, nil)
+	for name, caCert := range map[string]string{
+		"service-ca": serviceCA.Data["service-ca.crt"],
+		"wrong-ca":   wrongCA.CertPem,
+	} {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns.Name},
+			Data:       map[string]string{"ca.crt": caCert},
+		}
+		if err := kclient.Create(context.TODO(), cm); err != nil {
+			t.Fatalf("failed to create config map %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		caConfigMap         string
+		hostname            string
+		expectedStatusCodes []int
+		// expectInvalidPolicy indicates whether the policy is expected
+		// to report a failure in its status.
+		expectInvalidPolicy bool
+	}{
+		{
+			name:                "valid",
+			caConfigMap:         "service-ca",
+			hostname:            validHostname,
+			expectedStatusCodes: []int{http.StatusOK},
+		},
+		{
+			name:                "wrong-ca",
+			caConfigMap:         "wrong-ca",
+			hostname:            validHostname,
+			expectedStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+		{
+			name:                "wrong-hostname",
+			caConfigMap:         "service-ca",
+			hostname:            "wrong." + validHostname,
+			expectedStatusCodes: []int{http.StatusServiceUnavailable},
+		},
+		{
+			name:                "missing-ca",
+			caConfigMap:         "nonexistent-ca",
+			hostname:            validHostname,
+			expectedStatusCodes: []int{http.StatusInternalServerError, http.StatusServiceUnavailable},
+			expectInvalidPolicy: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := buildEchoService(tc.name, ns.Name, echoPod.Labels, echoTLS)
+			if err := kclient.Create(context.TODO(), service); err != nil {
+				t.Fatalf("failed to create service %s/%s: %v", service.Namespace, service.Name, err)
+			}
+			policy := buildBackendTLSPolicy(tc.name, ns.Name, service.Name, tc.caConfigMap, tc.hostname)
+			if err := kclient.Create(context.TODO(), policy); err != nil {
+				t.Fatalf("failed to create backendtlspolicy %s/%s: %v", policy.GetNamespace(), policy.GetName(), err)
+			}
+			port := gwapi.PortNumber(8443)
+			backendRef := gwapi.BackendObjectReference{Name: gwapi.ObjectName(service.Name), Port: &port}
+			hostname := names.SimpleNameGenerator.GenerateName(tc.name+"-") + ".gws." + dnsConfig.Spec.BaseDomain
+			httpRoute := buildHTTPRouteWithRules(tc.name, ns.Name, gateway.Name, gateway.Namespace, hostname, []gwapi.HTTPRouteRule{buildHTTPRouteRule("/", backendRef)})
+			if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+				t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+			}
+
+			if tc.expectInvalidPolicy {
+				if err := assertBackendTLSPolicyInvalid(t, policy.GetNamespace(), policy.GetName()); err != nil {
+					t.Error(err)
+				}
+			}
+			// The echo server only serves HTTPS, so a 200 response
+			// means that the gateway re-encrypted the request.
+			// Requests through a policy that cannot be satisfied
+			// must fail rather than fall back to plaintext.
+			if err := assertHttpRouteRuleResponse(t, hostname, "/", tc.expectedStatusCodes...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// buildBackendTLSPolicy returns a BackendTLSPolicy that targets the given
+// service and specifies the CA certificate in the given config map and the
+// given hostname.
+func buildBackendTLSPolicy(name, namespace, serviceName, caConfigMapName, hostname string) *unstructured.Unstructured {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(backendTLSPolicyGVK)
+	policy.SetName(name)
+	policy.SetNamespace(namespace)
+	policy.Object["spec"] = map[string]interface{}{
+		"targetRef": map[string]interface{}{
+			"group": "",
+			"kind":  "Service",
+			"name":  serviceName,
+		},
+		"tls": map[string]interface{}{
+			"caCertRefs": []interface{}{
+				map[string]interface{}{
+					"group": "",
+					"kind":  "ConfigMap",
+					"name":  caConfigMapName,
+				},
+			},
+			"hostname": hostname,
+		},
+	}
+	return policy
+}
+
+// assertBackendTLSPolicyInvalid checks that the BackendTLSPolicy of the given
+// name reports an Accepted or ResolvedRefs condition with status False for
+// some ancestor within 2 minutes, and returns an error if not.
+func assertBackendTLSPolicyInvalid(t *testing.T, namespace, name string) error {
+	t.Helper()
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(backendTLSPolicyGVK)
+	nsName := types.NamespacedName{Namespace: namespace, Name: name}
+	var lastStatus []interface{}
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, nsName, policy); err != nil {
+			t.Logf("failed to get backendtlspolicy %s: %v, retrying...", nsName, err)
+			return false, nil
+		}
+		ancestors, _, _ := unstructured.NestedSlice(policy.Object, "status", "ancestors")
+		lastStatus = ancestors
+		for _, ancestor := range ancestors {
+			conditions, _, _ := unstructured.NestedSlice(ancestor.(map[string]interface{}), "conditions")
+			for _, condition := range conditions {
+				c := condition.(map[string]interface{})
+				if (c["type"] == "Accepted" || c["type"] == "ResolvedRefs") && c["status"] == string(metav1.ConditionFalse) {
+					t.Logf("backendtlspolicy %s reports %s=False with reason %v: %v", nsName, c["type"], c["reason"], c["message"])
+					return true, nil
+				}
+			}
+		}
+		t.Logf("backendtlspolicy %s has ancestor status %v, expected Accepted=False or ResolvedRefs=False, retrying...", nsName, ancestors)
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("backendtlspolicy %s did not report Accepted=False or ResolvedRefs=False: %v, last recorded ancestor status: %v", nsName, err, lastStatus)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPIDefaultCertificate tests that the operator provisions a
+// certificate for an HTTPS listener that does not specify one.  It creates a
+// gateway with an HTTPS listener with no TLS configuration and an http route,
+// verifies that the operator sets a certificate on the listener, and verifies
+// that an HTTPS request to the route succeeds when the client trusts only the
+// router CA.
+func testGatewayAPIDefaultCertificate(t *testing.T) {
+	t.Helper()
+
+	domain := "gws-cert." + dnsConfig.Spec.BaseDomain
+	hostname := "app." + domain
+
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGatewayWithListeners("e2e-default-certificate", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, []gatewayListenerSpec{{
+		name:     "https",
+		protocol: gwapi.HTTPSProtocolType,
+		port:     443,
+		hostname: "*." + domain,
+	}})
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+
+	// The operator must set its certificate on the listener.
+	secretName := operatorcontroller.GatewayCertificateSecretName(gateway)
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, gatewayName, gateway); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", gatewayName, err)
+			return false, nil
+		}
+		tlsConfig := gateway.Spec.Listeners[0].TLS
+		if tlsConfig == nil || len(tlsConfig.CertificateRefs) != 1 || string(tlsConfig.CertificateRefs[0].Name) != secretName.Name {
+			t.Logf("gateway %s listener has TLS configuration %+v, expected certificate %q, retrying...", gatewayName, tlsConfig, secretName.Name)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("operator did not set the default certificate on gateway %s: %v", gatewayName, err)
+	}
+	if err := kclient.Get(context.TODO(), secretName, &corev1.Secret{}); err != nil {
+		t.Fatalf("failed to get certificate secret %s: %v", secretName, err)
+	}
+	if _, err := waitForGatewayProgrammed(t, gatewayName); err != nil {
+		t.Fatalf("gateway %s was not programmed: %v", gatewayName, err)
+	}
+	if err := assertGatewayDNSRecords(t, gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the echo server and the http route for it.  The pod, service,
+	// and route are cleaned up when the namespace is deleted.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-cert-"))
+	httpRoute, err := createHttpRoute(ns.Name, "default-certificate", gateway.Namespace, hostname, "cert-echo", gateway)
+	if err != nil {
+		t.Fatalf("failed to create httproute: %v", err)
+	}
+	if _, err := assertHttpRouteSuccessful(t, httpRoute.Namespace, httpRoute.Name, gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	caSecret := &corev1.Secret{}
+	if err := kclient.Get(context.TODO(), operatorcontroller.RouterCASecretName(operatorNamespace), caSecret); err != nil {
+		t.Fatalf("failed to get CA secret: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caSecret.Data["tls.crt"]) {
+		t.Fatalf("failed to parse CA certificate")
+	}
+	if err := assertHTTPSResponse(t, hostname, roots); err != nil {
+		t.Error(err)
+	}
+}
+
+// assertHTTPSResponse checks that an HTTPS request to the given hostname
+// succeeds within 5 minutes when the client trusts only the given roots, and
+// returns an error if not.
+func assertHTTPSResponse(t *testing.T, hostname string, roots *x509.CertPool) error {
+	t.Helper()
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: hostname},
+		},
+	}
+	url := "https://" + hostname + "/"
+	var lastErr error
+	err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		response, err := client.Get(url)
+		if err != nil {
+			lastErr = err
+			t.Logf("GET %s failed: %v, retrying...", url, err)
+			return false, nil
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("status %d", response.StatusCode)
+			t.Logf("GET %s returned status %d, expected %d, retrying...", url, response.StatusCode, http.StatusOK)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("GET %s did not succeed: %v, last error: %v", url, err, lastErr)
+	}
+	t.Logf("GET %s succeeded with the gateway's default certificate", url)
+	return nil
+}
@@ -0,0 +1,246 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	gatewayservicedns "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-service-dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// gatewayDeletionDrainPeriod is the drain period that
+// testGatewayAPIDeletionDrain sets on its gateway.  It exceeds the TTL of the
+// gateway's DNS records so that resolvers that cached the records have
+// expired them before the gateway stops serving.
+const gatewayDeletionDrainPeriod = 45 * time.Second
+
+// gatewayProbeOutcome classifies the result of a request to a gateway.
+type gatewayProbeOutcome string
+
+const (
+	// gatewayProbeOK means that the name resolved and the gateway
+	// responded.
+	gatewayProbeOK gatewayProbeOutcome = "OK"
+	// gatewayProbeNXDOMAIN means that the name did not resolve.
+	gatewayProbeNXDOMAIN gatewayProbeOutcome = "NXDOMAIN"
+	// gatewayProbeRefused means that the name resolved but the connection
+	// was refused, which is what clients see if the gateway stops serving
+	// before its DNS records are removed.
+	gatewayProbeRefused gatewayProbeOutcome = "Refused"
+	// gatewayProbeOther is any other failure, such as a timeout.
+	gatewayProbeOther gatewayProbeOutcome = "Other"
+)
+
+// probeGateway sends a request for the given hostname and classifies the
+// outcome.
+func probeGateway(ctx context.Context, httpClient *http.Client, hostname string) (gatewayProbeOutcome, error) {
+	if _, err := net.DefaultResolver.LookupHost(ctx, hostname); err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return gatewayProbeNXDOMAIN, err
+		}
+		return gatewayProbeOther, err
+	}
+	response, err := httpClient.Get("http://" + hostname)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return gatewayProbeRefused, err
+		}
+		return gatewayProbeOther, err
+	}
+	response.Body.Close()
+	return gatewayProbeOK, nil
+}
+
+// testGatewayAPIDeletionDrain verifies the order in which the resources of a
+// deleted gateway are torn down.  It deletes one of two HTTPRoutes and verifies
+// that the gateway's DNS record is kept and that the other route still serves
+// traffic.  It then deletes the gateway while sending requests to the other
+// route and verifies that the gateway's deployment outlives its DNS records
+// for the drain period, that no request is refused while the name still
+// resolves, and that clients eventually get NXDOMAIN.
+func testGatewayAPIDeletionDrain(t *testing.T) {
+	t.Helper()
+
+	domain := "gws-deletion." + dnsConfig.Spec.BaseDomain
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGateway("e2e-deletion", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, domain)
+	gateway.Annotations = map[string]string{
+		gatewayservicedns.GatewayDrainPeriodAnnotation: gatewayDeletionDrainPeriod.String(),
+	}
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !apierrors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-deletion-"))
+	echoPod := buildEchoPod("deletion-backend", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	keptHostname := "kept." + domain
+	deletedHostname := "deleted." + domain
+	keptRoute := buildHTTPRoute("kept", ns.Name, gateway.Name, gateway.Namespace, keptHostname, echoService.Name)
+	deletedRoute := buildHTTPRoute("deleted", ns.Name, gateway.Name, gateway.Namespace, deletedHostname, echoService.Name)
+	for _, httpRoute := range []*gwapi.HTTPRoute{keptRoute, deletedRoute} {
+		if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+			t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+		}
+		if _, err := assertHttpRouteSuccessful(t, ns.Name, httpRoute.Name, gateway); err != nil {
+			t.Fatalf("httproute %s/%s was not accepted: %v", httpRoute.Namespace, httpRoute.Name, err)
+		}
+	}
+	if err := assertHttpRouteConnection(t, keptHostname, gateway); err != nil {
+		t.Fatalf("failed to connect to %s: %v", keptHostname, err)
+	}
+	if err := assertHttpRouteRuleResponse(t, deletedHostname, "/", http.StatusOK); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting an HTTPRoute must only stop the gateway from serving the
+	// route's hostname.  The gateway's DNS record is for the listener and
+	// must be kept.
+	if err := kclient.Delete(context.TODO(), deletedRoute); err != nil {
+		t.Fatalf("failed to delete httproute %s/%s: %v", deletedRoute.Namespace, deletedRoute.Name, err)
+	}
+	if err := assertHttpRouteRuleResponse(t, deletedHostname, "/", http.StatusNotFound); err != nil {
+		t.Fatal(err)
+	}
+	recordName := operatorcontroller.GatewayDNSRecordName(gateway, "*."+domain+".")
+	if err := kclient.Get(context.TODO(), recordName, &iov1.DNSRecord{}); err != nil {
+		t.Fatalf("expected dnsrecord %s to be kept after deleting an httproute: %v", recordName, err)
+	}
+	if err := assertHttpRouteRuleResponse(t, keptHostname, "/", http.StatusOK); err != nil {
+		t.Fatal(err)
+	}
+
+	// Send light load to the remaining route while the gateway is deleted
+	// and record the outcome of each request along with whether the
+	// gateway still existed at the time.
+	var (
+		mu            sync.Mutex
+		gatewayGone   bool
+		counts        = map[gatewayProbeOutcome]int{}
+		refusedBefore []string
+	)
+	loadCtx, stopLoad := context.WithCancel(context.Background())
+	defer stopLoad()
+	loadDone := make(chan struct{})
+	go func() {
+		defer close(loadDone)
+		httpClient := &http.Client{Timeout: 5 * time.Second}
+		for {
+			outcome, err := probeGateway(loadCtx, httpClient, keptHostname)
+			mu.Lock()
+			counts[outcome]++
+			if outcome == gatewayProbeRefused && !gatewayGone {
+				refusedBefore = append(refusedBefore, time.Now().Format(time.RFC3339)+": "+err.Error())
+			}
+			mu.Unlock()
+			select {
+			case <-loadCtx.Done():
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}()
+
+	if err := kclient.Delete(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+
+	// The gateway's deployment must still exist once its dnsrecords are
+	// gone, and the gateway itself must be kept for the drain period.
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	gatewayLabels := client.MatchingLabels{"istio.io/gateway-name": gateway.Name}
+	var recordsGoneAt time.Time
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		var records iov1.DNSRecordList
+		if err := kclient.List(ctx, &records, client.InNamespace(gateway.Namespace), gatewayLabels); err != nil {
+			t.Logf("failed to list dnsrecords for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		if len(records.Items) != 0 {
+			t.Logf("gateway %s/%s still has %d dnsrecords, retrying...", gateway.Namespace, gateway.Name, len(records.Items))
+			return false, nil
+		}
+		recordsGoneAt = time.Now()
+		var deployments appsv1.DeploymentList
+		if err := kclient.List(ctx, &deployments, client.InNamespace(gateway.Namespace), gatewayLabels); err != nil {
+			return false, err
+		}
+		if len(deployments.Items) == 0 {
+			t.Errorf("gateway %s/%s has no deployment although its dnsrecords were only just removed", gateway.Namespace, gateway.Name)
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("dnsrecords for gateway %s/%s were not deleted: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, gatewayDeletionDrainPeriod+2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, gatewayName, &gwapi.Gateway{}); err == nil {
+			return false, nil
+		} else if !apierrors.IsNotFound(err) {
+			t.Logf("failed to get gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("gateway %s/%s was not deleted: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if drained := time.Since(recordsGoneAt); drained < gatewayDeletionDrainPeriod-5*time.Second {
+		t.Errorf("expected gateway %s/%s to be kept for %s after its dnsrecords were removed, but it was deleted after %s", gateway.Namespace, gateway.Name, gatewayDeletionDrainPeriod, drained)
+	}
+	mu.Lock()
+	gatewayGone = true
+	mu.Unlock()
+
+	// Clients must eventually see the name stop resolving.
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[gatewayProbeNXDOMAIN] != 0, nil
+	}); err != nil {
+		t.Errorf("expected %s to stop resolving after gateway %s/%s was deleted: %v", keptHostname, gateway.Namespace, gateway.Name, err)
+	}
+	stopLoad()
+	<-loadDone
+
+	t.Logf("outcomes of requests to %s while gateway %s/%s was deleted: %v", keptHostname, gateway.Namespace, gateway.Name, counts)
+	if len(refusedBefore) != 0 {
+		t.Errorf("expected no connections to be refused while %s still resolved and gateway %s/%s existed, got %d: %v", keptHostname, gateway.Namespace, gateway.Name, len(refusedBefore), refusedBefore)
+	}
+	if counts[gatewayProbeOK] == 0 {
+		t.Errorf("expected some requests to %s to succeed after gateway %s/%s was deleted", keptHostname, gateway.Namespace, gateway.Name)
+	}
+}
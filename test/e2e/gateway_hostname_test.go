@@ -0,0 +1,328 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	gatewayservicedns "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-service-dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	iov1 "github.com/openshift/api/operatoringress/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPIListenerHostnames verifies how the operator publishes DNS
+// records for gateway listener hostnames at the limits of what DNS allows.  It
+// creates a gateway with a listener whose hostname has a label of the maximum
+// length, a listener whose hostname has a label that exceeds the maximum
+// length, and a listener whose hostname is an internationalized domain name in
+// punycode form.  It verifies that the gateway reports the overlong hostname
+// as invalid, that no DNS record is created for it, and that the other two
+// hostnames are published in DNS and serve traffic.
+func testGatewayAPIListenerHostnames(t *testing.T) {
+	t.Helper()
+
+	domain := "gws-hostnames." + dnsConfig.Spec.BaseDomain
+	maxLengthHost := strings.Repeat("a", 63) + "." + domain
+	overlongHost := strings.Repeat("b", 64) + "." + domain
+	punycodeHost := "xn--bcher-kva." + domain
+
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	fromNamespace := gwapi.FromNamespaces(allNamespaces)
+	allowedRoutes := gwapi.AllowedRoutes{Namespaces: &gwapi.RouteNamespaces{From: &fromNamespace}}
+	listener := func(name, hostname string) gwapi.Listener {
+		h := gwapi.Hostname(hostname)
+		return gwapi.Listener{Name: gwapi.SectionName(name), Hostname: &h, Port: 80, Protocol: "HTTP", AllowedRoutes: &allowedRoutes}
+	}
+	gateway := &gwapi.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "e2e-hostnames", Namespace: operatorcontroller.DefaultOperandNamespace},
+		Spec: gwapi.GatewaySpec{
+			GatewayClassName: gwapi.ObjectName(gatewayClass.Name),
+			Listeners: []gwapi.Listener{
+				listener("max-length", maxLengthHost),
+				listener("overlong", overlongHost),
+				listener("punycode", punycodeHost),
+			},
+		},
+	}
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+
+	// The overlong hostname must be reported on the gateway and on the
+	// listener, and must not be published.
+	if err := waitForGatewayHostnamesInvalid(t, gateway, "overlong"); err != nil {
+		t.Fatal(err)
+	}
+	overlongRecordName := operatorcontroller.GatewayDNSRecordName(gateway, overlongHost+".")
+	if err := kclient.Get(context.TODO(), overlongRecordName, &iov1.DNSRecord{}); err == nil {
+		t.Errorf("expected no dnsrecord %s for the overlong hostname %s", overlongRecordName, overlongHost)
+	} else if !errors.IsNotFound(err) {
+		t.Errorf("failed to get dnsrecord %s: %v", overlongRecordName, err)
+	}
+
+	// The other hostnames must be published and serve traffic.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-hostnames-"))
+	echoPod := buildEchoPod("hostnames-backend", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for name, hostname := range map[string]string{"max-length": maxLengthHost, "punycode": punycodeHost} {
+		t.Run(name, func(t *testing.T) {
+			httpRoute := buildHTTPRoute(name, ns.Name, gateway.Name, gateway.Namespace, hostname, echoService.Name)
+			if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+				t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+			}
+			recordName := operatorcontroller.GatewayDNSRecordName(gateway, hostname+".")
+			if err := assertDNSRecord(t, recordName); err != nil {
+				t.Fatalf("dnsrecord %s for hostname %s was not published: %v", recordName, hostname, err)
+			}
+			var record iov1.DNSRecord
+			if err := kclient.Get(context.TODO(), recordName, &record); err != nil {
+				t.Fatalf("failed to get dnsrecord %s: %v", recordName, err)
+			}
+			if record.Spec.DNSName != hostname+"." {
+				t.Errorf("expected dnsrecord %s to have dnsName %q, got %q", recordName, hostname+".", record.Spec.DNSName)
+			}
+			if err := assertHttpRouteRuleResponse(t, hostname, "/", http.StatusOK); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// testGatewayAPIListenerDomains verifies that the operator publishes a DNS
+// record for each unique hostname of a gateway's listeners and deletes the
+// record for a listener's hostname when the listener is removed.  It creates a
+// gateway with listeners on two different domains, one of which has both an
+// HTTP and an HTTPS listener, verifies that each hostname gets its own DNS
+// record and serves traffic, and then removes the listener for one of the
+// domains and verifies that its DNS record is deleted.
+func testGatewayAPIListenerDomains(t *testing.T) {
+	t.Helper()
+
+	appsHostname := "*.gws-apps." + dnsConfig.Spec.BaseDomain
+	internalHostname := "*.gws-internal." + dnsConfig.Spec.BaseDomain
+
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGatewayWithListeners("e2e-listener-domains", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, []gatewayListenerSpec{{
+		name:     "apps-http",
+		protocol: gwapi.HTTPProtocolType,
+		port:     80,
+		hostname: appsHostname,
+	}, {
+		name:     "apps-https-passthrough",
+		protocol: gwapi.TLSProtocolType,
+		port:     443,
+		hostname: appsHostname,
+		tlsMode:  gwapi.TLSModePassthrough,
+	}, {
+		name:     "internal-http",
+		protocol: gwapi.HTTPProtocolType,
+		port:     8080,
+		hostname: internalHostname,
+	}})
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+	if _, err := waitForGatewayProgrammed(t, types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}); err != nil {
+		t.Fatalf("gateway %s/%s was not programmed: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if err := assertGatewayDNSRecords(t, gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	// A route for a host on each domain must be served through the record
+	// for that domain.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-domains-"))
+	echoPod := buildEchoPod("domains-backend", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for name, hostname := range map[string]string{"apps": appsHostname, "internal": internalHostname} {
+		routeHostname := strings.Replace(hostname, "*", "echo", 1)
+		httpRoute := buildHTTPRoute(name, ns.Name, gateway.Name, gateway.Namespace, routeHostname, echoService.Name)
+		if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+			t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+		}
+		if _, err := assertHttpRouteSuccessful(t, ns.Name, httpRoute.Name, gateway); err != nil {
+			t.Fatalf("httproute %s/%s was not accepted: %v", httpRoute.Namespace, httpRoute.Name, err)
+		}
+	}
+	if err := assertHttpRouteRuleResponse(t, strings.Replace(appsHostname, "*", "echo", 1), "/", http.StatusOK); err != nil {
+		t.Error(err)
+	}
+
+	// Removing the internal listener must delete its record and leave the
+	// record for the other domain in place.
+	internalRecordName := operatorcontroller.GatewayDNSRecordName(gateway, internalHostname)
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}, gateway); err != nil {
+			t.Logf("failed to get gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		gateway.Spec.Listeners = gateway.Spec.Listeners[:2]
+		if err := kclient.Update(ctx, gateway); err != nil {
+			t.Logf("failed to update gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to remove the internal listener from gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if err := assertGatewayDNSRecords(t, gateway); err != nil {
+		t.Fatal(err)
+	}
+	if err := kclient.Get(context.TODO(), internalRecordName, &iov1.DNSRecord{}); err == nil {
+		t.Errorf("expected dnsrecord %s for the removed listener to be deleted", internalRecordName)
+	} else if !errors.IsNotFound(err) {
+		t.Errorf("failed to get dnsrecord %s: %v", internalRecordName, err)
+	}
+}
+
+// testGatewayAPIDNSRecordCleanup verifies that the operator deletes a gateway's
+// DNS records when the gateway is deleted.  It creates a gateway, waits for its
+// DNS record to be published, deletes the gateway, and verifies that the
+// dnsrecord is deleted, which happens only after the record has been removed
+// from the DNS zones in which it was published, and that the gateway is gone.
+func testGatewayAPIDNSRecordCleanup(t *testing.T) {
+	t.Helper()
+
+	hostname := "*.gws-cleanup." + dnsConfig.Spec.BaseDomain
+
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGatewayWithListeners("e2e-dns-cleanup", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, []gatewayListenerSpec{{
+		name:     "http",
+		protocol: gwapi.HTTPProtocolType,
+		port:     80,
+		hostname: hostname,
+	}})
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+	recordName := operatorcontroller.GatewayDNSRecordName(gateway, hostname+".")
+	if err := assertDNSRecord(t, recordName); err != nil {
+		t.Fatalf("dnsrecord %s for hostname %s was not published: %v", recordName, hostname, err)
+	}
+
+	if err := kclient.Delete(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		var record iov1.DNSRecord
+		if err := kclient.Get(ctx, recordName, &record); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			t.Logf("failed to get dnsrecord %s: %v, retrying...", recordName, err)
+			return false, nil
+		}
+		var zones []string
+		for _, zone := range record.Status.Zones {
+			zones = append(zones, fmt.Sprintf("%+v", zone.DNSZone))
+		}
+		t.Logf("dnsrecord %s still exists (marked for deletion: %t, zones: %v), retrying...", recordName, record.DeletionTimestamp != nil, zones)
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to observe deletion of dnsrecord %s: %v", recordName, err)
+	}
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, gatewayName, &gwapi.Gateway{}); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			t.Logf("failed to get gateway %s: %v, retrying...", gatewayName, err)
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to observe deletion of gateway %s: %v", gatewayName, err)
+	}
+}
+
+// waitForGatewayHostnamesInvalid waits for the given gateway to report that
+// the named listener's hostname cannot be published in DNS and returns an
+// error if it does not.
+func waitForGatewayHostnamesInvalid(t *testing.T, gateway *gwapi.Gateway, listenerName gwapi.SectionName) error {
+	t.Helper()
+
+	name := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	var current gwapi.Gateway
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, name, &current); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		cond := meta.FindStatusCondition(current.Status.Conditions, gatewayservicedns.GatewayDNSHostnamesValidConditionType)
+		if cond == nil || cond.Status != metav1.ConditionFalse {
+			t.Logf("gateway %s does not yet report %s=False, retrying...", name, gatewayservicedns.GatewayDNSHostnamesValidConditionType)
+			return false, nil
+		}
+		if !strings.Contains(cond.Message, fmt.Sprintf("listener %q", listenerName)) {
+			return false, fmt.Errorf("expected condition message to mention listener %q, got %q", listenerName, cond.Message)
+		}
+		for _, listener := range current.Status.Listeners {
+			if listener.Name != listenerName {
+				continue
+			}
+			cond := meta.FindStatusCondition(listener.Conditions, gatewayservicedns.ListenerDNSHostnameValidConditionType)
+			if cond == nil || cond.Status != metav1.ConditionFalse {
+				t.Logf("listener %q of gateway %s does not yet report %s=False, retrying...", listenerName, name, gatewayservicedns.ListenerDNSHostnameValidConditionType)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("gateway %s did not report the hostname of listener %q as invalid: %w", name, listenerName, err)
+	}
+	return nil
+}
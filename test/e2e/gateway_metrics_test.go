@@ -0,0 +1,120 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	routev1client "github.com/openshift/client-go/route/clientset/versioned"
+	"github.com/openshift/library-go/test/library/metrics"
+	"github.com/prometheus/common/model"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPIMetrics verifies that the operator creates a podmonitor for
+// the test gateway and that cluster monitoring collects the gateway's Envoy
+// request metrics, labeled with the gateway's name and namespace.  It also
+// verifies that Envoy's own per-cluster metrics are not collected by default.
+// This test must run after testGatewayAPIObjects, which creates the test
+// gateway and http route.
+func testGatewayAPIMetrics(t *testing.T) {
+	t.Helper()
+
+	gateway := &gwapi.Gateway{}
+	gatewayName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: testGatewayName}
+	if err := kclient.Get(context.TODO(), gatewayName, gateway); err != nil {
+		t.Fatalf("failed to get gateway %s: %v", gatewayName, err)
+	}
+
+	// The operator must create a podmonitor that the gateway owns, so
+	// that the garbage collector deletes it with the gateway.
+	podMonitorName := operatorcontroller.GatewayPodMonitorName(gateway)
+	podMonitor := &unstructured.Unstructured{}
+	podMonitor.SetGroupVersionKind(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"})
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, podMonitorName, podMonitor); err != nil {
+			t.Logf("failed to get podmonitor %s: %v, retrying...", podMonitorName, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe podmonitor %s: %v", podMonitorName, err)
+	}
+	if !metav1.IsControlledBy(podMonitor, gateway) {
+		t.Fatalf("expected podmonitor %s to be owned by gateway %s, got %+v", podMonitorName, gatewayName, podMonitor.GetOwnerReferences())
+	}
+
+	// Send some requests through the gateway so that it has request
+	// metrics.
+	if err := assertHttpRouteConnection(t, defaultRoutename, gateway); err != nil {
+		t.Fatalf("failed to connect to %s: %v", defaultRoutename, err)
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	for i := 0; i < 10; i++ {
+		if _, err := getHttpResponse(httpClient, defaultRoutename); err != nil {
+			t.Logf("GET %s failed: %v", defaultRoutename, err)
+		}
+	}
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routeClient, err := routev1client.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prometheusClient, err := metrics.NewPrometheusClient(context.TODO(), kubeClient, routeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := fmt.Sprintf(`sum(istio_requests_total{gateway_name=%q, gateway_namespace=%q})`, gatewayName.Name, gatewayName.Namespace)
+	if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		result, _, err := prometheusClient.Query(ctx, query, time.Now())
+		if err != nil {
+			t.Logf("failed to query %q: %v, retrying...", query, err)
+			return false, nil
+		}
+		vec, ok := result.(model.Vector)
+		if !ok || len(vec) == 0 || vec[0].Value <= 0 {
+			t.Logf("query %q returned %v, retrying...", query, result)
+			return false, nil
+		}
+		t.Logf("query %q returned %v", query, vec[0].Value)
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe request metrics for gateway %s: %v", gatewayName, err)
+	}
+
+	// Envoy's per-cluster metrics must not be collected unless the
+	// gateway opts in to them.
+	query = fmt.Sprintf(`count({__name__=~"envoy_.*", gateway_name=%q, gateway_namespace=%q})`, gatewayName.Name, gatewayName.Namespace)
+	result, _, err := prometheusClient.Query(context.TODO(), query, time.Now())
+	if err != nil {
+		t.Fatalf("failed to query %q: %v", query, err)
+	}
+	if vec, ok := result.(model.Vector); !ok || len(vec) != 0 {
+		t.Errorf("expected query %q to return no results, got %v", query, result)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	gatewayservicedns "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gateway-service-dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPINodePortPublishing tests that a gateway that uses the
+// NodePortService endpoint publishing strategy gets a NodePort service.  With
+// the NodeAddresses DNS policy, the gateway's DNS record must point to the
+// nodes that run the gateway's ready pods; with the None DNS policy, the
+// gateway must have no DNS records.  The test only runs on platform type None,
+// where no cloud load balancer is available.
+func testGatewayAPINodePortPublishing(t *testing.T) {
+	t.Helper()
+
+	if infraConfig.Status.PlatformStatus == nil || infraConfig.Status.PlatformStatus.Type != configv1.NonePlatformType {
+		t.Skip("test only runs on platform type None")
+	}
+
+	domain := "gws-nodeport." + dnsConfig.Spec.BaseDomain
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGateway("e2e-nodeport", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, domain)
+	gateway.Annotations = map[string]string{
+		gatewayservicedns.GatewayEndpointPublishingStrategyAnnotation: string(operatorv1.NodePortServiceStrategyType),
+		gatewayservicedns.GatewayNodePortDNSPolicyAnnotation:          gatewayservicedns.NodePortDNSPolicyNodeAddresses,
+	}
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+
+	// The gateway's service must become a NodePort service.
+	gatewayLabels := client.MatchingLabels{"istio.io/gateway-name": gateway.Name}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		services := &corev1.ServiceList{}
+		if err := kclient.List(ctx, services, client.InNamespace(gateway.Namespace), gatewayLabels); err != nil {
+			t.Logf("failed to list services for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		if len(services.Items) == 0 {
+			t.Logf("no service found for gateway %s/%s, retrying...", gateway.Namespace, gateway.Name)
+			return false, nil
+		}
+		if services.Items[0].Spec.Type != corev1.ServiceTypeNodePort {
+			t.Logf("service for gateway %s/%s has type %s, expected %s, retrying...", gateway.Namespace, gateway.Name, services.Items[0].Spec.Type, corev1.ServiceTypeNodePort)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("service for gateway %s/%s did not become a NodePort service: %v", gateway.Namespace, gateway.Name, err)
+	}
+
+	// The DNS record must point to the host addresses of the gateway's
+	// ready pods.
+	recordName := operatorcontroller.GatewayDNSRecordName(gateway, "*."+domain+".")
+	var lastTargets, lastAddresses []string
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		pods := &corev1.PodList{}
+		if err := kclient.List(ctx, pods, client.InNamespace(gateway.Namespace), gatewayLabels); err != nil {
+			t.Logf("failed to list pods for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		lastAddresses = readyPodHostIPs(pods.Items)
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(ctx, recordName, record); err != nil {
+			t.Logf("failed to get dnsrecord %s: %v, retrying...", recordName, err)
+			return false, nil
+		}
+		lastTargets = append([]string(nil), record.Spec.Targets...)
+		sort.Strings(lastTargets)
+		if len(lastAddresses) == 0 || !reflect.DeepEqual(lastTargets, lastAddresses) {
+			t.Logf("dnsrecord %s has targets %v, expected %v, retrying...", recordName, lastTargets, lastAddresses)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("dnsrecord %s did not point to the gateway's nodes: %v, last targets: %v, last node addresses: %v", recordName, err, lastTargets, lastAddresses)
+	}
+
+	// With the None DNS policy, the gateway must have no DNS records.
+	if err := updateGatewayWithRetryOnConflict(t, client.ObjectKeyFromObject(gateway), 1*time.Minute, func(gateway *gwapi.Gateway) {
+		gateway.Annotations[gatewayservicedns.GatewayNodePortDNSPolicyAnnotation] = gatewayservicedns.NodePortDNSPolicyNone
+	}); err != nil {
+		t.Fatalf("failed to update gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		records := &iov1.DNSRecordList{}
+		if err := kclient.List(ctx, records, client.InNamespace(gateway.Namespace), gatewayLabels); err != nil {
+			t.Logf("failed to list dnsrecords for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		if len(records.Items) != 0 {
+			t.Logf("gateway %s/%s still has %d dnsrecords, retrying...", gateway.Namespace, gateway.Name, len(records.Items))
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("dnsrecords for gateway %s/%s were not deleted: %v", gateway.Namespace, gateway.Name, err)
+	}
+}
+
+// readyPodHostIPs returns the sorted, unique host IP addresses of the ready pods
+// among the given pods.
+func readyPodHostIPs(pods []corev1.Pod) []string {
+	addresses := sets.New[string]()
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || len(pod.Status.HostIP) == 0 {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				addresses.Insert(pod.Status.HostIP)
+			}
+		}
+	}
+	return sets.List(addresses)
+}
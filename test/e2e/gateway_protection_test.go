@@ -0,0 +1,287 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPIGatewayClassDeletionProtection verifies that deleting a
+// gatewayclass is blocked while a gateway references it, that the gateway's
+// status explains why, and that setting the force-delete annotation on the
+// gatewayclass allows the deletion to proceed.
+//
+// This test uses its own gatewayclass and gateway so that it does not disturb
+// the gatewayclass and gateway that the other tests use.
+func testGatewayAPIGatewayClassDeletionProtection(t *testing.T) {
+	t.Helper()
+
+	gatewayClass, err := createGatewayClass("e2e-protected", gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway, err := createGateway(gatewayClass, "e2e-protected-gateway", operatorcontroller.DefaultOperandNamespace, "gws-protected."+dnsConfig.Spec.BaseDomain)
+	if err != nil {
+		t.Fatalf("failed to create gateway: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+		if err := updateGatewayClassWithRetryOnConflict(t, gatewayClass.Name, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+			if gc.Annotations == nil {
+				gc.Annotations = map[string]string{}
+			}
+			gc.Annotations[gatewayclass.ForceDeleteAnnotation] = "true"
+		}); err != nil {
+			t.Logf("failed to annotate gatewayclass %s for deletion: %v", gatewayClass.Name, err)
+		}
+		if err := kclient.Delete(context.TODO(), gatewayClass); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gatewayclass %s: %v", gatewayClass.Name, err)
+		}
+	})
+
+	// Wait for the operator to add its finalizer before deleting the
+	// gatewayclass.
+	gatewayClassName := types.NamespacedName{Name: gatewayClass.Name}
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, gatewayClassName, gatewayClass); err != nil {
+			t.Logf("failed to get gatewayclass %s: %v, retrying...", gatewayClassName.Name, err)
+			return false, nil
+		}
+		return len(gatewayClass.Finalizers) != 0, nil
+	}); err != nil {
+		t.Fatalf("failed to observe finalizer on gatewayclass %s: %v", gatewayClassName.Name, err)
+	}
+
+	if err := kclient.Delete(context.TODO(), gatewayClass); err != nil {
+		t.Fatalf("failed to delete gatewayclass %s: %v", gatewayClassName.Name, err)
+	}
+
+	gatewayName := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	if err := waitForGatewayDependenciesCondition(t, gatewayName, metav1.ConditionFalse, "GatewayClassDeleting", 1*time.Minute); err != nil {
+		t.Fatalf("failed to observe blocked deletion on gateway %s: %v", gatewayName, err)
+	}
+	if err := kclient.Get(context.TODO(), gatewayClassName, gatewayClass); err != nil {
+		t.Fatalf("expected gatewayclass %s to remain while gateway %s references it: %v", gatewayClassName.Name, gatewayName, err)
+	}
+	if gatewayClass.DeletionTimestamp == nil {
+		t.Fatalf("expected gatewayclass %s to be marked for deletion", gatewayClassName.Name)
+	}
+
+	if err := updateGatewayClassWithRetryOnConflict(t, gatewayClassName.Name, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+		if gc.Annotations == nil {
+			gc.Annotations = map[string]string{}
+		}
+		gc.Annotations[gatewayclass.ForceDeleteAnnotation] = "true"
+	}); err != nil {
+		t.Fatalf("failed to annotate gatewayclass %s: %v", gatewayClassName.Name, err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, gatewayClassName, gatewayClass); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			t.Logf("failed to get gatewayclass %s: %v, retrying...", gatewayClassName.Name, err)
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to observe forced deletion of gatewayclass %s: %v", gatewayClassName.Name, err)
+	}
+}
+
+// testGatewayAPIServiceMeshControlPlaneRecreation verifies that the operator
+// recreates the servicemeshcontrolplane if it is deleted out-of-band, emits an
+// event about it, and reports the servicemeshcontrolplane's availability on
+// the gateways that depend on it.
+//
+// This test depends on the gateway that testGatewayAPIObjects creates.
+func testGatewayAPIServiceMeshControlPlaneRecreation(t *testing.T) {
+	t.Helper()
+
+	smcpName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: openshiftSMCPName}
+	smcp := &maistrav2.ServiceMeshControlPlane{}
+	if err := kclient.Get(context.TODO(), smcpName, smcp); err != nil {
+		t.Fatalf("failed to get ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+	oldUID := smcp.UID
+	if err := kclient.Delete(context.TODO(), smcp); err != nil {
+		t.Fatalf("failed to delete ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		return smcp.UID != oldUID, nil
+	}); err != nil {
+		t.Fatalf("failed to observe recreation of ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		events := &corev1.EventList{}
+		if err := kclient.List(ctx, events, client.InNamespace(metav1.NamespaceDefault)); err != nil {
+			t.Logf("failed to list events: %v, retrying...", err)
+			return false, nil
+		}
+		for _, event := range events.Items {
+			if event.InvolvedObject.Kind == "GatewayClass" && event.InvolvedObject.Name == gatewayclass.OpenShiftDefaultGatewayClassName && event.Reason == "ServiceMeshControlPlaneRecreated" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Errorf("failed to observe ServiceMeshControlPlaneRecreated event for gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+
+	if err := assertSMCP(context.TODO(), t); err != nil {
+		t.Fatalf("failed to observe recreated ServiceMeshControlPlane become ready: %v", err)
+	}
+	gatewayName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: testGatewayName}
+	if err := waitForGatewayDependenciesCondition(t, gatewayName, metav1.ConditionTrue, "DependenciesAvailable", 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe available dependencies on gateway %s: %v", gatewayName, err)
+	}
+}
+
+// testGatewayAPIServiceMeshControlPlaneRepair verifies that the operator
+// reverts out-of-band changes to the fields of the servicemeshcontrolplane
+// that it manages and preserves changes to the fields that it does not
+// manage.  The test removes an environment variable from the pilot container,
+// enables the cluster ingress gateway, and enables validation messages, which
+// the operator does not manage, and then verifies that the managed fields are
+// restored within a minute while validation messages remain enabled.
+func testGatewayAPIServiceMeshControlPlaneRepair(t *testing.T) {
+	t.Helper()
+
+	smcpName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: openshiftSMCPName}
+	pilot := maistrav2.ControlPlaneComponentNamePilot
+	const pilotEnvName = "PILOT_ENABLE_GATEWAY_CONTROLLER_MODE"
+	pilotEnv := func(smcp *maistrav2.ServiceMeshControlPlane) (string, bool) {
+		if smcp.Spec.Runtime == nil || smcp.Spec.Runtime.Components[pilot] == nil || smcp.Spec.Runtime.Components[pilot].Container == nil {
+			return "", false
+		}
+		value, ok := smcp.Spec.Runtime.Components[pilot].Container.Env[pilotEnvName]
+		return value, ok
+	}
+	ingressGatewayEnabled := func(smcp *maistrav2.ServiceMeshControlPlane) bool {
+		if smcp.Spec.Gateways == nil || smcp.Spec.Gateways.ClusterIngress == nil {
+			return false
+		}
+		enabled := smcp.Spec.Gateways.ClusterIngress.Enabled
+		return enabled != nil && *enabled
+	}
+	validationMessagesEnabled := func(smcp *maistrav2.ServiceMeshControlPlane) bool {
+		return smcp.Spec.General != nil && smcp.Spec.General.ValidationMessages != nil && *smcp.Spec.General.ValidationMessages
+	}
+
+	smcp := &maistrav2.ServiceMeshControlPlane{}
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		if _, ok := pilotEnv(smcp); !ok {
+			t.Logf("ServiceMeshControlPlane %s does not yet have pilot env var %s, retrying...", smcpName, pilotEnvName)
+			return false, nil
+		}
+		enabled := true
+		delete(smcp.Spec.Runtime.Components[pilot].Container.Env, pilotEnvName)
+		if smcp.Spec.Gateways == nil {
+			smcp.Spec.Gateways = &maistrav2.GatewaysConfig{}
+		}
+		if smcp.Spec.Gateways.ClusterIngress == nil {
+			smcp.Spec.Gateways.ClusterIngress = &maistrav2.ClusterIngressGatewayConfig{}
+		}
+		smcp.Spec.Gateways.ClusterIngress.Enabled = &enabled
+		if smcp.Spec.General == nil {
+			smcp.Spec.General = &maistrav2.GeneralConfig{}
+		}
+		smcp.Spec.General.ValidationMessages = &enabled
+		if err := kclient.Update(ctx, smcp); err != nil {
+			t.Logf("failed to update ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to modify ServiceMeshControlPlane %s: %v", smcpName, err)
+	}
+	t.Cleanup(func() {
+		if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+			smcp := &maistrav2.ServiceMeshControlPlane{}
+			if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+				t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+				return false, nil
+			}
+			if smcp.Spec.General == nil {
+				return true, nil
+			}
+			smcp.Spec.General.ValidationMessages = nil
+			if err := kclient.Update(ctx, smcp); err != nil {
+				t.Logf("failed to update ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			t.Errorf("failed to revert validation messages in ServiceMeshControlPlane %s: %v", smcpName, err)
+		}
+	})
+
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, smcpName, smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", smcpName, err)
+			return false, nil
+		}
+		value, ok := pilotEnv(smcp)
+		if !ok || value != "true" || ingressGatewayEnabled(smcp) {
+			t.Logf("ServiceMeshControlPlane %s has not been restored yet (pilot env var %s=%q, ingress gateway enabled: %t), retrying...", smcpName, pilotEnvName, value, ingressGatewayEnabled(smcp))
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe ServiceMeshControlPlane %s being restored: %v", smcpName, err)
+	}
+	if !validationMessagesEnabled(smcp) {
+		t.Errorf("expected ServiceMeshControlPlane %s to preserve spec.general.validationMessages", smcpName)
+	}
+
+	if err := assertSMCP(context.TODO(), t); err != nil {
+		t.Fatalf("failed to observe restored ServiceMeshControlPlane become ready: %v", err)
+	}
+}
+
+// waitForGatewayDependenciesCondition waits for the named gateway to have the
+// condition that reports on its dependencies with the given status and reason.
+func waitForGatewayDependenciesCondition(t *testing.T, name types.NamespacedName, status metav1.ConditionStatus, reason string, timeout time.Duration) error {
+	t.Helper()
+	gateway := &gwapi.Gateway{}
+	return wait.PollUntilContextTimeout(context.Background(), 2*time.Second, timeout, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, name, gateway); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		condition := meta.FindStatusCondition(gateway.Status.Conditions, gatewayclass.GatewayDependenciesAvailableConditionType)
+		if condition == nil {
+			return false, nil
+		}
+		t.Logf("observed condition %s=%s with reason %s on gateway %s", condition.Type, condition.Status, condition.Reason, name)
+		return condition.Status == status && condition.Reason == reason, nil
+	})
+}
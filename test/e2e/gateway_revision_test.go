@@ -0,0 +1,311 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	maistrastatus "github.com/maistra/istio-operator/pkg/apis/maistra/status"
+	maistrav2 "github.com/maistra/istio-operator/pkg/apis/maistra/v2"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	// gatewayAPIUpgradeChannelEnvVar is the environment variable that
+	// overrides the OSSM subscription channel that
+	// testGatewayAPIControlPlaneRevisionUpgrade upgrades to.
+	gatewayAPIUpgradeChannelEnvVar = "E2E_GATEWAY_API_UPGRADE_CHANNEL"
+	// gatewayAPIUpgradeControlPlaneVersionEnvVar is the environment
+	// variable that overrides the control plane version that
+	// testGatewayAPIControlPlaneRevisionUpgrade upgrades to.
+	gatewayAPIUpgradeControlPlaneVersionEnvVar = "E2E_GATEWAY_API_UPGRADE_CONTROL_PLANE_VERSION"
+	// defaultGatewayAPIUpgradeControlPlaneVersion is the control plane
+	// version that testGatewayAPIControlPlaneRevisionUpgrade upgrades to by
+	// default.
+	defaultGatewayAPIUpgradeControlPlaneVersion = "v2.6"
+	// controlPlaneMigrationTimeout is how long
+	// testGatewayAPIControlPlaneRevisionUpgrade waits for a migration
+	// between control plane revisions to finish.  It includes installing
+	// the OSSM operator from the new channel, provisioning the new
+	// revision, and rolling out every gateway.
+	controlPlaneMigrationTimeout = 15 * time.Minute
+	// maxControlPlaneMigrationErrorRate is the highest fraction of failed
+	// requests to a gateway that testGatewayAPIControlPlaneRevisionUpgrade
+	// tolerates while the gateway is migrated.
+	maxControlPlaneMigrationErrorRate = 0.01
+)
+
+// testGatewayAPIControlPlaneRevisionUpgrade verifies that upgrading OSSM to a
+// new channel and control plane version migrates gateways to a new control
+// plane revision without dropping traffic.  It sends requests through a
+// gateway while the gatewayclass's parameters bump the subscription channel and
+// the control plane version, waits for the gatewayclass to report that the
+// migration finished, and verifies that the gateway uses the new revision, that
+// the previous revision was removed, and that the error rate stayed under
+// maxControlPlaneMigrationErrorRate.  Finally, it removes the parameters and
+// verifies that the gateway is migrated back to the default revision.
+//
+// This test takes a long time to run and changes the cluster's OSSM
+// installation, so CI must opt in by setting E2E_LONG_RUNNING_TESTS=true.  The
+// target channel and version can be set using E2E_GATEWAY_API_UPGRADE_CHANNEL
+// and E2E_GATEWAY_API_UPGRADE_CONTROL_PLANE_VERSION.
+func testGatewayAPIControlPlaneRevisionUpgrade(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv(longRunningTestsEnvVar) != "true" {
+		t.Skipf("test skipped because %s is not set to \"true\"", longRunningTestsEnvVar)
+	}
+	channel := expectedSubscriptionChannel
+	if v := os.Getenv(gatewayAPIUpgradeChannelEnvVar); len(v) != 0 {
+		channel = v
+	}
+	version := defaultGatewayAPIUpgradeControlPlaneVersion
+	if v := os.Getenv(gatewayAPIUpgradeControlPlaneVersionEnvVar); len(v) != 0 {
+		version = v
+	}
+	newRevision := openshiftSMCPName + "-" + strings.ReplaceAll(version, ".", "-")
+
+	domain := "gws-upgrade." + dnsConfig.Spec.BaseDomain
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway, err := createGateway(gatewayClass, "e2e-upgrade", operatorcontroller.DefaultOperandNamespace, domain)
+	if err != nil {
+		t.Fatalf("failed to create gateway: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !apierrors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-upgrade-"))
+	echoPod := buildEchoPod("upgrade-backend", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	hostname := "upgrade." + domain
+	httpRoute := buildHTTPRoute("upgrade", ns.Name, gateway.Name, gateway.Namespace, hostname, echoService.Name)
+	if err := kclient.Create(context.TODO(), httpRoute); err != nil {
+		t.Fatalf("failed to create httproute %s/%s: %v", httpRoute.Namespace, httpRoute.Name, err)
+	}
+	if _, err := assertHttpRouteSuccessful(t, ns.Name, httpRoute.Name, gateway); err != nil {
+		t.Fatalf("httproute %s/%s was not accepted: %v", httpRoute.Namespace, httpRoute.Name, err)
+	}
+	if err := assertHttpRouteConnection(t, hostname, gateway); err != nil {
+		t.Fatalf("failed to connect to %s: %v", hostname, err)
+	}
+
+	// Send steady load through the gateway for the whole migration and
+	// count the requests that fail or get a server error.
+	var (
+		mu       sync.Mutex
+		total    int
+		failures []string
+	)
+	loadCtx, stopLoad := context.WithCancel(context.Background())
+	defer stopLoad()
+	loadDone := make(chan struct{})
+	go func() {
+		defer close(loadDone)
+		httpClient := &http.Client{Timeout: 5 * time.Second}
+		for {
+			response, err := httpClient.Get("http://" + hostname)
+			mu.Lock()
+			total++
+			switch {
+			case err != nil:
+				failures = append(failures, time.Now().Format(time.RFC3339)+": "+err.Error())
+			case response.StatusCode >= http.StatusInternalServerError:
+				failures = append(failures, time.Now().Format(time.RFC3339)+": "+response.Status)
+			}
+			mu.Unlock()
+			if err == nil {
+				response.Body.Close()
+			}
+			select {
+			case <-loadCtx.Done():
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	}()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorcontroller.DefaultOperatorNamespace,
+			Name:      "gateway-ossm-upgrade",
+		},
+		Data: map[string]string{
+			"ossmChannel":             channel,
+			"ossmControlPlaneVersion": version,
+		},
+	}
+	if err := kclient.Create(context.TODO(), cm); err != nil {
+		t.Fatalf("failed to create configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), cm); err != nil && !apierrors.IsNotFound(err) {
+			t.Errorf("failed to delete configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+	})
+	namespace := gwapi.Namespace(cm.Namespace)
+	if err := updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+		gc.Spec.ParametersRef = &gwapi.ParametersReference{
+			Kind:      "ConfigMap",
+			Name:      cm.Name,
+			Namespace: &namespace,
+		}
+	}); err != nil {
+		t.Fatalf("failed to set parameters on gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	parametersRemoved := false
+	removeParameters := func() error {
+		if err := updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+			gc.Spec.ParametersRef = nil
+		}); err != nil {
+			return err
+		}
+		parametersRemoved = true
+		return nil
+	}
+	t.Cleanup(func() {
+		if parametersRemoved {
+			return
+		}
+		if err := removeParameters(); err != nil {
+			t.Errorf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+			return
+		}
+		if err := waitForControlPlaneMigration(t, openshiftSMCPName, newRevision); err != nil {
+			t.Errorf("failed to migrate back to control plane revision %s: %v", openshiftSMCPName, err)
+		}
+	})
+
+	if err := waitForControlPlaneMigration(t, newRevision, openshiftSMCPName); err != nil {
+		t.Fatalf("failed to migrate to control plane revision %s: %v", newRevision, err)
+	}
+	if err := assertSubscription(context.TODO(), t, openshiftOperatorsNamespace, expectedSubscriptionName, expectedCatalogSourceNamespace, expectedCatalogSourceName, channel); err != nil {
+		t.Errorf("expected subscription %s to use channel %s: %v", expectedSubscriptionName, channel, err)
+	}
+	if err := assertGatewayControlPlaneRevision(t, gateway, newRevision); err != nil {
+		t.Error(err)
+	}
+	if err := assertHttpRouteRuleResponse(t, hostname, "/", http.StatusOK); err != nil {
+		t.Error(err)
+	}
+
+	stopLoad()
+	<-loadDone
+	mu.Lock()
+	defer mu.Unlock()
+	t.Logf("sent %d requests to %s while gateway %s/%s was migrated, %d failed", total, hostname, gateway.Namespace, gateway.Name, len(failures))
+	if total == 0 {
+		t.Fatalf("expected requests to be sent to %s during the migration", hostname)
+	}
+	if rate := float64(len(failures)) / float64(total); rate > maxControlPlaneMigrationErrorRate {
+		t.Errorf("expected at most %.1f%% of requests to %s to fail during the migration, got %.1f%%: %v", maxControlPlaneMigrationErrorRate*100, hostname, rate*100, failures)
+	}
+
+	// Removing the parameters must migrate the gateway back to the default
+	// revision.
+	if err := removeParameters(); err != nil {
+		t.Fatalf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	if err := waitForControlPlaneMigration(t, openshiftSMCPName, newRevision); err != nil {
+		t.Fatalf("failed to migrate back to control plane revision %s: %v", openshiftSMCPName, err)
+	}
+	if err := assertGatewayControlPlaneRevision(t, gateway, openshiftSMCPName); err != nil {
+		t.Error(err)
+	}
+}
+
+// waitForControlPlaneMigration waits for the default gatewayclass to report
+// that no migration between control plane revisions is in progress, for the
+// target servicemeshcontrolplane to be ready, and for the previous
+// servicemeshcontrolplane to be deleted.
+func waitForControlPlaneMigration(t *testing.T, target, previous string) error {
+	t.Helper()
+
+	return wait.PollUntilContextTimeout(context.Background(), 10*time.Second, controlPlaneMigrationTimeout, false, func(ctx context.Context) (bool, error) {
+		var gatewayClass gwapi.GatewayClass
+		if err := kclient.Get(ctx, types.NamespacedName{Name: gatewayclass.OpenShiftDefaultGatewayClassName}, &gatewayClass); err != nil {
+			t.Logf("failed to get gatewayclass %s: %v, retrying...", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+			return false, nil
+		}
+		cond := meta.FindStatusCondition(gatewayClass.Status.Conditions, gatewayclass.GatewayClassControlPlaneMigratingConditionType)
+		if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != "AsExpected" {
+			t.Logf("gatewayclass %s is still migrating (found %+v), retrying...", gatewayClass.Name, cond)
+			return false, nil
+		}
+		var smcp maistrav2.ServiceMeshControlPlane
+		targetName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: target}
+		if err := kclient.Get(ctx, targetName, &smcp); err != nil {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", targetName, err)
+			return false, nil
+		}
+		if smcp.Status.GetCondition(maistrastatus.ConditionTypeReady).Status != maistrastatus.ConditionStatusTrue {
+			t.Logf("ServiceMeshControlPlane %s is not ready, retrying...", targetName)
+			return false, nil
+		}
+		previousName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: previous}
+		if err := kclient.Get(ctx, previousName, &maistrav2.ServiceMeshControlPlane{}); err == nil {
+			t.Logf("ServiceMeshControlPlane %s still exists, retrying...", previousName)
+			return false, nil
+		} else if !apierrors.IsNotFound(err) {
+			t.Logf("failed to get ServiceMeshControlPlane %s: %v, retrying...", previousName, err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// assertGatewayControlPlaneRevision verifies that the given gateway uses the
+// given control plane revision and reports that its migration has finished.
+func assertGatewayControlPlaneRevision(t *testing.T, gateway *gwapi.Gateway, revision string) error {
+	t.Helper()
+
+	var current gwapi.Gateway
+	name := types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}
+	return wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, name, &current); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		// The default revision may be selected without the label.
+		if actual := current.Labels["istio.io/rev"]; actual != revision && !(actual == "" && revision == openshiftSMCPName) {
+			t.Logf("gateway %s uses control plane revision %q, not %q, retrying...", name, actual, revision)
+			return false, nil
+		}
+		cond := meta.FindStatusCondition(current.Status.Conditions, gatewayclass.GatewayControlPlaneRevisionConditionType)
+		if cond == nil || cond.Status != metav1.ConditionTrue {
+			t.Logf("gateway %s does not yet have condition %s=True (found %+v), retrying...", name, gatewayclass.GatewayControlPlaneRevisionConditionType, cond)
+			return false, nil
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,169 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// testGatewayAPISubscriptionParameters tests that the catalog source and
+// channel of the OSSM subscription can be overridden through the
+// gatewayclass's parameters, as is needed on disconnected clusters that mirror
+// operators into a custom catalog.  It first points the parameters at a
+// catalog source that does not exist and verifies that the gatewayclass
+// reports the missing catalog source and that the subscription is left as it
+// is.  It then creates the catalog source as a copy of the default one and
+// verifies that the subscription uses it.  Finally, it removes the parameters
+// and verifies that the subscription reverts to the default catalog source.
+func testGatewayAPISubscriptionParameters(t *testing.T) {
+	t.Helper()
+
+	mirrorName := "e2e-ossm-mirror"
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: operatorcontroller.DefaultOperatorNamespace,
+			Name:      "gateway-ossm-subscription",
+		},
+		Data: map[string]string{
+			"ossmCatalogSource":          mirrorName,
+			"ossmCatalogSourceNamespace": expectedCatalogSourceNamespace,
+			"ossmChannel":                expectedSubscriptionChannel,
+		},
+	}
+	if err := kclient.Create(context.TODO(), cm); err != nil {
+		t.Fatalf("failed to create configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), cm); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete configmap %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+	})
+
+	namespace := gwapi.Namespace(cm.Namespace)
+	if err := updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+		gc.Spec.ParametersRef = &gwapi.ParametersReference{
+			Kind:      "ConfigMap",
+			Name:      cm.Name,
+			Namespace: &namespace,
+		}
+	}); err != nil {
+		t.Fatalf("failed to set parameters on gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	removeParameters := func() error {
+		return updateGatewayClassWithRetryOnConflict(t, gatewayclass.OpenShiftDefaultGatewayClassName, 1*time.Minute, func(gc *gwapi.GatewayClass) {
+			gc.Spec.ParametersRef = nil
+		})
+	}
+	parametersRemoved := false
+	t.Cleanup(func() {
+		if parametersRemoved {
+			return
+		}
+		if err := removeParameters(); err != nil {
+			t.Errorf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+		}
+	})
+
+	// The mirror catalog source does not exist yet, so the subscription
+	// must be left as it is.
+	if err := waitForGatewayClassCondition(t, gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.GatewayClassSubscriptionAvailableConditionType, metav1.ConditionFalse, "CatalogSourceNotFound"); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertSubscription(context.TODO(), t, openshiftOperatorsNamespace, expectedSubscriptionName, expectedCatalogSourceNamespace, expectedCatalogSourceName, expectedSubscriptionChannel); err != nil {
+		t.Fatalf("expected subscription %s to keep the default catalog source: %v", expectedSubscriptionName, err)
+	}
+
+	// Create the mirror catalog source with the same contents as the
+	// default one, and verify that the subscription switches to it.
+	defaultCatalogSource := &operatorsv1alpha1.CatalogSource{}
+	defaultCatalogSourceName := types.NamespacedName{Namespace: expectedCatalogSourceNamespace, Name: expectedCatalogSourceName}
+	if err := kclient.Get(context.TODO(), defaultCatalogSourceName, defaultCatalogSource); err != nil {
+		t.Fatalf("failed to get catalog source %s: %v", defaultCatalogSourceName, err)
+	}
+	mirror := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: expectedCatalogSourceNamespace,
+			Name:      mirrorName,
+		},
+		Spec: *defaultCatalogSource.Spec.DeepCopy(),
+	}
+	if err := kclient.Create(context.TODO(), mirror); err != nil {
+		t.Fatalf("failed to create catalog source %s/%s: %v", mirror.Namespace, mirror.Name, err)
+	}
+	t.Cleanup(func() {
+		// Switch the subscription back before deleting the catalog
+		// source so that the subscription is never left without one.
+		if !parametersRemoved {
+			if err := removeParameters(); err != nil {
+				t.Errorf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+				return
+			}
+			parametersRemoved = true
+			if err := assertSubscription(context.TODO(), t, openshiftOperatorsNamespace, expectedSubscriptionName, expectedCatalogSourceNamespace, expectedCatalogSourceName, expectedSubscriptionChannel); err != nil {
+				t.Errorf("expected subscription %s to revert to the default catalog source: %v", expectedSubscriptionName, err)
+			}
+		}
+		if err := kclient.Delete(context.TODO(), mirror); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete catalog source %s/%s: %v", mirror.Namespace, mirror.Name, err)
+		}
+	})
+	if err := assertCatalogSource(context.TODO(), t, mirror.Namespace, mirror.Name); err != nil {
+		t.Fatalf("catalog source %s/%s is not ready: %v", mirror.Namespace, mirror.Name, err)
+	}
+	if err := waitForGatewayClassCondition(t, gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.GatewayClassSubscriptionAvailableConditionType, metav1.ConditionTrue, "SubscriptionAvailable"); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertSubscription(context.TODO(), t, openshiftOperatorsNamespace, expectedSubscriptionName, mirror.Namespace, mirror.Name, expectedSubscriptionChannel); err != nil {
+		t.Fatalf("expected subscription %s to use catalog source %s/%s: %v", expectedSubscriptionName, mirror.Namespace, mirror.Name, err)
+	}
+
+	// Removing the parameters must revert the subscription to the default
+	// catalog source.
+	if err := removeParameters(); err != nil {
+		t.Fatalf("failed to remove parameters from gatewayclass %s: %v", gatewayclass.OpenShiftDefaultGatewayClassName, err)
+	}
+	parametersRemoved = true
+	if err := assertSubscription(context.TODO(), t, openshiftOperatorsNamespace, expectedSubscriptionName, expectedCatalogSourceNamespace, expectedCatalogSourceName, expectedSubscriptionChannel); err != nil {
+		t.Fatalf("expected subscription %s to revert to the default catalog source: %v", expectedSubscriptionName, err)
+	}
+	if err := assertOSSMOperator(context.TODO(), t); err != nil {
+		t.Fatalf("failed to find expected Istio operator: %v", err)
+	}
+}
+
+// waitForGatewayClassCondition waits for the named gatewayclass to have the
+// given condition with the given status and reason, and returns an error if
+// it does not.
+func waitForGatewayClassCondition(t *testing.T, name, conditionType string, status metav1.ConditionStatus, reason string) error {
+	t.Helper()
+
+	var gatewayClass gwapi.GatewayClass
+	return wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, types.NamespacedName{Name: name}, &gatewayClass); err != nil {
+			t.Logf("failed to get gatewayclass %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		cond := meta.FindStatusCondition(gatewayClass.Status.Conditions, conditionType)
+		if cond == nil || cond.Status != status || cond.Reason != reason {
+			t.Logf("gatewayclass %s does not yet have condition %s=%s with reason %s (found %+v), retrying...", name, conditionType, status, reason, cond)
+			return false, nil
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,319 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller/gatewayclass"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// tlsRouteGVK is the group, version, and kind of the TLSRoute API.  TLSRoute
+// is only available in the experimental channel of Gateway API, for which the
+// operator does not vendor a typed client, so the test uses unstructured
+// objects.
+var tlsRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+	Kind:    "TLSRoute",
+}
+
+// testGatewayAPITLSRoutePassthrough tests that a gateway with both an HTTP
+// listener and a TLS listener with mode Passthrough serves both kinds of
+// routes.  It creates such a gateway, an echo server that serves HTTPS using a
+// serving certificate from the service CA, a TLS route for the echo server, a
+// plaintext echo server, and an http route for the plaintext echo server.  It
+// verifies that each listener gets its own DNS record and service port, that
+// the TLS route is accepted, that connections on the TLS listener are passed
+// through to the echo server without the gateway terminating TLS, and that
+// requests on the HTTP listener still succeed.
+func testGatewayAPITLSRoutePassthrough(t *testing.T) {
+	t.Helper()
+
+	domain := "gws-tls." + dnsConfig.Spec.BaseDomain
+	httpHostname := "plain." + domain
+	tlsHostname := "passthrough." + domain
+
+	gatewayClass, err := createGatewayClass(gatewayclass.OpenShiftDefaultGatewayClassName, gatewayclass.OpenShiftGatewayClassControllerName)
+	if err != nil {
+		t.Fatalf("failed to create gatewayclass: %v", err)
+	}
+	gateway := buildGatewayWithListeners("e2e-tls-passthrough", operatorcontroller.DefaultOperandNamespace, gatewayClass.Name, allNamespaces, []gatewayListenerSpec{{
+		name:     "http",
+		protocol: gwapi.HTTPProtocolType,
+		port:     80,
+		hostname: httpHostname,
+	}, {
+		name:     "tls-passthrough",
+		protocol: gwapi.TLSProtocolType,
+		port:     443,
+		hostname: tlsHostname,
+		tlsMode:  gwapi.TLSModePassthrough,
+	}})
+	if err := kclient.Create(context.TODO(), gateway); err != nil {
+		t.Fatalf("failed to create gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), gateway); err != nil && !errors.IsNotFound(err) {
+			t.Errorf("failed to delete gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+		}
+	})
+	if _, err := waitForGatewayProgrammed(t, types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}); err != nil {
+		t.Fatalf("gateway %s/%s was not programmed: %v", gateway.Namespace, gateway.Name, err)
+	}
+
+	// Each listener must get its own DNS record and service port.
+	if err := assertGatewayDNSRecords(t, gateway); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertGatewayServicePorts(t, gateway, 80, 443); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the HTTPS echo server and the TLS route for it.  The pods,
+	// services, and routes are cleaned up when the namespace is deleted.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("test-e2e-gwapi-tlsroute-"))
+	echoTLS := withEchoTLS("tls-echo-cert")
+	tlsEchoPod := buildEchoPod("tls-echo", ns.Name, echoTLS)
+	if err := kclient.Create(context.TODO(), tlsEchoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", tlsEchoPod.Namespace, tlsEchoPod.Name, err)
+	}
+	tlsEchoService := buildEchoService(tlsEchoPod.Name, ns.Name, tlsEchoPod.Labels, echoTLS)
+	tlsEchoService.Annotations = map[string]string{
+		"service.beta.openshift.io/serving-cert-secret-name": tlsEchoPod.Name + "-cert",
+	}
+	if err := kclient.Create(context.TODO(), tlsEchoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", tlsEchoService.Namespace, tlsEchoService.Name, err)
+	}
+	if err := waitForPodReady(t, kclient, tlsEchoPod, 3*time.Minute); err != nil {
+		t.Fatalf("pod %s/%s is not ready: %v", tlsEchoPod.Namespace, tlsEchoPod.Name, err)
+	}
+	tlsRoute, err := createTLSRoute("tls-passthrough", ns.Name, gateway.Name, gateway.Namespace, "tls-passthrough", tlsHostname, tlsEchoService.Name, echoDefaultHTTPSPort)
+	if err != nil {
+		t.Fatalf("failed to create tlsroute: %v", err)
+	}
+	if err := assertTLSRouteAccepted(t, tlsRoute.GetNamespace(), tlsRoute.GetName()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the plaintext echo server and the http route for it.
+	httpRoute, err := createHttpRoute(ns.Name, "plain", gateway.Namespace, httpHostname, "plain-echo", gateway)
+	if err != nil {
+		t.Fatalf("failed to create httproute: %v", err)
+	}
+	if _, err := assertHttpRouteSuccessful(t, httpRoute.Namespace, httpRoute.Name, gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	// The serving certificate from the service CA is valid for the
+	// service's DNS name and not for the route's hostname, so presenting
+	// it proves that the gateway passed the connection through rather
+	// than terminating TLS itself.
+	serviceHostname := fmt.Sprintf("%s.%s.svc", tlsEchoService.Name, tlsEchoService.Namespace)
+	if err := assertTLSPassthroughResponse(t, tlsHostname, serviceHostname); err != nil {
+		t.Error(err)
+	}
+	if err := assertHttpRouteRuleResponse(t, httpHostname, "/", http.StatusOK); err != nil {
+		t.Error(err)
+	}
+}
+
+// createTLSRoute creates the TLSRoute, or gets and updates it if it already
+// exists.  If this succeeds, the TLSRoute is returned.  Otherwise, an error is
+// returned.
+func createTLSRoute(name, namespace, parentGateway, parentNamespace, sectionName, hostname, backendName string, backendPort int32) (*unstructured.Unstructured, error) {
+	tlsRoute := buildTLSRoute(name, namespace, parentGateway, parentNamespace, sectionName, hostname, backendName, backendPort)
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(tlsRouteGVK)
+	return ensureResource(tlsRoute, current, func(current, desired *unstructured.Unstructured) (bool, *unstructured.Unstructured) {
+		if equality.Semantic.DeepEqual(current.Object["spec"], desired.Object["spec"]) {
+			return false, nil
+		}
+		updated := current.DeepCopy()
+		updated.Object["spec"] = desired.Object["spec"]
+		return true, updated
+	})
+}
+
+// buildTLSRoute returns a TLSRoute that attaches to the named listener of the
+// given gateway and forwards connections for the given hostname to the given
+// backend service and port.  If sectionName is empty, the route attaches to
+// all listeners of the gateway that allow it.
+func buildTLSRoute(name, namespace, parentGateway, parentNamespace, sectionName, hostname, backendName string, backendPort int32) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{
+		"name":      parentGateway,
+		"namespace": parentNamespace,
+	}
+	if len(sectionName) != 0 {
+		parentRef["sectionName"] = sectionName
+	}
+	tlsRoute := &unstructured.Unstructured{}
+	tlsRoute.SetGroupVersionKind(tlsRouteGVK)
+	tlsRoute.SetName(name)
+	tlsRoute.SetNamespace(namespace)
+	tlsRoute.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{hostname},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": backendName,
+						"port": int64(backendPort),
+					},
+				},
+			},
+		},
+	}
+	return tlsRoute
+}
+
+// assertTLSRouteAccepted checks that the TLSRoute of the given name reports
+// Accepted=True for every parent within 2 minutes, and returns an error if not.
+func assertTLSRouteAccepted(t *testing.T, namespace, name string) error {
+	t.Helper()
+
+	tlsRoute := &unstructured.Unstructured{}
+	tlsRoute.SetGroupVersionKind(tlsRouteGVK)
+	nsName := types.NamespacedName{Namespace: namespace, Name: name}
+	var lastStatus []interface{}
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, nsName, tlsRoute); err != nil {
+			t.Logf("failed to get tlsroute %s: %v, retrying...", nsName, err)
+			return false, nil
+		}
+		parents, _, _ := unstructured.NestedSlice(tlsRoute.Object, "status", "parents")
+		lastStatus = parents
+		if len(parents) == 0 {
+			t.Logf("tlsroute %s has no parent status, retrying...", nsName)
+			return false, nil
+		}
+		for _, parent := range parents {
+			accepted := false
+			conditions, _, _ := unstructured.NestedSlice(parent.(map[string]interface{}), "conditions")
+			for _, condition := range conditions {
+				c := condition.(map[string]interface{})
+				if c["type"] == string(gwapi.RouteConditionAccepted) && c["status"] == string(metav1.ConditionTrue) {
+					accepted = true
+				}
+			}
+			if !accepted {
+				t.Logf("tlsroute %s has parent status %v, expected %s=True, retrying...", nsName, parent, gwapi.RouteConditionAccepted)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("tlsroute %s did not report %s=True: %v, last recorded parent status: %v", nsName, gwapi.RouteConditionAccepted, err, lastStatus)
+	}
+	t.Logf("tlsroute %s accepted", nsName)
+	return nil
+}
+
+// assertGatewayServicePorts checks that the service that Istio creates for the
+// given gateway exposes each of the given ports within 2 minutes, and returns
+// an error if not.
+func assertGatewayServicePorts(t *testing.T, gateway *gwapi.Gateway, ports ...int32) error {
+	t.Helper()
+
+	var lastPorts []corev1.ServicePort
+	err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		services := &corev1.ServiceList{}
+		if err := kclient.List(ctx, services, client.InNamespace(gateway.Namespace), client.MatchingLabels{"istio.io/gateway-name": gateway.Name}); err != nil {
+			t.Logf("failed to list services for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		if len(services.Items) == 0 {
+			t.Logf("no service found for gateway %s/%s, retrying...", gateway.Namespace, gateway.Name)
+			return false, nil
+		}
+		lastPorts = services.Items[0].Spec.Ports
+		for _, port := range ports {
+			found := false
+			for _, servicePort := range lastPorts {
+				if servicePort.Port == port {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Logf("service for gateway %s/%s does not yet expose port %d, retrying...", gateway.Namespace, gateway.Name, port)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("service for gateway %s/%s did not expose ports %v: %v, last recorded ports: %v", gateway.Namespace, gateway.Name, ports, err, lastPorts)
+	}
+	return nil
+}
+
+// assertTLSPassthroughResponse checks that an HTTPS request to the given
+// hostname succeeds and that the server presents a certificate that is valid
+// for the given backend hostname, which shows that the connection was passed
+// through to the backend, and returns an error if not.
+func assertTLSPassthroughResponse(t *testing.T, hostname, backendHostname string) error {
+	t.Helper()
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// The certificate is not valid for the hostname, so
+			// verify it explicitly below.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	url := "https://" + hostname + "/"
+	var lastErr error
+	err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		response, err := client.Get(url)
+		if err != nil {
+			lastErr = err
+			t.Logf("GET %s failed: %v, retrying...", url, err)
+			return false, nil
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("status %d", response.StatusCode)
+			t.Logf("GET %s returned status %d, expected %d, retrying...", url, response.StatusCode, http.StatusOK)
+			return false, nil
+		}
+		if response.TLS == nil || len(response.TLS.PeerCertificates) == 0 {
+			lastErr = fmt.Errorf("no peer certificates")
+			return false, nil
+		}
+		if err := response.TLS.PeerCertificates[0].VerifyHostname(backendHostname); err != nil {
+			lastErr = err
+			t.Logf("GET %s was not served with the backend's certificate: %v, retrying...", url, err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("GET %s was not passed through to %s: %v, last error: %v", url, backendHostname, err, lastErr)
+	}
+	t.Logf("GET %s was passed through to %s", url, backendHostname)
+	return nil
+}
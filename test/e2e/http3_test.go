@@ -0,0 +1,160 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestHTTP3 is a smoke test for an ingresscontroller that enables HTTP/3 using
+// the "http3" unsupported config override.  It verifies that the router
+// deployment has a UDP port for HTTP/3, that the ingresscontroller reports the
+// "HTTP3Supported" status condition, and that the router advertises HTTP/3
+// using the Alt-Svc header on HTTPS responses.  If the client image's curl
+// supports HTTP/3, the test also sends a request to the router using HTTP/3
+// only.
+func TestHTTP3(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "http3"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"http3":{}}`)}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	conditions := append([]operatorv1.OperatorCondition{
+		{Type: ingresscontroller.IngressControllerHTTP3SupportedConditionType, Status: operatorv1.ConditionTrue},
+	}, availableConditionsForPrivateIngressController...)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_ENABLE_HTTP3", "true"); err != nil {
+		t.Fatalf("expected deployment to enable HTTP/3: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}, deployment); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	haveUDPPort := false
+	for _, port := range deployment.Spec.Template.Spec.Containers[0].Ports {
+		if port.Name == ingresscontroller.HTTP3PortName && port.Protocol == corev1.ProtocolUDP && port.ContainerPort == 443 {
+			haveUDPPort = true
+		}
+	}
+	if !haveUDPPort {
+		t.Fatalf("expected router container to have UDP port 443 named %q, got %+v", ingresscontroller.HTTP3PortName, deployment.Spec.Template.Spec.Containers[0].Ports)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("deployment has invalid selector: %v", err)
+	}
+	routerPods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), routerPods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list router pods: %v", err)
+	}
+	if len(routerPods.Items) == 0 {
+		t.Fatal("expected at least one router pod")
+	}
+	routerPodIP := routerPods.Items[0].Status.PodIP
+
+	ns := createNamespace(t, "http3-e2e")
+	echoPod := buildEchoPod("echo", ns.Name)
+	clientPod := buildExecPod("http3-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	routeHost := "echo." + domain
+	route := buildRoute("echo", ns.Name, echoService.Name)
+	route.Spec.Host = routeHost
+	route.Spec.TLS = &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}
+	if err := kclient.Create(context.TODO(), route); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+	}
+
+	// Verify that the router advertises HTTP/3 on HTTPS responses.
+	tcpCmd := []string{"/bin/curl", "-k", "-s", "-o", "/dev/null", "-D", "-", "--max-time", "10", "--resolve", fmt.Sprintf("%s:443:%s", routeHost, routerPodIP), "https://" + routeHost + "/"}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var stdout, stderr bytes.Buffer
+		if err := podExec(t, *clientPod, &stdout, &stderr, tcpCmd); err != nil {
+			t.Logf("failed to send request: %v: %s, retrying...", err, stderr.String())
+			return false, nil
+		}
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			name, value, ok := strings.Cut(line, ":")
+			if ok && strings.EqualFold(name, "alt-svc") && strings.Contains(value, `h3=":443"`) {
+				t.Logf("router advertises HTTP/3: %s", strings.TrimSpace(line))
+				return true, nil
+			}
+		}
+		t.Logf("response has no HTTP/3 Alt-Svc header: %q, retrying...", stdout.String())
+		return false, nil
+	}); err != nil {
+		t.Fatalf("failed to observe the router advertise HTTP/3: %v", err)
+	}
+
+	// Send a request over HTTP/3 if the client supports it.
+	var stdout, stderr bytes.Buffer
+	if err := podExec(t, *clientPod, &stdout, &stderr, []string{"/bin/curl", "-V"}); err != nil {
+		t.Fatalf("failed to get curl version: %v: %s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "HTTP3") {
+		t.Log("curl in the client image does not support HTTP/3; skipping the HTTP/3 request")
+		return
+	}
+	quicCmd := []string{"/bin/curl", "-k", "-s", "-o", "/dev/null", "-w", "%{http_version} %{http_code}", "--http3-only", "--max-time", "10", "--resolve", fmt.Sprintf("%s:443:%s", routeHost, routerPodIP), "https://" + routeHost + "/"}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var stdout, stderr bytes.Buffer
+		if err := podExec(t, *clientPod, &stdout, &stderr, quicCmd); err != nil {
+			t.Logf("failed to send HTTP/3 request: %v: %s, retrying...", err, stderr.String())
+			return false, nil
+		}
+		if stdout.String() != "3 200" {
+			t.Logf("got HTTP version and status %q, expected %q, retrying...", stdout.String(), "3 200")
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to get a response over HTTP/3: %v", err)
+	}
+}
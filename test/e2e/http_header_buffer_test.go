@@ -32,26 +32,14 @@ func TestHTTPHeaderBufferSize(t *testing.T) {
 	t.Parallel()
 	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "header-buffer-size"}
 	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
-	ic := newPrivateController(icName, domain)
 	// Create an ingress controller with header buffer values that are 2x
 	// the defaults.
-	ic.Spec.TuningOptions = operatorv1.IngressControllerTuningOptions{
+	ic := newIngressController(icName, domain, withTuningOptions(operatorv1.IngressControllerTuningOptions{
 		HeaderBufferBytes:           65536,
 		HeaderBufferMaxRewriteBytes: 16384,
-	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
-
+	}))
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -213,7 +201,7 @@ func TestHTTPHeaderBufferSize(t *testing.T) {
 		t.Fatalf("failed to update ingresscontroller %s: %v", icName, err)
 	}
 
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
+	if err := awaitIngressControllerReady(t, icName); err != nil {
 		t.Fatalf("failed to observe expected conditions: %v", err)
 	}
 
@@ -38,18 +38,8 @@ func TestHeaderNameCaseAdjustment(t *testing.T) {
 	ic.Spec.HTTPHeaders = &operatorv1.IngressControllerHTTPHeaders{
 		HeaderNameCaseAdjustments: testHeaderNames,
 	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -0,0 +1,135 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestAWSNLBDualstackIPAddressType creates an IngressController that uses an
+// AWS NLB with the dualstack IP address type, which it specifies using
+// spec.unsupportedConfigOverrides.awsNetworkLoadBalancer.ipAddressType.  The
+// test verifies that the LB-type service has the IP address type annotation
+// and that the wildcard DNS name resolves to an IPv6 address.
+func TestAWSNLBDualstackIPAddressType(t *testing.T) {
+	t.Parallel()
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	if infraConfig.Status.PlatformStatus.Type != configv1.AWSPlatformType {
+		t.Skipf("test skipped on platform %q", infraConfig.Status.PlatformStatus.Type)
+	}
+
+	// A dualstack NLB requires subnets with IPv6 CIDR blocks.
+	ec2ServiceClient := createEC2ServiceClient(t, infraConfig)
+	clusterName, err := getClusterName(infraConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpcID, err := getVPCId(ec2ServiceClient, clusterName)
+	if err != nil {
+		t.Fatalf("failed to get VPC ID due to error: %v", err)
+	}
+	if hasIPv6, err := vpcHasIPv6CIDRBlock(ec2ServiceClient, vpcID); err != nil {
+		t.Fatalf("failed to get IPv6 CIDR blocks for VPC %s: %v", vpcID, err)
+	} else if !hasIPv6 {
+		t.Skipf("test skipped because VPC %s has no IPv6 CIDR block", vpcID)
+	}
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "dualstack-nlb"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(name, domain)
+	ic.Spec.EndpointPublishingStrategy.LoadBalancer = &operatorv1.LoadBalancerStrategy{
+		Scope:               operatorv1.ExternalLoadBalancer,
+		DNSManagementPolicy: operatorv1.ManagedLoadBalancerDNS,
+		ProviderParameters: &operatorv1.ProviderLoadBalancerParameters{
+			Type: operatorv1.AWSLoadBalancerProvider,
+			AWS: &operatorv1.AWSLoadBalancerParameters{
+				Type: operatorv1.AWSNetworkLoadBalancer,
+			},
+		},
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"awsNetworkLoadBalancer":{"ipAddressType":"Dualstack"}}`),
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableNotProgressingConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	waitForLBAnnotation(t, ic, dnsrecord.AWSLBIPAddressTypeAnnotation, true, dnsrecord.AWSLBIPAddressTypeDualStack)
+
+	// Wait for the DNS controller to publish the wildcard record as
+	// dual-stack.
+	wildcardRecordName := controller.WildcardDNSRecordName(ic)
+	err = wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(ctx, wildcardRecordName, record); err != nil {
+			t.Logf("failed to get wildcard dnsrecord %s: %v", wildcardRecordName, err)
+			return false, nil
+		}
+		if record.Annotations[dnsrecord.DNSDualStackPublishedAnnotation] != "true" {
+			t.Logf("wildcard dnsrecord %s has not been published as dual-stack yet; annotations: %v", wildcardRecordName, record.Annotations)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe dual-stack wildcard dnsrecord %s: %v", wildcardRecordName, err)
+	}
+
+	// Verify that the AAAA record for the wildcard resolves.
+	host := "dualstack-test." + domain
+	err = wait.PollUntilContextTimeout(context.Background(), 10*time.Second, 10*time.Minute, true, func(ctx context.Context) (bool, error) {
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip6", host)
+		if err != nil {
+			t.Logf("failed to resolve AAAA record for %s: %v", host, err)
+			return false, nil
+		}
+		t.Logf("resolved AAAA record for %s: %v", host, ips)
+		return len(ips) != 0, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe an AAAA record for %s: %v", host, err)
+	}
+}
+
+// vpcHasIPv6CIDRBlock returns a Boolean value indicating whether the VPC with
+// the given ID has an associated IPv6 CIDR block.
+func vpcHasIPv6CIDRBlock(ec2Client *ec2.EC2, vpcID string) (bool, error) {
+	vpcs, err := ec2Client.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(vpcID)}})
+	if err != nil {
+		return false, err
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return false, fmt.Errorf("VPC %s not found", vpcID)
+	}
+	for _, association := range vpcs.Vpcs[0].Ipv6CidrBlockAssociationSet {
+		if association.Ipv6CidrBlockState != nil && aws.StringValue(association.Ipv6CidrBlockState.State) == ec2.VpcCidrBlockStateCodeAssociated {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,461 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/api/operatoringress/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// longRunningTestsEnvVar is the name of the environment variable that
+	// CI sets to "true" to run tests that take too long to run on every
+	// pull request.
+	longRunningTestsEnvVar = "E2E_LONG_RUNNING_TESTS"
+	// lifecycleSoakIterationsEnvVar is the name of the environment
+	// variable that specifies the number of iterations of
+	// TestIngressControllerLifecycleSoak.
+	lifecycleSoakIterationsEnvVar = "E2E_LIFECYCLE_SOAK_ITERATIONS"
+	// lifecycleSoakDefaultIterations is the number of iterations of
+	// TestIngressControllerLifecycleSoak if lifecycleSoakIterationsEnvVar
+	// is not set.
+	lifecycleSoakDefaultIterations = 5
+
+	// lifecycleSoakCleanupTimeout is how long TestIngressControllerLifecycleSoak
+	// waits for an ingresscontroller's resources to be deleted after the
+	// ingresscontroller has been deleted.
+	lifecycleSoakCleanupTimeout = 10 * time.Minute
+	// defaultOrphanCleanupGracePeriod is the default value of the
+	// operator's --orphan-cleanup-grace-period flag.
+	defaultOrphanCleanupGracePeriod = 1 * time.Hour
+)
+
+// lifecycleFailure is a failure that TestIngressControllerLifecycleSoak
+// injects into the lifecycle of an ingresscontroller.
+type lifecycleFailure string
+
+const (
+	// deleteLoadBalancerServiceFailure deletes the load balancer service
+	// before the ingresscontroller's load balancer is provisioned.
+	deleteLoadBalancerServiceFailure lifecycleFailure = "DeleteLoadBalancerServiceDuringProvisioning"
+	// deleteDNSRecordFailure deletes the wildcard dnsrecord of a ready
+	// ingresscontroller.
+	deleteDNSRecordFailure lifecycleFailure = "DeleteDNSRecord"
+	// restartOperatorFailure restarts the operator while it is deleting
+	// the ingresscontroller.
+	restartOperatorFailure lifecycleFailure = "RestartOperatorDuringDeletion"
+	// removeFinalizerFailure removes the ingresscontroller's finalizer
+	// while the operator is deleting the ingresscontroller, so that the
+	// ingresscontroller is deleted before its operand resources.
+	removeFinalizerFailure lifecycleFailure = "RemoveFinalizerDuringDeletion"
+)
+
+// TestIngressControllerLifecycleSoak repeatedly creates an ingresscontroller
+// with the "LoadBalancerService" endpoint publishing strategy, verifies that
+// it serves a request, and deletes it, injecting a random failure into each
+// iteration.  After each iteration, the test verifies that no operand resources
+// or dnsrecords of the ingresscontroller remain.
+//
+// This test takes a long time to run and restarts the operator, so CI must opt
+// in by setting E2E_LONG_RUNNING_TESTS=true.  The number of iterations can be
+// set using E2E_LIFECYCLE_SOAK_ITERATIONS.
+func TestIngressControllerLifecycleSoak(t *testing.T) {
+	if os.Getenv(longRunningTestsEnvVar) != "true" {
+		t.Skipf("test skipped because %s is not set to \"true\"", longRunningTestsEnvVar)
+	}
+	if infraConfig.Status.PlatformStatus == nil {
+		t.Skip("test skipped on nil platform")
+	}
+	platform := infraConfig.Status.PlatformStatus.Type
+	supportedPlatforms := map[configv1.PlatformType]struct{}{
+		configv1.AWSPlatformType:   {},
+		configv1.AzurePlatformType: {},
+		configv1.GCPPlatformType:   {},
+	}
+	if _, supported := supportedPlatforms[platform]; !supported {
+		t.Skipf("test skipped on platform %q", platform)
+	}
+
+	iterations := lifecycleSoakDefaultIterations
+	if v := os.Getenv(lifecycleSoakIterationsEnvVar); len(v) != 0 {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			t.Fatalf("invalid value for %s: %q", lifecycleSoakIterationsEnvVar, v)
+		}
+		iterations = n
+	}
+
+	failures := []lifecycleFailure{deleteLoadBalancerServiceFailure, deleteDNSRecordFailure, restartOperatorFailure}
+	// Removing the finalizer leaves the operand resources for the orphan
+	// cleanup controller, which only deletes them after its grace period
+	// and only if it is not in dry-run mode.
+	gracePeriod, err := orphanCleanupGracePeriod(t)
+	if err != nil {
+		t.Fatalf("failed to get the orphan cleanup grace period: %v", err)
+	}
+	dryRun, err := orphanCleanupDryRun(t)
+	if err != nil {
+		t.Fatalf("failed to get the orphan cleanup dry-run mode: %v", err)
+	}
+	switch {
+	case dryRun:
+		t.Logf("not injecting %s because the orphan cleanup is in dry-run mode", removeFinalizerFailure)
+	case gracePeriod >= lifecycleSoakCleanupTimeout:
+		t.Logf("not injecting %s because the orphan cleanup grace period %s exceeds the cleanup timeout %s", removeFinalizerFailure, gracePeriod, lifecycleSoakCleanupTimeout)
+	default:
+		failures = append(failures, removeFinalizerFailure)
+	}
+
+	seed := time.Now().UnixNano()
+	t.Logf("using random seed %d", seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "lifecycle-soak"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	for i := 1; i <= iterations; i++ {
+		failure := failures[rng.Intn(len(failures))]
+		t.Logf("iteration %d of %d: injecting %s", i, iterations, failure)
+		runLifecycleSoakIteration(t, newLoadBalancerController(name, domain), i, failure)
+		assertNoOperandResourcesForIngressController(t, name.Name, lifecycleSoakCleanupTimeout)
+	}
+}
+
+// runLifecycleSoakIteration creates, verifies, and deletes the given
+// ingresscontroller, injecting the given failure.
+func runLifecycleSoakIteration(t *testing.T, ic *operatorv1.IngressController, iteration int, failure lifecycleFailure) {
+	t.Helper()
+
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", name, err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	if failure == deleteLoadBalancerServiceFailure {
+		serviceName := controller.LoadBalancerServiceName(ic)
+		service := &corev1.Service{}
+		if err := wait.PollImmediate(1*time.Second, 2*time.Minute, func() (bool, error) {
+			if err := kclient.Get(context.TODO(), serviceName, service); err != nil {
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			t.Fatalf("failed to observe load balancer service %s: %v", serviceName, err)
+		}
+		if len(service.Status.LoadBalancer.Ingress) != 0 {
+			t.Logf("load balancer service %s was provisioned before it could be deleted", serviceName)
+		}
+		if err := kclient.Delete(context.TODO(), service); err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("failed to delete load balancer service %s: %v", serviceName, err)
+		}
+		t.Logf("deleted load balancer service %s", serviceName)
+	}
+
+	if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableConditionsForIngressControllerWithLoadBalancer...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	if failure == deleteDNSRecordFailure {
+		recordName := controller.WildcardDNSRecordName(ic)
+		record := &iov1.DNSRecord{}
+		if err := kclient.Get(context.TODO(), recordName, record); err != nil {
+			t.Fatalf("failed to get dnsrecord %s: %v", recordName, err)
+		}
+		if err := kclient.Delete(context.TODO(), record); err != nil {
+			t.Fatalf("failed to delete dnsrecord %s: %v", recordName, err)
+		}
+		t.Logf("deleted dnsrecord %s", recordName)
+		// Wait for the operator to replace the dnsrecord.
+		if err := wait.PollImmediate(2*time.Second, 5*time.Minute, func() (bool, error) {
+			current := &iov1.DNSRecord{}
+			if err := kclient.Get(context.TODO(), recordName, current); err != nil {
+				return false, nil
+			}
+			return current.UID != record.UID && current.DeletionTimestamp == nil, nil
+		}); err != nil {
+			t.Fatalf("failed to observe replacement of dnsrecord %s: %v", recordName, err)
+		}
+		if err := waitForIngressControllerCondition(t, kclient, 10*time.Minute, name, availableConditionsForIngressControllerWithLoadBalancer...); err != nil {
+			t.Fatalf("failed to observe expected conditions: %v", err)
+		}
+	}
+
+	record := &iov1.DNSRecord{}
+	if err := kclient.Get(context.TODO(), controller.WildcardDNSRecordName(ic), record); err != nil {
+		t.Fatalf("failed to get dnsrecord %s: %v", controller.WildcardDNSRecordName(ic), err)
+	}
+	if len(record.Spec.Targets) == 0 {
+		t.Fatalf("dnsrecord %s has no targets", controller.WildcardDNSRecordName(ic))
+	}
+	testName := types.NamespacedName{Namespace: name.Namespace, Name: fmt.Sprintf("%s-%d", name.Name, iteration)}
+	verifyExternalIngressController(t, testName, "apps."+ic.Spec.Domain, record.Spec.Targets[0])
+
+	switch failure {
+	case restartOperatorFailure:
+		if err := kclient.Delete(context.TODO(), ic); err != nil {
+			t.Fatalf("failed to delete ingresscontroller %s: %v", name, err)
+		}
+		restartIngressOperator(t)
+		if err := waitForIngressControllerDeleted(t, name, 5*time.Minute); err != nil {
+			t.Fatalf("failed to observe deletion of ingresscontroller %s: %v", name, err)
+		}
+	case removeFinalizerFailure:
+		if err := kclient.Delete(context.TODO(), ic); err != nil {
+			t.Fatalf("failed to delete ingresscontroller %s: %v", name, err)
+		}
+		if err := updateIngressControllerWithRetryOnConflict(t, name, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			finalizers := []string{}
+			for _, f := range ic.Finalizers {
+				if f != manifests.IngressControllerFinalizer {
+					finalizers = append(finalizers, f)
+				}
+			}
+			ic.Finalizers = finalizers
+		}); err != nil && !errors.IsNotFound(err) {
+			t.Logf("failed to remove finalizer from ingresscontroller %s: %v", name, err)
+		}
+		if err := waitForIngressControllerDeleted(t, name, 5*time.Minute); err != nil {
+			t.Fatalf("failed to observe deletion of ingresscontroller %s: %v", name, err)
+		}
+	default:
+		assertIngressControllerDeleted(t, kclient, ic)
+	}
+}
+
+// waitForIngressControllerDeleted waits for the named ingresscontroller to be
+// deleted.
+func waitForIngressControllerDeleted(t *testing.T, name types.NamespacedName, timeout time.Duration) error {
+	t.Helper()
+	return wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		if err := kclient.Get(context.TODO(), name, &operatorv1.IngressController{}); err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			t.Logf("failed to get ingresscontroller %s: %v", name, err)
+		}
+		return false, nil
+	})
+}
+
+// assertNoOperandResourcesForIngressController waits for all deployments,
+// services, configmaps, and secrets in the operand namespace that are labeled
+// as owned by the named ingresscontroller, the ingresscontroller's default
+// certificate secret, and the ingresscontroller's dnsrecords to be deleted,
+// and fails the test if any remain after the given timeout.
+func assertNoOperandResourcesForIngressController(t *testing.T, icName string, timeout time.Duration) {
+	t.Helper()
+
+	var remaining []string
+	err := wait.PollImmediate(5*time.Second, timeout, func() (bool, error) {
+		var err error
+		remaining, err = operandResourcesForIngressController(icName)
+		if err != nil {
+			t.Logf("failed to list resources of ingresscontroller %s: %v", icName, err)
+			return false, nil
+		}
+		if len(remaining) != 0 {
+			t.Logf("waiting for resources of ingresscontroller %s to be deleted: %s", icName, strings.Join(remaining, ", "))
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("resources of ingresscontroller %s were not deleted: %s", icName, strings.Join(remaining, ", "))
+	}
+}
+
+// operandResourcesForIngressController returns descriptions of the resources
+// that belong to the named ingresscontroller.
+func operandResourcesForIngressController(icName string) ([]string, error) {
+	var resources []string
+	ownedBy := client.MatchingLabels{manifests.OwningIngressControllerLabel: icName}
+	lists := []struct {
+		kind      string
+		namespace string
+		list      client.ObjectList
+	}{
+		{"Deployment", operandNamespace, &appsv1.DeploymentList{}},
+		{"Service", operandNamespace, &corev1.ServiceList{}},
+		{"ConfigMap", operandNamespace, &corev1.ConfigMapList{}},
+		{"Secret", operandNamespace, &corev1.SecretList{}},
+		{"DNSRecord", operatorNamespace, &iov1.DNSRecordList{}},
+	}
+	for _, l := range lists {
+		if err := kclient.List(context.TODO(), l.list, client.InNamespace(l.namespace), ownedBy); err != nil {
+			return nil, fmt.Errorf("failed to list %s resources: %w", l.kind, err)
+		}
+		items, err := metaItems(l.list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			resources = append(resources, fmt.Sprintf("%s %s/%s", l.kind, item.GetNamespace(), item.GetName()))
+		}
+	}
+
+	// The operator-generated default certificate secret is owned by the
+	// router deployment rather than labeled.
+	ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: icName}}
+	secretName := controller.RouterOperatorGeneratedDefaultCertificateSecretName(ic, operandNamespace)
+	if err := kclient.Get(context.TODO(), secretName, &corev1.Secret{}); err == nil {
+		resources = append(resources, fmt.Sprintf("Secret %s", secretName))
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+	return resources, nil
+}
+
+// metaItems returns the items of the given list.
+func metaItems(list client.ObjectList) ([]metav1.Object, error) {
+	var items []metav1.Object
+	switch l := list.(type) {
+	case *appsv1.DeploymentList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.ServiceList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.ConfigMapList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *corev1.SecretList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	case *iov1.DNSRecordList:
+		for i := range l.Items {
+			items = append(items, &l.Items[i])
+		}
+	default:
+		return nil, fmt.Errorf("unexpected list type %T", list)
+	}
+	return items, nil
+}
+
+// restartIngressOperator deletes the operator's pods and waits for the
+// operator's deployment to have ready replacement pods.
+func restartIngressOperator(t *testing.T) {
+	t.Helper()
+
+	pods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), pods, client.InNamespace(operatorNamespace), client.MatchingLabels{"name": "ingress-operator"}); err != nil {
+		t.Fatalf("failed to list operator pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		t.Fatal("found no operator pods")
+	}
+	oldPods := sets.New[types.UID]()
+	for i := range pods.Items {
+		oldPods.Insert(pods.Items[i].UID)
+		if err := kclient.Delete(context.TODO(), &pods.Items[i]); err != nil && !errors.IsNotFound(err) {
+			t.Fatalf("failed to delete operator pod %s: %v", pods.Items[i].Name, err)
+		}
+		t.Logf("deleted operator pod %s", pods.Items[i].Name)
+	}
+
+	if err := wait.PollImmediate(2*time.Second, 5*time.Minute, func() (bool, error) {
+		if err := kclient.List(context.TODO(), pods, client.InNamespace(operatorNamespace), client.MatchingLabels{"name": "ingress-operator"}); err != nil {
+			t.Logf("failed to list operator pods: %v", err)
+			return false, nil
+		}
+		ready := 0
+		for _, pod := range pods.Items {
+			if oldPods.Has(pod.UID) {
+				return false, nil
+			}
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+					ready++
+				}
+			}
+		}
+		return ready != 0, nil
+	}); err != nil {
+		t.Fatalf("failed to observe replacement operator pods: %v", err)
+	}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: "ingress-operator"}}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 5*time.Minute); err != nil {
+		t.Fatalf("failed to observe operator deployment rollout: %v", err)
+	}
+	t.Log("restarted the operator")
+}
+
+// orphanCleanupGracePeriod returns the value of the operator's
+// --orphan-cleanup-grace-period flag.
+func orphanCleanupGracePeriod(t *testing.T) (time.Duration, error) {
+	t.Helper()
+	value, ok, err := operatorFlagValue(t, "--orphan-cleanup-grace-period")
+	if err != nil || !ok {
+		return defaultOrphanCleanupGracePeriod, err
+	}
+	return time.ParseDuration(value)
+}
+
+// orphanCleanupDryRun returns the value of the operator's
+// --orphan-cleanup-dry-run flag, which is true by default.
+func orphanCleanupDryRun(t *testing.T) (bool, error) {
+	t.Helper()
+	value, ok, err := operatorFlagValue(t, "--orphan-cleanup-dry-run")
+	if err != nil || !ok {
+		return true, err
+	}
+	if len(value) == 0 {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// operatorFlagValue returns the value of the given flag of the operator
+// container and a Boolean value indicating whether the flag is specified.  A
+// Boolean flag that is specified without a value has an empty value.
+func operatorFlagValue(t *testing.T, flag string) (string, bool, error) {
+	t.Helper()
+	deployment, err := getDeployment(t, kclient, types.NamespacedName{Namespace: operatorNamespace, Name: "ingress-operator"}, 1*time.Minute)
+	if err != nil {
+		return "", false, err
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "ingress-operator" {
+			continue
+		}
+		args := append(append([]string{}, container.Command...), container.Args...)
+		for i, arg := range args {
+			switch {
+			case strings.HasPrefix(arg, flag+"="):
+				return strings.TrimPrefix(arg, flag+"="), true, nil
+			case arg == flag && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-"):
+				return args[i+1], true, nil
+			case arg == flag:
+				return "", true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
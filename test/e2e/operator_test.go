@@ -973,6 +973,52 @@ func TestHostNetworkEndpointPublishingStrategy(t *testing.T) {
 	}
 }
 
+// TestHostNetworkSingleReplicaRollout creates an ingresscontroller with the
+// "HostNetwork" endpoint publishing strategy type and a single replica,
+// changes an environment variable of its router, and verifies that the router
+// deployment uses the Recreate strategy and completes the rollout.
+func TestHostNetworkSingleReplicaRollout(t *testing.T) {
+	t.Parallel()
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "hostnetwork-single-replica"}
+	ing := newHostNetworkController(name, name.Name+"."+dnsConfig.Spec.BaseDomain)
+	ing.Spec.Replicas = pointer.Int32(1)
+	if err := kclient.Create(context.TODO(), ing); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ing)
+
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, name, availableConditionsForIngressControllerWithHostNetwork...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ing), deployment); err != nil {
+		t.Fatalf("failed to get router deployment: %v", err)
+	}
+	if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		t.Fatalf("expected router deployment to use the %s strategy, got %+v", appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy)
+	}
+
+	t.Log("updating the ingresscontroller's thread count to change the router's environment")
+	if err := updateIngressControllerWithRetryOnConflict(t, name, 1*time.Minute, func(ic *operatorv1.IngressController) {
+		ic.Spec.TuningOptions.ThreadCount = 8
+	}); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterHAProxyThreadsEnvName, "8"); err != nil {
+		t.Fatalf("expected router deployment to have %s=8: %v", ingresscontroller.RouterHAProxyThreadsEnvName, err)
+	}
+	if err := waitForDeploymentCompleteWithOldPodTermination(t, kclient, controller.RouterDeploymentName(ing), 5*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete its rollout: %v", err)
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, name, availableConditionsForIngressControllerWithHostNetwork...); err != nil {
+		t.Errorf("failed to observe expected conditions after the rollout: %v", err)
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 1*time.Minute, name, operatorv1.OperatorCondition{Type: operatorv1.OperatorStatusTypeDegraded, Status: operatorv1.ConditionFalse}); err != nil {
+		t.Errorf("expected the ingresscontroller not to be degraded after the rollout: %v", err)
+	}
+}
+
 // TestHostNetworkPortBinding creates two ingresscontrollers on the same node
 // with different port bindings and verifies that both routers are available.
 func TestHostNetworkPortBinding(t *testing.T) {
@@ -1153,6 +1199,12 @@ func TestInternalLoadBalancer(t *testing.T) {
 		t.Fatalf("failed to get LoadBalancer service: %v", err)
 	}
 
+	// The ingresscontroller's status should report the load balancer's
+	// addresses.
+	if err := waitForLoadBalancerAddressStatus(t, kclient, 1*time.Minute, name, lbService.Status.LoadBalancer.Ingress); err != nil {
+		t.Fatalf("failed to observe the load balancer's addresses in the ingresscontroller's status: %v", err)
+	}
+
 	for name, expected := range annotation {
 		if actual, ok := lbService.Annotations[name]; !ok {
 			t.Fatalf("load balancer has no %q annotation: %v", name, lbService.Annotations)
@@ -1666,6 +1718,17 @@ func TestNodePortServiceEndpointPublishingStrategy(t *testing.T) {
 		t.Errorf("failed to observe expected conditions: %v", err)
 	}
 
+	// The ingresscontroller has no load balancer, so its status should not
+	// report load balancer addresses.
+	if err := kclient.Get(context.TODO(), name, ing); err != nil {
+		t.Fatalf("failed to get ingresscontroller %s: %v", name, err)
+	}
+	for _, cond := range ing.Status.Conditions {
+		if cond.Type == ingresscontroller.IngressControllerLoadBalancerAddressConditionType {
+			t.Errorf("expected no %s condition, got %+v", cond.Type, cond)
+		}
+	}
+
 	// Make sure the ingresscontroller has a nodeport service
 	// with the expected ports.
 	svcName := controller.NodePortServiceName(ing)
@@ -1781,6 +1844,68 @@ func TestTLSSecurityProfile(t *testing.T) {
 	}
 }
 
+// TestMetricsTLSSecurityProfile creates an ingresscontroller with the "Modern"
+// TLS profile and verifies that the router's metrics/stats listener honors the
+// profile by rejecting a TLS 1.2 handshake and accepting a TLS 1.3 handshake.
+func TestMetricsTLSSecurityProfile(t *testing.T) {
+	t.Parallel()
+	name := types.NamespacedName{Namespace: operatorNamespace, Name: "metrics-tls-profile"}
+	domain := name.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(name, domain)
+	ic.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{
+		Type:   configv1.TLSProfileModernType,
+		Modern: &configv1.ModernTLSProfile{},
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", name, err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, name, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get router deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_METRICS_TLS_MIN_VERSION", string(configv1.VersionTLS13)); err != nil {
+		t.Fatalf("expected router deployment to restrict the metrics listener to TLS 1.3: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe router deployment completion: %v", err)
+	}
+	podList, err := getPods(t, kclient, deployment)
+	if err != nil {
+		t.Fatalf("failed to list router pods: %v", err)
+	}
+	if len(podList.Items) == 0 {
+		t.Fatalf("no router pods found for ingresscontroller %s", name)
+	}
+	routerPod := podList.Items[0]
+
+	// The metrics listener serves both plain HTTP and TLS on the stats
+	// port, so use TLS explicitly with each protocol version.
+	curl := func(tlsArgs ...string) (string, error) {
+		cmd := []string{"/bin/curl", "--silent", "--show-error", "-k", "--max-time", "10", "-o", "/dev/null", "-w", "%{http_code}"}
+		cmd = append(cmd, tlsArgs...)
+		cmd = append(cmd, "https://localhost:1936/healthz")
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		err := podExec(t, routerPod, &stdout, &stderr, cmd)
+		return stdout.String() + stderr.String(), err
+	}
+	if output, err := curl("--tlsv1.2", "--tls-max", "1.2"); err == nil {
+		t.Errorf("expected TLS 1.2 handshake with the metrics listener to fail, got output %q", output)
+	} else {
+		t.Logf("TLS 1.2 handshake with the metrics listener failed as expected: %v: %s", err, output)
+	}
+	if output, err := curl("--tlsv1.3"); err != nil {
+		t.Errorf("expected TLS 1.3 handshake with the metrics listener to succeed: %v: %s", err, output)
+	} else if output != "200" {
+		t.Errorf("expected status 200 from the metrics listener, got %q", output)
+	}
+}
+
 func TestRouteAdmissionPolicy(t *testing.T) {
 	t.Parallel()
 	// Set up an ingresscontroller which only selects routes created by this test
@@ -2168,6 +2293,185 @@ $ModLoad omstdout.so
 	}
 }
 
+// TestSyslogLoggingFacilityAndMaxLength verifies that an ingresscontroller that
+// logs to a syslog endpoint uses the specified syslog facility and does not
+// truncate access log messages that are longer than the default maximum length
+// of 1024 bytes when a larger maximum length is specified.
+func TestSyslogLoggingFacilityAndMaxLength(t *testing.T) {
+	t.Parallel()
+	ic := &operatorv1.IngressController{}
+	if err := kclient.Get(context.TODO(), defaultName, ic); err != nil {
+		t.Fatalf("failed to get default ingresscontroller: %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get default ingresscontroller's deployment: %v", err)
+	}
+	var (
+		image      string
+		foundImage bool
+	)
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == "router" {
+			image = container.Image
+			foundImage = true
+		}
+	}
+	if !foundImage {
+		t.Fatal("failed to determine default ingresscontroller deployment's image")
+	}
+
+	// Set up rsyslog to accept long messages and to log only messages
+	// with the "local3" facility.
+	syslogConfigmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rsyslog-maxlength-conf",
+			Namespace: "openshift-ingress",
+		},
+		Data: map[string]string{
+			"rsyslog.conf": `$MaxMessageSize 8k
+$ModLoad imudp
+$UDPServerRun 10514
+$ModLoad omstdout.so
+local3.* :omstdout:
+`,
+		},
+	}
+	if err := kclient.Create(context.TODO(), syslogConfigmap); err != nil {
+		t.Fatalf("failed to create configmap for rsyslog: %v", err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), syslogConfigmap); err != nil {
+			t.Fatalf("failed to delete configmap %s: %v", syslogConfigmap.Name, err)
+		}
+	}()
+	syslogPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-ingress",
+			Name:      "syslog-maxlength",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "syslog",
+					Image: image,
+					Command: []string{
+						"/sbin/rsyslogd", "-n",
+						"-i", "/tmp/rsyslog.pid",
+						"-f", "/etc/rsyslog/rsyslog.conf",
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "rsyslog-config",
+							MountPath: "/etc/rsyslog",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "rsyslog-config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: syslogConfigmap.Name,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := kclient.Create(context.TODO(), syslogPod); err != nil {
+		t.Fatalf("failed to create pod for rsyslog: %v", err)
+	}
+	defer func() {
+		if err := kclient.Delete(context.TODO(), syslogPod); err != nil {
+			t.Fatalf("failed to delete pod %s: %v", syslogPod.Name, err)
+		}
+	}()
+	if err := waitForPodReady(t, kclient, syslogPod, 3*time.Minute); err != nil {
+		t.Fatalf("failed to wait for syslog pod to become ready: %v", err)
+	}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Namespace: syslogPod.Namespace, Name: syslogPod.Name}, syslogPod); err != nil {
+		t.Fatalf("failed to get syslog pod: %v", err)
+	}
+
+	// Create an ingresscontroller that logs to the endpoint with a log
+	// format that is longer than 4096 bytes.
+	const marker = "4096abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@@@@@@@="
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "syslog-maxlength"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic = newPrivateController(icName, domain)
+	ic.Spec.Logging = &operatorv1.IngressControllerLogging{
+		Access: &operatorv1.AccessLogging{
+			Destination: operatorv1.LoggingDestination{
+				Type: operatorv1.SyslogLoggingDestinationType,
+				Syslog: &operatorv1.SyslogLoggingDestinationParameters{
+					Address:   syslogPod.Status.PodIP,
+					Port:      uint32(10514),
+					Facility:  "local3",
+					MaxLength: uint32(4096),
+				},
+			},
+			HttpLogFormat: "4096" + strings.Repeat(marker[4:], 60),
+		},
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	// Scan the syslog logs for a message that was logged with the local3
+	// facility and that is longer than the default maximum length but no
+	// longer than the specified one, allowing for rsyslog's overhead.  The
+	// kubelet's health probes should get logged.
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to create kube client: %v", err)
+	}
+	err = wait.PollImmediate(1*time.Second, 3*time.Minute, func() (bool, error) {
+		readCloser, err := client.CoreV1().Pods(syslogPod.Namespace).GetLogs(syslogPod.Name, &corev1.PodLogOptions{
+			Container: "syslog",
+			Follow:    false,
+		}).Stream(context.TODO())
+		if err != nil {
+			t.Logf("failed to read logs from syslog: %v", err)
+			return false, nil
+		}
+		defer func() {
+			if err := readCloser.Close(); err != nil {
+				t.Errorf("failed to close logs reader: %v", err)
+			}
+		}()
+		scanner := bufio.NewScanner(readCloser)
+		scanner.Buffer(make([]byte, 0, 16*1024), 16*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.Contains(line, marker) {
+				continue
+			}
+			length := len(line)
+			if length > 3900 && length <= 4400 {
+				t.Logf("found log message with %d characters in syslog", length)
+				return true, nil
+			}
+			t.Logf("found log message with %d characters in syslog, expected about 4096", length)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to observe the expected log message in syslog: %v", err)
+	}
+}
+
 func TestContainerLogging(t *testing.T) {
 	t.Parallel()
 	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "containerlogging"}
@@ -3886,23 +4190,6 @@ func TestIngressControllerServiceNameCollision(t *testing.T) {
 	})
 }
 
-func newLoadBalancerController(name types.NamespacedName, domain string) *operatorv1.IngressController {
-	repl := int32(1)
-	return &operatorv1.IngressController{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: name.Namespace,
-			Name:      name.Name,
-		},
-		Spec: operatorv1.IngressControllerSpec{
-			Domain:   domain,
-			Replicas: &repl,
-			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
-				Type: operatorv1.LoadBalancerServiceStrategyType,
-			},
-		},
-	}
-}
-
 // TestIngressOperatorCacheIsNotGlobal validates BZ2075671: Don't include all objects in all namespaces in the
 // Ingress Operator's cache. This tests adds an Ingress Controller in another namespace that isn't in the
 // Ingress Operator's cache and ensures the Ingress Operator ignores it.
@@ -3949,57 +4236,6 @@ func TestIngressOperatorCacheIsNotGlobal(t *testing.T) {
 	})
 }
 
-func newNodePortController(name types.NamespacedName, domain string) *operatorv1.IngressController {
-	repl := int32(1)
-	return &operatorv1.IngressController{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: name.Namespace,
-			Name:      name.Name,
-		},
-		Spec: operatorv1.IngressControllerSpec{
-			Domain:   domain,
-			Replicas: &repl,
-			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
-				Type: operatorv1.NodePortServiceStrategyType,
-			},
-		},
-	}
-}
-
-func newHostNetworkController(name types.NamespacedName, domain string) *operatorv1.IngressController {
-	repl := int32(1)
-	return &operatorv1.IngressController{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: name.Namespace,
-			Name:      name.Name,
-		},
-		Spec: operatorv1.IngressControllerSpec{
-			Domain:   domain,
-			Replicas: &repl,
-			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
-				Type: operatorv1.HostNetworkStrategyType,
-			},
-		},
-	}
-}
-
-func newPrivateController(name types.NamespacedName, domain string) *operatorv1.IngressController {
-	repl := int32(1)
-	return &operatorv1.IngressController{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: name.Namespace,
-			Name:      name.Name,
-		},
-		Spec: operatorv1.IngressControllerSpec{
-			Domain:   domain,
-			Replicas: &repl,
-			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
-				Type: operatorv1.PrivateStrategyType,
-			},
-		},
-	}
-}
-
 func waitForAvailableReplicas(t *testing.T, cl client.Client, ic *operatorv1.IngressController, timeout time.Duration, expectedReplicas int32) error {
 	ic = ic.DeepCopy()
 	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
@@ -4221,6 +4457,37 @@ func waitForRouteIngressConditions(t *testing.T, cl client.Client, routeName typ
 // waitForIngressControllerCondition marks the test as failed and returns an
 // error.  The caller can check the error value to stop execution of the test
 // using Fatal or FailNow if appropriate.
+// waitForLoadBalancerAddressStatus waits for the status of the
+// ingresscontroller with the given name to report the addresses of the given
+// load balancer ingress points.
+func waitForLoadBalancerAddressStatus(t *testing.T, cl client.Client, timeout time.Duration, name types.NamespacedName, ingresses []corev1.LoadBalancerIngress) error {
+	t.Helper()
+
+	var expected []corev1.LoadBalancerIngress
+	for _, ingress := range ingresses {
+		if len(ingress.IP) != 0 {
+			expected = append(expected, corev1.LoadBalancerIngress{IP: ingress.IP})
+		} else if len(ingress.Hostname) != 0 {
+			expected = append(expected, corev1.LoadBalancerIngress{Hostname: ingress.Hostname})
+		}
+	}
+	if len(expected) == 0 {
+		return fmt.Errorf("load balancer has no addresses")
+	}
+	ic := &operatorv1.IngressController{}
+	return wait.PollImmediate(1*time.Second, timeout, func() (bool, error) {
+		if err := cl.Get(context.TODO(), name, ic); err != nil {
+			t.Logf("failed to get ingresscontroller %s: %v", name, err)
+			return false, nil
+		}
+		if actual := ingresscontroller.LoadBalancerIngressForIngressController(ic); !reflect.DeepEqual(actual, expected) {
+			t.Logf("expected ingresscontroller %s to report load balancer addresses %v, got %v", name, expected, actual)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
 func waitForIngressControllerCondition(t *testing.T, cl client.Client, timeout time.Duration, name types.NamespacedName, conditions ...operatorv1.OperatorCondition) error {
 	t.Helper()
 
@@ -0,0 +1,204 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/echo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// proxyProtocolV2Header returns a PROXY protocol version 2 header for a TCP
+// over IPv4 connection from the given source address to the given destination
+// address with the given TLVs.
+func proxyProtocolV2Header(src, dst *net.TCPAddr, tlvs map[uint8]string) []byte {
+	var body bytes.Buffer
+	body.Write(src.IP.To4())
+	body.Write(dst.IP.To4())
+	binary.Write(&body, binary.BigEndian, uint16(src.Port))
+	binary.Write(&body, binary.BigEndian, uint16(dst.Port))
+	for t, v := range tlvs {
+		body.WriteByte(t)
+		binary.Write(&body, binary.BigEndian, uint16(len(v)))
+		body.WriteString(v)
+	}
+	var header bytes.Buffer
+	header.WriteString("\r\n\r\n\x00\r\nQUIT\n")
+	// Version 2, PROXY command; TCP over IPv4.
+	header.Write([]byte{0x21, 0x11})
+	binary.Write(&header, binary.BigEndian, uint16(body.Len()))
+	header.Write(body.Bytes())
+	return header.Bytes()
+}
+
+// printfEscape returns the given bytes as an argument for the shell's printf
+// builtin that prints them, with every byte escaped so that the argument needs
+// no further quoting.
+func printfEscape(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		fmt.Fprintf(&sb, `\x%02x`, b)
+	}
+	return sb.String()
+}
+
+// TestProxyProtocolV2 verifies that an ingresscontroller that uses the
+// NodePortService endpoint publishing strategy with the PROXY protocol and the
+// "proxyProtocol" unsupported config override for version 2 accepts
+// connections with a PROXY protocol version 2 header, uses the source address
+// from the header, and forwards the configured TLV to the backend in a request
+// header.  It also verifies that the router still accepts version 1 headers,
+// which is what allows router pods with either configuration to serve side by
+// side during a rollout.
+func TestProxyProtocolV2(t *testing.T) {
+	t.Parallel()
+
+	operatorImage, err := getIngressOperatorDeploymentImage(t, kclient, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingress operator image: %v", err)
+	}
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "proxy-protocol-v2"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newNodePortController(icName, domain)
+	ic.Spec.EndpointPublishingStrategy.NodePort = &operatorv1.NodePortStrategy{
+		Protocol: operatorv1.ProxyProtocol,
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"proxyProtocol":{"version":"v2","tlvHeaders":[{"type":"0xE0","header":"X-LB-Id"}]}}`),
+	}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForIngressControllerWithNodePort...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	if err := kclient.Get(context.TODO(), icName, ic); err != nil {
+		t.Fatalf("failed to get ingresscontroller: %v", err)
+	}
+
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	for name, value := range map[string]string{
+		"ROUTER_USE_PROXY_PROTOCOL":         "true",
+		"ROUTER_PROXY_PROTOCOL_VERSION":     "v2",
+		"ROUTER_PROXY_PROTOCOL_TLV_HEADERS": "0xe0:X-LB-Id",
+	} {
+		if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, name, value); err != nil {
+			t.Fatalf("expected deployment to have %s=%s: %v", name, value, err)
+		}
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+
+	ns := createNamespace(t, "proxy-protocol-v2-e2e")
+	echoOpts := []echoOption{withEchoServerImage(operatorImage)}
+	echoPod := buildEchoPod("echo", ns.Name, echoOpts...)
+	clientPod := buildExecPod("proxy-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels, echoOpts...)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	routeHost := "echo." + domain
+	route := buildRoute("echo", ns.Name, echoService.Name)
+	route.Spec.Host = routeHost
+	if err := kclient.Create(context.TODO(), route); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+	}
+
+	// Connect to the router's internal service, whose HTTP port is the
+	// router's HTTP port, which requires the PROXY protocol.
+	internalService := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), internalService); err != nil {
+		t.Fatalf("failed to get service %s: %v", controller.InternalIngressControllerServiceName(ic), err)
+	}
+	src := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 40000}
+	dst := &net.TCPAddr{IP: net.ParseIP("192.0.2.20"), Port: 80}
+	request := fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", routeHost)
+
+	testCases := []struct {
+		name          string
+		header        []byte
+		expectedLBId  string
+		expectedForIP string
+	}{{
+		name:          "version 2 with a TLV",
+		header:        proxyProtocolV2Header(src, dst, map[uint8]string{0xe0: "lb-e2e"}),
+		expectedLBId:  "lb-e2e",
+		expectedForIP: src.IP.String(),
+	}, {
+		name:          "version 1",
+		header:        []byte(fmt.Sprintf("PROXY TCP4 %s %s %d %d\r\n", src.IP, dst.IP, src.Port, dst.Port)),
+		expectedForIP: src.IP.String(),
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := printfEscape(append(tc.header, request...))
+			cmd := []string{"/bin/bash", "-c", fmt.Sprintf("printf '%s' | socat -t 10 - TCP:%s:80", payload, internalService.Spec.ClusterIP)}
+			var response *echo.Response
+			// Retry until the route is admitted and the router
+			// has loaded it.
+			if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+					t.Logf("failed to send request: %v: %s, retrying...", err, stderr.String())
+					return false, nil
+				}
+				resp, err := readCurlResponse(stdout.String())
+				if err != nil {
+					t.Logf("failed to read response: %v, retrying...", err)
+					return false, nil
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Logf("got status %s, retrying...", resp.Status)
+					return false, nil
+				}
+				if response, err = parseEchoResponse(resp); err != nil {
+					t.Logf("failed to parse response: %v, retrying...", err)
+					return false, nil
+				}
+				return true, nil
+			}); err != nil {
+				t.Fatalf("failed to get a response from the echo server through ingresscontroller %s: %v", icName.Name, err)
+			}
+			if actual := response.Headers.Get("X-Forwarded-For"); !strings.Contains(actual, tc.expectedForIP) {
+				t.Errorf("expected X-Forwarded-For to contain the PROXY protocol source address %s, got %q", tc.expectedForIP, actual)
+			}
+			if actual := response.Headers.Get("X-LB-Id"); actual != tc.expectedLBId {
+				t.Errorf("expected X-LB-Id %q, got %q", tc.expectedLBId, actual)
+			}
+		})
+	}
+}
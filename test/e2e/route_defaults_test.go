@@ -0,0 +1,358 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/echo"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestRouteDefaults verifies that route annotation defaults that are specified
+// using spec.unsupportedConfigOverrides.routeDefaults apply to routes that do
+// not specify the annotation, and that an annotation on a route overrides the
+// default.
+//
+// The test configures a default server timeout of 5 seconds and creates two
+// routes for a backend that takes 40 seconds to respond: the route without the
+// timeout annotation should time out, and the route with a timeout annotation
+// of 60 seconds should get a response.
+func TestRouteDefaults(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "route-defaults"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"routeDefaults":{"haproxy.router.openshift.io/timeout":"5s"}}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_DEFAULT_SERVER_TIMEOUT", "5s"); err != nil {
+		t.Fatalf("failed to observe ROUTER_DEFAULT_SERVER_TIMEOUT=5s: %v", err)
+	}
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+
+	ns := createNamespace(t, "route-defaults")
+
+	httpdPod := buildSlowHTTPDPod("route-defaults-httpd", ns.Name)
+	if err := kclient.Create(context.TODO(), httpdPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", httpdPod.Namespace, httpdPod.Name, err)
+	}
+	httpdService := buildEchoService(httpdPod.Name, httpdPod.Namespace, httpdPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), httpdService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", httpdService.Namespace, httpdService.Name, err)
+	}
+
+	defaultRoute := buildRoute("default-timeout", ns.Name, httpdService.Name)
+	defaultRoute.Spec.Host = fmt.Sprintf("%s-%s.%s", defaultRoute.Name, defaultRoute.Namespace, domain)
+	overrideRoute := buildRoute("override-timeout", ns.Name, httpdService.Name)
+	overrideRoute.Spec.Host = fmt.Sprintf("%s-%s.%s", overrideRoute.Name, overrideRoute.Namespace, domain)
+	overrideRoute.Annotations = map[string]string{
+		"haproxy.router.openshift.io/timeout": "60s",
+	}
+	for _, route := range []*routev1.Route{defaultRoute, overrideRoute} {
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+		}
+	}
+
+	// Use the router image, which includes curl, for the client pod.
+	clientPod := buildExecPod("route-defaults-client", ns.Name, deployment.Spec.Template.Spec.Containers[0].Image)
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{httpdPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 3*time.Minute); err != nil {
+			t.Fatalf("pod %s/%s is not ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	testCases := []struct {
+		route        *routev1.Route
+		expectedCode string
+	}{
+		{defaultRoute, "504"},
+		{overrideRoute, "200"},
+	}
+	for _, tc := range testCases {
+		cmd := []string{
+			"curl", "-s", "-o", "/dev/null", "-w", "%{http_code}",
+			"--max-time", "90",
+			"--resolve", tc.route.Spec.Host + ":80:" + service.Spec.ClusterIP,
+			"http://" + tc.route.Spec.Host,
+		}
+		// Poll in case the router has not yet loaded the route.
+		err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+				t.Logf("failed to execute %q: %v; stderr: %s", strings.Join(cmd, " "), err, stderr.String())
+				return false, nil
+			}
+			code := strings.TrimSpace(stdout.String())
+			if code != tc.expectedCode {
+				t.Logf("route %s/%s: expected HTTP status %s, got %s", tc.route.Namespace, tc.route.Name, tc.expectedCode, code)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Errorf("failed to observe HTTP status %s for route %s/%s: %v", tc.expectedCode, tc.route.Namespace, tc.route.Name, err)
+		}
+	}
+}
+
+// TestRouteDefaultInsecurePolicy verifies that the default insecure policy that
+// is specified using spec.unsupportedConfigOverrides.routeDefaults.insecurePolicy
+// applies to edge-terminated routes that do not specify
+// spec.tls.insecureEdgeTerminationPolicy, and that a route's own policy
+// overrides the default.
+//
+// The test configures a default insecure policy of "Redirect" and creates two
+// edge-terminated routes: the route without a policy should redirect plaintext
+// requests to HTTPS, and the route with the "Allow" policy should serve
+// plaintext requests.
+func TestRouteDefaultInsecurePolicy(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "route-default-insecure-policy"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"routeDefaults":{"insecurePolicy":"Redirect"}}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_DEFAULT_INSECURE_EDGE_TERMINATION_POLICY", "Redirect"); err != nil {
+		t.Fatalf("failed to observe ROUTER_DEFAULT_INSECURE_EDGE_TERMINATION_POLICY=Redirect: %v", err)
+	}
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+
+	ns := createNamespace(t, "route-default-insecure-policy")
+
+	echoPod := buildEchoPod("insecure-policy-echo", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, echoPod.Namespace, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+
+	defaultRoute := buildRoute("default-policy", ns.Name, echoService.Name)
+	defaultRoute.Spec.Host = fmt.Sprintf("%s-%s.%s", defaultRoute.Name, defaultRoute.Namespace, domain)
+	defaultRoute.Spec.TLS = &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}
+	allowRoute := buildRoute("allow-policy", ns.Name, echoService.Name)
+	allowRoute.Spec.Host = fmt.Sprintf("%s-%s.%s", allowRoute.Name, allowRoute.Namespace, domain)
+	allowRoute.Spec.TLS = &routev1.TLSConfig{
+		Termination:                   routev1.TLSTerminationEdge,
+		InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+	}
+	for _, route := range []*routev1.Route{defaultRoute, allowRoute} {
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+		}
+	}
+
+	// Use the router image, which includes curl, for the client pod.
+	clientPod := buildExecPod("insecure-policy-client", ns.Name, deployment.Spec.Template.Spec.Containers[0].Image)
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 3*time.Minute); err != nil {
+			t.Fatalf("pod %s/%s is not ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	testCases := []struct {
+		route            *routev1.Route
+		expectedCode     string
+		expectedLocation string
+	}{
+		{defaultRoute, "302", "https://" + defaultRoute.Spec.Host + "/"},
+		{allowRoute, "200", ""},
+	}
+	for _, tc := range testCases {
+		cmd := []string{
+			"curl", "-s", "-o", "/dev/null", "-w", "%{http_code} %{redirect_url}",
+			"--max-time", "30",
+			"--resolve", tc.route.Spec.Host + ":80:" + service.Spec.ClusterIP,
+			"http://" + tc.route.Spec.Host + "/",
+		}
+		// Poll in case the router has not yet loaded the route.
+		err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+				t.Logf("failed to execute %q: %v; stderr: %s", strings.Join(cmd, " "), err, stderr.String())
+				return false, nil
+			}
+			code, location, _ := strings.Cut(strings.TrimSpace(stdout.String()), " ")
+			if code != tc.expectedCode || location != tc.expectedLocation {
+				t.Logf("route %s/%s: expected HTTP status %s with location %q, got %s with location %q", tc.route.Namespace, tc.route.Name, tc.expectedCode, tc.expectedLocation, code, location)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Errorf("failed to observe HTTP status %s for route %s/%s: %v", tc.expectedCode, tc.route.Namespace, tc.route.Name, err)
+		}
+	}
+}
+
+// TestRouteDefaultPassthroughBalance verifies that the default load-balancing
+// algorithm for passthrough routes that is specified using
+// spec.unsupportedConfigOverrides.routeDefaults.passthroughBalance applies to
+// passthrough routes that do not specify the balance annotation, and that the
+// annotation on a route overrides the default.
+//
+// The test configures the "source" algorithm and creates two passthrough
+// routes for a service with two echo server pods: repeated connections from
+// one client through the route without the annotation should all reach the
+// same pod, and connections through the route with the "roundrobin" annotation
+// should reach both pods.
+func TestRouteDefaultPassthroughBalance(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "route-default-passthrough-balance"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"routeDefaults":{"passthroughBalance":"source"}}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_TCP_BALANCE_SCHEME", "source"); err != nil {
+		t.Fatalf("failed to observe ROUTER_TCP_BALANCE_SCHEME=source: %v", err)
+	}
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+	operatorImage, err := getIngressOperatorDeploymentImage(t, kclient, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingress operator image: %v", err)
+	}
+
+	ns := createNamespace(t, "route-default-passthrough-balance")
+
+	defaultRoute := buildRouteWithTermination("default-balance", ns.Name, "passthrough-echo", fmt.Sprintf("default-balance-%s.%s", ns.Name, domain), "https", routev1.TLSTerminationPassthrough)
+	roundRobinRoute := buildRouteWithTermination("roundrobin-balance", ns.Name, "passthrough-echo", fmt.Sprintf("roundrobin-balance-%s.%s", ns.Name, domain), "https", routev1.TLSTerminationPassthrough)
+	roundRobinRoute.Annotations = map[string]string{
+		"haproxy.router.openshift.io/balance": "roundrobin",
+	}
+	if _, err := createEchoServingCertSecret(t, "passthrough-echo-cert", ns.Name, defaultRoute.Spec.Host, roundRobinRoute.Spec.Host); err != nil {
+		t.Fatalf("failed to create serving certificate secret: %v", err)
+	}
+
+	echoOpts := []echoOption{
+		withEchoServerImage(operatorImage),
+		withEchoTLS("passthrough-echo-cert"),
+	}
+	labels := map[string]string{"app": "passthrough-echo"}
+	var pods []*corev1.Pod
+	for _, name := range []string{"passthrough-echo-1", "passthrough-echo-2"} {
+		pod := buildEchoPod(name, ns.Name, echoOpts...)
+		pod.Labels = labels
+		pods = append(pods, pod)
+	}
+	// Use the router image, which includes curl, for the client pod.
+	clientPod := buildExecPod("passthrough-balance-client", ns.Name, deployment.Spec.Template.Spec.Containers[0].Image)
+	pods = append(pods, clientPod)
+	for _, pod := range pods {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService("passthrough-echo", ns.Name, labels, echoOpts...)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, route := range []*routev1.Route{defaultRoute, roundRobinRoute} {
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+		}
+	}
+	for _, pod := range pods {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("pod %s/%s is not ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	testCases := []struct {
+		route *routev1.Route
+		// expectedPods is how many distinct pods the connections are
+		// expected to reach.
+		expectedPods int
+	}{
+		{defaultRoute, 1},
+		{roundRobinRoute, 2},
+	}
+	for _, tc := range testCases {
+		// Each curl invocation opens a new connection from the same
+		// client address.
+		script := fmt.Sprintf("for i in $(seq 10); do curl -sk --max-time 10 -o /dev/null -D - --resolve %[1]s:443:%[2]s https://%[1]s/ | grep -i '^%[3]s:'; done", tc.route.Spec.Host, service.Spec.ClusterIP, echo.PodNameHeader)
+		cmd := []string{"/bin/sh", "-c", script}
+		// Poll in case the router has not yet loaded the route or the
+		// second endpoint.
+		err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+				t.Logf("failed to execute %q: %v; stderr: %s", script, err, stderr.String())
+				return false, nil
+			}
+			responses := 0
+			reached := map[string]struct{}{}
+			for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+				if _, podName, ok := strings.Cut(line, ":"); ok {
+					responses++
+					reached[strings.TrimSpace(podName)] = struct{}{}
+				}
+			}
+			if responses != 10 || len(reached) != tc.expectedPods {
+				t.Logf("route %s/%s: expected 10 responses from %d pods, got %d responses from pods %v", tc.route.Namespace, tc.route.Name, tc.expectedPods, responses, reached)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Errorf("failed to observe connections through route %s/%s reaching %d pods: %v", tc.route.Namespace, tc.route.Name, tc.expectedPods, err)
+		}
+	}
+}
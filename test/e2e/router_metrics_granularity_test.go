@@ -0,0 +1,172 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	routev1client "github.com/openshift/client-go/route/clientset/versioned"
+	"github.com/openshift/library-go/test/library/metrics"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// haproxyResponseCodeClasses is the number of values of the "code" label of
+// the haproxy_server_http_responses_total metric (1xx, 2xx, 3xx, 4xx, 5xx, and
+// other).
+const haproxyResponseCodeClasses = 6
+
+// TestRouterMetricsRouteAllowList creates an ingresscontroller with the
+// "PerRoute" metrics granularity and a route label allow-list, creates one route
+// with the allow-listed label and one route without it, and verifies that the
+// router exports per-route series only for the labeled route and that the
+// number of per-route series is bounded.
+func TestRouterMetricsRouteAllowList(t *testing.T) {
+	t.Parallel()
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routeClient, err := routev1client.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prometheusClient, err := metrics.NewPrometheusClient(context.TODO(), kubeClient, routeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("e2e-router-metrics-"))
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "router-metrics-allow-list"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"kubernetes.io/metadata.name": ns.Name},
+	}
+	overrides := map[string]interface{}{
+		"routerMetrics": map[string]interface{}{
+			"granularity": "PerRoute",
+			"routeSelector": metav1.LabelSelector{
+				MatchLabels: map[string]string{"metrics": "true"},
+			},
+		},
+	}
+	raw, err := json.Marshal(overrides)
+	if err != nil {
+		t.Fatalf("failed to marshal unsupported config overrides: %v", err)
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: raw}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterMetricsRouteLabelSelector, "metrics=true"); err != nil {
+		t.Fatalf("expected router deployment to specify the route label selector: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe expected conditions for deployment %s: %v", deployment.Name, err)
+	}
+
+	echoPod := buildEchoPod("router-metrics-echo", ns.Name)
+	if err := kclient.Create(context.TODO(), echoPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", echoPod.Namespace, echoPod.Name, err)
+	}
+	echoService := buildEchoService(echoPod.Name, echoPod.Namespace, echoPod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+
+	allowedRoute := buildRoute("metrics-allowed", ns.Name, echoService.Name)
+	allowedRoute.Labels = map[string]string{"metrics": "true"}
+	deniedRoute := buildRoute("metrics-denied", ns.Name, echoService.Name)
+	for _, route := range []client.Object{allowedRoute, deniedRoute} {
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.GetNamespace(), route.GetName(), err)
+		}
+	}
+
+	// The router's internal service is the scrape target, so the "service"
+	// label distinguishes this ingresscontroller's series from those of
+	// other ingresscontrollers that may admit the same routes.
+	service := controller.InternalIngressControllerServiceName(ic).Name
+	allowedQuery := fmt.Sprintf(`haproxy_server_http_responses_total{service=%q,route=%q}`, service, allowedRoute.Name)
+	deniedQuery := fmt.Sprintf(`haproxy_server_http_responses_total{service=%q,route=%q}`, service, deniedRoute.Name)
+	countQuery := fmt.Sprintf(`count(haproxy_server_http_responses_total{service=%q,exported_namespace=%q})`, service, ns.Name)
+
+	t.Logf("Waiting for per-route series for route %s/%s...", allowedRoute.Namespace, allowedRoute.Name)
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(ctx context.Context) (bool, error) {
+		vec, err := queryPrometheusVector(ctx, prometheusClient, allowedQuery)
+		if err != nil {
+			t.Logf("failed to query %s: %v", allowedQuery, err)
+			return false, nil
+		}
+		if len(vec) == 0 {
+			t.Logf("no series yet for %s", allowedQuery)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("expected per-route series for route %s/%s: %v", allowedRoute.Namespace, allowedRoute.Name, err)
+	}
+
+	vec, err := queryPrometheusVector(context.Background(), prometheusClient, deniedQuery)
+	if err != nil {
+		t.Fatalf("failed to query %s: %v", deniedQuery, err)
+	}
+	if len(vec) != 0 {
+		t.Errorf("expected no per-route series for route %s/%s, got %v", deniedRoute.Namespace, deniedRoute.Name, vec)
+	}
+
+	// Only the allow-listed route has a per-route series for each response
+	// code class, for each of the service's endpoints (of which there is
+	// one).
+	vec, err = queryPrometheusVector(context.Background(), prometheusClient, countQuery)
+	if err != nil {
+		t.Fatalf("failed to query %s: %v", countQuery, err)
+	}
+	const maxSeries = haproxyResponseCodeClasses
+	if len(vec) != 1 || vec[0].Value > model.SampleValue(maxSeries) {
+		t.Errorf("expected at most %d per-route series in namespace %s, got %v", maxSeries, ns.Name, vec)
+	}
+}
+
+// queryPrometheusVector runs the given instant query and returns the resulting
+// vector.
+func queryPrometheusVector(ctx context.Context, prometheusClient prometheusv1.API, query string) (model.Vector, error) {
+	result, _, err := prometheusClient.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	vec, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected a vector result, got %T", result)
+	}
+	return vec, nil
+}
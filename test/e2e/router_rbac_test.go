@@ -0,0 +1,135 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestRouterRBACSelfHealing verifies that the operator restores the router
+// service account, the router cluster role binding, and the canary service
+// account if they are deleted or modified, and that the default
+// ingresscontroller reports that its pods are authorized afterwards.
+func TestRouterRBACSelfHealing(t *testing.T) {
+	t.Log("Waiting for the default IngressController to be available...")
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, defaultAvailableConditions...); err != nil {
+		t.Fatal(err)
+	}
+
+	desiredBinding := manifests.RouterClusterRoleBinding()
+	bindingName := types.NamespacedName{Name: desiredBinding.Name}
+
+	t.Logf("Deleting cluster role binding %s...", desiredBinding.Name)
+	binding := &rbacv1.ClusterRoleBinding{}
+	if err := kclient.Get(context.TODO(), bindingName, binding); err != nil {
+		t.Fatalf("failed to get cluster role binding %s: %v", desiredBinding.Name, err)
+	}
+	if err := kclient.Delete(context.TODO(), binding); err != nil {
+		t.Fatalf("failed to delete cluster role binding %s: %v", desiredBinding.Name, err)
+	}
+	waitForRouterClusterRoleBindingRestored(t, desiredBinding, binding.UID)
+
+	t.Logf("Removing the subjects from cluster role binding %s...", desiredBinding.Name)
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, bindingName, binding); err != nil {
+			t.Logf("failed to get cluster role binding %s: %v", desiredBinding.Name, err)
+			return false, nil
+		}
+		binding.Subjects = nil
+		if err := kclient.Update(ctx, binding); err != nil {
+			t.Logf("failed to update cluster role binding %s: %v", desiredBinding.Name, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRouterClusterRoleBindingRestored(t, desiredBinding, "")
+
+	routerServiceAccount := manifests.RouterServiceAccount()
+	deleteServiceAccountAndAwaitRecreation(t, types.NamespacedName{Namespace: routerServiceAccount.Namespace, Name: routerServiceAccount.Name})
+	deleteServiceAccountAndAwaitRecreation(t, controller.CanaryServiceAccountName())
+
+	t.Log("Waiting for the default IngressController to report that its pods are authorized...")
+	conditions := append([]operatorv1.OperatorCondition{
+		{Type: ingresscontroller.IngressControllerPodsAuthorizedConditionType, Status: operatorv1.ConditionTrue},
+	}, defaultAvailableConditions...)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, defaultName, conditions...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForRouterClusterRoleBindingRestored waits for the router cluster role
+// binding to exist with the expected subjects and role reference.  If oldUID
+// is non-empty, the binding must also have been recreated.
+func waitForRouterClusterRoleBindingRestored(t *testing.T, desired *rbacv1.ClusterRoleBinding, oldUID types.UID) {
+	t.Helper()
+
+	t.Logf("Waiting for cluster role binding %s to be restored...", desired.Name)
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		current := &rbacv1.ClusterRoleBinding{}
+		if err := kclient.Get(ctx, types.NamespacedName{Name: desired.Name}, current); err != nil {
+			t.Logf("failed to get cluster role binding %s: %v", desired.Name, err)
+			return false, nil
+		}
+		if len(oldUID) != 0 && current.UID == oldUID {
+			t.Logf("cluster role binding %s has not been recreated yet", desired.Name)
+			return false, nil
+		}
+		if !cmp.Equal(current.Subjects, desired.Subjects) || !cmp.Equal(current.RoleRef, desired.RoleRef) {
+			t.Logf("cluster role binding %s has unexpected subjects %v or role reference %v", desired.Name, current.Subjects, current.RoleRef)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("cluster role binding %s was not restored: %v", desired.Name, err)
+	}
+}
+
+// deleteServiceAccountAndAwaitRecreation deletes the named service account and
+// waits for the operator to recreate it.
+func deleteServiceAccountAndAwaitRecreation(t *testing.T, name types.NamespacedName) {
+	t.Helper()
+
+	t.Logf("Deleting service account %s...", name)
+	sa := &corev1.ServiceAccount{}
+	if err := kclient.Get(context.TODO(), name, sa); err != nil {
+		t.Fatalf("failed to get service account %s: %v", name, err)
+	}
+	oldUID := sa.UID
+	if err := kclient.Delete(context.TODO(), sa); err != nil {
+		t.Fatalf("failed to delete service account %s: %v", name, err)
+	}
+
+	t.Logf("Waiting for service account %s to be recreated...", name)
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, false, func(ctx context.Context) (bool, error) {
+		current := &corev1.ServiceAccount{}
+		if err := kclient.Get(ctx, name, current); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				t.Logf("failed to get service account %s: %v", name, err)
+			}
+			return false, nil
+		}
+		return current.UID != oldUID, nil
+	}); err != nil {
+		t.Fatalf("service account %s was not recreated: %v", name, err)
+	}
+}
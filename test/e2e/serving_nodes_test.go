@@ -0,0 +1,104 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// TestServingNodeAddressesTrackNodeDeletion verifies that the
+// ServingNodesAvailable status condition of an ingresscontroller that uses the
+// "HostNetwork" endpoint publishing strategy lists the addresses of the nodes
+// that are serving the ingresscontroller, and that when the node of a router
+// pod is deleted, its address is removed from the condition within a bounded
+// time.
+//
+// This test deletes a node object, which disrupts other workloads on the node
+// until the kubelet re-registers it, so it must run serially.
+func TestServingNodeAddressesTrackNodeDeletion(t *testing.T) {
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "serving-nodes"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newHostNetworkController(icName, domain)
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	expected := operatorv1.OperatorCondition{
+		Type:   ingresscontroller.IngressControllerServingNodesAvailableConditionType,
+		Status: operatorv1.ConditionTrue,
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 2*time.Minute, icName, expected); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	pods, err := getPods(t, kclient, deployment)
+	if err != nil {
+		t.Fatalf("failed to get router pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		t.Fatalf("found no router pods for ingresscontroller %s", icName)
+	}
+	routerPod := pods.Items[0]
+	address := routerPod.Status.HostIP
+
+	servingNodesMessage := func() (string, error) {
+		if err := kclient.Get(context.TODO(), icName, ic); err != nil {
+			return "", err
+		}
+		for _, cond := range ic.Status.Conditions {
+			if cond.Type == ingresscontroller.IngressControllerServingNodesAvailableConditionType {
+				return cond.Message, nil
+			}
+		}
+		return "", nil
+	}
+	if message, err := servingNodesMessage(); err != nil {
+		t.Fatalf("failed to get ingresscontroller %s: %v", icName, err)
+	} else if !strings.Contains(message, address) {
+		t.Fatalf("expected %s condition to list address %s of node %s, got %q", ingresscontroller.IngressControllerServingNodesAvailableConditionType, address, routerPod.Spec.NodeName, message)
+	}
+
+	node := &corev1.Node{}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Name: routerPod.Spec.NodeName}, node); err != nil {
+		t.Fatalf("failed to get node %s: %v", routerPod.Spec.NodeName, err)
+	}
+	t.Logf("deleting node %s with address %s", node.Name, address)
+	if err := kclient.Delete(context.TODO(), node); err != nil {
+		t.Fatalf("failed to delete node %s: %v", node.Name, err)
+	}
+
+	// Deleting the node causes its pods to be deleted; the kubelet may
+	// re-register the node and the router pod may be rescheduled onto it
+	// afterwards, so poll frequently for the address to disappear.
+	if err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 3*time.Minute, false, func(ctx context.Context) (bool, error) {
+		message, err := servingNodesMessage()
+		if err != nil {
+			t.Logf("failed to get ingresscontroller %s: %v", icName, err)
+			return false, nil
+		}
+		if strings.Contains(message, address) {
+			return false, nil
+		}
+		t.Logf("observed %s condition without address %s: %q", ingresscontroller.IngressControllerServingNodesAvailableConditionType, address, message)
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe address %s removed from %s condition: %v", address, ingresscontroller.IngressControllerServingNodesAvailableConditionType, err)
+	}
+}
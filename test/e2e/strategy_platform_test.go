@@ -0,0 +1,70 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestLoadBalancerServiceStrategyUnsupportedOnPlatform verifies that on a
+// platform without a load balancer implementation, such as bare metal, an
+// ingresscontroller with the "LoadBalancerService" endpoint publishing strategy
+// reports EndpointPublishingStrategySupported=False and Degraded=True with the
+// "StrategyUnsupportedOnPlatform" reason rather than waiting indefinitely for
+// its load balancer.
+func TestLoadBalancerServiceStrategyUnsupportedOnPlatform(t *testing.T) {
+	t.Parallel()
+
+	switch infraConfig.Status.PlatformStatus.Type {
+	case configv1.BareMetalPlatformType, configv1.LibvirtPlatformType, configv1.NonePlatformType, configv1.NutanixPlatformType, configv1.OvirtPlatformType, configv1.VSpherePlatformType:
+	default:
+		t.Skipf("test skipped on platform %q, which provides a load balancer implementation", infraConfig.Status.PlatformStatus.Type)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := kclient.Get(context.TODO(), types.NamespacedName{Name: "metallbs.metallb.io"}, crd); err == nil {
+		t.Skip("test skipped because MetalLB is installed")
+	} else if !apierrors.IsNotFound(err) {
+		t.Fatalf("failed to check whether MetalLB is installed: %v", err)
+	}
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "lb-unsupported-on-platform"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(icName, domain)
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
+	}
+	t.Cleanup(func() { assertIngressControllerDeleted(t, kclient, ic) })
+
+	conditions := []operatorv1.OperatorCondition{
+		{Type: ingresscontroller.IngressControllerAdmittedConditionType, Status: operatorv1.ConditionTrue},
+		{Type: ingresscontroller.IngressControllerEndpointPublishingStrategySupportedConditionType, Status: operatorv1.ConditionFalse},
+		{Type: operatorv1.OperatorStatusTypeDegraded, Status: operatorv1.ConditionTrue},
+	}
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	if err := kclient.Get(context.TODO(), icName, ic); err != nil {
+		t.Fatalf("failed to get ingresscontroller %s: %v", icName, err)
+	}
+	for _, cond := range ic.Status.Conditions {
+		switch cond.Type {
+		case ingresscontroller.IngressControllerEndpointPublishingStrategySupportedConditionType, operatorv1.OperatorStatusTypeDegraded:
+			if cond.Reason != "StrategyUnsupportedOnPlatform" {
+				t.Errorf("expected %s condition to have reason StrategyUnsupportedOnPlatform, got %+v", cond.Type, cond)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// sseResultPrefix prefixes the output of the timed request in the logs of the
+// pod that buildSSEClientPod returns.
+const sseResultPrefix = "SSE-RESULT:"
+
+// TestStreamingResponses verifies that an ingresscontroller that specifies the
+// "Stream" policy using spec.unsupportedConfigOverrides.streamingResponses
+// delivers the first event of a server-sent events stream within a second,
+// using an ingresscontroller without the override as a control.
+func TestStreamingResponses(t *testing.T) {
+	t.Parallel()
+
+	streamingName := types.NamespacedName{Namespace: operatorNamespace, Name: "streaming-responses"}
+	streaming := newPrivateController(streamingName, streamingName.Name+"."+dnsConfig.Spec.BaseDomain)
+	streaming.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"streamingResponses":{"policy":"Stream"}}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, streaming)
+	createIngressControllerAndAwaitReady(t, streaming)
+
+	controlName := types.NamespacedName{Namespace: operatorNamespace, Name: "streaming-responses-control"}
+	control := newPrivateController(controlName, controlName.Name+"."+dnsConfig.Spec.BaseDomain)
+	defer assertIngressControllerDeleted(t, kclient, control)
+	createIngressControllerAndAwaitReady(t, control)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(streaming), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterStreamingResponses, "true"); err != nil {
+		t.Fatalf("failed to observe %s=true: %v", ingresscontroller.RouterStreamingResponses, err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe expected conditions for deployment %s: %v", deployment.Name, err)
+	}
+
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("e2e-streaming-responses-"))
+	ssePod := buildSSEPod("sse-server", ns.Name)
+	if err := kclient.Create(context.TODO(), ssePod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", ssePod.Namespace, ssePod.Name, err)
+	}
+	sseService := buildEchoService(ssePod.Name, ssePod.Namespace, ssePod.ObjectMeta.Labels)
+	if err := kclient.Create(context.TODO(), sseService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", sseService.Namespace, sseService.Name, err)
+	}
+	sseRoute := buildRoute(ssePod.Name, ssePod.Namespace, sseService.Name)
+	if err := kclient.Create(context.TODO(), sseRoute); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", sseRoute.Namespace, sseRoute.Name, err)
+	}
+
+	image := deployment.Spec.Template.Spec.Containers[0].Image
+
+	output := testFirstServerSentEvent(t, image, sseRoute, streaming)
+	if !strings.Contains(output, "data: first") {
+		t.Errorf("expected the first event within a second through ingresscontroller %s, got %q", streaming.Name, output)
+	}
+
+	// Response buffering is what the override disables, so the control
+	// is expected to withhold the first event, but the exact behavior
+	// depends on HAProxy's defaults; only log the result.
+	output = testFirstServerSentEvent(t, image, sseRoute, control)
+	t.Logf("Output within a second through control ingresscontroller %s: %q", control.Name, output)
+}
+
+// testFirstServerSentEvent requests the given route through the given
+// ingresscontroller's internal service and returns whatever part of the
+// response body arrives within a second.
+func testFirstServerSentEvent(t *testing.T, image string, route *routev1.Route, ic *operatorv1.IngressController) string {
+	t.Helper()
+
+	kubeConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get kube config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to create kube client: %v", err)
+	}
+
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+
+	clientPod := buildSSEClientPod("sse-client-"+ic.Name, route.Namespace, image, route.Spec.Host, service.Spec.ClusterIP)
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+
+	var output string
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 10*time.Minute, false, func(ctx context.Context) (bool, error) {
+		logs, err := client.CoreV1().Pods(clientPod.Namespace).GetLogs(clientPod.Name, &corev1.PodLogOptions{
+			Container: "curl",
+		}).DoRaw(ctx)
+		if err != nil {
+			t.Logf("failed to read output from pod %s: %v", clientPod.Name, err)
+			return false, nil
+		}
+		_, result, found := strings.Cut(string(logs), sseResultPrefix)
+		if !found {
+			return false, nil
+		}
+		output = result
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe the result of the timed request: %v", err)
+	}
+	return output
+}
+
+// buildSSEPod returns a pod definition for an HTTP server that responds with a
+// server-sent events stream, sending one event immediately and another after
+// 30 seconds.
+func buildSSEPod(name, namespace string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app": name,
+			},
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Args: []string{
+						"TCP4-LISTEN:8080,reuseaddr,fork",
+						`EXEC:'/bin/bash -c \"sed -e \\\"/^\r/q\\\" >/dev/null; printf \\\"HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nCache-Control: no-cache\r\n\r\ndata: first\n\n\\\"; sleep 30; printf \\\"data: second\n\n\\\"\"'`,
+					},
+					Command: []string{"/bin/socat"},
+					Image:   "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest",
+					Name:    "sse",
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: int32(8080),
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					SecurityContext: generateUnprivilegedSecurityContext(),
+				},
+			},
+		},
+	}
+}
+
+// buildSSEClientPod returns a pod definition for a client that waits for the
+// given host to respond through the given address and then prints, following
+// sseResultPrefix, whatever part of the response body arrives within a second.
+func buildSSEClientPod(name, namespace, image, host, address string) *corev1.Pod {
+	resolve := fmt.Sprintf("%s:80:%s", host, address)
+	script := fmt.Sprintf(`for i in $(seq 1 60); do
+  code=$(curl -s -o /dev/null -w '%%{http_code}' --max-time 40 --resolve %[1]s http://%[2]s/)
+  [ "$code" = 200 ] && break
+  sleep 1
+done
+out=$(curl -sN --max-time 1 --resolve %[1]s http://%[2]s/)
+echo "%[3]s$out"
+sleep infinity
+`, resolve, host, sseResultPrefix)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			TerminationGracePeriodSeconds: pointer.Int64(0),
+			Containers: []corev1.Container{
+				{
+					Name:            "curl",
+					Image:           image,
+					Command:         []string{"/bin/bash", "-c", script},
+					SecurityContext: generateUnprivilegedSecurityContext(),
+				},
+			},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+}
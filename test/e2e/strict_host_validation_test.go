@@ -0,0 +1,159 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestStrictHostValidation creates an ingresscontroller and sends requests
+// with duplicate Host headers and with absolute-form targets whose host does
+// not match the Host header to a route.  The test verifies that the router
+// forwards these requests to the backend by default and that it rejects them
+// with status 400 once the "tuningOptions.strictHostValidation" unsupported
+// config override is set to "Enabled".  Requests with a single Host header
+// must succeed either way.
+func TestStrictHostValidation(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "strict-host-validation"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+
+	ns := createNamespace(t, "strict-host-validation-e2e")
+	echoPod := buildEchoPod("echo", ns.Name)
+	clientPod := buildExecPod("strict-host-validation-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService(echoPod.Name, ns.Name, echoPod.Labels)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, pod := range []*corev1.Pod{echoPod, clientPod} {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	routeHost := "echo." + domain
+	route := buildRoute("echo", ns.Name, echoService.Name)
+	route.Spec.Host = routeHost
+	if err := kclient.Create(context.TODO(), route); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+	}
+
+	// requests maps a description of each request to its raw HTTP/1.1
+	// request line and headers.
+	requests := map[string]string{
+		"single Host header":            fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\n", routeHost),
+		"duplicate Host headers":        fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nHost: other.%s\r\n", routeHost, domain),
+		"mismatched absolute-form host": fmt.Sprintf("GET http://other.%s/ HTTP/1.1\r\nHost: %s\r\n", domain, routeHost),
+	}
+	// expectStatus sends each request directly to a router pod and
+	// waits for the router to respond with the expected status code.
+	expectStatus := func(t *testing.T, expected map[string]string) {
+		t.Helper()
+		routerPodIP := strictHostValidationRouterPodIP(t, ic)
+		for description, request := range requests {
+			script := fmt.Sprintf(`exec 3<>/dev/tcp/%s/80 && printf %q >&3 && head -n 1 <&3`, routerPodIP, request+"Connection: close\r\n\r\n")
+			cmd := []string{"/bin/bash", "-c", script}
+			if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+					t.Logf("failed to send request with %s: %v: %s, retrying...", description, err, stderr.String())
+					return false, nil
+				}
+				statusLine := strings.TrimSpace(stdout.String())
+				if fields := strings.Fields(statusLine); len(fields) < 2 || fields[1] != expected[description] {
+					t.Logf("request with %s got response %q, expected status %s, retrying...", description, statusLine, expected[description])
+					return false, nil
+				}
+				return true, nil
+			}); err != nil {
+				t.Fatalf("failed to observe status %s for request with %s: %v", expected[description], description, err)
+			}
+		}
+	}
+
+	// By default, the router forwards every request to the backend.
+	expectStatus(t, map[string]string{
+		"single Host header":            "200",
+		"duplicate Host headers":        "200",
+		"mismatched absolute-form host": "200",
+	})
+
+	// With strict host validation, the router rejects ambiguous requests.
+	if err := updateIngressControllerWithRetryOnConflict(t, icName, timeout, func(ic *operatorv1.IngressController) {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"tuningOptions":{"strictHostValidation":"Enabled"}}`)}
+	}); err != nil {
+		t.Fatalf("failed to update ingresscontroller: %v", err)
+	}
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterStrictHostValidation, "true"); err != nil {
+		t.Fatalf("expected deployment to enable strict host validation: %v", err)
+	}
+	if err := waitForDeploymentCompleteWithOldPodTermination(t, kclient, controller.RouterDeploymentName(ic), 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+	expectStatus(t, map[string]string{
+		"single Host header":            "200",
+		"duplicate Host headers":        "400",
+		"mismatched absolute-form host": "400",
+	})
+}
+
+// strictHostValidationRouterPodIP returns the IP address of a pod of the given
+// ingresscontroller's router deployment.
+func strictHostValidationRouterPodIP(t *testing.T, ic *operatorv1.IngressController) string {
+	t.Helper()
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("deployment has invalid selector: %v", err)
+	}
+	routerPods := &corev1.PodList{}
+	if err := kclient.List(context.TODO(), routerPods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		t.Fatalf("failed to list router pods: %v", err)
+	}
+	for _, pod := range routerPods.Items {
+		if pod.DeletionTimestamp == nil && len(pod.Status.PodIP) != 0 {
+			return pod.Status.PodIP
+		}
+	}
+	t.Fatal("expected at least one router pod")
+	return ""
+}
@@ -0,0 +1,115 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/storage/names"
+)
+
+// TestStrictSNI verifies that an ingresscontroller that enables strict SNI
+// using spec.unsupportedConfigOverrides.strictSNI refuses TLS connections that
+// do not send SNI and accepts TLS connections that send a server name that
+// matches the default certificate.
+func TestStrictSNI(t *testing.T) {
+	t.Parallel()
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "strict-sni"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{
+		Raw: []byte(`{"strictSNI":"Enabled"}`),
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+
+	deployment := &appsv1.Deployment{}
+	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
+		t.Fatalf("failed to get ingresscontroller deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, ingresscontroller.RouterStrictSNI, "true"); err != nil {
+		t.Fatalf("failed to observe %s=true: %v", ingresscontroller.RouterStrictSNI, err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe expected conditions for deployment %s: %v", deployment.Name, err)
+	}
+
+	service := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), service); err != nil {
+		t.Fatalf("failed to get ingresscontroller service: %v", err)
+	}
+	address := net.JoinHostPort(service.Spec.ClusterIP, "443")
+
+	// Use the router image, which includes curl, for the client pod.
+	ns := createNamespace(t, names.SimpleNameGenerator.GenerateName("e2e-strict-sni-"))
+	clientPod := buildExecPod("strict-sni-client", ns.Name, deployment.Spec.Template.Spec.Containers[0].Image)
+	if err := kclient.Create(context.TODO(), clientPod); err != nil {
+		t.Fatalf("failed to create pod %s/%s: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+	if err := waitForPodReady(t, kclient, clientPod, 3*time.Minute); err != nil {
+		t.Fatalf("pod %s/%s is not ready: %v", clientPod.Namespace, clientPod.Name, err)
+	}
+
+	// curl does not send SNI when the URL has an IP address, which makes
+	// the first command equivalent to `openssl s_client -noservername`.
+	host := "strict-sni-test." + domain
+	testCases := []struct {
+		description  string
+		cmd          []string
+		expectRefuse bool
+	}{
+		{
+			description: "without SNI",
+			cmd: []string{
+				"curl", "-k", "-s", "-o", "/dev/null", "-w", "%{http_code}",
+				"--max-time", "10",
+				"https://" + address,
+			},
+			expectRefuse: true,
+		},
+		{
+			description: "with SNI",
+			cmd: []string{
+				"curl", "-k", "-s", "-o", "/dev/null", "-w", "%{http_code}",
+				"--max-time", "10",
+				"--resolve", host + ":443:" + service.Spec.ClusterIP,
+				"https://" + host,
+			},
+			expectRefuse: false,
+		},
+	}
+	for _, tc := range testCases {
+		// Poll because the connection may fail while the router is
+		// still starting.
+		err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+			var stdout, stderr bytes.Buffer
+			err := podExec(t, *clientPod, &stdout, &stderr, tc.cmd)
+			code := strings.TrimSpace(stdout.String())
+			// curl reports status code 000 if the TLS handshake fails.
+			refused := err != nil && code == "000"
+			if refused != tc.expectRefuse {
+				t.Logf("%s: expected refused=%t, got status code %q, error %v, stderr: %s", tc.description, tc.expectRefuse, code, err, stderr.String())
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Errorf("%s: failed to observe expected result of %q: %v", tc.description, strings.Join(tc.cmd, " "), err)
+		}
+	}
+}
@@ -48,27 +48,16 @@ func TestHAProxyTimeouts(t *testing.T) {
 	)
 	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "haproxy-timeout"}
 	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
-	ic := newPrivateController(icName, domain)
-	ic.Spec.TuningOptions = operatorv1.IngressControllerTuningOptions{
+	ic := newIngressController(icName, domain, withTuningOptions(operatorv1.IngressControllerTuningOptions{
 		ClientTimeout:    &metav1.Duration{Duration: clientTimeoutInput},
 		ClientFinTimeout: &metav1.Duration{Duration: clientFinTimeoutInput},
 		ServerTimeout:    &metav1.Duration{Duration: serverTimeoutInput},
 		ServerFinTimeout: &metav1.Duration{Duration: serverFinTimeoutInput},
 		TunnelTimeout:    &metav1.Duration{Duration: tunnelTimeoutInput},
 		TLSInspectDelay:  &metav1.Duration{Duration: tlsInspectDelayInput},
-	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
+	}))
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -203,27 +192,16 @@ func TestHAProxyTimeoutsRejection(t *testing.T) {
 	)
 	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "haproxy-timeout-rejection"}
 	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
-	ic := newPrivateController(icName, domain)
-	ic.Spec.TuningOptions = operatorv1.IngressControllerTuningOptions{
+	ic := newIngressController(icName, domain, withTuningOptions(operatorv1.IngressControllerTuningOptions{
 		ClientTimeout:    &metav1.Duration{Duration: clientTimeoutInput},
 		ClientFinTimeout: &metav1.Duration{Duration: clientFinTimeoutInput},
 		ServerTimeout:    &metav1.Duration{Duration: serverTimeoutInput},
 		ServerFinTimeout: &metav1.Duration{Duration: serverFinTimeoutInput},
 		TunnelTimeout:    &metav1.Duration{Duration: tunnelTimeoutInput},
 		TLSInspectDelay:  &metav1.Duration{Duration: tlsInspectDelayInput},
-	}
-	if err := kclient.Create(context.TODO(), ic); err != nil {
-		t.Fatalf("failed to create ingresscontroller %s: %v", icName, err)
-	}
+	}))
 	defer assertIngressControllerDeleted(t, kclient, ic)
-	conditions := []operatorv1.OperatorCondition{
-		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
-		{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-		{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
-	}
-	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, conditions...); err != nil {
-		t.Fatalf("failed to observe expected conditions: %v", err)
-	}
+	createIngressControllerAndAwaitReady(t, ic)
 
 	deployment := &appsv1.Deployment{}
 	if err := kclient.Get(context.TODO(), controller.RouterDeploymentName(ic), deployment); err != nil {
@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is built both with and without the e2e build tag so that the
+// deployment readiness checks can be unit-tested without a cluster.
+
+// awaitDeploymentReady waits for the named deployment to exist and to be ready,
+// as determined by deploymentReady, and returns the deployment.  Unlike
+// checking the deployment's pods, this tolerates deployments with multiple
+// replicas and pods that are still terminating after a rollout.
+func awaitDeploymentReady(ctx context.Context, t *testing.T, cl client.Client, name types.NamespacedName, timeout time.Duration) (*appsv1.Deployment, error) {
+	t.Helper()
+	deployment := &appsv1.Deployment{}
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := cl.Get(ctx, name, deployment); err != nil {
+			lastErr = err
+			t.Logf("failed to get deployment %v, retrying...", name)
+			return false, nil
+		}
+		if err := deploymentReady(deployment); err != nil {
+			lastErr = err
+			t.Logf("deployment %v is not ready, retrying...: %v", name, err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		if lastErr == nil {
+			return nil, fmt.Errorf("deployment %v did not become ready: %w", name, err)
+		}
+		return nil, fmt.Errorf("deployment %v did not become ready: %w: %w", name, lastErr, err)
+	}
+	return deployment, nil
+}
+
+// deploymentReady returns an error describing why the given deployment is not
+// ready, or nil if it is ready.  A deployment is ready if the deployment
+// controller has observed its latest generation, all of its desired replicas
+// have been updated to the latest pod template and are available, no replicas
+// from a previous pod template remain, and it has the Available condition.
+func deploymentReady(deployment *appsv1.Deployment) error {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return fmt.Errorf("observed generation %d is behind generation %d", deployment.Status.ObservedGeneration, deployment.Generation)
+	}
+	if condition := deploymentCondition(deployment, appsv1.DeploymentProgressing); condition != nil && condition.Status == corev1.ConditionFalse {
+		return fmt.Errorf("deployment is not progressing: %s: %s", condition.Reason, condition.Message)
+	}
+	// If spec.replicas is null, the default value is 1, per the API spec.
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas != desired {
+		return fmt.Errorf("%d of %d replicas are updated", deployment.Status.UpdatedReplicas, desired)
+	}
+	if deployment.Status.Replicas != desired {
+		return fmt.Errorf("%d replicas exist, expected %d", deployment.Status.Replicas, desired)
+	}
+	if deployment.Status.AvailableReplicas < desired {
+		return fmt.Errorf("%d of %d replicas are available", deployment.Status.AvailableReplicas, desired)
+	}
+	if condition := deploymentCondition(deployment, appsv1.DeploymentAvailable); condition == nil || condition.Status != corev1.ConditionTrue {
+		return fmt.Errorf("deployment does not have the %s condition", appsv1.DeploymentAvailable)
+	}
+	return nil
+}
+
+// deploymentCondition returns the condition of the given type on the given
+// deployment, or nil if the deployment has no such condition.
+func deploymentCondition(deployment *appsv1.Deployment, conditionType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range deployment.Status.Conditions {
+		if deployment.Status.Conditions[i].Type == conditionType {
+			return &deployment.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// runningPods returns the pods in the given list that are running and are not
+// terminating, along with the number of pods that are terminating.
+func runningPods(pods []corev1.Pod) ([]corev1.Pod, int) {
+	var running []corev1.Pod
+	terminating := 0
+	for _, pod := range pods {
+		switch {
+		case pod.DeletionTimestamp != nil:
+			terminating++
+		case pod.Status.Phase == corev1.PodRunning:
+			running = append(running, pod)
+		}
+	}
+	return running, terminating
+}
@@ -0,0 +1,187 @@
+//go:build !e2e
+// +build !e2e
+
+package e2e
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/utils/pointer"
+)
+
+// These tests are built without the e2e build tag so that they run as unit
+// tests, without a cluster and without being invoked by TestAll.
+
+// newFakeDeployment returns a deployment with the given desired replicas and
+// status.
+func newFakeDeployment(replicas int32, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "openshift-ingress",
+			Name:       "istiod-openshift-gateway",
+			Generation: 2,
+		},
+		Spec:   appsv1.DeploymentSpec{Replicas: pointer.Int32(replicas)},
+		Status: status,
+	}
+}
+
+func deploymentConditions(available, progressing corev1.ConditionStatus, progressingReason string) []appsv1.DeploymentCondition {
+	return []appsv1.DeploymentCondition{
+		{Type: appsv1.DeploymentAvailable, Status: available},
+		{Type: appsv1.DeploymentProgressing, Status: progressing, Reason: progressingReason},
+	}
+}
+
+func Test_deploymentReady(t *testing.T) {
+	testCases := []struct {
+		name        string
+		deployment  *appsv1.Deployment
+		expectReady bool
+	}{
+		{
+			name: "single replica ready",
+			deployment: newFakeDeployment(1, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           1,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "NewReplicaSetAvailable"),
+			}),
+			expectReady: true,
+		},
+		{
+			name: "scaled up to two replicas for HA",
+			deployment: newFakeDeployment(2, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           2,
+				UpdatedReplicas:    2,
+				AvailableReplicas:  2,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "NewReplicaSetAvailable"),
+			}),
+			expectReady: true,
+		},
+		{
+			name: "scaling up",
+			deployment: newFakeDeployment(2, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           2,
+				UpdatedReplicas:    2,
+				AvailableReplicas:  1,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "ReplicaSetUpdated"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "generation not observed",
+			deployment: newFakeDeployment(1, appsv1.DeploymentStatus{
+				ObservedGeneration: 1,
+				Replicas:           1,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "NewReplicaSetAvailable"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "mid-rollout with old replica",
+			deployment: newFakeDeployment(2, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           3,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  2,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "ReplicaSetUpdated"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "rollout complete with surge replica remaining",
+			deployment: newFakeDeployment(2, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           3,
+				UpdatedReplicas:    2,
+				AvailableReplicas:  3,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionTrue, "ReplicaSetUpdated"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "degraded",
+			deployment: newFakeDeployment(2, appsv1.DeploymentStatus{
+				ObservedGeneration:  2,
+				Replicas:            2,
+				UpdatedReplicas:     2,
+				AvailableReplicas:   0,
+				UnavailableReplicas: 2,
+				Conditions:          deploymentConditions(corev1.ConditionFalse, corev1.ConditionTrue, "NewReplicaSetAvailable"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "progress deadline exceeded",
+			deployment: newFakeDeployment(1, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           1,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+				Conditions:         deploymentConditions(corev1.ConditionTrue, corev1.ConditionFalse, "ProgressDeadlineExceeded"),
+			}),
+			expectReady: false,
+		},
+		{
+			name: "available condition missing",
+			deployment: newFakeDeployment(1, appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           1,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+			}),
+			expectReady: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := deploymentReady(tc.deployment)
+			switch {
+			case tc.expectReady && err != nil:
+				t.Errorf("expected deployment to be ready, got %v", err)
+			case !tc.expectReady && err == nil:
+				t.Error("expected deployment not to be ready")
+			}
+		})
+	}
+}
+
+func Test_runningPods(t *testing.T) {
+	now := metav1.Now()
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istiod-new-1"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istiod-new-2"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istiod-old", DeletionTimestamp: &now},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "istiod-pending"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	}
+	running, terminating := runningPods(pods)
+	if len(running) != 2 || running[0].Name != "istiod-new-1" || running[1].Name != "istiod-new-2" {
+		t.Errorf("expected the two new running pods, got %v", running)
+	}
+	if terminating != 1 {
+		t.Errorf("expected 1 terminating pod, got %d", terminating)
+	}
+}
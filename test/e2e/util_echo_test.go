@@ -0,0 +1,327 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-ingress-operator/test/echo"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// echoDefaultHTTPPort is the port on which the echo server listens
+	// for plaintext connections if no ports are specified.
+	echoDefaultHTTPPort = int32(8080)
+	// echoDefaultHTTPSPort is the port on which the echo server listens
+	// for TLS connections if withEchoTLS specifies no ports.
+	echoDefaultHTTPSPort = int32(8443)
+	// echoServingCertMountPath is the path at which the echo server's
+	// serving certificate secret is mounted.
+	echoServingCertMountPath = "/etc/serving-cert"
+)
+
+// echoConfig describes the echo server that buildEchoPod and buildEchoService
+// return.
+type echoConfig struct {
+	// image is the ingress operator image from which to run the echo test
+	// server.  If image is empty, the echo server is socat-based.
+	image string
+	// httpPorts are the ports on which the echo server serves plaintext
+	// HTTP.
+	httpPorts []int32
+	// httpsPorts are the ports on which the echo server serves HTTPS.
+	httpsPorts []int32
+	// tlsSecretName is the name of the secret with the echo server's
+	// serving certificate and key.
+	tlsSecretName string
+	// http2 specifies whether the echo server serves HTTP/2, using ALPN
+	// on the HTTPS ports and with prior knowledge (h2c) on the plaintext
+	// ports.
+	http2 bool
+}
+
+// echoOption configures the echo server that buildEchoPod and buildEchoService
+// return.
+type echoOption func(*echoConfig)
+
+// withEchoServerImage specifies that the echo server runs the echo test
+// server from the given ingress operator image rather than socat.  The echo
+// test server responds with a JSON description of the request, which
+// parseEchoResponse parses, and with response headers that identify the pod
+// and describe the request that the pod received.  The options for multiple
+// ports and HTTP/2 require the echo test server.
+func withEchoServerImage(image string) echoOption {
+	return func(c *echoConfig) {
+		c.image = image
+	}
+}
+
+// withEchoHTTPPorts specifies the ports on which the echo server serves
+// plaintext HTTP.  The default is 8080 unless withEchoTLS is specified, in
+// which case the default is none.
+func withEchoHTTPPorts(ports ...int32) echoOption {
+	return func(c *echoConfig) {
+		c.httpPorts = append(c.httpPorts, ports...)
+	}
+}
+
+// withEchoTLS specifies that the echo server serves HTTPS on the given ports,
+// or on 8443 if no ports are given, using the serving certificate and key in
+// the secret with the given name.  The secret may be one that the service CA
+// issues or one that createEchoServingCertSecret creates.
+func withEchoTLS(secretName string, ports ...int32) echoOption {
+	return func(c *echoConfig) {
+		c.tlsSecretName = secretName
+		if len(ports) == 0 {
+			ports = []int32{echoDefaultHTTPSPort}
+		}
+		c.httpsPorts = append(c.httpsPorts, ports...)
+	}
+}
+
+// withEchoHTTP2 specifies that the echo server serves HTTP/2 in addition to
+// HTTP/1, using ALPN on the HTTPS ports and with prior knowledge (h2c) on the
+// plaintext ports.
+func withEchoHTTP2() echoOption {
+	return func(c *echoConfig) {
+		c.http2 = true
+	}
+}
+
+// newEchoConfig returns the echo server configuration that the given options
+// specify.  It panics if the options require the echo test server but do not
+// specify its image, which is a mistake in the test.
+func newEchoConfig(opts []echoOption) echoConfig {
+	var c echoConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if len(c.httpPorts) == 0 && len(c.httpsPorts) == 0 {
+		c.httpPorts = []int32{echoDefaultHTTPPort}
+	}
+	if len(c.image) == 0 && (c.http2 || len(c.httpPorts)+len(c.httpsPorts) > 1) {
+		panic("the echo server options for multiple ports and HTTP/2 require withEchoServerImage")
+	}
+	return c
+}
+
+// echoContainerPorts returns the container ports of the echo server.
+func (c echoConfig) echoContainerPorts() []corev1.ContainerPort {
+	var ports []corev1.ContainerPort
+	for _, port := range append(append([]int32{}, c.httpPorts...), c.httpsPorts...) {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+	return ports
+}
+
+// echoServicePorts returns the service ports for the echo server.  The first
+// plaintext port is exposed on port 80 and named "http", and the first HTTPS
+// port is named "https"; every other port is exposed on its own number and
+// named after it.  With HTTP/2, the plaintext ports specify the h2c
+// application protocol.
+func (c echoConfig) echoServicePorts() []corev1.ServicePort {
+	var ports []corev1.ServicePort
+	for i, port := range c.httpPorts {
+		servicePort := corev1.ServicePort{
+			Name:       fmt.Sprintf("http-%d", port),
+			Port:       port,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(int(port)),
+		}
+		if i == 0 {
+			servicePort.Name = "http"
+			servicePort.Port = int32(80)
+		}
+		if c.http2 {
+			h2c := "h2c"
+			servicePort.AppProtocol = &h2c
+		}
+		ports = append(ports, servicePort)
+	}
+	for i, port := range c.httpsPorts {
+		servicePort := corev1.ServicePort{
+			Name:       fmt.Sprintf("https-%d", port),
+			Port:       port,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(int(port)),
+		}
+		if i == 0 {
+			servicePort.Name = "https"
+		}
+		ports = append(ports, servicePort)
+	}
+	return ports
+}
+
+// configureEchoTestServer configures the given echo container to run the echo
+// test server.
+func (c echoConfig) configureEchoTestServer(container *corev1.Container) {
+	joinPorts := func(ports []int32) string {
+		var s []string
+		for _, port := range ports {
+			s = append(s, fmt.Sprint(port))
+		}
+		return strings.Join(s, ",")
+	}
+	container.Image = c.image
+	container.Command = nil
+	container.Args = []string{"serve-echo-test-server"}
+	container.Env = []corev1.EnvVar{{
+		Name: "POD_NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+		},
+	}, {
+		Name:  "HTTP_PORTS",
+		Value: joinPorts(c.httpPorts),
+	}, {
+		Name:  "HTTPS_PORTS",
+		Value: joinPorts(c.httpsPorts),
+	}, {
+		Name:  "ENABLE_HTTP2",
+		Value: fmt.Sprint(c.http2),
+	}}
+	probe := &corev1.HTTPGetAction{Path: "/healthz"}
+	if len(c.httpPorts) != 0 {
+		probe.Port = intstr.FromInt(int(c.httpPorts[0]))
+	} else {
+		probe.Port = intstr.FromInt(int(c.httpsPorts[0]))
+		probe.Scheme = corev1.URISchemeHTTPS
+	}
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{HTTPGet: probe},
+	}
+}
+
+// configureEchoTLS configures the given socat-based echo container to serve
+// HTTPS instead of plaintext HTTP.
+func (c echoConfig) configureEchoTLS(container *corev1.Container) {
+	port := c.httpsPorts[0]
+	container.Args[0] = fmt.Sprintf("OPENSSL-LISTEN:%d,reuseaddr,fork,verify=0,cert=%s/tls.crt,key=%s/tls.key", port, echoServingCertMountPath, echoServingCertMountPath)
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(int(port))},
+		},
+	}
+}
+
+// mountEchoServingCert mounts the echo server's serving certificate secret
+// into the given echo pod.
+func (c echoConfig) mountEchoServingCert(pod *corev1.Pod) {
+	pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{
+		Name:      "serving-cert",
+		MountPath: echoServingCertMountPath,
+		ReadOnly:  true,
+	}}
+	pod.Spec.Volumes = []corev1.Volume{{
+		Name: "serving-cert",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: c.tlsSecretName},
+		},
+	}}
+}
+
+// parseEchoResponse reads the body of the given response from the echo test
+// server and returns the description of the request that the echo server
+// received.  An error is returned if the response is not from the echo test
+// server or if its identifying headers do not match its body.
+func parseEchoResponse(resp *http.Response) (*echo.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(resp.Header.Get(echo.PodNameHeader)) == 0 {
+		return nil, fmt.Errorf("response is missing the %s header; status: %s, body: %q", echo.PodNameHeader, resp.Status, body)
+	}
+	response := &echo.Response{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("failed to decode response body %q: %w", body, err)
+	}
+	for header, value := range map[string]string{
+		echo.PodNameHeader: response.PodName,
+		echo.HostHeader:    response.Host,
+		echo.ProtoHeader:   response.Proto,
+		echo.PortHeader:    response.Port,
+	} {
+		if actual := resp.Header.Get(header); actual != value {
+			return nil, fmt.Errorf("expected response header %s to be %q, got %q", header, value, actual)
+		}
+	}
+	return response, nil
+}
+
+// createEchoServingCertSecret generates a CA and a serving certificate that the
+// CA signs and that is valid for the given DNS names, and creates a TLS secret
+// with the given name and namespace that has the serving certificate and key,
+// for use with withEchoTLS.  The secret is deleted when the test ends.  Returns
+// the PEM-encoded CA certificate so that clients can verify the echo server.
+func createEchoServingCertSecret(t *testing.T, name, namespace string, dnsNames ...string) (string, error) {
+	t.Helper()
+
+	notBefore := time.Now().Add(-1 * time.Hour)
+	notAfter := time.Now().Add(24 * time.Hour)
+	ca, err := CreateTLSKeyCert(name+"-ca", notBefore, notAfter, true, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	serving, err := CreateTLSKeyCert(dnsNames[0], notBefore, notAfter, false, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	// CreateTLSKeyCert does not set subject alternative names, which
+	// clients require, so sign the serving certificate again with them.
+	template := serving.Cert
+	template.DNSNames = dnsNames
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	template.Subject = pkix.Name{CommonName: dnsNames[0]}
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return "", fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &serving.Key.PublicKey, ca.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create serving certificate: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serving.Key)}),
+		},
+	}
+	if err := kclient.Create(context.TODO(), secret); err != nil {
+		return "", fmt.Errorf("failed to create secret %s/%s: %w", namespace, name, err)
+	}
+	t.Cleanup(func() {
+		if err := kclient.Delete(context.TODO(), secret); err != nil && !apierrors.IsNotFound(err) {
+			t.Logf("failed to delete secret %s/%s: %v", namespace, name, err)
+		}
+	})
+	return ca.CertPem, nil
+}
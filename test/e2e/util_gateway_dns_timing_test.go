@@ -0,0 +1,219 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	v1 "github.com/openshift/api/operatoringress/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	// gatewayConditionProgrammed is the Gateway condition that indicates
+	// that the gateway implementation has configured the data plane.  The
+	// vendored Gateway API predates this condition, in which it was named
+	// "Ready".
+	gatewayConditionProgrammed = "Programmed"
+
+	// dnsRecordCreationBudgetEnvVar, dnsRecordPublicationBudgetEnvVar, and
+	// dnsResolutionBudgetEnvVar override the budget for the corresponding
+	// stage of DNS propagation.  Values use the format of
+	// time.ParseDuration.
+	dnsRecordCreationBudgetEnvVar    = "E2E_GATEWAY_DNS_RECORD_CREATION_BUDGET"
+	dnsRecordPublicationBudgetEnvVar = "E2E_GATEWAY_DNS_RECORD_PUBLICATION_BUDGET"
+	dnsResolutionBudgetEnvVar        = "E2E_GATEWAY_DNS_RESOLUTION_BUDGET"
+)
+
+// dnsPropagationBudgets specifies how long each stage of DNS propagation for a
+// gateway may take.
+type dnsPropagationBudgets struct {
+	// recordCreation is the budget from the gateway's becoming programmed
+	// to the creation of its DNSRecord.
+	recordCreation time.Duration
+	// recordPublication is the budget from the creation of the DNSRecord
+	// to its being published to the DNS provider.
+	recordPublication time.Duration
+	// resolution is the budget from the publication of the DNSRecord to
+	// the first successful resolution of the gateway's hostname.
+	resolution time.Duration
+}
+
+// defaultDNSPropagationBudgets is the budget for platforms that are not in
+// platformDNSPropagationBudgets.
+var defaultDNSPropagationBudgets = dnsPropagationBudgets{
+	recordCreation:    1 * time.Minute,
+	recordPublication: 2 * time.Minute,
+	resolution:        5 * time.Minute,
+}
+
+// platformDNSPropagationBudgets has the budget for each platform whose DNS
+// provider is known to propagate records faster than the default budget
+// allows.
+var platformDNSPropagationBudgets = map[configv1.PlatformType]dnsPropagationBudgets{
+	configv1.AWSPlatformType: {
+		recordCreation:    1 * time.Minute,
+		recordPublication: 1 * time.Minute,
+		resolution:        4 * time.Minute,
+	},
+	configv1.AzurePlatformType: {
+		recordCreation:    1 * time.Minute,
+		recordPublication: 1 * time.Minute,
+		resolution:        3 * time.Minute,
+	},
+	configv1.GCPPlatformType: {
+		recordCreation:    1 * time.Minute,
+		recordPublication: 1 * time.Minute,
+		resolution:        3 * time.Minute,
+	},
+}
+
+// clusterPlatformType returns the platform type of the cluster, or the empty
+// string if the infrastructure config does not specify it.
+func clusterPlatformType() configv1.PlatformType {
+	if infraConfig.Status.PlatformStatus == nil {
+		return ""
+	}
+	return infraConfig.Status.PlatformStatus.Type
+}
+
+// dnsPropagationBudgetsForPlatform returns the budgets for the given platform,
+// with any overrides from the environment applied.
+func dnsPropagationBudgetsForPlatform(platform configv1.PlatformType) (dnsPropagationBudgets, error) {
+	budgets, ok := platformDNSPropagationBudgets[platform]
+	if !ok {
+		budgets = defaultDNSPropagationBudgets
+	}
+	for envVar, budget := range map[string]*time.Duration{
+		dnsRecordCreationBudgetEnvVar:    &budgets.recordCreation,
+		dnsRecordPublicationBudgetEnvVar: &budgets.recordPublication,
+		dnsResolutionBudgetEnvVar:        &budgets.resolution,
+	} {
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return budgets, fmt.Errorf("invalid value for %s: %w", envVar, err)
+		}
+		*budget = d
+	}
+	return budgets, nil
+}
+
+// dnsPropagationTimings records when each stage of DNS propagation for a
+// gateway completed.  The first three timestamps come from the cluster, which
+// reports them with a resolution of one second, and the last comes from the
+// test's clock.
+type dnsPropagationTimings struct {
+	gatewayProgrammed time.Time
+	recordCreated     time.Time
+	recordPublished   time.Time
+	// firstResolved is zero if the gateway's hostname is an IP address,
+	// which does not need to be resolved.
+	firstResolved time.Time
+}
+
+// dnsPropagationStage is the duration of one stage of DNS propagation and its
+// budget.
+type dnsPropagationStage struct {
+	name     string
+	duration time.Duration
+	budget   time.Duration
+}
+
+// stages returns the duration of each stage of DNS propagation along with the
+// budget for the stage.  Stages that end before they start, as when a
+// DNSRecord is created before the gateway is programmed, take no time.
+func (d dnsPropagationTimings) stages(budgets dnsPropagationBudgets) []dnsPropagationStage {
+	since := func(start, end time.Time) time.Duration {
+		if end.Before(start) {
+			return 0
+		}
+		return end.Sub(start)
+	}
+	stages := []dnsPropagationStage{
+		{"gateway programmed to DNSRecord created", since(d.gatewayProgrammed, d.recordCreated), budgets.recordCreation},
+		{"DNSRecord created to DNSRecord published", since(d.recordCreated, d.recordPublished), budgets.recordPublication},
+	}
+	if !d.firstResolved.IsZero() {
+		stages = append(stages, dnsPropagationStage{"DNSRecord published to hostname resolved", since(d.recordPublished, d.firstResolved), budgets.resolution})
+	}
+	return stages
+}
+
+// check logs the duration of each stage of DNS propagation and returns an
+// error identifying each stage that exceeded its budget.
+func (d dnsPropagationTimings) check(t *testing.T, hostname string, platform configv1.PlatformType, budgets dnsPropagationBudgets) error {
+	t.Helper()
+
+	var exceeded []string
+	for _, stage := range d.stages(budgets) {
+		t.Logf("DNS propagation for %s on platform %q: %s took %v (budget %v)", hostname, platform, stage.name, stage.duration, stage.budget)
+		if stage.duration > stage.budget {
+			exceeded = append(exceeded, fmt.Sprintf("%s took %v, which exceeds its budget of %v", stage.name, stage.duration, stage.budget))
+		}
+	}
+	if len(exceeded) != 0 {
+		return fmt.Errorf("DNS propagation for %s on platform %q was too slow: %v", hostname, platform, exceeded)
+	}
+	return nil
+}
+
+// waitForGatewayProgrammed waits for the given gateway to be programmed and
+// returns the time at which it became programmed.
+func waitForGatewayProgrammed(t *testing.T, name types.NamespacedName) (time.Time, error) {
+	t.Helper()
+
+	var programmed time.Time
+	gw := &gwapi.Gateway{}
+	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 3*time.Minute, false, func(context context.Context) (bool, error) {
+		if err := kclient.Get(context, name, gw); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		for _, conditionType := range []string{gatewayConditionProgrammed, string(gwapi.GatewayConditionReady)} {
+			for _, condition := range gw.Status.Conditions {
+				if condition.Type == conditionType && condition.Status == metav1.ConditionTrue {
+					programmed = condition.LastTransitionTime.Time
+					return true, nil
+				}
+			}
+		}
+		t.Logf("gateway %s is not programmed yet, retrying...", name)
+		return false, nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("gateway %s was not programmed: %w", name, err)
+	}
+	return programmed, nil
+}
+
+// dnsRecordPublishedTime returns the time at which the given DNSRecord was
+// first published to a zone, or the zero time if it has not been published.
+func dnsRecordPublishedTime(record *v1.DNSRecord) time.Time {
+	var published time.Time
+	for _, zone := range record.Status.Zones {
+		for _, condition := range zone.Conditions {
+			if condition.Type != v1.DNSRecordPublishedConditionType || condition.Status != string(metav1.ConditionTrue) {
+				continue
+			}
+			if published.IsZero() || condition.LastTransitionTime.Time.Before(published) {
+				published = condition.LastTransitionTime.Time
+			}
+		}
+	}
+	return published
+}
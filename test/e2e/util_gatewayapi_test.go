@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,15 +20,19 @@ import (
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"golang.org/x/sync/errgroup"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwapi "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -45,34 +50,184 @@ const (
 	openshiftSMCPName = "openshift-gateway"
 )
 
-// updateIngressOperatorRole updates the ingress-operator cluster role with cluster-admin privilege.
+// ingressOperatorServiceAccountUser is the username of the ingress-operator
+// service account, which subject access reviews use.
+var ingressOperatorServiceAccountUser = "system:serviceaccount:" + operatorcontroller.DefaultOperatorNamespace + ":ingress-operator"
+
+// ossmWorkaroundRoleName is the name of the cluster role and cluster role
+// binding that grantIngressOperatorOSSMWorkaroundRole creates.
+const ossmWorkaroundRoleName = "ingress-operator-ossm-e2e"
+
+// ossmWorkaroundPolicyRules are the rules that the ingress-operator service
+// account needs in addition to its own cluster role in order to create a
+// ServiceMeshControlPlane.  OSSM's admission webhook rejects a
+// ServiceMeshControlPlane unless the user that creates it holds the
+// permissions that OSSM grants to istiod, which are broader than the
+// operator's own role.  The rules mirror istiod's cluster role.
+// TODO - Remove these rules after https://issues.redhat.com/browse/OSSM-3508 is fixed.
+var ossmWorkaroundPolicyRules = []rbacv1.PolicyRule{{
+	APIGroups: []string{"config.istio.io", "security.istio.io", "networking.istio.io", "authentication.istio.io", "rbac.istio.io", "telemetry.istio.io", "extensions.istio.io"},
+	Resources: []string{"*"},
+	Verbs:     []string{"get", "list", "watch"},
+}, {
+	APIGroups: []string{"networking.istio.io"},
+	Resources: []string{"workloadentries", "workloadentries/status"},
+	Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+}, {
+	APIGroups: []string{"gateway.networking.k8s.io"},
+	Resources: []string{"*"},
+	Verbs:     []string{"get", "list", "watch", "update", "patch"},
+}, {
+	APIGroups: []string{"discovery.k8s.io"},
+	Resources: []string{"endpointslices"},
+	Verbs:     []string{"get", "list", "watch"},
+}, {
+	APIGroups: []string{"networking.k8s.io"},
+	Resources: []string{"ingresses", "ingressclasses"},
+	Verbs:     []string{"get", "list", "watch"},
+}, {
+	APIGroups: []string{"networking.k8s.io"},
+	Resources: []string{"ingresses/status"},
+	Verbs:     []string{"update", "patch"},
+}, {
+	APIGroups: []string{""},
+	Resources: []string{"pods", "nodes", "services", "namespaces", "endpoints"},
+	Verbs:     []string{"get", "list", "watch"},
+}, {
+	APIGroups: []string{"admissionregistration.k8s.io"},
+	Resources: []string{"mutatingwebhookconfigurations", "validatingwebhookconfigurations"},
+	Verbs:     []string{"get", "list", "watch", "update", "patch"},
+}, {
+	APIGroups: []string{"authentication.k8s.io"},
+	Resources: []string{"tokenreviews"},
+	Verbs:     []string{"create"},
+}, {
+	APIGroups: []string{"authorization.k8s.io"},
+	Resources: []string{"subjectaccessreviews"},
+	Verbs:     []string{"create"},
+}, {
+	APIGroups: []string{"certificates.k8s.io"},
+	Resources: []string{"certificatesigningrequests", "certificatesigningrequests/approval", "certificatesigningrequests/status"},
+	Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
+}, {
+	APIGroups: []string{"maistra.io"},
+	Resources: []string{"servicemeshmemberrolls", "servicemeshextensions"},
+	Verbs:     []string{"get", "list", "watch"},
+}}
+
+// grantIngressOperatorOSSMWorkaroundRole grants the ingress-operator service
+// account the rules in ossmWorkaroundPolicyRules using a dedicated cluster role
+// and cluster role binding, which are updated if they already exist, and waits
+// until subject access reviews show that the service account has exactly the
+// expected permissions.  The cluster role and binding are deleted when the test
+// completes.
 // TODO - Remove this function after https://issues.redhat.com/browse/OSSM-3508 is fixed.
-func updateIngressOperatorRole(t *testing.T) error {
+func grantIngressOperatorOSSMWorkaroundRole(t *testing.T) error {
 	t.Helper()
 
-	// Create the same rolebinding that the `oc adm policy add-cluster-role-to-user` command creates.
-	// Caller must remove this after setting it.
-	crb := &rbacv1.ClusterRoleBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "cluster-admin-e2e",
-		},
-		RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
-		Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "ingress-operator", Namespace: operatorcontroller.DefaultOperatorNamespace}},
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: ossmWorkaroundRoleName},
+		Rules:      ossmWorkaroundPolicyRules,
 	}
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: ossmWorkaroundRoleName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: ossmWorkaroundRoleName},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "ingress-operator", Namespace: operatorcontroller.DefaultOperatorNamespace}},
+	}
+	t.Cleanup(func() {
+		for _, obj := range []client.Object{binding, role} {
+			if err := kclient.Delete(context.TODO(), obj); err != nil && !kerrors.IsNotFound(err) {
+				t.Errorf("failed to delete %T %s: %v", obj, obj.GetName(), err)
+			}
+		}
+	})
 
-	// Add the rolebinding to the ingress-operator user.
-	if err := kclient.Create(context.TODO(), crb); err != nil {
-		if kerrors.IsAlreadyExists(err) {
-			t.Logf("rolebinding already exists")
-			return nil
+	if _, err := ensureResource(role, &rbacv1.ClusterRole{}, func(current, desired *rbacv1.ClusterRole) (bool, *rbacv1.ClusterRole) {
+		if equality.Semantic.DeepEqual(current.Rules, desired.Rules) {
+			return false, nil
 		}
-		t.Logf("error adding rolebinding: %v", err)
+		updated := current.DeepCopy()
+		updated.Rules = desired.Rules
+		return true, updated
+	}); err != nil {
 		return err
 	}
-	t.Log("rolebinding has been added")
+	if _, err := ensureResource(binding, &rbacv1.ClusterRoleBinding{}, func(current, desired *rbacv1.ClusterRoleBinding) (bool, *rbacv1.ClusterRoleBinding) {
+		if equality.Semantic.DeepEqual(current.Subjects, desired.Subjects) {
+			return false, nil
+		}
+		updated := current.DeepCopy()
+		updated.Subjects = desired.Subjects
+		return true, updated
+	}); err != nil {
+		return err
+	}
+	t.Logf("granted clusterrole %s to %s", role.Name, ingressOperatorServiceAccountUser)
+
+	return wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := assertIngressOperatorPermissions(ctx, ossmWorkaroundPolicyRules); err != nil {
+			t.Logf("%v, retrying...", err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// assertIngressOperatorPermissions uses subject access reviews to verify that
+// the ingress-operator service account is allowed every verb on every resource
+// in the given rules, and that it does not have cluster-admin privileges.
+func assertIngressOperatorPermissions(ctx context.Context, rules []rbacv1.PolicyRule) error {
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					attrs := authorizationv1.ResourceAttributes{Group: group, Resource: resource, Verb: verb}
+					if strings.Contains(resource, "/") {
+						parts := strings.SplitN(resource, "/", 2)
+						attrs.Resource, attrs.Subresource = parts[0], parts[1]
+					}
+					allowed, err := ingressOperatorIsAllowed(ctx, attrs)
+					if err != nil {
+						return err
+					}
+					if !allowed {
+						return fmt.Errorf("%s is not allowed to %s %s.%s", ingressOperatorServiceAccountUser, verb, resource, group)
+					}
+				}
+			}
+		}
+	}
+	if isAdmin, err := ingressOperatorIsClusterAdmin(ctx); err != nil {
+		return err
+	} else if isAdmin {
+		return fmt.Errorf("%s unexpectedly has cluster-admin privileges", ingressOperatorServiceAccountUser)
+	}
 	return nil
 }
 
+// ingressOperatorIsClusterAdmin returns a Boolean value indicating whether the
+// ingress-operator service account is allowed every verb on every resource,
+// which only cluster-admin and equivalent roles allow.
+func ingressOperatorIsClusterAdmin(ctx context.Context) (bool, error) {
+	return ingressOperatorIsAllowed(ctx, authorizationv1.ResourceAttributes{Group: "*", Resource: "*", Verb: "*"})
+}
+
+// ingressOperatorIsAllowed uses a subject access review to determine whether
+// the ingress-operator service account is allowed the given resource access.
+func ingressOperatorIsAllowed(ctx context.Context, attrs authorizationv1.ResourceAttributes) (bool, error) {
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               ingressOperatorServiceAccountUser,
+			Groups:             []string{"system:serviceaccounts", "system:serviceaccounts:" + operatorcontroller.DefaultOperatorNamespace, "system:authenticated"},
+			ResourceAttributes: &attrs,
+		},
+	}
+	if err := kclient.Create(ctx, sar); err != nil {
+		return false, fmt.Errorf("failed to create subjectaccessreview: %w", err)
+	}
+	return sar.Status.Allowed, nil
+}
+
 // assertCrdExists checks if the CRD of the given name exists and returns an error if not.
 // Otherwise returns the CRD version.
 func assertCrdExists(t *testing.T, crdname string) (string, error) {
@@ -149,8 +304,9 @@ func deleteExistingCRD(t *testing.T, crdName string) error {
 	return nil
 }
 
-// createHttpRoute checks if the HTTPRoute can be created.
-// If it can't an error is returned.
+// createHttpRoute creates the HTTPRoute and its backend, or gets and updates
+// the HTTPRoute if it already exists.  If this succeeds, the HTTPRoute is
+// returned.  Otherwise, an error is returned.
 func createHttpRoute(namespace, routeName, parentNamespace, hostname, backendRefname string, gateway *gwapi.Gateway) (*gwapi.HTTPRoute, error) {
 	if gateway == nil {
 		return nil, errors.New("unable to create httpRoute, no gateway available")
@@ -170,53 +326,83 @@ func createHttpRoute(namespace, routeName, parentNamespace, hostname, backendRef
 	}
 
 	httpRoute := buildHTTPRoute(routeName, namespace, gateway.Name, parentNamespace, hostname, backendRefname)
-	if err := kclient.Create(context.TODO(), httpRoute); err != nil {
-		if kerrors.IsAlreadyExists(err) {
-			name := types.NamespacedName{Namespace: namespace, Name: routeName}
-			if err = kclient.Get(context.TODO(), name, httpRoute); err == nil {
-				return httpRoute, nil
-			} else {
-				return nil, fmt.Errorf("failed to access existing http route: %v", err.Error())
-			}
-		} else {
-			return nil, fmt.Errorf("failed to create http route: %v", err.Error())
+	return ensureResource(httpRoute, &gwapi.HTTPRoute{}, func(current, desired *gwapi.HTTPRoute) (bool, *gwapi.HTTPRoute) {
+		if equality.Semantic.DeepEqual(current.Spec, desired.Spec) {
+			return false, nil
 		}
-	}
-	return httpRoute, nil
+		updated := current.DeepCopy()
+		updated.Spec = desired.Spec
+		return true, updated
+	})
 }
 
-// createGateway checks if the Gateway can be created.
-// If it can, it is returned.  If it can't an error is returned.
+// createGateway creates the Gateway, or gets and updates it if it already
+// exists.  If this succeeds, the Gateway is returned.  Otherwise, an error is
+// returned.
 func createGateway(gatewayClass *gwapi.GatewayClass, name, namespace, domain string) (*gwapi.Gateway, error) {
 	gateway := buildGateway(name, namespace, gatewayClass.Name, allNamespaces, domain)
-	if err := kclient.Create(context.TODO(), gateway); err != nil {
-		if kerrors.IsAlreadyExists(err) {
-			name := types.NamespacedName{Namespace: namespace, Name: name}
-			if err = kclient.Get(context.TODO(), name, gateway); err != nil {
-				return nil, fmt.Errorf("failed to get the existing gateway: %v", err.Error())
-			}
-		} else {
-			return nil, fmt.Errorf("failed to create gateway: %v", err.Error())
+	return ensureResource(gateway, &gwapi.Gateway{}, func(current, desired *gwapi.Gateway) (bool, *gwapi.Gateway) {
+		if equality.Semantic.DeepEqual(current.Spec, desired.Spec) {
+			return false, nil
 		}
-	}
-	return gateway, nil
+		updated := current.DeepCopy()
+		updated.Spec = desired.Spec
+		return true, updated
+	})
 }
 
-// createGatewayClass checks if the GatewayClass can be created.
-// If it can, it is returned.  If it can't an error is returned.
+// createGatewayClass creates the GatewayClass, or gets it if it already
+// exists.  If this succeeds, the GatewayClass is returned.  Otherwise, an error
+// is returned.  An existing GatewayClass is not updated because its controller
+// name is immutable.
 func createGatewayClass(name, controllerName string) (*gwapi.GatewayClass, error) {
 	gatewayClass := buildGatewayClass(name, controllerName)
-	if err := kclient.Create(context.TODO(), gatewayClass); err != nil {
-		if kerrors.IsAlreadyExists(err) {
-			name := types.NamespacedName{Namespace: "", Name: name}
-			if err = kclient.Get(context.TODO(), name, gatewayClass); err == nil {
-				return gatewayClass, nil
+	return ensureResource(gatewayClass, &gwapi.GatewayClass{}, nil)
+}
+
+// updateGatewayClassWithRetryOnConflict gets a fresh copy of the named
+// gatewayclass, calls mutateGatewayClassFn() where callers can modify fields of
+// the gatewayclass, and then updates the gatewayclass object.  If there is a
+// conflict error on update then the complete sequence of get, mutate, and
+// update is retried until timeout is reached.
+func updateGatewayClassWithRetryOnConflict(t *testing.T, name string, timeout time.Duration, mutateGatewayClassFn func(*gwapi.GatewayClass)) error {
+	t.Helper()
+	gatewayClass := &gwapi.GatewayClass{}
+	return wait.PollUntilContextTimeout(context.Background(), 1*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, types.NamespacedName{Name: name}, gatewayClass); err != nil {
+			t.Logf("failed to get gatewayclass %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		mutateGatewayClassFn(gatewayClass)
+		if err := kclient.Update(ctx, gatewayClass); err != nil {
+			if kerrors.IsConflict(err) {
+				t.Logf("conflict when updating gatewayclass %s: %v, retrying...", name, err)
+				return false, nil
 			}
-		} else {
-			return nil, fmt.Errorf("failed to create gateway class: %v", err.Error())
+			return false, err
 		}
-	}
-	return gatewayClass, nil
+		return true, nil
+	})
+}
+
+func updateGatewayWithRetryOnConflict(t *testing.T, name types.NamespacedName, timeout time.Duration, mutateGatewayFn func(*gwapi.Gateway)) error {
+	t.Helper()
+	gateway := &gwapi.Gateway{}
+	return wait.PollUntilContextTimeout(context.Background(), 1*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := kclient.Get(ctx, name, gateway); err != nil {
+			t.Logf("failed to get gateway %s: %v, retrying...", name, err)
+			return false, nil
+		}
+		mutateGatewayFn(gateway)
+		if err := kclient.Update(ctx, gateway); err != nil {
+			if kerrors.IsConflict(err) {
+				t.Logf("conflict when updating gateway %s: %v, retrying...", name, err)
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
 }
 
 // buildGatewayClass initializes the GatewayClass and returns its address.
@@ -229,21 +415,60 @@ func buildGatewayClass(name, controllerName string) *gwapi.GatewayClass {
 	}
 }
 
-// buildGateway initializes the Gateway and returns its address.
+// gatewayListenerSpec describes a listener for buildGatewayWithListeners.
+type gatewayListenerSpec struct {
+	name     string
+	protocol gwapi.ProtocolType
+	port     gwapi.PortNumber
+	// hostname is the listener's hostname.  If it is empty, the listener
+	// matches any hostname.
+	hostname string
+	// tlsMode is the listener's TLS mode.  If it is empty, the listener
+	// has no TLS configuration.
+	tlsMode gwapi.TLSModeType
+}
+
+// buildGateway initializes the Gateway with a single HTTP listener on port 80
+// for the given domain and returns its address.
 func buildGateway(name, namespace, gcname, fromNs, domain string) *gwapi.Gateway {
-	hostname := gwapi.Hostname("*." + domain)
+	return buildGatewayWithListeners(name, namespace, gcname, fromNs, []gatewayListenerSpec{{
+		name:     "http",
+		protocol: gwapi.HTTPProtocolType,
+		port:     80,
+		hostname: "*." + domain,
+	}})
+}
+
+// buildGatewayWithListeners initializes the Gateway with the given listeners
+// and returns its address.  Each listener allows routes from the namespace/s in
+// fromNs, which could be "All".
+func buildGatewayWithListeners(name, namespace, gcname, fromNs string, listeners []gatewayListenerSpec) *gwapi.Gateway {
 	fromNamespace := gwapi.FromNamespaces(fromNs)
-	// Tell the gateway listener to allow routes from the namespace/s in the fromNamespaces variable, which could be "All".
 	allowedRoutes := gwapi.AllowedRoutes{Namespaces: &gwapi.RouteNamespaces{From: &fromNamespace}}
-	listener1 := gwapi.Listener{Name: "http", Hostname: &hostname, Port: 80, Protocol: "HTTP", AllowedRoutes: &allowedRoutes}
-
-	return &gwapi.Gateway{
+	gateway := &gwapi.Gateway{
 		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
 		Spec: gwapi.GatewaySpec{
 			GatewayClassName: gwapi.ObjectName(gcname),
-			Listeners:        []gwapi.Listener{listener1},
 		},
 	}
+	for _, l := range listeners {
+		listener := gwapi.Listener{
+			Name:          gwapi.SectionName(l.name),
+			Port:          l.port,
+			Protocol:      l.protocol,
+			AllowedRoutes: allowedRoutes.DeepCopy(),
+		}
+		if len(l.hostname) != 0 {
+			hostname := gwapi.Hostname(l.hostname)
+			listener.Hostname = &hostname
+		}
+		if len(l.tlsMode) != 0 {
+			tlsMode := l.tlsMode
+			listener.TLS = &gwapi.GatewayTLSConfig{Mode: &tlsMode}
+		}
+		gateway.Spec.Listeners = append(gateway.Spec.Listeners, listener)
+	}
+	return gateway
 }
 
 // buildHTTPRoute initializes the HTTPRoute and returns its address.
@@ -272,17 +497,57 @@ func buildHTTPRoute(routeName, namespace, parentgateway, parentNamespace, hostna
 	}
 }
 
-// assertSubscription checks if the Subscription of the given name exists and returns an error if not.
-func assertSubscription(t *testing.T, namespace, subName string) error {
+// buildHTTPRouteRule initializes an HTTPRouteRule that forwards requests with
+// the given path prefix to the given backend and returns it.
+func buildHTTPRouteRule(pathPrefix string, backendRef gwapi.BackendObjectReference) gwapi.HTTPRouteRule {
+	pathType := gwapi.PathMatchPathPrefix
+	return gwapi.HTTPRouteRule{
+		Matches: []gwapi.HTTPRouteMatch{{
+			Path: &gwapi.HTTPPathMatch{Type: &pathType, Value: &pathPrefix},
+		}},
+		BackendRefs: []gwapi.HTTPBackendRef{{
+			BackendRef: gwapi.BackendRef{BackendObjectReference: backendRef},
+		}},
+	}
+}
+
+// buildHTTPRouteWithRules initializes the HTTPRoute with the given rules and
+// returns its address.
+func buildHTTPRouteWithRules(routeName, namespace, parentgateway, parentNamespace, hostname string, rules []gwapi.HTTPRouteRule) *gwapi.HTTPRoute {
+	parentns := gwapi.Namespace(parentNamespace)
+	parent := gwapi.ParentReference{Name: gwapi.ObjectName(parentgateway), Namespace: &parentns}
+
+	return &gwapi.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: routeName, Namespace: namespace},
+		Spec: gwapi.HTTPRouteSpec{
+			CommonRouteSpec: gwapi.CommonRouteSpec{ParentRefs: []gwapi.ParentReference{parent}},
+			Hostnames:       []gwapi.Hostname{gwapi.Hostname(hostname)},
+			Rules:           rules,
+		},
+	}
+}
+
+// assertSubscription checks if the Subscription of the given name exists and
+// uses the given catalog source and channel, and returns an error if not.
+func assertSubscription(ctx context.Context, t *testing.T, namespace, subName, catalogSourceNamespace, catalogSourceName, channel string) error {
 	t.Helper()
 	subscription := &operatorsv1alpha1.Subscription{}
 	nsName := types.NamespacedName{Namespace: namespace, Name: subName}
 
-	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 30*time.Second, false, func(context context.Context) (bool, error) {
+	err := wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, false, func(context context.Context) (bool, error) {
 		if err := kclient.Get(context, nsName, subscription); err != nil {
 			t.Logf("failed to get subscription %s, retrying...", subName)
 			return false, nil
 		}
+		if subscription.Spec == nil {
+			t.Logf("found subscription %s without a spec, retrying...", subscription.Name)
+			return false, nil
+		}
+		spec := subscription.Spec
+		if spec.CatalogSourceNamespace != catalogSourceNamespace || spec.CatalogSource != catalogSourceName || spec.Channel != channel {
+			t.Logf("found subscription %s with catalog source %s/%s and channel %q, expected catalog source %s/%s and channel %q, retrying...", subscription.Name, spec.CatalogSourceNamespace, spec.CatalogSource, spec.Channel, catalogSourceNamespace, catalogSourceName, channel)
+			return false, nil
+		}
 		t.Logf("found subscription %s at installed version %s", subscription.Name, subscription.Status.InstalledCSV)
 		return true, nil
 	})
@@ -291,73 +556,46 @@ func assertSubscription(t *testing.T, namespace, subName string) error {
 
 // assertOSSMOperator checks if the OSSM Istio operator gets successfully installed
 // and returns an error if not.
-func assertOSSMOperator(t *testing.T) error {
+func assertOSSMOperator(ctx context.Context, t *testing.T) error {
 	t.Helper()
-	dep := &appsv1.Deployment{}
 	ns := types.NamespacedName{Namespace: openshiftOperatorsNamespace, Name: openshiftIstioOperatorDeploymentName}
-
-	// Get the Istio operator deployment.
-	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 30*time.Second, false, func(context context.Context) (bool, error) {
-		if err := kclient.Get(context, ns, dep); err != nil {
-			t.Logf("failed to get deployment %v, retrying...", ns)
-			return false, nil
-		}
-		return true, nil
-	})
-	if err != nil {
-		return fmt.Errorf("error finding deployment %v: %v", ns, err)
-	}
-
-	// Get the istio-operator pod.
-	podlist, err := getPods(t, kclient, dep)
-	if err != nil {
-		return fmt.Errorf("error finding pod for deployment %v: %v", ns, err)
-	}
-	if len(podlist.Items) > 1 {
-		return fmt.Errorf("too many pods for deployment %v: %d", ns, len(podlist.Items))
-	}
-	pod := podlist.Items[0]
-	if pod.Status.Phase != corev1.PodRunning {
-		return fmt.Errorf("OSSM operator failure: pod %s is not running, it is %v", pod.Name, pod.Status.Phase)
-	}
-
-	t.Logf("found OSSM operator pod %s/%s to be %s", pod.Namespace, pod.Name, pod.Status.Phase)
-	return nil
+	return assertDeploymentHasRunningPods(ctx, t, ns, 1*time.Minute, "OSSM operator")
 }
 
 // assertIstiodControlPlane checks if the OSSM Istiod control plane gets successfully installed
 // and returns an error if not.
-func assertIstiodControlPlane(t *testing.T) error {
+func assertIstiodControlPlane(ctx context.Context, t *testing.T) error {
 	t.Helper()
-	dep := &appsv1.Deployment{}
 	ns := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: openshiftIstiodDeploymentName}
+	return assertDeploymentHasRunningPods(ctx, t, ns, 2*time.Minute, "Istiod")
+}
 
-	// Get the Istiod deployment.
-	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(context context.Context) (bool, error) {
-		if err := kclient.Get(context, ns, dep); err != nil {
-			t.Logf("failed to get deployment %v, retrying...", ns)
-			return false, nil
-		}
-		return true, nil
-	})
+// assertDeploymentHasRunningPods waits for the named deployment to be ready and
+// checks that it has running pods, and returns an error if not.  The deployment
+// may have multiple replicas, and pods that are terminating after a rollout are
+// ignored.
+func assertDeploymentHasRunningPods(ctx context.Context, t *testing.T, name types.NamespacedName, timeout time.Duration, component string) error {
+	t.Helper()
+	dep, err := awaitDeploymentReady(ctx, t, kclient, name, timeout)
 	if err != nil {
-		return fmt.Errorf("error finding deployment %v: %v", ns, err)
+		return fmt.Errorf("%s failure: %w", component, err)
 	}
 
-	// Get the Istiod pod.
 	podlist, err := getPods(t, kclient, dep)
 	if err != nil {
-		return fmt.Errorf("error finding pod for deployment %v: %v", ns, err)
-	}
-	if len(podlist.Items) > 1 {
-		return fmt.Errorf("too many pods for deployment %v: %d", ns, len(podlist.Items))
+		return fmt.Errorf("error finding pods for deployment %v: %v", name, err)
 	}
-	pod := podlist.Items[0]
-	if pod.Status.Phase != corev1.PodRunning {
-		return fmt.Errorf("Istiod failure: pod %s is not running, it is %v", pod.Name, pod.Status.Phase)
+	running, terminating := runningPods(podlist.Items)
+	if len(running) == 0 {
+		return fmt.Errorf("%s failure: deployment %v has no running pods", component, name)
 	}
 
-	t.Logf("found istiod pod %s/%s to be %s", pod.Namespace, pod.Name, pod.Status.Phase)
+	for _, pod := range running {
+		t.Logf("found %s pod %s/%s to be %s", component, pod.Namespace, pod.Name, pod.Status.Phase)
+	}
+	if terminating != 0 {
+		t.Logf("ignoring %d terminating %s pods", terminating, component)
+	}
 	return nil
 }
 
@@ -493,11 +731,83 @@ func assertHttpRouteSuccessful(t *testing.T, namespace, name string, gateway *gw
 	return httproute, nil
 }
 
+// assertHttpRouteResolvedRefsFalse checks that the http route of the given name
+// reports ResolvedRefs=False with the given reason for every parent within 1
+// minute, and returns an error if not.
+func assertHttpRouteResolvedRefsFalse(t *testing.T, namespace, name string, reason gwapi.RouteConditionReason) error {
+	t.Helper()
+
+	httproute := &gwapi.HTTPRoute{}
+	nsName := types.NamespacedName{Namespace: namespace, Name: name}
+	var lastStatus string
+	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(context context.Context) (bool, error) {
+		if err := kclient.Get(context, nsName, httproute); err != nil {
+			t.Logf("failed to get httproute %s/%s, retrying...", namespace, name)
+			return false, nil
+		}
+		if len(httproute.Status.Parents) == 0 {
+			t.Logf("httpRoute %s/%s has no parent conditions, retrying...", namespace, name)
+			return false, nil
+		}
+		for _, parent := range httproute.Status.Parents {
+			found := false
+			for _, condition := range parent.Conditions {
+				if condition.Type != string(gwapi.RouteConditionResolvedRefs) {
+					continue
+				}
+				lastStatus = fmt.Sprintf("%s=%s, reason %s: %s", condition.Type, condition.Status, condition.Reason, condition.Message)
+				found = condition.Status == metav1.ConditionFalse && condition.Reason == string(reason)
+			}
+			if !found {
+				t.Logf("httpRoute %s/%s, parent %v/%v has status %q, expected %v=%v with reason %v, retrying...", namespace, name, parent.ParentRef.Namespace, parent.ParentRef.Name, lastStatus, gwapi.RouteConditionResolvedRefs, metav1.ConditionFalse, reason)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("httpRoute %s/%s did not report %v=%v with reason %v: %v, last recorded status: %s", namespace, name, gwapi.RouteConditionResolvedRefs, metav1.ConditionFalse, reason, err, lastStatus)
+	}
+	t.Logf("httpRoute %s/%s reports %v=%v with reason %v", namespace, name, gwapi.RouteConditionResolvedRefs, metav1.ConditionFalse, reason)
+	return nil
+}
+
+// assertHttpRouteRuleResponse checks that requests for the given path on the
+// given http route hostname get one of the expected status codes, and returns
+// an error if not.  This verifies the behavior of the individual rule that
+// matches the path.
+func assertHttpRouteRuleResponse(t *testing.T, hostname, path string, expectedStatusCodes ...int) error {
+	t.Helper()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	url := hostname + path
+	var lastStatusCode int
+	err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(context context.Context) (bool, error) {
+		statusCode, err := getHttpResponse(client, url)
+		if err != nil {
+			t.Logf("%v, retrying...", err)
+			return false, nil
+		}
+		lastStatusCode = statusCode
+		for _, expected := range expectedStatusCodes {
+			if statusCode == expected {
+				t.Logf("GET %s returned expected status %v", url, statusCode)
+				return true, nil
+			}
+		}
+		t.Logf("GET %s returned status %v, expected one of %v, retrying...", url, statusCode, expectedStatusCodes)
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("GET %s did not return one of %v: %v, last status: %v", url, expectedStatusCodes, err, lastStatusCode)
+	}
+	return nil
+}
+
 // assertHttpRouteConnection checks if the http route of the given name replies successfully,
 // and returns an error if not
 func assertHttpRouteConnection(t *testing.T, hostname string, gateway *gwapi.Gateway) error {
 	t.Helper()
-	domain := ""
 
 	// Create the http client to check the header.
 	client := &http.Client{
@@ -508,36 +818,51 @@ func assertHttpRouteConnection(t *testing.T, hostname string, gateway *gwapi.Gat
 	}
 
 	// Get gateway listener hostname to use for dnsRecord.
-	if len(gateway.Spec.Listeners) > 0 {
-		if gateway.Spec.Listeners[0].Hostname != nil && len(string(*gateway.Spec.Listeners[0].Hostname)) > 0 {
-			domain = string(*gateway.Spec.Listeners[0].Hostname)
-			if !strings.HasSuffix(domain, ".") {
-				domain = domain + "."
-			}
-		}
-	}
+	domain := gatewayListenerHostnameForHost(gateway, hostname)
 	// Obtain the standard formatting of the dnsRecord.
 	dnsRecordName := operatorcontroller.GatewayDNSRecordName(gateway, domain)
 
+	platform := clusterPlatformType()
+	budgets, err := dnsPropagationBudgetsForPlatform(platform)
+	if err != nil {
+		return err
+	}
+	var timings dnsPropagationTimings
+	if timings.gatewayProgrammed, err = waitForGatewayProgrammed(t, types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}); err != nil {
+		return err
+	}
+
 	// Make sure the DNSRecord is ready to use.
 	if err := assertDNSRecord(t, dnsRecordName); err != nil {
 		return err
 	}
+	dnsRecord := &v1.DNSRecord{}
+	if err := kclient.Get(context.Background(), dnsRecordName, dnsRecord); err != nil {
+		return fmt.Errorf("failed to get dnsrecord %s: %w", dnsRecordName, err)
+	}
+	timings.recordCreated = dnsRecord.CreationTimestamp.Time
+	timings.recordPublished = dnsRecordPublishedTime(dnsRecord)
 
 	// Wait and check that the dns name resolves first. Takes a long time, so
-	// if the hostname is actually an IP address, skip this.
+	// if the hostname is actually an IP address, skip this.  Poll for
+	// longer than the budget so that a slow resolution is reported with
+	// its duration.
 	if net.ParseIP(hostname) == nil {
-		if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Second, 5*time.Minute, false, func(context context.Context) (bool, error) {
+		if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, budgets.resolution+5*time.Minute, false, func(context context.Context) (bool, error) {
 			_, err := net.LookupHost(hostname)
 			if err != nil {
 				t.Logf("%v waiting for HTTP route name %s to resolve (%v)", time.Now(), hostname, err)
 				return false, nil
 			}
+			timings.firstResolved = time.Now()
 			return true, nil
 		}); err != nil {
 			t.Fatalf("HTTP route name %s was unable to be resolved: %v", hostname, err)
 		}
 	}
+	if err := timings.check(t, hostname, platform, budgets); err != nil {
+		return err
+	}
 
 	// Wait for http route to respond, and when it does, check for the status code.
 	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, false, func(context context.Context) (bool, error) {
@@ -575,12 +900,12 @@ func getHttpResponse(client *http.Client, name string) (int, error) {
 
 // assertCatalogSource checks if the CatalogSource of the given name exists,
 // and returns an error if not.
-func assertCatalogSource(t *testing.T, namespace, csName string) error {
+func assertCatalogSource(ctx context.Context, t *testing.T, namespace, csName string) error {
 	t.Helper()
 	catalogSource := &operatorsv1alpha1.CatalogSource{}
 	nsName := types.NamespacedName{Namespace: namespace, Name: csName}
 
-	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 30*time.Second, false, func(context context.Context) (bool, error) {
+	err := wait.PollUntilContextTimeout(ctx, 1*time.Second, 30*time.Second, false, func(context context.Context) (bool, error) {
 		if err := kclient.Get(context, nsName, catalogSource); err != nil {
 			t.Logf("failed to get catalogSource %s: %v, retrying...", csName, err)
 			return false, nil
@@ -597,12 +922,12 @@ func assertCatalogSource(t *testing.T, namespace, csName string) error {
 
 // assertSMCP checks if the ServiceMeshControlPlane exists in a ready state,
 // and returns an error if not.
-func assertSMCP(t *testing.T) error {
+func assertSMCP(ctx context.Context, t *testing.T) error {
 	t.Helper()
 	smcp := &maistrav2.ServiceMeshControlPlane{}
 	nsName := types.NamespacedName{Namespace: operatorcontroller.DefaultOperandNamespace, Name: openshiftSMCPName}
 
-	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 3*time.Minute, false, func(context context.Context) (bool, error) {
+	err := wait.PollUntilContextTimeout(ctx, 1*time.Second, 3*time.Minute, false, func(context context.Context) (bool, error) {
 		if err := kclient.Get(context, nsName, smcp); err != nil {
 			t.Logf("failed to get ServiceMeshControlPlane %s/%s: %v, retrying...", nsName.Namespace, nsName.Name, err)
 			return false, nil
@@ -625,6 +950,174 @@ func assertSMCP(t *testing.T) error {
 	return err
 }
 
+// ossmInstallationTimeout is the deadline that assertOSSMInstallation shares
+// among its checks.  Each check also has its own, shorter timeout.
+const ossmInstallationTimeout = 5 * time.Minute
+
+// assertOSSMInstallation concurrently checks that the OSSM Subscription and
+// CatalogSource are as expected, that the Istio operator and istiod are
+// running, and that the ServiceMeshControlPlane is ready.  The checks share a
+// deadline, which is the earlier of ossmInstallationTimeout and the test's
+// deadline.  If a check fails other than by timing out, the other checks are
+// canceled and that failure is returned.  Otherwise, the checks that timed out
+// are reported together.
+func assertOSSMInstallation(ctx context.Context, t *testing.T) error {
+	t.Helper()
+
+	deadline := time.Now().Add(ossmInstallationTimeout)
+	if testDeadline, ok := t.Deadline(); ok && testDeadline.Before(deadline) {
+		deadline = testDeadline
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	checks := []struct {
+		description string
+		check       func(context.Context) error
+	}{{
+		description: fmt.Sprintf("Subscription %s", expectedSubscriptionName),
+		check: func(ctx context.Context) error {
+			return assertSubscription(ctx, t, openshiftOperatorsNamespace, expectedSubscriptionName, expectedCatalogSourceNamespace, expectedCatalogSourceName, expectedSubscriptionChannel)
+		},
+	}, {
+		description: fmt.Sprintf("CatalogSource %s", expectedCatalogSourceName),
+		check: func(ctx context.Context) error {
+			return assertCatalogSource(ctx, t, expectedCatalogSourceNamespace, expectedCatalogSourceName)
+		},
+	}, {
+		description: "Istio operator",
+		check: func(ctx context.Context) error {
+			return assertOSSMOperator(ctx, t)
+		},
+	}, {
+		description: "Istiod control plane",
+		check: func(ctx context.Context) error {
+			return assertIstiodControlPlane(ctx, t)
+		},
+	}, {
+		// TODO - In OSSM 3.x the configuration object to check will be different.
+		description: "ServiceMeshControlPlane",
+		check: func(ctx context.Context) error {
+			return assertSMCP(ctx, t)
+		},
+	}}
+
+	var (
+		mu       sync.Mutex
+		timeouts []error
+	)
+	group, groupCtx := errgroup.WithContext(ctx)
+	for _, c := range checks {
+		group.Go(func() error {
+			err := c.check(groupCtx)
+			switch {
+			case err == nil:
+				return nil
+			case wait.Interrupted(err) && groupCtx.Err() != context.Canceled:
+				mu.Lock()
+				defer mu.Unlock()
+				timeouts = append(timeouts, fmt.Errorf("timed out waiting for %s: %w", c.description, err))
+				return nil
+			default:
+				return fmt.Errorf("failed to find expected %s: %w", c.description, err)
+			}
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+	return utilerrors.NewAggregate(timeouts)
+}
+
+// gatewayListenerHostnameForHost returns the hostname of the given gateway's
+// listener that matches the given host, or the hostname of the gateway's first
+// listener that has one if none matches.  A listener hostname matches the host
+// if they are equal or if the listener hostname is a wildcard and the host is
+// in its domain.
+func gatewayListenerHostnameForHost(gateway *gwapi.Gateway, host string) string {
+	host = strings.TrimSuffix(host, ".")
+	fallback := ""
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Hostname == nil || len(*listener.Hostname) == 0 {
+			continue
+		}
+		listenerHostname := strings.TrimSuffix(string(*listener.Hostname), ".")
+		if len(fallback) == 0 {
+			fallback = listenerHostname
+		}
+		if listenerHostname == host {
+			return listenerHostname
+		}
+		if strings.HasPrefix(listenerHostname, "*.") && strings.HasSuffix(host, listenerHostname[1:]) {
+			return listenerHostname
+		}
+	}
+	return fallback
+}
+
+// assertGatewayDNSRecords checks that the given gateway has a published
+// DNSRecord for each unique listener hostname and that it has no DNSRecords
+// for any other hostnames, such as stale DNSRecords for listeners that have
+// been removed.  All listener hostnames are expected to be publishable.
+// Returns an error if the DNSRecords are not as expected within the timeout.
+func assertGatewayDNSRecords(t *testing.T, gateway *gwapi.Gateway) error {
+	t.Helper()
+
+	expected := map[string]types.NamespacedName{}
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Hostname == nil || len(*listener.Hostname) == 0 {
+			continue
+		}
+		hostname := string(*listener.Hostname)
+		if !strings.HasSuffix(hostname, ".") {
+			hostname = hostname + "."
+		}
+		expected[hostname] = operatorcontroller.GatewayDNSRecordName(gateway, hostname)
+	}
+	for hostname, recordName := range expected {
+		if err := assertDNSRecord(t, recordName); err != nil {
+			return fmt.Errorf("dnsrecord %s for hostname %s was not published: %w", recordName, hostname, err)
+		}
+		dnsRecord := &v1.DNSRecord{}
+		if err := kclient.Get(context.Background(), recordName, dnsRecord); err != nil {
+			return fmt.Errorf("failed to get dnsrecord %s: %w", recordName, err)
+		}
+		if dnsRecord.Spec.DNSName != hostname {
+			return fmt.Errorf("expected dnsrecord %s to have dnsName %q, got %q", recordName, hostname, dnsRecord.Spec.DNSName)
+		}
+	}
+
+	// Stale DNSRecords are deleted asynchronously, so poll until only the
+	// expected ones remain.
+	listOpts := []client.ListOption{
+		client.MatchingLabels{"istio.io/gateway-name": gateway.Name},
+		client.InNamespace(gateway.Namespace),
+	}
+	var unexpected []string
+	err := wait.PollUntilContextTimeout(context.Background(), 1*time.Second, 1*time.Minute, false, func(context context.Context) (bool, error) {
+		var dnsRecords v1.DNSRecordList
+		if err := kclient.List(context, &dnsRecords, listOpts...); err != nil {
+			t.Logf("failed to list dnsrecords for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, err)
+			return false, nil
+		}
+		unexpected = nil
+		for _, dnsRecord := range dnsRecords.Items {
+			if recordName, ok := expected[dnsRecord.Spec.DNSName]; !ok || recordName.Name != dnsRecord.Name {
+				unexpected = append(unexpected, fmt.Sprintf("%s (%s)", dnsRecord.Name, dnsRecord.Spec.DNSName))
+			}
+		}
+		if len(unexpected) != 0 {
+			t.Logf("found unexpected dnsrecords for gateway %s/%s: %v, retrying...", gateway.Namespace, gateway.Name, unexpected)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("gateway %s/%s has unexpected dnsrecords %v: %w", gateway.Namespace, gateway.Name, unexpected, err)
+	}
+	return nil
+}
+
 // assertDNSRecord checks to make sure a DNSRecord exists in a ready state,
 // and returns an error if not.
 func assertDNSRecord(t *testing.T, recordName types.NamespacedName) error {
@@ -0,0 +1,189 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/dnsrecord"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ingressControllerReadyTimeout is how long awaitIngressControllerReady waits
+// for an ingresscontroller to report the expected status conditions.
+const ingressControllerReadyTimeout = 5 * time.Minute
+
+// ingressControllerOption mutates an ingresscontroller that is being built by
+// newIngressController.
+type ingressControllerOption func(*operatorv1.IngressController)
+
+// withReplicas sets spec.replicas on the ingresscontroller.
+func withReplicas(replicas int32) ingressControllerOption {
+	return func(ic *operatorv1.IngressController) {
+		ic.Spec.Replicas = &replicas
+	}
+}
+
+// withEndpointPublishingStrategy sets spec.endpointPublishingStrategy on the
+// ingresscontroller.
+func withEndpointPublishingStrategy(strategy *operatorv1.EndpointPublishingStrategy) ingressControllerOption {
+	return func(ic *operatorv1.IngressController) {
+		ic.Spec.EndpointPublishingStrategy = strategy
+	}
+}
+
+// withEndpointPublishingStrategyType sets spec.endpointPublishingStrategy on
+// the ingresscontroller to the given strategy type with default parameters.
+func withEndpointPublishingStrategyType(strategyType operatorv1.EndpointPublishingStrategyType) ingressControllerOption {
+	return withEndpointPublishingStrategy(&operatorv1.EndpointPublishingStrategy{Type: strategyType})
+}
+
+// withNodePlacement sets spec.nodePlacement on the ingresscontroller.
+func withNodePlacement(placement *operatorv1.NodePlacement) ingressControllerOption {
+	return func(ic *operatorv1.IngressController) {
+		ic.Spec.NodePlacement = placement
+	}
+}
+
+// withLogging sets spec.logging on the ingresscontroller.
+func withLogging(logging *operatorv1.IngressControllerLogging) ingressControllerOption {
+	return func(ic *operatorv1.IngressController) {
+		ic.Spec.Logging = logging
+	}
+}
+
+// withTuningOptions sets spec.tuningOptions on the ingresscontroller.
+func withTuningOptions(tuningOptions operatorv1.IngressControllerTuningOptions) ingressControllerOption {
+	return func(ic *operatorv1.IngressController) {
+		ic.Spec.TuningOptions = tuningOptions
+	}
+}
+
+// newIngressController returns an ingresscontroller with the given name and
+// domain, one replica, and the "Private" endpoint publishing strategy, with
+// the given options applied.
+func newIngressController(name types.NamespacedName, domain string, opts ...ingressControllerOption) *operatorv1.IngressController {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+		},
+		Spec: operatorv1.IngressControllerSpec{
+			Domain: domain,
+		},
+	}
+	withReplicas(1)(ic)
+	withEndpointPublishingStrategyType(operatorv1.PrivateStrategyType)(ic)
+	for _, opt := range opts {
+		opt(ic)
+	}
+	return ic
+}
+
+func newLoadBalancerController(name types.NamespacedName, domain string) *operatorv1.IngressController {
+	return newIngressController(name, domain, withEndpointPublishingStrategyType(operatorv1.LoadBalancerServiceStrategyType))
+}
+
+func newNodePortController(name types.NamespacedName, domain string) *operatorv1.IngressController {
+	return newIngressController(name, domain, withEndpointPublishingStrategyType(operatorv1.NodePortServiceStrategyType))
+}
+
+func newHostNetworkController(name types.NamespacedName, domain string) *operatorv1.IngressController {
+	return newIngressController(name, domain, withEndpointPublishingStrategyType(operatorv1.HostNetworkStrategyType))
+}
+
+func newPrivateController(name types.NamespacedName, domain string) *operatorv1.IngressController {
+	return newIngressController(name, domain)
+}
+
+// readyConditionsForIngressController returns the status conditions that the
+// given ingresscontroller is expected to have once it is fully ready.  The
+// conditions are derived from the ingresscontroller's spec and the cluster DNS
+// config: the load balancer conditions are only expected to be true for the
+// "LoadBalancerService" strategy, DNSManaged only if the cluster has DNS zones
+// and the ingresscontroller does not opt out of DNS management, and DNSReady
+// only if, in addition, the ingresscontroller's domain is in the cluster's
+// base domain.  If the spec does not specify an endpoint publishing strategy,
+// the strategy that the operator chose for the platform is used.
+func readyConditionsForIngressController(ic *operatorv1.IngressController) []operatorv1.OperatorCondition {
+	conditions := []operatorv1.OperatorCondition{
+		{Type: ingresscontroller.IngressControllerAdmittedConditionType, Status: operatorv1.ConditionTrue},
+		{Type: operatorv1.IngressControllerAvailableConditionType, Status: operatorv1.ConditionTrue},
+		{Type: ingresscontroller.IngressControllerDeploymentAvailableConditionType, Status: operatorv1.ConditionTrue},
+	}
+
+	strategy := ic.Spec.EndpointPublishingStrategy
+	if strategy == nil {
+		strategy = ic.Status.EndpointPublishingStrategy
+	}
+	if strategy == nil || strategy.Type != operatorv1.LoadBalancerServiceStrategyType {
+		return append(conditions,
+			operatorv1.OperatorCondition{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionFalse},
+			operatorv1.OperatorCondition{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
+		)
+	}
+
+	conditions = append(conditions,
+		operatorv1.OperatorCondition{Type: operatorv1.LoadBalancerManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+		operatorv1.OperatorCondition{Type: operatorv1.LoadBalancerReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+	)
+	switch {
+	case dnsConfig.Spec.PublicZone == nil && dnsConfig.Spec.PrivateZone == nil,
+		strategy.LoadBalancer != nil && strategy.LoadBalancer.DNSManagementPolicy == operatorv1.UnmanagedLoadBalancerDNS,
+		ingresscontroller.CDNOriginEnabled(ic):
+		return append(conditions,
+			operatorv1.OperatorCondition{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionFalse},
+		)
+	}
+	conditions = append(conditions,
+		operatorv1.OperatorCondition{Type: operatorv1.DNSManagedIngressConditionType, Status: operatorv1.ConditionTrue},
+	)
+	if dnsrecord.ManageDNSForDomain(ic.Spec.Domain, infraConfig.Status.PlatformStatus, &dnsConfig) {
+		conditions = append(conditions,
+			operatorv1.OperatorCondition{Type: operatorv1.DNSReadyIngressConditionType, Status: operatorv1.ConditionTrue},
+		)
+	}
+	return conditions
+}
+
+// awaitIngressControllerReady waits for the named ingresscontroller to report
+// the status conditions that readyConditionsForIngressController returns for
+// it.  On timeout, the ingresscontroller's status is logged and the test is
+// marked as failed, and the error is returned so that the caller can stop the
+// test using Fatal if appropriate.
+func awaitIngressControllerReady(t *testing.T, name types.NamespacedName) error {
+	t.Helper()
+
+	ic, err := getIngressController(t, kclient, name, 1*time.Minute)
+	if err != nil {
+		t.Errorf("failed to get ingresscontroller %s: %v", name, err)
+		return err
+	}
+	return waitForIngressControllerCondition(t, kclient, ingressControllerReadyTimeout, name, readyConditionsForIngressController(ic)...)
+}
+
+// createIngressControllerAndAwaitReady creates the given ingresscontroller and
+// waits for it to be ready.  The test is stopped if either step fails.  The
+// caller is responsible for deleting the ingresscontroller and should defer
+// assertIngressControllerDeleted before calling
+// createIngressControllerAndAwaitReady, which is safe even if the
+// ingresscontroller was never created.
+func createIngressControllerAndAwaitReady(t *testing.T, ic *operatorv1.IngressController) {
+	t.Helper()
+
+	name := types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller %s: %v", name, err)
+	}
+	if err := awaitIngressControllerReady(t, name); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/pkg/resources/ensure"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -28,7 +29,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"k8s.io/client-go/kubernetes"
@@ -39,9 +39,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-// buildEchoPod returns a pod definition for an socat-based echo server.
-func buildEchoPod(name, namespace string) *corev1.Pod {
-	return &corev1.Pod{
+// ensureResource creates the desired object or, if it already exists, gets the
+// existing object into current and updates it if changed is not nil and reports
+// that the existing object differs from the desired object.  The resulting
+// object is returned.  See ensure.CreateOrUpdate.
+func ensureResource[T client.Object](desired, current T, changed ensure.ChangedFunc[T]) (T, error) {
+	return ensure.CreateOrUpdate(context.TODO(), kclient, desired, current, changed)
+}
+
+// buildEchoPod returns a pod definition for an echo server.  By default, the
+// echo server is socat-based and serves plaintext HTTP on port 8080; the given
+// options can specify TLS, HTTP/2, other ports, and the echo test server, which
+// identifies the pod and describes the request in its response.
+func buildEchoPod(name, namespace string, opts ...echoOption) *corev1.Pod {
+	config := newEchoConfig(opts)
+	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
 				"app": name,
@@ -60,12 +72,7 @@ func buildEchoPod(name, namespace string) *corev1.Pod {
 					Command: []string{"/bin/socat"},
 					Image:   "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest",
 					Name:    "echo",
-					Ports: []corev1.ContainerPort{
-						{
-							ContainerPort: int32(8080),
-							Protocol:      corev1.ProtocolTCP,
-						},
-					},
+					Ports:   config.echoContainerPorts(),
 					SecurityContext: &corev1.SecurityContext{
 						AllowPrivilegeEscalation: pointer.Bool(false),
 						Capabilities: &corev1.Capabilities{
@@ -80,6 +87,17 @@ func buildEchoPod(name, namespace string) *corev1.Pod {
 			},
 		},
 	}
+	container := &pod.Spec.Containers[0]
+	switch {
+	case len(config.image) != 0:
+		config.configureEchoTestServer(container)
+	case len(config.httpsPorts) != 0:
+		config.configureEchoTLS(container)
+	}
+	if len(config.tlsSecretName) != 0 {
+		config.mountEchoServingCert(pod)
+	}
+	return pod
 }
 
 // generateUnprivilegedSecurityContext returns a SecurityContext with the minimum possible privileges that satisfy
@@ -111,22 +129,18 @@ func waitForHTTPClientCondition(t *testing.T, httpClient *http.Client, req *http
 	})
 }
 
-// buildEchoService returns a service definition for an HTTP service.
-func buildEchoService(name, namespace string, labels map[string]string) *corev1.Service {
+// buildEchoService returns a service definition for an echo server.  The given
+// options must be the ones that were given to buildEchoPod so that the
+// service's ports match the echo server's.  By default, the service exposes
+// port 80 for plaintext HTTP.
+func buildEchoService(name, namespace string, labels map[string]string, opts ...echoOption) *corev1.Service {
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
 		},
 		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Name:       "http",
-					Port:       int32(80),
-					Protocol:   corev1.ProtocolTCP,
-					TargetPort: intstr.FromInt(8080),
-				},
-			},
+			Ports:    newEchoConfig(opts).echoServicePorts(),
 			Selector: labels,
 		},
 	}
@@ -0,0 +1,450 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// protocolSmokeTestCase is a set of shard-level configuration knobs with which
+// TestWebSocketAndGRPCWithTuningOptions verifies WebSocket and gRPC traffic.
+type protocolSmokeTestCase struct {
+	name string
+	// enableHTTP2 specifies whether HTTP/2 is enabled on the
+	// ingresscontroller.  gRPC requires HTTP/2, so gRPC is only verified
+	// if HTTP/2 is enabled.
+	enableHTTP2     bool
+	tuningOptions   operatorv1.IngressControllerTuningOptions
+	httpCompression operatorv1.HTTPCompressionPolicy
+	// websocketIdle is how long the WebSocket client waits between
+	// messages.  Setting it longer than the client and server timeouts
+	// verifies that the tunnel timeout rather than those timeouts applies
+	// to upgraded connections.
+	websocketIdle time.Duration
+}
+
+// TestWebSocketAndGRPCWithTuningOptions verifies that WebSocket connections and
+// gRPC unary and streaming calls work through a shard after applying a set of
+// representative tuning options, HTTP/2 settings, and compression settings.
+// WebSocket traffic goes through an edge-terminated route, and gRPC traffic
+// goes through both a reencrypt route to a TLS backend and an edge-terminated
+// route to an h2c backend.  If the test fails, the router deployment's
+// environment variables are logged.
+func TestWebSocketAndGRPCWithTuningOptions(t *testing.T) {
+	t.Parallel()
+
+	operatorImage, err := getIngressOperatorDeploymentImage(t, kclient, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingress operator image: %v", err)
+	}
+
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "websocket-grpc"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newLoadBalancerController(icName, domain)
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	createIngressControllerAndAwaitReady(t, ic)
+	t.Cleanup(func() {
+		if t.Failed() {
+			logRouterEnvironment(t, ic)
+		}
+	})
+
+	ns := createNamespace(t, "websocket-grpc-e2e")
+	backends := []*corev1.Pod{
+		buildWebSocketEchoPod("websocket-echo", ns.Name, operatorImage),
+		buildGRPCInteropPod("grpc-interop", ns.Name, operatorImage),
+	}
+	for _, pod := range backends {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	websocketService := buildEchoService("websocket-echo", ns.Name, backends[0].Labels)
+	grpcService := buildGRPCInteropService("grpc-interop", ns.Name, backends[1].Labels)
+	for _, service := range []*corev1.Service{websocketService, grpcService} {
+		if err := kclient.Create(context.TODO(), service); err != nil {
+			t.Fatalf("failed to create service %s/%s: %v", service.Namespace, service.Name, err)
+		}
+	}
+	for _, pod := range backends {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	websocketHost := "websocket." + domain
+	grpcReencryptHost := "grpc-reencrypt." + domain
+	grpcEdgeHost := "grpc-edge." + domain
+	routes := []*routev1.Route{
+		buildRouteWithTermination("websocket", ns.Name, websocketService.Name, websocketHost, "http", routev1.TLSTerminationEdge),
+		buildRouteWithTermination("grpc-reencrypt", ns.Name, grpcService.Name, grpcReencryptHost, "h2", routev1.TLSTerminationReencrypt),
+		buildRouteWithTermination("grpc-edge", ns.Name, grpcService.Name, grpcEdgeHost, "h2c", routev1.TLSTerminationEdge),
+	}
+	for _, route := range routes {
+		if err := kclient.Create(context.TODO(), route); err != nil {
+			t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+		}
+	}
+
+	lbAddress := getIngressControllerLBAddress(t, ic)
+
+	testCases := []protocolSmokeTestCase{{
+		name:        "defaults with HTTP/2",
+		enableHTTP2: true,
+	}, {
+		name:        "short client and server timeouts",
+		enableHTTP2: true,
+		tuningOptions: operatorv1.IngressControllerTuningOptions{
+			ClientTimeout:    &metav1.Duration{Duration: 5 * time.Second},
+			ServerTimeout:    &metav1.Duration{Duration: 5 * time.Second},
+			ClientFinTimeout: &metav1.Duration{Duration: 2 * time.Second},
+			ServerFinTimeout: &metav1.Duration{Duration: 2 * time.Second},
+			TunnelTimeout:    &metav1.Duration{Duration: 1 * time.Hour},
+		},
+		websocketIdle: 10 * time.Second,
+	}, {
+		name:        "HTTP/2 disabled",
+		enableHTTP2: false,
+	}, {
+		name:        "compression",
+		enableHTTP2: true,
+		httpCompression: operatorv1.HTTPCompressionPolicy{
+			MimeTypes: []operatorv1.CompressionMIMEType{"text/plain", "application/json"},
+		},
+	}}
+	for _, tc := range testCases {
+		t.Logf("applying configuration %q", tc.name)
+		if err := updateIngressControllerWithRetryOnConflict(t, icName, 1*time.Minute, func(ic *operatorv1.IngressController) {
+			if ic.Annotations == nil {
+				ic.Annotations = map[string]string{}
+			}
+			ic.Annotations[ingresscontroller.RouterDefaultEnableHTTP2Annotation] = fmt.Sprint(tc.enableHTTP2)
+			ic.Spec.TuningOptions = tc.tuningOptions
+			ic.Spec.HTTPCompression = tc.httpCompression
+		}); err != nil {
+			t.Fatalf("failed to update ingresscontroller %s: %v", icName, err)
+		}
+		if err := waitForRouterDeploymentHTTP2Enabled(t, kclient, 1*time.Minute, ic, tc.enableHTTP2); err != nil {
+			t.Fatalf("failed to observe HTTP/2 enabled=%t in router deployment for %q: %v", tc.enableHTTP2, tc.name, err)
+		}
+		if err := waitForDeploymentCompleteWithOldPodTermination(t, kclient, controller.RouterDeploymentName(ic), 5*time.Minute); err != nil {
+			t.Fatalf("failed to observe router deployment rollout for %q: %v", tc.name, err)
+		}
+
+		if err := assertWebSocketRoundTrip(t, lbAddress, websocketHost, tc.websocketIdle); err != nil {
+			t.Errorf("%s: websocket round trip failed: %v", tc.name, err)
+		}
+		if !tc.enableHTTP2 {
+			t.Logf("%s: skipping gRPC checks because HTTP/2 is disabled", tc.name)
+			continue
+		}
+		for _, host := range []string{grpcReencryptHost, grpcEdgeHost} {
+			if err := assertGRPCUnaryAndStreaming(t, lbAddress, host); err != nil {
+				t.Errorf("%s: gRPC calls to %s failed: %v", tc.name, host, err)
+			}
+		}
+	}
+}
+
+// buildWebSocketEchoPod returns a pod that runs the WebSocket echo test server
+// from the given ingress operator image.
+func buildWebSocketEchoPod(name, namespace, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    map[string]string{"app": name},
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "echo",
+				Image: image,
+				Args:  []string{"serve-websocket-test-server"},
+				Ports: []corev1.ContainerPort{{
+					ContainerPort: int32(8080),
+					Protocol:      corev1.ProtocolTCP,
+				}},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+					},
+				},
+				SecurityContext: generateUnprivilegedSecurityContext(),
+			}},
+		},
+	}
+}
+
+// buildGRPCInteropPod returns a pod that runs the gRPC interoperability test
+// server from the given ingress operator image, serving h2 on port 8443 using
+// the serving certificate for the service that buildGRPCInteropService returns
+// and h2c on port 1110.
+func buildGRPCInteropPod(name, namespace, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    map[string]string{"app": name},
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "server",
+				Image: image,
+				Args:  []string{"serve-grpc-test-server"},
+				Ports: []corev1.ContainerPort{
+					{ContainerPort: int32(8443), Protocol: corev1.ProtocolTCP},
+					{ContainerPort: int32(1110), Protocol: corev1.ProtocolTCP},
+					{ContainerPort: int32(8080), Protocol: corev1.ProtocolTCP},
+				},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "serving-cert",
+					MountPath: "/etc/serving-cert",
+					ReadOnly:  true,
+				}},
+				SecurityContext: generateUnprivilegedSecurityContext(),
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "serving-cert",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: name + "-cert"},
+				},
+			}},
+		},
+	}
+}
+
+// buildGRPCInteropService returns a service for the gRPC interoperability test
+// server with an "h2" port for TLS and an "h2c" port for cleartext HTTP/2.  The
+// service requests a serving certificate from the service CA so that the
+// router can verify the server's certificate for reencrypt routes.
+func buildGRPCInteropService(name, namespace string, labels map[string]string) *corev1.Service {
+	h2c := "h2c"
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				"service.beta.openshift.io/serving-cert-secret-name": name + "-cert",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{
+				Name:       "h2",
+				Port:       int32(8443),
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromInt(8443),
+			}, {
+				Name:        "h2c",
+				Port:        int32(1110),
+				Protocol:    corev1.ProtocolTCP,
+				TargetPort:  intstr.FromInt(1110),
+				AppProtocol: &h2c,
+			}},
+			Selector: labels,
+		},
+	}
+}
+
+// buildRouteWithTermination returns a route with the given host and TLS
+// termination that targets the given port of the given service.
+func buildRouteWithTermination(name, namespace, serviceName, host, targetPort string, termination routev1.TLSTerminationType) *routev1.Route {
+	route := buildRouteWithHost(name, namespace, serviceName, host)
+	route.Spec.Port = &routev1.RoutePort{TargetPort: intstr.FromString(targetPort)}
+	route.Spec.TLS = &routev1.TLSConfig{Termination: termination}
+	return route
+}
+
+// dialThroughLoadBalancer returns a dial function that connects to the given
+// load balancer address on port 443 regardless of the requested address, so
+// that clients can use a route's host name before DNS for it resolves.
+func dialThroughLoadBalancer(lbAddress string) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, net.JoinHostPort(lbAddress, "443"))
+	}
+}
+
+// assertWebSocketRoundTrip opens a WebSocket connection to the echo server
+// through the given route host and verifies that messages are echoed back,
+// waiting for the given idle duration between messages.  Establishing the
+// connection is retried until the route is admitted and the load balancer is
+// reachable.
+func assertWebSocketRoundTrip(t *testing.T, lbAddress, host string, idle time.Duration) error {
+	t.Helper()
+
+	dialer := &websocket.Dialer{
+		NetDialContext:   dialThroughLoadBalancer(lbAddress),
+		TLSClientConfig:  &tls.Config{ServerName: host, InsecureSkipVerify: true},
+		HandshakeTimeout: 10 * time.Second,
+	}
+	url := "wss://" + host + "/echo"
+	var conn *websocket.Conn
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		c, resp, err := dialer.DialContext(ctx, url, nil)
+		if err != nil {
+			status := "none"
+			if resp != nil {
+				status = resp.Status
+			}
+			t.Logf("failed to dial %s (response status: %s): %v, retrying...", url, status, err)
+			return false, nil
+		}
+		conn = c
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("failed to establish websocket connection to %s: %w", url, err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 3; i++ {
+		if i > 0 && idle > 0 {
+			time.Sleep(idle)
+		}
+		message := []byte(fmt.Sprintf("message %d to %s", i, host))
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			return fmt.Errorf("failed to write message %d: %w", i, err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			return err
+		}
+		_, reply, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("failed to read reply to message %d: %w", i, err)
+		}
+		if !bytes.Equal(reply, message) {
+			return fmt.Errorf("expected reply %q to message %d, got %q", message, i, reply)
+		}
+	}
+	t.Logf("websocket round trip through %s succeeded", host)
+	return nil
+}
+
+// assertGRPCUnaryAndStreaming makes a unary call and a bidirectional
+// streaming call to the gRPC interoperability test server through the given
+// route host.  The unary call is retried until the route is admitted and the
+// load balancer is reachable.
+func assertGRPCUnaryAndStreaming(t *testing.T, lbAddress, host string) error {
+	t.Helper()
+
+	conn, err := grpc.Dial(host+":443",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialThroughLoadBalancer(lbAddress)(ctx, "tcp", addr)
+		}),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{ServerName: host, InsecureSkipVerify: true})),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client connection: %w", err)
+	}
+	defer conn.Close()
+	client := testpb.NewTestServiceClient(conn)
+
+	const payloadSize = 1024
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		callCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		resp, err := client.UnaryCall(callCtx, &testpb.SimpleRequest{
+			ResponseSize: payloadSize,
+			Payload:      &testpb.Payload{Body: make([]byte, payloadSize)},
+		})
+		if err != nil {
+			t.Logf("unary call to %s failed: %v, retrying...", host, err)
+			return false, nil
+		}
+		if size := len(resp.GetPayload().GetBody()); size != payloadSize {
+			return false, fmt.Errorf("expected unary response payload of %d bytes, got %d", payloadSize, size)
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("unary call failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stream, err := client.FullDuplexCall(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start streaming call: %w", err)
+	}
+	responseSizes := []int32{31415, 9, 2653, 58979}
+	for i, size := range responseSizes {
+		if err := stream.Send(&testpb.StreamingOutputCallRequest{
+			ResponseParameters: []*testpb.ResponseParameters{{Size: size}},
+			Payload:            &testpb.Payload{Body: make([]byte, size)},
+		}); err != nil {
+			return fmt.Errorf("failed to send streaming request %d: %w", i, err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("failed to receive streaming response %d: %w", i, err)
+		}
+		if got := len(resp.GetPayload().GetBody()); got != int(size) {
+			return fmt.Errorf("expected streaming response %d with payload of %d bytes, got %d", i, size, got)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("failed to close streaming call: %w", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		return fmt.Errorf("expected streaming call to end with EOF, got %v", err)
+	}
+	t.Logf("gRPC unary and streaming calls through %s succeeded", host)
+	return nil
+}
+
+// logRouterEnvironment logs the environment variables of the router container
+// in the given ingresscontroller's deployment, which is useful for diagnosing
+// failures that depend on the effective router configuration.
+func logRouterEnvironment(t *testing.T, ic *operatorv1.IngressController) {
+	t.Helper()
+
+	deployment := &appsv1.Deployment{}
+	name := controller.RouterDeploymentName(ic)
+	if err := kclient.Get(context.TODO(), name, deployment); err != nil {
+		t.Logf("failed to get router deployment %s: %v", name, err)
+		return
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "router" {
+			continue
+		}
+		t.Logf("effective environment of router deployment %s:", name)
+		for _, env := range container.Env {
+			if env.ValueFrom != nil {
+				t.Logf("  %s=<from %s>", env.Name, env.ValueFrom.String())
+				continue
+			}
+			t.Logf("  %s=%s", env.Name, env.Value)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
+	"github.com/openshift/cluster-ingress-operator/test/echo"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// zoneAwareRoutingRequests is the number of requests that
+// TestZoneAwareRouting sends to measure the fraction of requests that same-zone
+// backends serve.
+const zoneAwareRoutingRequests = 100
+
+// TestZoneAwareRouting verifies that the router of an ingresscontroller that
+// enables the "zoneAwareRouting" unsupported config override sends more than
+// 80% of the requests from a client in the router's zone to backends in the
+// same zone when the route's service has backends in multiple zones, that the
+// operator labels the router pod with its zone, and that the router falls back
+// to backends in other zones when its zone has no ready backends.  The test is
+// skipped on clusters whose worker nodes are not in at least two zones.
+func TestZoneAwareRouting(t *testing.T) {
+	t.Parallel()
+
+	nodes := &corev1.NodeList{}
+	if err := kclient.List(context.TODO(), nodes, client.HasLabels{"node-role.kubernetes.io/worker"}); err != nil {
+		t.Fatalf("failed to list worker nodes: %v", err)
+	}
+	zoneSet := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		if zone := node.Labels[corev1.LabelTopologyZone]; len(zone) != 0 && !node.Spec.Unschedulable {
+			zoneSet[zone] = struct{}{}
+		}
+	}
+	if len(zoneSet) < 2 {
+		t.Skipf("test requires worker nodes in at least 2 zones, found %d", len(zoneSet))
+	}
+	var zones []string
+	for zone := range zoneSet {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	localZone, remoteZone := zones[0], zones[1]
+	t.Logf("using local zone %q and remote zone %q", localZone, remoteZone)
+
+	operatorImage, err := getIngressOperatorDeploymentImage(t, kclient, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get ingress operator image: %v", err)
+	}
+
+	// Pin the router to the local zone so that the client, which is in
+	// the local zone too, only reaches a router in its own zone.
+	icName := types.NamespacedName{Namespace: operatorNamespace, Name: "zone-aware-routing"}
+	domain := icName.Name + "." + dnsConfig.Spec.BaseDomain
+	ic := newPrivateController(icName, domain)
+	ic.Spec.NodePlacement = &operatorv1.NodePlacement{
+		NodeSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"node-role.kubernetes.io/worker": "",
+				corev1.LabelTopologyZone:         localZone,
+			},
+		},
+	}
+	ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(`{"zoneAwareRouting":{}}`)}
+	if err := kclient.Create(context.TODO(), ic); err != nil {
+		t.Fatalf("failed to create ingresscontroller: %v", err)
+	}
+	defer assertIngressControllerDeleted(t, kclient, ic)
+	if err := waitForIngressControllerCondition(t, kclient, 5*time.Minute, icName, availableConditionsForPrivateIngressController...); err != nil {
+		t.Fatalf("failed to observe expected conditions: %v", err)
+	}
+	deployment, err := getDeployment(t, kclient, controller.RouterDeploymentName(ic), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if err := waitForDeploymentEnvVar(t, kclient, deployment, 1*time.Minute, "ROUTER_ZONE_AWARE_ROUTING", "true"); err != nil {
+		t.Fatalf("expected deployment to enable zone-aware routing: %v", err)
+	}
+	if err := waitForDeploymentComplete(t, kclient, deployment, 3*time.Minute); err != nil {
+		t.Fatalf("failed to observe the router deployment complete: %v", err)
+	}
+
+	// Verify that the operator labels the router pods with their zone.
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		t.Fatalf("deployment has invalid selector: %v", err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		pods := &corev1.PodList{}
+		if err := kclient.List(ctx, pods, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			t.Logf("failed to list router pods: %v, retrying...", err)
+			return false, nil
+		}
+		if len(pods.Items) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			if zone := pod.Labels[corev1.LabelTopologyZone]; zone != localZone {
+				t.Logf("router pod %s has zone label %q, expected %q, retrying...", pod.Name, zone, localZone)
+				return false, nil
+			}
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe router pods labeled with zone %q: %v", localZone, err)
+	}
+
+	// Create one backend in each zone behind the same service, and a
+	// client in the local zone.
+	ns := createNamespace(t, "zone-aware-routing-e2e")
+	echoOpts := []echoOption{withEchoServerImage(operatorImage)}
+	backendLabels := map[string]string{"app": "zone-aware-echo"}
+	var backends []*corev1.Pod
+	for _, zone := range []string{localZone, remoteZone} {
+		pod := buildEchoPod("echo-"+zoneSuffix(zone), ns.Name, echoOpts...)
+		pod.Labels = backendLabels
+		pod.Spec.NodeSelector = map[string]string{corev1.LabelTopologyZone: zone}
+		backends = append(backends, pod)
+	}
+	localBackend := backends[0]
+	clientPod := buildExecPod("zone-client", ns.Name, "image-registry.openshift-image-registry.svc:5000/openshift/tools:latest")
+	clientPod.Spec.NodeSelector = map[string]string{corev1.LabelTopologyZone: localZone}
+	for _, pod := range append(backends, clientPod) {
+		if err := kclient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	echoService := buildEchoService("zone-aware-echo", ns.Name, backendLabels, echoOpts...)
+	if err := kclient.Create(context.TODO(), echoService); err != nil {
+		t.Fatalf("failed to create service %s/%s: %v", echoService.Namespace, echoService.Name, err)
+	}
+	for _, pod := range append(backends, clientPod) {
+		if err := waitForPodReady(t, kclient, pod, 5*time.Minute); err != nil {
+			t.Fatalf("failed to wait for pod %s/%s to become ready: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	routeHost := "echo." + domain
+	route := buildRoute("echo", ns.Name, echoService.Name)
+	route.Spec.Host = routeHost
+	if err := kclient.Create(context.TODO(), route); err != nil {
+		t.Fatalf("failed to create route %s/%s: %v", route.Namespace, route.Name, err)
+	}
+
+	internalService := &corev1.Service{}
+	if err := kclient.Get(context.TODO(), controller.InternalIngressControllerServiceName(ic), internalService); err != nil {
+		t.Fatalf("failed to get service %s: %v", controller.InternalIngressControllerServiceName(ic), err)
+	}
+	// Print the name of the pod that serves each request, or nothing if
+	// the request fails.
+	cmd := []string{"/bin/bash", "-c", fmt.Sprintf(
+		`for i in $(seq %d); do curl -s -o /dev/null -D - --max-time 5 -H 'Host: %s' http://%s/ | sed -n 's/^%s: *//Ip' | tr -d '\r'; done`,
+		zoneAwareRoutingRequests, routeHost, internalService.Spec.ClusterIP, echo.PodNameHeader,
+	)}
+	servedBy := func(t *testing.T) map[string]int {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := podExec(t, *clientPod, &stdout, &stderr, cmd); err != nil {
+			t.Logf("failed to send requests: %v: %s", err, stderr.String())
+			return nil
+		}
+		counts := map[string]int{}
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if name := strings.TrimSpace(line); len(name) != 0 {
+				counts[name]++
+			}
+		}
+		return counts
+	}
+
+	// Wait for the router to load the route and for the endpoints to be
+	// ready, and then verify that the local backend serves more than 80%
+	// of the requests.
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		counts := servedBy(t)
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+		if total < zoneAwareRoutingRequests {
+			t.Logf("got %d of %d responses, retrying...", total, zoneAwareRoutingRequests)
+			return false, nil
+		}
+		local := counts[localBackend.Name]
+		if local*100 <= total*80 {
+			t.Logf("same-zone backend served %d of %d requests (%v), retrying...", local, total, counts)
+			return false, nil
+		}
+		t.Logf("same-zone backend served %d of %d requests", local, total)
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe more than 80%% of requests served by the same-zone backend: %v", err)
+	}
+
+	// Delete the local backend and verify that the router falls back to
+	// the backend in the remote zone.
+	if err := kclient.Delete(context.TODO(), localBackend); err != nil {
+		t.Fatalf("failed to delete pod %s/%s: %v", localBackend.Namespace, localBackend.Name, err)
+	}
+	remoteBackend := backends[1]
+	if err := wait.PollUntilContextTimeout(context.Background(), 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		counts := servedBy(t)
+		if counts[remoteBackend.Name] != zoneAwareRoutingRequests {
+			t.Logf("remote backend served %d of %d requests (%v), retrying...", counts[remoteBackend.Name], zoneAwareRoutingRequests, counts)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to observe the router fall back to the backend in zone %q: %v", remoteZone, err)
+	}
+}
+
+// zoneSuffix returns a string that is derived from the given zone name and
+// that can be used in a resource name.
+func zoneSuffix(zone string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, zone), "-")
+}
@@ -0,0 +1,235 @@
+package echo
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	defaultHTTPPorts = "8080"
+	defaultTLSCrt    = "/etc/serving-cert/tls.crt"
+	defaultTLSKey    = "/etc/serving-cert/tls.key"
+
+	// PodNameHeader, HostHeader, ProtoHeader, PortHeader, TLSHeader, and
+	// RequestHeadersHeader are the response headers that identify the pod
+	// that served the request, the Host header and protocol of the request
+	// that the pod received, the port on which the pod received it, whether
+	// the connection used TLS, and the request headers that the pod
+	// received, encoded as a JSON object.
+	PodNameHeader        = "X-Echo-Pod-Name"
+	HostHeader           = "X-Echo-Host"
+	ProtoHeader          = "X-Echo-Proto"
+	PortHeader           = "X-Echo-Port"
+	TLSHeader            = "X-Echo-TLS"
+	RequestHeadersHeader = "X-Echo-Request-Headers"
+)
+
+// Response is the body of every response of the echo server other than the
+// response to a health check.  It describes the request that the server
+// received.
+type Response struct {
+	// PodName is the name of the pod that served the request.
+	PodName string `json:"podName"`
+	// Host is the Host header of the request.
+	Host string `json:"host"`
+	// Proto is the protocol of the request, such as "HTTP/1.1" or
+	// "HTTP/2.0".
+	Proto string `json:"proto"`
+	// Port is the port on which the server received the request.
+	Port string `json:"port"`
+	// TLS indicates whether the connection used TLS.
+	TLS bool `json:"tls"`
+	// Method is the method of the request.
+	Method string `json:"method"`
+	// Path is the path of the request.
+	Path string `json:"path"`
+	// Headers are the headers of the request.
+	Headers http.Header `json:"headers"`
+}
+
+func lookupEnv(key, defaultVal string) string {
+	if val, ok := os.LookupEnv(key); ok {
+		return val
+	}
+	return defaultVal
+}
+
+// splitPorts returns the ports in the given comma-separated list.
+func splitPorts(ports string) []string {
+	var result []string
+	for _, port := range strings.Split(ports, ",") {
+		if port = strings.TrimSpace(port); len(port) != 0 {
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// Serve runs an HTTP echo server that responds to every request with a
+// description of the request, both as a JSON body and as response headers.
+// The server listens for plaintext connections on each port in the
+// comma-separated list HTTP_PORTS, which defaults to 8080, and for TLS
+// connections using the certificate and key at TLS_CRT and TLS_KEY on each
+// port in HTTPS_PORTS, which defaults to none.  If ENABLE_HTTP2 is "true", the
+// server negotiates HTTP/2 using ALPN on the TLS ports and accepts HTTP/2 with
+// prior knowledge (h2c) on the plaintext ports; otherwise it serves only
+// HTTP/1.  The server reports the pod name from POD_NAME.
+func Serve() {
+	podName := os.Getenv("POD_NAME")
+	enableHTTP2 := os.Getenv("ENABLE_HTTP2") == "true"
+	crtFile := lookupEnv("TLS_CRT", defaultTLSCrt)
+	keyFile := lookupEnv("TLS_KEY", defaultTLSKey)
+
+	for _, port := range splitPorts(lookupEnv("HTTP_PORTS", defaultHTTPPorts)) {
+		go func(port string) {
+			handler := newHandler(podName, port)
+			log.Printf("Listening on port %v (HTTP/2: %t)\n", port, enableHTTP2)
+			listener, err := net.Listen("tcp", ":"+port)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if enableHTTP2 {
+				err = serveH2C(listener, handler)
+			} else {
+				err = (&http.Server{Handler: handler}).Serve(listener)
+			}
+			log.Fatal(err)
+		}(port)
+	}
+
+	for _, port := range splitPorts(os.Getenv("HTTPS_PORTS")) {
+		go func(port string) {
+			server := &http.Server{
+				Addr:    ":" + port,
+				Handler: newHandler(podName, port),
+			}
+			if !enableHTTP2 {
+				// A non-nil, empty map disables HTTP/2.
+				server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+			}
+			log.Printf("Listening securely on port %v (HTTP/2: %t)\n", port, enableHTTP2)
+			log.Fatal(server.ListenAndServeTLS(crtFile, keyFile))
+		}(port)
+	}
+
+	select {}
+}
+
+// newHandler returns a handler for requests that the pod with the given name
+// receives on the given port.
+func newHandler(podName, port string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "ready")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		response := Response{
+			PodName: podName,
+			Host:    req.Host,
+			Proto:   req.Proto,
+			Port:    port,
+			TLS:     req.TLS != nil,
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Headers: req.Header,
+		}
+		requestHeaders, err := json.Marshal(req.Header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(PodNameHeader, response.PodName)
+		w.Header().Set(HostHeader, response.Host)
+		w.Header().Set(ProtoHeader, response.Proto)
+		w.Header().Set(PortHeader, response.Port)
+		w.Header().Set(TLSHeader, fmt.Sprint(response.TLS))
+		w.Header().Set(RequestHeadersHeader, string(requestHeaders))
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("failed to write response: %v", err)
+		}
+	})
+	return mux
+}
+
+// serveH2C serves HTTP/1 and HTTP/2 with prior knowledge on the given
+// listener.  Connections that begin with the HTTP/2 client preface are served
+// using HTTP/2, and all other connections are served using HTTP/1.
+func serveH2C(listener net.Listener, handler http.Handler) error {
+	h1Server := &http.Server{Handler: handler}
+	h2Server := &http2.Server{}
+	h1Listener := &connListener{addr: listener.Addr(), conns: make(chan net.Conn)}
+	go h1Server.Serve(h1Listener)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			reader := bufio.NewReader(conn)
+			bufferedConn := &bufferedConn{Conn: conn, reader: reader}
+			if hasClientPreface(reader) {
+				h2Server.ServeConn(bufferedConn, &http2.ServeConnOpts{
+					BaseConfig: h1Server,
+					Handler:    handler,
+				})
+				return
+			}
+			h1Listener.conns <- bufferedConn
+		}()
+	}
+}
+
+// hasClientPreface returns a Boolean value indicating whether the data from
+// the given reader begin with the HTTP/2 client preface.  It reads no more
+// than is needed to find a difference so that it does not block on an HTTP/1
+// request that is shorter than the preface.
+func hasClientPreface(reader *bufio.Reader) bool {
+	for i := 1; i <= len(http2.ClientPreface); i++ {
+		data, err := reader.Peek(i)
+		if err != nil || data[i-1] != http2.ClientPreface[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// bufferedConn is a connection whose reads are served from a buffered reader
+// that has already peeked at the connection's data.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// connListener is a listener that returns the connections that are sent on its
+// channel.
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+func (l *connListener) Close() error {
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	canarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/canary"
+)
+
+// edgeProbeStatusOK is the content of the status file after a successful
+// probe.
+const edgeProbeStatusOK = "ok"
+
+// NewServeCanaryEdgeProbeCommand returns a command that periodically probes
+// the canary route from the network namespace in which it runs and records the
+// outcome of the most recent probe in a status file.  With --check, the
+// command instead reports the recorded outcome through its exit status, for
+// use as the probe pod's readiness probe.
+func NewServeCanaryEdgeProbeCommand() *cobra.Command {
+	var check bool
+	var command = &cobra.Command{
+		Use:   canarycontroller.CanaryEdgeProbeCommand,
+		Short: "Probe the canary route from outside the cluster network",
+		Long:  canarycontroller.CanaryEdgeProbeCommand + ` periodically probes the canary route and records the outcome in a status file.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			interval, err := time.ParseDuration(os.Getenv("PROBE_INTERVAL"))
+			if err != nil {
+				interval = time.Minute
+			}
+			timeout, err := time.ParseDuration(os.Getenv("PROBE_TIMEOUT"))
+			if err != nil {
+				timeout = 10 * time.Second
+			}
+			statusFile := os.Getenv("STATUS_FILE")
+			if check {
+				// Allow for one missed probe before reporting
+				// the status as stale.
+				if err := checkEdgeProbeStatus(statusFile, 2*interval+timeout, time.Now()); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				return
+			}
+			serveCanaryEdgeProbe(os.Getenv("CANARY_HOST"), interval, timeout, statusFile)
+		},
+	}
+	command.Flags().BoolVar(&check, "check", false, "Report the outcome of the most recent probe through the exit status")
+
+	return command
+}
+
+// serveCanaryEdgeProbe probes the given host once per interval and records
+// the outcome of each probe in the given status file.
+func serveCanaryEdgeProbe(host string, interval, timeout time.Duration, statusFile string) {
+	for {
+		status := edgeProbeStatusOK
+		if err := probeCanaryEdge(host, timeout); err != nil {
+			status = err.Error()
+			fmt.Printf("Canary edge probe of %s failed: %v\n", host, err)
+		} else {
+			fmt.Printf("Canary edge probe of %s succeeded\n", host)
+		}
+		if err := writeEdgeProbeStatus(statusFile, status); err != nil {
+			fmt.Printf("Could not record canary edge probe status: %v\n", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// probeCanaryEdge sends a request to the canary route's host and verifies
+// that the canary application responds.  The host is resolved using the
+// resolvers of the network namespace in which the probe runs, and the request
+// bypasses any proxy, so that the probe takes the same path as a client
+// outside the cluster.  The canary's certificate is not verified because the
+// probe verifies reachability; the in-cluster canary check verifies the
+// certificate.
+func probeCanaryEdge(host string, timeout time.Duration) error {
+	if len(host) == 0 {
+		return fmt.Errorf("canary host is empty")
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	response, err := client.Get("https://" + host)
+	if err != nil {
+		return fmt.Errorf("error sending request to %q: %v", host, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from %q: %v", host, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %q returned status code %d", host, response.StatusCode)
+	}
+	if !strings.Contains(string(body), canarycontroller.CanaryHealthcheckResponse) {
+		return fmt.Errorf("expected response from %q to contain %q", host, canarycontroller.CanaryHealthcheckResponse)
+	}
+	return nil
+}
+
+// writeEdgeProbeStatus atomically replaces the content of the given status
+// file with the given status.
+func writeEdgeProbeStatus(statusFile, status string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(statusFile), filepath.Base(statusFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(status); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), statusFile)
+}
+
+// checkEdgeProbeStatus returns an error if the given status file does not
+// record a successful probe that is more recent than the given maximum age.
+func checkEdgeProbeStatus(statusFile string, maxAge time.Duration, now time.Time) error {
+	info, err := os.Stat(statusFile)
+	if err != nil {
+		return fmt.Errorf("no canary edge probe status: %v", err)
+	}
+	if age := now.Sub(info.ModTime()); age > maxAge {
+		return fmt.Errorf("canary edge probe status is stale: last probe %v ago", age.Round(time.Second))
+	}
+	status, err := os.ReadFile(statusFile)
+	if err != nil {
+		return fmt.Errorf("failed to read canary edge probe status: %v", err)
+	}
+	if string(status) != edgeProbeStatusOK {
+		return fmt.Errorf("%s", status)
+	}
+	return nil
+}
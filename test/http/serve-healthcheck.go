@@ -1,17 +1,34 @@
 package http
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	canarycontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/canary"
+	ingresscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/ingress"
 )
 
+// canaryResponseBody returns the given response padded to the given size, in
+// bytes, so that the canary controller can detect problems that only affect
+// large responses.  The response is not truncated if it is longer than the
+// size.
+func canaryResponseBody(response string, size int) string {
+	body := response + "\n"
+	if size <= len(body) {
+		return body
+	}
+	return body + strings.Repeat(".", size-len(body)-1) + "\n"
+}
+
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	response := os.Getenv("RESPONSE")
 	if len(response) == 0 {
@@ -24,8 +41,24 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
 		w.Header().Set("x-request-port", strconv.Itoa(tcpAddr.Port))
 	}
+	// Identify the canary pod that served the request so that the canary
+	// controller can attribute responses to endpoints.
+	if podName := os.Getenv("POD_NAME"); len(podName) != 0 {
+		w.Header().Set(canarycontroller.CanaryEndpointHeader, podName)
+	}
 
-	_, err := fmt.Fprintln(w, response)
+	// Pad the response to the size that the canary controller asked
+	// for, if any.
+	size := 0
+	if v := r.URL.Query().Get(canarycontroller.CanaryResponseSizeQueryParameter); len(v) != 0 {
+		var err error
+		if size, err = strconv.Atoi(v); err != nil || size < 0 || size > ingresscontroller.CanaryResponseSizeMax {
+			http.Error(w, fmt.Sprintf("invalid response size %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err := fmt.Fprint(w, canaryResponseBody(response, size))
 	if err == nil {
 		fmt.Println("Serving canary healthcheck request")
 	} else {
@@ -33,9 +66,49 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func listenAndServeTLS(port, certFile, keyFile string) {
+// certReloader serves the key pair in the given files and reloads it when the
+// certificate file changes so that a rotated certificate is served without
+// restarting the server.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// getCertificate returns the current key pair, reloading it if the certificate
+// file has changed.  If reloading fails, the previously loaded key pair is
+// returned.
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.certFile)
+	if err == nil && (c.cert == nil || !info.ModTime().Equal(c.modTime)) {
+		cert, loadErr := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if loadErr == nil {
+			fmt.Printf("loaded certificate from %s\n", c.certFile)
+			c.cert = &cert
+			c.modTime = info.ModTime()
+		} else {
+			err = loadErr
+		}
+	}
+	if c.cert == nil {
+		return nil, fmt.Errorf("failed to load certificate from %s: %v", c.certFile, err)
+	}
+	return c.cert, nil
+}
+
+func listenAndServeTLS(port string, reloader *certReloader) {
 	fmt.Printf("serving TLS on %s\n", port)
-	err := http.ListenAndServeTLS(":"+port, certFile, keyFile, nil)
+	server := &http.Server{
+		Addr:      ":" + port,
+		TLSConfig: &tls.Config{GetCertificate: reloader.getCertificate},
+	}
+	err := server.ListenAndServeTLS("", "")
 	if err != nil {
 		panic("ListenAndServeTLS: " + err.Error())
 	}
@@ -57,20 +130,22 @@ func NewServeHealthCheckCommand() *cobra.Command {
 func serveHealthCheck() {
 	http.HandleFunc("/", healthCheckHandler)
 
-	tlsCertFile := os.Getenv("TLS_CERT")
-	tlsKeyFile := os.Getenv("TLS_KEY")
+	reloader := &certReloader{
+		certFile: os.Getenv("TLS_CERT"),
+		keyFile:  os.Getenv("TLS_KEY"),
+	}
 
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
 		port = "8443"
 	}
-	go listenAndServeTLS(port, tlsCertFile, tlsKeyFile)
+	go listenAndServeTLS(port, reloader)
 
 	port = os.Getenv("SECOND_PORT")
 	if len(port) == 0 {
 		port = "8888"
 	}
-	go listenAndServeTLS(port, tlsCertFile, tlsKeyFile)
+	go listenAndServeTLS(port, reloader)
 
 	select {}
 }
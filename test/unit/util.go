@@ -10,6 +10,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type routeBuilder struct {
@@ -18,6 +19,15 @@ type routeBuilder struct {
 	labels        map[string]string
 	admittedICs   []string
 	unAdmittedICs []string
+	rejections    []routeRejection
+	certificate   string
+}
+
+// routeRejection is an ingresscontroller's rejection of a route.
+type routeRejection struct {
+	ic                 string
+	reason             string
+	lastTransitionTime time.Time
 }
 
 func NewRouteBuilder() *routeBuilder {
@@ -53,6 +63,19 @@ func (b *routeBuilder) WithUnAdmittedICs(unAdmittedICs ...string) *routeBuilder
 	return b
 }
 
+// WithRejectedIC adds a status entry for the given ingresscontroller that
+// rejects the route with the given reason at the given time.
+func (b *routeBuilder) WithRejectedIC(ic, reason string, lastTransitionTime time.Time) *routeBuilder {
+	b.rejections = append(b.rejections, routeRejection{ic: ic, reason: reason, lastTransitionTime: lastTransitionTime})
+	return b
+}
+
+// WithCertificate sets the route's edge-terminated TLS certificate.
+func (b *routeBuilder) WithCertificate(certificate string) *routeBuilder {
+	b.certificate = certificate
+	return b
+}
+
 func (b *routeBuilder) Build() *routev1.Route {
 	route := &routev1.Route{
 		ObjectMeta: metav1.ObjectMeta{
@@ -63,6 +86,12 @@ func (b *routeBuilder) Build() *routev1.Route {
 		Spec:   routev1.RouteSpec{},
 		Status: routev1.RouteStatus{},
 	}
+	if len(b.certificate) != 0 {
+		route.Spec.TLS = &routev1.TLSConfig{
+			Termination: routev1.TLSTerminationEdge,
+			Certificate: b.certificate,
+		}
+	}
 
 	for _, ic := range b.admittedICs {
 		route.Status.Ingress = append(route.Status.Ingress, routev1.RouteIngress{
@@ -88,6 +117,20 @@ func (b *routeBuilder) Build() *routev1.Route {
 		})
 	}
 
+	for _, rejection := range b.rejections {
+		route.Status.Ingress = append(route.Status.Ingress, routev1.RouteIngress{
+			RouterName: rejection.ic,
+			Conditions: []routev1.RouteIngressCondition{
+				{
+					Type:               routev1.RouteAdmitted,
+					Status:             corev1.ConditionFalse,
+					Reason:             rejection.reason,
+					LastTransitionTime: &metav1.Time{Time: rejection.lastTransitionTime},
+				},
+			},
+		})
+	}
+
 	return route
 }
 
@@ -100,6 +143,7 @@ type ingressControllerBuilder struct {
 	routeExpressionSelector     []metav1.LabelSelectorRequirement
 	deleting                    bool
 	admittedStatus              *v1.OperatorCondition
+	unsupportedConfigOverrides  string
 }
 
 func NewIngressControllerBuilder() *ingressControllerBuilder {
@@ -149,6 +193,13 @@ func (b *ingressControllerBuilder) WithNamespaceExpressionSelector(key string, o
 	return b
 }
 
+// WithUnsupportedConfigOverrides sets the ingresscontroller's
+// spec.unsupportedConfigOverrides to the given JSON.
+func (b *ingressControllerBuilder) WithUnsupportedConfigOverrides(overrides string) *ingressControllerBuilder {
+	b.unsupportedConfigOverrides = overrides
+	return b
+}
+
 func (b *ingressControllerBuilder) IsDeleting() *ingressControllerBuilder {
 	b.deleting = true
 	return b
@@ -186,6 +237,9 @@ func (b *ingressControllerBuilder) Build() *v1.IngressController {
 			},
 		},
 	}
+	if len(b.unsupportedConfigOverrides) != 0 {
+		ic.Spec.UnsupportedConfigOverrides = runtime.RawExtension{Raw: []byte(b.unsupportedConfigOverrides)}
+	}
 	if b.deleting {
 		ic.ObjectMeta.DeletionTimestamp = &metav1.Time{Time: time.Now()}
 		ic.ObjectMeta.Finalizers = []string{manifests.IngressControllerFinalizer}
@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultHTTPPort  = "8080"
+	defaultHTTPSPort = "8443"
+	defaultTLSCrt    = "/etc/serving-cert/tls.crt"
+	defaultTLSKey    = "/etc/serving-cert/tls.key"
+)
+
+func lookupEnv(key, defaultVal string) string {
+	if val, ok := os.LookupEnv(key); ok {
+		return val
+	}
+	return defaultVal
+}
+
+// Serve runs a WebSocket echo server that echoes every message that it
+// receives on the "/echo" path back to the client.  The server listens for
+// plaintext connections on HTTP_PORT and, if a certificate and key are found at
+// TLS_CRT and TLS_KEY, for TLS connections on HTTPS_PORT.
+func Serve() {
+	upgrader := websocket.Upgrader{
+		// Clients connect through the router using the route's host
+		// name, so accept any origin.
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+
+	http.HandleFunc("/echo", func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			log.Printf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("read failed: %v", err)
+				}
+				return
+			}
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				log.Printf("write failed: %v", err)
+				return
+			}
+		}
+	})
+
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "ready")
+	})
+
+	crtFile := lookupEnv("TLS_CRT", defaultTLSCrt)
+	keyFile := lookupEnv("TLS_KEY", defaultTLSKey)
+	if _, err := os.Stat(crtFile); err == nil {
+		go func() {
+			port := lookupEnv("HTTPS_PORT", defaultHTTPSPort)
+			log.Printf("Listening securely on port %v\n", port)
+
+			if err := http.ListenAndServeTLS(":"+port, crtFile, keyFile, nil); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	port := lookupEnv("HTTP_PORT", defaultHTTPPort)
+	log.Printf("Listening on port %v\n", port)
+
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		log.Fatal(err)
+	}
+}